@@ -4,18 +4,31 @@ package adapter
 
 import (
 	"context"
+	"crypto/ed25519"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/adapters/storage"
+	tfadapter "terraform-cost/adapters/terraform"
+	_ "terraform-cost/adapters/terraform/hcl"
+	"terraform-cost/core/determinism"
 	"terraform-cost/core/engine"
 	"terraform-cost/core/model"
+	"terraform-cost/core/policy"
 	"terraform-cost/core/pricing"
 	"terraform-cost/core/terraform"
+	"terraform-cost/internal/atomicfile"
+	appconfig "terraform-cost/internal/config"
+	"terraform-cost/internal/attestation"
+	"terraform-cost/internal/logging"
 )
 
 // isStrict returns true if strict mode is enabled (unified check)
@@ -23,12 +36,21 @@ func (a *CIAdapter) isStrict() bool {
 	return a.config.StrictMode || a.config.Mode == ModeStrict
 }
 
+// cacheEnabled returns true if results are cached in the store
+func (c *CIConfig) cacheEnabled() bool {
+	return c.Cache && !c.NoCache && c.Store != nil
+}
+
 // CIAdapter is a production-grade CI adapter
 type CIAdapter struct {
 	engine   *engine.Engine
 	pipeline *terraform.Pipeline
 	output   io.Writer
 	config   *CIConfig
+	logger   logging.LeveledLogger
+
+	// clock stamps results (nil = determinism.DefaultClock)
+	clock determinism.Clock
 }
 
 // CIConfig configures CI behavior
@@ -42,6 +64,9 @@ type CIConfig struct {
 	// Budget threshold (monthly)
 	BudgetLimit float64 `json:"budget_limit"`
 
+	// ShowAnnual reports the annual total next to the monthly one
+	ShowAnnual bool `json:"show_annual"`
+
 	// MaxSymbolicPercent allowed
 	MaxSymbolicPercent float64 `json:"max_symbolic_percent"`
 
@@ -59,6 +84,88 @@ type CIConfig struct {
 
 	// StrictMode fails on symbolic costs
 	StrictMode bool `json:"strict_mode"`
+
+	// OutputFile writes the result to this path atomically instead of the
+	// output writer, for upload as a CI artifact
+	OutputFile string `json:"output_file,omitempty"`
+
+	// WriteOnError also writes OutputFile when estimation fails
+	WriteOnError bool `json:"write_on_error,omitempty"`
+
+	// GroupByTag breaks costs down by this tag key (e.g. "team")
+	GroupByTag string `json:"group_by_tag,omitempty"`
+
+	// PolicyFile is the policy file loaded into Policies
+	PolicyFile string `json:"policy_file,omitempty"`
+
+	// Policies adds every policy of a policy file to the checks above.
+	// Its coverage thresholds replace MaxSymbolicPercent,
+	// MaxUnsupportedPercent and MinConfidence where set.
+	Policies *policy.PolicyFile `json:"-"`
+
+	// SuppressionFile is the suppression file loaded into Suppressions
+	SuppressionFile string `json:"suppression_file,omitempty"`
+
+	// Suppressions downgrade matching violations to info
+	Suppressions []Suppression `json:"suppressions,omitempty"`
+
+	// Cache reuses the result of an unchanged plan from Store, keyed by
+	// the plan and the snapshot's content hash (--cache). It applies only
+	// to requests with a PlanFile.
+	Cache bool `json:"cache,omitempty"`
+
+	// NoCache turns Cache off (--no-cache), so one run can skip a cache
+	// its pipeline enables by default
+	NoCache bool `json:"no_cache,omitempty"`
+
+	// IgnorePatterns are address globs (e.g. "aws_instance.test_*",
+	// "module.sandbox.*") for resources left out of the total (--ignore)
+	IgnorePatterns []string `json:"ignore_patterns,omitempty"`
+
+	// FailOnUnsupportedType adds an uncataloged_type violation at this
+	// severity ("warning" or "error") when the plan has AWS, Azure or GCP
+	// resource types missing from the catalog. Empty only lists them in
+	// CIResult.UncatalogedTypes (--fail-on-unsupported-type).
+	FailOnUnsupportedType string `json:"fail_on_unsupported_type,omitempty"`
+
+	// SigningKeyFile is the Ed25519 key loaded into SigningKey
+	SigningKeyFile string `json:"signing_key_file,omitempty"`
+
+	// SigningKey signs JSON output, embedding signature and public_key
+	SigningKey ed25519.PrivateKey `json:"-"`
+
+	// DiffAgainstLatestStored diffs each run against the latest estimate
+	// stored for ProjectID and the request's branch, then stores the run
+	// as the next baseline (--diff-against-latest-stored; see baseline.go)
+	DiffAgainstLatestStored bool `json:"diff_against_latest_stored,omitempty"`
+
+	// ProjectID names the project estimates are stored under
+	ProjectID string `json:"project_id,omitempty"`
+
+	// Store holds the stored estimates and, with Cache, cached results
+	Store storage.Store `json:"-"`
+}
+
+// LoadPolicyFile loads a policy file into Policies
+func (c *CIConfig) LoadPolicyFile(path string) error {
+	f, err := policy.LoadPolicyFile(path)
+	if err != nil {
+		return err
+	}
+	c.PolicyFile = path
+	c.Policies = f
+	return nil
+}
+
+// LoadSigningKey loads a PEM-encoded Ed25519 key into SigningKey
+func (c *CIConfig) LoadSigningKey(path string) error {
+	key, err := attestation.LoadPrivateKey(path)
+	if err != nil {
+		return err
+	}
+	c.SigningKeyFile = path
+	c.SigningKey = key
+	return nil
 }
 
 // CIMode controls CI behavior
@@ -78,6 +185,39 @@ const (
 	ModeStrict CIMode = "strict"
 )
 
+// Exit codes set in CIResult.ExitCode. A CI step can tell a blocked
+// change from a broken pipeline without parsing the output:
+//
+//	0  ExitSuccess          estimate produced; nothing blocks the change
+//	1  ExitPolicyFailure    an error-severity policy violation (blocking/strict)
+//	2  ExitEstimationError  scan, plan parse or estimation failed; no estimate
+//	3  ExitWarnings         only warnings, treated as errors by FailOnWarnings
+//
+// Informational and warning modes always exit 0 on a successful estimate.
+const (
+	ExitSuccess         = 0
+	ExitPolicyFailure   = 1
+	ExitEstimationError = 2
+	ExitWarnings        = 3
+)
+
+// exitCodeDescriptions documents every exit code; tests fail when a code
+// is added without a description
+var exitCodeDescriptions = map[int]string{
+	ExitSuccess:         "success",
+	ExitPolicyFailure:   "policy violation",
+	ExitEstimationError: "estimation error",
+	ExitWarnings:        "warnings treated as errors",
+}
+
+// ExitCodeDescription returns the documented meaning of a CI exit code
+func ExitCodeDescription(code int) string {
+	if d, ok := exitCodeDescriptions[code]; ok {
+		return d
+	}
+	return fmt.Sprintf("unknown exit code %d", code)
+}
+
 // CIOutputFormat specifies output format
 type CIOutputFormat string
 
@@ -87,6 +227,7 @@ const (
 	FormatTable    CIOutputFormat = "table"
 	FormatGitHub   CIOutputFormat = "github"
 	FormatGitLab   CIOutputFormat = "gitlab"
+	FormatSARIF    CIOutputFormat = "sarif"
 )
 
 // DefaultCIConfig returns production defaults
@@ -104,16 +245,24 @@ func DefaultCIConfig() *CIConfig {
 	}
 }
 
-// NewCIAdapter creates a CI adapter
+// NewCIAdapter creates a CI adapter. A nil pipeline uses the configured
+// expansion settings.
 func NewCIAdapter(eng *engine.Engine, pipeline *terraform.Pipeline, config *CIConfig) *CIAdapter {
 	if config == nil {
 		config = DefaultCIConfig()
 	}
+	if pipeline == nil {
+		pipeline = appconfig.Get().Terraform.NewPipeline()
+	}
+	if config.cacheEnabled() {
+		eng.SetResultCache(storage.NewResultCache(config.Store))
+	}
 	return &CIAdapter{
 		engine:   eng,
 		pipeline: pipeline,
 		output:   os.Stdout,
 		config:   config,
+		logger:   logging.Default(),
 	}
 }
 
@@ -122,12 +271,36 @@ func (a *CIAdapter) SetOutput(w io.Writer) {
 	a.output = w
 }
 
+// SetLogger sets the logger for diagnostic output.
+// Logs never go to the result writer, so JSON output stays parseable.
+func (a *CIAdapter) SetLogger(logger logging.LeveledLogger) {
+	if logger == nil {
+		logger = logging.Nop()
+	}
+	a.logger = logger
+}
+
+// SetClock sets the clock results are stamped with; golden tests pass a
+// determinism.FixedClock
+func (a *CIAdapter) SetClock(clock determinism.Clock) {
+	a.clock = clock
+}
+
+// now returns the current time in UTC from the adapter's clock
+func (a *CIAdapter) now() time.Time {
+	if a.clock == nil {
+		return determinism.DefaultClock.Now()
+	}
+	return a.clock.Now()
+}
+
 // CIRequest is the CI input
 type CIRequest struct {
 	// Path to Terraform project
 	Path string
 
-	// PlanFile is the tfplan file path
+	// PlanFile is the plan JSON, as written by `terraform show -json`: a
+	// file path, "-" for stdin or an http(s) URL. Terraform is not run.
 	PlanFile string
 
 	// Provider (aws, azure, gcp)
@@ -136,6 +309,9 @@ type CIRequest struct {
 	// Region
 	Region string
 
+	// Alias selects per-account pricing (empty = default)
+	Alias string
+
 	// SnapshotID to use
 	SnapshotID string
 
@@ -144,6 +320,11 @@ type CIRequest struct {
 
 	// BaseFile for diff comparison
 	BaseFile string
+
+	// Branch and Commit identify the change; with DiffAgainstLatestStored
+	// each branch has its own baseline
+	Branch string
+	Commit string
 }
 
 // CIResult is the CI output
@@ -151,7 +332,7 @@ type CIResult struct {
 	// Success indicates estimation succeeded
 	Success bool `json:"success"`
 
-	// ExitCode for CI
+	// ExitCode for CI (ExitSuccess, ExitPolicyFailure, ExitEstimationError, ExitWarnings)
 	ExitCode int `json:"exit_code"`
 
 	// CheckConclusion for GitHub
@@ -163,6 +344,17 @@ type CIResult struct {
 	// TotalCost monthly
 	TotalCost float64 `json:"total_cost"`
 
+	// Currency is the ISO 4217 code of every cost in the result
+	Currency string `json:"currency"`
+
+	// AnnualCost is the rounded monthly total x 12, with ShowAnnual
+	AnnualCost float64 `json:"annual_cost,omitempty"`
+
+	// IgnoredCount resources matched IgnorePatterns; IgnoredCost is what
+	// they would cost monthly, left out of TotalCost
+	IgnoredCount int     `json:"ignored_count,omitempty"`
+	IgnoredCost  float64 `json:"ignored_cost,omitempty"`
+
 	// Confidence (0-1)
 	Confidence float64 `json:"confidence"`
 
@@ -181,11 +373,41 @@ type CIResult struct {
 	// Resources with costs
 	Resources []CIResourceCost `json:"resources"`
 
+	// SymbolicResources have an unknown instance count, so no concrete cost
+	SymbolicResources []CISymbolicResource `json:"symbolic_resources,omitempty"`
+
+	// UncatalogedTypes are resource types the cost catalog does not know
+	UncatalogedTypes []CIUncatalogedType `json:"uncataloged_types,omitempty"`
+
 	// Snapshot used
 	Snapshot CISnapshot `json:"snapshot"`
 
+	// TagBreakdown groups costs by CIConfig.GroupByTag
+	TagBreakdown *CITagBreakdown `json:"tag_breakdown,omitempty"`
+
 	// Metadata
 	Metadata CIMetadata `json:"metadata"`
+
+	// Signature and PublicKey attest the rest of the result when
+	// CIConfig.SigningKey is set (see attestation.Verify)
+	Signature string `json:"signature,omitempty"`
+	PublicKey string `json:"public_key,omitempty"`
+
+	// locations maps resource addresses to their source for SARIF output
+	locations map[string]model.SourceLocation
+}
+
+// CITagBreakdown is cost grouped by one tag key
+type CITagBreakdown struct {
+	Key    string       `json:"key"`
+	Groups []CITagGroup `json:"groups"`
+}
+
+// CITagGroup is the cost of one tag value
+type CITagGroup struct {
+	Value         string  `json:"value"`
+	MonthlyCost   float64 `json:"monthly_cost"`
+	ResourceCount int     `json:"resource_count"`
 }
 
 // CICoverage is coverage breakdown
@@ -205,6 +427,11 @@ type CIDiff struct {
 	CreatedCount  int     `json:"created_count"`
 	DestroyedCount int    `json:"destroyed_count"`
 	UpdatedCount  int     `json:"updated_count"`
+
+	// BaselineID is the stored estimate diffed against, with
+	// DiffAgainstLatestStored
+	BaselineID        string    `json:"baseline_id,omitempty"`
+	BaselineCreatedAt time.Time `json:"baseline_created_at,omitempty"`
 }
 
 // CIResourceCost is per-resource cost
@@ -216,14 +443,52 @@ type CIResourceCost struct {
 	CoverageType string  `json:"coverage_type"`
 	ChangeType   string  `json:"change_type,omitempty"` // create, destroy, update
 	Delta        float64 `json:"delta,omitempty"`
+
+	// Components break MonthlyCost down, e.g. RDS compute and storage
+	Components []CIComponentCost `json:"components,omitempty"`
+
+	// SkipReason explains why an unsupported resource has no cost
+	SkipReason string `json:"skip_reason,omitempty"`
+
+	// Placeholders is set on the one row listing a resource with an
+	// unknown count: how many placeholder instances its cost sums
+	Placeholders int    `json:"placeholders,omitempty"`
+	CountRange   string `json:"count_range,omitempty"`
+}
+
+// CIComponentCost is one billed component of a resource
+type CIComponentCost struct {
+	Name        string  `json:"name"`
+	Category    string  `json:"category"` // compute, storage, usage
+	MonthlyCost float64 `json:"monthly_cost"`
+	Symbolic    bool    `json:"symbolic,omitempty"`
+}
+
+// CISymbolicResource is a resource whose count or for_each is unknown
+type CISymbolicResource struct {
+	Address    string `json:"address"`
+	Reason     string `json:"reason"` // count, for_each, provider
+	Range      string `json:"range"`  // e.g. "0..∞"
+	Expression string `json:"expression,omitempty"`
+	Message    string `json:"message,omitempty"`
+
+	// Assumed is the instance count priced in place of the unknown value
+	Assumed int `json:"assumed"`
+
+	// Placeholders priced for the resource and their monthly cost
+	Placeholders int     `json:"placeholders,omitempty"`
+	MonthlyCost  float64 `json:"monthly_cost,omitempty"`
 }
 
 // CISnapshot is snapshot info
 type CISnapshot struct {
-	ID          string `json:"id"`
-	Provider    string `json:"provider"`
-	Region      string `json:"region"`
-	ContentHash string `json:"content_hash"`
+	ID          string    `json:"id"`
+	Provider    string    `json:"provider"`
+	Region      string    `json:"region"`
+	ContentHash string    `json:"content_hash"`
+	EffectiveAt time.Time `json:"effective_at"`
+	AgeDays     float64   `json:"age_days,omitempty"`
+	Stale       bool      `json:"stale,omitempty"`
 }
 
 // CIMetadata is execution context
@@ -232,36 +497,70 @@ type CIMetadata struct {
 	Duration  string    `json:"duration"`
 	Version   string    `json:"version"`
 	Mode      string    `json:"mode"`
+
+	// Cached is true when the estimate was reused from the result cache
+	Cached bool `json:"cached,omitempty"`
 }
 
 // PolicyViolation is a policy failure
 type PolicyViolation struct {
-	Rule      string  `json:"rule"`
-	Message   string  `json:"message"`
-	Severity  string  `json:"severity"` // error, warning
-	Threshold float64 `json:"threshold,omitempty"`
-	Actual    float64 `json:"actual,omitempty"`
+	Rule            string  `json:"rule"`
+	ResourceAddress string  `json:"resource_address,omitempty"`
+	Message         string  `json:"message"`
+	Severity        string  `json:"severity"` // error, warning, info (suppressed)
+	Threshold       float64 `json:"threshold,omitempty"`
+	Actual          float64 `json:"actual,omitempty"`
+
+	// Suppressed is set when a suppression downgraded the violation
+	Suppressed        bool   `json:"suppressed,omitempty"`
+	SuppressionReason string `json:"suppression_reason,omitempty"`
 }
 
 // Run executes the CI estimation
 func (a *CIAdapter) Run(ctx context.Context, req *CIRequest) (*CIResult, error) {
 	start := time.Now()
+	log := a.logger.With(
+		logging.String("path", req.Path),
+		logging.String("provider", req.Provider),
+		logging.String("region", req.Region),
+	)
+	log.Info("starting CI estimation", logging.String("mode", string(a.config.Mode)))
 
 	// 1. Run Terraform pipeline
 	scanInput := &terraform.ScanInput{
-		RootPath:  req.Path,
-		Workspace: "default",
+		RootPath:      req.Path,
+		Workspace:     "default",
+		DefaultRegion: req.Region,
 	}
 
-	pipelineResult, err := a.pipeline.Execute(ctx, scanInput)
+	// Values recorded in the plan resolve var.* and data.* references,
+	// such as a count or for_each, that the configuration leaves unknown
+	pipeline := a.pipeline
+	var plan []byte
+	if req.PlanFile != "" {
+		// Read once: stdin cannot be read again for the cache key. The
+		// plan is JSON already; terraform is not run.
+		parsed, data, err := tfadapter.NewPlanSource(req.PlanFile).Plan(ctx)
+		if err != nil {
+			log.Warn("plan not readable", logging.String("plan_file", req.PlanFile), logging.Err(err))
+		} else {
+			plan = data
+			pipeline = pipeline.WithVariables(parsed.VariableValues()).
+				WithDataSources(parsed.DataSourceValues())
+		}
+	}
+
+	pipelineResult, err := pipeline.Execute(ctx, scanInput)
 	if err != nil {
-		return a.failResult(fmt.Sprintf("Failed to scan terraform: %v", err), start), nil
+		log.Error("terraform scan failed", logging.Err(err))
+		return a.emitFailure(fmt.Sprintf("Failed to scan terraform: %v", err), start)
 	}
 
 	// 2. Build snapshot request
 	snapshotReq := engine.SnapshotRequest{
 		Provider: req.Provider,
 		Region:   req.Region,
+		Alias:    req.Alias,
 	}
 	if req.SnapshotID != "" {
 		snapshotReq.SnapshotID = pricing.SnapshotID(req.SnapshotID)
@@ -272,11 +571,15 @@ func (a *CIAdapter) Run(ctx context.Context, req *CIRequest) (*CIResult, error)
 	if req.UsageFile != "" {
 		if data, err := os.ReadFile(req.UsageFile); err == nil {
 			var raw map[string]map[string]float64
-			if json.Unmarshal(data, &raw) == nil {
+			if err := json.Unmarshal(data, &raw); err == nil {
 				for k, v := range raw {
 					overrides[model.InstanceID(k)] = v
 				}
+			} else {
+				log.Warn("ignoring malformed usage file", logging.String("usage_file", req.UsageFile), logging.Err(err))
 			}
+		} else {
+			log.Warn("usage file not readable", logging.String("usage_file", req.UsageFile), logging.Err(err))
 		}
 	}
 
@@ -285,21 +588,54 @@ func (a *CIAdapter) Run(ctx context.Context, req *CIRequest) (*CIResult, error)
 		Graph:           pipelineResult.Graph,
 		SnapshotRequest: snapshotReq,
 		UsageOverrides:  overrides,
+
+		CardinalityWarnings: pipelineResult.CardinalityWarnings,
+		SourceWarnings:      pipelineResult.WarningMessages(),
+		IgnorePatterns:      a.config.IgnorePatterns,
+	}
+	if a.config.cacheEnabled() && plan != nil {
+		if key, err := engine.PlanCacheKey(plan); err == nil {
+			engineReq.CacheKey = key
+		} else {
+			log.Warn("result cache disabled: cannot hash plan", logging.String("plan_file", req.PlanFile), logging.Err(err))
+		}
 	}
 
 	result, err := a.engine.Estimate(ctx, engineReq)
 	if err != nil {
-		return a.failResult(fmt.Sprintf("Estimation failed: %v", err), start), nil
+		log.Error("estimation failed", logging.Err(err))
+		return a.emitFailure(fmt.Sprintf("Estimation failed: %v", err), start)
 	}
 
 	// 5. Build CI result
 	ciResult := a.buildCIResult(result, start)
+	ciResult.locations = sourceLocations(pipelineResult.Graph, req.Path)
 
 	// 6. Evaluate policies
+	if err := a.evaluatePolicyFile(ctx, result, ciResult); err != nil {
+		log.Error("policy evaluation failed", logging.Err(err))
+		return a.emitFailure(fmt.Sprintf("Policy evaluation failed: %v", err), start)
+	}
+	a.evaluateUncatalogedTypes(pipelineResult.Graph, ciResult)
+	if a.config.DiffAgainstLatestStored {
+		a.diffAgainstLatest(ctx, req, ciResult)
+	}
 	a.evaluatePolicies(ciResult)
+	if a.config.DiffAgainstLatestStored {
+		a.storeBaseline(ctx, req, ciResult)
+	}
+
+	log.Info("CI estimation complete",
+		logging.Bool("cached", result.Cached),
+		logging.Float64("total_cost", ciResult.TotalCost),
+		logging.Float64("confidence", ciResult.Confidence),
+		logging.Int("violations", len(ciResult.PolicyViolations)),
+		logging.Int("exit_code", ciResult.ExitCode),
+		logging.Duration("duration", time.Since(start)),
+	)
 
 	// 7. Output in requested format
-	if err := a.output(ciResult); err != nil {
+	if err := a.emit(ciResult); err != nil {
 		return nil, err
 	}
 
@@ -309,17 +645,38 @@ func (a *CIAdapter) Run(ctx context.Context, req *CIRequest) (*CIResult, error)
 func (a *CIAdapter) buildCIResult(result *engine.EstimationResult, start time.Time) *CIResult {
 	ciResult := &CIResult{
 		Success:    true,
-		ExitCode:   0,
-		TotalCost:  result.TotalMonthlyCost.Float64(),
+		ExitCode:   ExitSuccess,
+		TotalCost:  result.DisplayTotalMonthlyCost().Float64(),
+		Currency:   result.DisplayTotalMonthlyCost().Currency(),
 		Confidence: result.Confidence.Score,
 		Warnings:   result.Warnings,
 		Metadata: CIMetadata{
-			Timestamp: time.Now(),
+			Timestamp: a.now(),
 			Duration:  time.Since(start).String(),
 			Version:   "1.0.0",
 			Mode:      string(a.config.Mode),
+			Cached:    result.Cached,
 		},
 	}
+	if a.config.ShowAnnual {
+		ciResult.AnnualCost = result.AnnualCost().Float64()
+	}
+	if result.IgnoredCount > 0 {
+		ciResult.IgnoredCount = result.IgnoredCount
+		ciResult.IgnoredCost = result.IgnoredMonthlyCost.Round(determinism.DisplayPlaces).Float64()
+	}
+
+	if key := a.config.GroupByTag; key != "" {
+		breakdown := &CITagBreakdown{Key: key}
+		for _, g := range result.GroupByTag(key) {
+			breakdown.Groups = append(breakdown.Groups, CITagGroup{
+				Value:         g.Value,
+				MonthlyCost:   g.DisplayMonthlyCost.Float64(),
+				ResourceCount: g.InstanceCount,
+			})
+		}
+		ciResult.TagBreakdown = breakdown
+	}
 
 	// FIX #1: Populate coverage from engine result
 	if result.CoverageReport != nil {
@@ -363,12 +720,19 @@ func (a *CIAdapter) buildCIResult(result *engine.EstimationResult, start time.Ti
 			Provider:    result.Snapshot.Provider,
 			Region:      result.Snapshot.Region,
 			ContentHash: result.Snapshot.ContentHash.Hex(),
+			EffectiveAt: result.Snapshot.EffectiveAt,
+			AgeDays:     math.Floor(result.Snapshot.Age.Hours() / 24),
+			Stale:       result.Snapshot.Stale,
 		}
 	}
 
-	// Resources: collect all first
+	// Resources: collect all first. Placeholders of a resource with an
+	// unknown count are listed once, as their group.
 	var resources []CIResourceCost
 	result.InstanceCosts.Range(func(id model.InstanceID, cost *engine.InstanceCost) bool {
+		if cost.Symbolic {
+			return true
+		}
 		// FIX #3: Set CoverageType from core coverage classification
 		coverageType := "numeric" // default
 		switch cost.CoverageType {
@@ -383,38 +747,130 @@ func (a *CIAdapter) buildCIResult(result *engine.EstimationResult, start time.Ti
 		rc := CIResourceCost{
 			Address:      string(cost.Address),
 			Type:         string(cost.ResourceType),
-			MonthlyCost:  cost.MonthlyCost.Float64(),
+			MonthlyCost:  cost.DisplayMonthlyCost().Float64(),
 			Confidence:   cost.Confidence.Score,
 			CoverageType: coverageType,
+			SkipReason:   cost.SkipReason,
+		}
+		for _, comp := range cost.Components {
+			rc.Components = append(rc.Components, CIComponentCost{
+				Name:        comp.Name,
+				Category:    componentCategory(comp),
+				MonthlyCost: comp.MonthlyCost.Round(determinism.DisplayPlaces).Float64(),
+				Symbolic:    comp.IsSymbolic,
+			})
 		}
 		resources = append(resources, rc)
 		return true
 	})
-
-	// FIX #2: Sort resources by cost descending for consistent output
+	// FIX #2: Sort resources by cost descending, then address, so the
+	// JSON artifact is byte-identical for identical inputs
 	sort.Slice(resources, func(i, j int) bool {
-		return resources[i].MonthlyCost > resources[j].MonthlyCost
+		if resources[i].MonthlyCost != resources[j].MonthlyCost {
+			return resources[i].MonthlyCost > resources[j].MonthlyCost
+		}
+		return resources[i].Address < resources[j].Address
 	})
 
+	// Placeholders follow, as the HTTP response lists them
+	groups := make(map[string]engine.PlaceholderGroup)
+	for _, g := range result.PlaceholderGroups() {
+		rc := CIResourceCost{
+			Address:      g.Address,
+			Type:         g.ResourceType,
+			MonthlyCost:  g.MonthlyCost.Float64(),
+			Confidence:   g.Confidence,
+			CoverageType: "symbolic",
+			Placeholders: g.Placeholders,
+		}
+		if g.Symbolic != nil {
+			rc.CountRange = g.Symbolic.Range()
+			groups[g.Symbolic.Address] = g
+		}
+		resources = append(resources, rc)
+	}
+
 	ciResult.Resources = resources
 
+	for _, s := range result.SymbolicResources {
+		sr := CISymbolicResource{
+			Address:    s.Address,
+			Reason:     s.Reason,
+			Range:      s.Range(),
+			Expression: s.Expression,
+			Message:    s.Message,
+			Assumed:    s.AssumedCount,
+		}
+		if g, ok := groups[s.Address]; ok {
+			sr.Placeholders = g.Placeholders
+			sr.MonthlyCost = g.MonthlyCost.Float64()
+		}
+		ciResult.SymbolicResources = append(ciResult.SymbolicResources, sr)
+	}
+
 	return ciResult
 }
 
+// evaluatePolicyFile adds a violation for each failing policy-file policy.
+// Coverage thresholds the file leaves unset are checked by evaluatePolicies.
+func (a *CIAdapter) evaluatePolicyFile(ctx context.Context, result *engine.EstimationResult, ciResult *CIResult) error {
+	if a.config.Policies == nil {
+		return nil
+	}
+
+	outcome, err := engine.NewDeepPolicyEvaluator(a.config.Policies.Evaluator()).Evaluate(ctx, result)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range outcome.Policies {
+		if p.Passed {
+			continue
+		}
+		severity := "warning"
+		if p.Severity == string(policy.SeverityError) || p.Severity == string(policy.SeverityBlock) {
+			severity = "error"
+		}
+		if p.Name == "max_symbolic_percent" && a.isStrict() {
+			severity = "error"
+		}
+		violation := PolicyViolation{
+			Rule:     p.Name,
+			Message:  p.Message,
+			Severity: severity,
+			Actual:   p.CostImpact.Float64(),
+		}
+		// A violation caused by one resource can be suppressed for it alone
+		if len(p.AffectedInstances) == 1 && result.InstanceCosts != nil {
+			if cost, ok := result.InstanceCosts.Get(p.AffectedInstances[0]); ok {
+				violation.ResourceAddress = string(cost.Address)
+			}
+		}
+		ciResult.PolicyViolations = append(ciResult.PolicyViolations, violation)
+	}
+	return nil
+}
+
 func (a *CIAdapter) evaluatePolicies(result *CIResult) {
 	// Budget check
 	if a.config.BudgetLimit > 0 && result.TotalCost > a.config.BudgetLimit {
 		result.PolicyViolations = append(result.PolicyViolations, PolicyViolation{
 			Rule:      "budget_limit",
-			Message:   fmt.Sprintf("Monthly cost $%.2f exceeds budget $%.2f", result.TotalCost, a.config.BudgetLimit),
+			Message:   fmt.Sprintf("Monthly cost %s exceeds budget %s", result.money(result.TotalCost), result.money(a.config.BudgetLimit)),
 			Severity:  "error",
 			Threshold: a.config.BudgetLimit,
 			Actual:    result.TotalCost,
 		})
 	}
 
+	// Coverage thresholds set in a policy file are checked with its policies
+	var fileCoverage policy.CoverageRule
+	if a.config.Policies != nil {
+		fileCoverage = a.config.Policies.Coverage
+	}
+
 	// Symbolic check
-	if result.Coverage.SymbolicPercent > a.config.MaxSymbolicPercent {
+	if fileCoverage.MaxSymbolicPercent == nil && result.Coverage.SymbolicPercent > a.config.MaxSymbolicPercent {
 		severity := "warning"
 		if a.isStrict() { // FIX #4: Use unified isStrict() helper
 			severity = "error"
@@ -429,7 +885,7 @@ func (a *CIAdapter) evaluatePolicies(result *CIResult) {
 	}
 
 	// Unsupported check
-	if result.Coverage.UnsupportedPercent > a.config.MaxUnsupportedPercent {
+	if fileCoverage.MaxUnsupportedPercent == nil && result.Coverage.UnsupportedPercent > a.config.MaxUnsupportedPercent {
 		result.PolicyViolations = append(result.PolicyViolations, PolicyViolation{
 			Rule:      "unsupported_limit",
 			Message:   fmt.Sprintf("Unsupported coverage %.1f%% exceeds limit %.1f%%", result.Coverage.UnsupportedPercent, a.config.MaxUnsupportedPercent),
@@ -440,7 +896,7 @@ func (a *CIAdapter) evaluatePolicies(result *CIResult) {
 	}
 
 	// Confidence check
-	if result.Confidence < a.config.MinConfidence {
+	if fileCoverage.MinConfidence == nil && result.Confidence < a.config.MinConfidence {
 		result.PolicyViolations = append(result.PolicyViolations, PolicyViolation{
 			Rule:      "confidence_minimum",
 			Message:   fmt.Sprintf("Confidence %.0f%% below minimum %.0f%%", result.Confidence*100, a.config.MinConfidence*100),
@@ -450,23 +906,48 @@ func (a *CIAdapter) evaluatePolicies(result *CIResult) {
 		})
 	}
 
+	// Snapshot staleness check
+	if result.Snapshot.Stale {
+		severity := "warning"
+		if a.isStrict() {
+			severity = "error"
+		}
+		result.PolicyViolations = append(result.PolicyViolations, PolicyViolation{
+			Rule:     "snapshot_staleness",
+			Message:  fmt.Sprintf("Pricing snapshot is %.0f days old; prices may be out of date", result.Snapshot.AgeDays),
+			Severity: severity,
+			Actual:   result.Snapshot.AgeDays,
+		})
+	}
+
+	// Approved violations no longer count towards the exit code
+	if len(a.config.Suppressions) > 0 {
+		warnings := applySuppressions(result.PolicyViolations, a.config.Suppressions, a.now())
+		for _, w := range warnings {
+			a.logger.Warn(w)
+		}
+		result.Warnings = append(result.Warnings, warnings...)
+	}
+
 	// Determine exit code and check conclusion
 	hasErrors := false
 	hasWarnings := false
 	for _, v := range result.PolicyViolations {
-		if v.Severity == "error" {
+		switch v.Severity {
+		case "error":
 			hasErrors = true
-		} else {
+		case SeverityInfo:
+		default:
 			hasWarnings = true
 		}
 	}
 
 	switch a.config.Mode {
 	case ModeInformational:
-		result.ExitCode = 0
+		result.ExitCode = ExitSuccess
 		result.CheckConclusion = "success"
 	case ModeWarning:
-		result.ExitCode = 0
+		result.ExitCode = ExitSuccess
 		if hasErrors || hasWarnings {
 			result.CheckConclusion = "neutral"
 		} else {
@@ -474,13 +955,13 @@ func (a *CIAdapter) evaluatePolicies(result *CIResult) {
 		}
 	case ModeBlocking, ModeStrict:
 		if hasErrors {
-			result.ExitCode = 1
+			result.ExitCode = ExitPolicyFailure
 			result.CheckConclusion = "failure"
 		} else if hasWarnings && a.config.FailOnWarnings {
-			result.ExitCode = 1
+			result.ExitCode = ExitWarnings
 			result.CheckConclusion = "failure"
 		} else {
-			result.ExitCode = 0
+			result.ExitCode = ExitSuccess
 			result.CheckConclusion = "success"
 		}
 	}
@@ -492,7 +973,13 @@ func (a *CIAdapter) evaluatePolicies(result *CIResult) {
 func (a *CIAdapter) buildSummary(result *CIResult) string {
 	var sb strings.Builder
 
-	sb.WriteString(fmt.Sprintf("Total Monthly Cost: $%.2f\n", result.TotalCost))
+	sb.WriteString(fmt.Sprintf("Total Monthly Cost: %s\n", result.money(result.TotalCost)))
+	if a.config.ShowAnnual {
+		sb.WriteString(fmt.Sprintf("Total Annual Cost: %s\n", result.money(result.AnnualCost)))
+	}
+	if result.IgnoredCount > 0 {
+		sb.WriteString(fmt.Sprintf("Ignored: %s\n", ignoredDetail(result)))
+	}
 	sb.WriteString(fmt.Sprintf("Confidence: %.0f%%\n", result.Confidence*100))
 	sb.WriteString(fmt.Sprintf("Coverage: %.0f%% numeric | %.0f%% symbolic | %.0f%% unsupported\n",
 		result.Coverage.NumericPercent,
@@ -503,51 +990,103 @@ func (a *CIAdapter) buildSummary(result *CIResult) string {
 	if len(result.PolicyViolations) > 0 {
 		sb.WriteString("\nPolicy Violations:\n")
 		for _, v := range result.PolicyViolations {
-			icon := "⚠️"
-			if v.Severity == "error" {
-				icon = "❌"
-			}
-			sb.WriteString(fmt.Sprintf("%s %s: %s\n", icon, v.Rule, v.Message))
+			sb.WriteString(fmt.Sprintf("%s %s: %s%s\n", violationIcon(v), v.Rule, v.Message, suppressedNote(v)))
 		}
 	}
 
 	return sb.String()
 }
 
+// violationIcon returns the marker for a violation's severity
+func violationIcon(v PolicyViolation) string {
+	switch v.Severity {
+	case "error":
+		return "❌"
+	case SeverityInfo:
+		return "ℹ️"
+	}
+	return "⚠️"
+}
+
+// suppressedNote explains why a violation is not enforced
+func suppressedNote(v PolicyViolation) string {
+	if !v.Suppressed {
+		return ""
+	}
+	return fmt.Sprintf(" (suppressed: %s)", v.SuppressionReason)
+}
+
 func (a *CIAdapter) failResult(message string, start time.Time) *CIResult {
 	return &CIResult{
 		Success:         false,
-		ExitCode:        1,
+		ExitCode:        ExitEstimationError,
 		CheckConclusion: "failure",
 		Summary:         message,
 		Metadata: CIMetadata{
-			Timestamp: time.Now(),
+			Timestamp: a.now(),
 			Duration:  time.Since(start).String(),
 			Version:   "1.0.0",
 		},
 	}
 }
 
-func (a *CIAdapter) output(result *CIResult) error {
+// emitFailure builds a failure result and writes it when WriteOnError is set
+func (a *CIAdapter) emitFailure(message string, start time.Time) (*CIResult, error) {
+	result := a.failResult(message, start)
+	if a.config.OutputFile != "" && a.config.WriteOnError {
+		if err := a.emit(result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// emit writes result to OutputFile (replaced atomically) or the output writer
+func (a *CIAdapter) emit(result *CIResult) error {
+	if a.config.OutputFile == "" {
+		return a.writeOutput(a.output, result)
+	}
+
+	f, err := atomicfile.Create(a.config.OutputFile)
+	if err != nil {
+		return err
+	}
+	if err := a.writeOutput(f, result); err != nil {
+		f.Abort()
+		return err
+	}
+	return f.Commit()
+}
+
+func (a *CIAdapter) writeOutput(w io.Writer, result *CIResult) error {
 	switch a.config.OutputFormat {
 	case FormatJSON:
-		return a.outputJSON(result)
+		return a.outputJSON(w, result)
 	case FormatMarkdown, FormatGitHub:
-		return a.outputMarkdown(result)
+		return a.outputMarkdown(w, result)
 	case FormatTable:
-		return a.outputTable(result)
+		return a.outputTable(w, result)
+	case FormatSARIF:
+		return a.outputSARIF(w, result)
 	default:
-		return a.outputMarkdown(result)
+		return a.outputMarkdown(w, result)
 	}
 }
 
-func (a *CIAdapter) outputJSON(result *CIResult) error {
-	enc := json.NewEncoder(a.output)
+func (a *CIAdapter) outputJSON(w io.Writer, result *CIResult) error {
+	if a.config.SigningKey != nil {
+		sig, err := attestation.Sign(result, a.config.SigningKey)
+		if err != nil {
+			return fmt.Errorf("failed to sign result: %w", err)
+		}
+		result.Signature, result.PublicKey = sig.Signature, sig.PublicKey
+	}
+	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
 	return enc.Encode(result)
 }
 
-func (a *CIAdapter) outputMarkdown(result *CIResult) error {
+func (a *CIAdapter) outputMarkdown(w io.Writer, result *CIResult) error {
 	var sb strings.Builder
 
 	sb.WriteString(fmt.Sprintf("## %s\n\n", a.config.CommentPrefix))
@@ -555,14 +1094,16 @@ func (a *CIAdapter) outputMarkdown(result *CIResult) error {
 	// Summary
 	delta := ""
 	if result.Diff != nil {
-		sign := "+"
-		if result.Diff.Delta < 0 {
-			sign = ""
-		}
-		delta = fmt.Sprintf(" (%s$%.2f)", sign, result.Diff.Delta)
+		delta = fmt.Sprintf(" (%s)", determinism.FormatSignedAmount(decimal.NewFromFloat(result.Diff.Delta), result.Currency, determinism.DisplayPlaces))
 	}
 
-	sb.WriteString(fmt.Sprintf("**Total Monthly Cost:** $%.2f%s\n", result.TotalCost, delta))
+	sb.WriteString(fmt.Sprintf("**Total Monthly Cost:** %s%s\n", result.money(result.TotalCost), delta))
+	if a.config.ShowAnnual {
+		sb.WriteString(fmt.Sprintf("**Total Annual Cost:** %s\n", result.money(result.AnnualCost)))
+	}
+	if result.IgnoredCount > 0 {
+		sb.WriteString(fmt.Sprintf("**Ignored:** %s\n", ignoredDetail(result)))
+	}
 	sb.WriteString(fmt.Sprintf("**Confidence:** %.0f%%\n", result.Confidence*100))
 	sb.WriteString(fmt.Sprintf("**Coverage:** %.0f%% numeric | %.0f%% symbolic | %.0f%% unsupported\n\n",
 		result.Coverage.NumericPercent,
@@ -584,19 +1125,65 @@ func (a *CIAdapter) outputMarkdown(result *CIResult) error {
 		} else if r.CoverageType == "unsupported" {
 			icon = "🔴"
 		}
-		sb.WriteString(fmt.Sprintf("- %s `%s`: $%.2f\n", icon, r.Address, r.MonthlyCost))
+		if r.SkipReason != "" {
+			sb.WriteString(fmt.Sprintf("- %s `%s`: skipped (%s)\n", icon, r.Address, r.SkipReason))
+			continue
+		}
+		if r.Placeholders > 0 {
+			sb.WriteString(fmt.Sprintf("- %s `%s`: %s (%s, instance count %s)\n",
+				icon, r.Address, result.money(r.MonthlyCost), placeholderCount(r.Placeholders), r.CountRange))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("- %s `%s`: %s\n", icon, r.Address, result.money(r.MonthlyCost)))
 	}
 	sb.WriteString("\n")
 
+	// Component breakdown, collapsed so it does not crowd the summary
+	if hasComponents(result.Resources) {
+		sb.WriteString("<details><summary>Cost components</summary>\n\n")
+		sb.WriteString("| Resource | Component | Category | Monthly |\n|---|---|---|---:|\n")
+		for _, r := range result.Resources {
+			for _, c := range r.Components {
+				cost := result.money(c.MonthlyCost)
+				if c.Symbolic {
+					cost = "symbolic"
+				}
+				sb.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s |\n", r.Address, c.Name, c.Category, cost))
+			}
+		}
+		sb.WriteString("\n</details>\n\n")
+	}
+
+	// Tag breakdown
+	if result.TagBreakdown != nil && len(result.TagBreakdown.Groups) > 0 {
+		sb.WriteString(fmt.Sprintf("### Cost by `%s`\n", result.TagBreakdown.Key))
+		sb.WriteString("| Value | Resources | Monthly |\n|---|---:|---:|\n")
+		for _, g := range result.TagBreakdown.Groups {
+			sb.WriteString(fmt.Sprintf("| %s | %d | %s |\n", g.Value, g.ResourceCount, result.money(g.MonthlyCost)))
+		}
+		sb.WriteString("\n")
+	}
+
+	// Symbolic resources
+	if len(result.SymbolicResources) > 0 {
+		sb.WriteString("### Symbolic Resources\n")
+		sb.WriteString("These resources have no concrete cost because their instance count is unknown.\n\n")
+		sb.WriteString("| Resource | Unknown | Instances | Priced as | Monthly | Detail |\n|---|---|---|---:|---:|---|\n")
+		for _, s := range result.SymbolicResources {
+			monthly := "-"
+			if s.Placeholders > 0 {
+				monthly = result.money(s.MonthlyCost)
+			}
+			sb.WriteString(fmt.Sprintf("| `%s` | %s | %s | %d | %s | %s |\n", s.Address, s.Reason, s.Range, s.Assumed, monthly, symbolicDetail(s)))
+		}
+		sb.WriteString("\n")
+	}
+
 	// Policy violations
 	if len(result.PolicyViolations) > 0 {
 		sb.WriteString("### Policy Violations\n")
 		for _, v := range result.PolicyViolations {
-			icon := "⚠️"
-			if v.Severity == "error" {
-				icon = "❌"
-			}
-			sb.WriteString(fmt.Sprintf("- %s **%s**: %s\n", icon, v.Rule, v.Message))
+			sb.WriteString(fmt.Sprintf("- %s **%s**: %s%s\n", violationIcon(v), v.Rule, v.Message, suppressedNote(v)))
 		}
 		sb.WriteString("\n")
 	}
@@ -608,16 +1195,58 @@ func (a *CIAdapter) outputMarkdown(result *CIResult) error {
 		result.Snapshot.Region,
 		result.Snapshot.ID[:8],
 	))
+	if result.Snapshot.Stale {
+		sb.WriteString(fmt.Sprintf("⚠️ Pricing data is %.0f days old (effective %s)\n",
+			result.Snapshot.AgeDays,
+			result.Snapshot.EffectiveAt.Format("2006-01-02"),
+		))
+	}
 
-	_, err := a.output.Write([]byte(sb.String()))
+	_, err := w.Write([]byte(sb.String()))
 	return err
 }
 
-func (a *CIAdapter) outputTable(result *CIResult) error {
+// componentCategory classifies a component by what it bills for: named
+// storage and usage components first, then time-billed ones as compute
+func componentCategory(comp *engine.ComponentCost) string {
+	name := strings.ToLower(comp.Name)
+	for _, k := range []string{"storage", "backup", "snapshot", "volume"} {
+		if strings.Contains(name, k) {
+			return "storage"
+		}
+	}
+	for _, k := range []string{"io", "request", "transfer", "throughput", "query", "queries"} {
+		if strings.Contains(name, k) {
+			return "usage"
+		}
+	}
+	switch unit := strings.ToLower(comp.UsageUnit); {
+	case unit == "hours" || unit == "hrs":
+		return "compute"
+	case strings.HasPrefix(unit, "gb"):
+		return "storage"
+	}
+	return "usage"
+}
+
+// hasComponents reports whether any resource has a component breakdown
+func hasComponents(resources []CIResourceCost) bool {
+	for _, r := range resources {
+		if len(r.Components) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *CIAdapter) outputTable(w io.Writer, result *CIResult) error {
 	var sb strings.Builder
 
 	sb.WriteString("┌────────────────────────────────────────────────────────────┐\n")
-	sb.WriteString(fmt.Sprintf("│ Total Monthly Cost           $%-28.2f │\n", result.TotalCost))
+	sb.WriteString(fmt.Sprintf("│ Total Monthly Cost           %-29s │\n", result.money(result.TotalCost)))
+	if a.config.ShowAnnual {
+		sb.WriteString(fmt.Sprintf("│ Total Annual Cost            %-29s │\n", result.money(result.AnnualCost)))
+	}
 	sb.WriteString(fmt.Sprintf("│ Confidence                   %-29.0f%% │\n", result.Confidence*100))
 	sb.WriteString("├────────────────────────────────────────────────────────────┤\n")
 	sb.WriteString(fmt.Sprintf("│ Numeric: %.0f%%  Symbolic: %.0f%%  Unsupported: %.0f%%            │\n",
@@ -627,6 +1256,49 @@ func (a *CIAdapter) outputTable(result *CIResult) error {
 	))
 	sb.WriteString("└────────────────────────────────────────────────────────────┘\n")
 
-	_, err := a.output.Write([]byte(sb.String()))
+	if result.IgnoredCount > 0 {
+		sb.WriteString(fmt.Sprintf("\nIgnored: %s\n", ignoredDetail(result)))
+	}
+	if len(result.SymbolicResources) > 0 {
+		sb.WriteString("\nSymbolic Resources (unknown instance count):\n")
+		for _, s := range result.SymbolicResources {
+			sb.WriteString(fmt.Sprintf("  %s  unknown %s, %s instances, priced as %d: %s\n", s.Address, s.Reason, s.Range, s.Assumed, symbolicDetail(s)))
+		}
+	}
+
+	_, err := w.Write([]byte(sb.String()))
 	return err
 }
+
+// ignoredDetail describes the resources left out by IgnorePatterns
+func ignoredDetail(result *CIResult) string {
+	noun := "resources"
+	if result.IgnoredCount == 1 {
+		noun = "resource"
+	}
+	return fmt.Sprintf("%d %s (%s/month, not in the total)", result.IgnoredCount, noun, result.money(result.IgnoredCost))
+}
+
+// money writes a monthly cost of the result for people, e.g. "€12.34"
+func (r *CIResult) money(amount float64) string {
+	return determinism.FormatFloat(amount, r.Currency, determinism.DisplayPlaces)
+}
+
+// symbolicDetail explains a symbolic resource, preferring the message
+// placeholderCount renders a placeholder count, e.g. "3 placeholders"
+func placeholderCount(n int) string {
+	if n == 1 {
+		return "1 placeholder"
+	}
+	return fmt.Sprintf("%d placeholders", n)
+}
+
+func symbolicDetail(s CISymbolicResource) string {
+	if s.Message != "" {
+		return s.Message
+	}
+	if s.Expression != "" {
+		return "`" + s.Expression + "` is not known until apply"
+	}
+	return "not known until apply"
+}
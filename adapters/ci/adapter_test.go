@@ -0,0 +1,582 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/adapters/storage"
+	"terraform-cost/core/catalog"
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/engine"
+	"terraform-cost/core/model"
+	"terraform-cost/core/policy"
+	"terraform-cost/core/pricing"
+	"terraform-cost/core/terraform"
+	"terraform-cost/internal/attestation"
+	"terraform-cost/internal/logging"
+)
+
+type fixedResolver struct {
+	snapshot *pricing.PricingSnapshot
+	err      error
+}
+
+func (r *fixedResolver) GetSnapshot(ctx context.Context, req engine.SnapshotRequest) (*pricing.PricingSnapshot, error) {
+	return r.snapshot, r.err
+}
+
+func (r *fixedResolver) LookupRate(snapshot *pricing.PricingSnapshot, resourceType, component string, attrs map[string]string) (*pricing.RateEntry, error) {
+	rate, ok := snapshot.LookupRate(resourceType, component, attrs)
+	if !ok {
+		return nil, fmt.Errorf("rate not found")
+	}
+	return rate, nil
+}
+
+type noUsage struct{}
+
+func (noUsage) Estimate(ctx context.Context, inst *model.AssetInstance) (*engine.UsageResult, error) {
+	return &engine.UsageResult{Metrics: map[string]engine.UsageMetric{}, Confidence: 1.0}, nil
+}
+
+func runCI(t *testing.T, config *CIConfig, resolverErr error) *CIResult {
+	t.Helper()
+	snapshot := pricing.NewSnapshotBuilder("aws", "us-east-1").
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(0.1), "hour", "USD").
+		Build()
+	eng := engine.NewEngine(&fixedResolver{snapshot: snapshot, err: resolverErr}, noUsage{}, nil, engine.EngineConfig{})
+
+	a := NewCIAdapter(eng, terraform.NewPipeline(terraform.PipelineOptions{}), config)
+	a.SetOutput(&bytes.Buffer{})
+	a.SetLogger(logging.Nop())
+
+	result, err := a.Run(context.Background(), &CIRequest{Path: t.TempDir(), Provider: "aws", Region: "us-east-1"})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	return result
+}
+
+// TestExitCodes proves each CI outcome maps to its documented exit code
+func TestExitCodes(t *testing.T) {
+	config := func(mutate func(*CIConfig)) *CIConfig {
+		c := DefaultCIConfig()
+		c.OutputFormat = FormatJSON
+		c.MinConfidence = 0
+		mutate(c)
+		return c
+	}
+
+	// An empty project costs $0 at full confidence with 0% symbolic and
+	// unsupported coverage, so policies are triggered through negative or
+	// unreachable thresholds
+	cases := []struct {
+		name        string
+		config      *CIConfig
+		resolverErr error
+		want        int
+	}{
+		{"success", config(func(c *CIConfig) {}), nil, ExitSuccess},
+		{"policy error", config(func(c *CIConfig) { c.StrictMode = true; c.MaxSymbolicPercent = -1 }), nil, ExitPolicyFailure},
+		{"policy warning", config(func(c *CIConfig) { c.MaxUnsupportedPercent = -1 }), nil, ExitSuccess},
+		{"estimation error", config(func(c *CIConfig) {}), errors.New("pricing unavailable"), ExitEstimationError},
+		{"warnings as errors", config(func(c *CIConfig) { c.MinConfidence = 2; c.FailOnWarnings = true }), nil, ExitWarnings},
+		{"warnings allowed", config(func(c *CIConfig) { c.MinConfidence = 2 }), nil, ExitSuccess},
+		{"strict symbolic", config(func(c *CIConfig) { c.Mode = ModeStrict; c.MaxSymbolicPercent = -1 }), nil, ExitPolicyFailure},
+		{"warning mode never blocks", config(func(c *CIConfig) { c.Mode = ModeWarning; c.StrictMode = true; c.MaxSymbolicPercent = -1 }), nil, ExitSuccess},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := runCI(t, tc.config, tc.resolverErr)
+			if result.ExitCode != tc.want {
+				t.Errorf("exit code = %d (%s), want %d (%s)",
+					result.ExitCode, ExitCodeDescription(result.ExitCode), tc.want, ExitCodeDescription(tc.want))
+			}
+		})
+	}
+}
+
+// TestExitCodesDocumented fails when an exit code has no description
+func TestExitCodesDocumented(t *testing.T) {
+	for _, code := range []int{ExitSuccess, ExitPolicyFailure, ExitEstimationError, ExitWarnings} {
+		if _, ok := exitCodeDescriptions[code]; !ok {
+			t.Errorf("exit code %d is not documented", code)
+		}
+	}
+	if len(exitCodeDescriptions) != 4 {
+		t.Errorf("exitCodeDescriptions has %d entries, want 4", len(exitCodeDescriptions))
+	}
+}
+
+// TestSuppressions proves an active suppression stops a violation from
+// failing the run, while expired and non-matching ones do not
+func TestSuppressions(t *testing.T) {
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format("2006-01-02")
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format("2006-01-02")
+
+	cases := []struct {
+		name           string
+		suppression    Suppression
+		wantExit       int
+		wantSuppressed bool
+		wantWarning    bool
+	}{
+		{"active", Suppression{Rule: "symbolic_limit", Reason: "approved in RFC-12", Expires: tomorrow}, ExitSuccess, true, false},
+		{"no expiry", Suppression{Rule: "symbolic_limit", Reason: "approved in RFC-12"}, ExitSuccess, true, false},
+		{"expired", Suppression{Rule: "symbolic_limit", Reason: "approved in RFC-12", Expires: yesterday}, ExitPolicyFailure, false, true},
+		{"other rule", Suppression{Rule: "budget_limit", Reason: "approved in RFC-12", Expires: tomorrow}, ExitPolicyFailure, false, false},
+		{"other resource", Suppression{Rule: "symbolic_limit", ResourceAddress: "aws_instance.web", Reason: "approved in RFC-12"}, ExitPolicyFailure, false, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := DefaultCIConfig()
+			config.OutputFormat = FormatJSON
+			config.MinConfidence = 0
+			config.StrictMode = true
+			config.MaxSymbolicPercent = -1
+			config.Suppressions = []Suppression{tc.suppression}
+
+			result := runCI(t, config, nil)
+			if result.ExitCode != tc.wantExit {
+				t.Errorf("exit code = %d, want %d", result.ExitCode, tc.wantExit)
+			}
+
+			var v *PolicyViolation
+			for i := range result.PolicyViolations {
+				if result.PolicyViolations[i].Rule == "symbolic_limit" {
+					v = &result.PolicyViolations[i]
+				}
+			}
+			if v == nil {
+				t.Fatal("symbolic_limit violation missing")
+			}
+			if v.Suppressed != tc.wantSuppressed {
+				t.Errorf("suppressed = %v, want %v", v.Suppressed, tc.wantSuppressed)
+			}
+			if tc.wantSuppressed && (v.Severity != SeverityInfo || v.SuppressionReason != "approved in RFC-12") {
+				t.Errorf("suppressed violation = %+v, want info severity with the reason", v)
+			}
+			if !tc.wantSuppressed && v.Severity != "error" {
+				t.Errorf("severity = %q, want error", v.Severity)
+			}
+
+			expiredWarning := false
+			for _, w := range result.Warnings {
+				if strings.Contains(w, "expired") {
+					expiredWarning = true
+				}
+			}
+			if expiredWarning != tc.wantWarning {
+				t.Errorf("expired warning = %v, want %v (warnings: %v)", expiredWarning, tc.wantWarning, result.Warnings)
+			}
+		})
+	}
+}
+
+func TestLoadSuppressionFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	good := write("suppressions.yaml", "suppressions:\n  - rule: budget:team-a\n    resource_address: aws_instance.big\n    reason: approved by finance\n    expires: 2030-01-31\n")
+	bad := write("bad.json", `{"suppressions": [{"rule": "budget_limit", "reason": "x", "expires": "next week"}]}`)
+
+	config := DefaultCIConfig()
+	if err := config.LoadSuppressionFile(good); err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Suppressions) != 1 || config.Suppressions[0].ResourceAddress != "aws_instance.big" {
+		t.Errorf("suppressions = %+v", config.Suppressions)
+	}
+	if err := config.LoadSuppressionFile(bad); err == nil {
+		t.Error("expected an error for an unparseable expiry")
+	}
+}
+
+// TestSymbolicResourcesSection proves unknown count/for_each resources are
+// listed with their range and reason
+func TestSymbolicResourcesSection(t *testing.T) {
+	snapshot := pricing.NewSnapshotBuilder("aws", "us-east-1").Build()
+	eng := engine.NewEngine(&fixedResolver{snapshot: snapshot}, noUsage{}, nil, engine.EngineConfig{})
+	result, err := eng.Estimate(context.Background(), &engine.EstimateRequest{
+		Graph: model.NewInstanceGraph(),
+		CardinalityWarnings: []terraform.CardinalityWarning{
+			{Address: "aws_instance.workers", Type: "for_each", Message: "for_each could not be determined, no instances priced",
+				SymbolicRange: &terraform.SymbolicRange{Min: 0, Max: -1}},
+			{Address: "aws_instance.api", Type: "count", Expression: "var.replicas", AssumedCount: 2,
+				SymbolicRange: &terraform.SymbolicRange{Min: 1, Max: 3}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewCIAdapter(eng, nil, DefaultCIConfig())
+	ciResult := a.buildCIResult(result, time.Now())
+	if len(ciResult.SymbolicResources) != 2 || ciResult.SymbolicResources[0].Address != "aws_instance.api" {
+		t.Fatalf("symbolic resources = %+v", ciResult.SymbolicResources)
+	}
+
+	var out bytes.Buffer
+	if err := a.outputMarkdown(&out, ciResult); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"### Symbolic Resources",
+		"| `aws_instance.api` | count | 1..3 | 2 | - | `var.replicas` is not known until apply |",
+		"| `aws_instance.workers` | for_each | 0..∞ | 0 | - | for_each could not be determined, no instances priced |",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("markdown missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+// computePlugin maps every aws_instance to a single compute component
+type computePlugin struct{}
+
+func (computePlugin) Provider() string         { return "aws" }
+func (computePlugin) CatalogVersion() string   { return catalog.Version }
+func (computePlugin) SupportedTypes() []string { return []string{"aws_instance"} }
+
+func (computePlugin) MapInstance(inst *model.AssetInstance) ([]engine.CostComponent, error) {
+	return []engine.CostComponent{{Name: "compute", ResourceType: "aws_instance", Unit: "hours"}}, nil
+}
+
+// TestPlanVariablesResolveCount proves a count set by a variable without a
+// default is taken from the value the plan recorded
+func TestPlanVariablesResolveCount(t *testing.T) {
+	dir := t.TempDir()
+	tf := `variable "instance_count" {}
+
+resource "aws_instance" "web" {
+  count         = var.instance_count
+  instance_type = "t3.micro"
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(tf), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	planFile := filepath.Join(dir, "plan.json")
+	plan := `{"format_version": "1.2", "variables": {"instance_count": {"value": 3}}}`
+	if err := os.WriteFile(planFile, []byte(plan), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := pricing.NewSnapshotBuilder("aws", "us-east-1").
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(0.1), "hour", "USD").
+		Build()
+	eng := engine.NewEngine(&fixedResolver{snapshot: snapshot}, noUsage{}, nil, engine.EngineConfig{HoursPerMonth: 100})
+	eng.SetLogger(logging.Nop())
+	eng.RegisterPlugin(computePlugin{})
+
+	a := NewCIAdapter(eng, terraform.NewPipeline(terraform.PipelineOptions{}), DefaultCIConfig())
+	a.SetOutput(&bytes.Buffer{})
+	a.SetLogger(logging.Nop())
+
+	result, err := a.Run(context.Background(), &CIRequest{Path: dir, PlanFile: planFile, Provider: "aws", Region: "us-east-1"})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(result.SymbolicResources) != 0 {
+		t.Errorf("count should be resolved from the plan, got symbolic %+v", result.SymbolicResources)
+	}
+	if result.TotalCost != 30 {
+		t.Errorf("total = %v, want 30 (3 instances x 100h x 0.1)", result.TotalCost)
+	}
+}
+
+// TestResultCacheHit proves a second run on an unchanged plan is served
+// from the store with --cache, and priced again with --no-cache
+func TestResultCacheHit(t *testing.T) {
+	dir := t.TempDir()
+	tf := `resource "aws_instance" "web" {
+  count         = 2
+  instance_type = "t3.micro"
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(tf), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	planFile := filepath.Join(dir, "plan.json")
+	if err := os.WriteFile(planFile, []byte(`{"format_version": "1.2", "variables": {}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := storage.NewMemoryStore()
+	snapshot := pricing.NewSnapshotBuilder("aws", "us-east-1").
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(0.1), "hour", "USD").
+		Build()
+	run := func(noCache bool) *CIResult {
+		t.Helper()
+		eng := engine.NewEngine(&fixedResolver{snapshot: snapshot}, noUsage{}, nil, engine.EngineConfig{HoursPerMonth: 100})
+		eng.SetLogger(logging.Nop())
+		eng.RegisterPlugin(computePlugin{})
+
+		config := DefaultCIConfig()
+		config.Cache = true
+		config.NoCache = noCache
+		config.Store = store
+		a := NewCIAdapter(eng, terraform.NewPipeline(terraform.PipelineOptions{}), config)
+		a.SetOutput(&bytes.Buffer{})
+		a.SetLogger(logging.Nop())
+		result, err := a.Run(context.Background(), &CIRequest{Path: dir, PlanFile: planFile, Provider: "aws", Region: "us-east-1"})
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		return result
+	}
+
+	if first := run(false); first.Metadata.Cached || first.TotalCost != 20 {
+		t.Fatalf("first run: cached = %v, total = %v; want a fresh 20", first.Metadata.Cached, first.TotalCost)
+	}
+	if second := run(false); !second.Metadata.Cached || second.TotalCost != 20 {
+		t.Errorf("second run: cached = %v, total = %v; want a cached 20", second.Metadata.Cached, second.TotalCost)
+	}
+	if skipped := run(true); skipped.Metadata.Cached {
+		t.Error("--no-cache run was served from the cache")
+	}
+}
+
+// TestUncatalogedTypes proves a resource type missing from the catalog is
+// listed, and fails the run only when FailOnUnsupportedType asks it to
+func TestUncatalogedTypes(t *testing.T) {
+	dir := t.TempDir()
+	tf := `resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+}
+
+resource "aws_quantum_widget" "new" {}
+
+resource "random_id" "suffix" {}
+`
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(tf), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := pricing.NewSnapshotBuilder("aws", "us-east-1").
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(0.1), "hour", "USD").
+		Build()
+	run := func(severity string) *CIResult {
+		eng := engine.NewEngine(&fixedResolver{snapshot: snapshot}, noUsage{}, nil, engine.EngineConfig{})
+		eng.SetLogger(logging.Nop())
+		eng.RegisterPlugin(computePlugin{})
+
+		config := DefaultCIConfig()
+		config.MaxUnsupportedPercent = 100
+		config.MinConfidence = 0
+		config.FailOnUnsupportedType = severity
+		a := NewCIAdapter(eng, terraform.NewPipeline(terraform.PipelineOptions{}), config)
+		a.SetOutput(&bytes.Buffer{})
+		a.SetLogger(logging.Nop())
+
+		result, err := a.Run(context.Background(), &CIRequest{Path: dir, Provider: "aws", Region: "us-east-1"})
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		return result
+	}
+
+	listed := run("")
+	if len(listed.UncatalogedTypes) != 1 || listed.UncatalogedTypes[0] != (CIUncatalogedType{Type: "aws_quantum_widget", Count: 1}) {
+		t.Fatalf("uncataloged types = %+v, want only aws_quantum_widget", listed.UncatalogedTypes)
+	}
+	for _, v := range listed.PolicyViolations {
+		if v.Rule == RuleUncatalogedType {
+			t.Errorf("violation without FailOnUnsupportedType: %+v", v)
+		}
+	}
+	if listed.ExitCode != ExitSuccess {
+		t.Errorf("exit code = %d, want %d", listed.ExitCode, ExitSuccess)
+	}
+
+	failed := run("error")
+	if failed.ExitCode != ExitPolicyFailure {
+		t.Errorf("exit code = %d, want %d", failed.ExitCode, ExitPolicyFailure)
+	}
+	found := false
+	for _, v := range failed.PolicyViolations {
+		if v.Rule == RuleUncatalogedType {
+			found = true
+			if v.Severity != "error" || !strings.Contains(v.Message, "aws_quantum_widget") {
+				t.Errorf("unexpected violation %+v", v)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("no %s violation in %+v", RuleUncatalogedType, failed.PolicyViolations)
+	}
+
+	if warned := run("warning"); warned.ExitCode != ExitSuccess {
+		t.Errorf("warning severity exit code = %d, want %d", warned.ExitCode, ExitSuccess)
+	}
+}
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// TestJSONOutputGolden proves the JSON artifact is byte-identical for
+// identical inputs, so estimates can be diffed between CI runs. Run with
+// -update to rewrite testdata/estimate.golden.json.
+func TestJSONOutputGolden(t *testing.T) {
+	dir := t.TempDir()
+	tf := `resource "aws_instance" "web" {
+  count         = 3
+  instance_type = "t3.micro"
+}
+
+resource "aws_instance" "worker" {
+  for_each      = { c = "c", a = "a", b = "b" }
+  instance_type = "t3.micro"
+}
+
+resource "aws_instance" "untagged" {
+  instance_type = "t3.micro"
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(tf), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := pricing.NewSnapshotBuilder("aws", "us-east-1").
+		WithEffectiveAt(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)).
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(0.1), "hour", "USD").
+		Build()
+	policies, err := policy.ParsePolicyFile([]byte(`{"required_tags": ["team"], "resource_types": [{"type": "aws_instance", "max_instances": 2}]}`), "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A frozen clock a week after the snapshot fixes timestamps and age
+	clock := determinism.FixedClock(time.Date(2024, 1, 8, 12, 0, 0, 0, time.FixedZone("CET", 3600)))
+	render := func() []byte {
+		eng := engine.NewEngine(&fixedResolver{snapshot: snapshot}, noUsage{}, nil, engine.EngineConfig{HoursPerMonth: 100})
+		eng.SetLogger(logging.Nop())
+		eng.SetClock(clock)
+		eng.RegisterPlugin(computePlugin{})
+
+		config := DefaultCIConfig()
+		config.OutputFormat = FormatJSON
+		config.Policies = policies
+		a := NewCIAdapter(eng, terraform.NewPipeline(terraform.PipelineOptions{}), config)
+		a.SetOutput(&bytes.Buffer{})
+		a.SetLogger(logging.Nop())
+		a.SetClock(clock)
+
+		result, err := a.Run(context.Background(), &CIRequest{Path: dir, Provider: "aws", Region: "us-east-1"})
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		// Durations are measured, not read from the clock
+		result.Metadata.Duration = ""
+
+		var out bytes.Buffer
+		if err := a.outputJSON(&out, result); err != nil {
+			t.Fatal(err)
+		}
+		return out.Bytes()
+	}
+
+	got := render()
+	for i := 0; i < 10; i++ {
+		if again := render(); !bytes.Equal(got, again) {
+			t.Fatalf("run %d produced different JSON:\n%s\nvs\n%s", i, got, again)
+		}
+	}
+
+	golden := filepath.Join("testdata", "estimate.golden.json")
+	if *updateGolden {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("JSON output differs from %s:\n%s", golden, got)
+	}
+}
+
+// TestComponentsSection proves per-resource components are categorized and
+// rendered in a collapsed block
+func TestComponentsSection(t *testing.T) {
+	for _, tc := range []struct {
+		comp engine.ComponentCost
+		want string
+	}{
+		{engine.ComponentCost{Name: "instance", UsageUnit: "hours"}, "compute"},
+		{engine.ComponentCost{Name: "storage", UsageUnit: "hours"}, "storage"},
+		{engine.ComponentCost{Name: "iops", UsageUnit: "IOPS-months"}, "usage"},
+		{engine.ComponentCost{Name: "data", UsageUnit: "GB-months"}, "storage"},
+		{engine.ComponentCost{Name: "requests", UsageUnit: "requests"}, "usage"},
+	} {
+		if got := componentCategory(&tc.comp); got != tc.want {
+			t.Errorf("componentCategory(%s, %s) = %q, want %q", tc.comp.Name, tc.comp.UsageUnit, got, tc.want)
+		}
+	}
+
+	a := NewCIAdapter(nil, nil, DefaultCIConfig())
+	var out bytes.Buffer
+	err := a.outputMarkdown(&out, &CIResult{Snapshot: CISnapshot{ID: "b238e992eb78f35d"}, Resources: []CIResourceCost{{
+		Address:     "aws_db_instance.main",
+		Type:        "aws_db_instance",
+		MonthlyCost: 150,
+		Components: []CIComponentCost{
+			{Name: "instance", Category: "compute", MonthlyCost: 138.7},
+			{Name: "storage", Category: "storage", MonthlyCost: 11.3},
+			{Name: "backup_storage", Category: "storage", Symbolic: true},
+		},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<details><summary>Cost components</summary>",
+		"| `aws_db_instance.main` | instance | compute | $138.70 |",
+		"| `aws_db_instance.main` | storage | storage | $11.30 |",
+		"| `aws_db_instance.main` | backup_storage | storage | symbolic |",
+		"</details>",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("markdown missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+// TestSignedJSONOutput proves JSON output carries a signature that
+// verifies against the configured key
+func TestSignedJSONOutput(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(nil)
+	config := DefaultCIConfig()
+	config.SigningKey = key
+	a := NewCIAdapter(nil, nil, config)
+
+	var out bytes.Buffer
+	if err := a.outputJSON(&out, &CIResult{Success: true, TotalCost: 42}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := attestation.Verify(out.Bytes(), pub); err != nil {
+		t.Errorf("signed output does not verify: %v\n%s", err, out.String())
+	}
+}
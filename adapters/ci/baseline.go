@@ -0,0 +1,157 @@
+// Package adapter - Diff against the latest stored estimate
+// Instead of a base plan, a run can be compared with the last estimate
+// stored for the same project and branch: the delta is reported as the
+// run's diff, and the run is stored as the next baseline. The first run
+// of a branch has no baseline, so it reports no diff and stores itself.
+// The store is never allowed to fail the estimate: an unreachable store
+// only costs the diff, with a warning.
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+
+	"github.com/google/uuid"
+
+	"terraform-cost/adapters/storage"
+	"terraform-cost/internal/logging"
+)
+
+// baselineProject is the project ID a branch's estimates are stored
+// under; the branch is escaped so "feature/x" stays one path element
+func baselineProject(projectID, branch string) string {
+	if branch == "" {
+		return projectID
+	}
+	return projectID + "@" + url.PathEscape(branch)
+}
+
+// baselineReady reports whether stored baselines can be used, warning
+// once about what is missing
+func (a *CIAdapter) baselineReady(result *CIResult) bool {
+	switch {
+	case a.config.Store == nil:
+		result.Warnings = append(result.Warnings, "no diff against the latest stored estimate: no storage backend configured")
+	case a.config.ProjectID == "":
+		result.Warnings = append(result.Warnings, "no diff against the latest stored estimate: no project ID configured")
+	default:
+		return true
+	}
+	return false
+}
+
+// diffAgainstLatest sets result.Diff from the latest estimate stored for
+// the request's project and branch
+func (a *CIAdapter) diffAgainstLatest(ctx context.Context, req *CIRequest, result *CIResult) {
+	if !a.baselineReady(result) {
+		return
+	}
+	project := baselineProject(a.config.ProjectID, req.Branch)
+	latest, err := a.config.Store.GetLatest(ctx, project)
+	if errors.Is(err, storage.ErrNotFound) {
+		a.logger.Info("no stored baseline; this run becomes the first", logging.String("project", project))
+		result.Warnings = append(result.Warnings, fmt.Sprintf("no stored estimate for %s yet: this run is the baseline", project))
+		return
+	}
+	if err != nil {
+		a.logger.Warn("stored baseline not readable", logging.String("project", project), logging.Err(err))
+		result.Warnings = append(result.Warnings, fmt.Sprintf("no diff against the latest stored estimate: %v", err))
+		return
+	}
+
+	var base CIResult
+	if len(latest.RawResult) > 0 {
+		if err := json.Unmarshal(latest.RawResult, &base); err != nil {
+			a.logger.Warn("stored baseline has no resources", logging.String("id", latest.ID), logging.Err(err))
+		}
+	}
+	result.Diff = diffResults(latest.TotalCost, base.Resources, result)
+	result.Diff.BaselineID = latest.ID
+	result.Diff.BaselineCreatedAt = latest.CreatedAt
+}
+
+// diffResults compares result with a baseline total and its resources,
+// marking each of result's resources with its change and delta
+func diffResults(baseTotal float64, baseResources []CIResourceCost, result *CIResult) *CIDiff {
+	diff := &CIDiff{
+		OldCost: baseTotal,
+		NewCost: result.TotalCost,
+		Delta:   roundCents(result.TotalCost - baseTotal),
+	}
+	if baseTotal != 0 {
+		diff.DeltaPercent = math.Round(diff.Delta/baseTotal*10000) / 100
+	}
+
+	base := make(map[string]float64, len(baseResources))
+	for _, r := range baseResources {
+		base[r.Address] = r.MonthlyCost
+	}
+	seen := make(map[string]bool, len(result.Resources))
+	for i := range result.Resources {
+		r := &result.Resources[i]
+		seen[r.Address] = true
+		old, ok := base[r.Address]
+		switch {
+		case !ok:
+			r.ChangeType = "create"
+			r.Delta = r.MonthlyCost
+			diff.CreatedCount++
+		case old != r.MonthlyCost:
+			r.ChangeType = "update"
+			r.Delta = roundCents(r.MonthlyCost - old)
+			diff.UpdatedCount++
+		}
+	}
+	for address := range base {
+		if !seen[address] {
+			diff.DestroyedCount++
+		}
+	}
+	return diff
+}
+
+// roundCents rounds a float delta to whole cents
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// storeBaseline stores a successful result as the latest estimate for the
+// request's project and branch
+func (a *CIAdapter) storeBaseline(ctx context.Context, req *CIRequest, result *CIResult) {
+	if a.config.Store == nil || a.config.ProjectID == "" || !result.Success {
+		return
+	}
+	raw, err := json.Marshal(result)
+	if err != nil {
+		a.logger.Warn("estimate not stored", logging.Err(err))
+		return
+	}
+	stored := &storage.StoredResult{
+		ID:            uuid.NewString(),
+		ProjectID:     baselineProject(a.config.ProjectID, req.Branch),
+		TotalCost:     result.TotalCost,
+		Confidence:    result.Confidence,
+		ResourceCount: len(result.Resources),
+		SnapshotID:    result.Snapshot.ID,
+		Provider:      req.Provider,
+		Region:        req.Region,
+		CreatedAt:     a.now(),
+		Coverage: storage.CoverageData{
+			NumericPercent:     result.Coverage.NumericPercent,
+			SymbolicPercent:    result.Coverage.SymbolicPercent,
+			UnsupportedPercent: result.Coverage.UnsupportedPercent,
+		},
+		RawResult: raw,
+	}
+	if req.Branch != "" || req.Commit != "" {
+		stored.GitInfo = &storage.GitInfo{Branch: req.Branch, Commit: req.Commit}
+	}
+	if err := a.config.Store.Save(ctx, stored); err != nil {
+		a.logger.Warn("estimate not stored as the next baseline", logging.String("project", stored.ProjectID), logging.Err(err))
+		result.Warnings = append(result.Warnings, fmt.Sprintf("estimate not stored as the next baseline: %v", err))
+	}
+}
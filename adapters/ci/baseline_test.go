@@ -0,0 +1,108 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/adapters/storage"
+	"terraform-cost/core/engine"
+	"terraform-cost/core/pricing"
+	"terraform-cost/core/terraform"
+	"terraform-cost/internal/logging"
+)
+
+// TestDiffAgainstLatestStored proves a branch's first run stores itself as
+// the baseline without a diff, and the next run diffs against it per
+// resource before replacing it
+func TestDiffAgainstLatestStored(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewMemoryStore()
+	snapshot := pricing.NewSnapshotBuilder("aws", "us-east-1").
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(0.1), "hour", "USD").
+		Build()
+
+	run := func(branch, tf string) *CIResult {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(tf), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		eng := engine.NewEngine(&fixedResolver{snapshot: snapshot}, noUsage{}, nil, engine.EngineConfig{HoursPerMonth: 100})
+		eng.SetLogger(logging.Nop())
+		eng.RegisterPlugin(computePlugin{})
+
+		config := DefaultCIConfig()
+		config.DiffAgainstLatestStored = true
+		config.ProjectID = "shop"
+		config.Store = store
+		a := NewCIAdapter(eng, terraform.NewPipeline(terraform.PipelineOptions{}), config)
+		a.SetOutput(&bytes.Buffer{})
+		a.SetLogger(logging.Nop())
+		result, err := a.Run(context.Background(), &CIRequest{Path: dir, Provider: "aws", Region: "us-east-1", Branch: branch, Commit: "abc123"})
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		return result
+	}
+
+	first := run("feature/x", `
+resource "aws_instance" "web" {
+  count         = 2
+  instance_type = "t3.micro"
+}
+
+resource "aws_instance" "db" {
+  instance_type = "t3.micro"
+}
+`)
+	if first.Diff != nil || first.ExitCode != ExitSuccess {
+		t.Errorf("first run: diff = %+v, exit %d; want no diff and success", first.Diff, first.ExitCode)
+	}
+	if !strings.Contains(strings.Join(first.Warnings, "\n"), "this run is the baseline") {
+		t.Errorf("first run warnings = %v, want a baseline note", first.Warnings)
+	}
+	baseline, err := store.GetLatest(context.Background(), "shop@feature%2Fx")
+	if err != nil || baseline.TotalCost != 30 || baseline.GitInfo == nil || baseline.GitInfo.Branch != "feature/x" {
+		t.Fatalf("stored baseline = %+v, %v", baseline, err)
+	}
+
+	second := run("feature/x", `
+resource "aws_instance" "web" {
+  count         = 3
+  instance_type = "t3.micro"
+}
+
+resource "aws_instance" "cache" {
+  instance_type = "t3.micro"
+}
+`)
+	d := second.Diff
+	if d == nil {
+		t.Fatalf("second run has no diff: %v", second.Warnings)
+	}
+	if d.BaselineID != baseline.ID || d.OldCost != 30 || d.NewCost != 40 || d.Delta != 10 || d.DeltaPercent != 33.33 {
+		t.Errorf("diff = %+v, want 30 -> 40 against %s", d, baseline.ID)
+	}
+	if d.CreatedCount != 2 || d.DestroyedCount != 1 || d.UpdatedCount != 0 {
+		t.Errorf("diff counts = %+v, want 2 created (web[2], cache), 1 destroyed (db)", d)
+	}
+	for _, r := range second.Resources {
+		wantCreated := r.Address == "aws_instance.web[2]" || r.Address == "aws_instance.cache"
+		if created := r.ChangeType == "create"; created != wantCreated || (created && r.Delta != 10) {
+			t.Errorf("%s: change %q delta %v", r.Address, r.ChangeType, r.Delta)
+		}
+	}
+	if latest, _ := store.GetLatest(context.Background(), "shop@feature%2Fx"); latest == nil || latest.TotalCost != 40 {
+		t.Errorf("the second run should be the new baseline, got %+v", latest)
+	}
+
+	// Each branch has its own baseline
+	if other := run("main", `resource "aws_instance" "web" {}`); other.Diff != nil {
+		t.Errorf("main's first run diffed against %s", other.Diff.BaselineID)
+	}
+}
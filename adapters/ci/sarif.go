@@ -0,0 +1,255 @@
+// Package adapter - SARIF output
+// Security and quality dashboards (GitHub code scanning among them) ingest
+// SARIF 2.1.0. Each policy violation, estimation warning and symbolic
+// resource becomes a result; results about a resource name its address as
+// a logical location and, when it came from a .tf file, the file and line.
+package adapter
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"terraform-cost/core/model"
+)
+
+const (
+	sarifVersion  = "2.1.0"
+	sarifSchema   = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifToolName = "terraform-cost"
+)
+
+// Rule IDs for SARIF results that are not policy violations
+const (
+	RuleEstimationWarning = "estimation_warning"
+	RuleSymbolicResource  = "symbolic_resource"
+)
+
+// sarifRuleDescriptions describe the built-in rules; policy-file rules are
+// described by their name
+var sarifRuleDescriptions = map[string]string{
+	"budget_limit":        "Monthly cost exceeds the budget",
+	"symbolic_limit":      "Symbolic coverage exceeds the limit",
+	"unsupported_limit":   "Unsupported coverage exceeds the limit",
+	"confidence_minimum":  "Estimate confidence is below the minimum",
+	"snapshot_staleness":  "Pricing snapshot is stale",
+	RuleUncatalogedType:   "Resource type is missing from the cost catalog",
+	RuleEstimationWarning: "Estimation warning",
+	RuleSymbolicResource:  "Resource count is unknown until apply",
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version,omitempty"`
+	Rules   []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID       string             `json:"ruleId"`
+	RuleIndex    int                `json:"ruleIndex"`
+	Level        string             `json:"level"`
+	Message      sarifMessage       `json:"message"`
+	Locations    []sarifLocation    `json:"locations,omitempty"`
+	Suppressions []sarifSuppression `json:"suppressions,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+// outputSARIF writes the result as a SARIF log with one run
+func (a *CIAdapter) outputSARIF(w io.Writer, result *CIResult) error {
+	var results []sarifResult
+	for _, v := range result.PolicyViolations {
+		r := sarifResult{
+			RuleID:    v.Rule,
+			Level:     sarifLevel(v.Severity),
+			Message:   sarifMessage{Text: v.Message},
+			Locations: result.sarifLocations(v.ResourceAddress),
+		}
+		if v.Suppressed {
+			r.Suppressions = []sarifSuppression{{Kind: "external", Justification: v.SuppressionReason}}
+		}
+		results = append(results, r)
+	}
+	for _, s := range result.SymbolicResources {
+		results = append(results, sarifResult{
+			RuleID:    RuleSymbolicResource,
+			Level:     "warning",
+			Message:   sarifMessage{Text: s.Address + ": " + symbolicDetail(s)},
+			Locations: result.sarifLocations(s.Address),
+		})
+	}
+	for _, warning := range result.Warnings {
+		// Source warnings read "address: message"
+		address, _, _ := strings.Cut(warning, ": ")
+		results = append(results, sarifResult{
+			RuleID:    RuleEstimationWarning,
+			Level:     "warning",
+			Message:   sarifMessage{Text: warning},
+			Locations: result.sarifLocations(address),
+		})
+	}
+
+	rules := sarifRules(results)
+	if results == nil {
+		results = []sarifResult{}
+	}
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: sarifDriver{Name: sarifToolName, Version: result.Metadata.Version, Rules: rules}},
+			Results: results,
+		}},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+// sarifRules lists the rules the results use, sorted by ID, and points
+// each result at its rule
+func sarifRules(results []sarifResult) []sarifRule {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, r := range results {
+		if !seen[r.RuleID] {
+			seen[r.RuleID] = true
+			ids = append(ids, r.RuleID)
+		}
+	}
+	sort.Strings(ids)
+
+	rules := make([]sarifRule, len(ids))
+	index := make(map[string]int, len(ids))
+	for i, id := range ids {
+		description, ok := sarifRuleDescriptions[id]
+		if !ok {
+			description = id
+		}
+		rules[i] = sarifRule{ID: id, ShortDescription: sarifMessage{Text: description}}
+		index[id] = i
+	}
+	for i := range results {
+		results[i].RuleIndex = index[results[i].RuleID]
+	}
+	return rules
+}
+
+// sarifLevel maps a violation severity to a SARIF level
+func sarifLevel(severity string) string {
+	switch severity {
+	case "error":
+		return "error"
+	case SeverityInfo:
+		return "note"
+	}
+	return "warning"
+}
+
+// sarifLocations locates a result at a resource address, adding the
+// source file and line when the address came from a .tf file. Results
+// not about a resource have no location.
+func (r *CIResult) sarifLocations(address string) []sarifLocation {
+	loc, ok := r.locations[address]
+	if !ok {
+		if address == "" || !strings.Contains(address, ".") || strings.Contains(address, " ") {
+			return nil
+		}
+	}
+	location := sarifLocation{
+		LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: address, Kind: "resource"}},
+	}
+	if ok && loc.File != "" {
+		location.PhysicalLocation = &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: loc.File}}
+		if loc.StartLine > 0 {
+			location.PhysicalLocation.Region = &sarifRegion{StartLine: loc.StartLine, EndLine: loc.EndLine}
+		}
+	}
+	return []sarifLocation{location}
+}
+
+// sourceLocations maps the addresses of the graph's instances, and of
+// their definitions, to where they are written. Files are joined to the
+// module root and rendered as SARIF URIs.
+func sourceLocations(graph *model.InstanceGraph, root string) map[string]model.SourceLocation {
+	if graph == nil {
+		return nil
+	}
+	locations := make(map[string]model.SourceLocation)
+	for _, inst := range graph.Instances() {
+		loc := inst.Metadata.Location
+		if loc.File == "" {
+			continue
+		}
+		loc.File = sarifURI(filepath.Join(root, loc.File))
+		locations[string(inst.Address)] = loc
+		definition, _, _ := strings.Cut(string(inst.Address), "[")
+		if _, ok := locations[definition]; !ok {
+			locations[definition] = loc
+		}
+	}
+	return locations
+}
+
+// sarifURI renders a path as a relative URI reference, or a file URI when
+// it is absolute
+func sarifURI(path string) string {
+	if filepath.IsAbs(path) {
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+	}
+	return filepath.ToSlash(path)
+}
@@ -0,0 +1,162 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/engine"
+	"terraform-cost/core/policy"
+	"terraform-cost/core/pricing"
+	"terraform-cost/core/terraform"
+	"terraform-cost/internal/logging"
+)
+
+// TestSARIFOutput proves violations, symbolic resources and warnings are
+// SARIF 2.1.0 results, located at their resource's file and line
+func TestSARIFOutput(t *testing.T) {
+	dir := t.TempDir()
+	tf := `variable "replicas" {}
+
+resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+}
+
+resource "aws_instance" "api" {
+  count         = var.replicas
+  instance_type = "t3.micro"
+  tags          = { team = "platform" }
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(tf), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := pricing.NewSnapshotBuilder("aws", "us-east-1").
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(0.1), "hour", "USD").
+		Build()
+	eng := engine.NewEngine(&fixedResolver{snapshot: snapshot}, noUsage{}, nil, engine.EngineConfig{})
+	eng.SetLogger(logging.Nop())
+	eng.RegisterPlugin(computePlugin{})
+
+	policies, err := policy.ParsePolicyFile([]byte(`{"required_tags": ["team"]}`), "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultCIConfig()
+	config.OutputFormat = FormatSARIF
+	config.Policies = policies
+	config.BudgetLimit = 1
+	a := NewCIAdapter(eng, terraform.NewPipeline(terraform.PipelineOptions{}), config)
+	var out bytes.Buffer
+	a.SetOutput(&out)
+	a.SetLogger(logging.Nop())
+
+	result, err := a.Run(context.Background(), &CIRequest{Path: dir, Provider: "aws", Region: "us-east-1"})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	var log struct {
+		Schema  string `json:"$schema"`
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name    string `json:"name"`
+					Version string `json:"version"`
+					Rules   []struct {
+						ID               string `json:"id"`
+						ShortDescription struct {
+							Text string `json:"text"`
+						} `json:"shortDescription"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex *int   `json:"ruleIndex"`
+				Level     string `json:"level"`
+				Message   struct {
+					Text string `json:"text"`
+				} `json:"message"`
+				Locations []struct {
+					PhysicalLocation *struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region *struct {
+							StartLine int `json:"startLine"`
+							EndLine   int `json:"endLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+					LogicalLocations []struct {
+						FullyQualifiedName string `json:"fullyQualifiedName"`
+						Kind               string `json:"kind"`
+					} `json:"logicalLocations"`
+				} `json:"locations"`
+				Suppressions []struct {
+					Kind          string `json:"kind"`
+					Justification string `json:"justification"`
+				} `json:"suppressions"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(out.Bytes()))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&log); err != nil {
+		t.Fatalf("SARIF does not match the expected shape: %v\n%s", err, out.String())
+	}
+
+	if log.Version != "2.1.0" || !strings.Contains(log.Schema, "sarif-2.1.0") {
+		t.Errorf("version = %q, schema = %q", log.Version, log.Schema)
+	}
+	if len(log.Runs) != 1 || log.Runs[0].Tool.Driver.Name == "" {
+		t.Fatalf("want one run with a named tool, got %+v", log.Runs)
+	}
+	run := log.Runs[0]
+	if want := len(result.PolicyViolations) + len(result.SymbolicResources) + len(result.Warnings); len(run.Results) != want {
+		t.Errorf("got %d results, want %d", len(run.Results), want)
+	}
+
+	levels := map[string]bool{"none": true, "note": true, "warning": true, "error": true}
+	located := map[string]int{}
+	for _, r := range run.Results {
+		if r.RuleIndex == nil || *r.RuleIndex >= len(run.Tool.Driver.Rules) || run.Tool.Driver.Rules[*r.RuleIndex].ID != r.RuleID {
+			t.Errorf("result %s does not index its rule", r.RuleID)
+		}
+		if !levels[r.Level] || r.Message.Text == "" {
+			t.Errorf("result %s has level %q, message %q", r.RuleID, r.Level, r.Message.Text)
+		}
+		for _, loc := range r.Locations {
+			if len(loc.LogicalLocations) != 1 || loc.LogicalLocations[0].Kind != "resource" {
+				t.Errorf("result %s: logical locations %+v", r.RuleID, loc.LogicalLocations)
+				continue
+			}
+			p := loc.PhysicalLocation
+			if p == nil || !strings.HasSuffix(p.ArtifactLocation.URI, "/main.tf") || p.Region == nil {
+				t.Errorf("result %s at %s has no source location", r.RuleID, loc.LogicalLocations[0].FullyQualifiedName)
+				continue
+			}
+			located[loc.LogicalLocations[0].FullyQualifiedName] = p.Region.StartLine
+		}
+	}
+	for _, rule := range run.Tool.Driver.Rules {
+		if rule.ShortDescription.Text == "" {
+			t.Errorf("rule %s has no description", rule.ID)
+		}
+	}
+
+	if located["aws_instance.web"] != 3 {
+		t.Errorf("aws_instance.web located at line %d, want 3 (located: %v)", located["aws_instance.web"], located)
+	}
+	if located["aws_instance.api"] != 7 {
+		t.Errorf("aws_instance.api located at line %d, want 7 (located: %v)", located["aws_instance.api"], located)
+	}
+}
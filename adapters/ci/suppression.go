@@ -0,0 +1,125 @@
+// Package adapter - Policy violation suppressions
+// A known, approved overage should not fail every CI run. A suppression
+// file lists the violations that are accepted, why, and until when; a
+// matching violation is downgraded to info with the reason in the output.
+package adapter
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SeverityInfo is the severity of a suppressed violation
+const SeverityInfo = "info"
+
+// Suppression accepts violations of one rule until it expires
+type Suppression struct {
+	// Rule is the violated rule (e.g. budget_limit, budget:team-a)
+	Rule string `json:"rule" yaml:"rule"`
+
+	// ResourceAddress limits the suppression to violations for one
+	// resource; empty matches every violation of Rule
+	ResourceAddress string `json:"resource_address,omitempty" yaml:"resource_address,omitempty"`
+
+	// Reason is recorded on the suppressed violation
+	Reason string `json:"reason" yaml:"reason"`
+
+	// Expires is the last day (YYYY-MM-DD) or instant (RFC 3339) the
+	// suppression applies; empty never expires
+	Expires string `json:"expires,omitempty" yaml:"expires,omitempty"`
+}
+
+// ExpiresAt returns when the suppression stops applying. A date expires at
+// the end of that day (UTC). ok is false when the suppression never expires.
+func (s Suppression) ExpiresAt() (at time.Time, ok bool, err error) {
+	if s.Expires == "" {
+		return time.Time{}, false, nil
+	}
+	if day, err := time.Parse("2006-01-02", s.Expires); err == nil {
+		return day.AddDate(0, 0, 1), true, nil
+	}
+	at, err = time.Parse(time.RFC3339, s.Expires)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("suppression for %s: expires %q is not YYYY-MM-DD or RFC 3339", s.Rule, s.Expires)
+	}
+	return at, true, nil
+}
+
+// matches reports whether the suppression covers a violation
+func (s Suppression) matches(v PolicyViolation) bool {
+	return s.Rule == v.Rule && (s.ResourceAddress == "" || s.ResourceAddress == v.ResourceAddress)
+}
+
+// LoadSuppressionFile reads suppressions from a YAML or JSON file into
+// Suppressions. Every entry needs a rule and a reason.
+func (c *CIConfig) LoadSuppressionFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read suppression file: %w", err)
+	}
+
+	// JSON is valid YAML, so one decoder reads both
+	var file struct {
+		Suppressions []Suppression `yaml:"suppressions"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse suppression file %s: %w", path, err)
+	}
+
+	for i, s := range file.Suppressions {
+		if s.Rule == "" {
+			return fmt.Errorf("suppression file %s: suppressions[%d].rule is required", path, i)
+		}
+		if s.Reason == "" {
+			return fmt.Errorf("suppression file %s: suppressions[%d].reason is required", path, i)
+		}
+		if _, _, err := s.ExpiresAt(); err != nil {
+			return fmt.Errorf("suppression file %s: %w", path, err)
+		}
+	}
+
+	c.SuppressionFile = path
+	c.Suppressions = file.Suppressions
+	return nil
+}
+
+// applySuppressions downgrades violations covered by an active suppression
+// to info and returns a warning for each expired suppression that would
+// otherwise have matched
+func applySuppressions(violations []PolicyViolation, suppressions []Suppression, now time.Time) []string {
+	var warnings []string
+	for i := range violations {
+		v := &violations[i]
+		for _, s := range suppressions {
+			if !s.matches(*v) {
+				continue
+			}
+			at, expires, err := s.ExpiresAt()
+			if err != nil {
+				warnings = append(warnings, err.Error())
+				continue
+			}
+			if expires && !now.Before(at) {
+				warnings = append(warnings, fmt.Sprintf("suppression for %s expired %s; the violation is enforced again (%s)",
+					suppressionTarget(s), s.Expires, s.Reason))
+				continue
+			}
+			v.Severity = SeverityInfo
+			v.Suppressed = true
+			v.SuppressionReason = s.Reason
+			break
+		}
+	}
+	return warnings
+}
+
+// suppressionTarget names what a suppression covers
+func suppressionTarget(s Suppression) string {
+	if s.ResourceAddress == "" {
+		return s.Rule
+	}
+	return s.Rule + " on " + s.ResourceAddress
+}
@@ -0,0 +1,88 @@
+// Package adapter - Uncataloged resource types
+// Symbolic and unsupported coverage covers types the catalog knows about.
+// A resource type missing from core/catalog altogether is new to the tool:
+// FailOnUnsupportedType reports it as a violation so teams notice coverage
+// drift when a PR introduces one, and can add a mapper.
+package adapter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"terraform-cost/core/catalog"
+	"terraform-cost/core/engine"
+	"terraform-cost/core/model"
+)
+
+// RuleUncatalogedType is the violation rule for resource types missing
+// from the catalog
+const RuleUncatalogedType = "uncataloged_type"
+
+// CIUncatalogedType is a resource type the catalog does not know
+type CIUncatalogedType struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+}
+
+// uncatalogedTypes counts the graph's AWS, Azure and GCP instances whose
+// resource type is not in cat, sorted by type. Other providers' types
+// (random, null, kubernetes) are never in the catalog and are skipped.
+func uncatalogedTypes(graph *model.InstanceGraph, cat *catalog.Catalog) []CIUncatalogedType {
+	if graph == nil {
+		return nil
+	}
+	counts := make(map[string]int)
+	for _, inst := range graph.Instances() {
+		if inst.Metadata.Destroyed || strings.HasPrefix(string(inst.Address), "data.") || strings.Contains(string(inst.Address), ".data.") {
+			continue
+		}
+		resourceType := engine.ResourceTypeFromAddress(inst.Address)
+		cloud, ok := catalog.CloudForResourceType(resourceType)
+		if !ok {
+			continue
+		}
+		if _, ok := cat.Get(cloud, resourceType); !ok {
+			counts[resourceType]++
+		}
+	}
+
+	types := make([]CIUncatalogedType, 0, len(counts))
+	for resourceType, count := range counts {
+		types = append(types, CIUncatalogedType{Type: resourceType, Count: count})
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Type < types[j].Type })
+	return types
+}
+
+// evaluateUncatalogedTypes lists the uncataloged types on the result and,
+// when FailOnUnsupportedType is set, adds one violation naming them all
+func (a *CIAdapter) evaluateUncatalogedTypes(graph *model.InstanceGraph, result *CIResult) {
+	types := uncatalogedTypes(graph, catalog.Default())
+	if len(types) == 0 {
+		return
+	}
+	result.UncatalogedTypes = types
+
+	severity := strings.ToLower(a.config.FailOnUnsupportedType)
+	if severity == "" {
+		return
+	}
+	if severity != "warning" {
+		severity = "error"
+	}
+
+	names := make([]string, len(types))
+	total := 0
+	for i, t := range types {
+		names[i] = t.Type
+		total += t.Count
+	}
+	result.PolicyViolations = append(result.PolicyViolations, PolicyViolation{
+		Rule: RuleUncatalogedType,
+		Message: fmt.Sprintf("%d resource type(s) not in the cost catalog: %s",
+			len(types), strings.Join(names, ", ")),
+		Severity: severity,
+		Actual:   float64(total),
+	})
+}
@@ -8,20 +8,29 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"time"
 
+	"terraform-cost/adapters/storage"
+	tfadapter "terraform-cost/adapters/terraform"
+	_ "terraform-cost/adapters/terraform/hcl"
 	"terraform-cost/core/engine"
 	"terraform-cost/core/model"
 	"terraform-cost/core/pricing"
 	"terraform-cost/core/terraform"
+	"terraform-cost/internal/config"
 )
 
+// CacheDirName is the result cache directory in the project directory
+const CacheDirName = ".terraform-cost-cache"
+
 // CLIAdapter is a THIN wrapper around the core engine.
 // It handles input/output only - all logic is in the engine.
 type CLIAdapter struct {
 	engine   *engine.Engine
 	pipeline *terraform.Pipeline
 	output   io.Writer
+	progress io.Writer
 	format   OutputFormat
 }
 
@@ -34,8 +43,12 @@ const (
 	FormatMarkdown
 )
 
-// NewCLIAdapter creates a new CLI adapter
+// NewCLIAdapter creates a new CLI adapter. A nil pipeline uses the
+// configured expansion settings.
 func NewCLIAdapter(eng *engine.Engine, pipeline *terraform.Pipeline) *CLIAdapter {
+	if pipeline == nil {
+		pipeline = config.Get().Terraform.NewPipeline()
+	}
 	return &CLIAdapter{
 		engine:   eng,
 		pipeline: pipeline,
@@ -62,28 +75,122 @@ type CLIRequest struct {
 	// Variables from CLI
 	Variables map[string]any
 
+	// Workspace terraform.workspace resolves to (--workspace); empty is
+	// "default"
+	Workspace string
+
+	// CompareWorkspaces expands and prices the project once per
+	// workspace and prints a side-by-side comparison instead of the
+	// estimate (--compare-workspace)
+	CompareWorkspaces []string
+
 	// Snapshot specification
 	SnapshotID string
 	Provider   string
 	Region     string
+	Alias      string
 
 	// Usage overrides file
 	UsageFile string
 
+	// RateOverrides is a file of negotiated prices layered on the
+	// snapshot's rates (--rate-overrides)
+	RateOverrides string
+
+	// PlanFile is the plan JSON the result cache is keyed by: a file
+	// path, "-" for stdin or an http(s) URL
+	PlanFile string
+
+	// Cache reuses the result of an unchanged plan, keyed by the plan and
+	// the snapshot's content hash, from CacheDir (--cache). It applies
+	// only with a PlanFile.
+	Cache bool
+
+	// CacheDir holds cached results; CacheDirName in the project
+	// directory when empty (--cache-dir)
+	CacheDir string
+
+	// NoCache turns Cache off (--no-cache)
+	NoCache bool
+
+	// IgnorePatterns are address globs for resources left out of the
+	// total (--ignore 'aws_instance.test_*,module.sandbox.*')
+	IgnorePatterns []string
+
+	// CompareRegions prices the project in each region and prints a
+	// side-by-side comparison instead of the estimate (--compare-region)
+	CompareRegions []string
+
+	// CompareTo is a base project directory the project is diffed
+	// against instance by instance; the diff is printed instead of the
+	// estimate (--compare-to)
+	CompareTo string
+
+	// MatchByAttributes reports an instance whose address changed but
+	// whose type and cost inputs did not as moved, with zero delta,
+	// instead of removed and added (--match-by-attributes)
+	MatchByAttributes bool
+
+	// IncludeSymbolicEstimate prices resources with an unknown for_each
+	// at the assumed count, as placeholders excluded from the firm total
+	// (--include-symbolic-estimate)
+	IncludeSymbolicEstimate bool
+
+	// UpdateLock re-pins the latest snapshot in the project's lockfile
+	// instead of pricing from the pinned one (--update-lock)
+	UpdateLock bool
+
+	// NoLock neither reads nor writes the lockfile (--no-lock)
+	NoLock bool
+
 	// Output options
 	Format     string
 	ShowLineage bool
+
+	// ShowAnnual adds the annual total (rounded monthly x 12) next to the
+	// monthly one (--show-annual)
+	ShowAnnual bool
 }
 
 // Run executes the estimation
 func (a *CLIAdapter) Run(ctx context.Context, req *CLIRequest) error {
 	// 1. Run Terraform pipeline
 	scanInput := &terraform.ScanInput{
-		RootPath:  req.Path,
-		Workspace: "default",
+		RootPath:      req.Path,
+		Workspace:     req.Workspace,
+		DefaultRegion: req.Region,
+	}
+
+	// Values recorded in the plan resolve var.* and data.* references the
+	// configuration leaves unknown; variables from the command line take
+	// precedence
+	pipeline := a.pipeline
+	var plan []byte
+	if req.PlanFile != "" {
+		// Read once: stdin cannot be read again for the cache key
+		data, err := tfadapter.NewPlanSource(req.PlanFile).Read(ctx)
+		if err != nil {
+			fmt.Fprintf(a.output, "Warning: plan not readable: %v\n", err)
+		}
+		plan = data
+	}
+	if plan != nil {
+		parsed, err := parsePlan(plan)
+		if err != nil {
+			fmt.Fprintf(a.output, "Warning: plan variables not used: %v\n", err)
+		} else {
+			pipeline = pipeline.WithVariables(parsed.VariableValues()).
+				WithDataSources(parsed.DataSourceValues())
+		}
+	}
+	if len(req.Variables) > 0 {
+		pipeline = pipeline.WithVariables(req.Variables)
+	}
+	if req.IncludeSymbolicEstimate {
+		pipeline = pipeline.WithSymbolicEstimate()
 	}
 
-	pipelineResult, err := a.pipeline.Execute(ctx, scanInput)
+	pipelineResult, err := pipeline.Execute(ctx, scanInput)
 	if err != nil {
 		return fmt.Errorf("failed to scan terraform: %w", err)
 	}
@@ -92,11 +199,31 @@ func (a *CLIAdapter) Run(ctx context.Context, req *CLIRequest) error {
 	snapshotReq := engine.SnapshotRequest{
 		Provider: req.Provider,
 		Region:   req.Region,
+		Alias:    req.Alias,
 	}
 	if req.SnapshotID != "" {
 		snapshotReq.SnapshotID = pricing.SnapshotID(req.SnapshotID)
 	}
 
+	// An explicit snapshot or a region comparison bypasses the lockfile
+	var lock *LockFile
+	var pin *LockedSnapshot
+	if !req.NoLock && req.SnapshotID == "" && len(req.CompareRegions) == 0 {
+		lock, err = LoadLockFile(lockPath(req.Path))
+		if err != nil {
+			return err
+		}
+		alias := req.Alias
+		if alias == "" {
+			alias = engine.DefaultProviderAlias
+		}
+		if pin = lock.Find(req.Provider, req.Region, alias); pin != nil && !req.UpdateLock {
+			snapshotReq.SnapshotID = pricing.SnapshotID(pin.SnapshotID)
+		} else {
+			pin = nil
+		}
+	}
+
 	// Load usage overrides if provided
 	overrides := make(map[model.InstanceID]map[string]float64)
 	if req.UsageFile != "" {
@@ -107,27 +234,130 @@ func (a *CLIAdapter) Run(ctx context.Context, req *CLIRequest) error {
 		}
 	}
 
+	var rateOverrides *pricing.RateOverrides
+	if req.RateOverrides != "" {
+		var err error
+		if rateOverrides, err = pricing.LoadRateOverrides(req.RateOverrides); err != nil {
+			return err
+		}
+	}
+
 	// 3. Delegate to engine
 	estimateReq := &engine.EstimateRequest{
 		Graph:           pipelineResult.Graph,
 		SnapshotRequest: snapshotReq,
 		UsageOverrides:  overrides,
+		RateOverrides:   rateOverrides,
+		IgnorePatterns:  req.IgnorePatterns,
+
+		CardinalityWarnings: pipelineResult.CardinalityWarnings,
+		SourceWarnings:      pipelineResult.WarningMessages(),
+		Workspace:           pipelineResult.Workspace,
+		OnProgress:          a.progressReporter(),
+	}
+	if req.Cache && !req.NoCache && plan != nil {
+		if err := a.useCache(req, estimateReq, plan); err != nil {
+			fmt.Fprintf(a.output, "Warning: result cache disabled: %v\n", err)
+		}
+	}
+
+	if len(req.CompareRegions) > 0 {
+		comparison, err := a.engine.CompareRegions(ctx, estimateReq, req.CompareRegions)
+		if err != nil {
+			return fmt.Errorf("region comparison failed: %w", err)
+		}
+		return a.outputComparison(comparison)
+	}
+	if len(req.CompareWorkspaces) > 0 {
+		comparison, err := a.compareWorkspaces(ctx, pipeline, scanInput, estimateReq, req.CompareWorkspaces)
+		if err != nil {
+			return fmt.Errorf("workspace comparison failed: %w", err)
+		}
+		return a.outputWorkspaceComparison(comparison)
+	}
+	if req.CompareTo != "" {
+		result, err := a.diffAgainst(ctx, pipeline, scanInput, estimateReq, req.CompareTo, req.MatchByAttributes)
+		if err != nil {
+			return fmt.Errorf("comparison with %s failed: %w", req.CompareTo, err)
+		}
+		return a.outputDiff(result)
 	}
 
 	result, err := a.engine.Estimate(ctx, estimateReq)
 	if err != nil {
+		if pin != nil {
+			return fmt.Errorf("pinned snapshot %s in %s is not available (run with --update-lock to pin the latest): %w",
+				pin.SnapshotID, LockFileName, err)
+		}
 		return fmt.Errorf("estimation failed: %w", err)
 	}
 
+	var outcome *lockOutcome
+	if lock != nil {
+		outcome, err = a.applyLock(lock, pin, result, req.Path)
+		if err != nil {
+			return err
+		}
+	}
+
 	// 4. Format and output
 	switch a.format {
 	case FormatJSON:
-		return a.outputJSON(result)
+		return a.outputJSON(result, req.ShowAnnual, outcome)
 	case FormatMarkdown:
-		return a.outputMarkdown(result)
+		return a.outputMarkdown(result, req.ShowAnnual, outcome)
 	default:
-		return a.outputTable(result, req.ShowLineage)
+		return a.outputTable(result, req.ShowLineage, req.ShowAnnual, outcome)
+	}
+}
+
+// applyLock checks the estimate used the pinned snapshot unchanged, or
+// pins the snapshot it used when nothing was pinned
+func (a *CLIAdapter) applyLock(lock *LockFile, pin *LockedSnapshot, result *engine.EstimationResult, projectPath string) (*lockOutcome, error) {
+	outcome := &lockOutcome{path: LockFileName}
+	if pin != nil {
+		if hash := result.Snapshot.ContentHash.Hex(); hash != pin.ContentHash {
+			return nil, fmt.Errorf("pinned snapshot %s has content hash %s, but %s expects %s",
+				pin.SnapshotID, hash, LockFileName, pin.ContentHash)
+		}
+		outcome.pinned = true
+		return outcome, nil
+	}
+	lock.Pin(result.Snapshot)
+	if err := lock.Save(lockPath(projectPath)); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", LockFileName, err)
 	}
+	outcome.written = true
+	return outcome, nil
+}
+
+// useCache keys the request by its plan and gives the engine the result
+// cache in the request's cache directory
+func (a *CLIAdapter) useCache(req *CLIRequest, estimateReq *engine.EstimateRequest, plan []byte) error {
+	key, err := engine.PlanCacheKey(plan)
+	if err != nil {
+		return err
+	}
+	dir := req.CacheDir
+	if dir == "" {
+		dir = filepath.Join(req.Path, CacheDirName)
+	}
+	store, err := storage.NewFileStore(dir)
+	if err != nil {
+		return err
+	}
+	a.engine.SetResultCache(storage.NewResultCache(store))
+	estimateReq.CacheKey = key
+	return nil
+}
+
+// parsePlan reads plan JSON for its input variable and data source values
+func parsePlan(data []byte) (*tfadapter.PlanOutput, error) {
+	var plan tfadapter.PlanOutput
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan: %w", err)
+	}
+	return &plan, nil
 }
 
 func (a *CLIAdapter) loadUsageOverrides(path string) (map[model.InstanceID]map[string]float64, error) {
@@ -148,7 +378,7 @@ func (a *CLIAdapter) loadUsageOverrides(path string) (map[model.InstanceID]map[s
 	return result, nil
 }
 
-func (a *CLIAdapter) outputTable(result *engine.EstimationResult, showLineage bool) error {
+func (a *CLIAdapter) outputTable(result *engine.EstimationResult, showLineage, showAnnual bool, lock *lockOutcome) error {
 	fmt.Fprintln(a.output, "")
 	fmt.Fprintln(a.output, "╔══════════════════════════════════════════════════════════════════╗")
 	fmt.Fprintln(a.output, "║                     COST ESTIMATION REPORT                        ║")
@@ -160,6 +390,15 @@ func (a *CLIAdapter) outputTable(result *engine.EstimationResult, showLineage bo
 		result.Snapshot.ID, result.Snapshot.ContentHash.String())
 	fmt.Fprintf(a.output, "Effective Date:   %s\n", result.Snapshot.EffectiveAt.Format(time.RFC3339))
 	fmt.Fprintf(a.output, "Provider/Region:  %s / %s\n", result.Snapshot.Provider, result.Snapshot.Region)
+	if result.Workspace != "" {
+		fmt.Fprintf(a.output, "Workspace:        %s\n", result.Workspace)
+	}
+	if status := lock.describe(); status != "" {
+		fmt.Fprintf(a.output, "Lockfile:         %s\n", status)
+	}
+	if result.Cached {
+		fmt.Fprintln(a.output, "Result:           cached (plan and snapshot unchanged)")
+	}
 	fmt.Fprintln(a.output, "")
 
 	// Instance costs
@@ -168,31 +407,64 @@ func (a *CLIAdapter) outputTable(result *engine.EstimationResult, showLineage bo
 	fmt.Fprintf(a.output, "%-40s %12s %10s\n", "INSTANCE", "MONTHLY", "CONFIDENCE")
 	fmt.Fprintln(a.output, "─────────────────────────────────────────────────────────────────────")
 
-	result.InstanceCosts.Range(func(id model.InstanceID, cost *engine.InstanceCost) bool {
+	for _, cost := range result.SortedInstanceCosts() {
 		confStr := fmt.Sprintf("%.0f%%", cost.Confidence.Score*100)
 		if cost.Confidence.Score < 0.7 {
 			confStr += " ⚠"
 		}
+		if cost.Symbolic {
+			continue
+		}
+		address := string(cost.Address)
+		if cost.SkipReason != "" {
+			fmt.Fprintf(a.output, "%-40s %12s %10s\n", truncate(address, 40), "-", "skipped")
+			fmt.Fprintf(a.output, "  └─ %s\n", cost.SkipReason)
+			continue
+		}
 		fmt.Fprintf(a.output, "%-40s %12s %10s\n",
-			truncate(string(cost.Address), 40),
-			cost.MonthlyCost.String(),
+			truncate(address, 40),
+			cost.DisplayMonthlyCost().String(),
 			confStr)
 
 		// Show components if requested
 		if showLineage {
 			for _, comp := range cost.Components {
+				name := comp.Name
+				if comp.Credit {
+					name += " (credit)"
+				}
 				fmt.Fprintf(a.output, "  └─ %-36s %12s\n",
-					comp.Name, comp.MonthlyCost.String())
+					name, comp.MonthlyCost.String())
 			}
 		}
-		return true
-	})
+	}
+
+	// Placeholders of a resource with an unknown count, once per resource
+	for _, g := range result.PlaceholderGroups() {
+		fmt.Fprintf(a.output, "%-40s %12s %10s\n",
+			truncate("~ "+g.Address, 40),
+			g.MonthlyCost.String(),
+			fmt.Sprintf("%.0f%%", g.Confidence*100))
+		fmt.Fprintf(a.output, "  └─ %s\n", placeholderSummary(g))
+	}
 
 	fmt.Fprintln(a.output, "─────────────────────────────────────────────────────────────────────")
 	fmt.Fprintf(a.output, "%-40s %12s %10s\n",
 		"TOTAL",
-		result.TotalMonthlyCost.String(),
+		result.DisplayTotalMonthlyCost().String(),
 		fmt.Sprintf("%.0f%%", result.Confidence.Score*100))
+	if showAnnual {
+		fmt.Fprintf(a.output, "%-40s %12s\n", "TOTAL ANNUAL", result.AnnualCost().String())
+	}
+	if hasSymbolicEstimate(result) {
+		fmt.Fprintf(a.output, "%-40s %12s\n", "  firm (known counts)", result.FirmTotal.String())
+		fmt.Fprintf(a.output, "%-40s %12s\n", "  ~ symbolic placeholders",
+			result.EstimatedTotalIncludingSymbolic.Sub(result.FirmTotal).String())
+	}
+	if result.IgnoredCount > 0 {
+		fmt.Fprintf(a.output, "%-40s %12s\n", fmt.Sprintf("  ignored (%d, not in total)", result.IgnoredCount),
+			result.IgnoredMonthlyCost.String())
+	}
 	fmt.Fprintln(a.output, "")
 
 	// Warnings
@@ -222,24 +494,50 @@ func (a *CLIAdapter) outputTable(result *engine.EstimationResult, showLineage bo
 	return nil
 }
 
-func (a *CLIAdapter) outputJSON(result *engine.EstimationResult) error {
-	// Convert to JSON-friendly structure
+func (a *CLIAdapter) outputJSON(result *engine.EstimationResult, showAnnual bool, lock *lockOutcome) error {
+	snapshot := map[string]interface{}{
+		"id":           result.Snapshot.ID,
+		"content_hash": result.Snapshot.ContentHash.Hex(),
+		"effective_at": result.Snapshot.EffectiveAt,
+		"provider":     result.Snapshot.Provider,
+		"region":       result.Snapshot.Region,
+	}
+	if lock != nil {
+		snapshot["pinned"] = lock.pinned
+		snapshot["lock_file"] = lock.path
+	}
+
+	// Convert to JSON-friendly structure. Costs are the displayed
+	// amounts, the figures the HTTP and CI outputs report.
 	output := map[string]interface{}{
-		"snapshot": map[string]interface{}{
-			"id":           result.Snapshot.ID,
-			"content_hash": result.Snapshot.ContentHash.Hex(),
-			"effective_at": result.Snapshot.EffectiveAt,
-			"provider":     result.Snapshot.Provider,
-			"region":       result.Snapshot.Region,
-		},
-		"total_monthly_cost": result.TotalMonthlyCost.StringRaw(),
-		"total_hourly_cost":  result.TotalHourlyCost.StringRaw(),
+		"snapshot":           snapshot,
+		"total_monthly_cost": result.DisplayTotalMonthlyCost().StringRaw(),
+		"total_hourly_cost":  result.DisplayTotalHourlyCost().StringRaw(),
+		"firm_total":         result.FirmTotal.StringRaw(),
+		"estimated_total_including_symbolic": result.EstimatedTotalIncludingSymbolic.StringRaw(),
 		"confidence":         result.Confidence.Score,
 		"instance_count":     result.InstanceCosts.Len(),
 		"estimated_at":       result.EstimatedAt,
 		"duration_ms":        result.Duration.Milliseconds(),
 		"warnings":           result.Warnings,
 		"degraded":           result.Degraded,
+		"cached":             result.Cached,
+	}
+	if result.Workspace != "" {
+		output["workspace"] = result.Workspace
+	}
+	if report := result.CoverageReport; report != nil {
+		output["coverage"] = map[string]interface{}{
+			"numeric_percent":     report.NumericPercent,
+			"symbolic_percent":    report.SymbolicPercent,
+			"indirect_percent":    report.IndirectPercent,
+			"unsupported_percent": report.UnsupportedPercent,
+			"total_resources":     report.TotalInstances,
+		}
+	}
+	if result.IgnoredCount > 0 {
+		output["ignored_count"] = result.IgnoredCount
+		output["ignored_monthly_cost"] = result.IgnoredMonthlyCost.StringRaw()
 	}
 
 	// Add instance costs
@@ -257,28 +555,47 @@ func (a *CLIAdapter) outputJSON(result *engine.EstimationResult) error {
 			}
 		}
 
-		instances[string(id)] = map[string]interface{}{
+		instance := map[string]interface{}{
 			"address":       cost.Address,
 			"definition_id": cost.DefinitionID,
-			"monthly_cost":  cost.MonthlyCost.StringRaw(),
-			"hourly_cost":   cost.HourlyCost.StringRaw(),
+			"monthly_cost":  cost.DisplayMonthlyCost().StringRaw(),
+			"hourly_cost":   cost.DisplayHourlyCost().StringRaw(),
 			"confidence":    cost.Confidence.Score,
+			"symbolic":      cost.Symbolic,
 			"components":    components,
 		}
+		if cost.SkipReason != "" {
+			instance["coverage_type"] = cost.CoverageType.String()
+			instance["skip_reason"] = cost.SkipReason
+		}
+		instances[string(id)] = instance
 		return true
 	})
 	output["instances"] = instances
+	if showAnnual {
+		output["total_annual_cost"] = result.AnnualCost().StringRaw()
+	}
 
 	encoder := json.NewEncoder(a.output)
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(output)
 }
 
-func (a *CLIAdapter) outputMarkdown(result *engine.EstimationResult) error {
+func (a *CLIAdapter) outputMarkdown(result *engine.EstimationResult, showAnnual bool, lock *lockOutcome) error {
 	fmt.Fprintln(a.output, "# Cost Estimation Report")
 	fmt.Fprintln(a.output, "")
-	fmt.Fprintf(a.output, "**Total Monthly Cost:** %s\n", result.TotalMonthlyCost.String())
+	fmt.Fprintf(a.output, "**Total Monthly Cost:** %s\n", result.DisplayTotalMonthlyCost().String())
+	if showAnnual {
+		fmt.Fprintf(a.output, "**Total Annual Cost:** %s\n", result.AnnualCost().String())
+	}
 	fmt.Fprintf(a.output, "**Confidence:** %.0f%%\n", result.Confidence.Score*100)
+	if result.Workspace != "" {
+		fmt.Fprintf(a.output, "**Workspace:** `%s`\n", result.Workspace)
+	}
+	if status := lock.describe(); status != "" {
+		fmt.Fprintf(a.output, "**Pricing Snapshot:** `%s` (content hash `%s`, %s)\n",
+			result.Snapshot.ID, result.Snapshot.ContentHash.Hex(), status)
+	}
 	fmt.Fprintln(a.output, "")
 
 	fmt.Fprintln(a.output, "## Summary")
@@ -286,19 +603,59 @@ func (a *CLIAdapter) outputMarkdown(result *engine.EstimationResult) error {
 	fmt.Fprintln(a.output, "| Instance | Monthly Cost | Confidence |")
 	fmt.Fprintln(a.output, "|----------|-------------|------------|")
 
-	result.InstanceCosts.Range(func(id model.InstanceID, cost *engine.InstanceCost) bool {
-		fmt.Fprintf(a.output, "| `%s` | %s | %.0f%% |\n",
-			cost.Address, cost.MonthlyCost.String(), cost.Confidence.Score*100)
-		return true
-	})
+	for _, cost := range result.SortedInstanceCosts() {
+		if cost.Symbolic {
+			continue
+		}
+		address := fmt.Sprintf("`%s`", cost.Address)
+		if cost.SkipReason != "" {
+			fmt.Fprintf(a.output, "| %s | skipped: %s | - |\n", address, cost.SkipReason)
+			continue
+		}
+		fmt.Fprintf(a.output, "| %s | %s | %.0f%% |\n",
+			address, cost.DisplayMonthlyCost().String(), cost.Confidence.Score*100)
+	}
+	for _, g := range result.PlaceholderGroups() {
+		fmt.Fprintf(a.output, "| `%s` (%s) | %s | %.0f%% |\n",
+			g.Address, placeholderSummary(g), g.MonthlyCost.String(), g.Confidence*100)
+	}
 
 	fmt.Fprintln(a.output, "")
 	fmt.Fprintf(a.output, "| **Total** | **%s** | **%.0f%%** |\n",
-		result.TotalMonthlyCost.String(), result.Confidence.Score*100)
+		result.DisplayTotalMonthlyCost().String(), result.Confidence.Score*100)
+	if hasSymbolicEstimate(result) {
+		fmt.Fprintln(a.output, "")
+		fmt.Fprintf(a.output, "**Firm total:** %s (estimated %s including symbolic placeholders)\n",
+			result.FirmTotal.String(), result.EstimatedTotalIncludingSymbolic.String())
+	}
+	if result.IgnoredCount > 0 {
+		fmt.Fprintln(a.output, "")
+		fmt.Fprintf(a.output, "**Ignored:** %d resources, %s/month, not in the total\n",
+			result.IgnoredCount, result.IgnoredMonthlyCost.String())
+	}
 
 	return nil
 }
 
+// placeholderSummary describes a placeholder group, e.g.
+// "3 placeholders, unknown count, range 0..∞"
+func placeholderSummary(g engine.PlaceholderGroup) string {
+	summary := fmt.Sprintf("%d placeholders", g.Placeholders)
+	if g.Placeholders == 1 {
+		summary = "1 placeholder"
+	}
+	if g.Symbolic == nil {
+		return summary + ", unknown count"
+	}
+	return fmt.Sprintf("%s, unknown %s, range %s", summary, g.Symbolic.Reason, g.Symbolic.Range())
+}
+
+// hasSymbolicEstimate reports whether placeholder instances added cost,
+// so the firm total differs from the estimate including them
+func hasSymbolicEstimate(result *engine.EstimationResult) bool {
+	return result.FirmTotal.Cmp(result.EstimatedTotalIncludingSymbolic) != 0
+}
+
 func truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
@@ -0,0 +1,141 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/catalog"
+	"terraform-cost/core/engine"
+	"terraform-cost/core/model"
+	"terraform-cost/core/pricing"
+	"terraform-cost/core/terraform"
+	"terraform-cost/internal/logging"
+)
+
+// fixedResolver serves one snapshot for every request
+type fixedResolver struct {
+	snapshot *pricing.PricingSnapshot
+}
+
+func (r *fixedResolver) GetSnapshot(ctx context.Context, req engine.SnapshotRequest) (*pricing.PricingSnapshot, error) {
+	if req.SnapshotID != "" && req.SnapshotID != r.snapshot.ID {
+		return nil, fmt.Errorf("snapshot %s not found", req.SnapshotID)
+	}
+	return r.snapshot, nil
+}
+
+func (r *fixedResolver) LookupRate(snapshot *pricing.PricingSnapshot, resourceType, component string, attrs map[string]string) (*pricing.RateEntry, error) {
+	rate, ok := snapshot.LookupRate(resourceType, component, attrs)
+	if !ok {
+		return nil, fmt.Errorf("rate not found")
+	}
+	return rate, nil
+}
+
+type noUsage struct{}
+
+func (noUsage) Estimate(ctx context.Context, inst *model.AssetInstance) (*engine.UsageResult, error) {
+	return &engine.UsageResult{Metrics: map[string]engine.UsageMetric{}, Confidence: 1.0}, nil
+}
+
+// computePlugin maps every aws_instance to a single compute component
+type computePlugin struct{}
+
+func (computePlugin) Provider() string         { return "aws" }
+func (computePlugin) CatalogVersion() string   { return catalog.Version }
+func (computePlugin) SupportedTypes() []string { return []string{"aws_instance"} }
+
+func (computePlugin) MapInstance(inst *model.AssetInstance) ([]engine.CostComponent, error) {
+	return []engine.CostComponent{{Name: "compute", ResourceType: "aws_instance", Unit: "hours"}}, nil
+}
+
+// computeSnapshot prices aws_instance compute at rate per hour
+func computeSnapshot(rate float64) *pricing.PricingSnapshot {
+	return pricing.NewSnapshotBuilder("aws", "us-east-1").
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(rate), "hour", "USD").
+		Build()
+}
+
+// newTestAdapter returns a JSON adapter pricing from snapshot and the
+// buffer it writes to
+func newTestAdapter(snapshot *pricing.PricingSnapshot) (*CLIAdapter, *bytes.Buffer) {
+	eng := engine.NewEngine(&fixedResolver{snapshot: snapshot}, noUsage{}, nil, engine.EngineConfig{HoursPerMonth: 100})
+	eng.SetLogger(logging.Nop())
+	eng.RegisterPlugin(computePlugin{})
+
+	a := NewCLIAdapter(eng, terraform.NewPipeline(terraform.PipelineOptions{}))
+	out := &bytes.Buffer{}
+	a.SetOutput(out)
+	a.SetFormat(FormatJSON)
+	return a, out
+}
+
+// writeProject writes a project of count aws_instances and returns its
+// directory
+func writeProject(t *testing.T, count int) string {
+	t.Helper()
+	dir := t.TempDir()
+	tf := fmt.Sprintf(`resource "aws_instance" "web" {
+  count         = %d
+  instance_type = "t3.micro"
+}
+`, count)
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(tf), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// decodeOutput decodes the JSON estimate written to out
+func decodeOutput(t *testing.T, out *bytes.Buffer) map[string]interface{} {
+	t.Helper()
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	return decoded
+}
+
+// TestResultCacheHit proves a second run on an unchanged plan is served
+// from the cache directory, and --no-cache prices it again
+func TestResultCacheHit(t *testing.T) {
+	dir := writeProject(t, 2)
+	planFile := filepath.Join(dir, "plan.json")
+	if err := os.WriteFile(planFile, []byte(`{"format_version": "1.2", "variables": {}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(req CLIRequest) map[string]interface{} {
+		t.Helper()
+		a, out := newTestAdapter(computeSnapshot(0.1))
+		req.Path, req.PlanFile, req.Provider, req.Region, req.NoLock = dir, planFile, "aws", "us-east-1", true
+		if err := a.Run(context.Background(), &req); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		return decodeOutput(t, out)
+	}
+
+	first := run(CLIRequest{Cache: true})
+	if first["cached"] != false || first["total_monthly_cost"] != "20" {
+		t.Fatalf("first run: cached = %v, total = %v; want a fresh 20", first["cached"], first["total_monthly_cost"])
+	}
+	if _, err := os.Stat(filepath.Join(dir, CacheDirName)); err != nil {
+		t.Fatalf("cache directory not created: %v", err)
+	}
+
+	second := run(CLIRequest{Cache: true})
+	if second["cached"] != true || second["total_monthly_cost"] != "20" {
+		t.Errorf("second run: cached = %v, total = %v; want a cached 20", second["cached"], second["total_monthly_cost"])
+	}
+
+	if skipped := run(CLIRequest{Cache: true, NoCache: true}); skipped["cached"] != false {
+		t.Errorf("--no-cache run was served from the cache")
+	}
+}
@@ -0,0 +1,365 @@
+// Package adapter - Region, workspace and base comparison output
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"terraform-cost/core/cost"
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/diff"
+	"terraform-cost/core/engine"
+	"terraform-cost/core/model"
+	"terraform-cost/core/terraform"
+)
+
+// comparison is a region or workspace comparison, as printed
+type comparison struct {
+	// kind is "region" or "workspace"
+	kind     string
+	columns  []comparisonColumn
+	services []string
+	warnings []string
+}
+
+// comparisonColumn is one compared region or workspace
+type comparisonColumn struct {
+	label     string
+	result    *engine.EstimationResult
+	byService map[string]determinism.Money
+}
+
+// outputComparison prints the comparison in the selected format
+func (a *CLIAdapter) outputComparison(c *engine.RegionComparison) error {
+	columns := make([]comparisonColumn, len(c.Regions))
+	for i, r := range c.Regions {
+		columns[i] = comparisonColumn{label: r.Region, result: r.Result, byService: r.ByService}
+	}
+	return a.printComparison(&comparison{kind: "region", columns: columns, services: c.Services(), warnings: c.Warnings})
+}
+
+// outputWorkspaceComparison prints the comparison in the selected format
+func (a *CLIAdapter) outputWorkspaceComparison(c *engine.WorkspaceComparison) error {
+	columns := make([]comparisonColumn, len(c.Workspaces))
+	for i, w := range c.Workspaces {
+		columns[i] = comparisonColumn{label: w.Workspace, result: w.Result, byService: w.ByService}
+	}
+	return a.printComparison(&comparison{kind: "workspace", columns: columns, services: c.Services(), warnings: c.Warnings})
+}
+
+// compareWorkspaces expands the project once per workspace and prices
+// each graph like base
+func (a *CLIAdapter) compareWorkspaces(ctx context.Context, pipeline *terraform.Pipeline, input *terraform.ScanInput, base *engine.EstimateRequest, workspaces []string) (*engine.WorkspaceComparison, error) {
+	var reqs []*engine.EstimateRequest
+	var warnings []string
+	seen := make(map[string]bool)
+	for _, workspace := range workspaces {
+		if workspace == "" || seen[workspace] {
+			continue
+		}
+		seen[workspace] = true
+
+		workspaceInput := *input
+		workspaceInput.Workspace = workspace
+		expanded, err := pipeline.Execute(ctx, &workspaceInput)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("workspace %s skipped: %v", workspace, err))
+			continue
+		}
+		req := *base
+		req.Graph = expanded.Graph
+		req.CardinalityWarnings = expanded.CardinalityWarnings
+		req.SourceWarnings = expanded.WarningMessages()
+		req.Workspace = expanded.Workspace
+		reqs = append(reqs, &req)
+	}
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("no workspace could be expanded: %s", strings.Join(warnings, "; "))
+	}
+
+	comparison, err := a.engine.CompareWorkspaces(ctx, reqs)
+	if comparison != nil {
+		comparison.Warnings = append(warnings, comparison.Warnings...)
+	}
+	return comparison, err
+}
+
+func (a *CLIAdapter) printComparison(c *comparison) error {
+	switch a.format {
+	case FormatJSON:
+		return a.comparisonJSON(c)
+	case FormatMarkdown:
+		return a.comparisonMarkdown(c)
+	default:
+		return a.comparisonTable(c)
+	}
+}
+
+func (a *CLIAdapter) comparisonTable(c *comparison) error {
+	fmt.Fprintln(a.output, "")
+	fmt.Fprintf(a.output, "%s COMPARISON (monthly)\n", strings.ToUpper(c.kind))
+
+	rule := strings.Repeat("─", 20+14*len(c.columns))
+	fmt.Fprintln(a.output, rule)
+	fmt.Fprintf(a.output, "%-20s", "SERVICE")
+	for _, col := range c.columns {
+		fmt.Fprintf(a.output, " %13s", col.label)
+	}
+	fmt.Fprintln(a.output, "")
+	fmt.Fprintln(a.output, rule)
+
+	for _, service := range c.services {
+		fmt.Fprintf(a.output, "%-20s", truncate(service, 20))
+		for _, col := range c.columns {
+			fmt.Fprintf(a.output, " %13s", serviceCost(col, service))
+		}
+		fmt.Fprintln(a.output, "")
+	}
+
+	fmt.Fprintln(a.output, rule)
+	fmt.Fprintf(a.output, "%-20s", "TOTAL")
+	for _, col := range c.columns {
+		fmt.Fprintf(a.output, " %13s", col.result.DisplayTotalMonthlyCost().String())
+	}
+	fmt.Fprintln(a.output, "")
+	fmt.Fprintf(a.output, "%-20s", "SNAPSHOT")
+	for _, col := range c.columns {
+		fmt.Fprintf(a.output, " %13s", truncate(string(col.result.Snapshot.ID), 13))
+	}
+	fmt.Fprintln(a.output, "")
+	fmt.Fprintln(a.output, "")
+
+	if len(c.warnings) > 0 {
+		fmt.Fprintln(a.output, "WARNINGS")
+		fmt.Fprintln(a.output, "─────────────────────────────────────────────────────────────────────")
+		for _, w := range c.warnings {
+			fmt.Fprintf(a.output, "⚠ %s\n", w)
+		}
+		fmt.Fprintln(a.output, "")
+	}
+	return nil
+}
+
+func (a *CLIAdapter) comparisonJSON(c *comparison) error {
+	columns := make([]map[string]interface{}, len(c.columns))
+	for i, col := range c.columns {
+		services := make(map[string]string, len(col.byService))
+		for service, cost := range col.byService {
+			services[service] = cost.StringRaw()
+		}
+		columns[i] = map[string]interface{}{
+			c.kind:               col.label,
+			"snapshot_id":        col.result.Snapshot.ID,
+			"total_monthly_cost": col.result.TotalMonthlyCost.StringRaw(),
+			"total_hourly_cost":  col.result.TotalHourlyCost.StringRaw(),
+			"confidence":         col.result.Confidence.Score,
+			"by_service":         services,
+			"warnings":           col.result.Warnings,
+		}
+	}
+
+	encoder := json.NewEncoder(a.output)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(map[string]interface{}{
+		c.kind + "s": columns,
+		"warnings":   c.warnings,
+	})
+}
+
+func (a *CLIAdapter) comparisonMarkdown(c *comparison) error {
+	fmt.Fprintf(a.output, "# %s%s Comparison\n", strings.ToUpper(c.kind[:1]), c.kind[1:])
+	fmt.Fprintln(a.output, "")
+
+	header := "| Service |"
+	divider := "|---------|"
+	for _, col := range c.columns {
+		header += " " + col.label + " |"
+		divider += "------|"
+	}
+	fmt.Fprintln(a.output, header)
+	fmt.Fprintln(a.output, divider)
+
+	for _, service := range c.services {
+		row := "| " + service + " |"
+		for _, col := range c.columns {
+			row += " " + serviceCost(col, service) + " |"
+		}
+		fmt.Fprintln(a.output, row)
+	}
+	row := "| **Total** |"
+	for _, col := range c.columns {
+		row += " **" + col.result.DisplayTotalMonthlyCost().String() + "** |"
+	}
+	fmt.Fprintln(a.output, row)
+
+	if len(c.warnings) > 0 {
+		fmt.Fprintln(a.output, "")
+		for _, w := range c.warnings {
+			fmt.Fprintf(a.output, "> ⚠ %s\n", w)
+		}
+	}
+	return nil
+}
+
+// serviceCost renders a column's cost for a service, "-" when it has none
+func serviceCost(col comparisonColumn, service string) string {
+	cost, ok := col.byService[service]
+	if !ok {
+		return "-"
+	}
+	return cost.String()
+}
+
+// diffAgainst expands the base project, prices it like head and diffs the
+// two instance by instance
+func (a *CLIAdapter) diffAgainst(ctx context.Context, pipeline *terraform.Pipeline, input *terraform.ScanInput, head *engine.EstimateRequest, basePath string, matchByAttributes bool) (*diff.DiffResult, error) {
+	baseInput := *input
+	baseInput.RootPath = basePath
+	expanded, err := pipeline.Execute(ctx, &baseInput)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan terraform: %w", err)
+	}
+	baseReq := *head
+	baseReq.Graph = expanded.Graph
+	baseReq.CardinalityWarnings = expanded.CardinalityWarnings
+	baseReq.SourceWarnings = expanded.WarningMessages()
+	baseReq.Workspace = expanded.Workspace
+
+	before, err := a.engine.Estimate(ctx, &baseReq)
+	if err != nil {
+		return nil, err
+	}
+	after, err := a.engine.Estimate(ctx, head)
+	if err != nil {
+		return nil, err
+	}
+	differ := diff.NewDiffer(0).WithAttributeMatching(matchByAttributes)
+	return differ.Diff(aggregatedCosts(before), aggregatedCosts(after)), nil
+}
+
+// aggregatedCosts converts an estimate to the instance costs core/diff
+// compares
+func aggregatedCosts(result *engine.EstimationResult) *cost.AggregatedCostResult {
+	aggregated := cost.NewAggregatedCostResult(result.Snapshot.ID)
+	result.InstanceCosts.Range(func(_ model.InstanceID, ic *engine.InstanceCost) bool {
+		canonical, err := model.ParseAddress(string(ic.Address))
+		if err != nil {
+			canonical = model.CanonicalAddress(ic.Address)
+		}
+		identity := &model.InstanceIdentity{
+			Canonical:    canonical,
+			ID:           ic.InstanceID,
+			ResourceType: ic.ResourceType,
+			DefinitionID: ic.DefinitionID,
+		}
+		inst := cost.NewInstanceCostResult(identity, result.Snapshot.ID)
+		inst.Total = &cost.ConfidenceBoundCost{
+			Monthly:    ic.MonthlyCost,
+			Hourly:     ic.HourlyCost,
+			Confidence: ic.Confidence.Score,
+			SnapshotID: result.Snapshot.ID,
+		}
+		for _, c := range ic.Components {
+			price, ok := c.Formula.Inputs["rate"]
+			if !ok {
+				price = c.MonthlyCost.StringRaw()
+			}
+			inst.Components = append(inst.Components, &cost.CostWithProvenance{
+				Cost: &cost.ConfidenceBoundCost{
+					Monthly:    c.MonthlyCost,
+					Hourly:     c.HourlyCost,
+					Confidence: c.Confidence,
+					SnapshotID: result.Snapshot.ID,
+				},
+				Identity:  identity,
+				Component: c.Name,
+				Rate:      &cost.RateProvenance{RateID: c.RateID, RateKey: c.RateKey, Price: price, WasFound: !c.IsSymbolic},
+				Usage:     &cost.UsageProvenance{Value: c.UsageValue, Unit: c.UsageUnit},
+			})
+		}
+		aggregated.Add(inst)
+		return true
+	})
+	return aggregated
+}
+
+// outputDiff prints a diff against a base project in the selected format
+func (a *CLIAdapter) outputDiff(d *diff.DiffResult) error {
+	sections := []struct {
+		mark  string
+		diffs []*diff.InstanceDiff
+	}{
+		{"+", d.Added},
+		{"-", d.Removed},
+		{"~", d.Changed},
+		{">", d.Moved},
+	}
+
+	switch a.format {
+	case FormatJSON:
+		changes := []map[string]interface{}{}
+		for _, section := range sections {
+			for _, c := range section.diffs {
+				change := map[string]interface{}{
+					"address": c.Address,
+					"change":  c.ChangeType.String(),
+					"delta":   c.Delta.StringRaw(),
+				}
+				if c.PreviousAddress != "" {
+					change["previous_address"] = c.PreviousAddress
+				}
+				changes = append(changes, change)
+			}
+		}
+		encoder := json.NewEncoder(a.output)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(map[string]interface{}{
+			"total_before": d.TotalBefore.StringRaw(),
+			"total_after":  d.TotalAfter.StringRaw(),
+			"total_delta":  d.TotalDelta.StringRaw(),
+			"added":        d.AddedCount,
+			"removed":      d.RemovedCount,
+			"changed":      d.ChangedCount,
+			"moved":        d.MovedCount,
+			"changes":      changes,
+		})
+	case FormatMarkdown:
+		fmt.Fprintln(a.output, "# Cost Diff")
+		fmt.Fprintln(a.output, "")
+		fmt.Fprintf(a.output, "**Monthly:** %s → %s (%s)\n", d.TotalBefore.String(), d.TotalAfter.String(), d.TotalDelta.String())
+		fmt.Fprintln(a.output, "")
+		fmt.Fprintln(a.output, "| | Resource | Delta |")
+		fmt.Fprintln(a.output, "|---|---|---|")
+		for _, section := range sections {
+			for _, c := range section.diffs {
+				fmt.Fprintf(a.output, "| %s | `%s` | %s |\n", section.mark, diffLabel(c), c.Delta.String())
+			}
+		}
+		return nil
+	default:
+		fmt.Fprintln(a.output, "")
+		fmt.Fprintln(a.output, "COST DIFF (monthly)")
+		fmt.Fprintln(a.output, "─────────────────────────────────────────────────────────────────────")
+		fmt.Fprintf(a.output, "Before: %s\n", d.TotalBefore.String())
+		fmt.Fprintf(a.output, "After:  %s\n", d.TotalAfter.String())
+		fmt.Fprintf(a.output, "Delta:  %s\n", d.TotalDelta.String())
+		fmt.Fprintf(a.output, "%d added, %d removed, %d changed, %d moved\n", d.AddedCount, d.RemovedCount, d.ChangedCount, d.MovedCount)
+		fmt.Fprintln(a.output, "")
+		for _, section := range sections {
+			for _, c := range section.diffs {
+				fmt.Fprintf(a.output, "%s %-55s %13s\n", section.mark, truncate(diffLabel(c), 55), c.Delta.String())
+			}
+		}
+		return nil
+	}
+}
+
+// diffLabel is an instance's address, with where it moved from
+func diffLabel(c *diff.InstanceDiff) string {
+	if c.PreviousAddress != "" {
+		return fmt.Sprintf("%s (from %s)", c.Address, c.PreviousAddress)
+	}
+	return string(c.Address)
+}
@@ -0,0 +1,61 @@
+package adapter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeRenamedProject writes writeProject's single aws_instance under
+// another name and returns its directory
+func writeRenamedProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	tf := `resource "aws_instance" "app" {
+  instance_type = "t3.micro"
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(tf), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// TestCompareToMatchByAttributes proves a renamed resource is reported as
+// moved with --match-by-attributes, and as removed and added
+// without it
+func TestCompareToMatchByAttributes(t *testing.T) {
+	base, head := writeProject(t, 1), writeRenamedProject(t)
+
+	run := func(match bool) map[string]interface{} {
+		t.Helper()
+		a, out := newTestAdapter(computeSnapshot(0.1))
+		req := CLIRequest{Path: head, Provider: "aws", Region: "us-east-1", NoLock: true, CompareTo: base, MatchByAttributes: match}
+		if err := a.Run(context.Background(), &req); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		return decodeOutput(t, out)
+	}
+
+	matched := run(true)
+	if matched["moved"] != float64(1) || matched["added"] != float64(0) || matched["removed"] != float64(0) {
+		t.Fatalf("with matching: moved=%v added=%v removed=%v, want 1 moved", matched["moved"], matched["added"], matched["removed"])
+	}
+	if matched["total_delta"] != "0" {
+		t.Errorf("with matching: total delta = %v, want 0", matched["total_delta"])
+	}
+	changes, _ := matched["changes"].([]interface{})
+	if len(changes) != 1 {
+		t.Fatalf("with matching: %d changes, want 1", len(changes))
+	}
+	change, _ := changes[0].(map[string]interface{})
+	if change["change"] != "moved" || change["delta"] != "0" || change["previous_address"] == nil {
+		t.Errorf("with matching: change = %v, want a zero-delta move", change)
+	}
+
+	unmatched := run(false)
+	if unmatched["moved"] != float64(0) || unmatched["added"] != float64(1) || unmatched["removed"] != float64(1) {
+		t.Errorf("without matching: moved=%v added=%v removed=%v, want 1 added and 1 removed", unmatched["moved"], unmatched["added"], unmatched["removed"])
+	}
+}
@@ -0,0 +1,134 @@
+// Package adapter - Snapshot lockfile
+// Pricing is re-ingested over time, so the same plan estimated next week
+// can cost differently. .terraform-cost.lock in the project directory pins
+// the snapshot each provider/region/alias was priced from: the first run
+// writes it, later runs price from the pinned snapshot and fail if it is
+// gone, until --update-lock re-pins the latest one. Only the request's
+// primary snapshot is pinned; instances in other regions use those
+// regions' active snapshots.
+package adapter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"terraform-cost/core/engine"
+	"terraform-cost/internal/atomicfile"
+)
+
+// LockFileName is the lockfile in the project directory
+const LockFileName = ".terraform-cost.lock"
+
+// LockFileVersion is the lockfile format version
+const LockFileVersion = 1
+
+// LockFile pins snapshots for reproducible estimates
+type LockFile struct {
+	Version   int              `json:"version"`
+	Snapshots []LockedSnapshot `json:"snapshots"`
+}
+
+// LockedSnapshot pins the snapshot for one provider/region/alias
+type LockedSnapshot struct {
+	Provider    string `json:"provider"`
+	Region      string `json:"region"`
+	Alias       string `json:"alias"`
+	SnapshotID  string `json:"snapshot_id"`
+	ContentHash string `json:"content_hash"`
+}
+
+// LoadLockFile reads a lockfile; a missing file is an empty lockfile
+func LoadLockFile(path string) (*LockFile, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &LockFile{Version: LockFileVersion}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+
+	var lock LockFile
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("invalid lockfile %s: %w", path, err)
+	}
+	if lock.Version != LockFileVersion {
+		return nil, fmt.Errorf("lockfile %s has version %d, want %d", path, lock.Version, LockFileVersion)
+	}
+	return &lock, nil
+}
+
+// Find returns the pin for a provider/region/alias, or nil
+func (l *LockFile) Find(provider, region, alias string) *LockedSnapshot {
+	for i := range l.Snapshots {
+		s := &l.Snapshots[i]
+		if s.Provider == provider && s.Region == region && s.Alias == alias {
+			return s
+		}
+	}
+	return nil
+}
+
+// Pin records the snapshot an estimate used, replacing any earlier pin
+// for its provider/region/alias
+func (l *LockFile) Pin(ref *engine.SnapshotReference) {
+	pin := LockedSnapshot{
+		Provider:    ref.Provider,
+		Region:      ref.Region,
+		Alias:       ref.Alias,
+		SnapshotID:  string(ref.ID),
+		ContentHash: ref.ContentHash.Hex(),
+	}
+	if s := l.Find(pin.Provider, pin.Region, pin.Alias); s != nil {
+		*s = pin
+		return
+	}
+	l.Snapshots = append(l.Snapshots, pin)
+	sort.Slice(l.Snapshots, func(i, j int) bool {
+		a, b := l.Snapshots[i], l.Snapshots[j]
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		return a.Alias < b.Alias
+	})
+}
+
+// Save writes the lockfile atomically
+func (l *LockFile) Save(path string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, append(data, '\n'))
+}
+
+// lockOutcome is how the lockfile took part in a run
+type lockOutcome struct {
+	path    string
+	pinned  bool // priced from the pinned snapshot
+	written bool // the pin was created or updated
+}
+
+// lockPath is the lockfile of a project directory
+func lockPath(projectPath string) string {
+	return filepath.Join(projectPath, LockFileName)
+}
+
+// describe is the lockfile status line shown with the snapshot
+func (o *lockOutcome) describe() string {
+	switch {
+	case o == nil:
+		return ""
+	case o.pinned:
+		return "pinned by " + o.path
+	case o.written:
+		return "pinned to " + o.path
+	}
+	return ""
+}
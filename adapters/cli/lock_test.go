@@ -0,0 +1,163 @@
+package adapter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"terraform-cost/core/engine"
+	"terraform-cost/core/pricing"
+)
+
+func TestLoadLockFile(t *testing.T) {
+	dir := t.TempDir()
+
+	lock, err := LoadLockFile(filepath.Join(dir, "missing.lock"))
+	if err != nil || lock.Version != LockFileVersion || len(lock.Snapshots) != 0 {
+		t.Fatalf("missing lockfile = %+v, %v; want an empty lockfile", lock, err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"invalid json", `{"version": 1,`, "invalid lockfile"},
+		{"wrong version", `{"version": 2, "snapshots": []}`, "has version 2, want 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".lock")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadLockFile(path); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestLockFilePinAndSave proves pins are replaced per provider/region/alias,
+// kept sorted, and survive a save and load
+func TestLockFilePinAndSave(t *testing.T) {
+	east, west := computeSnapshot(0.1), computeSnapshot(0.2)
+	ref := func(snapshot *pricing.PricingSnapshot, region, alias string) *engine.SnapshotReference {
+		return &engine.SnapshotReference{ID: snapshot.ID, ContentHash: snapshot.ContentHash, Provider: "aws", Region: region, Alias: alias}
+	}
+
+	lock := &LockFile{Version: LockFileVersion}
+	lock.Pin(ref(east, "us-west-2", "default"))
+	lock.Pin(ref(east, "us-east-1", "default"))
+	lock.Pin(ref(east, "us-east-1", "billing"))
+	lock.Pin(ref(west, "us-west-2", "default"))
+
+	var order []string
+	for _, s := range lock.Snapshots {
+		order = append(order, s.Region+"/"+s.Alias)
+	}
+	if got := strings.Join(order, ","); got != "us-east-1/billing,us-east-1/default,us-west-2/default" {
+		t.Errorf("pins = %s, want one per provider/region/alias, sorted", got)
+	}
+	if pin := lock.Find("aws", "us-west-2", "default"); pin == nil || pin.SnapshotID != string(west.ID) || pin.ContentHash != west.ContentHash.Hex() {
+		t.Errorf("us-west-2 pin = %+v, want the re-pinned snapshot %s", pin, west.ID)
+	}
+	if lock.Find("aws", "eu-west-1", "default") != nil {
+		t.Error("found a pin for a region never pinned")
+	}
+
+	path := filepath.Join(t.TempDir(), LockFileName)
+	if err := lock.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadLockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Snapshots) != len(lock.Snapshots) {
+		t.Fatalf("loaded %d pins, want %d", len(loaded.Snapshots), len(lock.Snapshots))
+	}
+	for i := range lock.Snapshots {
+		if loaded.Snapshots[i] != lock.Snapshots[i] {
+			t.Errorf("pin %d = %+v, want %+v", i, loaded.Snapshots[i], lock.Snapshots[i])
+		}
+	}
+}
+
+// TestLockFileRuns proves the first run pins its snapshot, later runs price
+// from the pin and fail when it is gone or changed, and --update-lock
+// re-pins the latest snapshot
+func TestLockFileRuns(t *testing.T) {
+	dir := writeProject(t, 1)
+	run := func(snapshot *pricing.PricingSnapshot, req CLIRequest) (map[string]interface{}, error) {
+		t.Helper()
+		a, out := newTestAdapter(snapshot)
+		req.Path, req.Provider, req.Region = dir, "aws", "us-east-1"
+		if err := a.Run(context.Background(), &req); err != nil {
+			return nil, err
+		}
+		return decodeOutput(t, out), nil
+	}
+	snapshotOf := func(out map[string]interface{}) map[string]interface{} {
+		snapshot, _ := out["snapshot"].(map[string]interface{})
+		return snapshot
+	}
+	pinned := func() *LockedSnapshot {
+		t.Helper()
+		lock, err := LoadLockFile(lockPath(dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return lock.Find("aws", "us-east-1", engine.DefaultProviderAlias)
+	}
+
+	original, repriced := computeSnapshot(0.1), computeSnapshot(0.2)
+
+	out, err := run(original, CLIRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshotOf(out)["pinned"] != false {
+		t.Errorf("first run reported pinned, want a new pin")
+	}
+	if pin := pinned(); pin == nil || pin.SnapshotID != string(original.ID) {
+		t.Fatalf("pin after first run = %+v, want %s", pin, original.ID)
+	}
+
+	if out, err = run(original, CLIRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if snapshotOf(out)["pinned"] != true {
+		t.Errorf("second run not priced from the pin")
+	}
+
+	// The pinned snapshot is gone once pricing is re-ingested
+	if _, err = run(repriced, CLIRequest{}); err == nil || !strings.Contains(err.Error(), "is not available (run with --update-lock") {
+		t.Errorf("error = %v, want the pinned snapshot reported unavailable", err)
+	}
+	if _, err = run(repriced, CLIRequest{NoLock: true}); err != nil {
+		t.Errorf("--no-lock run: %v", err)
+	}
+	if pin := pinned(); pin.SnapshotID != string(original.ID) {
+		t.Errorf("--no-lock run re-pinned %s", pin.SnapshotID)
+	}
+
+	if _, err = run(repriced, CLIRequest{UpdateLock: true}); err != nil {
+		t.Fatal(err)
+	}
+	if pin := pinned(); pin.SnapshotID != string(repriced.ID) {
+		t.Fatalf("pin after --update-lock = %s, want %s", pin.SnapshotID, repriced.ID)
+	}
+
+	// A pin whose content hash no longer matches the snapshot is refused
+	lock, _ := LoadLockFile(lockPath(dir))
+	lock.Find("aws", "us-east-1", engine.DefaultProviderAlias).ContentHash = original.ContentHash.Hex()
+	if err := lock.Save(lockPath(dir)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = run(repriced, CLIRequest{}); err == nil || !strings.Contains(err.Error(), "but "+LockFileName+" expects") {
+		t.Errorf("error = %v, want a content hash mismatch", err)
+	}
+}
@@ -0,0 +1,49 @@
+// Package adapter - Progress bar
+package adapter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"terraform-cost/core/engine"
+)
+
+// progressWidth is the bar width in cells
+const progressWidth = 30
+
+// SetProgress renders estimation progress to w (usually stderr when it is
+// a terminal); nil disables it
+func (a *CLIAdapter) SetProgress(w io.Writer) {
+	a.progress = w
+}
+
+// progressReporter returns a reporter that redraws one line on the
+// progress writer, or nil when progress is disabled
+func (a *CLIAdapter) progressReporter() engine.ProgressReporter {
+	if a.progress == nil {
+		return nil
+	}
+	w := a.progress
+	return func(phase string, completed, total int, _ string) {
+		fmt.Fprintf(w, "\r%s", progressLine(phase, completed, total))
+		if completed >= total {
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// progressLine renders "phase [████░░░░] completed/total (pct%)"
+func progressLine(phase string, completed, total int) string {
+	percent := 100.0
+	if total > 0 {
+		percent = float64(completed) / float64(total) * 100
+	}
+	filled := int(percent / 100 * progressWidth)
+	if filled > progressWidth {
+		filled = progressWidth
+	}
+	return fmt.Sprintf("%-10s [%s%s] %d/%d (%.0f%%)", phase,
+		strings.Repeat("█", filled), strings.Repeat("░", progressWidth-filled),
+		completed, total, percent)
+}
@@ -0,0 +1,285 @@
+// Package consistency checks the CLI, HTTP and CI adapters are thin
+// wrappers over the same engine: one project priced through each reports
+// the same totals, per-resource costs and coverage.
+package consistency
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	ci "terraform-cost/adapters/ci"
+	cli "terraform-cost/adapters/cli"
+	httpadapter "terraform-cost/adapters/http"
+	"terraform-cost/core/catalog"
+	"terraform-cost/core/engine"
+	"terraform-cost/core/model"
+	"terraform-cost/core/pricing"
+	"terraform-cost/core/terraform"
+	"terraform-cost/internal/logging"
+)
+
+type fixedResolver struct {
+	snapshot *pricing.PricingSnapshot
+}
+
+func (r *fixedResolver) GetSnapshot(ctx context.Context, req engine.SnapshotRequest) (*pricing.PricingSnapshot, error) {
+	return r.snapshot, nil
+}
+
+func (r *fixedResolver) LookupRate(snapshot *pricing.PricingSnapshot, resourceType, component string, attrs map[string]string) (*pricing.RateEntry, error) {
+	rate, ok := snapshot.LookupRate(resourceType, component, attrs)
+	if !ok {
+		return nil, fmt.Errorf("rate not found")
+	}
+	return rate, nil
+}
+
+// bucketUsage leaves bucket requests unknown, so buckets are symbolic
+type bucketUsage struct{}
+
+func (bucketUsage) Estimate(ctx context.Context, inst *model.AssetInstance) (*engine.UsageResult, error) {
+	metrics := map[string]engine.UsageMetric{}
+	if engine.ResourceTypeFromAddress(inst.Address) == "aws_s3_bucket" {
+		metrics["requests"] = engine.UsageMetric{Name: "requests", IsUnknown: true}
+	}
+	return &engine.UsageResult{Metrics: metrics, Confidence: 1.0}, nil
+}
+
+// fixturePlugin prices instances by type and buckets by request; queues
+// are not supported
+type fixturePlugin struct{}
+
+func (fixturePlugin) Provider() string { return "aws" }
+
+func (fixturePlugin) CatalogVersion() string { return catalog.Version }
+
+func (fixturePlugin) SupportedTypes() []string { return []string{"aws_instance", "aws_s3_bucket"} }
+
+func (fixturePlugin) MapInstance(inst *model.AssetInstance) ([]engine.CostComponent, error) {
+	switch resourceType := engine.ResourceTypeFromAddress(inst.Address); resourceType {
+	case "aws_instance":
+	case "aws_s3_bucket":
+		return []engine.CostComponent{{Name: "requests", ResourceType: "aws_s3_bucket", Unit: "requests"}}, nil
+	default:
+		return nil, fmt.Errorf("%s is not supported", resourceType)
+	}
+	instanceType, _, _ := inst.GetAttribute("instance_type")
+	return []engine.CostComponent{{
+		Name:         "compute",
+		ResourceType: "aws_instance",
+		Unit:         "hours",
+		Attributes:   map[string]string{"instance_type": fmt.Sprint(instanceType)},
+	}}, nil
+}
+
+func newFixtureEngine() *engine.Engine {
+	rate := func(resourceType, component, attrs string, price float64) (pricing.RateKey, decimal.Decimal) {
+		return pricing.RateKey{ResourceType: resourceType, Component: component, Attributes: attrs}, decimal.NewFromFloat(price)
+	}
+	builder := pricing.NewSnapshotBuilder("aws", "us-east-1")
+	for _, r := range []struct {
+		resourceType, component, attrs string
+		price                          float64
+		unit                           string
+	}{
+		// Prices with fractions of a cent a month, so rounding shows
+		{"aws_instance", "compute", "instance_type=t3.micro", 0.0104, "hour"},
+		{"aws_instance", "compute", "instance_type=t3.large", 0.08327, "hour"},
+		{"aws_s3_bucket", "requests", "", 0.0000004, "requests"},
+	} {
+		key, price := rate(r.resourceType, r.component, r.attrs, r.price)
+		builder.AddRate(key, price, r.unit, "USD")
+	}
+
+	eng := engine.NewEngine(&fixedResolver{snapshot: builder.Build()}, bucketUsage{}, nil, engine.EngineConfig{})
+	eng.SetLogger(logging.Nop())
+	eng.RegisterPlugin(fixturePlugin{})
+	return eng
+}
+
+// estimate is what every adapter reports for the fixture
+type estimate struct {
+	total     decimal.Decimal
+	resources map[string]decimal.Decimal
+	coverage  map[string]float64
+}
+
+func (e estimate) addresses() []string {
+	out := make([]string, 0, len(e.resources))
+	for address := range e.resources {
+		out = append(out, address)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func coverage(numeric, symbolic, indirect, unsupported float64) map[string]float64 {
+	return map[string]float64{"numeric": numeric, "symbolic": symbolic, "indirect": indirect, "unsupported": unsupported}
+}
+
+// parseMoney reads an amount rendered with or without its currency
+func parseMoney(s string) decimal.Decimal {
+	return decimal.RequireFromString(strings.Fields(s)[0])
+}
+
+func fixturePath(t *testing.T) string {
+	t.Helper()
+	path, err := filepath.Abs(filepath.Join("testdata", "project"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func estimateCLI(t *testing.T, path string) estimate {
+	t.Helper()
+	var out bytes.Buffer
+	a := cli.NewCLIAdapter(newFixtureEngine(), terraform.NewPipeline(terraform.PipelineOptions{}))
+	a.SetOutput(&out)
+	a.SetFormat(cli.FormatJSON)
+	if err := a.Run(context.Background(), &cli.CLIRequest{
+		Path: path, Provider: "aws", Region: "us-east-1", NoLock: true, NoCache: true,
+	}); err != nil {
+		t.Fatalf("CLI: %v", err)
+	}
+
+	var resp struct {
+		TotalMonthlyCost string `json:"total_monthly_cost"`
+		Coverage         struct {
+			Numeric     float64 `json:"numeric_percent"`
+			Symbolic    float64 `json:"symbolic_percent"`
+			Indirect    float64 `json:"indirect_percent"`
+			Unsupported float64 `json:"unsupported_percent"`
+		} `json:"coverage"`
+		Instances map[string]struct {
+			Address     string `json:"address"`
+			MonthlyCost string `json:"monthly_cost"`
+		} `json:"instances"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		t.Fatalf("CLI output: %v\n%s", err, out.String())
+	}
+	e := estimate{
+		total:     parseMoney(resp.TotalMonthlyCost),
+		resources: map[string]decimal.Decimal{},
+		coverage:  coverage(resp.Coverage.Numeric, resp.Coverage.Symbolic, resp.Coverage.Indirect, resp.Coverage.Unsupported),
+	}
+	for _, inst := range resp.Instances {
+		e.resources[inst.Address] = parseMoney(inst.MonthlyCost)
+	}
+	// The CLI's JSON keys instances by ID, so it has no order to compare
+	return e
+}
+
+func estimateHTTP(t *testing.T, path string) (estimate, []string) {
+	t.Helper()
+	a := httpadapter.New(newFixtureEngine(), terraform.NewPipeline(terraform.PipelineOptions{}), nil)
+	a.SetLogger(nil)
+	body, _ := json.Marshal(map[string]interface{}{"provider": "aws", "region": "us-east-1", "hcl_path": path})
+	rec := httptest.NewRecorder()
+	a.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/estimate", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("HTTP: status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp httpadapter.EstimateResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	e := estimate{
+		total:     parseMoney(resp.TotalMonthlyCost),
+		resources: map[string]decimal.Decimal{},
+		coverage: coverage(resp.Coverage.NumericPercent, resp.Coverage.SymbolicPercent,
+			resp.Coverage.IndirectPercent, resp.Coverage.UnsupportedPercent),
+	}
+	var order []string
+	for _, r := range resp.Resources {
+		e.resources[r.Address] = parseMoney(r.MonthlyCost)
+		order = append(order, r.Address)
+	}
+	return e, order
+}
+
+func estimateCI(t *testing.T, path string) (estimate, []string) {
+	t.Helper()
+	config := ci.DefaultCIConfig()
+	config.OutputFormat = ci.FormatJSON
+	a := ci.NewCIAdapter(newFixtureEngine(), terraform.NewPipeline(terraform.PipelineOptions{}), config)
+	a.SetOutput(&bytes.Buffer{})
+	a.SetLogger(logging.Nop())
+	result, err := a.Run(context.Background(), &ci.CIRequest{Path: path, Provider: "aws", Region: "us-east-1"})
+	if err != nil {
+		t.Fatalf("CI: %v", err)
+	}
+
+	e := estimate{
+		total:     decimal.NewFromFloat(result.TotalCost),
+		resources: map[string]decimal.Decimal{},
+		coverage: coverage(result.Coverage.NumericPercent, result.Coverage.SymbolicPercent,
+			result.Coverage.IndirectPercent, result.Coverage.UnsupportedPercent),
+	}
+	var order []string
+	for _, r := range result.Resources {
+		e.resources[r.Address] = decimal.NewFromFloat(r.MonthlyCost)
+		order = append(order, r.Address)
+	}
+	return e, order
+}
+
+// TestAdaptersAgree prices the fixture through the CLI, HTTP and CI
+// adapters and checks they report the same total, per-resource costs
+// and coverage, and list resources in the same order
+func TestAdaptersAgree(t *testing.T) {
+	path := fixturePath(t)
+	cliEstimate := estimateCLI(t, path)
+	httpEstimate, httpOrder := estimateHTTP(t, path)
+	ciEstimate, ciOrder := estimateCI(t, path)
+
+	// The fixture's total is the sum of what its resources display
+	sum := decimal.Zero
+	for _, cost := range httpEstimate.resources {
+		sum = sum.Add(cost)
+	}
+	if !sum.Equal(httpEstimate.total) || httpEstimate.total.IsZero() {
+		t.Fatalf("HTTP total %s, resources sum to %s", httpEstimate.total, sum)
+	}
+
+	for _, other := range []struct {
+		name string
+		e    estimate
+	}{{"CLI", cliEstimate}, {"CI", ciEstimate}} {
+		if !other.e.total.Equal(httpEstimate.total) {
+			t.Errorf("%s total = %s, HTTP total = %s", other.name, other.e.total, httpEstimate.total)
+		}
+		if got, want := strings.Join(other.e.addresses(), ", "), strings.Join(httpEstimate.addresses(), ", "); got != want {
+			t.Errorf("%s resources = %s\nHTTP resources = %s", other.name, got, want)
+		}
+		for address, cost := range httpEstimate.resources {
+			if got, ok := other.e.resources[address]; ok && !got.Equal(cost) {
+				t.Errorf("%s: %s costs %s, HTTP says %s", other.name, address, got, cost)
+			}
+		}
+		for kind, percent := range httpEstimate.coverage {
+			if got := other.e.coverage[kind]; got != percent {
+				t.Errorf("%s %s coverage = %v%%, HTTP says %v%%", other.name, kind, got, percent)
+			}
+		}
+	}
+	if httpEstimate.coverage["numeric"] == 0 || httpEstimate.coverage["unsupported"] == 0 || httpEstimate.coverage["symbolic"] == 0 {
+		t.Errorf("fixture should cover numeric, symbolic and unsupported resources, got %v", httpEstimate.coverage)
+	}
+
+	if got, want := strings.Join(ciOrder, ", "), strings.Join(httpOrder, ", "); got != want {
+		t.Errorf("CI order = %s\nHTTP order = %s", got, want)
+	}
+}
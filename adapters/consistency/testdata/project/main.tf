@@ -0,0 +1,27 @@
+# Fixture priced through the CLI, HTTP and CI adapters
+
+variable "web_count" {
+  default = 2
+}
+
+resource "aws_instance" "web" {
+  count         = var.web_count
+  instance_type = "t3.micro"
+}
+
+resource "aws_instance" "api" {
+  instance_type = "t3.large"
+}
+
+resource "aws_s3_bucket" "assets" {
+  bucket = "assets"
+}
+
+resource "aws_sqs_queue" "jobs" {
+  name = "jobs"
+}
+
+resource "aws_instance" "workers" {
+  for_each      = toset(data.aws_subnets.private.ids)
+  instance_type = "t3.micro"
+}
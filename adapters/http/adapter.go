@@ -4,17 +4,23 @@ package http
 
 import (
 	"context"
+	"crypto/ed25519"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"terraform-cost/core/determinism"
 	"terraform-cost/core/engine"
 	"terraform-cost/core/model"
+	"terraform-cost/core/policy"
 	"terraform-cost/core/pricing"
 	"terraform-cost/core/terraform"
+	"terraform-cost/internal/attestation"
+	"terraform-cost/internal/logging"
 )
 
 // Config holds HTTP adapter configuration
@@ -27,9 +33,17 @@ type Config struct {
 	
 	// WriteTimeout for responses
 	WriteTimeout time.Duration `json:"write_timeout"`
+
+	// EstimateTimeout bounds one estimate, answering 504 when it passes
+	// (0 = no deadline); keep it below WriteTimeout
+	EstimateTimeout time.Duration `json:"estimate_timeout"`
 	
 	// MaxBodySize limits request body size
 	MaxBodySize int64 `json:"max_body_size"`
+
+	// MaxDecompressedSize limits a gzip-encoded body once decompressed
+	// (0 = MaxBodySize); larger bodies are rejected with 413
+	MaxDecompressedSize int64 `json:"max_decompressed_size"`
 	
 	// EnableCORS enables CORS headers
 	EnableCORS bool `json:"enable_cors"`
@@ -42,19 +56,53 @@ type Config struct {
 	
 	// EnableMetrics enables Prometheus metrics
 	EnableMetrics bool `json:"enable_metrics"`
+	
+	// Warmup lists snapshots to load before reporting ready
+	Warmup []WarmupTarget `json:"warmup,omitempty"`
+
+	// ReadySnapshots must each have an active snapshot for /ready to
+	// report ready; they are checked on every probe
+	ReadySnapshots []WarmupTarget `json:"ready_snapshots,omitempty"`
+
+	// PolicyFile is loaded into Policies on Start when Policies is nil
+	PolicyFile string `json:"policy_file,omitempty"`
+
+	// Policies are evaluated on every non-streamed estimate
+	Policies *policy.PolicyFile `json:"-"`
+
+	// MaxBatchSize limits the plans in one batch estimate (0 = 100)
+	MaxBatchSize int `json:"max_batch_size,omitempty"`
+
+	// BatchConcurrency is how many plans of a batch are estimated at
+	// once (0 = 4)
+	BatchConcurrency int `json:"batch_concurrency,omitempty"`
+
+	// SigningKeyFile is loaded into SigningKey on Start when SigningKey
+	// is nil
+	SigningKeyFile string `json:"signing_key_file,omitempty"`
+
+	// SigningKey signs estimate responses (see handleVerify)
+	SigningKey ed25519.PrivateKey `json:"-"`
+
+	// TrustedKeys are the public keys (base64 or PEM) /api/v1/verify
+	// accepts besides SigningKey's; with neither, every signer is
+	// untrusted
+	TrustedKeys []string `json:"trusted_keys,omitempty"`
 }
 
 // DefaultConfig returns sensible defaults
 func DefaultConfig() *Config {
 	return &Config{
-		Address:        ":8080",
-		ReadTimeout:    30 * time.Second,
-		WriteTimeout:   60 * time.Second,
-		MaxBodySize:    10 * 1024 * 1024, // 10MB
-		EnableCORS:     true,
-		AllowedOrigins: []string{"*"},
-		RateLimit:      10,
-		EnableMetrics:  true,
+		Address:             ":8080",
+		ReadTimeout:         30 * time.Second,
+		WriteTimeout:        60 * time.Second,
+		EstimateTimeout:     45 * time.Second,
+		MaxBodySize:         10 * 1024 * 1024, // 10MB
+		MaxDecompressedSize: 50 * 1024 * 1024, // 50MB
+		EnableCORS:          true,
+		AllowedOrigins:      []string{"*"},
+		RateLimit:           10,
+		EnableMetrics:       true,
 	}
 }
 
@@ -64,6 +112,20 @@ type Adapter struct {
 	pipeline *terraform.Pipeline
 	config   *Config
 	server   *http.Server
+	logger   logging.LeveledLogger
+	
+	// newRequestID generates IDs for requests without X-Request-ID
+	newRequestID func() string
+	
+	// warmedUp is set once the configured snapshots are loaded
+	warmedUp atomic.Bool
+
+	// stopWarmup cancels the startup warm-up's retries on shutdown
+	warmupCtx  context.Context
+	stopWarmup context.CancelFunc
+
+	// clock stamps response metadata (nil = determinism.DefaultClock)
+	clock determinism.Clock
 	
 	// Metrics
 	requestCount   int64
@@ -78,11 +140,37 @@ func New(eng *engine.Engine, pipeline *terraform.Pipeline, config *Config) *Adap
 		config = DefaultConfig()
 	}
 	
+	warmupCtx, stopWarmup := context.WithCancel(context.Background())
 	return &Adapter{
 		engine:   eng,
 		pipeline: pipeline,
 		config:   config,
+		logger:   logging.Default(),
+		newRequestID: defaultRequestID,
+		warmupCtx:    warmupCtx,
+		stopWarmup:   stopWarmup,
+	}
+}
+
+// SetLogger sets the request logger
+func (a *Adapter) SetLogger(logger logging.LeveledLogger) {
+	if logger == nil {
+		logger = logging.Nop()
+	}
+	a.logger = logger
+}
+
+// SetClock sets the clock response metadata is stamped with
+func (a *Adapter) SetClock(clock determinism.Clock) {
+	a.clock = clock
+}
+
+// now returns the current time in UTC from the adapter's clock
+func (a *Adapter) now() time.Time {
+	if a.clock == nil {
+		return determinism.DefaultClock.Now()
 	}
+	return a.clock.Now()
 }
 
 // Router returns the HTTP handler
@@ -95,10 +183,16 @@ func (a *Adapter) Router() http.Handler {
 	
 	// API v1 endpoints
 	mux.HandleFunc("POST /api/v1/estimate", a.handleEstimate)
+	mux.HandleFunc("POST /api/v1/estimate/batch", a.handleEstimateBatch)
 	mux.HandleFunc("POST /api/v1/diff", a.handleDiff)
 	mux.HandleFunc("GET /api/v1/snapshots", a.handleListSnapshots)
 	mux.HandleFunc("GET /api/v1/snapshots/{id}", a.handleGetSnapshot)
+	mux.HandleFunc("POST /api/v1/snapshots/invalidate", a.handleInvalidateSnapshots)
 	mux.HandleFunc("GET /api/v1/coverage", a.handleCoverage)
+	mux.HandleFunc("POST /api/v1/coverage/gaps", a.handleCoverageGaps)
+	mux.HandleFunc("POST /api/v1/usage/validate", a.handleValidateUsage)
+	mux.HandleFunc("POST /api/v1/warmup", a.handleWarmup)
+	mux.HandleFunc("POST /api/v1/verify", a.handleVerify)
 	
 	// Metrics
 	if a.config.EnableMetrics {
@@ -109,12 +203,32 @@ func (a *Adapter) Router() http.Handler {
 	handler := a.corsMiddleware(mux)
 	handler = a.loggingMiddleware(handler)
 	handler = a.recoveryMiddleware(handler)
+	handler = a.requestIDMiddleware(handler)
 	
 	return handler
 }
 
 // Start starts the HTTP server
 func (a *Adapter) Start() error {
+	if a.config.PolicyFile != "" && a.config.Policies == nil {
+		policies, err := policy.LoadPolicyFile(a.config.PolicyFile)
+		if err != nil {
+			return err
+		}
+		a.config.Policies = policies
+	}
+	if a.config.SigningKeyFile != "" && a.config.SigningKey == nil {
+		key, err := attestation.LoadPrivateKey(a.config.SigningKeyFile)
+		if err != nil {
+			return err
+		}
+		a.config.SigningKey = key
+	}
+	
+	if len(a.config.Warmup) > 0 {
+		go a.warmupUntilReady(a.warmupCtx)
+	}
+	
 	a.server = &http.Server{
 		Addr:         a.config.Address,
 		Handler:      a.Router(),
@@ -127,6 +241,9 @@ func (a *Adapter) Start() error {
 
 // Shutdown gracefully shuts down the server
 func (a *Adapter) Shutdown(ctx context.Context) error {
+	if a.stopWarmup != nil {
+		a.stopWarmup()
+	}
 	if a.server != nil {
 		return a.server.Shutdown(ctx)
 	}
@@ -137,11 +254,17 @@ func (a *Adapter) Shutdown(ctx context.Context) error {
 type EstimateRequest struct {
 	// TerraformPlan is the JSON plan output
 	TerraformPlan json.RawMessage `json:"terraform_plan,omitempty"`
+
+	// TerraformState is `terraform show -json` state output. With a
+	// targeted plan, resources the plan leaves out are priced from it.
+	TerraformState json.RawMessage `json:"terraform_state,omitempty"`
 	
-	// HCLPath is the path to HCL files (for local)
+	// HCLPath is a module directory on the server, read without running
+	// terraform (used when TerraformPlan is empty)
 	HCLPath string `json:"hcl_path,omitempty"`
 	
-	// HCLContent is inline HCL content
+	// HCLContent is inline HCL for a single-file module (used when
+	// TerraformPlan is empty; takes precedence over HCLPath)
 	HCLContent string `json:"hcl_content,omitempty"`
 	
 	// Variables for Terraform
@@ -159,14 +282,27 @@ type EstimateRequest struct {
 	// Alias for multi-account
 	Alias string `json:"alias,omitempty"`
 	
-	// UsageOverrides for symbolic costs
+	// UsageOverrides for symbolic costs (take precedence over UsageProfile)
 	UsageOverrides map[string]map[string]float64 `json:"usage_overrides,omitempty"`
 	
+	// UsageProfile scales default usage (production, development, off-hours)
+	UsageProfile string `json:"usage_profile,omitempty"`
+	
 	// StrictMode fails on symbolic costs
 	StrictMode bool `json:"strict_mode,omitempty"`
 	
 	// IncludeLineage includes pricing lineage
 	IncludeLineage bool `json:"include_lineage,omitempty"`
+
+	// GroupBy is a tag key (e.g. "team") to break costs down by
+	GroupBy string `json:"group_by,omitempty"`
+
+	// ShowAnnual adds the annual total (monthly x 12) to the response
+	ShowAnnual bool `json:"show_annual,omitempty"`
+
+	// Ignore lists address globs (e.g. "aws_instance.test_*") for
+	// resources left out of the totals
+	Ignore []string `json:"ignore,omitempty"`
 }
 
 // EstimateResponse is the API response
@@ -179,9 +315,35 @@ type EstimateResponse struct {
 	
 	// TotalMonthlyCost is the total cost
 	TotalMonthlyCost string `json:"total_monthly_cost"`
+
+	// Currency is the ISO 4217 code of every cost in the response
+	Currency string `json:"currency"`
 	
 	// TotalHourlyCost is hourly cost
 	TotalHourlyCost string `json:"total_hourly_cost"`
+
+	// TotalAnnualCost is the monthly total x 12, with show_annual
+	TotalAnnualCost string `json:"total_annual_cost,omitempty"`
+
+	// DestroyedMonthlyCost is the monthly savings from resources the
+	// plan destroys, which the totals leave out
+	DestroyedMonthlyCost string `json:"destroyed_monthly_cost,omitempty"`
+	DestroyedCount       int    `json:"destroyed_count,omitempty"`
+
+	// DestroyPlan is set when the plan destroys every resource. The totals
+	// are then the post-destroy cost, zero, and MonthlyCostDelta is the
+	// savings as a negative amount.
+	DestroyPlan      bool   `json:"destroy_plan,omitempty"`
+	MonthlyCostDelta string `json:"monthly_cost_delta,omitempty"`
+
+	// IgnoredMonthlyCost is what the resources matching the request's
+	// ignore patterns would cost, which the totals leave out
+	IgnoredMonthlyCost string `json:"ignored_monthly_cost,omitempty"`
+	IgnoredCount       int    `json:"ignored_count,omitempty"`
+
+	// TotalScope is "targeted subset" when the totals cover only the
+	// resources of a targeted plan
+	TotalScope string `json:"total_scope,omitempty"`
 	
 	// Confidence (0-1)
 	Confidence float64 `json:"confidence"`
@@ -197,18 +359,39 @@ type EstimateResponse struct {
 	
 	// UnsupportedTypes that couldn't be estimated
 	UnsupportedTypes []string `json:"unsupported_types,omitempty"`
+
+	// SymbolicResources have an unknown instance count (count/for_each)
+	SymbolicResources []SymbolicResourceResponse `json:"symbolic_resources,omitempty"`
 	
 	// Snapshot used for pricing
 	Snapshot SnapshotResponse `json:"snapshot"`
 	
 	// Lineage for auditability
 	Lineage []LineageEntry `json:"lineage,omitempty"`
+
+	// TagBreakdown groups costs by the requested tag
+	TagBreakdown *TagBreakdownResponse `json:"tag_breakdown,omitempty"`
+	
+	// Policies are the results of the configured policy file
+	Policies []PolicyResponse `json:"policies,omitempty"`
 	
 	// Warnings during estimation
 	Warnings []string `json:"warnings,omitempty"`
+
+	// Degraded is set when the estimate is incomplete or was priced from
+	// fallback rates; Warnings say why
+	Degraded bool `json:"degraded,omitempty"`
 	
 	// Metadata
 	Metadata ResponseMetadata `json:"metadata"`
+
+	// Signature and PublicKey attest the rest of the response when the
+	// server has a signing key
+	Signature string `json:"signature,omitempty"`
+	PublicKey string `json:"public_key,omitempty"`
+
+	// totalMonthly is TotalMonthlyCost as money, for batch totals
+	totalMonthly determinism.Money
 }
 
 // CoverageResponse is coverage breakdown
@@ -230,6 +413,70 @@ type ResourceCostResponse struct {
 	Confidence   float64                   `json:"confidence"`
 	CoverageType string                    `json:"coverage_type"`
 	Components   []ComponentCostResponse   `json:"components,omitempty"`
+
+	// SkipReason explains why an unsupported resource has no cost
+	SkipReason string `json:"skip_reason,omitempty"`
+
+	// Placeholders is set on the one row listing a resource with an
+	// unknown count: how many placeholder instances its cost sums
+	Placeholders int    `json:"placeholders,omitempty"`
+	CountRange   string `json:"count_range,omitempty"`
+}
+
+// newCoverageResponse converts the engine's coverage report
+func newCoverageResponse(report *engine.CoverageReport) CoverageResponse {
+	if report == nil {
+		return CoverageResponse{}
+	}
+	return CoverageResponse{
+		NumericPercent:     report.NumericPercent,
+		SymbolicPercent:    report.SymbolicPercent,
+		IndirectPercent:    report.IndirectPercent,
+		UnsupportedPercent: report.UnsupportedPercent,
+		TotalResources:     report.TotalInstances,
+	}
+}
+
+// SymbolicResourceResponse is a resource with an unknown instance count
+type SymbolicResourceResponse struct {
+	Address    string `json:"address"`
+	Reason     string `json:"reason"`
+	Range      string `json:"range"`
+	Expression string `json:"expression,omitempty"`
+	Message    string `json:"message,omitempty"`
+	Assumed    int    `json:"assumed"`
+
+	// Placeholders priced for the resource and their monthly cost
+	Placeholders int    `json:"placeholders,omitempty"`
+	MonthlyCost  string `json:"monthly_cost,omitempty"`
+}
+
+// newSymbolicResourcesResponse converts the result's symbolic resources
+func newSymbolicResourcesResponse(result *engine.EstimationResult) []SymbolicResourceResponse {
+	groups := make(map[string]engine.PlaceholderGroup)
+	for _, g := range result.PlaceholderGroups() {
+		if g.Symbolic != nil {
+			groups[g.Symbolic.Address] = g
+		}
+	}
+
+	var out []SymbolicResourceResponse
+	for _, s := range result.SymbolicResources {
+		r := SymbolicResourceResponse{
+			Address:    s.Address,
+			Reason:     s.Reason,
+			Range:      s.Range(),
+			Expression: s.Expression,
+			Message:    s.Message,
+			Assumed:    s.AssumedCount,
+		}
+		if g, ok := groups[s.Address]; ok {
+			r.Placeholders = g.Placeholders
+			r.MonthlyCost = g.MonthlyCost.Display(determinism.DisplayPlaces)
+		}
+		out = append(out, r)
+	}
+	return out
 }
 
 // ComponentCostResponse is per-component cost
@@ -252,6 +499,49 @@ type SnapshotResponse struct {
 	ContentHash  string    `json:"content_hash"`
 	EffectiveAt  time.Time `json:"effective_at"`
 	RateCount    int       `json:"rate_count"`
+	Stale        bool      `json:"stale,omitempty"`
+}
+
+// TagBreakdownResponse is cost grouped by one tag key
+type TagBreakdownResponse struct {
+	Key    string             `json:"key"`
+	Groups []TagGroupResponse `json:"groups"`
+}
+
+// TagGroupResponse is the cost of one tag value
+type TagGroupResponse struct {
+	Value         string `json:"value"`
+	MonthlyCost   string `json:"monthly_cost"`
+	HourlyCost    string `json:"hourly_cost"`
+	ResourceCount int    `json:"resource_count"`
+}
+
+// newTagBreakdownResponse groups result costs by the tag key
+func newTagBreakdownResponse(result *engine.EstimationResult, key string) *TagBreakdownResponse {
+	groups := result.GroupByTag(key)
+	resp := &TagBreakdownResponse{Key: key, Groups: make([]TagGroupResponse, 0, len(groups))}
+	for _, g := range groups {
+		resp.Groups = append(resp.Groups, TagGroupResponse{
+			Value:         g.Value,
+			MonthlyCost:   g.DisplayMonthlyCost.Display(determinism.DisplayPlaces),
+			HourlyCost:    g.DisplayHourlyCost.Display(determinism.HourlyDisplayPlaces),
+			ResourceCount: g.InstanceCount,
+		})
+	}
+	return resp
+}
+
+// newSnapshotResponse converts an engine snapshot reference
+func newSnapshotResponse(ref *engine.SnapshotReference) SnapshotResponse {
+	return SnapshotResponse{
+		ID:          string(ref.ID),
+		Provider:    ref.Provider,
+		Region:      ref.Region,
+		Alias:       ref.Alias,
+		ContentHash: ref.ContentHash.Hex(),
+		EffectiveAt: ref.EffectiveAt,
+		Stale:       ref.Stale,
+	}
 }
 
 // LineageEntry traces a rate lookup
@@ -275,40 +565,71 @@ type ResponseMetadata struct {
 
 // Handler implementations
 
+// handleHealth is liveness only; it never touches pricing, so a database
+// outage makes the server unready (see handleReady) but not restarted
 func (a *Adapter) handleHealth(w http.ResponseWriter, r *http.Request) {
 	a.writeJSON(w, http.StatusOK, map[string]string{"status": "healthy"})
 }
 
-func (a *Adapter) handleReady(w http.ResponseWriter, r *http.Request) {
-	// Check engine health
-	a.writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
-}
-
 func (a *Adapter) handleEstimate(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
-	ctx := r.Context()
+	ctx, cancel := a.estimateContext(r.Context())
+	defer cancel()
 	
 	// Parse request
 	var req EstimateRequest
 	if err := a.parseJSON(r, &req); err != nil {
-		a.writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		a.writeError(w, bodyErrorStatus(err), "invalid request body: "+err.Error())
+		return
+	}
+	
+	requestID := RequestIDFromContext(ctx)
+	engineReq, status, err := a.prepareEstimate(ctx, &req, requestID)
+	if err != nil {
+		status, err = a.estimateTimedOut(ctx, status, err)
+		a.writeError(w, status, err.Error())
 		return
 	}
 	
+	if wantsNDJSON(r) {
+		a.streamEstimate(ctx, w, engineReq, requestID, start)
+		return
+	}
+	
+	resp, status, err := a.runEstimate(ctx, &req, engineReq, requestID, start)
+	if err != nil {
+		status, err = a.estimateTimedOut(ctx, status, err)
+		a.writeError(w, status, err.Error())
+		return
+	}
+	if err := a.sign(resp); err != nil {
+		a.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	a.writeJSON(w, http.StatusOK, resp)
+}
+
+// prepareEstimate validates a request and builds the engine request for
+// it. On error the int is the HTTP status to respond with.
+func (a *Adapter) prepareEstimate(ctx context.Context, req *EstimateRequest, requestID string) (*engine.EstimateRequest, int, error) {
 	// Validate
 	if req.Provider == "" {
-		a.writeError(w, http.StatusBadRequest, "provider is required")
-		return
+		return nil, http.StatusBadRequest, errors.New("provider is required")
 	}
 	if req.Region == "" {
-		a.writeError(w, http.StatusBadRequest, "region is required")
-		return
+		return nil, http.StatusBadRequest, errors.New("region is required")
+	}
+	if req.UsageProfile != "" {
+		if _, ok := engine.LookupUsageProfile(req.UsageProfile); !ok {
+			return nil, http.StatusBadRequest, errors.New("unknown usage_profile: " + req.UsageProfile)
+		}
 	}
 	
 	// Build snapshot request
 	snapshotReq := engine.SnapshotRequest{
 		Provider: req.Provider,
 		Region:   req.Region,
+		Alias:    req.Alias,
 	}
 	if req.SnapshotID != "" {
 		snapshotReq.SnapshotID = pricing.SnapshotID(req.SnapshotID)
@@ -320,21 +641,56 @@ func (a *Adapter) handleEstimate(w http.ResponseWriter, r *http.Request) {
 		overrides[model.InstanceID(k)] = v
 	}
 	
-	// Execute estimation
-	engineReq := &engine.EstimateRequest{
-		SnapshotRequest: snapshotReq,
-		UsageOverrides:  overrides,
+	// Build the instance graph from plan JSON or HCL
+	source, err := a.buildSource(ctx, req)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	// Refuse oversized graphs before a stream commits to 200
+	if err := a.engine.CheckGraphLimits(source.graph); err != nil {
+		return nil, statusForEngineError(err), err
 	}
 	
+	return &engine.EstimateRequest{
+		Graph:               source.graph,
+		SnapshotRequest:     snapshotReq,
+		UsageOverrides:      overrides,
+		UsageProfile:        req.UsageProfile,
+		RequestID:           requestID,
+		CardinalityWarnings: source.cardinalityWarnings,
+		SourceWarnings:      source.warnings,
+		Scope:               source.scope,
+		IgnorePatterns:      req.Ignore,
+	}, 0, nil
+}
+
+// runEstimate prices a prepared request and builds its response. On error
+// the int is the HTTP status to respond with.
+func (a *Adapter) runEstimate(ctx context.Context, req *EstimateRequest, engineReq *engine.EstimateRequest, requestID string, start time.Time) (*EstimateResponse, int, error) {
 	result, err := a.engine.Estimate(ctx, engineReq)
 	if err != nil {
-		a.writeError(w, http.StatusInternalServerError, "estimation failed: "+err.Error())
-		return
+		return nil, statusForEngineError(err), fmt.Errorf("estimation failed: %w", err)
+	}
+	if req.StrictMode && result.Snapshot != nil && result.Snapshot.Stale {
+		return nil, http.StatusServiceUnavailable, fmt.Errorf("estimation failed: %w", engine.ErrStaleSnapshot)
 	}
 	
 	// Build response
-	resp := a.buildEstimateResponse(result, r.Header.Get("X-Request-ID"), start)
-	a.writeJSON(w, http.StatusOK, resp)
+	resp := a.buildEstimateResponse(result, requestID, start)
+	if req.ShowAnnual {
+		resp.TotalAnnualCost = result.AnnualCost().Display(determinism.DisplayPlaces)
+	}
+	if req.GroupBy != "" {
+		resp.TagBreakdown = newTagBreakdownResponse(result, req.GroupBy)
+	}
+	if a.config.Policies != nil {
+		policies, err := a.evaluatePolicies(ctx, result)
+		if err != nil {
+			resp.Warnings = append(resp.Warnings, "policy evaluation failed: "+err.Error())
+		}
+		resp.Policies = policies
+	}
+	return resp, http.StatusOK, nil
 }
 
 func (a *Adapter) handleDiff(w http.ResponseWriter, r *http.Request) {
@@ -384,59 +740,125 @@ terraform_cost_latency_avg_ms %.2f
 }
 
 func (a *Adapter) buildEstimateResponse(result *engine.EstimationResult, requestID string, start time.Time) *EstimateResponse {
+	totalMonthly := result.DisplayTotalMonthlyCost()
 	resp := &EstimateResponse{
 		Success:          true,
-		TotalMonthlyCost: result.TotalMonthlyCost.String(),
-		TotalHourlyCost:  result.TotalHourlyCost.String(),
+		TotalMonthlyCost: totalMonthly.Display(determinism.DisplayPlaces),
+		TotalHourlyCost:  result.DisplayTotalHourlyCost().Display(determinism.HourlyDisplayPlaces),
+		Currency:         totalMonthly.Currency(),
 		Confidence:       result.Confidence.Score,
+		TotalScope:       result.Scope,
 		Warnings:         result.Warnings,
+		Degraded:         result.Degraded,
 		Metadata: ResponseMetadata{
 			RequestID: requestID,
 			Duration:  time.Since(start),
 			Version:   "1.0.0",
-			Timestamp: time.Now(),
+			Timestamp: a.now(),
 		},
+		totalMonthly: totalMonthly,
 	}
 	
+	if result.DestroyedCount > 0 {
+		resp.DestroyedMonthlyCost = result.DestroyedMonthlyCost.Display(determinism.DisplayPlaces)
+		resp.DestroyedCount = result.DestroyedCount
+	}
+	if result.Destroy {
+		resp.DestroyPlan = true
+		resp.MonthlyCostDelta = result.DestroyedMonthlyCost.Neg().Display(determinism.DisplayPlaces)
+	}
+	if result.IgnoredCount > 0 {
+		resp.IgnoredMonthlyCost = result.IgnoredMonthlyCost.Display(determinism.DisplayPlaces)
+		resp.IgnoredCount = result.IgnoredCount
+	}
+
 	// Snapshot
 	if result.Snapshot != nil {
-		resp.Snapshot = SnapshotResponse{
-			ID:          string(result.Snapshot.ID),
-			Provider:    result.Snapshot.Provider,
-			Region:      result.Snapshot.Region,
-			ContentHash: result.Snapshot.ContentHash.Hex(),
-			EffectiveAt: result.Snapshot.EffectiveAt,
+		resp.Snapshot = newSnapshotResponse(result.Snapshot)
+	}
+	resp.SymbolicResources = newSymbolicResourcesResponse(result)
+	
+	resp.Coverage = newCoverageResponse(result.CoverageReport)
+
+	// Resources, sorted by monthly cost descending then address so the
+	// response is byte-stable for the same input. Placeholders of a
+	// resource with an unknown count follow as one row per resource.
+	costs := result.SortedInstanceCosts()
+	resp.Resources = make([]ResourceCostResponse, 0, len(costs))
+	for _, cost := range costs {
+		if !cost.Symbolic {
+			resp.Resources = append(resp.Resources, newResourceCostResponse(cost))
 		}
 	}
+	for _, g := range result.PlaceholderGroups() {
+		resp.Resources = append(resp.Resources, newPlaceholderGroupResponse(g))
+	}
 	
-	// Resources
-	resp.Resources = make([]ResourceCostResponse, 0)
-	result.InstanceCosts.Range(func(id model.InstanceID, cost *engine.InstanceCost) bool {
-		rc := ResourceCostResponse{
-			Address:     string(cost.Address),
-			Type:        string(cost.ResourceType),
-			MonthlyCost: cost.MonthlyCost.String(),
-			HourlyCost:  cost.HourlyCost.String(),
-			Confidence:  cost.Confidence.Score,
-		}
-		
-		// Components
-		for _, comp := range cost.Components {
-			cc := ComponentCostResponse{
-				Name:        comp.Name,
-				MonthlyCost: comp.MonthlyCost.String(),
-				UsageValue:  comp.UsageValue,
-				UsageUnit:   comp.UsageUnit,
-				IsSymbolic:  comp.Confidence < 0.7,
-			}
-			rc.Components = append(rc.Components, cc)
+	return resp
+}
+
+// newResourceCostResponse converts one instance cost for the API
+func newResourceCostResponse(cost *engine.InstanceCost) ResourceCostResponse {
+	rc := ResourceCostResponse{
+		Address:      string(cost.Address),
+		Type:         string(cost.ResourceType),
+		MonthlyCost:  cost.DisplayMonthlyCost().Display(determinism.DisplayPlaces),
+		HourlyCost:   cost.DisplayHourlyCost().Display(determinism.HourlyDisplayPlaces),
+		Confidence:   cost.Confidence.Score,
+		CoverageType: cost.CoverageType.String(),
+		SkipReason:   cost.SkipReason,
+	}
+	
+	// Components
+	for _, comp := range cost.Components {
+		cc := ComponentCostResponse{
+			Name:        comp.Name,
+			MonthlyCost: comp.MonthlyCost.Display(determinism.DisplayPlaces),
+			UsageValue:  comp.UsageValue,
+			UsageUnit:   comp.UsageUnit,
+			IsSymbolic:  comp.Confidence < 0.7,
 		}
-		
-		resp.Resources = append(resp.Resources, rc)
-		return true
-	})
+		rc.Components = append(rc.Components, cc)
+	}
 	
-	return resp
+	return rc
+}
+
+// newPlaceholderGroupResponse lists a resource's placeholders as one row
+func newPlaceholderGroupResponse(g engine.PlaceholderGroup) ResourceCostResponse {
+	rc := ResourceCostResponse{
+		Address:      g.Address,
+		Type:         g.ResourceType,
+		MonthlyCost:  g.MonthlyCost.Display(determinism.DisplayPlaces),
+		HourlyCost:   g.HourlyCost.Display(determinism.HourlyDisplayPlaces),
+		Confidence:   g.Confidence,
+		CoverageType: engine.CoverageTypeSymbolic.String(),
+		Placeholders: g.Placeholders,
+	}
+	if g.Symbolic != nil {
+		rc.CountRange = g.Symbolic.Range()
+	}
+	return rc
+}
+
+// StatusClientClosedRequest is the non-standard status used when the client
+// disconnects before the estimate completes
+const StatusClientClosedRequest = 499
+
+// statusForEngineError maps engine errors to HTTP status codes
+func statusForEngineError(err error) int {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return StatusClientClosedRequest
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, engine.ErrStaleSnapshot), errors.Is(err, engine.ErrPricingUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, engine.ErrGraphTooLarge):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
 // Middleware
@@ -450,7 +872,8 @@ func (a *Adapter) corsMiddleware(next http.Handler) http.Handler {
 			}
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Request-ID")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, "+RequestIDHeader)
+			w.Header().Set("Access-Control-Expose-Headers", RequestIDHeader)
 		}
 		
 		if r.Method == "OPTIONS" {
@@ -465,15 +888,61 @@ func (a *Adapter) corsMiddleware(next http.Handler) http.Handler {
 func (a *Adapter) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
-		next.ServeHTTP(w, r)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		elapsed := time.Since(start)
 		
 		a.mu.Lock()
 		a.requestCount++
-		a.totalLatencyMs += time.Since(start).Milliseconds()
+		a.totalLatencyMs += elapsed.Milliseconds()
 		a.mu.Unlock()
+		
+		fields := []logging.Field{
+			logging.String("method", r.Method),
+			logging.String("path", r.URL.Path),
+			logging.Int("status", rec.status),
+			logging.Duration("duration", elapsed),
+			logging.String("remote_addr", r.RemoteAddr),
+		}
+		if reqID := RequestIDFromContext(r.Context()); reqID != "" {
+			fields = append(fields, logging.String("request_id", reqID))
+		}
+		
+		switch {
+		case rec.status >= 500:
+			a.logger.Error("request failed", fields...)
+		case rec.status >= 400:
+			a.logger.Warn("request rejected", fields...)
+		default:
+			a.logger.Info("request completed", fields...)
+		}
 	})
 }
 
+// statusRecorder captures the response status for logging
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush passes a flush through to the wrapped writer, so a streamed
+// response is not buffered until the handler returns
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
 func (a *Adapter) recoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
@@ -482,6 +951,13 @@ func (a *Adapter) recoveryMiddleware(next http.Handler) http.Handler {
 				a.errorCount++
 				a.mu.Unlock()
 				
+				a.logger.Error("panic recovered",
+					logging.String("method", r.Method),
+					logging.String("path", r.URL.Path),
+					logging.String("request_id", RequestIDFromContext(r.Context())),
+					logging.Any("panic", err),
+				)
+				
 				a.writeError(w, http.StatusInternalServerError, "internal server error")
 			}
 		}()
@@ -491,9 +967,10 @@ func (a *Adapter) recoveryMiddleware(next http.Handler) http.Handler {
 
 // Helpers
 
+// parseJSON decodes the request body; bodyErrorStatus gives the status
+// for its errors
 func (a *Adapter) parseJSON(r *http.Request, v interface{}) error {
-	defer r.Body.Close()
-	body, err := io.ReadAll(io.LimitReader(r.Body, a.config.MaxBodySize))
+	body, err := a.readBody(r)
 	if err != nil {
 		return err
 	}
@@ -506,9 +983,15 @@ func (a *Adapter) writeJSON(w http.ResponseWriter, status int, v interface{}) {
 	json.NewEncoder(w).Encode(v)
 }
 
+// writeError writes an error body carrying the request ID the
+// request ID middleware put on the response
 func (a *Adapter) writeError(w http.ResponseWriter, status int, message string) {
-	a.writeJSON(w, status, map[string]interface{}{
+	body := map[string]interface{}{
 		"success": false,
 		"error":   message,
-	})
+	}
+	if id := w.Header().Get(RequestIDHeader); id != "" {
+		body["request_id"] = id
+	}
+	a.writeJSON(w, status, body)
 }
@@ -0,0 +1,1191 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	tfadapter "terraform-cost/adapters/terraform"
+	"terraform-cost/core/catalog"
+	"terraform-cost/core/engine"
+	"terraform-cost/core/model"
+	"terraform-cost/core/policy"
+	"terraform-cost/core/pricing"
+	"terraform-cost/internal/attestation"
+	"terraform-cost/internal/logging"
+)
+
+type fixedResolver struct {
+	snapshot *pricing.PricingSnapshot
+}
+
+func (r *fixedResolver) GetSnapshot(ctx context.Context, req engine.SnapshotRequest) (*pricing.PricingSnapshot, error) {
+	return r.snapshot, nil
+}
+
+func (r *fixedResolver) LookupRate(snapshot *pricing.PricingSnapshot, resourceType, component string, attrs map[string]string) (*pricing.RateEntry, error) {
+	rate, ok := snapshot.LookupRate(resourceType, component, attrs)
+	if !ok {
+		return nil, fmt.Errorf("rate not found")
+	}
+	return rate, nil
+}
+
+type noUsage struct{}
+
+func (noUsage) Estimate(ctx context.Context, inst *model.AssetInstance) (*engine.UsageResult, error) {
+	return &engine.UsageResult{Metrics: map[string]engine.UsageMetric{}, Confidence: 1.0}, nil
+}
+
+type computePlugin struct{}
+
+func (computePlugin) Provider() string { return "aws" }
+
+func (computePlugin) CatalogVersion() string { return catalog.Version }
+
+func (computePlugin) SupportedTypes() []string { return []string{"aws_instance"} }
+
+func (computePlugin) MapInstance(inst *model.AssetInstance) ([]engine.CostComponent, error) {
+	return []engine.CostComponent{{Name: "compute", ResourceType: "aws_instance", Unit: "hours"}}, nil
+}
+
+func newTestEngine() *engine.Engine {
+	snapshot := pricing.NewSnapshotBuilder("aws", "us-east-1").
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(0.1), "hour", "USD").
+		Build()
+	eng := engine.NewEngine(&fixedResolver{snapshot: snapshot}, noUsage{}, nil, engine.EngineConfig{})
+	eng.SetLogger(logging.Nop())
+	eng.RegisterPlugin(computePlugin{})
+	return eng
+}
+
+// TestEstimateResponseDeterministic proves resources are cost-sorted and
+// two runs over the same graph serialize identically
+func TestEstimateResponseDeterministic(t *testing.T) {
+	eng := newTestEngine()
+
+	graph := model.NewInstanceGraph()
+	overrides := map[model.InstanceID]map[string]float64{}
+	for i, hours := range []float64{100, 730, 100, 400} {
+		id := model.InstanceID(fmt.Sprintf("inst-%d", i))
+		graph.AddInstance(&model.AssetInstance{
+			ID:       id,
+			Address:  model.InstanceAddress(fmt.Sprintf("aws_instance.web[%d]", i)),
+			Provider: model.ResolvedProvider{Type: "aws", Region: "us-east-1"},
+		})
+		overrides[id] = map[string]float64{"compute": hours}
+	}
+
+	a := &Adapter{}
+	render := func() []byte {
+		result, err := eng.Estimate(context.Background(), &engine.EstimateRequest{Graph: graph, UsageOverrides: overrides})
+		if err != nil {
+			t.Fatalf("estimate failed: %v", err)
+		}
+		resp := a.buildEstimateResponse(result, "req-1", time.Now())
+		resp.Metadata = ResponseMetadata{}
+		out, err := json.Marshal(resp)
+		if err != nil {
+			t.Fatalf("marshal failed: %v", err)
+		}
+
+		var got []string
+		for _, r := range resp.Resources {
+			got = append(got, r.Address)
+		}
+		want := []string{"aws_instance.web[1]", "aws_instance.web[3]", "aws_instance.web[0]", "aws_instance.web[2]"}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("resource order = %v, want %v", got, want)
+		}
+		return out
+	}
+
+	first, second := render(), render()
+	if string(first) != string(second) {
+		t.Errorf("responses differ:\n%s\n%s", first, second)
+	}
+}
+
+// TestStreamEstimateNDJSON proves each resource is streamed and the summary
+// totals cover every streamed resource
+func TestStreamEstimateNDJSON(t *testing.T) {
+	a := New(newTestEngine(), nil, nil)
+	a.SetLogger(nil)
+
+	graph := model.NewInstanceGraph()
+	for i := 0; i < 5; i++ {
+		graph.AddInstance(&model.AssetInstance{
+			ID:       model.InstanceID(fmt.Sprintf("inst-%d", i)),
+			Address:  model.InstanceAddress(fmt.Sprintf("aws_instance.web[%d]", i)),
+			Provider: model.ResolvedProvider{Type: "aws", Region: "us-east-1"},
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	a.streamEstimate(context.Background(), rec, &engine.EstimateRequest{Graph: graph}, "req-1", time.Now())
+
+	if ct := rec.Header().Get("Content-Type"); ct != ContentTypeNDJSON {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	var resources int
+	var summary *NDJSONSummary
+	total := decimal.Zero
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		var line NDJSONLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		switch line.Type {
+		case NDJSONLineResource:
+			resources++
+			total = total.Add(parseMoney(line.Resource.MonthlyCost))
+		case NDJSONLineSummary:
+			summary = line.Summary
+		default:
+			t.Fatalf("unexpected line: %s", scanner.Text())
+		}
+	}
+
+	if resources != 5 {
+		t.Errorf("expected 5 resource lines, got %d", resources)
+	}
+	if summary == nil {
+		t.Fatal("missing summary line")
+	}
+	if summary.ResourceCount != 5 || summary.Coverage.TotalResources != 5 {
+		t.Errorf("summary counts = %d/%d, want 5", summary.ResourceCount, summary.Coverage.TotalResources)
+	}
+	if !parseMoney(summary.TotalMonthlyCost).Equal(total) {
+		t.Errorf("summary total %s != sum of resources %s", summary.TotalMonthlyCost, total)
+	}
+}
+
+// streamGraph is n aws_instance.web instances
+func streamGraph(n int) *model.InstanceGraph {
+	graph := model.NewInstanceGraph()
+	for i := 0; i < n; i++ {
+		graph.AddInstance(&model.AssetInstance{
+			ID:       model.InstanceID(fmt.Sprintf("inst-%d", i)),
+			Address:  model.InstanceAddress(fmt.Sprintf("aws_instance.web[%d]", i)),
+			Provider: model.ResolvedProvider{Type: "aws", Region: "us-east-1"},
+		})
+	}
+	return graph
+}
+
+// TestStreamEstimateFailsBeforeStreaming proves an estimate that fails
+// before pricing anything is an HTTP error, not a 200 with an error line
+func TestStreamEstimateFailsBeforeStreaming(t *testing.T) {
+	eng := engine.NewEngine(downResolver{&fixedResolver{}}, noUsage{}, nil, engine.EngineConfig{})
+	eng.SetLogger(logging.Nop())
+	eng.RegisterPlugin(computePlugin{})
+	a := New(eng, nil, nil)
+	a.SetLogger(nil)
+
+	rec := httptest.NewRecorder()
+	a.streamEstimate(context.Background(), rec, &engine.EstimateRequest{Graph: streamGraph(2)}, "req-1", time.Now())
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct == ContentTypeNDJSON {
+		t.Errorf("error response has Content-Type %q", ct)
+	}
+}
+
+// TestStreamEstimatePolicies proves policies see the streamed instances
+func TestStreamEstimatePolicies(t *testing.T) {
+	policies, err := policy.ParsePolicyFile([]byte(`{"resource_types": [{"type": "aws_instance", "max_instances": 2}]}`), "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := New(newTestEngine(), nil, &Config{Policies: policies})
+	a.SetLogger(nil)
+
+	rec := httptest.NewRecorder()
+	a.streamEstimate(context.Background(), rec, &engine.EstimateRequest{Graph: streamGraph(5)}, "req-1", time.Now())
+
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	var last NDJSONLine
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &last); err != nil || last.Summary == nil {
+		t.Fatalf("last line is not a summary: %s", lines[len(lines)-1])
+	}
+	if len(last.Summary.Policies) != 1 || last.Summary.Policies[0].Passed {
+		t.Errorf("policies = %+v, want max_instances 2 to fail over 5 instances", last.Summary.Policies)
+	}
+}
+
+// failingWriter accepts the first write, then fails as a gone client does
+type failingWriter struct {
+	*httptest.ResponseRecorder
+	writes int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if w.writes > 1 {
+		return 0, errors.New("broken pipe")
+	}
+	return w.ResponseRecorder.Write(p)
+}
+
+// TestStreamEstimateStopsOnWriteError proves a failed write aborts the
+// estimate and nothing more is written
+func TestStreamEstimateStopsOnWriteError(t *testing.T) {
+	a := New(newTestEngine(), nil, nil)
+	a.SetLogger(nil)
+
+	w := &failingWriter{ResponseRecorder: httptest.NewRecorder()}
+	a.streamEstimate(context.Background(), w, &engine.EstimateRequest{Graph: streamGraph(5)}, "req-1", time.Now())
+	if w.writes != 2 {
+		t.Errorf("%d writes, want the first line and the failed second", w.writes)
+	}
+}
+
+// parseMoney parses "73.00 USD"
+func parseMoney(s string) decimal.Decimal {
+	return decimal.RequireFromString(strings.Fields(s)[0])
+}
+
+// requestUsage reports request volume as unknown, as a usage estimator
+// does for usage-priced resources without a usage file
+type requestUsage struct{}
+
+func (requestUsage) Estimate(ctx context.Context, inst *model.AssetInstance) (*engine.UsageResult, error) {
+	return &engine.UsageResult{
+		Metrics:    map[string]engine.UsageMetric{"requests": {Name: "requests", IsUnknown: true}},
+		Confidence: 1.0,
+	}, nil
+}
+
+type requestPlugin struct{}
+
+func (requestPlugin) Provider() string { return "aws" }
+
+func (requestPlugin) CatalogVersion() string { return catalog.Version }
+
+func (requestPlugin) SupportedTypes() []string { return []string{"aws_lambda_function"} }
+
+func (requestPlugin) MapInstance(inst *model.AssetInstance) ([]engine.CostComponent, error) {
+	return []engine.CostComponent{{Name: "requests", ResourceType: "aws_lambda_function", Unit: "requests"}}, nil
+}
+
+const usagePlanJSON = `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {"address": "aws_lambda_function.api", "mode": "managed", "type": "aws_lambda_function", "name": "api",
+     "provider_name": "registry.terraform.io/hashicorp/aws", "change": {"actions": ["create"], "after": {}}},
+    {"address": "aws_lambda_function.worker", "mode": "managed", "type": "aws_lambda_function", "name": "worker",
+     "provider_name": "registry.terraform.io/hashicorp/aws", "change": {"actions": ["create"], "after": {}}}
+  ]
+}`
+
+// TestCoverageGaps proves gaps are ranked across the plans in a request
+func TestCoverageGaps(t *testing.T) {
+	a := New(engine.NewEngine(&fixedResolver{}, noUsage{}, nil, engine.EngineConfig{}), nil, nil)
+	a.SetLogger(nil)
+
+	msk := `{"format_version": "1.2", "resource_changes": [
+	  {"address": "aws_msk_cluster.events", "mode": "managed", "type": "aws_msk_cluster", "name": "events",
+	   "provider_name": "registry.terraform.io/hashicorp/aws", "change": {"actions": ["create"], "after": {}}}]}`
+	body, _ := json.Marshal(CoverageGapsRequest{Plans: []json.RawMessage{json.RawMessage(usagePlanJSON), json.RawMessage(msk), json.RawMessage(msk)}})
+
+	rec := httptest.NewRecorder()
+	a.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/coverage/gaps", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var report catalog.GapReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Plans != 3 || report.Resources != 4 || len(report.Gaps) != 1 {
+		t.Fatalf("report = %+v, want 3 plans, 4 resources and one gap", report)
+	}
+	if g := report.Gaps[0]; g.ResourceType != "aws_msk_cluster" || g.Occurrences != 2 || g.Plans != 2 || g.BlindSpotPercent != 50 {
+		t.Errorf("gap = %+v, want aws_msk_cluster in 2 of 3 plans, half the resources", g)
+	}
+}
+
+// TestValidateUsage proves covered, missing and mistyped usage keys are reported
+func TestValidateUsage(t *testing.T) {
+	eng := engine.NewEngine(&fixedResolver{}, requestUsage{}, nil, engine.EngineConfig{})
+	eng.SetLogger(logging.Nop())
+	eng.RegisterPlugin(requestPlugin{})
+	a := New(eng, nil, nil)
+	a.SetLogger(nil)
+
+	apiID := string(model.CanonicalAddress("aws_lambda_function.api").StableID())
+	body, _ := json.Marshal(UsageValidateRequest{
+		TerraformPlan: json.RawMessage(usagePlanJSON),
+		Region:        "us-east-1",
+		UsageOverrides: map[string]map[string]float64{
+			apiID:                        {"requests": 1e6, "duration": 100},
+			"aws_lambda_function.worker": {"requests": 5e5},
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	a.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/usage/validate", strings.NewReader(string(body))))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+
+	var resp UsageValidateResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Valid {
+		t.Error("expected invalid usage")
+	}
+	if len(resp.Covered) != 1 || resp.Covered[0].Address != "aws_lambda_function.api" {
+		t.Errorf("covered = %+v", resp.Covered)
+	}
+	if len(resp.Missing) != 1 || resp.Missing[0].Address != "aws_lambda_function.worker" || resp.Missing[0].Component != "requests" {
+		t.Errorf("missing = %+v", resp.Missing)
+	}
+	if len(resp.UnknownKeys) != 2 {
+		t.Fatalf("unknown keys = %+v", resp.UnknownKeys)
+	}
+	for _, k := range resp.UnknownKeys {
+		switch k.InstanceID {
+		case apiID:
+			if k.Component != "duration" {
+				t.Errorf("unexpected unknown component %+v", k)
+			}
+		case "aws_lambda_function.worker":
+			if !strings.Contains(k.Reason, "instance ID") {
+				t.Errorf("address key should suggest the instance ID: %+v", k)
+			}
+		default:
+			t.Errorf("unexpected unknown key %+v", k)
+		}
+	}
+}
+
+// countingResolver counts snapshot loads
+type countingResolver struct {
+	fixedResolver
+	loads int
+}
+
+func (r *countingResolver) GetSnapshot(ctx context.Context, req engine.SnapshotRequest) (*pricing.PricingSnapshot, error) {
+	r.loads++
+	return r.fixedResolver.GetSnapshot(ctx, req)
+}
+
+// TestWarmupGatesReady proves /ready is not-ready until the configured
+// snapshots are loaded, and estimates are then served from the cache
+func TestWarmupGatesReady(t *testing.T) {
+	inner := &countingResolver{fixedResolver: fixedResolver{snapshot: pricing.NewSnapshotBuilder("aws", "us-east-1").
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(0.1), "hour", "USD").
+		Build()}}
+	eng := engine.NewEngine(engine.NewCachingResolver(inner), noUsage{}, nil, engine.EngineConfig{})
+	eng.SetLogger(logging.Nop())
+	eng.RegisterPlugin(computePlugin{})
+
+	config := DefaultConfig()
+	config.Warmup = []WarmupTarget{{Provider: "aws", Region: "us-east-1"}}
+	a := New(eng, nil, config)
+	a.SetLogger(nil)
+	router := a.Router()
+
+	ready := func() int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+		return rec.Code
+	}
+	if code := ready(); code != http.StatusServiceUnavailable {
+		t.Fatalf("ready before warm-up = %d, want 503", code)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/warmup", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("warm-up status = %d: %s", rec.Code, rec.Body)
+	}
+	var resp WarmupResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Ready || resp.Snapshots != 1 || resp.Rates != 1 {
+		t.Errorf("unexpected warm-up report %+v", resp)
+	}
+	if code := ready(); code != http.StatusOK {
+		t.Errorf("ready after warm-up = %d, want 200", code)
+	}
+
+	graph := model.NewInstanceGraph()
+	graph.AddInstance(&model.AssetInstance{ID: "inst-0", Address: "aws_instance.web", Provider: model.ResolvedProvider{Type: "aws"}})
+	if _, err := eng.Estimate(context.Background(), &engine.EstimateRequest{
+		Graph:           graph,
+		SnapshotRequest: engine.SnapshotRequest{Provider: "aws", Region: "us-east-1"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if inner.loads != 1 {
+		t.Errorf("snapshot loaded %d times, want 1 (served from cache)", inner.loads)
+	}
+}
+
+// flakyResolver fails the first `failures` snapshot loads, then succeeds
+type flakyResolver struct {
+	fixedResolver
+	failures int32
+	loads    atomic.Int32
+}
+
+func (r *flakyResolver) GetSnapshot(ctx context.Context, req engine.SnapshotRequest) (*pricing.PricingSnapshot, error) {
+	if r.loads.Add(1) <= r.failures {
+		return nil, errors.New("pricing database unavailable")
+	}
+	return r.fixedResolver.GetSnapshot(ctx, req)
+}
+
+// TestWarmupRetriesUntilReady proves the startup warm-up retries after a
+// failed load until the adapter is ready, and stops retrying on shutdown
+func TestWarmupRetriesUntilReady(t *testing.T) {
+	initial, max := warmupRetryInitial, warmupRetryMax
+	warmupRetryInitial, warmupRetryMax = time.Millisecond, 4*time.Millisecond
+	defer func() { warmupRetryInitial, warmupRetryMax = initial, max }()
+
+	newAdapter := func(resolver *flakyResolver) *Adapter {
+		eng := engine.NewEngine(resolver, noUsage{}, nil, engine.EngineConfig{})
+		eng.SetLogger(logging.Nop())
+		config := DefaultConfig()
+		config.Warmup = []WarmupTarget{{Provider: "aws", Region: "us-east-1"}}
+		a := New(eng, nil, config)
+		a.SetLogger(nil)
+		return a
+	}
+	snapshot := pricing.NewSnapshotBuilder("aws", "us-east-1").Build()
+
+	recovering := &flakyResolver{fixedResolver: fixedResolver{snapshot: snapshot}, failures: 3}
+	a := newAdapter(recovering)
+	done := make(chan struct{})
+	go func() {
+		a.warmupUntilReady(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("warm-up did not recover")
+	}
+	if !a.Ready() {
+		t.Error("adapter not ready after warm-up recovered")
+	}
+	if loads := recovering.loads.Load(); loads != 4 {
+		t.Errorf("snapshot loaded %d times, want 4 (3 failures, then success)", loads)
+	}
+
+	down := &flakyResolver{fixedResolver: fixedResolver{snapshot: snapshot}, failures: 1 << 30}
+	a = newAdapter(down)
+	done = make(chan struct{})
+	go func() {
+		a.warmupUntilReady(a.warmupCtx)
+		close(done)
+	}()
+	for down.loads.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := a.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("warm-up kept retrying after shutdown")
+	}
+	if a.Ready() {
+		t.Error("adapter ready although no warm-up succeeded")
+	}
+}
+
+// TestInvalidateSnapshots proves POST /api/v1/snapshots/invalidate drops
+// the engine's cached snapshots so the next estimate reloads them
+func TestInvalidateSnapshots(t *testing.T) {
+	inner := &countingResolver{fixedResolver: fixedResolver{snapshot: pricing.NewSnapshotBuilder("aws", "us-east-1").Build()}}
+	eng := engine.NewEngine(engine.NewCachingResolver(inner), noUsage{}, nil, engine.EngineConfig{})
+	eng.SetLogger(logging.Nop())
+	a := New(eng, nil, DefaultConfig())
+	a.SetLogger(nil)
+
+	load := func() {
+		if _, err := eng.Warmup(context.Background(), []engine.SnapshotRequest{{Provider: "aws", Region: "us-east-1"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	load()
+	load()
+	if inner.loads != 1 {
+		t.Fatalf("snapshot loaded %d times before invalidation, want 1", inner.loads)
+	}
+
+	rec := httptest.NewRecorder()
+	a.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/snapshots/invalidate", nil))
+	var resp InvalidateResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK || !resp.Invalidated {
+		t.Fatalf("invalidate = %d %s", rec.Code, rec.Body)
+	}
+	load()
+	if inner.loads != 2 {
+		t.Errorf("snapshot loaded %d times, want a reload after invalidation", inner.loads)
+	}
+}
+
+// regionResolver has an active snapshot only in its own region
+type regionResolver struct {
+	fixedResolver
+	region string
+}
+
+func (r *regionResolver) GetSnapshot(ctx context.Context, req engine.SnapshotRequest) (*pricing.PricingSnapshot, error) {
+	if req.Region != r.region {
+		return nil, fmt.Errorf("no active snapshot for %s/%s", req.Provider, req.Region)
+	}
+	return r.snapshot, nil
+}
+
+// TestReadyRequiresSnapshots proves /ready names each configured
+// provider/region without an active snapshot, while /health stays healthy
+func TestReadyRequiresSnapshots(t *testing.T) {
+	resolver := &regionResolver{fixedResolver: fixedResolver{snapshot: pricing.NewSnapshotBuilder("aws", "us-east-1").Build()}, region: "us-east-1"}
+	eng := engine.NewEngine(resolver, noUsage{}, nil, engine.EngineConfig{})
+	eng.SetLogger(logging.Nop())
+
+	get := func(a *Adapter, path string) (int, ReadyResponse) {
+		rec := httptest.NewRecorder()
+		a.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var resp ReadyResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return rec.Code, resp
+	}
+
+	config := DefaultConfig()
+	config.ReadySnapshots = []WarmupTarget{{Provider: "aws", Region: "us-east-1"}, {Provider: "aws", Region: "eu-west-1"}}
+	a := New(eng, nil, config)
+	a.SetLogger(nil)
+
+	code, resp := get(a, "/ready")
+	if code != http.StatusServiceUnavailable || resp.Status != "missing_snapshots" {
+		t.Fatalf("ready = %d %+v, want 503 missing_snapshots", code, resp)
+	}
+	if len(resp.Missing) != 1 || resp.Missing[0].Region != "eu-west-1" || resp.Missing[0].Error == "" {
+		t.Errorf("missing = %+v, want eu-west-1 only", resp.Missing)
+	}
+	if len(resp.Snapshots) != 2 || resp.Snapshots[0].SnapshotID == "" {
+		t.Errorf("snapshots = %+v", resp.Snapshots)
+	}
+	if code, resp := get(a, "/health"); code != http.StatusOK || resp.Status != "healthy" {
+		t.Errorf("health = %d %+v, want 200 healthy", code, resp)
+	}
+
+	config.ReadySnapshots = config.ReadySnapshots[:1]
+	if code, resp := get(a, "/ready"); code != http.StatusOK || resp.Status != "ready" {
+		t.Errorf("ready = %d %+v, want 200 ready", code, resp)
+	}
+}
+
+// TestRequestID proves a missing X-Request-ID is generated, a supplied one
+// is kept, and both are echoed in the header and error body
+func TestRequestID(t *testing.T) {
+	a := New(newTestEngine(), nil, nil)
+	a.SetLogger(nil)
+	a.newRequestID = func() string { return "generated-1" }
+	router := a.Router()
+
+	cases := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{name: "missing", header: "", want: "generated-1"},
+		{name: "supplied", header: "client-42", want: "client-42"},
+		{name: "invalid", header: "has spaces", want: "generated-1"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/estimate", strings.NewReader(`{}`))
+			if tc.header != "" {
+				req.Header.Set(RequestIDHeader, tc.header)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400", rec.Code)
+			}
+			if got := rec.Header().Get(RequestIDHeader); got != tc.want {
+				t.Errorf("%s header = %q, want %q", RequestIDHeader, got, tc.want)
+			}
+			var body struct {
+				RequestID string `json:"request_id"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode error body: %v", err)
+			}
+			if body.RequestID != tc.want {
+				t.Errorf("error body request_id = %q, want %q", body.RequestID, tc.want)
+			}
+		})
+	}
+}
+
+// TestEstimateFromHCL proves an estimate can be made from inline HCL
+// without a plan, resolving variables and reporting what was not priced
+func TestEstimateFromHCL(t *testing.T) {
+	a := New(newTestEngine(), nil, nil)
+	a.SetLogger(nil)
+	router := a.Router()
+
+	hcl := `
+variable "web_count" {
+  default = 2
+}
+
+variable "region" {}
+
+provider "aws" {
+  region = var.region
+}
+
+resource "aws_instance" "web" {
+  count         = var.web_count
+  instance_type = "t3.micro"
+
+  root_block_device {
+    volume_size = 20
+  }
+}
+
+resource "aws_instance" "workers" {
+  for_each      = toset(data.aws_subnets.private.ids)
+  instance_type = "t3.micro"
+}
+
+module "vpc" {
+  source = "terraform-aws-modules/vpc/aws"
+}
+`
+	body, _ := json.Marshal(map[string]interface{}{
+		"provider":    "aws",
+		"region":      "us-east-1",
+		"hcl_content": hcl,
+		"variables":   map[string]interface{}{"region": "eu-west-1"},
+	})
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/estimate", strings.NewReader(string(body))))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp EstimateResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Resources) != 2 || resp.Resources[0].Address != "aws_instance.web[0]" || resp.Resources[1].Address != "aws_instance.web[1]" {
+		t.Fatalf("resources = %+v", resp.Resources)
+	}
+	if len(resp.SymbolicResources) != 1 || resp.SymbolicResources[0].Address != "aws_instance.workers" {
+		t.Errorf("symbolic resources = %+v", resp.SymbolicResources)
+	}
+	if warnings := strings.Join(resp.Warnings, "\n"); !strings.Contains(warnings, "module vpc") {
+		t.Errorf("warnings should name the unexpanded module:\n%s", warnings)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/estimate",
+		strings.NewReader(`{"provider": "aws", "region": "us-east-1"}`)))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "hcl_path") {
+		t.Errorf("request without a source: status %d, body %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestGzipBodyLimits proves gzip bodies are accepted and one that would
+// decompress past MaxDecompressedSize is rejected with 413 without being
+// decompressed in full
+func TestGzipBodyLimits(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxBodySize = 1 << 20
+	config.MaxDecompressedSize = 1 << 20
+	a := New(newTestEngine(), nil, config)
+	a.SetLogger(nil)
+	router := a.Router()
+
+	compress := func(data []byte) *bytes.Buffer {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(data)
+		zw.Close()
+		return &buf
+	}
+	post := func(body io.Reader, encoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/estimate", body)
+		req.Header.Set("Content-Encoding", encoding)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	hcl := `{"provider": "aws", "region": "us-east-1", "hcl_content": "resource \"aws_instance\" \"web\" {}"}`
+	if rec := post(compress([]byte(hcl)), "gzip"); rec.Code != http.StatusOK {
+		t.Fatalf("gzip body: status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// 64MB of spaces compresses to roughly 64KB, well under MaxBodySize
+	bomb := compress(bytes.Repeat([]byte(" "), 64<<20))
+	if bomb.Len() >= int(config.MaxBodySize) {
+		t.Fatalf("compressed payload is %d bytes, want it under MaxBodySize", bomb.Len())
+	}
+	size := int64(bomb.Len())
+	counted := &countingReader{r: bomb}
+	rec := post(counted, "gzip")
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("bomb: status = %d, want 413: %s", rec.Code, rec.Body.String())
+	}
+	if counted.n > size/2 {
+		t.Errorf("read %d of %d compressed bytes, decompression should stop at the limit", counted.n, size)
+	}
+
+	if rec := post(strings.NewReader(strings.Repeat(" ", 2<<20)), ""); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized plain body: status = %d, want 413", rec.Code)
+	}
+	if rec := post(strings.NewReader(hcl), "br"); rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("unsupported encoding: status = %d, want 415", rec.Code)
+	}
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// TestEstimateBatch proves items are priced independently, a bad plan
+// fails only its own item, and the shared snapshot is loaded once
+func TestEstimateBatch(t *testing.T) {
+	inner := &countingResolver{fixedResolver: fixedResolver{snapshot: pricing.NewSnapshotBuilder("aws", "us-east-1").
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(0.1), "hour", "USD").
+		Build()}}
+	eng := engine.NewEngine(engine.NewCachingResolver(inner), noUsage{}, nil, engine.EngineConfig{})
+	eng.SetLogger(logging.Nop())
+	eng.RegisterPlugin(computePlugin{})
+	a := New(eng, nil, nil)
+	a.SetLogger(nil)
+
+	plan := func(names ...string) string {
+		var changes []string
+		for _, name := range names {
+			changes = append(changes, fmt.Sprintf(`{"address": "aws_instance.%s", "mode": "managed", "type": "aws_instance", "name": %q,
+				"provider_name": "registry.terraform.io/hashicorp/aws", "change": {"actions": ["create"], "after": {}}}`, name, name))
+		}
+		return `{"format_version": "1.2", "resource_changes": [` + strings.Join(changes, ",") + `]}`
+	}
+	body := `[
+		{"id": "app", "plan": ` + plan("web") + `, "provider": "aws", "region": "us-east-1"},
+		{"id": "state", "plan": {"format_version": "1.0", "values": {}}, "provider": "aws", "region": "us-east-1"},
+		{"id": "workers", "plan": ` + plan("a", "b") + `, "provider": "aws", "region": "us-east-1"}
+	]`
+
+	rec := httptest.NewRecorder()
+	a.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/estimate/batch", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var resp BatchEstimateResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Succeeded != 2 || resp.Failed != 1 || len(resp.Results) != 3 {
+		t.Fatalf("succeeded=%d failed=%d results=%d", resp.Succeeded, resp.Failed, len(resp.Results))
+	}
+	for i, id := range []string{"app", "state", "workers"} {
+		if resp.Results[i].ID != id {
+			t.Errorf("result %d = %q, want %q (request order)", i, resp.Results[i].ID, id)
+		}
+	}
+	if bad := resp.Results[1]; bad.Status != http.StatusBadRequest || bad.Error == "" || bad.Estimate != nil {
+		t.Errorf("invalid plan should fail its item with 400, got %+v", bad)
+	}
+
+	sum := decimal.Zero
+	for _, r := range []BatchEstimateResult{resp.Results[0], resp.Results[2]} {
+		if r.Status != http.StatusOK || r.Estimate == nil {
+			t.Fatalf("%s: status %d, error %q", r.ID, r.Status, r.Error)
+		}
+		sum = sum.Add(parseMoney(r.Estimate.TotalMonthlyCost))
+	}
+	if !sum.IsPositive() || !parseMoney(resp.TotalMonthlyCost).Equal(sum) {
+		t.Errorf("total = %s, want the items' sum %s", resp.TotalMonthlyCost, sum)
+	}
+	if inner.loads != 1 {
+		t.Errorf("snapshot loaded %d times, want 1 shared load", inner.loads)
+	}
+
+	rec = httptest.NewRecorder()
+	a.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/estimate/batch", strings.NewReader(`[]`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("empty batch status = %d, want 400", rec.Code)
+	}
+}
+
+// TestSignedEstimateVerifies proves a signed estimate verifies, and fails
+// once a value is changed or the signer is not trusted
+func TestSignedEstimateVerifies(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(nil)
+	a := New(newTestEngine(), nil, &Config{MaxBodySize: 1 << 20, SigningKey: key})
+	a.SetLogger(nil)
+	router := a.Router()
+
+	body := `{"provider": "aws", "region": "us-east-1", "hcl_content": "resource \"aws_instance\" \"web\" {\n  instance_type = \"t3.micro\"\n}\n"}`
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/estimate", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	signed := rec.Body.String()
+	if !strings.Contains(signed, `"signature":`) || !strings.Contains(signed, `"public_key":`) {
+		t.Fatalf("response is not signed: %s", signed)
+	}
+
+	verify := func(router http.Handler, doc string) VerifyResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/verify", strings.NewReader(doc)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("verify status = %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp VerifyResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	if resp := verify(router, signed); !resp.Valid {
+		t.Errorf("signed estimate did not verify: %+v", resp)
+	}
+	tampered := strings.Replace(signed, `"total_monthly_cost":"`, `"total_monthly_cost":"1`, 1)
+	if resp := verify(router, tampered); resp.Valid || !strings.Contains(resp.Error, "does not match") {
+		t.Errorf("tampered estimate: %+v", resp)
+	}
+
+	// A server trusting only another key rejects the signer
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	other := New(newTestEngine(), nil, &Config{MaxBodySize: 1 << 20,
+		TrustedKeys: []string{base64.StdEncoding.EncodeToString(otherPub)}})
+	if resp := verify(other.Router(), signed); resp.Valid || !strings.Contains(resp.Error, "untrusted") {
+		t.Errorf("untrusted signer: %+v", resp)
+	}
+
+	// A server with no trust anchor rejects a tampered estimate re-signed
+	// with the tamperer's own key, though its signature is self-consistent
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(tampered), &doc); err != nil {
+		t.Fatal(err)
+	}
+	delete(doc, "signature")
+	delete(doc, "public_key")
+	_, forger, _ := ed25519.GenerateKey(nil)
+	sig, err := attestation.Sign(doc, forger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc["signature"], doc["public_key"] = sig.Signature, sig.PublicKey
+	forged, _ := json.Marshal(doc)
+	anchorless := New(newTestEngine(), nil, &Config{MaxBodySize: 1 << 20})
+	anchorless.SetLogger(nil)
+	if resp := verify(anchorless.Router(), string(forged)); resp.Valid || !strings.Contains(resp.Error, "untrusted") {
+		t.Errorf("self-signed tampered estimate without trusted keys: %+v", resp)
+	}
+	if resp := verify(other.Router(), string(forged)); resp.Valid {
+		t.Errorf("self-signed tampered estimate verified: %+v", resp)
+	}
+}
+
+// downResolver has no pricing data, as when the database is unreachable
+type downResolver struct{ *fixedResolver }
+
+func (downResolver) GetSnapshot(ctx context.Context, req engine.SnapshotRequest) (*pricing.PricingSnapshot, error) {
+	return nil, fmt.Errorf("connection refused")
+}
+
+// TestEstimatePricingUnavailable proves a missing pricing database is a
+// 503 naming the provider/region and cause
+func TestEstimatePricingUnavailable(t *testing.T) {
+	eng := engine.NewEngine(downResolver{&fixedResolver{}}, noUsage{}, nil, engine.EngineConfig{})
+	eng.SetLogger(logging.Nop())
+	eng.RegisterPlugin(computePlugin{})
+	a := New(eng, nil, nil)
+	a.SetLogger(nil)
+
+	body := `{"provider": "aws", "region": "us-east-1", "hcl_content": "resource \"aws_instance\" \"web\" {\n  instance_type = \"t3.micro\"\n}\n"}`
+	rec := httptest.NewRecorder()
+	a.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/estimate", strings.NewReader(body)))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if want := "pricing data unavailable for aws/us-east-1: connection refused"; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("body %s should contain %q", rec.Body.String(), want)
+	}
+}
+
+// TestEstimateTargetedPlan proves a targeted plan's total is labeled a
+// subset until state supplies the resources it leaves out
+func TestEstimateTargetedPlan(t *testing.T) {
+	a := New(newTestEngine(), nil, nil)
+	a.SetLogger(nil)
+	router := a.Router()
+
+	plan := `{"format_version": "1.2",
+	  "resource_changes": [{"address": "aws_instance.web", "mode": "managed", "type": "aws_instance", "name": "web", "provider_name": "registry.terraform.io/hashicorp/aws",
+	    "change": {"actions": ["create"], "after": {"instance_type": "t3.micro"}}}],
+	  "planned_values": {"root_module": {"resources": [
+	    {"address": "aws_instance.web", "mode": "managed", "type": "aws_instance", "name": "web", "provider_name": "registry.terraform.io/hashicorp/aws", "values": {"instance_type": "t3.micro"}},
+	    {"address": "aws_instance.api", "mode": "managed", "type": "aws_instance", "name": "api", "provider_name": "registry.terraform.io/hashicorp/aws", "values": {"instance_type": "t3.micro"}}
+	  ]}}}`
+	state := `{"format_version": "1.0", "values": {"root_module": {"resources": [
+	  {"address": "aws_instance.api", "mode": "managed", "type": "aws_instance", "name": "api", "provider_name": "registry.terraform.io/hashicorp/aws", "values": {"instance_type": "t3.micro"}}
+	]}}}`
+
+	estimate := func(body map[string]interface{}) EstimateResponse {
+		t.Helper()
+		data, _ := json.Marshal(body)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/estimate", strings.NewReader(string(data))))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp EstimateResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := estimate(map[string]interface{}{"provider": "aws", "region": "us-east-1", "terraform_plan": json.RawMessage(plan)})
+	if resp.TotalScope != tfadapter.ScopeTargetedSubset || len(resp.Resources) != 1 {
+		t.Errorf("targeted plan: scope %q, %d resources", resp.TotalScope, len(resp.Resources))
+	}
+
+	resp = estimate(map[string]interface{}{"provider": "aws", "region": "us-east-1",
+		"terraform_plan": json.RawMessage(plan), "terraform_state": json.RawMessage(state)})
+	if resp.TotalScope != "" || len(resp.Resources) != 2 {
+		t.Errorf("with state: scope %q, %d resources", resp.TotalScope, len(resp.Resources))
+	}
+}
+
+// TestEstimateExcludesDestroyed proves a destroyed resource is reported as
+// savings and left out of the post-apply total
+func TestEstimateExcludesDestroyed(t *testing.T) {
+	a := New(newTestEngine(), nil, nil)
+	a.SetLogger(nil)
+
+	change := func(name, action string) string {
+		after := `{"instance_type": "t3.micro"}`
+		if action == "delete" {
+			after = "null"
+		}
+		return fmt.Sprintf(`{"address": "aws_instance.%s", "mode": "managed", "type": "aws_instance", "name": "%s", "provider_name": "registry.terraform.io/hashicorp/aws",
+		  "change": {"actions": ["%s"], "before": {"instance_type": "t3.micro"}, "after": %s}}`, name, name, action, after)
+	}
+	plan := `{"format_version": "1.2", "resource_changes": [` + strings.Join([]string{
+		change("new", "create"), change("resized", "update"), change("kept", "no-op"), change("old", "delete"),
+	}, ",") + `]}`
+	body, _ := json.Marshal(map[string]interface{}{"provider": "aws", "region": "us-east-1", "terraform_plan": json.RawMessage(plan)})
+
+	rec := httptest.NewRecorder()
+	a.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/estimate", strings.NewReader(string(body))))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp EstimateResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resp.Resources) != 3 {
+		t.Fatalf("got %d resources, want create, update and no-op only", len(resp.Resources))
+	}
+	for _, r := range resp.Resources {
+		if r.Address == "aws_instance.old" {
+			t.Errorf("destroyed resource listed: %+v", r)
+		}
+	}
+	each := resp.Resources[0].MonthlyCost
+	if resp.DestroyedCount != 1 || resp.DestroyedMonthlyCost != each {
+		t.Errorf("destroyed = %d at %s, want 1 at %s", resp.DestroyedCount, resp.DestroyedMonthlyCost, each)
+	}
+	amount, _ := decimal.NewFromString(strings.Fields(each)[0])
+	if amount.IsZero() {
+		t.Fatalf("resources priced at %s; the test needs a non-zero cost", each)
+	}
+	if want := amount.Mul(decimal.NewFromInt(3)).StringFixed(2) + " USD"; resp.TotalMonthlyCost != want {
+		t.Errorf("total = %s, want %s", resp.TotalMonthlyCost, want)
+	}
+}
+
+// TestEstimateDestroyPlan proves a plan destroying every resource reports
+// a zero post-destroy total and its savings as a negative delta
+func TestEstimateDestroyPlan(t *testing.T) {
+	a := New(newTestEngine(), nil, nil)
+	a.SetLogger(nil)
+
+	estimate := func(actions ...string) EstimateResponse {
+		t.Helper()
+		changes := make([]string, len(actions))
+		for i, action := range actions {
+			after := `{"instance_type": "t3.micro"}`
+			if action == "delete" {
+				after = "null"
+			}
+			changes[i] = fmt.Sprintf(`{"address": "aws_instance.web%d", "mode": "managed", "type": "aws_instance", "name": "web%d", "provider_name": "registry.terraform.io/hashicorp/aws",
+			  "change": {"actions": ["%s"], "before": {"instance_type": "t3.micro"}, "after": %s}}`, i, i, action, after)
+		}
+		plan := `{"format_version": "1.2", "resource_changes": [` + strings.Join(changes, ",") + `]}`
+		body, _ := json.Marshal(map[string]interface{}{"provider": "aws", "region": "us-east-1", "terraform_plan": json.RawMessage(plan)})
+
+		rec := httptest.NewRecorder()
+		a.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/estimate", strings.NewReader(string(body))))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp EstimateResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := estimate("delete", "delete")
+	if !resp.DestroyPlan {
+		t.Error("destroy_plan not set for an all-destroy plan")
+	}
+	if len(resp.Resources) != 0 || resp.TotalMonthlyCost != "0.00 USD" {
+		t.Errorf("post-destroy: %d resources at %s, want none at 0.00 USD", len(resp.Resources), resp.TotalMonthlyCost)
+	}
+	savings, err := decimal.NewFromString(strings.Fields(resp.DestroyedMonthlyCost)[0])
+	if err != nil || !savings.IsPositive() || resp.DestroyedCount != 2 {
+		t.Fatalf("destroyed = %d at %q, want 2 at a positive cost", resp.DestroyedCount, resp.DestroyedMonthlyCost)
+	}
+	if want := "-" + resp.DestroyedMonthlyCost; resp.MonthlyCostDelta != want {
+		t.Errorf("delta = %q, want %q", resp.MonthlyCostDelta, want)
+	}
+
+	resp = estimate("delete", "no-op")
+	if resp.DestroyPlan || resp.MonthlyCostDelta != "" {
+		t.Errorf("a plan keeping a resource is not a destroy plan: %v, %q", resp.DestroyPlan, resp.MonthlyCostDelta)
+	}
+}
+
+// slowPlugin takes a while to map each instance, counting the calls
+type slowPlugin struct {
+	computePlugin
+	mapped *atomic.Int32
+}
+
+func (p slowPlugin) MapInstance(inst *model.AssetInstance) ([]engine.CostComponent, error) {
+	p.mapped.Add(1)
+	time.Sleep(10 * time.Millisecond)
+	return p.computePlugin.MapInstance(inst)
+}
+
+// TestEstimateTimeout proves an estimate past EstimateTimeout answers 504
+// with a JSON error, and that the deadline stops the pricing loop
+func TestEstimateTimeout(t *testing.T) {
+	snapshot := pricing.NewSnapshotBuilder("aws", "us-east-1").
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(0.1), "hour", "USD").
+		Build()
+	eng := engine.NewEngine(&fixedResolver{snapshot: snapshot}, noUsage{}, nil, engine.EngineConfig{})
+	eng.SetLogger(logging.Nop())
+	var mapped atomic.Int32
+	eng.RegisterPlugin(slowPlugin{mapped: &mapped})
+
+	config := DefaultConfig()
+	config.EstimateTimeout = 50 * time.Millisecond
+	a := New(eng, nil, config)
+	a.SetLogger(nil)
+
+	body := `{"provider": "aws", "region": "us-east-1", "hcl_content": "resource \"aws_instance\" \"web\" {\n  count         = 100\n  instance_type = \"t3.micro\"\n}\n"}`
+	rec := httptest.NewRecorder()
+	a.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/estimate", strings.NewReader(body)))
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Success bool   `json:"success"`
+		Error   string `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("body is not JSON: %v: %s", err, rec.Body.String())
+	}
+	if resp.Success || resp.Error != "estimation timed out after 50ms" {
+		t.Errorf("body = %+v", resp)
+	}
+	if n := mapped.Load(); n == 0 || n >= 100 {
+		t.Errorf("mapped %d of 100 instances, want the loop stopped at the deadline", n)
+	}
+}
+
+// flushCounter records how many lines had been written at each flush
+type flushCounter struct {
+	*httptest.ResponseRecorder
+	flushedLines []int
+}
+
+func (f *flushCounter) Flush() {
+	f.flushedLines = append(f.flushedLines, strings.Count(f.Body.String(), "\n"))
+	f.ResponseRecorder.Flush()
+}
+
+// TestStreamEstimateFlushesThroughRouter proves each NDJSON line is flushed
+// as it is written behind the full middleware chain, not only when the
+// handler is called directly
+func TestStreamEstimateFlushesThroughRouter(t *testing.T) {
+	a := New(newTestEngine(), nil, nil)
+	a.SetLogger(nil)
+
+	body := `{"provider": "aws", "region": "us-east-1", "hcl_content": "resource \"aws_instance\" \"web\" {\n  count = 3\n  instance_type = \"t3.micro\"\n}"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/estimate", strings.NewReader(body))
+	req.Header.Set("Accept", ContentTypeNDJSON)
+	rec := &flushCounter{ResponseRecorder: httptest.NewRecorder()}
+	a.Router().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != ContentTypeNDJSON {
+		t.Fatalf("status = %d (%s): %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+	lines := strings.Count(rec.Body.String(), "\n")
+	if lines != 4 {
+		t.Fatalf("got %d lines, want 3 resources and a summary:\n%s", lines, rec.Body.String())
+	}
+	want := []int{1, 2, 3, 4}
+	if fmt.Sprint(rec.flushedLines) != fmt.Sprint(want) {
+		t.Errorf("lines flushed = %v, want each line flushed as written %v", rec.flushedLines, want)
+	}
+}
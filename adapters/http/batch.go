@@ -0,0 +1,233 @@
+// Package http - Batch estimates
+// CI for a monorepo can produce dozens of plans. POST
+// /api/v1/estimate/batch prices them in one request with bounded
+// concurrency, loading each distinct snapshot once up front. A failed item
+// is reported in its own result and never fails the batch.
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/engine"
+)
+
+const (
+	// defaultMaxBatchSize is the default for Config.MaxBatchSize
+	defaultMaxBatchSize = 100
+
+	// defaultBatchConcurrency is the default for Config.BatchConcurrency
+	defaultBatchConcurrency = 4
+)
+
+// BatchEstimateItem is one plan in a batch; the request body is an array
+// of them
+type BatchEstimateItem struct {
+	// ID identifies the item in the response (e.g. the plan's directory)
+	ID string `json:"id"`
+
+	// Plan is `terraform show -json` output
+	Plan json.RawMessage `json:"plan"`
+
+	// Provider (aws, azure, gcp)
+	Provider string `json:"provider"`
+
+	// Region
+	Region string `json:"region"`
+}
+
+// BatchEstimateResponse is the result of every item, in request order
+type BatchEstimateResponse struct {
+	// TotalMonthlyCost sums the successful items; it is empty when they
+	// are priced in different currencies
+	TotalMonthlyCost string `json:"total_monthly_cost"`
+
+	// Succeeded and Failed count items
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+
+	Results []BatchEstimateResult `json:"results"`
+
+	// Warnings about the batch as a whole
+	Warnings []string `json:"warnings,omitempty"`
+
+	Metadata ResponseMetadata `json:"metadata"`
+}
+
+// BatchEstimateResult is the outcome of one item: an estimate, or an
+// error with the status the single-plan endpoint would have returned
+type BatchEstimateResult struct {
+	ID       string            `json:"id"`
+	Status   int               `json:"status"`
+	Error    string            `json:"error,omitempty"`
+	Estimate *EstimateResponse `json:"estimate,omitempty"`
+}
+
+func (a *Adapter) handleEstimateBatch(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	ctx := r.Context()
+
+	var items []BatchEstimateItem
+	if err := a.parseJSON(r, &items); err != nil {
+		a.writeError(w, bodyErrorStatus(err), "invalid request body: "+err.Error())
+		return
+	}
+	if len(items) == 0 {
+		a.writeError(w, http.StatusBadRequest, "batch has no items")
+		return
+	}
+	if limit := a.maxBatchSize(); len(items) > limit {
+		a.writeError(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("batch has %d items, over the limit of %d", len(items), limit))
+		return
+	}
+	seen := make(map[string]bool, len(items))
+	for i, item := range items {
+		if item.ID == "" {
+			a.writeError(w, http.StatusBadRequest, fmt.Sprintf("item %d: id is required", i))
+			return
+		}
+		if seen[item.ID] {
+			a.writeError(w, http.StatusBadRequest, "duplicate item id: "+item.ID)
+			return
+		}
+		seen[item.ID] = true
+	}
+
+	requestID := RequestIDFromContext(ctx)
+	a.warmBatchSnapshots(ctx, items)
+	results := a.estimateBatch(ctx, items, requestID)
+	if ctx.Err() != nil {
+		a.writeError(w, statusForEngineError(ctx.Err()), "batch estimation canceled")
+		return
+	}
+
+	resp := &BatchEstimateResponse{
+		Results: results,
+		Metadata: ResponseMetadata{
+			RequestID: requestID,
+			Duration:  time.Since(start),
+			Version:   "1.0.0",
+			Timestamp: a.now(),
+		},
+	}
+	var total determinism.Money
+	mixed := false
+	for _, res := range results {
+		if res.Estimate == nil {
+			resp.Failed++
+			continue
+		}
+		resp.Succeeded++
+		switch m := res.Estimate.totalMonthly; {
+		case resp.Succeeded == 1:
+			total = m
+		case m.Currency() != total.Currency():
+			mixed = true
+		default:
+			total = total.Add(m)
+		}
+	}
+	switch {
+	case mixed:
+		resp.Warnings = append(resp.Warnings, "items are priced in different currencies; no total is reported")
+	case resp.Succeeded > 0:
+		resp.TotalMonthlyCost = total.Display(determinism.DisplayPlaces)
+	}
+	a.writeJSON(w, http.StatusOK, resp)
+}
+
+// estimateBatch estimates every item, at most BatchConcurrency at once
+func (a *Adapter) estimateBatch(ctx context.Context, items []BatchEstimateItem, requestID string) []BatchEstimateResult {
+	results := make([]BatchEstimateResult, len(items))
+	sem := make(chan struct{}, a.batchConcurrency())
+	var wg sync.WaitGroup
+	for i, item := range items {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, item BatchEstimateItem) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = a.estimateBatchItem(ctx, item, requestID)
+		}(i, item)
+	}
+	wg.Wait()
+	return results
+}
+
+// estimateBatchItem estimates one item as POST /api/v1/estimate would,
+// turning a panic into a failed item so one plan cannot sink the batch
+func (a *Adapter) estimateBatchItem(ctx context.Context, item BatchEstimateItem, requestID string) (result BatchEstimateResult) {
+	start := time.Now()
+	result.ID = item.ID
+	defer func() {
+		if rec := recover(); rec != nil {
+			result = BatchEstimateResult{ID: item.ID, Status: http.StatusInternalServerError, Error: fmt.Sprintf("internal error: %v", rec)}
+		}
+	}()
+
+	req := &EstimateRequest{TerraformPlan: item.Plan, Provider: item.Provider, Region: item.Region}
+	if len(req.TerraformPlan) == 0 {
+		result.Status, result.Error = http.StatusBadRequest, "plan is required"
+		return result
+	}
+	itemRequestID := requestID + "/" + item.ID
+	ctx, cancel := a.estimateContext(ctx)
+	defer cancel()
+	engineReq, status, err := a.prepareEstimate(ctx, req, itemRequestID)
+	if err != nil {
+		status, err = a.estimateTimedOut(ctx, status, err)
+		result.Status, result.Error = status, err.Error()
+		return result
+	}
+	resp, status, err := a.runEstimate(ctx, req, engineReq, itemRequestID, start)
+	if err != nil {
+		status, err = a.estimateTimedOut(ctx, status, err)
+		result.Status, result.Error = status, err.Error()
+		return result
+	}
+	result.Status, result.Estimate = http.StatusOK, resp
+	return result
+}
+
+// warmBatchSnapshots loads each distinct provider/region once before the
+// items run, so with a caching resolver concurrent items share the load
+// instead of racing to load the same snapshot. Failures surface per item.
+func (a *Adapter) warmBatchSnapshots(ctx context.Context, items []BatchEstimateItem) {
+	seen := make(map[engine.SnapshotRequest]bool)
+	var targets []engine.SnapshotRequest
+	for _, item := range items {
+		if item.Provider == "" || item.Region == "" {
+			continue
+		}
+		req := engine.SnapshotRequest{Provider: item.Provider, Region: item.Region}
+		if !seen[req] {
+			seen[req] = true
+			targets = append(targets, req)
+		}
+	}
+	if len(targets) > 0 {
+		a.engine.Warmup(ctx, targets)
+	}
+}
+
+// maxBatchSize is MaxBatchSize, defaulting to defaultMaxBatchSize
+func (a *Adapter) maxBatchSize() int {
+	if a.config.MaxBatchSize > 0 {
+		return a.config.MaxBatchSize
+	}
+	return defaultMaxBatchSize
+}
+
+// batchConcurrency is BatchConcurrency, defaulting to defaultBatchConcurrency
+func (a *Adapter) batchConcurrency() int {
+	if a.config.BatchConcurrency > 0 {
+		return a.config.BatchConcurrency
+	}
+	return defaultBatchConcurrency
+}
@@ -0,0 +1,109 @@
+// Package http - Request body limits
+// Bodies may be gzip-compressed (Content-Encoding: gzip). MaxBodySize
+// bounds the bytes read off the wire and MaxDecompressedSize the bytes the
+// gzip reader may produce, so a small compressed body cannot expand into
+// gigabytes; decompression stops as soon as the limit is passed.
+package http
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var (
+	// errBodyTooLarge is returned when a body exceeds MaxBodySize or,
+	// once decompressed, MaxDecompressedSize
+	errBodyTooLarge = errors.New("request body too large")
+
+	// errUnsupportedEncoding is returned for a Content-Encoding other than gzip
+	errUnsupportedEncoding = errors.New("unsupported content encoding")
+)
+
+// readBody reads the request body, decompressing it when gzip-encoded
+func (a *Adapter) readBody(r *http.Request) ([]byte, error) {
+	defer r.Body.Close()
+
+	raw := &limitedReader{r: r.Body, n: a.config.MaxBodySize}
+	var body io.Reader = raw
+	limit := a.config.MaxBodySize
+
+	switch encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))); encoding {
+	case "", "identity":
+	case "gzip":
+		zr, err := gzip.NewReader(raw)
+		if err != nil {
+			if raw.exceeded {
+				return nil, fmt.Errorf("%w: over %d bytes", errBodyTooLarge, a.config.MaxBodySize)
+			}
+			return nil, fmt.Errorf("invalid gzip body: %w", err)
+		}
+		defer zr.Close()
+		body = zr
+		limit = a.maxDecompressedSize()
+	default:
+		return nil, fmt.Errorf("%w %q", errUnsupportedEncoding, encoding)
+	}
+
+	// Read one byte past the limit to tell "exactly at" from "over"
+	data, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if raw.exceeded {
+		return nil, fmt.Errorf("%w: over %d bytes", errBodyTooLarge, a.config.MaxBodySize)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: over %d bytes decompressed", errBodyTooLarge, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// maxDecompressedSize is MaxDecompressedSize, defaulting to MaxBodySize
+func (a *Adapter) maxDecompressedSize() int64 {
+	if a.config.MaxDecompressedSize > 0 {
+		return a.config.MaxDecompressedSize
+	}
+	return a.config.MaxBodySize
+}
+
+// bodyErrorStatus maps a readBody or decoding error to its HTTP status
+func bodyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errBodyTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, errUnsupportedEncoding):
+		return http.StatusUnsupportedMediaType
+	}
+	return http.StatusBadRequest
+}
+
+// limitedReader reads at most n bytes and records whether the source had
+// more, unlike io.LimitReader which ends silently
+type limitedReader struct {
+	r        io.Reader
+	n        int64
+	exceeded bool
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		// Probe for one more byte to tell a full read from an overflow
+		var probe [1]byte
+		n, err := l.r.Read(probe[:])
+		if n > 0 {
+			l.exceeded = true
+			return 0, errBodyTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
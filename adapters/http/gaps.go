@@ -0,0 +1,82 @@
+// Package http - Coverage gap endpoint
+// POST /api/v1/coverage/gaps classifies the resources of many plans
+// against the catalog and ranks the resource types left uncosted, so
+// platform teams can decide which mappers to write next. Nothing is priced.
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	tfadapter "terraform-cost/adapters/terraform"
+	"terraform-cost/core/catalog"
+)
+
+// CoverageGapsRequest is the body of POST /api/v1/coverage/gaps
+type CoverageGapsRequest struct {
+	// Plans are `terraform show -json` outputs, one per repository
+	Plans []json.RawMessage `json:"plans"`
+
+	// Top lists only the N most frequent gaps (0 = all)
+	Top int `json:"top,omitempty"`
+}
+
+func (a *Adapter) handleCoverageGaps(w http.ResponseWriter, r *http.Request) {
+	var req CoverageGapsRequest
+	if err := a.parseJSON(r, &req); err != nil {
+		a.writeError(w, bodyErrorStatus(err), "invalid request body: "+err.Error())
+		return
+	}
+	if len(req.Plans) == 0 {
+		a.writeError(w, http.StatusBadRequest, "plans is required")
+		return
+	}
+	if req.Top < 0 {
+		a.writeError(w, http.StatusBadRequest, "top must not be negative")
+		return
+	}
+
+	report, err := coverageGaps(req.Plans)
+	if err != nil {
+		a.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Top > 0 && len(report.Gaps) > req.Top {
+		report.Gaps = report.Gaps[:req.Top]
+	}
+	a.writeJSON(w, http.StatusOK, report)
+}
+
+// coverageGaps aggregates the gaps of the resources each plan leaves in
+// place after the apply
+func coverageGaps(plans []json.RawMessage) (*catalog.GapReport, error) {
+	tf, err := tfadapter.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	cat := catalog.NewCatalog()
+	catalog.RegisterAWS(cat)
+	catalog.RegisterAzure(cat)
+	catalog.RegisterGCP(cat)
+
+	gaps := catalog.NewGapAggregator(cat)
+	for i, raw := range plans {
+		plan, err := tf.ParsePlanJSON(raw)
+		if err != nil {
+			return nil, fmt.Errorf("plans[%d]: %w", i, err)
+		}
+		extraction, err := tf.ExtractPlan(plan)
+		if err != nil {
+			return nil, fmt.Errorf("plans[%d]: invalid plan: %w", i, err)
+		}
+		resourceTypes := make([]string, 0, len(extraction.Resources))
+		for _, res := range extraction.Resources {
+			if res.InHead() {
+				resourceTypes = append(resourceTypes, res.Type)
+			}
+		}
+		gaps.AddPlan(resourceTypes)
+	}
+	return gaps.Report(), nil
+}
@@ -0,0 +1,168 @@
+// Package http - NDJSON streaming for large estimates
+// With "Accept: application/x-ndjson" the estimate is written as one JSON
+// object per line: a "resource" line per instance as it is priced, then a
+// single "summary" line with totals over every instance. Unless a policy
+// file needs every instance, nothing is buffered, so memory stays flat
+// regardless of plan size.
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/engine"
+	"terraform-cost/internal/logging"
+)
+
+// ContentTypeNDJSON is the media type for newline-delimited JSON
+const ContentTypeNDJSON = "application/x-ndjson"
+
+// NDJSON line types
+const (
+	NDJSONLineResource = "resource"
+	NDJSONLineSummary  = "summary"
+	NDJSONLineError    = "error"
+)
+
+// NDJSONLine is one line of a streamed estimate
+type NDJSONLine struct {
+	Type     string                `json:"type"`
+	Resource *ResourceCostResponse `json:"resource,omitempty"`
+	Summary  *NDJSONSummary        `json:"summary,omitempty"`
+	Error    string                `json:"error,omitempty"`
+}
+
+// NDJSONSummary closes a streamed estimate
+type NDJSONSummary struct {
+	TotalMonthlyCost  string                     `json:"total_monthly_cost"`
+	TotalHourlyCost   string                     `json:"total_hourly_cost"`
+	Confidence        float64                    `json:"confidence"`
+	ResourceCount     int                        `json:"resource_count"`
+	Coverage          CoverageResponse           `json:"coverage"`
+	Snapshot          SnapshotResponse           `json:"snapshot"`
+	Warnings          []string                   `json:"warnings,omitempty"`
+	Degraded          bool                       `json:"degraded,omitempty"`
+	SymbolicResources []SymbolicResourceResponse `json:"symbolic_resources,omitempty"`
+	Policies          []PolicyResponse           `json:"policies,omitempty"`
+	Metadata          ResponseMetadata           `json:"metadata"`
+}
+
+// wantsNDJSON reports whether the client asked for a streamed response
+func wantsNDJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), ContentTypeNDJSON)
+}
+
+// streamEstimate runs the estimate, writing each resource as it is priced.
+// The status is sent with the first line, so an estimate that fails before
+// pricing anything (no snapshot, engine errors) is an HTTP error like the
+// buffered response. Once a line is written the status is committed, and
+// later failures are reported as a final "error" line. A failed write
+// means the client is gone, so the estimate is aborted and nothing more
+// is written.
+//
+// With a policy file the streamed costs are kept so policies see every
+// instance; without one nothing is buffered.
+func (a *Adapter) streamEstimate(ctx context.Context, w http.ResponseWriter, req *engine.EstimateRequest, requestID string, start time.Time) {
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	started := false
+	var writeErr error
+	write := func(line NDJSONLine) error {
+		if writeErr != nil {
+			return writeErr
+		}
+		if !started {
+			started = true
+			w.Header().Set("Content-Type", ContentTypeNDJSON)
+			w.WriteHeader(http.StatusOK)
+		}
+		if err := enc.Encode(line); err != nil {
+			writeErr = err
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+
+	// Totals are summed from the streamed resources as displayed, so the
+	// summary matches the lines a client adds up
+	count := 0
+	monthly, hourly := determinism.Zero("USD"), determinism.Zero("USD")
+	var streamed []*engine.InstanceCost
+	req.OnInstanceCost = func(cost *engine.InstanceCost) error {
+		rc := newResourceCostResponse(cost)
+		count++
+		monthly = monthly.Add(cost.DisplayMonthlyCost())
+		hourly = hourly.Add(cost.DisplayHourlyCost())
+		if a.config.Policies != nil {
+			streamed = append(streamed, cost)
+		}
+		return write(NDJSONLine{Type: NDJSONLineResource, Resource: &rc})
+	}
+
+	result, err := a.engine.Estimate(ctx, req)
+	if writeErr != nil {
+		a.logger.Warn("streamed estimate aborted: client write failed",
+			logging.String("request_id", requestID),
+			logging.Int("resources_written", count),
+			logging.Err(writeErr))
+		return
+	}
+	if err != nil {
+		a.logger.Warn("streamed estimate failed",
+			logging.String("request_id", requestID),
+			logging.Int("resources_written", count),
+			logging.Err(err))
+		if !started {
+			status, err := a.estimateTimedOut(ctx, statusForEngineError(err), fmt.Errorf("estimation failed: %w", err))
+			a.writeError(w, status, err.Error())
+			return
+		}
+		message := "estimation failed: " + err.Error()
+		if a.deadlinePassed(ctx) {
+			_, timedOut := a.estimateTimedOut(ctx, 0, err)
+			message = timedOut.Error()
+		}
+		write(NDJSONLine{Type: NDJSONLineError, Error: message})
+		return
+	}
+
+	summary := &NDJSONSummary{
+		TotalMonthlyCost:  monthly.Display(determinism.DisplayPlaces),
+		TotalHourlyCost:   hourly.Display(determinism.HourlyDisplayPlaces),
+		Confidence:        result.Confidence.Score,
+		ResourceCount:     count,
+		Warnings:          result.Warnings,
+		Degraded:          result.Degraded,
+		SymbolicResources: newSymbolicResourcesResponse(result),
+		Metadata: ResponseMetadata{
+			RequestID: requestID,
+			Duration:  time.Since(start),
+			Version:   "1.0.0",
+			Timestamp: a.now(),
+		},
+	}
+	summary.Coverage = newCoverageResponse(result.CoverageReport)
+	if result.Snapshot != nil {
+		summary.Snapshot = newSnapshotResponse(result.Snapshot)
+	}
+	if a.config.Policies != nil {
+		// The engine handed the costs to the stream instead of the result
+		for _, cost := range streamed {
+			result.InstanceCosts.Set(cost.InstanceID, cost)
+		}
+		policies, err := a.evaluatePolicies(ctx, result)
+		if err != nil {
+			summary.Warnings = append(summary.Warnings, "policy evaluation failed: "+err.Error())
+		}
+		summary.Policies = policies
+	}
+	write(NDJSONLine{Type: NDJSONLineSummary, Summary: summary})
+}
@@ -0,0 +1,46 @@
+// Package http - Policy results
+// When the adapter is configured with a policy file, each estimate response
+// carries the outcome of every policy so API clients can gate on it.
+package http
+
+import (
+	"context"
+
+	"terraform-cost/core/engine"
+)
+
+// PolicyResponse is the outcome of one policy
+type PolicyResponse struct {
+	Name              string   `json:"name"`
+	Passed            bool     `json:"passed"`
+	Severity          string   `json:"severity"`
+	Message           string   `json:"message"`
+	AffectedCost      string   `json:"affected_cost,omitempty"`
+	AffectedInstances []string `json:"affected_instances,omitempty"`
+}
+
+// evaluatePolicies runs the configured policy file over an estimate
+func (a *Adapter) evaluatePolicies(ctx context.Context, result *engine.EstimationResult) ([]PolicyResponse, error) {
+	outcome, err := engine.NewDeepPolicyEvaluator(a.config.Policies.Evaluator()).Evaluate(ctx, result)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make([]PolicyResponse, 0, len(outcome.Policies))
+	for _, p := range outcome.Policies {
+		resp := PolicyResponse{
+			Name:     p.Name,
+			Passed:   p.Passed,
+			Severity: p.Severity,
+			Message:  p.Message,
+		}
+		if !p.CostImpact.IsZero() {
+			resp.AffectedCost = p.CostImpact.String()
+		}
+		for _, id := range p.AffectedInstances {
+			resp.AffectedInstances = append(resp.AffectedInstances, string(id))
+		}
+		policies = append(policies, resp)
+	}
+	return policies, nil
+}
@@ -0,0 +1,94 @@
+// Package http - Readiness
+// /ready tells a load balancer whether to route estimates here. A server
+// backing a multi-region estimator is only useful when the snapshots it
+// serves exist, so Config.ReadySnapshots lists provider/region pairs that
+// must each have an active snapshot; a missing one makes /ready return 503
+// naming it. /health stays a pure liveness check.
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"terraform-cost/core/engine"
+)
+
+// readyCheckTimeout bounds the snapshot checks of one readiness probe
+const readyCheckTimeout = 5 * time.Second
+
+// ReadyResponse is the body of GET /ready
+type ReadyResponse struct {
+	// Status is ready, warming_up or missing_snapshots
+	Status string `json:"status"`
+
+	// Snapshots are the ReadySnapshots checks, in configured order
+	Snapshots []ReadySnapshot `json:"snapshots,omitempty"`
+
+	// Missing lists the checks that found no active snapshot
+	Missing []ReadySnapshot `json:"missing,omitempty"`
+}
+
+// ReadySnapshot is the outcome of one readiness requirement
+type ReadySnapshot struct {
+	WarmupTarget
+	SnapshotID string `json:"snapshot_id,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+func (a *Adapter) handleReady(w http.ResponseWriter, r *http.Request) {
+	// Not ready until configured snapshots are loaded, so load balancers
+	// do not route the first estimates to a cold cache
+	if !a.Ready() {
+		a.writeJSON(w, http.StatusServiceUnavailable, &ReadyResponse{Status: "warming_up"})
+		return
+	}
+
+	resp := &ReadyResponse{Status: "ready"}
+	if len(a.config.ReadySnapshots) > 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), readyCheckTimeout)
+		defer cancel()
+		resp.Snapshots = a.checkReadySnapshots(ctx)
+		for _, s := range resp.Snapshots {
+			if s.Error != "" {
+				resp.Missing = append(resp.Missing, s)
+			}
+		}
+	}
+	if len(resp.Missing) > 0 {
+		resp.Status = "missing_snapshots"
+		a.writeJSON(w, http.StatusServiceUnavailable, resp)
+		return
+	}
+	a.writeJSON(w, http.StatusOK, resp)
+}
+
+// checkReadySnapshots looks up the active snapshot of each ReadySnapshots
+// target through the engine's resolver, so a caching resolver answers
+// repeated probes from memory
+func (a *Adapter) checkReadySnapshots(ctx context.Context) []ReadySnapshot {
+	reqs := make([]engine.SnapshotRequest, 0, len(a.config.ReadySnapshots))
+	for _, t := range a.config.ReadySnapshots {
+		reqs = append(reqs, engine.SnapshotRequest{Provider: t.Provider, Region: t.Region, Alias: t.Alias})
+	}
+
+	checks := make([]ReadySnapshot, 0, len(reqs))
+	report, err := a.engine.Warmup(ctx, reqs)
+	if err != nil {
+		for _, t := range a.config.ReadySnapshots {
+			checks = append(checks, ReadySnapshot{WarmupTarget: t, Error: err.Error()})
+		}
+		return checks
+	}
+	for _, t := range report.Targets {
+		check := ReadySnapshot{
+			WarmupTarget: WarmupTarget{Provider: t.Provider, Region: t.Region, Alias: t.Alias},
+			SnapshotID:   string(t.SnapshotID),
+		}
+		if t.Err != nil {
+			check.Error = t.Err.Error()
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
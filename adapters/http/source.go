@@ -0,0 +1,118 @@
+// Package http - Estimate sources
+// An estimate request carries its infrastructure as plan JSON, a local HCL
+// directory or inline HCL. HCL is read by the evaluation pipeline directly,
+// so no terraform binary, backend or credentials are needed.
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	tfadapter "terraform-cost/adapters/terraform"
+	_ "terraform-cost/adapters/terraform/hcl"
+	"terraform-cost/core/model"
+	"terraform-cost/core/terraform"
+	"terraform-cost/internal/config"
+)
+
+// errNoSource is returned when a request names no infrastructure
+var errNoSource = errors.New("one of terraform_plan, hcl_path or hcl_content is required")
+
+// estimateSource is the instance graph built from a request
+type estimateSource struct {
+	graph               *model.InstanceGraph
+	cardinalityWarnings []terraform.CardinalityWarning
+	warnings            []string
+
+	// scope labels a graph covering part of the infrastructure
+	scope string
+}
+
+// buildSource builds the instance graph for a request from plan JSON when
+// given, otherwise from HCL. Errors are the client's to fix.
+func (a *Adapter) buildSource(ctx context.Context, req *EstimateRequest) (*estimateSource, error) {
+	switch {
+	case len(req.TerraformPlan) > 0:
+		return planSource(req)
+	case req.HCLContent != "":
+		dir, err := os.MkdirTemp("", "terraform-cost-hcl-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to stage hcl_content: %w", err)
+		}
+		defer os.RemoveAll(dir)
+		if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(req.HCLContent), 0o600); err != nil {
+			return nil, fmt.Errorf("failed to stage hcl_content: %w", err)
+		}
+		return a.hclSource(ctx, dir, req)
+	case req.HCLPath != "":
+		if info, err := os.Stat(req.HCLPath); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("hcl_path %q is not a directory", req.HCLPath)
+		}
+		return a.hclSource(ctx, req.HCLPath, req)
+	}
+	return nil, errNoSource
+}
+
+// planSource extracts the graph from `terraform show -json` output. A
+// targeted plan is completed from the request's state when given.
+func planSource(req *EstimateRequest) (*estimateSource, error) {
+	tf, err := tfadapter.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := tf.ParsePlanJSON(req.TerraformPlan)
+	if err != nil {
+		return nil, err
+	}
+	extraction, err := tf.ExtractPlan(plan)
+	if err != nil {
+		return nil, fmt.Errorf("invalid plan: %w", err)
+	}
+	if len(req.TerraformState) > 0 {
+		state, err := tf.ParseStateJSON(req.TerraformState)
+		if err != nil {
+			return nil, err
+		}
+		if err := tf.FillUntargeted(extraction, state); err != nil {
+			return nil, fmt.Errorf("invalid state: %w", err)
+		}
+	}
+	graph, err := tfadapter.BuildInstanceGraph(extraction.Resources, req.Region)
+	if err != nil {
+		return nil, fmt.Errorf("invalid plan: %w", err)
+	}
+	return &estimateSource{
+		graph:    graph,
+		warnings: extraction.Metadata.Warnings,
+		scope:    extraction.Metadata.Scope,
+	}, nil
+}
+
+// hclSource runs the evaluation pipeline over a module directory
+func (a *Adapter) hclSource(ctx context.Context, dir string, req *EstimateRequest) (*estimateSource, error) {
+	pipeline := a.pipeline
+	if pipeline == nil {
+		pipeline = config.Get().Terraform.NewPipeline()
+	}
+	if len(req.Variables) > 0 {
+		pipeline = pipeline.WithVariables(req.Variables)
+	}
+
+	result, err := pipeline.Execute(ctx, &terraform.ScanInput{
+		RootPath:      dir,
+		Workspace:     "default",
+		DefaultRegion: req.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HCL: %w", err)
+	}
+
+	return &estimateSource{
+		graph:               result.Graph,
+		cardinalityWarnings: result.CardinalityWarnings,
+		warnings:            result.WarningMessages(),
+	}, nil
+}
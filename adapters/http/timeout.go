@@ -0,0 +1,37 @@
+// Package http - Estimate deadlines
+// Config.EstimateTimeout bounds building and pricing one estimate, so a
+// pathological plan fails with a 504 and a JSON error instead of holding
+// a handler until WriteTimeout cuts the response off. The engine checks
+// its context between instances, so a deadline stops the pricing loop;
+// each item of a batch gets its own deadline.
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// estimateContext bounds one estimate by EstimateTimeout; zero or
+// negative leaves it unbounded
+func (a *Adapter) estimateContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if a.config.EstimateTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, a.config.EstimateTimeout)
+}
+
+// deadlinePassed reports whether the estimate's EstimateTimeout passed
+func (a *Adapter) deadlinePassed(ctx context.Context) bool {
+	return a.config.EstimateTimeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
+
+// estimateTimedOut rewrites the failure of an estimate whose deadline
+// passed as a 504 naming the timeout; other failures are returned as is
+func (a *Adapter) estimateTimedOut(ctx context.Context, status int, err error) (int, error) {
+	if a.deadlinePassed(ctx) {
+		return http.StatusGatewayTimeout, fmt.Errorf("estimation timed out after %s", a.config.EstimateTimeout)
+	}
+	return status, err
+}
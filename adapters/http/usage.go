@@ -0,0 +1,146 @@
+// Package http - Usage override validation endpoint
+// POST /api/v1/usage/validate checks a usage map against a plan before an
+// estimate is run, so mistyped keys and uncovered usage-based components
+// are reported instead of silently priced as defaults or symbolic.
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	tfadapter "terraform-cost/adapters/terraform"
+	"terraform-cost/core/engine"
+	"terraform-cost/core/model"
+)
+
+// UsageValidateRequest is the body of POST /api/v1/usage/validate
+type UsageValidateRequest struct {
+	// TerraformPlan is the `terraform show -json` output
+	TerraformPlan json.RawMessage `json:"terraform_plan"`
+
+	// Region is used for resources whose provider region is not in the plan
+	Region string `json:"region,omitempty"`
+
+	// UsageOverrides is the usage map to validate, keyed by instance ID
+	UsageOverrides map[string]map[string]float64 `json:"usage_overrides"`
+
+	// UsageProfile is applied as it would be for the estimate
+	UsageProfile string `json:"usage_profile,omitempty"`
+}
+
+// UsageValidateResponse reports how a usage map matches the plan
+type UsageValidateResponse struct {
+	Valid       bool                      `json:"valid"`
+	Covered     []UsageCoveredResponse    `json:"covered"`
+	Missing     []UsageMissingResponse    `json:"missing"`
+	UnknownKeys []UsageUnknownKeyResponse `json:"unknown_keys"`
+	Warnings    []string                  `json:"warnings,omitempty"`
+}
+
+// UsageCoveredResponse is an instance with usage provided
+type UsageCoveredResponse struct {
+	InstanceID string   `json:"instance_id"`
+	Address    string   `json:"address"`
+	Components []string `json:"components"`
+}
+
+// UsageMissingResponse is a symbolic component still lacking usage
+type UsageMissingResponse struct {
+	InstanceID string `json:"instance_id"`
+	Address    string `json:"address"`
+	Component  string `json:"component"`
+}
+
+// UsageUnknownKeyResponse is a usage key that matches nothing in the plan
+type UsageUnknownKeyResponse struct {
+	InstanceID string `json:"instance_id"`
+	Component  string `json:"component,omitempty"`
+	Reason     string `json:"reason"`
+}
+
+func (a *Adapter) handleValidateUsage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req UsageValidateRequest
+	if err := a.parseJSON(r, &req); err != nil {
+		a.writeError(w, bodyErrorStatus(err), "invalid request body: "+err.Error())
+		return
+	}
+	if len(req.TerraformPlan) == 0 {
+		a.writeError(w, http.StatusBadRequest, "terraform_plan is required")
+		return
+	}
+	if req.UsageProfile != "" {
+		if _, ok := engine.LookupUsageProfile(req.UsageProfile); !ok {
+			a.writeError(w, http.StatusBadRequest, "unknown usage_profile: "+req.UsageProfile)
+			return
+		}
+	}
+
+	tf, err := tfadapter.New(nil)
+	if err != nil {
+		a.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	plan, err := tf.ParsePlanJSON(req.TerraformPlan)
+	if err != nil {
+		a.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	extraction, err := tf.ExtractPlan(plan)
+	if err != nil {
+		a.writeError(w, http.StatusUnprocessableEntity, "invalid plan: "+err.Error())
+		return
+	}
+	graph, err := tfadapter.BuildInstanceGraph(extraction.Resources, req.Region)
+	if err != nil {
+		a.writeError(w, http.StatusUnprocessableEntity, "invalid plan: "+err.Error())
+		return
+	}
+
+	overrides := make(map[model.InstanceID]map[string]float64, len(req.UsageOverrides))
+	for k, v := range req.UsageOverrides {
+		overrides[model.InstanceID(k)] = v
+	}
+
+	validation, err := a.engine.ValidateUsage(ctx, graph, overrides, req.UsageProfile)
+	if err != nil {
+		a.writeError(w, http.StatusInternalServerError, "usage validation failed: "+err.Error())
+		return
+	}
+
+	resp := newUsageValidateResponse(validation)
+	resp.Warnings = extraction.Metadata.Warnings
+	a.writeJSON(w, http.StatusOK, resp)
+}
+
+func newUsageValidateResponse(v *engine.UsageValidation) *UsageValidateResponse {
+	resp := &UsageValidateResponse{
+		Valid:       v.Valid(),
+		Covered:     make([]UsageCoveredResponse, 0, len(v.Covered)),
+		Missing:     make([]UsageMissingResponse, 0, len(v.Missing)),
+		UnknownKeys: make([]UsageUnknownKeyResponse, 0, len(v.UnknownKeys)),
+	}
+	for _, c := range v.Covered {
+		resp.Covered = append(resp.Covered, UsageCoveredResponse{
+			InstanceID: string(c.InstanceID),
+			Address:    string(c.Address),
+			Components: c.Components,
+		})
+	}
+	for _, m := range v.Missing {
+		resp.Missing = append(resp.Missing, UsageMissingResponse{
+			InstanceID: string(m.InstanceID),
+			Address:    string(m.Address),
+			Component:  m.Component,
+		})
+	}
+	for _, k := range v.UnknownKeys {
+		resp.UnknownKeys = append(resp.UnknownKeys, UsageUnknownKeyResponse{
+			InstanceID: string(k.InstanceID),
+			Component:  k.Component,
+			Reason:     k.Reason,
+		})
+	}
+	return resp
+}
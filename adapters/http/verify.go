@@ -0,0 +1,87 @@
+// Package http - Signed estimates
+// With Config.SigningKey set, estimate responses carry an Ed25519
+// signature and the public key that verifies it, so an archived response
+// can later be shown untampered. POST /api/v1/verify checks such a
+// document, including CI JSON signed with the same scheme.
+package http
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+
+	"terraform-cost/internal/attestation"
+)
+
+// errNoTrustAnchor rejects signers when the server trusts no key
+var errNoTrustAnchor = fmt.Errorf("%w: the server has no signing key or trusted keys configured", attestation.ErrUntrustedKey)
+
+// VerifyResponse is the body of POST /api/v1/verify
+type VerifyResponse struct {
+	Valid     bool   `json:"valid"`
+	PublicKey string `json:"public_key,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// sign embeds a signature over the response when a key is configured
+func (a *Adapter) sign(resp *EstimateResponse) error {
+	if a.config.SigningKey == nil {
+		return nil
+	}
+	sig, err := attestation.Sign(resp, a.config.SigningKey)
+	if err != nil {
+		return fmt.Errorf("failed to sign response: %w", err)
+	}
+	resp.Signature, resp.PublicKey = sig.Signature, sig.PublicKey
+	return nil
+}
+
+// trustedKeys are SigningKey's public key and Config.TrustedKeys
+func (a *Adapter) trustedKeys() ([]ed25519.PublicKey, error) {
+	var keys []ed25519.PublicKey
+	if a.config.SigningKey != nil {
+		keys = append(keys, a.config.SigningKey.Public().(ed25519.PublicKey))
+	}
+	for _, s := range a.config.TrustedKeys {
+		key, err := attestation.ParsePublicKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// handleVerify checks a signed estimate. An invalid or untrusted
+// signature is a 200 with valid=false; only unreadable bodies are errors.
+// Without a signing key or trusted keys there is nothing to trust: a
+// correct signature only shows the document matches the key embedded in
+// it, which anyone re-signing a tampered estimate can provide, so every
+// signer is untrusted.
+func (a *Adapter) handleVerify(w http.ResponseWriter, r *http.Request) {
+	body, err := a.readBody(r)
+	if err != nil {
+		a.writeError(w, bodyErrorStatus(err), "invalid request body: "+err.Error())
+		return
+	}
+	trusted, err := a.trustedKeys()
+	if err != nil {
+		a.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := &VerifyResponse{Valid: true}
+	key, err := attestation.Verify(body, trusted...)
+	if key != nil {
+		resp.PublicKey = base64.StdEncoding.EncodeToString(key)
+	}
+	if err == nil && len(trusted) == 0 {
+		err = errNoTrustAnchor
+	}
+	if err != nil {
+		resp.Valid = false
+		resp.Error = err.Error()
+	}
+	a.writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,189 @@
+// Package http - Pricing warm-up endpoint
+// POST /api/v1/warmup loads snapshots into the engine's resolver cache and
+// reports what was loaded; /ready stays not-ready until a warm-up succeeds.
+// POST /api/v1/snapshots/invalidate drops the cache after a snapshot is
+// activated or deactivated, so the next estimate prices from the new one.
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"terraform-cost/core/engine"
+	"terraform-cost/internal/logging"
+)
+
+// The startup warm-up retries after waiting warmupRetryInitial, doubling
+// the wait up to warmupRetryMax
+var (
+	warmupRetryInitial = time.Second
+	warmupRetryMax     = time.Minute
+)
+
+// WarmupTarget is a provider/region/alias snapshot to load
+type WarmupTarget struct {
+	Provider string `json:"provider"`
+	Region   string `json:"region"`
+	Alias    string `json:"alias,omitempty"`
+}
+
+// WarmupRequest is the body of POST /api/v1/warmup
+type WarmupRequest struct {
+	// Targets overrides the configured warm-up targets
+	Targets []WarmupTarget `json:"targets,omitempty"`
+}
+
+// WarmupResponse reports loaded snapshots
+type WarmupResponse struct {
+	Ready      bool                   `json:"ready"`
+	Snapshots  int                    `json:"snapshots"`
+	Rates      int                    `json:"rates"`
+	DurationMs int64                  `json:"duration_ms"`
+	Targets    []WarmupTargetResponse `json:"targets"`
+}
+
+// WarmupTargetResponse is the outcome of one target
+type WarmupTargetResponse struct {
+	WarmupTarget
+	SnapshotID string `json:"snapshot_id,omitempty"`
+	Rates      int    `json:"rates"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Ready reports whether the adapter can take traffic: always when no
+// warm-up is configured, otherwise once a warm-up has loaded every target
+func (a *Adapter) Ready() bool {
+	return len(a.config.Warmup) == 0 || a.warmedUp.Load()
+}
+
+// Warmup loads targets (empty = configured targets). Only a warm-up of the
+// configured targets that loads all of them marks the adapter ready.
+func (a *Adapter) Warmup(ctx context.Context, targets []WarmupTarget) (*engine.WarmupReport, error) {
+	configured := len(targets) == 0
+	if configured {
+		targets = a.config.Warmup
+	}
+	reqs := make([]engine.SnapshotRequest, 0, len(targets))
+	for _, t := range targets {
+		reqs = append(reqs, engine.SnapshotRequest{Provider: t.Provider, Region: t.Region, Alias: t.Alias})
+	}
+
+	report, err := a.engine.Warmup(ctx, reqs)
+	if err != nil {
+		a.logger.Error("pricing warm-up failed", logging.Err(err))
+		return nil, err
+	}
+	for _, t := range report.Targets {
+		if t.Err != nil {
+			a.logger.Warn("warm-up snapshot not loaded",
+				logging.String("provider", t.Provider),
+				logging.String("region", t.Region),
+				logging.String("alias", t.Alias),
+				logging.Err(t.Err))
+		}
+	}
+	if configured && report.Ready() {
+		a.warmedUp.Store(true)
+	}
+	a.logger.Info("pricing warm-up finished",
+		logging.Int("snapshots", report.Snapshots()),
+		logging.Int("rates", report.Rates()),
+		logging.Duration("duration", report.Duration),
+		logging.Bool("ready", a.Ready()))
+	return report, nil
+}
+
+// warmupUntilReady warms up the configured targets, retrying with backoff
+// until every target is loaded or ctx is done, so a pricing database that
+// is unreachable at startup delays readiness instead of blocking it until
+// someone calls POST /api/v1/warmup
+func (a *Adapter) warmupUntilReady(ctx context.Context) {
+	delay := warmupRetryInitial
+	for {
+		a.Warmup(ctx, nil)
+		if a.warmedUp.Load() {
+			return
+		}
+
+		a.logger.Warn("pricing warm-up incomplete, retrying", logging.Duration("after", delay))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		delay *= 2
+		if delay > warmupRetryMax {
+			delay = warmupRetryMax
+		}
+	}
+}
+
+func (a *Adapter) handleWarmup(w http.ResponseWriter, r *http.Request) {
+	var req WarmupRequest
+	if r.ContentLength != 0 {
+		if err := a.parseJSON(r, &req); err != nil {
+			a.writeError(w, bodyErrorStatus(err), "invalid request body: "+err.Error())
+			return
+		}
+	}
+	if len(req.Targets) == 0 && len(a.config.Warmup) == 0 {
+		a.writeError(w, http.StatusBadRequest, "no warm-up targets configured or provided")
+		return
+	}
+
+	report, err := a.Warmup(r.Context(), req.Targets)
+	if err != nil {
+		a.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := &WarmupResponse{
+		Ready:      report.Ready(),
+		Snapshots:  report.Snapshots(),
+		Rates:      report.Rates(),
+		DurationMs: report.Duration.Milliseconds(),
+		Targets:    make([]WarmupTargetResponse, 0, len(report.Targets)),
+	}
+	for _, t := range report.Targets {
+		target := WarmupTargetResponse{
+			WarmupTarget: WarmupTarget{Provider: t.Provider, Region: t.Region, Alias: t.Alias},
+			SnapshotID:   string(t.SnapshotID),
+			Rates:        t.Rates,
+		}
+		if t.Err != nil {
+			target.Error = t.Err.Error()
+		}
+		resp.Targets = append(resp.Targets, target)
+	}
+
+	status := http.StatusOK
+	if !resp.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	a.writeJSON(w, status, resp)
+}
+
+// InvalidateResponse is the response of POST /api/v1/snapshots/invalidate
+type InvalidateResponse struct {
+	// Invalidated is false when the engine's resolver caches nothing
+	Invalidated bool `json:"invalidated"`
+
+	// Rewarming reports that the configured warm-up targets are being
+	// reloaded in the background
+	Rewarming bool `json:"rewarming"`
+}
+
+func (a *Adapter) handleInvalidateSnapshots(w http.ResponseWriter, r *http.Request) {
+	resp := &InvalidateResponse{Invalidated: a.engine.InvalidatePricingCache()}
+	if resp.Invalidated && len(a.config.Warmup) > 0 {
+		resp.Rewarming = true
+		go a.Warmup(context.Background(), nil)
+	}
+	a.logger.Info("pricing snapshot cache invalidated",
+		logging.Bool("invalidated", resp.Invalidated),
+		logging.Bool("rewarming", resp.Rewarming))
+	a.writeJSON(w, http.StatusOK, resp)
+}
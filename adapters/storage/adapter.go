@@ -5,6 +5,7 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -13,6 +14,9 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"terraform-cost/core/determinism"
+	"terraform-cost/internal/atomicfile"
 )
 
 // Backend is a storage backend type
@@ -27,12 +31,15 @@ const (
 	BackendMemory   Backend = "memory"
 )
 
+// ErrNotFound is returned by Get for an unknown ID
+var ErrNotFound = errors.New("result not found")
+
 // Store is the storage interface
 type Store interface {
 	// Save stores an estimation result
 	Save(ctx context.Context, result *StoredResult) error
 
-	// Get retrieves an estimation by ID
+	// Get retrieves an estimation by ID, or an error wrapping ErrNotFound
 	Get(ctx context.Context, id string) (*StoredResult, error)
 
 	// List lists estimations with filters
@@ -41,7 +48,8 @@ type Store interface {
 	// Delete removes an estimation
 	Delete(ctx context.Context, id string) error
 
-	// GetLatest gets the latest estimation for a project
+	// GetLatest gets the latest estimation for a project, or an error
+	// wrapping ErrNotFound when it has none
 	GetLatest(ctx context.Context, projectID string) (*StoredResult, error)
 
 	// Compare compares two estimations
@@ -138,10 +146,31 @@ type CompareResult struct {
 	CreatedAt     time.Time `json:"created_at"`
 }
 
-// FileStore is a file-based storage backend
+// saveAttempts bounds how often FileStore.Save tries a failed write
+const saveAttempts = 3
+
+// FileStore is a file-based storage backend. Results are replaced
+// atomically, so readers - including other processes sharing the
+// directory - see either the previous file or the complete new one.
 type FileStore struct {
 	basePath string
 	mu       sync.RWMutex
+
+	// clock stamps CreatedAt (nil = determinism.DefaultClock)
+	clock determinism.Clock
+}
+
+// SetClock sets the clock new results and comparisons are stamped with
+func (s *FileStore) SetClock(clock determinism.Clock) {
+	s.clock = clock
+}
+
+// now returns the current time in UTC from clock, or the default clock
+func now(clock determinism.Clock) time.Time {
+	if clock == nil {
+		return determinism.DefaultClock.Now()
+	}
+	return clock.Now()
 }
 
 // NewFileStore creates a file store
@@ -160,7 +189,7 @@ func (s *FileStore) Save(ctx context.Context, result *StoredResult) error {
 		result.ID = uuid.New().String()
 	}
 	if result.CreatedAt.IsZero() {
-		result.CreatedAt = time.Now()
+		result.CreatedAt = now(s.clock)
 	}
 
 	// Create project directory
@@ -176,11 +205,22 @@ func (s *FileStore) Save(ctx context.Context, result *StoredResult) error {
 		return fmt.Errorf("failed to marshal result: %w", err)
 	}
 
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write result: %w", err)
+	// Each attempt writes its own temp file, so saves racing from another
+	// store or process on the same directory cannot interleave
+	var writeErr error
+	for attempt := 0; attempt < saveAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("failed to write result: %w", ctx.Err())
+			case <-time.After(time.Duration(attempt) * 10 * time.Millisecond):
+			}
+		}
+		if writeErr = atomicfile.WriteFile(filePath, data); writeErr == nil {
+			return nil
+		}
 	}
-
-	return nil
+	return fmt.Errorf("failed to write result after %d attempts: %w", saveAttempts, writeErr)
 }
 
 func (s *FileStore) Get(ctx context.Context, id string) (*StoredResult, error) {
@@ -209,7 +249,7 @@ func (s *FileStore) Get(ctx context.Context, id string) (*StoredResult, error) {
 		}
 	}
 
-	return nil, fmt.Errorf("result not found: %s", id)
+	return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
 }
 
 func (s *FileStore) List(ctx context.Context, filter *ListFilter) ([]*StoredResult, error) {
@@ -314,7 +354,7 @@ func (s *FileStore) GetLatest(ctx context.Context, projectID string) (*StoredRes
 		return nil, err
 	}
 	if len(results) == 0 {
-		return nil, fmt.Errorf("no results for project: %s", projectID)
+		return nil, fmt.Errorf("%w: no results for project: %s", ErrNotFound, projectID)
 	}
 	return results[0], nil
 }
@@ -345,7 +385,7 @@ func (s *FileStore) Compare(ctx context.Context, oldID, newID string) (*CompareR
 		DeltaPercent:  deltaPercent,
 		OldConfidence: oldResult.Confidence,
 		NewConfidence: newResult.Confidence,
-		CreatedAt:     time.Now(),
+		CreatedAt:     now(s.clock),
 	}, nil
 }
 
@@ -357,6 +397,14 @@ func (s *FileStore) Close() error {
 type MemoryStore struct {
 	results map[string]*StoredResult
 	mu      sync.RWMutex
+
+	// clock stamps CreatedAt (nil = determinism.DefaultClock)
+	clock determinism.Clock
+}
+
+// SetClock sets the clock new results and comparisons are stamped with
+func (s *MemoryStore) SetClock(clock determinism.Clock) {
+	s.clock = clock
 }
 
 // NewMemoryStore creates a memory store
@@ -374,7 +422,7 @@ func (s *MemoryStore) Save(ctx context.Context, result *StoredResult) error {
 		result.ID = uuid.New().String()
 	}
 	if result.CreatedAt.IsZero() {
-		result.CreatedAt = time.Now()
+		result.CreatedAt = now(s.clock)
 	}
 
 	s.results[result.ID] = result
@@ -387,7 +435,7 @@ func (s *MemoryStore) Get(ctx context.Context, id string) (*StoredResult, error)
 
 	result, ok := s.results[id]
 	if !ok {
-		return nil, fmt.Errorf("result not found: %s", id)
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
 	}
 	return result, nil
 }
@@ -425,7 +473,7 @@ func (s *MemoryStore) GetLatest(ctx context.Context, projectID string) (*StoredR
 	}
 
 	if latest == nil {
-		return nil, fmt.Errorf("no results for project: %s", projectID)
+		return nil, fmt.Errorf("%w: no results for project: %s", ErrNotFound, projectID)
 	}
 	return latest, nil
 }
@@ -456,7 +504,7 @@ func (s *MemoryStore) Compare(ctx context.Context, oldID, newID string) (*Compar
 		DeltaPercent:  deltaPercent,
 		OldConfidence: oldResult.Confidence,
 		NewConfidence: newResult.Confidence,
-		CreatedAt:     time.Now(),
+		CreatedAt:     now(s.clock),
 	}, nil
 }
 
@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/engine"
+)
+
+// TestFileStoreSaveIsAtomic proves a reader polling the result file while
+// two stores on the same directory rewrite it only ever sees complete JSON
+func TestFileStoreSaveIsAtomic(t *testing.T) {
+	dir := t.TempDir()
+	stores := make([]*FileStore, 2)
+	for i := range stores {
+		store, err := NewFileStore(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stores[i] = store
+	}
+
+	// Large enough that a plain write is observable half done
+	metadata := map[string]string{"padding": strings.Repeat("x", 1<<20)}
+	path := filepath.Join(dir, "project", "result.json")
+
+	done := make(chan struct{})
+	readerErr := make(chan error, 1)
+	go func() {
+		defer close(readerErr)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			data, err := os.ReadFile(path)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				readerErr <- err
+				return
+			}
+			var result StoredResult
+			if err := json.Unmarshal(data, &result); err != nil {
+				readerErr <- fmt.Errorf("reader saw a partial file (%d bytes): %w", len(data), err)
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i, store := range stores {
+		wg.Add(1)
+		go func(i int, store *FileStore) {
+			defer wg.Done()
+			for n := 0; n < 20; n++ {
+				err := store.Save(context.Background(), &StoredResult{
+					ID:        "result",
+					ProjectID: "project",
+					TotalCost: float64(i*100 + n),
+					Metadata:  metadata,
+				})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(i, store)
+	}
+	wg.Wait()
+	close(done)
+	if err := <-readerErr; err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "result.json" {
+		t.Errorf("expected only result.json, found %v", entries)
+	}
+	if _, err := stores[0].Get(context.Background(), "result"); err != nil {
+		t.Errorf("saved result unreadable: %v", err)
+	}
+}
+
+// TestScenarioCache proves a scenario's result is returned under its key
+// and its definition is listed, apart from other stored results
+func TestScenarioCache(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	if err := store.Save(ctx, &StoredResult{ID: "other", ProjectID: "project"}); err != nil {
+		t.Fatal(err)
+	}
+	cache := NewScenarioCache(store)
+
+	scenario := engine.Scenario{Name: "bigger", Substitutions: []engine.Substitution{
+		{Address: "aws_instance.web", Attribute: "instance_type", Value: "t3.large"},
+		{Address: "aws_db_instance.main", Attribute: "instance_class", Value: "db.r5.large"},
+	}}
+	key := engine.ScenarioCacheKey("plan", scenario, nil)
+	if _, ok, err := cache.Get(ctx, key); ok || err != nil {
+		t.Fatalf("empty cache: ok=%v err=%v", ok, err)
+	}
+
+	result := &engine.EstimationResult{
+		TotalMonthlyCost: determinism.NewMoneyFromFloat(60.74, "USD"),
+		TotalHourlyCost:  determinism.NewMoneyFromFloat(0.0832, "USD"),
+	}
+	if err := cache.Put(ctx, key, scenario, result); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := cache.Get(ctx, key)
+	if err != nil || !ok || got.TotalMonthlyCost.Cmp(result.TotalMonthlyCost) != 0 {
+		t.Fatalf("Get = %v, %v, %v", got, ok, err)
+	}
+
+	listed, err := cache.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 1 || listed[0].Key != key || listed[0].Scenario.Name != "bigger" || listed[0].TotalCost != 60.74 {
+		t.Fatalf("List = %+v", listed)
+	}
+	if first := listed[0].Scenario.Substitutions[0]; first.Address != "aws_db_instance.main" {
+		t.Errorf("stored substitutions are not canonical: %+v", listed[0].Scenario.Substitutions)
+	}
+}
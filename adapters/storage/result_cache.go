@@ -0,0 +1,84 @@
+// Package storage - Estimation result cache
+// Stores full engine results in any Store so unchanged plans are not
+// repriced. Entries live under their own project and are keyed by the
+// engine's cache key, so a changed snapshot simply never hits them.
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"terraform-cost/core/engine"
+)
+
+// ResultCacheProject is the project ID cache entries are stored under
+const ResultCacheProject = "result-cache"
+
+// ResultCache adapts a Store to engine.ResultCache
+type ResultCache struct {
+	store Store
+}
+
+// NewResultCache creates a result cache backed by store
+func NewResultCache(store Store) *ResultCache {
+	return &ResultCache{store: store}
+}
+
+// Get returns the result cached under key
+func (c *ResultCache) Get(ctx context.Context, key string) (*engine.EstimationResult, bool, error) {
+	stored, err := c.store.Get(ctx, cacheEntryID(key))
+	if errors.Is(err, ErrNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var result engine.EstimationResult
+	if err := json.Unmarshal(stored.RawResult, &result); err != nil {
+		return nil, false, fmt.Errorf("failed to decode cached result %s: %w", key, err)
+	}
+	return &result, true, nil
+}
+
+// Put caches a result under key, replacing any previous entry
+func (c *ResultCache) Put(ctx context.Context, key string, result *engine.EstimationResult) error {
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	stored := &StoredResult{
+		ID:         cacheEntryID(key),
+		ProjectID:  ResultCacheProject,
+		TotalCost:  result.TotalMonthlyCost.Float64(),
+		Confidence: result.Confidence.Score,
+		Metadata:   map[string]string{"cache_key": key},
+		RawResult:  raw,
+	}
+	if result.InstanceCosts != nil {
+		stored.ResourceCount = result.InstanceCosts.Len()
+	}
+	if result.Snapshot != nil {
+		stored.SnapshotID = string(result.Snapshot.ID)
+		stored.Provider = result.Snapshot.Provider
+		stored.Region = result.Snapshot.Region
+	}
+	if result.CoverageReport != nil {
+		stored.Coverage = CoverageData{
+			NumericPercent:     result.CoverageReport.NumericPercent,
+			SymbolicPercent:    result.CoverageReport.SymbolicPercent,
+			UnsupportedPercent: result.CoverageReport.UnsupportedPercent,
+		}
+	}
+	return c.store.Save(ctx, stored)
+}
+
+// cacheEntryID is the stored result ID for a cache key
+func cacheEntryID(key string) string {
+	return "cache-" + key
+}
+
+var _ engine.ResultCache = (*ResultCache)(nil)
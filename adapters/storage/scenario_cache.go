@@ -0,0 +1,116 @@
+// Package storage - What-if scenario cache
+// Stores the result of each what-if scenario under its content-addressed
+// key (engine.ScenarioCacheKey), with the scenario definition alongside
+// so stored scenarios can be listed later.
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"terraform-cost/core/engine"
+)
+
+// ScenarioCacheProject is the project ID scenario entries are stored under
+const ScenarioCacheProject = "scenario-cache"
+
+// scenarioMetadataKey holds the JSON scenario definition in Metadata
+const scenarioMetadataKey = "scenario"
+
+// ScenarioCache stores what-if scenario results in a Store
+type ScenarioCache struct {
+	store Store
+}
+
+// NewScenarioCache creates a scenario cache backed by store
+func NewScenarioCache(store Store) *ScenarioCache {
+	return &ScenarioCache{store: store}
+}
+
+// StoredScenario is a cached scenario as listed
+type StoredScenario struct {
+	Key       string
+	Scenario  engine.Scenario
+	TotalCost float64
+}
+
+// Get returns the result cached under key
+func (c *ScenarioCache) Get(ctx context.Context, key string) (*engine.EstimationResult, bool, error) {
+	stored, err := c.store.Get(ctx, scenarioEntryID(key))
+	if errors.Is(err, ErrNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var result engine.EstimationResult
+	if err := json.Unmarshal(stored.RawResult, &result); err != nil {
+		return nil, false, fmt.Errorf("failed to decode cached scenario %s: %w", key, err)
+	}
+	return &result, true, nil
+}
+
+// Put caches a scenario's result under key with its definition
+func (c *ScenarioCache) Put(ctx context.Context, key string, scenario engine.Scenario, result *engine.EstimationResult) error {
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode result: %w", err)
+	}
+	scenario.Substitutions = engine.CanonicalSubstitutions(scenario.Substitutions)
+	definition, err := json.Marshal(scenario)
+	if err != nil {
+		return fmt.Errorf("failed to encode scenario: %w", err)
+	}
+
+	stored := &StoredResult{
+		ID:         scenarioEntryID(key),
+		ProjectID:  ScenarioCacheProject,
+		TotalCost:  result.DisplayTotalMonthlyCost().Float64(),
+		Confidence: result.Confidence.Score,
+		Metadata:   map[string]string{"cache_key": key, scenarioMetadataKey: string(definition)},
+		RawResult:  raw,
+	}
+	if result.Snapshot != nil {
+		stored.SnapshotID = string(result.Snapshot.ID)
+		stored.Provider = result.Snapshot.Provider
+		stored.Region = result.Snapshot.Region
+	}
+	return c.store.Save(ctx, stored)
+}
+
+// List returns the cached scenarios, ordered by name then key
+func (c *ScenarioCache) List(ctx context.Context) ([]StoredScenario, error) {
+	stored, err := c.store.List(ctx, &ListFilter{ProjectID: ScenarioCacheProject})
+	if err != nil {
+		return nil, err
+	}
+
+	var out []StoredScenario
+	for _, s := range stored {
+		// Not every Store applies the filter
+		if s.ProjectID != ScenarioCacheProject {
+			continue
+		}
+		entry := StoredScenario{Key: s.Metadata["cache_key"], TotalCost: s.TotalCost}
+		if err := json.Unmarshal([]byte(s.Metadata[scenarioMetadataKey]), &entry.Scenario); err != nil {
+			return nil, fmt.Errorf("failed to decode scenario %s: %w", s.ID, err)
+		}
+		out = append(out, entry)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Scenario.Name != out[j].Scenario.Name {
+			return out[i].Scenario.Name < out[j].Scenario.Name
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out, nil
+}
+
+// scenarioEntryID is the stored result ID for a scenario key
+func scenarioEntryID(key string) string {
+	return "scenario-" + key
+}
@@ -6,12 +6,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"terraform-cost/core/engine"
 )
 
 // Adapter is the Terraform adapter
@@ -52,6 +55,12 @@ type Config struct {
 
 	// LockTimeout for state locking
 	LockTimeout time.Duration `json:"lock_timeout"`
+
+	// RefreshOnly plans with -refresh-only: drift is recorded, nothing changes
+	RefreshOnly bool `json:"refresh_only,omitempty"`
+
+	// Replace lists addresses to force-replace with -replace
+	Replace []string `json:"replace,omitempty"`
 }
 
 // DefaultConfig returns sensible defaults
@@ -96,6 +105,12 @@ type PlanOutput struct {
 	// ResourceChanges contains resource changes
 	ResourceChanges []ResourceChange `json:"resource_changes"`
 
+	// ResourceDrift contains changes made outside Terraform (refresh-only plans)
+	ResourceDrift []ResourceChange `json:"resource_drift,omitempty"`
+
+	// Errored is set when planning failed part way through
+	Errored bool `json:"errored,omitempty"`
+
 	// Configuration contains the configuration
 	Configuration *Configuration `json:"configuration,omitempty"`
 
@@ -137,6 +152,9 @@ type ResourceChange struct {
 
 	// ActionReason explains why action is needed
 	ActionReason string `json:"action_reason,omitempty"`
+
+	// PreviousAddress is set when the resource was moved by a moved block
+	PreviousAddress string `json:"previous_address,omitempty"`
 }
 
 // Change represents the change details
@@ -158,8 +176,22 @@ type Change struct {
 
 	// AfterSensitive marks sensitive values
 	AfterSensitive interface{} `json:"after_sensitive"`
+
+	// Importing is set when the resource is adopted by an import block
+	Importing *Importing `json:"importing,omitempty"`
+}
+
+// Importing describes a resource being imported
+type Importing struct {
+	ID string `json:"id"`
 }
 
+// ActionReasonMove marks a change caused only by a moved address
+const ActionReasonMove = "move"
+
+// ActionReasonReplaceByRequest marks a replacement forced with -replace
+const ActionReasonReplaceByRequest = "replace_by_request"
+
 // Configuration represents Terraform configuration
 type Configuration struct {
 	// ProviderConfig contains provider configs
@@ -173,6 +205,8 @@ type Configuration struct {
 type ProviderConfig struct {
 	Name        string                 `json:"name"`
 	FullName    string                 `json:"full_name"`
+	Alias       string                 `json:"alias,omitempty"`
+	ModuleAddress string               `json:"module_address,omitempty"`
 	VersionConstraint string           `json:"version_constraint,omitempty"`
 	Expressions map[string]interface{} `json:"expressions,omitempty"`
 }
@@ -267,6 +301,18 @@ type Variable struct {
 	Value interface{} `json:"value"`
 }
 
+// VariableValues returns the input variable values recorded in the plan,
+// for resolving var.* references when evaluating the configuration
+func (p *PlanOutput) VariableValues() map[string]any {
+	values := make(map[string]any, len(p.Variables))
+	for name, v := range p.Variables {
+		if v.Value != nil {
+			values[name] = v.Value
+		}
+	}
+	return values
+}
+
 // Init initializes the Terraform working directory
 func (a *Adapter) Init(ctx context.Context) error {
 	args := []string{"init", "-input=false"}
@@ -300,6 +346,14 @@ func (a *Adapter) Plan(ctx context.Context, outFile string) error {
 		args = append(args, fmt.Sprintf("-lock-timeout=%s", a.config.LockTimeout))
 	}
 
+	if a.config.RefreshOnly {
+		args = append(args, "-refresh-only")
+	}
+
+	for _, addr := range a.config.Replace {
+		args = append(args, "-replace="+addr)
+	}
+
 	// Add var files
 	for _, varFile := range a.config.VarFiles {
 		args = append(args, "-var-file="+varFile)
@@ -335,18 +389,21 @@ func (a *Adapter) ShowPlanJSON(ctx context.Context, planFile string) (*PlanOutpu
 	return &plan, nil
 }
 
-// ParsePlanFile parses an existing plan file
+// ParsePlanFile parses an existing plan. Plan JSON ("-" for stdin, a URL,
+// or a file holding JSON) is read directly; only a binary plan file is
+// run through `terraform show -json`.
 func (a *Adapter) ParsePlanFile(ctx context.Context, planFile string) (*PlanOutput, error) {
+	source := NewPlanSource(planFile)
+	if source.Kind() != PlanSourceFile || IsPlanJSONFile(planFile) {
+		plan, _, err := source.Plan(ctx)
+		return plan, err
+	}
 	return a.ShowPlanJSON(ctx, planFile)
 }
 
 // ParsePlanJSON parses plan JSON directly
 func (a *Adapter) ParsePlanJSON(data []byte) (*PlanOutput, error) {
-	var plan PlanOutput
-	if err := json.Unmarshal(data, &plan); err != nil {
-		return nil, fmt.Errorf("failed to parse plan JSON: %w", err)
-	}
-	return &plan, nil
+	return parsePlanJSON(data)
 }
 
 // Validate validates Terraform configuration
@@ -422,6 +479,9 @@ func (a *Adapter) Providers(ctx context.Context) ([]string, error) {
 // Version returns Terraform version
 func (a *Adapter) Version(ctx context.Context) (string, error) {
 	output, err := a.run(ctx, "version", "-json")
+	if errors.Is(err, ErrTerraformNotFound) {
+		return "", err
+	}
 	if err != nil {
 		// Try without -json for older versions
 		output, err = a.run(ctx, "version")
@@ -488,6 +548,9 @@ func (a *Adapter) run(ctx context.Context, args ...string) (string, error) {
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
+		if IsNotFound(err) {
+			return "", a.notFound()
+		}
 		return "", fmt.Errorf("terraform %s failed: %w: %s", strings.Join(args, " "), err, stderr.String())
 	}
 
@@ -497,54 +560,121 @@ func (a *Adapter) run(ctx context.Context, args ...string) (string, error) {
 // ExtractResources extracts resources from plan for cost estimation
 func (a *Adapter) ExtractResources(plan *PlanOutput) []ResourceInfo {
 	var resources []ResourceInfo
+	regions := newProviderRegions(plan)
 
 	for _, change := range plan.ResourceChanges {
-		// Skip data sources
+		// Skip data sources. Drift (resource_drift) is never priced: it
+		// changes state, not configuration.
 		if change.Mode == "data" {
 			continue
 		}
 
-		// Determine action
-		action := "no_change"
-		if len(change.Change.Actions) > 0 {
-			if contains(change.Change.Actions, "create") {
-				action = "create"
-			} else if contains(change.Change.Actions, "delete") {
-				action = "destroy"
-			} else if contains(change.Change.Actions, "update") {
-				action = "update"
-			}
+		providerKey := regions.providerKey(change.Address)
+		region := regions.region(providerKey)
+		if region == "" {
+			// Some resources carry their own region attribute
+			region, _ = change.Change.After["region"].(string)
 		}
 
 		resources = append(resources, ResourceInfo{
-			Address:       change.Address,
-			Type:          change.Type,
-			Name:          change.Name,
-			Provider:      change.ProviderName,
-			ModuleAddress: change.ModuleAddress,
-			Index:         change.Index,
-			Action:        action,
-			Values:        change.Change.After,
-			PriorValues:   change.Change.Before,
-			Unknown:       change.Change.AfterUnknown,
+			Address:         change.Address,
+			PreviousAddress: change.PreviousAddress,
+			Type:            change.Type,
+			Name:            change.Name,
+			Provider:        change.ProviderName,
+			ProviderKey:     providerKey,
+			Region:          region,
+			ModuleAddress:   change.ModuleAddress,
+			Index:           change.Index,
+			Action:          changeAction(change),
+			ActionReason:    change.ActionReason,
+			Imported:        change.Change.Importing != nil,
+			Replace:         isReplace(change.Change.Actions),
+			Tags:            mergeTags(regions.defaultTags(providerKey), engine.AllocationTags(change.Change.After["tags_all"], change.Change.After["tags"])),
+			Values:          change.Change.After,
+			PriorValues:     change.Change.Before,
+			Unknown:         change.Change.AfterUnknown,
 		})
 	}
 
 	return resources
 }
 
+// changeAction maps plan actions to a cost-delta action. Moved and imported
+// resources already exist, so they are never counted as a create or destroy:
+// a no-op stays no_change and a replace is priced as an update in place.
+// A replacement (["delete","create"], or ["create","delete"] with
+// create_before_destroy) briefly runs two copies, but in steady state the
+// new resource takes the old one's place, so it is an update too.
+func changeAction(change ResourceChange) string {
+	actions := change.Change.Actions
+	existing := change.PreviousAddress != "" ||
+		change.ActionReason == ActionReasonMove ||
+		change.Change.Importing != nil
+
+	switch {
+	case len(actions) == 0, contains(actions, "no-op"):
+		return "no_change"
+	case isReplace(actions):
+		return "update"
+	case existing && (contains(actions, "create") || contains(actions, "update")):
+		return "update"
+	case contains(actions, "create"):
+		return "create"
+	case contains(actions, "delete"):
+		return "destroy"
+	case contains(actions, "update"):
+		return "update"
+	}
+	return "no_change"
+}
+
+// isReplace reports whether actions destroy and recreate a resource
+func isReplace(actions []string) bool {
+	return contains(actions, "create") && contains(actions, "delete")
+}
+
+// InBase reports whether the resource exists before the change, i.e.
+// whether it is priced on the base side of a cost diff
+func (r ResourceInfo) InBase() bool {
+	return r.Action != "create"
+}
+
+// InHead reports whether the resource exists after the change
+func (r ResourceInfo) InHead() bool {
+	return r.Action != "destroy"
+}
+
+// MovedAddresses returns previous-to-new addresses for moved resources
+func MovedAddresses(resources []ResourceInfo) map[string]string {
+	moves := make(map[string]string)
+	for _, r := range resources {
+		if r.PreviousAddress != "" && r.PreviousAddress != r.Address {
+			moves[r.PreviousAddress] = r.Address
+		}
+	}
+	return moves
+}
+
 // ResourceInfo is extracted resource information
 type ResourceInfo struct {
-	Address       string                 `json:"address"`
-	Type          string                 `json:"type"`
-	Name          string                 `json:"name"`
-	Provider      string                 `json:"provider"`
-	ModuleAddress string                 `json:"module_address,omitempty"`
-	Index         interface{}            `json:"index,omitempty"`
-	Action        string                 `json:"action"`
-	Values        map[string]interface{} `json:"values"`
-	PriorValues   map[string]interface{} `json:"prior_values,omitempty"`
-	Unknown       map[string]interface{} `json:"unknown,omitempty"`
+	Address         string                 `json:"address"`
+	PreviousAddress string                 `json:"previous_address,omitempty"`
+	Type            string                 `json:"type"`
+	Name            string                 `json:"name"`
+	Provider        string                 `json:"provider"`
+	ProviderKey     string                 `json:"provider_key,omitempty"`
+	Region          string                 `json:"region,omitempty"`
+	ModuleAddress   string                 `json:"module_address,omitempty"`
+	Index           interface{}            `json:"index,omitempty"`
+	Action          string                 `json:"action"`
+	ActionReason    string                 `json:"action_reason,omitempty"`
+	Imported        bool                   `json:"imported,omitempty"`
+	Replace         bool                   `json:"replace,omitempty"`
+	Tags            map[string]string      `json:"tags,omitempty"`
+	Values          map[string]interface{} `json:"values"`
+	PriorValues     map[string]interface{} `json:"prior_values,omitempty"`
+	Unknown         map[string]interface{} `json:"unknown,omitempty"`
 }
 
 func contains(slice []string, item string) bool {
@@ -0,0 +1,1003 @@
+// Package terraform - Plan extraction tests
+package terraform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"terraform-cost/core/cost"
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/diff"
+	"terraform-cost/core/engine"
+	"terraform-cost/core/model"
+	"terraform-cost/core/policy"
+)
+
+const movedPlanJSON = `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {
+      "address": "aws_instance.app",
+      "previous_address": "aws_instance.web",
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "app",
+      "change": {"actions": ["no-op"], "before": {"instance_type": "t3.large"}, "after": {"instance_type": "t3.large"}}
+    },
+    {
+      "address": "aws_db_instance.main",
+      "previous_address": "aws_db_instance.primary",
+      "mode": "managed",
+      "type": "aws_db_instance",
+      "name": "main",
+      "change": {"actions": ["delete", "create"], "before": {"instance_class": "db.t3.small"}, "after": {"instance_class": "db.t3.medium"}}
+    },
+    {
+      "address": "aws_s3_bucket.logs",
+      "mode": "managed",
+      "type": "aws_s3_bucket",
+      "name": "logs",
+      "change": {"actions": ["update"], "importing": {"id": "logs-bucket"}, "after": {"bucket": "logs-bucket"}}
+    },
+    {
+      "address": "aws_nat_gateway.new",
+      "mode": "managed",
+      "type": "aws_nat_gateway",
+      "name": "new",
+      "change": {"actions": ["create"], "after": {}}
+    }
+  ]
+}`
+
+// TestExtractResourcesMoved proves moved and imported resources are not
+// counted as creates or destroys
+func TestExtractResourcesMoved(t *testing.T) {
+	a := &Adapter{config: DefaultConfig()}
+	plan, err := a.ParsePlanJSON([]byte(movedPlanJSON))
+	if err != nil {
+		t.Fatalf("ParsePlanJSON: %v", err)
+	}
+
+	resources := a.ExtractResources(plan)
+	want := map[string]string{
+		"aws_instance.app":     "no_change",
+		"aws_db_instance.main": "update",
+		"aws_s3_bucket.logs":   "update",
+		"aws_nat_gateway.new":  "create",
+	}
+	for _, r := range resources {
+		if r.Action != want[r.Address] {
+			t.Errorf("%s: action = %q, want %q", r.Address, r.Action, want[r.Address])
+		}
+	}
+	if !resources[2].Imported {
+		t.Error("aws_s3_bucket.logs should be marked imported")
+	}
+
+	moves := MovedAddresses(resources)
+	if moves["aws_instance.web"] != "aws_instance.app" || len(moves) != 2 {
+		t.Fatalf("unexpected moves: %v", moves)
+	}
+
+	// A moved instance with the same cost is unchanged in the diff
+	instance := func(addr string) *cost.InstanceCostResult {
+		return &cost.InstanceCostResult{
+			Identity: &model.InstanceIdentity{Canonical: model.CanonicalAddress(addr)},
+			Total:    &cost.ConfidenceBoundCost{Monthly: determinism.NewMoneyFromFloat(60.74, "USD")},
+		}
+	}
+	before := &cost.AggregatedCostResult{Instances: []*cost.InstanceCostResult{instance("aws_instance.web")}, TotalMonthly: determinism.NewMoneyFromFloat(60.74, "USD")}
+	after := &cost.AggregatedCostResult{Instances: []*cost.InstanceCostResult{instance("aws_instance.app")}, TotalMonthly: determinism.NewMoneyFromFloat(60.74, "USD")}
+
+	differ := diff.NewDiffer(0)
+	if result := differ.Diff(before, after); result.AddedCount != 1 || result.RemovedCount != 1 {
+		t.Fatalf("without moves expected add+remove, got %+v", result)
+	}
+
+	canonical := make(map[model.CanonicalAddress]model.CanonicalAddress, len(moves))
+	for from, to := range moves {
+		canonical[model.CanonicalAddress(from)] = model.CanonicalAddress(to)
+	}
+	result := differ.WithMoves(canonical).Diff(before, after)
+	if result.AddedCount != 0 || result.RemovedCount != 0 || result.UnchangedCount != 1 {
+		t.Errorf("moved instance should be unchanged, got added=%d removed=%d unchanged=%d",
+			result.AddedCount, result.RemovedCount, result.UnchangedCount)
+	}
+}
+
+const replacePlanJSON = `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {
+      "address": "aws_instance.web",
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "action_reason": "replace_because_cannot_update",
+      "change": {"actions": ["create", "delete"], "before": {"instance_type": "t3.small"}, "after": {"instance_type": "t3.large"}}
+    },
+    {
+      "address": "aws_db_instance.main",
+      "mode": "managed",
+      "type": "aws_db_instance",
+      "name": "main",
+      "change": {"actions": ["delete", "create"], "before": {"instance_class": "db.t3.small"}, "after": {"instance_class": "db.t3.medium"}}
+    }
+  ]
+}`
+
+// TestExtractResourcesReplace proves a replacement is priced as new minus
+// old, not as a create plus a destroy
+func TestExtractResourcesReplace(t *testing.T) {
+	a := &Adapter{config: DefaultConfig()}
+	plan, err := a.ParsePlanJSON([]byte(replacePlanJSON))
+	if err != nil {
+		t.Fatalf("ParsePlanJSON: %v", err)
+	}
+
+	resources := a.ExtractResources(plan)
+	for _, r := range resources {
+		if r.Action != "update" || !r.Replace {
+			t.Errorf("%s: action = %q replace = %v, want update replacement", r.Address, r.Action, r.Replace)
+		}
+	}
+
+	monthly := map[string]float64{
+		"t3.small":     15.18,
+		"t3.large":     60.74,
+		"db.t3.small":  24.82,
+		"db.t3.medium": 49.64,
+	}
+	price := func(r ResourceInfo, values map[string]interface{}) *cost.InstanceCostResult {
+		size, _ := values["instance_type"].(string)
+		if size == "" {
+			size, _ = values["instance_class"].(string)
+		}
+		return &cost.InstanceCostResult{
+			Identity: &model.InstanceIdentity{Canonical: model.CanonicalAddress(r.Address)},
+			Total:    &cost.ConfidenceBoundCost{Monthly: determinism.NewMoneyFromFloat(monthly[size], "USD")},
+		}
+	}
+	before := &cost.AggregatedCostResult{TotalMonthly: determinism.Zero("USD")}
+	after := &cost.AggregatedCostResult{TotalMonthly: determinism.Zero("USD")}
+	for _, r := range resources {
+		if r.InBase() {
+			inst := price(r, r.PriorValues)
+			before.Instances = append(before.Instances, inst)
+			before.TotalMonthly = before.TotalMonthly.Add(inst.Total.Monthly)
+		}
+		if r.InHead() {
+			inst := price(r, r.Values)
+			after.Instances = append(after.Instances, inst)
+			after.TotalMonthly = after.TotalMonthly.Add(inst.Total.Monthly)
+		}
+	}
+
+	result := diff.NewDiffer(0).Diff(before, after)
+	if result.AddedCount != 0 || result.RemovedCount != 0 || result.ChangedCount != 2 {
+		t.Fatalf("expected two changed instances, got added=%d removed=%d changed=%d",
+			result.AddedCount, result.RemovedCount, result.ChangedCount)
+	}
+	// (60.74 - 15.18) + (49.64 - 24.82); a create+destroy count would be 150.38
+	want := determinism.NewMoneyFromFloat(70.38, "USD")
+	if result.TotalDelta.Cmp(want) != 0 {
+		t.Errorf("delta = %s, want new-old %s", result.TotalDelta, want)
+	}
+}
+
+const multiRegionPlanJSON = `{
+  "format_version": "1.2",
+  "variables": {"region": {"value": "eu-west-1"}},
+  "configuration": {
+    "provider_config": {
+      "aws": {"name": "aws", "expressions": {"region": {"references": ["var.region"]}}},
+      "aws.west": {"name": "aws", "alias": "west", "expressions": {"region": {"constant_value": "us-west-2"}}}
+    },
+    "root_module": {
+      "resources": [
+        {"address": "aws_instance.main", "mode": "managed", "type": "aws_instance", "name": "main", "provider_config_key": "aws"},
+        {"address": "aws_instance.replica", "mode": "managed", "type": "aws_instance", "name": "replica", "provider_config_key": "aws.west"}
+      ],
+      "module_calls": {
+        "app": {"module": {"resources": [
+          {"address": "aws_instance.web", "mode": "managed", "type": "aws_instance", "name": "web", "provider_config_key": "module.app:aws"}
+        ]}}
+      }
+    }
+  },
+  "resource_changes": [
+    {"address": "aws_instance.main", "mode": "managed", "type": "aws_instance", "name": "main", "change": {"actions": ["create"], "after": {}}},
+    {"address": "aws_instance.replica[0]", "mode": "managed", "type": "aws_instance", "name": "replica", "index": 0, "change": {"actions": ["create"], "after": {}}},
+    {"address": "module.app[\"blue\"].aws_instance.web", "module_address": "module.app[\"blue\"]", "mode": "managed", "type": "aws_instance", "name": "web", "change": {"actions": ["create"], "after": {}}}
+  ]
+}`
+
+// TestExtractResourcesRegion proves each resource gets its provider's region
+func TestExtractResourcesRegion(t *testing.T) {
+	a := &Adapter{config: DefaultConfig()}
+	plan, err := a.ParsePlanJSON([]byte(multiRegionPlanJSON))
+	if err != nil {
+		t.Fatalf("ParsePlanJSON: %v", err)
+	}
+
+	want := map[string]string{
+		"aws_instance.main":                   "eu-west-1",
+		"aws_instance.replica[0]":             "us-west-2",
+		`module.app["blue"].aws_instance.web`: "eu-west-1",
+	}
+	for _, r := range a.ExtractResources(plan) {
+		if r.Region != want[r.Address] {
+			t.Errorf("%s: region = %q, want %q", r.Address, r.Region, want[r.Address])
+		}
+	}
+}
+
+// TestCheckPlanCompatibility covers version warnings and empty-plan errors
+func TestCheckPlanCompatibility(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		wantErr  bool
+		warnings int
+	}{
+		{
+			name: "known format",
+			json: `{"format_version": "1.2", "terraform_version": "1.7.0", "resource_changes": [
+				{"address": "aws_instance.a", "mode": "managed", "change": {"actions": ["create"]}}]}`,
+		},
+		{
+			name: "newer minor format",
+			json: `{"format_version": "1.9", "resource_changes": [
+				{"address": "aws_instance.a", "mode": "managed", "change": {"actions": ["create"]}}]}`,
+			warnings: 1,
+		},
+		{
+			name: "no-op only",
+			json: `{"format_version": "1.2", "resource_changes": [
+				{"address": "aws_instance.a", "mode": "managed", "change": {"actions": ["no-op"]}}]}`,
+			warnings: 1,
+		},
+		{
+			name: "refresh-only",
+			json: `{"format_version": "1.2", "resource_drift": [
+				{"address": "aws_instance.a", "mode": "managed", "change": {"actions": ["update"]}}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "state file",
+			json:    `{"format_version": "1.0", "values": {}}`,
+			wantErr: true,
+		},
+	}
+
+	a := &Adapter{config: DefaultConfig()}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := a.ParsePlanJSON([]byte(tt.json))
+			if err != nil {
+				t.Fatalf("ParsePlanJSON: %v", err)
+			}
+			extraction, err := a.ExtractPlan(plan)
+			if tt.wantErr {
+				if !errors.Is(err, ErrNoResourceChanges) {
+					t.Fatalf("expected ErrNoResourceChanges, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExtractPlan: %v", err)
+			}
+			if got := len(extraction.Metadata.Warnings); got != tt.warnings {
+				t.Errorf("warnings = %v, want %d", extraction.Metadata.Warnings, tt.warnings)
+			}
+			if extraction.Metadata.FormatVersion != plan.FormatVersion {
+				t.Errorf("metadata format_version = %q", extraction.Metadata.FormatVersion)
+			}
+		})
+	}
+}
+
+const stateJSON = `{
+  "format_version": "1.0",
+  "terraform_version": "1.6.0",
+  "values": {"root_module": {
+    "resources": [
+      {"address": "aws_instance.web[0]", "mode": "managed", "type": "aws_instance", "name": "web", "index": 0,
+       "provider_name": "registry.terraform.io/hashicorp/aws", "values": {"instance_type": "m5.large", "availability_zone": "us-east-1a"}},
+      {"address": "data.aws_ami.base", "mode": "data", "type": "aws_ami", "name": "base", "values": {}}
+    ],
+    "child_modules": [{"address": "module.db", "resources": [
+      {"address": "module.db.aws_db_instance.main", "mode": "managed", "type": "aws_db_instance", "name": "main",
+       "provider_name": "registry.terraform.io/hashicorp/aws", "values": {"arn": "arn:aws:rds:eu-west-1:123456789012:db:main"}}
+    ]}]
+  }}
+}`
+
+// TestExtractStateResources proves managed resources in every module are
+// extracted as existing, with regions inferred from their values
+func TestExtractStateResources(t *testing.T) {
+	a := &Adapter{}
+	state, err := a.ParseStateJSON([]byte(stateJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resources, err := a.ExtractStateResources(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"aws_instance.web[0]":            "us-east-1",
+		"module.db.aws_db_instance.main": "eu-west-1",
+	}
+	if len(resources) != len(want) {
+		t.Fatalf("got %d resources, want %d: %+v", len(resources), len(want), resources)
+	}
+	for _, r := range resources {
+		region, ok := want[r.Address]
+		if !ok {
+			t.Errorf("unexpected resource %s", r.Address)
+			continue
+		}
+		if r.Region != region || r.Action != "no_change" {
+			t.Errorf("%s: region=%q action=%q, want region %q and no_change", r.Address, r.Region, r.Action, region)
+		}
+	}
+
+	if _, err := a.ExtractStateResources(&State{FormatVersion: "1.0"}); !errors.Is(err, ErrEmptyState) {
+		t.Errorf("empty state error = %v, want ErrEmptyState", err)
+	}
+}
+
+const diffBasePlanJSON = `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {"address": "aws_instance.web", "mode": "managed", "type": "aws_instance", "name": "web",
+     "change": {"actions": ["no-op"], "before": {"instance_type": "t3.small"}, "after": {"instance_type": "t3.small"}}},
+    {"address": "aws_s3_bucket.logs", "mode": "managed", "type": "aws_s3_bucket", "name": "logs",
+     "change": {"actions": ["no-op"], "before": {"bucket": "logs"}, "after": {"bucket": "logs"}}},
+    {"address": "aws_db_instance.primary", "mode": "managed", "type": "aws_db_instance", "name": "primary",
+     "change": {"actions": ["no-op"], "before": {"instance_class": "db.t3.small"}, "after": {"instance_class": "db.t3.small"}}},
+    {"address": "aws_eip.old", "mode": "managed", "type": "aws_eip", "name": "old",
+     "change": {"actions": ["create"], "after": {"domain": "vpc"}}},
+    {"address": "aws_eip.gone", "mode": "managed", "type": "aws_eip", "name": "gone",
+     "change": {"actions": ["delete"], "before": {"domain": "vpc"}}}
+  ]
+}`
+
+const diffHeadPlanJSON = `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {"address": "aws_instance.web", "mode": "managed", "type": "aws_instance", "name": "web",
+     "change": {"actions": ["update"], "before": {"instance_type": "t3.small"}, "after": {"instance_type": "t3.large"}}},
+    {"address": "aws_s3_bucket.logs", "mode": "managed", "type": "aws_s3_bucket", "name": "logs",
+     "change": {"actions": ["no-op"], "before": {"bucket": "logs"}, "after": {"bucket": "logs"}}},
+    {"address": "aws_db_instance.main", "previous_address": "aws_db_instance.primary", "mode": "managed", "type": "aws_db_instance", "name": "main",
+     "change": {"actions": ["no-op"], "before": {"instance_class": "db.t3.small"}, "after": {"instance_class": "db.t3.small"}}},
+    {"address": "aws_nat_gateway.new", "mode": "managed", "type": "aws_nat_gateway", "name": "new",
+     "change": {"actions": ["create"], "after": {"connectivity_type": "public"}, "after_unknown": {"id": true}}}
+  ]
+}`
+
+// TestDiff proves resources are classified between two plans, with moves
+// matched and attribute changes reported
+func TestDiff(t *testing.T) {
+	a := &Adapter{config: DefaultConfig()}
+	base, err := a.ParsePlanJSON([]byte(diffBasePlanJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := a.ParsePlanJSON([]byte(diffHeadPlanJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := a.Diff(base, head)
+	check := func(name string, got []string, want ...string) {
+		t.Helper()
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+	check("created", d.Created, "aws_nat_gateway.new")
+	check("destroyed", d.Destroyed, "aws_eip.old")
+	check("updated", d.Updated, "aws_db_instance.main", "aws_instance.web")
+	check("unchanged", d.Unchanged, "aws_s3_bucket.logs")
+
+	web := d.Resources["aws_instance.web"]
+	if len(web.Attributes) != 1 || web.Attributes[0].Name != "instance_type" ||
+		web.Attributes[0].Before != "t3.small" || web.Attributes[0].After != "t3.large" {
+		t.Errorf("unexpected attribute diff %+v", web.Attributes)
+	}
+	if moved := d.Resources["aws_db_instance.main"]; moved.PreviousAddress != "aws_db_instance.primary" || len(moved.Attributes) != 0 {
+		t.Errorf("moved resource should match its previous address, got %+v", moved)
+	}
+	nat := d.Resources["aws_nat_gateway.new"]
+	if len(nat.Attributes) != 2 || nat.Attributes[1].Name != "id" || !nat.Attributes[1].Unknown {
+		t.Errorf("unknown head attributes should be flagged, got %+v", nat.Attributes)
+	}
+
+	if a.Diff(head, head).HasChanges() {
+		t.Error("a plan diffed with itself should have no changes")
+	}
+	if d := a.Diff(nil, head); len(d.Created) != 4 {
+		t.Errorf("everything is created against no base, got %v", d.Created)
+	}
+}
+
+// ignoreChangesPlanJSON is planned after tags were edited in a resource
+// with lifecycle { ignore_changes = [tags] }: Terraform keeps the prior
+// tags and plans a no-op
+const ignoreChangesPlanJSON = `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {
+      "address": "aws_instance.web",
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "change": {
+        "actions": ["no-op"],
+        "before": {"instance_type": "t3.large", "tags": {"team": "payments"}},
+        "after": {"instance_type": "t3.large", "tags": {"team": "payments"}}
+      }
+    }
+  ],
+  "configuration": {
+    "root_module": {
+      "resources": [
+        {"address": "aws_instance.web", "mode": "managed", "type": "aws_instance", "name": "web",
+         "expressions": {"instance_type": {"constant_value": "t3.large"}, "tags": {"constant_value": {"team": "billing"}}}}
+      ]
+    }
+  }
+}`
+
+// TestIgnoreChangesNoOp proves a no-op left by ignore_changes is priced
+// from its planned values on both sides and is not a change in a diff
+func TestIgnoreChangesNoOp(t *testing.T) {
+	a := &Adapter{config: DefaultConfig()}
+	head, err := a.ParsePlanJSON([]byte(ignoreChangesPlanJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resources := a.ExtractResources(head)
+	if len(resources) != 1 {
+		t.Fatalf("got %d resources, want 1", len(resources))
+	}
+	web := resources[0]
+	if web.Action != "no_change" || !web.InBase() || !web.InHead() {
+		t.Errorf("action = %q, want no_change priced on both sides", web.Action)
+	}
+	if web.Values["instance_type"] != "t3.large" {
+		t.Errorf("values = %v, want the planned instance_type", web.Values)
+	}
+	if _, ok := web.Values["lifecycle"]; ok {
+		t.Errorf("lifecycle leaked into the priced values: %v", web.Values)
+	}
+	if web.Tags["team"] != "payments" {
+		t.Errorf("tags = %v, want the prior tags Terraform keeps", web.Tags)
+	}
+
+	base, err := a.ParsePlanJSON([]byte(strings.ReplaceAll(ignoreChangesPlanJSON, `"no-op"`, `"update"`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := a.Diff(base, head)
+	if d.HasChanges() || len(d.Unchanged) != 1 {
+		t.Errorf("ignored tag edit should leave the resource unchanged, got %+v", d)
+	}
+}
+
+const refreshOnlyPlanJSON = `{
+  "format_version": "1.2",
+  "resource_drift": [
+    {
+      "address": "aws_instance.web",
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "change": {"actions": ["update"], "before": {"instance_type": "t3.small"}, "after": {"instance_type": "t3.large"}}
+    }
+  ],
+  "resource_changes": [
+    {
+      "address": "aws_instance.web",
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "change": {"actions": ["no-op"], "before": {"instance_type": "t3.large"}, "after": {"instance_type": "t3.large"}}
+    }
+  ]
+}`
+
+const forcedReplacePlanJSON = `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {
+      "address": "aws_instance.web",
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "action_reason": "replace_by_request",
+      "change": {"actions": ["delete", "create"], "before": {"instance_type": "t3.large"}, "after": {"instance_type": "t3.large"}}
+    }
+  ]
+}`
+
+const driftUpdatePlanJSON = `{
+  "format_version": "1.2",
+  "resource_drift": [
+    {
+      "address": "aws_instance.web",
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "change": {"actions": ["update"], "before": {"instance_type": "t3.small"}, "after": {"instance_type": "t3.large"}}
+    }
+  ],
+  "resource_changes": [
+    {
+      "address": "aws_instance.web",
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "change": {"actions": ["update"], "before": {"instance_type": "t3.large"}, "after": {"instance_type": "t3.xlarge"}}
+    }
+  ]
+}`
+
+// TestPlanModes proves refresh-only drift never changes cost and a
+// -replace is an update, in both extraction and plan diffs
+func TestPlanModes(t *testing.T) {
+	a := &Adapter{config: DefaultConfig()}
+	parse := func(js string) *PlanOutput {
+		t.Helper()
+		plan, err := a.ParsePlanJSON([]byte(js))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return plan
+	}
+
+	tests := []struct {
+		name        string
+		plan        *PlanOutput
+		mode        PlanMode
+		action      string
+		replace     []string
+		warnings    int
+		diffUpdated bool
+	}{
+		{name: "normal with drift", plan: parse(driftUpdatePlanJSON), mode: PlanModeNormal, action: "update", diffUpdated: true},
+		{name: "refresh-only", plan: parse(refreshOnlyPlanJSON), mode: PlanModeRefreshOnly, action: "no_change", warnings: 1},
+		{name: "replace", plan: parse(forcedReplacePlanJSON), mode: PlanModeNormal, action: "update",
+			replace: []string{"aws_instance.web"}, diffUpdated: true},
+	}
+
+	// The base is the configuration before any of the plans: one t3.large
+	base := parse(refreshOnlyPlanJSON)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extraction, err := a.ExtractPlan(tt.plan)
+			if err != nil {
+				t.Fatalf("ExtractPlan: %v", err)
+			}
+			meta := extraction.Metadata
+			if meta.Mode != tt.mode {
+				t.Errorf("mode = %q, want %q", meta.Mode, tt.mode)
+			}
+			if strings.Join(meta.Replace, ",") != strings.Join(tt.replace, ",") {
+				t.Errorf("replace = %v, want %v", meta.Replace, tt.replace)
+			}
+			if len(meta.Warnings) != tt.warnings {
+				t.Errorf("warnings = %v, want %d", meta.Warnings, tt.warnings)
+			}
+
+			if len(extraction.Resources) != 1 {
+				t.Fatalf("drift must not be extracted, got %d resources", len(extraction.Resources))
+			}
+			r := extraction.Resources[0]
+			if r.Action != tt.action {
+				t.Errorf("action = %q, want %q", r.Action, tt.action)
+			}
+			if r.ReplaceRequested() != (tt.replace != nil) {
+				t.Errorf("ReplaceRequested = %v", r.ReplaceRequested())
+			}
+
+			d := a.Diff(base, tt.plan)
+			if updated := len(d.Updated) == 1; updated != tt.diffUpdated {
+				t.Errorf("diff updated = %v, want %v", d.Updated, tt.diffUpdated)
+			}
+			if len(d.Created)+len(d.Destroyed) != 0 {
+				t.Errorf("no create or destroy expected, got %+v", d)
+			}
+		})
+	}
+}
+
+const destroyPlanJSON = `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {
+      "address": "aws_instance.web",
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "change": {"actions": ["delete"], "before": {"instance_type": "t3.large"}, "after": null}
+    },
+    {
+      "address": "aws_ebs_volume.data",
+      "mode": "managed",
+      "type": "aws_ebs_volume",
+      "name": "data",
+      "change": {"actions": ["delete"], "before": {"type": "gp3", "size": 100}, "after": null}
+    },
+    {
+      "address": "data.aws_ami.ubuntu",
+      "mode": "data",
+      "type": "aws_ami",
+      "name": "ubuntu",
+      "change": {"actions": ["read"], "before": null, "after": {"id": "ami-123"}}
+    }
+  ]
+}`
+
+// TestDestroyPlan proves a plan deleting every managed resource is a
+// destroy plan whose resources exist only before the apply, and that one
+// delete among other changes is not
+func TestDestroyPlan(t *testing.T) {
+	a := &Adapter{config: DefaultConfig()}
+	plan, err := a.ParsePlanJSON([]byte(destroyPlanJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	extraction, err := a.ExtractPlan(plan)
+	if err != nil {
+		t.Fatalf("ExtractPlan: %v", err)
+	}
+	if extraction.Metadata.Mode != PlanModeDestroy {
+		t.Errorf("mode = %q, want %q", extraction.Metadata.Mode, PlanModeDestroy)
+	}
+	if len(extraction.Resources) != 2 {
+		t.Fatalf("got %d resources, want the two destroyed", len(extraction.Resources))
+	}
+	for _, r := range extraction.Resources {
+		if r.Action != "destroy" || !r.InBase() || r.InHead() {
+			t.Errorf("%s: action %q, in base %v, in head %v", r.Address, r.Action, r.InBase(), r.InHead())
+		}
+		if len(r.PriorValues) == 0 {
+			t.Errorf("%s: prior values lost, nothing to price the savings from", r.Address)
+		}
+	}
+
+	for name, js := range map[string]string{
+		"delete and create": strings.Replace(destroyPlanJSON, `"actions": ["delete"], "before": {"type"`, `"actions": ["create"], "before": {"type"`, 1),
+		"replace":           forcedReplacePlanJSON,
+	} {
+		plan, err := a.ParsePlanJSON([]byte(js))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode := plan.Mode(); mode != PlanModeNormal {
+			t.Errorf("%s: mode = %q, want %q", name, mode, PlanModeNormal)
+		}
+	}
+}
+
+// fakeTerraform writes a terraform stand-in that prints output for
+// `version -json`
+func fakeTerraform(t *testing.T, output string) string {
+	t.Helper()
+	bin := filepath.Join(t.TempDir(), "terraform")
+	script := "#!/bin/sh\ncat <<'OUT'\n" + output + "\nOUT\n"
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return bin
+}
+
+func TestCheckBinary(t *testing.T) {
+	adapter := func(path string) *Adapter {
+		config := DefaultConfig()
+		config.TerraformPath = path
+		a, err := New(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	ctx := context.Background()
+
+	for _, missing := range []string{"terraform-cost-no-such-binary", filepath.Join(t.TempDir(), "terraform")} {
+		a := adapter(missing)
+		if _, err := a.CheckBinary(ctx); !errors.Is(err, ErrTerraformNotFound) || !strings.Contains(err.Error(), "set TerraformPath") {
+			t.Errorf("CheckBinary(%s) = %v, want ErrTerraformNotFound", missing, err)
+		}
+		if err := a.Plan(ctx, "plan.out"); !errors.Is(err, ErrTerraformNotFound) {
+			t.Errorf("Plan with %s = %v, want ErrTerraformNotFound", missing, err)
+		}
+	}
+
+	version, err := adapter(fakeTerraform(t, `{"terraform_version": "1.5.7"}`)).CheckBinary(ctx)
+	if err != nil || version != "1.5.7" {
+		t.Errorf("CheckBinary = %q, %v; want 1.5.7", version, err)
+	}
+	if _, err := adapter(fakeTerraform(t, `{"terraform_version": "0.11.14"}`)).CheckBinary(ctx); !errors.Is(err, ErrTerraformTooOld) {
+		t.Errorf("0.11.14 should be too old, got %v", err)
+	}
+}
+
+func TestVersionAtLeast(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{"0.12.0", true},
+		{"v0.12.31", true},
+		{"1.6.0-beta1", true},
+		{"0.11.14", false},
+		{"0.9", false},
+		{"dev", true},
+	}
+	for _, tt := range tests {
+		if got := VersionAtLeast(tt.version, MinTerraformVersion); got != tt.want {
+			t.Errorf("VersionAtLeast(%q) = %v, want %v", tt.version, got, tt.want)
+		}
+	}
+}
+
+const defaultTagsPlanJSON = `{
+  "format_version": "1.2",
+  "variables": {"tags": {"value": {"cost-center": "cc-42"}}},
+  "configuration": {
+    "provider_config": {
+      "aws": {"name": "aws", "expressions": {
+        "region": {"constant_value": "us-east-1"},
+        "default_tags": [{"tags": {"constant_value": {"team": "platform", "Name": "default"}}}]
+      }},
+      "aws.shared": {"name": "aws", "alias": "shared", "expressions": {
+        "default_tags": [{"tags": {"references": ["var.tags"]}}]
+      }}
+    },
+    "root_module": {
+      "resources": [
+        {"address": "aws_instance.web", "mode": "managed", "type": "aws_instance", "name": "web", "provider_config_key": "aws"},
+        {"address": "aws_s3_bucket.logs", "mode": "managed", "type": "aws_s3_bucket", "name": "logs", "provider_config_key": "aws.shared"}
+      ],
+      "module_calls": {
+        "app": {"module": {"resources": [
+          {"address": "aws_instance.worker", "mode": "managed", "type": "aws_instance", "name": "worker", "provider_config_key": "module.app:aws"}
+        ]}}
+      }
+    }
+  },
+  "resource_changes": [
+    {"address": "aws_instance.web", "mode": "managed", "type": "aws_instance", "name": "web",
+     "change": {"actions": ["create"], "after": {"tags": {"Name": "web"}}, "after_unknown": {"tags_all": true}}},
+    {"address": "module.app.aws_instance.worker", "module_address": "module.app", "mode": "managed", "type": "aws_instance", "name": "worker",
+     "change": {"actions": ["create"], "after": {}}},
+    {"address": "aws_s3_bucket.logs", "mode": "managed", "type": "aws_s3_bucket", "name": "logs",
+     "change": {"actions": ["create"], "after": {"tags": {"Name": "logs"}}}}
+  ]
+}`
+
+// TestProviderDefaultTags proves a required tag set only through provider
+// default_tags satisfies a tag policy, and resource tags win over defaults
+func TestProviderDefaultTags(t *testing.T) {
+	a := &Adapter{config: DefaultConfig()}
+	plan, err := a.ParsePlanJSON([]byte(defaultTagsPlanJSON))
+	if err != nil {
+		t.Fatalf("ParsePlanJSON: %v", err)
+	}
+
+	want := map[string]map[string]string{
+		"aws_instance.web":               {"team": "platform", "Name": "web"},
+		"module.app.aws_instance.worker": {"team": "platform", "Name": "default"},
+		"aws_s3_bucket.logs":             {"cost-center": "cc-42", "Name": "logs"},
+	}
+	resources := a.ExtractResources(plan)
+	for _, r := range resources {
+		if fmt.Sprint(r.Tags) != fmt.Sprint(want[r.Address]) {
+			t.Errorf("%s: tags = %v, want %v", r.Address, r.Tags, want[r.Address])
+		}
+	}
+
+	graph, err := BuildInstanceGraph(resources, "us-east-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := &policy.PolicyInput{InstanceCosts: make(map[model.InstanceID]*policy.InstanceCostDetail)}
+	for _, inst := range graph.Instances() {
+		input.InstanceCosts[inst.ID] = &policy.InstanceCostDetail{
+			InstanceID: inst.ID,
+			Address:    inst.Address,
+			Tags:       engine.TagsFromValue(inst.Attributes["tags_all"].Value),
+		}
+	}
+	out, err := policy.NewTagRequirementPolicy("required_tags", []string{"Name"}).Evaluate(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !out.Passed {
+		t.Errorf("Name is set on every resource, got %s %v", out.Message, out.AffectedInstances)
+	}
+	out, err = policy.NewTagRequirementPolicy("required_tags", []string{"team"}).Evaluate(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Passed || len(out.AffectedInstances) != 1 {
+		t.Errorf("only the bucket lacks team, got %s %v", out.Message, out.AffectedInstances)
+	}
+}
+
+// targetedPlanJSON is `terraform plan -target=aws_instance.web`: the
+// database is in planned_values but has no resource change
+const targetedPlanJSON = `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {"address": "aws_instance.web", "mode": "managed", "type": "aws_instance", "name": "web",
+     "change": {"actions": ["update"], "before": {"instance_type": "t3.small"}, "after": {"instance_type": "t3.large"}}}
+  ],
+  "planned_values": {"root_module": {
+    "resources": [
+      {"address": "aws_instance.web", "mode": "managed", "type": "aws_instance", "name": "web", "values": {"instance_type": "t3.large"}},
+      {"address": "data.aws_ami.ubuntu", "mode": "data", "type": "aws_ami", "name": "ubuntu", "values": {}}
+    ],
+    "child_modules": [{"address": "module.db", "resources": [
+      {"address": "module.db.aws_db_instance.main", "mode": "managed", "type": "aws_db_instance", "name": "main", "values": {"instance_class": "db.t3.medium"}},
+      {"address": "module.db.aws_db_instance.replica", "mode": "managed", "type": "aws_db_instance", "name": "replica", "values": {"instance_class": "db.t3.medium"}}
+    ]}]
+  }}
+}`
+
+// TestTargetedPlan proves a targeted plan is labeled a partial total and
+// that state fills in the untargeted resources it has
+func TestTargetedPlan(t *testing.T) {
+	a := &Adapter{config: DefaultConfig()}
+	plan, err := a.ParsePlanJSON([]byte(targetedPlanJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	extraction, err := a.ExtractPlan(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := extraction.Metadata
+	if meta.Scope != ScopeTargetedSubset {
+		t.Errorf("scope = %q, want %q", meta.Scope, ScopeTargetedSubset)
+	}
+	if got := strings.Join(meta.Untargeted, ","); got != "module.db.aws_db_instance.main,module.db.aws_db_instance.replica" {
+		t.Errorf("untargeted = %s", got)
+	}
+	if len(meta.Warnings) != 1 || !strings.Contains(meta.Warnings[0], "2 of 3 resources") {
+		t.Errorf("warnings = %v", meta.Warnings)
+	}
+
+	// State has the primary but not the replica
+	state, err := a.ParseStateJSON([]byte(`{"format_version": "1.0", "values": {"root_module": {"child_modules": [{"address": "module.db", "resources": [
+	  {"address": "module.db.aws_db_instance.main", "mode": "managed", "type": "aws_db_instance", "name": "main", "values": {"instance_class": "db.t3.medium"}}
+	]}]}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.FillUntargeted(extraction, state); err != nil {
+		t.Fatal(err)
+	}
+	if len(extraction.Resources) != 2 || extraction.Resources[1].Address != "module.db.aws_db_instance.main" {
+		t.Fatalf("resources = %+v", extraction.Resources)
+	}
+	if extraction.Resources[1].Action != "no_change" {
+		t.Errorf("untargeted action = %q, want no_change", extraction.Resources[1].Action)
+	}
+	meta = extraction.Metadata
+	if meta.Scope != ScopeTargetedSubset {
+		t.Error("a resource missing from state should keep the total partial")
+	}
+	if len(meta.Warnings) != 2 || !strings.Contains(meta.Warnings[1], "module.db.aws_db_instance.replica") {
+		t.Errorf("warnings = %v", meta.Warnings)
+	}
+
+	// A full plan is not targeted
+	full, _ := a.ParsePlanJSON([]byte(diffHeadPlanJSON))
+	if untargeted := full.Untargeted(); len(untargeted) != 0 {
+		t.Errorf("plan without planned_values reported untargeted %v", untargeted)
+	}
+}
+
+func TestPlanSource(t *testing.T) {
+	ctx := context.Background()
+	plan := []byte(movedPlanJSON)
+
+	path := filepath.Join(t.TempDir(), "plan.json")
+	if err := os.WriteFile(path, plan, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/plan.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(plan)
+	}))
+	defer server.Close()
+
+	for _, src := range []*PlanSource{
+		{Location: "-", Stdin: strings.NewReader(movedPlanJSON)},
+		{Location: path},
+		{Location: server.URL + "/plan.json"},
+	} {
+		data, err := src.Read(ctx)
+		if err != nil {
+			t.Errorf("%s: %v", src.Kind(), err)
+			continue
+		}
+		if string(data) != movedPlanJSON {
+			t.Errorf("%s: read %d bytes, want the plan", src.Kind(), len(data))
+		}
+	}
+
+	for name, src := range map[string]*PlanSource{
+		"stdin over limit": {Location: "-", Stdin: strings.NewReader(movedPlanJSON), MaxBytes: 10},
+		"file over limit":  {Location: path, MaxBytes: 10},
+		"URL over limit":   {Location: server.URL + "/plan.json", MaxBytes: 10},
+	} {
+		if _, err := src.Read(ctx); !errors.Is(err, ErrPlanTooLarge) {
+			t.Errorf("%s: err = %v, want ErrPlanTooLarge", name, err)
+		}
+	}
+
+	if _, err := NewPlanSource(server.URL + "/missing.json").Read(ctx); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("missing URL: err = %v, want a 404", err)
+	}
+}
+
+// TestPlanJSONWithoutTerraform proves plan JSON is parsed from a reader or
+// a file of any name without running terraform, and a binary plan file
+// given as JSON is explained
+func TestPlanJSONWithoutTerraform(t *testing.T) {
+	plan, err := ReadPlanJSON(strings.NewReader(movedPlanJSON))
+	if err != nil || len(plan.ResourceChanges) == 0 {
+		t.Fatalf("ReadPlanJSON = %v, %v", plan, err)
+	}
+
+	if _, err := ReadPlanJSON(strings.NewReader("PK\x03\x04binary")); !errors.Is(err, ErrBinaryPlan) {
+		t.Errorf("binary plan: err = %v, want ErrBinaryPlan", err)
+	}
+
+	// No terraform binary: only a binary plan file would need one
+	a, err := New(&Config{TerraformPath: filepath.Join(t.TempDir(), "no-terraform"), WorkDir: "."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "tfplan.out")
+	if err := os.WriteFile(path, []byte("\n  "+movedPlanJSON), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !IsPlanJSONFile(path) {
+		t.Errorf("%s holds JSON", path)
+	}
+	plan, err = a.ParsePlanFile(context.Background(), path)
+	if err != nil || len(plan.ResourceChanges) == 0 {
+		t.Fatalf("ParsePlanFile(JSON) = %v, %v", plan, err)
+	}
+
+	binary := filepath.Join(t.TempDir(), "tfplan")
+	if err := os.WriteFile(binary, []byte("PK\x03\x04binary"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if IsPlanJSONFile(binary) {
+		t.Error("a binary plan file is not JSON")
+	}
+	if _, err := a.ParsePlanFile(context.Background(), binary); err == nil {
+		t.Error("a binary plan file needs terraform, which is missing")
+	}
+}
@@ -0,0 +1,96 @@
+// Package terraform - Terraform binary checks
+// Parsing plan and state JSON needs no executable, so New does not look for
+// one. Commands that run terraform fail with ErrTerraformNotFound rather
+// than a raw exec error, and CheckBinary lets callers verify the binary and
+// its version before starting a plan.
+package terraform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// MinTerraformVersion is the oldest release with `terraform show -json`
+const MinTerraformVersion = "0.12.0"
+
+var (
+	// ErrTerraformNotFound is returned when the terraform executable
+	// cannot be found
+	ErrTerraformNotFound = errors.New("terraform not found")
+
+	// ErrTerraformTooOld is returned when terraform predates MinTerraformVersion
+	ErrTerraformTooOld = errors.New("terraform version too old")
+)
+
+// CheckBinary verifies the configured terraform executable exists and is at
+// least MinTerraformVersion, returning its version
+func (a *Adapter) CheckBinary(ctx context.Context) (string, error) {
+	if _, err := exec.LookPath(a.terraformPath); err != nil {
+		return "", a.notFound()
+	}
+
+	version, err := a.Version(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to determine terraform version: %w", err)
+	}
+	if !VersionAtLeast(version, MinTerraformVersion) {
+		return version, fmt.Errorf("%w: %s is %s, need %s or later for JSON plan output",
+			ErrTerraformTooOld, a.terraformPath, version, MinTerraformVersion)
+	}
+	return version, nil
+}
+
+// notFound is the error for a missing executable
+func (a *Adapter) notFound() error {
+	return fmt.Errorf("%w (looked for %q); install terraform or set TerraformPath", ErrTerraformNotFound, a.terraformPath)
+}
+
+// IsNotFound reports whether a failed exec means the executable is missing.
+// A bare name not on PATH gives exec.ErrNotFound; a path that does not
+// exist gives fs.ErrNotExist.
+func IsNotFound(err error) bool {
+	return errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist)
+}
+
+// VersionAtLeast compares dotted versions numerically, ignoring a leading
+// "v" and any pre-release suffix. Unparseable versions pass, since refusing
+// an unknown build is worse than trying it.
+func VersionAtLeast(version, min string) bool {
+	have, ok := parseVersion(version)
+	if !ok {
+		return true
+	}
+	want, _ := parseVersion(min)
+	for i := range want {
+		if have[i] != want[i] {
+			return have[i] > want[i]
+		}
+	}
+	return true
+}
+
+// parseVersion parses "1.5.7", "v0.12.31" or "1.6.0-beta1" into numbers
+func parseVersion(version string) ([3]int, bool) {
+	var parts [3]int
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, "-+ "); i >= 0 {
+		version = version[:i]
+	}
+	fields := strings.Split(version, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
@@ -0,0 +1,140 @@
+// Package terraform - Plan format compatibility checks
+// ExtractResources assumes the JSON plan layout Terraform has produced since
+// format 0.1. A newer major format, a refresh-only plan or a state file passed
+// by mistake would otherwise parse cleanly and yield an empty estimate.
+package terraform
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// knownPlanFormats are the plan format_version values extraction is tested against
+var knownPlanFormats = map[string]bool{
+	"0.1": true,
+	"0.2": true,
+	"1.0": true,
+	"1.1": true,
+	"1.2": true,
+}
+
+// ErrNoResourceChanges is returned when a plan has nothing to estimate
+var ErrNoResourceChanges = errors.New("plan has no resource changes")
+
+// PlanMetadata describes the plan an extraction came from
+type PlanMetadata struct {
+	FormatVersion    string   `json:"format_version"`
+	TerraformVersion string   `json:"terraform_version,omitempty"`
+	Mode             PlanMode `json:"mode"`
+	Replace          []string `json:"replace,omitempty"`
+	Warnings         []string `json:"warnings,omitempty"`
+
+	// Untargeted lists resources a targeted plan leaves out
+	Untargeted []string `json:"untargeted,omitempty"`
+
+	// Scope is ScopeTargetedSubset when the resources cover only part of
+	// the configuration, otherwise empty
+	Scope string `json:"scope,omitempty"`
+}
+
+// PlanExtraction is the set of resources to price plus plan metadata
+type PlanExtraction struct {
+	Resources []ResourceInfo `json:"resources"`
+	Metadata  PlanMetadata   `json:"metadata"`
+}
+
+// CheckPlanCompatibility validates the plan format before extraction.
+// Unknown format versions and no-op-only plans produce warnings; plans that
+// cannot yield an estimate (errored, refresh-only, no resource_changes)
+// return an error.
+func CheckPlanCompatibility(plan *PlanOutput) (*PlanMetadata, error) {
+	meta := &PlanMetadata{
+		FormatVersion:    plan.FormatVersion,
+		TerraformVersion: plan.TerraformVersion,
+		Mode:             plan.Mode(),
+		Replace:          plan.ReplaceRequested(),
+	}
+
+	switch {
+	case plan.FormatVersion == "":
+		meta.Warnings = append(meta.Warnings,
+			"plan has no format_version; input may not be `terraform show -json` output")
+	case !knownPlanFormats[plan.FormatVersion]:
+		if strings.HasPrefix(plan.FormatVersion, "0.") || strings.HasPrefix(plan.FormatVersion, "1.") {
+			meta.Warnings = append(meta.Warnings, fmt.Sprintf(
+				"plan format_version %s is newer than tested; new fields are ignored", plan.FormatVersion))
+		} else {
+			meta.Warnings = append(meta.Warnings, fmt.Sprintf(
+				"plan format_version %s is not supported; extraction may be incomplete", plan.FormatVersion))
+		}
+	}
+
+	if plan.Errored {
+		return meta, fmt.Errorf("plan errored during planning (terraform %s); fix the plan errors and re-run terraform plan",
+			versionOrUnknown(plan.TerraformVersion))
+	}
+
+	if len(plan.ResourceChanges) == 0 {
+		switch {
+		case len(plan.ResourceDrift) > 0:
+			return meta, fmt.Errorf("%w: plan only reports drift, which looks like a refresh-only plan; run terraform plan without -refresh-only",
+				ErrNoResourceChanges)
+		case plan.PlannedValues == nil && plan.Configuration == nil:
+			return meta, fmt.Errorf("%w: input has neither planned_values nor configuration; pass `terraform show -json <planfile>` output, not state",
+				ErrNoResourceChanges)
+		default:
+			return meta, fmt.Errorf("%w: nothing to estimate; check the plan targets the intended configuration",
+				ErrNoResourceChanges)
+		}
+	}
+
+	switch {
+	case meta.Mode == PlanModeRefreshOnly:
+		meta.Warnings = append(meta.Warnings,
+			"refresh-only plan: drift updates state, not configuration, so the cost delta is zero")
+	case noChangesOnly(plan.ResourceChanges):
+		meta.Warnings = append(meta.Warnings,
+			"plan contains only no-op changes; the estimate reflects existing resources and the cost delta is zero")
+	}
+
+	if untargeted := plan.Untargeted(); len(untargeted) > 0 {
+		meta.Untargeted = untargeted
+		meta.Scope = ScopeTargetedSubset
+		meta.Warnings = append(meta.Warnings,
+			targetedWarning(len(untargeted), len(ExtractPlannedResources(plan.PlannedValues))))
+	}
+
+	return meta, nil
+}
+
+// ExtractPlan checks plan compatibility and extracts resources for estimation
+func (a *Adapter) ExtractPlan(plan *PlanOutput) (*PlanExtraction, error) {
+	meta, err := CheckPlanCompatibility(plan)
+	if err != nil {
+		return nil, err
+	}
+	return &PlanExtraction{
+		Resources: a.ExtractResources(plan),
+		Metadata:  *meta,
+	}, nil
+}
+
+func noChangesOnly(changes []ResourceChange) bool {
+	for _, c := range changes {
+		if c.Mode == "data" {
+			continue
+		}
+		if len(c.Change.Actions) > 0 && !contains(c.Change.Actions, "no-op") {
+			return false
+		}
+	}
+	return true
+}
+
+func versionOrUnknown(v string) string {
+	if v == "" {
+		return "unknown"
+	}
+	return v
+}
@@ -0,0 +1,64 @@
+// Package terraform - Data source values from a plan
+// terraform plan reads data sources, and their results are recorded in
+// the plan's prior state and planned values. They let the HCL pipeline
+// resolve references such as for_each = toset(data.aws_subnets.x.ids),
+// which are unknown from the configuration alone.
+package terraform
+
+// DataSourceValues returns the values of the root module's data sources
+// read by the plan, keyed by address without an index, e.g.
+// "data.aws_subnets.private". A data source with count has a []any of its
+// instance values, one with for_each a map[string]any keyed by each.key.
+// Planned values win over prior state.
+func (p *PlanOutput) DataSourceValues() map[string]any {
+	values := make(map[string]any)
+	if p.PriorState != nil {
+		addDataSourceValues(values, p.PriorState.Values)
+	}
+	addDataSourceValues(values, p.PlannedValues)
+	return values
+}
+
+// addDataSourceValues adds the root module's data sources in planned
+func addDataSourceValues(values map[string]any, planned *PlannedValues) {
+	if planned == nil {
+		return
+	}
+	counted := make(map[string]map[int]any)
+	for _, r := range planned.RootModule.Resources {
+		if r.Mode != "data" || r.Values == nil {
+			continue
+		}
+		addr := "data." + r.Type + "." + r.Name
+		switch index := r.Index.(type) {
+		case nil:
+			values[addr] = r.Values
+		case string:
+			instances, ok := values[addr].(map[string]any)
+			if !ok {
+				instances = make(map[string]any)
+				values[addr] = instances
+			}
+			instances[index] = r.Values
+		case float64:
+			if counted[addr] == nil {
+				counted[addr] = make(map[int]any)
+			}
+			counted[addr][int(index)] = r.Values
+		}
+	}
+	for addr, byIndex := range counted {
+		instances := make([]any, len(byIndex))
+		for i, v := range byIndex {
+			if i < 0 || i >= len(instances) {
+				// a gap in the indexes: the count is not known
+				instances = nil
+				break
+			}
+			instances[i] = v
+		}
+		if instances != nil {
+			values[addr] = instances
+		}
+	}
+}
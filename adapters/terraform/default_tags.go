@@ -0,0 +1,79 @@
+// Package terraform - Provider default tags
+// AWS provider default_tags apply to every resource the provider manages
+// but are not in the resource's tags attribute. tags_all includes them,
+// yet it is unknown in a plan whenever any tag is computed, and absent for
+// resources that predate it. The provider configuration carries the
+// default_tags block, so each resource's effective tags can be rebuilt.
+package terraform
+
+import (
+	"fmt"
+	"strings"
+)
+
+// providerDefaultTags reads the default_tags block of a provider. Constant
+// tag maps are used directly; a single root variable reference resolves
+// from the plan's input variables.
+func providerDefaultTags(cfg ProviderConfig, vars map[string]Variable) map[string]string {
+	blocks, ok := cfg.Expressions["default_tags"].([]interface{})
+	if !ok || len(blocks) == 0 {
+		return nil
+	}
+	block, ok := blocks[0].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	expr, ok := block["tags"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	if v, ok := expr["constant_value"].(map[string]interface{}); ok {
+		return stringTags(v)
+	}
+	if cfg.ModuleAddress != "" {
+		return nil
+	}
+	refs, _ := expr["references"].([]interface{})
+	for _, ref := range refs {
+		name, ok := ref.(string)
+		if !ok || !strings.HasPrefix(name, "var.") {
+			continue
+		}
+		if v, ok := vars[strings.TrimPrefix(name, "var.")].Value.(map[string]interface{}); ok {
+			return stringTags(v)
+		}
+	}
+	return nil
+}
+
+// stringTags converts a tag map, formatting non-string values
+func stringTags(raw map[string]interface{}) map[string]string {
+	if len(raw) == 0 {
+		return nil
+	}
+	tags := make(map[string]string, len(raw))
+	for k, v := range raw {
+		if s, ok := v.(string); ok {
+			tags[k] = s
+		} else if v != nil {
+			tags[k] = fmt.Sprint(v)
+		}
+	}
+	return tags
+}
+
+// mergeTags returns defaults overlaid with tags; as in Terraform, a
+// resource tag wins over a default tag with the same key
+func mergeTags(defaults, tags map[string]string) map[string]string {
+	if len(defaults) == 0 {
+		return tags
+	}
+	merged := make(map[string]string, len(defaults)+len(tags))
+	for k, v := range defaults {
+		merged[k] = v
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,157 @@
+// Package terraform - Plan-to-plan diff
+// Compares the resources two plans leave behind (e.g. the base and head of
+// a pull request). This is the shared primitive for CI and HTTP diffs, so
+// address matching and attribute comparison live in one place.
+package terraform
+
+import (
+	"reflect"
+	"sort"
+)
+
+// PlanDiff is the resource-level difference between two plans
+type PlanDiff struct {
+	Created   []string `json:"created"`
+	Destroyed []string `json:"destroyed"`
+	Updated   []string `json:"updated"`
+	Unchanged []string `json:"unchanged"`
+
+	// Resources holds created, destroyed and updated resources by head
+	// address (base address for destroyed ones)
+	Resources map[string]*ResourceDiff `json:"resources"`
+}
+
+// ResourceDiff is one changed resource
+type ResourceDiff struct {
+	Address         string          `json:"address"`
+	PreviousAddress string          `json:"previous_address,omitempty"`
+	Type            string          `json:"type"`
+	Action          string          `json:"action"`
+	Attributes      []AttributeDiff `json:"attributes,omitempty"`
+}
+
+// AttributeDiff is a top-level attribute whose value differs
+type AttributeDiff struct {
+	Name   string      `json:"name"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+
+	// Unknown is set when the head value is only known after apply
+	Unknown bool `json:"unknown,omitempty"`
+}
+
+// HasChanges reports whether any resource differs
+func (d *PlanDiff) HasChanges() bool {
+	return len(d.Created)+len(d.Destroyed)+len(d.Updated) > 0
+}
+
+// Diff compares the resources that exist after applying base with those
+// after applying head. A head resource moved from a base address is
+// matched to it rather than counted as a destroy and a create, and a
+// replaced resource is an update. Drift is never compared. A nil plan has
+// no resources.
+func (a *Adapter) Diff(base, head *PlanOutput) *PlanDiff {
+	result := &PlanDiff{Resources: make(map[string]*ResourceDiff)}
+	if base == nil {
+		base = &PlanOutput{}
+	}
+	if head == nil {
+		head = &PlanOutput{}
+	}
+
+	baseByAddr := make(map[string]ResourceInfo)
+	for _, r := range a.ExtractResources(base) {
+		if r.InHead() {
+			baseByAddr[r.Address] = r
+		}
+	}
+
+	matched := make(map[string]bool, len(baseByAddr))
+	for _, h := range a.ExtractResources(head) {
+		if !h.InHead() {
+			continue
+		}
+
+		b, ok := baseByAddr[h.Address]
+		if !ok && h.PreviousAddress != "" {
+			b, ok = baseByAddr[h.PreviousAddress]
+		}
+		if !ok {
+			result.Created = append(result.Created, h.Address)
+			result.Resources[h.Address] = &ResourceDiff{
+				Address:    h.Address,
+				Type:       h.Type,
+				Action:     "create",
+				Attributes: diffAttributes(nil, h.Values, nil, h.Unknown),
+			}
+			continue
+		}
+		matched[b.Address] = true
+
+		// A replacement forced with -replace changes no attribute but
+		// still recreates the resource, so it is an update
+		attrs := diffAttributes(b.Values, h.Values, b.Unknown, h.Unknown)
+		if len(attrs) == 0 && b.Address == h.Address && (!h.Replace || b.Replace) {
+			result.Unchanged = append(result.Unchanged, h.Address)
+			continue
+		}
+		diff := &ResourceDiff{Address: h.Address, Type: h.Type, Action: "update", Attributes: attrs}
+		if b.Address != h.Address {
+			diff.PreviousAddress = b.Address
+		}
+		result.Updated = append(result.Updated, h.Address)
+		result.Resources[h.Address] = diff
+	}
+
+	for addr, b := range baseByAddr {
+		if matched[addr] {
+			continue
+		}
+		result.Destroyed = append(result.Destroyed, addr)
+		result.Resources[addr] = &ResourceDiff{
+			Address:    addr,
+			Type:       b.Type,
+			Action:     "destroy",
+			Attributes: diffAttributes(b.Values, nil, b.Unknown, nil),
+		}
+	}
+
+	sort.Strings(result.Created)
+	sort.Strings(result.Destroyed)
+	sort.Strings(result.Updated)
+	sort.Strings(result.Unchanged)
+	return result
+}
+
+// diffAttributes returns the sorted top-level attributes that differ. An
+// attribute unknown in both plans is not a difference.
+func diffAttributes(before, after, beforeUnknown, unknown map[string]interface{}) []AttributeDiff {
+	names := make(map[string]bool, len(before)+len(after))
+	for name := range before {
+		names[name] = true
+	}
+	for name := range after {
+		names[name] = true
+	}
+	for name, v := range unknown {
+		if isUnknown, _ := v.(bool); isUnknown {
+			names[name] = true
+		}
+	}
+
+	var diffs []AttributeDiff
+	for name := range names {
+		if isUnknown, _ := unknown[name].(bool); isUnknown {
+			if wasUnknown, _ := beforeUnknown[name].(bool); wasUnknown {
+				continue
+			}
+			diffs = append(diffs, AttributeDiff{Name: name, Before: before[name], Unknown: true})
+			continue
+		}
+		if !reflect.DeepEqual(before[name], after[name]) {
+			diffs = append(diffs, AttributeDiff{Name: name, Before: before[name], After: after[name]})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Name < diffs[j].Name })
+	return diffs
+}
@@ -0,0 +1,100 @@
+// Package terraform - Instance graph from plan resources
+// A plan already has every count/for_each instance expanded and every
+// known value resolved, so each planned resource maps to one instance.
+package terraform
+
+import (
+	"strings"
+
+	"terraform-cost/core/model"
+)
+
+// BuildInstanceGraph converts extracted plan resources into an instance
+// graph. Destroyed resources keep their prior values and are marked
+// Destroyed, so they are priced as savings rather than counted;
+// defaultRegion applies when the provider region could not be resolved
+// from the plan. Two addresses sharing an instance ID is an error.
+func BuildInstanceGraph(resources []ResourceInfo, defaultRegion string) (*model.InstanceGraph, error) {
+	graph := model.NewInstanceGraph()
+	for _, r := range resources {
+		canonical, err := model.ParseAddress(r.Address)
+		if err != nil {
+			canonical = model.CanonicalAddress(r.Address)
+		}
+
+		region := r.Region
+		if region == "" {
+			region = defaultRegion
+		}
+
+		destroyed := !r.InHead()
+		attrs := resolvedAttributes(r.Values, r.Unknown)
+		if destroyed {
+			attrs = resolvedAttributes(r.PriorValues, nil)
+		}
+		if tagsAll, ok := attrs["tags_all"]; len(r.Tags) > 0 && (!ok || tagsAll.IsUnknown) {
+			// Effective tags, including provider default_tags, for
+			// cost allocation and tag policies
+			attrs["tags_all"] = model.ResolvedAttribute{Value: effectiveTags(r.Tags)}
+		}
+
+		err = graph.AddInstance(&model.AssetInstance{
+			ID:         canonical.StableID(),
+			Address:    model.InstanceAddress(r.Address),
+			Attributes: attrs,
+			Provider: model.ResolvedProvider{
+				Type:   providerType(r.Provider),
+				Alias:  providerAlias(r.ProviderKey),
+				Region: region,
+			},
+			Metadata: model.InstanceMetadata{Source: model.SourcePlanJSON, Destroyed: destroyed},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return graph, nil
+}
+
+// resolvedAttributes marks after_unknown attributes as computed at apply
+func resolvedAttributes(values, unknown map[string]interface{}) map[string]model.ResolvedAttribute {
+	attrs := make(map[string]model.ResolvedAttribute, len(values)+len(unknown))
+	for name, v := range values {
+		attrs[name] = model.ResolvedAttribute{Value: v}
+	}
+	for name, v := range unknown {
+		if isUnknown, _ := v.(bool); isUnknown {
+			attrs[name] = model.ResolvedAttribute{IsUnknown: true, Reason: model.ReasonComputedAtApply}
+		}
+	}
+	return attrs
+}
+
+// effectiveTags converts tags to the attribute value shape of tags_all
+func effectiveTags(tags map[string]string) map[string]interface{} {
+	value := make(map[string]interface{}, len(tags))
+	for k, v := range tags {
+		value[k] = v
+	}
+	return value
+}
+
+// providerType returns the short provider name of
+// "registry.terraform.io/hashicorp/aws"
+func providerType(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// providerAlias returns "west" for a provider key like "module.app:aws.west"
+func providerAlias(key string) string {
+	if i := strings.LastIndex(key, ":"); i >= 0 {
+		key = key[i+1:]
+	}
+	if i := strings.Index(key, "."); i >= 0 {
+		return key[i+1:]
+	}
+	return ""
+}
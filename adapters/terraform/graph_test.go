@@ -0,0 +1,48 @@
+// Package terraform - Plan graph golden tests
+package terraform
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"terraform-cost/core/model/graphtest"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// TestPlanGraphGolden reads each plan in testdata/plans, builds its
+// instance graph and compares the graph's addresses, provider bindings,
+// unknown values, edges and order with the plan's .golden.json. Run with
+// -update to rewrite the golden files after an intended change.
+func TestPlanGraphGolden(t *testing.T) {
+	plans, err := filepath.Glob(filepath.Join("testdata", "plans", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &Adapter{config: DefaultConfig()}
+	for _, path := range plans {
+		if strings.HasSuffix(path, ".golden.json") {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(path), ".json")
+		t.Run(name, func(t *testing.T) {
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			plan, err := ReadPlanJSON(f)
+			if err != nil {
+				t.Fatalf("ReadPlanJSON: %v", err)
+			}
+			graph, err := BuildInstanceGraph(a.ExtractResources(plan), "us-east-1")
+			if err != nil {
+				t.Fatalf("BuildInstanceGraph: %v", err)
+			}
+			graphtest.Assert(t, strings.TrimSuffix(path, ".json")+".golden.json", graph, *updateGolden)
+		})
+	}
+}
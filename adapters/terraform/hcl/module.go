@@ -0,0 +1,318 @@
+// Package hcl - Pipeline source parser
+// ModuleParser reads a module directory's .tf files into the evaluation
+// pipeline's blocks, so estimates need neither `terraform init` nor a
+// backend or credentials. Literal values are kept; any expression with
+// references is passed on unevaluated for the later phases.
+package hcl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+
+	"terraform-cost/core/model"
+	"terraform-cost/core/terraform"
+)
+
+// metaBlocks are nested blocks that configure Terraform, not the resource
+var metaBlocks = map[string]bool{
+	"lifecycle":   true,
+	"provisioner": true,
+	"connection":  true,
+	"dynamic":     true,
+}
+
+// ModuleParser implements terraform.SourceParser for one module directory
+type ModuleParser struct{}
+
+// NewModuleParser creates a module parser
+func NewModuleParser() *ModuleParser {
+	return &ModuleParser{}
+}
+
+// ParseModule parses input.Files, or every .tf file directly in
+// input.RootPath. Files that fail to parse are reported together in the
+// error; blocks from the other files are still returned.
+func (p *ModuleParser) ParseModule(ctx context.Context, input *terraform.ScanInput) (*terraform.ParsedModule, error) {
+	files := input.Files
+	if len(files) == 0 {
+		matches, err := filepath.Glob(filepath.Join(input.RootPath, "*.tf"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list .tf files: %w", err)
+		}
+		files = matches
+	}
+	sort.Strings(files)
+
+	parsed := &terraform.ParsedModule{
+		Path:        input.RootPath,
+		Definitions: []*model.AssetDefinition{},
+		Variables:   []*terraform.VariableBlock{},
+		Locals:      []*terraform.LocalBlock{},
+	}
+	var errs []error
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return parsed, err
+		}
+		if err := p.parseFile(file, input.RootPath, parsed); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	parsed.Definitions, errs = dropDuplicates(parsed.Definitions, errs)
+	parsed.DataSources, errs = dropDuplicates(parsed.DataSources, errs)
+	return parsed, errors.Join(errs...)
+}
+
+// dropDuplicates keeps the first definition of each address, adding an
+// error naming both locations for every later one. Terraform rejects a
+// module that defines an address twice; pricing both would count it twice.
+func dropDuplicates(defs []*model.AssetDefinition, errs []error) ([]*model.AssetDefinition, []error) {
+	first := make(map[model.DefinitionAddress]*model.AssetDefinition, len(defs))
+	kept := defs[:0]
+	for _, def := range defs {
+		if prev, ok := first[def.Address]; ok {
+			errs = append(errs, fmt.Errorf("duplicate resource %s: defined in %s:%d and %s:%d",
+				def.Address, prev.Location.File, prev.Location.StartLine, def.Location.File, def.Location.StartLine))
+			continue
+		}
+		first[def.Address] = def
+		kept = append(kept, def)
+	}
+	return kept, errs
+}
+
+// parseFile adds the blocks of one file to parsed
+func (p *ModuleParser) parseFile(file, root string, parsed *terraform.ParsedModule) error {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	hclFile, diags := hclsyntax.ParseConfig(src, file, hcl.InitialPos)
+	if diags.HasErrors() {
+		return fmt.Errorf("failed to parse %s: %s", file, diags.Error())
+	}
+	body, ok := hclFile.Body.(*hclsyntax.Body)
+	if !ok {
+		return fmt.Errorf("failed to parse %s: not native HCL syntax", file)
+	}
+
+	relPath, err := filepath.Rel(root, file)
+	if err != nil {
+		relPath = file
+	}
+
+	for _, block := range body.Blocks {
+		switch block.Type {
+		case "resource", "data":
+			if len(block.Labels) < 2 {
+				continue
+			}
+			def := definition(block, src, relPath)
+			if def.Mode == model.ModeData {
+				parsed.DataSources = append(parsed.DataSources, def)
+			} else {
+				parsed.Definitions = append(parsed.Definitions, def)
+			}
+		case "variable":
+			if len(block.Labels) == 1 {
+				parsed.Variables = append(parsed.Variables, variable(block, src))
+			}
+		case "locals":
+			for _, name := range sortedAttributeNames(block.Body) {
+				parsed.Locals = append(parsed.Locals, &terraform.LocalBlock{
+					Name:       name,
+					Expression: expression(block.Body.Attributes[name].Expr, src),
+				})
+			}
+		case "provider":
+			if len(block.Labels) == 1 {
+				parsed.Providers = append(parsed.Providers, provider(block, src))
+			}
+		case "module":
+			if len(block.Labels) == 1 {
+				parsed.Modules = append(parsed.Modules, moduleCall(block, src))
+			}
+		}
+	}
+	return nil
+}
+
+// definition converts a resource or data block
+func definition(block *hclsyntax.Block, src []byte, file string) *model.AssetDefinition {
+	resourceType, name := block.Labels[0], block.Labels[1]
+	def := &model.AssetDefinition{
+		Address:    model.DefinitionAddress(resourceType + "." + name),
+		Provider:   model.ProviderKey(strings.SplitN(resourceType, "_", 2)[0]),
+		Type:       model.ResourceType(resourceType),
+		Name:       name,
+		Mode:       model.ModeManaged,
+		Attributes: make(map[string]model.Expression),
+		Location: model.SourceLocation{
+			File:      file,
+			StartLine: block.DefRange().Start.Line,
+			EndLine:   block.Body.SrcRange.End.Line,
+		},
+	}
+	if block.Type == "data" {
+		def.Mode = model.ModeData
+		def.Address = model.DefinitionAddress("data." + string(def.Address))
+	}
+
+	for name, attr := range block.Body.Attributes {
+		switch name {
+		case "count":
+			expr := expression(attr.Expr, src)
+			def.Count = &expr
+		case "for_each":
+			expr := expression(attr.Expr, src)
+			def.ForEach = &expr
+		case "provider":
+			// provider = aws.west is a reference, but names a configuration
+			ref := strings.TrimSpace(string(attr.Expr.Range().SliceBytes(src)))
+			def.Provider = model.ProviderKey(ref)
+			def.Attributes[name] = model.Expression{Raw: ref, IsLiteral: true, LiteralVal: ref}
+		default:
+			def.Attributes[name] = expression(attr.Expr, src)
+		}
+	}
+	for name, value := range nestedBlocks(block.Body, src) {
+		if _, ok := def.Attributes[name]; !ok {
+			def.Attributes[name] = model.Expression{Raw: name, IsLiteral: true, LiteralVal: value}
+		}
+	}
+
+	def.ID = def.ComputeID()
+	return def
+}
+
+// nestedBlocks renders nested blocks (root_block_device, ebs_block_device)
+// as a list of attribute maps per block type, as plan JSON does. Only
+// literal attributes are kept.
+func nestedBlocks(body *hclsyntax.Body, src []byte) map[string]any {
+	blocks := make(map[string]any)
+	for _, block := range body.Blocks {
+		if metaBlocks[block.Type] {
+			continue
+		}
+		values := make(map[string]any)
+		for name, attr := range block.Body.Attributes {
+			if expr := expression(attr.Expr, src); expr.IsLiteral {
+				values[name] = expr.LiteralVal
+			}
+		}
+		for name, value := range nestedBlocks(block.Body, src) {
+			values[name] = value
+		}
+		list, _ := blocks[block.Type].([]any)
+		blocks[block.Type] = append(list, values)
+	}
+	return blocks
+}
+
+// variable converts a variable block
+func variable(block *hclsyntax.Block, src []byte) *terraform.VariableBlock {
+	v := &terraform.VariableBlock{Name: block.Labels[0]}
+	attrs := block.Body.Attributes
+	if attr, ok := attrs["default"]; ok {
+		if expr := expression(attr.Expr, src); expr.IsLiteral {
+			v.Default = expr.LiteralVal
+		}
+	}
+	if attr, ok := attrs["type"]; ok {
+		v.Type = strings.TrimSpace(string(attr.Expr.Range().SliceBytes(src)))
+	}
+	if attr, ok := attrs["description"]; ok {
+		v.Description, _ = expression(attr.Expr, src).LiteralVal.(string)
+	}
+	if attr, ok := attrs["sensitive"]; ok {
+		v.Sensitive, _ = expression(attr.Expr, src).LiteralVal.(bool)
+	}
+	return v
+}
+
+// provider converts a provider block
+func provider(block *hclsyntax.Block, src []byte) *terraform.ProviderBlock {
+	p := &terraform.ProviderBlock{
+		Type:       block.Labels[0],
+		Attributes: make(map[string]model.Expression),
+	}
+	for name, attr := range block.Body.Attributes {
+		expr := expression(attr.Expr, src)
+		if name == "alias" {
+			p.Alias, _ = expr.LiteralVal.(string)
+			continue
+		}
+		p.Attributes[name] = expr
+	}
+	return p
+}
+
+// moduleCall converts a module block
+func moduleCall(block *hclsyntax.Block, src []byte) *terraform.ModuleCall {
+	m := &terraform.ModuleCall{
+		Name:   block.Labels[0],
+		Inputs: make(map[string]model.Expression),
+	}
+	for name, attr := range block.Body.Attributes {
+		expr := expression(attr.Expr, src)
+		switch name {
+		case "source":
+			m.Source, _ = expr.LiteralVal.(string)
+		case "version":
+			m.Version, _ = expr.LiteralVal.(string)
+		case "count":
+			m.Count = &expr
+		case "for_each":
+			m.ForEach = &expr
+		case "providers", "depends_on":
+		default:
+			m.Inputs[name] = expr
+		}
+	}
+	return m
+}
+
+// expression captures an expression's source and references, evaluating
+// it only when it references nothing
+func expression(expr hclsyntax.Expression, src []byte) model.Expression {
+	e := model.Expression{Raw: strings.TrimSpace(string(expr.Range().SliceBytes(src)))}
+	for _, traversal := range expr.Variables() {
+		e.References = append(e.References, formatTraversal(traversal))
+	}
+	if len(e.References) > 0 {
+		return e
+	}
+
+	// Function calls fail here without an evaluation context and stay
+	// unevaluated
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		return e
+	}
+	if safe := CtyToSafe(val); safe.IsKnown {
+		e.IsLiteral = true
+		e.LiteralVal = safe.Value
+	}
+	return e
+}
+
+func sortedAttributeNames(body *hclsyntax.Body) []string {
+	names := make([]string, 0, len(body.Attributes))
+	for name := range body.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	terraform.RegisterSourceParser(NewModuleParser())
+}
@@ -0,0 +1,277 @@
+package hcl
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	tfadapter "terraform-cost/adapters/terraform"
+	"terraform-cost/core/terraform"
+)
+
+func writeModule(t *testing.T, src string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// TestPipelineLocalsChain proves locals referencing locals and variables
+// resolve in dependency order, whatever order they are written in
+func TestPipelineLocalsChain(t *testing.T) {
+	dir := writeModule(t, `variable "env" {
+  default = "prod"
+}
+
+locals {
+  size     = lookup(local.sizes, local.env, "t3.micro")
+  replicas = local.env == "prod" ? 3 : 1
+  sizes    = { prod = "m5.large", dev = "t3.small" }
+  env      = var.env
+}
+
+resource "aws_instance" "web" {
+  count         = local.replicas
+  instance_type = local.size
+}
+`)
+	pipeline := terraform.NewPipeline(terraform.PipelineOptions{SourceParser: NewModuleParser()})
+	result, err := pipeline.Execute(context.Background(), &terraform.ScanInput{RootPath: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instances := result.Graph.Instances()
+	if len(instances) != 3 {
+		t.Fatalf("got %d instances, want count = local.replicas = 3", len(instances))
+	}
+	for _, inst := range instances {
+		if attr := inst.Attributes["instance_type"]; attr.IsUnknown || attr.Value != "m5.large" {
+			t.Errorf("%s instance_type = %+v, want m5.large", inst.Address, attr)
+		}
+	}
+}
+
+// TestPipelineLocalsCycle proves locals referencing each other in a cycle
+// fail the pipeline, naming the cycle
+func TestPipelineLocalsCycle(t *testing.T) {
+	dir := writeModule(t, `locals {
+  a = local.b
+  b = "${local.c}-x"
+  c = local.a
+}
+`)
+	pipeline := terraform.NewPipeline(terraform.PipelineOptions{SourceParser: NewModuleParser()})
+	_, err := pipeline.Execute(context.Background(), &terraform.ScanInput{RootPath: dir})
+
+	var cycle *terraform.LocalsCycleError
+	if !errors.As(err, &cycle) {
+		t.Fatalf("err = %v, want a LocalsCycleError", err)
+	}
+	if got := len(cycle.Cycle); got != 4 || cycle.Cycle[0] != cycle.Cycle[3] {
+		t.Errorf("cycle = %v, want three locals returning to the first", cycle.Cycle)
+	}
+}
+
+// TestPipelineSkipsMetaArguments proves lifecycle and the other
+// meta-arguments never reach the resolved attributes a mapper prices from
+func TestPipelineSkipsMetaArguments(t *testing.T) {
+	dir := writeModule(t, `resource "aws_instance" "web" {
+  instance_type = "t3.large"
+  tags          = { team = "payments" }
+  depends_on    = [aws_instance.db]
+
+  lifecycle {
+    ignore_changes = [tags]
+  }
+
+  provisioner "local-exec" {
+    command = "echo ready"
+  }
+}
+
+resource "aws_instance" "db" {
+  instance_type = "m5.large"
+}
+`)
+	pipeline := terraform.NewPipeline(terraform.PipelineOptions{SourceParser: NewModuleParser()})
+	result, err := pipeline.Execute(context.Background(), &terraform.ScanInput{RootPath: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, inst := range result.Graph.Instances() {
+		if inst.Address != "aws_instance.web" {
+			continue
+		}
+		for _, name := range []string{"lifecycle", "provisioner", "depends_on", "count", "provider"} {
+			if _, ok := inst.Attributes[name]; ok {
+				t.Errorf("meta-argument %s leaked into the attributes", name)
+			}
+		}
+		if attr := inst.Attributes["instance_type"]; attr.IsUnknown || attr.Value != "t3.large" {
+			t.Errorf("instance_type = %+v, want t3.large", attr)
+		}
+		return
+	}
+	t.Fatal("aws_instance.web was not expanded")
+}
+
+// dataSourcesPlanJSON is a plan that read the subnets and AMIs the
+// configuration in TestPipelineDataSourcesFromPlan expands over
+const dataSourcesPlanJSON = `{
+  "format_version": "1.2",
+  "planned_values": {
+    "root_module": {
+      "resources": [
+        {
+          "address": "data.aws_subnets.private",
+          "mode": "data",
+          "type": "aws_subnets",
+          "name": "private",
+          "values": {"ids": ["subnet-c", "subnet-a", "subnet-b"]}
+        },
+        {
+          "address": "data.aws_ami.base[0]",
+          "mode": "data",
+          "type": "aws_ami",
+          "name": "base",
+          "index": 0,
+          "values": {"id": "ami-0"}
+        },
+        {
+          "address": "data.aws_ami.base[1]",
+          "mode": "data",
+          "type": "aws_ami",
+          "name": "base",
+          "index": 1,
+          "values": {"id": "ami-1"}
+        }
+      ]
+    }
+  }
+}`
+
+// TestPipelineDataSourcesFromPlan proves count and for_each over data
+// sources expand concretely once a plan supplies their values, and stay
+// unknown from the configuration alone
+func TestPipelineDataSourcesFromPlan(t *testing.T) {
+	dir := writeModule(t, `data "aws_subnets" "private" {}
+
+data "aws_ami" "base" {
+  count = 2
+}
+
+resource "aws_instance" "web" {
+  for_each      = toset(data.aws_subnets.private.ids)
+  subnet_id     = each.key
+  instance_type = "t3.micro"
+}
+
+resource "aws_instance" "worker" {
+  count         = length(data.aws_ami.base)
+  instance_type = "t3.small"
+}
+`)
+	pipeline := terraform.NewPipeline(terraform.PipelineOptions{SourceParser: NewModuleParser()})
+	result, err := pipeline.Execute(context.Background(), &terraform.ScanInput{RootPath: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, inst := range result.Graph.Instances() {
+		if !inst.Metadata.IsPlaceholder {
+			t.Errorf("%s expanded concretely without the plan", inst.Address)
+		}
+	}
+	if n := len(result.CardinalityWarnings); n != 2 {
+		t.Errorf("got %d cardinality warnings without the plan, want 2", n)
+	}
+
+	plan, err := tfadapter.ReadPlanJSON(strings.NewReader(dataSourcesPlanJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = pipeline.WithDataSources(plan.DataSourceValues()).
+		Execute(context.Background(), &terraform.ScanInput{RootPath: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, inst := range result.Graph.Instances() {
+		got = append(got, string(inst.Address))
+	}
+	sort.Strings(got)
+	want := []string{
+		`aws_instance.web["subnet-a"]`,
+		`aws_instance.web["subnet-b"]`,
+		`aws_instance.web["subnet-c"]`,
+		"aws_instance.worker[0]",
+		"aws_instance.worker[1]",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("instances = %v, want %v", got, want)
+	}
+	if len(result.CardinalityWarnings) != 0 {
+		t.Errorf("cardinality warnings = %+v, want none", result.CardinalityWarnings)
+	}
+}
+
+// TestPipelineWorkspace proves terraform.workspace resolves to the
+// configured workspace, so workspace-conditional sizing and counts expand
+// concretely, and a scan's workspace overrides the pipeline's
+func TestPipelineWorkspace(t *testing.T) {
+	dir := writeModule(t, `variable "sizes" {
+  default = { default = "t3.micro", staging = "t3.large", prod = "m5.xlarge" }
+}
+
+locals {
+  replicas = terraform.workspace == "prod" ? 3 : 1
+}
+
+resource "aws_instance" "web" {
+  count         = local.replicas
+  instance_type = var.sizes[terraform.workspace]
+  tags          = { env = terraform.workspace }
+}
+`)
+	pipeline := terraform.NewPipeline(terraform.PipelineOptions{SourceParser: NewModuleParser()})
+	for _, tc := range []struct {
+		pipeline  *terraform.Pipeline
+		input     string
+		workspace string
+		size      string
+		count     int
+	}{
+		{pipeline, "", "default", "t3.micro", 1},
+		{pipeline.WithWorkspace("staging"), "", "staging", "t3.large", 1},
+		{pipeline.WithWorkspace("staging"), "prod", "prod", "m5.xlarge", 3},
+	} {
+		result, err := tc.pipeline.Execute(context.Background(), &terraform.ScanInput{RootPath: dir, Workspace: tc.input})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Workspace != tc.workspace {
+			t.Errorf("workspace = %q, want %q", result.Workspace, tc.workspace)
+		}
+		instances := result.Graph.Instances()
+		if len(instances) != tc.count {
+			t.Fatalf("%s: got %d instances, want %d", tc.workspace, len(instances), tc.count)
+		}
+		for _, inst := range instances {
+			if attr := inst.Attributes["instance_type"]; attr.IsUnknown || attr.Value != tc.size {
+				t.Errorf("%s: %s instance_type = %+v, want %s", tc.workspace, inst.Address, attr, tc.size)
+			}
+			tags, _ := inst.Attributes["tags"].Value.(map[string]any)
+			if tags["env"] != tc.workspace {
+				t.Errorf("%s: %s tags = %v, want env = %s", tc.workspace, inst.Address, tags, tc.workspace)
+			}
+		}
+	}
+}
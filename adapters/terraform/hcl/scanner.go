@@ -11,7 +11,6 @@ import (
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
-	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 
 	"terraform-cost/core/scanner"
@@ -21,15 +20,14 @@ import (
 // Scanner implements the scanner.Scanner interface for Terraform HCL
 // CRITICAL: This scanner does NOT evaluate expressions.
 // It captures expressions as unevaluated for later resolution.
-type Scanner struct {
-	parser *hclparse.Parser
-}
+// The scanner is registered once and may scan a project many times (as
+// estimate --watch does), so files are parsed fresh on every scan rather
+// than through an hclparse.Parser, which caches files by name.
+type Scanner struct{}
 
 // NewScanner creates a new HCL scanner
 func NewScanner() *Scanner {
-	return &Scanner{
-		parser: hclparse.NewParser(),
-	}
+	return &Scanner{}
 }
 
 // Name returns the scanner name
@@ -93,6 +91,7 @@ func (s *Scanner) Scan(ctx context.Context, input *types.ProjectInput) (*scanner
 		result.Warnings = append(result.Warnings, warnings...)
 		result.Errors = append(result.Errors, errs...)
 	}
+	result.Assets, result.Errors = dropDuplicateAssets(result.Assets, result.Errors)
 
 	// Load tfvars if present
 	result.Variables = s.loadVariables(input.Path)
@@ -100,6 +99,37 @@ func (s *Scanner) Scan(ctx context.Context, input *types.ProjectInput) (*scanner
 	return result, nil
 }
 
+// DuplicateAddressCode marks a scan error for an address defined twice
+const DuplicateAddressCode = "duplicate_address"
+
+// dropDuplicateAssets keeps the first asset of each address in a
+// directory, reporting every later one with both locations. Each
+// directory is its own module, so the same address in two is not a
+// duplicate.
+func dropDuplicateAssets(assets []types.RawAsset, errs []scanner.ScanError) ([]types.RawAsset, []scanner.ScanError) {
+	type moduleAddress struct {
+		dir     string
+		address types.ResourceAddress
+	}
+	first := make(map[moduleAddress]types.RawAsset, len(assets))
+	kept := assets[:0]
+	for _, asset := range assets {
+		key := moduleAddress{dir: filepath.Dir(asset.SourceFile), address: asset.Address}
+		if prev, ok := first[key]; ok {
+			errs = append(errs, scanner.ScanError{
+				File:    asset.SourceFile,
+				Line:    asset.SourceLine,
+				Message: fmt.Sprintf("duplicate resource %s: already defined at %s:%d", asset.Address, prev.SourceFile, prev.SourceLine),
+				Code:    DuplicateAddressCode,
+			})
+			continue
+		}
+		first[key] = asset
+		kept = append(kept, asset)
+	}
+	return kept, errs
+}
+
 func (s *Scanner) parseFile(ctx context.Context, file, basePath string) ([]types.RawAsset, []scanner.ModuleReference, []scanner.ScanWarning, []scanner.ScanError) {
 	var assets []types.RawAsset
 	var modules []scanner.ModuleReference
@@ -116,7 +146,7 @@ func (s *Scanner) parseFile(ctx context.Context, file, basePath string) ([]types
 		return assets, modules, warnings, errors
 	}
 
-	hclFile, diags := s.parser.ParseHCL(src, file)
+	hclFile, diags := hclsyntax.ParseConfig(src, file, hcl.Pos{Line: 1, Column: 1})
 	if diags.HasErrors() {
 		for _, diag := range diags {
 			if diag.Severity == hcl.DiagError {
@@ -205,15 +235,28 @@ func (s *Scanner) parseResourceDeferred(block *hcl.Block, file string, isDataSou
 	}
 
 	return &types.RawAsset{
-		Address:      address,
-		Provider:     provider,
-		Type:         resourceType,
-		Name:         resourceName,
-		Attributes:   attrs,
-		IsDataSource: isDataSource,
-		SourceFile:   file,
-		SourceLine:   line,
+		Address:       address,
+		Provider:      provider,
+		ProviderAlias: providerAlias(attrs),
+		Type:          resourceType,
+		Name:          resourceName,
+		Attributes:    attrs,
+		IsDataSource:  isDataSource,
+		SourceFile:    file,
+		SourceLine:    line,
+	}
+}
+
+// providerAlias returns the alias a resource selects with the provider
+// meta-argument: provider = aws.us_west → us_west. A resource without one
+// uses the default configuration of its type's provider.
+func providerAlias(attrs types.Attributes) string {
+	attr, ok := attrs["provider"]
+	if !ok || len(attr.References) != 1 {
+		return ""
 	}
+	_, alias, _ := strings.Cut(attr.References[0], ".")
+	return alias
 }
 
 func (s *Scanner) parseModuleDeferred(block *hcl.Block) *scanner.ModuleReference {
@@ -254,10 +297,12 @@ func (s *Scanner) parseModuleDeferred(block *hcl.Block) *scanner.ModuleReference
 func (s *Scanner) extractAttributesDeferred(body hcl.Body) types.Attributes {
 	attrs := make(types.Attributes)
 
-	// Get all attributes from the body
-	content, _, _ := body.PartialContent(&hcl.BodySchema{})
+	// Get all attributes from the body. An empty schema would match none;
+	// JustAttributes reports nested blocks as errors but still returns
+	// every attribute.
+	bodyAttrs, _ := body.JustAttributes()
 
-	for name, attr := range content.Attributes {
+	for name, attr := range bodyAttrs {
 		// Analyze the expression to determine if it needs context
 		exprInfo := s.analyzeExpression(attr.Expr)
 
@@ -0,0 +1,199 @@
+package hcl
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"terraform-cost/core/engine"
+	"terraform-cost/core/graph"
+	"terraform-cost/core/terraform"
+	"terraform-cost/core/types"
+)
+
+// writeDuplicatedWeb writes aws_instance.web into two files of one module,
+// and into a child module directory where it is not a duplicate
+func writeDuplicatedWeb(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.tf": `resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+}
+`,
+		"b.tf": `
+resource "aws_instance" "web" {
+  instance_type = "m5.large"
+}
+`,
+		"modules/app/main.tf": `resource "aws_instance" "web" {
+  instance_type = "t3.small"
+}
+`,
+	}
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestScanDuplicateAddress(t *testing.T) {
+	dir := writeDuplicatedWeb(t)
+
+	result, err := NewScanner().Scan(context.Background(), &types.ProjectInput{Path: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Assets) != 2 {
+		t.Fatalf("got %d assets, want the first aws_instance.web and the module's", len(result.Assets))
+	}
+	for _, asset := range result.Assets {
+		if asset.SourceFile == "b.tf" {
+			t.Error("duplicate in b.tf was kept")
+		}
+	}
+
+	if len(result.Errors) != 1 {
+		t.Fatalf("got errors %+v, want one duplicate error", result.Errors)
+	}
+	e := result.Errors[0]
+	if e.Code != DuplicateAddressCode || e.File != "b.tf" || e.Line != 2 || !strings.Contains(e.Message, "a.tf:1") {
+		t.Errorf("unexpected duplicate error %+v", e)
+	}
+}
+
+// TestScanRereadsChangedFiles proves one scanner sees a file's new
+// content when it scans again, as estimate --watch does
+func TestScanRereadsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.tf")
+	s := NewScanner()
+	for i, src := range []string{
+		`resource "aws_instance" "web" {}`,
+		`resource "aws_instance" "web" {}
+resource "aws_instance" "api" {}`,
+	} {
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		result, err := s.Scan(context.Background(), &types.ProjectInput{Path: dir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Assets) != i+1 {
+			t.Errorf("scan %d found %d assets, want %d", i+1, len(result.Assets), i+1)
+		}
+	}
+}
+
+func TestParseModuleDuplicateAddress(t *testing.T) {
+	dir := writeDuplicatedWeb(t)
+
+	parsed, err := NewModuleParser().ParseModule(context.Background(), &terraform.ScanInput{RootPath: dir})
+	if err == nil || !strings.Contains(err.Error(), "a.tf:1") || !strings.Contains(err.Error(), "b.tf:2") {
+		t.Fatalf("err = %v, want a duplicate error naming a.tf:1 and b.tf:2", err)
+	}
+	if len(parsed.Definitions) != 1 || parsed.Definitions[0].Location.File != "a.tf" {
+		t.Errorf("definitions = %+v, want only a.tf's", parsed.Definitions)
+	}
+}
+
+// TestScanProviderAlias proves resources selecting an aliased provider
+// keep the alias and bind to that configuration's region when expanded
+func TestScanProviderAlias(t *testing.T) {
+	dir := t.TempDir()
+	src := `provider "aws" {
+  region = "us-east-1"
+}
+
+provider "aws" {
+  alias  = "us_west"
+  region = "us-west-2"
+}
+
+provider "aws" {
+  alias  = "eu"
+  region = "eu-west-1"
+}
+
+resource "aws_instance" "default" {
+  instance_type = "t3.micro"
+}
+
+resource "aws_instance" "west" {
+  provider      = aws.us_west
+  instance_type = "t3.micro"
+}
+
+data "aws_ami" "eu" {
+  provider = aws.eu
+}
+
+resource "aws_s3_bucket" "eu" {
+  provider = aws.eu
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result, err := NewScanner().Scan(context.Background(), &types.ProjectInput{Path: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"aws_instance.default": "aws",
+		"aws_instance.west":    "aws.us_west",
+		"data.aws_ami.eu":      "aws.eu",
+		"aws_s3_bucket.eu":     "aws.eu",
+	}
+	var definitions []*terraform.ResourceDefinition
+	for _, asset := range result.Assets {
+		if got := asset.ProviderKey(); got != want[string(asset.Address)] {
+			t.Errorf("%s provider = %q, want %q", asset.Address, got, want[string(asset.Address)])
+		}
+		if !asset.IsDataSource {
+			definitions = append(definitions, terraform.DefinitionFromRawAsset(asset))
+		}
+	}
+
+	o := engine.NewAuthoritativeOrchestrator(terraform.ModeStrict)
+	if err := o.BuildDependencyGraph(context.Background(), &graph.ParsedInfra{}); err != nil {
+		t.Fatal(err)
+	}
+	err = o.FreezeProviders(context.Background(), []*terraform.ProviderContext{
+		{ProviderType: "aws", Region: "us-east-1"},
+		{ProviderType: "aws", Alias: "us_west", Region: "us-west-2"},
+		{ProviderType: "aws", Alias: "eu", Region: "eu-west-1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.ExpandAssets(context.Background(), definitions); err != nil {
+		t.Fatal(err)
+	}
+
+	regions := map[string]string{
+		"aws_instance.default": "us-east-1",
+		"aws_instance.west":    "us-west-2",
+		"aws_s3_bucket.eu":     "eu-west-1",
+	}
+	instances := o.GetAssetGraph().AllInstances()
+	if len(instances) != len(regions) {
+		t.Fatalf("got %d instances, want %d", len(instances), len(regions))
+	}
+	for _, inst := range instances {
+		if inst.Provider == nil || inst.Provider.Region != regions[string(inst.Address)] {
+			t.Errorf("%s bound to %+v, want region %s", inst.Address, inst.Provider, regions[string(inst.Address)])
+		}
+	}
+}
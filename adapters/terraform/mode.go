@@ -0,0 +1,79 @@
+// Package terraform - Plan modes
+// The plan JSON does not record the flags terraform plan ran with, so the
+// mode is inferred. A -refresh-only plan reports drift in resource_drift
+// and proposes no managed changes; drift updates state, not configuration,
+// so it never changes cost. A -replace plan marks each forced replacement
+// with action_reason "replace_by_request"; the replacement is priced as an
+// update in place like any other. A destroy plan (terraform plan -destroy)
+// deletes every managed resource; what remains after it costs nothing.
+package terraform
+
+// PlanMode is how a plan was generated
+type PlanMode string
+
+const (
+	// PlanModeNormal proposes configuration changes
+	PlanModeNormal PlanMode = "normal"
+
+	// PlanModeRefreshOnly only reconciles state with real infrastructure
+	PlanModeRefreshOnly PlanMode = "refresh-only"
+
+	// PlanModeDestroy deletes every managed resource
+	PlanModeDestroy PlanMode = "destroy"
+)
+
+// Mode infers the plan mode: a plan whose managed changes are all deletes
+// is a destroy plan, and a plan with drift but no managed create, update or
+// delete is refresh-only
+func (p *PlanOutput) Mode() PlanMode {
+	if destroysAll(p.ResourceChanges) {
+		return PlanModeDestroy
+	}
+	if len(p.ResourceDrift) == 0 {
+		return PlanModeNormal
+	}
+	for _, c := range p.ResourceChanges {
+		if c.Mode == "data" {
+			continue
+		}
+		actions := c.Change.Actions
+		if contains(actions, "create") || contains(actions, "update") || contains(actions, "delete") {
+			return PlanModeNormal
+		}
+	}
+	return PlanModeRefreshOnly
+}
+
+// destroysAll reports whether changes delete at least one managed resource
+// and neither keep nor create any
+func destroysAll(changes []ResourceChange) bool {
+	deleted := false
+	for _, c := range changes {
+		if c.Mode == "data" {
+			continue
+		}
+		actions := c.Change.Actions
+		if len(actions) != 1 || actions[0] != "delete" {
+			return false
+		}
+		deleted = true
+	}
+	return deleted
+}
+
+// ReplaceRequested lists the addresses forced to be replaced with -replace
+func (p *PlanOutput) ReplaceRequested() []string {
+	var addrs []string
+	for _, c := range p.ResourceChanges {
+		if c.ActionReason == ActionReasonReplaceByRequest {
+			addrs = append(addrs, c.Address)
+		}
+	}
+	return addrs
+}
+
+// ReplaceRequested reports whether the resource is replaced because of
+// -replace rather than a change to its configuration
+func (r ResourceInfo) ReplaceRequested() bool {
+	return r.ActionReason == ActionReasonReplaceByRequest
+}
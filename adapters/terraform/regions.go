@@ -0,0 +1,150 @@
+// Package terraform - Per-resource provider region resolution
+// Multi-region plans configure aliased providers (provider "aws" { alias =
+// "west" region = "us-west-2" }). The plan's configuration section links
+// each resource to its provider_config_key, and provider_config holds the
+// region expression, so every resource can be priced in its own region.
+// The same lookup yields each provider's default_tags.
+package terraform
+
+import (
+	"strings"
+)
+
+// providerRegions resolves resource addresses to provider keys, regions
+// and default tags
+type providerRegions struct {
+	// resourceKeys maps a configuration address (no instance keys) to
+	// its provider_config_key
+	resourceKeys map[string]string
+
+	// regions maps a provider_config_key to its literal region
+	regions map[string]string
+
+	// tags maps a provider_config_key to its default_tags
+	tags map[string]map[string]string
+}
+
+func newProviderRegions(plan *PlanOutput) *providerRegions {
+	r := &providerRegions{
+		resourceKeys: make(map[string]string),
+		regions:      make(map[string]string),
+		tags:         make(map[string]map[string]string),
+	}
+	if plan.Configuration == nil {
+		return r
+	}
+
+	for key, cfg := range plan.Configuration.ProviderConfig {
+		if region := providerRegion(cfg, plan.Variables); region != "" {
+			r.regions[key] = region
+		}
+		if tags := providerDefaultTags(cfg, plan.Variables); len(tags) > 0 {
+			r.tags[key] = tags
+		}
+	}
+	r.collect("", plan.Configuration.RootModule)
+	return r
+}
+
+// collect walks the module tree recording each resource's provider key
+func (r *providerRegions) collect(prefix string, module ModuleConfig) {
+	for _, res := range module.Resources {
+		if res.ProviderConfigKey != "" {
+			r.resourceKeys[prefix+res.Address] = res.ProviderConfigKey
+		}
+	}
+	for name, call := range module.ModuleCalls {
+		r.collect(prefix+"module."+name+".", call.Module)
+	}
+}
+
+// providerKey returns the provider_config_key for a resource instance address
+func (r *providerRegions) providerKey(address string) string {
+	return r.resourceKeys[stripInstanceKeys(address)]
+}
+
+// region returns the literal region of a provider key, or "" when unknown.
+// Module-scoped keys ("module.app:aws") fall back to the unscoped provider,
+// which is what Terraform passes down implicitly.
+func (r *providerRegions) region(key string) string {
+	if key == "" {
+		return ""
+	}
+	if region, ok := r.regions[key]; ok {
+		return region
+	}
+	if i := strings.LastIndex(key, ":"); i >= 0 {
+		return r.regions[key[i+1:]]
+	}
+	return ""
+}
+
+// defaultTags returns the default_tags of a provider key, with the same
+// module fallback as region
+func (r *providerRegions) defaultTags(key string) map[string]string {
+	if key == "" {
+		return nil
+	}
+	if tags, ok := r.tags[key]; ok {
+		return tags
+	}
+	if i := strings.LastIndex(key, ":"); i >= 0 {
+		return r.tags[key[i+1:]]
+	}
+	return nil
+}
+
+// providerRegion reads the region expression of a provider block. Constant
+// values are used directly; a single root variable reference resolves from
+// the plan's input variables.
+func providerRegion(cfg ProviderConfig, vars map[string]Variable) string {
+	expr, ok := cfg.Expressions["region"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	if v, ok := expr["constant_value"].(string); ok {
+		return v
+	}
+	if cfg.ModuleAddress != "" {
+		return ""
+	}
+	refs, _ := expr["references"].([]interface{})
+	for _, ref := range refs {
+		name, ok := ref.(string)
+		if !ok || !strings.HasPrefix(name, "var.") {
+			continue
+		}
+		if v, ok := vars[strings.TrimPrefix(name, "var.")].Value.(string); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// stripInstanceKeys removes count/for_each keys from an address:
+// module.app["a"].aws_instance.web[0] -> module.app.aws_instance.web
+func stripInstanceKeys(address string) string {
+	var b strings.Builder
+	depth := 0
+	inString := false
+	for i := 0; i < len(address); i++ {
+		c := address[i]
+		switch {
+		case inString:
+			if c == '\\' && i+1 < len(address) {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"' && depth > 0:
+			inString = true
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case depth == 0:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
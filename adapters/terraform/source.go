@@ -0,0 +1,245 @@
+// Package terraform - Plan and state JSON input sources
+// Every command that takes `terraform show -json` output accepts the same
+// three forms: "-" reads standard input, an http:// or https:// URL is
+// fetched (e.g. a CI artifact), and anything else is a local file. Reads
+// are capped at MaxBytes so a wrong path or URL cannot exhaust memory, and
+// URL fetches time out.
+//
+// Plan JSON is parsed as read: terraform is not run, so CI that already
+// ran `terraform show -json tfplan > plan.json` needs neither the binary
+// nor the backend here. Only a binary plan file needs `terraform show`
+// (Adapter.ParsePlanFile).
+package terraform
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultMaxPlanBytes caps how much plan or state JSON is read
+	DefaultMaxPlanBytes int64 = 256 << 20
+
+	// DefaultPlanFetchTimeout bounds fetching a plan from a URL
+	DefaultPlanFetchTimeout = 60 * time.Second
+
+	// StdinSource is the location that reads standard input
+	StdinSource = "-"
+)
+
+// ErrPlanTooLarge is returned when a source holds more than MaxBytes
+var ErrPlanTooLarge = errors.New("plan input too large")
+
+// ErrBinaryPlan is returned when a binary plan file is given where plan
+// JSON was expected
+var ErrBinaryPlan = errors.New("binary plan file, not plan JSON")
+
+// binaryPlanMagic starts a binary plan file, which is a zip archive
+var binaryPlanMagic = []byte("PK\x03\x04")
+
+// PlanSourceKind is where a PlanSource reads from
+type PlanSourceKind string
+
+const (
+	PlanSourceStdin PlanSourceKind = "stdin"
+	PlanSourceFile  PlanSourceKind = "file"
+	PlanSourceURL   PlanSourceKind = "url"
+)
+
+// PlanSource resolves a plan or state location into its JSON bytes
+type PlanSource struct {
+	// Location is "-", an http(s) URL or a file path
+	Location string
+
+	// MaxBytes caps the bytes read; DefaultMaxPlanBytes when zero
+	MaxBytes int64
+
+	// Timeout bounds a URL fetch; DefaultPlanFetchTimeout when zero
+	Timeout time.Duration
+
+	// Stdin is read for "-"; os.Stdin when nil
+	Stdin io.Reader
+
+	// Client fetches URLs; http.DefaultClient when nil
+	Client *http.Client
+}
+
+// NewPlanSource returns a source for location with the default limits
+func NewPlanSource(location string) *PlanSource {
+	return &PlanSource{Location: location}
+}
+
+// IsRemotePlanSource reports whether location is read from standard input
+// or a URL rather than the file system
+func IsRemotePlanSource(location string) bool {
+	kind := NewPlanSource(location).Kind()
+	return kind == PlanSourceStdin || kind == PlanSourceURL
+}
+
+// Kind reports where the source reads from
+func (s *PlanSource) Kind() PlanSourceKind {
+	switch {
+	case s.Location == StdinSource:
+		return PlanSourceStdin
+	case strings.HasPrefix(s.Location, "http://"), strings.HasPrefix(s.Location, "https://"):
+		return PlanSourceURL
+	}
+	return PlanSourceFile
+}
+
+// Read returns the source's bytes. Standard input can be read only once,
+// so callers needing the plan more than once should keep the result.
+func (s *PlanSource) Read(ctx context.Context) ([]byte, error) {
+	switch s.Kind() {
+	case PlanSourceStdin:
+		stdin := s.Stdin
+		if stdin == nil {
+			stdin = os.Stdin
+		}
+		data, err := s.readLimited(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read plan from stdin: %w", err)
+		}
+		return data, nil
+	case PlanSourceURL:
+		return s.fetch(ctx)
+	}
+
+	f, err := os.Open(s.Location)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := s.readLimited(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", s.Location, err)
+	}
+	return data, nil
+}
+
+// Plan reads and parses the source's plan JSON. The bytes read are
+// returned too, for callers that hash the plan: standard input cannot be
+// read a second time.
+func (s *PlanSource) Plan(ctx context.Context) (*PlanOutput, []byte, error) {
+	data, err := s.Read(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	plan, err := parsePlanJSON(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return plan, data, nil
+}
+
+// ReadPlanJSON parses `terraform show -json` output from r, such as
+// standard input, up to DefaultMaxPlanBytes
+func ReadPlanJSON(r io.Reader) (*PlanOutput, error) {
+	data, err := (&PlanSource{}).readLimited(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+	return parsePlanJSON(data)
+}
+
+// parsePlanJSON parses plan JSON, explaining a binary plan file given in
+// its place
+func parsePlanJSON(data []byte) (*PlanOutput, error) {
+	if bytes.HasPrefix(data, binaryPlanMagic) {
+		return nil, fmt.Errorf("%w: pass the output of `terraform show -json <planfile>`", ErrBinaryPlan)
+	}
+	var plan PlanOutput
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan JSON: %w", err)
+	}
+	return &plan, nil
+}
+
+// IsPlanJSONFile reports whether the file at path holds JSON, whatever
+// its name, rather than being a binary plan file or a directory
+func IsPlanJSONFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return false
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b == '{'
+	}
+}
+
+// fetch downloads a URL source
+func (s *PlanSource) fetch(ctx context.Context) ([]byte, error) {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = DefaultPlanFetchTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.Location, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid plan URL: %w", err)
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch plan: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch plan from %s: %s", s.Location, resp.Status)
+	}
+	if s.maxBytes() < resp.ContentLength {
+		return nil, fmt.Errorf("%w: %s is %d bytes (max %d)", ErrPlanTooLarge, s.Location, resp.ContentLength, s.maxBytes())
+	}
+
+	data, err := s.readLimited(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch plan from %s: %w", s.Location, err)
+	}
+	return data, nil
+}
+
+// readLimited reads r, failing once it passes MaxBytes
+func (s *PlanSource) readLimited(r io.Reader) ([]byte, error) {
+	limit := s.maxBytes()
+	// Read one byte past the limit to tell "exactly at" from "over"
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: over %d bytes", ErrPlanTooLarge, limit)
+	}
+	return data, nil
+}
+
+// maxBytes is MaxBytes, defaulting to DefaultMaxPlanBytes
+func (s *PlanSource) maxBytes() int64 {
+	if s.MaxBytes > 0 {
+		return s.MaxBytes
+	}
+	return DefaultMaxPlanBytes
+}
@@ -0,0 +1,91 @@
+// Package terraform - Resources from state
+// `terraform show -json` on a state file has no resource_changes, only the
+// current values, so existing infrastructure is costed from values instead
+// of a plan. Plans carry the same layout in planned_values.
+package terraform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"terraform-cost/core/engine"
+)
+
+// ErrEmptyState is returned when a state has no resources to estimate
+var ErrEmptyState = errors.New("state has no resources")
+
+// ParseStateJSON parses `terraform show -json` state output
+func (a *Adapter) ParseStateJSON(data []byte) (*State, error) {
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse state JSON: %w", err)
+	}
+	return &state, nil
+}
+
+// ExtractStateResources extracts the current resources of a state.
+// Every resource already exists, so its action is no_change.
+func (a *Adapter) ExtractStateResources(state *State) ([]ResourceInfo, error) {
+	if state.Values == nil {
+		return nil, fmt.Errorf("%w: input has no values; pass `terraform show -json` output for a non-empty state", ErrEmptyState)
+	}
+	resources := ExtractPlannedResources(state.Values)
+	if len(resources) == 0 {
+		return nil, ErrEmptyState
+	}
+	return resources, nil
+}
+
+// ExtractPlannedResources flattens managed resources from planned_values
+// or state values, including child modules
+func ExtractPlannedResources(values *PlannedValues) []ResourceInfo {
+	var resources []ResourceInfo
+	var walk func(module PlannedModule)
+	walk = func(module PlannedModule) {
+		for _, r := range module.Resources {
+			if r.Mode == "data" {
+				continue
+			}
+			resources = append(resources, ResourceInfo{
+				Address:       r.Address,
+				Type:          r.Type,
+				Name:          r.Name,
+				Provider:      r.ProviderName,
+				Region:        valuesRegion(r.Values),
+				ModuleAddress: module.Address,
+				Index:         r.Index,
+				Action:        "no_change",
+				Tags:          engine.AllocationTags(r.Values["tags_all"], r.Values["tags"]),
+				Values:        r.Values,
+			})
+		}
+		for _, child := range module.ChildModules {
+			walk(child)
+		}
+	}
+	if values != nil {
+		walk(values.RootModule)
+	}
+	return resources
+}
+
+// valuesRegion infers a resource's region from its own values, since state
+// does not record provider configuration: an explicit region, the region
+// field of its ARN, or its availability zone.
+func valuesRegion(values map[string]interface{}) string {
+	if region, ok := values["region"].(string); ok && region != "" {
+		return region
+	}
+	if arn, ok := values["arn"].(string); ok {
+		// arn:partition:service:region:account:resource
+		if parts := strings.SplitN(arn, ":", 5); len(parts) == 5 && parts[3] != "" {
+			return parts[3]
+		}
+	}
+	if az, ok := values["availability_zone"].(string); ok && len(az) > 1 {
+		return strings.TrimRight(az, "abcdefghijklmnopqrstuvwxyz")
+	}
+	return ""
+}
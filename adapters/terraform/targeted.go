@@ -0,0 +1,91 @@
+// Package terraform - Targeted plans
+// `terraform plan -target` proposes changes only for the targeted
+// resources and their dependencies, but planned_values still describes
+// every resource. A plan whose planned_values has managed resources
+// missing from resource_changes is treated as targeted: its total covers
+// only the targeted subset unless the untargeted resources are filled in
+// from state.
+package terraform
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ScopeTargetedSubset labels totals that cover only a targeted plan's
+// resource_changes
+const ScopeTargetedSubset = "targeted subset"
+
+// Untargeted lists the managed resources in planned_values that have no
+// resource change, in planned_values order. It is empty for a full plan.
+func (p *PlanOutput) Untargeted() []string {
+	changed := make(map[string]bool, len(p.ResourceChanges))
+	for _, c := range p.ResourceChanges {
+		if c.Mode != "data" {
+			changed[c.Address] = true
+		}
+	}
+
+	var untargeted []string
+	for _, r := range ExtractPlannedResources(p.PlannedValues) {
+		if !changed[r.Address] {
+			untargeted = append(untargeted, r.Address)
+		}
+	}
+	return untargeted
+}
+
+// targetedWarningPrefix starts every targeted plan warning
+const targetedWarningPrefix = "targeted plan (terraform plan -target): "
+
+// targetedWarning describes a targeted plan's partial total
+func targetedWarning(untargeted, planned int) string {
+	return fmt.Sprintf(targetedWarningPrefix+"%d of %d resources are not in the plan; the total covers the targeted subset only. Provide state to include them",
+		untargeted, planned)
+}
+
+// FillUntargeted adds the untargeted resources of a targeted extraction
+// from state, so its total covers the whole configuration. Resources not
+// in state are left out and reported, and the scope stays targeted.
+func (a *Adapter) FillUntargeted(extraction *PlanExtraction, state *State) error {
+	meta := &extraction.Metadata
+	if len(meta.Untargeted) == 0 {
+		return nil
+	}
+
+	stateResources, err := a.ExtractStateResources(state)
+	if err != nil {
+		return err
+	}
+	byAddress := make(map[string]ResourceInfo, len(stateResources))
+	for _, r := range stateResources {
+		byAddress[r.Address] = r
+	}
+
+	var missing []string
+	for _, addr := range meta.Untargeted {
+		r, ok := byAddress[addr]
+		if !ok {
+			missing = append(missing, addr)
+			continue
+		}
+		extraction.Resources = append(extraction.Resources, r)
+	}
+
+	// The partial total warning no longer applies
+	warnings := meta.Warnings[:0]
+	for _, w := range meta.Warnings {
+		if !strings.HasPrefix(w, targetedWarningPrefix) {
+			warnings = append(warnings, w)
+		}
+	}
+	meta.Warnings = append(warnings, fmt.Sprintf(targetedWarningPrefix+
+		"%d untargeted resources priced from state at their current configuration", len(meta.Untargeted)-len(missing)))
+	meta.Scope = ""
+	if len(missing) > 0 {
+		meta.Scope = ScopeTargetedSubset
+		meta.Warnings = append(meta.Warnings, fmt.Sprintf(
+			"%d untargeted resources are not in state and are left out of the total: %v", len(missing), missing))
+	}
+	return nil
+}
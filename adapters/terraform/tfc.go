@@ -0,0 +1,216 @@
+// Package terraform - Terraform Cloud run plans
+// Runs in Terraform Cloud (or Terraform Enterprise) keep their plan
+// remotely, so CI need not carry a plan artifact. TFCRunSource reads a
+// run's plan JSON through the API: it polls the run's plan until it has
+// finished, then downloads its JSON output, which the API redirects to a
+// short-lived archive URL. Reading JSON output needs a token with admin
+// access to the workspace (or a team with "download sentinel mocks").
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTFCAddress is Terraform Cloud's API host
+	DefaultTFCAddress = "https://app.terraform.io"
+
+	// DefaultTFCPollInterval is how often an unfinished plan is polled
+	DefaultTFCPollInterval = 5 * time.Second
+
+	// DefaultTFCWaitTimeout bounds waiting for a plan to finish
+	DefaultTFCWaitTimeout = 15 * time.Minute
+)
+
+// ErrTFCPlanFailed is returned when a run's plan ends without finishing
+var ErrTFCPlanFailed = errors.New("terraform cloud plan did not finish")
+
+// TFCRunSource reads the plan JSON of a Terraform Cloud run
+type TFCRunSource struct {
+	// RunID names the run, e.g. run-CZcmD7eagjhyX0vN
+	RunID string
+
+	// Token authenticates API requests
+	Token string
+
+	// Address is the API host; DefaultTFCAddress when empty
+	Address string
+
+	// PollInterval paces polling; DefaultTFCPollInterval when zero
+	PollInterval time.Duration
+
+	// WaitTimeout bounds polling and the download;
+	// DefaultTFCWaitTimeout when zero
+	WaitTimeout time.Duration
+
+	// MaxBytes caps the plan JSON read; DefaultMaxPlanBytes when zero
+	MaxBytes int64
+
+	// Client sends API requests; http.DefaultClient when nil
+	Client *http.Client
+}
+
+// NewTFCRunSource returns a source for a run with the default address
+// and limits
+func NewTFCRunSource(runID, token string) *TFCRunSource {
+	return &TFCRunSource{RunID: runID, Token: token}
+}
+
+// TFCTokenFromEnv returns the API token from TFE_TOKEN, or from the
+// TF_TOKEN_<host> variable Terraform itself reads for address
+func TFCTokenFromEnv(address string) string {
+	if token := os.Getenv("TFE_TOKEN"); token != "" {
+		return token
+	}
+	if address == "" {
+		address = DefaultTFCAddress
+	}
+	u, err := url.Parse(address)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	host := strings.NewReplacer(".", "_", "-", "__").Replace(u.Hostname())
+	return os.Getenv("TF_TOKEN_" + host)
+}
+
+// tfcPlan is the part of a plan resource the source reads
+type tfcPlan struct {
+	Data struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			Status string `json:"status"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// Read waits for the run's plan to finish and returns its JSON
+func (s *TFCRunSource) Read(ctx context.Context) ([]byte, error) {
+	if s.RunID == "" {
+		return nil, fmt.Errorf("a Terraform Cloud run ID is required")
+	}
+	if s.Token == "" {
+		return nil, fmt.Errorf("a Terraform Cloud API token is required to read run %s", s.RunID)
+	}
+
+	timeout := s.WaitTimeout
+	if timeout <= 0 {
+		timeout = DefaultTFCWaitTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	planID, err := s.waitForPlan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.get(ctx, "/api/v2/plans/"+url.PathEscape(planID)+"/json-output")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download the plan of run %s: %s", s.RunID, resp.Status)
+	}
+	limits := PlanSource{MaxBytes: s.MaxBytes}
+	if limits.maxBytes() < resp.ContentLength {
+		return nil, fmt.Errorf("%w: the plan of run %s is %d bytes (max %d)", ErrPlanTooLarge, s.RunID, resp.ContentLength, limits.maxBytes())
+	}
+	data, err := limits.readLimited(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download the plan of run %s: %w", s.RunID, err)
+	}
+	return data, nil
+}
+
+// waitForPlan polls the run's plan until it finishes and returns its ID.
+// Rate-limited requests are retried at the next poll.
+func (s *TFCRunSource) waitForPlan(ctx context.Context) (string, error) {
+	interval := s.PollInterval
+	if interval <= 0 {
+		interval = DefaultTFCPollInterval
+	}
+
+	status := "unknown"
+	for {
+		plan, retry, err := s.fetchPlan(ctx)
+		if err != nil {
+			return "", err
+		}
+		if !retry {
+			status = plan.Data.Attributes.Status
+			switch status {
+			case "finished":
+				return plan.Data.ID, nil
+			case "errored", "canceled", "unreachable":
+				return "", fmt.Errorf("%w: the plan of run %s is %s", ErrTFCPlanFailed, s.RunID, status)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("timed out waiting for the plan of run %s (status %s): %w", s.RunID, status, ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+// fetchPlan reads the run's plan; retry is true when the API asked to
+// slow down
+func (s *TFCRunSource) fetchPlan(ctx context.Context) (plan tfcPlan, retry bool, err error) {
+	resp, err := s.get(ctx, "/api/v2/runs/"+url.PathEscape(s.RunID)+"/plan")
+	if err != nil {
+		return plan, false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusTooManyRequests:
+		return plan, true, nil
+	case http.StatusNotFound:
+		// The API hides runs the token cannot read
+		return plan, false, fmt.Errorf("run %s not found, or the token cannot read it", s.RunID)
+	default:
+		return plan, false, fmt.Errorf("failed to read run %s: %s", s.RunID, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&plan); err != nil {
+		return plan, false, fmt.Errorf("invalid plan response for run %s: %w", s.RunID, err)
+	}
+	if plan.Data.ID == "" {
+		return plan, false, fmt.Errorf("run %s has no plan", s.RunID)
+	}
+	return plan, false, nil
+}
+
+// get sends an authenticated API request
+func (s *TFCRunSource) get(ctx context.Context, path string) (*http.Response, error) {
+	address := s.Address
+	if address == "" {
+		address = DefaultTFCAddress
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(address, "/")+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Terraform Cloud address: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	req.Header.Set("Content-Type", "application/vnd.api+json")
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach Terraform Cloud: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,90 @@
+package terraform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestTFCRunSource proves the source polls an unfinished plan, follows
+// the JSON output redirect, and fails on a plan that errored
+func TestTFCRunSource(t *testing.T) {
+	var polls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" && r.URL.Path != "/archive/plan.json" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v2/runs/run-ok/plan":
+			status := "running"
+			switch polls.Add(1) {
+			case 1:
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			case 2:
+			default:
+				status = "finished"
+			}
+			fmt.Fprintf(w, `{"data": {"id": "plan-ok", "type": "plans", "attributes": {"status": %q}}}`, status)
+		case "/api/v2/runs/run-bad/plan":
+			fmt.Fprint(w, `{"data": {"id": "plan-bad", "type": "plans", "attributes": {"status": "errored"}}}`)
+		case "/api/v2/plans/plan-ok/json-output":
+			http.Redirect(w, r, "/archive/plan.json", http.StatusTemporaryRedirect)
+		case "/archive/plan.json":
+			fmt.Fprint(w, movedPlanJSON)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	source := func(runID string) *TFCRunSource {
+		return &TFCRunSource{RunID: runID, Token: "secret", Address: server.URL, PollInterval: time.Millisecond}
+	}
+	ctx := context.Background()
+
+	data, err := source("run-ok").Read(ctx)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if string(data) != movedPlanJSON {
+		t.Errorf("read %d bytes, want the plan", len(data))
+	}
+	if n := polls.Load(); n != 3 {
+		t.Errorf("polled %d times, want 3 (rate limited, running, finished)", n)
+	}
+
+	if _, err := source("run-bad").Read(ctx); !errors.Is(err, ErrTFCPlanFailed) {
+		t.Errorf("errored plan: err = %v, want ErrTFCPlanFailed", err)
+	}
+	if _, err := source("run-missing").Read(ctx); err == nil {
+		t.Error("missing run should fail")
+	}
+	if _, err := (&TFCRunSource{RunID: "run-ok", Address: server.URL}).Read(ctx); err == nil {
+		t.Error("a source without a token should fail")
+	}
+
+	small := source("run-ok")
+	small.MaxBytes = 10
+	if _, err := small.Read(ctx); !errors.Is(err, ErrPlanTooLarge) {
+		t.Errorf("over limit: err = %v, want ErrPlanTooLarge", err)
+	}
+}
+
+func TestTFCTokenFromEnv(t *testing.T) {
+	t.Setenv("TFE_TOKEN", "")
+	t.Setenv("TF_TOKEN_tfe_example__corp_com", "host-token")
+	if got := TFCTokenFromEnv("https://tfe.example-corp.com"); got != "host-token" {
+		t.Errorf("host token = %q", got)
+	}
+	t.Setenv("TFE_TOKEN", "env-token")
+	if got := TFCTokenFromEnv(""); got != "env-token" {
+		t.Errorf("TFE_TOKEN = %q", got)
+	}
+}
@@ -13,6 +13,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"terraform-cost/adapters/terraform"
 )
 
 // Adapter is the Terragrunt adapter
@@ -37,6 +39,10 @@ type Config struct {
 	// Timeout for commands
 	Timeout time.Duration `json:"timeout"`
 
+	// ModuleTimeout bounds plan and show for one module in run-all.
+	// Zero means only Timeout applies to each command.
+	ModuleTimeout time.Duration `json:"module_timeout"`
+
 	// Parallelism for run-all commands
 	Parallelism int `json:"parallelism"`
 
@@ -63,6 +69,7 @@ func DefaultConfig() *Config {
 		TerraformPath:          "terraform",
 		WorkDir:                ".",
 		Timeout:                60 * time.Minute,
+		ModuleTimeout:          15 * time.Minute,
 		Parallelism:            5,
 		IgnoreDependencyErrors: false,
 		NoColor:                true,
@@ -110,6 +117,14 @@ type Module struct {
 	IncludeConfigs []string `json:"include_configs,omitempty"`
 }
 
+// Module run statuses
+const (
+	ModuleStatusSucceeded = "succeeded"
+	ModuleStatusFailed    = "failed"
+	ModuleStatusTimedOut  = "timed_out"
+	ModuleStatusCanceled  = "canceled"
+)
+
 // ModuleOutput is the output from a module estimation
 type ModuleOutput struct {
 	// Module is the module info
@@ -118,6 +133,9 @@ type ModuleOutput struct {
 	// Success indicates if estimation succeeded
 	Success bool `json:"success"`
 
+	// Status is one of the ModuleStatus values
+	Status string `json:"status"`
+
 	// Error message if failed
 	Error string `json:"error,omitempty"`
 
@@ -148,9 +166,12 @@ type RunAllOutput struct {
 	// SuccessCount is number of successful modules
 	SuccessCount int `json:"success_count"`
 
-	// FailureCount is number of failed modules
+	// FailureCount is number of failed modules, including timed out ones
 	FailureCount int `json:"failure_count"`
 
+	// CanceledCount is number of modules not run because the run was canceled
+	CanceledCount int `json:"canceled_count"`
+
 	// Duration of the entire run
 	Duration time.Duration `json:"duration"`
 }
@@ -329,6 +350,11 @@ func (a *Adapter) ShowPlanJSON(ctx context.Context, module *Module, planFile str
 func (a *Adapter) RunAll(ctx context.Context) (*RunAllOutput, error) {
 	start := time.Now()
 
+	// Without the binaries every module would fail the same way
+	if err := a.lookPaths(); err != nil {
+		return nil, err
+	}
+
 	modules, err := a.FindModules(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find modules: %w", err)
@@ -338,38 +364,48 @@ func (a *Adapter) RunAll(ctx context.Context) (*RunAllOutput, error) {
 		Modules: make([]*ModuleOutput, 0, len(modules)),
 	}
 
-	// Process modules in parallel
+	// Process modules in parallel. Once ctx is done, modules still waiting
+	// for a slot are recorded as canceled instead of launched.
 	var wg sync.WaitGroup
 	results := make(chan *ModuleOutput, len(modules))
-	semaphore := make(chan struct{}, a.config.Parallelism)
+	semaphore := make(chan struct{}, max(a.config.Parallelism, 1))
 
 	for _, module := range modules {
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+			results <- canceledModule(module, ctx.Err())
+			continue
+		}
+		if ctx.Err() != nil {
+			<-semaphore
+			results <- canceledModule(module, ctx.Err())
+			continue
+		}
+
 		wg.Add(1)
 		go func(m *Module) {
 			defer wg.Done()
-
-			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 
-			result := a.processModule(ctx, m)
-			results <- result
+			results <- a.processModule(ctx, m)
 		}(module)
 	}
 
-	// Close results when done
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+	wg.Wait()
+	close(results)
 
 	// Collect results
 	for result := range results {
 		output.Modules = append(output.Modules, result)
-		if result.Success {
+		switch result.Status {
+		case ModuleStatusSucceeded:
 			output.SuccessCount++
 			output.TotalCost += result.TotalCost
 			output.TotalResources += result.ResourceCount
-		} else {
+		case ModuleStatusCanceled:
+			output.CanceledCount++
+		default:
 			output.FailureCount++
 		}
 	}
@@ -378,12 +414,45 @@ func (a *Adapter) RunAll(ctx context.Context) (*RunAllOutput, error) {
 	return output, nil
 }
 
-// processModule processes a single module
+// canceledModule is the output of a module that was never started
+func canceledModule(module *Module, err error) *ModuleOutput {
+	return &ModuleOutput{
+		Module: module,
+		Status: ModuleStatusCanceled,
+		Error:  fmt.Sprintf("not run: %v", err),
+	}
+}
+
+// processModule processes a single module within the per-module timeout
 func (a *Adapter) processModule(ctx context.Context, module *Module) *ModuleOutput {
 	start := time.Now()
 
 	result := &ModuleOutput{
 		Module: module,
+		Status: ModuleStatusFailed,
+	}
+
+	parent := ctx
+	if a.config.ModuleTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.config.ModuleTimeout)
+		defer cancel()
+	}
+	// A command killed by an expired context reports as the timeout or
+	// cancellation, not the exit status of the killed process
+	fail := func(step string, err error) *ModuleOutput {
+		switch {
+		case parent.Err() != nil:
+			result.Status = ModuleStatusCanceled
+			result.Error = fmt.Sprintf("%s canceled: %v", step, parent.Err())
+		case ctx.Err() != nil:
+			result.Status = ModuleStatusTimedOut
+			result.Error = fmt.Sprintf("%s timed out after %s", step, a.config.ModuleTimeout)
+		default:
+			result.Error = fmt.Sprintf("%s failed: %v", step, err)
+		}
+		result.Duration = time.Since(start)
+		return result
 	}
 
 	// Create temp plan file
@@ -392,20 +461,17 @@ func (a *Adapter) processModule(ctx context.Context, module *Module) *ModuleOutp
 
 	// Generate plan
 	if err := a.PlanModule(ctx, module, planFile); err != nil {
-		result.Error = fmt.Sprintf("plan failed: %v", err)
-		result.Duration = time.Since(start)
-		return result
+		return fail("plan", err)
 	}
 
 	// Get plan JSON
 	planJSON, err := a.ShowPlanJSON(ctx, module, planFile)
 	if err != nil {
-		result.Error = fmt.Sprintf("show plan failed: %v", err)
-		result.Duration = time.Since(start)
-		return result
+		return fail("show plan", err)
 	}
 
 	result.Success = true
+	result.Status = ModuleStatusSucceeded
 	result.PlanJSON = planJSON
 	result.Duration = time.Since(start)
 
@@ -498,6 +564,9 @@ func (a *Adapter) runInDir(ctx context.Context, dir string, args ...string) (str
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
+		if terraform.IsNotFound(err) {
+			return "", a.notFound()
+		}
 		return "", fmt.Errorf("terragrunt %s failed: %w: %s", strings.Join(args, " "), err, stderr.String())
 	}
 
@@ -0,0 +1,121 @@
+package terragrunt
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"terraform-cost/adapters/terraform"
+)
+
+// newSlowAdapter returns an adapter over dirs whose terragrunt binary fails
+// graph-dependencies (so modules are found by file) and sleeps on any other
+// command
+func newSlowAdapter(t *testing.T, moduleTimeout time.Duration, dirs ...string) *Adapter {
+	t.Helper()
+	root := t.TempDir()
+	for _, dir := range dirs {
+		path := filepath.Join(root, dir)
+		if err := os.MkdirAll(path, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(path, "terragrunt.hcl"), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bin := filepath.Join(t.TempDir(), "terragrunt")
+	script := "#!/bin/sh\n[ \"$1\" = graph-dependencies ] && exit 1\nexec sleep 10\n"
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultConfig()
+	config.TerragruntPath = bin
+	config.TerraformPath = ""
+	config.WorkDir = root
+	config.ModuleTimeout = moduleTimeout
+	config.Parallelism = 1
+
+	a, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestRunAllModuleTimeout(t *testing.T) {
+	a := newSlowAdapter(t, 100*time.Millisecond, "app", "db")
+
+	start := time.Now()
+	output, err := a.RunAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("run-all took %s; module timeout was not applied", elapsed)
+	}
+
+	if len(output.Modules) != 2 || output.FailureCount != 2 {
+		t.Fatalf("modules = %d, failures = %d; want 2 timed out", len(output.Modules), output.FailureCount)
+	}
+	for _, m := range output.Modules {
+		if m.Status != ModuleStatusTimedOut || m.Success {
+			t.Errorf("%s: status = %q, error = %q; want %q", m.Module.RelativePath, m.Status, m.Error, ModuleStatusTimedOut)
+		}
+	}
+}
+
+func TestRunAllCanceled(t *testing.T) {
+	a := newSlowAdapter(t, 0, "app", "db", "network")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	output, err := a.RunAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("run-all took %s; cancellation did not stop running modules", elapsed)
+	}
+
+	// With one slot, the first module is killed and the rest never start
+	if len(output.Modules) != 3 || output.CanceledCount != 3 {
+		t.Fatalf("modules = %d, canceled = %d; want 3 canceled", len(output.Modules), output.CanceledCount)
+	}
+	for _, m := range output.Modules {
+		if m.Status != ModuleStatusCanceled {
+			t.Errorf("%s: status = %q; want %q", m.Module.RelativePath, m.Status, ModuleStatusCanceled)
+		}
+	}
+}
+
+func TestRunAllMissingBinary(t *testing.T) {
+	config := DefaultConfig()
+	config.WorkDir = t.TempDir()
+	config.TerragruntPath = filepath.Join(t.TempDir(), "terragrunt")
+	a, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.RunAll(context.Background()); !errors.Is(err, ErrTerragruntNotFound) {
+		t.Errorf("RunAll = %v, want ErrTerragruntNotFound", err)
+	}
+	if _, err := a.Version(context.Background()); !errors.Is(err, ErrTerragruntNotFound) {
+		t.Errorf("Version = %v, want ErrTerragruntNotFound", err)
+	}
+
+	// terragrunt is present but the terraform it would run is not
+	a = newSlowAdapter(t, 0)
+	a.terraformPath = "terraform-cost-no-such-binary"
+	if _, err := a.CheckBinary(context.Background()); !errors.Is(err, ErrTerraformNotFound) {
+		t.Errorf("CheckBinary = %v, want ErrTerraformNotFound", err)
+	} else if !errors.Is(err, terraform.ErrTerraformNotFound) {
+		t.Errorf("CheckBinary = %v, want the terraform adapter's ErrTerraformNotFound", err)
+	}
+}
@@ -0,0 +1,70 @@
+// Package terragrunt - Terragrunt binary checks
+// Terragrunt shells out to terraform, so both executables must be present.
+// Commands fail with ErrTerragruntNotFound or ErrTerraformNotFound rather
+// than a raw exec error. RunAll checks both exist before finding modules,
+// and CheckBinary also verifies the terragrunt version.
+package terragrunt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+
+	"terraform-cost/adapters/terraform"
+)
+
+// MinTerragruntVersion is the oldest release supporting Terraform 0.12,
+// the first with JSON plan output
+const MinTerragruntVersion = "0.19.0"
+
+var (
+	// ErrTerragruntNotFound is returned when the terragrunt executable
+	// cannot be found
+	ErrTerragruntNotFound = errors.New("terragrunt not found")
+
+	// ErrTerraformNotFound is returned when the terraform executable
+	// terragrunt would run cannot be found; it is the terraform adapter's
+	// error, so callers can check for either adapter's missing terraform
+	ErrTerraformNotFound = terraform.ErrTerraformNotFound
+
+	// ErrTerragruntTooOld is returned when terragrunt predates MinTerragruntVersion
+	ErrTerragruntTooOld = errors.New("terragrunt version too old")
+)
+
+// CheckBinary verifies the terragrunt executable exists and is at least
+// MinTerragruntVersion, and that the configured terraform executable
+// exists. It returns the terragrunt version.
+func (a *Adapter) CheckBinary(ctx context.Context) (string, error) {
+	if err := a.lookPaths(); err != nil {
+		return "", err
+	}
+
+	version, err := a.Version(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to determine terragrunt version: %w", err)
+	}
+	if !terraform.VersionAtLeast(version, MinTerragruntVersion) {
+		return version, fmt.Errorf("%w: %s is %s, need %s or later",
+			ErrTerragruntTooOld, a.terragruntPath, version, MinTerragruntVersion)
+	}
+	return version, nil
+}
+
+// lookPaths checks both executables can be found without running them
+func (a *Adapter) lookPaths() error {
+	if _, err := exec.LookPath(a.terragruntPath); err != nil {
+		return a.notFound()
+	}
+	if a.terraformPath != "" {
+		if _, err := exec.LookPath(a.terraformPath); err != nil {
+			return fmt.Errorf("%w (looked for %q); install terraform or set TerraformPath", ErrTerraformNotFound, a.terraformPath)
+		}
+	}
+	return nil
+}
+
+// notFound is the error for a missing terragrunt executable
+func (a *Adapter) notFound() error {
+	return fmt.Errorf("%w (looked for %q); install terragrunt or set TerragruntPath", ErrTerragruntNotFound, a.terragruntPath)
+}
@@ -0,0 +1,90 @@
+// Package api - Policy file enforcement
+// A server started with --policy-file evaluates the file's policies over
+// every estimate with the same evaluator the CLI, CI and HTTP adapter
+// use, and reports each result in policy_results.
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/model"
+	"terraform-cost/core/policy"
+)
+
+// SetPolicies enforces a policy file on every estimate; nil disables it
+func (s *Server) SetPolicies(policies *policy.PolicyFile) {
+	s.policies = policies
+}
+
+// evaluatePolicies evaluates the server's policy file over an estimate
+func (s *Server) evaluatePolicies(ctx context.Context, resp *EstimateResponse) ([]PolicyResult, error) {
+	result, err := s.policies.Evaluator().Evaluate(ctx, policyInput(resp))
+	if err != nil {
+		return nil, fmt.Errorf("policy evaluation failed: %w", err)
+	}
+	results := make([]PolicyResult, 0, len(result.Policies))
+	for _, p := range result.Policies {
+		results = append(results, PolicyResult{
+			PolicyName: p.Name,
+			Passed:     p.Passed,
+			Message:    p.Message,
+			Severity:   string(p.Severity),
+		})
+	}
+	return results, nil
+}
+
+// policyInput builds the deep policy context from an estimate response.
+// Unknowns count as symbolic resources for coverage.
+func policyInput(resp *EstimateResponse) *policy.PolicyInput {
+	currency := "USD"
+	if resp.TotalMonthlyCost != nil && resp.TotalMonthlyCost.Currency != "" {
+		currency = resp.TotalMonthlyCost.Currency
+	}
+	input := &policy.PolicyInput{
+		InstanceCosts:     make(map[model.InstanceID]*policy.InstanceCostDetail),
+		TotalMonthlyCost:  costMoney(resp.TotalMonthlyCost, currency),
+		TotalHourlyCost:   costMoney(resp.TotalHourlyCost, currency),
+		OverallConfidence: resp.Confidence,
+	}
+	for _, r := range resp.Resources {
+		id := model.InstanceID(r.Address)
+		detail, ok := input.InstanceCosts[id]
+		if !ok {
+			detail = &policy.InstanceCostDetail{
+				InstanceID:   id,
+				Address:      model.InstanceAddress(r.Address),
+				ResourceType: r.ResourceType,
+				MonthlyCost:  determinism.Zero(currency),
+				HourlyCost:   determinism.Zero(currency),
+				Confidence:   r.Confidence,
+			}
+			input.InstanceCosts[id] = detail
+		}
+		detail.MonthlyCost = detail.MonthlyCost.Add(costMoney(r.MonthlyCost, currency))
+		detail.HourlyCost = detail.HourlyCost.Add(costMoney(r.HourlyCost, currency))
+	}
+
+	input.Coverage = &policy.CoverageInput{}
+	if total := len(resp.Resources) + len(resp.Unknowns); total > 0 {
+		input.Coverage.SymbolicPercent = float64(len(resp.Unknowns)) / float64(total) * 100
+	}
+	return input
+}
+
+// costMoney converts a response amount; missing or malformed amounts are
+// zero
+func costMoney(cost *CostValue, currency string) determinism.Money {
+	if cost == nil {
+		return determinism.Zero(currency)
+	}
+	amount, err := decimal.NewFromString(cost.Amount)
+	if err != nil {
+		return determinism.Zero(currency)
+	}
+	return determinism.NewMoneyFromDecimal(amount, currency)
+}
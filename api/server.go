@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"time"
 
+	"terraform-cost/core/policy"
 	"terraform-cost/db"
 )
 
@@ -21,6 +22,9 @@ type Server struct {
 	mux     *http.ServeMux
 	version string
 	store   db.PricingStore
+
+	// policies are evaluated over every estimate (see SetPolicies)
+	policies *policy.PolicyFile
 }
 
 // NewServer creates a new API server (without database)
@@ -93,6 +97,15 @@ func (s *Server) handleEstimate(w http.ResponseWriter, r *http.Request) {
 		DurationMs:      time.Since(start).Milliseconds(),
 	}
 
+	if s.policies != nil {
+		policies, err := s.evaluatePolicies(ctx, result)
+		if err != nil {
+			s.writeError(w, "POLICY_ERROR", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result.PolicyResults = policies
+	}
+
 	s.writeJSON(w, result, http.StatusOK)
 }
 
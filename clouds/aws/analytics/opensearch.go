@@ -37,7 +37,7 @@ func (m *OpenSearchMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageCo
 		}, nil
 	}
 
-	monthlyHours := ctx.ResolveOrDefault("monthly_hours", 730)
+	monthlyHours := ctx.MonthlyHours()
 
 	return []clouds.UsageVector{
 		clouds.NewUsageVector(clouds.MetricMonthlyHours, monthlyHours, 0.95),
@@ -1,16 +1,28 @@
 // Package analytics - AWS Redshift cost mapper
 // Pricing model:
-// - Node hours (by node type)
-// - Managed storage (RA3 nodes only)
+// - Node hours (by node type, times number_of_nodes)
+// - Managed storage (RA3 nodes only, per GB-month from the usage file)
 // - Spectrum queries (per TB scanned)
 // - Concurrency scaling (per second)
 // - Snapshots (beyond free storage)
+//
+// DC2 and DS2 nodes include their storage. A node count that is not a
+// literal makes the cluster symbolic rather than one node.
 package analytics
 
 import (
 	"terraform-cost/clouds"
 )
 
+// Redshift usage metrics
+const (
+	// MetricNodeCount is the number of nodes in a cluster
+	MetricNodeCount clouds.Metric = "node_count"
+
+	// MetricManagedStorageGB is RA3 managed storage, from the usage file
+	MetricManagedStorageGB clouds.Metric = "managed_storage_gb"
+)
+
 // RedshiftMapper maps aws_redshift_cluster to cost units
 type RedshiftMapper struct{}
 
@@ -37,29 +49,44 @@ func (m *RedshiftMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageCont
 		}, nil
 	}
 
-	monthlyHours := ctx.ResolveOrDefault("monthly_hours", 730)
+	nodes, ok := clusterNodeCount(asset)
+	if !ok {
+		return []clouds.UsageVector{
+			clouds.SymbolicUsage(MetricNodeCount, "Redshift node count unknown: number_of_nodes is not a literal"),
+		}, nil
+	}
+
+	monthlyHours := ctx.MonthlyHours()
 
-	return []clouds.UsageVector{
+	usage := []clouds.UsageVector{
+		clouds.NewUsageVector(MetricNodeCount, float64(nodes), 0.95),
 		clouds.NewUsageVector(clouds.MetricMonthlyHours, monthlyHours, 0.95),
-	}, nil
+	}
+
+	// RA3 bills managed storage separately; DC2 and DS2 include theirs
+	if isRA3Node(nodeType(asset)) {
+		if gb, ok := ctx.Resolve(string(MetricManagedStorageGB)); ok {
+			usage = append(usage, clouds.NewUsageVector(MetricManagedStorageGB, gb, 0.5))
+		} else {
+			usage = append(usage, clouds.SymbolicUsage(MetricManagedStorageGB,
+				"RA3 managed storage is billed per GB-month: set managed_storage_gb in the usage file"))
+		}
+	}
+	return usage, nil
 }
 
 // BuildCostUnits creates cost units
 func (m *RedshiftMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVector) ([]clouds.CostUnit, error) {
 	usageVecs := clouds.UsageVectors(usage)
 
-	if usageVecs.IsSymbolic() {
+	nodes, ok := usageVecs.Get(MetricNodeCount)
+	if !ok {
 		return []clouds.CostUnit{
-			clouds.SymbolicCost("redshift", "Redshift cost unknown due to cardinality"),
+			clouds.SymbolicCost("nodes", clouds.UsageVectors(usage).SymbolicReason("Redshift cost unknown due to cardinality")),
 		}, nil
 	}
 
-	nodeType := asset.Attr("node_type")
-	if nodeType == "" {
-		nodeType = "dc2.large"
-	}
-
-	numberOfNodes := asset.AttrInt("number_of_nodes", 1)
+	nodeType := nodeType(asset)
 	monthlyHours, _ := usageVecs.Get(clouds.MetricMonthlyHours)
 
 	providerID := asset.ProviderContext.ProviderID
@@ -70,7 +97,7 @@ func (m *RedshiftMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.U
 		clouds.NewCostUnit(
 			"nodes",
 			"node-hours",
-			float64(numberOfNodes)*monthlyHours,
+			nodes*monthlyHours,
 			clouds.RateKey{
 				Provider: providerID,
 				Service:  "AmazonRedshift",
@@ -86,11 +113,25 @@ func (m *RedshiftMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.U
 
 	// RA3 nodes have separate managed storage cost
 	if isRA3Node(nodeType) {
-		// RA3 storage is usage-based
-		units = append(units, clouds.SymbolicCost(
-			"managed_storage",
-			"RA3 managed storage depends on data volume",
-		))
+		if gb, ok := usageVecs.Get(MetricManagedStorageGB); ok {
+			units = append(units, clouds.NewCostUnit(
+				"managed_storage",
+				"GB-months",
+				gb,
+				clouds.RateKey{
+					Provider: providerID,
+					Service:  "AmazonRedshift",
+					Region:   region,
+					Attributes: map[string]string{
+						"usageType": "RMS:ManagedStorage",
+					},
+				},
+				0.5,
+			))
+		} else {
+			units = append(units, clouds.SymbolicCost("managed_storage",
+				clouds.UsageVectors(usage).SymbolicReason("RA3 managed storage depends on data volume")))
+		}
 	}
 
 	// Concurrency scaling (if enabled)
@@ -103,6 +144,34 @@ func (m *RedshiftMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.U
 	return units, nil
 }
 
+// nodeType returns the cluster's node type, dc2.large when not set
+func nodeType(asset clouds.AssetNode) string {
+	if nodeType := asset.Attr("node_type"); nodeType != "" {
+		return nodeType
+	}
+	return "dc2.large"
+}
+
+// clusterNodeCount returns the cluster's node count. A single-node
+// cluster, or one that does not set number_of_nodes, has one node; a
+// multi-node cluster needs number_of_nodes as a literal.
+func clusterNodeCount(asset clouds.AssetNode) (int, bool) {
+	raw, set := asset.Attributes["number_of_nodes"]
+	if !set || raw == nil {
+		if asset.Attr("cluster_type") == "multi-node" {
+			return 0, false
+		}
+		return 1, true
+	}
+	switch v := raw.(type) {
+	case int:
+		return v, v > 0
+	case float64:
+		return int(v), v > 0
+	}
+	return 0, false
+}
+
 func isRA3Node(nodeType string) bool {
 	return len(nodeType) >= 3 && nodeType[:3] == "ra3"
 }
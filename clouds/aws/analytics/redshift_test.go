@@ -0,0 +1,68 @@
+package analytics
+
+import (
+	"strings"
+	"testing"
+
+	"terraform-cost/clouds/cloudstest"
+)
+
+// TestRedshiftRA3TwoNodes proves RA3 node hours scale with the node count
+// and managed storage is priced from the usage file, or symbolic without it
+func TestRedshiftRA3TwoNodes(t *testing.T) {
+	asset := cloudstest.Asset("aws_redshift_cluster", map[string]interface{}{
+		"node_type":       "ra3.xlplus",
+		"cluster_type":    "multi-node",
+		"number_of_nodes": 2,
+	})
+	units := cloudstest.Units(t, NewRedshiftMapper(), asset, map[string]interface{}{"managed_storage_gb": 500.0})
+
+	nodes := units["nodes"]
+	if nodes.Quantity == nil || *nodes.Quantity != 2*730 {
+		t.Errorf("nodes = %v node-hours, want %d", nodes.Quantity, 2*730)
+	}
+	if got := nodes.RateKey.Attributes["nodeType"]; got != "ra3.xlplus" {
+		t.Errorf("nodeType = %q", got)
+	}
+	storage := units["managed_storage"]
+	if storage.IsSymbolic || storage.Quantity == nil || *storage.Quantity != 500 || storage.Measure != "GB-months" {
+		t.Errorf("managed storage = %+v, want 500 GB-months", storage)
+	}
+
+	units = cloudstest.Units(t, NewRedshiftMapper(), asset, nil)
+	if u := units["managed_storage"]; !u.IsSymbolic || !strings.Contains(u.SymbolicReason, "managed_storage_gb") {
+		t.Errorf("managed storage without usage = %+v, want symbolic naming managed_storage_gb", u)
+	}
+	if u := units["nodes"]; u.IsSymbolic {
+		t.Error("missing storage usage must not make the nodes symbolic")
+	}
+}
+
+// TestRedshiftSingleNodeDC2 proves a single-node DC2 cluster is one node
+// with its storage included
+func TestRedshiftSingleNodeDC2(t *testing.T) {
+	units := cloudstest.Units(t, NewRedshiftMapper(), cloudstest.Asset("aws_redshift_cluster", map[string]interface{}{
+		"node_type":    "dc2.large",
+		"cluster_type": "single-node",
+	}), nil)
+	if u := units["nodes"]; u.Quantity == nil || *u.Quantity != 730 {
+		t.Errorf("nodes = %+v, want 730 node-hours", u)
+	}
+	if _, ok := units["managed_storage"]; ok {
+		t.Error("DC2 storage is included in the node price")
+	}
+}
+
+// TestRedshiftUnknownNodeCount proves a node count that is not a literal
+// is symbolic, not one node
+func TestRedshiftUnknownNodeCount(t *testing.T) {
+	for name, attrs := range map[string]map[string]interface{}{
+		"expression":        {"node_type": "ra3.4xlarge", "number_of_nodes": "${var.nodes}"},
+		"multi-node, unset": {"node_type": "ra3.4xlarge", "cluster_type": "multi-node"},
+	} {
+		units := cloudstest.Units(t, NewRedshiftMapper(), cloudstest.Asset("aws_redshift_cluster", attrs), nil)
+		if len(units) != 1 || !units["nodes"].IsSymbolic || !strings.Contains(units["nodes"].SymbolicReason, "number_of_nodes") {
+			t.Errorf("%s: units = %+v, want one symbolic node unit", name, units)
+		}
+	}
+}
@@ -1,15 +1,114 @@
 // Package apigateway - AWS API Gateway cost mapper
 // Pricing model:
-// - REST API: per million requests + cache (if enabled)
-// - HTTP API: per million requests (cheaper)
+// - REST API: per million requests in volume tiers + cache (if enabled)
+// - HTTP API: per million requests in volume tiers (cheaper)
 // - WebSocket API: per million messages + connection minutes
 // - Data transfer: standard AWS rates
+//
+// HTTP APIs meter each 512 KB of a request as one request. A REST API's
+// cache is enabled on its stages and billed by the hour. Request volume
+// comes from the usage file; without it the request cost is symbolic
+// rather than $0.
 package apigateway
 
 import (
+	"math"
+	"sort"
+
 	"terraform-cost/clouds"
 )
 
+// StageType is the REST API stage resource, which enables the cache
+const StageType = "aws_api_gateway_stage"
+
+// API Gateway usage, read from the usage file
+const (
+	// MetricRequestSizeKB is the average HTTP API request payload
+	MetricRequestSizeKB clouds.Metric = "request_size_kb"
+
+	// MetricMonthlyMessages is WebSocket messages a month
+	MetricMonthlyMessages clouds.Metric = "monthly_messages"
+)
+
+// httpRequestChunkKB is the HTTP API payload metered as one request
+const httpRequestChunkKB = 512
+
+// defaultCacheSizeGB is a stage cache's size when cache_cluster_size is
+// not set
+const defaultCacheSizeGB = "0.5"
+
+// restRequestTiers are REST API's monthly request volume bands
+var restRequestTiers = []clouds.VolumeTier{
+	{Name: "First333M", UpTo: 333e6},
+	{Name: "Next667M", UpTo: 1e9},
+	{Name: "Next19B", UpTo: 20e9},
+	{Name: "Over20B"},
+}
+
+// httpRequestTiers are HTTP API's monthly request volume bands
+var httpRequestTiers = []clouds.VolumeTier{
+	{Name: "First300M", UpTo: 300e6},
+	{Name: "Over300M"},
+}
+
+// requestUsage reads the request volume, or a symbolic vector naming the
+// usage key when the usage file does not give it
+func requestUsage(ctx clouds.UsageContext) clouds.UsageVector {
+	monthlyRequests, ok := ctx.Resolve(string(clouds.MetricMonthlyRequests))
+	if !ok {
+		return clouds.SymbolicUsage(clouds.MetricMonthlyRequests,
+			"API Gateway bills per request: set monthly_requests in the usage file")
+	}
+	return clouds.NewUsageVector(clouds.MetricMonthlyRequests, monthlyRequests, 0.5)
+}
+
+// requestUnits prices the request volume across tiers, or a symbolic unit
+// carrying the usage vector's reason
+func requestUnits(asset clouds.AssetNode, usage []clouds.UsageVector, requests float64, apiType, usageType string, tiers []clouds.VolumeTier) []clouds.CostUnit {
+	if _, ok := clouds.UsageVectors(usage).Get(clouds.MetricMonthlyRequests); !ok {
+		return []clouds.CostUnit{clouds.SymbolicCost("requests", clouds.UsageVectors(usage).SymbolicReason("API Gateway cost requires request volume"))}
+	}
+	rateKey := func(tier string) clouds.RateKey {
+		return clouds.RateKey{
+			Provider: asset.ProviderContext.ProviderID,
+			Service:  "AmazonApiGateway",
+			Region:   asset.ProviderContext.Region,
+			Attributes: map[string]string{
+				"usageType": usageType,
+				"apiType":   apiType,
+				"tier":      tier,
+			},
+		}
+	}
+	return clouds.TieredCostUnits("requests", "million-requests", requests, 1000000, tiers, rateKey, 0.5)
+}
+
+// dataTransferUnits prices outbound data transfer when the usage file
+// gives it
+func dataTransferUnits(asset clouds.AssetNode, usage []clouds.UsageVector) []clouds.CostUnit {
+	gb, ok := clouds.UsageVectors(usage).Get(clouds.MetricDataTransferGB)
+	if !ok || gb <= 0 {
+		return nil
+	}
+	return []clouds.CostUnit{clouds.NewCostUnit("data_transfer_out", "GB", gb, clouds.RateKey{
+		Provider: asset.ProviderContext.ProviderID,
+		Service:  "AWSDataTransfer",
+		Region:   asset.ProviderContext.Region,
+		Attributes: map[string]string{
+			"transferType": "AWS Outbound",
+			"toLocation":   "External",
+		},
+	}, 0.5)}
+}
+
+// dataTransferUsage reads the outbound data transfer, if given
+func dataTransferUsage(ctx clouds.UsageContext) []clouds.UsageVector {
+	if gb, ok := ctx.Resolve(string(clouds.MetricDataTransferGB)); ok {
+		return []clouds.UsageVector{clouds.NewUsageVector(clouds.MetricDataTransferGB, gb, 0.5)}
+	}
+	return nil
+}
+
 // RESTAPIMapper maps aws_api_gateway_rest_api to cost units
 type RESTAPIMapper struct{}
 
@@ -37,51 +136,66 @@ func (m *RESTAPIMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageConte
 	}
 
 	// API Gateway is HIGHLY usage-dependent
-	monthlyRequests := ctx.ResolveOrDefault("monthly_requests", -1)
-
-	if monthlyRequests < 0 {
-		return []clouds.UsageVector{
-			clouds.SymbolicUsage(clouds.MetricMonthlyRequests, "monthly API requests not provided"),
-		}, nil
+	usage := []clouds.UsageVector{requestUsage(ctx)}
+	usage = append(usage, dataTransferUsage(ctx)...)
+	if len(cachedStages(asset)) > 0 {
+		usage = append(usage, clouds.NewUsageVector(clouds.MetricMonthlyHours, ctx.MonthlyHours(), 0.95))
 	}
-
-	return []clouds.UsageVector{
-		clouds.NewUsageVector(clouds.MetricMonthlyRequests, monthlyRequests, 0.5),
-	}, nil
+	return usage, nil
 }
 
 // BuildCostUnits creates cost units
 func (m *RESTAPIMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVector) ([]clouds.CostUnit, error) {
-	usageVecs := clouds.UsageVectors(usage)
-
-	if usageVecs.IsSymbolic() {
+	if asset.Cardinality.IsUnknown() {
 		return []clouds.CostUnit{
-			clouds.SymbolicCost("api_requests", "API Gateway cost requires request volume"),
+			clouds.SymbolicCost("requests", clouds.UsageVectors(usage).SymbolicReason("API Gateway cost requires request volume")),
 		}, nil
 	}
 
-	monthlyRequests, _ := usageVecs.Get(clouds.MetricMonthlyRequests)
+	requests, _ := clouds.UsageVectors(usage).Get(clouds.MetricMonthlyRequests)
+	units := requestUnits(asset, usage, requests, "REST", "ApiGatewayRequest", restRequestTiers)
 
-	providerID := asset.ProviderContext.ProviderID
-	region := asset.ProviderContext.Region
-
-	return []clouds.CostUnit{
-		clouds.NewCostUnit(
-			"requests",
-			"million-requests",
-			monthlyRequests/1000000,
-			clouds.RateKey{
-				Provider: providerID,
-				Service:  "AmazonApiGateway",
-				Region:   region,
-				Attributes: map[string]string{
-					"usageType": "ApiGatewayRequest",
-					"apiType":   "REST",
-				},
+	// The cache bills by the hour whatever the request volume
+	hours, _ := clouds.UsageVectors(usage).Get(clouds.MetricMonthlyHours)
+	for _, stage := range cachedStages(asset) {
+		size := stage.Attr("cache_cluster_size")
+		if size == "" {
+			size = defaultCacheSizeGB
+		}
+		units = append(units, clouds.NewCostUnit("cache_"+stageName(stage), "hours", hours, clouds.RateKey{
+			Provider: asset.ProviderContext.ProviderID,
+			Service:  "AmazonApiGateway",
+			Region:   asset.ProviderContext.Region,
+			Attributes: map[string]string{
+				"usageType":       "ApiGatewayCacheUsage",
+				"cacheMemorySize": size,
 			},
-			0.5,
-		),
-	}, nil
+		}, 0.95))
+	}
+
+	return append(units, dataTransferUnits(asset, usage)...), nil
+}
+
+// cachedStages returns the API's stages with caching enabled, in address
+// order
+func cachedStages(asset clouds.AssetNode) []clouds.AssetNode {
+	var cached []clouds.AssetNode
+	for _, stage := range asset.DependentsOfType(StageType) {
+		if stage.AttrBool("cache_cluster_enabled", false) {
+			cached = append(cached, stage)
+		}
+	}
+	sort.Slice(cached, func(i, j int) bool { return cached[i].Address < cached[j].Address })
+	return cached
+}
+
+// stageName names a stage's cache unit by its stage_name, falling back
+// to its address
+func stageName(stage clouds.AssetNode) string {
+	if name := stage.Attr("stage_name"); name != "" {
+		return name
+	}
+	return stage.Address
 }
 
 // HTTPAPIMapper maps aws_apigatewayv2_api (HTTP) to cost units
@@ -110,54 +224,46 @@ func (m *HTTPAPIMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageConte
 		}, nil
 	}
 
-	monthlyRequests := ctx.ResolveOrDefault("monthly_requests", -1)
-	monthlyMessages := ctx.ResolveOrDefault("monthly_messages", -1)
-
-	protocolType := asset.Attr("protocol_type")
-
-	if protocolType == "WEBSOCKET" {
-		if monthlyMessages < 0 {
+	if asset.Attr("protocol_type") == "WEBSOCKET" {
+		monthlyMessages, ok := ctx.Resolve(string(MetricMonthlyMessages))
+		if !ok {
 			return []clouds.UsageVector{
-				clouds.SymbolicUsage("monthly_messages", "WebSocket messages not provided"),
+				clouds.SymbolicUsage(MetricMonthlyMessages, "WebSocket APIs bill per message: set monthly_messages in the usage file"),
 			}, nil
 		}
 		return []clouds.UsageVector{
-			clouds.NewUsageVector("monthly_messages", monthlyMessages, 0.5),
+			clouds.NewUsageVector(MetricMonthlyMessages, monthlyMessages, 0.5),
 		}, nil
 	}
 
 	// HTTP API
-	if monthlyRequests < 0 {
-		return []clouds.UsageVector{
-			clouds.SymbolicUsage(clouds.MetricMonthlyRequests, "monthly API requests not provided"),
-		}, nil
+	usage := []clouds.UsageVector{
+		requestUsage(ctx),
+		clouds.NewUsageVector(MetricRequestSizeKB, ctx.ResolveOrDefault(string(MetricRequestSizeKB), httpRequestChunkKB), 0.5),
 	}
-
-	return []clouds.UsageVector{
-		clouds.NewUsageVector(clouds.MetricMonthlyRequests, monthlyRequests, 0.5),
-	}, nil
+	return append(usage, dataTransferUsage(ctx)...), nil
 }
 
 // BuildCostUnits creates cost units
 func (m *HTTPAPIMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVector) ([]clouds.CostUnit, error) {
 	usageVecs := clouds.UsageVectors(usage)
 
-	if usageVecs.IsSymbolic() {
-		return []clouds.CostUnit{
-			clouds.SymbolicCost("api", "API Gateway v2 cost requires usage data"),
-		}, nil
-	}
-
 	protocolType := asset.Attr("protocol_type")
 	if protocolType == "" {
 		protocolType = "HTTP"
 	}
 
+	if asset.Cardinality.IsUnknown() || (protocolType == "WEBSOCKET" && usageVecs.IsSymbolic()) {
+		return []clouds.CostUnit{
+			clouds.SymbolicCost("api", clouds.UsageVectors(usage).SymbolicReason("API Gateway v2 cost requires usage data")),
+		}, nil
+	}
+
 	providerID := asset.ProviderContext.ProviderID
 	region := asset.ProviderContext.Region
 
 	if protocolType == "WEBSOCKET" {
-		messages, _ := usageVecs.Get("monthly_messages")
+		messages, _ := usageVecs.Get(MetricMonthlyMessages)
 		return []clouds.CostUnit{
 			clouds.NewCostUnit(
 				"messages",
@@ -177,23 +283,13 @@ func (m *HTTPAPIMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.Us
 		}, nil
 	}
 
-	// HTTP API (cheaper than REST)
+	// HTTP API (cheaper than REST), metered per 512 KB of request
 	monthlyRequests, _ := usageVecs.Get(clouds.MetricMonthlyRequests)
-	return []clouds.CostUnit{
-		clouds.NewCostUnit(
-			"requests",
-			"million-requests",
-			monthlyRequests/1000000,
-			clouds.RateKey{
-				Provider: providerID,
-				Service:  "AmazonApiGateway",
-				Region:   region,
-				Attributes: map[string]string{
-					"usageType": "ApiGatewayHttpRequest",
-					"apiType":   "HTTP",
-				},
-			},
-			0.5,
-		),
-	}, nil
+	requestSizeKB, ok := usageVecs.Get(MetricRequestSizeKB)
+	if !ok {
+		requestSizeKB = httpRequestChunkKB
+	}
+	chunks := math.Max(1, math.Ceil(requestSizeKB/httpRequestChunkKB))
+	units := requestUnits(asset, usage, monthlyRequests*chunks, "HTTP", "ApiGatewayHttpRequest", httpRequestTiers)
+	return append(units, dataTransferUnits(asset, usage)...), nil
 }
@@ -0,0 +1,93 @@
+// Package apigateway - API Gateway mapper tests
+package apigateway
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"terraform-cost/clouds"
+	"terraform-cost/clouds/cloudstest"
+)
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+// TestRESTAPIRequestTiers proves REST requests past a tier boundary are
+// priced in the next tier at the REST rates
+func TestRESTAPIRequestTiers(t *testing.T) {
+	units := cloudstest.Units(t, NewRESTAPIMapper(), cloudstest.Asset("aws_api_gateway_rest_api", nil), map[string]interface{}{
+		"monthly_requests": 1.5e9, "data_transfer_gb": 40.0,
+	})
+	want := map[string]float64{"requests": 333, "requests_Next667M": 667, "requests_Next19B": 500}
+	for name, millions := range want {
+		u, ok := units[name]
+		if !ok || !approx(*u.Quantity, millions) {
+			t.Errorf("%s = %+v, want %v million", name, u, millions)
+			continue
+		}
+		if u.RateKey.Attributes["apiType"] != "REST" || u.RateKey.Attributes["usageType"] != "ApiGatewayRequest" {
+			t.Errorf("%s rate key = %v", name, u.RateKey.Attributes)
+		}
+	}
+	if _, ok := units["requests_Over20B"]; ok {
+		t.Error("1.5 billion requests do not reach the last tier")
+	}
+	if u := units["data_transfer_out"]; u.Quantity == nil || !approx(*u.Quantity, 40) {
+		t.Errorf("data transfer = %+v, want 40 GB", u)
+	}
+}
+
+// TestRESTAPICache proves each stage with caching enabled adds an hourly
+// cache at its size, whether or not request volume is known
+func TestRESTAPICache(t *testing.T) {
+	api := cloudstest.Asset("aws_api_gateway_rest_api", nil)
+	api.Dependents = []clouds.AssetNode{
+		{Address: "aws_api_gateway_stage.prod", Type: StageType, Attributes: map[string]interface{}{
+			"stage_name": "prod", "cache_cluster_enabled": true, "cache_cluster_size": "6.1",
+		}},
+		{Address: "aws_api_gateway_stage.dev", Type: StageType, Attributes: map[string]interface{}{
+			"stage_name": "dev",
+		}},
+	}
+
+	units := cloudstest.Units(t, NewRESTAPIMapper(), api, nil)
+	cache, ok := units["cache_prod"]
+	if !ok || !approx(*cache.Quantity, 730) || cache.RateKey.Attributes["cacheMemorySize"] != "6.1" {
+		t.Errorf("prod cache = %+v, want 730 hours of a 6.1 GB cache", cache)
+	}
+	if _, ok := units["cache_dev"]; ok {
+		t.Error("a stage without caching has no cache cost")
+	}
+	if u := units["requests"]; !u.IsSymbolic || !strings.Contains(u.SymbolicReason, "monthly_requests") {
+		t.Errorf("requests without usage = %+v, want symbolic naming monthly_requests", u)
+	}
+}
+
+// TestHTTPAPIRequests proves HTTP APIs price at HTTP rates in their own
+// tiers, metering each 512 KB of a request as one request
+func TestHTTPAPIRequests(t *testing.T) {
+	units := cloudstest.Units(t, NewHTTPAPIMapper(), cloudstest.Asset("aws_apigatewayv2_api", map[string]interface{}{"protocol_type": "HTTP"}),
+		map[string]interface{}{"monthly_requests": 200e6, "request_size_kb": 600.0})
+	// 600 KB requests are two 512 KB requests each: 400 million
+	want := map[string]float64{"requests": 300, "requests_Over300M": 100}
+	if len(units) != len(want) {
+		t.Errorf("got units %v", units)
+	}
+	for name, millions := range want {
+		u, ok := units[name]
+		if !ok || !approx(*u.Quantity, millions) {
+			t.Errorf("%s = %+v, want %v million", name, u, millions)
+			continue
+		}
+		if u.RateKey.Attributes["apiType"] != "HTTP" || u.RateKey.Attributes["usageType"] != "ApiGatewayHttpRequest" {
+			t.Errorf("%s rate key = %v", name, u.RateKey.Attributes)
+		}
+	}
+
+	units = cloudstest.Units(t, NewHTTPAPIMapper(), cloudstest.Asset("aws_apigatewayv2_api", nil), nil)
+	if u := units["requests"]; !u.IsSymbolic || !strings.Contains(u.SymbolicReason, "monthly_requests") {
+		t.Errorf("requests without usage = %+v, want symbolic naming monthly_requests", u)
+	}
+}
@@ -65,15 +65,9 @@ func NewLambdaFunctionBuilder() asset.Builder {
 
 // Build converts a raw Lambda function into an asset
 func (b *LambdaFunctionBuilder) Build(ctx context.Context, raw *types.RawAsset) (*types.Asset, error) {
-	memorySize := raw.Attributes.GetInt("memory_size")
-	if memorySize == 0 {
-		memorySize = 128 // Default
-	}
+	memorySize := raw.Attributes.GetIntOr("memory_size", 128)
 
-	timeout := raw.Attributes.GetInt("timeout")
-	if timeout == 0 {
-		timeout = 3 // Default
-	}
+	timeout := raw.Attributes.GetIntOr("timeout", 3)
 
 	return &types.Asset{
 		ID:       fmt.Sprintf("aws_lambda_function.%s", raw.Name),
@@ -113,10 +107,7 @@ func NewECSServiceBuilder() asset.Builder {
 
 // Build converts a raw ECS service into an asset
 func (b *ECSServiceBuilder) Build(ctx context.Context, raw *types.RawAsset) (*types.Asset, error) {
-	desiredCount := raw.Attributes.GetInt("desired_count")
-	if desiredCount == 0 {
-		desiredCount = 1
-	}
+	desiredCount := raw.Attributes.GetIntOr("desired_count", 1)
 
 	return &types.Asset{
 		ID:       fmt.Sprintf("aws_ecs_service.%s", raw.Name),
@@ -37,10 +37,7 @@ func (b *RDSInstanceBuilder) Build(ctx context.Context, raw *types.RawAsset) (*t
 		storageType = "gp2"
 	}
 
-	allocatedStorage := raw.Attributes.GetInt("allocated_storage")
-	if allocatedStorage == 0 {
-		allocatedStorage = 20
-	}
+	allocatedStorage := raw.Attributes.GetIntOr("allocated_storage", 20)
 
 	return &types.Asset{
 		ID:       fmt.Sprintf("aws_db_instance.%s", raw.Name),
@@ -178,10 +175,7 @@ func (b *ElastiCacheClusterBuilder) Build(ctx context.Context, raw *types.RawAss
 		nodeType = "cache.t3.micro"
 	}
 
-	numCacheNodes := raw.Attributes.GetInt("num_cache_nodes")
-	if numCacheNodes == 0 {
-		numCacheNodes = 1
-	}
+	numCacheNodes := raw.Attributes.GetIntOr("num_cache_nodes", 1)
 
 	return &types.Asset{
 		ID:       fmt.Sprintf("aws_elasticache_cluster.%s", raw.Name),
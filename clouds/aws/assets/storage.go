@@ -67,10 +67,7 @@ func (b *EBSVolumeBuilder) Build(ctx context.Context, raw *types.RawAsset) (*typ
 		volumeType = "gp3"
 	}
 
-	size := raw.Attributes.GetInt("size")
-	if size == 0 {
-		size = 8
-	}
+	size := raw.Attributes.GetIntOr("size", 8)
 
 	az := raw.Attributes.GetString("availability_zone")
 	region := ""
@@ -1,7 +1,18 @@
 // Package cdn - AWS CloudFront and Global Accelerator mappers
+// CloudFront pricing model:
+// - Data transfer out: per GB, by the edge region serving the viewer
+// - HTTP and HTTPS requests: per 10k requests, by edge region
+// - Field-level encryption: per 10k requests, on behaviors that use it
+// - Lambda@Edge: per request and per GB-second, where associated
+// - Price class: limits which edge regions serve traffic
+//
+// Viewers outside the distribution's price class are served, and billed,
+// by the nearest included region.
 package cdn
 
 import (
+	"strings"
+
 	"terraform-cost/clouds"
 )
 
@@ -13,62 +24,287 @@ func NewCloudFrontMapper() *CloudFrontMapper { return &CloudFrontMapper{} }
 func (m *CloudFrontMapper) Cloud() clouds.CloudProvider { return clouds.AWS }
 func (m *CloudFrontMapper) ResourceType() string        { return "aws_cloudfront_distribution" }
 
+// CloudFront usage, read from the usage file. Regional data transfer is
+// "data_transfer_gb_<edge region>", e.g. data_transfer_gb_europe.
+const (
+	MetricHTTPRequests             clouds.Metric = "http_requests"
+	MetricHTTPSRequests            clouds.Metric = "https_requests"
+	MetricFieldLevelEncryptionReqs clouds.Metric = "field_level_encryption_requests"
+	MetricLambdaEdgeRequests       clouds.Metric = "lambda_edge_requests"
+	MetricLambdaEdgeGBSeconds      clouds.Metric = "lambda_edge_gb_seconds"
+)
+
+// CloudFront price classes
+const (
+	PriceClass100 = "PriceClass_100"
+	PriceClass200 = "PriceClass_200"
+	PriceClassAll = "PriceClass_All"
+)
+
+// edgeRegion is a CloudFront billing region
+type edgeRegion struct {
+	// key names the region in usage keys and cost unit names
+	key string
+
+	// code prefixes the region's AWS usage types
+	code string
+
+	// minClass is the cheapest price class that includes the region
+	minClass string
+
+	// servedBy is the region billed for this region's viewers under each
+	// price class that excludes it
+	servedBy map[string]string
+}
+
+// edgeRegions in billing order
+var edgeRegions = []edgeRegion{
+	{key: "us", code: "US", minClass: PriceClass100},
+	{key: "europe", code: "EU", minClass: PriceClass100},
+	{key: "japan", code: "JP", minClass: PriceClass200, servedBy: map[string]string{PriceClass100: "us"}},
+	{key: "asia_pacific", code: "AP", minClass: PriceClass200, servedBy: map[string]string{PriceClass100: "us"}},
+	{key: "india", code: "IN", minClass: PriceClass200, servedBy: map[string]string{PriceClass100: "europe"}},
+	{key: "middle_east", code: "ME", minClass: PriceClass200, servedBy: map[string]string{PriceClass100: "europe"}},
+	{key: "south_africa", code: "ZA", minClass: PriceClass200, servedBy: map[string]string{PriceClass100: "europe"}},
+	{key: "south_america", code: "SA", minClass: PriceClassAll, servedBy: map[string]string{PriceClass100: "us", PriceClass200: "us"}},
+	{key: "australia", code: "AU", minClass: PriceClassAll, servedBy: map[string]string{PriceClass100: "us", PriceClass200: "asia_pacific"}},
+}
+
+// priceClassRank orders price classes from cheapest to broadest
+var priceClassRank = map[string]int{PriceClass100: 0, PriceClass200: 1, PriceClassAll: 2}
+
+// billedRegion returns the region whose rates apply to viewers in r
+func (r edgeRegion) billedRegion(priceClass string) string {
+	if priceClassRank[priceClass] >= priceClassRank[r.minClass] {
+		return r.key
+	}
+	return r.servedBy[priceClass]
+}
+
+func regionalTransferMetric(region string) clouds.Metric {
+	return clouds.Metric("data_transfer_gb_" + region)
+}
+
 func (m *CloudFrontMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext) ([]clouds.UsageVector, error) {
 	if asset.Cardinality.IsUnknown() {
 		return []clouds.UsageVector{clouds.SymbolicUsage("distributions", "unknown distribution count")}, nil
 	}
 
-	// CloudFront is entirely usage-based
-	dataTransferGB := ctx.ResolveOrDefault("data_transfer_gb", -1)
-	requests := ctx.ResolveOrDefault("monthly_requests", -1)
+	// CloudFront is entirely usage-based: regional data transfer, or a
+	// total that is billed as US traffic, plus request counts
+	var usage []clouds.UsageVector
+	for _, r := range edgeRegions {
+		if gb, ok := ctx.Resolve(string(regionalTransferMetric(r.key))); ok {
+			usage = append(usage, clouds.NewUsageVector(regionalTransferMetric(r.key), gb, 0.8))
+		}
+	}
+	if len(usage) == 0 {
+		if gb, ok := ctx.Resolve(string(clouds.MetricDataTransferGB)); ok {
+			usage = append(usage, clouds.NewUsageVector(clouds.MetricDataTransferGB, gb, 0.5))
+		}
+	}
+	hasTransfer := len(usage) > 0
+
+	httpReqs, hasHTTP := ctx.Resolve(string(MetricHTTPRequests))
+	httpsReqs, hasHTTPS := ctx.Resolve(string(MetricHTTPSRequests))
+	if !hasHTTPS {
+		// monthly_requests predates the HTTP/HTTPS split; CloudFront
+		// distributions are almost always HTTPS
+		httpsReqs, hasHTTPS = ctx.Resolve(string(clouds.MetricMonthlyRequests))
+	}
 
-	if dataTransferGB < 0 || requests < 0 {
+	var missing []string
+	if !hasTransfer {
+		missing = append(missing, "data transfer")
+	}
+	if !hasHTTP && !hasHTTPS {
+		missing = append(missing, "requests")
+	}
+	if len(missing) > 0 {
 		return []clouds.UsageVector{
-			clouds.SymbolicUsage(clouds.MetricDataTransferGB, "CloudFront usage not provided"),
+			clouds.SymbolicUsage(clouds.MetricDataTransferGB,
+				"CloudFront is billed on usage; usage file has no "+strings.Join(missing, " or ")+" for "+asset.Address),
 		}, nil
 	}
 
-	return []clouds.UsageVector{
-		clouds.NewUsageVector(clouds.MetricDataTransferGB, dataTransferGB, 0.5),
-		clouds.NewUsageVector(clouds.MetricMonthlyRequests, requests, 0.5),
-	}, nil
+	usage = append(usage,
+		clouds.NewUsageVector(MetricHTTPRequests, httpReqs, 0.8),
+		clouds.NewUsageVector(MetricHTTPSRequests, httpsReqs, 0.8),
+	)
+
+	// Optional features are only reported when the usage file has them;
+	// BuildCostUnits marks a configured feature without usage symbolic
+	for _, metric := range []clouds.Metric{MetricFieldLevelEncryptionReqs, MetricLambdaEdgeRequests, MetricLambdaEdgeGBSeconds} {
+		if v, ok := ctx.Resolve(string(metric)); ok {
+			usage = append(usage, clouds.NewUsageVector(metric, v, 0.8))
+		}
+	}
+
+	return usage, nil
 }
 
 func (m *CloudFrontMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVector) ([]clouds.CostUnit, error) {
 	usageVecs := clouds.UsageVectors(usage)
 	if usageVecs.IsSymbolic() {
-		return []clouds.CostUnit{
-			clouds.SymbolicCost("cloudfront", "CloudFront cost depends on data transfer and requests"),
-		}, nil
+		reason := "CloudFront cost depends on data transfer and requests"
+		for _, v := range usageVecs {
+			if v.IsSymbolic && v.SymbolicReason != "" {
+				reason = v.SymbolicReason
+			}
+		}
+		return []clouds.CostUnit{clouds.SymbolicCost("cloudfront", reason)}, nil
 	}
 
-	dataTransferGB, _ := usageVecs.Get(clouds.MetricDataTransferGB)
-	requests, _ := usageVecs.Get(clouds.MetricMonthlyRequests)
-
 	priceClass := asset.Attr("price_class")
-	if priceClass == "" {
-		priceClass = "PriceClass_All"
+	if _, known := priceClassRank[priceClass]; !known {
+		priceClass = PriceClassAll
 	}
 
-	return []clouds.CostUnit{
-		clouds.NewCostUnit("data_transfer", "GB", dataTransferGB, clouds.RateKey{
+	// Bill each viewer region's transfer to the edge region serving it
+	transferGB := make(map[string]float64)
+	confidence := 0.8
+	if total, ok := usageVecs.Get(clouds.MetricDataTransferGB); ok {
+		transferGB["us"] = total
+		confidence = 0.5
+	}
+	for _, r := range edgeRegions {
+		if gb, ok := usageVecs.Get(regionalTransferMetric(r.key)); ok {
+			transferGB[r.billedRegion(priceClass)] += gb
+		}
+	}
+
+	var totalGB float64
+	for _, gb := range transferGB {
+		totalGB += gb
+	}
+	httpReqs, _ := usageVecs.Get(MetricHTTPRequests)
+	httpsReqs, _ := usageVecs.Get(MetricHTTPSRequests)
+
+	rateKey := func(usageType string) clouds.RateKey {
+		return clouds.RateKey{
 			Provider: asset.ProviderContext.ProviderID,
 			Service:  "AmazonCloudFront",
 			Region:   "global",
 			Attributes: map[string]string{
 				"priceClass": priceClass,
-				"usageType":  "DataTransfer-Out-Bytes",
+				"usageType":  usageType,
 			},
-		}, 0.5),
-		clouds.NewCostUnit("requests", "10k-requests", requests/10000, clouds.RateKey{
-			Provider: asset.ProviderContext.ProviderID,
-			Service:  "AmazonCloudFront",
-			Region:   "global",
-			Attributes: map[string]string{
-				"usageType": "Requests-Tier1",
-			},
-		}, 0.5),
-	}, nil
+		}
+	}
+
+	var units []clouds.CostUnit
+	for _, r := range edgeRegions {
+		gb, ok := transferGB[r.key]
+		if !ok {
+			continue
+		}
+		units = append(units, clouds.NewCostUnit("data_transfer_"+r.key, "GB", gb,
+			rateKey(r.code+"-DataTransfer-Out-Bytes"), confidence))
+
+		// Requests follow the traffic: each region gets its share
+		share := 1 / float64(len(transferGB))
+		if totalGB > 0 {
+			share = gb / totalGB
+		}
+		if httpReqs > 0 {
+			units = append(units, clouds.NewCostUnit("http_requests_"+r.key, "10k-requests", httpReqs*share/10000,
+				rateKey(r.code+"-Requests-Tier1"), confidence))
+		}
+		if httpsReqs > 0 {
+			units = append(units, clouds.NewCostUnit("https_requests_"+r.key, "10k-requests", httpsReqs*share/10000,
+				rateKey(r.code+"-Requests-Tier2-HTTPS"), confidence))
+		}
+	}
+
+	features := cacheBehaviorFeatures(asset)
+	if features.fieldLevelEncryption {
+		if reqs, ok := usageVecs.Get(MetricFieldLevelEncryptionReqs); ok {
+			units = append(units, clouds.NewCostUnit("field_level_encryption", "10k-requests", reqs/10000,
+				rateKey("Requests-FLE"), 0.8))
+		} else {
+			units = append(units, clouds.SymbolicCost("field_level_encryption",
+				"field-level encryption is configured but field_level_encryption_requests is not in the usage file"))
+		}
+	}
+	if features.lambdaEdge {
+		units = append(units, lambdaEdgeUnits(asset, usageVecs)...)
+	}
+
+	return units, nil
+}
+
+// lambdaEdgeUnits prices Lambda@Edge invocations and duration
+func lambdaEdgeUnits(asset clouds.AssetNode, usageVecs clouds.UsageVectors) []clouds.CostUnit {
+	rateKey := func(usageType string) clouds.RateKey {
+		return clouds.RateKey{
+			Provider:   asset.ProviderContext.ProviderID,
+			Service:    "AWSLambda",
+			Region:     "global",
+			Attributes: map[string]string{"usageType": usageType},
+		}
+	}
+
+	var units []clouds.CostUnit
+	if reqs, ok := usageVecs.Get(MetricLambdaEdgeRequests); ok {
+		units = append(units, clouds.NewCostUnit("lambda_edge_requests", "requests", reqs,
+			rateKey("Lambda-Edge-Request"), 0.8))
+	} else {
+		units = append(units, clouds.SymbolicCost("lambda_edge_requests",
+			"Lambda@Edge is associated but lambda_edge_requests is not in the usage file"))
+	}
+	if gbSeconds, ok := usageVecs.Get(MetricLambdaEdgeGBSeconds); ok {
+		units = append(units, clouds.NewCostUnit("lambda_edge_duration", "GB-seconds", gbSeconds,
+			rateKey("Lambda-Edge-GB-Second"), 0.8))
+	} else {
+		units = append(units, clouds.SymbolicCost("lambda_edge_duration",
+			"Lambda@Edge is associated but lambda_edge_gb_seconds is not in the usage file"))
+	}
+	return units
+}
+
+// behaviorFeatures are the billable features enabled on any cache behavior
+type behaviorFeatures struct {
+	fieldLevelEncryption bool
+	lambdaEdge           bool
+}
+
+// cacheBehaviorFeatures scans default_cache_behavior and
+// ordered_cache_behavior, either as lists of objects (plan JSON) or as
+// flattened ".N.*" keys
+func cacheBehaviorFeatures(asset clouds.AssetNode) behaviorFeatures {
+	var f behaviorFeatures
+	check := func(behavior map[string]interface{}) {
+		if id, _ := behavior["field_level_encryption_id"].(string); id != "" {
+			f.fieldLevelEncryption = true
+		}
+		if assocs, _ := behavior["lambda_function_association"].([]interface{}); len(assocs) > 0 {
+			f.lambdaEdge = true
+		}
+	}
+
+	for _, attr := range []string{"default_cache_behavior", "ordered_cache_behavior"} {
+		blocks, _ := asset.Attributes[attr].([]interface{})
+		for _, block := range blocks {
+			if behavior, ok := block.(map[string]interface{}); ok {
+				check(behavior)
+			}
+		}
+	}
+
+	for k, v := range asset.Attributes {
+		if !strings.HasPrefix(k, "default_cache_behavior.") && !strings.HasPrefix(k, "ordered_cache_behavior.") {
+			continue
+		}
+		switch {
+		case strings.HasSuffix(k, ".field_level_encryption_id"):
+			if id, _ := v.(string); id != "" {
+				f.fieldLevelEncryption = true
+			}
+		case strings.Contains(k, ".lambda_function_association.") && !strings.HasSuffix(k, ".#"):
+			f.lambdaEdge = true
+		}
+	}
+	return f
 }
 
 // GlobalAcceleratorMapper maps aws_global_accelerator to cost units
@@ -84,7 +320,7 @@ func (m *GlobalAcceleratorMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.
 		return []clouds.UsageVector{clouds.SymbolicUsage(clouds.MetricMonthlyHours, "unknown accelerator count")}, nil
 	}
 	return []clouds.UsageVector{
-		clouds.NewUsageVector(clouds.MetricMonthlyHours, 730, 0.95),
+		clouds.NewUsageVector(clouds.MetricMonthlyHours, ctx.BillingHours(), 0.95),
 		clouds.NewUsageVector(clouds.MetricDataTransferGB, ctx.ResolveOrDefault("data_transfer_gb", 0), 0.5),
 	}, nil
 }
@@ -0,0 +1,88 @@
+// Package cdn - CloudFront mapper tests
+package cdn
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"terraform-cost/clouds/cloudstest"
+)
+
+func TestCloudFrontWithoutUsageIsSymbolic(t *testing.T) {
+	units := cloudstest.Units(t, NewCloudFrontMapper(), cloudstest.Asset("aws_cloudfront_distribution", nil), map[string]interface{}{"data_transfer_gb": 100.0})
+	unit, ok := units["cloudfront"]
+	if !ok || !unit.IsSymbolic || !strings.Contains(unit.SymbolicReason, "requests") {
+		t.Fatalf("expected symbolic cost naming missing requests, got %+v", units)
+	}
+}
+
+// TestCloudFrontPriceClass proves viewers outside the price class are
+// billed at the region serving them, and requests follow the traffic
+func TestCloudFrontPriceClass(t *testing.T) {
+	usage := map[string]interface{}{
+		"data_transfer_gb_us":            600.0,
+		"data_transfer_gb_europe":        300.0,
+		"data_transfer_gb_south_america": 100.0,
+		"https_requests":                 1000000.0,
+	}
+
+	units := cloudstest.Units(t, NewCloudFrontMapper(), cloudstest.Asset("aws_cloudfront_distribution", map[string]interface{}{"price_class": PriceClass100}), usage)
+	if _, ok := units["data_transfer_south_america"]; ok {
+		t.Fatal("PriceClass_100 must not bill South American edges")
+	}
+	us := units["data_transfer_us"]
+	if us.Quantity == nil || *us.Quantity != 700 {
+		t.Fatalf("us transfer = %v, want 700", us.Quantity)
+	}
+	if us.RateKey.Attributes["priceClass"] != PriceClass100 || us.RateKey.Attributes["usageType"] != "US-DataTransfer-Out-Bytes" {
+		t.Errorf("unexpected rate key %v", us.RateKey.Attributes)
+	}
+	if reqs := units["https_requests_us"]; reqs.Quantity == nil || math.Abs(*reqs.Quantity-70) > 1e-9 {
+		t.Errorf("us https requests = %v, want 70 (10k)", reqs.Quantity)
+	}
+	if _, ok := units["http_requests_us"]; ok {
+		t.Error("no HTTP requests were provided")
+	}
+
+	units = cloudstest.Units(t, NewCloudFrontMapper(), cloudstest.Asset("aws_cloudfront_distribution", nil), usage)
+	if sa := units["data_transfer_south_america"]; sa.Quantity == nil || *sa.Quantity != 100 ||
+		sa.RateKey.Attributes["priceClass"] != PriceClassAll {
+		t.Errorf("PriceClass_All should bill South America directly, got %+v", sa)
+	}
+}
+
+func TestCloudFrontEdgeFeatures(t *testing.T) {
+	attrs := map[string]interface{}{
+		"default_cache_behavior": []interface{}{map[string]interface{}{
+			"field_level_encryption_id": "fle-123",
+			"lambda_function_association": []interface{}{
+				map[string]interface{}{"event_type": "viewer-request"},
+			},
+		}},
+	}
+	units := cloudstest.Units(t, NewCloudFrontMapper(), cloudstest.Asset("aws_cloudfront_distribution", attrs), map[string]interface{}{
+		"data_transfer_gb":                100.0,
+		"monthly_requests":                50000.0,
+		"field_level_encryption_requests": 20000.0,
+		"lambda_edge_requests":            50000.0,
+	})
+
+	if fle := units["field_level_encryption"]; fle.IsSymbolic || fle.Quantity == nil || *fle.Quantity != 2 {
+		t.Errorf("field-level encryption = %+v, want 2 (10k)", fle)
+	}
+	if reqs := units["lambda_edge_requests"]; reqs.IsSymbolic || reqs.RateKey.Service != "AWSLambda" {
+		t.Errorf("Lambda@Edge requests = %+v", reqs)
+	}
+	if dur := units["lambda_edge_duration"]; !dur.IsSymbolic {
+		t.Error("Lambda@Edge duration without usage should be symbolic")
+	}
+	if https := units["https_requests_us"]; https.Quantity == nil || *https.Quantity != 5 {
+		t.Errorf("monthly_requests should count as HTTPS, got %+v", https)
+	}
+
+	plain := cloudstest.Units(t, NewCloudFrontMapper(), cloudstest.Asset("aws_cloudfront_distribution", nil), map[string]interface{}{"data_transfer_gb": 100.0, "http_requests": 1.0})
+	if _, ok := plain["lambda_edge_requests"]; ok {
+		t.Error("distribution without associations must not bill Lambda@Edge")
+	}
+}
@@ -0,0 +1,43 @@
+// Package compute - EC2 CPU architecture
+// Graviton (arm64) instances are priced under their own SKUs, usually
+// well below the x86 type of the same size. The instance type fixes the
+// architecture (an AMI must match it), so it is derived from the type.
+package compute
+
+import "strings"
+
+const (
+	// ArchitectureARM64 is AWS Graviton
+	ArchitectureARM64 = "arm64"
+
+	// ArchitectureX86_64 is Intel and AMD
+	ArchitectureX86_64 = "x86_64"
+)
+
+// InstanceArchitecture returns the CPU architecture of an EC2 instance
+// type. Graviton families carry a "g" among the attribute letters after
+// the generation (t4g, m6gd, c7gn, x2gd, im4gn); a1 is first-generation
+// Graviton. Everything else, including unparseable types, is x86_64.
+func InstanceArchitecture(instanceType string) string {
+	family := strings.ToLower(instanceType)
+	if i := strings.IndexByte(family, '.'); i >= 0 {
+		family = family[:i]
+	}
+	if family == "a1" {
+		return ArchitectureARM64
+	}
+
+	// Split "c7gn" into series "c", generation "7" and attributes "gn"
+	i := strings.IndexAny(family, "0123456789")
+	if i <= 0 {
+		return ArchitectureX86_64
+	}
+	j := i
+	for j < len(family) && family[j] >= '0' && family[j] <= '9' {
+		j++
+	}
+	if strings.Contains(family[j:], "g") {
+		return ArchitectureARM64
+	}
+	return ArchitectureX86_64
+}
@@ -0,0 +1,77 @@
+// Package compute - EC2 architecture tests
+package compute
+
+import (
+	"context"
+	"testing"
+
+	"terraform-cost/clouds"
+	"terraform-cost/clouds/aws/pricing"
+	"terraform-cost/core/types"
+)
+
+// TestInstanceArchitecture proves Graviton families are recognized by the
+// g among their attribute letters
+func TestInstanceArchitecture(t *testing.T) {
+	for instanceType, want := range map[string]string{
+		"t4g.micro":      ArchitectureARM64,
+		"m6gd.large":     ArchitectureARM64,
+		"c7gn.xlarge":    ArchitectureARM64,
+		"x2gd.medium":    ArchitectureARM64,
+		"im4gn.large":    ArchitectureARM64,
+		"g5g.xlarge":     ArchitectureARM64,
+		"a1.medium":      ArchitectureARM64,
+		"t3.micro":       ArchitectureX86_64,
+		"t3a.micro":      ArchitectureX86_64,
+		"g4dn.xlarge":    ArchitectureX86_64,
+		"m7i-flex.large": ArchitectureX86_64,
+		"":               ArchitectureX86_64,
+	} {
+		if got := InstanceArchitecture(instanceType); got != want {
+			t.Errorf("InstanceArchitecture(%q) = %q, want %q", instanceType, got, want)
+		}
+	}
+}
+
+// TestGravitonRateKey proves t4g.micro and t3.micro are looked up under
+// different architectures, and the Graviton type prices lower
+func TestGravitonRateKey(t *testing.T) {
+	keys := make(map[string]clouds.RateKey)
+	for _, instanceType := range []string{"t4g.micro", "t3.micro"} {
+		asset := clouds.AssetNode{
+			Address:         "aws_instance.this",
+			Type:            "aws_instance",
+			Attributes:      map[string]interface{}{"instance_type": instanceType},
+			ProviderContext: clouds.ProviderContext{ProviderID: "aws", Region: "us-east-1"},
+			Cardinality:     clouds.Cardinality{IsKnown: true, Count: 1},
+		}
+		m := NewEC2Mapper()
+		usage, err := m.BuildUsage(asset, clouds.UsageContext{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		units, err := m.BuildCostUnits(asset, usage)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[instanceType] = units[0].RateKey
+	}
+	if got := keys["t4g.micro"].Attributes["architecture"]; got != ArchitectureARM64 {
+		t.Errorf("t4g.micro architecture = %q", got)
+	}
+	if got := keys["t3.micro"].Attributes["architecture"]; got != ArchitectureX86_64 {
+		t.Errorf("t3.micro architecture = %q", got)
+	}
+
+	source := pricing.NewAWSPricingSource("us-east-1")
+	rates, err := source.FetchRates(context.Background(), []types.RateKey{
+		{Provider: types.ProviderAWS, Service: "EC2", Region: "us-east-1", Attributes: map[string]string{"instance_type": "t4g.micro"}},
+		{Provider: types.ProviderAWS, Service: "EC2", Region: "us-east-1", Attributes: map[string]string{"instance_type": "t3.micro"}},
+	})
+	if err != nil || len(rates) != 2 {
+		t.Fatalf("rates = %v, err = %v", rates, err)
+	}
+	if graviton, x86 := rates[0].Price, rates[1].Price; !graviton.LessThan(x86) {
+		t.Errorf("t4g.micro rate %s should be below t3.micro rate %s", graviton, x86)
+	}
+}
@@ -55,7 +55,7 @@ func (m *AutoscalingMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageC
 	}
 
 	// Calculate instance hours (instances * hours/month)
-	monthlyHours := ctx.ResolveOrDefault("monthly_hours", 730)
+	monthlyHours := ctx.MonthlyHours()
 	totalInstanceHours := instanceCount * monthlyHours
 
 	// Confidence is lower for ASG because actual capacity varies
@@ -93,22 +93,23 @@ func (m *AutoscalingMapper) BuildCostUnits(asset clouds.AssetNode, usage []cloud
 	// Infer OS from launch template/config
 	os := "Linux" // Default
 
+	rateKey, err := clouds.SchemaRateKey(asset, m.ResourceType(), "AmazonEC2", "instances", map[string]string{
+		"instanceType":    instanceType,
+		"operatingSystem": os,
+		"tenancy":         "default",
+		"capacityStatus":  "Used",
+		"architecture":    InstanceArchitecture(instanceType),
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return []clouds.CostUnit{
 		clouds.NewCostUnit(
 			"instances",
 			"instance-hours",
 			totalHours,
-			clouds.RateKey{
-				Provider: asset.ProviderContext.ProviderID,
-				Service:  "AmazonEC2",
-				Region:   asset.ProviderContext.Region,
-				Attributes: map[string]string{
-					"instanceType":    instanceType,
-					"operatingSystem": os,
-					"tenancy":         "default",
-					"capacityStatus":  "Used",
-				},
-			},
+			rateKey,
 			0.7, // Lower confidence for ASG
 		),
 	}, nil
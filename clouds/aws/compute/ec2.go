@@ -1,6 +1,6 @@
 // Package compute - AWS EC2 cost mapper
 // Clean-room implementation based on EC2 pricing model:
-// - Instance hours (by instance type, region, OS, tenancy)
+// - Instance hours (by instance type, architecture, region, OS, tenancy)
 // - EBS storage for root/additional volumes
 // - Data transfer
 // - EBS-optimized surcharge (some instance types)
@@ -37,8 +37,8 @@ func (m *EC2Mapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext)
 		}, nil
 	}
 
-	// Default: 730 hours/month (24*365/12)
-	monthlyHours := ctx.ResolveOrDefault("monthly_hours", 730)
+	// Default: the whole billing month
+	monthlyHours := ctx.MonthlyHours()
 
 	return []clouds.UsageVector{
 		clouds.NewUsageVector(clouds.MetricMonthlyHours, monthlyHours, ctx.Confidence),
@@ -76,16 +76,15 @@ func (m *EC2Mapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageV
 	monthlyHours, _ := usageVecs.Get(clouds.MetricMonthlyHours)
 
 	// Build rate key
-	rateKey := clouds.RateKey{
-		Provider: asset.ProviderContext.ProviderID,
-		Service:  "AmazonEC2",
-		Region:   asset.ProviderContext.Region,
-		Attributes: map[string]string{
-			"instanceType": instanceType,
-			"operatingSystem": os,
-			"tenancy":      tenancy,
-			"capacityStatus": "Used",
-		},
+	rateKey, err := clouds.SchemaRateKey(asset, m.ResourceType(), "AmazonEC2", "compute", map[string]string{
+		"instanceType":    instanceType,
+		"operatingSystem": os,
+		"tenancy":         tenancy,
+		"capacityStatus":  "Used",
+		"architecture":    InstanceArchitecture(instanceType),
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	units := []clouds.CostUnit{
@@ -17,7 +17,7 @@ func (m *EC2HostMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageConte
 	if asset.Cardinality.IsUnknown() {
 		return []clouds.UsageVector{clouds.SymbolicUsage(clouds.MetricMonthlyHours, "unknown dedicated host count")}, nil
 	}
-	return []clouds.UsageVector{clouds.NewUsageVector(clouds.MetricMonthlyHours, ctx.ResolveOrDefault("monthly_hours", 730), 0.95)}, nil
+	return []clouds.UsageVector{clouds.NewUsageVector(clouds.MetricMonthlyHours, ctx.MonthlyHours(), 0.95)}, nil
 }
 
 func (m *EC2HostMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVector) ([]clouds.CostUnit, error) {
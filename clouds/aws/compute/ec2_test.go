@@ -0,0 +1,76 @@
+package compute
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"terraform-cost/clouds"
+	"terraform-cost/clouds/cloudstest"
+	"terraform-cost/core/catalog"
+)
+
+// TestEC2RateKeyFollowsSchema proves the EC2 compute key carries exactly
+// the catalog's price-relevant attributes
+func TestEC2RateKeyFollowsSchema(t *testing.T) {
+	asset := clouds.AssetNode{
+		Address: "aws_instance.this",
+		Type:    "aws_instance",
+		Attributes: map[string]interface{}{
+			"instance_type": "m5.large",
+			"ami":           "ami-123",
+			"subnet_id":     "subnet-1",
+		},
+		ProviderContext: clouds.ProviderContext{ProviderID: "aws", Region: "us-east-1"},
+		Cardinality:     clouds.Cardinality{IsKnown: true, Count: 1},
+	}
+	m := NewEC2Mapper()
+	usage, err := m.BuildUsage(asset, clouds.UsageContext{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	units, err := m.BuildCostUnits(asset, usage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for name := range units[0].RateKey.Attributes {
+		got = append(got, name)
+	}
+	sort.Strings(got)
+	want, _ := catalog.RateKeyAttributes("aws_instance", "compute")
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("rate key attributes = %v, want %v", got, want)
+	}
+}
+
+// TestSchemaRateKeyMissingAttribute proves a key missing a price-relevant
+// attribute is refused rather than matched loosely
+func TestSchemaRateKeyMissingAttribute(t *testing.T) {
+	asset := clouds.AssetNode{ProviderContext: clouds.ProviderContext{ProviderID: "aws", Region: "us-east-1"}}
+	_, err := clouds.SchemaRateKey(asset, "aws_instance", "AmazonEC2", "compute", map[string]string{
+		"instanceType": "m5.large",
+		"unrelated":    "x",
+	})
+	if err == nil || !strings.Contains(err.Error(), "operatingSystem") {
+		t.Errorf("err = %v, want missing operatingSystem", err)
+	}
+}
+
+// TestEC2BillingMonth proves an instance runs for the configured billing
+// month unless the usage file sets its hours
+func TestEC2BillingMonth(t *testing.T) {
+	asset := cloudstest.Asset("aws_instance", map[string]interface{}{"instance_type": "m5.large"})
+	for _, tc := range []struct {
+		ctx  clouds.UsageContext
+		want float64
+	}{
+		{clouds.UsageContext{}, 730},
+		{clouds.UsageContext{HoursPerMonth: 744}, 744},
+		{clouds.UsageContext{HoursPerMonth: 744, Overrides: map[string]interface{}{"monthly_hours": float64(200)}}, 200},
+	} {
+		units := cloudstest.ContextUnitList(t, NewEC2Mapper(), asset, tc.ctx)
+		cloudstest.Quantity(t, units[0], tc.want)
+	}
+}
@@ -1,10 +1,30 @@
 // Package containers - AWS ECS Service and Task Definition mappers
+// Pricing model:
+// - Fargate tasks: task vCPU-hours + GB-hours x running tasks
+// - Fargate Spot tasks: the same at Spot rates
+// - EC2 launch type: no ECS charge; the cluster's instances carry the cost
 package containers
 
 import (
+	"errors"
+	"strconv"
+	"strings"
+
 	"terraform-cost/clouds"
 )
 
+// Fargate Spot usage metrics
+const (
+	MetricFargateSpotVCPUHours clouds.Metric = "fargate_spot_vcpu_hours"
+	MetricFargateSpotGBHours   clouds.Metric = "fargate_spot_gb_hours"
+)
+
+// Capacity providers that run tasks on Fargate
+const (
+	capacityProviderFargate     = "FARGATE"
+	capacityProviderFargateSpot = "FARGATE_SPOT"
+)
+
 // ECSServiceMapper maps aws_ecs_service to cost units
 type ECSServiceMapper struct{}
 
@@ -13,45 +33,205 @@ func NewECSServiceMapper() *ECSServiceMapper { return &ECSServiceMapper{} }
 func (m *ECSServiceMapper) Cloud() clouds.CloudProvider { return clouds.AWS }
 func (m *ECSServiceMapper) ResourceType() string        { return "aws_ecs_service" }
 
+// BuildUsage extracts Fargate vCPU-hours and GB-hours. Task size comes
+// from the referenced task definition, resolved into task_definition.cpu
+// and task_definition.memory.
 func (m *ECSServiceMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext) ([]clouds.UsageVector, error) {
 	if asset.Cardinality.IsUnknown() {
 		return []clouds.UsageVector{clouds.SymbolicUsage(clouds.MetricMonthlyHours, "unknown service count")}, nil
 	}
-	return []clouds.UsageVector{clouds.NewUsageVector(clouds.MetricMonthlyHours, ctx.ResolveOrDefault("monthly_hours", 730), 0.95)}, nil
+
+	desiredCount, ok := desiredTaskCount(asset)
+	if !ok {
+		return []clouds.UsageVector{
+			clouds.SymbolicUsage(MetricFargateVCPUHours, "task count unknown: desired_count is not a literal"),
+		}, nil
+	}
+	tasks := splitTasks(asset, desiredCount)
+	if tasks.fargate == 0 && tasks.spot == 0 {
+		// EC2 launch type only
+		return []clouds.UsageVector{clouds.NewUsageVector(clouds.MetricMonthlyHours, 0, 1.0)}, nil
+	}
+
+	vcpu, memoryGB, err := fargateTaskSize(asset)
+	if err != nil {
+		return []clouds.UsageVector{clouds.SymbolicUsage(MetricFargateVCPUHours, err.Error())}, nil
+	}
+
+	monthlyHours := ctx.MonthlyHours()
+	return []clouds.UsageVector{
+		clouds.NewUsageVector(MetricFargateVCPUHours, tasks.fargate*vcpu*monthlyHours, 0.95),
+		clouds.NewUsageVector(MetricFargateGBHours, tasks.fargate*memoryGB*monthlyHours, 0.95),
+		clouds.NewUsageVector(MetricFargateSpotVCPUHours, tasks.spot*vcpu*monthlyHours, 0.8),
+		clouds.NewUsageVector(MetricFargateSpotGBHours, tasks.spot*memoryGB*monthlyHours, 0.8),
+	}, nil
 }
 
 func (m *ECSServiceMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVector) ([]clouds.CostUnit, error) {
 	usageVecs := clouds.UsageVectors(usage)
 	if usageVecs.IsSymbolic() {
-		return []clouds.CostUnit{clouds.SymbolicCost("ecs_service", "service count unknown")}, nil
+		return []clouds.CostUnit{clouds.SymbolicCost("fargate_tasks", "ECS service cost unknown: "+usageVecs.SymbolicReason(""))}, nil
+	}
+
+	cpuType := "x86"
+	if strings.EqualFold(asset.Attr("task_definition.runtime_platform.0.cpu_architecture"), "ARM64") {
+		cpuType = "ARM"
 	}
 
-	launchType := asset.Attr("launch_type")
-	if launchType == "" {
-		launchType = "EC2"
+	// EC2 launch type tasks have no units here: they run on the cluster's
+	// container instances, which carry the cost
+	units := []clouds.CostUnit{}
+	for _, part := range []struct {
+		component string
+		metric    clouds.Metric
+		measure   string
+		attrs     map[string]string
+	}{
+		{"fargate_vcpu", MetricFargateVCPUHours, "vCPU-hours", map[string]string{"usageType": "Fargate-vCPU-Hours", "cpuType": cpuType}},
+		{"fargate_memory", MetricFargateGBHours, "GB-hours", map[string]string{"usageType": "Fargate-GB-Hours"}},
+		{"fargate_spot_vcpu", MetricFargateSpotVCPUHours, "vCPU-hours", map[string]string{"usageType": "Fargate-SpotUsage-vCPU-Hours"}},
+		{"fargate_spot_memory", MetricFargateSpotGBHours, "GB-hours", map[string]string{"usageType": "Fargate-SpotUsage-GB-Hours"}},
+	} {
+		quantity, _ := usageVecs.Get(part.metric)
+		if quantity == 0 {
+			continue
+		}
+		rateKey, err := clouds.SchemaRateKey(asset, m.ResourceType(), "AmazonECS", part.component, part.attrs)
+		if err != nil {
+			return nil, err
+		}
+		confidence := 0.95
+		if strings.HasPrefix(part.component, "fargate_spot") {
+			confidence = 0.8 // Spot tasks can be interrupted and replaced
+		}
+		units = append(units, clouds.NewCostUnit(part.component, part.measure, quantity, rateKey, confidence))
 	}
+	return units, nil
+}
 
-	desiredCount := asset.AttrInt("desired_count", 1)
-	monthlyHours, _ := usageVecs.Get(clouds.MetricMonthlyHours)
+// taskSplit is a service's running tasks by where they run. Counts are
+// fractional when a capacity provider strategy weights them.
+type taskSplit struct {
+	fargate float64
+	spot    float64
+	ec2     float64
+}
 
-	// For Fargate, cost is vCPU + memory hours
-	if launchType == "FARGATE" {
-		return []clouds.CostUnit{
-			clouds.SymbolicCost("fargate_tasks", "Fargate cost depends on task definition CPU/memory"),
-		}, nil
+// splitTasks divides desired tasks across the capacity provider strategy:
+// each provider's base first, then the rest by weight. Without a strategy
+// every task uses the launch type (EC2 by default).
+func splitTasks(asset clouds.AssetNode, desired int) taskSplit {
+	type provider struct {
+		name   string
+		base   int
+		weight int
+	}
+	var strategy []provider
+	for i := 0; ; i++ {
+		prefix := "capacity_provider_strategy." + strconv.Itoa(i) + "."
+		name := asset.Attr(prefix + "capacity_provider")
+		if name == "" {
+			break
+		}
+		strategy = append(strategy, provider{
+			name:   name,
+			base:   asset.AttrInt(prefix+"base", 0),
+			weight: asset.AttrInt(prefix+"weight", 0),
+		})
 	}
 
-	// For EC2 launch type, tasks run on EC2 instances (no additional service cost)
-	return []clouds.CostUnit{
-		clouds.NewCostUnit("service_tasks", "task-hours", float64(desiredCount)*monthlyHours, clouds.RateKey{
-			Provider: asset.ProviderContext.ProviderID,
-			Service:  "AmazonECS",
-			Region:   asset.ProviderContext.Region,
-			Attributes: map[string]string{
-				"launchType": launchType,
-			},
-		}, 0.7),
-	}, nil
+	var split taskSplit
+	add := func(name string, n float64) {
+		switch name {
+		case capacityProviderFargate:
+			split.fargate += n
+		case capacityProviderFargateSpot:
+			split.spot += n
+		default:
+			split.ec2 += n
+		}
+	}
+
+	if len(strategy) == 0 {
+		launchType := asset.Attr("launch_type")
+		if launchType == "" {
+			launchType = "EC2"
+		}
+		add(launchType, float64(desired))
+		return split
+	}
+
+	remaining := desired
+	totalWeight := 0
+	for _, p := range strategy {
+		base := min(p.base, remaining)
+		add(p.name, float64(base))
+		remaining -= base
+		totalWeight += p.weight
+	}
+	if remaining == 0 {
+		return split
+	}
+	if totalWeight == 0 {
+		add(strategy[0].name, float64(remaining))
+		return split
+	}
+	for _, p := range strategy {
+		add(p.name, float64(remaining)*float64(p.weight)/float64(totalWeight))
+	}
+	return split
+}
+
+// desiredTaskCount reads desired_count. A value that is present but not a
+// number (e.g. an unresolved variable) is unknown.
+func desiredTaskCount(asset clouds.AssetNode) (int, bool) {
+	v, ok := asset.Attributes["desired_count"]
+	if !ok {
+		return 1, true
+	}
+	switch v.(type) {
+	case int, float64:
+		return asset.AttrInt("desired_count", 1), true
+	}
+	return 0, false
+}
+
+// fargateTaskSize returns a Fargate task's vCPUs and memory in GB from
+// the resolved task definition
+func fargateTaskSize(asset clouds.AssetNode) (vcpu, memoryGB float64, err error) {
+	cpuUnits, ok := taskSizeAttr(asset, "task_definition.cpu", "vcpu")
+	if !ok {
+		return 0, 0, errors.New("Fargate task size unknown: task definition cpu is not resolved")
+	}
+	memoryMiB, ok := taskSizeAttr(asset, "task_definition.memory", "gb")
+	if !ok {
+		return 0, 0, errors.New("Fargate task size unknown: task definition memory is not resolved")
+	}
+	return cpuUnits / 1024, memoryMiB / 1024, nil
+}
+
+// taskSizeAttr reads a task definition cpu or memory value. Terraform
+// accepts CPU units or MiB ("256", 512) or a unit suffix ("1 vCPU",
+// "2 GB"); suffixed values are scaled by 1024.
+func taskSizeAttr(asset clouds.AssetNode, key, suffix string) (float64, bool) {
+	switch v := asset.Attributes[key].(type) {
+	case int:
+		return float64(v), v > 0
+	case float64:
+		return v, v > 0
+	case string:
+		s := strings.TrimSpace(strings.ToLower(v))
+		scale := 1.0
+		if trimmed, ok := strings.CutSuffix(s, suffix); ok {
+			s, scale = strings.TrimSpace(trimmed), 1024
+		}
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil || n <= 0 {
+			return 0, false
+		}
+		return n * scale, true
+	}
+	return 0, false
 }
 
 // ECSTaskDefinitionMapper maps aws_ecs_task_definition to cost units
@@ -67,35 +247,8 @@ func (m *ECSTaskDefinitionMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.
 	return []clouds.UsageVector{clouds.NewUsageVector("task_definitions", 1, 1.0)}, nil
 }
 
+// BuildCostUnits returns no units: a task definition is free, and its
+// tasks are priced on the services that run them
 func (m *ECSTaskDefinitionMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVector) ([]clouds.CostUnit, error) {
-	requiresCompatibilities := asset.Attr("requires_compatibilities.0")
-
-	if requiresCompatibilities == "FARGATE" {
-		cpu := asset.AttrFloat("cpu", 256)
-		memory := asset.AttrFloat("memory", 512)
-
-		// Convert to vCPU (256 CPU units = 0.25 vCPU)
-		vcpu := cpu / 1024
-		memoryGB := memory / 1024
-
-		return []clouds.CostUnit{
-			clouds.SymbolicCost("fargate", 
-				"Fargate task definition: "+
-				"vCPU="+formatFloat(vcpu)+
-				", Memory="+formatFloat(memoryGB)+"GB. "+
-				"Cost depends on number of running tasks"),
-		}, nil
-	}
-
-	// EC2 launch type - no additional cost from task definition
-	return []clouds.CostUnit{
-		clouds.SymbolicCost("ec2_task", "Task runs on EC2 instances, no additional ECS cost"),
-	}, nil
-}
-
-func formatFloat(f float64) string {
-	if f == float64(int(f)) {
-		return string(rune('0' + int(f)))
-	}
-	return "~"
+	return []clouds.CostUnit{}, nil
 }
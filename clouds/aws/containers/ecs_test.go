@@ -0,0 +1,69 @@
+package containers
+
+import (
+	"testing"
+
+	"terraform-cost/clouds/cloudstest"
+)
+
+func TestECSServiceFargate(t *testing.T) {
+	units := cloudstest.Units(t, NewECSServiceMapper(), cloudstest.Asset("aws_ecs_service", map[string]interface{}{
+		"launch_type":            "FARGATE",
+		"desired_count":          3,
+		"task_definition.cpu":    "512",
+		"task_definition.memory": "1 GB",
+	}), nil)
+	if got := *units["fargate_vcpu"].Quantity; got != 3*0.5*730 {
+		t.Errorf("vCPU-hours = %g, want %g", got, 3*0.5*730)
+	}
+	if got := *units["fargate_memory"].Quantity; got != 3*1*730 {
+		t.Errorf("GB-hours = %g, want %g", got, 3.0*730)
+	}
+	if _, ok := units["fargate_spot_vcpu"]; ok {
+		t.Error("unexpected Fargate Spot units without a Spot strategy")
+	}
+}
+
+// TestECSServiceFargateSpot proves base tasks are placed first and the
+// rest split by weight
+func TestECSServiceFargateSpot(t *testing.T) {
+	units := cloudstest.Units(t, NewECSServiceMapper(), cloudstest.Asset("aws_ecs_service", map[string]interface{}{
+		"desired_count": 5,
+		"capacity_provider_strategy.0.capacity_provider": "FARGATE",
+		"capacity_provider_strategy.0.base":              1,
+		"capacity_provider_strategy.0.weight":            1,
+		"capacity_provider_strategy.1.capacity_provider": "FARGATE_SPOT",
+		"capacity_provider_strategy.1.weight":            3,
+		"task_definition.cpu":                            1024,
+		"task_definition.memory":                         2048,
+	}), nil)
+	// 1 base + 1 of the 4 remaining on demand, 3 on Spot
+	if got := *units["fargate_vcpu"].Quantity; got != 2*730 {
+		t.Errorf("on-demand vCPU-hours = %g, want %d", got, 2*730)
+	}
+	if got := *units["fargate_spot_memory"].Quantity; got != 3*2*730 {
+		t.Errorf("Spot GB-hours = %g, want %d", got, 3*2*730)
+	}
+	if got := units["fargate_spot_vcpu"].RateKey.Attributes["usageType"]; got != "Fargate-SpotUsage-vCPU-Hours" {
+		t.Errorf("Spot usage type = %q", got)
+	}
+}
+
+func TestECSServiceEC2LaunchType(t *testing.T) {
+	units := cloudstest.Units(t, NewECSServiceMapper(), cloudstest.Asset("aws_ecs_service", map[string]interface{}{"launch_type": "EC2", "desired_count": 4}), nil)
+	if len(units) != 0 {
+		t.Errorf("EC2 launch type tasks should be priced on the cluster's instances, got %v", units)
+	}
+}
+
+func TestECSServiceUnknownDesiredCount(t *testing.T) {
+	units := cloudstest.Units(t, NewECSServiceMapper(), cloudstest.Asset("aws_ecs_service", map[string]interface{}{
+		"launch_type":            "FARGATE",
+		"desired_count":          "${var.count}",
+		"task_definition.cpu":    "256",
+		"task_definition.memory": "512",
+	}), nil)
+	if u, ok := units["fargate_tasks"]; !ok || !u.IsSymbolic {
+		t.Errorf("expected a symbolic cost, got %v", units)
+	}
+}
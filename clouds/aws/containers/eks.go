@@ -1,8 +1,8 @@
 // Package containers - AWS EKS cost mapper
 // Pricing model:
 // - Control plane: $0.10/hour per cluster
-// - Nodes: EC2 instance costs (tracked separately)
-// - Fargate: vCPU-hour + GB-hour
+// - Node groups: EC2 instance-hours x node count, plus node EBS volumes
+// - Fargate profiles: vCPU-hour + GB-hour for scheduled pods
 package containers
 
 import (
@@ -35,7 +35,7 @@ func (m *EKSClusterMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageCo
 		}, nil
 	}
 
-	monthlyHours := ctx.ResolveOrDefault("monthly_hours", 730)
+	monthlyHours := ctx.MonthlyHours()
 
 	return []clouds.UsageVector{
 		clouds.NewUsageVector(clouds.MetricMonthlyHours, monthlyHours, 0.95),
@@ -102,27 +102,27 @@ func (m *EKSNodeGroupMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.Usage
 		}, nil
 	}
 
-	// Get scaling config
-	desiredSize := asset.AttrInt("scaling_config.0.desired_size", 2)
-	minSize := asset.AttrInt("scaling_config.0.min_size", 1)
-
-	// Use desired or min for estimation
-	nodeCount := float64(desiredSize)
-	if nodeCount == 0 {
-		nodeCount = float64(minSize)
+	// Node count comes from scaling_config; without it we cannot price nodes
+	nodeCount, ok := nodeGroupSize(asset)
+	if !ok {
+		return []clouds.UsageVector{
+			clouds.SymbolicUsage(clouds.MetricMonthlyHours, "node count unknown: scaling_config.desired_size is not a literal"),
+		}, nil
 	}
 
-	monthlyHours := ctx.ResolveOrDefault("monthly_hours", 730)
+	monthlyHours := ctx.MonthlyHours()
 
 	// Confidence depends on whether it's fixed or auto-scaling
 	confidence := 0.7
-	maxSize := asset.AttrInt("scaling_config.0.max_size", desiredSize)
+	minSize := asset.AttrInt("scaling_config.0.min_size", nodeCount)
+	maxSize := asset.AttrInt("scaling_config.0.max_size", nodeCount)
 	if minSize == maxSize {
 		confidence = 0.95
 	}
 
 	return []clouds.UsageVector{
-		clouds.NewUsageVector(clouds.MetricMonthlyHours, nodeCount*monthlyHours, confidence),
+		clouds.NewUsageVector(MetricNodeCount, float64(nodeCount), confidence),
+		clouds.NewUsageVector(clouds.MetricMonthlyHours, float64(nodeCount)*monthlyHours, confidence),
 	}, nil
 }
 
@@ -132,7 +132,7 @@ func (m *EKSNodeGroupMapper) BuildCostUnits(asset clouds.AssetNode, usage []clou
 
 	if usageVecs.IsSymbolic() {
 		return []clouds.CostUnit{
-			clouds.SymbolicCost("nodes", "EKS node cost unknown"),
+			clouds.SymbolicCost("nodes", "EKS node cost unknown: node count is not known"),
 		}, nil
 	}
 
@@ -143,11 +143,12 @@ func (m *EKSNodeGroupMapper) BuildCostUnits(asset clouds.AssetNode, usage []clou
 	}
 
 	totalHours, _ := usageVecs.Get(clouds.MetricMonthlyHours)
+	nodeCount, _ := usageVecs.Get(MetricNodeCount)
 
 	providerID := asset.ProviderContext.ProviderID
 	region := asset.ProviderContext.Region
 
-	return []clouds.CostUnit{
+	units := []clouds.CostUnit{
 		clouds.NewCostUnit(
 			"nodes",
 			"instance-hours",
@@ -164,5 +165,129 @@ func (m *EKSNodeGroupMapper) BuildCostUnits(asset clouds.AssetNode, usage []clou
 			},
 			0.7,
 		),
+	}
+
+	// Each node gets an EBS root volume; a launch template owns disk config instead
+	if asset.Attr("launch_template.0.id") == "" && asset.Attr("launch_template.0.name") == "" {
+		diskSize := asset.AttrFloat("disk_size", 20)
+		units = append(units, clouds.NewCostUnit(
+			"node_storage",
+			"GB-months",
+			diskSize*nodeCount,
+			clouds.RateKey{
+				Provider: providerID,
+				Service:  "AmazonEC2",
+				Region:   region,
+				Attributes: map[string]string{
+					"volumeType": "gp3",
+					"usageType":  "EBS:VolumeUsage.gp3",
+				},
+			},
+			0.9,
+		))
+	}
+
+	return units, nil
+}
+
+// nodeGroupSize returns the node count from scaling_config.
+// desired_size wins; min_size is the fallback when desired_size is absent.
+func nodeGroupSize(asset clouds.AssetNode) (int, bool) {
+	for _, key := range []string{"scaling_config.0.desired_size", "scaling_config.0.min_size"} {
+		switch v := asset.Attributes[key].(type) {
+		case int:
+			return v, true
+		case float64:
+			return int(v), true
+		}
+	}
+	return 0, false
+}
+
+// MetricNodeCount is the number of nodes in a node group
+const MetricNodeCount clouds.Metric = "node_count"
+
+// Fargate usage metrics
+const (
+	MetricFargateVCPUHours clouds.Metric = "fargate_vcpu_hours"
+	MetricFargateGBHours   clouds.Metric = "fargate_gb_hours"
+)
+
+// EKSFargateProfileMapper maps aws_eks_fargate_profile to cost units.
+// The profile itself is free; pods scheduled onto it are billed per
+// vCPU-hour and GB-hour, which cannot be known from configuration.
+type EKSFargateProfileMapper struct{}
+
+// NewEKSFargateProfileMapper creates an EKS Fargate profile mapper
+func NewEKSFargateProfileMapper() *EKSFargateProfileMapper {
+	return &EKSFargateProfileMapper{}
+}
+
+// Cloud returns the cloud provider
+func (m *EKSFargateProfileMapper) Cloud() clouds.CloudProvider {
+	return clouds.AWS
+}
+
+// ResourceType returns the Terraform resource type
+func (m *EKSFargateProfileMapper) ResourceType() string {
+	return "aws_eks_fargate_profile"
+}
+
+// BuildUsage extracts usage vectors.
+// Pod usage must be supplied as fargate_vcpu_hours and fargate_gb_hours overrides.
+func (m *EKSFargateProfileMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext) ([]clouds.UsageVector, error) {
+	if asset.Cardinality.IsUnknown() {
+		return []clouds.UsageVector{
+			clouds.SymbolicUsage(MetricFargateVCPUHours, "unknown Fargate profile count: "+asset.Cardinality.Reason),
+		}, nil
+	}
+
+	vcpuHours := ctx.ResolveOrDefault(string(MetricFargateVCPUHours), -1)
+	gbHours := ctx.ResolveOrDefault(string(MetricFargateGBHours), -1)
+	if vcpuHours < 0 || gbHours < 0 {
+		return []clouds.UsageVector{
+			clouds.SymbolicUsage(MetricFargateVCPUHours, "Fargate pod usage not provided"),
+		}, nil
+	}
+
+	return []clouds.UsageVector{
+		clouds.NewUsageVector(MetricFargateVCPUHours, vcpuHours, ctx.Confidence),
+		clouds.NewUsageVector(MetricFargateGBHours, gbHours, ctx.Confidence),
+	}, nil
+}
+
+// BuildCostUnits creates cost units for Fargate pods
+func (m *EKSFargateProfileMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVector) ([]clouds.CostUnit, error) {
+	usageVecs := clouds.UsageVectors(usage)
+
+	if usageVecs.IsSymbolic() {
+		return []clouds.CostUnit{
+			clouds.SymbolicCost("fargate_pods", "Fargate cost depends on pod vCPU/memory and runtime"),
+		}, nil
+	}
+
+	vcpuHours, _ := usageVecs.Get(MetricFargateVCPUHours)
+	gbHours, _ := usageVecs.Get(MetricFargateGBHours)
+
+	providerID := asset.ProviderContext.ProviderID
+	region := asset.ProviderContext.Region
+
+	return []clouds.CostUnit{
+		clouds.NewCostUnit("fargate_vcpu", "vCPU-hours", vcpuHours, clouds.RateKey{
+			Provider: providerID,
+			Service:  "AmazonEKS",
+			Region:   region,
+			Attributes: map[string]string{
+				"usageType": "Fargate-vCPU-Hours:perCPU",
+			},
+		}, 0.8),
+		clouds.NewCostUnit("fargate_memory", "GB-hours", gbHours, clouds.RateKey{
+			Provider: providerID,
+			Service:  "AmazonEKS",
+			Region:   region,
+			Attributes: map[string]string{
+				"usageType": "Fargate-GB-Hours",
+			},
+		}, 0.8),
 	}, nil
 }
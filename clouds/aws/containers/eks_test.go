@@ -0,0 +1,74 @@
+package containers
+
+import (
+	"testing"
+
+	"terraform-cost/clouds/cloudstest"
+)
+
+func TestEKSClusterControlPlane(t *testing.T) {
+	units := cloudstest.Units(t, NewEKSClusterMapper(), cloudstest.Asset("aws_eks_cluster", nil), nil)
+	u := units["control_plane"]
+	cloudstest.Quantity(t, u, 730)
+	if got := u.RateKey.Attributes["usageType"]; got != "AmazonEKS-Hours:perkubernetes" {
+		t.Errorf("usage type = %q", got)
+	}
+}
+
+// TestEKSNodeGroup proves nodes are priced as desired_size instances, each
+// with its own root volume
+func TestEKSNodeGroup(t *testing.T) {
+	units := cloudstest.Units(t, NewEKSNodeGroupMapper(), cloudstest.Asset("aws_eks_node_group", map[string]interface{}{
+		"instance_types.0":              "m5.large",
+		"disk_size":                     float64(50),
+		"scaling_config.0.desired_size": 3,
+		"scaling_config.0.min_size":     1,
+		"scaling_config.0.max_size":     5,
+	}), nil)
+	cloudstest.Quantity(t, units["nodes"], 3*730)
+	cloudstest.Quantity(t, units["node_storage"], 3*50)
+	if got := units["nodes"].RateKey.Attributes["instanceType"]; got != "m5.large" {
+		t.Errorf("instance type = %q, want m5.large", got)
+	}
+}
+
+func TestEKSNodeGroupSizing(t *testing.T) {
+	// min_size stands in for an absent desired_size
+	units := cloudstest.Units(t, NewEKSNodeGroupMapper(), cloudstest.Asset("aws_eks_node_group", map[string]interface{}{
+		"scaling_config.0.min_size": float64(2),
+	}), nil)
+	cloudstest.Quantity(t, units["nodes"], 2*730)
+	cloudstest.Quantity(t, units["node_storage"], 2*20)
+
+	// A launch template owns the disk configuration
+	units = cloudstest.Units(t, NewEKSNodeGroupMapper(), cloudstest.Asset("aws_eks_node_group", map[string]interface{}{
+		"scaling_config.0.desired_size": 2,
+		"launch_template.0.id":          "lt-123",
+	}), nil)
+	if _, ok := units["node_storage"]; ok {
+		t.Error("node storage priced for a launch template node group")
+	}
+
+	// An unknown node count cannot be priced
+	units = cloudstest.Units(t, NewEKSNodeGroupMapper(), cloudstest.Asset("aws_eks_node_group", map[string]interface{}{
+		"scaling_config.0.desired_size": "${var.nodes}",
+	}), nil)
+	if u, ok := units["nodes"]; !ok || !u.IsSymbolic {
+		t.Errorf("nodes = %+v, want symbolic", u)
+	}
+}
+
+func TestEKSFargateProfile(t *testing.T) {
+	asset := cloudstest.Asset("aws_eks_fargate_profile", nil)
+	units := cloudstest.Units(t, NewEKSFargateProfileMapper(), asset, nil)
+	if u, ok := units["fargate_pods"]; !ok || !u.IsSymbolic || len(units) != 1 {
+		t.Errorf("units = %v, want symbolic fargate_pods without pod usage", units)
+	}
+
+	units = cloudstest.Units(t, NewEKSFargateProfileMapper(), asset, map[string]interface{}{
+		"fargate_vcpu_hours": float64(1460),
+		"fargate_gb_hours":   float64(2920),
+	})
+	cloudstest.Quantity(t, units["fargate_vcpu"], 1460)
+	cloudstest.Quantity(t, units["fargate_memory"], 2920)
+}
@@ -2,13 +2,15 @@
 // Pricing model:
 // - Aurora Provisioned: instance hours + storage + I/O
 // - Aurora Serverless v1: ACU-hours
-// - Aurora Serverless v2: ACU-hours (more granular)
+// - Aurora Serverless v2: ACU-hours between min and max capacity
 // - Storage: per GB-month (grows automatically)
 // - I/O: per million requests (unless I/O-Optimized)
 // - Backtrack: per million change records
 package database
 
 import (
+	"fmt"
+
 	"terraform-cost/clouds"
 )
 
@@ -30,6 +32,21 @@ func (m *AuroraMapper) ResourceType() string {
 	return "aws_rds_cluster"
 }
 
+// Aurora usage, read from the usage file
+const (
+	// MetricACUHours is Serverless v2 capacity consumed per month
+	MetricACUHours clouds.Metric = "monthly_acu_hours"
+
+	// MetricIORequestsMillions is standard-storage I/O per month
+	MetricIORequestsMillions clouds.Metric = "io_requests_millions"
+)
+
+// Aurora storage types
+const (
+	AuroraStorageStandard    = "aurora"
+	AuroraStorageIOOptimized = "aurora-iopt1"
+)
+
 // BuildUsage extracts usage vectors
 func (m *AuroraMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext) ([]clouds.UsageVector, error) {
 	if asset.Cardinality.IsUnknown() {
@@ -38,18 +55,29 @@ func (m *AuroraMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContex
 		}, nil
 	}
 
-	monthlyHours := ctx.ResolveOrDefault("monthly_hours", 730)
+	monthlyHours := ctx.MonthlyHours()
 	storageGB := ctx.ResolveOrDefault("storage_gb", 10)
-	ioRequestsMillions := ctx.ResolveOrDefault("io_requests_millions", 10)
 
-	return []clouds.UsageVector{
+	usage := []clouds.UsageVector{
 		clouds.NewUsageVector(clouds.MetricMonthlyHours, monthlyHours, 0.95),
 		clouds.NewUsageVector(clouds.MetricStorageGB, storageGB, 0.5),
-		clouds.NewUsageVector("io_requests_millions", ioRequestsMillions, 0.5),
-	}, nil
+	}
+
+	// I/O volume and Serverless v2 capacity have no sensible default;
+	// they are only reported when the usage file provides them
+	if io, ok := ctx.Resolve(string(MetricIORequestsMillions)); ok {
+		usage = append(usage, clouds.NewUsageVector(MetricIORequestsMillions, io, 0.8))
+	}
+	if acuHours, ok := ctx.Resolve(string(MetricACUHours)); ok {
+		usage = append(usage, clouds.NewUsageVector(MetricACUHours, acuHours, 0.8))
+	}
+
+	return usage, nil
 }
 
-// BuildCostUnits creates cost units
+// BuildCostUnits creates cost units. Provisioned compute is not charged on
+// the cluster: each aws_rds_cluster_instance contributes its own instance
+// hours, so the cluster's compute is the sum of its instances.
 func (m *AuroraMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVector) ([]clouds.CostUnit, error) {
 	usageVecs := clouds.UsageVectors(usage)
 
@@ -69,95 +97,71 @@ func (m *AuroraMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.Usa
 		engineMode = "provisioned"
 	}
 
-	providerID := asset.ProviderContext.ProviderID
-	region := asset.ProviderContext.Region
+	rateKey := func(usageType string) clouds.RateKey {
+		return clouds.RateKey{
+			Provider: asset.ProviderContext.ProviderID,
+			Service:  "AmazonRDS",
+			Region:   asset.ProviderContext.Region,
+			Attributes: map[string]string{
+				"databaseEngine": normalizeAuroraEngine(engine),
+				"usageType":      usageType,
+			},
+		}
+	}
 
 	monthlyHours, _ := usageVecs.Get(clouds.MetricMonthlyHours)
 	storageGB, _ := usageVecs.Get(clouds.MetricStorageGB)
-	ioRequests, _ := usageVecs.Get("io_requests_millions")
 
 	var units []clouds.CostUnit
 
 	// Engine mode determines compute pricing
-	switch engineMode {
-	case "serverless":
+	if engineMode == "serverless" {
 		// Aurora Serverless v1
 		minCapacity := asset.AttrFloat("scaling_configuration.0.min_capacity", 2)
 		units = append(units, clouds.NewCostUnit(
 			"serverless_acu",
 			"ACU-hours",
 			minCapacity*monthlyHours,
-			clouds.RateKey{
-				Provider: providerID,
-				Service:  "AmazonRDS",
-				Region:   region,
-				Attributes: map[string]string{
-					"databaseEngine": normalizeAuroraEngine(engine),
-					"usageType":      "Aurora:ServerlessUsage",
-				},
-			},
+			rateKey("Aurora:ServerlessUsage"),
 			0.7, // Lower confidence - actual ACU usage varies
 		))
-
-	case "serverlessv2":
-		// Aurora Serverless v2
-		minCapacity := asset.AttrFloat("serverlessv2_scaling_configuration.0.min_capacity", 0.5)
-		units = append(units, clouds.NewCostUnit(
-			"serverlessv2_acu",
-			"ACU-hours",
-			minCapacity*monthlyHours,
-			clouds.RateKey{
-				Provider: providerID,
-				Service:  "AmazonRDS",
-				Region:   region,
-				Attributes: map[string]string{
-					"databaseEngine": normalizeAuroraEngine(engine),
-					"usageType":      "Aurora:ServerlessV2Usage",
-				},
-			},
-			0.7,
-		))
-
-	default:
-		// Provisioned - instances are separate aws_rds_cluster_instance resources
-		// Cluster itself has no compute cost
+	} else if minACU, maxACU, ok := serverlessV2Capacity(asset); ok {
+		// Aurora Serverless v2 - capacity scales between min and max ACUs,
+		// so without usage only the range is known
+		if acuHours, ok := usageVecs.Get(MetricACUHours); ok {
+			units = append(units, clouds.NewCostUnit(
+				"serverlessv2_acu", "ACU-hours", acuHours, rateKey("Aurora:ServerlessV2Usage"), 0.8))
+		} else {
+			units = append(units, clouds.SymbolicCost("serverlessv2_acu", fmt.Sprintf(
+				"Serverless v2 scales between %g and %g ACUs (%g-%g ACU-hours per instance); set %s",
+				minACU, maxACU, minACU*monthlyHours, maxACU*monthlyHours, MetricACUHours)))
+		}
 	}
 
-	// Storage (always charged)
+	// Storage (always charged); I/O-Optimized trades per-request I/O
+	// charges for a higher storage rate
+	ioOptimized := asset.Attr("storage_type") == AuroraStorageIOOptimized
+	storageUsageType := "Aurora:StorageUsage"
+	if ioOptimized {
+		storageUsageType = "Aurora:IO-OptimizedStorageUsage"
+	}
 	units = append(units, clouds.NewCostUnit(
 		"storage",
 		"GB-months",
 		storageGB,
-		clouds.RateKey{
-			Provider: providerID,
-			Service:  "AmazonRDS",
-			Region:   region,
-			Attributes: map[string]string{
-				"databaseEngine": normalizeAuroraEngine(engine),
-				"usageType":      "Aurora:StorageUsage",
-			},
-		},
+		rateKey(storageUsageType),
 		0.5,
 	))
 
-	// I/O (unless I/O-Optimized storage type)
-	storageType := asset.Attr("storage_type")
-	if storageType != "aurora-iopt1" && ioRequests > 0 {
-		units = append(units, clouds.NewCostUnit(
-			"io_requests",
-			"million-requests",
-			ioRequests,
-			clouds.RateKey{
-				Provider: providerID,
-				Service:  "AmazonRDS",
-				Region:   region,
-				Attributes: map[string]string{
-					"databaseEngine": normalizeAuroraEngine(engine),
-					"usageType":      "Aurora:IOUsage",
-				},
-			},
-			0.5,
-		))
+	// I/O (standard storage only)
+	if !ioOptimized {
+		if ioRequests, ok := usageVecs.Get(MetricIORequestsMillions); ok {
+			units = append(units, clouds.NewCostUnit(
+				"io_requests", "million-requests", ioRequests, rateKey("Aurora:IOUsage"), 0.8))
+		} else {
+			units = append(units, clouds.SymbolicCost("io_requests",
+				"Aurora I/O usage unknown: set "+string(MetricIORequestsMillions)))
+		}
 	}
 
 	// Backtrack (if enabled)
@@ -171,6 +175,38 @@ func (m *AuroraMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.Usa
 	return units, nil
 }
 
+// serverlessV2Capacity reads serverlessv2_scaling_configuration, either as a
+// list of objects (plan JSON) or as flattened ".0.*" keys
+func serverlessV2Capacity(asset clouds.AssetNode) (minACU, maxACU float64, ok bool) {
+	var config clouds.AssetNode
+	if blocks, isList := asset.Attributes["serverlessv2_scaling_configuration"].([]interface{}); isList {
+		if len(blocks) == 0 {
+			return 0, 0, false
+		}
+		block, isMap := blocks[0].(map[string]interface{})
+		if !isMap {
+			return 0, 0, false
+		}
+		config = clouds.AssetNode{Attributes: map[string]interface{}{
+			"min_capacity": block["min_capacity"],
+			"max_capacity": block["max_capacity"],
+		}}
+	} else {
+		const prefix = "serverlessv2_scaling_configuration.0."
+		if _, found := asset.Attributes[prefix+"max_capacity"]; !found {
+			return 0, 0, false
+		}
+		config = clouds.AssetNode{Attributes: map[string]interface{}{
+			"min_capacity": asset.Attributes[prefix+"min_capacity"],
+			"max_capacity": asset.Attributes[prefix+"max_capacity"],
+		}}
+	}
+
+	minACU = config.AttrFloat("min_capacity", 0.5)
+	maxACU = config.AttrFloat("max_capacity", minACU)
+	return minACU, maxACU, true
+}
+
 func normalizeAuroraEngine(engine string) string {
 	switch engine {
 	case "aurora", "aurora-mysql":
@@ -0,0 +1,81 @@
+// Package database - Aurora mapper tests
+package database
+
+import (
+	"strings"
+	"testing"
+
+	"terraform-cost/clouds/cloudstest"
+)
+
+// TestAuroraProvisioned proves compute comes from cluster instances, not
+// the cluster, and I/O is priced from usage on standard storage
+func TestAuroraProvisioned(t *testing.T) {
+	cluster := cloudstest.Asset("aws_rds_cluster", map[string]interface{}{"engine": "aurora-postgresql"})
+
+	units := cloudstest.Units(t, NewAuroraMapper(), cluster, map[string]interface{}{"storage_gb": 200.0})
+	if len(units) != 2 || units["storage"].Quantity == nil || *units["storage"].Quantity != 200 {
+		t.Fatalf("expected storage and I/O only, got %+v", units)
+	}
+	if !units["io_requests"].IsSymbolic {
+		t.Error("I/O without usage should be symbolic")
+	}
+
+	units = cloudstest.Units(t, NewAuroraMapper(), cluster, map[string]interface{}{"io_requests_millions": 25.0})
+	if io := units["io_requests"]; io.IsSymbolic || *io.Quantity != 25 {
+		t.Errorf("I/O from usage = %+v", io)
+	}
+
+	instances := NewRDSClusterInstanceMapper()
+	var instanceHours float64
+	for _, class := range []string{"db.r6g.large", "db.r6g.large"} {
+		units := cloudstest.Units(t, instances, cloudstest.Asset("aws_rds_cluster_instance", map[string]interface{}{
+			"instance_class": class, "engine": "aurora-postgresql",
+		}), nil)
+		instanceHours += *units["instance"].Quantity
+	}
+	if instanceHours != 2*730 {
+		t.Errorf("cluster compute should sum its instances, got %v hours", instanceHours)
+	}
+}
+
+// TestAuroraIOOptimized proves I/O-Optimized storage has no I/O charge
+func TestAuroraIOOptimized(t *testing.T) {
+	units := cloudstest.Units(t, NewAuroraMapper(), cloudstest.Asset("aws_rds_cluster", map[string]interface{}{
+		"storage_type": AuroraStorageIOOptimized,
+	}), map[string]interface{}{"io_requests_millions": 25.0})
+
+	if _, ok := units["io_requests"]; ok {
+		t.Error("I/O-Optimized storage should not charge I/O")
+	}
+	if got := units["storage"].RateKey.Attributes["usageType"]; got != "Aurora:IO-OptimizedStorageUsage" {
+		t.Errorf("storage usage type = %q", got)
+	}
+}
+
+// TestAuroraServerlessV2 proves ACUs are a symbolic range without usage
+func TestAuroraServerlessV2(t *testing.T) {
+	cluster := cloudstest.Asset("aws_rds_cluster", map[string]interface{}{
+		"engine_mode": "provisioned",
+		"serverlessv2_scaling_configuration": []interface{}{
+			map[string]interface{}{"min_capacity": 0.5, "max_capacity": 16.0},
+		},
+	})
+
+	acu := cloudstest.Units(t, NewAuroraMapper(), cluster, nil)["serverlessv2_acu"]
+	if !acu.IsSymbolic || !strings.Contains(acu.SymbolicReason, "between 0.5 and 16 ACUs") {
+		t.Errorf("expected symbolic ACU range, got %+v", acu)
+	}
+
+	acu = cloudstest.Units(t, NewAuroraMapper(), cluster, map[string]interface{}{"monthly_acu_hours": 1460.0})["serverlessv2_acu"]
+	if acu.IsSymbolic || *acu.Quantity != 1460 {
+		t.Errorf("expected ACU-hours from usage, got %+v", acu)
+	}
+
+	instance := cloudstest.Units(t, NewRDSClusterInstanceMapper(), cloudstest.Asset("aws_rds_cluster_instance", map[string]interface{}{
+		"instance_class": AuroraServerlessInstanceClass,
+	}), nil)
+	if len(instance) != 0 {
+		t.Errorf("db.serverless instances are billed on the cluster, got %+v", instance)
+	}
+}
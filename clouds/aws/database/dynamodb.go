@@ -3,13 +3,30 @@
 // - On-demand: Read/Write Request Units
 // - Provisioned: Read/Write Capacity Units
 // - Storage (per GB-month)
+// - Global secondary indexes (own capacity, or replicated writes on-demand)
 // - Global tables (additional per-replicated write)
 // - Backups (per GB-month)
 // - Streams (per 100K read requests)
 package database
 
 import (
+	"fmt"
+	"strconv"
+
 	"terraform-cost/clouds"
+	"terraform-cost/core/determinism"
+)
+
+// DynamoDB billing modes
+const (
+	DynamoDBPayPerRequest = "PAY_PER_REQUEST"
+	DynamoDBProvisioned   = "PROVISIONED"
+)
+
+// On-demand request usage, read from the usage file
+const (
+	MetricReadRequestUnits  clouds.Metric = "monthly_read_request_units"
+	MetricWriteRequestUnits clouds.Metric = "monthly_write_request_units"
 )
 
 // DynamoDBMapper maps aws_dynamodb_table to cost units
@@ -43,9 +60,27 @@ func (m *DynamoDBMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageCont
 	// Storage grows with data
 	storageGB := ctx.ResolveOrDefault("storage_gb", 10)
 
-	return []clouds.UsageVector{
+	usage := []clouds.UsageVector{
 		clouds.NewUsageVector(clouds.MetricStorageGB, storageGB, 0.5),
-	}, nil
+	}
+
+	// Provisioned capacity is reserved for every hour of the month
+	if billingMode(asset) != DynamoDBPayPerRequest {
+		usage = append(usage, clouds.NewUsageVector(clouds.MetricMonthlyHours, ctx.BillingHours(), 0.95))
+	}
+
+	// On-demand request volume has no sensible default; it is only
+	// reported when the usage file provides it
+	if billingMode(asset) == DynamoDBPayPerRequest {
+		if reads, ok := ctx.Resolve(string(MetricReadRequestUnits)); ok {
+			usage = append(usage, clouds.NewUsageVector(MetricReadRequestUnits, reads, 0.8))
+		}
+		if writes, ok := ctx.Resolve(string(MetricWriteRequestUnits)); ok {
+			usage = append(usage, clouds.NewUsageVector(MetricWriteRequestUnits, writes, 0.8))
+		}
+	}
+
+	return usage, nil
 }
 
 // BuildCostUnits creates cost units for a DynamoDB table
@@ -59,61 +94,81 @@ func (m *DynamoDBMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.U
 		}, nil
 	}
 
-	// Determine billing mode
-	billingMode := asset.Attr("billing_mode")
-	if billingMode == "" {
-		billingMode = "PROVISIONED" // Default
+	mode := billingMode(asset)
+	indexes := secondaryIndexes(asset)
+	replicas := asset.AttrInt("replica.#", 0)
+	if list, ok := asset.Attributes["replica"].([]interface{}); ok {
+		replicas = len(list)
 	}
 
-	providerID := asset.ProviderContext.ProviderID
-	region := asset.ProviderContext.Region
+	rateKey := func(usageType string) clouds.RateKey {
+		return clouds.RateKey{
+			Provider: asset.ProviderContext.ProviderID,
+			Service:  "AmazonDynamoDB",
+			Region:   asset.ProviderContext.Region,
+			Attributes: map[string]string{
+				"usageType": usageType,
+			},
+		}
+	}
 
 	var units []clouds.CostUnit
 
-	if billingMode == "PAY_PER_REQUEST" {
-		// On-demand mode - charged per request
-		// Need usage data for accurate estimation
-		units = []clouds.CostUnit{
-			clouds.SymbolicCost("read_requests", "on-demand read usage unknown"),
-			clouds.SymbolicCost("write_requests", "on-demand write usage unknown"),
+	if mode == DynamoDBPayPerRequest {
+		// On-demand mode - charged per request unit. Every write is
+		// repeated on each GSI and each global table replica.
+		reads, hasReads := usageVecs.Get(MetricReadRequestUnits)
+		writes, hasWrites := usageVecs.Get(MetricWriteRequestUnits)
+
+		if hasReads {
+			units = append(units, clouds.NewCostUnit("read_requests", "RRUs", reads, rateKey("ReadRequestUnits"), 0.8))
+		} else {
+			units = append(units, clouds.SymbolicCost("read_requests", "on-demand read usage unknown: set "+string(MetricReadRequestUnits)))
+		}
+
+		if hasWrites {
+			units = append(units, clouds.NewCostUnit("write_requests", "WRUs", writes, rateKey("WriteRequestUnits"), 0.8))
+			for _, idx := range indexes {
+				units = append(units, clouds.NewCostUnit(
+					"gsi_write_requests:"+idx.name, "WRUs", writes, rateKey("WriteRequestUnits"), 0.6))
+			}
+			for i := 0; i < replicas; i++ {
+				units = append(units, clouds.NewCostUnit(
+					"replica_write_requests:"+strconv.Itoa(i), "rWRUs", writes, rateKey("ReplicatedWriteRequestUnits"), 0.7))
+			}
+		} else {
+			units = append(units, clouds.SymbolicCost("write_requests", "on-demand write usage unknown: set "+string(MetricWriteRequestUnits)))
 		}
 	} else {
-		// Provisioned mode
+		// Provisioned mode - table and each GSI reserve their own capacity
+		hours, ok := usageVecs.Get(clouds.MetricMonthlyHours)
+		if !ok {
+			hours = determinism.DefaultHoursPerMonth
+		}
 		readCapacity := asset.AttrFloat("read_capacity", 5)
 		writeCapacity := asset.AttrFloat("write_capacity", 5)
 
-		units = []clouds.CostUnit{
-			// Read Capacity Units
-			clouds.NewCostUnit(
-				"read_capacity",
-				"RCU-hours",
-				readCapacity*730, // RCUs * hours/month
-				clouds.RateKey{
-					Provider: providerID,
-					Service:  "AmazonDynamoDB",
-					Region:   region,
-					Attributes: map[string]string{
-						"usageType": "ReadCapacityUnit-Hrs",
-					},
-				},
-				0.9,
-			),
-
-			// Write Capacity Units
-			clouds.NewCostUnit(
-				"write_capacity",
-				"WCU-hours",
-				writeCapacity*730,
-				clouds.RateKey{
-					Provider: providerID,
-					Service:  "AmazonDynamoDB",
-					Region:   region,
-					Attributes: map[string]string{
-						"usageType": "WriteCapacityUnit-Hrs",
-					},
-				},
-				0.9,
-			),
+		units = append(units,
+			clouds.NewCostUnit("read_capacity", "RCU-hours", readCapacity*hours, rateKey("ReadCapacityUnit-Hrs"), 0.9),
+			clouds.NewCostUnit("write_capacity", "WCU-hours", writeCapacity*hours, rateKey("WriteCapacityUnit-Hrs"), 0.9),
+		)
+
+		for _, idx := range indexes {
+			units = append(units,
+				clouds.NewCostUnit("gsi_read_capacity:"+idx.name, "RCU-hours", idx.readCapacity*hours, rateKey("ReadCapacityUnit-Hrs"), 0.9),
+				clouds.NewCostUnit("gsi_write_capacity:"+idx.name, "WCU-hours", idx.writeCapacity*hours, rateKey("WriteCapacityUnit-Hrs"), 0.9),
+			)
+		}
+
+		// Global tables - each replica incurs additional write costs
+		for i := 0; i < replicas; i++ {
+			units = append(units, clouds.NewCostUnit(
+				"replica_write_capacity:"+strconv.Itoa(i),
+				"rWCU-hours",
+				writeCapacity*hours,
+				rateKey("ReplicatedWriteCapacityUnit-Hrs"), // Would be replica region
+				0.8,
+			))
 		}
 	}
 
@@ -123,43 +178,12 @@ func (m *DynamoDBMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.U
 		"storage",
 		"GB-months",
 		storageGB,
-		clouds.RateKey{
-			Provider: providerID,
-			Service:  "AmazonDynamoDB",
-			Region:   region,
-			Attributes: map[string]string{
-				"usageType": "TimedStorage-ByteHrs",
-			},
-		},
+		rateKey("TimedStorage-ByteHrs"),
 		0.5, // Lower confidence - storage is usage-dependent
 	))
 
-	// Global tables (if replicas exist)
-	if replicas := asset.AttrInt("replica.#", 0); replicas > 0 {
-		// Each replica incurs additional write costs
-		if billingMode != "PAY_PER_REQUEST" {
-			writeCapacity := asset.AttrFloat("write_capacity", 5)
-			for i := 0; i < replicas; i++ {
-				units = append(units, clouds.NewCostUnit(
-					"replica_write_capacity",
-					"rWCU-hours",
-					writeCapacity*730,
-					clouds.RateKey{
-						Provider: providerID,
-						Service:  "AmazonDynamoDB",
-						Region:   region, // Would be replica region
-						Attributes: map[string]string{
-							"usageType": "ReplicatedWriteCapacityUnit-Hrs",
-						},
-					},
-					0.8,
-				))
-			}
-		}
-	}
-
 	// Streams (if enabled)
-	if streamEnabled := asset.Attr("stream_enabled"); streamEnabled == "true" {
+	if asset.Attr("stream_enabled") == "true" || asset.AttrBool("stream_enabled", false) {
 		units = append(units, clouds.SymbolicCost(
 			"streams",
 			"stream read requests depend on consumer patterns",
@@ -168,3 +192,58 @@ func (m *DynamoDBMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.U
 
 	return units, nil
 }
+
+// billingMode returns the table billing mode, defaulting to provisioned
+func billingMode(asset clouds.AssetNode) string {
+	if mode := asset.Attr("billing_mode"); mode != "" {
+		return mode
+	}
+	return DynamoDBProvisioned
+}
+
+// dynamoIndex is a global secondary index and its provisioned capacity
+type dynamoIndex struct {
+	name          string
+	readCapacity  float64
+	writeCapacity float64
+}
+
+// secondaryIndexes reads global_secondary_index blocks, either as a list
+// of objects (plan JSON) or as flattened "global_secondary_index.N.*" keys
+func secondaryIndexes(asset clouds.AssetNode) []dynamoIndex {
+	var indexes []dynamoIndex
+
+	if blocks, ok := asset.Attributes["global_secondary_index"].([]interface{}); ok {
+		for i, b := range blocks {
+			block, ok := b.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			node := clouds.AssetNode{Attributes: block}
+			indexes = append(indexes, dynamoIndex{
+				name:          indexName(node.Attr("name"), i),
+				readCapacity:  node.AttrFloat("read_capacity", 5),
+				writeCapacity: node.AttrFloat("write_capacity", 5),
+			})
+		}
+		return indexes
+	}
+
+	count := asset.AttrInt("global_secondary_index.#", 0)
+	for i := 0; i < count; i++ {
+		prefix := "global_secondary_index." + strconv.Itoa(i) + "."
+		indexes = append(indexes, dynamoIndex{
+			name:          indexName(asset.Attr(prefix+"name"), i),
+			readCapacity:  asset.AttrFloat(prefix+"read_capacity", 5),
+			writeCapacity: asset.AttrFloat(prefix+"write_capacity", 5),
+		})
+	}
+	return indexes
+}
+
+func indexName(name string, i int) string {
+	if name != "" {
+		return name
+	}
+	return fmt.Sprintf("gsi%d", i)
+}
@@ -0,0 +1,129 @@
+// Package database - DynamoDB mapper tests
+package database
+
+import (
+	"testing"
+
+	"terraform-cost/clouds"
+	"terraform-cost/clouds/cloudstest"
+)
+
+// TestDynamoDBProvisioned proves table and GSI capacity are both charged
+func TestDynamoDBProvisioned(t *testing.T) {
+	units := cloudstest.Units(t, NewDynamoDBMapper(), cloudstest.Asset("aws_dynamodb_table", map[string]interface{}{
+		"billing_mode":   "PROVISIONED",
+		"read_capacity":  float64(20),
+		"write_capacity": float64(10),
+		"global_secondary_index": []interface{}{
+			map[string]interface{}{"name": "by_email", "read_capacity": float64(4), "write_capacity": float64(2)},
+		},
+	}), nil)
+
+	want := map[string]float64{
+		"read_capacity":               20 * 730,
+		"write_capacity":              10 * 730,
+		"gsi_read_capacity:by_email":  4 * 730,
+		"gsi_write_capacity:by_email": 2 * 730,
+		"storage":                     10,
+	}
+	for name, qty := range want {
+		u, ok := units[name]
+		if !ok || u.Quantity == nil || *u.Quantity != qty {
+			t.Errorf("%s = %+v, want quantity %v", name, u, qty)
+		}
+	}
+	if _, ok := units["read_requests"]; ok {
+		t.Error("provisioned table should not bill request units")
+	}
+}
+
+// TestDynamoDBProvisionedBillingMonth proves provisioned capacity is
+// reserved for the configured billing month
+func TestDynamoDBProvisionedBillingMonth(t *testing.T) {
+	asset := cloudstest.Asset("aws_dynamodb_table", map[string]interface{}{
+		"billing_mode":   "PROVISIONED",
+		"read_capacity":  float64(20),
+		"write_capacity": float64(10),
+	})
+	units := cloudstest.ContextUnitList(t, NewDynamoDBMapper(), asset, clouds.UsageContext{HoursPerMonth: 672})
+
+	want := map[string]float64{"read_capacity": 20 * 672, "write_capacity": 10 * 672}
+	for _, u := range units {
+		if qty, ok := want[u.Name]; ok {
+			cloudstest.Quantity(t, u, qty)
+			delete(want, u.Name)
+		}
+	}
+	if len(want) > 0 {
+		t.Errorf("units missing: %v", want)
+	}
+}
+
+// TestDynamoDBOnDemand proves request units come from usage and are
+// symbolic without it
+func TestDynamoDBOnDemand(t *testing.T) {
+	asset := cloudstest.Asset("aws_dynamodb_table", map[string]interface{}{
+		"billing_mode":                  "PAY_PER_REQUEST",
+		"global_secondary_index.#":      1,
+		"global_secondary_index.0.name": "by_status",
+	})
+
+	units := cloudstest.Units(t, NewDynamoDBMapper(), asset, nil)
+	for _, name := range []string{"read_requests", "write_requests"} {
+		if !units[name].IsSymbolic {
+			t.Errorf("%s should be symbolic without request usage", name)
+		}
+	}
+	if u := units["storage"]; u.IsSymbolic || u.Quantity == nil {
+		t.Error("storage should still be charged")
+	}
+
+	units = cloudstest.Units(t, NewDynamoDBMapper(), asset, map[string]interface{}{
+		"monthly_read_request_units":  float64(3000000),
+		"monthly_write_request_units": 1000000,
+	})
+	want := map[string]float64{
+		"read_requests":                3000000,
+		"write_requests":               1000000,
+		"gsi_write_requests:by_status": 1000000,
+	}
+	for name, qty := range want {
+		u, ok := units[name]
+		if !ok || u.IsSymbolic || *u.Quantity != qty {
+			t.Errorf("%s = %+v, want quantity %v", name, u, qty)
+		}
+	}
+	if _, ok := units["read_capacity"]; ok {
+		t.Error("on-demand table should not bill provisioned capacity")
+	}
+}
+
+// TestDynamoDBReplicas proves each global table replica is its own unit
+func TestDynamoDBReplicas(t *testing.T) {
+	for _, tc := range []struct {
+		attrs     map[string]interface{}
+		overrides map[string]interface{}
+		unit      string
+		quantity  float64
+	}{
+		{
+			map[string]interface{}{"billing_mode": "PROVISIONED", "write_capacity": float64(10), "replica.#": 2},
+			nil, "replica_write_capacity", 10 * 730,
+		},
+		{
+			map[string]interface{}{"billing_mode": "PAY_PER_REQUEST", "replica.#": 2},
+			map[string]interface{}{"monthly_write_request_units": float64(1000000)}, "replica_write_requests", 1000000,
+		},
+	} {
+		units := cloudstest.Units(t, NewDynamoDBMapper(), cloudstest.Asset("aws_dynamodb_table", tc.attrs), tc.overrides)
+		for _, name := range []string{tc.unit + ":0", tc.unit + ":1"} {
+			u, ok := units[name]
+			if !ok || u.IsSymbolic || *u.Quantity != tc.quantity {
+				t.Errorf("%s = %+v, want quantity %v", name, u, tc.quantity)
+			}
+		}
+		if _, ok := units[tc.unit+":2"]; ok {
+			t.Errorf("%s:2 priced for a table with two replicas", tc.unit)
+		}
+	}
+}
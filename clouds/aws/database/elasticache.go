@@ -6,6 +6,8 @@
 package database
 
 import (
+	"fmt"
+
 	"terraform-cost/clouds"
 )
 
@@ -35,7 +37,7 @@ func (m *ElastiCacheMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageC
 		}, nil
 	}
 
-	monthlyHours := ctx.ResolveOrDefault("monthly_hours", 730)
+	monthlyHours := ctx.MonthlyHours()
 
 	return []clouds.UsageVector{
 		clouds.NewUsageVector(clouds.MetricMonthlyHours, monthlyHours, 0.95),
@@ -65,24 +67,21 @@ func (m *ElastiCacheMapper) BuildCostUnits(asset clouds.AssetNode, usage []cloud
 	numCacheNodes := asset.AttrInt("num_cache_nodes", 1)
 	monthlyHours, _ := usageVecs.Get(clouds.MetricMonthlyHours)
 
-	providerID := asset.ProviderContext.ProviderID
-	region := asset.ProviderContext.Region
+	rateKey, err := clouds.SchemaRateKey(asset, m.ResourceType(), "AmazonElastiCache", "cache_nodes", map[string]string{
+		"nodeType":    nodeType,
+		"cacheEngine": engine,
+		"usageType":   "NodeUsage:" + nodeType,
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	return []clouds.CostUnit{
 		clouds.NewCostUnit(
 			"cache_nodes",
 			"node-hours",
 			float64(numCacheNodes)*monthlyHours,
-			clouds.RateKey{
-				Provider: providerID,
-				Service:  "AmazonElastiCache",
-				Region:   region,
-				Attributes: map[string]string{
-					"nodeType":   nodeType,
-					"cacheEngine": engine,
-					"usageType":  "NodeUsage:" + nodeType,
-				},
-			},
+			rateKey,
 			0.95,
 		),
 	}, nil
@@ -114,7 +113,7 @@ func (m *ElastiCacheReplicationGroupMapper) BuildUsage(asset clouds.AssetNode, c
 		}, nil
 	}
 
-	monthlyHours := ctx.ResolveOrDefault("monthly_hours", 730)
+	monthlyHours := ctx.MonthlyHours()
 
 	return []clouds.UsageVector{
 		clouds.NewUsageVector(clouds.MetricMonthlyHours, monthlyHours, 0.95),
@@ -136,32 +135,115 @@ func (m *ElastiCacheReplicationGroupMapper) BuildCostUnits(asset clouds.AssetNod
 		nodeType = "cache.t3.micro"
 	}
 
-	// Calculate total nodes
-	numNodeGroups := asset.AttrInt("num_node_groups", 1)
-	replicasPerGroup := asset.AttrInt("replicas_per_node_group", 1)
-	totalNodes := numNodeGroups * (replicasPerGroup + 1) // +1 for primary
+	engine := asset.Attr("engine")
+	if engine == "" {
+		engine = "redis"
+	}
 
 	monthlyHours, _ := usageVecs.Get(clouds.MetricMonthlyHours)
 
-	providerID := asset.ProviderContext.ProviderID
-	region := asset.ProviderContext.Region
+	nodes := replicationGroupNodes(asset)
+	if nodes.Unknown != "" {
+		return []clouds.CostUnit{
+			clouds.SymbolicCost("cache_nodes", fmt.Sprintf(
+				"node count depends on unknown %s: %d-%d %s nodes (%g-%g node-hours)",
+				nodes.Unknown, nodes.Min, nodes.Max, nodeType,
+				float64(nodes.Min)*monthlyHours, float64(nodes.Max)*monthlyHours)),
+		}, nil
+	}
+
+	rateKey, err := clouds.SchemaRateKey(asset, m.ResourceType(), "AmazonElastiCache", "cache_nodes", map[string]string{
+		"nodeType":    nodeType,
+		"cacheEngine": engine,
+		"usageType":   "NodeUsage:" + nodeType,
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	return []clouds.CostUnit{
 		clouds.NewCostUnit(
 			"cache_nodes",
 			"node-hours",
-			float64(totalNodes)*monthlyHours,
-			clouds.RateKey{
-				Provider: providerID,
-				Service:  "AmazonElastiCache",
-				Region:   region,
-				Attributes: map[string]string{
-					"nodeType":   nodeType,
-					"cacheEngine": "redis",
-					"usageType":  "NodeUsage:" + nodeType,
-				},
-			},
+			float64(nodes.Min)*monthlyHours,
+			rateKey,
 			0.95,
 		),
 	}, nil
 }
+
+// Replication group limits, used to bound the node count when it is unknown
+const (
+	maxCacheClusters        = 6   // cluster mode disabled: primary + 5 replicas
+	maxNodeGroups           = 500 // cluster mode enabled shards
+	maxReplicasPerNodeGroup = 5
+)
+
+// nodeCount is the number of nodes in a replication group. When a count
+// attribute is unknown, Unknown names it and Min/Max bound the total;
+// otherwise Min == Max.
+type nodeCount struct {
+	Min, Max int
+	Unknown  string
+}
+
+// replicationGroupNodes counts the cache nodes of a replication group.
+// Cluster mode enabled groups have num_node_groups shards of one primary
+// plus replicas_per_node_group replicas; cluster mode disabled groups have
+// num_cache_clusters nodes.
+func replicationGroupNodes(asset clouds.AssetNode) nodeCount {
+	clusterMode := asset.AttrBool("cluster_mode_enabled", false)
+
+	// AWS provider < 5.0 nests the shard settings in a cluster_mode block
+	if blocks, ok := asset.Attributes["cluster_mode"].([]interface{}); ok && len(blocks) > 0 {
+		if block, ok := blocks[0].(map[string]interface{}); ok {
+			asset = clouds.AssetNode{Attributes: block}
+			clusterMode = true
+		}
+	}
+	for _, key := range []string{"num_node_groups", "replicas_per_node_group"} {
+		if _, ok := asset.Attributes[key]; ok {
+			clusterMode = true
+		}
+	}
+
+	if !clusterMode {
+		key := "num_cache_clusters"
+		if _, ok := asset.Attributes[key]; !ok {
+			key = "number_cache_clusters" // AWS provider < 4.0
+		}
+		n, known := countAttr(asset, key, 1)
+		if !known {
+			return nodeCount{Min: 1, Max: maxCacheClusters, Unknown: key}
+		}
+		return nodeCount{Min: n, Max: n}
+	}
+
+	groups, groupsKnown := countAttr(asset, "num_node_groups", 1)
+	replicas, replicasKnown := countAttr(asset, "replicas_per_node_group", 0)
+	switch {
+	case !groupsKnown && !replicasKnown:
+		return nodeCount{Min: 1, Max: maxNodeGroups * (maxReplicasPerNodeGroup + 1),
+			Unknown: "num_node_groups and replicas_per_node_group"}
+	case !groupsKnown:
+		return nodeCount{Min: replicas + 1, Max: maxNodeGroups * (replicas + 1), Unknown: "num_node_groups"}
+	case !replicasKnown:
+		return nodeCount{Min: groups, Max: groups * (maxReplicasPerNodeGroup + 1), Unknown: "replicas_per_node_group"}
+	}
+	n := groups * (replicas + 1) // +1 for each shard's primary
+	return nodeCount{Min: n, Max: n}
+}
+
+// countAttr reads a count attribute. A value that is present but not a
+// number (e.g. an unresolved variable) is unknown; an absent one defaults.
+func countAttr(asset clouds.AssetNode, key string, defaultVal int) (n int, known bool) {
+	v, ok := asset.Attributes[key]
+	if !ok {
+		return defaultVal, true
+	}
+	switch v.(type) {
+	case int, float64:
+		return asset.AttrInt(key, defaultVal), true
+	}
+	return 0, false
+}
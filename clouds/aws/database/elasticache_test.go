@@ -0,0 +1,58 @@
+// Package database - ElastiCache mapper tests
+package database
+
+import (
+	"strings"
+	"testing"
+
+	"terraform-cost/clouds/cloudstest"
+)
+
+// TestReplicationGroupNonClusterMode proves a cluster mode disabled group
+// is priced for num_cache_clusters nodes
+func TestReplicationGroupNonClusterMode(t *testing.T) {
+	units := cloudstest.Units(t, NewElastiCacheReplicationGroupMapper(), cloudstest.Asset("aws_elasticache_replication_group", map[string]interface{}{
+		"node_type":          "cache.r6g.large",
+		"num_cache_clusters": 3.0,
+	}), nil)
+
+	nodes := units["cache_nodes"]
+	if nodes.IsSymbolic || nodes.Quantity == nil || *nodes.Quantity != 3*730 {
+		t.Fatalf("expected 3 nodes x 730 hours, got %+v", nodes)
+	}
+	if got := nodes.RateKey.Attributes["nodeType"]; got != "cache.r6g.large" {
+		t.Errorf("node type = %q", got)
+	}
+}
+
+// TestReplicationGroupClusterMode proves shards and replicas multiply,
+// including the pre-5.0 cluster_mode block
+func TestReplicationGroupClusterMode(t *testing.T) {
+	for name, attrs := range map[string]map[string]interface{}{
+		"top-level": {"num_node_groups": 2.0, "replicas_per_node_group": 2.0},
+		"block": {"cluster_mode": []interface{}{
+			map[string]interface{}{"num_node_groups": 2.0, "replicas_per_node_group": 2.0},
+		}},
+	} {
+		units := cloudstest.Units(t, NewElastiCacheReplicationGroupMapper(), cloudstest.Asset("aws_elasticache_replication_group", attrs), nil)
+		if q := units["cache_nodes"].Quantity; q == nil || *q != 6*730 {
+			t.Errorf("%s: expected 2 shards x 3 nodes, got %+v", name, units["cache_nodes"])
+		}
+	}
+}
+
+// TestReplicationGroupUnknownCount proves an unresolved count is symbolic
+// with the node range in the reason
+func TestReplicationGroupUnknownCount(t *testing.T) {
+	units := cloudstest.Units(t, NewElastiCacheReplicationGroupMapper(), cloudstest.Asset("aws_elasticache_replication_group", map[string]interface{}{
+		"num_cache_clusters": "${var.cache_nodes}",
+	}), nil)
+
+	nodes := units["cache_nodes"]
+	if !nodes.IsSymbolic {
+		t.Fatalf("expected symbolic cost, got %+v", nodes)
+	}
+	if !strings.Contains(nodes.SymbolicReason, "num_cache_clusters: 1-6") {
+		t.Errorf("reason should carry the range, got %q", nodes.SymbolicReason)
+	}
+}
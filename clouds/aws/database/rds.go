@@ -39,7 +39,7 @@ func (m *RDSMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext)
 	}
 
 	// RDS instances run 24/7 by default
-	monthlyHours := ctx.ResolveOrDefault("monthly_hours", 730)
+	monthlyHours := ctx.MonthlyHours()
 
 	return []clouds.UsageVector{
 		clouds.NewUsageVector(clouds.MetricMonthlyHours, monthlyHours, 0.95),
@@ -91,22 +91,22 @@ func (m *RDSMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageV
 		deploymentOption = "Multi-AZ"
 	}
 
+	instanceKey, err := clouds.SchemaRateKey(asset, m.ResourceType(), "AmazonRDS", "instance", map[string]string{
+		"instanceType":     instanceClass,
+		"databaseEngine":   engineFamily,
+		"deploymentOption": deploymentOption,
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	units := []clouds.CostUnit{
 		// Instance
 		clouds.NewCostUnit(
 			"instance",
 			"hours",
 			monthlyHours,
-			clouds.RateKey{
-				Provider: providerID,
-				Service:  "AmazonRDS",
-				Region:   region,
-				Attributes: map[string]string{
-					"instanceType":     instanceClass,
-					"databaseEngine":   engineFamily,
-					"deploymentOption": deploymentOption,
-				},
-			},
+			instanceKey,
 			0.95,
 		),
 
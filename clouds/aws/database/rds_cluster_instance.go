@@ -1,10 +1,15 @@
 // Package database - AWS RDS Cluster Instance mapper
+// Cluster instances carry Aurora's provisioned compute; the cluster itself
+// only prices storage, I/O and Serverless capacity.
 package database
 
 import (
 	"terraform-cost/clouds"
 )
 
+// AuroraServerlessInstanceClass is the instance class of Serverless v2 instances
+const AuroraServerlessInstanceClass = "db.serverless"
+
 // RDSClusterInstanceMapper maps aws_rds_cluster_instance to cost units
 type RDSClusterInstanceMapper struct{}
 
@@ -17,7 +22,7 @@ func (m *RDSClusterInstanceMapper) BuildUsage(asset clouds.AssetNode, ctx clouds
 	if asset.Cardinality.IsUnknown() {
 		return []clouds.UsageVector{clouds.SymbolicUsage(clouds.MetricMonthlyHours, "unknown instance count")}, nil
 	}
-	return []clouds.UsageVector{clouds.NewUsageVector(clouds.MetricMonthlyHours, ctx.ResolveOrDefault("monthly_hours", 730), 0.95)}, nil
+	return []clouds.UsageVector{clouds.NewUsageVector(clouds.MetricMonthlyHours, ctx.MonthlyHours(), 0.95)}, nil
 }
 
 func (m *RDSClusterInstanceMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVector) ([]clouds.CostUnit, error) {
@@ -31,6 +36,11 @@ func (m *RDSClusterInstanceMapper) BuildCostUnits(asset clouds.AssetNode, usage
 		instanceClass = "db.r5.large"
 	}
 
+	// Serverless v2 instances are billed in ACUs on the cluster
+	if instanceClass == AuroraServerlessInstanceClass {
+		return []clouds.CostUnit{}, nil
+	}
+
 	engine := asset.Attr("engine")
 	if engine == "" {
 		engine = "aurora-mysql"
@@ -38,15 +48,15 @@ func (m *RDSClusterInstanceMapper) BuildCostUnits(asset clouds.AssetNode, usage
 
 	monthlyHours, _ := usageVecs.Get(clouds.MetricMonthlyHours)
 
+	rateKey, err := clouds.SchemaRateKey(asset, m.ResourceType(), "AmazonRDS", "instance", map[string]string{
+		"instanceType":   instanceClass,
+		"databaseEngine": normalizeAuroraEngine(engine),
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return []clouds.CostUnit{
-		clouds.NewCostUnit("instance", "hours", monthlyHours, clouds.RateKey{
-			Provider: asset.ProviderContext.ProviderID,
-			Service:  "AmazonRDS",
-			Region:   asset.ProviderContext.Region,
-			Attributes: map[string]string{
-				"instanceType":   instanceClass,
-				"databaseEngine": normalizeAuroraEngine(engine),
-			},
-		}, 0.95),
+		clouds.NewCostUnit("instance", "hours", monthlyHours, rateKey, 0.95),
 	}, nil
 }
@@ -1,17 +1,53 @@
 // Package messaging - AWS SQS/SNS cost mapper
 // SQS Pricing:
-// - Requests: per million requests (Standard vs FIFO)
+// - Requests: per million, in monthly volume tiers (Standard vs FIFO)
 // - Data transfer: outbound
 // SNS Pricing:
-// - Publishes: per million
+// - Publishes: per million (Standard), or per million plus payload GB (FIFO)
 // - Deliveries: per protocol (HTTP, Email, SMS, Lambda)
 // - Data transfer: outbound
+//
+// Both bill every 64 KB chunk of a message as one request, so a 256 KB
+// message is four. Volume comes from the usage file; without it the
+// cost is symbolic rather than $0.
 package messaging
 
 import (
+	"math"
+
 	"terraform-cost/clouds"
 )
 
+// Messaging usage, read from the usage file
+const (
+	// MetricMessageSizeKB is the average message payload
+	MetricMessageSizeKB clouds.Metric = "message_size_kb"
+
+	MetricMonthlyPublishes       clouds.Metric = "monthly_publishes"
+	MetricMonthlyHTTPDeliveries  clouds.Metric = "monthly_http_deliveries"
+	MetricMonthlyEmailDeliveries clouds.Metric = "monthly_email_deliveries"
+)
+
+// requestChunkKB is the payload billed as one request
+const requestChunkKB = 64
+
+// billableRequests counts each started 64 KB chunk of a message as a
+// request
+func billableRequests(requests, messageSizeKB float64) float64 {
+	chunks := math.Ceil(messageSizeKB / requestChunkKB)
+	if chunks < 1 {
+		chunks = 1
+	}
+	return requests * chunks
+}
+
+// sqsRequestTiers are SQS's monthly request volume bands
+var sqsRequestTiers = []clouds.VolumeTier{
+	{Name: "First100B", UpTo: 100e9},
+	{Name: "Next100B", UpTo: 200e9},
+	{Name: "Over200B"},
+}
+
 // SQSMapper maps aws_sqs_queue to cost units
 type SQSMapper struct{}
 
@@ -39,16 +75,19 @@ func (m *SQSMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext)
 	}
 
 	// SQS is HIGHLY usage-dependent
-	monthlyRequests := ctx.ResolveOrDefault("monthly_requests", -1)
-
-	if monthlyRequests < 0 {
+	monthlyRequests, ok := ctx.Resolve(string(clouds.MetricMonthlyRequests))
+	if !ok {
 		return []clouds.UsageVector{
-			clouds.SymbolicUsage(clouds.MetricMonthlyRequests, "monthly SQS requests not provided"),
+			clouds.SymbolicUsage(clouds.MetricMonthlyRequests, "SQS bills per request: set monthly_requests in the usage file"),
 		}, nil
 	}
 
+	// One chunk per message unless the usage file says otherwise
+	messageSizeKB := ctx.ResolveOrDefault(string(MetricMessageSizeKB), requestChunkKB)
+
 	return []clouds.UsageVector{
 		clouds.NewUsageVector(clouds.MetricMonthlyRequests, monthlyRequests, 0.5),
+		clouds.NewUsageVector(MetricMessageSizeKB, messageSizeKB, 0.5),
 	}, nil
 }
 
@@ -58,11 +97,16 @@ func (m *SQSMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageV
 
 	if usageVecs.IsSymbolic() {
 		return []clouds.CostUnit{
-			clouds.SymbolicCost("sqs_requests", "SQS cost requires request volume"),
+			clouds.SymbolicCost("sqs_requests", clouds.UsageVectors(usage).SymbolicReason("SQS cost requires request volume")),
 		}, nil
 	}
 
 	monthlyRequests, _ := usageVecs.Get(clouds.MetricMonthlyRequests)
+	messageSizeKB, ok := usageVecs.Get(MetricMessageSizeKB)
+	if !ok {
+		messageSizeKB = requestChunkKB
+	}
+	requests := billableRequests(monthlyRequests, messageSizeKB)
 
 	// FIFO queues cost more
 	isFIFO := asset.AttrBool("fifo_queue", false)
@@ -74,23 +118,19 @@ func (m *SQSMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageV
 	providerID := asset.ProviderContext.ProviderID
 	region := asset.ProviderContext.Region
 
-	return []clouds.CostUnit{
-		clouds.NewCostUnit(
-			"requests",
-			"million-requests",
-			monthlyRequests/1000000,
-			clouds.RateKey{
-				Provider: providerID,
-				Service:  "AmazonSQS",
-				Region:   region,
-				Attributes: map[string]string{
-					"usageType": "Requests",
-					"queueType": queueType,
-				},
+	rateKey := func(tier string) clouds.RateKey {
+		return clouds.RateKey{
+			Provider: providerID,
+			Service:  "AmazonSQS",
+			Region:   region,
+			Attributes: map[string]string{
+				"usageType": "Requests",
+				"queueType": queueType,
+				"tier":      tier,
 			},
-			0.5,
-		),
-	}, nil
+		}
+	}
+	return clouds.TieredCostUnits("requests", "million-requests", requests, 1000000, sqsRequestTiers, rateKey, 0.5), nil
 }
 
 // SNSMapper maps aws_sns_topic to cost units
@@ -115,22 +155,28 @@ func (m *SNSMapper) ResourceType() string {
 func (m *SNSMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext) ([]clouds.UsageVector, error) {
 	if asset.Cardinality.IsUnknown() {
 		return []clouds.UsageVector{
-			clouds.SymbolicUsage("publishes", "unknown topic count: "+asset.Cardinality.Reason),
+			clouds.SymbolicUsage(MetricMonthlyPublishes, "unknown topic count: "+asset.Cardinality.Reason),
 		}, nil
 	}
 
 	// SNS is HIGHLY usage-dependent
-	monthlyPublishes := ctx.ResolveOrDefault("monthly_publishes", -1)
-
-	if monthlyPublishes < 0 {
+	monthlyPublishes, ok := ctx.Resolve(string(MetricMonthlyPublishes))
+	if !ok {
 		return []clouds.UsageVector{
-			clouds.SymbolicUsage("publishes", "monthly SNS publishes not provided"),
+			clouds.SymbolicUsage(MetricMonthlyPublishes, "SNS bills per publish: set monthly_publishes in the usage file"),
 		}, nil
 	}
 
-	return []clouds.UsageVector{
-		clouds.NewUsageVector("publishes", monthlyPublishes, 0.5),
-	}, nil
+	usage := []clouds.UsageVector{
+		clouds.NewUsageVector(MetricMonthlyPublishes, monthlyPublishes, 0.5),
+		clouds.NewUsageVector(MetricMessageSizeKB, ctx.ResolveOrDefault(string(MetricMessageSizeKB), requestChunkKB), 0.5),
+	}
+	for _, metric := range []clouds.Metric{MetricMonthlyHTTPDeliveries, MetricMonthlyEmailDeliveries} {
+		if deliveries, ok := ctx.Resolve(string(metric)); ok {
+			usage = append(usage, clouds.NewUsageVector(metric, deliveries, 0.5))
+		}
+	}
+	return usage, nil
 }
 
 // BuildCostUnits creates cost units
@@ -139,11 +185,16 @@ func (m *SNSMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageV
 
 	if usageVecs.IsSymbolic() {
 		return []clouds.CostUnit{
-			clouds.SymbolicCost("sns_publishes", "SNS cost requires publish volume"),
+			clouds.SymbolicCost("sns_publishes", clouds.UsageVectors(usage).SymbolicReason("SNS cost requires publish volume")),
 		}, nil
 	}
 
-	monthlyPublishes, _ := usageVecs.Get("publishes")
+	monthlyPublishes, _ := usageVecs.Get(MetricMonthlyPublishes)
+	messageSizeKB, ok := usageVecs.Get(MetricMessageSizeKB)
+	if !ok {
+		messageSizeKB = requestChunkKB
+	}
+	publishes := billableRequests(monthlyPublishes, messageSizeKB)
 
 	// FIFO topics cost more
 	isFIFO := asset.AttrBool("fifo_topic", false)
@@ -154,24 +205,43 @@ func (m *SNSMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageV
 
 	providerID := asset.ProviderContext.ProviderID
 	region := asset.ProviderContext.Region
-
-	return []clouds.CostUnit{
-		clouds.NewCostUnit(
-			"publishes",
-			"million-publishes",
-			monthlyPublishes/1000000,
-			clouds.RateKey{
-				Provider: providerID,
-				Service:  "AmazonSNS",
-				Region:   region,
-				Attributes: map[string]string{
-					"usageType": "PublishAPI-Requests",
-					"topicType": topicType,
-				},
+	rateKey := func(usageType string) clouds.RateKey {
+		return clouds.RateKey{
+			Provider: providerID,
+			Service:  "AmazonSNS",
+			Region:   region,
+			Attributes: map[string]string{
+				"usageType": usageType,
+				"topicType": topicType,
 			},
-			0.5,
-		),
-		// Deliveries depend on subscriber types - symbolic
-		clouds.SymbolicCost("deliveries", "delivery cost depends on subscriber protocols"),
-	}, nil
+		}
+	}
+
+	units := []clouds.CostUnit{
+		clouds.NewCostUnit("publishes", "million-publishes", publishes/1000000, rateKey("PublishAPI-Requests"), 0.5),
+	}
+
+	// FIFO topics also bill the payload published
+	if isFIFO {
+		payloadGB := monthlyPublishes * messageSizeKB / (1024 * 1024)
+		units = append(units, clouds.NewCostUnit("payload", "GB", payloadGB, rateKey("PublishAPI-Payload"), 0.5))
+	}
+
+	// Deliveries are billed by subscriber protocol; SQS and Lambda
+	// deliveries are free
+	httpDeliveries, hasHTTP := usageVecs.Get(MetricMonthlyHTTPDeliveries)
+	emailDeliveries, hasEmail := usageVecs.Get(MetricMonthlyEmailDeliveries)
+	if hasHTTP {
+		units = append(units, clouds.NewCostUnit("http_deliveries", "million-deliveries",
+			billableRequests(httpDeliveries, messageSizeKB)/1000000, rateKey("DeliveryAttempts-HTTP"), 0.5))
+	}
+	if hasEmail {
+		units = append(units, clouds.NewCostUnit("email_deliveries", "100k-deliveries",
+			billableRequests(emailDeliveries, messageSizeKB)/100000, rateKey("DeliveryAttempts-SMTP"), 0.5))
+	}
+	if !hasHTTP && !hasEmail {
+		units = append(units, clouds.SymbolicCost("deliveries",
+			"delivery cost depends on subscriber protocols: set monthly_http_deliveries or monthly_email_deliveries in the usage file"))
+	}
+	return units, nil
 }
@@ -0,0 +1,105 @@
+// Package messaging - SQS/SNS mapper tests
+package messaging
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"terraform-cost/clouds/cloudstest"
+)
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+// TestSQSStandardVsFIFO proves standard and FIFO queues price the same
+// requests at their own rates, counting each 64 KB chunk as a request
+func TestSQSStandardVsFIFO(t *testing.T) {
+	m := NewSQSMapper()
+	usage := map[string]interface{}{"monthly_requests": 5e6, "message_size_kb": 100.0}
+
+	for _, tc := range []struct {
+		attrs     map[string]interface{}
+		queueType string
+	}{
+		{map[string]interface{}{}, "Standard"},
+		{map[string]interface{}{"fifo_queue": true}, "FIFO"},
+	} {
+		units := cloudstest.Units(t, m, cloudstest.Asset("aws_sqs_queue", tc.attrs), usage)
+		if len(units) != 1 {
+			t.Errorf("%s: got units %v, want one request tier", tc.queueType, units)
+		}
+		u := units["requests"]
+		// 100 KB messages are two 64 KB chunks
+		if u.Quantity == nil || !approx(*u.Quantity, 10) {
+			t.Errorf("%s: %v million requests, want 10", tc.queueType, u.Quantity)
+		}
+		if got := u.RateKey.Attributes["queueType"]; got != tc.queueType {
+			t.Errorf("queueType = %q, want %q", got, tc.queueType)
+		}
+		if got := u.RateKey.Attributes["tier"]; got != "First100B" {
+			t.Errorf("%s: tier = %q", tc.queueType, got)
+		}
+	}
+}
+
+// TestSQSRequestTiers proves volume past a tier boundary is priced in the
+// next tier
+func TestSQSRequestTiers(t *testing.T) {
+	units := cloudstest.Units(t, NewSQSMapper(), cloudstest.Asset("aws_sqs_queue", nil), map[string]interface{}{"monthly_requests": 250e9})
+	want := map[string]float64{"requests": 100e3, "requests_Next100B": 100e3, "requests_Over200B": 50e3}
+	if len(units) != len(want) {
+		t.Errorf("got units %v", units)
+	}
+	for name, millions := range want {
+		if u, ok := units[name]; !ok || !approx(*u.Quantity, millions) {
+			t.Errorf("%s = %+v, want %v million", name, u, millions)
+		}
+	}
+}
+
+// TestMessagingWithoutUsage proves missing volume is symbolic with a
+// reason naming the usage key, not $0
+func TestMessagingWithoutUsage(t *testing.T) {
+	sqs := cloudstest.Units(t, NewSQSMapper(), cloudstest.Asset("aws_sqs_queue", nil), nil)
+	if u := sqs["sqs_requests"]; !u.IsSymbolic || !strings.Contains(u.SymbolicReason, "monthly_requests") {
+		t.Errorf("sqs = %+v", sqs)
+	}
+	sns := cloudstest.Units(t, NewSNSMapper(), cloudstest.Asset("aws_sns_topic", nil), nil)
+	if u := sns["sns_publishes"]; !u.IsSymbolic || !strings.Contains(u.SymbolicReason, "monthly_publishes") {
+		t.Errorf("sns = %+v", sns)
+	}
+}
+
+// TestSNSPublishes proves publishes are chunked by payload, FIFO topics
+// also bill payload GB, and deliveries are priced once their volume is
+// given
+func TestSNSPublishes(t *testing.T) {
+	m := NewSNSMapper()
+	units := cloudstest.Units(t, m, cloudstest.Asset("aws_sns_topic", nil), map[string]interface{}{
+		"monthly_publishes": 2e6, "message_size_kb": 200.0,
+	})
+	if u := units["publishes"]; !approx(*u.Quantity, 8) {
+		t.Errorf("publishes = %v million, want 8 (four chunks each)", *u.Quantity)
+	}
+	if u := units["deliveries"]; !u.IsSymbolic {
+		t.Errorf("deliveries without volume = %+v, want symbolic", u)
+	}
+	if _, ok := units["payload"]; ok {
+		t.Error("standard topics do not bill payload")
+	}
+
+	units = cloudstest.Units(t, m, cloudstest.Asset("aws_sns_topic", map[string]interface{}{"fifo_topic": true}), map[string]interface{}{
+		"monthly_publishes": 1048576.0, "message_size_kb": 1.0, "monthly_http_deliveries": 3e6,
+	})
+	if u := units["payload"]; !approx(*u.Quantity, 1) || u.RateKey.Attributes["topicType"] != "FIFO" {
+		t.Errorf("payload = %v GB at %v, want 1 GB FIFO", *u.Quantity, u.RateKey.Attributes)
+	}
+	if u := units["http_deliveries"]; !approx(*u.Quantity, 3) {
+		t.Errorf("http deliveries = %+v, want 3 million", u)
+	}
+	if _, ok := units["deliveries"]; ok {
+		t.Error("deliveries with volume should not be symbolic")
+	}
+}
@@ -5,7 +5,11 @@ import (
 	"terraform-cost/clouds"
 )
 
-// EIPMapper maps aws_eip to cost units
+// EIPMapper maps aws_eip to cost units.
+// An EIP associated with an instance or network interface is free; an idle
+// one is billed per hour. Associations made through aws_eip_association are
+// not visible on the EIP itself, so an EIP without attachment attributes is
+// priced as idle.
 type EIPMapper struct{}
 
 func NewEIPMapper() *EIPMapper { return &EIPMapper{} }
@@ -13,32 +17,74 @@ func NewEIPMapper() *EIPMapper { return &EIPMapper{} }
 func (m *EIPMapper) Cloud() clouds.CloudProvider { return clouds.AWS }
 func (m *EIPMapper) ResourceType() string        { return "aws_eip" }
 
+// MetricIdleHours is the number of hours an EIP sits unassociated
+const MetricIdleHours clouds.Metric = "idle_hours"
+
+// eipAttachmentAttrs are the attributes that associate an EIP
+var eipAttachmentAttrs = []string{"instance", "network_interface", "association_id"}
+
+// eipAttachment reports whether the EIP is associated. known is false when an
+// attachment attribute is set but its value is not resolvable at plan time.
+func eipAttachment(asset clouds.AssetNode) (attached, known bool) {
+	for _, key := range eipAttachmentAttrs {
+		v, ok := asset.Attributes[key]
+		if !ok {
+			continue
+		}
+		switch val := v.(type) {
+		case string:
+			if val != "" {
+				return true, true
+			}
+		default:
+			return false, false
+		}
+	}
+	return false, true
+}
+
 func (m *EIPMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext) ([]clouds.UsageVector, error) {
 	if asset.Cardinality.IsUnknown() {
-		return []clouds.UsageVector{clouds.SymbolicUsage(clouds.MetricMonthlyHours, "unknown EIP count")}, nil
+		return []clouds.UsageVector{clouds.SymbolicUsage(MetricIdleHours, "unknown EIP count")}, nil
 	}
-	return []clouds.UsageVector{clouds.NewUsageVector(clouds.MetricMonthlyHours, 730, 0.95)}, nil
+
+	attached, known := eipAttachment(asset)
+	if !known {
+		return []clouds.UsageVector{clouds.SymbolicUsage(MetricIdleHours, "EIP attachment unknown until apply")}, nil
+	}
+	if attached {
+		return []clouds.UsageVector{clouds.NewUsageVector(MetricIdleHours, 0, 0.95)}, nil
+	}
+	return []clouds.UsageVector{clouds.NewUsageVector(MetricIdleHours, ctx.MonthlyHours(), 0.8)}, nil
 }
 
 func (m *EIPMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVector) ([]clouds.CostUnit, error) {
 	usageVecs := clouds.UsageVectors(usage)
 	if usageVecs.IsSymbolic() {
-		return []clouds.CostUnit{clouds.SymbolicCost("eip", "EIP count unknown")}, nil
+		reason := "EIP idle hours unknown"
+		for _, v := range usageVecs {
+			if v.IsSymbolic {
+				reason = v.SymbolicReason
+			}
+		}
+		return []clouds.CostUnit{clouds.SymbolicCost("idle_address", reason)}, nil
 	}
 
-	// Unattached EIPs cost money, attached ones are free (pre-2024)
-	// Post Feb 2024, all EIPs cost $0.005/hour
-	monthlyHours, _ := usageVecs.Get(clouds.MetricMonthlyHours)
+	idleHours, _ := usageVecs.Get(MetricIdleHours)
+	confidence := 0.8
+	if idleHours == 0 {
+		confidence = 0.95
+	}
 
 	return []clouds.CostUnit{
-		clouds.NewCostUnit("eip", "hours", monthlyHours, clouds.RateKey{
+		clouds.NewCostUnit("idle_address", "hours", idleHours, clouds.RateKey{
 			Provider: asset.ProviderContext.ProviderID,
 			Service:  "AmazonEC2",
 			Region:   asset.ProviderContext.Region,
 			Attributes: map[string]string{
 				"usageType": "ElasticIP:IdleAddress",
 			},
-		}, 0.95),
+		}, confidence),
 	}, nil
 }
 
@@ -55,7 +101,7 @@ func (m *VPCEndpointMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageC
 		return []clouds.UsageVector{clouds.SymbolicUsage(clouds.MetricMonthlyHours, "unknown endpoint count")}, nil
 	}
 	return []clouds.UsageVector{
-		clouds.NewUsageVector(clouds.MetricMonthlyHours, 730, 0.95),
+		clouds.NewUsageVector(clouds.MetricMonthlyHours, ctx.BillingHours(), 0.95),
 		clouds.NewUsageVector("data_processed_gb", ctx.ResolveOrDefault("data_processed_gb", 0), 0.5),
 	}, nil
 }
@@ -118,7 +164,7 @@ func (m *VPNConnectionMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.Usag
 	if asset.Cardinality.IsUnknown() {
 		return []clouds.UsageVector{clouds.SymbolicUsage(clouds.MetricMonthlyHours, "unknown VPN count")}, nil
 	}
-	return []clouds.UsageVector{clouds.NewUsageVector(clouds.MetricMonthlyHours, 730, 0.95)}, nil
+	return []clouds.UsageVector{clouds.NewUsageVector(clouds.MetricMonthlyHours, ctx.BillingHours(), 0.95)}, nil
 }
 
 func (m *VPNConnectionMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVector) ([]clouds.CostUnit, error) {
@@ -153,7 +199,7 @@ func (m *DXConnectionMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.Usage
 	if asset.Cardinality.IsUnknown() {
 		return []clouds.UsageVector{clouds.SymbolicUsage(clouds.MetricMonthlyHours, "unknown DX count")}, nil
 	}
-	return []clouds.UsageVector{clouds.NewUsageVector(clouds.MetricMonthlyHours, 730, 0.95)}, nil
+	return []clouds.UsageVector{clouds.NewUsageVector(clouds.MetricMonthlyHours, ctx.BillingHours(), 0.95)}, nil
 }
 
 func (m *DXConnectionMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVector) ([]clouds.CostUnit, error) {
@@ -194,7 +240,7 @@ func (m *CLBMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext)
 		return []clouds.UsageVector{clouds.SymbolicUsage(clouds.MetricMonthlyHours, "unknown ELB count")}, nil
 	}
 	return []clouds.UsageVector{
-		clouds.NewUsageVector(clouds.MetricMonthlyHours, 730, 0.95),
+		clouds.NewUsageVector(clouds.MetricMonthlyHours, ctx.BillingHours(), 0.95),
 		clouds.NewUsageVector("data_processed_gb", ctx.ResolveOrDefault("data_processed_gb", 0), 0.5),
 	}, nil
 }
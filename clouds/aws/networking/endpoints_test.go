@@ -0,0 +1,39 @@
+// Package networking - EIP mapper tests
+package networking
+
+import (
+	"testing"
+
+	"terraform-cost/clouds"
+	"terraform-cost/clouds/cloudstest"
+)
+
+func buildEIP(t *testing.T, attrs map[string]interface{}) clouds.CostUnit {
+	t.Helper()
+	units := cloudstest.UnitList(t, NewEIPMapper(), cloudstest.Asset("aws_eip", attrs), nil)
+	if len(units) != 1 {
+		t.Fatalf("expected one cost unit, got %d", len(units))
+	}
+	return units[0]
+}
+
+// TestEIPAttachState proves only idle EIPs are charged
+func TestEIPAttachState(t *testing.T) {
+	attached := buildEIP(t, map[string]interface{}{"instance": "i-0123456789abcdef0"})
+	if attached.IsSymbolic || attached.Quantity == nil || *attached.Quantity != 0 {
+		t.Errorf("attached EIP should bill 0 idle hours, got %+v", attached)
+	}
+
+	idle := buildEIP(t, map[string]interface{}{})
+	if idle.IsSymbolic || idle.Quantity == nil || *idle.Quantity != 730 {
+		t.Errorf("unattached EIP should bill 730 idle hours, got %+v", idle)
+	}
+	if idle.RateKey.Attributes["usageType"] != "ElasticIP:IdleAddress" {
+		t.Errorf("unexpected rate key %s", idle.RateKey)
+	}
+
+	unknown := buildEIP(t, map[string]interface{}{"network_interface": nil})
+	if !unknown.IsSymbolic || unknown.SymbolicReason == "" {
+		t.Errorf("unresolved attachment should be symbolic with a reason, got %+v", unknown)
+	}
+}
@@ -1,14 +1,125 @@
 // Package networking - AWS Load Balancer cost mapper
 // Pricing model:
-// - Hourly charge
-// - LCU charge (ALB/NLB) or data processed (Classic)
-// Types: ALB, NLB, GLB, Classic ELB
+// - Hourly charge per load balancer
+// - Capacity units per hour: LCUs (ALB), NLCUs (NLB) or GLCUs (GWLB)
+//
+// A capacity unit covers a fixed amount of each usage dimension; an hour
+// is billed for the dimension that needs the most units. Classic ELBs are
+// priced by CLBMapper.
 package networking
 
 import (
+	"fmt"
+	"math"
+
 	"terraform-cost/clouds"
 )
 
+// Load balancer usage, read from the usage file as monthly averages
+const (
+	// MetricNewConnections is new connections (or flows) per second
+	MetricNewConnections clouds.Metric = "new_connections"
+
+	// MetricActiveConnections is concurrent connections, sampled per minute
+	MetricActiveConnections clouds.Metric = "active_connections"
+
+	// MetricDataProcessedGB is GB processed per month
+	MetricDataProcessedGB clouds.Metric = "data_processed_gb"
+
+	// MetricRuleEvaluations is listener rule evaluations per second beyond
+	// the 10 free rules (ALB only)
+	MetricRuleEvaluations clouds.Metric = "rule_evaluations"
+
+	// MetricCapacityUnitHours is billed LCU, NLCU or GLCU hours
+	MetricCapacityUnitHours clouds.Metric = "capacity_unit_hours"
+)
+
+// lbKind is how one load_balancer_type is billed
+type lbKind struct {
+	productFamily string
+
+	// unit names the capacity unit; usageType prices it
+	unit      string
+	usageType string
+
+	// What one capacity unit covers per dimension; zero when the
+	// dimension is not billed
+	newConnectionsPerSec    float64
+	activeConnectionsPerMin float64
+	processedGBPerHour      float64
+	ruleEvaluationsPerSec   float64
+}
+
+// lbKinds by load_balancer_type
+var lbKinds = map[string]lbKind{
+	"application": {
+		productFamily:           "Load Balancer-Application",
+		unit:                    "LCU",
+		usageType:               "LCUUsage",
+		newConnectionsPerSec:    25,
+		activeConnectionsPerMin: 3000,
+		processedGBPerHour:      1,
+		ruleEvaluationsPerSec:   1000,
+	},
+	"network": {
+		productFamily:           "Load Balancer-Network",
+		unit:                    "NLCU",
+		usageType:               "NLCUUsage",
+		newConnectionsPerSec:    800,
+		activeConnectionsPerMin: 100000,
+		processedGBPerHour:      1,
+	},
+	"gateway": {
+		productFamily:           "Load Balancer-Gateway",
+		unit:                    "GLCU",
+		usageType:               "GLCUUsage",
+		newConnectionsPerSec:    600,
+		activeConnectionsPerMin: 60000,
+		processedGBPerHour:      1,
+	},
+}
+
+// lbKindOf returns the billing of the asset's load_balancer_type, which
+// defaults to application
+func lbKindOf(asset clouds.AssetNode) (string, lbKind, bool) {
+	lbType := asset.Attr("load_balancer_type")
+	if lbType == "" {
+		lbType = "application"
+	}
+	kind, ok := lbKinds[lbType]
+	return lbType, kind, ok
+}
+
+// capacityUnits returns the capacity units used per hour, the most any
+// dimension needs. ok is false when the usage file has none of the
+// dimensions; a dimension it leaves out is taken as zero.
+func (k lbKind) capacityUnits(ctx clouds.UsageContext, monthlyHours float64) (units float64, ok bool) {
+	for _, d := range []struct {
+		metric   clouds.Metric
+		perUnit  float64
+		perMonth bool
+	}{
+		{MetricNewConnections, k.newConnectionsPerSec, false},
+		{MetricActiveConnections, k.activeConnectionsPerMin, false},
+		{MetricDataProcessedGB, k.processedGBPerHour, true},
+		{MetricRuleEvaluations, k.ruleEvaluationsPerSec, false},
+	} {
+		v, found := ctx.Resolve(string(d.metric))
+		if !found || d.perUnit == 0 {
+			continue
+		}
+		ok = true
+		if d.perMonth {
+			if monthlyHours == 0 {
+				continue
+			}
+			v /= monthlyHours
+		}
+		units = math.Max(units, v/d.perUnit)
+	}
+	return units, ok
+}
+
 // LBMapper maps aws_lb to cost units
 type LBMapper struct{}
 
@@ -27,7 +138,8 @@ func (m *LBMapper) ResourceType() string {
 	return "aws_lb"
 }
 
-// BuildUsage extracts usage vectors
+// BuildUsage extracts hours and capacity-unit hours. Without load
+// balancer usage in the usage file the capacity units are symbolic.
 func (m *LBMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext) ([]clouds.UsageVector, error) {
 	if asset.Cardinality.IsUnknown() {
 		return []clouds.UsageVector{
@@ -35,84 +147,55 @@ func (m *LBMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext) (
 		}, nil
 	}
 
-	monthlyHours := ctx.ResolveOrDefault("monthly_hours", 730)
+	lbType, kind, ok := lbKindOf(asset)
+	if !ok {
+		return []clouds.UsageVector{
+			clouds.SymbolicUsage(clouds.MetricMonthlyHours, "unsupported load_balancer_type "+lbType),
+		}, nil
+	}
 
-	// LCU usage is highly variable
-	lcuCount := ctx.ResolveOrDefault("lcu_count", 1)
+	monthlyHours := ctx.MonthlyHours()
+	usage := []clouds.UsageVector{clouds.NewUsageVector(clouds.MetricMonthlyHours, monthlyHours, 0.95)}
 
-	return []clouds.UsageVector{
-		clouds.NewUsageVector(clouds.MetricMonthlyHours, monthlyHours, 0.95),
-		clouds.NewUsageVector("lcu_count", lcuCount, 0.5),
-	}, nil
+	units, ok := kind.capacityUnits(ctx, monthlyHours)
+	if !ok {
+		return append(usage, clouds.SymbolicUsage(MetricCapacityUnitHours, fmt.Sprintf(
+			"%s usage unknown: usage file has no new_connections, active_connections, data_processed_gb or rule_evaluations for %s",
+			kind.unit, asset.Address))), nil
+	}
+	return append(usage, clouds.NewUsageVector(MetricCapacityUnitHours, units*monthlyHours, 0.7)), nil
 }
 
-// BuildCostUnits creates cost units
+// BuildCostUnits prices the hourly fee and, when usage is known, the
+// capacity units
 func (m *LBMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVector) ([]clouds.CostUnit, error) {
 	usageVecs := clouds.UsageVectors(usage)
 
-	if usageVecs.IsSymbolic() {
-		return []clouds.CostUnit{
-			clouds.SymbolicCost("load_balancer", "LB cost unknown due to cardinality"),
-		}, nil
+	monthlyHours, ok := usageVecs.Get(clouds.MetricMonthlyHours)
+	if !ok {
+		return []clouds.CostUnit{clouds.SymbolicCost("load_balancer", usageVecs.Metric(clouds.MetricMonthlyHours).SymbolicReason("load balancer usage unknown"))}, nil
 	}
 
-	lbType := asset.Attr("load_balancer_type")
-	if lbType == "" {
-		lbType = "application"
+	_, kind, _ := lbKindOf(asset)
+	hourlyKey, err := clouds.SchemaRateKey(asset, m.ResourceType(), "ElasticLoadBalancing", "hourly", map[string]string{
+		"productFamily": kind.productFamily,
+		"usageType":     "LoadBalancerUsage",
+	})
+	if err != nil {
+		return nil, err
 	}
+	units := []clouds.CostUnit{clouds.NewCostUnit("hourly", "hours", monthlyHours, hourlyKey, 0.95)}
 
-	monthlyHours, _ := usageVecs.Get(clouds.MetricMonthlyHours)
-	lcuCount, _ := usageVecs.Get("lcu_count")
-
-	providerID := asset.ProviderContext.ProviderID
-	region := asset.ProviderContext.Region
-
-	// Determine rate key based on LB type
-	var usageTypeHourly, usageTypeLCU string
-	switch lbType {
-	case "network":
-		usageTypeHourly = "LoadBalancerUsage"
-		usageTypeLCU = "LCUUsage"
-	case "gateway":
-		usageTypeHourly = "LoadBalancerUsage"
-		usageTypeLCU = "LCUUsage"
-	default: // application
-		usageTypeHourly = "LoadBalancerUsage"
-		usageTypeLCU = "LCUUsage"
+	unitHours, ok := usageVecs.Get(MetricCapacityUnitHours)
+	if !ok {
+		return append(units, clouds.SymbolicCost("lcu", usageVecs.Metric(MetricCapacityUnitHours).SymbolicReason("load balancer usage unknown"))), nil
 	}
-
-	return []clouds.CostUnit{
-		// Hourly charge
-		clouds.NewCostUnit(
-			"hourly",
-			"hours",
-			monthlyHours,
-			clouds.RateKey{
-				Provider: providerID,
-				Service:  "AWSELB",
-				Region:   region,
-				Attributes: map[string]string{
-					"usageType":        usageTypeHourly,
-					"loadBalancerType": lbType,
-				},
-			},
-			0.95,
-		),
-		// LCU charge
-		clouds.NewCostUnit(
-			"lcu",
-			"LCU-hours",
-			lcuCount*monthlyHours,
-			clouds.RateKey{
-				Provider: providerID,
-				Service:  "AWSELB",
-				Region:   region,
-				Attributes: map[string]string{
-					"usageType":        usageTypeLCU,
-					"loadBalancerType": lbType,
-				},
-			},
-			0.5, // LCU is usage-dependent
-		),
-	}, nil
+	lcuKey, err := clouds.SchemaRateKey(asset, m.ResourceType(), "ElasticLoadBalancing", "lcu", map[string]string{
+		"productFamily": kind.productFamily,
+		"usageType":     kind.usageType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(units, clouds.NewCostUnit("lcu", kind.unit+"-hours", unitHours, lcuKey, 0.7)), nil
 }
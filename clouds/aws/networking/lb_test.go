@@ -0,0 +1,81 @@
+// Package networking - Load balancer mapper tests
+package networking
+
+import (
+	"math"
+	"testing"
+
+	"terraform-cost/clouds"
+	"terraform-cost/clouds/cloudstest"
+)
+
+func buildLB(t *testing.T, lbType string, overrides map[string]interface{}) []clouds.CostUnit {
+	t.Helper()
+	attrs := map[string]interface{}{}
+	if lbType != "" {
+		attrs["load_balancer_type"] = lbType
+	}
+	units := cloudstest.UnitList(t, NewLBMapper(), cloudstest.Asset("aws_lb", attrs), overrides)
+	if len(units) != 2 {
+		t.Fatalf("expected hourly and lcu units, got %+v", units)
+	}
+	return units
+}
+
+// TestLBCapacityUnits proves each type bills its own capacity unit by the
+// dimension needing the most units
+func TestLBCapacityUnits(t *testing.T) {
+	tests := []struct {
+		lbType    string
+		usage     map[string]interface{}
+		family    string
+		usageType string
+		measure   string
+		lcuHours  float64
+	}{
+		// 50 new connections/s is 2 LCUs; 730 GB/month is 1 LCU
+		{"", map[string]interface{}{"new_connections": 50, "data_processed_gb": 730.0},
+			"Load Balancer-Application", "LCUUsage", "LCU-hours", 2 * 730},
+		// Rule evaluations dominate: 3000/s is 3 LCUs
+		{"application", map[string]interface{}{"new_connections": 25, "rule_evaluations": 3000},
+			"Load Balancer-Application", "LCUUsage", "LCU-hours", 3 * 730},
+		// 7300 GB/month is 10 GB/hour: 10 NLCUs; rule evaluations are not billed
+		{"network", map[string]interface{}{"data_processed_gb": 7300.0, "new_connections": 800, "rule_evaluations": 1e6},
+			"Load Balancer-Network", "NLCUUsage", "NLCU-hours", 10 * 730},
+		// 120000 active connections is 2 GLCUs
+		{"gateway", map[string]interface{}{"active_connections": 120000},
+			"Load Balancer-Gateway", "GLCUUsage", "GLCU-hours", 2 * 730},
+	}
+	for _, tt := range tests {
+		units := buildLB(t, tt.lbType, tt.usage)
+
+		hourly, lcu := units[0], units[1]
+		if hourly.IsSymbolic || *hourly.Quantity != 730 {
+			t.Errorf("%s: hourly = %+v, want 730 hours", tt.lbType, hourly)
+		}
+		if hourly.RateKey.Service != "ElasticLoadBalancing" || hourly.RateKey.Attributes["productFamily"] != tt.family ||
+			hourly.RateKey.Attributes["usageType"] != "LoadBalancerUsage" {
+			t.Errorf("%s: unexpected hourly rate key %s", tt.lbType, hourly.RateKey)
+		}
+		if lcu.IsSymbolic || math.Abs(*lcu.Quantity-tt.lcuHours) > 1e-9 {
+			t.Errorf("%s: lcu = %+v, want %v %s", tt.lbType, lcu, tt.lcuHours, tt.measure)
+			continue
+		}
+		if lcu.Measure != tt.measure || lcu.RateKey.Attributes["usageType"] != tt.usageType ||
+			lcu.RateKey.Attributes["productFamily"] != tt.family {
+			t.Errorf("%s: unexpected lcu unit %s %s", tt.lbType, lcu.Measure, lcu.RateKey)
+		}
+	}
+}
+
+// TestLBWithoutUsage proves the hourly fee is charged and capacity units
+// are symbolic when the usage file has no load balancer usage
+func TestLBWithoutUsage(t *testing.T) {
+	units := buildLB(t, "network", nil)
+	if units[0].IsSymbolic || *units[0].Quantity != 730 {
+		t.Errorf("hourly fee should be charged, got %+v", units[0])
+	}
+	if !units[1].IsSymbolic || units[1].SymbolicReason == "" {
+		t.Errorf("NLCUs should be symbolic with a reason, got %+v", units[1])
+	}
+}
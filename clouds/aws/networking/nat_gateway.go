@@ -34,7 +34,7 @@ func (m *NATGatewayMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageCo
 		}, nil
 	}
 
-	monthlyHours := ctx.ResolveOrDefault("monthly_hours", 730)
+	monthlyHours := ctx.MonthlyHours()
 	dataProcessedGB := ctx.ResolveOrDefault("data_processed_gb", 100)
 
 	return []clouds.UsageVector{
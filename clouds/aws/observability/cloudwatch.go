@@ -1,16 +1,44 @@
 // Package observability - AWS CloudWatch cost mapper
 // Pricing model:
-// - Log ingestion per GB
+// - Log ingestion per GB (lower for the Infrequent Access class)
 // - Log storage per GB-month
-// - Metrics (custom metrics, high-res)
-// - Alarms per metric
-// - Dashboards
+// - Custom metrics per metric-month (a log metric filter publishes one)
+// - Alarms per alarm metric-month (high-resolution alarms cost more)
+// - Dashboards per dashboard-month
+//
+// Alarms, dashboards and metric filters are flat fees known from the
+// configuration. Log volume is not: ingestion comes from the usage file
+// and is symbolic without it.
 package observability
 
 import (
+	"strings"
+
 	"terraform-cost/clouds"
 )
 
+// CloudWatch usage metrics
+const (
+	// MetricIngestionGB is log data ingested per month
+	MetricIngestionGB clouds.Metric = "monthly_ingestion_gb"
+
+	// MetricAlarmMetrics is the number of metrics an alarm evaluates
+	MetricAlarmMetrics clouds.Metric = "alarm_metrics"
+
+	// MetricCustomMetrics is the number of custom metrics published
+	MetricCustomMetrics clouds.Metric = "custom_metrics"
+)
+
+// rateKey is a CloudWatch rate key in the asset's region
+func rateKey(asset clouds.AssetNode, attrs map[string]string) clouds.RateKey {
+	return clouds.RateKey{
+		Provider:   asset.ProviderContext.ProviderID,
+		Service:    "AmazonCloudWatch",
+		Region:     asset.ProviderContext.Region,
+		Attributes: attrs,
+	}
+}
+
 // CloudWatchLogGroupMapper maps aws_cloudwatch_log_group to cost units
 type CloudWatchLogGroupMapper struct{}
 
@@ -29,73 +57,65 @@ func (m *CloudWatchLogGroupMapper) ResourceType() string {
 	return "aws_cloudwatch_log_group"
 }
 
-// BuildUsage extracts usage vectors
+// BuildUsage extracts usage vectors. Ingestion and storage are reported
+// separately, so a usage file with only one of them prices that one.
 func (m *CloudWatchLogGroupMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext) ([]clouds.UsageVector, error) {
-	// Log groups are HIGHLY usage-dependent
-	// Without explicit usage data, we return symbolic
-	ingestionGB := ctx.ResolveOrDefault("monthly_ingestion_gb", -1)
-	storageGB := ctx.ResolveOrDefault("storage_gb", -1)
-
-	if ingestionGB < 0 || storageGB < 0 {
+	if asset.Cardinality.IsUnknown() {
 		return []clouds.UsageVector{
-			clouds.SymbolicUsage(clouds.MetricStorageGB, "log ingestion/storage usage not provided"),
+			clouds.SymbolicUsage(MetricIngestionGB, "unknown log group count: "+asset.Cardinality.Reason),
 		}, nil
 	}
 
-	return []clouds.UsageVector{
-		clouds.NewUsageVector("ingestion_gb", ingestionGB, 0.5),
-		clouds.NewUsageVector(clouds.MetricStorageGB, storageGB, 0.5),
-	}, nil
+	var usage []clouds.UsageVector
+	if ingestionGB, ok := ctx.Resolve(string(MetricIngestionGB)); ok {
+		usage = append(usage, clouds.NewUsageVector(MetricIngestionGB, ingestionGB, 0.5))
+	}
+	if storageGB, ok := ctx.Resolve(string(clouds.MetricStorageGB)); ok {
+		usage = append(usage, clouds.NewUsageVector(clouds.MetricStorageGB, storageGB, 0.5))
+	}
+	return usage, nil
 }
 
-// BuildCostUnits creates cost units
+// BuildCostUnits creates cost units. Without storage usage, storage is
+// the retained share of a month's ingestion: retention_in_days/30 months
+// of it. A group that never expires its logs grows without bound, so its
+// storage is symbolic.
 func (m *CloudWatchLogGroupMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVector) ([]clouds.CostUnit, error) {
 	usageVecs := clouds.UsageVectors(usage)
 
 	if usageVecs.IsSymbolic() {
 		return []clouds.CostUnit{
-			clouds.SymbolicCost("logs", "CloudWatch Logs cost requires usage data"),
+			clouds.SymbolicCost("logs", "CloudWatch Logs cost unknown due to cardinality"),
 		}, nil
 	}
 
-	ingestionGB, _ := usageVecs.Get("ingestion_gb")
-	storageGB, _ := usageVecs.Get(clouds.MetricStorageGB)
+	ingestionType := "DataProcessing-Bytes"
+	if strings.EqualFold(asset.Attr("log_group_class"), "INFREQUENT_ACCESS") {
+		ingestionType = "DataProcessingIA-Bytes"
+	}
 
-	providerID := asset.ProviderContext.ProviderID
-	region := asset.ProviderContext.Region
+	var units []clouds.CostUnit
+	ingestionGB, hasIngestion := usageVecs.Get(MetricIngestionGB)
+	if hasIngestion {
+		units = append(units, clouds.NewCostUnit("ingestion", "GB", ingestionGB,
+			rateKey(asset, map[string]string{"usageType": ingestionType}), 0.5))
+	} else {
+		units = append(units, clouds.SymbolicCost("ingestion", "log ingestion depends on volume; set monthly_ingestion_gb in the usage file"))
+	}
 
-	return []clouds.CostUnit{
-		// Log ingestion
-		clouds.NewCostUnit(
-			"ingestion",
-			"GB",
-			ingestionGB,
-			clouds.RateKey{
-				Provider: providerID,
-				Service:  "AmazonCloudWatch",
-				Region:   region,
-				Attributes: map[string]string{
-					"usageType": "DataProcessing-Bytes",
-				},
-			},
-			0.5,
-		),
-		// Log storage
-		clouds.NewCostUnit(
-			"storage",
-			"GB-months",
-			storageGB,
-			clouds.RateKey{
-				Provider: providerID,
-				Service:  "AmazonCloudWatch",
-				Region:   region,
-				Attributes: map[string]string{
-					"usageType": "TimedStorage-ByteHrs",
-				},
-			},
-			0.5,
-		),
-	}, nil
+	storageKey := rateKey(asset, map[string]string{"usageType": "TimedStorage-ByteHrs"})
+	retentionDays := asset.AttrFloat("retention_in_days", 0)
+	switch storageGB, ok := usageVecs.Get(clouds.MetricStorageGB); {
+	case ok:
+		units = append(units, clouds.NewCostUnit("storage", "GB-months", storageGB, storageKey, 0.5))
+	case hasIngestion && retentionDays > 0:
+		units = append(units, clouds.NewCostUnit("storage", "GB-months", ingestionGB*retentionDays/30, storageKey, 0.4))
+	case hasIngestion:
+		units = append(units, clouds.SymbolicCost("storage", "logs are never expired, so stored volume grows without bound; set storage_gb in the usage file"))
+	default:
+		units = append(units, clouds.SymbolicCost("storage", "log storage depends on ingestion; set storage_gb or monthly_ingestion_gb in the usage file"))
+	}
+	return units, nil
 }
 
 // CloudWatchMetricAlarmMapper maps aws_cloudwatch_metric_alarm to cost units
@@ -116,17 +136,18 @@ func (m *CloudWatchMetricAlarmMapper) ResourceType() string {
 	return "aws_cloudwatch_metric_alarm"
 }
 
-// BuildUsage extracts usage vectors
+// BuildUsage extracts usage vectors. An alarm is billed per metric it
+// evaluates: one for a single-metric alarm, one per metric of a metric
+// math expression, and three for an anomaly detection band.
 func (m *CloudWatchMetricAlarmMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext) ([]clouds.UsageVector, error) {
 	if asset.Cardinality.IsUnknown() {
 		return []clouds.UsageVector{
-			clouds.SymbolicUsage("alarm_count", "unknown alarm count: "+asset.Cardinality.Reason),
+			clouds.SymbolicUsage(MetricAlarmMetrics, "unknown alarm count: "+asset.Cardinality.Reason),
 		}, nil
 	}
 
-	// One alarm per resource
 	return []clouds.UsageVector{
-		clouds.NewUsageVector("alarm_count", 1, 1.0),
+		clouds.NewUsageVector(MetricAlarmMetrics, float64(alarmMetrics(asset)), 1.0),
 	}, nil
 }
 
@@ -140,31 +161,189 @@ func (m *CloudWatchMetricAlarmMapper) BuildCostUnits(asset clouds.AssetNode, usa
 		}, nil
 	}
 
-	// Determine alarm type (standard vs high-res)
-	period := asset.AttrInt("period", 60)
+	// Alarms evaluating more often than once a minute are high resolution
 	alarmType := "standard"
-	if period < 60 {
+	if period := alarmPeriod(asset); period > 0 && period < 60 {
 		alarmType = "high_resolution"
 	}
 
-	providerID := asset.ProviderContext.ProviderID
-	region := asset.ProviderContext.Region
+	metrics, _ := usageVecs.Get(MetricAlarmMetrics)
+	return []clouds.CostUnit{
+		clouds.NewCostUnit("alarm", "alarm-metrics", metrics,
+			rateKey(asset, map[string]string{
+				"usageType": "AlarmMonitorUsage",
+				"alarmType": alarmType,
+			}), 0.95),
+	}, nil
+}
 
+// metricQueries returns an alarm's metric_query blocks
+func metricQueries(asset clouds.AssetNode) []map[string]interface{} {
+	blocks, _ := asset.Attributes["metric_query"].([]interface{})
+	queries := make([]map[string]interface{}, 0, len(blocks))
+	for _, b := range blocks {
+		if q, ok := b.(map[string]interface{}); ok {
+			queries = append(queries, q)
+		}
+	}
+	return queries
+}
+
+// alarmMetrics counts the metrics an alarm is billed for
+func alarmMetrics(asset clouds.AssetNode) int {
+	queries := metricQueries(asset)
+	if len(queries) == 0 {
+		return 1
+	}
+	metrics := 0
+	for _, q := range queries {
+		if expr, _ := q["expression"].(string); strings.Contains(expr, "ANOMALY_DETECTION_BAND") {
+			return 3
+		}
+		if m, ok := q["metric"].([]interface{}); ok && len(m) > 0 {
+			metrics++
+		}
+	}
+	if metrics == 0 {
+		return 1
+	}
+	return metrics
+}
+
+// alarmPeriod is the alarm's evaluation period in seconds: the period
+// attribute, or the shortest period of its metric queries
+func alarmPeriod(asset clouds.AssetNode) int {
+	period := asset.AttrInt("period", 0)
+	for _, q := range metricQueries(asset) {
+		metric, _ := q["metric"].([]interface{})
+		if len(metric) == 0 {
+			continue
+		}
+		if block, ok := metric[0].(map[string]interface{}); ok {
+			if p := (clouds.AssetNode{Attributes: block}).AttrInt("period", 0); p > 0 && (period == 0 || p < period) {
+				period = p
+			}
+		}
+	}
+	return period
+}
+
+// CloudWatchDashboardMapper maps aws_cloudwatch_dashboard to cost units
+type CloudWatchDashboardMapper struct{}
+
+// NewCloudWatchDashboardMapper creates a CloudWatch Dashboard mapper
+func NewCloudWatchDashboardMapper() *CloudWatchDashboardMapper {
+	return &CloudWatchDashboardMapper{}
+}
+
+// Cloud returns the cloud provider
+func (m *CloudWatchDashboardMapper) Cloud() clouds.CloudProvider {
+	return clouds.AWS
+}
+
+// ResourceType returns the Terraform resource type
+func (m *CloudWatchDashboardMapper) ResourceType() string {
+	return "aws_cloudwatch_dashboard"
+}
+
+// BuildUsage extracts usage vectors
+func (m *CloudWatchDashboardMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext) ([]clouds.UsageVector, error) {
+	if asset.Cardinality.IsUnknown() {
+		return []clouds.UsageVector{
+			clouds.SymbolicUsage("dashboard_count", "unknown dashboard count: "+asset.Cardinality.Reason),
+		}, nil
+	}
+	return []clouds.UsageVector{
+		clouds.NewUsageVector("dashboard_count", 1, 1.0),
+	}, nil
+}
+
+// BuildCostUnits creates cost units
+func (m *CloudWatchDashboardMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVector) ([]clouds.CostUnit, error) {
+	usageVecs := clouds.UsageVectors(usage)
+
+	if usageVecs.IsSymbolic() {
+		return []clouds.CostUnit{
+			clouds.SymbolicCost("dashboard", "CloudWatch Dashboard cost unknown"),
+		}, nil
+	}
+
+	dashboards, _ := usageVecs.Get("dashboard_count")
+	return []clouds.CostUnit{
+		clouds.NewCostUnit("dashboard", "dashboards", dashboards,
+			rateKey(asset, map[string]string{"usageType": "DashboardsUsageHour"}), 0.95),
+	}, nil
+}
+
+// CloudWatchLogMetricFilterMapper maps aws_cloudwatch_log_metric_filter to
+// the custom metric it publishes
+type CloudWatchLogMetricFilterMapper struct{}
+
+// NewCloudWatchLogMetricFilterMapper creates a log metric filter mapper
+func NewCloudWatchLogMetricFilterMapper() *CloudWatchLogMetricFilterMapper {
+	return &CloudWatchLogMetricFilterMapper{}
+}
+
+// Cloud returns the cloud provider
+func (m *CloudWatchLogMetricFilterMapper) Cloud() clouds.CloudProvider {
+	return clouds.AWS
+}
+
+// ResourceType returns the Terraform resource type
+func (m *CloudWatchLogMetricFilterMapper) ResourceType() string {
+	return "aws_cloudwatch_log_metric_filter"
+}
+
+// BuildUsage extracts usage vectors. A filter publishes one metric, or
+// one per combination of dimension values when it has dimensions; that
+// count comes from the usage file.
+func (m *CloudWatchLogMetricFilterMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext) ([]clouds.UsageVector, error) {
+	if asset.Cardinality.IsUnknown() {
+		return []clouds.UsageVector{
+			clouds.SymbolicUsage(MetricCustomMetrics, "unknown metric filter count: "+asset.Cardinality.Reason),
+		}, nil
+	}
+
+	if metrics, ok := ctx.Resolve(string(MetricCustomMetrics)); ok {
+		return []clouds.UsageVector{clouds.NewUsageVector(MetricCustomMetrics, metrics, 0.8)}, nil
+	}
+	if hasDimensions(asset) {
+		return []clouds.UsageVector{
+			clouds.SymbolicUsage(MetricCustomMetrics, "one metric per dimension value combination; set custom_metrics in the usage file"),
+		}, nil
+	}
+	return []clouds.UsageVector{
+		clouds.NewUsageVector(MetricCustomMetrics, 1, 1.0),
+	}, nil
+}
+
+// BuildCostUnits creates cost units
+func (m *CloudWatchLogMetricFilterMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVector) ([]clouds.CostUnit, error) {
+	usageVecs := clouds.UsageVectors(usage)
+
+	if usageVecs.IsSymbolic() {
+		return []clouds.CostUnit{
+			clouds.SymbolicCost("custom_metrics", "CloudWatch custom metric count unknown"),
+		}, nil
+	}
+
+	metrics, _ := usageVecs.Get(MetricCustomMetrics)
 	return []clouds.CostUnit{
-		clouds.NewCostUnit(
-			"alarm",
-			"alarms",
-			1,
-			clouds.RateKey{
-				Provider: providerID,
-				Service:  "AmazonCloudWatch",
-				Region:   region,
-				Attributes: map[string]string{
-					"usageType": "AlarmMonitorUsage",
-					"alarmType": alarmType,
-				},
-			},
-			0.95,
-		),
+		clouds.NewCostUnit("custom_metrics", "metric-months", metrics,
+			rateKey(asset, map[string]string{"usageType": "CW:MetricMonitorUsage"}), 0.9),
 	}, nil
 }
+
+// hasDimensions reports whether a metric filter's transformation has
+// dimensions
+func hasDimensions(asset clouds.AssetNode) bool {
+	blocks, _ := asset.Attributes["metric_transformation"].([]interface{})
+	for _, b := range blocks {
+		if block, ok := b.(map[string]interface{}); ok {
+			if dims, ok := block["dimensions"].(map[string]interface{}); ok && len(dims) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
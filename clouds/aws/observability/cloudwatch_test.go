@@ -0,0 +1,109 @@
+// Package observability - CloudWatch mapper tests
+package observability
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"terraform-cost/clouds"
+	"terraform-cost/clouds/cloudstest"
+)
+
+func approx(u clouds.CostUnit, want float64) bool {
+	return !u.IsSymbolic && u.Quantity != nil && math.Abs(*u.Quantity-want) < 1e-9
+}
+
+// TestAlarmMetricCount proves an alarm is priced per metric it evaluates
+// without any usage, at the high-resolution rate below a minute
+func TestAlarmMetricCount(t *testing.T) {
+	metric := func(period int) map[string]interface{} {
+		return map[string]interface{}{"metric": []interface{}{
+			map[string]interface{}{"metric_name": "Errors", "period": period},
+		}}
+	}
+	for name, tc := range map[string]struct {
+		attrs     map[string]interface{}
+		metrics   float64
+		alarmType string
+	}{
+		"single metric":   {map[string]interface{}{"metric_name": "CPUUtilization", "period": 300}, 1, "standard"},
+		"high resolution": {map[string]interface{}{"metric_name": "CPUUtilization", "period": 10}, 1, "high_resolution"},
+		"metric math": {map[string]interface{}{"metric_query": []interface{}{
+			metric(60), metric(60), metric(60),
+			map[string]interface{}{"expression": "m1+m2+m3", "return_data": true},
+		}}, 3, "standard"},
+		"anomaly detection": {map[string]interface{}{"metric_query": []interface{}{
+			metric(60),
+			map[string]interface{}{"expression": "ANOMALY_DETECTION_BAND(m1, 2)"},
+		}}, 3, "standard"},
+	} {
+		units := cloudstest.Units(t, NewCloudWatchMetricAlarmMapper(), cloudstest.Asset("aws_cloudwatch_metric_alarm", tc.attrs), nil)
+		alarm := units["alarm"]
+		if !approx(alarm, tc.metrics) {
+			t.Errorf("%s: alarm = %+v, want %v alarm metrics", name, alarm, tc.metrics)
+		}
+		if got := alarm.RateKey.Attributes["alarmType"]; got != tc.alarmType {
+			t.Errorf("%s: alarm type = %q, want %q", name, got, tc.alarmType)
+		}
+	}
+}
+
+// TestLogIngestion proves provided ingestion is priced, storage follows
+// retention when not provided, and missing ingestion is symbolic
+func TestLogIngestion(t *testing.T) {
+	group := cloudstest.Asset("aws_cloudwatch_log_group", map[string]interface{}{"retention_in_days": 90.0})
+
+	units := cloudstest.Units(t, NewCloudWatchLogGroupMapper(), group, map[string]interface{}{"monthly_ingestion_gb": 50.0})
+	if !approx(units["ingestion"], 50) || units["ingestion"].RateKey.Attributes["usageType"] != "DataProcessing-Bytes" {
+		t.Errorf("ingestion = %+v, want 50 GB", units["ingestion"])
+	}
+	if !approx(units["storage"], 150) {
+		t.Errorf("storage = %+v, want 50 GB a month kept 90 days", units["storage"])
+	}
+
+	units = cloudstest.Units(t, NewCloudWatchLogGroupMapper(), group, map[string]interface{}{"monthly_ingestion_gb": 50.0, "storage_gb": 20.0})
+	if !approx(units["storage"], 20) {
+		t.Errorf("storage = %+v, want the 20 GB provided", units["storage"])
+	}
+
+	units = cloudstest.Units(t, NewCloudWatchLogGroupMapper(), group, nil)
+	if u := units["ingestion"]; !u.IsSymbolic || !strings.Contains(u.SymbolicReason, "monthly_ingestion_gb") {
+		t.Errorf("ingestion without usage = %+v, want symbolic", u)
+	}
+
+	never := cloudstest.Asset("aws_cloudwatch_log_group", map[string]interface{}{"log_group_class": "INFREQUENT_ACCESS"})
+	units = cloudstest.Units(t, NewCloudWatchLogGroupMapper(), never, map[string]interface{}{"monthly_ingestion_gb": 50.0})
+	if got := units["ingestion"].RateKey.Attributes["usageType"]; got != "DataProcessingIA-Bytes" {
+		t.Errorf("infrequent access ingestion usage type = %q", got)
+	}
+	if !units["storage"].IsSymbolic {
+		t.Errorf("storage of logs never expired = %+v, want symbolic", units["storage"])
+	}
+}
+
+// TestDashboardAndMetricFilter proves dashboards and metric filters are
+// numeric without usage, unless the filter has dimensions
+func TestDashboardAndMetricFilter(t *testing.T) {
+	units := cloudstest.Units(t, NewCloudWatchDashboardMapper(), cloudstest.Asset("aws_cloudwatch_dashboard", nil), nil)
+	if !approx(units["dashboard"], 1) {
+		t.Errorf("dashboard = %+v, want 1", units["dashboard"])
+	}
+
+	filter := cloudstest.Asset("aws_cloudwatch_log_metric_filter", map[string]interface{}{"metric_transformation": []interface{}{
+		map[string]interface{}{"name": "Errors", "namespace": "App"},
+	}})
+	if units := cloudstest.Units(t, NewCloudWatchLogMetricFilterMapper(), filter, nil); !approx(units["custom_metrics"], 1) {
+		t.Errorf("custom metrics = %+v, want 1", units["custom_metrics"])
+	}
+
+	filter.Attributes["metric_transformation"] = []interface{}{
+		map[string]interface{}{"name": "Errors", "namespace": "App", "dimensions": map[string]interface{}{"Service": "$.service"}},
+	}
+	if units := cloudstest.Units(t, NewCloudWatchLogMetricFilterMapper(), filter, nil); !units["custom_metrics"].IsSymbolic {
+		t.Errorf("custom metrics with dimensions = %+v, want symbolic", units["custom_metrics"])
+	}
+	if units := cloudstest.Units(t, NewCloudWatchLogMetricFilterMapper(), filter, map[string]interface{}{"custom_metrics": 12.0}); !approx(units["custom_metrics"], 12) {
+		t.Errorf("custom metrics from usage = %+v, want 12", units["custom_metrics"])
+	}
+}
@@ -115,6 +115,7 @@ func (p *Plugin) SupportedResourceTypes() []string {
 		"aws_ecs_service",
 		"aws_eks_cluster",
 		"aws_eks_node_group",
+		"aws_eks_fargate_profile",
 
 		// Storage
 		"aws_s3_bucket",
@@ -104,6 +104,21 @@ func (s *AWSPricingSource) getEC2Rate(key types.RateKey) (types.Rate, error) {
 		"r5.large":    0.126,
 		"r5.xlarge":   0.252,
 		"r5.2xlarge":  0.504,
+
+		// Graviton (arm64)
+		"t4g.micro":   0.0084,
+		"t4g.small":   0.0168,
+		"t4g.medium":  0.0336,
+		"t4g.large":   0.0672,
+		"t4g.xlarge":  0.1344,
+		"t4g.2xlarge": 0.2688,
+		"m6g.large":   0.077,
+		"m6g.xlarge":  0.154,
+		"m6g.2xlarge": 0.308,
+		"c6g.large":   0.068,
+		"c6g.xlarge":  0.136,
+		"r6g.large":   0.1008,
+		"r6g.xlarge":  0.2016,
 	}
 
 	instanceType := key.Attributes["instance_type"]
@@ -21,7 +21,7 @@ func (m *LambdaProvisionedConcurrencyMapper) BuildUsage(asset clouds.AssetNode,
 	if asset.Cardinality.IsUnknown() {
 		return []clouds.UsageVector{clouds.SymbolicUsage(clouds.MetricMonthlyHours, "unknown concurrency config count")}, nil
 	}
-	return []clouds.UsageVector{clouds.NewUsageVector(clouds.MetricMonthlyHours, 730, 0.95)}, nil
+	return []clouds.UsageVector{clouds.NewUsageVector(clouds.MetricMonthlyHours, ctx.BillingHours(), 0.95)}, nil
 }
 
 func (m *LambdaProvisionedConcurrencyMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVector) ([]clouds.CostUnit, error) {
@@ -7,9 +7,19 @@
 package storage
 
 import (
+	"fmt"
+
 	"terraform-cost/clouds"
 )
 
+// gp3 includes a baseline of IOPS and throughput in the storage price;
+// only provisioning above it is billed. An unset iops or throughput is
+// the baseline, not zero.
+const (
+	gp3BaselineIOPS       = 3000
+	gp3BaselineThroughput = 125
+)
+
 // EBSMapper maps aws_ebs_volume to cost units
 type EBSMapper struct{}
 
@@ -64,6 +74,18 @@ func (m *EBSMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageV
 	iops := asset.AttrFloat("iops", 0)
 	throughput := asset.AttrFloat("throughput", 0)
 
+	var assumptions []string
+	if volumeType == "gp3" {
+		if !asset.HasAttr("iops") {
+			iops = gp3BaselineIOPS
+			assumptions = append(assumptions, fmt.Sprintf("iops not set; gp3 baseline of %d IOPS", gp3BaselineIOPS))
+		}
+		if !asset.HasAttr("throughput") {
+			throughput = gp3BaselineThroughput
+			assumptions = append(assumptions, fmt.Sprintf("throughput not set; gp3 baseline of %d MiB/s", gp3BaselineThroughput))
+		}
+	}
+
 	providerID := asset.ProviderContext.ProviderID
 	region := asset.ProviderContext.Region
 
@@ -85,6 +107,8 @@ func (m *EBSMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageV
 			0.95,
 		),
 	}
+	// The storage price includes the gp3 baselines assumed above
+	units[0].Assumptions = assumptions
 
 	// Provisioned IOPS for io1/io2
 	if (volumeType == "io1" || volumeType == "io2") && iops > 0 {
@@ -105,9 +129,9 @@ func (m *EBSMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageV
 		))
 	}
 
-	// Provisioned IOPS for gp3 (above 3000 baseline)
-	if volumeType == "gp3" && iops > 3000 {
-		extraIOPS := iops - 3000
+	// Provisioned IOPS for gp3 (above the baseline)
+	if volumeType == "gp3" && iops > gp3BaselineIOPS {
+		extraIOPS := iops - gp3BaselineIOPS
 		units = append(units, clouds.NewCostUnit(
 			"provisioned_iops",
 			"IOPS-months",
@@ -125,9 +149,9 @@ func (m *EBSMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageV
 		))
 	}
 
-	// Provisioned throughput for gp3 (above 125 MiB/s baseline)
-	if volumeType == "gp3" && throughput > 125 {
-		extraThroughput := throughput - 125
+	// Provisioned throughput for gp3 (above the baseline)
+	if volumeType == "gp3" && throughput > gp3BaselineThroughput {
+		extraThroughput := throughput - gp3BaselineThroughput
 		units = append(units, clouds.NewCostUnit(
 			"provisioned_throughput",
 			"MiBps-months",
@@ -0,0 +1,63 @@
+// Package storage - EBS mapper tests
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"terraform-cost/clouds/cloudstest"
+)
+
+// TestEBSGP3NullIOPS proves a null iops, as a plan records an unset
+// optional argument, is the gp3 baseline and not zero
+func TestEBSGP3NullIOPS(t *testing.T) {
+	asset := cloudstest.Asset("aws_ebs_volume", map[string]interface{}{
+		"type":       "gp3",
+		"size":       float64(100),
+		"iops":       nil,
+		"throughput": nil,
+	})
+
+	units := cloudstest.Units(t, NewEBSMapper(), asset, nil)
+	if _, ok := units["provisioned_iops"]; ok {
+		t.Errorf("null iops billed provisioned IOPS: %+v", units["provisioned_iops"])
+	}
+	if _, ok := units["provisioned_throughput"]; ok {
+		t.Errorf("null throughput billed provisioned throughput: %+v", units["provisioned_throughput"])
+	}
+	storage := units["storage"]
+	if storage.Quantity == nil || *storage.Quantity != 100 {
+		t.Fatalf("storage = %+v", storage)
+	}
+	assumed := strings.Join(storage.Assumptions, "; ")
+	if !strings.Contains(assumed, "gp3 baseline of 3000 IOPS") || !strings.Contains(assumed, "gp3 baseline of 125 MiB/s") {
+		t.Errorf("assumptions = %q, want the gp3 baselines", assumed)
+	}
+}
+
+// TestEBSGP3ExplicitIOPS proves explicit values are kept: 0 is not
+// replaced by the baseline, and IOPS above the baseline are billed
+func TestEBSGP3ExplicitIOPS(t *testing.T) {
+	asset := cloudstest.Asset("aws_ebs_volume", map[string]interface{}{
+		"type":       "gp3",
+		"iops":       float64(0),
+		"throughput": float64(0),
+	})
+
+	units := cloudstest.Units(t, NewEBSMapper(), asset, nil)
+	if _, ok := units["provisioned_iops"]; ok {
+		t.Errorf("explicit 0 iops billed provisioned IOPS: %+v", units["provisioned_iops"])
+	}
+	if got := units["storage"].Assumptions; len(got) != 0 {
+		t.Errorf("explicit 0 iops assumed a baseline: %q", got)
+	}
+	if s := units["storage"]; s.Quantity == nil || *s.Quantity != 8 {
+		t.Errorf("storage = %+v, want the 8 GB default size", s)
+	}
+
+	asset.Attributes["iops"] = float64(4000)
+	units = cloudstest.Units(t, NewEBSMapper(), asset, nil)
+	if p := units["provisioned_iops"]; p.Quantity == nil || *p.Quantity != 1000 {
+		t.Errorf("provisioned IOPS = %+v, want 4000 - 3000 = 1000", p)
+	}
+}
@@ -1,18 +1,45 @@
 // Package storage - AWS EFS/FSx cost mapper
 // EFS Pricing:
-// - Storage: per GB-month (Standard, IA, One Zone)
-// - Throughput: provisioned or bursting
-// - Data transfer: infrequent access reads
+// - Storage: per GB-month by class (Standard, Infrequent Access, One Zone)
+// - Provisioned throughput: per MiBps-month above the storage baseline
+// - Elastic throughput: per GB transferred
 // FSx Pricing:
-// - Capacity: per GB-month
-// - Throughput: per MBps-month (FSx for Lustre, Windows)
+// - Capacity: per GB-month (Lustre by per-unit throughput tier)
+// - Throughput: per MBps-month (Windows, OpenZFS)
 // - Backups: per GB-month
+// Stored data, IA data, elastic throughput and backup size come from the
+// usage file; a charge whose usage is absent is symbolic with a reason
+// naming the usage key to set.
 package storage
 
 import (
+	"fmt"
+
 	"terraform-cost/clouds"
+	"terraform-cost/core/determinism"
+)
+
+// EFS and FSx usage, read from the usage file
+const (
+	// MetricInfrequentAccessGB is EFS data stored in the IA class
+	MetricInfrequentAccessGB clouds.Metric = "infrequent_access_storage_gb"
+
+	// MetricBackupStorageGB is FSx backup storage
+	MetricBackupStorageGB clouds.Metric = "backup_storage_gb"
+)
+
+// EFS throughput modes
+const (
+	EFSThroughputBursting    = "bursting"
+	EFSThroughputProvisioned = "provisioned"
+	EFSThroughputElastic     = "elastic"
 )
 
+// efsBaselineGBPerMiBps is how much Standard storage earns 1 MiBps of
+// baseline throughput (50 KiBps per GiB); provisioned throughput is only
+// billed above it
+const efsBaselineGBPerMiBps = 20
+
 // EFSMapper maps aws_efs_file_system to cost units
 type EFSMapper struct{}
 
@@ -31,7 +58,8 @@ func (m *EFSMapper) ResourceType() string {
 	return "aws_efs_file_system"
 }
 
-// BuildUsage extracts usage vectors
+// BuildUsage extracts usage vectors. EFS grows with the data written, so
+// there are no defaults; only the usage file provides values.
 func (m *EFSMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext) ([]clouds.UsageVector, error) {
 	if asset.Cardinality.IsUnknown() {
 		return []clouds.UsageVector{
@@ -39,18 +67,17 @@ func (m *EFSMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext)
 		}, nil
 	}
 
-	// EFS storage is usage-dependent
-	storageGB := ctx.ResolveOrDefault("storage_gb", -1)
-
-	if storageGB < 0 {
-		return []clouds.UsageVector{
-			clouds.SymbolicUsage(clouds.MetricStorageGB, "EFS storage size unknown (grows dynamically)"),
-		}, nil
+	var usage []clouds.UsageVector
+	for _, metric := range []clouds.Metric{clouds.MetricStorageGB, MetricInfrequentAccessGB, clouds.MetricThroughputMBps} {
+		if v, ok := ctx.Resolve(string(metric)); ok {
+			usage = append(usage, clouds.NewUsageVector(metric, v, 0.8))
+		}
 	}
-
-	return []clouds.UsageVector{
-		clouds.NewUsageVector(clouds.MetricStorageGB, storageGB, 0.5),
-	}, nil
+	// An average MB/s is transferred for every hour of the month
+	if _, ok := ctx.Resolve(string(clouds.MetricThroughputMBps)); ok {
+		usage = append(usage, clouds.NewUsageVector(clouds.MetricMonthlyHours, ctx.BillingHours(), 0.95))
+	}
+	return usage, nil
 }
 
 // BuildCostUnits creates cost units
@@ -59,70 +86,154 @@ func (m *EFSMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageV
 
 	if usageVecs.IsSymbolic() {
 		return []clouds.CostUnit{
-			clouds.SymbolicCost("efs_storage", "EFS cost depends on actual storage used"),
+			clouds.SymbolicCost("efs_storage", "EFS cost unknown due to cardinality"),
 		}, nil
 	}
 
-	storageGB, _ := usageVecs.Get(clouds.MetricStorageGB)
-
-	// Determine storage class
 	performanceMode := asset.Attr("performance_mode")
 	if performanceMode == "" {
 		performanceMode = "generalPurpose"
 	}
 
-	// One Zone vs Standard
-	availabilityZoneName := asset.Attr("availability_zone_name")
-	storageClass := "Standard"
-	if availabilityZoneName != "" {
-		storageClass = "One Zone"
+	// One Zone file systems have their own storage classes
+	oneZone := asset.Attr("availability_zone_name") != ""
+	storageClass, iaClass := "Standard", "Infrequent Access"
+	if oneZone {
+		storageClass, iaClass = "One Zone", "One Zone-Infrequent Access"
 	}
 
-	providerID := asset.ProviderContext.ProviderID
-	region := asset.ProviderContext.Region
+	rateKey := func(attrs map[string]string) clouds.RateKey {
+		return clouds.RateKey{
+			Provider:   asset.ProviderContext.ProviderID,
+			Service:    "AmazonEFS",
+			Region:     asset.ProviderContext.Region,
+			Attributes: attrs,
+		}
+	}
 
-	units := []clouds.CostUnit{
-		clouds.NewCostUnit(
-			"storage",
-			"GB-months",
-			storageGB,
-			clouds.RateKey{
-				Provider: providerID,
-				Service:  "AmazonEFS",
-				Region:   region,
-				Attributes: map[string]string{
-					"storageClass":    storageClass,
-					"performanceMode": performanceMode,
-					"usageType":       "TimedStorage-ByteHrs",
-				},
-			},
-			0.5,
-		),
+	var units []clouds.CostUnit
+
+	storageGB, hasStorage := usageVecs.Get(clouds.MetricStorageGB)
+	if hasStorage {
+		units = append(units, clouds.NewCostUnit("storage", "GB-months", storageGB, rateKey(map[string]string{
+			"storageClass":    storageClass,
+			"performanceMode": performanceMode,
+			"usageType":       "TimedStorage-ByteHrs",
+		}), 0.8))
+	} else {
+		units = append(units, clouds.SymbolicCost("storage",
+			"EFS storage grows with the data written: set "+string(clouds.MetricStorageGB)))
 	}
 
-	// Provisioned throughput (if specified)
-	provisionedThroughput := asset.AttrFloat("provisioned_throughput_in_mibps", 0)
-	if provisionedThroughput > 0 {
-		units = append(units, clouds.NewCostUnit(
-			"provisioned_throughput",
-			"MiBps-months",
-			provisionedThroughput,
-			clouds.RateKey{
-				Provider: providerID,
-				Service:  "AmazonEFS",
-				Region:   region,
-				Attributes: map[string]string{
-					"usageType": "ProvisionedTP-MiBpsHrs",
-				},
-			},
-			0.95,
-		))
+	if iaGB, ok := usageVecs.Get(MetricInfrequentAccessGB); ok {
+		units = append(units, clouds.NewCostUnit("ia_storage", "GB-months", iaGB, rateKey(map[string]string{
+			"storageClass": iaClass,
+			"usageType":    "IATimedStorage-ByteHrs",
+		}), 0.8))
+	} else if efsTransitionsToIA(asset) {
+		units = append(units, clouds.SymbolicCost("ia_storage",
+			"lifecycle policy moves files to Infrequent Access: set "+string(MetricInfrequentAccessGB)))
+	}
+
+	switch asset.Attr("throughput_mode") {
+	case EFSThroughputProvisioned:
+		provisioned := asset.AttrFloat("provisioned_throughput_in_mibps", 0)
+		if provisioned <= 0 {
+			units = append(units, clouds.SymbolicCost("provisioned_throughput",
+				"provisioned_throughput_in_mibps is not known until apply"))
+			break
+		}
+		// Standard storage earns baseline throughput for free; without
+		// the stored size the whole provisioned amount is billed
+		billable := provisioned
+		if hasStorage && !oneZone {
+			billable -= storageGB / efsBaselineGBPerMiBps
+		}
+		if billable > 0 {
+			units = append(units, clouds.NewCostUnit("provisioned_throughput", "MiBps-months", billable,
+				rateKey(map[string]string{"usageType": "ProvisionedTP-MiBpsHrs"}), 0.95))
+		}
+	case EFSThroughputElastic:
+		if mbps, ok := usageVecs.Get(clouds.MetricThroughputMBps); ok {
+			hours, ok := usageVecs.Get(clouds.MetricMonthlyHours)
+			if !ok {
+				hours = determinism.DefaultHoursPerMonth
+			}
+			units = append(units, clouds.NewCostUnit("elastic_throughput", "GB", mbps*hours*3600/1000,
+				rateKey(map[string]string{"usageType": "ElasticThroughput-Bytes"}), 0.6))
+		} else {
+			units = append(units, clouds.SymbolicCost("elastic_throughput", fmt.Sprintf(
+				"elastic throughput is billed per GB read and written: set %s (average MB/s transferred)",
+				clouds.MetricThroughputMBps)))
+		}
 	}
 
 	return units, nil
 }
 
-// FSxMapper maps aws_fsx_lustre_file_system to cost units
+// efsTransitionsToIA reports whether a lifecycle_policy block moves files
+// to Infrequent Access, reading either a list of objects (plan JSON) or
+// flattened ".N.*" keys
+func efsTransitionsToIA(asset clouds.AssetNode) bool {
+	if blocks, ok := asset.Attributes["lifecycle_policy"].([]interface{}); ok {
+		for _, b := range blocks {
+			if block, ok := b.(map[string]interface{}); ok {
+				if v, _ := block["transition_to_ia"].(string); v != "" {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	for i := 0; ; i++ {
+		v, found := asset.Attributes[fmt.Sprintf("lifecycle_policy.%d.transition_to_ia", i)]
+		if !found {
+			return false
+		}
+		if s, _ := v.(string); s != "" {
+			return true
+		}
+	}
+}
+
+// fsxUsage is the usage of every FSx mapper: only backup storage, which
+// depends on how much data changes between backups
+func fsxUsage(asset clouds.AssetNode, ctx clouds.UsageContext) []clouds.UsageVector {
+	if asset.Cardinality.IsUnknown() {
+		return []clouds.UsageVector{
+			clouds.SymbolicUsage(clouds.MetricStorageGB, "unknown FSx count: "+asset.Cardinality.Reason),
+		}
+	}
+	if v, ok := ctx.Resolve(string(MetricBackupStorageGB)); ok {
+		return []clouds.UsageVector{clouds.NewUsageVector(MetricBackupStorageGB, v, 0.8)}
+	}
+	return nil
+}
+
+// fsxBackups prices automatic backups when they are retained;
+// defaultRetention is the provider's default retention in days
+func fsxBackups(asset clouds.AssetNode, usageVecs clouds.UsageVectors, fileSystemType string, defaultRetention int) []clouds.CostUnit {
+	retention := asset.AttrInt("automatic_backup_retention_days", defaultRetention)
+	if retention <= 0 {
+		return nil
+	}
+	if backupGB, ok := usageVecs.Get(MetricBackupStorageGB); ok {
+		return []clouds.CostUnit{clouds.NewCostUnit("backup_storage", "GB-months", backupGB, clouds.RateKey{
+			Provider: asset.ProviderContext.ProviderID,
+			Service:  "AmazonFSx",
+			Region:   asset.ProviderContext.Region,
+			Attributes: map[string]string{
+				"fileSystemType": fileSystemType,
+				"usageType":      "BackupUsage",
+			},
+		}, 0.8)}
+	}
+	return []clouds.CostUnit{clouds.SymbolicCost("backup_storage", fmt.Sprintf(
+		"automatic backups are kept %d days and grow with changed data: set %s",
+		retention, MetricBackupStorageGB))}
+}
+
+// FSxLustreMapper maps aws_fsx_lustre_file_system to cost units
 type FSxLustreMapper struct{}
 
 // NewFSxLustreMapper creates an FSx for Lustre mapper
@@ -142,24 +253,18 @@ func (m *FSxLustreMapper) ResourceType() string {
 
 // BuildUsage extracts usage vectors
 func (m *FSxLustreMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext) ([]clouds.UsageVector, error) {
-	if asset.Cardinality.IsUnknown() {
-		return []clouds.UsageVector{
-			clouds.SymbolicUsage(clouds.MetricStorageGB, "unknown FSx count: "+asset.Cardinality.Reason),
-		}, nil
-	}
-
-	return []clouds.UsageVector{
-		clouds.NewUsageVector(clouds.MetricStorageGB, 1, 1.0), // Placeholder
-	}, nil
+	return fsxUsage(asset, ctx), nil
 }
 
-// BuildCostUnits creates cost units
+// BuildCostUnits creates cost units. Persistent deployments price storage
+// by per_unit_storage_throughput (MB/s per TiB), so throughput is a
+// dimension of the storage rate rather than a separate charge.
 func (m *FSxLustreMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVector) ([]clouds.CostUnit, error) {
 	usageVecs := clouds.UsageVectors(usage)
 
 	if usageVecs.IsSymbolic() {
 		return []clouds.CostUnit{
-			clouds.SymbolicCost("fsx_lustre", "FSx cost unknown"),
+			clouds.SymbolicCost("fsx_lustre", "FSx cost unknown due to cardinality"),
 		}, nil
 	}
 
@@ -169,27 +274,35 @@ func (m *FSxLustreMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.
 		deploymentType = "SCRATCH_1"
 	}
 
-	providerID := asset.ProviderContext.ProviderID
-	region := asset.ProviderContext.Region
+	attrs := map[string]string{
+		"fileSystemType": "Lustre",
+		"deploymentType": deploymentType,
+		"usageType":      "Storage-SDD",
+	}
+	if throughput := asset.AttrInt("per_unit_storage_throughput", 0); throughput > 0 {
+		attrs["perUnitStorageThroughput"] = fmt.Sprintf("%d", throughput)
+	}
 
-	return []clouds.CostUnit{
+	units := []clouds.CostUnit{
 		clouds.NewCostUnit(
 			"storage",
 			"GB-months",
 			storageCapacity,
 			clouds.RateKey{
-				Provider: providerID,
-				Service:  "AmazonFSx",
-				Region:   region,
-				Attributes: map[string]string{
-					"fileSystemType": "Lustre",
-					"deploymentType": deploymentType,
-					"usageType":      "Storage-SDD",
-				},
+				Provider:   asset.ProviderContext.ProviderID,
+				Service:    "AmazonFSx",
+				Region:     asset.ProviderContext.Region,
+				Attributes: attrs,
 			},
 			0.95,
 		),
-	}, nil
+	}
+
+	// Scratch file systems cannot be backed up
+	if deploymentType != "SCRATCH_1" && deploymentType != "SCRATCH_2" {
+		units = append(units, fsxBackups(asset, usageVecs, "Lustre", 0)...)
+	}
+	return units, nil
 }
 
 // FSxWindowsMapper maps aws_fsx_windows_file_system to cost units
@@ -212,15 +325,7 @@ func (m *FSxWindowsMapper) ResourceType() string {
 
 // BuildUsage extracts usage vectors
 func (m *FSxWindowsMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext) ([]clouds.UsageVector, error) {
-	if asset.Cardinality.IsUnknown() {
-		return []clouds.UsageVector{
-			clouds.SymbolicUsage(clouds.MetricStorageGB, "unknown FSx count: "+asset.Cardinality.Reason),
-		}, nil
-	}
-
-	return []clouds.UsageVector{
-		clouds.NewUsageVector(clouds.MetricStorageGB, 1, 1.0),
-	}, nil
+	return fsxUsage(asset, ctx), nil
 }
 
 // BuildCostUnits creates cost units
@@ -229,7 +334,7 @@ func (m *FSxWindowsMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds
 
 	if usageVecs.IsSymbolic() {
 		return []clouds.CostUnit{
-			clouds.SymbolicCost("fsx_windows", "FSx cost unknown"),
+			clouds.SymbolicCost("fsx_windows", "FSx cost unknown due to cardinality"),
 		}, nil
 	}
 
@@ -247,7 +352,7 @@ func (m *FSxWindowsMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds
 	providerID := asset.ProviderContext.ProviderID
 	region := asset.ProviderContext.Region
 
-	return []clouds.CostUnit{
+	units := []clouds.CostUnit{
 		clouds.NewCostUnit(
 			"storage",
 			"GB-months",
@@ -274,10 +379,101 @@ func (m *FSxWindowsMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds
 				Region:   region,
 				Attributes: map[string]string{
 					"fileSystemType": "Windows",
+					"deploymentType": deploymentType,
 					"usageType":      "Throughput-MBps",
 				},
 			},
 			0.95,
 		),
-	}, nil
+	}
+
+	// Windows keeps 7 days of automatic backups unless told otherwise
+	units = append(units, fsxBackups(asset, usageVecs, "Windows", 7)...)
+	return units, nil
+}
+
+// FSxOpenZFSMapper maps aws_fsx_openzfs_file_system to cost units
+type FSxOpenZFSMapper struct{}
+
+// NewFSxOpenZFSMapper creates an FSx for OpenZFS mapper
+func NewFSxOpenZFSMapper() *FSxOpenZFSMapper {
+	return &FSxOpenZFSMapper{}
+}
+
+// Cloud returns the cloud provider
+func (m *FSxOpenZFSMapper) Cloud() clouds.CloudProvider {
+	return clouds.AWS
+}
+
+// ResourceType returns the Terraform resource type
+func (m *FSxOpenZFSMapper) ResourceType() string {
+	return "aws_fsx_openzfs_file_system"
+}
+
+// BuildUsage extracts usage vectors
+func (m *FSxOpenZFSMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext) ([]clouds.UsageVector, error) {
+	return fsxUsage(asset, ctx), nil
+}
+
+// BuildCostUnits creates cost units
+func (m *FSxOpenZFSMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVector) ([]clouds.CostUnit, error) {
+	usageVecs := clouds.UsageVectors(usage)
+
+	if usageVecs.IsSymbolic() {
+		return []clouds.CostUnit{
+			clouds.SymbolicCost("fsx_openzfs", "FSx cost unknown due to cardinality"),
+		}, nil
+	}
+
+	storageCapacity := asset.AttrFloat("storage_capacity", 64) // Minimum 64 GB
+	deploymentType := asset.Attr("deployment_type")
+	if deploymentType == "" {
+		deploymentType = "SINGLE_AZ_1"
+	}
+
+	providerID := asset.ProviderContext.ProviderID
+	region := asset.ProviderContext.Region
+
+	units := []clouds.CostUnit{
+		clouds.NewCostUnit(
+			"storage",
+			"GB-months",
+			storageCapacity,
+			clouds.RateKey{
+				Provider: providerID,
+				Service:  "AmazonFSx",
+				Region:   region,
+				Attributes: map[string]string{
+					"fileSystemType": "OpenZFS",
+					"storageType":    "SSD",
+					"deploymentType": deploymentType,
+				},
+			},
+			0.95,
+		),
+	}
+
+	if throughput := asset.AttrFloat("throughput_capacity", 0); throughput > 0 {
+		units = append(units, clouds.NewCostUnit(
+			"throughput",
+			"MBps-months",
+			throughput,
+			clouds.RateKey{
+				Provider: providerID,
+				Service:  "AmazonFSx",
+				Region:   region,
+				Attributes: map[string]string{
+					"fileSystemType": "OpenZFS",
+					"deploymentType": deploymentType,
+					"usageType":      "Throughput-MBps",
+				},
+			},
+			0.95,
+		))
+	} else {
+		units = append(units, clouds.SymbolicCost("throughput", "throughput_capacity is not known until apply"))
+	}
+
+	units = append(units, fsxBackups(asset, usageVecs, "OpenZFS", 0)...)
+	return units, nil
 }
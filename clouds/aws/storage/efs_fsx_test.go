@@ -0,0 +1,108 @@
+// Package storage - EFS/FSx mapper tests
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"terraform-cost/clouds/cloudstest"
+)
+
+// TestEFSProvisionedThroughput proves provisioned throughput is billed
+// above the baseline Standard storage earns, and in full when the stored
+// size is unknown
+func TestEFSProvisionedThroughput(t *testing.T) {
+	asset := cloudstest.Asset("aws_efs_file_system", map[string]interface{}{
+		"throughput_mode":                 "provisioned",
+		"provisioned_throughput_in_mibps": float64(10),
+	})
+
+	units := cloudstest.Units(t, NewEFSMapper(), asset, map[string]interface{}{"storage_gb": float64(100)})
+	tp := units["provisioned_throughput"]
+	if tp.IsSymbolic || tp.Quantity == nil || *tp.Quantity != 5 {
+		t.Fatalf("provisioned throughput = %+v, want 10 - 100/20 = 5 MiBps-months", tp)
+	}
+	if got := tp.RateKey.Attributes["usageType"]; got != "ProvisionedTP-MiBpsHrs" {
+		t.Errorf("usage type = %q", got)
+	}
+	if s := units["storage"]; s.Quantity == nil || *s.Quantity != 100 || s.RateKey.Attributes["storageClass"] != "Standard" {
+		t.Errorf("storage = %+v", s)
+	}
+
+	units = cloudstest.Units(t, NewEFSMapper(), asset, nil)
+	if tp := units["provisioned_throughput"]; tp.Quantity == nil || *tp.Quantity != 10 {
+		t.Errorf("without storage usage, provisioned throughput = %+v, want 10", tp)
+	}
+	if s := units["storage"]; !s.IsSymbolic || !strings.Contains(s.SymbolicReason, "storage_gb") {
+		t.Errorf("storage without usage should be symbolic naming storage_gb, got %+v", s)
+	}
+
+	// Enough storage covers the provisioned amount
+	units = cloudstest.Units(t, NewEFSMapper(), asset, map[string]interface{}{"storage_gb": float64(400)})
+	if tp, ok := units["provisioned_throughput"]; ok {
+		t.Errorf("baseline covers provisioned throughput, got %+v", tp)
+	}
+}
+
+// TestEFSStorageClassesAndElastic proves IA storage and elastic throughput
+// are priced from usage, and symbolic with a reason without it
+func TestEFSStorageClassesAndElastic(t *testing.T) {
+	asset := cloudstest.Asset("aws_efs_file_system", map[string]interface{}{
+		"throughput_mode": "elastic",
+		"lifecycle_policy": []interface{}{
+			map[string]interface{}{"transition_to_ia": "AFTER_30_DAYS"},
+		},
+	})
+
+	units := cloudstest.Units(t, NewEFSMapper(), asset, nil)
+	for _, name := range []string{"storage", "ia_storage", "elastic_throughput"} {
+		if !units[name].IsSymbolic || units[name].SymbolicReason == "" {
+			t.Errorf("%s should be symbolic with a reason, got %+v", name, units[name])
+		}
+	}
+
+	units = cloudstest.Units(t, NewEFSMapper(), asset, map[string]interface{}{
+		"storage_gb":                   float64(50),
+		"infrequent_access_storage_gb": float64(200),
+		"throughput_mbps":              float64(1),
+	})
+	if ia := units["ia_storage"]; ia.Quantity == nil || *ia.Quantity != 200 || ia.RateKey.Attributes["storageClass"] != "Infrequent Access" {
+		t.Errorf("ia storage = %+v", ia)
+	}
+	if e := units["elastic_throughput"]; e.Quantity == nil || *e.Quantity != 730*3600/1000.0 {
+		t.Errorf("elastic throughput = %+v, want 1 MB/s for a month in GB", e)
+	}
+}
+
+// TestFSxBackups proves retained backups are priced from usage, symbolic
+// without it, and absent when retention is off
+func TestFSxBackups(t *testing.T) {
+	windows := cloudstest.Asset("aws_fsx_windows_file_system", map[string]interface{}{
+		"storage_capacity":    float64(300),
+		"throughput_capacity": float64(32),
+	})
+	units := cloudstest.Units(t, NewFSxWindowsMapper(), windows, nil)
+	if b := units["backup_storage"]; !b.IsSymbolic || !strings.Contains(b.SymbolicReason, "7 days") {
+		t.Errorf("default Windows backups should be symbolic, got %+v", b)
+	}
+	if tp := units["throughput"]; tp.Quantity == nil || *tp.Quantity != 32 {
+		t.Errorf("throughput = %+v", tp)
+	}
+
+	units = cloudstest.Units(t, NewFSxWindowsMapper(), windows, map[string]interface{}{"backup_storage_gb": float64(40)})
+	if b := units["backup_storage"]; b.Quantity == nil || *b.Quantity != 40 {
+		t.Errorf("backups = %+v, want 40 GB-months", b)
+	}
+
+	lustre := cloudstest.Asset("aws_fsx_lustre_file_system", map[string]interface{}{
+		"deployment_type":             "PERSISTENT_2",
+		"per_unit_storage_throughput": float64(250),
+	})
+	units = cloudstest.Units(t, NewFSxLustreMapper(), lustre, nil)
+	if _, ok := units["backup_storage"]; ok {
+		t.Error("Lustre backups are off by default")
+	}
+	if got := units["storage"].RateKey.Attributes["perUnitStorageThroughput"]; got != "250" {
+		t.Errorf("per-unit throughput = %q", got)
+	}
+}
@@ -4,9 +4,15 @@
 // - Requests (PUT, GET, LIST, etc.)
 // - Data transfer (out to internet, cross-region)
 // - Management features (analytics, inventory, replication)
+// Storage is split across storage classes by the usage file's per-class
+// GB (standard_ia_storage_gb, glacier_storage_gb, ...) or, failing that,
+// by the transitions of the bucket's lifecycle configuration.
 package storage
 
 import (
+	"sort"
+	"strings"
+
 	"terraform-cost/clouds"
 )
 
@@ -33,9 +39,6 @@ func (m *S3Mapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext) (
 	// S3 buckets always have known cardinality (1 bucket)
 	// But storage size and request volume are usage-dependent
 
-	// Storage (usage-based, needs override or default)
-	storageGB := ctx.ResolveOrDefault("storage_gb", 100)
-
 	// Requests (usage-based)
 	putRequests := ctx.ResolveOrDefault("put_requests", 10000)
 	getRequests := ctx.ResolveOrDefault("get_requests", 100000)
@@ -49,12 +52,108 @@ func (m *S3Mapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext) (
 		confidence = 0.5 // Usage-based = moderate confidence
 	}
 
-	return []clouds.UsageVector{
-		clouds.NewUsageVector(clouds.MetricStorageGB, storageGB, confidence),
+	// Storage (usage-based, needs override or default), by class
+	usage := s3StorageUsage(asset, ctx, confidence)
+
+	return append(usage,
 		clouds.NewUsageVector("put_requests", putRequests, confidence),
 		clouds.NewUsageVector("get_requests", getRequests, confidence),
 		clouds.NewUsageVector(clouds.MetricDataTransferGB, dataTransferGB, confidence),
-	}, nil
+	), nil
+}
+
+// s3BaseClass is the class objects are stored in before any transition
+func s3BaseClass(asset clouds.AssetNode) string {
+	if sc := asset.Attr("storage_class"); sc != "" {
+		return sc
+	}
+	return "STANDARD"
+}
+
+// s3StorageMetric is the metric for GB stored in a class; the base class
+// keeps the plain storage metric
+func s3StorageMetric(class, baseClass string) clouds.Metric {
+	if class == baseClass {
+		return clouds.MetricStorageGB
+	}
+	return clouds.Metric(s3ClassUsageKey(class))
+}
+
+// s3MetricClass returns the storage class a storage metric measures
+func s3MetricClass(metric clouds.Metric, baseClass string) (string, bool) {
+	if metric == clouds.MetricStorageGB {
+		return baseClass, true
+	}
+	if class, ok := strings.CutSuffix(string(metric), "_storage_gb"); ok {
+		return strings.ToUpper(class), true
+	}
+	return "", false
+}
+
+// s3StorageUsage returns GB stored per class. Per-class GB in the usage
+// file is taken as given; otherwise storage_gb is split by the bucket's
+// lifecycle transitions, with the split recorded as an assumption.
+func s3StorageUsage(asset clouds.AssetNode, ctx clouds.UsageContext, confidence float64) []clouds.UsageVector {
+	baseClass := s3BaseClass(asset)
+
+	var usage []clouds.UsageVector
+	for _, class := range s3StorageClasses {
+		if gb, ok := ctx.Resolve(s3ClassUsageKey(class)); ok {
+			usage = append(usage, clouds.NewUsageVector(s3StorageMetric(class, baseClass), gb, confidence))
+		}
+	}
+	if len(usage) > 0 {
+		return usage
+	}
+
+	storageGB := ctx.ResolveOrDefault("storage_gb", 100)
+	rules, configs, known := s3LifecycleRules(asset)
+	if len(rules) == 0 {
+		vector := clouds.NewUsageVector(clouds.MetricStorageGB, storageGB, confidence)
+		if !known {
+			vector.Assumption = "lifecycle rules of " + strings.Join(configs, ", ") +
+				" are unknown; transitions may lower the cost, storage priced as " + baseClass
+		}
+		return []clouds.UsageVector{vector}
+	}
+
+	// An unfiltered rule applies to every object, so it shapes the mix
+	rule := rules[0]
+	for _, r := range rules {
+		if !r.filtered {
+			rule = r
+			break
+		}
+	}
+	shares, assumption := rule.mix(baseClass)
+	if len(rules) > 1 {
+		assumption += "; other transition rules are not modelled"
+	}
+
+	classes := make([]string, 0, len(shares))
+	for class := range shares {
+		classes = append(classes, class)
+	}
+	sort.Slice(classes, func(i, j int) bool { return s3ClassRank(classes[i]) < s3ClassRank(classes[j]) })
+
+	// Derived from assumed ageing, so less certain than storage_gb itself
+	derived := confidence * 0.8
+	for _, class := range classes {
+		vector := clouds.NewUsageVector(s3StorageMetric(class, baseClass), storageGB*shares[class], derived)
+		vector.Assumption = assumption
+		usage = append(usage, vector)
+	}
+	return usage
+}
+
+// s3ClassRank orders storage classes as s3StorageClasses, unknown last
+func s3ClassRank(class string) int {
+	for i, c := range s3StorageClasses {
+		if c == class {
+			return i
+		}
+	}
+	return len(s3StorageClasses)
 }
 
 // BuildCostUnits creates cost units for an S3 bucket
@@ -62,13 +161,9 @@ func (m *S3Mapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVe
 	usageVecs := clouds.UsageVectors(usage)
 
 	// Determine storage class
-	storageClass := "STANDARD"
-	if sc := asset.Attr("storage_class"); sc != "" {
-		storageClass = sc
-	}
+	storageClass := s3BaseClass(asset)
 
 	// Get usage values
-	storageGB, _ := usageVecs.Get(clouds.MetricStorageGB)
 	putRequests, _ := usageVecs.Get("put_requests")
 	getRequests, _ := usageVecs.Get("get_requests")
 	dataTransferGB, _ := usageVecs.Get(clouds.MetricDataTransferGB)
@@ -76,24 +171,10 @@ func (m *S3Mapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVe
 	providerID := asset.ProviderContext.ProviderID
 	region := asset.ProviderContext.Region
 
-	units := []clouds.CostUnit{
-		// Storage
-		clouds.NewCostUnit(
-			"storage",
-			"GB-months",
-			storageGB,
-			clouds.RateKey{
-				Provider: providerID,
-				Service:  "AmazonS3",
-				Region:   region,
-				Attributes: map[string]string{
-					"storageClass": storageClass,
-					"usageType":    "TimedStorage-" + storageClass,
-				},
-			},
-			0.5,
-		),
+	// Storage, one unit per class
+	units := s3StorageUnits(usage, storageClass, providerID, region)
 
+	units = append(units,
 		// PUT requests
 		clouds.NewCostUnit(
 			"put_requests",
@@ -127,7 +208,7 @@ func (m *S3Mapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVe
 			},
 			0.5,
 		),
-	}
+	)
 
 	// Data transfer (first 1GB free, then tiered)
 	if dataTransferGB > 0 {
@@ -150,3 +231,60 @@ func (m *S3Mapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVe
 
 	return units, nil
 }
+
+// s3StorageUnits prices the GB stored in each class, carrying any
+// assumption behind the split into the unit's lineage
+func s3StorageUnits(usage []clouds.UsageVector, baseClass, providerID, region string) []clouds.CostUnit {
+	var units []clouds.CostUnit
+	for _, v := range usage {
+		class, ok := s3MetricClass(v.Metric, baseClass)
+		if !ok || v.Value == nil {
+			continue
+		}
+		name := "storage"
+		if class != baseClass {
+			name = "storage_" + strings.ToLower(class)
+		}
+		confidence := 0.5
+		if v.Assumption != "" {
+			confidence = 0.4
+		}
+		unit := clouds.NewCostUnit(
+			name,
+			"GB-months",
+			*v.Value,
+			clouds.RateKey{
+				Provider: providerID,
+				Service:  "AmazonS3",
+				Region:   region,
+				Attributes: map[string]string{
+					"storageClass": class,
+					"usageType":    "TimedStorage-" + class,
+				},
+			},
+			confidence,
+		)
+		if v.Assumption != "" {
+			unit.Assumptions = []string{v.Assumption}
+		}
+		units = append(units, unit)
+	}
+	if len(units) == 0 {
+		units = append(units, clouds.NewCostUnit(
+			"storage",
+			"GB-months",
+			0,
+			clouds.RateKey{
+				Provider: providerID,
+				Service:  "AmazonS3",
+				Region:   region,
+				Attributes: map[string]string{
+					"storageClass": baseClass,
+					"usageType":    "TimedStorage-" + baseClass,
+				},
+			},
+			0.5,
+		))
+	}
+	return units
+}
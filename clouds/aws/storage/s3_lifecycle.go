@@ -0,0 +1,190 @@
+// Package storage - S3 lifecycle storage-class mix
+// A bucket's aws_s3_bucket_lifecycle_configuration transitions objects to
+// cheaper storage classes as they age, so the bucket's storage is priced
+// across several classes. Without per-class GB in the usage file, the
+// mix is derived from the transition days, assuming objects age
+// uniformly up to their expiration (or s3AssumedRetentionDays); the
+// assumption is recorded on the storage cost units.
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"terraform-cost/clouds"
+)
+
+// S3LifecycleConfigurationType links lifecycle rules to their bucket
+const S3LifecycleConfigurationType = "aws_s3_bucket_lifecycle_configuration"
+
+// s3AssumedRetentionDays is how long objects are assumed to be kept when
+// the lifecycle rule has no expiration
+const s3AssumedRetentionDays = 365
+
+// s3StorageClasses are the storage classes objects can be stored or
+// transitioned in, from the most expensive per GB
+var s3StorageClasses = []string{
+	"STANDARD",
+	"INTELLIGENT_TIERING",
+	"STANDARD_IA",
+	"ONEZONE_IA",
+	"GLACIER_IR",
+	"GLACIER",
+	"DEEP_ARCHIVE",
+}
+
+// s3ClassUsageKey is the usage file key for GB stored in a class, e.g.
+// standard_ia_storage_gb
+func s3ClassUsageKey(class string) string {
+	return strings.ToLower(class) + "_storage_gb"
+}
+
+// s3Transition moves objects to a storage class after a number of days
+type s3Transition struct {
+	days  float64
+	class string
+}
+
+// s3LifecycleRule is the lifecycle rule that shapes a bucket's mix
+type s3LifecycleRule struct {
+	// source names the configuration and rule, for assumptions
+	source string
+
+	transitions    []s3Transition
+	expirationDays float64
+	filtered       bool
+}
+
+// attrBlocks reads a nested block as a list of objects; plan JSON and
+// HCL give a list, a flattened single block a map
+func attrBlocks(attrs map[string]interface{}, key string) []clouds.AssetNode {
+	switch v := attrs[key].(type) {
+	case []interface{}:
+		blocks := make([]clouds.AssetNode, 0, len(v))
+		for _, b := range v {
+			if block, ok := b.(map[string]interface{}); ok {
+				blocks = append(blocks, clouds.AssetNode{Attributes: block})
+			}
+		}
+		return blocks
+	case map[string]interface{}:
+		return []clouds.AssetNode{{Attributes: v}}
+	}
+	return nil
+}
+
+// s3LifecycleRules returns the enabled rules of a bucket's lifecycle
+// configurations that transition objects by age. known is false when a
+// configuration's rules cannot be read, e.g. before apply.
+func s3LifecycleRules(bucket clouds.AssetNode) (rules []s3LifecycleRule, configs []string, known bool) {
+	known = true
+	lifecycles := bucket.DependentsOfType(S3LifecycleConfigurationType)
+	sort.Slice(lifecycles, func(i, j int) bool { return lifecycles[i].Address < lifecycles[j].Address })
+	for _, lc := range lifecycles {
+		configs = append(configs, lc.Address)
+		blocks := attrBlocks(lc.Attributes, "rule")
+		if blocks == nil {
+			known = false
+			continue
+		}
+		for i, rule := range blocks {
+			if rule.Attr("status") != "Enabled" {
+				continue
+			}
+			r := s3LifecycleRule{
+				source:   fmt.Sprintf("%s rule %q", lc.Address, ruleID(rule, i)),
+				filtered: ruleFiltered(rule),
+			}
+			for _, t := range attrBlocks(rule.Attributes, "transition") {
+				days := t.AttrFloat("days", -1)
+				class := t.Attr("storage_class")
+				if days < 0 || class == "" {
+					continue
+				}
+				r.transitions = append(r.transitions, s3Transition{days: days, class: class})
+			}
+			for _, e := range attrBlocks(rule.Attributes, "expiration") {
+				if days := e.AttrFloat("days", 0); days > 0 {
+					r.expirationDays = days
+				}
+			}
+			if len(r.transitions) > 0 {
+				sort.SliceStable(r.transitions, func(a, b int) bool { return r.transitions[a].days < r.transitions[b].days })
+				rules = append(rules, r)
+			}
+		}
+	}
+	return rules, configs, known
+}
+
+// ruleID names a rule by its id, or its position
+func ruleID(rule clouds.AssetNode, i int) string {
+	if id := rule.Attr("id"); id != "" {
+		return id
+	}
+	return fmt.Sprintf("#%d", i)
+}
+
+// ruleFiltered reports whether a rule applies only to some objects
+func ruleFiltered(rule clouds.AssetNode) bool {
+	if rule.Attr("prefix") != "" {
+		return true
+	}
+	for _, f := range attrBlocks(rule.Attributes, "filter") {
+		for _, v := range f.Attributes {
+			switch val := v.(type) {
+			case nil:
+			case string:
+				if val != "" {
+					return true
+				}
+			case []interface{}:
+				if len(val) > 0 {
+					return true
+				}
+			case map[string]interface{}:
+				if len(val) > 0 {
+					return true
+				}
+			default:
+				// object size bounds
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mix returns the share of stored GB in each class, starting in
+// baseClass. Objects are assumed to age uniformly over the retention
+// period, so a class holds the share of it between its transitions.
+func (r s3LifecycleRule) mix(baseClass string) (map[string]float64, string) {
+	retention, retentionNote := r.expirationDays, fmt.Sprintf("expiring after %g days", r.expirationDays)
+	if retention <= 0 {
+		retention, retentionNote = s3AssumedRetentionDays, fmt.Sprintf("no expiration, %d days assumed", s3AssumedRetentionDays)
+	}
+
+	shares := make(map[string]float64)
+	class, from := baseClass, 0.0
+	var steps []string
+	for _, t := range r.transitions {
+		if t.days >= retention {
+			break
+		}
+		shares[class] += (t.days - from) / retention
+		class, from = t.class, t.days
+		steps = append(steps, fmt.Sprintf("%s after %g days", t.class, t.days))
+	}
+	shares[class] += (retention - from) / retention
+
+	assumption := fmt.Sprintf("storage split by %s (%s): objects assumed to age uniformly, %s",
+		r.source, strings.Join(steps, ", "), retentionNote)
+	if len(steps) == 0 {
+		assumption = fmt.Sprintf("%s transitions objects only after they expire (%s)", r.source, retentionNote)
+	}
+	if r.filtered {
+		assumption += "; the rule's filter is assumed to match every object"
+	}
+	return shares, assumption
+}
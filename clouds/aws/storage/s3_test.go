@@ -0,0 +1,122 @@
+// Package storage - S3 mapper tests
+package storage
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"terraform-cost/clouds"
+	"terraform-cost/clouds/cloudstest"
+)
+
+// lifecycleBucket links a bucket to a lifecycle configuration the way
+// the infrastructure graph does: the configuration references the bucket
+func lifecycleBucket(rules []interface{}) clouds.AssetNode {
+	bucket := cloudstest.Asset("aws_s3_bucket", map[string]interface{}{})
+	lifecycle := cloudstest.Asset("aws_s3_bucket_lifecycle_configuration", map[string]interface{}{
+		"bucket": "${aws_s3_bucket.this.id}",
+	})
+	if rules != nil {
+		lifecycle.Attributes["rule"] = rules
+	}
+	dependents := map[string][]string{
+		"aws_s3_bucket.this": {"aws_s3_bucket_lifecycle_configuration.this"},
+	}
+	nodes := clouds.LinkDependents([]clouds.AssetNode{bucket, lifecycle}, func(address string) []string {
+		return dependents[address]
+	})
+	return nodes[0]
+}
+
+func archiveRule(extra map[string]interface{}) []interface{} {
+	rule := map[string]interface{}{
+		"id":     "archive",
+		"status": "Enabled",
+		"transition": []interface{}{
+			map[string]interface{}{"days": 90, "storage_class": "GLACIER"},
+			map[string]interface{}{"days": 30, "storage_class": "STANDARD_IA"},
+		},
+		"expiration": []interface{}{map[string]interface{}{"days": 365}},
+	}
+	for k, v := range extra {
+		rule[k] = v
+	}
+	return []interface{}{rule}
+}
+
+// TestS3LifecycleStorageMix proves a linked lifecycle configuration
+// splits storage_gb across the classes its transitions move objects to,
+// recording the split as an assumption on each storage unit
+func TestS3LifecycleStorageMix(t *testing.T) {
+	m := NewS3Mapper()
+	units := cloudstest.Units(t, m, lifecycleBucket(archiveRule(nil)), map[string]interface{}{"storage_gb": 365.0})
+
+	want := map[string]struct {
+		gb    float64
+		class string
+	}{
+		"storage":             {30, "STANDARD"},
+		"storage_standard_ia": {60, "STANDARD_IA"},
+		"storage_glacier":     {275, "GLACIER"},
+	}
+	for name, w := range want {
+		u, ok := units[name]
+		if !ok {
+			t.Fatalf("no %s unit in %v", name, units)
+		}
+		if math.Abs(*u.Quantity-w.gb) > 1e-9 {
+			t.Errorf("%s = %v GB, want %v", name, *u.Quantity, w.gb)
+		}
+		if u.RateKey.Attributes["storageClass"] != w.class || u.RateKey.Attributes["usageType"] != "TimedStorage-"+w.class {
+			t.Errorf("%s rate key = %v", name, u.RateKey.Attributes)
+		}
+		if len(u.Assumptions) != 1 || !strings.Contains(u.Assumptions[0], `aws_s3_bucket_lifecycle_configuration.this rule "archive"`) {
+			t.Errorf("%s assumptions = %v", name, u.Assumptions)
+		}
+	}
+
+	// A filtered rule may not cover every object, which is assumed
+	units = cloudstest.Units(t, m, lifecycleBucket(archiveRule(map[string]interface{}{
+		"filter": []interface{}{map[string]interface{}{"prefix": "logs/"}},
+	})), nil)
+	if a := units["storage_glacier"].Assumptions; len(a) != 1 || !strings.Contains(a[0], "filter is assumed to match every object") {
+		t.Errorf("filtered rule assumptions = %v", a)
+	}
+}
+
+// TestS3StorageClassUsage proves per-class GB in the usage file is priced
+// as given, with no assumed split
+func TestS3StorageClassUsage(t *testing.T) {
+	units := cloudstest.Units(t, NewS3Mapper(), lifecycleBucket(archiveRule(nil)), map[string]interface{}{
+		"standard_storage_gb":     50.0,
+		"deep_archive_storage_gb": 1000.0,
+	})
+	if len(units) != 5 {
+		t.Errorf("got units %v, want storage, storage_deep_archive, requests and transfer", units)
+	}
+	if u := units["storage"]; *u.Quantity != 50 || len(u.Assumptions) != 0 {
+		t.Errorf("storage = %v GB, assumptions %v", *u.Quantity, u.Assumptions)
+	}
+	if u := units["storage_deep_archive"]; *u.Quantity != 1000 || u.RateKey.Attributes["storageClass"] != "DEEP_ARCHIVE" {
+		t.Errorf("storage_deep_archive = %v GB at %v", *u.Quantity, u.RateKey.Attributes)
+	}
+}
+
+// TestS3WithoutLifecycle proves a bucket without transitions is priced in
+// one class, noting when its lifecycle rules are unknown
+func TestS3WithoutLifecycle(t *testing.T) {
+	m := NewS3Mapper()
+	units := cloudstest.Units(t, m, cloudstest.Asset("aws_s3_bucket", map[string]interface{}{}), nil)
+	if u, ok := units["storage"]; !ok || *u.Quantity != 100 || len(u.Assumptions) != 0 {
+		t.Errorf("storage = %+v", u)
+	}
+	if len(units) != 4 {
+		t.Errorf("got units %v, want a single storage unit", units)
+	}
+
+	units = cloudstest.Units(t, m, lifecycleBucket(nil), nil)
+	if a := units["storage"].Assumptions; len(a) != 1 || !strings.Contains(a[0], "transitions may lower the cost") {
+		t.Errorf("unknown rules assumptions = %v", a)
+	}
+}
@@ -36,7 +36,7 @@ func (m *KinesisStreamMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.Usag
 		}, nil
 	}
 
-	monthlyHours := ctx.ResolveOrDefault("monthly_hours", 730)
+	monthlyHours := ctx.MonthlyHours()
 	// PUT units are usage-dependent
 	putUnitsMillions := ctx.ResolveOrDefault("put_units_millions", -1)
 
@@ -17,7 +17,7 @@ func (m *MSKClusterMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageCo
 	if asset.Cardinality.IsUnknown() {
 		return []clouds.UsageVector{clouds.SymbolicUsage(clouds.MetricMonthlyHours, "unknown MSK cluster count")}, nil
 	}
-	return []clouds.UsageVector{clouds.NewUsageVector(clouds.MetricMonthlyHours, 730, 0.95)}, nil
+	return []clouds.UsageVector{clouds.NewUsageVector(clouds.MetricMonthlyHours, ctx.BillingHours(), 0.95)}, nil
 }
 
 func (m *MSKClusterMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVector) ([]clouds.CostUnit, error) {
@@ -35,8 +35,8 @@ func NewEC2InstanceEstimator() coreUsage.Estimator {
 
 // Estimate produces usage vectors for an EC2 instance
 func (e *EC2InstanceEstimator) Estimate(ctx context.Context, asset *types.Asset, uctx *coreUsage.Context) ([]types.UsageVector, error) {
-	// Default: 24/7 operation = 730 hours/month
-	monthlyHours := 730.0
+	// Default: 24/7 operation for the whole billing month
+	monthlyHours := uctx.BillingHours()
 	confidence := 0.8
 
 	// Check for overrides
@@ -140,15 +140,12 @@ func (e *RDSInstanceEstimator) Estimate(ctx context.Context, asset *types.Asset,
 	}
 
 	// Get storage from attributes
-	storage := asset.Attributes.GetInt("allocated_storage")
-	if storage == 0 {
-		storage = 20
-	}
+	storage := asset.Attributes.GetIntOr("allocated_storage", 20)
 
 	return []types.UsageVector{
 		{
 			Metric:      types.MetricMonthlyHours,
-			Value:       730, // 24/7
+			Value:       uctx.BillingHours(), // 24/7
 			Confidence:  0.9,
 			Source:      types.SourceDefault,
 			Description: "Database typically runs 24/7",
@@ -191,15 +188,9 @@ func (e *LambdaFunctionEstimator) Estimate(ctx context.Context, asset *types.Ass
 		}
 	}
 
-	memorySize := asset.Attributes.GetInt("memory_size")
-	if memorySize == 0 {
-		memorySize = 128
-	}
+	memorySize := asset.Attributes.GetIntOr("memory_size", 128)
 
-	timeout := asset.Attributes.GetInt("timeout")
-	if timeout == 0 {
-		timeout = 3
-	}
+	timeout := asset.Attributes.GetIntOr("timeout", 3)
 
 	// Default: 1 million invocations, average 500ms duration
 	invocations := 1000000.0
@@ -309,7 +300,7 @@ func (e *NATGatewayEstimator) Estimate(ctx context.Context, asset *types.Asset,
 	return []types.UsageVector{
 		{
 			Metric:      types.MetricMonthlyHours,
-			Value:       730, // 24/7
+			Value:       uctx.BillingHours(), // 24/7
 			Confidence:  0.95,
 			Source:      types.SourceDefault,
 			Description: "NAT Gateway runs continuously",
@@ -345,10 +336,7 @@ func (e *EBSVolumeEstimator) Estimate(ctx context.Context, asset *types.Asset, u
 		}
 	}
 
-	size := asset.Attributes.GetInt("size")
-	if size == 0 {
-		size = 8
-	}
+	size := asset.Attributes.GetIntOr("size", 8)
 
 	return []types.UsageVector{
 		{
@@ -36,7 +36,7 @@ func (m *VMMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext) (
 		}, nil
 	}
 
-	monthlyHours := ctx.ResolveOrDefault("monthly_hours", 730)
+	monthlyHours := ctx.MonthlyHours()
 
 	return []clouds.UsageVector{
 		clouds.NewUsageVector(clouds.MetricMonthlyHours, monthlyHours, 0.95),
@@ -60,20 +60,20 @@ func (m *VMMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVe
 
 	monthlyHours, _ := usageVecs.Get(clouds.MetricMonthlyHours)
 
+	rateKey, err := clouds.SchemaRateKey(asset, m.ResourceType(), "Virtual Machines", "compute", map[string]string{
+		"vmSize": vmSize,
+		"os":     "Linux",
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return []clouds.CostUnit{
 		clouds.NewCostUnit(
 			"compute",
 			"hours",
 			monthlyHours,
-			clouds.RateKey{
-				Provider: asset.ProviderContext.ProviderID,
-				Service:  "Virtual Machines",
-				Region:   asset.ProviderContext.Region,
-				Attributes: map[string]string{
-					"vmSize": vmSize,
-					"os":     "Linux",
-				},
-			},
+			rateKey,
 			0.95,
 		),
 	}, nil
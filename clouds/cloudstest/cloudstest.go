@@ -0,0 +1,70 @@
+// Package cloudstest builds assets and prices them through a mapper for
+// mapper tests, so each cloud package tests its mappers against the same
+// fixture instead of keeping its own copy.
+package cloudstest
+
+import (
+	"math"
+	"testing"
+
+	"terraform-cost/clouds"
+)
+
+// Asset returns a single known instance of resourceType, addressed
+// "<resourceType>.this", in AWS us-east-1
+func Asset(resourceType string, attrs map[string]interface{}) clouds.AssetNode {
+	return clouds.AssetNode{
+		Address:         resourceType + ".this",
+		Type:            resourceType,
+		Attributes:      attrs,
+		ProviderContext: clouds.ProviderContext{ProviderID: "aws", Region: "us-east-1"},
+		Cardinality:     clouds.Cardinality{IsKnown: true, Count: 1},
+	}
+}
+
+// InRegion returns asset moved to providerID's region
+func InRegion(asset clouds.AssetNode, providerID, region string) clouds.AssetNode {
+	asset.ProviderContext = clouds.ProviderContext{ProviderID: providerID, Region: region}
+	return asset
+}
+
+// UnitList prices asset through m with the given usage overrides and
+// returns its cost units in the mapper's order, failing the test on error
+func UnitList(t testing.TB, m clouds.AssetCostMapper, asset clouds.AssetNode, overrides map[string]interface{}) []clouds.CostUnit {
+	t.Helper()
+	return ContextUnitList(t, m, asset, clouds.UsageContext{Overrides: overrides})
+}
+
+// ContextUnitList is UnitList with a whole usage context, e.g. one with a
+// billing month other than the default
+func ContextUnitList(t testing.TB, m clouds.AssetCostMapper, asset clouds.AssetNode, ctx clouds.UsageContext) []clouds.CostUnit {
+	t.Helper()
+	usage, err := m.BuildUsage(asset, ctx)
+	if err != nil {
+		t.Fatalf("BuildUsage: %v", err)
+	}
+	units, err := m.BuildCostUnits(asset, usage)
+	if err != nil {
+		t.Fatalf("BuildCostUnits: %v", err)
+	}
+	return units
+}
+
+// Units is UnitList indexed by unit name
+func Units(t testing.TB, m clouds.AssetCostMapper, asset clouds.AssetNode, overrides map[string]interface{}) map[string]clouds.CostUnit {
+	t.Helper()
+	units := UnitList(t, m, asset, overrides)
+	byName := make(map[string]clouds.CostUnit, len(units))
+	for _, u := range units {
+		byName[u.Name] = u
+	}
+	return byName
+}
+
+// Quantity fails the test unless u's quantity is want
+func Quantity(t testing.TB, u clouds.CostUnit, want float64) {
+	t.Helper()
+	if u.Quantity == nil || math.Abs(*u.Quantity-want) > 1e-6 {
+		t.Errorf("%s: quantity = %v, want %v", u.Name, u.Quantity, want)
+	}
+}
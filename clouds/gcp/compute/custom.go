@@ -0,0 +1,102 @@
+// Package compute - GCE custom machine types and committed use discounts
+// A custom machine type ("custom-4-8192", "n2-custom-4-8192-ext") has no
+// SKU of its own: its vCPUs and memory are billed separately per hour.
+// Memory above the family's per-vCPU maximum is extended memory, billed
+// at its own rate. Committed use discounts bill resources at a fixed
+// fraction of the on-demand rate for a 1 or 3 year commitment.
+package compute
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CustomMachineType is a parsed custom machine type
+type CustomMachineType struct {
+	// Family is the machine series (n1 when the type has no prefix)
+	Family string
+
+	VCPUs    int
+	MemoryMB int
+
+	// Extended allows memory beyond the family's per-vCPU maximum
+	Extended bool
+}
+
+// maxMemoryPerVCPUGB is the memory per vCPU a custom type may have
+// before it counts as extended memory
+var maxMemoryPerVCPUGB = map[string]float64{
+	"n1":  6.5,
+	"n2":  8,
+	"n2d": 8,
+	"e2":  8,
+}
+
+// ParseCustomMachineType parses "[family-]custom-<vcpus>-<memory MB>[-ext]"
+func ParseCustomMachineType(machineType string) (CustomMachineType, bool) {
+	family, rest, ok := strings.Cut(machineType, "custom-")
+	if !ok {
+		return CustomMachineType{}, false
+	}
+	family = strings.TrimSuffix(family, "-")
+	if family == "" {
+		family = "n1"
+	}
+
+	rest, extended := strings.CutSuffix(rest, "-ext")
+	vcpus, memory, ok := strings.Cut(rest, "-")
+	if !ok {
+		return CustomMachineType{}, false
+	}
+	n, err := strconv.Atoi(vcpus)
+	if err != nil || n < 1 {
+		return CustomMachineType{}, false
+	}
+	mb, err := strconv.Atoi(memory)
+	if err != nil || mb < 1 {
+		return CustomMachineType{}, false
+	}
+	return CustomMachineType{Family: family, VCPUs: n, MemoryMB: mb, Extended: extended}, true
+}
+
+// MemoryGB is the total memory in GB
+func (t CustomMachineType) MemoryGB() float64 {
+	return float64(t.MemoryMB) / 1024
+}
+
+// SplitMemoryGB divides memory into standard and extended GB. Only -ext
+// types have extended memory; families without a known maximum have none.
+func (t CustomMachineType) SplitMemoryGB() (standard, extended float64) {
+	total := t.MemoryGB()
+	perVCPU, ok := maxMemoryPerVCPUGB[t.Family]
+	if !t.Extended || !ok {
+		return total, 0
+	}
+	limit := perVCPU * float64(t.VCPUs)
+	if total <= limit {
+		return total, 0
+	}
+	return limit, total - limit
+}
+
+// Committed use discount factors: the fraction of the on-demand rate
+// billed under a resource-based commitment
+const (
+	CommittedUse1YearFactor = 0.63
+	CommittedUse3YearFactor = 0.45
+)
+
+// committedUseFactor returns the factor for a commitment term in years;
+// 0 is on-demand
+func committedUseFactor(years float64) (float64, error) {
+	switch years {
+	case 0:
+		return 1, nil
+	case 1:
+		return CommittedUse1YearFactor, nil
+	case 3:
+		return CommittedUse3YearFactor, nil
+	}
+	return 0, fmt.Errorf("%s must be 0, 1 or 3, got %g", MetricCommitmentYears, years)
+}
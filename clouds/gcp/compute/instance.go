@@ -2,6 +2,7 @@
 // Clean-room implementation based on GCE pricing model:
 // - Machine type hours (by machine type, region)
 // - Preemptible/Spot VMs
+// - Custom machine types (vCPU and memory hours)
 // - Committed use discounts
 // - Sustained use discounts
 package compute
@@ -10,6 +11,10 @@ import (
 	"terraform-cost/clouds"
 )
 
+// MetricCommitmentYears is the committed use discount term: 0 (on-demand),
+// 1 or 3. Set it with the "commitment_years" usage override.
+const MetricCommitmentYears clouds.Metric = "commitment_years"
+
 // InstanceMapper maps google_compute_instance to cost units
 type InstanceMapper struct{}
 
@@ -36,11 +41,18 @@ func (m *InstanceMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageCont
 		}, nil
 	}
 
-	monthlyHours := ctx.ResolveOrDefault("monthly_hours", 730)
+	monthlyHours := ctx.MonthlyHours()
 
-	return []clouds.UsageVector{
+	usage := []clouds.UsageVector{
 		clouds.NewUsageVector(clouds.MetricMonthlyHours, monthlyHours, 0.95),
-	}, nil
+	}
+	if years, ok := ctx.Resolve(string(MetricCommitmentYears)); ok {
+		if _, err := committedUseFactor(years); err != nil {
+			return nil, err
+		}
+		usage = append(usage, clouds.NewUsageVector(MetricCommitmentYears, years, 1.0))
+	}
+	return usage, nil
 }
 
 // BuildCostUnits creates cost units for a GCE instance
@@ -60,22 +72,67 @@ func (m *InstanceMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.U
 
 	monthlyHours, _ := usageVecs.Get(clouds.MetricMonthlyHours)
 
+	// Committed use bills a fixed fraction of the on-demand rate, so
+	// committed resources are on-demand rates over discounted hours
+	years, _ := usageVecs.Get(MetricCommitmentYears)
+	factor, err := committedUseFactor(years)
+	if err != nil {
+		return nil, err
+	}
+	hours := monthlyHours * factor
+
+	if custom, ok := ParseCustomMachineType(machineType); ok {
+		return customCostUnits(asset, custom, hours)
+	}
+
+	key, err := rateKey(asset, "compute", map[string]string{
+		"machineType": machineType,
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	// GCP applies sustained use discounts automatically
 	// For estimation, we use full hourly rate
 	return []clouds.CostUnit{
-		clouds.NewCostUnit(
-			"compute",
-			"hours",
-			monthlyHours,
-			clouds.RateKey{
-				Provider: asset.ProviderContext.ProviderID,
-				Service:  "Compute Engine",
-				Region:   asset.ProviderContext.Region,
-				Attributes: map[string]string{
-					"machineType": machineType,
-				},
-			},
-			0.95,
-		),
+		clouds.NewCostUnit("compute", "hours", hours, key, 0.95),
 	}, nil
 }
+
+// customCostUnits prices a custom machine type's vCPUs and memory
+// separately, with extended memory at its own rate
+func customCostUnits(asset clouds.AssetNode, custom CustomMachineType, hours float64) ([]clouds.CostUnit, error) {
+	standardGB, extendedGB := custom.SplitMemoryGB()
+
+	parts := []struct {
+		component string
+		measure   string
+		resource  string
+		quantity  float64
+	}{
+		{"vcpu", "vCPU-hours", "custom_core", float64(custom.VCPUs) * hours},
+		{"memory", "GB-hours", "custom_ram", standardGB * hours},
+		{"extended_memory", "GB-hours", "custom_extended_ram", extendedGB * hours},
+	}
+
+	var units []clouds.CostUnit
+	for _, part := range parts {
+		if part.component == "extended_memory" && extendedGB == 0 {
+			continue
+		}
+		key, err := rateKey(asset, part.component, map[string]string{
+			"machineFamily": custom.Family,
+			"resource":      part.resource,
+		})
+		if err != nil {
+			return nil, err
+		}
+		units = append(units, clouds.NewCostUnit(part.component, part.measure, part.quantity, key, 0.9))
+	}
+	return units, nil
+}
+
+// rateKey is a Compute Engine rate key in the instance's region
+func rateKey(asset clouds.AssetNode, component string, attrs map[string]string) (clouds.RateKey, error) {
+	return clouds.SchemaRateKey(asset, "google_compute_instance", "Compute Engine", component, attrs)
+}
@@ -0,0 +1,80 @@
+// Package compute - GCE instance mapper tests
+package compute
+
+import (
+	"testing"
+
+	"terraform-cost/clouds"
+	"terraform-cost/clouds/cloudstest"
+)
+
+func instanceUnits(t *testing.T, machineType string, overrides map[string]interface{}) map[string]clouds.CostUnit {
+	t.Helper()
+	asset := cloudstest.InRegion(cloudstest.Asset("google_compute_instance", map[string]interface{}{"machine_type": machineType}), "google", "us-central1")
+	return cloudstest.Units(t, NewInstanceMapper(), asset, overrides)
+}
+
+func TestParseCustomMachineType(t *testing.T) {
+	for input, want := range map[string]CustomMachineType{
+		"custom-4-8192":        {Family: "n1", VCPUs: 4, MemoryMB: 8192},
+		"n2-custom-8-16384":    {Family: "n2", VCPUs: 8, MemoryMB: 16384},
+		"n2d-custom-2-4096":    {Family: "n2d", VCPUs: 2, MemoryMB: 4096},
+		"custom-2-20480-ext":   {Family: "n1", VCPUs: 2, MemoryMB: 20480, Extended: true},
+		"e2-custom-2-8192-ext": {Family: "e2", VCPUs: 2, MemoryMB: 8192, Extended: true},
+	} {
+		got, ok := ParseCustomMachineType(input)
+		if !ok || got != want {
+			t.Errorf("ParseCustomMachineType(%q) = %+v, %v", input, got, ok)
+		}
+	}
+	for _, input := range []string{"e2-standard-4", "custom-4", "custom-x-8192", "custom-0-1024"} {
+		if _, ok := ParseCustomMachineType(input); ok {
+			t.Errorf("ParseCustomMachineType(%q) should not parse", input)
+		}
+	}
+}
+
+// TestCustomMachineType proves vCPUs and memory are priced separately
+func TestCustomMachineType(t *testing.T) {
+	units := instanceUnits(t, "custom-4-8192", nil)
+	if _, ok := units["compute"]; ok {
+		t.Error("custom type should not be priced as a catalog machine type")
+	}
+	vcpu, memory := units["vcpu"], units["memory"]
+	cloudstest.Quantity(t, vcpu, 4*730)
+	cloudstest.Quantity(t, memory, 8*730)
+	if got := vcpu.RateKey.Attributes; got["machineFamily"] != "n1" || got["resource"] != "custom_core" {
+		t.Errorf("vcpu rate key = %v", got)
+	}
+	if got := memory.RateKey.Attributes["resource"]; got != "custom_ram" {
+		t.Errorf("memory rate key resource = %q", got)
+	}
+	if _, ok := units["extended_memory"]; ok {
+		t.Error("unexpected extended memory")
+	}
+}
+
+// TestCustomExtendedMemory proves memory over the per-vCPU maximum is
+// priced as extended memory
+func TestCustomExtendedMemory(t *testing.T) {
+	// n1 allows 6.5 GB per vCPU: 13 GB standard, 7 GB extended
+	units := instanceUnits(t, "custom-2-20480-ext", nil)
+	cloudstest.Quantity(t, units["memory"], 13*730)
+	cloudstest.Quantity(t, units["extended_memory"], 7*730)
+}
+
+// TestCommittedUseDiscount proves commitments scale billed hours
+func TestCommittedUseDiscount(t *testing.T) {
+	units := instanceUnits(t, "n2-custom-8-32768", map[string]interface{}{"commitment_years": 3})
+	cloudstest.Quantity(t, units["vcpu"], 8*730*CommittedUse3YearFactor)
+	cloudstest.Quantity(t, units["memory"], 32*730*CommittedUse3YearFactor)
+
+	units = instanceUnits(t, "e2-standard-4", map[string]interface{}{"commitment_years": 1})
+	cloudstest.Quantity(t, units["compute"], 730*CommittedUse1YearFactor)
+
+	m := NewInstanceMapper()
+	asset := clouds.AssetNode{Cardinality: clouds.Cardinality{IsKnown: true, Count: 1}}
+	if _, err := m.BuildUsage(asset, clouds.UsageContext{Overrides: map[string]interface{}{"commitment_years": 2}}); err == nil {
+		t.Error("expected an error for a 2 year commitment")
+	}
+}
@@ -0,0 +1,204 @@
+// Package database - GCP Cloud SQL cost mapper
+// Clean-room implementation based on Cloud SQL pricing model:
+// - Custom tiers ("db-custom-4-16384"): vCPU hours and memory GB-hours
+// - Shared-core tiers ("db-f1-micro", "db-g1-small"): instance hours
+// - High availability (REGIONAL) bills compute and storage twice
+// - Storage GB-months by disk type (PD_SSD, PD_HDD)
+// - Backup storage GB-months, from the usage file
+package database
+
+import (
+	"strconv"
+	"strings"
+
+	"terraform-cost/clouds"
+)
+
+// MetricBackupStorageGB is the backup storage kept per month
+const MetricBackupStorageGB clouds.Metric = "backup_storage_gb"
+
+// SQLInstanceMapper maps google_sql_database_instance to cost units
+type SQLInstanceMapper struct{}
+
+// NewSQLInstanceMapper creates a Cloud SQL instance mapper
+func NewSQLInstanceMapper() *SQLInstanceMapper {
+	return &SQLInstanceMapper{}
+}
+
+// Cloud returns the cloud provider
+func (m *SQLInstanceMapper) Cloud() clouds.CloudProvider {
+	return clouds.GCP
+}
+
+// ResourceType returns the Terraform resource type
+func (m *SQLInstanceMapper) ResourceType() string {
+	return "google_sql_database_instance"
+}
+
+// BuildUsage extracts usage vectors from a Cloud SQL instance
+func (m *SQLInstanceMapper) BuildUsage(asset clouds.AssetNode, ctx clouds.UsageContext) ([]clouds.UsageVector, error) {
+	if asset.Cardinality.IsUnknown() {
+		return []clouds.UsageVector{
+			clouds.SymbolicUsage(clouds.MetricMonthlyHours, "unknown Cloud SQL instance count: "+asset.Cardinality.Reason),
+		}, nil
+	}
+
+	monthlyHours := ctx.MonthlyHours()
+
+	usage := []clouds.UsageVector{
+		clouds.NewUsageVector(clouds.MetricMonthlyHours, monthlyHours, 0.95),
+	}
+
+	// Backup size depends on the data and its change rate; it is only
+	// reported when the usage file provides it
+	if backupGB, ok := ctx.Resolve(string(MetricBackupStorageGB)); ok {
+		usage = append(usage, clouds.NewUsageVector(MetricBackupStorageGB, backupGB, 0.7))
+	}
+	return usage, nil
+}
+
+// BuildCostUnits creates cost units for a Cloud SQL instance
+func (m *SQLInstanceMapper) BuildCostUnits(asset clouds.AssetNode, usage []clouds.UsageVector) ([]clouds.CostUnit, error) {
+	usageVecs := clouds.UsageVectors(usage)
+
+	if usageVecs.IsSymbolic() {
+		return []clouds.CostUnit{
+			clouds.SymbolicCost("instance", "Cloud SQL cost unknown due to cardinality"),
+		}, nil
+	}
+
+	settings := sqlSettings(asset)
+
+	tier := settings.Attr("tier")
+	if tier == "" {
+		return []clouds.CostUnit{
+			clouds.SymbolicCost("instance", "Cloud SQL tier unknown until apply"),
+		}, nil
+	}
+
+	// A REGIONAL instance keeps a standby in a second zone, billed like
+	// the primary
+	replicas := 1.0
+	if strings.EqualFold(settings.Attr("availability_type"), "REGIONAL") {
+		replicas = 2
+	}
+
+	monthlyHours, _ := usageVecs.Get(clouds.MetricMonthlyHours)
+	hours := monthlyHours * replicas
+
+	var units []clouds.CostUnit
+	if custom, ok := ParseCustomTier(tier); ok {
+		for _, part := range []struct {
+			component string
+			measure   string
+			quantity  float64
+		}{
+			{"vcpu", "vCPU-hours", float64(custom.VCPUs) * hours},
+			{"memory", "GB-hours", custom.MemoryGB() * hours},
+		} {
+			key, err := sqlRateKey(asset, part.component, map[string]string{"resource": "sql_" + part.component})
+			if err != nil {
+				return nil, err
+			}
+			units = append(units, clouds.NewCostUnit(part.component, part.measure, part.quantity, key, 0.9))
+		}
+	} else {
+		key, err := sqlRateKey(asset, "instance", map[string]string{"tier": tier})
+		if err != nil {
+			return nil, err
+		}
+		units = append(units, clouds.NewCostUnit("instance", "hours", hours, key, 0.95))
+	}
+
+	diskType := settings.Attr("disk_type")
+	if diskType == "" {
+		diskType = "PD_SSD"
+	}
+	storageKey, err := sqlRateKey(asset, "storage", map[string]string{"diskType": diskType})
+	if err != nil {
+		return nil, err
+	}
+	units = append(units, clouds.NewCostUnit("storage", "GB-months", settings.AttrFloat("disk_size", 10)*replicas, storageKey, 0.95))
+
+	if backupGB, ok := usageVecs.Get(MetricBackupStorageGB); ok {
+		key, err := sqlRateKey(asset, "backups", map[string]string{"resource": "sql_backup"})
+		if err != nil {
+			return nil, err
+		}
+		units = append(units, clouds.NewCostUnit("backups", "GB-months", backupGB, key, 0.7))
+	} else if backupsEnabled(settings) {
+		units = append(units, clouds.SymbolicCost("backups", "backup storage depends on data size; set backup_storage_gb in the usage file"))
+	}
+
+	return units, nil
+}
+
+// CustomTier is a parsed Cloud SQL custom tier
+type CustomTier struct {
+	VCPUs    int
+	MemoryMB int
+}
+
+// MemoryGB returns the tier's memory in GB
+func (t CustomTier) MemoryGB() float64 {
+	return float64(t.MemoryMB) / 1024
+}
+
+// ParseCustomTier parses "db-custom-<vcpus>-<memory MB>"
+func ParseCustomTier(tier string) (CustomTier, bool) {
+	rest, ok := strings.CutPrefix(tier, "db-custom-")
+	if !ok {
+		return CustomTier{}, false
+	}
+	parts := strings.Split(rest, "-")
+	if len(parts) != 2 {
+		return CustomTier{}, false
+	}
+	vcpus, err := strconv.Atoi(parts[0])
+	if err != nil || vcpus <= 0 {
+		return CustomTier{}, false
+	}
+	memoryMB, err := strconv.Atoi(parts[1])
+	if err != nil || memoryMB <= 0 {
+		return CustomTier{}, false
+	}
+	return CustomTier{VCPUs: vcpus, MemoryMB: memoryMB}, true
+}
+
+// sqlSettings reads the settings block, either as a list of objects (plan
+// JSON) or as flattened "settings.0.*" keys
+func sqlSettings(asset clouds.AssetNode) clouds.AssetNode {
+	if blocks, ok := asset.Attributes["settings"].([]interface{}); ok {
+		if len(blocks) > 0 {
+			if block, ok := blocks[0].(map[string]interface{}); ok {
+				return clouds.AssetNode{Attributes: block}
+			}
+		}
+		return clouds.AssetNode{}
+	}
+
+	const prefix = "settings.0."
+	attrs := make(map[string]interface{})
+	for key, v := range asset.Attributes {
+		if name, ok := strings.CutPrefix(key, prefix); ok {
+			attrs[name] = v
+		}
+	}
+	return clouds.AssetNode{Attributes: attrs}
+}
+
+// backupsEnabled reports whether automated backups are on; they are off
+// unless backup_configuration enables them
+func backupsEnabled(settings clouds.AssetNode) bool {
+	if blocks, ok := settings.Attributes["backup_configuration"].([]interface{}); ok && len(blocks) > 0 {
+		if block, ok := blocks[0].(map[string]interface{}); ok {
+			return clouds.AssetNode{Attributes: block}.AttrBool("enabled", false)
+		}
+	}
+	return settings.AttrBool("backup_configuration.0.enabled", false)
+}
+
+// sqlRateKey is a Cloud SQL rate key in the instance's region
+func sqlRateKey(asset clouds.AssetNode, component string, attrs map[string]string) (clouds.RateKey, error) {
+	return clouds.SchemaRateKey(asset, "google_sql_database_instance", "Cloud SQL", component, attrs)
+}
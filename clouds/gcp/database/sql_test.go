@@ -0,0 +1,83 @@
+// Package database - Cloud SQL mapper tests
+package database
+
+import (
+	"testing"
+
+	"terraform-cost/clouds"
+	"terraform-cost/clouds/cloudstest"
+)
+
+func sqlUnits(t *testing.T, settings map[string]interface{}, overrides map[string]interface{}) map[string]clouds.CostUnit {
+	t.Helper()
+	asset := cloudstest.InRegion(cloudstest.Asset("google_sql_database_instance", map[string]interface{}{"database_version": "POSTGRES_15", "settings": []interface{}{settings}}), "google", "us-central1")
+	return cloudstest.Units(t, NewSQLInstanceMapper(), asset, overrides)
+}
+
+// TestRegionalCustomTier proves a custom tier is priced as vCPU and memory
+// hours, and REGIONAL availability doubles compute and storage
+func TestRegionalCustomTier(t *testing.T) {
+	units := sqlUnits(t, map[string]interface{}{
+		"tier":              "db-custom-4-16384",
+		"availability_type": "REGIONAL",
+		"disk_size":         100.0,
+		"disk_type":         "PD_SSD",
+		"backup_configuration": []interface{}{
+			map[string]interface{}{"enabled": true},
+		},
+	}, map[string]interface{}{"backup_storage_gb": 40.0})
+
+	if _, ok := units["instance"]; ok {
+		t.Error("custom tier should not be priced as a named tier")
+	}
+	cloudstest.Quantity(t, units["vcpu"], 2*4*730)
+	cloudstest.Quantity(t, units["memory"], 2*16*730)
+	cloudstest.Quantity(t, units["storage"], 2*100)
+	cloudstest.Quantity(t, units["backups"], 40)
+
+	if got := units["vcpu"].RateKey.Attributes["resource"]; got != "sql_vcpu" {
+		t.Errorf("vcpu rate key resource = %q", got)
+	}
+	if got := units["storage"].RateKey; got.Service != "Cloud SQL" || got.Attributes["diskType"] != "PD_SSD" {
+		t.Errorf("storage rate key = %+v", got)
+	}
+}
+
+// TestZonalSharedCoreTier proves a shared-core tier is one instance-hour
+// per hour, and enabled backups without usage are symbolic
+func TestZonalSharedCoreTier(t *testing.T) {
+	units := sqlUnits(t, map[string]interface{}{
+		"tier": "db-f1-micro",
+		"backup_configuration": []interface{}{
+			map[string]interface{}{"enabled": true},
+		},
+	}, nil)
+
+	cloudstest.Quantity(t, units["instance"], 730)
+	if got := units["instance"].RateKey.Attributes["tier"]; got != "db-f1-micro" {
+		t.Errorf("instance rate key tier = %q", got)
+	}
+	cloudstest.Quantity(t, units["storage"], 10)
+	if !units["backups"].IsSymbolic {
+		t.Errorf("backups without usage should be symbolic, got %+v", units["backups"])
+	}
+}
+
+// TestUnknownTier proves a tier only known after apply is symbolic
+func TestUnknownTier(t *testing.T) {
+	units := sqlUnits(t, map[string]interface{}{"availability_type": "REGIONAL"}, nil)
+	if len(units) != 1 || !units["instance"].IsSymbolic {
+		t.Errorf("units = %+v, want a single symbolic instance", units)
+	}
+}
+
+func TestParseCustomTier(t *testing.T) {
+	if got, ok := ParseCustomTier("db-custom-2-7680"); !ok || got != (CustomTier{VCPUs: 2, MemoryMB: 7680}) {
+		t.Errorf("ParseCustomTier = %+v, %v", got, ok)
+	}
+	for _, tier := range []string{"db-f1-micro", "db-custom-2", "db-custom-x-1024", "db-custom-0-1024", "db-n1-standard-2"} {
+		if _, ok := ParseCustomTier(tier); ok {
+			t.Errorf("ParseCustomTier(%q) should not parse", tier)
+		}
+	}
+}
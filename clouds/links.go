@@ -0,0 +1,73 @@
+// Package clouds - Dependent resources
+// Some resources change what another costs without being billed
+// themselves: a lifecycle configuration moves a bucket's objects to
+// cheaper storage classes. The infrastructure graph links them through
+// their references, and LinkDependents hands each node the nodes that
+// reference it, so its mapper can account for them.
+package clouds
+
+import "strings"
+
+// LinkDependents returns the nodes with Dependents set from dependents,
+// which lists the addresses that reference an address (for example
+// InfrastructureGraph.GetDependents). The graph may address definitions
+// rather than instances, so an instance is also linked to what references
+// its resource, and a dependent definition links each of its instances.
+func LinkDependents(nodes []AssetNode, dependents func(address string) []string) []AssetNode {
+	byAddress := make(map[string][]int, len(nodes))
+	for i, node := range nodes {
+		byAddress[node.Address] = append(byAddress[node.Address], i)
+		if resource := resourceAddress(node.Address); resource != node.Address {
+			byAddress[resource] = append(byAddress[resource], i)
+		}
+	}
+
+	linked := make([]AssetNode, len(nodes))
+	for i, node := range nodes {
+		seen := make(map[int]bool)
+		var deps []AssetNode
+		for _, address := range lookupAddresses(node.Address) {
+			for _, dependent := range dependents(address) {
+				for _, j := range byAddress[dependent] {
+					if j != i && !seen[j] {
+						seen[j] = true
+						deps = append(deps, nodes[j])
+					}
+				}
+			}
+		}
+		node.Dependents = deps
+		linked[i] = node
+	}
+	return linked
+}
+
+// lookupAddresses returns an address and, for an instance, its resource's
+func lookupAddresses(address string) []string {
+	if resource := resourceAddress(address); resource != address {
+		return []string{address, resource}
+	}
+	return []string{address}
+}
+
+// resourceAddress strips an instance key: aws_s3_bucket.logs[0] is an
+// instance of aws_s3_bucket.logs
+func resourceAddress(address string) string {
+	if strings.HasSuffix(address, "]") {
+		if i := strings.LastIndex(address, "["); i > 0 {
+			return address[:i]
+		}
+	}
+	return address
+}
+
+// DependentsOfType returns the node's dependents of one resource type
+func (a AssetNode) DependentsOfType(resourceType string) []AssetNode {
+	var matched []AssetNode
+	for _, dep := range a.Dependents {
+		if dep.Type == resourceType {
+			matched = append(matched, dep)
+		}
+	}
+	return matched
+}
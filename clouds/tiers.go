@@ -0,0 +1,53 @@
+// Package clouds - Volume tiers
+// Many services bill monthly volume in bands, each cheaper than the last:
+// SQS prices the first 100 billion requests above the next. SplitTiers
+// divides a volume among the bands, and TieredCostUnits prices each band
+// as its own cost unit at its own rate.
+package clouds
+
+// VolumeTier is a band of monthly volume billed at one rate
+type VolumeTier struct {
+	// Name is the tier's rate key attribute and cost unit suffix
+	Name string
+
+	// UpTo is the monthly volume the band ends at (0 = unbounded)
+	UpTo float64
+}
+
+// SplitTiers returns the volume falling in each tier
+func SplitTiers(volume float64, tiers []VolumeTier) []float64 {
+	split := make([]float64, len(tiers))
+	from := 0.0
+	for i, t := range tiers {
+		if volume <= from {
+			break
+		}
+		if t.UpTo == 0 || volume <= t.UpTo {
+			split[i] = volume - from
+			break
+		}
+		split[i] = t.UpTo - from
+		from = t.UpTo
+	}
+	return split
+}
+
+// TieredCostUnits prices volume across tiers, one cost unit per tier it
+// reaches: the first is named name, later ones name_<tier>. Each unit's
+// quantity is its share of the volume divided by perUnit, e.g. 1e6 for
+// per-million pricing. The first tier is always priced, so zero volume
+// reads as $0.
+func TieredCostUnits(name, measure string, volume, perUnit float64, tiers []VolumeTier, rateKey func(tier string) RateKey, confidence float64) []CostUnit {
+	var units []CostUnit
+	for i, tierVolume := range SplitTiers(volume, tiers) {
+		if i > 0 && tierVolume == 0 {
+			continue
+		}
+		unitName := name
+		if i > 0 {
+			unitName = name + "_" + tiers[i].Name
+		}
+		units = append(units, NewCostUnit(unitName, measure, tierVolume/perUnit, rateKey(tiers[i].Name), confidence))
+	}
+	return units
+}
@@ -5,6 +5,9 @@ package clouds
 
 import (
 	"fmt"
+
+	"terraform-cost/core/catalog"
+	"terraform-cost/core/determinism"
 )
 
 // CloudProvider identifies a cloud provider
@@ -52,9 +55,21 @@ type AssetNode struct {
 
 	// InstanceKey for expanded resources (count/for_each)
 	InstanceKey string
+
+	// Dependents are the resources that reference this one, such as a
+	// bucket's aws_s3_bucket_lifecycle_configuration (see LinkDependents)
+	Dependents []AssetNode
+}
+
+// HasAttr reports whether an attribute is set. A plan records an unset
+// optional argument as null, which is not set, so a mapper can tell it
+// from an explicit zero.
+func (a AssetNode) HasAttr(key string) bool {
+	return a.Attributes[key] != nil
 }
 
-// Attr returns an attribute value as string
+// Attr returns an attribute value as string, or "" when it is unset, null
+// or not a string
 func (a AssetNode) Attr(key string) string {
 	if v, ok := a.Attributes[key].(string); ok {
 		return v
@@ -62,7 +77,9 @@ func (a AssetNode) Attr(key string) string {
 	return ""
 }
 
-// AttrFloat returns an attribute value as float64
+// AttrFloat returns an attribute value as float64, or defaultVal when it
+// is unset or null, so mappers pass the provider's documented default
+// rather than zero
 func (a AssetNode) AttrFloat(key string, defaultVal float64) float64 {
 	if v, ok := a.Attributes[key].(float64); ok {
 		return v
@@ -73,7 +90,8 @@ func (a AssetNode) AttrFloat(key string, defaultVal float64) float64 {
 	return defaultVal
 }
 
-// AttrInt returns an attribute value as int
+// AttrInt returns an attribute value as int, or defaultVal when it is
+// unset or null (see AttrFloat)
 func (a AssetNode) AttrInt(key string, defaultVal int) int {
 	if v, ok := a.Attributes[key].(int); ok {
 		return v
@@ -84,7 +102,8 @@ func (a AssetNode) AttrInt(key string, defaultVal int) int {
 	return defaultVal
 }
 
-// AttrBool returns an attribute value as bool
+// AttrBool returns an attribute value as bool, or defaultVal when it is
+// unset or null (see AttrFloat)
 func (a AssetNode) AttrBool(key string, defaultVal bool) bool {
 	if v, ok := a.Attributes[key].(bool); ok {
 		return v
@@ -129,16 +148,46 @@ type UsageContext struct {
 
 	// Confidence is the confidence in the usage values
 	Confidence float64
+
+	// HoursPerMonth is the billing month of the estimate
+	// (0 = determinism.DefaultHoursPerMonth)
+	HoursPerMonth float64
+}
+
+// BillingHours returns the hours in the estimate's billing month, which
+// always-on resources run for
+func (ctx UsageContext) BillingHours() float64 {
+	if ctx.HoursPerMonth > 0 {
+		return ctx.HoursPerMonth
+	}
+	return determinism.DefaultHoursPerMonth
+}
+
+// MonthlyHours returns the monthly_hours override, or the hours in the
+// billing month
+func (ctx UsageContext) MonthlyHours() float64 {
+	return ctx.ResolveOrDefault(string(MetricMonthlyHours), ctx.BillingHours())
 }
 
 // ResolveOrDefault returns an override value or default
 func (ctx UsageContext) ResolveOrDefault(key string, defaultVal float64) float64 {
-	if v, ok := ctx.Overrides[key].(float64); ok {
+	if v, ok := ctx.Resolve(key); ok {
 		return v
 	}
 	return defaultVal
 }
 
+// Resolve returns an override value and whether it was provided
+func (ctx UsageContext) Resolve(key string) (float64, bool) {
+	switch v := ctx.Overrides[key].(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
 // Metric is a usage metric type
 type Metric string
 
@@ -167,6 +216,10 @@ type UsageVector struct {
 
 	// Confidence in this usage value (0.0 to 1.0)
 	Confidence float64
+
+	// Assumption explains a value derived rather than read from the
+	// usage file, for the lineage of the cost units it quantifies
+	Assumption string
 }
 
 // NewUsageVector creates a concrete usage vector
@@ -211,6 +264,28 @@ func (vs UsageVectors) Get(metric Metric) (float64, bool) {
 	return 0, false
 }
 
+// Metric returns the usage vectors of one metric
+func (vs UsageVectors) Metric(metric Metric) UsageVectors {
+	var out UsageVectors
+	for _, v := range vs {
+		if v.Metric == metric {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// SymbolicReason returns the reason of the first symbolic usage vector
+// that gives one, or fallback when none does
+func (vs UsageVectors) SymbolicReason(fallback string) string {
+	for _, v := range vs {
+		if v.IsSymbolic && v.SymbolicReason != "" {
+			return v.SymbolicReason
+		}
+	}
+	return fallback
+}
+
 // CostUnit represents a billable cost component
 type CostUnit struct {
 	// Name of the cost component (e.g., "compute", "storage")
@@ -233,6 +308,9 @@ type CostUnit struct {
 
 	// Confidence in this cost (0.0 to 1.0)
 	Confidence float64
+
+	// Assumptions behind the quantity, recorded in its lineage
+	Assumptions []string
 }
 
 // NewCostUnit creates a concrete cost unit
@@ -275,3 +353,19 @@ type RateKey struct {
 func (k RateKey) String() string {
 	return fmt.Sprintf("%s/%s/%s/%v", k.Provider, k.Service, k.Region, k.Attributes)
 }
+
+// SchemaRateKey builds the rate key of a resource type's cost component in
+// the asset's provider and region, keeping only the attributes the
+// catalog's rate-key schema lists as price-relevant
+func SchemaRateKey(asset AssetNode, resourceType, service, component string, values map[string]string) (RateKey, error) {
+	attrs, err := catalog.BuildRateKeyAttributes(resourceType, component, values)
+	if err != nil {
+		return RateKey{}, err
+	}
+	return RateKey{
+		Provider:   asset.ProviderContext.ProviderID,
+		Service:    service,
+		Region:     asset.ProviderContext.Region,
+		Attributes: attrs,
+	}, nil
+}
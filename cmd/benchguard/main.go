@@ -0,0 +1,55 @@
+// Package main - Benchmark regression guard
+// Reads go test -bench output on stdin and fails when a benchmark
+// regressed against the baseline; run through scripts/bench-guard.sh.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"terraform-cost/internal/benchguard"
+)
+
+func main() {
+	baselinePath := flag.String("baseline", "testdata/benchmarks/baseline.json", "Baseline results file")
+	timeThreshold := flag.Float64("time-threshold", benchguard.DefaultThresholds.Time, "Tolerated ns/op increase over the baseline, as a fraction")
+	allocThreshold := flag.Float64("alloc-threshold", benchguard.DefaultThresholds.Allocs, "Tolerated allocs/op increase over the baseline, as a fraction")
+	update := flag.Bool("update", false, "Write the results as the new baseline instead of comparing")
+	flag.Parse()
+
+	current, err := benchguard.Parse(os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	if len(current) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no benchmark results on stdin")
+		os.Exit(2)
+	}
+
+	if *update {
+		if err := benchguard.WriteBaseline(*baselinePath, current); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		fmt.Printf("Wrote %d benchmarks to %s\n", len(current), *baselinePath)
+		return
+	}
+
+	baseline, err := benchguard.ReadBaseline(*baselinePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	regressions := benchguard.Compare(baseline, current, benchguard.Thresholds{Time: *timeThreshold, Allocs: *allocThreshold})
+	if len(regressions) == 0 {
+		fmt.Printf("No regressions in %d benchmarks\n", len(baseline))
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%d benchmark regressions:\n", len(regressions))
+	for _, r := range regressions {
+		fmt.Fprintf(os.Stderr, "  %s\n", r)
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,241 @@
+// Package cmd - compare command
+// compare prices two versions of a project and diffs them resource by
+// resource with core/diff. With --match-by-attributes a resource whose
+// address changed but whose type and cost inputs did not, e.g. one moved
+// into a module without a moved block, is reported as moved with no cost
+// delta instead of as one removed and one added resource.
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"terraform-cost/core/cost"
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/diff"
+	"terraform-cost/core/model"
+	"terraform-cost/core/pricing"
+	"terraform-cost/core/scanner"
+	"terraform-cost/core/types"
+)
+
+var (
+	compareFormat            string
+	compareMatchByAttributes bool
+)
+
+// compareCmd diffs the cost of two versions of a project
+var compareCmd = &cobra.Command{
+	Use:   "compare <base> <head>",
+	Short: "Diff the cost of two versions of a project",
+	Long: `Price two versions of a project and list the resources added, removed,
+changed and moved between them with their monthly cost deltas.
+
+Each argument is a plan JSON file, a URL, or a module directory.
+
+Examples:
+  terraform-cost compare ./main ./feature-branch
+  terraform-cost compare --match-by-attributes --format json base.json head.json`,
+	Args: cobra.ExactArgs(2),
+	RunE: runCompare,
+}
+
+func init() {
+	rootCmd.AddCommand(compareCmd)
+
+	compareCmd.Flags().StringVarP(&compareFormat, "format", "f", "cli", "output format (cli, json)")
+	compareCmd.Flags().BoolVar(&compareMatchByAttributes, "match-by-attributes", false,
+		"report a resource whose address changed but whose type and cost inputs did not as moved, not removed and added")
+}
+
+func runCompare(cmd *cobra.Command, args []string) error {
+	if compareFormat != "cli" && compareFormat != "json" {
+		return fmt.Errorf("unknown format %q (available: cli, json)", compareFormat)
+	}
+	ctx := context.Background()
+
+	before, err := comparedCosts(ctx, args[0])
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	after, err := comparedCosts(ctx, args[1])
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[1], err)
+	}
+	result := compareCosts(before, after)
+
+	if compareFormat == "json" {
+		return writeCostDiffJSON(os.Stdout, result)
+	}
+	printCostDiff(os.Stdout, result)
+	return nil
+}
+
+// compareCosts diffs two estimates, matching moved resources by
+// attributes with --match-by-attributes
+func compareCosts(before, after *cost.AggregatedCostResult) *diff.DiffResult {
+	return diff.NewDiffer(0).WithAttributeMatching(compareMatchByAttributes).Diff(before, after)
+}
+
+// comparedCosts prices the plan or module directory at path
+func comparedCosts(ctx context.Context, path string) (*cost.AggregatedCostResult, error) {
+	var rawAssets []types.RawAsset
+	if isPlanInput(path) {
+		loaded, err := loadPlanAssets(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range loaded.warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+		}
+		rawAssets = loaded.assets
+	} else {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("path does not exist")
+		}
+		result, err := scanner.GetDefault().DetectAndScan(ctx, &types.ProjectInput{
+			ID:     "compare",
+			Path:   path,
+			Source: types.SourceCLI,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan project: %w", err)
+		}
+		for _, e := range result.Errors {
+			fmt.Fprintf(os.Stderr, "Warning: %s:%d: %s\n", e.File, e.Line, e.Message)
+		}
+		rawAssets = result.Assets
+	}
+
+	graph := buildAssetGraph(ctx, rawAssets)
+	return aggregatedCosts(graph, calculateCosts(graph)), nil
+}
+
+// aggregatedCosts converts an estimate to the instance costs core/diff
+// compares, one per asset with a cost
+func aggregatedCosts(graph *types.AssetGraph, costGraph *types.CostGraph) *cost.AggregatedCostResult {
+	// The built-in rates are not a snapshot
+	currency := string(costGraph.Currency)
+	aggregated := cost.NewAggregatedCostResult("")
+	graph.Walk(func(asset *types.Asset) error {
+		agg, ok := costGraph.ByAsset[asset.ID]
+		if !ok {
+			return nil
+		}
+		canonical, err := model.ParseAddress(string(asset.Address))
+		if err != nil {
+			canonical = model.CanonicalAddress(asset.Address)
+		}
+		identity := &model.InstanceIdentity{
+			Canonical:    canonical,
+			ResourceType: asset.Type,
+			ResourceName: asset.Name,
+		}
+		inst := cost.NewInstanceCostResult(identity, "")
+		for _, unit := range agg.Units {
+			component := strings.TrimPrefix(unit.ID, asset.ID+"-")
+			quantity, _ := unit.Quantity.Float64()
+			inst.AddComponent(&cost.CostWithProvenance{
+				Cost: &cost.ConfidenceBoundCost{
+					Monthly:    determinism.NewMoneyFromDecimal(unit.Amount, currency),
+					Hourly:     determinism.Zero(currency),
+					Confidence: unitConfidence(unit),
+				},
+				Identity:  identity,
+				Component: component,
+				Rate: &cost.RateProvenance{
+					RateKey:  pricing.RateKey{ResourceType: asset.Type, Component: component, Attributes: unit.Label},
+					Price:    unit.Rate.String(),
+					Unit:     unit.Measure,
+					Currency: currency,
+					WasFound: true,
+				},
+				Usage: &cost.UsageProvenance{Value: quantity, Unit: unit.Measure},
+			})
+		}
+		aggregated.Add(inst)
+		return nil
+	})
+	return aggregated
+}
+
+// unitConfidence is a unit's confidence, full when it was not assessed
+func unitConfidence(unit *types.CostUnit) float64 {
+	if unit.Confidence == 0 {
+		return 1.0
+	}
+	return unit.Confidence
+}
+
+// costDiffSections are the kinds of change a diff lists, in print order
+func costDiffSections(d *diff.DiffResult) []struct {
+	mark  string
+	diffs []*diff.InstanceDiff
+} {
+	return []struct {
+		mark  string
+		diffs []*diff.InstanceDiff
+	}{
+		{"+", d.Added},
+		{"-", d.Removed},
+		{"~", d.Changed},
+		{">", d.Moved},
+	}
+}
+
+// printCostDiff prints each changed resource with its monthly delta, and
+// the totals
+func printCostDiff(w io.Writer, d *diff.DiffResult) {
+	fmt.Fprintln(w, "COST DIFF (monthly)")
+	fmt.Fprintln(w, strings.Repeat("─", 70))
+	fmt.Fprintf(w, "Before: %s\n", d.TotalBefore.String())
+	fmt.Fprintf(w, "After:  %s\n", d.TotalAfter.String())
+	fmt.Fprintf(w, "Delta:  %s\n", d.TotalDelta.String())
+	fmt.Fprintf(w, "%d added, %d removed, %d changed, %d moved\n", d.AddedCount, d.RemovedCount, d.ChangedCount, d.MovedCount)
+	fmt.Fprintln(w)
+	for _, section := range costDiffSections(d) {
+		for _, c := range section.diffs {
+			label := string(c.Address)
+			if c.PreviousAddress != "" {
+				label = fmt.Sprintf("%s (from %s)", c.Address, c.PreviousAddress)
+			}
+			fmt.Fprintf(w, "%s %-55s %13s\n", section.mark, truncate(label, 55), c.Delta.String())
+		}
+	}
+}
+
+// writeCostDiffJSON writes the diff as JSON
+func writeCostDiffJSON(w io.Writer, d *diff.DiffResult) error {
+	changes := []map[string]any{}
+	for _, section := range costDiffSections(d) {
+		for _, c := range section.diffs {
+			change := map[string]any{
+				"address": c.Address,
+				"change":  c.ChangeType.String(),
+				"delta":   c.Delta.StringRaw(),
+			}
+			if c.PreviousAddress != "" {
+				change["previous_address"] = c.PreviousAddress
+			}
+			changes = append(changes, change)
+		}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(map[string]any{
+		"total_before": d.TotalBefore.StringRaw(),
+		"total_after":  d.TotalAfter.StringRaw(),
+		"total_delta":  d.TotalDelta.StringRaw(),
+		"added":        d.AddedCount,
+		"removed":      d.RemovedCount,
+		"changed":      d.ChangedCount,
+		"moved":        d.MovedCount,
+		"changes":      changes,
+	})
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeCompareProject writes a project of one m5.large instance named name
+func writeCompareProject(t *testing.T, name string) string {
+	t.Helper()
+	dir := t.TempDir()
+	src := `resource "aws_instance" "` + name + `" {
+  ami           = "ami-123"
+  instance_type = "m5.large"
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// TestCompareMatchByAttributes proves a renamed resource is reported as
+// moved with no delta under --match-by-attributes, and as removed and
+// added without it
+func TestCompareMatchByAttributes(t *testing.T) {
+	ctx := context.Background()
+	before, err := comparedCosts(ctx, writeCompareProject(t, "web"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := comparedCosts(ctx, writeCompareProject(t, "app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(before.Instances) != 1 || before.TotalMonthly.IsZero() {
+		t.Fatalf("base priced %d instances at %s, want one with a cost", len(before.Instances), before.TotalMonthly)
+	}
+
+	defer func() { compareMatchByAttributes = false }()
+	for _, tc := range []struct {
+		match                   bool
+		added, removed, moved   int
+		wantChange, wantAddress string
+	}{
+		{true, 0, 0, 1, "moved", "aws_instance.app"},
+		{false, 1, 1, 0, "added", "aws_instance.app"},
+	} {
+		compareMatchByAttributes = tc.match
+		var out bytes.Buffer
+		result := compareCosts(before, after)
+		if err := writeCostDiffJSON(&out, result); err != nil {
+			t.Fatal(err)
+		}
+		var payload struct {
+			Added, Removed, Moved int
+			Delta                 string `json:"total_delta"`
+			Changes               []struct {
+				Address  string `json:"address"`
+				Change   string `json:"change"`
+				Delta    string `json:"delta"`
+				Previous string `json:"previous_address"`
+			} `json:"changes"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+			t.Fatal(err)
+		}
+		if payload.Added != tc.added || payload.Removed != tc.removed || payload.Moved != tc.moved {
+			t.Errorf("match=%v: added=%d removed=%d moved=%d, want %d %d %d", tc.match,
+				payload.Added, payload.Removed, payload.Moved, tc.added, tc.removed, tc.moved)
+		}
+		if payload.Delta != "0" {
+			t.Errorf("match=%v: total delta = %s, want 0", tc.match, payload.Delta)
+		}
+		if len(payload.Changes) == 0 || payload.Changes[0].Change != tc.wantChange || payload.Changes[0].Address != tc.wantAddress {
+			t.Errorf("match=%v: changes = %+v", tc.match, payload.Changes)
+		}
+		if tc.match && (payload.Changes[0].Delta != "0" || payload.Changes[0].Previous != "aws_instance.web") {
+			t.Errorf("moved change = %+v, want a zero-delta move from aws_instance.web", payload.Changes[0])
+		}
+
+		var table bytes.Buffer
+		printCostDiff(&table, result)
+		if tc.match && !strings.Contains(table.String(), "aws_instance.app (from aws_instance.web)") {
+			t.Errorf("table does not show the move:\n%s", table.String())
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/types"
+)
+
+// Confidence of the built-in rate table's cost units
+const (
+	// confidenceListPrice: a listed rate for attributes set in the config
+	confidenceListPrice = 0.9
+
+	// confidenceAssumedAttribute: a sizing attribute was not set and the
+	// provider default was assumed
+	confidenceAssumedAttribute = 0.6
+
+	// confidenceDefaultRate: the type is not in the rate table, so a
+	// placeholder rate was used
+	confidenceDefaultRate = 0.4
+
+	// confidenceUsageBased: the cost depends on usage that was guessed
+	confidenceUsageBased = 0.3
+
+	// lowConfidenceThreshold: lines below it print their reason
+	lowConfidenceThreshold = 0.7
+
+	// unassessedConfidence is the overall confidence when no unit has one
+	unassessedConfidence = 0.7
+)
+
+// setConfidence records a unit's confidence, keeping the lowest when
+// several factors apply, and joins their reasons
+func setConfidence(unit *types.CostUnit, confidence float64, reason string) {
+	if unit.Confidence == 0 || confidence < unit.Confidence {
+		unit.Confidence = confidence
+	}
+	if reason == "" {
+		return
+	}
+	if unit.ConfidenceReason != "" {
+		reason = unit.ConfidenceReason + "; " + reason
+	}
+	unit.ConfidenceReason = reason
+}
+
+// unitsConfidence is the cost-weighted confidence of units; ok is false
+// when none was assessed. Units count equally when their costs add up to
+// nothing to weight by: all zero, or credits cancelling the charges.
+func unitsConfidence(units []*types.CostUnit) (float64, bool) {
+	var weighted, total decimal.Decimal
+	var sum float64
+	n := 0
+	for _, unit := range units {
+		if unit.Confidence == 0 {
+			continue
+		}
+		weighted = weighted.Add(unit.Amount.Mul(decimal.NewFromFloat(unit.Confidence)))
+		total = total.Add(unit.Amount)
+		sum += unit.Confidence
+		n++
+	}
+	if n == 0 {
+		return 0, false
+	}
+	if !total.IsPositive() {
+		return sum / float64(n), true
+	}
+	return weighted.Div(total).InexactFloat64(), true
+}
+
+// graphConfidence is the cost-weighted confidence of every priced unit
+func graphConfidence(costGraph *types.CostGraph) float64 {
+	var units []*types.CostUnit
+	for _, agg := range costGraph.ByAsset {
+		units = append(units, agg.Units...)
+	}
+	if c, ok := unitsConfidence(units); ok {
+		return c
+	}
+	return unassessedConfidence
+}
+
+// confidenceLabel renders a unit's confidence for the detail column
+func confidenceLabel(unit *types.CostUnit) string {
+	if unit.Confidence == 0 {
+		return "-"
+	}
+	marker := "✓"
+	if unit.Confidence < lowConfidenceThreshold {
+		marker = "⚠"
+	}
+	return fmt.Sprintf("%s%3.0f%%", marker, unit.Confidence*100)
+}
+
+// setRateConfidence scores a unit priced from the rate table by one
+// sizing attribute
+func setRateConfidence(unit *types.CostUnit, attribute, value string, assumed, listed bool) {
+	setConfidence(unit, confidenceListPrice, "")
+	if assumed {
+		setConfidence(unit, confidenceAssumedAttribute, fmt.Sprintf("%s not set; assumed %s", attribute, value))
+	}
+	if !listed {
+		setConfidence(unit, confidenceDefaultRate, fmt.Sprintf("no listed rate for %s; placeholder rate used", value))
+	}
+}
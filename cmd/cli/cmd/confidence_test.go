@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"math"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/types"
+)
+
+func confidenceUnit(amount, confidence float64) *types.CostUnit {
+	return &types.CostUnit{Amount: decimal.NewFromFloat(amount), Confidence: confidence}
+}
+
+// TestUnitsConfidence proves units are weighted by cost, and counted
+// equally when there is no positive cost to weight by
+func TestUnitsConfidence(t *testing.T) {
+	tests := []struct {
+		name   string
+		units  []*types.CostUnit
+		want   float64
+		wantOK bool
+	}{
+		{"none", nil, 0, false},
+		{"unassessed", []*types.CostUnit{confidenceUnit(10, 0)}, 0, false},
+		{"cost weighted", []*types.CostUnit{confidenceUnit(90, 0.9), confidenceUnit(10, 0.4)}, 0.85, true},
+		{"unassessed skipped", []*types.CostUnit{confidenceUnit(90, 0.9), confidenceUnit(1000, 0)}, 0.9, true},
+		{"all zero", []*types.CostUnit{confidenceUnit(0, 0.9), confidenceUnit(0, 0.3)}, 0.6, true},
+		{"credit cancels charge", []*types.CostUnit{confidenceUnit(10, 0.9), confidenceUnit(-10, 0.3)}, 0.6, true},
+		{"net credit", []*types.CostUnit{confidenceUnit(5, 0.9), confidenceUnit(-10, 0.3)}, 0.6, true},
+	}
+	for _, tt := range tests {
+		got, ok := unitsConfidence(tt.units)
+		if ok != tt.wantOK || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: unitsConfidence = %v, %v, want %v, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// TestSetRateConfidence proves the lowest applicable confidence wins and
+// every reason is kept
+func TestSetRateConfidence(t *testing.T) {
+	tests := []struct {
+		name           string
+		assumed        bool
+		listed         bool
+		wantConfidence float64
+		wantReason     string
+	}{
+		{"listed", false, true, confidenceListPrice, ""},
+		{"assumed", true, true, confidenceAssumedAttribute, "instance_type not set; assumed t3.micro"},
+		{"unlisted", false, false, confidenceDefaultRate, "no listed rate for t3.micro; placeholder rate used"},
+		{"assumed and unlisted", true, false, confidenceDefaultRate,
+			"instance_type not set; assumed t3.micro; no listed rate for t3.micro; placeholder rate used"},
+	}
+	for _, tt := range tests {
+		unit := &types.CostUnit{}
+		setRateConfidence(unit, "instance_type", "t3.micro", tt.assumed, tt.listed)
+		if unit.Confidence != tt.wantConfidence || unit.ConfidenceReason != tt.wantReason {
+			t.Errorf("%s: confidence %v (%q), want %v (%q)",
+				tt.name, unit.Confidence, unit.ConfidenceReason, tt.wantConfidence, tt.wantReason)
+		}
+	}
+}
+
+// TestConfidenceLabel proves lines below the threshold are flagged
+func TestConfidenceLabel(t *testing.T) {
+	tests := []struct {
+		confidence float64
+		want       string
+	}{
+		{0, "-"},
+		{confidenceListPrice, "✓ 90%"},
+		{lowConfidenceThreshold, "✓ 70%"},
+		{confidenceAssumedAttribute, "⚠ 60%"},
+		{confidenceUsageBased, "⚠ 30%"},
+	}
+	for _, tt := range tests {
+		if got := confidenceLabel(&types.CostUnit{Confidence: tt.confidence}); got != tt.want {
+			t.Errorf("confidenceLabel(%v) = %q, want %q", tt.confidence, got, tt.want)
+		}
+	}
+}
+
+// TestGraphConfidence proves every asset's units are weighted together
+// and a graph with nothing assessed gets the unassessed confidence
+func TestGraphConfidence(t *testing.T) {
+	tests := []struct {
+		name    string
+		byAsset map[string]*types.CostAggregate
+		want    float64
+	}{
+		{"empty", nil, unassessedConfidence},
+		{"unassessed", map[string]*types.CostAggregate{
+			"aws_instance.web": {Units: []*types.CostUnit{confidenceUnit(10, 0)}},
+		}, unassessedConfidence},
+		{"across assets", map[string]*types.CostAggregate{
+			"aws_instance.web": {Units: []*types.CostUnit{confidenceUnit(30, 0.9)}},
+			"aws_lambda_function.api": {Units: []*types.CostUnit{
+				confidenceUnit(10, 0.3), confidenceUnit(0, 0),
+			}},
+		}, 0.75},
+	}
+	for _, tt := range tests {
+		got := graphConfidence(&types.CostGraph{ByAsset: tt.byAsset})
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: graphConfidence = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Package cmd - Estimating a destroy plan
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/types"
+)
+
+// printDestroyPlan reports a plan that destroys every resource. Nothing
+// is left to pay for after the apply, so instead of a total it shows what
+// each resource costs today, the monthly cost delta (the savings, as a
+// negative amount) and the post-destroy cost of zero.
+func printDestroyPlan(w io.Writer, assets *types.AssetGraph, costGraph *types.CostGraph) {
+	currency := string(costGraph.Currency)
+	money := func(amount decimal.Decimal) string {
+		return determinism.FormatAmount(amount, currency, determinism.DisplayPlaces)
+	}
+
+	addrs := make([]string, 0, len(assets.ByAddress))
+	for addr := range assets.ByAddress {
+		addrs = append(addrs, string(addr))
+	}
+	sort.Strings(addrs)
+
+	fmt.Fprintln(w, "┌─────────────────────────────────────────────────────────────────────────┐")
+	fmt.Fprintln(w, "│                          DESTROY PLAN SUMMARY                          │")
+	fmt.Fprintln(w, "├─────────────────────────────────────────────────────────────────────────┤")
+	for _, addr := range addrs {
+		agg, ok := costGraph.ByAsset[assets.ByAddress[types.ResourceAddress(addr)].ID]
+		if !ok || len(agg.Units) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "│ %-50s %20s │\n",
+			truncate(addr, 50),
+			determinism.FormatSignedAmount(agg.MonthlyCost.Neg(), currency, determinism.DisplayPlaces)+"/month")
+	}
+	fmt.Fprintln(w, "├─────────────────────────────────────────────────────────────────────────┤")
+	fmt.Fprintf(w, "│ %-50s %20s │\n",
+		"MONTHLY COST DELTA",
+		determinism.FormatSignedAmount(costGraph.TotalMonthlyCost.Neg(), currency, determinism.DisplayPlaces))
+	fmt.Fprintf(w, "│ %-50s %20s │\n",
+		"POST-DESTROY COST",
+		money(decimal.Zero))
+	fmt.Fprintln(w, "└─────────────────────────────────────────────────────────────────────────┘")
+
+	fmt.Fprintf(w, "\nDestroying %d resources stops %s/month of spend\n",
+		len(addrs), money(costGraph.TotalMonthlyCost))
+}
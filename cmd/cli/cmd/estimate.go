@@ -3,19 +3,29 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/shopspring/decimal"
 	"github.com/spf13/cobra"
 
+	tfadapter "terraform-cost/adapters/terraform"
 	"terraform-cost/clouds"
 	"terraform-cost/clouds/aws"
+	"terraform-cost/clouds/aws/compute"
 	"terraform-cost/core/asset"
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/engine"
+	"terraform-cost/core/model"
 	"terraform-cost/core/output"
+	"terraform-cost/core/policy"
 	"terraform-cost/core/scanner"
 	"terraform-cost/core/types"
+	"terraform-cost/internal/atomicfile"
 	"terraform-cost/internal/logging"
 )
 
@@ -24,6 +34,27 @@ var (
 	usageFile    string
 	showDetails  bool
 	region       string
+	usageProfile  string
+	hoursPerMonth float64
+	explainAddr   string
+	outputFile    string
+	writeOnError  bool
+	groupByTag    string
+	fromState     string
+	policyFile    string
+	showAnnual    bool
+	ignoreGlobs   []string
+	tfcRun        string
+	tfcToken      string
+	tfcAddress    string
+	graphOut      string
+	templatePath  string
+
+	// policies is the parsed --policy-file
+	policies *policy.PolicyFile
+
+	// reportTemplate is the parsed --template
+	reportTemplate *output.TemplateFormatter
 )
 
 // estimateCmd represents the estimate command
@@ -32,22 +63,63 @@ var estimateCmd = &cobra.Command{
 	Short: "Estimate costs for a Terraform project",
 	Long: `Analyze Terraform configurations and produce cost estimates.
 
-The path can be a directory containing .tf files or a Terraform plan JSON file.
+The path can be a directory containing .tf files or Terraform plan JSON
+(terraform show -json output): a .json file, "-" to read standard input, or
+an http(s) URL such as a CI artifact. With --from-state, the current
+infrastructure recorded in state JSON is estimated instead, and no path is
+needed; the state accepts the same file, "-" and URL forms. With --tfc-run,
+the plan of a Terraform Cloud run is downloaded through its API, waiting
+for the plan to finish; the token defaults to TFE_TOKEN or Terraform's
+TF_TOKEN_<host> credentials variable.
 
 Examples:
   terraform-cost estimate .
   terraform-cost estimate ./infrastructure
   terraform-cost estimate --format json ./my-project
-  terraform-cost estimate --usage usage.yml ./my-project`,
+  terraform-cost estimate --usage usage.yml ./my-project
+  terraform-cost estimate --explain aws_instance.web ./my-project
+  terraform-cost estimate --format ndjson --output-file cost.ndjson ./my-project
+  terraform-cost estimate --group-by team ./my-project
+  terraform-cost estimate --show-annual ./my-project
+  terraform-cost estimate --policy-file policy.yaml ./my-project
+  terraform-cost estimate --ignore 'aws_instance.test_*,module.sandbox.*' ./my-project
+  terraform-cost estimate --graph-out graph.dot ./my-project && dot -Tsvg graph.dot > graph.svg
+  terraform-cost estimate --template report.tmpl ./my-project
+  terraform-cost estimate --template slack ./my-project | curl -d @- "$SLACK_WEBHOOK_URL"
+  terraform show -json tfplan | terraform-cost estimate -
+  terraform-cost estimate https://ci.example.com/artifacts/plan.json
+  terraform show -json > state.json && terraform-cost estimate --from-state state.json
+  terraform-cost estimate --tfc-run run-CZcmD7eagjhyX0vN
+  terraform-cost estimate --offline --region eu-west-1 ./my-project
+  terraform-cost estimate --watch --offline ./my-project
+  terraform-cost estimate --rate-overrides negotiated.json ./my-project
+  terraform-cost estimate --workspace prod ./my-project
+  terraform-cost estimate --compare-workspace dev,staging,prod ./my-project`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runEstimate,
 }
 
 func init() {
-	estimateCmd.Flags().StringVarP(&outputFormat, "format", "f", "cli", "output format (cli, json, html, markdown)")
+	estimateCmd.Flags().StringVarP(&outputFormat, "format", "f", "cli", "output format (cli, json, ndjson, html, markdown)")
 	estimateCmd.Flags().StringVarP(&usageFile, "usage", "u", "", "usage file for custom usage estimates")
 	estimateCmd.Flags().BoolVarP(&showDetails, "details", "d", true, "show detailed cost breakdown")
 	estimateCmd.Flags().StringVarP(&region, "region", "r", "", "default AWS region")
+	estimateCmd.Flags().StringVar(&usageProfile, "usage-profile", engine.ProfileProduction,
+		"usage profile scaling default usage ("+strings.Join(engine.UsageProfileNames(), ", ")+"); values in --usage take precedence")
+	estimateCmd.Flags().StringVar(&explainAddr, "explain", "", "print the full cost lineage for one resource address (e.g. aws_instance.web)")
+	estimateCmd.Flags().Float64Var(&hoursPerMonth, "hours-per-month", determinism.DefaultHoursPerMonth, "hours in a billing month, used for hourly/monthly conversion")
+	estimateCmd.Flags().StringVar(&outputFile, "output-file", "", "write results to this file atomically instead of stdout")
+	estimateCmd.Flags().BoolVar(&writeOnError, "write-on-error", false, "with --output-file, keep partial output when the estimate fails")
+	estimateCmd.Flags().StringVar(&groupByTag, "group-by", "", "break costs down by a tag key (e.g. team, cost-center)")
+	estimateCmd.Flags().StringVar(&fromState, "from-state", "", "estimate existing infrastructure from a state JSON file (terraform show -json)")
+	estimateCmd.Flags().BoolVar(&showAnnual, "show-annual", false, "also show the annual total (rounded monthly x 12)")
+	estimateCmd.Flags().StringVar(&policyFile, "policy-file", "", "enforce the policies in this file (policy.yaml or policy.json); exits non-zero when an error policy fails")
+	estimateCmd.Flags().StringSliceVar(&ignoreGlobs, "ignore", nil, "leave resources matching these address globs out of the total (repeatable, comma-separated)")
+	estimateCmd.Flags().StringVar(&tfcRun, "tfc-run", "", "estimate the plan of a Terraform Cloud run (e.g. run-CZcmD7eagjhyX0vN)")
+	estimateCmd.Flags().StringVar(&tfcToken, "tfc-token", "", "Terraform Cloud API token for --tfc-run (default TFE_TOKEN or TF_TOKEN_<host>)")
+	estimateCmd.Flags().StringVar(&tfcAddress, "tfc-address", tfadapter.DefaultTFCAddress, "Terraform Cloud or Enterprise address for --tfc-run")
+	estimateCmd.Flags().StringVar(&templatePath, "template", "", "render the results through this Go text/template file, or a built-in template (plain, slack); see docs/TEMPLATES.md")
+	estimateCmd.Flags().StringVar(&graphOut, "graph-out", "", "write the dependency graph with node costs to this file (Graphviz DOT, or JSON for a .json path)")
 }
 
 func runEstimate(cmd *cobra.Command, args []string) error {
@@ -61,69 +133,339 @@ func runEstimate(cmd *cobra.Command, args []string) error {
 	}
 
 	// Validate path exists
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return fmt.Errorf("path does not exist: %s", path)
+	if fromState != "" {
+		if len(args) > 0 {
+			return fmt.Errorf("--from-state cannot be combined with a project path")
+		}
+		path = fromState
+	}
+	if tfcRun != "" {
+		if len(args) > 0 || fromState != "" {
+			return fmt.Errorf("--tfc-run cannot be combined with a project path or --from-state")
+		}
+		path = tfcRun
+	}
+	if tfcRun == "" && !tfadapter.IsRemotePlanSource(path) {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return fmt.Errorf("path does not exist: %s", path)
+		}
+	}
+	if watchMode {
+		if err := validateWatch(path); err != nil {
+			return err
+		}
 	}
 
+	profile, ok := engine.LookupUsageProfile(usageProfile)
+	if !ok {
+		return fmt.Errorf("unknown usage profile %q (available: %s)",
+			usageProfile, strings.Join(engine.UsageProfileNames(), ", "))
+	}
+	activeProfile = profile
+	if hoursPerMonth <= 0 {
+		return fmt.Errorf("--hours-per-month must be positive, got %g", hoursPerMonth)
+	}
+	if err := validateLockFlags(); err != nil {
+		return err
+	}
+	if err := validateWorkspaceFlags(path); err != nil {
+		return err
+	}
+	if policyFile != "" {
+		if outputFormat == formatNDJSON {
+			return fmt.Errorf("--policy-file cannot be combined with --format ndjson")
+		}
+		loaded, err := policy.LoadPolicyFile(policyFile)
+		if err != nil {
+			return err
+		}
+		policies = loaded
+	}
+	if templatePath != "" {
+		if outputFormat == formatNDJSON {
+			return fmt.Errorf("--template cannot be combined with --format ndjson")
+		}
+		loaded, err := output.LoadTemplateFormatter(templatePath)
+		if err != nil {
+			return err
+		}
+		reportTemplate = loaded
+	}
+
+	status := statusWriter()
+
 	logging.Info("Starting cost estimation")
 
 	// Initialize cloud plugins
 	if err := initializePlugins(); err != nil {
 		return fmt.Errorf("failed to initialize plugins: %w", err)
 	}
+	if offline {
+		if err := startOffline(ctx); err != nil {
+			return err
+		}
+		fmt.Fprintf(status, "Using built-in demo rates for %s: costs are approximate\n", offlineSnapshot.Region)
+		if dir := lockDir(path); dir != "" && !noLock {
+			lockStatus, err := checkLock(dir, offlineSnapshot)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(status, "Snapshot %s %s\n", offlineSnapshot.ID, lockStatus)
+		}
+	}
+	if err := loadRateOverrides(); err != nil {
+		return err
+	}
+	if watchMode {
+		return watchEstimate(path, status)
+	}
 
 	// Create project input
+	now := determinism.DefaultClock.Now()
 	input := &types.ProjectInput{
-		ID:     fmt.Sprintf("estimate-%d", time.Now().Unix()),
+		ID:     fmt.Sprintf("estimate-%d", now.Unix()),
 		Path:   path,
 		Source: types.SourceCLI,
 		Metadata: types.InputMetadata{
-			Timestamp: time.Now(),
+			Timestamp: now,
 		},
 	}
 
-	// Scan the project
-	fmt.Println("Scanning Terraform files...")
-	scanResult, err := scanner.GetDefault().DetectAndScan(ctx, input)
-	if err != nil {
-		return fmt.Errorf("failed to scan project: %w", err)
+	var rawAssets []types.RawAsset
+	var planned *planInput
+	if fromState != "" {
+		// Existing infrastructure: resources come from state, not .tf files
+		fmt.Fprintln(status, "Reading Terraform state...")
+		assets, err := loadStateAssets(ctx, fromState)
+		if err != nil {
+			return fmt.Errorf("failed to read state: %w", err)
+		}
+		rawAssets = assets
+	} else if tfcRun != "" {
+		fmt.Fprintf(status, "Reading the plan of Terraform Cloud run %s...\n", tfcRun)
+		loaded, err := loadTFCRunAssets(ctx, tfcRun, tfcToken, tfcAddress)
+		if err != nil {
+			return err
+		}
+		for _, w := range loaded.warnings {
+			fmt.Fprintf(status, "Warning: %s\n", w)
+		}
+		rawAssets, planned = loaded.assets, loaded
+	} else if isPlanInput(path) {
+		fmt.Fprintln(status, "Reading Terraform plan...")
+		loaded, err := loadPlanAssets(ctx, path)
+		if err != nil {
+			return err
+		}
+		for _, w := range loaded.warnings {
+			fmt.Fprintf(status, "Warning: %s\n", w)
+		}
+		rawAssets, planned = loaded.assets, loaded
+	} else if len(compareWorkspaces) > 0 {
+		fmt.Fprintf(status, "Comparing workspaces %s...\n", strings.Join(compareWorkspaces, ", "))
+		return runWorkspaceComparison(ctx, path, status)
+	} else if workspace != "" {
+		// Only evaluation resolves terraform.workspace
+		fmt.Fprintf(status, "Evaluating Terraform files for workspace %s...\n", workspace)
+		assets, resolved, warnings, err := workspaceAssets(ctx, path, workspace)
+		if err != nil {
+			return err
+		}
+		for _, w := range warnings {
+			fmt.Fprintf(status, "Warning: %s\n", w)
+		}
+		rawAssets, estimateWorkspace = assets, resolved
+	} else {
+		// Scan the project
+		fmt.Fprintln(status, "Scanning Terraform files...")
+		scanResult, err := scanner.GetDefault().DetectAndScan(ctx, input)
+		if err != nil {
+			return fmt.Errorf("failed to scan project: %w", err)
+		}
+
+		if scanResult.HasErrors() {
+			fmt.Fprintf(status, "Warning: %d errors during scanning\n", len(scanResult.Errors))
+			for _, e := range scanResult.Errors {
+				fmt.Fprintf(status, "  %s:%d: %s\n", e.File, e.Line, e.Message)
+			}
+		}
+		rawAssets = scanResult.Assets
+	}
+
+	matcher := model.NewIgnoreMatcher(ignoreGlobs)
+	rawAssets, ignored := ignoreAssets(rawAssets, matcher)
+	if len(ignored) > 0 {
+		wouldCost := calculateCosts(buildAssetGraph(ctx, ignored))
+		fmt.Fprintf(status, "Ignored %d resources matching --ignore (would cost %s/month)\n",
+			len(ignored), determinism.FormatAmount(wouldCost.TotalMonthlyCost, string(wouldCost.Currency), determinism.DisplayPlaces))
 	}
 
-	if scanResult.HasErrors() {
-		fmt.Printf("Warning: %d errors during scanning\n", len(scanResult.Errors))
-		for _, e := range scanResult.Errors {
-			fmt.Printf("  %s:%d: %s\n", e.File, e.Line, e.Message)
+	if planned != nil && len(planned.destroyed) > 0 {
+		// Destroyed resources are left out of the totals, which cover the
+		// infrastructure after the apply; what they cost today is saved
+		destroyed, _ := ignoreAssets(planned.destroyed, matcher)
+		destroyedGraph := buildAssetGraph(ctx, destroyed)
+		savings := calculateCosts(destroyedGraph)
+		if planned.destroy {
+			out, finish, err := openOutput(outputFile, writeOnError)
+			if err != nil {
+				return err
+			}
+			printDestroyPlan(out, destroyedGraph, savings)
+			if err := finish(nil); err != nil {
+				return err
+			}
+			if outputFile != "" {
+				fmt.Fprintf(status, "Results written to %s\n", outputFile)
+			}
+			return nil
 		}
+		fmt.Fprintf(status, "Destroying %d resources saves %s/month\n",
+			len(destroyed), determinism.FormatAmount(savings.TotalMonthlyCost, string(savings.Currency), determinism.DisplayPlaces))
 	}
 
-	if len(scanResult.Assets) == 0 {
-		fmt.Println("No resources found in the project.")
+	if len(rawAssets) == 0 {
+		fmt.Fprintln(status, "No resources found in the project.")
 		return nil
 	}
 
-	fmt.Printf("Found %d resources\n\n", len(scanResult.Assets))
+	fmt.Fprintf(status, "Found %d resources\n\n", len(rawAssets))
 
 	// Build asset graph
-	graph := buildAssetGraph(ctx, scanResult.Assets)
+	graph := buildAssetGraph(ctx, rawAssets)
+
+	out, finish, err := openOutput(outputFile, writeOnError)
+	if err != nil {
+		return err
+	}
+	renderErr := renderEstimate(out, rawAssets, graph, startTime)
+	var failed *policyFailure
+	if errors.As(renderErr, &failed) {
+		// The estimate succeeded; keep its output and report the failure
+		if err := finish(nil); err != nil {
+			return err
+		}
+		return renderErr
+	}
+	if err := finish(renderErr); err != nil {
+		return err
+	}
+	if outputFile != "" {
+		fmt.Fprintf(status, "Results written to %s\n", outputFile)
+	}
+	return nil
+}
+
+// statusWriter is where progress lines go: stderr when stdout carries
+// machine-readable output (ndjson, or a template such as the Slack
+// payload that is piped on), else stdout
+func statusWriter() io.Writer {
+	if outputFile == "" && (outputFormat == formatNDJSON || reportTemplate != nil) {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// ignoreAssets splits off the assets whose address matches --ignore
+func ignoreAssets(assets []types.RawAsset, matcher *model.IgnoreMatcher) (kept, ignored []types.RawAsset) {
+	if matcher == nil {
+		return assets, nil
+	}
+	for _, raw := range assets {
+		if matcher.Matches(model.InstanceAddress(raw.Address)) {
+			ignored = append(ignored, raw)
+		} else {
+			kept = append(kept, raw)
+		}
+	}
+	return kept, ignored
+}
+
+// openOutput returns the writer for results and a function that finalizes it.
+// With a path, output goes to a temp file that is renamed into place when the
+// run succeeds; on failure it is discarded unless keepOnError is set.
+func openOutput(path string, keepOnError bool) (io.Writer, func(error) error, error) {
+	if path == "" {
+		return os.Stdout, func(err error) error { return err }, nil
+	}
+
+	f, err := atomicfile.Create(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open output file: %w", err)
+	}
+	return f, func(runErr error) error {
+		if runErr != nil && !keepOnError {
+			f.Abort()
+			return runErr
+		}
+		if err := f.Commit(); err != nil {
+			if runErr != nil {
+				return runErr
+			}
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+		return runErr
+	}, nil
+}
+
+// renderEstimate prices the graph and writes it in the selected format;
+// rawAssets are the scanned resources the graph was built from
+func renderEstimate(w io.Writer, rawAssets []types.RawAsset, graph *types.AssetGraph, startTime time.Time) error {
+	if outputFormat == formatNDJSON && explainAddr == "" {
+		if graphOut != "" {
+			if err := writeDependencyGraph(graphOut, rawAssets, graph, nil); err != nil {
+				return fmt.Errorf("failed to write dependency graph: %w", err)
+			}
+		}
+		return streamNDJSON(w, graph)
+	}
 
 	// Calculate costs (simplified)
 	costGraph := calculateCosts(graph)
+	if graphOut != "" {
+		if err := writeDependencyGraph(graphOut, rawAssets, graph, costGraph); err != nil {
+			return fmt.Errorf("failed to write dependency graph: %w", err)
+		}
+	}
+
+	if explainAddr != "" {
+		return explainAsset(w, graph, costGraph, explainAddr)
+	}
 
 	// Create estimation result
 	result := &output.EstimationResult{
 		CostGraph:  costGraph,
 		AssetGraph: graph,
-		Confidence: 0.7,
+		Confidence: graphConfidence(costGraph),
 		Metadata: output.EstimationMetadata{
-			Timestamp: time.Now().Format(time.RFC3339),
+			Timestamp: determinism.Timestamp(determinism.DefaultClock.Now()),
 			Duration:  time.Since(startTime).String(),
 			Version:   "0.1.0",
 			Source:    types.SourceCLI,
+			// A workspace is the environment the estimate is for
+			Environment: estimateWorkspace,
 		},
 	}
 
 	// Output results
-	printResults(result)
+	if reportTemplate != nil {
+		if err := reportTemplate.Render(w, result); err != nil {
+			return err
+		}
+		// The template owns the output's layout, so the policy report
+		// goes to stderr
+		if policies != nil {
+			return enforcePolicies(os.Stderr, policies, graph, costGraph, result.Confidence)
+		}
+		return nil
+	}
+	printResults(w, result)
+	if groupByTag != "" {
+		printTagBreakdown(w, graph, costGraph, groupByTag)
+	}
+	if policies != nil {
+		return enforcePolicies(w, policies, graph, costGraph, result.Confidence)
+	}
 
 	return nil
 }
@@ -153,6 +495,7 @@ func buildAssetGraph(ctx context.Context, rawAssets []types.RawAsset) *types.Ass
 	}
 
 	for _, raw := range rawAssets {
+		tags := engine.AllocationTags(tagsValue(raw.Attributes["tags_all"]), tagsValue(raw.Attributes["tags"]))
 		builder, ok := builderRegistry.GetBuilder(raw.Provider, raw.Type)
 		if !ok {
 			// No builder for this resource type - create a generic asset
@@ -164,20 +507,33 @@ func buildAssetGraph(ctx context.Context, rawAssets []types.RawAsset) *types.Ass
 				Type:       raw.Type,
 				Name:       raw.Name,
 				Attributes: raw.Attributes,
+				Tags:       tags,
 				Metadata: types.AssetMetadata{
 					Source: raw.SourceFile,
 					Line:   raw.SourceLine,
 				},
 			}
+			if string(raw.Address) != raw.Type+"."+raw.Name {
+				asset.ID = string(raw.Address)
+			}
 			graph.Add(asset)
 			continue
 		}
 
 		asset, err := builder.Build(ctx, &raw)
 		if err != nil {
-			fmt.Printf("Warning: failed to build asset %s: %v\n", raw.Address, err)
+			fmt.Fprintf(os.Stderr, "Warning: failed to build asset %s: %v\n", raw.Address, err)
 			continue
 		}
+		if len(asset.Tags) == 0 {
+			asset.Tags = tags
+		}
+
+		// Expanded instances (state addresses like aws_instance.web[0])
+		// share a builder ID, so key them by their full address
+		if string(raw.Address) != raw.Type+"."+raw.Name {
+			asset.ID = string(raw.Address)
+		}
 
 		graph.Add(asset)
 	}
@@ -187,6 +543,7 @@ func buildAssetGraph(ctx context.Context, rawAssets []types.RawAsset) *types.Ass
 
 func calculateCosts(graph *types.AssetGraph) *types.CostGraph {
 	costGraph := types.NewCostGraph(types.CurrencyUSD)
+	costGraph.HoursPerMonth = hoursPerMonth
 
 	graph.Walk(func(asset *types.Asset) error {
 		// Calculate cost for this asset
@@ -201,6 +558,22 @@ func calculateCosts(graph *types.AssetGraph) *types.CostGraph {
 	return costGraph
 }
 
+// activeProfile is the usage profile applied to hour-based defaults
+var activeProfile, _ = engine.LookupUsageProfile(engine.ProfileProduction)
+
+// profileMonthlyHours returns the billed hours per month under the active profile
+func profileMonthlyHours() decimal.Decimal {
+	return decimal.NewFromFloat(hoursPerMonth).Mul(decimal.NewFromFloat(activeProfile.UptimeFraction)).Round(2)
+}
+
+// hoursFormula describes the hours term used in a formula
+func hoursFormula() string {
+	if activeProfile.UptimeFraction == 1.0 {
+		return fmt.Sprintf("%g hours/month", hoursPerMonth)
+	}
+	return fmt.Sprintf("%s hours/month (%s profile)", profileMonthlyHours().String(), activeProfile.Name)
+}
+
 func calculateAssetCost(asset *types.Asset) []*types.CostUnit {
 	var units []*types.CostUnit
 
@@ -208,14 +581,15 @@ func calculateAssetCost(asset *types.Asset) []*types.CostUnit {
 	switch asset.Type {
 	case "aws_instance":
 		instanceType := asset.Attributes.GetString("instance_type")
-		if instanceType == "" {
+		assumed := instanceType == ""
+		if assumed {
 			instanceType = "t3.micro"
 		}
-		hourlyRate := getEC2HourlyRate(instanceType)
-		monthlyHours := decimal.NewFromInt(730)
+		hourlyRate, listed := getEC2HourlyRate(instanceType)
+		monthlyHours := profileMonthlyHours()
 		monthlyCost := hourlyRate.Mul(monthlyHours)
 
-		units = append(units, &types.CostUnit{
+		unit := &types.CostUnit{
 			ID:       fmt.Sprintf("%s-compute", asset.ID),
 			Label:    fmt.Sprintf("EC2 Instance (%s)", instanceType),
 			Measure:  "hours",
@@ -226,20 +600,24 @@ func calculateAssetCost(asset *types.Asset) []*types.CostUnit {
 			Lineage: types.CostLineage{
 				AssetID:      asset.ID,
 				AssetAddress: asset.Address,
-				Formula:      "hourly_rate * 730 hours/month",
+				Formula:      "hourly_rate * " + hoursFormula(),
 			},
-		})
+		}
+		setRateConfidence(unit, "instance_type", instanceType, assumed, listed)
+		noteRateOverride(unit, "aws_instance", "compute", map[string]string{"instance_type": instanceType})
+		units = append(units, unit)
 
 	case "aws_db_instance":
 		instanceClass := asset.Attributes.GetString("instance_class")
-		if instanceClass == "" {
+		assumed := instanceClass == ""
+		if assumed {
 			instanceClass = "db.t3.micro"
 		}
-		hourlyRate := getRDSHourlyRate(instanceClass)
-		monthlyHours := decimal.NewFromInt(730)
+		hourlyRate, listed := getRDSHourlyRate(instanceClass)
+		monthlyHours := profileMonthlyHours()
 		monthlyCost := hourlyRate.Mul(monthlyHours)
 
-		units = append(units, &types.CostUnit{
+		unit := &types.CostUnit{
 			ID:       fmt.Sprintf("%s-compute", asset.ID),
 			Label:    fmt.Sprintf("RDS Instance (%s)", instanceClass),
 			Measure:  "hours",
@@ -250,14 +628,20 @@ func calculateAssetCost(asset *types.Asset) []*types.CostUnit {
 			Lineage: types.CostLineage{
 				AssetID:      asset.ID,
 				AssetAddress: asset.Address,
-				Formula:      "hourly_rate * 730 hours/month",
+				Formula:      "hourly_rate * " + hoursFormula(),
 			},
-		})
+		}
+		setRateConfidence(unit, "instance_class", instanceClass, assumed, listed)
+		noteRateOverride(unit, "aws_db_instance", "compute", map[string]string{"instance_class": instanceClass})
+		units = append(units, unit)
 
 		// Add storage cost
 		storage := asset.Attributes.GetInt("allocated_storage")
 		if storage > 0 {
 			storageRate := decimal.NewFromFloat(0.115) // gp2 per GB-month
+			if rate, ok := lookupRate("aws_db_instance", "storage", map[string]string{"storage_type": "gp2"}); ok {
+				storageRate = rate
+			}
 			storageCost := storageRate.Mul(decimal.NewFromInt(int64(storage)))
 			units = append(units, &types.CostUnit{
 				ID:       fmt.Sprintf("%s-storage", asset.ID),
@@ -270,14 +654,16 @@ func calculateAssetCost(asset *types.Asset) []*types.CostUnit {
 				Lineage: types.CostLineage{
 					AssetID:      asset.ID,
 					AssetAddress: asset.Address,
-					Formula:      "storage_gb * $0.115/GB-month",
+					Formula:      fmt.Sprintf("storage_gb * $%s/GB-month", storageRate),
 				},
+				Confidence: confidenceListPrice,
 			})
+			noteRateOverride(units[len(units)-1], "aws_db_instance", "storage", map[string]string{"storage_type": "gp2"})
 		}
 
 	case "aws_nat_gateway":
 		hourlyRate := decimal.NewFromFloat(0.045)
-		monthlyHours := decimal.NewFromInt(730)
+		monthlyHours := profileMonthlyHours()
 		monthlyCost := hourlyRate.Mul(monthlyHours)
 
 		units = append(units, &types.CostUnit{
@@ -291,23 +677,48 @@ func calculateAssetCost(asset *types.Asset) []*types.CostUnit {
 			Lineage: types.CostLineage{
 				AssetID:      asset.ID,
 				AssetAddress: asset.Address,
-				Formula:      "$0.045/hour * 730 hours/month",
+				Formula:      "$0.045/hour * " + hoursFormula(),
+			},
+			Confidence:       confidenceAssumedAttribute,
+			ConfidenceReason: "per-GB data processing not included",
+		})
+
+	case "aws_eks_cluster":
+		hourlyRate := decimal.NewFromFloat(0.10)
+		monthlyHours := profileMonthlyHours()
+		monthlyCost := hourlyRate.Mul(monthlyHours)
+
+		units = append(units, &types.CostUnit{
+			ID:       fmt.Sprintf("%s-control-plane", asset.ID),
+			Label:    "EKS Control Plane",
+			Measure:  "hours",
+			Quantity: monthlyHours,
+			Rate:     hourlyRate,
+			Amount:   monthlyCost,
+			Currency: types.CurrencyUSD,
+			Lineage: types.CostLineage{
+				AssetID:      asset.ID,
+				AssetAddress: asset.Address,
+				Formula:      "$0.10/hour * " + hoursFormula(),
 			},
+			Confidence: confidenceListPrice,
 		})
 
 	case "aws_ebs_volume":
 		volumeType := asset.Attributes.GetString("type")
-		if volumeType == "" {
+		assumedType := volumeType == ""
+		if assumedType {
 			volumeType = "gp3"
 		}
 		size := asset.Attributes.GetInt("size")
-		if size == 0 {
+		assumedSize := size == 0
+		if assumedSize {
 			size = 8
 		}
-		rate := getEBSRate(volumeType)
+		rate, listed := getEBSRate(volumeType)
 		amount := rate.Mul(decimal.NewFromInt(int64(size)))
 
-		units = append(units, &types.CostUnit{
+		unit := &types.CostUnit{
 			ID:       fmt.Sprintf("%s-storage", asset.ID),
 			Label:    fmt.Sprintf("EBS Volume (%s)", volumeType),
 			Measure:  "GB-month",
@@ -320,7 +731,13 @@ func calculateAssetCost(asset *types.Asset) []*types.CostUnit {
 				AssetAddress: asset.Address,
 				Formula:      fmt.Sprintf("$%.3f/GB-month * %d GB", rate.InexactFloat64(), size),
 			},
-		})
+		}
+		setRateConfidence(unit, "type", volumeType, assumedType, listed)
+		if assumedSize {
+			setConfidence(unit, confidenceAssumedAttribute, "size not set; assumed 8 GB")
+		}
+		noteRateOverride(unit, "aws_ebs_volume", "storage", map[string]string{"volume_type": volumeType})
+		units = append(units, unit)
 
 	case "aws_lambda_function":
 		// Lambda free tier: 1M requests, 400K GB-seconds
@@ -339,13 +756,19 @@ func calculateAssetCost(asset *types.Asset) []*types.CostUnit {
 				Formula:      "Usage-based pricing (1M requests estimate)",
 				Assumptions:  []string{"Estimated 1M invocations/month"},
 			},
+			Confidence:       confidenceUsageBased,
+			ConfidenceReason: "usage-based; assumed 1M invocations/month",
 		})
 	}
 
+	markOffline(units)
 	return units
 }
 
-func getEC2HourlyRate(instanceType string) decimal.Decimal {
+func getEC2HourlyRate(instanceType string) (decimal.Decimal, bool) {
+	if rate, ok := lookupRate("aws_instance", "compute", map[string]string{"instance_type": instanceType}); ok {
+		return rate, true
+	}
 	rates := map[string]float64{
 		"t3.micro":   0.0104,
 		"t3.small":   0.0208,
@@ -356,14 +779,31 @@ func getEC2HourlyRate(instanceType string) decimal.Decimal {
 		"m5.xlarge":  0.192,
 		"c5.large":   0.085,
 		"r5.large":   0.126,
+		// Graviton (arm64)
+		"t4g.micro":  0.0084,
+		"t4g.small":  0.0168,
+		"t4g.medium": 0.0336,
+		"t4g.large":  0.0672,
+		"t4g.xlarge": 0.1344,
+		"m6g.large":  0.077,
+		"m6g.xlarge": 0.154,
+		"c6g.large":  0.068,
+		"r6g.large":  0.1008,
 	}
 	if rate, ok := rates[instanceType]; ok {
-		return decimal.NewFromFloat(rate)
+		return decimal.NewFromFloat(rate), true
+	}
+	// Graviton runs about 20% below the x86 type of the same size
+	if compute.InstanceArchitecture(instanceType) == compute.ArchitectureARM64 {
+		return decimal.NewFromFloat(0.08), false
 	}
-	return decimal.NewFromFloat(0.10) // Default
+	return decimal.NewFromFloat(0.10), false // Default
 }
 
-func getRDSHourlyRate(instanceClass string) decimal.Decimal {
+func getRDSHourlyRate(instanceClass string) (decimal.Decimal, bool) {
+	if rate, ok := lookupRate("aws_db_instance", "compute", map[string]string{"instance_class": instanceClass}); ok {
+		return rate, true
+	}
 	rates := map[string]float64{
 		"db.t3.micro":   0.017,
 		"db.t3.small":   0.034,
@@ -372,12 +812,15 @@ func getRDSHourlyRate(instanceClass string) decimal.Decimal {
 		"db.r5.large":   0.24,
 	}
 	if rate, ok := rates[instanceClass]; ok {
-		return decimal.NewFromFloat(rate)
+		return decimal.NewFromFloat(rate), true
 	}
-	return decimal.NewFromFloat(0.10) // Default
+	return decimal.NewFromFloat(0.10), false // Default
 }
 
-func getEBSRate(volumeType string) decimal.Decimal {
+func getEBSRate(volumeType string) (decimal.Decimal, bool) {
+	if rate, ok := lookupRate("aws_ebs_volume", "storage", map[string]string{"volume_type": volumeType}); ok {
+		return rate, true
+	}
 	rates := map[string]float64{
 		"gp3": 0.08,
 		"gp2": 0.10,
@@ -386,45 +829,63 @@ func getEBSRate(volumeType string) decimal.Decimal {
 		"sc1": 0.015,
 	}
 	if rate, ok := rates[volumeType]; ok {
-		return decimal.NewFromFloat(rate)
+		return decimal.NewFromFloat(rate), true
 	}
-	return decimal.NewFromFloat(0.10) // Default
+	return decimal.NewFromFloat(0.10), false // Default
 }
 
-func printResults(result *output.EstimationResult) {
-	fmt.Println("┌─────────────────────────────────────────────────────────────────────────┐")
-	fmt.Println("│                        COST ESTIMATION SUMMARY                         │")
-	fmt.Println("├─────────────────────────────────────────────────────────────────────────┤")
+func printResults(w io.Writer, result *output.EstimationResult) {
+	currency := string(result.CostGraph.Currency)
+	money := func(amount decimal.Decimal, places int) string {
+		return determinism.FormatAmount(amount, currency, places)
+	}
+
+	fmt.Fprintln(w, "┌─────────────────────────────────────────────────────────────────────────┐")
+	fmt.Fprintln(w, "│                        COST ESTIMATION SUMMARY                         │")
+	fmt.Fprintln(w, "├─────────────────────────────────────────────────────────────────────────┤")
 
 	// Print by resource
 	for assetID, agg := range result.CostGraph.ByAsset {
 		if len(agg.Units) == 0 {
 			continue
 		}
-		fmt.Printf("│ %-50s %20s │\n", 
+		fmt.Fprintf(w, "│ %-50s %20s │\n", 
 			truncate(assetID, 50), 
-			fmt.Sprintf("$%.2f/month", agg.MonthlyCost.InexactFloat64()))
+			money(agg.MonthlyCost, determinism.DisplayPlaces)+"/month")
 		
 		if showDetails {
 			for _, unit := range agg.Units {
-				fmt.Printf("│   └─ %-46s %20s │\n",
+				fmt.Fprintf(w, "│   └─ %-46s %13s %6s │\n",
 					truncate(unit.Label, 46),
-					fmt.Sprintf("$%.2f", unit.Amount.InexactFloat64()))
+					money(unit.Amount, determinism.DisplayPlaces),
+					confidenceLabel(unit))
+				if unit.Confidence > 0 && unit.Confidence < lowConfidenceThreshold && unit.ConfidenceReason != "" {
+					fmt.Fprintf(w, "│        %-65s │\n", truncate(unit.ConfidenceReason, 65))
+				}
 			}
 		}
 	}
 
-	fmt.Println("├─────────────────────────────────────────────────────────────────────────┤")
-	fmt.Printf("│ %-50s %20s │\n", 
+	fmt.Fprintln(w, "├─────────────────────────────────────────────────────────────────────────┤")
+	fmt.Fprintf(w, "│ %-50s %20s │\n", 
 		"TOTAL MONTHLY ESTIMATE",
-		fmt.Sprintf("$%.2f", result.CostGraph.TotalMonthlyCost.InexactFloat64()))
-	fmt.Printf("│ %-50s %20s │\n",
+		money(result.CostGraph.TotalMonthlyCost, determinism.DisplayPlaces))
+	fmt.Fprintf(w, "│ %-50s %20s │\n",
 		"TOTAL HOURLY ESTIMATE",
-		fmt.Sprintf("$%.4f", result.CostGraph.TotalHourlyCost.InexactFloat64()))
-	fmt.Println("└─────────────────────────────────────────────────────────────────────────┘")
+		money(result.CostGraph.TotalHourlyCost, determinism.HourlyDisplayPlaces))
+	if showAnnual {
+		annual := determinism.Annualize(determinism.NewMoneyFromDecimal(result.CostGraph.TotalMonthlyCost, currency))
+		fmt.Fprintf(w, "│ %-50s %20s │\n",
+			"TOTAL ANNUAL ESTIMATE",
+			annual.Format(determinism.DisplayPlaces))
+	}
+	fmt.Fprintln(w, "└─────────────────────────────────────────────────────────────────────────┘")
 
-	fmt.Printf("\nEstimation completed in %s\n", result.Metadata.Duration)
-	fmt.Printf("Confidence: %.0f%%\n", result.Confidence*100)
+	fmt.Fprintf(w, "\nEstimation completed in %s\n", result.Metadata.Duration)
+	fmt.Fprintf(w, "Confidence: %.0f%%\n", result.Confidence*100)
+	if result.Metadata.Environment != "" {
+		fmt.Fprintf(w, "Workspace: %s\n", result.Metadata.Environment)
+	}
 }
 
 func truncate(s string, maxLen int) string {
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	cliadapter "terraform-cost/adapters/cli"
+	"terraform-cost/core/policy"
+	"terraform-cost/core/pricing"
+	"terraform-cost/core/types"
+)
+
+// captureStdout runs fn with os.Stdout redirected and returns what it wrote
+func captureStdout(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	out := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- data
+	}()
+	runErr := fn()
+	w.Close()
+	return string(<-out), runErr
+}
+
+// writeProject writes a one-instance Terraform project
+func writeProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	src := `resource "aws_instance" "web" {
+  ami           = "ami-123"
+  instance_type = "t3.micro"
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// TestEstimateTemplateStdoutIsReport proves status lines stay off stdout
+// with --template, so the Slack payload can be piped to a webhook
+func TestEstimateTemplateStdoutIsReport(t *testing.T) {
+	dir := writeProject(t)
+	templatePath = "slack"
+	defer func() { templatePath, reportTemplate = "", nil }()
+
+	out, err := captureStdout(t, func() error { return runEstimate(estimateCmd, []string{dir}) })
+	if err != nil {
+		t.Fatalf("estimate failed: %v", err)
+	}
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(out), &payload); err != nil {
+		t.Fatalf("stdout is not the Slack payload alone: %v\n%s", err, out)
+	}
+	if _, ok := payload["blocks"]; !ok {
+		t.Errorf("payload has no blocks: %s", out)
+	}
+}
+
+// TestPolicyFileCoverage proves the CLI applies a policy file's coverage
+// thresholds, counting resources it cannot price as unsupported
+func TestPolicyFileCoverage(t *testing.T) {
+	raw := []types.RawAsset{
+		{Address: "aws_instance.web", Provider: types.ProviderAWS, Type: "aws_instance", Name: "web",
+			Attributes: types.Attributes{"instance_type": {Value: "t3.micro"}}},
+		{Address: "aws_iam_role.app", Provider: types.ProviderAWS, Type: "aws_iam_role", Name: "app",
+			Attributes: types.Attributes{"name": {Value: "app"}}},
+	}
+	graph := buildAssetGraph(context.Background(), raw)
+	costGraph := calculateCosts(graph)
+
+	loaded, err := policy.ParsePolicyFile([]byte("coverage:\n  max_unsupported_percent: 10\n"), "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := enforcePolicies(&out, loaded, graph, costGraph, graphConfidence(costGraph)); err != nil {
+		t.Fatalf("a coverage warning should not fail the estimate: %v", err)
+	}
+	if !strings.Contains(out.String(), "max_unsupported_percent: Unsupported coverage 50.0% exceeds limit 10.0%") {
+		t.Errorf("output lacks the coverage warning:\n%s", out.String())
+	}
+}
+
+// TestOfflineLockFile proves --offline pins its snapshot in the project's
+// lockfile, refuses a different snapshot until --update-lock, and that the
+// lock flags are rejected where they cannot apply
+func TestOfflineLockFile(t *testing.T) {
+	snapshot := func(rate float64) *pricing.PricingSnapshot {
+		return pricing.NewSnapshotBuilder("aws", "us-east-1").
+			AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(rate), "hour", "USD").
+			Build()
+	}
+	current, next := snapshot(0.1), snapshot(0.2)
+	dir := writeProject(t)
+	defer func() { updateLock, noLock, offline = false, false, false }()
+
+	if got, err := checkLock(dir, current); err != nil || got != "pinned to "+cliadapter.LockFileName {
+		t.Fatalf("first run = %q, %v; want a new pin", got, err)
+	}
+	if got, err := checkLock(dir, current); err != nil || got != "pinned by "+cliadapter.LockFileName {
+		t.Fatalf("second run = %q, %v; want priced from the pin", got, err)
+	}
+	if _, err := checkLock(dir, next); err == nil || !strings.Contains(err.Error(), "run with --update-lock") {
+		t.Errorf("error = %v, want the pinned snapshot reported unavailable", err)
+	}
+
+	updateLock = true
+	if _, err := checkLock(dir, next); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := cliadapter.LoadLockFile(filepath.Join(dir, cliadapter.LockFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pin := lock.Find("aws", "us-east-1", "default"); pin == nil || pin.SnapshotID != string(next.ID) {
+		t.Errorf("pin after --update-lock = %+v, want %s", pin, next.ID)
+	}
+
+	if err := validateLockFlags(); err == nil || !strings.Contains(err.Error(), "needs --offline") {
+		t.Errorf("--update-lock without --offline: error = %v", err)
+	}
+	offline, noLock = true, true
+	if err := validateLockFlags(); err == nil || !strings.Contains(err.Error(), "cannot be combined with --no-lock") {
+		t.Errorf("--update-lock with --no-lock: error = %v", err)
+	}
+	updateLock = false
+	if err := validateLockFlags(); err != nil {
+		t.Errorf("--offline --no-lock: %v", err)
+	}
+
+	plan := filepath.Join(dir, "plan.json")
+	if got := lockDir(plan); got != dir {
+		t.Errorf("lock directory of a plan file = %s, want %s", got, dir)
+	}
+	if got := lockDir(dir); got != dir {
+		t.Errorf("lock directory of a project = %s, want %s", got, dir)
+	}
+}
+
+// TestRateOverrides proves --rate-overrides replaces the built-in and demo
+// rates it matches, only in its region, and notes the override in lineage
+func TestRateOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "negotiated.json")
+	overrides := `{"rates": [
+		{"resource_type": "aws_instance", "component": "compute", "attributes": {"instance_type": "m5.large"}, "price": "0.05", "unit": "hour"},
+		{"region": "eu-west-1", "resource_type": "aws_instance", "component": "compute", "attributes": {"instance_type": "t3.micro"}, "price": "0.001", "unit": "hour"}
+	]}`
+	if err := os.WriteFile(path, []byte(overrides), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rateOverridesFile = path
+	defer func() {
+		rateOverridesFile, region, rateOverrides = "", "", nil
+		offlineResolver, offlineSnapshot = nil, nil
+	}()
+
+	units := func() map[string]*types.CostUnit {
+		raw := []types.RawAsset{
+			{Address: "aws_instance.app", Provider: types.ProviderAWS, Type: "aws_instance", Name: "app",
+				Attributes: types.Attributes{"instance_type": {Value: "m5.large"}}},
+			{Address: "aws_instance.web", Provider: types.ProviderAWS, Type: "aws_instance", Name: "web",
+				Attributes: types.Attributes{"instance_type": {Value: "t3.micro"}}},
+		}
+		byAddress := make(map[string]*types.CostUnit)
+		for _, agg := range calculateCosts(buildAssetGraph(context.Background(), raw)).ByAsset {
+			byAddress[agg.Label] = agg.Units[0]
+		}
+		return byAddress
+	}
+
+	// The built-in table, in the default region
+	if err := loadRateOverrides(); err != nil {
+		t.Fatal(err)
+	}
+	got := units()
+	if app := got["aws_instance.app"]; !app.Rate.Equal(decimal.RequireFromString("0.05")) {
+		t.Errorf("m5.large rate = %s, want the 0.05 override", app.Rate)
+	} else if want := "rate 0.05/hour overridden by negotiated.json"; len(app.Lineage.Assumptions) == 0 || app.Lineage.Assumptions[0] != want {
+		t.Errorf("lineage assumptions = %v, want %q", app.Lineage.Assumptions, want)
+	}
+	if web := got["aws_instance.web"]; !web.Rate.Equal(decimal.RequireFromString("0.0104")) || len(web.Lineage.Assumptions) != 0 {
+		t.Errorf("t3.micro = %s %v, want the built-in rate: its override is for eu-west-1", web.Rate, web.Lineage.Assumptions)
+	}
+
+	// The demo snapshot in eu-west-1, where both overrides apply
+	region = "eu-west-1"
+	if err := startOffline(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadRateOverrides(); err != nil {
+		t.Fatal(err)
+	}
+	if web := units()["aws_instance.web"]; !web.Rate.Equal(decimal.RequireFromString("0.001")) {
+		t.Errorf("offline t3.micro rate = %s, want the eu-west-1 override", web.Rate)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"rates": [{"resource_type": "aws_instance", "component": "compute", "price": "0.05"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadRateOverrides(); err == nil || !strings.Contains(err.Error(), "unit is required") {
+		t.Errorf("invalid overrides: error = %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"rates": [{"resource_type": "aws_instance", "component": "compute", "price": "0.05", "unit": "hour", "currency": "EUR"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadRateOverrides(); err == nil || !strings.Contains(err.Error(), "currency EUR differs") {
+		t.Errorf("EUR overrides: error = %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+// Package cmd - per-resource cost explanation for estimate --explain
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/types"
+)
+
+// explainAsset prints the full lineage of one asset's cost units
+func explainAsset(w io.Writer, graph *types.AssetGraph, costGraph *types.CostGraph, address string) error {
+	a, ok := graph.ByAddress[types.ResourceAddress(address)]
+	if !ok {
+		a, ok = graph.ByID[address]
+	}
+	if !ok {
+		return fmt.Errorf("resource %q is not in the graph (%d resources scanned)", address, graph.Metadata.TotalAssets)
+	}
+
+	snapshotID := costGraph.Metadata.PricingSnapshotID
+	if snapshotID == "" {
+		snapshotID = "none (built-in rate table)"
+	}
+
+	fmt.Fprintf(w, "Resource:  %s\n", a.Address)
+	fmt.Fprintf(w, "Type:      %s\n", a.Type)
+	if a.Metadata.Source != "" {
+		fmt.Fprintf(w, "Source:    %s:%d\n", a.Metadata.Source, a.Metadata.Line)
+	}
+	fmt.Fprintf(w, "Snapshot:  %s\n", snapshotID)
+
+	agg, ok := costGraph.ByAsset[a.ID]
+	if !ok || len(agg.Units) == 0 {
+		fmt.Fprintln(w, "\nNo cost components: this resource type is not priced.")
+		return nil
+	}
+	currency := string(costGraph.Currency)
+	fmt.Fprintf(w, "Monthly:   %s\n", determinism.FormatAmount(agg.MonthlyCost, currency, determinism.DisplayPlaces))
+
+	for _, unit := range agg.Units {
+		fmt.Fprintf(w, "\nComponent: %s\n", unit.Label)
+		fmt.Fprintf(w, "  Rate:     %s per %s\n", formatRate(unit.Rate, currency), unit.Measure)
+		if key := formatRateKey(unit.RateKey); key != "" {
+			fmt.Fprintf(w, "  Rate key: %s\n", key)
+		}
+		fmt.Fprintf(w, "  Usage:    %s %s (%s)\n", unit.Quantity.String(), unit.Measure, usageSource(unit.Lineage.UsageVector))
+		fmt.Fprintf(w, "  Formula:  %s = %s\n", unit.Lineage.Formula, determinism.FormatAmount(unit.Amount, currency, determinism.DisplayPlaces))
+		if unit.Confidence > 0 {
+			fmt.Fprintf(w, "  Confidence: %.0f%%\n", unit.Confidence*100)
+		}
+
+		factors := confidenceFactors(unit)
+		if len(factors) > 0 {
+			fmt.Fprintln(w, "  Confidence factors:")
+			for _, f := range factors {
+				fmt.Fprintf(w, "    - %s\n", f)
+			}
+		}
+	}
+
+	return nil
+}
+
+// formatRateKey renders a rate key with attributes in sorted order
+func formatRateKey(k types.RateKey) string {
+	if k.Service == "" && len(k.Attributes) == 0 {
+		return ""
+	}
+	parts := []string{string(k.Provider), k.Service, k.Region}
+	attrs := make([]string, 0, len(k.Attributes))
+	for name, v := range k.Attributes {
+		attrs = append(attrs, name+"="+v)
+	}
+	sort.Strings(attrs)
+	return strings.Join(parts, "/") + " {" + strings.Join(attrs, ", ") + "}"
+}
+
+// usageSource describes where a usage value came from
+func usageSource(v *types.UsageVector) string {
+	if v == nil {
+		return "default, " + activeProfile.Name + " profile"
+	}
+	return fmt.Sprintf("%s, confidence %.2f", v.Source, v.Confidence)
+}
+
+// confidenceFactors lists what lowers confidence in a cost unit
+func confidenceFactors(unit *types.CostUnit) []string {
+	factors := append([]string{}, unit.Lineage.Assumptions...)
+	if unit.ConfidenceReason != "" {
+		factors = append(factors, unit.ConfidenceReason)
+	}
+	if unit.Lineage.UsageVector == nil && activeProfile.UptimeFraction < 1.0 {
+		factors = append(factors, fmt.Sprintf("hours scaled by %s profile (%.0f%% uptime)",
+			activeProfile.Name, activeProfile.UptimeFraction*100))
+	}
+	if unit.RateKey.Service == "" {
+		factors = append(factors, "rate from built-in list-price table, not a pricing snapshot")
+	}
+	return factors
+}
+
+// formatRate writes a unit price at full precision with the currency's
+// symbol; rates are often fractions of a cent
+func formatRate(rate decimal.Decimal, currency string) string {
+	if symbol, ok := determinism.CurrencySymbol(currency); ok {
+		return symbol + rate.String()
+	}
+	if currency == "" {
+		currency = determinism.DefaultCurrency
+	}
+	return rate.String() + " " + currency
+}
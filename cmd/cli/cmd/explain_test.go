@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"terraform-cost/core/scanner"
+	"terraform-cost/core/types"
+)
+
+// TestExplainAsset proves --explain prints each component's rate, usage,
+// formula and confidence factors, and rejects an address not scanned
+func TestExplainAsset(t *testing.T) {
+	ctx := context.Background()
+	scanned, err := scanner.GetDefault().DetectAndScan(ctx, &types.ProjectInput{ID: "explain", Path: writeProject(t), Source: types.SourceCLI})
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph := buildAssetGraph(ctx, scanned.Assets)
+	costGraph := calculateCosts(graph)
+
+	var out bytes.Buffer
+	if err := explainAsset(&out, graph, costGraph, "aws_instance.web"); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"Resource:  aws_instance.web",
+		"Source:    main.tf:1",
+		"Snapshot:  none (built-in rate table)",
+		"Component: EC2 Instance (t3.micro)",
+		"Rate:     $0.0104 per hours",
+		"Usage:    730 hours (default, production profile)",
+		"Formula:  hourly_rate * 730 hours/month = $7.59",
+		"- rate from built-in list-price table, not a pricing snapshot",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("explanation lacks %q:\n%s", line, out.String())
+		}
+	}
+
+	err = explainAsset(&out, graph, costGraph, "aws_instance.missing")
+	if err == nil || !strings.Contains(err.Error(), `"aws_instance.missing" is not in the graph`) {
+		t.Errorf("err = %v, want the address reported missing", err)
+	}
+}
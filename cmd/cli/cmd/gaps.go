@@ -0,0 +1,136 @@
+// Package cmd - coverage-gaps command
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"terraform-cost/core/catalog"
+	"terraform-cost/core/scanner"
+	"terraform-cost/core/types"
+)
+
+var (
+	gapsFormat string
+	gapsTop    int
+)
+
+// coverageGapsCmd ranks uncosted resource types across many projects
+var coverageGapsCmd = &cobra.Command{
+	Use:   "coverage-gaps <plan-or-dir>...",
+	Short: "Rank the resource types left uncosted across many plans",
+	Long: `Classify the resources of many plans against the resource catalog and
+rank the unsupported and symbolic resource types by how often they occur.
+
+Each argument is a plan JSON file, a URL, or a module directory. The blind
+spot of a type is its share of all resources across the plans; use the
+ranking to decide which mappers to write next. No pricing snapshot or
+database is required.
+
+Examples:
+  terraform-cost coverage-gaps repos/*/plan.json
+  terraform-cost coverage-gaps --format json --top 20 ./svc-a ./svc-b`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runCoverageGaps,
+}
+
+func init() {
+	rootCmd.AddCommand(coverageGapsCmd)
+
+	coverageGapsCmd.Flags().StringVarP(&gapsFormat, "format", "f", "table", "output format (table, json)")
+	coverageGapsCmd.Flags().IntVar(&gapsTop, "top", 0, "list only the N most frequent gaps (0 = all)")
+}
+
+func runCoverageGaps(cmd *cobra.Command, args []string) error {
+	if gapsFormat != "table" && gapsFormat != "json" {
+		return fmt.Errorf("unknown format %q (available: table, json)", gapsFormat)
+	}
+	if gapsTop < 0 {
+		return fmt.Errorf("--top must not be negative")
+	}
+	ctx := context.Background()
+
+	cat := catalog.NewCatalog()
+	catalog.RegisterAWS(cat)
+	catalog.RegisterAzure(cat)
+	catalog.RegisterGCP(cat)
+
+	gaps := catalog.NewGapAggregator(cat)
+	for _, path := range args {
+		assets, err := gapAssets(ctx, path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		resourceTypes := make([]string, 0, len(assets))
+		for _, raw := range assets {
+			if !raw.IsDataSource {
+				resourceTypes = append(resourceTypes, raw.Type)
+			}
+		}
+		gaps.AddPlan(resourceTypes)
+	}
+
+	report := gaps.Report()
+	if gapsTop > 0 && len(report.Gaps) > gapsTop {
+		report.Gaps = report.Gaps[:gapsTop]
+	}
+	if gapsFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	return printCoverageGaps(report)
+}
+
+// gapAssets reads the resources of a plan JSON or a module directory
+func gapAssets(ctx context.Context, path string) ([]types.RawAsset, error) {
+	if isPlanInput(path) {
+		input, err := loadPlanAssets(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		return input.assets, nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("path does not exist")
+	}
+	result, err := scanner.GetDefault().DetectAndScan(ctx, &types.ProjectInput{
+		ID:     "coverage-gaps",
+		Path:   path,
+		Source: types.SourceCLI,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan project: %w", err)
+	}
+	for _, e := range result.Errors {
+		fmt.Fprintf(os.Stderr, "Warning: %s:%d: %s\n", e.File, e.Line, e.Message)
+	}
+	return result.Assets, nil
+}
+
+func printCoverageGaps(r *catalog.GapReport) error {
+	fmt.Printf("Plans: %d  Resources: %d  Without a numeric cost: %.1f%%\n", r.Plans, r.Resources, r.BlindSpotPercent)
+	if r.SkippedResources > 0 {
+		fmt.Printf("Skipped %d resources of non-cloud providers\n", r.SkippedResources)
+	}
+	if len(r.Gaps) == 0 {
+		fmt.Println("\nNo coverage gaps")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\nRESOURCE TYPE\tCLASS\tOCCURRENCES\tPLANS\tBLIND SPOT")
+	for _, g := range r.Gaps {
+		class := string(g.Class)
+		if !g.InCatalog {
+			class += " (not in catalog)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%.1f%%\n", g.ResourceType, class, g.Occurrences, g.Plans, g.BlindSpotPercent)
+	}
+	return w.Flush()
+}
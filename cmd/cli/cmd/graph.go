@@ -0,0 +1,87 @@
+// Package cmd - dependency graph export for estimate --graph-out
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+
+	"terraform-cost/core/determinism"
+	infragraph "terraform-cost/core/graph"
+	"terraform-cost/core/types"
+	"terraform-cost/internal/atomicfile"
+)
+
+// writeDependencyGraph writes the dependency graph of the scanned
+// resources to path, as JSON for a .json path and as Graphviz DOT
+// otherwise. Edges come from the references of the raw attributes, which
+// asset builders do not keep. Nodes carry their monthly cost when
+// costGraph prices their asset; costGraph may be nil.
+func writeDependencyGraph(path string, raw []types.RawAsset, assets *types.AssetGraph, costGraph *types.CostGraph) error {
+	g, err := infragraph.NewInfraGraphBuilder().Build(parsedInfra(raw))
+	if err != nil {
+		return err
+	}
+	if costGraph != nil {
+		for addr, asset := range assets.ByAddress {
+			agg, ok := costGraph.ByAsset[asset.ID]
+			node := g.GetNode(string(addr))
+			if !ok || node == nil {
+				continue
+			}
+			monthly := determinism.NewMoneyFromDecimal(agg.MonthlyCost, string(costGraph.Currency))
+			node.MonthlyCost = &monthly
+		}
+	}
+
+	var buf bytes.Buffer
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = g.ExportJSON(&buf)
+	} else {
+		err = g.ExportDOT(&buf)
+	}
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, buf.Bytes())
+}
+
+// parsedInfra lists the assets with the references of their attributes;
+// depends_on references are explicit dependencies
+func parsedInfra(assets []types.RawAsset) *infragraph.ParsedInfra {
+	parsed := &infragraph.ParsedInfra{}
+	for _, asset := range assets {
+		var dependsOn, refs []string
+		attrRefs := make(map[string][]string)
+		for name, attr := range asset.Attributes {
+			if len(attr.References) == 0 {
+				continue
+			}
+			if name == "depends_on" {
+				dependsOn = append(dependsOn, attr.References...)
+				continue
+			}
+			refs = append(refs, attr.References...)
+			attrRefs[name] = attr.References
+		}
+
+		if asset.IsDataSource {
+			parsed.DataSources = append(parsed.DataSources, &infragraph.ParsedDataSource{
+				Address:      string(asset.Address),
+				ModulePath:   asset.Module,
+				ImplicitRefs: refs,
+			})
+			continue
+		}
+		parsed.Resources = append(parsed.Resources, &infragraph.ParsedResource{
+			Address:       string(asset.Address),
+			ModulePath:    asset.Module,
+			SourceFile:    asset.SourceFile,
+			SourceLine:    asset.SourceLine,
+			DependsOn:     dependsOn,
+			ImplicitRefs:  refs,
+			AttributeRefs: attrRefs,
+		})
+	}
+	return parsed
+}
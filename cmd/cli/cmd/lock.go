@@ -0,0 +1,91 @@
+// Package cmd - Snapshot lockfile
+// estimate --offline prices from the demo snapshot built into the binary,
+// which a new release can change. The project's .terraform-cost.lock, the
+// same lockfile the CLI adapter keeps, pins that snapshot: the first run
+// writes it, later runs fail when the binary's snapshot is not the pinned
+// one until --update-lock re-pins it, and --no-lock neither reads nor
+// writes it. The built-in rate table has no snapshot to pin.
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	cliadapter "terraform-cost/adapters/cli"
+	tfadapter "terraform-cost/adapters/terraform"
+	"terraform-cost/core/engine"
+	"terraform-cost/core/pricing"
+)
+
+var (
+	updateLock bool
+	noLock     bool
+)
+
+func init() {
+	estimateCmd.Flags().BoolVar(&updateLock, "update-lock", false,
+		"with --offline, re-pin the built-in snapshot in the project's "+cliadapter.LockFileName+" instead of requiring the pinned one")
+	estimateCmd.Flags().BoolVar(&noLock, "no-lock", false,
+		"neither read nor write the project's "+cliadapter.LockFileName)
+}
+
+// validateLockFlags rejects lockfile flags that cannot apply
+func validateLockFlags() error {
+	if updateLock && noLock {
+		return fmt.Errorf("--update-lock cannot be combined with --no-lock")
+	}
+	if updateLock && !offline {
+		return fmt.Errorf("--update-lock needs --offline: only a pricing snapshot is pinned, and the built-in rate table has none")
+	}
+	return nil
+}
+
+// lockDir is the directory whose lockfile pins the estimate of path: the
+// project directory, or the directory of a local plan or state file; ""
+// for remote plans
+func lockDir(path string) string {
+	if tfcRun != "" || tfadapter.IsRemotePlanSource(path) {
+		return ""
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return path
+	}
+	return filepath.Dir(path)
+}
+
+// checkLock pins snapshot in dir's lockfile, or checks it is the snapshot
+// already pinned for its provider and region. It returns the lockfile
+// status line shown with the estimate.
+func checkLock(dir string, snapshot *pricing.PricingSnapshot) (string, error) {
+	path := filepath.Join(dir, cliadapter.LockFileName)
+	lock, err := cliadapter.LoadLockFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	ref := &engine.SnapshotReference{
+		ID:          snapshot.ID,
+		ContentHash: snapshot.ContentHash,
+		Provider:    snapshot.Provider,
+		Region:      snapshot.Region,
+		Alias:       engine.DefaultProviderAlias,
+	}
+	if pin := lock.Find(ref.Provider, ref.Region, ref.Alias); pin != nil && !updateLock {
+		switch {
+		case pin.SnapshotID != string(ref.ID):
+			return "", fmt.Errorf("pinned snapshot %s in %s is not available: this build has %s (run with --update-lock to pin it)",
+				pin.SnapshotID, cliadapter.LockFileName, ref.ID)
+		case pin.ContentHash != ref.ContentHash.Hex():
+			return "", fmt.Errorf("pinned snapshot %s has content hash %s, but %s expects %s",
+				pin.SnapshotID, ref.ContentHash.Hex(), cliadapter.LockFileName, pin.ContentHash)
+		}
+		return "pinned by " + cliadapter.LockFileName, nil
+	}
+
+	lock.Pin(ref)
+	if err := lock.Save(path); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", cliadapter.LockFileName, err)
+	}
+	return "pinned to " + cliadapter.LockFileName, nil
+}
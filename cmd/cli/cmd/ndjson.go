@@ -0,0 +1,82 @@
+// Package cmd - NDJSON streaming output for estimate --format ndjson
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/types"
+)
+
+// formatNDJSON streams one JSON object per resource, then a summary
+const formatNDJSON = "ndjson"
+
+// ndjsonLine is one line of streamed output
+type ndjsonLine struct {
+	Type     string          `json:"type"`
+	Resource *ndjsonResource `json:"resource,omitempty"`
+	Summary  *ndjsonSummary  `json:"summary,omitempty"`
+}
+
+// ndjsonResource is the per-resource line
+type ndjsonResource struct {
+	Address     string            `json:"address"`
+	Type        string            `json:"type"`
+	MonthlyCost decimal.Decimal   `json:"monthly_cost"`
+	Components  []*types.CostUnit `json:"components,omitempty"`
+}
+
+// ndjsonSummary is the final line
+type ndjsonSummary struct {
+	TotalMonthlyCost decimal.Decimal  `json:"total_monthly_cost"`
+	TotalHourlyCost  decimal.Decimal  `json:"total_hourly_cost"`
+	TotalAnnualCost  *decimal.Decimal `json:"total_annual_cost,omitempty"`
+	ResourceCount    int              `json:"resource_count"`
+	PricedCount      int              `json:"priced_count"`
+	Currency         types.Currency   `json:"currency"`
+	UsageProfile     string           `json:"usage_profile"`
+}
+
+// streamNDJSON prices each asset and writes it immediately, keeping only
+// running totals in memory
+func streamNDJSON(w io.Writer, graph *types.AssetGraph) error {
+	enc := json.NewEncoder(w)
+	summary := &ndjsonSummary{
+		Currency:     types.CurrencyUSD,
+		UsageProfile: activeProfile.Name,
+	}
+
+	err := graph.Walk(func(asset *types.Asset) error {
+		units := calculateAssetCost(asset)
+		line := &ndjsonResource{
+			Address:    string(asset.Address),
+			Type:       asset.Type,
+			Components: units,
+		}
+		for _, unit := range units {
+			line.MonthlyCost = line.MonthlyCost.Add(unit.Amount)
+		}
+
+		summary.ResourceCount++
+		if len(units) > 0 {
+			summary.PricedCount++
+		}
+		summary.TotalMonthlyCost = summary.TotalMonthlyCost.Add(line.MonthlyCost)
+
+		return enc.Encode(ndjsonLine{Type: "resource", Resource: line})
+	})
+	if err != nil {
+		return err
+	}
+
+	summary.TotalHourlyCost = summary.TotalMonthlyCost.Div(decimal.NewFromFloat(hoursPerMonth))
+	if showAnnual {
+		annual := determinism.Annualize(determinism.NewMoneyFromDecimal(summary.TotalMonthlyCost, string(summary.Currency)))
+		amount := annual.Amount()
+		summary.TotalAnnualCost = &amount
+	}
+	return enc.Encode(ndjsonLine{Type: "summary", Summary: summary})
+}
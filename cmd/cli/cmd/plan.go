@@ -0,0 +1,103 @@
+// Package cmd - Estimating a plan JSON
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	tfadapter "terraform-cost/adapters/terraform"
+	"terraform-cost/core/types"
+)
+
+// isPlanInput reports whether the estimate path is plan JSON rather than a
+// module directory: "-" (stdin), a URL, a .json file, or any file holding
+// JSON
+func isPlanInput(path string) bool {
+	if tfadapter.IsRemotePlanSource(path) {
+		return true
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	return strings.HasSuffix(path, ".json") || tfadapter.IsPlanJSONFile(path)
+}
+
+// planInput is what an estimate reads from a plan
+type planInput struct {
+	// assets are the resources that exist after the apply
+	assets []types.RawAsset
+
+	// destroyed are the resources the apply destroys, with the values
+	// they have before it, so the savings can be priced
+	destroyed []types.RawAsset
+
+	// destroy is set for a plan destroying every resource
+	destroy bool
+
+	warnings []string
+}
+
+// loadPlanAssets reads `terraform show -json` plan output from a file, stdin
+// ("-") or a URL and returns its resources as raw assets, along with the
+// plan's warnings. Terraform is not run.
+func loadPlanAssets(ctx context.Context, location string) (*planInput, error) {
+	plan, _, err := tfadapter.NewPlanSource(location).Plan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+	return planAssets(plan, location)
+}
+
+// loadTFCRunAssets reads the plan of a Terraform Cloud run, as
+// loadPlanAssets does a plan JSON
+func loadTFCRunAssets(ctx context.Context, runID, token, address string) (*planInput, error) {
+	if token == "" {
+		token = tfadapter.TFCTokenFromEnv(address)
+	}
+	source := tfadapter.NewTFCRunSource(runID, token)
+	source.Address = address
+	data, err := source.Read(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+	plan, err := tfadapter.ReadPlanJSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return planAssets(plan, runID)
+}
+
+// planAssets splits the resources of a plan into those that exist after
+// the apply and those it destroys, with source naming where the plan came
+// from
+func planAssets(plan *tfadapter.PlanOutput, source string) (*planInput, error) {
+	tf, err := tfadapter.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	extraction, err := tf.ExtractPlan(plan)
+	if err != nil {
+		return nil, fmt.Errorf("invalid plan: %w", err)
+	}
+
+	resources := make([]tfadapter.ResourceInfo, 0, len(extraction.Resources))
+	var destroyed []tfadapter.ResourceInfo
+	for _, r := range extraction.Resources {
+		if r.InHead() {
+			resources = append(resources, r)
+			continue
+		}
+		r.Values = r.PriorValues
+		destroyed = append(destroyed, r)
+	}
+	return &planInput{
+		assets:    resourceAssets(resources, source),
+		destroyed: resourceAssets(destroyed, source),
+		destroy:   extraction.Metadata.Mode == tfadapter.PlanModeDestroy,
+		warnings:  extraction.Metadata.Warnings,
+	}, nil
+}
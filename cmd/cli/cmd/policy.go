@@ -0,0 +1,99 @@
+// Package cmd - Policy file enforcement for estimate --policy-file
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/model"
+	"terraform-cost/core/policy"
+	"terraform-cost/core/types"
+)
+
+// policyFailure is returned when error or block severity policies fail.
+// The estimate itself succeeded, so its output is kept.
+type policyFailure struct {
+	failed int
+}
+
+func (e *policyFailure) Error() string {
+	return fmt.Sprintf("%d policies failed", e.failed)
+}
+
+// enforcePolicies evaluates the policy file over the priced graph, prints
+// each result and returns a *policyFailure when a blocking policy fails
+func enforcePolicies(w io.Writer, policies *policy.PolicyFile, graph *types.AssetGraph, costGraph *types.CostGraph, confidence float64) error {
+	result, err := policies.Evaluator().Evaluate(context.Background(), policyInputFromCostGraph(graph, costGraph, confidence))
+	if err != nil {
+		return fmt.Errorf("policy evaluation failed: %w", err)
+	}
+
+	fmt.Fprintln(w, "\nPolicies:")
+	failed := 0
+	for _, p := range result.Policies {
+		icon := "✅"
+		if !p.Passed {
+			icon = "⚠️"
+			if p.Severity == policy.SeverityError || p.Severity == policy.SeverityBlock {
+				icon = "❌"
+				failed++
+			}
+		}
+		fmt.Fprintf(w, "  %s %s: %s\n", icon, p.Name, p.Message)
+	}
+	if failed > 0 {
+		return &policyFailure{failed: failed}
+	}
+	return nil
+}
+
+// policyInputFromCostGraph builds the deep policy context from the CLI's
+// cost graph; every asset is included so tag and type policies see
+// resources that have no cost. The rate table prices every resource it
+// supports numerically, so coverage counts the unpriced ones as
+// unsupported and none as symbolic.
+func policyInputFromCostGraph(graph *types.AssetGraph, costGraph *types.CostGraph, confidence float64) *policy.PolicyInput {
+	currency := string(costGraph.Currency)
+	input := &policy.PolicyInput{
+		InstanceCosts:     make(map[model.InstanceID]*policy.InstanceCostDetail),
+		TotalMonthlyCost:  determinism.NewMoneyFromDecimal(costGraph.TotalMonthlyCost, currency),
+		TotalHourlyCost:   determinism.NewMoneyFromDecimal(costGraph.TotalHourlyCost, currency),
+		OverallConfidence: confidence,
+	}
+
+	graph.Walk(func(asset *types.Asset) error {
+		detail := &policy.InstanceCostDetail{
+			InstanceID:   model.InstanceID(asset.ID),
+			Address:      model.InstanceAddress(asset.Address),
+			ResourceType: asset.Type,
+			Provider:     string(asset.Provider),
+			MonthlyCost:  determinism.Zero(currency),
+			HourlyCost:   determinism.Zero(currency),
+			Confidence:   confidence,
+			Tags:         asset.Tags,
+		}
+		if agg, ok := costGraph.ByAsset[asset.ID]; ok {
+			detail.MonthlyCost = determinism.NewMoneyFromDecimal(agg.MonthlyCost, currency)
+			detail.HourlyCost = determinism.NewMoneyFromDecimal(agg.HourlyCost, currency)
+			if c, ok := unitsConfidence(agg.Units); ok {
+				detail.Confidence = c
+			}
+		}
+		input.InstanceCosts[detail.InstanceID] = detail
+		return nil
+	})
+
+	input.Coverage = &policy.CoverageInput{}
+	if total := len(input.InstanceCosts); total > 0 {
+		unpriced := 0
+		for id := range input.InstanceCosts {
+			if _, ok := costGraph.ByAsset[string(id)]; !ok {
+				unpriced++
+			}
+		}
+		input.Coverage.UnsupportedPercent = float64(unpriced) / float64(total) * 100
+	}
+	return input
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"terraform-cost/db"
+	"terraform-cost/db/ingestion"
+)
+
+func TestSplitStreamingConfig(t *testing.T) {
+	config := ingestion.DefaultStreamingConfig()
+
+	if got := splitStreamingConfig(config, 1); got != config {
+		t.Errorf("one region: got a copy, want the profile itself")
+	}
+
+	split := splitStreamingConfig(config, 4)
+	if split.MaxMemoryMB != config.MaxMemoryMB/4 {
+		t.Errorf("MaxMemoryMB = %d, want %d", split.MaxMemoryMB, config.MaxMemoryMB/4)
+	}
+	if split.BatchSize != config.BatchSize/4 {
+		t.Errorf("BatchSize = %d, want %d", split.BatchSize, config.BatchSize/4)
+	}
+	if split.ConcurrentFetches != 1 {
+		t.Errorf("ConcurrentFetches = %d, want 1", split.ConcurrentFetches)
+	}
+	if split.WorkDir != config.WorkDir {
+		t.Errorf("WorkDir = %q, want %q", split.WorkDir, config.WorkDir)
+	}
+	if *config != *ingestion.DefaultStreamingConfig() {
+		t.Errorf("splitting modified the profile: %+v", config)
+	}
+
+	// Many regions never shrink a batch below 1000 prices
+	if split := splitStreamingConfig(ingestion.LowMemoryConfig(), 20); split.BatchSize != 1000 {
+		t.Errorf("BatchSize = %d, want the 1000 floor", split.BatchSize)
+	}
+}
+
+func TestIngestRegionsConcurrency(t *testing.T) {
+	names := []string{"r1", "r2", "r3", "r4", "r5", "r6", "r7", "r8"}
+	for _, workers := range []int{1, 3} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			var inFlight, peak atomic.Int32
+			run := ingestRegions(context.Background(), names, workers, func(i int, region string) error {
+				n := inFlight.Add(1)
+				defer inFlight.Add(-1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				return nil
+			})
+
+			if got := peak.Load(); got > int32(workers) {
+				t.Errorf("%d regions ingested at once, want at most %d", got, workers)
+			}
+			if len(run.successful) != len(names) || len(run.failed) != 0 || run.skipped != 0 {
+				t.Errorf("successful=%v failed=%v skipped=%d", run.successful, run.failed, run.skipped)
+			}
+			if err := run.err(); err != nil {
+				t.Errorf("err() = %v", err)
+			}
+		})
+	}
+}
+
+func TestIngestRegionsFailures(t *testing.T) {
+	names := []string{"us-west-2", "eu-west-1", "us-east-1", "ap-south-1"}
+	failing := map[string]error{
+		"us-west-2":  errors.New("offer file unavailable"),
+		"ap-south-1": errors.New("coverage 80% below minimum"),
+	}
+
+	run := ingestRegions(context.Background(), names, 2, func(i int, region string) error {
+		if names[i] != region {
+			t.Errorf("region %d is %s, want %s", i, region, names[i])
+		}
+		return failing[region]
+	})
+
+	if want := []string{"ap-south-1", "us-west-2"}; !reflect.DeepEqual(run.failed, want) {
+		t.Errorf("failed = %v, want %v", run.failed, want)
+	}
+	for region, err := range failing {
+		if run.errors[region] != err {
+			t.Errorf("errors[%s] = %v, want %v", region, run.errors[region], err)
+		}
+	}
+	if len(run.successful) != 2 {
+		t.Errorf("successful = %v, want 2 regions", run.successful)
+	}
+	if err := run.err(); err == nil || err.Error() != "2 regions failed, 0 not run" {
+		t.Errorf("err() = %v", err)
+	}
+}
+
+func TestIngestRegionsCanceled(t *testing.T) {
+	names := []string{"r1", "r2", "r3", "r4"}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var mu sync.Mutex
+	var started []string
+	run := ingestRegions(ctx, names, 1, func(i int, region string) error {
+		mu.Lock()
+		started = append(started, region)
+		mu.Unlock()
+		if region == "r2" {
+			cancel()
+			return ctx.Err()
+		}
+		return nil
+	})
+
+	if len(started) != 2 {
+		t.Errorf("started %v after cancel, want r1 and r2 only", started)
+	}
+	if run.skipped != 2 {
+		t.Errorf("skipped = %d, want 2", run.skipped)
+	}
+	if err := run.err(); err == nil || err.Error() != "1 regions failed, 2 not run" {
+		t.Errorf("err() = %v", err)
+	}
+}
+
+func TestRunMultiRegionIngestionConcurrencyFlag(t *testing.T) {
+	saved := pricingRegionConcurrency
+	defer func() { pricingRegionConcurrency = saved }()
+
+	pricingRegionConcurrency = 0
+	err := runMultiRegionIngestion(context.Background(), db.AWS)
+	if err == nil || !strings.Contains(err.Error(), "--region-concurrency must be at least 1") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+// TestIngestionRemediation proves each typed lifecycle error gets the hint
+// for its phase, and a regression found while committing suggests --force
+func TestIngestionRemediation(t *testing.T) {
+	cause := errors.New("boom")
+	for _, tc := range []struct {
+		err  error
+		hint string
+	}{
+		{&ingestion.FetchError{Phase: ingestion.PhaseFetching, Cause: cause}, "check network access"},
+		{&ingestion.NormalizeError{Phase: ingestion.PhaseNormalizing, Cause: cause}, "upstream price format"},
+		{&ingestion.ValidationError{Phase: ingestion.PhaseValidating, Cause: cause}, "--dry-run"},
+		{&ingestion.ValidationError{Phase: ingestion.PhaseCommitting, Cause: cause}, "--force"},
+		{&ingestion.BackupError{Phase: ingestion.PhaseBackedUp, Path: "/backups", Cause: cause}, `"/backups"`},
+		{fmt.Errorf("wrapped: %w", &ingestion.CommitError{Phase: ingestion.PhaseCommitting, Cause: cause}), "rolled back"},
+	} {
+		if hint := ingestionRemediation(tc.err); !strings.Contains(hint, tc.hint) {
+			t.Errorf("%v: hint %q, want it to mention %q", tc.err, hint, tc.hint)
+		}
+	}
+	if hint := ingestionRemediation(cause); hint != "" {
+		t.Errorf("untyped error: hint %q, want none", hint)
+	}
+}
@@ -0,0 +1,148 @@
+// Package cmd - Profiling
+// Slow estimates on large repositories are diagnosed from profiles of the
+// real run. The hidden --cpuprofile, --memprofile and --trace flags write
+// a CPU profile, a heap profile and an execution trace around the
+// command; TERRAFORM_COST_PROFILE=<dir> writes all three into dir
+// instead. Profiling is off unless asked for, and the files are flushed
+// when the command returns or is interrupted.
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"sync"
+	"syscall"
+)
+
+// ProfileEnv names a directory to write every profile into
+const ProfileEnv = "TERRAFORM_COST_PROFILE"
+
+var (
+	cpuProfile string
+	memProfile string
+	traceFile  string
+
+	// stopProfiles flushes the running profiles; nil when none run
+	stopProfiles func() error
+)
+
+func init() {
+	flags := rootCmd.PersistentFlags()
+	flags.StringVar(&cpuProfile, "cpuprofile", "", "write a CPU profile to this file")
+	flags.StringVar(&memProfile, "memprofile", "", "write a heap profile to this file when the command ends")
+	flags.StringVar(&traceFile, "trace", "", "write an execution trace to this file")
+	for _, name := range []string{"cpuprofile", "memprofile", "trace"} {
+		_ = flags.MarkHidden(name)
+	}
+}
+
+// profilePaths returns the profile files asked for by flag, or all three
+// in the ProfileEnv directory
+func profilePaths() (cpu, mem, tr string, err error) {
+	cpu, mem, tr = cpuProfile, memProfile, traceFile
+	dir := os.Getenv(ProfileEnv)
+	if dir == "" || cpu != "" || mem != "" || tr != "" {
+		return cpu, mem, tr, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", "", "", fmt.Errorf("%s: %w", ProfileEnv, err)
+	}
+	return filepath.Join(dir, "cpu.pprof"), filepath.Join(dir, "mem.pprof"), filepath.Join(dir, "trace.out"), nil
+}
+
+// startProfiling starts the profiles asked for and sets stopProfiles
+func startProfiling() error {
+	cpu, mem, tr, err := profilePaths()
+	if err != nil || (cpu == "" && mem == "" && tr == "") {
+		return err
+	}
+
+	var stops []func() error
+	stop := func() error {
+		var errs []error
+		for i := len(stops) - 1; i >= 0; i-- {
+			errs = append(errs, stops[i]())
+		}
+		return errors.Join(errs...)
+	}
+
+	if cpu != "" {
+		f, err := os.Create(cpu)
+		if err != nil {
+			return fmt.Errorf("cpu profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return fmt.Errorf("cpu profile: %w", err)
+		}
+		stops = append(stops, func() error {
+			pprof.StopCPUProfile()
+			return f.Close()
+		})
+	}
+	if tr != "" {
+		f, err := os.Create(tr)
+		if err != nil {
+			stop()
+			return fmt.Errorf("trace: %w", err)
+		}
+		if err := trace.Start(f); err != nil {
+			f.Close()
+			stop()
+			return fmt.Errorf("trace: %w", err)
+		}
+		stops = append(stops, func() error {
+			trace.Stop()
+			return f.Close()
+		})
+	}
+	if mem != "" {
+		stops = append(stops, func() error {
+			f, err := os.Create(mem)
+			if err != nil {
+				return fmt.Errorf("heap profile: %w", err)
+			}
+			defer f.Close()
+			// Collect garbage so the profile shows live memory
+			runtime.GC()
+			if err := pprof.WriteHeapProfile(f); err != nil {
+				return fmt.Errorf("heap profile: %w", err)
+			}
+			return nil
+		})
+	}
+
+	var once sync.Once
+	var stopErr error
+	stopProfiles = func() error {
+		once.Do(func() { stopErr = stop() })
+		return stopErr
+	}
+
+	// An interrupted slow run is the one worth profiling
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		if _, ok := <-interrupts; ok {
+			if err := stopProfiles(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing profiles: %v\n", err)
+			}
+			os.Exit(130)
+		}
+	}()
+	return nil
+}
+
+// stopProfiling flushes the running profiles, if any
+func stopProfiling() error {
+	if stopProfiles == nil {
+		return nil
+	}
+	return stopProfiles()
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// resetProfiling clears the profiling flags and state after a test
+func resetProfiling(t *testing.T) {
+	t.Cleanup(func() {
+		cpuProfile, memProfile, traceFile = "", "", ""
+		stopProfiles = nil
+	})
+}
+
+// TestProfilingOffByDefault proves nothing is profiled unless asked for
+func TestProfilingOffByDefault(t *testing.T) {
+	resetProfiling(t)
+	t.Setenv(ProfileEnv, "")
+
+	if err := startProfiling(); err != nil {
+		t.Fatal(err)
+	}
+	if stopProfiles != nil {
+		t.Error("profiling started without a flag or " + ProfileEnv)
+	}
+	if err := stopProfiling(); err != nil {
+		t.Errorf("stopProfiling with nothing running = %v", err)
+	}
+}
+
+// TestProfilePaths proves the flags take precedence over the environment
+// directory, which gets all three profiles
+func TestProfilePaths(t *testing.T) {
+	resetProfiling(t)
+	dir := filepath.Join(t.TempDir(), "profiles")
+	t.Setenv(ProfileEnv, dir)
+
+	cpu, mem, tr, err := profilePaths()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "cpu.pprof"), filepath.Join(dir, "mem.pprof"), filepath.Join(dir, "trace.out")}
+	if cpu != want[0] || mem != want[1] || tr != want[2] {
+		t.Errorf("paths = %s, %s, %s, want %v", cpu, mem, tr, want)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Errorf("%s was not created: %v", ProfileEnv, err)
+	}
+
+	memProfile = "heap.pprof"
+	cpu, mem, tr, err = profilePaths()
+	if err != nil || cpu != "" || mem != "heap.pprof" || tr != "" {
+		t.Errorf("with --memprofile: paths = %q, %q, %q (%v), want only the heap profile", cpu, mem, tr, err)
+	}
+}
+
+// TestProfilingWritesFiles proves every profile is flushed when the
+// command ends, and stopping twice writes them once
+func TestProfilingWritesFiles(t *testing.T) {
+	resetProfiling(t)
+	dir := t.TempDir()
+	t.Setenv(ProfileEnv, dir)
+
+	if err := startProfiling(); err != nil {
+		t.Fatal(err)
+	}
+	if err := stopProfiling(); err != nil {
+		t.Fatal(err)
+	}
+	if err := stopProfiling(); err != nil {
+		t.Errorf("second stopProfiling = %v", err)
+	}
+
+	for _, name := range []string{"cpu.pprof", "mem.pprof", "trace.out"} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if info.Size() == 0 {
+			t.Errorf("%s is empty", name)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"terraform-cost/db/ingestion"
+)
+
+// stderrIsTerminal reports whether stderr is an interactive terminal, so a
+// redrawn progress line is not written into redirected output
+func stderrIsTerminal() bool {
+	info, err := os.Stderr.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// ingestionProgress renders item-level ingestion progress as a bar on
+// stderr, or returns nil when stderr is not a terminal. Phase boundaries
+// are already logged.
+func ingestionProgress() ingestion.ProgressReporter {
+	if !stderrIsTerminal() {
+		return nil
+	}
+	return func(phase string, completed, total int, _ string) {
+		if phase != ingestion.ProgressPhaseNormalizing && phase != ingestion.ProgressPhaseWriting {
+			return
+		}
+		if total == 0 {
+			// A streamed fetch: the count so far, without a bar
+			fmt.Fprintf(os.Stderr, "\r%-12s %d", strings.ToLower(phase), completed)
+			return
+		}
+		const width = 30
+		percent := 100.0
+		if total > 0 {
+			percent = float64(completed) / float64(total) * 100
+		}
+		filled := int(percent / 100 * width)
+		if filled > width {
+			filled = width
+		}
+		fmt.Fprintf(os.Stderr, "\r%-12s [%s%s] %d/%d (%.0f%%)", strings.ToLower(phase),
+			strings.Repeat("█", filled), strings.Repeat("░", width-filled), completed, total, percent)
+		if completed >= total {
+			fmt.Fprintln(os.Stderr)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Package cmd - Rate overrides
+// estimate --rate-overrides layers a file of negotiated prices (see
+// pricing.LoadRateOverrides) on the rates the estimate prices from: the
+// demo snapshot with --offline, the built-in rate table otherwise. An
+// override applies when its resource type, component and attributes are
+// the ones a rate is looked up by, e.g. aws_instance compute with
+// {"instance_type": "m5.large"}, and its provider and region, when set,
+// are aws and the estimate's region. Units priced from an override say so
+// in their lineage. The lockfile still pins the snapshot itself.
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/pricing"
+	"terraform-cost/core/pricing/demo"
+	"terraform-cost/core/types"
+)
+
+var (
+	rateOverridesFile string
+
+	// rateOverrides holds the overrides that apply to the estimate's
+	// provider and region; nil unless --rate-overrides is set
+	rateOverrides *pricing.OverlaySnapshot
+)
+
+func init() {
+	estimateCmd.Flags().StringVar(&rateOverridesFile, "rate-overrides", "",
+		"JSON file of negotiated prices that replace the rates they match")
+}
+
+// loadRateOverrides reads --rate-overrides for the estimate's region. It
+// runs after startOffline, so offline estimates use the demo region.
+func loadRateOverrides() error {
+	rateOverrides = nil
+	if rateOverridesFile == "" {
+		return nil
+	}
+	overrides, err := pricing.LoadRateOverrides(rateOverridesFile)
+	if err != nil {
+		return err
+	}
+
+	overrideRegion := region
+	switch {
+	case offlineSnapshot != nil:
+		overrideRegion = offlineSnapshot.Region
+	case overrideRegion == "":
+		overrideRegion = demo.DefaultRegion
+	}
+	// The base has no rates, so lookups only ever find an override; it
+	// prices in the default currency like the rate table and demo rates
+	base := pricing.NewSnapshotBuilder(demo.Provider, overrideRegion).Build()
+	overlay, err := pricing.NewOverlaySnapshot(base, overrides)
+	if err != nil {
+		return fmt.Errorf("invalid rate overrides %s: %w", rateOverridesFile, err)
+	}
+	rateOverrides = overlay
+	return nil
+}
+
+// overrideRate looks a rate up in the rate overrides; ok is false without
+// --rate-overrides or when no override matches
+func overrideRate(resourceType, component string, attrs map[string]string) (*pricing.RateEntry, bool) {
+	if rateOverrides == nil {
+		return nil, false
+	}
+	return rateOverrides.LookupRate(resourceType, component, attrs)
+}
+
+// lookupRate is the rate an estimate prices from before the built-in
+// table: an override, else the demo snapshot's rate when offline
+func lookupRate(resourceType, component string, attrs map[string]string) (decimal.Decimal, bool) {
+	if rate, ok := overrideRate(resourceType, component, attrs); ok {
+		return rate.Price, true
+	}
+	return offlineRate(resourceType, component, attrs)
+}
+
+// noteRateOverride records in unit's lineage that its rate came from the
+// overrides file, when it did
+func noteRateOverride(unit *types.CostUnit, resourceType, component string, attrs map[string]string) {
+	rate, ok := overrideRate(resourceType, component, attrs)
+	if !ok {
+		return
+	}
+	unit.Lineage.Assumptions = append(unit.Lineage.Assumptions,
+		fmt.Sprintf("rate %s/%s overridden by %s", rate.Price, rate.Unit, rate.Overlay))
+}
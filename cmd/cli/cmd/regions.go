@@ -0,0 +1,194 @@
+// Package cmd - regions command
+// Lists the regions the registry considers billable, so operators can plan
+// multi-region ingestion and audit which regions have pricing without
+// reading the registry's source.
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"terraform-cost/db"
+	"terraform-cost/db/regions"
+)
+
+var (
+	regionsProvider string
+	regionsFormat   string
+)
+
+var regionsCmd = &cobra.Command{
+	Use:   "regions",
+	Short: "Inspect the billable regions registry",
+}
+
+var regionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List billable regions and whether they have active pricing",
+	Long: `List the regions each provider is billed in, as used by
+"pricing update --region all".
+
+For each region the command prints its display name, its pricing source,
+its code in each provider (the equivalent region in the same location, or
+"-" when the provider has none) and the aliases of its active snapshots.
+Snapshot status needs the pricing database; without it the status is
+reported as unknown.
+
+Examples:
+  terraform-cost regions list --provider aws
+  terraform-cost regions list --format json`,
+	Args: cobra.NoArgs,
+	RunE: runRegionsList,
+}
+
+func init() {
+	rootCmd.AddCommand(regionsCmd)
+	regionsCmd.AddCommand(regionsListCmd)
+
+	regionsListCmd.Flags().StringVarP(&regionsProvider, "provider", "p", "", "Cloud provider (aws, azure, gcp); all when omitted")
+	regionsListCmd.Flags().StringVarP(&regionsFormat, "format", "f", "table", "Output format (table, json)")
+}
+
+// snapshotStatus values of a listed region
+const (
+	snapshotStatusActive  = "active"
+	snapshotStatusNone    = "none"
+	snapshotStatusUnknown = "unknown"
+)
+
+// regionListing is one billable region in `regions list` output
+type regionListing struct {
+	Provider      db.CloudProvider `json:"provider"`
+	Region        string           `json:"region"`
+	DisplayName   string           `json:"display_name"`
+	PricingSource string           `json:"pricing_source"`
+
+	// Codes maps each provider to its region in the same location
+	Codes map[db.CloudProvider]string `json:"codes"`
+
+	// SnapshotStatus is active, none, or unknown without a database
+	SnapshotStatus string `json:"snapshot_status"`
+
+	// ActiveAliases are the provider aliases with an active snapshot
+	ActiveAliases []string `json:"active_aliases,omitempty"`
+}
+
+var regionProviders = []db.CloudProvider{db.AWS, db.Azure, db.GCP}
+
+func runRegionsList(cmd *cobra.Command, args []string) error {
+	if regionsFormat != "table" && regionsFormat != "json" {
+		return fmt.Errorf("unknown format %q (available: table, json)", regionsFormat)
+	}
+	providers := regionProviders
+	if regionsProvider != "" {
+		cloud, err := parseCloudProvider(regionsProvider)
+		if err != nil {
+			return err
+		}
+		providers = []db.CloudProvider{cloud}
+	}
+
+	active, err := activeSnapshotAliases()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: snapshot status unknown: %v\n", err)
+	}
+
+	listings := regionListings(regions.NewRegistry(), providers, active)
+	if regionsFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(listings)
+	}
+	return printRegionListings(listings)
+}
+
+// regionListings lists the providers' billable regions; a nil active map
+// leaves their snapshot status unknown
+func regionListings(registry *regions.Registry, providers []db.CloudProvider, active map[db.CloudProvider]map[string][]string) []regionListing {
+	listings := make([]regionListing, 0)
+	for _, provider := range providers {
+		for _, region := range registry.GetBillableRegions(provider) {
+			listing := regionListing{
+				Provider:       provider,
+				Region:         region.Region,
+				DisplayName:    region.DisplayName,
+				PricingSource:  region.PricingSource,
+				Codes:          make(map[db.CloudProvider]string, len(regionProviders)),
+				SnapshotStatus: snapshotStatusUnknown,
+			}
+			for _, other := range regionProviders {
+				if code, ok := regions.Equivalent(provider, region.Region, other); ok {
+					listing.Codes[other] = code
+				}
+			}
+			if active != nil {
+				listing.ActiveAliases = active[provider][region.Region]
+				listing.SnapshotStatus = snapshotStatusNone
+				if len(listing.ActiveAliases) > 0 {
+					listing.SnapshotStatus = snapshotStatusActive
+				}
+			}
+			listings = append(listings, listing)
+		}
+	}
+	return listings
+}
+
+// activeSnapshotAliases maps provider and region to the aliases of their
+// active snapshots
+func activeSnapshotAliases() (map[db.CloudProvider]map[string][]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	store, err := getDBStore()
+	if err != nil {
+		return nil, fmt.Errorf("database connection required: %w", err)
+	}
+	defer store.Close()
+
+	snapshots, err := store.ListActiveSnapshots(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active snapshots: %w", err)
+	}
+	return snapshotAliases(snapshots), nil
+}
+
+// snapshotAliases maps provider and region to the aliases of snapshots
+func snapshotAliases(snapshots []*db.PricingSnapshot) map[db.CloudProvider]map[string][]string {
+	aliases := make(map[db.CloudProvider]map[string][]string)
+	for _, s := range snapshots {
+		if aliases[s.Cloud] == nil {
+			aliases[s.Cloud] = make(map[string][]string)
+		}
+		aliases[s.Cloud][s.Region] = append(aliases[s.Cloud][s.Region], s.ProviderAlias)
+	}
+	return aliases
+}
+
+func printRegionListings(listings []regionListing) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROVIDER\tREGION\tNAME\tSOURCE\tAWS\tAZURE\tGCP\tSNAPSHOT")
+	for _, l := range listings {
+		codes := make([]string, len(regionProviders))
+		for i, provider := range regionProviders {
+			codes[i] = l.Codes[provider]
+			if codes[i] == "" {
+				codes[i] = "-"
+			}
+		}
+		snapshot := l.SnapshotStatus
+		if len(l.ActiveAliases) > 0 {
+			snapshot = strings.Join(l.ActiveAliases, ",")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			l.Provider, l.Region, l.DisplayName, l.PricingSource, strings.Join(codes, "\t"), snapshot)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"terraform-cost/db"
+	"terraform-cost/db/regions"
+)
+
+// TestRegionListings proves each billable region is listed with its code
+// in every provider and the aliases of its active snapshots
+func TestRegionListings(t *testing.T) {
+	active := snapshotAliases([]*db.PricingSnapshot{
+		{Cloud: db.AWS, Region: "us-east-1", ProviderAlias: "default"},
+		{Cloud: db.AWS, Region: "us-east-1", ProviderAlias: "prod"},
+		{Cloud: db.Azure, Region: "eastus", ProviderAlias: "default"},
+	})
+
+	registry := regions.NewRegistry()
+	listings := regionListings(registry, []db.CloudProvider{db.AWS}, active)
+	if len(listings) != len(registry.GetBillableRegions(db.AWS)) {
+		t.Fatalf("listed %d regions, want every billable AWS region", len(listings))
+	}
+
+	byRegion := make(map[string]regionListing, len(listings))
+	for _, l := range listings {
+		if l.Provider != db.AWS {
+			t.Errorf("%s listed for %s, want aws only", l.Region, l.Provider)
+		}
+		byRegion[l.Region] = l
+	}
+
+	virginia := byRegion["us-east-1"]
+	wantCodes := map[db.CloudProvider]string{db.AWS: "us-east-1", db.Azure: "eastus", db.GCP: "us-east4"}
+	if !reflect.DeepEqual(virginia.Codes, wantCodes) {
+		t.Errorf("us-east-1 codes = %v, want %v", virginia.Codes, wantCodes)
+	}
+	if virginia.SnapshotStatus != snapshotStatusActive || !reflect.DeepEqual(virginia.ActiveAliases, []string{"default", "prod"}) {
+		t.Errorf("us-east-1 snapshot = %s %v, want active default,prod", virginia.SnapshotStatus, virginia.ActiveAliases)
+	}
+
+	ireland := byRegion["eu-west-1"]
+	if _, ok := ireland.Codes[db.GCP]; ok || ireland.SnapshotStatus != snapshotStatusNone {
+		t.Errorf("eu-west-1 = %+v, want no GCP code and no snapshot", ireland)
+	}
+
+	// Without the database the status is unknown
+	for _, l := range regionListings(registry, []db.CloudProvider{db.AWS}, nil) {
+		if l.SnapshotStatus != snapshotStatusUnknown || l.ActiveAliases != nil {
+			t.Fatalf("%s without a database: snapshot = %s %v, want unknown", l.Region, l.SnapshotStatus, l.ActiveAliases)
+		}
+	}
+}
+
+// TestPrintRegionListings proves a missing code prints as "-" and active
+// aliases replace the snapshot status
+func TestPrintRegionListings(t *testing.T) {
+	out, err := captureStdout(t, func() error {
+		return printRegionListings([]regionListing{
+			{
+				Provider: db.AWS, Region: "us-east-1", DisplayName: "US East (N. Virginia)", PricingSource: "api",
+				Codes:          map[db.CloudProvider]string{db.AWS: "us-east-1", db.Azure: "eastus", db.GCP: "us-east4"},
+				SnapshotStatus: snapshotStatusActive, ActiveAliases: []string{"default", "prod"},
+			},
+			{
+				Provider: db.AWS, Region: "eu-west-1", DisplayName: "Europe (Ireland)", PricingSource: "api",
+				Codes:          map[db.CloudProvider]string{db.AWS: "eu-west-1", db.Azure: "northeurope"},
+				SnapshotStatus: snapshotStatusNone,
+			},
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "PROVIDER") {
+		t.Fatalf("output:\n%s", out)
+	}
+	// The display name has spaces, so compare the columns around it
+	for i, want := range []struct{ head, tail string }{
+		{"aws us-east-1", "api us-east-1 eastus us-east4 default,prod"},
+		{"aws eu-west-1", "api eu-west-1 northeurope - none"},
+	} {
+		row := strings.Join(strings.Fields(lines[i+1]), " ")
+		if !strings.HasPrefix(row, want.head+" ") || !strings.HasSuffix(row, " "+want.tail) {
+			t.Errorf("row %d = %q, want %q ... %q", i, row, want.head, want.tail)
+		}
+	}
+}
+
+// TestRegionsListFlags proves bad flags are rejected before the database
+// is consulted
+func TestRegionsListFlags(t *testing.T) {
+	defer func() { regionsProvider, regionsFormat = "", "table" }()
+
+	regionsProvider, regionsFormat = "", "yaml"
+	if err := runRegionsList(regionsListCmd, nil); err == nil || !strings.Contains(err.Error(), "unknown format") {
+		t.Errorf("--format yaml: err = %v", err)
+	}
+	regionsProvider, regionsFormat = "oracle", "json"
+	if err := runRegionsList(regionsListCmd, nil); err == nil || !strings.Contains(err.Error(), "unsupported provider") {
+		t.Errorf("--provider oracle: err = %v", err)
+	}
+}
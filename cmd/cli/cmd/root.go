@@ -12,8 +12,10 @@ import (
 )
 
 var (
-	cfgFile string
-	verbose bool
+	cfgFile   string
+	verbose   bool
+	logFormat string
+	logLevel  string
 )
 
 // rootCmd represents the base command
@@ -29,11 +31,18 @@ Examples:
   terraform-cost estimate ./my-terraform-project
   terraform-cost estimate --format json ./infrastructure
   terraform-cost diff main..feature-branch`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return startProfiling()
+	},
 }
 
-// Execute runs the CLI
+// Execute runs the CLI, flushing any profiles when the command ends
 func Execute() error {
-	return rootCmd.Execute()
+	err := rootCmd.Execute()
+	if stopErr := stopProfiling(); stopErr != nil {
+		fmt.Fprintf(os.Stderr, "Error writing profiles: %v\n", stopErr)
+	}
+	return err
 }
 
 func init() {
@@ -41,6 +50,8 @@ func init() {
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.terraform-cost.json)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "enable verbose output")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "", "log output format (json, text)")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "", "minimum log level (debug, info, warn, error)")
 
 	// Add subcommands
 	rootCmd.AddCommand(estimateCmd)
@@ -60,6 +71,12 @@ func initConfig() {
 
 	// Initialize logging
 	cfg := config.Get()
+	if logFormat != "" {
+		cfg.Logging.Format = logFormat
+	}
+	if logLevel != "" {
+		cfg.Logging.Level = logLevel
+	}
 	if verbose {
 		cfg.Logging.Level = "debug"
 	}
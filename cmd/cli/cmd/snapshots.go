@@ -0,0 +1,413 @@
+// Package cmd - Pricing snapshot activation commands
+// Operators flip the active snapshot for a provider/region/alias without
+// re-ingesting, e.g. to roll back to a previous price set, and diff two
+// snapshots to audit what an ingestion changed. A running server caches
+// snapshots; --server tells it to drop its cache so the flip takes effect
+// before the cache TTL passes.
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+
+	"terraform-cost/db"
+	"terraform-cost/db/snapshotdiff"
+)
+
+var pricingSnapshotsCmd = &cobra.Command{
+	Use:   "snapshots",
+	Short: "List, activate, and deactivate pricing snapshots",
+}
+
+var pricingSnapshotsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List snapshots for a provider and region",
+	RunE:  runSnapshotsList,
+}
+
+var pricingSnapshotsActivateCmd = &cobra.Command{
+	Use:   "activate <snapshot-id>",
+	Short: "Make a snapshot the active one for its provider/region/alias",
+	Long: `Activate an existing snapshot.
+
+Any other active snapshot for the same provider, region, and alias is
+deactivated in the same transaction, so exactly one stays active.
+
+With --server, the server at that URL is told to drop its cached
+snapshots, so estimates use the new one immediately.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSnapshotsActivate,
+}
+
+var pricingSnapshotsDeactivateCmd = &cobra.Command{
+	Use:   "deactivate <snapshot-id>",
+	Short: "Deactivate a snapshot",
+	Long: `Deactivate an active snapshot.
+
+Estimates for its provider/region/alias fail until another snapshot is
+activated or ingested.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSnapshotsDeactivate,
+}
+
+var pricingSnapshotsDiffCmd = &cobra.Command{
+	Use:   "diff <snapshot-a> <snapshot-b>",
+	Short: "Show rates added, removed, or repriced between two snapshots",
+	Long: `Compare the rates of two snapshots, A (before) and B (after).
+
+Reports rate keys only in B (added), only in A (removed), and rates whose
+price changed, largest movement first. Changes smaller than --threshold
+percent are counted but not listed.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runSnapshotsDiff,
+}
+
+var (
+	snapshotsProvider    string
+	snapshotsRegion      string
+	snapshotsEnvironment string
+	snapshotsConfirm     bool
+	snapshotsServer      string
+
+	snapshotsDiffFormat    string
+	snapshotsDiffThreshold float64
+	snapshotsDiffSort      string
+)
+
+func init() {
+	pricingCmd.AddCommand(pricingSnapshotsCmd)
+	pricingSnapshotsCmd.AddCommand(pricingSnapshotsListCmd)
+	pricingSnapshotsCmd.AddCommand(pricingSnapshotsActivateCmd)
+	pricingSnapshotsCmd.AddCommand(pricingSnapshotsDeactivateCmd)
+	pricingSnapshotsCmd.AddCommand(pricingSnapshotsDiffCmd)
+
+	pricingSnapshotsListCmd.Flags().StringVarP(&snapshotsProvider, "provider", "p", "", "Cloud provider (aws, azure, gcp) [REQUIRED]")
+	pricingSnapshotsListCmd.Flags().StringVarP(&snapshotsRegion, "region", "r", "", "Region [REQUIRED]")
+	pricingSnapshotsListCmd.MarkFlagRequired("provider")
+	pricingSnapshotsListCmd.MarkFlagRequired("region")
+
+	for _, c := range []*cobra.Command{pricingSnapshotsActivateCmd, pricingSnapshotsDeactivateCmd} {
+		c.Flags().StringVar(&snapshotsEnvironment, "environment", "production", "Environment (production, staging, development)")
+		c.Flags().BoolVar(&snapshotsConfirm, "confirm", false, "Confirm you want to modify production pricing [REQUIRED in production]")
+		c.Flags().StringVar(&snapshotsServer, "server", "", "Base URL of a running server whose snapshot cache to invalidate")
+	}
+
+	pricingSnapshotsDiffCmd.Flags().StringVarP(&snapshotsDiffFormat, "format", "f", "table", "Output format (table, json)")
+	pricingSnapshotsDiffCmd.Flags().Float64Var(&snapshotsDiffThreshold, "threshold", 0, "Hide price changes below this percent")
+	pricingSnapshotsDiffCmd.Flags().StringVar(&snapshotsDiffSort, "sort", "percent", "Rank price changes by movement (percent, absolute)")
+}
+
+func runSnapshotsList(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	cloud, err := parseCloudProvider(snapshotsProvider)
+	if err != nil {
+		return err
+	}
+
+	store, err := getDBStore()
+	if err != nil {
+		return fmt.Errorf("database connection required: %w", err)
+	}
+	defer store.Close()
+
+	snapshots, err := store.ListSnapshots(ctx, cloud, snapshotsRegion)
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	if len(snapshots) == 0 {
+		fmt.Printf("No snapshots for %s/%s\n", cloud, snapshotsRegion)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACTIVE\tID\tALIAS\tSOURCE\tFETCHED\tHASH")
+	for _, s := range snapshots {
+		active := ""
+		if s.IsActive {
+			active = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			active, s.ID, s.ProviderAlias, s.Source, s.FetchedAt.Format(time.RFC3339), shortHash(s.Hash))
+	}
+	return w.Flush()
+}
+
+func runSnapshotsActivate(cmd *cobra.Command, args []string) error {
+	return changeSnapshotActivation(args[0], true)
+}
+
+func runSnapshotsDeactivate(cmd *cobra.Command, args []string) error {
+	return changeSnapshotActivation(args[0], false)
+}
+
+// changeSnapshotActivation activates or deactivates a snapshot in one
+// transaction and reports the snapshot it superseded
+func changeSnapshotActivation(rawID string, activate bool) error {
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return fmt.Errorf("invalid snapshot id %q: %w", rawID, err)
+	}
+
+	verb := "deactivate"
+	if activate {
+		verb = "activate"
+	}
+	if snapshotsEnvironment == "production" && !snapshotsConfirm {
+		return fmt.Errorf("refusing to %s a production snapshot without --confirm", verb)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	store, err := getDBStore()
+	if err != nil {
+		return fmt.Errorf("database connection required: %w", err)
+	}
+	defer store.Close()
+
+	snapshot, err := store.GetSnapshot(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to load snapshot: %w", err)
+	}
+	if snapshot == nil {
+		return fmt.Errorf("snapshot %s not found", id)
+	}
+
+	tx, err := store.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// The active snapshot is read under the transaction's lock, so a
+	// concurrent activation cannot supersede it unreported
+	scoped, err := tx.LockSnapshots(ctx, snapshot.Cloud, snapshot.Region, snapshot.ProviderAlias)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to load active snapshot: %w", err)
+	}
+	current, previous := activeSnapshots(scoped, id)
+	if current == nil {
+		tx.Rollback()
+		return fmt.Errorf("snapshot %s not found", id)
+	}
+	if current.IsActive == activate {
+		tx.Rollback()
+		fmt.Printf("Snapshot %s is already %sd\n", id, verb)
+		return nil
+	}
+
+	if activate {
+		err = tx.ActivateSnapshot(ctx, id)
+	} else {
+		err = tx.DeactivateSnapshot(ctx, id)
+	}
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to %s snapshot: %w", verb, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+
+	scope := fmt.Sprintf("%s/%s (alias %s)", snapshot.Cloud, snapshot.Region, snapshot.ProviderAlias)
+	if activate {
+		fmt.Printf("✓ Activated snapshot %s for %s\n", id, scope)
+		if previous != nil {
+			fmt.Printf("  Superseded: %s (fetched %s, hash %s)\n",
+				previous.ID, previous.FetchedAt.Format(time.RFC3339), shortHash(previous.Hash))
+		} else {
+			fmt.Println("  Superseded: none (no snapshot was active)")
+		}
+	} else {
+		fmt.Printf("✓ Deactivated snapshot %s for %s\n", id, scope)
+		fmt.Println("  No snapshot is active for this scope; estimates will fail until one is activated")
+	}
+
+	if snapshotsServer != "" {
+		cached, err := invalidateServerCache(ctx, snapshotsServer)
+		switch {
+		case err != nil:
+			return fmt.Errorf("snapshot %sd, but the server cache was not invalidated (it refreshes after its TTL): %w", verb, err)
+		case cached:
+			fmt.Printf("  Invalidated the snapshot cache of %s\n", snapshotsServer)
+		default:
+			fmt.Printf("  %s does not cache snapshots; nothing to invalidate\n", snapshotsServer)
+		}
+	}
+	return nil
+}
+
+// activeSnapshots finds snapshot id among the snapshots of its scope and
+// the other snapshot active there, if any
+func activeSnapshots(scoped []*db.PricingSnapshot, id uuid.UUID) (snapshot, active *db.PricingSnapshot) {
+	for _, s := range scoped {
+		switch {
+		case s.ID == id:
+			snapshot = s
+		case s.IsActive:
+			active = s
+		}
+	}
+	return snapshot, active
+}
+
+// invalidateServerCache asks a running server to drop its cached
+// snapshots; cached is false when the server does not cache them
+func invalidateServerCache(ctx context.Context, server string) (cached bool, err error) {
+	url := strings.TrimSuffix(server, "/") + "/api/v1/snapshots/invalidate"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Invalidated bool `json:"invalidated"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return false, fmt.Errorf("invalid response from %s: %w", url, err)
+	}
+	return body.Invalidated, nil
+}
+
+// parseCloudProvider validates a --provider flag value
+func parseCloudProvider(name string) (db.CloudProvider, error) {
+	switch name {
+	case "aws":
+		return db.AWS, nil
+	case "azure":
+		return db.Azure, nil
+	case "gcp":
+		return db.GCP, nil
+	default:
+		return "", fmt.Errorf("unsupported provider: %s (use aws, azure, or gcp)", name)
+	}
+}
+
+// shortHash abbreviates a content hash for display
+func shortHash(hash string) string {
+	if len(hash) > 16 {
+		return hash[:16]
+	}
+	return hash
+}
+
+func runSnapshotsDiff(cmd *cobra.Command, args []string) error {
+	if snapshotsDiffFormat != "table" && snapshotsDiffFormat != "json" {
+		return fmt.Errorf("unsupported format: %s (use table or json)", snapshotsDiffFormat)
+	}
+	order := snapshotdiff.Order(snapshotsDiffSort)
+	if order != snapshotdiff.ByPercent && order != snapshotdiff.ByAbsolute {
+		return fmt.Errorf("unsupported sort: %s (use percent or absolute)", snapshotsDiffSort)
+	}
+	if snapshotsDiffThreshold < 0 {
+		return fmt.Errorf("--threshold must not be negative")
+	}
+
+	ids := make([]uuid.UUID, len(args))
+	for i, raw := range args {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return fmt.Errorf("invalid snapshot id %q: %w", raw, err)
+		}
+		ids[i] = id
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	store, err := getDBStore()
+	if err != nil {
+		return fmt.Errorf("database connection required: %w", err)
+	}
+	defer store.Close()
+
+	rates := make([][]db.SnapshotRate, len(ids))
+	for i, id := range ids {
+		snapshot, err := store.GetSnapshot(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to load snapshot: %w", err)
+		}
+		if snapshot == nil {
+			return fmt.Errorf("snapshot %s not found", id)
+		}
+		if rates[i], err = store.ListRates(ctx, id); err != nil {
+			return fmt.Errorf("failed to load rates for snapshot %s: %w", id, err)
+		}
+	}
+
+	result := snapshotdiff.Diff(rates[0], rates[1], snapshotdiff.Options{
+		ThresholdPercent: snapshotsDiffThreshold,
+		Order:            order,
+	})
+
+	if snapshotsDiffFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	return printSnapshotDiff(ids[0], ids[1], result)
+}
+
+// printSnapshotDiff renders a snapshot diff as a summary and tables
+func printSnapshotDiff(a, b uuid.UUID, result *snapshotdiff.Result) error {
+	fmt.Printf("Snapshot %s → %s\n", a, b)
+	fmt.Printf("  Added: %d  Removed: %d  Changed: %d (%d up, %d down)  Unchanged: %d",
+		len(result.Added), len(result.Removed), len(result.Changed),
+		result.Increases(), len(result.Changed)-result.Increases(), result.Unchanged)
+	if result.BelowThreshold > 0 {
+		fmt.Printf("  Below threshold: %d", result.BelowThreshold)
+	}
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if len(result.Changed) > 0 {
+		fmt.Fprintln(w, "\nCHANGED\tOLD\tNEW\tDELTA\tPERCENT")
+		for _, c := range result.Changed {
+			percent := "new price"
+			if c.Percent != nil {
+				percent = fmt.Sprintf("%+.2f%%", *c.Percent)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", c.Key, c.OldPrice, c.Price, signed(c.Delta), percent)
+		}
+	}
+	for _, section := range []struct {
+		title string
+		rates []snapshotdiff.Rate
+	}{{"ADDED", result.Added}, {"REMOVED", result.Removed}} {
+		if len(section.rates) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s\tPRICE\tCURRENCY\n", section.title)
+		for _, r := range section.rates {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", r.Key, r.Price, r.Currency)
+		}
+	}
+	return w.Flush()
+}
+
+// signed renders a decimal with an explicit sign
+func signed(d decimal.Decimal) string {
+	if d.IsPositive() {
+		return "+" + d.String()
+	}
+	return d.String()
+}
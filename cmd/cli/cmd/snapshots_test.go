@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"terraform-cost/db"
+)
+
+func TestActiveSnapshots(t *testing.T) {
+	target := &db.PricingSnapshot{ID: uuid.New()}
+	active := &db.PricingSnapshot{ID: uuid.New(), IsActive: true}
+	inactive := &db.PricingSnapshot{ID: uuid.New()}
+
+	snapshot, previous := activeSnapshots([]*db.PricingSnapshot{inactive, active, target}, target.ID)
+	if snapshot != target || previous != active {
+		t.Errorf("got (%v, %v), want the target and the active snapshot", snapshot, previous)
+	}
+
+	// An active target supersedes nothing
+	target.IsActive, active.IsActive = true, false
+	if snapshot, previous = activeSnapshots([]*db.PricingSnapshot{active, target}, target.ID); snapshot != target || previous != nil {
+		t.Errorf("got (%v, %v), want the target only", snapshot, previous)
+	}
+
+	// A snapshot deleted before the lock is not found
+	if snapshot, _ = activeSnapshots([]*db.PricingSnapshot{active}, target.ID); snapshot != nil {
+		t.Errorf("found %v, want nil", snapshot)
+	}
+}
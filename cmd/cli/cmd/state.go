@@ -0,0 +1,72 @@
+// Package cmd - Estimating existing infrastructure from state
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tfadapter "terraform-cost/adapters/terraform"
+	"terraform-cost/core/types"
+)
+
+// loadStateAssets reads `terraform show -json` state output from a file,
+// stdin ("-") or a URL and returns its managed resources as raw assets, in
+// place of scanning .tf files
+func loadStateAssets(ctx context.Context, location string) ([]types.RawAsset, error) {
+	data, err := tfadapter.NewPlanSource(location).Read(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	tf, err := tfadapter.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	state, err := tf.ParseStateJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := tf.ExtractStateResources(state)
+	if err != nil {
+		return nil, err
+	}
+	return resourceAssets(resources, location), nil
+}
+
+// resourceAssets converts extracted plan or state resources to raw assets
+func resourceAssets(resources []tfadapter.ResourceInfo, source string) []types.RawAsset {
+	assets := make([]types.RawAsset, 0, len(resources))
+	for _, r := range resources {
+		attrs := make(types.Attributes, len(r.Values))
+		for name, v := range r.Values {
+			attrs[name] = types.Attribute{Value: v}
+		}
+		if _, ok := attrs["region"]; !ok && r.Region != "" {
+			attrs["region"] = types.Attribute{Value: r.Region}
+		}
+		assets = append(assets, types.RawAsset{
+			Address:    types.ResourceAddress(r.Address),
+			Provider:   stateProvider(r.Provider),
+			Type:       r.Type,
+			Name:       r.Name,
+			Attributes: attrs,
+			Module:     r.ModuleAddress,
+			SourceFile: source,
+		})
+	}
+	return assets
+}
+
+// stateProvider maps "registry.terraform.io/hashicorp/aws" to a provider
+func stateProvider(name string) types.Provider {
+	switch name[strings.LastIndex(name, "/")+1:] {
+	case "aws":
+		return types.ProviderAWS
+	case "azurerm":
+		return types.ProviderAzure
+	case "google", "google-beta":
+		return types.ProviderGCP
+	}
+	return types.ProviderUnknown
+}
@@ -0,0 +1,61 @@
+// Package cmd - Cost breakdown by tag for estimate --group-by
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"terraform-cost/adapters/terraform/hcl"
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/engine"
+	"terraform-cost/core/model"
+	"terraform-cost/core/types"
+)
+
+// tagsValue returns a tags attribute's value as plan values decode it, or
+// nil when it is unknown. The HCL scanner keeps literal values as
+// cty.Value.
+func tagsValue(attr types.Attribute) any {
+	if attr.IsUnknown {
+		return nil
+	}
+	if v, ok := attr.Value.(cty.Value); ok {
+		return hcl.CtyToSafe(v).AsMap()
+	}
+	return attr.Value
+}
+
+// tagGroups groups the priced assets by the tag key with
+// EstimationResult.GroupByTag
+func tagGroups(graph *types.AssetGraph, costGraph *types.CostGraph, key string) []engine.TagGroup {
+	currency := string(costGraph.Currency)
+	result := &engine.EstimationResult{
+		InstanceCosts: determinism.NewStableMap[model.InstanceID, *engine.InstanceCost](),
+	}
+	graph.Walk(func(asset *types.Asset) error {
+		agg, ok := costGraph.ByAsset[asset.ID]
+		if !ok {
+			return nil
+		}
+		result.InstanceCosts.Set(model.InstanceID(asset.ID), &engine.InstanceCost{
+			Address:      model.InstanceAddress(asset.Address),
+			ResourceType: asset.Type,
+			MonthlyCost:  determinism.NewMoneyFromDecimal(agg.MonthlyCost, currency),
+			HourlyCost:   determinism.NewMoneyFromDecimal(agg.HourlyCost, currency),
+			Tags:         asset.Tags,
+		})
+		return nil
+	})
+	return result.GroupByTag(key)
+}
+
+// printTagBreakdown prints the cost per tag value
+func printTagBreakdown(w io.Writer, graph *types.AssetGraph, costGraph *types.CostGraph, key string) {
+	fmt.Fprintf(w, "\nCost by tag %q:\n", key)
+	for _, g := range tagGroups(graph, costGraph, key) {
+		fmt.Fprintf(w, "  %-40s %4d resources %15s\n",
+			truncate(g.Value, 40), g.InstanceCount, determinism.FormatAmount(g.DisplayMonthlyCost.Amount(), string(costGraph.Currency), determinism.DisplayPlaces)+"/month")
+	}
+}
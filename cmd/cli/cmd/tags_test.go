@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"terraform-cost/core/engine"
+	"terraform-cost/core/scanner"
+	"terraform-cost/core/types"
+)
+
+// TestTagBreakdown proves --group-by reads scanned tags and groups costs
+// with EstimationResult.GroupByTag
+func TestTagBreakdown(t *testing.T) {
+	dir := t.TempDir()
+	src := `resource "aws_instance" "api" {
+  instance_type = "t3.micro"
+  tags = { team = "payments" }
+}
+
+resource "aws_instance" "worker" {
+  instance_type = "t3.micro"
+  tags = { team = "payments" }
+}
+
+resource "aws_instance" "search" {
+  instance_type = "t3.micro"
+  tags = { team = "search" }
+}
+
+resource "aws_instance" "scratch" {
+  instance_type = "t3.micro"
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	scanned, err := scanner.GetDefault().DetectAndScan(ctx, &types.ProjectInput{ID: "tags", Path: dir, Source: types.SourceCLI})
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph := buildAssetGraph(ctx, scanned.Assets)
+	costGraph := calculateCosts(graph)
+
+	groups := tagGroups(graph, costGraph, "team")
+	want := []struct {
+		value string
+		count int
+	}{{"payments", 2}, {"search", 1}, {engine.UntaggedValue, 1}}
+	if len(groups) != len(want) {
+		t.Fatalf("got %d groups, want %d: %+v", len(groups), len(want), groups)
+	}
+	for i, w := range want {
+		if groups[i].Value != w.value || groups[i].InstanceCount != w.count {
+			t.Errorf("group %d = %s x%d, want %s x%d", i, groups[i].Value, groups[i].InstanceCount, w.value, w.count)
+		}
+	}
+
+	var out bytes.Buffer
+	printTagBreakdown(&out, graph, costGraph, "team")
+	if !strings.Contains(out.String(), `Cost by tag "team"`) || !strings.Contains(out.String(), "payments") {
+		t.Errorf("breakdown:\n%s", out.String())
+	}
+}
@@ -0,0 +1,173 @@
+// Package cmd - validate command
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	_ "terraform-cost/adapters/terraform/hcl"
+	"terraform-cost/core/catalog"
+	"terraform-cost/core/scanner"
+	"terraform-cost/core/types"
+)
+
+var (
+	minCoverage    float64
+	validateFormat string
+)
+
+// validateCmd reports catalog coverage without pricing
+var validateCmd = &cobra.Command{
+	Use:   "validate [path]",
+	Short: "Check how much of a Terraform project can be costed",
+	Long: `Scan Terraform configurations and classify every resource against the
+resource catalog (numeric, symbolic, indirect, unsupported).
+
+No pricing snapshot or database is required. With --format json the report
+is a single JSON object on stdout; the exit code is non-zero exactly when
+"passed" is false.
+
+Examples:
+  terraform-cost validate .
+  terraform-cost validate --min-coverage 90 ./infrastructure
+  terraform-cost validate --format json --min-coverage 90 .`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runValidate,
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+
+	validateCmd.Flags().Float64Var(&minCoverage, "min-coverage", 0, "fail if coverage percentage is below this threshold (0-100)")
+	validateCmd.Flags().StringVarP(&validateFormat, "format", "f", "table", "output format (table, json)")
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("path does not exist: %s", path)
+	}
+	if minCoverage < 0 || minCoverage > 100 {
+		return fmt.Errorf("--min-coverage must be between 0 and 100, got %g", minCoverage)
+	}
+	if validateFormat != "table" && validateFormat != "json" {
+		return fmt.Errorf("unknown format %q (available: table, json)", validateFormat)
+	}
+
+	input := &types.ProjectInput{
+		ID:     "validate",
+		Path:   path,
+		Source: types.SourceCLI,
+	}
+
+	scanResult, err := scanner.GetDefault().DetectAndScan(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to scan project: %w", err)
+	}
+	for _, e := range scanResult.Errors {
+		fmt.Fprintf(os.Stderr, "Warning: %s:%d: %s\n", e.File, e.Line, e.Message)
+	}
+
+	cat := catalog.NewCatalog()
+	catalog.RegisterAWS(cat)
+	catalog.RegisterAzure(cat)
+	catalog.RegisterGCP(cat)
+
+	summary := catalog.NewCoverageSummary()
+	for _, raw := range scanResult.Assets {
+		if raw.IsDataSource {
+			continue
+		}
+		summary.Add(raw.Type, cat.Classify(catalog.CloudProvider(raw.Provider), raw.Type))
+	}
+
+	passed := summary.Coverage() >= minCoverage
+	if validateFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(newCoverageReport(summary, passed)); err != nil {
+			return err
+		}
+	} else {
+		printCoverage(summary)
+	}
+
+	if !passed {
+		// The threshold failure is a result, not a usage error
+		cmd.SilenceUsage = true
+		return fmt.Errorf("coverage %.1f%% is below --min-coverage %.1f%%", summary.Coverage(), minCoverage)
+	}
+	return nil
+}
+
+// coverageReport is the JSON form of a validate run
+type coverageReport struct {
+	Coverage         coveragePercents  `json:"coverage"`
+	CoveragePercent  float64           `json:"coverage_percent"`
+	UnsupportedTypes []unsupportedType `json:"unsupported_types"`
+	TotalResources   int               `json:"total_resources"`
+	Threshold        float64           `json:"threshold"`
+	Passed           bool              `json:"passed"`
+}
+
+// coveragePercents is the share of resources in each coverage class
+type coveragePercents struct {
+	Numeric     float64 `json:"numeric"`
+	Symbolic    float64 `json:"symbolic"`
+	Indirect    float64 `json:"indirect"`
+	Unsupported float64 `json:"unsupported"`
+}
+
+type unsupportedType struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+}
+
+func newCoverageReport(s *catalog.CoverageSummary, passed bool) *coverageReport {
+	report := &coverageReport{
+		Coverage: coveragePercents{
+			Numeric:     s.Percent(catalog.ClassNumeric),
+			Symbolic:    s.Percent(catalog.ClassSymbolic),
+			Indirect:    s.Percent(catalog.ClassIndirect),
+			Unsupported: s.Percent(catalog.ClassUnsupported),
+		},
+		CoveragePercent:  s.Coverage(),
+		UnsupportedTypes: []unsupportedType{},
+		TotalResources:   s.Total,
+		Threshold:        minCoverage,
+		Passed:           passed,
+	}
+	for _, t := range s.UnsupportedTypes() {
+		report.UnsupportedTypes = append(report.UnsupportedTypes, unsupportedType{Type: t, Count: s.UnsupportedCount(t)})
+	}
+	return report
+}
+
+func printCoverage(s *catalog.CoverageSummary) {
+	fmt.Printf("Resources: %d\n\n", s.Total)
+	for _, class := range []catalog.CoverageClass{
+		catalog.ClassNumeric,
+		catalog.ClassSymbolic,
+		catalog.ClassIndirect,
+		catalog.ClassUnsupported,
+	} {
+		fmt.Printf("  %-12s %5d  %6.1f%%\n", class, s.ByClass[class], s.Percent(class))
+	}
+	fmt.Printf("\nCoverage: %.1f%%\n", s.Coverage())
+
+	if unsupported := s.UnsupportedTypes(); len(unsupported) > 0 {
+		fmt.Println("\nUnsupported resource types:")
+		for _, t := range unsupported {
+			fmt.Printf("  %s (%d)\n", t, s.UnsupportedCount(t))
+		}
+	}
+}
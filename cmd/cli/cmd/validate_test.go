@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// validateFixture has two numeric, one indirect and one unsupported
+// resource, so 75% of it is covered
+const validateFixture = `resource "aws_instance" "web" {
+  ami           = "ami-123"
+  instance_type = "t3.micro"
+}
+
+resource "aws_instance" "worker" {
+  ami           = "ami-123"
+  instance_type = "t3.micro"
+}
+
+resource "aws_iam_role" "web" {
+  name = "web"
+}
+
+resource "aws_made_up_thing" "x" {}
+`
+
+// writeValidateFixture writes validateFixture to a new module directory
+func writeValidateFixture(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(validateFixture), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// TestValidateCoverage proves validate reports coverage from the catalog
+// alone and fails below --min-coverage
+func TestValidateCoverage(t *testing.T) {
+	dir := writeValidateFixture(t)
+	defer func() { minCoverage = 0 }()
+
+	for _, tc := range []struct {
+		threshold float64
+		passed    bool
+	}{{75, true}, {80, false}} {
+		minCoverage = tc.threshold
+		out, err := captureStdout(t, func() error { return runValidate(validateCmd, []string{dir}) })
+		if tc.passed != (err == nil) {
+			t.Errorf("--min-coverage %v: err = %v, want passed=%v", tc.threshold, err, tc.passed)
+		}
+		if err != nil && !strings.Contains(err.Error(), "below --min-coverage") {
+			t.Errorf("--min-coverage %v: err = %v", tc.threshold, err)
+		}
+
+		for _, want := range []string{
+			"Resources: 4",
+			"numeric          2    50.0%",
+			"indirect         1    25.0%",
+			"Coverage: 75.0%",
+			"aws_made_up_thing (1)",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("--min-coverage %v: output missing %q:\n%s", tc.threshold, want, out)
+			}
+		}
+	}
+}
+
+// TestValidateJSON proves --format json reports the coverage, the
+// unsupported types and whether the threshold was met
+func TestValidateJSON(t *testing.T) {
+	dir := writeValidateFixture(t)
+	defer func() { minCoverage, validateFormat = 0, "table" }()
+
+	for _, tc := range []struct {
+		threshold float64
+		passed    bool
+	}{{75, true}, {80, false}} {
+		minCoverage, validateFormat = tc.threshold, "json"
+		out, err := captureStdout(t, func() error { return runValidate(validateCmd, []string{dir}) })
+		if tc.passed != (err == nil) {
+			t.Errorf("--min-coverage %v: err = %v, want passed=%v", tc.threshold, err, tc.passed)
+		}
+
+		var report coverageReport
+		if err := json.Unmarshal([]byte(out), &report); err != nil {
+			t.Fatalf("invalid JSON report: %v\n%s", err, out)
+		}
+		if report.TotalResources != 4 || report.Passed != tc.passed || report.Threshold != tc.threshold {
+			t.Errorf("--min-coverage %v: report = %+v", tc.threshold, report)
+		}
+		if report.Coverage.Numeric != 50 || report.Coverage.Indirect != 25 || report.CoveragePercent != 75 {
+			t.Errorf("--min-coverage %v: coverage = %+v (%v%%)", tc.threshold, report.Coverage, report.CoveragePercent)
+		}
+		if len(report.UnsupportedTypes) != 1 || report.UnsupportedTypes[0] != (unsupportedType{Type: "aws_made_up_thing", Count: 1}) {
+			t.Errorf("--min-coverage %v: unsupported = %+v", tc.threshold, report.UnsupportedTypes)
+		}
+	}
+
+	validateFormat = "yaml"
+	if err := runValidate(validateCmd, []string{dir}); err == nil || !strings.Contains(err.Error(), "unknown format") {
+		t.Errorf("--format yaml: err = %v, want an unknown format error", err)
+	}
+}
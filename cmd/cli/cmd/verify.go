@@ -0,0 +1,73 @@
+// Package cmd - verify command
+package cmd
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"terraform-cost/internal/attestation"
+)
+
+var verifyPublicKeys []string
+
+// verifyCmd checks the signature of a signed estimate
+var verifyCmd = &cobra.Command{
+	Use:   "verify <file>",
+	Short: "Verify the signature of a signed estimate",
+	Long: `Check that a signed estimate (CI JSON output, or an HTTP estimate
+response) has not been changed since it was signed.
+
+The document carries the public key that verifies it. Without --public-key
+this proves only that the document is intact; with it, the signer must also
+be one of the given keys. Keys are base64, as embedded in signed documents,
+or a PEM file. Use - to read the document from stdin.
+
+Examples:
+  terraform-cost verify cost-estimate.json
+  terraform-cost verify --public-key signing.pub cost-estimate.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runVerify,
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+
+	verifyCmd.Flags().StringArrayVar(&verifyPublicKeys, "public-key", nil, "trusted signer public key, base64 or PEM file (repeatable)")
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	var doc []byte
+	var err error
+	if args[0] == "-" {
+		doc, err = io.ReadAll(os.Stdin)
+	} else {
+		doc, err = os.ReadFile(args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", args[0], err)
+	}
+
+	var trusted []ed25519.PublicKey
+	for _, s := range verifyPublicKeys {
+		if data, err := os.ReadFile(s); err == nil {
+			s = string(data)
+		}
+		key, err := attestation.ParsePublicKey(s)
+		if err != nil {
+			return err
+		}
+		trusted = append(trusted, key)
+	}
+
+	key, err := attestation.Verify(doc, trusted...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%s: signature valid (public key %s)\n", args[0], base64.StdEncoding.EncodeToString(key))
+	return nil
+}
@@ -0,0 +1,219 @@
+// Package cmd - Watch mode
+// estimate --watch re-estimates a project each time its .tf files are
+// saved and prints a compact summary with the change from the previous
+// run, for instant cost feedback while editing. Each run is the estimate
+// path's own scan and pricing; rates are loaded once at start (the
+// built-in table, or the demo rates with --offline), so a run never waits
+// on a pricing database.
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/model"
+	"terraform-cost/core/scanner"
+	"terraform-cost/core/types"
+	"terraform-cost/internal/watch"
+)
+
+// watchChangesListed caps the resources a summary lists as changed
+const watchChangesListed = 10
+
+var watchMode bool
+
+func init() {
+	estimateCmd.Flags().BoolVar(&watchMode, "watch", false,
+		"re-estimate whenever the project's .tf files are saved, printing a compact summary with the change from the previous run")
+}
+
+// validateWatch checks --watch has a directory to watch and no flags whose
+// output its summary replaces
+func validateWatch(path string) error {
+	if fromState != "" || tfcRun != "" || isPlanInput(path) {
+		return fmt.Errorf("--watch needs a directory of .tf files, not a plan or state")
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return fmt.Errorf("--watch needs a directory of .tf files: %s", path)
+	}
+	conflicts := []struct {
+		flag string
+		set  bool
+	}{
+		{"--format", outputFormat != "cli"},
+		{"--output-file", outputFile != ""},
+		{"--template", templatePath != ""},
+		{"--explain", explainAddr != ""},
+		{"--graph-out", graphOut != ""},
+		{"--group-by", groupByTag != ""},
+		{"--workspace", workspace != ""},
+		{"--compare-workspace", len(compareWorkspaces) > 0},
+	}
+	for _, c := range conflicts {
+		if c.set {
+			return fmt.Errorf("--watch cannot be combined with %s", c.flag)
+		}
+	}
+	return nil
+}
+
+// watchRun is one estimate of the watched project
+type watchRun struct {
+	at         time.Time
+	resources  int
+	monthly    decimal.Decimal
+	currency   string
+	confidence float64
+
+	// byAddress is the monthly cost of each priced resource
+	byAddress map[string]decimal.Decimal
+}
+
+// watchEstimate estimates the project at path, then again after every
+// debounced batch of .tf changes, until interrupted
+func watchEstimate(path string, w io.Writer) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(w, "Watching %s for changes to .tf files (Ctrl-C to stop)\n", path)
+	previous := runWatchEstimate(ctx, path, w, nil)
+
+	opts := watch.DefaultOptions()
+	opts.Match = watch.HasSuffix(".tf")
+	err := watch.Watch(ctx, path, opts, func(events []watch.Event) {
+		fmt.Fprintln(w)
+		for _, e := range events {
+			name, relErr := filepath.Rel(path, e.Path)
+			if relErr != nil {
+				name = e.Path
+			}
+			fmt.Fprintf(w, "%s %s\n", e.Op, name)
+		}
+		if run := runWatchEstimate(ctx, path, w, previous); run != nil {
+			previous = run
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %w", path, err)
+	}
+	return nil
+}
+
+// runWatchEstimate estimates the project and prints its summary against
+// previous; a failed run is reported and returns nil, so watching goes on
+// and the next run is compared with the last good one
+func runWatchEstimate(ctx context.Context, path string, w io.Writer, previous *watchRun) *watchRun {
+	now := determinism.DefaultClock.Now()
+	input := &types.ProjectInput{
+		ID:       fmt.Sprintf("estimate-%d", now.Unix()),
+		Path:     path,
+		Source:   types.SourceCLI,
+		Metadata: types.InputMetadata{Timestamp: now},
+	}
+	scanResult, err := scanner.GetDefault().DetectAndScan(ctx, input)
+	if err != nil {
+		fmt.Fprintf(w, "[%s] Estimate failed: %v\n", now.Format(time.TimeOnly), err)
+		return nil
+	}
+	for _, e := range scanResult.Errors {
+		fmt.Fprintf(w, "  %s:%d: %s\n", e.File, e.Line, e.Message)
+	}
+
+	rawAssets, _ := ignoreAssets(scanResult.Assets, model.NewIgnoreMatcher(ignoreGlobs))
+	graph := buildAssetGraph(ctx, rawAssets)
+	costGraph := calculateCosts(graph)
+
+	run := &watchRun{
+		at:         now,
+		resources:  len(rawAssets),
+		monthly:    costGraph.TotalMonthlyCost,
+		currency:   string(costGraph.Currency),
+		confidence: graphConfidence(costGraph),
+		byAddress:  make(map[string]decimal.Decimal, len(costGraph.ByAsset)),
+	}
+	for _, agg := range costGraph.ByAsset {
+		run.byAddress[agg.Label] = run.byAddress[agg.Label].Add(agg.MonthlyCost)
+	}
+	printWatchSummary(w, run, previous)
+
+	if policies != nil {
+		var failed *policyFailure
+		if err := enforcePolicies(w, policies, graph, costGraph, run.confidence); err != nil && !errors.As(err, &failed) {
+			fmt.Fprintf(w, "Warning: %v\n", err)
+		}
+	}
+	return run
+}
+
+// watchChange is a resource whose monthly cost changed between runs
+type watchChange struct {
+	address string
+	mark    string
+	delta   decimal.Decimal
+}
+
+// printWatchSummary prints the run's totals and, after the first run, the
+// change in total and the resources that changed most
+func printWatchSummary(w io.Writer, run, previous *watchRun) {
+	money := func(amount decimal.Decimal) string {
+		return determinism.FormatAmount(amount, run.currency, determinism.DisplayPlaces)
+	}
+
+	line := fmt.Sprintf("[%s] %d resources  %s/month", run.at.Format(time.TimeOnly), run.resources, money(run.monthly))
+	if showAnnual {
+		annual := determinism.Annualize(determinism.NewMoneyFromDecimal(run.monthly, run.currency))
+		line += fmt.Sprintf(" (%s/year)", annual.Format(determinism.DisplayPlaces))
+	}
+	if previous != nil {
+		line += "  " + determinism.FormatSignedAmount(run.monthly.Sub(previous.monthly), run.currency, determinism.DisplayPlaces)
+	}
+	fmt.Fprintf(w, "%s  confidence %.0f%%\n", line, run.confidence*100)
+	if previous == nil {
+		return
+	}
+
+	var changes []watchChange
+	for address, cost := range run.byAddress {
+		before, existed := previous.byAddress[address]
+		switch {
+		case !existed:
+			changes = append(changes, watchChange{address, "+", cost})
+		case !cost.Equal(before):
+			changes = append(changes, watchChange{address, "~", cost.Sub(before)})
+		}
+	}
+	for address, cost := range previous.byAddress {
+		if _, exists := run.byAddress[address]; !exists {
+			changes = append(changes, watchChange{address, "-", cost.Neg()})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if c := changes[i].delta.Abs().Cmp(changes[j].delta.Abs()); c != 0 {
+			return c > 0
+		}
+		return changes[i].address < changes[j].address
+	})
+
+	listed := changes
+	if len(listed) > watchChangesListed {
+		listed = listed[:watchChangesListed]
+	}
+	for _, c := range listed {
+		fmt.Fprintf(w, "  %s %-50s %14s\n", c.mark, truncate(c.address, 50),
+			determinism.FormatSignedAmount(c.delta, run.currency, determinism.DisplayPlaces))
+	}
+	if len(changes) > len(listed) {
+		fmt.Fprintf(w, "  ... and %d more\n", len(changes)-len(listed))
+	}
+}
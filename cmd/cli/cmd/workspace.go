@@ -0,0 +1,319 @@
+// Package cmd - Workspaces
+// estimate --workspace estimates a project as a Terraform workspace sees
+// it: terraform.workspace resolves to the workspace, so workspace-keyed
+// sizing such as var.sizes[terraform.workspace] prices concretely, and the
+// estimate is labeled with it. The file scan leaves expressions
+// unevaluated, so a workspace estimate reads the project through the
+// evaluation pipeline instead. --compare-workspace estimates the project
+// once per workspace and prints their costs side by side.
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/engine"
+	"terraform-cost/core/model"
+	"terraform-cost/core/terraform"
+	"terraform-cost/core/types"
+	"terraform-cost/internal/config"
+)
+
+var (
+	workspace         string
+	compareWorkspaces []string
+
+	// estimateWorkspace labels the estimate; empty unless --workspace is set
+	estimateWorkspace string
+)
+
+func init() {
+	estimateCmd.Flags().StringVar(&workspace, "workspace", "",
+		"estimate the project as this Terraform workspace, resolving terraform.workspace to it")
+	estimateCmd.Flags().StringSliceVar(&compareWorkspaces, "compare-workspace", nil,
+		"estimate the project once per workspace and compare their costs (repeatable, comma-separated, e.g. dev,staging,prod)")
+}
+
+// validateWorkspaceFlags checks the workspace flags have a directory of .tf
+// files to evaluate and an output they can produce
+func validateWorkspaceFlags(path string) error {
+	if workspace == "" && len(compareWorkspaces) == 0 {
+		return nil
+	}
+	flag := "--workspace"
+	if len(compareWorkspaces) > 0 {
+		flag = "--compare-workspace"
+	}
+	if workspace != "" && len(compareWorkspaces) > 0 {
+		return fmt.Errorf("--workspace cannot be combined with --compare-workspace")
+	}
+	if fromState != "" || tfcRun != "" || isPlanInput(path) {
+		return fmt.Errorf("%s needs a directory of .tf files: a plan or state was made in one workspace already", flag)
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s needs a directory of .tf files: %s", flag, path)
+	}
+	if len(compareWorkspaces) == 0 {
+		return nil
+	}
+	if outputFormat != "cli" && outputFormat != "json" {
+		return fmt.Errorf("--compare-workspace prints a cli or json comparison, not --format %s", outputFormat)
+	}
+	conflicts := []struct {
+		flag string
+		set  bool
+	}{
+		{"--template", templatePath != ""},
+		{"--explain", explainAddr != ""},
+		{"--graph-out", graphOut != ""},
+		{"--group-by", groupByTag != ""},
+		{"--policy-file", policyFile != ""},
+	}
+	for _, c := range conflicts {
+		if c.set {
+			return fmt.Errorf("--compare-workspace cannot be combined with %s", c.flag)
+		}
+	}
+	return nil
+}
+
+// workspaceAssets evaluates the project at path for a workspace and returns
+// its instances as raw assets, the workspace terraform.workspace resolved
+// to, and the evaluation's warnings
+func workspaceAssets(ctx context.Context, path, name string) ([]types.RawAsset, string, []string, error) {
+	result, err := config.Get().Terraform.NewPipeline().Execute(ctx, &terraform.ScanInput{
+		RootPath:      path,
+		Workspace:     name,
+		DefaultRegion: region,
+	})
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to evaluate %s for workspace %s: %w", path, name, err)
+	}
+	return instanceAssets(result.Graph), result.Workspace, result.WarningMessages(), nil
+}
+
+// instanceAssets converts the expanded instances of an evaluated project to
+// raw assets, one per count or for_each instance
+func instanceAssets(graph *model.InstanceGraph) []types.RawAsset {
+	instances := graph.Instances()
+	assets := make([]types.RawAsset, 0, len(instances))
+	for _, inst := range instances {
+		if inst.Metadata.Destroyed {
+			continue
+		}
+		attrs := make(types.Attributes, len(inst.Attributes))
+		for name, attr := range inst.Attributes {
+			attrs[name] = types.Attribute{Value: attr.Value, IsUnknown: attr.IsUnknown}
+		}
+		if _, ok := attrs["region"]; !ok && inst.Provider.Region != "" {
+			attrs["region"] = types.Attribute{Value: inst.Provider.Region}
+		}
+
+		resourceType := engine.ResourceTypeFromAddress(inst.Address)
+		module, name, isData := splitInstanceAddress(string(inst.Address), resourceType)
+		assets = append(assets, types.RawAsset{
+			Address:       types.ResourceAddress(inst.Address),
+			Provider:      stateProvider(inst.Provider.Type),
+			ProviderAlias: inst.Provider.Alias,
+			Type:          resourceType,
+			Name:          name,
+			Attributes:    attrs,
+			Module:        module,
+			IsDataSource:  isData,
+			SourceFile:    inst.Metadata.Location.File,
+			SourceLine:    inst.Metadata.Location.StartLine,
+		})
+	}
+	return assets
+}
+
+// splitInstanceAddress splits module.app.aws_instance.web[0] into its
+// module path (module.app) and resource name (web)
+func splitInstanceAddress(address, resourceType string) (module, name string, isData bool) {
+	parts := strings.Split(address, ".")
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[i] != resourceType {
+			continue
+		}
+		name = parts[i+1]
+		if j := strings.Index(name, "["); j >= 0 {
+			name = name[:j]
+		}
+		prefix := parts[:i]
+		if n := len(prefix); n > 0 && prefix[n-1] == "data" {
+			prefix, isData = prefix[:n-1], true
+		}
+		return strings.Join(prefix, "."), name, isData
+	}
+	return "", "", false
+}
+
+// workspaceCost is one workspace's estimate in a comparison
+type workspaceCost struct {
+	workspace string
+	resources int
+	monthly   decimal.Decimal
+	byType    map[string]decimal.Decimal
+	warnings  []string
+}
+
+// compareWorkspaceCosts estimates the project at path once per workspace,
+// in the order given
+func compareWorkspaceCosts(ctx context.Context, path string, names []string) ([]*workspaceCost, error) {
+	var costs []*workspaceCost
+	seen := make(map[string]bool)
+	matcher := model.NewIgnoreMatcher(ignoreGlobs)
+	for _, name := range names {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		rawAssets, resolved, warnings, err := workspaceAssets(ctx, path, name)
+		if err != nil {
+			return nil, err
+		}
+		rawAssets, _ = ignoreAssets(rawAssets, matcher)
+		graph := buildAssetGraph(ctx, rawAssets)
+		costGraph := calculateCosts(graph)
+
+		cost := &workspaceCost{
+			workspace: resolved,
+			resources: len(rawAssets),
+			monthly:   costGraph.TotalMonthlyCost,
+			byType:    make(map[string]decimal.Decimal),
+			warnings:  warnings,
+		}
+		graph.Walk(func(asset *types.Asset) error {
+			if agg, ok := costGraph.ByAsset[asset.ID]; ok {
+				cost.byType[asset.Type] = cost.byType[asset.Type].Add(agg.MonthlyCost)
+			}
+			return nil
+		})
+		costs = append(costs, cost)
+	}
+	if len(costs) == 0 {
+		return nil, fmt.Errorf("--compare-workspace names no workspace")
+	}
+	return costs, nil
+}
+
+// printWorkspaceComparison prints the monthly cost of each resource type in
+// each workspace, with totals
+func printWorkspaceComparison(w io.Writer, costs []*workspaceCost) {
+	currency := string(types.CurrencyUSD)
+	money := func(amount decimal.Decimal) string {
+		return determinism.FormatAmount(amount, currency, determinism.DisplayPlaces)
+	}
+
+	rule := strings.Repeat("─", 24+14*len(costs))
+	fmt.Fprintln(w, "WORKSPACE COMPARISON (monthly)")
+	fmt.Fprintln(w, rule)
+	fmt.Fprintf(w, "%-24s", "RESOURCE TYPE")
+	for _, c := range costs {
+		fmt.Fprintf(w, " %13s", truncate(c.workspace, 13))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, rule)
+
+	for _, resourceType := range comparedTypes(costs) {
+		fmt.Fprintf(w, "%-24s", truncate(resourceType, 24))
+		for _, c := range costs {
+			cost, ok := c.byType[resourceType]
+			if !ok {
+				fmt.Fprintf(w, " %13s", "-")
+				continue
+			}
+			fmt.Fprintf(w, " %13s", money(cost))
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, rule)
+	fmt.Fprintf(w, "%-24s", "TOTAL")
+	for _, c := range costs {
+		fmt.Fprintf(w, " %13s", money(c.monthly))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%-24s", "RESOURCES")
+	for _, c := range costs {
+		fmt.Fprintf(w, " %13d", c.resources)
+	}
+	fmt.Fprintln(w)
+
+	for _, c := range costs {
+		for _, warning := range c.warnings {
+			fmt.Fprintf(w, "Warning (%s): %s\n", c.workspace, warning)
+		}
+	}
+}
+
+// writeWorkspaceComparisonJSON writes the comparison as JSON
+func writeWorkspaceComparisonJSON(w io.Writer, costs []*workspaceCost) error {
+	workspaces := make([]map[string]any, len(costs))
+	for i, c := range costs {
+		byType := make(map[string]string, len(c.byType))
+		for resourceType, cost := range c.byType {
+			byType[resourceType] = cost.StringFixed(determinism.DisplayPlaces)
+		}
+		workspaces[i] = map[string]any{
+			"workspace":          c.workspace,
+			"resources":          c.resources,
+			"total_monthly_cost": c.monthly.StringFixed(determinism.DisplayPlaces),
+			"by_resource_type":   byType,
+			"warnings":           c.warnings,
+		}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(map[string]any{"workspaces": workspaces})
+}
+
+// comparedTypes lists the resource types any workspace has a cost for
+func comparedTypes(costs []*workspaceCost) []string {
+	seen := make(map[string]bool)
+	var resourceTypes []string
+	for _, c := range costs {
+		for resourceType := range c.byType {
+			if !seen[resourceType] {
+				seen[resourceType] = true
+				resourceTypes = append(resourceTypes, resourceType)
+			}
+		}
+	}
+	sort.Strings(resourceTypes)
+	return resourceTypes
+}
+
+// runWorkspaceComparison estimates each --compare-workspace workspace and
+// writes the comparison in the selected format
+func runWorkspaceComparison(ctx context.Context, path string, status io.Writer) error {
+	costs, err := compareWorkspaceCosts(ctx, path, compareWorkspaces)
+	if err != nil {
+		return err
+	}
+	out, finish, err := openOutput(outputFile, writeOnError)
+	if err != nil {
+		return err
+	}
+	if outputFormat == "json" {
+		err = writeWorkspaceComparisonJSON(out, costs)
+	} else {
+		printWorkspaceComparison(out, costs)
+	}
+	if err := finish(err); err != nil {
+		return err
+	}
+	if outputFile != "" {
+		fmt.Fprintf(status, "Results written to %s\n", outputFile)
+	}
+	return nil
+}
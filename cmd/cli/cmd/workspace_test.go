@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/output"
+	"terraform-cost/core/types"
+)
+
+// writeWorkspaceProject writes a project whose instance size and count
+// depend on terraform.workspace
+func writeWorkspaceProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	src := `variable "sizes" {
+  default = { default = "t3.micro", prod = "m5.large" }
+}
+
+locals {
+  replicas = terraform.workspace == "prod" ? 2 : 1
+}
+
+resource "aws_instance" "web" {
+  count         = local.replicas
+  ami           = "ami-123"
+  instance_type = var.sizes[terraform.workspace]
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// TestWorkspaceAssets proves --workspace resolves workspace-keyed sizing
+// and counts to concrete instances
+func TestWorkspaceAssets(t *testing.T) {
+	dir := writeWorkspaceProject(t)
+	for _, tc := range []struct {
+		workspace string
+		resolved  string
+		size      string
+		count     int
+	}{
+		{"", "default", "t3.micro", 1},
+		{"prod", "prod", "m5.large", 2},
+	} {
+		assets, resolved, _, err := workspaceAssets(context.Background(), dir, tc.workspace)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resolved != tc.resolved {
+			t.Errorf("workspace %q resolved to %q, want %q", tc.workspace, resolved, tc.resolved)
+		}
+		if len(assets) != tc.count {
+			t.Fatalf("%s: got %d assets, want %d", tc.resolved, len(assets), tc.count)
+		}
+		for _, a := range assets {
+			if a.Type != "aws_instance" || a.Name != "web" || a.Module != "" || a.Provider != types.ProviderAWS {
+				t.Errorf("%s: asset %s = %s %q in %q (%s)", tc.resolved, a.Address, a.Type, a.Name, a.Module, a.Provider)
+			}
+			if got := a.Attributes.GetString("instance_type"); got != tc.size {
+				t.Errorf("%s: %s instance_type = %q, want %s", tc.resolved, a.Address, got, tc.size)
+			}
+		}
+	}
+}
+
+func TestSplitInstanceAddress(t *testing.T) {
+	for _, tc := range []struct {
+		address, resourceType, module, name string
+		isData                              bool
+	}{
+		{"aws_instance.web", "aws_instance", "", "web", false},
+		{"aws_instance.web[0]", "aws_instance", "", "web", false},
+		{`module.app["eu"].aws_instance.web["a"]`, "aws_instance", `module.app["eu"]`, "web", false},
+		{"module.app.data.aws_ami.ubuntu", "aws_ami", "module.app", "ubuntu", true},
+	} {
+		module, name, isData := splitInstanceAddress(tc.address, tc.resourceType)
+		if module != tc.module || name != tc.name || isData != tc.isData {
+			t.Errorf("%s: got (%q, %q, %v), want (%q, %q, %v)", tc.address, module, name, isData, tc.module, tc.name, tc.isData)
+		}
+	}
+}
+
+// TestCompareWorkspaces proves --compare-workspace prices each workspace
+// separately and prints them side by side
+func TestCompareWorkspaces(t *testing.T) {
+	dir := writeWorkspaceProject(t)
+	costs, err := compareWorkspaceCosts(context.Background(), dir, []string{"default", "prod", "default"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(costs) != 2 || costs[0].workspace != "default" || costs[1].workspace != "prod" {
+		t.Fatalf("compared %d workspaces, want default and prod once each", len(costs))
+	}
+
+	hours := profileMonthlyHours()
+	want := map[string]decimal.Decimal{
+		"default": decimal.RequireFromString("0.0104").Mul(hours),
+		"prod":    decimal.RequireFromString("0.096").Mul(hours).Mul(decimal.NewFromInt(2)),
+	}
+	for _, c := range costs {
+		if !c.monthly.Equal(want[c.workspace]) {
+			t.Errorf("%s monthly = %s, want %s", c.workspace, c.monthly, want[c.workspace])
+		}
+		if !c.byType["aws_instance"].Equal(c.monthly) {
+			t.Errorf("%s aws_instance = %s, want the whole %s", c.workspace, c.byType["aws_instance"], c.monthly)
+		}
+	}
+	if costs[1].resources != 2 {
+		t.Errorf("prod resources = %d, want 2", costs[1].resources)
+	}
+
+	var table bytes.Buffer
+	printWorkspaceComparison(&table, costs)
+	for _, line := range []string{"WORKSPACE COMPARISON (monthly)", "default", "prod", "aws_instance", "TOTAL"} {
+		if !strings.Contains(table.String(), line) {
+			t.Errorf("table lacks %q:\n%s", line, table.String())
+		}
+	}
+
+	var out bytes.Buffer
+	if err := writeWorkspaceComparisonJSON(&out, costs); err != nil {
+		t.Fatal(err)
+	}
+	var payload struct {
+		Workspaces []struct {
+			Workspace string `json:"workspace"`
+			Total     string `json:"total_monthly_cost"`
+		} `json:"workspaces"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Workspaces) != 2 || payload.Workspaces[1].Workspace != "prod" ||
+		payload.Workspaces[1].Total != want["prod"].StringFixed(2) {
+		t.Errorf("JSON comparison = %s", out.String())
+	}
+}
+
+// TestWorkspaceLabel proves an estimate made for a workspace says so
+func TestWorkspaceLabel(t *testing.T) {
+	result := &output.EstimationResult{
+		CostGraph: types.NewCostGraph(types.CurrencyUSD),
+		Metadata:  output.EstimationMetadata{Environment: "prod"},
+	}
+	var out bytes.Buffer
+	printResults(&out, result)
+	if !strings.Contains(out.String(), "Workspace: prod") {
+		t.Errorf("estimate is not labeled with its workspace:\n%s", out.String())
+	}
+}
+
+func TestValidateWorkspaceFlags(t *testing.T) {
+	dir := writeWorkspaceProject(t)
+	defer func() { workspace, compareWorkspaces, outputFormat = "", nil, "cli" }()
+
+	for _, tc := range []struct {
+		name      string
+		workspace string
+		compare   []string
+		path      string
+		format    string
+		err       string
+	}{
+		{"workspace", "prod", nil, dir, "cli", ""},
+		{"comparison", "", []string{"dev", "prod"}, dir, "json", ""},
+		{"both", "prod", []string{"dev"}, dir, "cli", "cannot be combined with --compare-workspace"},
+		{"plan", "prod", nil, filepath.Join(dir, "plan.json"), "cli", "needs a directory of .tf files"},
+		{"ndjson comparison", "", []string{"dev"}, dir, formatNDJSON, "not --format ndjson"},
+	} {
+		workspace, compareWorkspaces, outputFormat = tc.workspace, tc.compare, tc.format
+		err := validateWorkspaceFlags(tc.path)
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("%s: %v", tc.name, err)
+		case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+			t.Errorf("%s: error = %v, want %q", tc.name, err, tc.err)
+		}
+	}
+}
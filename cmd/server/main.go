@@ -5,7 +5,6 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
 	"os/signal"
@@ -13,7 +12,9 @@ import (
 	"time"
 
 	"terraform-cost/api"
+	"terraform-cost/core/policy"
 	"terraform-cost/db"
+	"terraform-cost/internal/logging"
 )
 
 const version = "1.0.0"
@@ -21,27 +22,41 @@ const version = "1.0.0"
 func main() {
 	addr := flag.String("addr", ":8080", "Server address")
 	uiPath := flag.String("ui", "./ui", "Path to UI files")
+	logFormat := flag.String("log-format", "json", "Log format (json, text)")
+	logLevel := flag.String("log-level", "info", "Minimum log level (debug, info, warn, error)")
+	policyFile := flag.String("policy-file", "", "Enforce the policies in this file (policy.yaml or policy.json) on every estimate")
+	maxSnapshotAge := flag.Duration("max-snapshot-age", 7*24*time.Hour, "Warn at startup about active pricing snapshots older than this (0 disables)")
 	flag.Parse()
 
+	logCfg := logging.DefaultConfig()
+	logCfg.Format = *logFormat
+	logCfg.Level = *logLevel
+	if err := logging.Initialize(logCfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing logging: %v\n", err)
+	}
+	defer logging.Sync()
+
 	// Connect to database
 	store, err := getDBStore()
 	if err != nil {
-		log.Printf("Warning: Database not available: %v", err)
-		log.Printf("API will work in read-only mode without pricing data")
+		logging.Warn("database not available, API will work in read-only mode without pricing data", logging.Err(err))
 	} else {
 		defer store.Close()
-		log.Printf("✓ Connected to pricing database")
+		logging.Info("connected to pricing database")
 		
-		// Check active snapshots
-		ctx := context.Background()
-		if snap, err := store.GetActiveSnapshot(ctx, db.AWS, "us-east-1", "default"); err == nil && snap != nil {
-			count, _ := store.CountRates(ctx, snap.ID)
-			log.Printf("✓ Active AWS us-east-1 snapshot: %d rates", count)
-		}
+		logSnapshotInventory(store, *maxSnapshotAge)
 	}
 
 	// Create API server with database
 	apiServer := api.NewServerWithStore(version, store)
+	if *policyFile != "" {
+		policies, err := policy.LoadPolicyFile(*policyFile)
+		if err != nil {
+			logging.Fatal("invalid policy file", logging.Err(err))
+		}
+		apiServer.SetPolicies(policies)
+		logging.Info("enforcing policy file", logging.String("path", *policyFile))
+	}
 
 	// Create main mux
 	mux := http.NewServeMux()
@@ -77,7 +92,7 @@ func main() {
 		fmt.Printf("   Health: http://localhost%s/health\n", *addr)
 		fmt.Println()
 		if err := server.ListenAndServe(); err != http.ErrServerClosed {
-			log.Fatal(err)
+			logging.Fatal("server failed", logging.Err(err))
 		}
 	}()
 
@@ -86,13 +101,54 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutting down server...")
+	logging.Info("shutting down server")
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown:", err)
+		logging.Fatal("server forced to shutdown", logging.Err(err))
+	}
+	logging.Info("server stopped")
+}
+
+// logSnapshotInventory logs every active snapshot with its rate count and
+// age, warning about stale ones, so pricing gaps show up at startup rather
+// than per request
+func logSnapshotInventory(store db.PricingStore, maxAge time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	entries, err := db.SnapshotInventory(ctx, store, time.Now(), maxAge)
+	if err != nil {
+		logging.Warn("snapshot inventory failed", logging.Err(err))
+		return
+	}
+	if len(entries) == 0 {
+		logging.Warn("no active pricing snapshots; estimates will have no pricing data")
+		return
+	}
+
+	stale := 0
+	for _, e := range entries {
+		fields := []logging.Field{
+			logging.String("cloud", string(e.Cloud)),
+			logging.String("region", e.Region),
+			logging.String("alias", e.Alias),
+			logging.String("snapshot_id", e.SnapshotID.String()),
+			logging.Int("rates", e.Rates),
+			logging.Duration("age", e.Age.Round(time.Minute)),
+		}
+		if e.Stale {
+			stale++
+			logging.Warn("active snapshot is stale; prices may be out of date",
+				append(fields, logging.Duration("max_age", maxAge))...)
+			continue
+		}
+		logging.Info("active snapshot found", fields...)
 	}
-	log.Println("Server stopped")
+	logging.Info("pricing snapshot inventory",
+		logging.Int("active", len(entries)),
+		logging.Int("stale", stale),
+	)
 }
 
 // getDBStore creates database connection from environment
@@ -15,37 +15,38 @@ func RegisterAWS(c *Catalog) {
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_ec2_host", Tier: Tier1Numeric, Behavior: CostDirect, Category: "compute", MapperExists: false})
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_eks_cluster", Tier: Tier1Numeric, Behavior: CostDirect, Category: "containers", MapperExists: true})
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_eks_node_group", Tier: Tier1Numeric, Behavior: CostDirect, Category: "containers", MapperExists: true})
-	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_ecs_service", Tier: Tier1Numeric, Behavior: CostDirect, Category: "containers", MapperExists: false})
-	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_ecs_task_definition", Tier: Tier1Numeric, Behavior: CostDirect, Category: "containers", MapperExists: false})
+	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_ecs_service", Tier: Tier1Numeric, Behavior: CostDirect, Category: "containers", MapperExists: true, Notes: "Fargate and Fargate Spot tasks; EC2 launch type tasks are priced as the cluster's instances"})
+	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_ecs_task_definition", Tier: Tier1Numeric, Behavior: CostDirect, Category: "containers", MapperExists: true, Notes: "Free; its tasks are priced on the services that run them"})
 
 	// Storage
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_ebs_volume", Tier: Tier1Numeric, Behavior: CostDirect, Category: "storage", MapperExists: true})
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_ebs_snapshot", Tier: Tier1Numeric, Behavior: CostDirect, Category: "storage", MapperExists: false})
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_s3_bucket", Tier: Tier1Numeric, Behavior: CostUsageBased, Category: "storage", RequiresUsage: true, MapperExists: true})
+	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_s3_bucket_lifecycle_configuration", Tier: Tier3Indirect, Behavior: CostIndirect, Category: "storage", Notes: "No direct cost; its transitions shift its bucket's storage-class mix"})
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_efs_file_system", Tier: Tier1Numeric, Behavior: CostUsageBased, Category: "storage", RequiresUsage: true, MapperExists: true})
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_fsx_windows_file_system", Tier: Tier1Numeric, Behavior: CostDirect, Category: "storage", MapperExists: true})
-	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_fsx_openzfs_file_system", Tier: Tier1Numeric, Behavior: CostDirect, Category: "storage", MapperExists: false})
+	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_fsx_openzfs_file_system", Tier: Tier1Numeric, Behavior: CostDirect, Category: "storage", MapperExists: true})
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_fsx_lustre_file_system", Tier: Tier1Numeric, Behavior: CostDirect, Category: "storage", MapperExists: true})
 
 	// Database
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_db_instance", Tier: Tier1Numeric, Behavior: CostDirect, Category: "database", MapperExists: true})
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_rds_cluster", Tier: Tier1Numeric, Behavior: CostDirect, Category: "database", MapperExists: true})
-	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_rds_cluster_instance", Tier: Tier1Numeric, Behavior: CostDirect, Category: "database", MapperExists: false})
+	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_rds_cluster_instance", Tier: Tier1Numeric, Behavior: CostDirect, Category: "database", MapperExists: true, Notes: "Carries Aurora provisioned instance cost"})
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_dynamodb_table", Tier: Tier1Numeric, Behavior: CostDirect, Category: "database", MapperExists: true})
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_elasticache_cluster", Tier: Tier1Numeric, Behavior: CostDirect, Category: "database", MapperExists: true})
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_elasticache_replication_group", Tier: Tier1Numeric, Behavior: CostDirect, Category: "database", MapperExists: true})
-	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_redshift_cluster", Tier: Tier1Numeric, Behavior: CostDirect, Category: "analytics", MapperExists: true})
+	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_redshift_cluster", Tier: Tier1Numeric, Behavior: CostDirect, Category: "analytics", MapperExists: true, Notes: "Node hours times number_of_nodes; RA3 managed storage from managed_storage_gb in the usage file"})
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_opensearch_domain", Tier: Tier1Numeric, Behavior: CostDirect, Category: "analytics", MapperExists: true})
 
 	// Networking
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_nat_gateway", Tier: Tier1Numeric, Behavior: CostDirect, Category: "networking", MapperExists: true})
-	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_eip", Tier: Tier1Numeric, Behavior: CostDirect, Category: "networking", MapperExists: false})
+	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_eip", Tier: Tier1Numeric, Behavior: CostDirect, Category: "networking", MapperExists: true})
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_vpc_endpoint", Tier: Tier1Numeric, Behavior: CostDirect, Category: "networking", MapperExists: false})
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_dx_connection", Tier: Tier1Numeric, Behavior: CostDirect, Category: "networking", MapperExists: false})
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_vpn_connection", Tier: Tier1Numeric, Behavior: CostDirect, Category: "networking", MapperExists: false})
 
 	// Load Balancing
-	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_lb", Tier: Tier1Numeric, Behavior: CostDirect, Category: "networking", MapperExists: true})
+	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_lb", Tier: Tier1Numeric, Behavior: CostDirect, Category: "networking", MapperExists: true, Notes: "Hourly fee plus LCU/NLCU/GLCU hours from the usage file"})
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_elb", Tier: Tier1Numeric, Behavior: CostDirect, Category: "networking", MapperExists: false})
 
 	// Serverless
@@ -53,25 +54,29 @@ func RegisterAWS(c *Catalog) {
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_lambda_provisioned_concurrency_config", Tier: Tier1Numeric, Behavior: CostDirect, Category: "serverless", MapperExists: false})
 
 	// Monitoring
-	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_cloudwatch_log_group", Tier: Tier1Numeric, Behavior: CostUsageBased, Category: "monitoring", RequiresUsage: true, MapperExists: true})
+	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_cloudwatch_log_group", Tier: Tier1Numeric, Behavior: CostUsageBased, Category: "monitoring", RequiresUsage: true, MapperExists: true, Notes: "Ingestion from monthly_ingestion_gb in the usage file; storage from storage_gb or retention_in_days of ingestion"})
+	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_cloudwatch_metric_alarm", Tier: Tier1Numeric, Behavior: CostDirect, Category: "monitoring", MapperExists: true, Notes: "Per alarm metric; metric math counts each metric, anomaly detection three"})
+	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_cloudwatch_dashboard", Tier: Tier1Numeric, Behavior: CostDirect, Category: "monitoring", MapperExists: true})
+	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_cloudwatch_log_metric_filter", Tier: Tier1Numeric, Behavior: CostDirect, Category: "monitoring", MapperExists: true, Notes: "One custom metric, or custom_metrics from the usage file when it has dimensions"})
 
 	// Data Transfer / CDN
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_global_accelerator", Tier: Tier1Numeric, Behavior: CostDirect, Category: "networking", MapperExists: false})
-	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_cloudfront_distribution", Tier: Tier1Numeric, Behavior: CostUsageBased, Category: "cdn", RequiresUsage: true, MapperExists: false})
+	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_cloudfront_distribution", Tier: Tier1Numeric, Behavior: CostUsageBased, Category: "cdn", RequiresUsage: true, MapperExists: true})
 
 	// ============================================
 	// TIER 2 - SYMBOLIC/USAGE-DEPENDENT
 	// ============================================
 
-	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_api_gateway_rest_api", Tier: Tier2Symbolic, Behavior: CostUsageBased, Category: "apigateway", RequiresUsage: true, MapperExists: true})
-	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_api_gateway_stage", Tier: Tier2Symbolic, Behavior: CostUsageBased, Category: "apigateway", RequiresUsage: true, MapperExists: false})
-	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_apigatewayv2_api", Tier: Tier2Symbolic, Behavior: CostUsageBased, Category: "apigateway", RequiresUsage: true, MapperExists: true})
+	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_api_gateway_rest_api", Tier: Tier2Symbolic, Behavior: CostUsageBased, Category: "apigateway", RequiresUsage: true, MapperExists: true, Notes: "Requests per million in volume tiers; stages with caching enabled add an hourly cache"})
+	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_api_gateway_stage", Tier: Tier2Symbolic, Behavior: CostUsageBased, Category: "apigateway", RequiresUsage: true, MapperExists: false, Notes: "Its cache is priced with its REST API"})
+	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_apigatewayv2_api", Tier: Tier2Symbolic, Behavior: CostUsageBased, Category: "apigateway", RequiresUsage: true, MapperExists: true, Notes: "HTTP API requests per million in volume tiers, each 512 KB metered as one; WebSocket messages per million"})
+	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_eks_fargate_profile", Tier: Tier2Symbolic, Behavior: CostUsageBased, Category: "containers", RequiresUsage: true, MapperExists: true})
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_sfn_state_machine", Tier: Tier2Symbolic, Behavior: CostUsageBased, Category: "serverless", RequiresUsage: true, MapperExists: false})
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_kinesis_stream", Tier: Tier2Symbolic, Behavior: CostDirect, Category: "streaming", MapperExists: true})
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_kinesis_firehose_delivery_stream", Tier: Tier2Symbolic, Behavior: CostUsageBased, Category: "streaming", RequiresUsage: true, MapperExists: false})
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_msk_cluster", Tier: Tier2Symbolic, Behavior: CostDirect, Category: "streaming", MapperExists: false})
-	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_sqs_queue", Tier: Tier2Symbolic, Behavior: CostUsageBased, Category: "messaging", RequiresUsage: true, MapperExists: true})
-	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_sns_topic", Tier: Tier2Symbolic, Behavior: CostUsageBased, Category: "messaging", RequiresUsage: true, MapperExists: true})
+	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_sqs_queue", Tier: Tier2Symbolic, Behavior: CostUsageBased, Category: "messaging", RequiresUsage: true, MapperExists: true, Notes: "Requests per million in volume tiers, Standard or FIFO; each 64 KB of payload is one request"})
+	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_sns_topic", Tier: Tier2Symbolic, Behavior: CostUsageBased, Category: "messaging", RequiresUsage: true, MapperExists: true, Notes: "Publishes per million (FIFO adds payload GB) and HTTP/email deliveries; each 64 KB of payload is one request"})
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_cloudtrail", Tier: Tier2Symbolic, Behavior: CostUsageBased, Category: "monitoring", RequiresUsage: true, MapperExists: false})
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_backup_vault", Tier: Tier2Symbolic, Behavior: CostUsageBased, Category: "backup", RequiresUsage: true, MapperExists: false})
 	c.Register(ResourceEntry{Cloud: AWS, ResourceType: "aws_ecr_repository", Tier: Tier2Symbolic, Behavior: CostUsageBased, Category: "containers", RequiresUsage: true, MapperExists: false})
@@ -3,6 +3,15 @@
 // This is the source of truth for coverage.
 package catalog
 
+import (
+	"sort"
+	"strings"
+)
+
+// Version identifies the catalog revision. Cloud plugins report the version
+// they were built against so a stale plugin is flagged at registration.
+const Version = "1"
+
 // CoverageTier classifies resources by cost behavior
 type CoverageTier int
 
@@ -68,6 +77,25 @@ const (
 	GCP   CloudProvider = "gcp"
 )
 
+// resourceTypePrefixes maps Terraform resource type prefixes to clouds
+var resourceTypePrefixes = map[string]CloudProvider{
+	"aws":     AWS,
+	"azurerm": Azure,
+	"google":  GCP,
+}
+
+// CloudForResourceType returns the cloud a resource type belongs to
+// (aws_instance → aws, azurerm_* → azure, google_* → gcp); ok is false
+// for other providers (random, null, kubernetes)
+func CloudForResourceType(resourceType string) (CloudProvider, bool) {
+	prefix, _, found := strings.Cut(resourceType, "_")
+	if !found {
+		return "", false
+	}
+	cloud, ok := resourceTypePrefixes[prefix]
+	return cloud, ok
+}
+
 // ResourceEntry is a catalog entry for a resource type
 type ResourceEntry struct {
 	Cloud         CloudProvider
@@ -124,6 +152,19 @@ func (c *Catalog) ListByTier(cloud CloudProvider, tier CoverageTier) []string {
 	return result
 }
 
+// ListMapped returns the sorted resource types in a tier that the catalog
+// expects a cost mapper for
+func (c *Catalog) ListMapped(cloud CloudProvider, tier CoverageTier) []string {
+	var result []string
+	for _, entry := range c.entries {
+		if entry.Cloud == cloud && entry.Tier == tier && entry.MapperExists {
+			result = append(result, entry.ResourceType)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
 // Stats returns catalog statistics
 func (c *Catalog) Stats() CatalogStats {
 	stats := CatalogStats{
@@ -0,0 +1,103 @@
+// Package catalog - Coverage classification
+// Classifies resources against the catalog without pricing them, so users
+// can see how much of a plan is costable before wiring CI.
+package catalog
+
+import (
+	"sort"
+)
+
+// CoverageClass describes how a resource will be costed
+type CoverageClass string
+
+const (
+	// ClassNumeric - a numeric mapper produces a priced cost
+	ClassNumeric CoverageClass = "numeric"
+	// ClassSymbolic - costable only with usage data
+	ClassSymbolic CoverageClass = "symbolic"
+	// ClassIndirect - zero-cost graph node that enables other costs
+	ClassIndirect CoverageClass = "indirect"
+	// ClassUnsupported - not in the catalog or no mapper yet
+	ClassUnsupported CoverageClass = "unsupported"
+)
+
+// Classify returns the coverage class for a resource type
+func (c *Catalog) Classify(cloud CloudProvider, resourceType string) CoverageClass {
+	entry, ok := c.Get(cloud, resourceType)
+	if !ok || entry.Behavior == CostUnsupported {
+		return ClassUnsupported
+	}
+
+	switch entry.Tier {
+	case Tier3Indirect:
+		return ClassIndirect
+	case Tier2Symbolic:
+		if !entry.MapperExists {
+			return ClassUnsupported
+		}
+		return ClassSymbolic
+	default:
+		if !entry.MapperExists {
+			return ClassUnsupported
+		}
+		return ClassNumeric
+	}
+}
+
+// CoverageSummary tallies classified resources
+type CoverageSummary struct {
+	Total   int
+	ByClass map[CoverageClass]int
+
+	// unsupported counts resources per unsupported type
+	unsupported map[string]int
+}
+
+// NewCoverageSummary creates an empty summary
+func NewCoverageSummary() *CoverageSummary {
+	return &CoverageSummary{
+		ByClass:     make(map[CoverageClass]int),
+		unsupported: make(map[string]int),
+	}
+}
+
+// Add records one resource
+func (s *CoverageSummary) Add(resourceType string, class CoverageClass) {
+	s.Total++
+	s.ByClass[class]++
+	if class == ClassUnsupported {
+		s.unsupported[resourceType]++
+	}
+}
+
+// Percent returns the share of resources in a class (0-100)
+func (s *CoverageSummary) Percent(class CoverageClass) float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return float64(s.ByClass[class]) / float64(s.Total) * 100
+}
+
+// Coverage returns the share of resources that are not unsupported (0-100).
+// An empty plan is fully covered.
+func (s *CoverageSummary) Coverage() float64 {
+	if s.Total == 0 {
+		return 100
+	}
+	return 100 - s.Percent(ClassUnsupported)
+}
+
+// UnsupportedTypes returns unsupported resource types in sorted order
+func (s *CoverageSummary) UnsupportedTypes() []string {
+	types := make([]string, 0, len(s.unsupported))
+	for t := range s.unsupported {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// UnsupportedCount returns how many resources have the given unsupported type
+func (s *CoverageSummary) UnsupportedCount(resourceType string) int {
+	return s.unsupported[resourceType]
+}
@@ -0,0 +1,51 @@
+package catalog
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	c := NewCatalog()
+	RegisterAWS(c)
+
+	for resourceType, want := range map[string]CoverageClass{
+		"aws_instance":             ClassNumeric,
+		"aws_api_gateway_rest_api": ClassSymbolic,
+		"aws_api_gateway_stage":    ClassUnsupported, // symbolic without a mapper
+		"aws_iam_role":             ClassIndirect,
+		"aws_msk_cluster":          ClassUnsupported, // numeric without a mapper
+		"aws_made_up_thing":        ClassUnsupported,
+	} {
+		if got := c.Classify(AWS, resourceType); got != want {
+			t.Errorf("%s classified %s, want %s", resourceType, got, want)
+		}
+	}
+}
+
+func TestCoverageSummary(t *testing.T) {
+	s := NewCoverageSummary()
+	if s.Coverage() != 100 || s.Percent(ClassNumeric) != 0 {
+		t.Errorf("empty summary coverage = %v, numeric = %v; want 100 and 0", s.Coverage(), s.Percent(ClassNumeric))
+	}
+
+	s.Add("aws_instance", ClassNumeric)
+	s.Add("aws_instance", ClassNumeric)
+	s.Add("aws_iam_role", ClassIndirect)
+	s.Add("aws_iam_policy", ClassIndirect)
+	s.Add("aws_msk_cluster", ClassUnsupported)
+	s.Add("aws_made_up_thing", ClassUnsupported)
+	s.Add("aws_msk_cluster", ClassUnsupported)
+	s.Add("aws_made_up_thing", ClassUnsupported)
+
+	if s.Total != 8 || s.Percent(ClassNumeric) != 25 || s.Percent(ClassIndirect) != 25 || s.Coverage() != 50 {
+		t.Errorf("total = %d, numeric = %v%%, indirect = %v%%, coverage = %v%%; want 8, 25%%, 25%% and 50%%",
+			s.Total, s.Percent(ClassNumeric), s.Percent(ClassIndirect), s.Coverage())
+	}
+	if got := s.UnsupportedTypes(); !reflect.DeepEqual(got, []string{"aws_made_up_thing", "aws_msk_cluster"}) {
+		t.Errorf("unsupported types = %v", got)
+	}
+	if s.UnsupportedCount("aws_msk_cluster") != 2 || s.UnsupportedCount("aws_instance") != 0 {
+		t.Errorf("unsupported counts = %d and %d, want 2 and 0", s.UnsupportedCount("aws_msk_cluster"), s.UnsupportedCount("aws_instance"))
+	}
+}
@@ -0,0 +1,142 @@
+// Package catalog - Coverage gaps across plans
+// Platform teams prioritize mapper work by how often each resource type
+// goes uncosted across all their repositories. A GapAggregator classifies
+// the resources of many plans and ranks the unsupported and symbolic
+// types by occurrence, with the share of all resources each leaves
+// without a numeric cost (its blind spot).
+package catalog
+
+import (
+	"math"
+	"sort"
+)
+
+// TypeGap is a resource type left without a numeric cost
+type TypeGap struct {
+	Cloud        CloudProvider `json:"cloud"`
+	ResourceType string        `json:"resource_type"`
+	Class        CoverageClass `json:"class"`
+	Category     string        `json:"category,omitempty"`
+
+	// InCatalog is false for types the catalog does not know at all
+	InCatalog bool `json:"in_catalog"`
+
+	// Occurrences counts resources of the type across all plans
+	Occurrences int `json:"occurrences"`
+
+	// Plans counts the plans with at least one resource of the type
+	Plans int `json:"plans"`
+
+	// BlindSpotPercent is the type's share of all resources (0-100)
+	BlindSpotPercent float64 `json:"blind_spot_percent"`
+}
+
+// GapReport ranks the coverage gaps of many plans, most frequent first
+type GapReport struct {
+	Plans     int `json:"plans"`
+	Resources int `json:"resources"`
+
+	// SkippedResources counts resources of non-cloud providers (random,
+	// null, kubernetes), which are neither classified nor counted above
+	SkippedResources int `json:"skipped_resources"`
+
+	// BlindSpotPercent is the share of resources without a numeric cost
+	BlindSpotPercent float64 `json:"blind_spot_percent"`
+
+	Gaps []TypeGap `json:"gaps"`
+}
+
+// GapAggregator accumulates the coverage gaps of plans
+type GapAggregator struct {
+	catalog   *Catalog
+	plans     int
+	resources int
+	skipped   int
+	gaps      map[string]*TypeGap
+
+	// lastPlan is the last plan each gap was counted in
+	lastPlan map[string]int
+}
+
+// NewGapAggregator creates an aggregator classifying against c
+func NewGapAggregator(c *Catalog) *GapAggregator {
+	return &GapAggregator{
+		catalog:  c,
+		gaps:     make(map[string]*TypeGap),
+		lastPlan: make(map[string]int),
+	}
+}
+
+// AddPlan records the managed resource types of one plan, one entry per
+// resource
+func (g *GapAggregator) AddPlan(resourceTypes []string) {
+	g.plans++
+	for _, resourceType := range resourceTypes {
+		cloud, ok := CloudForResourceType(resourceType)
+		if !ok {
+			g.skipped++
+			continue
+		}
+		g.resources++
+
+		class := g.catalog.Classify(cloud, resourceType)
+		if class != ClassUnsupported && class != ClassSymbolic {
+			continue
+		}
+		gap, ok := g.gaps[resourceType]
+		if !ok {
+			gap = &TypeGap{Cloud: cloud, ResourceType: resourceType, Class: class}
+			if entry, found := g.catalog.Get(cloud, resourceType); found {
+				gap.InCatalog = true
+				gap.Category = entry.Category
+			}
+			g.gaps[resourceType] = gap
+		}
+		gap.Occurrences++
+		if g.lastPlan[resourceType] != g.plans {
+			g.lastPlan[resourceType] = g.plans
+			gap.Plans++
+		}
+	}
+}
+
+// Report ranks the gaps by occurrences, then by the number of plans they
+// appear in; unsupported types come before symbolic ones on a tie
+func (g *GapAggregator) Report() *GapReport {
+	report := &GapReport{
+		Plans:            g.plans,
+		Resources:        g.resources,
+		SkippedResources: g.skipped,
+		Gaps:             make([]TypeGap, 0, len(g.gaps)),
+	}
+	uncosted := 0
+	for _, gap := range g.gaps {
+		ranked := *gap
+		ranked.BlindSpotPercent = percentOf(gap.Occurrences, g.resources)
+		report.Gaps = append(report.Gaps, ranked)
+		uncosted += gap.Occurrences
+	}
+	report.BlindSpotPercent = percentOf(uncosted, g.resources)
+
+	sort.Slice(report.Gaps, func(i, j int) bool {
+		a, b := report.Gaps[i], report.Gaps[j]
+		switch {
+		case a.Occurrences != b.Occurrences:
+			return a.Occurrences > b.Occurrences
+		case a.Plans != b.Plans:
+			return a.Plans > b.Plans
+		case a.Class != b.Class:
+			return a.Class == ClassUnsupported
+		}
+		return a.ResourceType < b.ResourceType
+	})
+	return report
+}
+
+// percentOf is n as a share of total (0-100), to two decimals
+func percentOf(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(n)/float64(total)*10000) / 100
+}
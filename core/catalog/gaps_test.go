@@ -0,0 +1,37 @@
+package catalog
+
+import "testing"
+
+// TestGapReportRanking proves gaps are ranked by occurrences across plans,
+// counted once per plan for Plans, and non-cloud resources are skipped
+func TestGapReportRanking(t *testing.T) {
+	c := NewCatalog()
+	RegisterAWS(c)
+
+	g := NewGapAggregator(c)
+	g.AddPlan([]string{"aws_instance", "aws_msk_cluster", "aws_msk_cluster", "aws_made_up_thing", "random_id"})
+	g.AddPlan([]string{"aws_instance", "aws_made_up_thing", "aws_vpc"})
+	g.AddPlan([]string{"aws_msk_cluster"})
+	report := g.Report()
+
+	if report.Plans != 3 || report.Resources != 8 || report.SkippedResources != 1 {
+		t.Fatalf("report counts = %d plans, %d resources, %d skipped", report.Plans, report.Resources, report.SkippedResources)
+	}
+	if len(report.Gaps) != 2 {
+		t.Fatalf("gaps = %+v, want aws_msk_cluster and aws_made_up_thing", report.Gaps)
+	}
+
+	msk, unknown := report.Gaps[0], report.Gaps[1]
+	if msk.ResourceType != "aws_msk_cluster" || msk.Occurrences != 3 || msk.Plans != 2 || msk.BlindSpotPercent != 37.5 {
+		t.Errorf("first gap = %+v, want aws_msk_cluster 3 times in 2 plans (37.5%%)", msk)
+	}
+	if !msk.InCatalog || msk.Category != "streaming" || msk.Class != ClassUnsupported {
+		t.Errorf("aws_msk_cluster should be a catalogued streaming type without a mapper: %+v", msk)
+	}
+	if unknown.ResourceType != "aws_made_up_thing" || unknown.InCatalog || unknown.Occurrences != 2 {
+		t.Errorf("second gap = %+v", unknown)
+	}
+	if report.BlindSpotPercent != 62.5 {
+		t.Errorf("blind spot = %v, want 5 of 8 resources", report.BlindSpotPercent)
+	}
+}
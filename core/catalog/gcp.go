@@ -22,7 +22,7 @@ func RegisterGCP(c *Catalog) {
 	c.Register(ResourceEntry{Cloud: GCP, ResourceType: "google_storage_bucket", Tier: Tier1Numeric, Behavior: CostUsageBased, Category: "storage", RequiresUsage: true, MapperExists: false})
 
 	// Database
-	c.Register(ResourceEntry{Cloud: GCP, ResourceType: "google_sql_database_instance", Tier: Tier1Numeric, Behavior: CostDirect, Category: "database", MapperExists: false})
+	c.Register(ResourceEntry{Cloud: GCP, ResourceType: "google_sql_database_instance", Tier: Tier1Numeric, Behavior: CostDirect, Category: "database", MapperExists: true, Notes: "Custom tiers as vCPU and memory hours, doubled for REGIONAL; backups from backup_storage_gb in the usage file"})
 	c.Register(ResourceEntry{Cloud: GCP, ResourceType: "google_spanner_instance", Tier: Tier1Numeric, Behavior: CostDirect, Category: "database", MapperExists: false})
 	c.Register(ResourceEntry{Cloud: GCP, ResourceType: "google_bigtable_instance", Tier: Tier1Numeric, Behavior: CostDirect, Category: "database", MapperExists: false})
 
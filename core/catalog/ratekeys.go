@@ -0,0 +1,86 @@
+// Package catalog - Rate-key attribute schemas
+// A rate key carries only the attributes its rates vary by. An attribute
+// the rates don't vary by makes every lookup miss; leaving out one they do
+// vary by matches the wrong rate. The schemas here are the single record
+// of which attributes are price-relevant for each cost component, and
+// mappers build their keys from them.
+package catalog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ec2InstanceAttributes are the attributes EC2 instance-hour rates vary by
+var ec2InstanceAttributes = []string{"instanceType", "operatingSystem", "tenancy", "capacityStatus", "architecture"}
+
+// rateKeySchemas lists the price-relevant attributes of each resource
+// type's cost components, keyed by "resource_type/component"
+var rateKeySchemas = map[string][]string{
+	// AWS
+	"aws_instance/compute":                          ec2InstanceAttributes,
+	"aws_autoscaling_group/instances":               ec2InstanceAttributes,
+	"aws_db_instance/instance":                      {"instanceType", "databaseEngine", "deploymentOption"},
+	"aws_rds_cluster_instance/instance":             {"instanceType", "databaseEngine"},
+	"aws_elasticache_cluster/cache_nodes":           {"nodeType", "cacheEngine", "usageType"},
+	"aws_elasticache_replication_group/cache_nodes": {"nodeType", "cacheEngine", "usageType"},
+	"aws_ecs_service/fargate_vcpu":                  {"usageType", "cpuType"},
+	"aws_ecs_service/fargate_memory":                {"usageType"},
+	"aws_ecs_service/fargate_spot_vcpu":             {"usageType"},
+	"aws_ecs_service/fargate_spot_memory":           {"usageType"},
+	"aws_lb/hourly":                                 {"productFamily", "usageType"},
+	"aws_lb/lcu":                                    {"productFamily", "usageType"},
+
+	// Azure
+	"azurerm_linux_virtual_machine/compute": {"vmSize", "os"},
+
+	// GCP
+	"google_compute_instance/compute":         {"machineType"},
+	"google_compute_instance/vcpu":            {"machineFamily", "resource"},
+	"google_compute_instance/memory":          {"machineFamily", "resource"},
+	"google_compute_instance/extended_memory": {"machineFamily", "resource"},
+	"google_sql_database_instance/instance":   {"tier"},
+	"google_sql_database_instance/vcpu":       {"resource"},
+	"google_sql_database_instance/memory":     {"resource"},
+	"google_sql_database_instance/storage":    {"diskType"},
+	"google_sql_database_instance/backups":    {"resource"},
+}
+
+// RateKeyAttributes returns the price-relevant attributes of a resource
+// type's cost component, sorted; ok is false when it has no schema
+func RateKeyAttributes(resourceType, component string) ([]string, bool) {
+	attrs, ok := rateKeySchemas[resourceType+"/"+component]
+	if !ok {
+		return nil, false
+	}
+	sorted := make([]string, len(attrs))
+	copy(sorted, attrs)
+	sort.Strings(sorted)
+	return sorted, true
+}
+
+// BuildRateKeyAttributes selects the schema attributes of a cost component
+// from values. Values outside the schema are dropped; a schema attribute
+// without a value is an error, as the key would match the wrong rate.
+func BuildRateKeyAttributes(resourceType, component string, values map[string]string) (map[string]string, error) {
+	schema, ok := RateKeyAttributes(resourceType, component)
+	if !ok {
+		return nil, fmt.Errorf("no rate-key schema for %s %s", resourceType, component)
+	}
+
+	attrs := make(map[string]string, len(schema))
+	var missing []string
+	for _, name := range schema {
+		v := values[name]
+		if v == "" {
+			missing = append(missing, name)
+			continue
+		}
+		attrs[name] = v
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%s %s rate key is missing %s", resourceType, component, strings.Join(missing, ", "))
+	}
+	return attrs, nil
+}
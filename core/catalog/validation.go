@@ -4,6 +4,7 @@ package catalog
 
 import (
 	"fmt"
+	"sync"
 )
 
 // ValidationRule is a catalog validation rule
@@ -85,3 +86,22 @@ func Init() {
 	RegisterGCP(GlobalCatalog)
 	GlobalCatalog.MustValidate()
 }
+
+var (
+	defaultCatalog     *Catalog
+	defaultCatalogOnce sync.Once
+)
+
+// Default returns a validated catalog of all clouds, built once. Unlike
+// GlobalCatalog it does not depend on Init having been called.
+func Default() *Catalog {
+	defaultCatalogOnce.Do(func() {
+		c := NewCatalog()
+		RegisterAWS(c)
+		RegisterAzure(c)
+		RegisterGCP(c)
+		c.MustValidate()
+		defaultCatalog = c
+	})
+	return defaultCatalog
+}
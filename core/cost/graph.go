@@ -373,7 +373,7 @@ func (g *CostGraph) BuildServiceAggregates() {
 	byService := make(map[string]*CostAggregate)
 
 	for _, node := range g.NodesByID {
-		service := extractService(node.ResourceType)
+		service := ServiceForResourceType(node.ResourceType)
 		agg, ok := byService[service]
 		if !ok {
 			agg = NewCostAggregate(service, LevelService)
@@ -388,7 +388,9 @@ func (g *CostGraph) BuildServiceAggregates() {
 	}
 }
 
-func extractService(resourceType string) string {
+// ServiceForResourceType returns the short service name a resource type
+// is billed under, or "other"
+func ServiceForResourceType(resourceType string) string {
 	// aws_instance → ec2
 	// aws_s3_bucket → s3
 	// aws_db_instance → rds
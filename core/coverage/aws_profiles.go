@@ -87,6 +87,22 @@ func RegisterAWS(reg *Registry) {
 		Notes:                      "EKS control plane ($0.10/hour)",
 	})
 
+	reg.Register(ResourceCostProfile{
+		ResourceType:               "aws_eks_node_group",
+		Behavior:                   CostDirect,
+		MapperExists:               true,
+		EstimatedSpendContribution: 5.0,
+		Notes:                      "EC2 instance-hours x desired_size plus node EBS",
+	})
+
+	reg.Register(ResourceCostProfile{
+		ResourceType:               "aws_eks_fargate_profile",
+		Behavior:                   CostUsageBased,
+		MapperExists:               true,
+		EstimatedSpendContribution: 1.0,
+		Notes:                      "Profile is free - pods billed per vCPU-hour and GB-hour",
+	})
+
 	reg.Register(ResourceCostProfile{
 		ResourceType:               "aws_ecs_service",
 		Behavior:                   CostIndirect,
@@ -182,7 +198,7 @@ func RegisterAWS(reg *Registry) {
 	reg.Register(ResourceCostProfile{
 		ResourceType:               "aws_eip",
 		Behavior:                   CostDirect,
-		MapperExists:               false,
+		MapperExists:               true,
 		EstimatedSpendContribution: 0.3,
 		Notes:                      "Idle EIPs cost money",
 	})
@@ -0,0 +1,38 @@
+// Package determinism - Clock
+// Every result, lineage and metadata timestamp is read from a Clock, so
+// timestamps are always UTC and serialize as RFC 3339 ("2024-01-01T00:00:00Z")
+// whatever the host's zone. Tests inject a FixedClock to freeze time in
+// golden output. Durations still use time.Now and time.Since: they need
+// the monotonic clock, not a wall-clock stamp.
+package determinism
+
+import "time"
+
+// Clock returns the current time in UTC
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the host clock, converted to UTC
+type SystemClock struct{}
+
+// Now returns the current time in UTC
+func (SystemClock) Now() time.Time {
+	return time.Now().UTC()
+}
+
+// FixedClock always returns the same instant
+type FixedClock time.Time
+
+// Now returns the fixed instant in UTC
+func (c FixedClock) Now() time.Time {
+	return time.Time(c).UTC()
+}
+
+// DefaultClock is the clock used when none is injected
+var DefaultClock Clock = SystemClock{}
+
+// Timestamp formats t as UTC RFC 3339, for timestamps rendered as text
+func Timestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
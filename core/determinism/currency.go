@@ -0,0 +1,106 @@
+// Package determinism - Currency display
+// Human-readable outputs (CLI tables, CI comments, policy messages) show
+// money with the currency's symbol, e.g. "€12.34" or "¥1235". Machine
+// formats keep the raw decimal string and an explicit currency code.
+package determinism
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultCurrency is assumed when a cost carries no currency code
+const DefaultCurrency = "USD"
+
+// currencyFormat is how one currency is written
+type currencyFormat struct {
+	symbol string
+
+	// minorUnits is the number of decimals the currency is quoted in
+	minorUnits int
+}
+
+// currencyFormats maps ISO 4217 codes to their display format
+var currencyFormats = map[string]currencyFormat{
+	"USD": {symbol: "$", minorUnits: 2},
+	"EUR": {symbol: "€", minorUnits: 2},
+	"GBP": {symbol: "£", minorUnits: 2},
+	"JPY": {symbol: "¥", minorUnits: 0},
+	"KRW": {symbol: "₩", minorUnits: 0},
+	"INR": {symbol: "₹", minorUnits: 2},
+	"CNY": {symbol: "CN¥", minorUnits: 2},
+	"AUD": {symbol: "A$", minorUnits: 2},
+	"CAD": {symbol: "CA$", minorUnits: 2},
+	"BRL": {symbol: "R$", minorUnits: 2},
+	"CHF": {symbol: "CHF ", minorUnits: 2},
+}
+
+// normalizeCurrency upper-cases a code, defaulting to DefaultCurrency
+func normalizeCurrency(currency string) string {
+	if currency == "" {
+		return DefaultCurrency
+	}
+	return strings.ToUpper(currency)
+}
+
+// CurrencySymbol returns the symbol of a currency and whether it is known.
+// An unknown currency has no symbol and is written with its code.
+func CurrencySymbol(currency string) (string, bool) {
+	f, ok := currencyFormats[normalizeCurrency(currency)]
+	return f.symbol, ok
+}
+
+// CurrencyPlaces adapts a precision given for a two-decimal currency such
+// as USD to currency: a currency quoted without cents (JPY) drops two
+// places, so monthly costs show none and hourly costs show two. Unknown
+// currencies keep places.
+func CurrencyPlaces(currency string, places int) int {
+	f, ok := currencyFormats[normalizeCurrency(currency)]
+	if !ok {
+		return places
+	}
+	places -= 2 - f.minorUnits
+	if places < 0 {
+		return 0
+	}
+	return places
+}
+
+// FormatAmount writes an amount for people, e.g. "$12.34", "-€0.50" or
+// "12.34 XYZ" for a currency without a known symbol. places is the
+// precision for a two-decimal currency (DisplayPlaces or
+// HourlyDisplayPlaces) and is adapted with CurrencyPlaces.
+func FormatAmount(amount decimal.Decimal, currency string, places int) string {
+	currency = normalizeCurrency(currency)
+	digits := amount.Abs().StringFixed(int32(CurrencyPlaces(currency, places)))
+	sign := ""
+	if amount.IsNegative() && strings.Trim(digits, "0.") != "" {
+		sign = "-"
+	}
+	if symbol, ok := CurrencySymbol(currency); ok {
+		return sign + symbol + digits
+	}
+	return sign + digits + " " + currency
+}
+
+// FormatSignedAmount is FormatAmount with an explicit sign, for deltas:
+// "+$5.00", "-$5.00" or "$0.00"
+func FormatSignedAmount(amount decimal.Decimal, currency string, places int) string {
+	formatted := FormatAmount(amount, currency, places)
+	if amount.IsPositive() && FormatAmount(amount.Neg(), currency, places) != formatted {
+		return "+" + formatted
+	}
+	return formatted
+}
+
+// FormatFloat is FormatAmount for costs already converted to float64 for
+// display, such as the CI result's
+func FormatFloat(amount float64, currency string, places int) string {
+	return FormatAmount(decimal.NewFromFloat(amount), currency, places)
+}
+
+// Format writes the money for people, e.g. "€12.34" (see FormatAmount)
+func (m Money) Format(places int) string {
+	return FormatAmount(m.amount, m.currency, places)
+}
@@ -0,0 +1,73 @@
+package determinism
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// TestFormatAmount proves amounts are written with the currency's symbol
+// and decimals, and unknown currencies fall back to their code
+func TestFormatAmount(t *testing.T) {
+	amount := decimal.RequireFromString("1234.567")
+	for _, tc := range []struct {
+		currency string
+		places   int
+		want     string
+	}{
+		{"USD", DisplayPlaces, "$1234.57"},
+		{"", DisplayPlaces, "$1234.57"},
+		{"EUR", DisplayPlaces, "€1234.57"},
+		{"eur", HourlyDisplayPlaces, "€1234.5670"},
+		{"JPY", DisplayPlaces, "¥1235"},
+		{"JPY", HourlyDisplayPlaces, "¥1234.57"},
+		{"XYZ", DisplayPlaces, "1234.57 XYZ"},
+	} {
+		if got := FormatAmount(amount, tc.currency, tc.places); got != tc.want {
+			t.Errorf("FormatAmount(%s, %d) = %q, want %q", tc.currency, tc.places, got, tc.want)
+		}
+	}
+
+	if got := FormatAmount(decimal.RequireFromString("-0.001"), "USD", DisplayPlaces); got != "$0.00" {
+		t.Errorf("a negative amount rounding to zero = %q, want no sign", got)
+	}
+	if got := FormatSignedAmount(decimal.NewFromInt(5), "GBP", DisplayPlaces); got != "+£5.00" {
+		t.Errorf("signed increase = %q", got)
+	}
+	if got := FormatSignedAmount(decimal.NewFromInt(-5), "GBP", DisplayPlaces); got != "-£5.00" {
+		t.Errorf("signed decrease = %q", got)
+	}
+	if got := NewMoneyFromFloat(1234.5, "JPY").Display(DisplayPlaces); got != "1235 JPY" {
+		t.Errorf("machine display of yen = %q, want no decimals", got)
+	}
+}
+
+// TestRoundCurrencyPlaces proves rounding uses the currency's decimals, so
+// a total adds up to the line items as displayed
+func TestRoundCurrencyPlaces(t *testing.T) {
+	if got := NewMoneyFromFloat(1234.567, "JPY").Round(DisplayPlaces); got.StringRaw() != "1235" {
+		t.Errorf("yen rounded = %s, want 1235", got.StringRaw())
+	}
+	if got := NewMoneyFromFloat(1234.567, "USD").Round(DisplayPlaces); got.StringRaw() != "1234.57" {
+		t.Errorf("dollars rounded = %s, want 1234.57", got.StringRaw())
+	}
+
+	// Three lines of ¥0.40 display as ¥0 each, so their total must too
+	lines := []Money{NewMoneyFromFloat(0.4, "JPY"), NewMoneyFromFloat(0.4, "JPY"), NewMoneyFromFloat(0.4, "JPY")}
+	total := Zero("JPY")
+	for _, l := range lines {
+		total = total.Add(l)
+	}
+	if got := RoundedSum(total, DisplayPlaces, lines); got.Display(DisplayPlaces) != "0 JPY" {
+		t.Errorf("RoundedSum = %s, want 0 JPY", got.Display(DisplayPlaces))
+	}
+
+	// Allocating the rounded ¥1 total hands the yen to one line
+	allocated := AllocateRounded(total.Round(DisplayPlaces), DisplayPlaces, lines)
+	want := []string{"1", "0", "0"}
+	for i, a := range allocated {
+		if a.StringRaw() != want[i] {
+			t.Errorf("allocated[%d] = %s, want %s", i, a.StringRaw(), want[i])
+		}
+	}
+}
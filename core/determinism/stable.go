@@ -5,6 +5,7 @@ package determinism
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"sync"
@@ -99,6 +100,32 @@ func (m *StableMap[K, V]) Len() int {
 	return len(m.values)
 }
 
+// MarshalJSON encodes the map as a JSON object. K must be a string or
+// integer kind, as for a Go map.
+func (m *StableMap[K, V]) MarshalJSON() ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return json.Marshal(m.values)
+}
+
+// UnmarshalJSON decodes a JSON object, replacing the map's contents
+func (m *StableMap[K, V]) UnmarshalJSON(data []byte) error {
+	values := make(map[K]V)
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values = values
+	m.keys = make([]K, 0, len(values))
+	for k := range values {
+		m.keys = append(m.keys, k)
+	}
+	m.sortKeys()
+	return nil
+}
+
 func (m *StableMap[K, V]) sortKeys() {
 	sort.Slice(m.keys, func(i, j int) bool {
 		if m.keyFunc != nil {
@@ -151,6 +178,11 @@ func (h ContentHash) String() string {
 	return h.Hex()[:16] + "..."
 }
 
+// DefaultHoursPerMonth is the billing month used for hourly↔monthly
+// conversion (24 * 365 / 12). Callers that allow overriding it must use the
+// same value for both directions of the conversion.
+const DefaultHoursPerMonth = 730.0
+
 // Money represents a monetary amount with full precision.
 // NEVER use float64 for money calculations.
 type Money struct {
@@ -223,6 +255,11 @@ func (m Money) Div(divisor decimal.Decimal) Money {
 	return Money{amount: m.amount.Div(divisor), currency: m.currency}
 }
 
+// Neg returns the amount with its sign flipped
+func (m Money) Neg() Money {
+	return Money{amount: m.amount.Neg(), currency: m.currency}
+}
+
 // IsZero returns true if amount is zero
 func (m Money) IsZero() bool {
 	return m.amount.IsZero()
@@ -241,9 +278,102 @@ func (m Money) Cmp(other Money) int {
 	return m.amount.Cmp(other.amount)
 }
 
-// String returns formatted money (2 decimal places)
+// Display precision for costs shown to users. Every output formats money
+// through Display with these, so the CLI, CI and HTTP agree.
+const (
+	// DisplayPlaces is used for monthly costs and totals
+	DisplayPlaces = 2
+
+	// HourlyDisplayPlaces is used for hourly costs
+	HourlyDisplayPlaces = 4
+)
+
+// Round returns the amount rounded to places decimal places, half away
+// from zero. places is adapted to the currency with CurrencyPlaces, as in
+// Display, so a rounded amount is the amount displayed.
+func (m Money) Round(places int) Money {
+	return Money{amount: m.amount.Round(int32(CurrencyPlaces(m.currency, places))), currency: m.currency}
+}
+
+// Display returns the amount rounded to places, e.g. "12.34 USD". places
+// is adapted to the currency with CurrencyPlaces, so yen show no decimals.
+func (m Money) Display(places int) string {
+	return fmt.Sprintf("%s %s", m.amount.StringFixed(int32(CurrencyPlaces(m.currency, places))), m.currency)
+}
+
+// RoundedSum rounds each part to places and adds them. A total displayed
+// next to its line items must be computed this way: rounding the exact
+// total instead can differ from the sum of the rounded items by a cent.
+// With no parts, the total itself is rounded.
+func RoundedSum(total Money, places int, parts []Money) Money {
+	if len(parts) == 0 {
+		return total.Round(places)
+	}
+	sum := Zero(parts[0].currency)
+	for _, p := range parts {
+		sum = sum.Add(p.Round(places))
+	}
+	return sum
+}
+
+// AllocateRounded rounds each part to places so the rounded parts add up
+// to total exactly, by the largest remainder method: each part is
+// truncated, then the units of the last place still owed to total go to
+// the parts that lost the most, one each (ties to the earlier part). Every
+// part stays within one unit of its exact value when total is the parts'
+// exact sum rounded. total should already have at most places decimals.
+// places is adapted to the currency with CurrencyPlaces.
+func AllocateRounded(total Money, places int, parts []Money) []Money {
+	if len(parts) == 0 {
+		return nil
+	}
+	places = CurrencyPlaces(total.currency, places)
+	unit := decimal.New(1, int32(-places))
+	rounded := make([]Money, len(parts))
+	remainders := make([]decimal.Decimal, len(parts))
+	owed := total.amount
+	for i, p := range parts {
+		rounded[i] = Money{amount: p.amount.Truncate(int32(places)), currency: p.currency}
+		remainders[i] = p.amount.Sub(rounded[i].amount)
+		owed = owed.Sub(rounded[i].amount)
+	}
+
+	// Parts by remainder, largest first; a negative balance is taken back
+	// from the smallest remainders first
+	order := make([]int, len(parts))
+	for i := range order {
+		order[i] = i
+	}
+	step := unit
+	if owed.IsNegative() {
+		step = unit.Neg()
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		if step.IsNegative() {
+			return remainders[order[a]].LessThan(remainders[order[b]])
+		}
+		return remainders[order[a]].GreaterThan(remainders[order[b]])
+	})
+	units := owed.Div(unit).Abs().Round(0).IntPart()
+	for n := int64(0); n < units; n++ {
+		i := order[n%int64(len(order))]
+		rounded[i].amount = rounded[i].amount.Add(step)
+	}
+	return rounded
+}
+
+// MonthsPerYear annualizes monthly costs
+const MonthsPerYear = 12
+
+// Annualize returns twelve times the monthly cost as displayed, so an
+// annual figure shown beside a monthly one is always exactly 12x it
+func Annualize(monthly Money) Money {
+	return monthly.Round(DisplayPlaces).Mul(decimal.NewFromInt(MonthsPerYear))
+}
+
+// String returns formatted money (DisplayPlaces decimal places)
 func (m Money) String() string {
-	return fmt.Sprintf("%s %s", m.amount.StringFixed(2), m.currency)
+	return m.Display(DisplayPlaces)
 }
 
 // StringRaw returns the raw decimal string (full precision)
@@ -257,6 +387,37 @@ func (m Money) Float64() float64 {
 	return f
 }
 
+// moneyJSON is the JSON form of Money; the amount is a string so no
+// precision is lost
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON encodes money as {"amount": "12.34", "currency": "USD"}
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.amount.String(), Currency: m.currency})
+}
+
+// UnmarshalJSON decodes money encoded by MarshalJSON
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	amount := decimal.Zero
+	if v.Amount != "" {
+		d, err := decimal.NewFromString(v.Amount)
+		if err != nil {
+			return fmt.Errorf("invalid money amount %q: %w", v.Amount, err)
+		}
+		amount = d
+	}
+	m.amount = amount
+	m.currency = v.Currency
+	return nil
+}
+
 // SortSlice sorts a slice in a stable, deterministic manner
 func SortSlice[T any](slice []T, less func(a, b T) bool) {
 	sort.SliceStable(slice, func(i, j int) bool {
@@ -4,6 +4,8 @@ package diff
 
 import (
 	"sort"
+	"strconv"
+	"strings"
 
 	"terraform-cost/core/cost"
 	"terraform-cost/core/determinism"
@@ -24,11 +26,15 @@ type DiffResult struct {
 	Changed    []*InstanceDiff
 	Unchanged  []*InstanceDiff
 
+	// Moved instances matched by attributes across an address change
+	Moved []*InstanceDiff
+
 	// Counts
 	AddedCount    int
 	RemovedCount  int
 	ChangedCount  int
 	UnchangedCount int
+	MovedCount     int
 
 	// Confidence impact
 	ConfidenceBefore float64
@@ -41,6 +47,9 @@ type InstanceDiff struct {
 	Identity *model.InstanceIdentity
 	Address  model.CanonicalAddress
 
+	// PreviousAddress is the base address of a moved instance
+	PreviousAddress model.CanonicalAddress
+
 	// Change type
 	ChangeType ChangeType
 
@@ -64,6 +73,7 @@ const (
 	ChangeRemoved                     // Instance removed
 	ChangeModified                    // Instance cost changed
 	ChangeUnchanged                   // No cost change
+	ChangeMoved                       // Same instance under a new address
 )
 
 // String returns the change type name
@@ -77,6 +87,8 @@ func (c ChangeType) String() string {
 		return "modified"
 	case ChangeUnchanged:
 		return "unchanged"
+	case ChangeMoved:
+		return "moved"
 	default:
 		return "unknown"
 	}
@@ -111,6 +123,15 @@ type ChangeReason struct {
 type Differ struct {
 	// Threshold for "unchanged" (e.g., 0.01 = 1%)
 	ChangeThreshold float64
+
+	// Moves maps a previous address to its new address (from moved blocks),
+	// so a moved instance is compared instead of counted as removed + added
+	Moves map[model.CanonicalAddress]model.CanonicalAddress
+
+	// MatchByAttributes pairs removed and added instances with the same
+	// resource type and cost-relevant attributes and reports them as moved.
+	// Off by default: two genuinely different resources can look alike.
+	MatchByAttributes bool
 }
 
 // NewDiffer creates a new differ
@@ -121,6 +142,22 @@ func NewDiffer(changeThreshold float64) *Differ {
 	return &Differ{ChangeThreshold: changeThreshold}
 }
 
+// WithMoves returns a copy of the differ that compares each moved resource
+// at its new address (Moves)
+func (d *Differ) WithMoves(moves map[model.CanonicalAddress]model.CanonicalAddress) *Differ {
+	cp := *d
+	cp.Moves = moves
+	return &cp
+}
+
+// WithAttributeMatching returns a copy of the differ that matches moved
+// instances by attributes when enabled (MatchByAttributes)
+func (d *Differ) WithAttributeMatching(enabled bool) *Differ {
+	cp := *d
+	cp.MatchByAttributes = enabled
+	return &cp
+}
+
 // Diff computes the diff between before and after
 func (d *Differ) Diff(before, after *cost.AggregatedCostResult) *DiffResult {
 	result := &DiffResult{
@@ -140,10 +177,14 @@ func (d *Differ) Diff(before, after *cost.AggregatedCostResult) *DiffResult {
 		result.DeltaPercent = (after.TotalMonthly.Float64() - before.TotalMonthly.Float64()) / before.TotalMonthly.Float64() * 100
 	}
 
-	// Index before instances
+	// Index before instances under their new address if they were moved
 	beforeMap := make(map[model.CanonicalAddress]*cost.InstanceCostResult)
 	for _, inst := range before.Instances {
-		beforeMap[inst.Identity.Canonical] = inst
+		addr := inst.Identity.Canonical
+		if moved, ok := d.Moves[addr]; ok {
+			addr = moved
+		}
+		beforeMap[addr] = inst
 	}
 
 	// Index after instances
@@ -152,6 +193,19 @@ func (d *Differ) Diff(before, after *cost.AggregatedCostResult) *DiffResult {
 		afterMap[inst.Identity.Canonical] = inst
 	}
 
+	// Pair up instances whose address changed but whose cost did not
+	if d.MatchByAttributes {
+		for _, pair := range matchByAttributes(beforeMap, afterMap) {
+			diff := d.compareInstances(pair.before, pair.after)
+			diff.ChangeType = ChangeMoved
+			diff.PreviousAddress = pair.before.Identity.Canonical
+			result.Moved = append(result.Moved, diff)
+			result.MovedCount++
+			delete(beforeMap, pair.beforeAddr)
+			delete(afterMap, pair.afterAddr)
+		}
+	}
+
 	// Find added, changed, unchanged
 	for addr, afterInst := range afterMap {
 		beforeInst, existed := beforeMap[addr]
@@ -188,10 +242,92 @@ func (d *Differ) Diff(before, after *cost.AggregatedCostResult) *DiffResult {
 	d.sortDiffs(result.Removed)
 	d.sortDiffs(result.Changed)
 	d.sortDiffs(result.Unchanged)
+	d.sortDiffs(result.Moved)
 
 	return result
 }
 
+// movedPair is a removed and an added instance matched by attributes
+type movedPair struct {
+	beforeAddr, afterAddr model.CanonicalAddress
+	before, after         *cost.InstanceCostResult
+}
+
+// matchByAttributes pairs instances present only in before with instances
+// present only in after when their fingerprints are equal. A fingerprint
+// shared by more than one instance on either side is ambiguous and left
+// as added/removed.
+func matchByAttributes(beforeMap, afterMap map[model.CanonicalAddress]*cost.InstanceCostResult) []movedPair {
+	removed := make(map[string][]model.CanonicalAddress)
+	for addr, inst := range beforeMap {
+		if _, ok := afterMap[addr]; !ok {
+			fp := costFingerprint(inst)
+			removed[fp] = append(removed[fp], addr)
+		}
+	}
+	added := make(map[string][]model.CanonicalAddress)
+	for addr, inst := range afterMap {
+		if _, ok := beforeMap[addr]; !ok {
+			fp := costFingerprint(inst)
+			added[fp] = append(added[fp], addr)
+		}
+	}
+
+	var pairs []movedPair
+	for fp, befores := range removed {
+		afters := added[fp]
+		if fp == "" || len(befores) != 1 || len(afters) != 1 {
+			continue
+		}
+		pairs = append(pairs, movedPair{
+			beforeAddr: befores[0],
+			afterAddr:  afters[0],
+			before:     beforeMap[befores[0]],
+			after:      afterMap[afters[0]],
+		})
+	}
+	return pairs
+}
+
+// costFingerprint identifies an instance by resource type and everything
+// its cost depends on: each component's rate key, price and usage.
+// Instances without a resource type or components have no fingerprint.
+func costFingerprint(inst *cost.InstanceCostResult) string {
+	if inst.Identity == nil || len(inst.Components) == 0 {
+		return ""
+	}
+	resourceType := inst.Identity.ResourceType
+	if resourceType == "" {
+		resourceType = addressResourceType(inst.Identity.Canonical)
+	}
+	if resourceType == "" {
+		return ""
+	}
+
+	parts := make([]string, 0, len(inst.Components))
+	for _, c := range inst.Components {
+		part := c.Component
+		if c.Rate != nil {
+			part += "|" + c.Rate.RateKey.String() + "|" + c.Rate.Price + "/" + c.Rate.Unit
+		}
+		if c.Usage != nil {
+			part += "|" + strconv.FormatFloat(c.Usage.Value, 'g', -1, 64) + " " + c.Usage.Unit
+		}
+		parts = append(parts, part)
+	}
+	sort.Strings(parts)
+	return resourceType + "\n" + strings.Join(parts, "\n")
+}
+
+// addressResourceType returns the resource type of a canonical address
+func addressResourceType(addr model.CanonicalAddress) string {
+	base := model.CanonicalAddress(addr.ResourceAddress()).BaseAddress()
+	if idx := strings.Index(base, "."); idx > 0 {
+		return base[:idx]
+	}
+	return ""
+}
+
 func (d *Differ) createInstanceDiff(before, after *cost.InstanceCostResult, changeType ChangeType) *InstanceDiff {
 	diff := &InstanceDiff{
 		ChangeType:     changeType,
@@ -387,6 +523,9 @@ func (r *DiffResult) Summary() string {
 	if r.ChangedCount > 0 {
 		summary += "  ~ " + string(rune('0'+r.ChangedCount)) + " instances changed\n"
 	}
+	if r.MovedCount > 0 {
+		summary += "  > " + strconv.Itoa(r.MovedCount) + " instances moved\n"
+	}
 
 	return summary
 }
@@ -0,0 +1,106 @@
+package diff
+
+import (
+	"testing"
+
+	"terraform-cost/core/cost"
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/model"
+	"terraform-cost/core/pricing"
+)
+
+func instanceWithRate(addr, instanceType string, monthly float64) *cost.InstanceCostResult {
+	price := determinism.NewMoneyFromFloat(monthly, "USD")
+	return &cost.InstanceCostResult{
+		Identity: &model.InstanceIdentity{Canonical: model.CanonicalAddress(addr)},
+		Total:    &cost.ConfidenceBoundCost{Monthly: price},
+		Components: []*cost.CostWithProvenance{{
+			Component: "compute",
+			Cost:      &cost.ConfidenceBoundCost{Monthly: price},
+			Rate: &cost.RateProvenance{
+				RateKey: pricing.RateKey{ResourceType: "aws_instance", Component: "compute", Attributes: "instance_type=" + instanceType},
+				Price:   "0.0832",
+				Unit:    "Hrs",
+			},
+			Usage: &cost.UsageProvenance{Value: 730, Unit: "hours"},
+		}},
+	}
+}
+
+func aggregate(instances ...*cost.InstanceCostResult) *cost.AggregatedCostResult {
+	total := determinism.Zero("USD")
+	for _, inst := range instances {
+		total = total.Add(inst.Total.Monthly)
+	}
+	return &cost.AggregatedCostResult{Instances: instances, TotalMonthly: total}
+}
+
+func TestMatchByAttributes(t *testing.T) {
+	before := aggregate(
+		instanceWithRate("aws_instance.web", "t3.large", 60.74),
+		instanceWithRate("aws_instance.db", "m5.large", 70.08),
+	)
+	after := aggregate(
+		instanceWithRate("module.app:aws_instance.web", "t3.large", 60.74),
+		instanceWithRate("aws_instance.worker", "c5.large", 62.05),
+	)
+
+	if result := NewDiffer(0).Diff(before, after); result.MovedCount != 0 || result.AddedCount != 2 || result.RemovedCount != 2 {
+		t.Fatalf("matching must be opt-in, got moved=%d added=%d removed=%d",
+			result.MovedCount, result.AddedCount, result.RemovedCount)
+	}
+
+	result := NewDiffer(0).WithAttributeMatching(true).Diff(before, after)
+	if result.MovedCount != 1 || result.AddedCount != 1 || result.RemovedCount != 1 {
+		t.Fatalf("expected one move, got moved=%d added=%d removed=%d",
+			result.MovedCount, result.AddedCount, result.RemovedCount)
+	}
+	moved := result.Moved[0]
+	if moved.ChangeType != ChangeMoved || moved.PreviousAddress != "aws_instance.web" || moved.Address != "module.app:aws_instance.web" {
+		t.Errorf("unexpected move %+v", moved)
+	}
+	if !moved.Delta.IsZero() {
+		t.Errorf("moved instance delta = %s, want zero", moved.Delta)
+	}
+}
+
+func TestMatchByAttributesAmbiguous(t *testing.T) {
+	before := aggregate(
+		instanceWithRate("aws_instance.a", "t3.large", 60.74),
+		instanceWithRate("aws_instance.b", "t3.large", 60.74),
+	)
+	after := aggregate(
+		instanceWithRate("module.app:aws_instance.a", "t3.large", 60.74),
+		instanceWithRate("module.app:aws_instance.b", "t3.large", 60.74),
+	)
+
+	result := NewDiffer(0).WithAttributeMatching(true).Diff(before, after)
+	if result.MovedCount != 0 || result.AddedCount != 2 || result.RemovedCount != 2 {
+		t.Errorf("identical candidates must not be matched, got moved=%d added=%d removed=%d",
+			result.MovedCount, result.AddedCount, result.RemovedCount)
+	}
+}
+
+// TestOptionsCopy proves the With* options leave the differ they are called
+// on unchanged, so a shared differ keeps its configuration
+func TestOptionsCopy(t *testing.T) {
+	before := aggregate(instanceWithRate("aws_instance.web", "t3.large", 60.74))
+	after := aggregate(instanceWithRate("aws_instance.app", "t3.large", 60.74))
+
+	base := NewDiffer(0)
+	moves := base.WithMoves(map[model.CanonicalAddress]model.CanonicalAddress{"aws_instance.web": "aws_instance.app"})
+	matching := base.WithAttributeMatching(true)
+	if base.Moves != nil || base.MatchByAttributes {
+		t.Fatalf("options changed the base differ: %+v", base)
+	}
+
+	if result := base.Diff(before, after); result.AddedCount != 1 || result.RemovedCount != 1 {
+		t.Errorf("base: got added=%d removed=%d, want 1 and 1", result.AddedCount, result.RemovedCount)
+	}
+	if result := moves.Diff(before, after); result.UnchangedCount != 1 {
+		t.Errorf("moves: got unchanged=%d", result.UnchangedCount)
+	}
+	if result := matching.Diff(before, after); result.MovedCount != 1 {
+		t.Errorf("matching: got moved=%d, want 1", result.MovedCount)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/shopspring/decimal"
+
 	"terraform-cost/core/determinism"
 	"terraform-cost/core/graph"
 	"terraform-cost/core/guards"
@@ -26,6 +28,10 @@ type EstimationPipeline struct {
 	providerFinal   *terraform.ProviderFinalizer
 	costGraph       *graph.DerivedCostGraph
 	pricingSnapshot *pricing.PricingSnapshot
+
+	// hoursPerMonth converts monthly to hourly cost
+	// (0 = determinism.DefaultHoursPerMonth)
+	hoursPerMonth   float64
 	
 	// Accumulated warnings and errors
 	warnings        []string
@@ -228,7 +234,7 @@ func (p *EstimationPipeline) Step5_ApplyPricing(ctx context.Context, expanded *E
 
 		// Calculate cost
 		monthly := determinism.NewMoneyFromDecimal(rate.Price, rate.Currency)
-		hourly := monthly.Div(determinism.NewMoneyFromFloat(730, "USD").Amount())
+		hourly := monthly.Div(decimal.NewFromFloat(p.HoursPerMonth()))
 
 		if err := p.costGraph.SetNodeCost(inst.DefinitionAddr, monthly, hourly, 1.0); err != nil {
 			p.warnings = append(p.warnings, fmt.Sprintf("could not set cost for %s: %v", inst.Address, err))
@@ -240,6 +246,20 @@ func (p *EstimationPipeline) Step5_ApplyPricing(ctx context.Context, expanded *E
 	return nil
 }
 
+// SetHoursPerMonth sets the hours per billing month, from the estimate
+// configuration
+func (p *EstimationPipeline) SetHoursPerMonth(hours float64) {
+	p.hoursPerMonth = hours
+}
+
+// HoursPerMonth returns the configured hours per billing month
+func (p *EstimationPipeline) HoursPerMonth() float64 {
+	if p.hoursPerMonth > 0 {
+		return p.hoursPerMonth
+	}
+	return determinism.DefaultHoursPerMonth
+}
+
 // SetPricingSnapshot sets the pricing snapshot to use
 func (p *EstimationPipeline) SetPricingSnapshot(snapshot *pricing.PricingSnapshot) {
 	p.pricingSnapshot = snapshot
@@ -0,0 +1,194 @@
+// Package engine - Component cost cache
+// Identical components (e.g. a fleet of the same instance type in one region)
+// price to the same ComponentCost. The cache keys on everything that feeds
+// priceComponent except the instance identity, so repeated components skip
+// rate lookups and formula construction while lineage stays per-instance.
+package engine
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"terraform-cost/core/pricing"
+)
+
+// componentCacheLimit bounds the cache; it is cleared when full
+const componentCacheLimit = 10000
+
+// cachedComponent is a priced component with instance-independent lineage.
+// It shares no maps or slices with what callers hold: entries are copied
+// in and copied out, so adjusting a priced component (e.g. capping a
+// credit) cannot change later hits.
+type cachedComponent struct {
+	cost    ComponentCost
+	lineage pricing.CostLineage
+}
+
+// newCachedComponent copies a priced component into a cache entry
+func newCachedComponent(cost *ComponentCost, lineage *pricing.CostLineage) *cachedComponent {
+	entry := &cachedComponent{cost: *cost, lineage: *copyLineage(lineage)}
+	entry.cost.Formula = copyFormula(cost.Formula)
+	return entry
+}
+
+// copies returns a copy of the entry's component and lineage
+func (c *cachedComponent) copies() (*ComponentCost, *pricing.CostLineage) {
+	cost := c.cost
+	cost.Formula = copyFormula(c.cost.Formula)
+	return &cost, copyLineage(&c.lineage)
+}
+
+// copyLineage copies a lineage and everything it references
+func copyLineage(l *pricing.CostLineage) *pricing.CostLineage {
+	out := *l
+	out.Formula = copyFormula(l.Formula)
+	out.Usage.Assumptions = append([]string(nil), l.Usage.Assumptions...)
+	if l.DerivedFrom != nil {
+		out.DerivedFrom = make([]*pricing.CostLineage, len(l.DerivedFrom))
+		for i, d := range l.DerivedFrom {
+			if d != nil {
+				out.DerivedFrom[i] = copyLineage(d)
+			}
+		}
+	}
+	return &out
+}
+
+// copyFormula copies a formula application's inputs
+func copyFormula(f pricing.FormulaApplication) pricing.FormulaApplication {
+	if f.Inputs != nil {
+		inputs := make(map[string]string, len(f.Inputs))
+		for k, v := range f.Inputs {
+			inputs[k] = v
+		}
+		f.Inputs = inputs
+	}
+	return f
+}
+
+// componentCache memoizes priced components across estimates
+type componentCache struct {
+	mu      sync.RWMutex
+	entries map[string]*cachedComponent
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+func newComponentCache() *componentCache {
+	return &componentCache{entries: make(map[string]*cachedComponent)}
+}
+
+func (c *componentCache) get(key string) (*cachedComponent, bool) {
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if ok {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+	return entry, ok
+}
+
+func (c *componentCache) put(key string, entry *cachedComponent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= componentCacheLimit {
+		c.entries = make(map[string]*cachedComponent)
+	}
+	c.entries[key] = entry
+}
+
+// ComponentCacheStats reports component cache hits and misses
+type ComponentCacheStats struct {
+	Hits    int64
+	Misses  int64
+	Entries int
+}
+
+// ComponentCacheStats returns cache counters (zero when disabled)
+func (e *Engine) ComponentCacheStats() ComponentCacheStats {
+	if e.componentCache == nil {
+		return ComponentCacheStats{}
+	}
+	c := e.componentCache
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return ComponentCacheStats{Hits: c.hits.Load(), Misses: c.misses.Load(), Entries: len(c.entries)}
+}
+
+// componentCacheKey normalizes every input that affects a component's price:
+// resource type, component, region, sorted attributes, snapshot hashes, and
+// the usage that applies to this component.
+func componentCacheKey(
+	comp CostComponent,
+	snapshot *pricing.PricingSnapshot,
+	fallback *pricing.PricingSnapshot,
+	usage *UsageResult,
+	overrides map[string]float64,
+	hoursPerMonth float64,
+) string {
+	var b strings.Builder
+	sep := func() { b.WriteByte(0) }
+
+	b.WriteString(comp.ResourceType)
+	sep()
+	b.WriteString(comp.Name)
+	sep()
+	if comp.Credit {
+		b.WriteString("credit:")
+		b.WriteString(comp.CreditFor)
+	}
+	sep()
+	b.WriteString(snapshot.Region)
+	sep()
+	b.WriteString(snapshot.ContentHash.Hex())
+	sep()
+	if fallback != nil {
+		b.WriteString(fallback.ContentHash.Hex())
+	}
+	sep()
+
+	keys := make([]string, 0, len(comp.Attributes))
+	for k := range comp.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(comp.Attributes[k])
+		b.WriteByte(';')
+	}
+	sep()
+
+	writeFloat := func(f float64) {
+		b.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+		b.WriteByte(';')
+	}
+	writeFloat(hoursPerMonth)
+	writeFloat(usage.UptimeFraction)
+	b.WriteString(strconv.Itoa(int(usage.Source)))
+	b.WriteByte(';')
+	b.WriteString(usage.Profile)
+	sep()
+
+	if metric, ok := usage.Metrics[comp.Name]; ok {
+		writeFloat(metric.Value)
+		writeFloat(metric.Confidence)
+		b.WriteString(metric.Unit)
+		b.WriteString(strconv.FormatBool(metric.IsUnknown))
+	}
+	sep()
+
+	if override, ok := overrides[comp.Name]; ok {
+		writeFloat(override)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,94 @@
+// Package engine - Confidence compounding
+// Per-instance confidence scores are combined into one estimate-level score.
+// Multiplying scores drives confidence toward zero on large graphs even when
+// most resources are certain, so the default weights each instance by cost.
+// An instance without a cost, often one that could not be priced, weighs
+// as much as the average priced instance rather than nothing.
+package engine
+
+import (
+	"math"
+)
+
+// ConfidenceStrategy defines how instance confidence compounds
+type ConfidenceStrategy int
+
+const (
+	// ConfidenceCostWeightedAverage weights each instance by its monthly cost,
+	// so expensive, well-understood resources dominate (default)
+	ConfidenceCostWeightedAverage ConfidenceStrategy = iota
+	// ConfidenceMultiplicative multiplies every instance score
+	ConfidenceMultiplicative
+	// ConfidenceMin takes the lowest instance score
+	ConfidenceMin
+)
+
+// String returns the strategy name
+func (s ConfidenceStrategy) String() string {
+	switch s {
+	case ConfidenceCostWeightedAverage:
+		return "cost_weighted_average"
+	case ConfidenceMultiplicative:
+		return "multiplicative"
+	case ConfidenceMin:
+		return "min"
+	default:
+		return "unknown"
+	}
+}
+
+// confidenceAccumulator combines instance scores as they are priced
+type confidenceAccumulator struct {
+	strategy ConfidenceStrategy
+
+	count       int
+	product     float64
+	min         float64
+	weightedSum float64
+	totalWeight float64
+
+	// unpriced instances, weighted when the score is taken
+	unpricedCount int
+	unpricedSum   float64
+}
+
+func newConfidenceAccumulator(strategy ConfidenceStrategy) *confidenceAccumulator {
+	return &confidenceAccumulator{strategy: strategy, product: 1.0, min: 1.0}
+}
+
+// Add records one instance score with its monthly cost
+func (a *confidenceAccumulator) Add(score, monthlyCost float64) {
+	a.count++
+	a.product *= score
+	a.min = math.Min(a.min, score)
+	if monthlyCost > 0 {
+		a.weightedSum += score * monthlyCost
+		a.totalWeight += monthlyCost
+	} else {
+		a.unpricedCount++
+		a.unpricedSum += score
+	}
+}
+
+// Score returns the compounded score (1.0 when nothing was added)
+func (a *confidenceAccumulator) Score() float64 {
+	if a.count == 0 {
+		return 1.0
+	}
+
+	switch a.strategy {
+	case ConfidenceMultiplicative:
+		return a.product
+	case ConfidenceMin:
+		return a.min
+	default:
+		// With no cost to weight by, every instance counts equally
+		if a.totalWeight == 0 {
+			return a.unpricedSum / float64(a.count)
+		}
+		priced := a.count - a.unpricedCount
+		weight := a.totalWeight / float64(priced)
+		return (a.weightedSum + a.unpricedSum*weight) /
+			(a.totalWeight + float64(a.unpricedCount)*weight)
+	}
+}
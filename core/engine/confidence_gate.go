@@ -0,0 +1,44 @@
+// Package engine - Confidence gate
+// Confidence scoring degrades: a low-confidence component still counts
+// toward every total and only lowers the score. With
+// EngineConfig.DropBelowConfidence set, components under the floor are
+// also listed as UncertainComponents ("needs input") and left out of
+// FirmTotal. They stay in TotalMonthlyCost and
+// EstimatedTotalIncludingSymbolic, and in the confidence score, so the
+// gate partitions the firm total without changing the estimate.
+package engine
+
+import (
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/model"
+)
+
+// UncertainComponent is a component priced below
+// EngineConfig.DropBelowConfidence
+type UncertainComponent struct {
+	InstanceID model.InstanceID
+	Address    model.InstanceAddress
+	Component  *ComponentCost
+}
+
+// uncertainComponents returns the instance's components below floor and
+// their monthly cost; a floor of 0 keeps every component
+func uncertainComponents(cost *InstanceCost, floor float64) ([]UncertainComponent, determinism.Money) {
+	total := determinism.Zero("USD")
+	if floor <= 0 {
+		return nil, total
+	}
+	var uncertain []UncertainComponent
+	for _, comp := range cost.Components {
+		if comp.Confidence >= floor {
+			continue
+		}
+		uncertain = append(uncertain, UncertainComponent{
+			InstanceID: cost.InstanceID,
+			Address:    cost.Address,
+			Component:  comp,
+		})
+		total = total.Add(comp.MonthlyCost)
+	}
+	return uncertain, total
+}
@@ -0,0 +1,111 @@
+// Package engine - Credit line items
+// Free-tier allowances, savings plan coverage and committed-use discounts
+// are credit components: a plugin returns them with Credit set, and they
+// are priced like any other component and then negated. A credit with
+// CreditFor is priced at that component's rate and never deducts more
+// than that component costs, so an allowance larger than the usage nets
+// the component to zero rather than below. An instance's net cost is
+// floored at zero unless AllowNegativeTotals is set.
+package engine
+
+import (
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/pricing"
+)
+
+// creditRateComponent is the component whose rate prices comp: a credit
+// for another component is worth its usage at that component's rate
+func creditRateComponent(comp CostComponent) string {
+	if comp.Credit && comp.CreditFor != "" {
+		return comp.CreditFor
+	}
+	return comp.Name
+}
+
+// applyCredits caps the credits offsetting each component at that
+// component's cost, in component order. A credit for a component the
+// instance does not have, or one that could not be priced, deducts
+// nothing and is symbolic like it.
+func (e *Engine) applyCredits(ic *InstanceCost) {
+	remaining := make(map[string]determinism.Money)
+	symbolic := make(map[string]bool)
+	for _, comp := range ic.Components {
+		if !comp.Credit {
+			if prev, ok := remaining[comp.Name]; ok {
+				remaining[comp.Name] = prev.Add(comp.MonthlyCost)
+			} else {
+				remaining[comp.Name] = comp.MonthlyCost
+			}
+			symbolic[comp.Name] = symbolic[comp.Name] || comp.IsSymbolic
+		}
+	}
+
+	for i, comp := range ic.Components {
+		if !comp.Credit || comp.CreditFor == "" {
+			continue
+		}
+		left, ok := remaining[comp.CreditFor]
+		if !ok || left.IsNegative() {
+			left = determinism.Zero(comp.MonthlyCost.Currency())
+		}
+		if symbolic[comp.CreditFor] {
+			comp.IsSymbolic = true
+		}
+		if comp.MonthlyCost.Neg().Cmp(left) > 0 {
+			comp.MonthlyCost = left.Neg()
+			comp.HourlyCost = comp.MonthlyCost.Div(decimal.NewFromFloat(e.HoursPerMonth()))
+			if i < len(ic.Lineage) {
+				capCreditLineage(ic.Lineage[i], comp.MonthlyCost)
+			}
+		}
+		remaining[comp.CreditFor] = left.Add(comp.MonthlyCost)
+	}
+}
+
+// capCreditLineage records a credit's cap in its lineage
+func capCreditLineage(l *pricing.CostLineage, capped determinism.Money) {
+	if l.Formula.Inputs == nil {
+		l.Formula.Inputs = make(map[string]string, 1)
+	}
+	l.Formula.Inputs["uncapped"] = l.Formula.Output
+	l.Formula.Output = capped.StringRaw()
+}
+
+// rollUp sums the components into the instance's monthly and hourly
+// cost, flooring a net credit at zero unless negative totals are allowed
+func (e *Engine) rollUp(ic *InstanceCost) {
+	monthly, hourly := determinism.Zero("USD"), determinism.Zero("USD")
+	for _, comp := range ic.Components {
+		monthly = monthly.Add(comp.MonthlyCost)
+		hourly = hourly.Add(comp.HourlyCost)
+	}
+	if monthly.IsNegative() && !e.config.AllowNegativeTotals {
+		monthly, hourly = determinism.Zero(monthly.Currency()), determinism.Zero(hourly.Currency())
+	}
+	ic.MonthlyCost, ic.HourlyCost = monthly, hourly
+}
+
+// Credits returns the instance's credit components
+func (ic *InstanceCost) Credits() []*ComponentCost {
+	var credits []*ComponentCost
+	for _, comp := range ic.Components {
+		if comp.Credit {
+			credits = append(credits, comp)
+		}
+	}
+	return credits
+}
+
+// NetComponentCost returns a component's monthly cost less the credits
+// offsetting it
+func (ic *InstanceCost) NetComponentCost(name string) determinism.Money {
+	net := determinism.Zero("USD")
+	for _, comp := range ic.Components {
+		if (!comp.Credit && comp.Name == name) || (comp.Credit && comp.CreditFor == name) {
+			net = net.Add(comp.MonthlyCost)
+		}
+	}
+	return net
+}
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/model"
+	"terraform-cost/core/pricing"
+	"terraform-cost/internal/logging"
+)
+
+// creditPlugin maps every instance to compute, a free-tier credit against
+// it and an account credit priced at its own rate
+type creditPlugin struct{ computePlugin }
+
+func (p *creditPlugin) MapInstance(inst *model.AssetInstance) ([]CostComponent, error) {
+	return []CostComponent{
+		{Name: "compute", ResourceType: "aws_instance", Unit: "hours"},
+		{Name: "free_tier", ResourceType: "aws_instance", Unit: "hours", Credit: true, CreditFor: "compute"},
+		{Name: "account_credit", ResourceType: "aws_instance", Unit: "months", Credit: true},
+	}, nil
+}
+
+// freeHours gives each instance its free-tier hours and account credit
+type freeHours map[model.InstanceID][2]float64
+
+func (f freeHours) Estimate(ctx context.Context, inst *model.AssetInstance) (*UsageResult, error) {
+	usage := f[inst.ID]
+	return &UsageResult{Metrics: map[string]UsageMetric{
+		"free_tier":      {Name: "free_tier", Value: usage[0], Unit: "hours", Confidence: 1.0},
+		"account_credit": {Name: "account_credit", Value: usage[1], Unit: "months", Confidence: 1.0},
+	}, Confidence: 1.0}, nil
+}
+
+// TestCreditLineItems proves a free-tier credit reduces its component's
+// net cost, is capped at that cost, shows as a credit in lineage, and
+// that a net credit floors the instance at zero unless negative totals
+// are allowed
+func TestCreditLineItems(t *testing.T) {
+	snapshot := pricing.NewSnapshotBuilder("aws", "us-east-1").
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(0.1), "hour", "USD").
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "account_credit"}, decimal.NewFromInt(50), "month", "USD").
+		Build()
+	usage := freeHours{
+		"inst-000": {365, 0},  // half the month free
+		"inst-001": {1000, 0}, // more free hours than it runs
+		"inst-002": {0, 1},    // a $50 credit against $73 of compute
+		"inst-003": {0, 2},    // a $100 credit against $73 of compute
+	}
+	estimate := func(allowNegative bool) *EstimationResult {
+		eng := NewEngine(&staticResolver{snapshot: snapshot}, usage, nil, EngineConfig{AllowNegativeTotals: allowNegative})
+		eng.SetLogger(logging.Nop())
+		eng.RegisterPlugin(&creditPlugin{})
+		result, err := eng.Estimate(context.Background(), &EstimateRequest{Graph: newTestGraph(4)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	cost := func(result *EstimationResult, id model.InstanceID) *InstanceCost {
+		ic, ok := result.InstanceCosts.Get(id)
+		if !ok {
+			t.Fatalf("%s not priced", id)
+		}
+		return ic
+	}
+	money := func(amount string) determinism.Money {
+		m, _ := determinism.NewMoney(amount, "USD")
+		return m
+	}
+
+	result := estimate(false)
+
+	half := cost(result, "inst-000")
+	if got := half.NetComponentCost("compute"); got.Cmp(money("36.5")) != 0 {
+		t.Errorf("compute net of 365 free hours = %s, want 36.50", got)
+	}
+	if credits := half.Credits(); len(credits) != 2 || credits[0].MonthlyCost.Cmp(money("-36.5")) != 0 {
+		t.Errorf("credits = %+v, want a -36.50 free tier first", credits)
+	}
+	for _, l := range half.Lineage {
+		if l.Component == "free_tier" && (!l.Credit || l.CreditFor != "compute" || l.Formula.Name != "credit") {
+			t.Errorf("free tier lineage = %+v, want a credit for compute", l)
+		}
+	}
+
+	capped := cost(result, "inst-001")
+	if got := capped.NetComponentCost("compute"); !got.IsZero() {
+		t.Errorf("compute net of 1000 free hours = %s, want 0", got)
+	}
+	for _, l := range capped.Lineage {
+		if l.Component == "free_tier" && (l.Formula.Output != "-73" || l.Formula.Inputs["uncapped"] != "-100") {
+			t.Errorf("capped free tier lineage = %s (inputs %v), want -73 capped from -100", l.Formula.Output, l.Formula.Inputs)
+		}
+	}
+
+	if got := cost(result, "inst-002").MonthlyCost; got.Cmp(money("23")) != 0 {
+		t.Errorf("$73 less a $50 credit = %s", got)
+	}
+	if got := cost(result, "inst-003").MonthlyCost; !got.IsZero() {
+		t.Errorf("a credit larger than the cost nets to %s, want 0", got)
+	}
+	if got := result.TotalMonthlyCost; got.Cmp(money("59.5")) != 0 {
+		t.Errorf("total = %s, want 36.50 + 0 + 23 + 0", got)
+	}
+
+	negative := estimate(true)
+	if got := cost(negative, "inst-003").MonthlyCost; got.Cmp(money("-27")) != 0 {
+		t.Errorf("with negative totals allowed, net = %s, want -27", got)
+	}
+	if got := negative.DisplayTotalMonthlyCost(); got.Cmp(money("32.5")) != 0 {
+		t.Errorf("display total = %s, want 32.50", got)
+	}
+}
@@ -0,0 +1,106 @@
+// Package engine - Deep policy evaluation
+// Adapts policy.DeepEvaluator to the engine's PolicyEvaluator, giving deep
+// policies per-instance cost, confidence, tags and lineage.
+package engine
+
+import (
+	"context"
+
+	"terraform-cost/core/model"
+	"terraform-cost/core/policy"
+)
+
+// DeepPolicyEvaluator evaluates deep policies over an estimation result
+type DeepPolicyEvaluator struct {
+	evaluator *policy.DeepEvaluator
+}
+
+// NewDeepPolicyEvaluator wraps a deep evaluator, e.g. from
+// policy.PolicyFile.Evaluator
+func NewDeepPolicyEvaluator(evaluator *policy.DeepEvaluator) *DeepPolicyEvaluator {
+	return &DeepPolicyEvaluator{evaluator: evaluator}
+}
+
+// Evaluate runs the deep policies. Failing info and warning policies are
+// reported but do not fail the result.
+func (e *DeepPolicyEvaluator) Evaluate(ctx context.Context, result *EstimationResult) (*PolicyResult, error) {
+	deep, err := e.evaluator.Evaluate(ctx, NewPolicyInput(result))
+	if err != nil {
+		return nil, err
+	}
+
+	out := &PolicyResult{Passed: true, Policies: make([]PolicyOutcome, 0, len(deep.Policies))}
+	for _, p := range deep.Policies {
+		out.Policies = append(out.Policies, PolicyOutcome{
+			Name:              p.Name,
+			Passed:            p.Passed,
+			Message:           p.Message,
+			Severity:          string(p.Severity),
+			AffectedInstances: p.AffectedInstances,
+			CostImpact:        p.AffectedCost,
+			LineageRefs:       p.LineageRefs,
+		})
+		if !p.Passed && (p.Severity == policy.SeverityError || p.Severity == policy.SeverityBlock) {
+			out.Passed = false
+		}
+	}
+	return out, nil
+}
+
+// NewPolicyInput builds the deep policy context from an estimation result.
+// A streamed result (no retained InstanceCosts) yields totals only.
+func NewPolicyInput(result *EstimationResult) *policy.PolicyInput {
+	input := &policy.PolicyInput{
+		InstanceCosts:     make(map[model.InstanceID]*policy.InstanceCostDetail),
+		TotalMonthlyCost:  result.TotalMonthlyCost,
+		TotalHourlyCost:   result.TotalHourlyCost,
+		OverallConfidence: result.Confidence.Score,
+	}
+	if report := result.CoverageReport; report != nil {
+		input.Coverage = &policy.CoverageInput{
+			SymbolicPercent:    report.SymbolicPercent,
+			UnsupportedPercent: report.UnsupportedPercent,
+		}
+	}
+	if result.InstanceCosts == nil {
+		return input
+	}
+
+	result.InstanceCosts.Range(func(id model.InstanceID, cost *InstanceCost) bool {
+		detail := &policy.InstanceCostDetail{
+			InstanceID:   id,
+			Address:      cost.Address,
+			DefinitionID: cost.DefinitionID,
+			ResourceType: cost.ResourceType,
+			MonthlyCost:  cost.MonthlyCost,
+			HourlyCost:   cost.HourlyCost,
+			Confidence:   cost.Confidence.Score,
+			Lineage:      cost.Lineage,
+			Tags:         cost.Tags,
+		}
+		for _, f := range cost.Confidence.Factors {
+			detail.Factors = append(detail.Factors, policy.ConfidenceFactor{
+				Reason:    f.Reason,
+				Impact:    f.Impact,
+				Component: f.Component,
+				IsUnknown: f.IsUnknown,
+			})
+		}
+		for _, c := range cost.Components {
+			detail.Components = append(detail.Components, policy.ComponentDetail{
+				Name:        c.Name,
+				MonthlyCost: c.MonthlyCost,
+				HourlyCost:  c.HourlyCost,
+				Rate:        policy.RateInfo{ID: c.RateID, Key: c.RateKey},
+				UsageValue:  c.UsageValue,
+				UsageUnit:   c.UsageUnit,
+				Formula:     c.Formula,
+				Confidence:  c.Confidence,
+			})
+		}
+		input.InstanceCosts[id] = detail
+		input.AllLineage = append(input.AllLineage, cost.Lineage...)
+		return true
+	})
+	return input
+}
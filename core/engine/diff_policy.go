@@ -0,0 +1,129 @@
+// Package engine - Diff policy context
+// Diff-aware policies compare two orchestration results: the estimate of
+// the current infrastructure and of the proposed change. Resources are
+// matched by instance ID, which the orchestrator derives from the address.
+package engine
+
+import (
+	"sort"
+
+	"terraform-cost/core/model"
+	"terraform-cost/core/policy"
+)
+
+// BuildDiffPolicyContext builds the context for diff-aware policies from
+// the results before and after a change. before is nil for new
+// infrastructure, in which case every resource is created.
+func BuildDiffPolicyContext(before, after *OrchestrationResult) *policy.DiffPolicyContext {
+	ctx := &policy.DiffPolicyContext{
+		Before: costSnapshot(before),
+		After:  costSnapshot(after),
+	}
+
+	beforeResources := map[model.InstanceID]float64{}
+	if ctx.Before != nil {
+		beforeResources = ctx.Before.Resources
+	}
+	afterResources := map[model.InstanceID]float64{}
+	if ctx.After != nil {
+		afterResources = ctx.After.Resources
+	}
+	resourceTypes := resourceTypes(before, after)
+
+	ids := make([]model.InstanceID, 0, len(beforeResources)+len(afterResources))
+	for id := range beforeResources {
+		ids = append(ids, id)
+	}
+	for id := range afterResources {
+		if _, ok := beforeResources[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var changed []string
+	for _, id := range ids {
+		was, existed := beforeResources[id]
+		now, exists := afterResources[id]
+
+		delta := policy.ResourceDelta{
+			InstanceID:   id,
+			ResourceType: resourceTypes[id],
+			Before:       was,
+			After:        now,
+			Delta:        now - was,
+		}
+		switch {
+		case !existed:
+			delta.Change = policy.ResourceCreated
+			ctx.Created = append(ctx.Created, id)
+		case !exists:
+			delta.Change = policy.ResourceDestroyed
+			ctx.Destroyed = append(ctx.Destroyed, id)
+		case now != was:
+			delta.Change = policy.ResourceUpdated
+		default:
+			continue
+		}
+		ctx.Deltas = append(ctx.Deltas, delta)
+		if exists {
+			changed = append(changed, string(id))
+		}
+	}
+
+	if ctx.After != nil {
+		ctx.TotalDelta = ctx.After.TotalMonthly
+		ctx.ConfidenceInfo = &policy.DiffConfidenceInfo{AfterConfidence: ctx.After.Confidence}
+	}
+	if ctx.Before != nil {
+		ctx.TotalDelta -= ctx.Before.TotalMonthly
+		if ctx.ConfidenceInfo == nil {
+			ctx.ConfidenceInfo = &policy.DiffConfidenceInfo{}
+		}
+		ctx.ConfidenceInfo.BeforeConfidence = ctx.Before.Confidence
+	}
+
+	// Created and updated resources, and what depends on them
+	if after != nil && after.CostGraph != nil {
+		ctx.Changes = after.CostGraph.CalculateChangeCost(changed)
+	}
+
+	return ctx
+}
+
+// costSnapshot summarizes a result's costs per resource and service
+func costSnapshot(result *OrchestrationResult) *policy.CostSnapshot {
+	if result == nil {
+		return nil
+	}
+
+	snapshot := &policy.CostSnapshot{
+		TotalMonthly: result.TotalMonthly,
+		Resources:    make(map[model.InstanceID]float64),
+		ByService:    make(map[string]float64, len(result.ByService)),
+		Confidence:   result.Confidence,
+	}
+	for _, svc := range result.ByService {
+		snapshot.ByService[svc.Service] = svc.TotalMonthly
+	}
+	if result.CostGraph != nil {
+		for _, node := range result.CostGraph.GetCostNodes() {
+			snapshot.Resources[node.InstanceID] = node.TotalMonthly.Float64()
+		}
+	}
+	return snapshot
+}
+
+// resourceTypes maps each costed resource of either result to its type
+func resourceTypes(results ...*OrchestrationResult) map[model.InstanceID]string {
+	types := make(map[model.InstanceID]string)
+	for _, result := range results {
+		if result == nil || result.CostGraph == nil {
+			continue
+		}
+		for _, node := range result.CostGraph.GetCostNodes() {
+			types[node.InstanceID] = node.ResourceType
+		}
+	}
+	return types
+}
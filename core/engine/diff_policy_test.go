@@ -0,0 +1,94 @@
+package engine
+
+import (
+	"testing"
+
+	"terraform-cost/core/cost"
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/graph"
+	"terraform-cost/core/model"
+	"terraform-cost/core/policy"
+)
+
+// orchestrationResult builds a costed result of aws_instance resources
+// with the given monthly costs, keyed by address
+func orchestrationResult(monthly map[string]float64) *OrchestrationResult {
+	infra := graph.NewInfrastructureGraph()
+	costs := cost.NewCostGraph("project")
+	total := 0.0
+	for address, amount := range monthly {
+		infra.AddNode(&graph.InfraNode{Address: address})
+		node := cost.NewCostNode(model.InstanceID(address), model.InstanceAddress(address), "aws_instance", "aws", "us-east-1")
+		node.TotalMonthly = determinism.NewMoneyFromFloat(amount, "USD")
+		costs.NodesByID[node.InstanceID] = node
+		total += amount
+	}
+
+	return &OrchestrationResult{
+		CostGraph:    graph.NewDependencyAwareCostGraph(infra, costs),
+		TotalMonthly: total,
+		Confidence:   0.9,
+		ByService:    []ServiceCost{{Service: "ec2", TotalMonthly: total}},
+	}
+}
+
+func TestBuildDiffPolicyContext(t *testing.T) {
+	before := orchestrationResult(map[string]float64{
+		"aws_instance.web":    100,
+		"aws_instance.old":    40,
+		"aws_instance.stable": 10,
+	})
+	after := orchestrationResult(map[string]float64{
+		"aws_instance.web":    700, // scaled up
+		"aws_instance.new":    50,
+		"aws_instance.stable": 10,
+	})
+
+	ctx := BuildDiffPolicyContext(before, after)
+
+	if len(ctx.Created) != 1 || ctx.Created[0] != "aws_instance.new" {
+		t.Errorf("Created = %v", ctx.Created)
+	}
+	if len(ctx.Destroyed) != 1 || ctx.Destroyed[0] != "aws_instance.old" {
+		t.Errorf("Destroyed = %v", ctx.Destroyed)
+	}
+	if ctx.TotalDelta != 610 {
+		t.Errorf("TotalDelta = %v, want 610", ctx.TotalDelta)
+	}
+
+	want := map[model.InstanceID]policy.ResourceDelta{
+		"aws_instance.new": {Change: policy.ResourceCreated, Before: 0, After: 50, Delta: 50},
+		"aws_instance.old": {Change: policy.ResourceDestroyed, Before: 40, After: 0, Delta: -40},
+		"aws_instance.web": {Change: policy.ResourceUpdated, Before: 100, After: 700, Delta: 600},
+	}
+	if len(ctx.Deltas) != len(want) {
+		t.Fatalf("Deltas = %+v, want %d entries", ctx.Deltas, len(want))
+	}
+	for _, d := range ctx.Deltas {
+		w := want[d.InstanceID]
+		if d.Change != w.Change || d.Before != w.Before || d.After != w.After || d.Delta != w.Delta {
+			t.Errorf("%s: got %+v, want %+v", d.InstanceID, d, w)
+		}
+		if d.ResourceType != "aws_instance" {
+			t.Errorf("%s: resource type %q", d.InstanceID, d.ResourceType)
+		}
+	}
+
+	// The scaled resource breaks a per-resource limit
+	result := policy.NewResourceDeltaPolicy(500).EvaluateDiff(ctx)
+	if result.Passed || len(result.Violations) != 1 || result.Violations[0].Address != "aws_instance.web" {
+		t.Errorf("resource delta policy: %+v", result)
+	}
+}
+
+// TestBuildDiffPolicyContextNewInfrastructure proves every resource is
+// created when there is no prior result
+func TestBuildDiffPolicyContextNewInfrastructure(t *testing.T) {
+	ctx := BuildDiffPolicyContext(nil, orchestrationResult(map[string]float64{"aws_instance.web": 25}))
+	if ctx.Before != nil {
+		t.Error("Before should be nil for new infrastructure")
+	}
+	if len(ctx.Created) != 1 || ctx.TotalDelta != 25 {
+		t.Errorf("Created = %v, TotalDelta = %v", ctx.Created, ctx.TotalDelta)
+	}
+}
@@ -0,0 +1,89 @@
+// Package engine - Display amounts
+// Outputs show costs rounded to determinism.DisplayPlaces. An instance's
+// displayed cost is the sum of its displayed components and the displayed
+// total the sum of displayed instances, so every column adds up exactly.
+// Every output lists instances in the same order, SortedInstanceCosts.
+package engine
+
+import (
+	"sort"
+
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/model"
+)
+
+// DisplayMonthlyCost is the instance's monthly cost as displayed
+func (c *InstanceCost) DisplayMonthlyCost() determinism.Money {
+	parts := make([]determinism.Money, len(c.Components))
+	for i, comp := range c.Components {
+		parts[i] = comp.MonthlyCost
+	}
+	return determinism.RoundedSum(c.MonthlyCost, determinism.DisplayPlaces, parts)
+}
+
+// DisplayHourlyCost is the instance's hourly cost as displayed
+func (c *InstanceCost) DisplayHourlyCost() determinism.Money {
+	parts := make([]determinism.Money, len(c.Components))
+	for i, comp := range c.Components {
+		parts[i] = comp.HourlyCost
+	}
+	return determinism.RoundedSum(c.HourlyCost, determinism.HourlyDisplayPlaces, parts)
+}
+
+// DisplayTotalMonthlyCost is the total monthly cost as displayed. Results
+// whose instance costs were streamed have only the total to round.
+func (r *EstimationResult) DisplayTotalMonthlyCost() determinism.Money {
+	var parts []determinism.Money
+	if r.InstanceCosts != nil {
+		r.InstanceCosts.Range(func(_ model.InstanceID, c *InstanceCost) bool {
+			parts = append(parts, c.DisplayMonthlyCost())
+			return true
+		})
+	}
+	return determinism.RoundedSum(r.TotalMonthlyCost, determinism.DisplayPlaces, parts)
+}
+
+// AnnualCost is the displayed monthly total annualized
+func (r *EstimationResult) AnnualCost() determinism.Money {
+	return determinism.Annualize(r.DisplayTotalMonthlyCost())
+}
+
+// DisplayTotalHourlyCost is the total hourly cost as displayed
+func (r *EstimationResult) DisplayTotalHourlyCost() determinism.Money {
+	var parts []determinism.Money
+	if r.InstanceCosts != nil {
+		r.InstanceCosts.Range(func(_ model.InstanceID, c *InstanceCost) bool {
+			parts = append(parts, c.DisplayHourlyCost())
+			return true
+		})
+	}
+	return determinism.RoundedSum(r.TotalHourlyCost, determinism.HourlyDisplayPlaces, parts)
+}
+
+// SortedInstanceCosts lists the instance costs as outputs show them: by
+// displayed monthly cost, highest first, then by address
+func (r *EstimationResult) SortedInstanceCosts() []*InstanceCost {
+	if r.InstanceCosts == nil {
+		return nil
+	}
+	type row struct {
+		cost    *InstanceCost
+		monthly determinism.Money
+	}
+	rows := make([]row, 0, r.InstanceCosts.Len())
+	r.InstanceCosts.Range(func(_ model.InstanceID, c *InstanceCost) bool {
+		rows = append(rows, row{cost: c, monthly: c.DisplayMonthlyCost()})
+		return true
+	})
+	sort.SliceStable(rows, func(i, j int) bool {
+		if c := rows[i].monthly.Cmp(rows[j].monthly); c != 0 {
+			return c > 0
+		}
+		return rows[i].cost.Address < rows[j].cost.Address
+	})
+	costs := make([]*InstanceCost, len(rows))
+	for i, r := range rows {
+		costs[i] = r.cost
+	}
+	return costs
+}
@@ -0,0 +1,123 @@
+// Package engine - Display amount tests
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/model"
+	"terraform-cost/core/pricing"
+)
+
+// TestDisplayedTotalMatchesLineItems proves sub-cent remainders do not make
+// the displayed total disagree with the displayed instance costs
+func TestDisplayedTotalMatchesLineItems(t *testing.T) {
+	eng := newTestEngine(&computePlugin{})
+	// 0.0000457/hour is 0.033361/month: each instance shows 0.03, while
+	// the exact total of three rounds to 0.10
+	eng.pricingResolver.(*staticResolver).snapshot = pricing.NewSnapshotBuilder("aws", "us-east-1").
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.RequireFromString("0.0000457"), "hour", "USD").
+		Build()
+
+	result, err := eng.Estimate(context.Background(), &EstimateRequest{Graph: newTestGraph(3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := determinism.Zero("USD")
+	result.InstanceCosts.Range(func(_ model.InstanceID, cost *InstanceCost) bool {
+		sum = sum.Add(cost.DisplayMonthlyCost())
+		return true
+	})
+	total := result.DisplayTotalMonthlyCost()
+	if total.Cmp(sum) != 0 {
+		t.Errorf("displayed total %s != sum of displayed instances %s", total, sum)
+	}
+	if got := total.String(); got != "0.09 USD" {
+		t.Errorf("displayed total = %s, want 0.09 USD", got)
+	}
+	if got := result.TotalMonthlyCost.Round(determinism.DisplayPlaces).String(); got != "0.10 USD" {
+		t.Fatalf("exact total rounds to %s; the test no longer exercises sub-cent rounding", got)
+	}
+	if got := result.DisplayTotalHourlyCost().Display(determinism.HourlyDisplayPlaces); got != "0.0000 USD" {
+		t.Errorf("displayed hourly total = %s, want 0.0000 USD", got)
+	}
+}
+
+func TestAnnualCostUsesRoundedMonthly(t *testing.T) {
+	eng := newTestEngine(&computePlugin{})
+	// 0.0000457/hour is 0.033361/month: the annual total is twelve times
+	// the displayed 0.03, not the exact 0.400332
+	eng.pricingResolver.(*staticResolver).snapshot = pricing.NewSnapshotBuilder("aws", "us-east-1").
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.RequireFromString("0.0000457"), "hour", "USD").
+		Build()
+
+	result, err := eng.Estimate(context.Background(), &EstimateRequest{Graph: newTestGraph(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := result.DisplayTotalMonthlyCost().Mul(decimal.NewFromInt(determinism.MonthsPerYear))
+	if got := result.AnnualCost(); got.Cmp(want) != 0 {
+		t.Errorf("annual = %s, want %s", got, want)
+	}
+	if got := result.AnnualCost().Display(determinism.DisplayPlaces); got != "0.36 USD" {
+		t.Errorf("annual = %s, want 0.36 USD", got)
+	}
+}
+
+// TestRateOverridesPriceAndLineage proves overridden rates are priced and
+// traced to their file while the result still names the stored snapshot
+func TestRateOverridesPriceAndLineage(t *testing.T) {
+	eng := newTestEngine(&computePlugin{})
+	base := pricing.NewSnapshotBuilder("aws", "us-east-1").
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.RequireFromString("0.10"), "hour", "USD").
+		Build()
+	eng.pricingResolver.(*staticResolver).snapshot = base
+
+	overrides := &pricing.RateOverrides{Source: "negotiated.json", Rates: []pricing.RateOverride{
+		{ResourceType: "aws_instance", Component: "compute", Price: decimal.RequireFromString("0.05"), Unit: "hour"},
+	}}
+	result, err := eng.Estimate(context.Background(), &EstimateRequest{Graph: newTestGraph(1), RateOverrides: overrides})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Snapshot.ID != base.ID || result.Snapshot.OverlayHash == (determinism.ContentHash{}) {
+		t.Errorf("snapshot reference = %s (overlay %s), want base %s with an overlay hash",
+			result.Snapshot.ID, result.Snapshot.OverlayHash.Hex(), base.ID)
+	}
+	if got, want := result.TotalMonthlyCost.Round(determinism.DisplayPlaces).String(), "36.50 USD"; got != want {
+		t.Errorf("total = %s, want %s at the overridden rate", got, want)
+	}
+	result.InstanceCosts.Range(func(_ model.InstanceID, cost *InstanceCost) bool {
+		for _, l := range cost.Lineage {
+			if l.Overlay != "negotiated.json" {
+				t.Errorf("%s lineage overlay = %q, want negotiated.json", l.Component, l.Overlay)
+			}
+		}
+		return true
+	})
+}
+
+// TestRateOverridesCurrencyMismatch proves an override in another currency
+// than the snapshot fails the estimate instead of panicking when costs are
+// summed
+func TestRateOverridesCurrencyMismatch(t *testing.T) {
+	eng := newTestEngine(&computePlugin{})
+	eng.pricingResolver.(*staticResolver).snapshot = pricing.NewSnapshotBuilder("aws", "us-east-1").
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.RequireFromString("0.10"), "hour", "USD").
+		Build()
+
+	overrides := &pricing.RateOverrides{Source: "negotiated.json", Rates: []pricing.RateOverride{
+		{ResourceType: "aws_instance", Component: "compute", Price: decimal.RequireFromString("0.05"), Unit: "hour", Currency: "EUR"},
+	}}
+	_, err := eng.Estimate(context.Background(), &EstimateRequest{Graph: newTestGraph(1), RateOverrides: overrides})
+	if err == nil || !strings.Contains(err.Error(), "currency EUR differs") {
+		t.Errorf("err = %v, want the currency mismatch", err)
+	}
+}
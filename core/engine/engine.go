@@ -4,12 +4,19 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/catalog"
 	"terraform-cost/core/determinism"
 	"terraform-cost/core/model"
 	"terraform-cost/core/pricing"
+	"terraform-cost/core/terraform"
+	"terraform-cost/internal/logging"
 )
 
 // Engine is the primary API for cost estimation.
@@ -22,9 +29,21 @@ type Engine struct {
 
 	// Plugin registry
 	cloudPlugins map[string]CloudPlugin
+	pluginCompat map[string]*PluginCompatibility
 
 	// Configuration
 	config EngineConfig
+
+	// Priced components reused across identical instances (nil = disabled)
+	componentCache *componentCache
+
+	// Whole results reused across runs (nil = disabled)
+	resultCache ResultCache
+
+	logger logging.LeveledLogger
+
+	// clock stamps results and lineage (nil = determinism.DefaultClock)
+	clock determinism.Clock
 }
 
 // EngineConfig configures the estimation engine
@@ -38,8 +57,79 @@ type EngineConfig struct {
 
 	// Confidence thresholds
 	MinConfidenceForEstimate float64
+
+	// DropBelowConfidence lists components priced with lower confidence
+	// as UncertainComponents and leaves them out of FirmTotal
+	// (0 = keep all; see confidence_gate.go)
+	DropBelowConfidence float64
+
+	// HoursPerMonth converts between hourly and monthly cost
+	// (0 = determinism.DefaultHoursPerMonth)
+	HoursPerMonth float64
+
+	// AllowNegativeTotals lets credits take an instance's net cost below
+	// zero. Off by default: credits only ever reduce a cost to zero.
+	AllowNegativeTotals bool
+
+	// GroupRounding is how cost groups round their displayed costs
+	// ("" = GroupRoundingLineItems; see grouping.go)
+	GroupRounding GroupRounding
+
+	// AllowRegionFallback prices components missing from the requested
+	// region using FallbackRegion instead of leaving them unpriced.
+	// Off by default: strict users keep unpriced components symbolic.
+	AllowRegionFallback bool
+
+	// FallbackRegion is the reference region (empty = DefaultFallbackRegion)
+	FallbackRegion string
+
+	// ConfidenceStrategy combines instance confidence into the estimate
+	// score (zero value = ConfidenceCostWeightedAverage)
+	ConfidenceStrategy ConfidenceStrategy
+
+	// MaxSnapshotAge flags estimates priced from a snapshot older than this
+	// (0 = never stale)
+	MaxSnapshotAge time.Duration
+
+	// FailOnStaleSnapshot turns a stale snapshot into ErrStaleSnapshot
+	// instead of a warning
+	FailOnStaleSnapshot bool
+
+	// DisableComponentCache prices every component independently
+	DisableComponentCache bool
+
+	// MaxInstances and MaxGraphEdges bound the graphs Estimate accepts
+	// (0 = DefaultMaxInstances / DefaultMaxGraphEdges, negative = no limit)
+	MaxInstances  int
+	MaxGraphEdges int
+
+	// Catalog checks plugin coverage at registration (nil = catalog.Default())
+	Catalog *catalog.Catalog
+
+	// PricingUnavailable is what Estimate does when no snapshot can be
+	// resolved (empty = PricingUnavailableFail)
+	PricingUnavailable PricingUnavailableBehavior
+
+	// FallbackSnapshots bundles the rate sets PricingUnavailableFallback
+	// prices from
+	FallbackSnapshots FallbackSnapshotFunc
 }
 
+// DefaultFallbackRegion is the reference region for region fallback
+const DefaultFallbackRegion = "us-east-1"
+
+// regionFallbackConfidence scales confidence for components priced
+// from the fallback region
+const regionFallbackConfidence = 0.7
+
+// staleSnapshotConfidence scales overall confidence when pricing data is
+// older than MaxSnapshotAge
+const staleSnapshotConfidence = 0.9
+
+// ErrStaleSnapshot is returned when FailOnStaleSnapshot is set and the
+// resolved snapshot is older than MaxSnapshotAge
+var ErrStaleSnapshot = errors.New("pricing snapshot is stale")
+
 // UnknownBehavior defines how to handle unknown values
 type UnknownBehavior int
 
@@ -59,6 +149,9 @@ type PricingResolver interface {
 	LookupRate(snapshot *pricing.PricingSnapshot, resourceType, component string, attrs map[string]string) (*pricing.RateEntry, error)
 }
 
+// DefaultProviderAlias is the alias of the default (un-aliased) account
+const DefaultProviderAlias = pricing.DefaultAlias
+
 // SnapshotRequest specifies which snapshot to retrieve
 type SnapshotRequest struct {
 	// SnapshotID is preferred if known
@@ -68,8 +161,16 @@ type SnapshotRequest struct {
 	Provider string
 	Region   string
 
+	// Alias selects per-account pricing for multi-account setups
+	// (empty = DefaultProviderAlias)
+	Alias string
+
 	// AsOf specifies point-in-time (nil = latest known)
 	AsOf *time.Time
+
+	// OverrideRegion prices every instance from Region's snapshot, ignoring
+	// the regions of their providers (see CompareRegions)
+	OverrideRegion bool
 }
 
 // UsageEstimator estimates usage for instances
@@ -82,8 +183,19 @@ type UsageResult struct {
 	Metrics    map[string]UsageMetric
 	Source     pricing.UsageSource
 	Confidence float64
+
+	// UptimeFraction scales the default monthly hours when a component has
+	// no explicit metric (0 = unset, treated as always on)
+	UptimeFraction float64
+
+	// Profile is the usage profile applied, if any
+	Profile string
 }
 
+// UnitUptimeFraction marks a metric whose value is the fraction of the
+// month a resource runs (0.0 - 1.0) rather than an absolute quantity
+const UnitUptimeFraction = "uptime_fraction"
+
 // UsageMetric is a single usage estimate
 type UsageMetric struct {
 	Name       string
@@ -102,6 +214,12 @@ type PolicyEvaluator interface {
 type CloudPlugin interface {
 	Provider() string
 	MapInstance(instance *model.AssetInstance) ([]CostComponent, error)
+
+	// CatalogVersion is the catalog.Version the plugin was built against
+	CatalogVersion() string
+
+	// SupportedTypes lists the resource types the plugin has mappers for
+	SupportedTypes() []string
 }
 
 // CostComponent is a billable component of an instance
@@ -110,6 +228,14 @@ type CostComponent struct {
 	ResourceType string
 	Unit         string
 	Attributes   map[string]string
+
+	// Credit marks a deduction such as a free-tier allowance or savings
+	// plan coverage; its priced amount is subtracted (see credits.go)
+	Credit bool
+
+	// CreditFor names the component a credit offsets: the credit is
+	// priced at that component's rate and capped at its cost
+	CreditFor string
 }
 
 // NewEngine creates a new estimation engine
@@ -119,18 +245,63 @@ func NewEngine(
 	policyEvaluator PolicyEvaluator,
 	config EngineConfig,
 ) *Engine {
-	return &Engine{
+	eng := &Engine{
 		pricingResolver: pricingResolver,
 		usageEstimator:  usageEstimator,
 		policyEvaluator: policyEvaluator,
 		cloudPlugins:    make(map[string]CloudPlugin),
+		pluginCompat:    make(map[string]*PluginCompatibility),
 		config:          config,
+		logger:          logging.Default(),
+	}
+	if !config.DisableComponentCache {
+		eng.componentCache = newComponentCache()
+	}
+	return eng
+}
+
+// HoursPerMonth returns the configured hours per billing month
+func (e *Engine) HoursPerMonth() float64 {
+	if e.config.HoursPerMonth > 0 {
+		return e.config.HoursPerMonth
+	}
+	return determinism.DefaultHoursPerMonth
+}
+
+// SetClock sets the clock results and lineage are stamped with; tests
+// pass a determinism.FixedClock to freeze EstimatedAt
+func (e *Engine) SetClock(clock determinism.Clock) {
+	e.clock = clock
+}
+
+// now returns the current time in UTC from the engine's clock
+func (e *Engine) now() time.Time {
+	if e.clock == nil {
+		return determinism.DefaultClock.Now()
 	}
+	return e.clock.Now()
 }
 
-// RegisterPlugin registers a cloud plugin
+// fallbackRegion returns the configured reference region
+func (e *Engine) fallbackRegion() string {
+	if e.config.FallbackRegion != "" {
+		return e.config.FallbackRegion
+	}
+	return DefaultFallbackRegion
+}
+
+// RegisterPlugin registers a cloud plugin and checks it against the
+// catalog, logging Tier1 types it has no mapper for
 func (e *Engine) RegisterPlugin(plugin CloudPlugin) {
 	e.cloudPlugins[plugin.Provider()] = plugin
+
+	cat := e.config.Catalog
+	if cat == nil {
+		cat = catalog.Default()
+	}
+	compat := CheckPluginCompatibility(plugin, cat)
+	e.pluginCompat[plugin.Provider()] = compat
+	e.logCompatibility(compat)
 }
 
 // EstimateRequest is the input to estimation
@@ -141,11 +312,61 @@ type EstimateRequest struct {
 	// REQUIRED: Pricing snapshot to use
 	SnapshotRequest SnapshotRequest
 
-	// Optional: Usage overrides per instance
+	// Optional: Usage overrides per instance (take precedence over the profile)
 	UsageOverrides map[model.InstanceID]map[string]float64
 
+	// Optional: Named usage profile (production, development, off-hours)
+	UsageProfile string
+
 	// Optional: Policy configuration
 	PolicyConfig map[string]any
+
+	// Optional: RequestID correlates the estimate with the caller's request
+	// (e.g. the HTTP X-Request-ID); it is copied to the result
+	RequestID string
+
+	// Optional: CacheKey identifies the input (e.g. PlanCacheKey of the plan
+	// JSON). With a result cache set, a result for the same input, snapshot
+	// and usage is reused. Empty disables caching for this request.
+	CacheKey string
+
+	// Optional: OnInstanceCost streams each instance cost as it is priced.
+	// When set, instance costs are handed to the callback instead of being
+	// retained in InstanceCosts, so memory stays flat for very large graphs;
+	// totals, confidence and coverage are still computed over every instance.
+	// Returning an error aborts the estimate.
+	OnInstanceCost func(*InstanceCost) error
+
+	// Optional: OnProgress receives periodic updates while instances are
+	// priced (ProgressPhasePricing). A cached result reports nothing.
+	OnProgress ProgressReporter
+
+	// Optional: CardinalityWarnings from expansion (PipelineResult or
+	// the orchestrator's GetCardinalityWarnings), reported on the result
+	// as SymbolicResources
+	CardinalityWarnings []terraform.CardinalityWarning
+
+	// Optional: SourceWarnings from reading the input (plan format, module
+	// calls that were not expanded), reported first in the result's Warnings
+	SourceWarnings []string
+
+	// Optional: Scope labels a graph covering only part of the
+	// infrastructure (e.g. a targeted plan), copied to the result
+	Scope string
+
+	// Optional: Workspace the graph was expanded for
+	// (PipelineResult.Workspace), copied to the result
+	Workspace string
+
+	// Optional: RateOverrides replace snapshot rates with negotiated
+	// prices in every snapshot the estimate uses (see OverlaySnapshot)
+	RateOverrides *pricing.RateOverrides
+
+	// Optional: IgnorePatterns are address globs (see NewIgnoreMatcher)
+	// for instances left out of the totals, e.g. "aws_instance.test_*" or
+	// "module.sandbox.*". Matching instances are still priced, for
+	// IgnoredMonthlyCost.
+	IgnorePatterns []string
 }
 
 // EstimationResult is the output of estimation
@@ -160,9 +381,45 @@ type EstimationResult struct {
 	TotalMonthlyCost determinism.Money
 	TotalHourlyCost  determinism.Money
 
+	// FirmTotal is the monthly cost of instances with a known count.
+	// Placeholder instances, priced at an assumed count for a resource
+	// whose count or for_each is unknown, are left out.
+	FirmTotal determinism.Money
+
+	// EstimatedTotalIncludingSymbolic is FirmTotal plus the best-effort
+	// monthly cost of placeholder instances and uncertain components
+	EstimatedTotalIncludingSymbolic determinism.Money
+
+	// UncertainComponents are the components priced below
+	// EngineConfig.DropBelowConfidence, which need usage input; their
+	// UncertainMonthlyCost is left out of FirmTotal
+	UncertainComponents  []UncertainComponent
+	UncertainMonthlyCost determinism.Money
+
+	// DestroyedMonthlyCost is what the instances a plan destroys cost
+	// today: the savings on apply. They are left out of every total,
+	// which covers the infrastructure as it will be after the apply.
+	DestroyedMonthlyCost determinism.Money
+	DestroyedCount       int
+
+	// Destroy is set when the plan destroys every instance, as
+	// terraform plan -destroy does: nothing is left to pay for after the
+	// apply, so the totals are zero and the monthly cost delta is
+	// DestroyedMonthlyCost, negated
+	Destroy bool
+
+	// IgnoredMonthlyCost is what the instances matching IgnorePatterns
+	// would cost; like destroyed instances, they are left out of every
+	// total
+	IgnoredMonthlyCost determinism.Money
+	IgnoredCount       int
+
 	// Overall confidence
 	Confidence CostConfidence
 
+	// Coverage breakdown across all instances
+	CoverageReport *CoverageReport
+
 	// Warnings and degradations
 	Warnings []string
 	Degraded bool
@@ -173,6 +430,29 @@ type EstimationResult struct {
 	// Timing
 	EstimatedAt time.Time
 	Duration    time.Duration
+
+	// RequestID from the request, for tracing the estimate end-to-end
+	RequestID string
+
+	// Cached is true when the result was reused from the result cache
+	Cached bool
+
+	// UnmatchedTypes lists instance types with no rate in the snapshot
+	UnmatchedTypes []UnmatchedType
+
+	// SymbolicResources lists resources whose instance count is unknown
+	SymbolicResources []SymbolicResource
+
+	// Scope is EstimateRequest.Scope: non-empty when the totals cover
+	// only part of the infrastructure
+	Scope string
+
+	// Workspace is EstimateRequest.Workspace
+	Workspace string
+
+	// GroupRounding is EngineConfig.GroupRounding, for the result's
+	// cost groups
+	GroupRounding GroupRounding
 }
 
 // SnapshotReference is an immutable reference to the pricing snapshot used
@@ -180,19 +460,74 @@ type SnapshotReference struct {
 	ID          pricing.SnapshotID
 	ContentHash determinism.ContentHash
 	EffectiveAt time.Time
+	CreatedAt   time.Time
 	Provider    string
 	Region      string
+	Alias       string
+
+	// Age is measured from EffectiveAt (CreatedAt when unset)
+	Age time.Duration
+
+	// Stale is true when Age exceeds EngineConfig.MaxSnapshotAge
+	Stale bool
+
+	// OverlayHash is the content hash of the snapshot with the request's
+	// RateOverrides applied; zero without overrides
+	OverlayHash determinism.ContentHash
+}
+
+// CoverageReport summarizes how instances were costed
+type CoverageReport struct {
+	NumericPercent     float64
+	SymbolicPercent    float64
+	IndirectPercent    float64
+	UnsupportedPercent float64
+	TotalInstances     int
+}
+
+// CoverageType classifies how an instance was costed
+type CoverageType int
+
+const (
+	// CoverageTypeNumeric means every component was priced with known usage
+	CoverageTypeNumeric CoverageType = iota
+	// CoverageTypeSymbolic means at least one component depends on unknowns
+	CoverageTypeSymbolic
+	// CoverageTypeIndirect means the instance has no direct billable components
+	CoverageTypeIndirect
+	// CoverageTypeUnsupported means the instance could not be mapped
+	CoverageTypeUnsupported
+)
+
+// String returns the coverage type name
+func (c CoverageType) String() string {
+	switch c {
+	case CoverageTypeNumeric:
+		return "numeric"
+	case CoverageTypeSymbolic:
+		return "symbolic"
+	case CoverageTypeIndirect:
+		return "indirect"
+	case CoverageTypeUnsupported:
+		return "unsupported"
+	default:
+		return "unknown"
+	}
 }
 
 // InstanceCost is the cost for a SINGLE INSTANCE (not definition)
 type InstanceCost struct {
 	// Instance identity
-	InstanceID model.InstanceID
-	Address    model.InstanceAddress
+	InstanceID   model.InstanceID
+	Address      model.InstanceAddress
+	ResourceType string
 
 	// Link to definition (for grouping)
 	DefinitionID model.DefinitionID
 
+	// How this instance was costed
+	CoverageType CoverageType
+
 	// Cost components
 	Components []*ComponentCost
 
@@ -205,6 +540,18 @@ type InstanceCost struct {
 
 	// Full lineage for explainability
 	Lineage []*pricing.CostLineage
+
+	// Tags for cost allocation (tags_all, falling back to tags)
+	Tags map[string]string
+
+	// Symbolic is true for a placeholder instance priced at an assumed
+	// count; its cost is excluded from FirmTotal
+	Symbolic bool
+
+	// SkipReason explains why an unsupported instance was not costed
+	// (e.g. no mapper for its type); it is listed at zero cost so the
+	// inventory stays complete
+	SkipReason string
 }
 
 // ComponentCost is a single cost component
@@ -226,6 +573,17 @@ type ComponentCost struct {
 
 	// Confidence
 	Confidence float64
+
+	// IsSymbolic is true when the rate or usage could not be determined
+	IsSymbolic bool
+
+	// FallbackRegion is set when the rate came from the fallback region
+	FallbackRegion string
+
+	// Credit is set for a deduction; its costs are negative. CreditFor
+	// names the component it offsets, if any.
+	Credit    bool
+	CreditFor string
 }
 
 // CostConfidence tracks estimation confidence
@@ -254,13 +612,18 @@ type PolicyOutcome struct {
 	Passed  bool
 	Message string
 
+	// Severity of a failure (info, warning, error, block)
+	Severity string
+
 	// Deep context for explainability
 	AffectedInstances []model.InstanceID
 	CostImpact        determinism.Money
 	LineageRefs       []*pricing.CostLineage
 }
 
-// Estimate performs the estimation
+// Estimate performs the estimation.
+// If ctx is canceled mid-run, the partial result is returned (marked Degraded)
+// together with an error wrapping ctx.Err().
 func (e *Engine) Estimate(ctx context.Context, req *EstimateRequest) (*EstimationResult, error) {
 	start := time.Now()
 
@@ -268,11 +631,28 @@ func (e *Engine) Estimate(ctx context.Context, req *EstimateRequest) (*Estimatio
 	if req.Graph == nil {
 		return nil, fmt.Errorf("instance graph is required")
 	}
+	graph, ignored := req.Graph, []*model.AssetInstance(nil)
+	if matcher := model.NewIgnoreMatcher(req.IgnorePatterns); matcher != nil {
+		graph, ignored = req.Graph.Without(func(inst *model.AssetInstance) bool {
+			return matcher.Matches(inst.Address)
+		})
+	}
+	if err := e.CheckGraphLimits(graph); err != nil {
+		return nil, err
+	}
 
 	// REQUIRED: Get pricing snapshot
-	snapshot, err := e.pricingResolver.GetSnapshot(ctx, req.SnapshotRequest)
+	snapshotReq := req.SnapshotRequest
+	if snapshotReq.Alias == "" {
+		snapshotReq.Alias = DefaultProviderAlias
+	}
+	snapshot, err := e.pricingResolver.GetSnapshot(ctx, snapshotReq)
+	var unavailable error
 	if err != nil {
-		return nil, fmt.Errorf("failed to get pricing snapshot: %w", err)
+		unavailable = err
+		if snapshot, err = e.pricingUnavailable(ctx, snapshotReq, err); err != nil {
+			return nil, err
+		}
 	}
 
 	// Verify snapshot integrity
@@ -280,39 +660,210 @@ func (e *Engine) Estimate(ctx context.Context, req *EstimateRequest) (*Estimatio
 		return nil, fmt.Errorf("pricing snapshot failed integrity check")
 	}
 
+	// The reference names the stored snapshot; rates come from the overlay
+	primary, err := req.RateOverrides.Apply(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rate overrides: %w", err)
+	}
+	regions := newRegionSnapshots(ctx, e, snapshotReq, primary, req.RateOverrides)
+
+	usageEstimator := e.usageEstimator
+	if req.UsageProfile != "" {
+		profile, ok := LookupUsageProfile(req.UsageProfile)
+		if !ok {
+			return nil, fmt.Errorf("unknown usage profile %q (available: %s)",
+				req.UsageProfile, strings.Join(UsageProfileNames(), ", "))
+		}
+		usageEstimator = NewProfileUsageEstimator(usageEstimator, profile)
+	}
+
 	result := &EstimationResult{
 		Snapshot: &SnapshotReference{
 			ID:          snapshot.ID,
 			ContentHash: snapshot.ContentHash,
 			EffectiveAt: snapshot.EffectiveAt,
+			CreatedAt:   snapshot.CreatedAt,
 			Provider:    snapshot.Provider,
 			Region:      snapshot.Region,
+			Alias:       snapshotReq.Alias,
 		},
 		InstanceCosts:    determinism.NewStableMap[model.InstanceID, *InstanceCost](),
 		TotalMonthlyCost: determinism.Zero("USD"),
 		TotalHourlyCost:  determinism.Zero("USD"),
 		Confidence:       CostConfidence{Score: 1.0},
-		EstimatedAt:      time.Now().UTC(),
+		EstimatedAt:      e.now(),
+
+		FirmTotal:                       determinism.Zero("USD"),
+		EstimatedTotalIncludingSymbolic: determinism.Zero("USD"),
+		DestroyedMonthlyCost:            determinism.Zero("USD"),
+		IgnoredMonthlyCost:              determinism.Zero("USD"),
+		UncertainMonthlyCost:            determinism.Zero("USD"),
+		IgnoredCount:                    len(ignored),
+		RequestID:        req.RequestID,
+
+		SymbolicResources: symbolicResources(req.CardinalityWarnings),
+		Warnings:          append([]string(nil), req.SourceWarnings...),
+		Scope:             req.Scope,
+		Workspace:         req.Workspace,
+		GroupRounding:     e.config.GroupRounding,
+	}
+	if regions.primary != snapshot {
+		result.Snapshot.OverlayHash = regions.primary.ContentHash
 	}
 
+	confidenceScale := 1.0
+	if unavailable != nil {
+		result.Degraded = true
+		result.Warnings = append(result.Warnings,
+			fmt.Sprintf("pricing data unavailable (%v); priced from the bundled fallback rates, which may be incomplete or out of date", unavailable))
+		result.Confidence.Factors = append(result.Confidence.Factors, ConfidenceFactor{
+			Reason: "fallback_pricing",
+			Impact: 1 - fallbackPricingConfidence,
+		})
+		confidenceScale = fallbackPricingConfidence
+	} else if snapshot.Source == pricing.SourceDemo {
+		result.Warnings = append(result.Warnings,
+			"priced from the built-in demo rates, which are approximate; ingest a pricing catalog for real estimates")
+		result.Confidence.Factors = append(result.Confidence.Factors, ConfidenceFactor{
+			Reason: "demo_pricing",
+			Impact: 1 - fallbackPricingConfidence,
+		})
+		confidenceScale = fallbackPricingConfidence
+	}
+	if age, ok := snapshotAge(snapshot, result.EstimatedAt); ok {
+		result.Snapshot.Age = age
+		if e.config.MaxSnapshotAge > 0 && age > e.config.MaxSnapshotAge {
+			if e.config.FailOnStaleSnapshot {
+				return nil, fmt.Errorf("%w: %s is %s old (max %s)",
+					ErrStaleSnapshot, snapshot.ID, formatAge(age), formatAge(e.config.MaxSnapshotAge))
+			}
+			result.Snapshot.Stale = true
+			result.Warnings = append(result.Warnings,
+				fmt.Sprintf("pricing snapshot %s is %s old (max %s); prices may be out of date",
+					snapshot.ID, formatAge(age), formatAge(e.config.MaxSnapshotAge)))
+			result.Confidence.Factors = append(result.Confidence.Factors, ConfidenceFactor{
+				Reason: "stale_snapshot",
+				Impact: 1 - staleSnapshotConfidence,
+			})
+			confidenceScale *= staleSnapshotConfidence
+		}
+	}
+
+	instances := graph.Instances()
+	cacheKey := e.resultCacheKey(req, result.Snapshot, regions, instances, ignored)
+	if cached := e.cachedResult(ctx, cacheKey, result.Snapshot, req.RequestID); cached != nil {
+		cached.Duration = time.Since(start)
+		cached.GroupRounding = e.config.GroupRounding
+		return cached, nil
+	}
+
+	coverageCounts := make(map[CoverageType]int)
+	confidence := newConfidenceAccumulator(e.config.ConfidenceStrategy)
+	unmatched := newUnmatchedTypes()
+	progress := newProgressTracker(req.OnProgress, ProgressPhasePricing, len(instances))
+	progress.update(0, "pricing instances")
+
 	// Process each INSTANCE (not definition)
-	for _, inst := range req.Graph.Instances() {
-		instanceCost, err := e.estimateInstance(ctx, inst, snapshot, req.UsageOverrides)
+	for i, inst := range instances {
+		if i > 0 {
+			progress.update(i, string(instances[i-1].Address))
+		}
+
+		// Honor cancellation between instances: a client disconnect or
+		// timeout returns what was priced so far, marked degraded
+		select {
+		case <-ctx.Done():
+			result.Degraded = true
+			result.Warnings = append(result.Warnings,
+				fmt.Sprintf("estimation canceled after %d of %d instances", i, len(instances)))
+			result.CoverageReport = newCoverageReport(coverageCounts)
+			result.Confidence.Score = confidence.Score() * confidenceScale
+			result.Duration = time.Since(start)
+			return result, fmt.Errorf("estimation canceled: %w", ctx.Err())
+		default:
+		}
+
+		if inst.Metadata.Destroyed {
+			// Gone after the apply: report the savings, count nothing. Its
+			// region and types are not reported as missing or unmatched.
+			e.addDestroyed(ctx, result, inst, regions, usageEstimator, req.UsageOverrides)
+			continue
+		}
+
+		instSnapshot, fallback := regions.forInstance(inst)
+		instanceCost, err := e.estimateInstance(ctx, inst, instSnapshot, fallback, usageEstimator, req.UsageOverrides, unmatched)
 		if err != nil {
 			result.Warnings = append(result.Warnings,
 				fmt.Sprintf("%s: %v", inst.Address, err))
 			result.Degraded = true
+			coverageCounts[CoverageTypeUnsupported]++
+			if err := emitInstanceCost(req, result, skippedInstanceCost(instanceCost, err)); err != nil {
+				result.CoverageReport = newCoverageReport(coverageCounts)
+				result.Confidence.Score = confidence.Score() * confidenceScale
+				result.Duration = time.Since(start)
+				return result, fmt.Errorf("streaming %s: %w", inst.Address, err)
+			}
 			continue
 		}
+		coverageCounts[instanceCost.CoverageType]++
+
+		for _, comp := range instanceCost.Components {
+			if comp.FallbackRegion != "" {
+				if instSnapshot != nil {
+					result.Warnings = append(result.Warnings,
+						fmt.Sprintf("%s: %s has no rate in %s, priced from %s",
+							inst.Address, comp.Name, instSnapshot.Region, comp.FallbackRegion))
+				}
+			}
+		}
 
-		result.InstanceCosts.Set(inst.ID, instanceCost)
+		if err := emitInstanceCost(req, result, instanceCost); err != nil {
+			result.Degraded = true
+			result.CoverageReport = newCoverageReport(coverageCounts)
+			result.Confidence.Score = confidence.Score() * confidenceScale
+			result.Duration = time.Since(start)
+			return result, fmt.Errorf("streaming %s: %w", inst.Address, err)
+		}
 		result.TotalMonthlyCost = result.TotalMonthlyCost.Add(instanceCost.MonthlyCost)
 		result.TotalHourlyCost = result.TotalHourlyCost.Add(instanceCost.HourlyCost)
+		result.EstimatedTotalIncludingSymbolic = result.EstimatedTotalIncludingSymbolic.Add(instanceCost.MonthlyCost)
+		if !instanceCost.Symbolic {
+			uncertain, uncertainCost := uncertainComponents(instanceCost, e.config.DropBelowConfidence)
+			result.UncertainComponents = append(result.UncertainComponents, uncertain...)
+			result.UncertainMonthlyCost = result.UncertainMonthlyCost.Add(uncertainCost)
+			result.FirmTotal = result.FirmTotal.Add(instanceCost.MonthlyCost.Sub(uncertainCost))
+		}
 
 		// Compound confidence
-		result.Confidence.Score *= instanceCost.Confidence.Score
+		confidence.Add(instanceCost.Confidence.Score, instanceCost.MonthlyCost.Float64())
+	}
+
+	progress.update(len(instances), "pricing complete")
+	result.Destroy = destroysAll(req.Graph.Instances())
+
+	// Ignored instances are priced only for what they would cost; their
+	// warnings and unmatched types are not reported
+	for _, inst := range ignored {
+		if inst.Metadata.Destroyed {
+			continue
+		}
+		instSnapshot, fallback := regions.forInstance(inst)
+		instanceCost, err := e.estimateInstance(ctx, inst, instSnapshot, fallback, usageEstimator, req.UsageOverrides, newUnmatchedTypes())
+		if err == nil {
+			result.IgnoredMonthlyCost = result.IgnoredMonthlyCost.Add(instanceCost.MonthlyCost)
+		}
 	}
 
+	result.CoverageReport = newCoverageReport(coverageCounts)
+	result.Confidence.Score = confidence.Score() * confidenceScale
+	if missing := regions.warnings(); len(missing) > 0 {
+		result.Warnings = append(result.Warnings, missing...)
+		result.Degraded = true
+	}
+	types, typeWarnings := unmatched.resolve(regions)
+	result.UnmatchedTypes = types
+	result.Warnings = append(result.Warnings, typeWarnings...)
+
 	// Evaluate policies with full context
 	if e.policyEvaluator != nil {
 		policyResult, err := e.policyEvaluator.Evaluate(ctx, result)
@@ -325,24 +876,115 @@ func (e *Engine) Estimate(ctx context.Context, req *EstimateRequest) (*Estimatio
 	}
 
 	result.Duration = time.Since(start)
+	if !result.Degraded {
+		e.storeResult(ctx, cacheKey, result)
+	}
 	return result, nil
 }
 
+// addDestroyed adds a destroyed instance's current cost to the savings,
+// warning when it cannot be priced, since the savings are then understated
+func (e *Engine) addDestroyed(
+	ctx context.Context,
+	result *EstimationResult,
+	inst *model.AssetInstance,
+	regions *regionSnapshots,
+	usageEstimator UsageEstimator,
+	overrides map[model.InstanceID]map[string]float64,
+) {
+	instSnapshot, fallback := regions.lookup(inst)
+	instanceCost, err := e.estimateInstance(ctx, inst, instSnapshot, fallback, usageEstimator, overrides, newUnmatchedTypes())
+	if err != nil {
+		result.Warnings = append(result.Warnings,
+			fmt.Sprintf("%s: destroyed, but its cost could not be priced, so the savings exclude it: %v", inst.Address, err))
+		return
+	}
+	if instSnapshot == nil && fallback == nil {
+		result.Warnings = append(result.Warnings,
+			fmt.Sprintf("%s: destroyed, but region %s has no pricing snapshot, so the savings exclude it", inst.Address, regions.region(inst)))
+		return
+	}
+	result.DestroyedMonthlyCost = result.DestroyedMonthlyCost.Add(instanceCost.MonthlyCost)
+	result.DestroyedCount++
+}
+
+// destroysAll reports whether instances are all destroyed; ignored
+// instances count, since they are still there after the apply
+func destroysAll(instances []*model.AssetInstance) bool {
+	for _, inst := range instances {
+		if !inst.Metadata.Destroyed {
+			return false
+		}
+	}
+	return len(instances) > 0
+}
+
+// emitInstanceCost streams an instance cost to OnInstanceCost, or keeps it
+// in the result
+func emitInstanceCost(req *EstimateRequest, result *EstimationResult, cost *InstanceCost) error {
+	if req.OnInstanceCost != nil {
+		return req.OnInstanceCost(cost)
+	}
+	result.InstanceCosts.Set(cost.InstanceID, cost)
+	return nil
+}
+
+// skippedInstanceCost turns an instance that could not be costed into a
+// zero-cost unsupported entry explaining why
+func skippedInstanceCost(cost *InstanceCost, err error) *InstanceCost {
+	cost.CoverageType = CoverageTypeUnsupported
+	cost.Components = []*ComponentCost{}
+	cost.MonthlyCost = determinism.Zero("USD")
+	cost.HourlyCost = determinism.Zero("USD")
+	cost.Confidence = CostConfidence{Score: 0}
+	cost.Lineage = []*pricing.CostLineage{}
+	cost.SkipReason = err.Error()
+	return cost
+}
+
+// snapshotAge returns how old the snapshot's pricing data is, measured from
+// EffectiveAt (CreatedAt when unset). ok is false when neither is set.
+func snapshotAge(snapshot *pricing.PricingSnapshot, now time.Time) (time.Duration, bool) {
+	ref := snapshot.EffectiveAt
+	if ref.IsZero() {
+		ref = snapshot.CreatedAt
+	}
+	if ref.IsZero() {
+		return 0, false
+	}
+	return now.Sub(ref), true
+}
+
+// formatAge renders a duration in days once it exceeds one day
+func formatAge(d time.Duration) string {
+	if d >= 24*time.Hour {
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	}
+	return d.Round(time.Minute).String()
+}
+
 func (e *Engine) estimateInstance(
 	ctx context.Context,
 	inst *model.AssetInstance,
 	snapshot *pricing.PricingSnapshot,
+	fallback *pricing.PricingSnapshot,
+	usageEstimator UsageEstimator,
 	overrides map[model.InstanceID]map[string]float64,
+	unmatched *unmatchedTypes,
 ) (*InstanceCost, error) {
 	result := &InstanceCost{
 		InstanceID:   inst.ID,
 		Address:      inst.Address,
+		ResourceType: ResourceTypeFromAddress(inst.Address),
 		DefinitionID: inst.DefinitionID,
+		CoverageType: CoverageTypeUnsupported,
 		Components:   []*ComponentCost{},
 		MonthlyCost:  determinism.Zero("USD"),
 		HourlyCost:   determinism.Zero("USD"),
 		Confidence:   CostConfidence{Score: 1.0},
 		Lineage:      []*pricing.CostLineage{},
+		Tags:         instanceTags(inst),
+		Symbolic:     inst.Metadata.IsPlaceholder,
 	}
 
 	// Get cloud plugin
@@ -357,8 +999,13 @@ func (e *Engine) estimateInstance(
 		return result, err
 	}
 
+	result.CoverageType = CoverageTypeNumeric
+	if len(components) == 0 {
+		result.CoverageType = CoverageTypeIndirect
+	}
+
 	// Get usage estimates
-	usage, err := e.usageEstimator.Estimate(ctx, inst)
+	usage, err := usageEstimator.Estimate(ctx, inst)
 	if err != nil {
 		result.Confidence.Factors = append(result.Confidence.Factors, ConfidenceFactor{
 			Reason: "usage estimation failed",
@@ -372,14 +1019,26 @@ func (e *Engine) estimateInstance(
 
 	// Price each component
 	for _, comp := range components {
-		compCost, lineage := e.priceComponent(comp, inst, snapshot, usage, instanceOverrides)
+		compCost, lineage := e.cachedPriceComponent(comp, inst, snapshot, fallback, usage, instanceOverrides)
 		result.Components = append(result.Components, compCost)
-		result.MonthlyCost = result.MonthlyCost.Add(compCost.MonthlyCost)
-		result.HourlyCost = result.HourlyCost.Add(compCost.HourlyCost)
 		result.Lineage = append(result.Lineage, lineage)
+		if compCost.RateID == "" {
+			unmatched.record(inst, comp, snapshot)
+		}
+
+		// Unpriced or unknown-usage components make the instance symbolic
+		if compCost.IsSymbolic {
+			result.CoverageType = CoverageTypeSymbolic
+		}
 
 		// Track confidence factors
-		if compCost.Confidence < 1.0 {
+		if compCost.FallbackRegion != "" {
+			result.Confidence.Factors = append(result.Confidence.Factors, ConfidenceFactor{
+				Reason:    "region_fallback",
+				Impact:    1.0 - regionFallbackConfidence,
+				Component: comp.Name,
+			})
+		} else if compCost.Confidence < 1.0 {
 			result.Confidence.Factors = append(result.Confidence.Factors, ConfidenceFactor{
 				Reason:    "reduced component confidence",
 				Impact:    1.0 - compCost.Confidence,
@@ -388,36 +1047,88 @@ func (e *Engine) estimateInstance(
 		}
 	}
 
+	// Credits are capped by what they offset before the roll-up
+	e.applyCredits(result)
+	e.rollUp(result)
+
 	// Calculate overall confidence
 	result.Confidence.Score = e.calculateConfidence(result)
 
 	return result, nil
 }
 
+// cachedPriceComponent prices a component, reusing an identical component's
+// result when the cache is enabled. Lineage is always per-instance.
+func (e *Engine) cachedPriceComponent(
+	comp CostComponent,
+	inst *model.AssetInstance,
+	snapshot *pricing.PricingSnapshot,
+	fallback *pricing.PricingSnapshot,
+	usage *UsageResult,
+	overrides map[string]float64,
+) (*ComponentCost, *pricing.CostLineage) {
+	if e.componentCache == nil || snapshot == nil {
+		return e.priceComponent(comp, inst, snapshot, fallback, usage, overrides)
+	}
+
+	key := componentCacheKey(comp, snapshot, fallback, usage, overrides, e.HoursPerMonth())
+	if entry, ok := e.componentCache.get(key); ok {
+		cost, lineage := entry.copies()
+		lineage.InstanceID = string(inst.ID)
+		lineage.Timestamp = e.now()
+		return cost, lineage
+	}
+
+	cost, lineage := e.priceComponent(comp, inst, snapshot, fallback, usage, overrides)
+	e.componentCache.put(key, newCachedComponent(cost, lineage))
+	return cost, lineage
+}
+
 func (e *Engine) priceComponent(
 	comp CostComponent,
 	inst *model.AssetInstance,
 	snapshot *pricing.PricingSnapshot,
+	fallback *pricing.PricingSnapshot,
 	usage *UsageResult,
 	overrides map[string]float64,
 ) (*ComponentCost, *pricing.CostLineage) {
 	result := &ComponentCost{
-		Name:       comp.Name,
-		Confidence: 1.0,
+		Name:        comp.Name,
+		MonthlyCost: determinism.Zero("USD"),
+		HourlyCost:  determinism.Zero("USD"),
+		Confidence:  1.0,
+		Credit:      comp.Credit,
+		CreditFor:   comp.CreditFor,
 	}
 
 	lineage := &pricing.CostLineage{
 		InstanceID: string(inst.ID),
 		Component:  comp.Name,
-		SnapshotID: snapshot.ID,
-		Timestamp:  time.Now().UTC(),
+		Credit:     comp.Credit,
+		CreditFor:  comp.CreditFor,
+		Timestamp:  e.now(),
 	}
 
-	// Look up rate
-	rate, ok := snapshot.LookupRate(comp.ResourceType, comp.Name, comp.Attributes)
+	// Look up rate (no snapshot when the instance's region has none)
+	var rate *pricing.RateEntry
+	ok := false
+	rateComponent := creditRateComponent(comp)
+	if snapshot != nil {
+		lineage.SnapshotID = snapshot.ID
+		rate, ok = snapshot.LookupRate(comp.ResourceType, rateComponent, comp.Attributes)
+	}
+	if !ok && fallback != nil {
+		// Opt-in: price from the reference region, flagged as a fallback
+		if rate, ok = fallback.LookupRate(comp.ResourceType, rateComponent, comp.Attributes); ok {
+			result.FallbackRegion = fallback.Region
+			result.Confidence = regionFallbackConfidence
+			lineage.SnapshotID = fallback.ID
+		}
+	}
 	if !ok {
 		// Rate not found - degraded estimation
 		result.Confidence = 0.0
+		result.IsSymbolic = true
 		lineage.Confidence = 0.0
 		return result, lineage
 	}
@@ -426,18 +1137,29 @@ func (e *Engine) priceComponent(
 	result.RateKey = rate.Key
 	lineage.RateID = rate.ID
 	lineage.RateKey = rate.Key
+	lineage.SKU = rate.SKU
+	lineage.Candidates = rate.Candidates
+	lineage.Overlay = rate.Overlay
 
 	// Get usage value
-	usageValue := 730.0 // Default monthly hours
+	hoursPerMonth := e.HoursPerMonth()
+	usageValue := hoursPerMonth // Default: always on
+	if usage.UptimeFraction > 0 {
+		usageValue *= usage.UptimeFraction
+	}
 	usageUnit := "hours"
 	usageConfidence := 1.0
 
 	if metric, ok := usage.Metrics[comp.Name]; ok {
 		if metric.IsUnknown {
 			// UNKNOWN: propagate, don't guess
+			result.IsSymbolic = true
 			result.Confidence *= 0.5
 			usageConfidence = 0.5
 			lineage.Confidence = 0.5
+		} else if metric.Unit == UnitUptimeFraction {
+			usageValue = hoursPerMonth * metric.Value
+			usageConfidence = metric.Confidence
 		} else {
 			usageValue = metric.Value
 			usageUnit = metric.Unit
@@ -449,6 +1171,7 @@ func (e *Engine) priceComponent(
 	if override, ok := overrides[comp.Name]; ok {
 		usageValue = override
 		usageConfidence = 1.0 // User-provided is trusted
+		result.IsSymbolic = false
 	}
 
 	result.UsageValue = usageValue
@@ -459,7 +1182,12 @@ func (e *Engine) priceComponent(
 		rate.Price.Mul(determinism.NewMoneyFromFloat(usageValue, "USD").Amount()),
 		rate.Currency,
 	)
-	hourlyCost := monthlyCost.Div(determinism.NewMoneyFromFloat(730.0, "USD").Amount())
+	formulaName, expression := "usage_based", fmt.Sprintf("%s * %s", rate.Price.String(), usageUnit)
+	if comp.Credit {
+		monthlyCost = monthlyCost.Neg()
+		formulaName, expression = "credit", "-("+expression+")"
+	}
+	hourlyCost := monthlyCost.Div(decimal.NewFromFloat(hoursPerMonth))
 
 	result.MonthlyCost = monthlyCost
 	result.HourlyCost = hourlyCost
@@ -467,18 +1195,20 @@ func (e *Engine) priceComponent(
 
 	// Record formula
 	result.Formula = pricing.FormulaApplication{
-		Name:       "usage_based",
-		Expression: fmt.Sprintf("%s * %s", rate.Price.String(), usageUnit),
+		Name:       formulaName,
+		Expression: expression,
 		Inputs: map[string]string{
-			"rate":  rate.Price.String(),
-			"usage": fmt.Sprintf("%.2f", usageValue),
-			"unit":  usageUnit,
+			"rate":            rate.Price.String(),
+			"usage":           fmt.Sprintf("%.2f", usageValue),
+			"unit":            usageUnit,
+			"hours_per_month": fmt.Sprintf("%g", hoursPerMonth),
 		},
 		Output: monthlyCost.StringRaw(),
 	}
 	lineage.Formula = result.Formula
 	lineage.Usage = pricing.UsageLineage{
 		Source:     usage.Source,
+		Profile:    usage.Profile,
 		Confidence: usageConfidence,
 	}
 	lineage.Confidence = result.Confidence
@@ -497,3 +1227,42 @@ func (e *Engine) calculateConfidence(ic *InstanceCost) float64 {
 	}
 	return total / float64(len(ic.Components))
 }
+
+func newCoverageReport(counts map[CoverageType]int) *CoverageReport {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+
+	report := &CoverageReport{TotalInstances: total}
+	if total == 0 {
+		return report
+	}
+
+	pct := func(c CoverageType) float64 {
+		return float64(counts[c]) / float64(total) * 100
+	}
+	report.NumericPercent = pct(CoverageTypeNumeric)
+	report.SymbolicPercent = pct(CoverageTypeSymbolic)
+	report.IndirectPercent = pct(CoverageTypeIndirect)
+	report.UnsupportedPercent = pct(CoverageTypeUnsupported)
+	return report
+}
+
+// ResourceTypeFromAddress extracts the resource type from an instance address
+// module.app.aws_instance.web[0] → aws_instance
+func ResourceTypeFromAddress(addr model.InstanceAddress) string {
+	parts := strings.Split(string(addr), ".")
+	i := 0
+	for i+1 < len(parts) && (parts[i] == "module" || parts[i] == "data") {
+		if parts[i] == "data" {
+			i++
+			break
+		}
+		i += 2
+	}
+	if i >= len(parts) {
+		return ""
+	}
+	return parts[i]
+}
@@ -0,0 +1,1120 @@
+// Package engine - Estimation loop tests
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/catalog"
+	"terraform-cost/core/cost"
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/model"
+	"terraform-cost/core/pricing"
+	"terraform-cost/core/terraform"
+	"terraform-cost/internal/logging"
+)
+
+// staticResolver always returns the same snapshot
+type staticResolver struct {
+	snapshot *pricing.PricingSnapshot
+
+	// aliases records the alias of every snapshot request
+	aliases []string
+}
+
+func (r *staticResolver) GetSnapshot(ctx context.Context, req SnapshotRequest) (*pricing.PricingSnapshot, error) {
+	r.aliases = append(r.aliases, req.Alias)
+	return r.snapshot, nil
+}
+
+func (r *staticResolver) LookupRate(snapshot *pricing.PricingSnapshot, resourceType, component string, attrs map[string]string) (*pricing.RateEntry, error) {
+	rate, ok := snapshot.LookupRate(resourceType, component, attrs)
+	if !ok {
+		return nil, fmt.Errorf("rate not found")
+	}
+	return rate, nil
+}
+
+// noUsage returns no usage metrics, so defaults apply
+type noUsage struct{}
+
+func (noUsage) Estimate(ctx context.Context, inst *model.AssetInstance) (*UsageResult, error) {
+	return &UsageResult{Metrics: map[string]UsageMetric{}, Confidence: 1.0}, nil
+}
+
+// computePlugin maps every instance to a single compute component
+type computePlugin struct {
+	onMap func()
+}
+
+func (p *computePlugin) Provider() string { return "aws" }
+
+func (p *computePlugin) CatalogVersion() string { return catalog.Version }
+
+func (p *computePlugin) SupportedTypes() []string { return []string{"aws_instance"} }
+
+func (p *computePlugin) MapInstance(inst *model.AssetInstance) ([]CostComponent, error) {
+	if p.onMap != nil {
+		p.onMap()
+	}
+	return []CostComponent{{Name: "compute", ResourceType: "aws_instance", Unit: "hours"}}, nil
+}
+
+func newTestEngine(plugin CloudPlugin) *Engine {
+	return newTestEngineWithConfig(plugin, EngineConfig{})
+}
+
+func newTestEngineWithConfig(plugin CloudPlugin, config EngineConfig) *Engine {
+	snapshot := pricing.NewSnapshotBuilder("aws", "us-east-1").
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(0.1), "hour", "USD").
+		Build()
+
+	eng := NewEngine(&staticResolver{snapshot: snapshot}, noUsage{}, nil, config)
+	eng.SetLogger(logging.Nop())
+	eng.RegisterPlugin(plugin)
+	return eng
+}
+
+func newTestGraph(n int) *model.InstanceGraph {
+	return newTestGraphInRegion(n, "us-east-1")
+}
+
+func newTestGraphInRegion(n int, region string) *model.InstanceGraph {
+	g := model.NewInstanceGraph()
+	for i := 0; i < n; i++ {
+		addr := fmt.Sprintf("aws_instance.web[%d]", i)
+		g.AddInstance(&model.AssetInstance{
+			ID:       model.InstanceID(fmt.Sprintf("inst-%03d", i)),
+			Address:  model.InstanceAddress(addr),
+			Provider: model.ResolvedProvider{Type: "aws", Region: region},
+		})
+	}
+	return g
+}
+
+// TestEstimateCanceledContext proves a canceled context aborts the loop
+func TestEstimateCanceledContext(t *testing.T) {
+	eng := newTestEngine(&computePlugin{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := eng.Estimate(ctx, &EstimateRequest{Graph: newTestGraph(5)})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if result == nil || !result.Degraded {
+		t.Fatal("expected a partial result marked degraded")
+	}
+	if result.InstanceCosts.Len() != 0 {
+		t.Errorf("expected no instances priced, got %d", result.InstanceCosts.Len())
+	}
+}
+
+// TestEstimateCanceledMidLoop proves cancellation between instances returns a partial result
+func TestEstimateCanceledMidLoop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+	eng := newTestEngine(&computePlugin{onMap: func() {
+		calls++
+		if calls == 2 {
+			cancel()
+		}
+	}})
+
+	result, err := eng.Estimate(ctx, &EstimateRequest{Graph: newTestGraph(10)})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if got := result.InstanceCosts.Len(); got != 2 {
+		t.Errorf("expected 2 instances priced before cancellation, got %d", got)
+	}
+	if !result.Degraded {
+		t.Error("partial result should be marked degraded")
+	}
+}
+
+// TestEstimateCompletes is the control: a live context prices everything
+func TestEstimateCompletes(t *testing.T) {
+	eng := newTestEngine(&computePlugin{})
+
+	result, err := eng.Estimate(context.Background(), &EstimateRequest{Graph: newTestGraph(3)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := result.InstanceCosts.Len(); got != 3 {
+		t.Errorf("expected 3 instances priced, got %d", got)
+	}
+	if result.Degraded {
+		t.Errorf("unexpected degradation: %v", result.Warnings)
+	}
+}
+
+// TestUsageProfileScalesDefaultHours proves profiles scale defaults but not overrides
+func TestUsageProfileScalesDefaultHours(t *testing.T) {
+	eng := newTestEngine(&computePlugin{})
+	graph := newTestGraph(2)
+
+	result, err := eng.Estimate(context.Background(), &EstimateRequest{
+		Graph:        graph,
+		UsageProfile: "dev",
+		UsageOverrides: map[model.InstanceID]map[string]float64{
+			"inst-001": {"compute": 730},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	profiled, _ := result.InstanceCosts.Get("inst-000")
+	if got := profiled.Components[0].UsageValue; got < 243.3 || got > 243.4 {
+		t.Errorf("development profile should bill ~243.3 hours, got %.2f", got)
+	}
+
+	overridden, _ := result.InstanceCosts.Get("inst-001")
+	if got := overridden.Components[0].UsageValue; got != 730 {
+		t.Errorf("explicit override should win over profile, got %.2f", got)
+	}
+
+	if _, err := eng.Estimate(context.Background(), &EstimateRequest{Graph: graph, UsageProfile: "nope"}); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+}
+
+// TestHoursPerMonthConsistency proves monthly and hourly derive from the same constant
+func TestHoursPerMonthConsistency(t *testing.T) {
+	for _, hours := range []float64{0, 720, 744} {
+		eng := newTestEngineWithConfig(&computePlugin{}, EngineConfig{HoursPerMonth: hours})
+		want := hours
+		if want == 0 {
+			want = 730
+		}
+		if got := eng.HoursPerMonth(); got != want {
+			t.Fatalf("HoursPerMonth() = %v, want %v", got, want)
+		}
+
+		result, err := eng.Estimate(context.Background(), &EstimateRequest{Graph: newTestGraph(1)})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		cost, _ := result.InstanceCosts.Get("inst-000")
+		wantMonthly := decimal.NewFromFloat(0.1 * want)
+		if !cost.MonthlyCost.Amount().Equal(wantMonthly) {
+			t.Errorf("hours=%v: monthly = %s, want %s", want, cost.MonthlyCost, wantMonthly)
+		}
+		if !cost.HourlyCost.Amount().Round(6).Equal(decimal.NewFromFloat(0.1)) {
+			t.Errorf("hours=%v: hourly = %s, want 0.1", want, cost.HourlyCost)
+		}
+	}
+}
+
+// regionResolver serves one snapshot per region
+type regionResolver struct {
+	staticResolver
+	byRegion map[string]*pricing.PricingSnapshot
+}
+
+func (r *regionResolver) GetSnapshot(ctx context.Context, req SnapshotRequest) (*pricing.PricingSnapshot, error) {
+	snap, ok := r.byRegion[req.Region]
+	if !ok {
+		return nil, fmt.Errorf("no snapshot for %s", req.Region)
+	}
+	return snap, nil
+}
+
+// TestRegionFallback proves fallback is opt-in and flagged when used
+func TestRegionFallback(t *testing.T) {
+	resolver := &regionResolver{byRegion: map[string]*pricing.PricingSnapshot{
+		"eu-north-1": pricing.NewSnapshotBuilder("aws", "eu-north-1").Build(),
+		"us-east-1": pricing.NewSnapshotBuilder("aws", "us-east-1").
+			AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(0.1), "hour", "USD").
+			Build(),
+	}}
+	req := &EstimateRequest{Graph: newTestGraphInRegion(1, "eu-north-1"), SnapshotRequest: SnapshotRequest{Provider: "aws", Region: "eu-north-1"}}
+
+	strict := NewEngine(resolver, noUsage{}, nil, EngineConfig{})
+	strict.RegisterPlugin(&computePlugin{})
+	result, err := strict.Estimate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cost, _ := result.InstanceCosts.Get("inst-000")
+	if !cost.MonthlyCost.IsZero() || cost.Components[0].FallbackRegion != "" {
+		t.Fatal("strict engine must not fall back")
+	}
+
+	lenient := NewEngine(resolver, noUsage{}, nil, EngineConfig{AllowRegionFallback: true})
+	lenient.RegisterPlugin(&computePlugin{})
+	result, err = lenient.Estimate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cost, _ = result.InstanceCosts.Get("inst-000")
+	comp := cost.Components[0]
+	if comp.FallbackRegion != "us-east-1" || comp.IsSymbolic {
+		t.Fatalf("expected numeric price from us-east-1, got region=%q symbolic=%v", comp.FallbackRegion, comp.IsSymbolic)
+	}
+	if cost.MonthlyCost.IsZero() {
+		t.Error("fallback should produce a non-zero cost")
+	}
+	if len(result.Warnings) != 1 {
+		t.Errorf("expected one fallback warning, got %v", result.Warnings)
+	}
+
+	found := false
+	for _, f := range cost.Confidence.Factors {
+		if f.Reason == "region_fallback" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected a region_fallback confidence factor")
+	}
+}
+
+// TestCompareRegions proves every instance is priced in the compared region
+// and a region without a snapshot is skipped with a warning
+func TestCompareRegions(t *testing.T) {
+	snapshot := func(region string, price float64) *pricing.PricingSnapshot {
+		return pricing.NewSnapshotBuilder("aws", region).
+			AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(price), "hour", "USD").
+			Build()
+	}
+	resolver := &regionResolver{byRegion: map[string]*pricing.PricingSnapshot{
+		"us-east-1": snapshot("us-east-1", 0.1),
+		"eu-west-1": snapshot("eu-west-1", 0.2),
+	}}
+	eng := NewEngine(resolver, noUsage{}, nil, EngineConfig{HoursPerMonth: 100})
+	eng.RegisterPlugin(&computePlugin{})
+
+	// Instances configured for us-east-1 are re-priced in each region
+	req := &EstimateRequest{Graph: newTestGraphInRegion(2, "us-east-1"), SnapshotRequest: SnapshotRequest{Provider: "aws"}}
+	comparison, err := eng.CompareRegions(context.Background(), req, []string{"eu-west-1", "ap-south-1", "us-east-1", "eu-west-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(comparison.Regions) != 2 || comparison.Regions[0].Region != "eu-west-1" || comparison.Regions[1].Region != "us-east-1" {
+		t.Fatalf("regions = %+v, want eu-west-1 then us-east-1", comparison.Regions)
+	}
+	if len(comparison.Warnings) != 1 || !strings.Contains(comparison.Warnings[0], "ap-south-1") {
+		t.Errorf("warnings = %v, want one for ap-south-1", comparison.Warnings)
+	}
+
+	for region, want := range map[int]string{0: "40", 1: "20"} {
+		got := comparison.Regions[region].Result.TotalMonthlyCost.Amount()
+		if !got.Round(2).Equal(decimal.RequireFromString(want)) {
+			t.Errorf("%s total = %s, want %s", comparison.Regions[region].Region, got, want)
+		}
+	}
+	if ec2 := comparison.Regions[0].ByService["ec2"]; !ec2.Amount().Equal(decimal.RequireFromString("40")) {
+		t.Errorf("eu-west-1 ec2 = %s, want 40", ec2)
+	}
+	if services := comparison.Services(); len(services) != 1 || services[0] != "ec2" {
+		t.Errorf("services = %v, want [ec2]", services)
+	}
+
+	if _, err := eng.CompareRegions(context.Background(), req, []string{"ap-south-1"}); err == nil {
+		t.Error("expected an error when no region has a snapshot")
+	}
+}
+
+// TestCompareWorkspaces proves each workspace's graph is priced and
+// labeled with its workspace
+func TestCompareWorkspaces(t *testing.T) {
+	eng := newTestEngineWithConfig(&computePlugin{}, EngineConfig{HoursPerMonth: 100})
+	reqs := []*EstimateRequest{
+		{Graph: newTestGraph(1), Workspace: "staging"},
+		{Graph: newTestGraph(3), Workspace: "prod"},
+	}
+	comparison, err := eng.CompareWorkspaces(context.Background(), reqs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(comparison.Workspaces) != 2 {
+		t.Fatalf("workspaces = %+v, want staging and prod", comparison.Workspaces)
+	}
+	staging, prod := comparison.Workspaces[0], comparison.Workspaces[1]
+	if staging.Workspace != "staging" || staging.Result.Workspace != "staging" || prod.Result.Workspace != "prod" {
+		t.Errorf("workspaces = %s (%s), %s (%s)", staging.Workspace, staging.Result.Workspace, prod.Workspace, prod.Result.Workspace)
+	}
+	if got, want := prod.Result.TotalMonthlyCost.Amount(), staging.Result.TotalMonthlyCost.Amount().Mul(decimal.NewFromInt(3)); !got.Equal(want) {
+		t.Errorf("prod total = %s, want 3 x staging = %s", got, want)
+	}
+	if services := comparison.Services(); len(services) != 1 || services[0] != "ec2" {
+		t.Errorf("services = %v, want [ec2]", services)
+	}
+
+	if _, err := eng.CompareWorkspaces(context.Background(), nil); err == nil {
+		t.Error("expected an error with no workspaces")
+	}
+}
+
+// TestGraphLimits proves oversized graphs are refused before any pricing
+func TestGraphLimits(t *testing.T) {
+	mapped := 0
+	plugin := &computePlugin{onMap: func() { mapped++ }}
+
+	graph := newTestGraph(DefaultMaxInstances + 1)
+	_, err := newTestEngine(plugin).Estimate(context.Background(), &EstimateRequest{Graph: graph})
+	if !errors.Is(err, ErrGraphTooLarge) {
+		t.Fatalf("expected ErrGraphTooLarge with default limits, got %v", err)
+	}
+	if mapped != 0 {
+		t.Errorf("%d instances mapped before the limit was enforced", mapped)
+	}
+
+	unlimited := newTestEngineWithConfig(plugin, EngineConfig{MaxInstances: -1})
+	if err := unlimited.CheckGraphLimits(graph); err != nil {
+		t.Errorf("negative MaxInstances should disable the limit: %v", err)
+	}
+
+	small := newTestGraph(3)
+	small.AddEdge("inst-000", "inst-001", model.EdgeExplicit)
+	small.AddEdge("inst-001", "inst-002", model.EdgeExplicit)
+	edges := newTestEngineWithConfig(plugin, EngineConfig{MaxGraphEdges: 1})
+	if err := edges.CheckGraphLimits(small); !errors.Is(err, ErrGraphTooLarge) || !strings.Contains(err.Error(), "2 dependency edges") {
+		t.Errorf("expected an edge limit error, got %v", err)
+	}
+}
+
+// TestEstimateReportsProgress proves progress is throttled for large graphs
+// and always ends with every instance complete
+func TestEstimateReportsProgress(t *testing.T) {
+	var updates [][2]int
+	req := &EstimateRequest{
+		Graph: newTestGraph(1000),
+		OnProgress: func(phase string, completed, total int, _ string) {
+			if phase != ProgressPhasePricing {
+				t.Errorf("unexpected phase %q", phase)
+			}
+			updates = append(updates, [2]int{completed, total})
+		},
+	}
+	if _, err := newTestEngine(&computePlugin{}).Estimate(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(updates) < 2 || len(updates) > progressUpdates+2 {
+		t.Fatalf("got %d updates, want between 2 and %d", len(updates), progressUpdates+2)
+	}
+	if first := updates[0]; first != [2]int{0, 1000} {
+		t.Errorf("first update = %v, want [0 1000]", first)
+	}
+	if last := updates[len(updates)-1]; last != [2]int{1000, 1000} {
+		t.Errorf("last update = %v, want [1000 1000]", last)
+	}
+	for i := 1; i < len(updates); i++ {
+		if updates[i][0] < updates[i-1][0] {
+			t.Fatalf("progress went backwards: %v", updates)
+		}
+	}
+}
+
+// TestConfidenceStrategies proves one cheap uncertain resource does not sink a
+// cost-weighted score the way multiplication does
+func TestConfidenceStrategies(t *testing.T) {
+	scores := func(strategy ConfidenceStrategy) float64 {
+		acc := newConfidenceAccumulator(strategy)
+		for i := 0; i < 99; i++ {
+			acc.Add(0.95, 100)
+		}
+		acc.Add(0.2, 1)
+		return acc.Score()
+	}
+
+	if got := scores(ConfidenceCostWeightedAverage); got < 0.94 {
+		t.Errorf("cost-weighted score = %.3f, want >= 0.94", got)
+	}
+	if got := scores(ConfidenceMultiplicative); got > 0.01 {
+		t.Errorf("multiplicative score = %.3f, want near zero", got)
+	}
+	if got := scores(ConfidenceMin); got != 0.2 {
+		t.Errorf("min score = %.3f, want 0.2", got)
+	}
+
+	zeroCost := newConfidenceAccumulator(ConfidenceCostWeightedAverage)
+	zeroCost.Add(1.0, 0)
+	zeroCost.Add(0.5, 0)
+	if got := zeroCost.Score(); got != 0.75 {
+		t.Errorf("zero-cost graph should average scores, got %.3f", got)
+	}
+
+	// Unpriced instances weigh as much as the average priced one, so
+	// failing to price half the graph halves the score
+	unpriced := newConfidenceAccumulator(ConfidenceCostWeightedAverage)
+	unpriced.Add(1.0, 100)
+	unpriced.Add(1.0, 300)
+	unpriced.Add(0, 0)
+	unpriced.Add(0, 0)
+	if got := unpriced.Score(); got != 0.5 {
+		t.Errorf("unpriced instances should lower the score to 0.5, got %.3f", got)
+	}
+}
+
+// TestStaleSnapshot proves an old snapshot warns by default and fails when strict
+func TestStaleSnapshot(t *testing.T) {
+	snapshot := pricing.NewSnapshotBuilder("aws", "us-east-1").
+		WithEffectiveAt(time.Now().UTC().Add(-90*24*time.Hour)).
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(0.1), "hour", "USD").
+		Build()
+	newEngine := func(config EngineConfig) *Engine {
+		eng := NewEngine(&staticResolver{snapshot: snapshot}, noUsage{}, nil, config)
+		eng.RegisterPlugin(&computePlugin{})
+		return eng
+	}
+	req := &EstimateRequest{Graph: newTestGraph(1)}
+
+	result, err := newEngine(EngineConfig{}).Estimate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Snapshot.Stale || len(result.Warnings) != 0 {
+		t.Fatal("snapshot age must not be checked without MaxSnapshotAge")
+	}
+
+	result, err = newEngine(EngineConfig{MaxSnapshotAge: 30 * 24 * time.Hour}).Estimate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Snapshot.Stale || len(result.Warnings) != 1 {
+		t.Fatalf("expected stale snapshot warning, got stale=%v warnings=%v", result.Snapshot.Stale, result.Warnings)
+	}
+	if result.Confidence.Score >= 1.0 || len(result.Confidence.Factors) != 1 || result.Confidence.Factors[0].Reason != "stale_snapshot" {
+		t.Errorf("expected stale_snapshot confidence factor, got %+v", result.Confidence)
+	}
+
+	_, err = newEngine(EngineConfig{MaxSnapshotAge: 30 * 24 * time.Hour, FailOnStaleSnapshot: true}).Estimate(context.Background(), req)
+	if !errors.Is(err, ErrStaleSnapshot) {
+		t.Errorf("expected ErrStaleSnapshot, got %v", err)
+	}
+}
+
+// TestComponentCache proves identical components are priced once while
+// lineage stays per-instance
+func TestComponentCache(t *testing.T) {
+	eng := newTestEngine(&computePlugin{})
+	result, err := eng.Estimate(context.Background(), &EstimateRequest{Graph: newTestGraph(50)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stats := eng.ComponentCacheStats()
+	if stats.Misses != 1 || stats.Hits != 49 {
+		t.Errorf("expected 1 miss and 49 hits, got %+v", stats)
+	}
+
+	uncached := newTestEngineWithConfig(&computePlugin{}, EngineConfig{DisableComponentCache: true})
+	want, err := uncached.Estimate(context.Background(), &EstimateRequest{Graph: newTestGraph(50)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.TotalMonthlyCost.Amount().Equal(want.TotalMonthlyCost.Amount()) {
+		t.Errorf("cached total %s != uncached total %s", result.TotalMonthlyCost, want.TotalMonthlyCost)
+	}
+
+	cost, _ := result.InstanceCosts.Get("inst-007")
+	if got := cost.Lineage[0].InstanceID; got != "inst-007" {
+		t.Errorf("lineage should name its own instance, got %s", got)
+	}
+
+	// Hits share no formula inputs with the entry or with each other
+	if len(cost.Components[0].Formula.Inputs) == 0 || len(cost.Lineage[0].Formula.Inputs) == 0 {
+		t.Fatal("expected formula inputs to check")
+	}
+	cost.Components[0].Formula.Inputs["tampered"] = "yes"
+	cost.Lineage[0].Formula.Inputs["tampered"] = "yes"
+	other, _ := result.InstanceCosts.Get("inst-008")
+	again, err := eng.Estimate(context.Background(), &EstimateRequest{Graph: newTestGraph(1)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fresh, _ := again.InstanceCosts.Get("inst-000")
+	for name, c := range map[string]*InstanceCost{"same estimate": other, "later estimate": fresh} {
+		if _, ok := c.Components[0].Formula.Inputs["tampered"]; ok {
+			t.Errorf("%s: component formula inputs shared with another instance", name)
+		}
+		if _, ok := c.Lineage[0].Formula.Inputs["tampered"]; ok {
+			t.Errorf("%s: lineage formula inputs shared with another instance", name)
+		}
+	}
+}
+
+// BenchmarkEstimateHomogeneousFleet compares pricing a fleet of identical
+// instances with and without the component cache. Results are streamed so
+// the benchmark measures pricing rather than result-map bookkeeping.
+func BenchmarkEstimateHomogeneousFleet(b *testing.B) {
+	graph := newTestGraph(1000)
+	for _, bc := range []struct {
+		name   string
+		config EngineConfig
+	}{
+		{"cached", EngineConfig{}},
+		{"uncached", EngineConfig{DisableComponentCache: true}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			eng := newTestEngineWithConfig(&computePlugin{}, bc.config)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				req := &EstimateRequest{Graph: graph, OnInstanceCost: func(*InstanceCost) error { return nil }}
+				if _, err := eng.Estimate(context.Background(), req); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkEstimateLargeGraph prices a 5000-instance graph; its result
+// is guarded against regressions by scripts/bench-guard.sh
+func BenchmarkEstimateLargeGraph(b *testing.B) {
+	graph := newTestGraph(5000)
+	eng := newTestEngine(&computePlugin{})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := eng.Estimate(context.Background(), &EstimateRequest{Graph: graph}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestDestroyedInstanceBookkeeping proves a destroyed instance that cannot
+// be priced is warned about, without reporting its region as missing or
+// its type as unmatched
+func TestDestroyedInstanceBookkeeping(t *testing.T) {
+	resolver := &regionResolver{byRegion: map[string]*pricing.PricingSnapshot{
+		"us-east-1": pricing.NewSnapshotBuilder("aws", "us-east-1").
+			AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(0.1), "hour", "USD").
+			Build(),
+	}}
+	eng := NewEngine(resolver, noUsage{}, nil, EngineConfig{})
+	eng.SetLogger(logging.Nop())
+	eng.RegisterPlugin(&computePlugin{})
+
+	graph := newTestGraph(1)
+	for _, inst := range []*model.AssetInstance{
+		{ID: "old-1", Address: "aws_instance.old", Provider: model.ResolvedProvider{Type: "aws", Region: "us-east-1"}},
+		{ID: "old-2", Address: "aws_instance.elsewhere", Provider: model.ResolvedProvider{Type: "aws", Region: "eu-west-1"}},
+		{ID: "old-3", Address: "google_compute_instance.old", Provider: model.ResolvedProvider{Type: "google", Region: "us-central1"}},
+	} {
+		inst.Metadata.Destroyed = true
+		graph.AddInstance(inst)
+	}
+
+	result, err := eng.Estimate(context.Background(), &EstimateRequest{
+		Graph:           graph,
+		SnapshotRequest: SnapshotRequest{Provider: "aws", Region: "us-east-1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.DestroyedCount != 1 || result.DestroyedMonthlyCost.IsZero() {
+		t.Errorf("destroyed = %d at %s, want the one priced instance", result.DestroyedCount, result.DestroyedMonthlyCost)
+	}
+	if result.Degraded || len(result.UnmatchedTypes) != 0 {
+		t.Errorf("degraded = %v, unmatched = %v; destroyed instances must not be reported", result.Degraded, result.UnmatchedTypes)
+	}
+	if len(result.Warnings) != 2 ||
+		!strings.Contains(result.Warnings[0], "aws_instance.elsewhere: destroyed, but region eu-west-1 has no pricing snapshot") ||
+		!strings.Contains(result.Warnings[1], "google_compute_instance.old: destroyed, but its cost could not be priced") {
+		t.Errorf("warnings = %q, want one per unpriced destroyed instance", result.Warnings)
+	}
+}
+
+// TestPerInstanceRegion proves instances are priced from their own region's
+// snapshot and a region without one is reported, not silently repriced
+func TestPerInstanceRegion(t *testing.T) {
+	rate := func(region string, price float64) *pricing.PricingSnapshot {
+		return pricing.NewSnapshotBuilder("aws", region).
+			AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(price), "hour", "USD").
+			Build()
+	}
+	resolver := &regionResolver{byRegion: map[string]*pricing.PricingSnapshot{
+		"us-east-1": rate("us-east-1", 0.1),
+		"us-west-2": rate("us-west-2", 0.2),
+	}}
+	eng := NewEngine(resolver, noUsage{}, nil, EngineConfig{})
+	eng.RegisterPlugin(&computePlugin{})
+
+	graph := model.NewInstanceGraph()
+	for id, region := range map[string]string{"east": "us-east-1", "west": "us-west-2", "south": "sa-east-1"} {
+		graph.AddInstance(&model.AssetInstance{
+			ID:       model.InstanceID(id),
+			Address:  model.InstanceAddress("aws_instance." + id),
+			Provider: model.ResolvedProvider{Type: "aws", Region: region},
+		})
+	}
+
+	result, err := eng.Estimate(context.Background(), &EstimateRequest{
+		Graph:           graph,
+		SnapshotRequest: SnapshotRequest{Provider: "aws", Region: "us-east-1"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for id, want := range map[model.InstanceID]float64{"east": 73, "west": 146, "south": 0} {
+		cost, _ := result.InstanceCosts.Get(id)
+		if got := cost.MonthlyCost.Float64(); got != want {
+			t.Errorf("%s: monthly = %v, want %v", id, got, want)
+		}
+	}
+
+	south, _ := result.InstanceCosts.Get("south")
+	if south.CoverageType != CoverageTypeSymbolic {
+		t.Errorf("instance in a region without a snapshot should be symbolic, got %s", south.CoverageType)
+	}
+	if len(result.Warnings) != 1 || !result.Degraded {
+		t.Errorf("expected one missing-region warning, got %v", result.Warnings)
+	}
+}
+
+// TestOrchestrationResultAggregates proves totals are summed once across
+// services and confidence is the minimum
+func TestOrchestrationResultAggregates(t *testing.T) {
+	ec2 := cost.NewCostAggregate("ec2", cost.LevelService)
+	ec2.TotalMonthly = determinism.NewMoneyFromFloat(73, "USD")
+	ec2.TotalHourly = determinism.NewMoneyFromFloat(0.1, "USD")
+	ec2.Confidence = 0.9
+
+	rds := cost.NewCostAggregate("rds", cost.LevelService)
+	rds.TotalMonthly = determinism.NewMoneyFromFloat(27, "USD")
+	rds.TotalHourly = determinism.NewMoneyFromFloat(0.04, "USD")
+	rds.Confidence = 0.6
+
+	result := &OrchestrationResult{}
+	result.aggregateServices([]*cost.CostAggregate{ec2, rds})
+
+	if result.TotalMonthly != 100 {
+		t.Errorf("TotalMonthly = %v, want 100", result.TotalMonthly)
+	}
+	if result.Confidence != 0.6 {
+		t.Errorf("Confidence = %v, want 0.6", result.Confidence)
+	}
+	if len(result.ByService) != 2 || result.ByService[0].Service != "ec2" {
+		t.Errorf("unexpected ByService: %+v", result.ByService)
+	}
+}
+
+// TestSnapshotAlias proves the alias reaches the resolver, defaulting to "default"
+func TestSnapshotAlias(t *testing.T) {
+	snapshot := pricing.NewSnapshotBuilder("aws", "us-east-1").
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(0.1), "hour", "USD").
+		Build()
+
+	for _, tt := range []struct{ alias, want string }{
+		{"", DefaultProviderAlias},
+		{"negotiated", "negotiated"},
+	} {
+		resolver := &staticResolver{snapshot: snapshot}
+		eng := NewEngine(resolver, noUsage{}, nil, EngineConfig{})
+		eng.RegisterPlugin(&computePlugin{})
+
+		result, err := eng.Estimate(context.Background(), &EstimateRequest{
+			Graph:           newTestGraph(1),
+			SnapshotRequest: SnapshotRequest{Provider: "aws", Region: "us-east-1", Alias: tt.alias},
+		})
+		if err != nil {
+			t.Fatalf("Estimate: %v", err)
+		}
+		if len(resolver.aliases) == 0 || resolver.aliases[0] != tt.want {
+			t.Errorf("alias %q: resolver saw %v, want %q", tt.alias, resolver.aliases, tt.want)
+		}
+		if result.Snapshot.Alias != tt.want {
+			t.Errorf("alias %q: result alias = %q", tt.alias, result.Snapshot.Alias)
+		}
+	}
+}
+
+func TestGroupByTag(t *testing.T) {
+	costs := determinism.NewStableMap[model.InstanceID, *InstanceCost]()
+	add := func(id string, monthly float64, tags map[string]string) {
+		costs.Set(model.InstanceID(id), &InstanceCost{
+			InstanceID:  model.InstanceID(id),
+			MonthlyCost: determinism.NewMoneyFromFloat(monthly, "USD"),
+			HourlyCost:  determinism.NewMoneyFromFloat(monthly/730, "USD"),
+			Tags:        tags,
+		})
+	}
+	add("a", 10, map[string]string{"team": "payments"})
+	add("b", 30, map[string]string{"team": "search"})
+	add("c", 5, map[string]string{"team": "payments"})
+	add("d", 7, map[string]string{"env": "dev"})
+	add("e", 1, nil)
+
+	groups := (&EstimationResult{InstanceCosts: costs}).GroupByTag("team")
+	want := []struct {
+		value   string
+		monthly float64
+		count   int
+	}{
+		{"search", 30, 1},
+		{"payments", 15, 2},
+		{UntaggedValue, 8, 2},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("got %d groups, want %d", len(groups), len(want))
+	}
+	for i, w := range want {
+		g := groups[i]
+		if g.Value != w.value || g.MonthlyCost.Float64() != w.monthly || g.InstanceCount != w.count {
+			t.Errorf("group %d = %s %.2f x%d, want %s %.2f x%d",
+				i, g.Value, g.MonthlyCost.Float64(), g.InstanceCount, w.value, w.monthly, w.count)
+		}
+	}
+}
+
+func TestTagsFromValue(t *testing.T) {
+	tags := TagsFromValue(map[string]any{"team": "payments", "tier": 2, "empty": nil})
+	if tags["team"] != "payments" || tags["tier"] != "2" {
+		t.Errorf("unexpected tags %v", tags)
+	}
+	if _, ok := tags["empty"]; ok {
+		t.Error("nil tag values should be skipped")
+	}
+	if TagsFromValue("not a map") != nil {
+		t.Error("non-map values should yield no tags")
+	}
+}
+
+func TestAllocationTags(t *testing.T) {
+	tagsAll := map[string]any{"team": "payments", "env": "prod"}
+	tags := map[string]any{"team": "payments"}
+	if got := AllocationTags(tagsAll, tags); got["env"] != "prod" {
+		t.Errorf("tags_all should win over tags, got %v", got)
+	}
+	if got := AllocationTags(nil, tags); got["team"] != "payments" || len(got) != 1 {
+		t.Errorf("absent tags_all should fall back to tags, got %v", got)
+	}
+	if got := AllocationTags(map[string]any{}, nil); got != nil {
+		t.Errorf("no tags should yield nil, got %v", got)
+	}
+}
+
+// stalePlugin was built against an older catalog
+type stalePlugin struct{ computePlugin }
+
+func (p *stalePlugin) CatalogVersion() string { return "0" }
+
+func (p *stalePlugin) SupportedTypes() []string {
+	return []string{"aws_instance", "aws_elastic_transcoder"}
+}
+
+func TestRegisterPluginCompatibility(t *testing.T) {
+	cat := catalog.NewCatalog()
+	cat.Register(catalog.ResourceEntry{Cloud: catalog.AWS, ResourceType: "aws_instance", Tier: catalog.Tier1Numeric, MapperExists: true})
+	cat.Register(catalog.ResourceEntry{Cloud: catalog.AWS, ResourceType: "aws_nat_gateway", Tier: catalog.Tier1Numeric, MapperExists: true})
+	cat.Register(catalog.ResourceEntry{Cloud: catalog.AWS, ResourceType: "aws_ecs_service", Tier: catalog.Tier1Numeric, MapperExists: false})
+	cat.Register(catalog.ResourceEntry{Cloud: catalog.AWS, ResourceType: "aws_vpc", Tier: catalog.Tier3Indirect, Behavior: catalog.CostIndirect})
+
+	eng := NewEngine(&staticResolver{}, noUsage{}, nil, EngineConfig{Catalog: cat})
+	eng.SetLogger(logging.Nop())
+
+	eng.RegisterPlugin(&computePlugin{})
+	compat, ok := eng.PluginCompatibility("aws")
+	if !ok {
+		t.Fatal("registration should record compatibility")
+	}
+	if compat.Compatible() || len(compat.MissingTypes) != 1 || compat.MissingTypes[0] != "aws_nat_gateway" {
+		t.Errorf("expected only aws_nat_gateway missing, got %+v", compat)
+	}
+
+	eng.RegisterPlugin(&stalePlugin{})
+	compat, _ = eng.PluginCompatibility("aws")
+	if !compat.VersionMismatch || len(compat.UnknownTypes) != 1 || compat.UnknownTypes[0] != "aws_elastic_transcoder" {
+		t.Errorf("expected version mismatch and unknown type, got %+v", compat)
+	}
+}
+
+// TestEstimateSymbolicTotals proves placeholder instances are flagged and
+// left out of the firm total but counted in the estimate including them
+func TestEstimateSymbolicTotals(t *testing.T) {
+	eng := newTestEngine(&computePlugin{})
+	graph := newTestGraph(2)
+	graph.AddInstance(&model.AssetInstance{
+		ID:       "inst-placeholder",
+		Address:  `aws_instance.workers["<unknown-0>"]`,
+		Provider: model.ResolvedProvider{Type: "aws", Region: "us-east-1"},
+		Metadata: model.InstanceMetadata{IsPlaceholder: true},
+	})
+
+	result, err := eng.Estimate(context.Background(), &EstimateRequest{Graph: graph})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	placeholder, ok := result.InstanceCosts.Get("inst-placeholder")
+	if !ok || !placeholder.Symbolic {
+		t.Fatal("placeholder instance should be priced and flagged symbolic")
+	}
+	firm := result.TotalMonthlyCost.Sub(placeholder.MonthlyCost)
+	if placeholder.MonthlyCost.IsZero() || result.FirmTotal.Cmp(firm) != 0 {
+		t.Errorf("firm total = %s, want %s", result.FirmTotal, firm)
+	}
+	if result.EstimatedTotalIncludingSymbolic.Cmp(result.TotalMonthlyCost) != 0 {
+		t.Errorf("estimate including symbolic = %s, want %s", result.EstimatedTotalIncludingSymbolic, result.TotalMonthlyCost)
+	}
+}
+
+// TestPricingUnavailable proves a missing snapshot fails with
+// ErrPricingUnavailable by default and prices degraded from the bundled
+// rates when fallback is configured
+func TestPricingUnavailable(t *testing.T) {
+	down := &regionResolver{byRegion: map[string]*pricing.PricingSnapshot{}}
+	bundled := pricing.NewSnapshotBuilder("aws", "").
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(0.1), "hour", "USD").
+		Build()
+	newEngine := func(config EngineConfig) *Engine {
+		eng := NewEngine(down, noUsage{}, nil, config)
+		eng.SetLogger(logging.Nop())
+		eng.RegisterPlugin(&computePlugin{})
+		return eng
+	}
+	req := &EstimateRequest{Graph: newTestGraph(1), SnapshotRequest: SnapshotRequest{Provider: "aws", Region: "us-east-1"}}
+
+	_, err := newEngine(EngineConfig{FallbackSnapshots: StaticFallback(bundled)}).Estimate(context.Background(), req)
+	if !errors.Is(err, ErrPricingUnavailable) || !strings.Contains(err.Error(), "aws/us-east-1") {
+		t.Fatalf("expected ErrPricingUnavailable naming aws/us-east-1, got %v", err)
+	}
+
+	fallback := EngineConfig{PricingUnavailable: PricingUnavailableFallback, FallbackSnapshots: StaticFallback(bundled)}
+	result, err := newEngine(fallback).Estimate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Degraded || result.TotalMonthlyCost.IsZero() || result.Confidence.Score > fallbackPricingConfidence {
+		t.Errorf("expected a degraded, priced, low-confidence result: degraded=%v total=%s confidence=%v",
+			result.Degraded, result.TotalMonthlyCost, result.Confidence.Score)
+	}
+	if len(result.Warnings) == 0 || !strings.Contains(result.Warnings[0], "bundled fallback rates") {
+		t.Errorf("expected a fallback warning, got %v", result.Warnings)
+	}
+
+	// A pinned snapshot is never substituted
+	pinned := *req
+	pinned.SnapshotRequest.SnapshotID = "snap-1"
+	if _, err := newEngine(fallback).Estimate(context.Background(), &pinned); err == nil || errors.Is(err, ErrPricingUnavailable) {
+		t.Errorf("pinned snapshot: expected a snapshot error, got %v", err)
+	}
+
+	gcp := *req
+	gcp.SnapshotRequest.Provider = "gcp"
+	if _, err := newEngine(fallback).Estimate(context.Background(), &gcp); !errors.Is(err, ErrPricingUnavailable) {
+		t.Errorf("no bundled rates for gcp: expected ErrPricingUnavailable, got %v", err)
+	}
+}
+
+// TestEstimateIgnorePatterns proves ignored instances are left out of the
+// totals but priced for what they would cost
+func TestEstimateIgnorePatterns(t *testing.T) {
+	eng := newTestEngine(&computePlugin{})
+	graph := newTestGraph(2)
+	graph.AddInstance(&model.AssetInstance{
+		ID:       "inst-sandbox",
+		Address:  "module.sandbox.aws_instance.web",
+		Provider: model.ResolvedProvider{Type: "aws", Region: "us-east-1"},
+	})
+
+	all, err := eng.Estimate(context.Background(), &EstimateRequest{Graph: graph})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := eng.Estimate(context.Background(), &EstimateRequest{
+		Graph:          graph,
+		IgnorePatterns: []string{"module.sandbox.*", "aws_instance.web[1]"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.InstanceCosts.Len() != 1 || result.IgnoredCount != 2 {
+		t.Fatalf("priced %d instances and ignored %d, want 1 and 2", result.InstanceCosts.Len(), result.IgnoredCount)
+	}
+	if _, ok := result.InstanceCosts.Get("inst-000"); !ok {
+		t.Error("aws_instance.web[0] should be priced")
+	}
+	if got := result.TotalMonthlyCost.Add(result.IgnoredMonthlyCost); got.Cmp(all.TotalMonthlyCost) != 0 {
+		t.Errorf("total %s + ignored %s = %s, want the unfiltered total %s",
+			result.TotalMonthlyCost, result.IgnoredMonthlyCost, got, all.TotalMonthlyCost)
+	}
+	if result.FirmTotal.Cmp(result.TotalMonthlyCost) != 0 {
+		t.Errorf("firm total %s includes ignored instances", result.FirmTotal)
+	}
+}
+
+// guessedUsage marks one instance's compute usage as a low-confidence guess
+type guessedUsage struct{ guessed model.InstanceID }
+
+func (u guessedUsage) Estimate(ctx context.Context, inst *model.AssetInstance) (*UsageResult, error) {
+	metrics := map[string]UsageMetric{}
+	if inst.ID == u.guessed {
+		metrics["compute"] = UsageMetric{Name: "compute", Value: 1, Unit: UnitUptimeFraction, Confidence: 0.3}
+	}
+	return &UsageResult{Metrics: metrics, Confidence: 1.0}, nil
+}
+
+// TestDropBelowConfidence proves components under the floor are listed as
+// uncertain and left out of the firm total, but not out of the total
+func TestDropBelowConfidence(t *testing.T) {
+	snapshot := pricing.NewSnapshotBuilder("aws", "us-east-1").
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(0.1), "hour", "USD").
+		Build()
+	estimate := func(floor float64) *EstimationResult {
+		eng := NewEngine(&staticResolver{snapshot: snapshot}, guessedUsage{guessed: "inst-001"}, nil, EngineConfig{DropBelowConfidence: floor})
+		eng.SetLogger(logging.Nop())
+		eng.RegisterPlugin(&computePlugin{})
+		result, err := eng.Estimate(context.Background(), &EstimateRequest{Graph: newTestGraph(3)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	kept := estimate(0)
+	if len(kept.UncertainComponents) != 0 || kept.FirmTotal.Cmp(kept.TotalMonthlyCost) != 0 {
+		t.Errorf("floor 0 should keep every component: uncertain %d, firm %s of %s",
+			len(kept.UncertainComponents), kept.FirmTotal, kept.TotalMonthlyCost)
+	}
+
+	gated := estimate(0.5)
+	if len(gated.UncertainComponents) != 1 {
+		t.Fatalf("got %d uncertain components, want 1", len(gated.UncertainComponents))
+	}
+	uncertain := gated.UncertainComponents[0]
+	if uncertain.Address != "aws_instance.web[1]" || uncertain.Component.Name != "compute" {
+		t.Errorf("uncertain component = %s %s", uncertain.Address, uncertain.Component.Name)
+	}
+	if gated.UncertainMonthlyCost.Cmp(uncertain.Component.MonthlyCost) != 0 || gated.UncertainMonthlyCost.IsZero() {
+		t.Errorf("uncertain cost = %s, want %s", gated.UncertainMonthlyCost, uncertain.Component.MonthlyCost)
+	}
+	if got := gated.FirmTotal.Add(gated.UncertainMonthlyCost); got.Cmp(gated.TotalMonthlyCost) != 0 {
+		t.Errorf("firm %s + uncertain %s = %s, want the total %s",
+			gated.FirmTotal, gated.UncertainMonthlyCost, got, gated.TotalMonthlyCost)
+	}
+	if gated.TotalMonthlyCost.Cmp(kept.TotalMonthlyCost) != 0 || gated.Confidence.Score != kept.Confidence.Score {
+		t.Error("the gate should change neither the total nor the confidence score")
+	}
+}
+
+// TestEstimateListsSkippedInstances proves an instance that cannot be
+// costed stays in the inventory as unsupported, with the reason
+func TestEstimateListsSkippedInstances(t *testing.T) {
+	eng := newTestEngine(&computePlugin{})
+	graph := newTestGraph(1)
+	graph.AddInstance(&model.AssetInstance{
+		ID:       "inst-gcp",
+		Address:  "google_compute_instance.web",
+		Provider: model.ResolvedProvider{Type: "gcp", Region: "us-central1"},
+	})
+
+	result, err := eng.Estimate(context.Background(), &EstimateRequest{Graph: graph})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.InstanceCosts.Len(); got != 2 {
+		t.Fatalf("listed %d instances, want both", got)
+	}
+	skipped, _ := result.InstanceCosts.Get("inst-gcp")
+	if skipped.CoverageType != CoverageTypeUnsupported || skipped.SkipReason != "no plugin for provider gcp" {
+		t.Errorf("skipped instance: coverage %s, reason %q", skipped.CoverageType, skipped.SkipReason)
+	}
+	if !skipped.MonthlyCost.IsZero() || len(skipped.Components) != 0 {
+		t.Errorf("skipped instance costs %s in %d components", skipped.MonthlyCost, len(skipped.Components))
+	}
+	priced, _ := result.InstanceCosts.Get("inst-000")
+	if result.TotalMonthlyCost.Cmp(priced.MonthlyCost) != 0 {
+		t.Errorf("total %s, want only the priced instance's %s", result.TotalMonthlyCost, priced.MonthlyCost)
+	}
+	if result.CoverageReport.UnsupportedPercent != 50 {
+		t.Errorf("unsupported = %.0f%%, want 50%%", result.CoverageReport.UnsupportedPercent)
+	}
+}
+
+// TestPlaceholderGroups proves the placeholders of one resource are
+// listed once, summing their displayed costs and naming the count range
+func TestPlaceholderGroups(t *testing.T) {
+	eng := newTestEngine(&computePlugin{})
+	graph := newTestGraph(1)
+	for i := 0; i < 3; i++ {
+		graph.AddInstance(&model.AssetInstance{
+			ID:       model.InstanceID(fmt.Sprintf("inst-worker-%d", i)),
+			Address:  model.InstanceAddress(fmt.Sprintf("aws_instance.worker[%d]", i)),
+			Provider: model.ResolvedProvider{Type: "aws", Region: "us-east-1"},
+			Metadata: model.InstanceMetadata{IsPlaceholder: true},
+		})
+	}
+
+	result, err := eng.Estimate(context.Background(), &EstimateRequest{
+		Graph: graph,
+		CardinalityWarnings: []terraform.CardinalityWarning{
+			{Address: "aws_instance.worker", Type: "count", SymbolicRange: &terraform.SymbolicRange{Min: 0, Max: -1}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	groups := result.PlaceholderGroups()
+	if len(groups) != 1 {
+		t.Fatalf("groups = %+v, want one for aws_instance.worker", groups)
+	}
+	g := groups[0]
+	if g.Address != "aws_instance.worker[*]" || g.Placeholders != 3 {
+		t.Errorf("group = %+v", g)
+	}
+	if want := "aws_instance.worker[*] (unknown count, range 0..∞)"; g.Label() != want {
+		t.Errorf("label = %q, want %q", g.Label(), want)
+	}
+
+	one, _ := result.InstanceCosts.Get("inst-worker-0")
+	if want := one.DisplayMonthlyCost().Mul(decimal.NewFromInt(3)); g.MonthlyCost.Cmp(want) != 0 {
+		t.Errorf("group monthly = %s, want %s", g.MonthlyCost, want)
+	}
+	firm := result.DisplayTotalMonthlyCost().Sub(g.MonthlyCost)
+	concrete, _ := result.InstanceCosts.Get("inst-000")
+	if firm.Cmp(concrete.DisplayMonthlyCost()) != 0 {
+		t.Errorf("total %s less group %s should be the concrete instance's %s",
+			result.DisplayTotalMonthlyCost(), g.MonthlyCost, concrete.DisplayMonthlyCost())
+	}
+}
+
+func TestStaticResolverDemoRates(t *testing.T) {
+	demo := pricing.NewSnapshotBuilder("aws", "us-east-1").
+		WithSource(pricing.SourceDemo).
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(0.1), "hour", "USD").
+		Build()
+	resolver := NewStaticResolver(demo)
+	if _, err := resolver.GetSnapshot(context.Background(), SnapshotRequest{Provider: "aws", Region: "eu-west-1"}); !errors.Is(err, ErrPricingUnavailable) {
+		t.Fatalf("unbundled region: got %v, want ErrPricingUnavailable", err)
+	}
+	if s, err := resolver.GetSnapshot(context.Background(), SnapshotRequest{SnapshotID: demo.ID}); err != nil || s != demo {
+		t.Fatalf("by ID: got %v, %v", s, err)
+	}
+
+	eng := NewEngine(resolver, noUsage{}, nil, EngineConfig{})
+	eng.SetLogger(logging.Nop())
+	eng.RegisterPlugin(&computePlugin{})
+	result, err := eng.Estimate(context.Background(), &EstimateRequest{
+		Graph:           newTestGraph(1),
+		SnapshotRequest: SnapshotRequest{Provider: "aws", Region: "us-east-1"},
+	})
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	if result.TotalMonthlyCost.IsZero() || result.Confidence.Score > fallbackPricingConfidence {
+		t.Errorf("expected a priced, low-confidence result: total=%s confidence=%v", result.TotalMonthlyCost, result.Confidence.Score)
+	}
+	if len(result.Warnings) == 0 || !strings.Contains(result.Warnings[0], "demo rates") {
+		t.Errorf("expected a demo rates warning, got %v", result.Warnings)
+	}
+}
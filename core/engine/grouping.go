@@ -0,0 +1,112 @@
+// Package engine - Cost groups
+// A breakdown (by tag, by resource type) is shown beside the total, so
+// its groups must add up to the total as displayed, not merely to within
+// a cent. Two rounding modes keep that invariant:
+//
+// GroupRoundingLineItems (the default) displays each group as the sum of
+// its instances' displayed costs, exactly as the total is built, so a
+// group always matches the rows listed under it.
+//
+// GroupRoundingLargestRemainder rounds the groups' exact costs so they
+// add up to the displayed total by the largest remainder method, so each
+// group is within a cent of its exact cost however many instances it has.
+package engine
+
+import (
+	"sort"
+
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/model"
+)
+
+// GroupRounding is how a cost group's displayed cost is rounded
+type GroupRounding string
+
+const (
+	// GroupRoundingLineItems sums the group's displayed instance costs
+	GroupRoundingLineItems GroupRounding = "line_items"
+
+	// GroupRoundingLargestRemainder apportions the displayed total over
+	// the groups' exact costs
+	GroupRoundingLargestRemainder GroupRounding = "largest_remainder"
+)
+
+// CostGroup is the cost of all instances sharing one value of a grouping
+// dimension. MonthlyCost and HourlyCost are exact; outputs show
+// DisplayMonthlyCost and DisplayHourlyCost, which add up across the
+// groups to the result's displayed totals.
+type CostGroup struct {
+	Value              string
+	MonthlyCost        determinism.Money
+	HourlyCost         determinism.Money
+	DisplayMonthlyCost determinism.Money
+	DisplayHourlyCost  determinism.Money
+	InstanceCount      int
+}
+
+// GroupByResourceType sums instance costs per resource type, ordered like
+// GroupByTag
+func (r *EstimationResult) GroupByResourceType() []CostGroup {
+	return r.groupCosts(func(ic *InstanceCost) string {
+		if ic.ResourceType != "" {
+			return ic.ResourceType
+		}
+		return ResourceTypeFromAddress(ic.Address)
+	})
+}
+
+// groupCosts sums instance costs per value of valueFn and rounds the
+// groups by the result's GroupRounding. Groups are ordered by displayed
+// monthly cost, highest first, then by value.
+func (r *EstimationResult) groupCosts(valueFn func(*InstanceCost) string) []CostGroup {
+	groups := make(map[string]*CostGroup)
+	if r.InstanceCosts != nil {
+		r.InstanceCosts.Range(func(_ model.InstanceID, ic *InstanceCost) bool {
+			value := valueFn(ic)
+			g, ok := groups[value]
+			if !ok {
+				g = &CostGroup{
+					Value:              value,
+					MonthlyCost:        determinism.Zero("USD"),
+					HourlyCost:         determinism.Zero("USD"),
+					DisplayMonthlyCost: determinism.Zero("USD"),
+					DisplayHourlyCost:  determinism.Zero("USD"),
+				}
+				groups[value] = g
+			}
+			g.MonthlyCost = g.MonthlyCost.Add(ic.MonthlyCost)
+			g.HourlyCost = g.HourlyCost.Add(ic.HourlyCost)
+			g.DisplayMonthlyCost = g.DisplayMonthlyCost.Add(ic.DisplayMonthlyCost())
+			g.DisplayHourlyCost = g.DisplayHourlyCost.Add(ic.DisplayHourlyCost())
+			g.InstanceCount++
+			return true
+		})
+	}
+
+	result := make([]CostGroup, 0, len(groups))
+	for _, g := range groups {
+		result = append(result, *g)
+	}
+	// Allocation breaks remainder ties by position, so fix the order first
+	sort.Slice(result, func(i, j int) bool { return result[i].Value < result[j].Value })
+
+	if r.GroupRounding == GroupRoundingLargestRemainder && len(result) > 0 {
+		monthly := make([]determinism.Money, len(result))
+		hourly := make([]determinism.Money, len(result))
+		for i, g := range result {
+			monthly[i] = g.MonthlyCost
+			hourly[i] = g.HourlyCost
+		}
+		monthly = determinism.AllocateRounded(r.DisplayTotalMonthlyCost(), determinism.DisplayPlaces, monthly)
+		hourly = determinism.AllocateRounded(r.DisplayTotalHourlyCost(), determinism.HourlyDisplayPlaces, hourly)
+		for i := range result {
+			result[i].DisplayMonthlyCost = monthly[i]
+			result[i].DisplayHourlyCost = hourly[i]
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].DisplayMonthlyCost.Cmp(result[j].DisplayMonthlyCost) > 0
+	})
+	return result
+}
@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/model"
+)
+
+// randomCosts builds a result of n instances with random sub-cent costs
+// split across components, random tags and random resource types
+func randomCosts(rng *rand.Rand, n int, rounding GroupRounding) *EstimationResult {
+	types := []string{"aws_instance", "aws_s3_bucket", "aws_lambda_function", "aws_db_instance"}
+	teams := []string{"payments", "search", "platform", ""}
+	costs := determinism.NewStableMap[model.InstanceID, *InstanceCost]()
+	monthlyTotal := determinism.Zero("USD")
+	hourlyTotal := determinism.Zero("USD")
+	for i := 0; i < n; i++ {
+		resourceType := types[rng.Intn(len(types))]
+		ic := &InstanceCost{
+			InstanceID:   model.InstanceID(fmt.Sprintf("i%03d", i)),
+			Address:      model.InstanceAddress(fmt.Sprintf("%s.r%d", resourceType, i)),
+			ResourceType: resourceType,
+			MonthlyCost:  determinism.Zero("USD"),
+			HourlyCost:   determinism.Zero("USD"),
+			Tags:         map[string]string{"team": teams[rng.Intn(len(teams))], "env": fmt.Sprintf("env%d", rng.Intn(3))},
+		}
+		for c := rng.Intn(4); c >= 0; c-- {
+			// Thousandths of a cent, so nearly every component rounds
+			monthly := determinism.NewMoneyFromFloat(float64(rng.Intn(5000000))/100000, "USD")
+			hourly := monthly.Div(decimal.NewFromInt(730))
+			ic.Components = append(ic.Components, &ComponentCost{Name: fmt.Sprintf("c%d", c), MonthlyCost: monthly, HourlyCost: hourly})
+			ic.MonthlyCost = ic.MonthlyCost.Add(monthly)
+			ic.HourlyCost = ic.HourlyCost.Add(hourly)
+		}
+		costs.Set(ic.InstanceID, ic)
+		monthlyTotal = monthlyTotal.Add(ic.MonthlyCost)
+		hourlyTotal = hourlyTotal.Add(ic.HourlyCost)
+	}
+	return &EstimationResult{
+		InstanceCosts:    costs,
+		TotalMonthlyCost: monthlyTotal,
+		TotalHourlyCost:  hourlyTotal,
+		GroupRounding:    rounding,
+	}
+}
+
+// TestGroupTotalsSumToTotal proves that, for random cost sets and every
+// rounding mode, the displayed groups of every grouping dimension add up
+// exactly to the displayed totals
+func TestGroupTotalsSumToTotal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1428))
+	for iter := 0; iter < 200; iter++ {
+		n := 1 + rng.Intn(60)
+		for _, rounding := range []GroupRounding{"", GroupRoundingLineItems, GroupRoundingLargestRemainder} {
+			r := randomCosts(rng, n, rounding)
+			wantMonthly := r.DisplayTotalMonthlyCost()
+			wantHourly := r.DisplayTotalHourlyCost()
+
+			for name, groups := range map[string][]CostGroup{
+				"tag team":      r.GroupByTag("team"),
+				"tag env":       r.GroupByTag("env"),
+				"tag missing":   r.GroupByTag("owner"),
+				"resource type": r.GroupByResourceType(),
+			} {
+				monthly := determinism.Zero("USD")
+				hourly := determinism.Zero("USD")
+				count := 0
+				for _, g := range groups {
+					monthly = monthly.Add(g.DisplayMonthlyCost)
+					hourly = hourly.Add(g.DisplayHourlyCost)
+					count += g.InstanceCount
+				}
+				if monthly.Cmp(wantMonthly) != 0 || hourly.Cmp(wantHourly) != 0 {
+					t.Fatalf("iter %d, %q rounding, %s: groups sum to %s / %s, totals are %s / %s",
+						iter, rounding, name, monthly.StringRaw(), hourly.StringRaw(), wantMonthly.StringRaw(), wantHourly.StringRaw())
+				}
+				if count != n {
+					t.Fatalf("iter %d, %s: groups hold %d instances, want %d", iter, name, count, n)
+				}
+			}
+		}
+	}
+}
+
+// TestGroupRoundingModes proves line-item groups match their displayed
+// rows while largest-remainder groups track their exact costs
+func TestGroupRoundingModes(t *testing.T) {
+	costs := determinism.NewStableMap[model.InstanceID, *InstanceCost]()
+	add := func(id, resourceType string, monthly float64) {
+		costs.Set(model.InstanceID(id), &InstanceCost{
+			InstanceID:   model.InstanceID(id),
+			ResourceType: resourceType,
+			MonthlyCost:  determinism.NewMoneyFromFloat(monthly, "USD"),
+			HourlyCost:   determinism.Zero("USD"),
+		})
+	}
+	// Each queue displays $0.00, but the three cost $0.012
+	add("a", "aws_sqs_queue", 0.004)
+	add("b", "aws_sqs_queue", 0.004)
+	add("c", "aws_sqs_queue", 0.004)
+	add("d", "aws_instance", 1)
+	r := &EstimationResult{
+		InstanceCosts:    costs,
+		TotalMonthlyCost: determinism.NewMoneyFromFloat(1.012, "USD"),
+		TotalHourlyCost:  determinism.Zero("USD"),
+	}
+
+	for _, tc := range []struct {
+		rounding        GroupRounding
+		queues, servers string
+	}{
+		{GroupRoundingLineItems, "0.00 USD", "1.00 USD"},
+		// The displayed total is $1.00, so the queues' cent comes from
+		// the instance group
+		{GroupRoundingLargestRemainder, "0.01 USD", "0.99 USD"},
+	} {
+		r.GroupRounding = tc.rounding
+		got := map[string]string{}
+		for _, g := range r.GroupByResourceType() {
+			got[g.Value] = g.DisplayMonthlyCost.String()
+		}
+		if got["aws_sqs_queue"] != tc.queues || got["aws_instance"] != tc.servers {
+			t.Errorf("%s: groups = %v, want queues %s and instances %s", tc.rounding, got, tc.queues, tc.servers)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Package engine - Graph size limits
+// A plan with millions of resources, by accident or on purpose, would
+// exhaust memory and CPU in Estimate. Graphs over the configured limits are
+// refused up front with ErrGraphTooLarge.
+package engine
+
+import (
+	"errors"
+	"fmt"
+
+	"terraform-cost/core/model"
+)
+
+const (
+	// DefaultMaxInstances is the instance limit when MaxInstances is 0
+	DefaultMaxInstances = 500_000
+
+	// DefaultMaxGraphEdges is the edge limit when MaxGraphEdges is 0
+	DefaultMaxGraphEdges = 2_000_000
+)
+
+// ErrGraphTooLarge is returned when a graph exceeds MaxInstances or
+// MaxGraphEdges
+var ErrGraphTooLarge = errors.New("instance graph too large")
+
+// CheckGraphLimits returns ErrGraphTooLarge when graph exceeds the
+// configured limits. Estimate calls it; adapters that respond before
+// estimating (streaming) call it first.
+func (e *Engine) CheckGraphLimits(graph *model.InstanceGraph) error {
+	if max := graphLimit(e.config.MaxInstances, DefaultMaxInstances); max >= 0 && graph.Size() > max {
+		return fmt.Errorf("%w: %d instances (max %d)", ErrGraphTooLarge, graph.Size(), max)
+	}
+	if max := graphLimit(e.config.MaxGraphEdges, DefaultMaxGraphEdges); max >= 0 && graph.EdgeCount() > max {
+		return fmt.Errorf("%w: %d dependency edges (max %d)", ErrGraphTooLarge, graph.EdgeCount(), max)
+	}
+	return nil
+}
+
+// graphLimit resolves a configured limit: 0 is the default, negative is none
+func graphLimit(configured, def int) int {
+	if configured == 0 {
+		return def
+	}
+	return configured
+}
@@ -10,8 +10,11 @@ package engine
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/shopspring/decimal"
+
 	"terraform-cost/core/cost"
 	"terraform-cost/core/graph"
 	"terraform-cost/core/model"
@@ -138,7 +141,11 @@ func (o *AuthoritativeOrchestrator) FreezeProviders(ctx context.Context, provide
 	}
 
 	for _, p := range providers {
-		if _, err := o.providerFinal.Freeze(p); err != nil {
+		// Freeze under the cloud name so resources bind and plugins match
+		// whichever provider family they come from
+		normalized := *p
+		normalized.ProviderType = normalizeProviderType(p.ProviderType)
+		if _, err := o.providerFinal.Freeze(&normalized); err != nil {
 			o.recordError(PhaseProvidersFrozen, "failed to freeze provider", err, true)
 			return err
 		}
@@ -163,25 +170,23 @@ func (o *AuthoritativeOrchestrator) ExpandAssets(ctx context.Context, definition
 		return fmt.Errorf("cannot expand assets: providers not finalized")
 	}
 
+	// Report every missing provider at once; permissive mode records them
+	// and continues with unbound assets
+	if err := o.ValidateProviderCoverage(definitions); err != nil {
+		fatal := o.mode == terraform.ModeStrict
+		o.recordError(PhaseExpanded, "missing frozen providers", err, fatal)
+		if fatal {
+			return err
+		}
+	}
+
 	o.assetGraph = NewAssetGraph()
 	forEachEval := terraform.NewSafeForEachEvaluator(o.mode)
 	countEval := terraform.NewSafeCountEvaluator(o.mode)
 
 	for _, def := range definitions {
-		// Get frozen provider
-		providerKey := def.Provider
-		if providerKey == "" {
-			providerKey = extractProviderType(def.Type)
-		}
-
-		frozenProvider, ok := o.providerFinal.Get(providerKey)
-		if !ok {
-			if o.mode == terraform.ModeStrict {
-				o.recordError(PhaseExpanded, "no frozen provider for "+def.Address, nil, true)
-				return fmt.Errorf("no frozen provider for %s", def.Address)
-			}
-			// Use default in permissive mode
-		}
+		// Get frozen provider (nil if missing, already reported above)
+		frozenProvider, _ := o.providerFinal.Get(definitionProviderKey(def))
 
 		// Handle for_each
 		if def.ForEach != nil {
@@ -196,11 +201,15 @@ func (o *AuthoritativeOrchestrator) ExpandAssets(ctx context.Context, definition
 			if result.IsKnown {
 				// Expand with known keys
 				for _, key := range result.Keys {
-					o.addAssetInstance(def, key, frozenProvider)
+					if err := o.addAssetInstance(def, key, frozenProvider); err != nil {
+						return err
+					}
 				}
 			} else {
 				// DO NOT EXPAND - add symbolic placeholder
-				o.addSymbolicAsset(def, result.SymbolicRange, frozenProvider)
+				if err := o.addSymbolicAsset(def, result.SymbolicRange, frozenProvider); err != nil {
+					return err
+				}
 			}
 			continue
 		}
@@ -217,24 +226,30 @@ func (o *AuthoritativeOrchestrator) ExpandAssets(ctx context.Context, definition
 
 			if result.IsKnown {
 				for i := 0; i < result.Value; i++ {
-					o.addAssetInstance(def, i, frozenProvider)
+					if err := o.addAssetInstance(def, i, frozenProvider); err != nil {
+						return err
+					}
 				}
 			} else {
 				// DO NOT EXPAND - add symbolic placeholder
-				o.addSymbolicAsset(def, result.SymbolicRange, frozenProvider)
+				if err := o.addSymbolicAsset(def, result.SymbolicRange, frozenProvider); err != nil {
+					return err
+				}
 			}
 			continue
 		}
 
 		// Single instance
-		o.addAssetInstance(def, nil, frozenProvider)
+		if err := o.addAssetInstance(def, nil, frozenProvider); err != nil {
+			return err
+		}
 	}
 
 	o.phase = PhaseExpanded
 	return nil
 }
 
-func (o *AuthoritativeOrchestrator) addAssetInstance(def *terraform.ResourceDefinition, key interface{}, provider *terraform.FrozenProviderContext) {
+func (o *AuthoritativeOrchestrator) addAssetInstance(def *terraform.ResourceDefinition, key interface{}, provider *terraform.FrozenProviderContext) error {
 	address := def.Address
 	if key != nil {
 		switch k := key.(type) {
@@ -255,15 +270,18 @@ func (o *AuthoritativeOrchestrator) addAssetInstance(def *terraform.ResourceDefi
 		IsSymbolic:   false,
 	}
 
-	o.assetGraph.AddInstance(asset)
+	if err := o.addToAssetGraph(asset); err != nil {
+		return err
+	}
 
 	// Bind to provider
 	if provider != nil {
 		o.bindingRegistry.Bind(address, key, provider)
 	}
+	return nil
 }
 
-func (o *AuthoritativeOrchestrator) addSymbolicAsset(def *terraform.ResourceDefinition, symRange *terraform.SymbolicRange, provider *terraform.FrozenProviderContext) {
+func (o *AuthoritativeOrchestrator) addSymbolicAsset(def *terraform.ResourceDefinition, symRange *terraform.SymbolicRange, provider *terraform.FrozenProviderContext) error {
 	asset := &AssetInstance{
 		ID:            model.InstanceID(def.Address + "[*]"),
 		Address:       model.InstanceAddress(def.Address + "[*]"),
@@ -274,7 +292,17 @@ func (o *AuthoritativeOrchestrator) addSymbolicAsset(def *terraform.ResourceDefi
 		SymbolicRange: symRange,
 	}
 
-	o.assetGraph.AddInstance(asset)
+	return o.addToAssetGraph(asset)
+}
+
+// addToAssetGraph adds an expanded asset, recording an ID collision as a
+// fatal expansion error
+func (o *AuthoritativeOrchestrator) addToAssetGraph(asset *AssetInstance) error {
+	if err := o.assetGraph.AddInstance(asset); err != nil {
+		o.recordError(PhaseExpanded, "instance ID collision", err, true)
+		return err
+	}
+	return nil
 }
 
 // CalculateCosts calculates costs from expanded assets
@@ -379,14 +407,42 @@ func (o *AuthoritativeOrchestrator) GetPhase() OrchestrationPhase {
 	return o.phase
 }
 
+// providerTypeAliases maps Terraform provider names to the cloud names
+// plugins register under
+var providerTypeAliases = map[string]string{
+	"azurerm":     "azure",
+	"google":      "gcp",
+	"google-beta": "gcp",
+}
+
+// normalizeProviderType returns the cloud name for a Terraform provider
+// name (azurerm → azure, google → gcp); other names are unchanged
+func normalizeProviderType(providerType string) string {
+	if cloud, ok := providerTypeAliases[providerType]; ok {
+		return cloud
+	}
+	return providerType
+}
+
+// normalizeProviderKey normalizes the type part of a provider key,
+// keeping any alias (azurerm.west → azure.west)
+func normalizeProviderKey(key string) string {
+	providerType, alias, found := strings.Cut(key, ".")
+	if !found {
+		return normalizeProviderType(key)
+	}
+	return normalizeProviderType(providerType) + "." + alias
+}
+
 func extractProviderType(resourceType string) string {
-	// aws_instance → aws
+	// aws_instance → aws, azurerm_linux_virtual_machine → azure,
+	// google_compute_instance → gcp
 	for i, c := range resourceType {
 		if c == '_' {
-			return resourceType[:i]
+			return normalizeProviderType(resourceType[:i])
 		}
 	}
-	return resourceType
+	return normalizeProviderType(resourceType)
 }
 
 // AssetGraph holds expanded asset instances
@@ -403,10 +459,19 @@ func NewAssetGraph() *AssetGraph {
 	}
 }
 
-// AddInstance adds an instance
-func (g *AssetGraph) AddInstance(inst *AssetInstance) {
+// AddInstance adds an instance. An ID already held by another address is
+// rejected; re-adding an address replaces it in place.
+func (g *AssetGraph) AddInstance(inst *AssetInstance) error {
+	if existing, ok := g.instances[inst.ID]; ok {
+		if existing.Address != inst.Address {
+			return fmt.Errorf("%w: %s and %s both have ID %s", model.ErrInstanceIDCollision, existing.Address, inst.Address, inst.ID)
+		}
+		g.instances[inst.ID] = inst
+		return nil
+	}
 	g.instances[inst.ID] = inst
 	g.order = append(g.order, inst.ID)
+	return nil
 }
 
 // GetInstance returns an instance
@@ -463,6 +528,7 @@ type OrchestrationResult struct {
 	TotalMonthly      float64
 	TotalHourly       float64
 	Confidence        float64
+	ByService         []ServiceCost
 	ResourceCount     int
 	SymbolicCount     int
 	CardinalityWarns  []terraform.CardinalityWarning
@@ -487,7 +553,50 @@ func NewOrchestrationResult(o *AuthoritativeOrchestrator, duration time.Duration
 	}
 
 	// Calculate aggregates from cost graph
-	// No field access needed here
+	if result.CostGraph != nil {
+		result.aggregateServices(result.CostGraph.GetServiceAggregates())
+	}
 
 	return result
 }
+
+// ServiceCost is one service's share of the orchestrated estimate
+type ServiceCost struct {
+	Service       string
+	TotalMonthly  float64
+	TotalHourly   float64
+	Confidence    float64
+	ResourceCount int
+}
+
+// aggregateServices fills totals and the per-service breakdown.
+// Confidence is the minimum across services, matching cost.CostAggregate.
+func (r *OrchestrationResult) aggregateServices(services []*cost.CostAggregate) {
+	if len(services) == 0 {
+		return
+	}
+
+	// Sum amounts rather than Money: an empty aggregate has no currency yet
+	monthly := decimal.Zero
+	hourly := decimal.Zero
+	r.Confidence = 1.0
+	r.ByService = make([]ServiceCost, 0, len(services))
+
+	for _, agg := range services {
+		monthly = monthly.Add(agg.TotalMonthly.Amount())
+		hourly = hourly.Add(agg.TotalHourly.Amount())
+		if agg.Confidence < r.Confidence {
+			r.Confidence = agg.Confidence
+		}
+		r.ByService = append(r.ByService, ServiceCost{
+			Service:       agg.Name,
+			TotalMonthly:  agg.TotalMonthly.Float64(),
+			TotalHourly:   agg.TotalHourly.Float64(),
+			Confidence:    agg.Confidence,
+			ResourceCount: len(agg.Nodes),
+		})
+	}
+
+	r.TotalMonthly = monthly.InexactFloat64()
+	r.TotalHourly = hourly.InexactFloat64()
+}
@@ -0,0 +1,95 @@
+// Package engine - Plugin compatibility
+// A plugin built against an older catalog can silently lack mappers for
+// types the catalog now prices numerically; checking at registration
+// surfaces the gap at startup instead of as symbolic costs per estimate.
+package engine
+
+import (
+	"sort"
+
+	"terraform-cost/core/catalog"
+	"terraform-cost/internal/logging"
+)
+
+// PluginCompatibility is the result of checking a plugin against the catalog
+type PluginCompatibility struct {
+	Provider string
+
+	// CatalogVersion is the catalog version the plugin was built against
+	CatalogVersion string
+
+	// VersionMismatch is set when CatalogVersion differs from catalog.Version
+	VersionMismatch bool
+
+	// MissingTypes are Tier1 catalog types with no mapper in the plugin
+	MissingTypes []string
+
+	// UnknownTypes are plugin types the catalog does not know
+	UnknownTypes []string
+}
+
+// Compatible reports whether the plugin covers the catalog for its provider
+func (c *PluginCompatibility) Compatible() bool {
+	return !c.VersionMismatch && len(c.MissingTypes) == 0
+}
+
+// CheckPluginCompatibility compares a plugin's supported types with the
+// Tier1 types the catalog expects a mapper for
+func CheckPluginCompatibility(plugin CloudPlugin, cat *catalog.Catalog) *PluginCompatibility {
+	provider := plugin.Provider()
+	result := &PluginCompatibility{
+		Provider:        provider,
+		CatalogVersion:  plugin.CatalogVersion(),
+		VersionMismatch: plugin.CatalogVersion() != catalog.Version,
+	}
+
+	supported := make(map[string]bool)
+	for _, t := range plugin.SupportedTypes() {
+		supported[t] = true
+		if _, ok := cat.Get(catalog.CloudProvider(provider), t); !ok {
+			result.UnknownTypes = append(result.UnknownTypes, t)
+		}
+	}
+	sort.Strings(result.UnknownTypes)
+
+	for _, t := range cat.ListMapped(catalog.CloudProvider(provider), catalog.Tier1Numeric) {
+		if !supported[t] {
+			result.MissingTypes = append(result.MissingTypes, t)
+		}
+	}
+	return result
+}
+
+// PluginCompatibility returns the registration check for a provider
+func (e *Engine) PluginCompatibility(provider string) (*PluginCompatibility, bool) {
+	c, ok := e.pluginCompat[provider]
+	return c, ok
+}
+
+// SetLogger sets the logger used for registration warnings
+func (e *Engine) SetLogger(logger logging.LeveledLogger) {
+	if logger == nil {
+		logger = logging.Nop()
+	}
+	e.logger = logger
+}
+
+// logCompatibility warns about catalog gaps found at registration
+func (e *Engine) logCompatibility(c *PluginCompatibility) {
+	if c.VersionMismatch {
+		e.logger.Warn("cloud plugin built against a different catalog version",
+			logging.String("provider", c.Provider),
+			logging.String("plugin_catalog_version", c.CatalogVersion),
+			logging.String("catalog_version", catalog.Version))
+	}
+	if len(c.MissingTypes) > 0 {
+		e.logger.Warn("cloud plugin has no mapper for Tier1 catalog types; they will be unpriced",
+			logging.String("provider", c.Provider),
+			logging.Strings("missing_types", c.MissingTypes))
+	}
+	if len(c.UnknownTypes) > 0 {
+		e.logger.Debug("cloud plugin maps types not in the catalog",
+			logging.String("provider", c.Provider),
+			logging.Strings("unknown_types", c.UnknownTypes))
+	}
+}
@@ -0,0 +1,90 @@
+// Package engine - Pricing unavailable
+// Estimate needs a pricing snapshot. When none can be resolved, e.g. the
+// pricing database is down or was never configured, EngineConfig.
+// PricingUnavailable decides what happens:
+//
+//   - PricingUnavailableFail (default): Estimate returns an error wrapping
+//     ErrPricingUnavailable that names the provider/region and the cause;
+//     the HTTP adapter answers 503.
+//   - PricingUnavailableFallback: the estimate is priced from the rate set
+//     EngineConfig.FallbackSnapshots bundles for the provider/region,
+//     marked Degraded, warned about and at reduced confidence. Without a
+//     bundled rate set for the provider/region it fails as above. Other
+//     regions of the estimate fall back the same way.
+//
+// A request pinned to a SnapshotID always fails: pricing it from other
+// rates would break its reproducibility.
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"terraform-cost/core/pricing"
+)
+
+// PricingUnavailableBehavior is what Estimate does without a snapshot
+type PricingUnavailableBehavior string
+
+const (
+	// PricingUnavailableFail returns ErrPricingUnavailable
+	PricingUnavailableFail PricingUnavailableBehavior = "fail"
+
+	// PricingUnavailableFallback prices from the bundled rate set
+	PricingUnavailableFallback PricingUnavailableBehavior = "fallback"
+)
+
+// ErrPricingUnavailable is returned when no pricing snapshot can be
+// resolved and no fallback applies
+var ErrPricingUnavailable = errors.New("pricing data unavailable")
+
+// fallbackPricingConfidence scales overall confidence for estimates
+// priced from the bundled rate set or the demo rates (snapshots with
+// pricing.SourceDemo)
+const fallbackPricingConfidence = 0.5
+
+// FallbackSnapshotFunc returns the bundled rate set for a provider/region,
+// or nil when there is none
+type FallbackSnapshotFunc func(provider, region string) *pricing.PricingSnapshot
+
+// StaticFallback bundles fixed snapshots. A snapshot without a region
+// serves every region of its provider not bundled explicitly.
+func StaticFallback(snapshots ...*pricing.PricingSnapshot) FallbackSnapshotFunc {
+	return func(provider, region string) *pricing.PricingSnapshot {
+		var anyRegion *pricing.PricingSnapshot
+		for _, s := range snapshots {
+			if s.Provider != provider {
+				continue
+			}
+			if s.Region == region {
+				return s
+			}
+			if s.Region == "" && anyRegion == nil {
+				anyRegion = s
+			}
+		}
+		return anyRegion
+	}
+}
+
+// pricingUnavailable handles a failed snapshot lookup: it returns the
+// bundled snapshot to price from, or the error to fail with
+func (e *Engine) pricingUnavailable(ctx context.Context, req SnapshotRequest, cause error) (*pricing.PricingSnapshot, error) {
+	if req.SnapshotID != "" || ctx.Err() != nil {
+		return nil, fmt.Errorf("failed to get pricing snapshot: %w", cause)
+	}
+	if snapshot := e.bundledSnapshot(req.Provider, req.Region); snapshot != nil {
+		return snapshot, nil
+	}
+	return nil, fmt.Errorf("%w for %s/%s: %v", ErrPricingUnavailable, req.Provider, req.Region, cause)
+}
+
+// bundledSnapshot is the bundled rate set to price from when fallback is
+// enabled, or nil
+func (e *Engine) bundledSnapshot(provider, region string) *pricing.PricingSnapshot {
+	if e.config.PricingUnavailable != PricingUnavailableFallback || e.config.FallbackSnapshots == nil {
+		return nil
+	}
+	return e.config.FallbackSnapshots(provider, region)
+}
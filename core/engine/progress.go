@@ -0,0 +1,48 @@
+// Package engine - Estimation progress
+// Pricing a very large graph takes minutes. EstimateRequest.OnProgress
+// receives updates as instances are priced so callers can render a
+// progress bar or report percent-complete.
+package engine
+
+// ProgressPhasePricing is the phase reported while instances are priced
+const ProgressPhasePricing = "pricing"
+
+// progressUpdates is roughly how many updates a run reports, so a large
+// graph does not call the reporter once per instance
+const progressUpdates = 100
+
+// ProgressReporter receives progress: the phase, how many of total items
+// in it are complete, and a message. Estimate calls it from the goroutine
+// running Estimate, never concurrently.
+type ProgressReporter func(phase string, completed, total int, message string)
+
+// progressTracker throttles updates to a reporter; a nil reporter makes
+// every call a no-op
+type progressTracker struct {
+	report ProgressReporter
+	phase  string
+	total  int
+	step   int
+	next   int
+}
+
+func newProgressTracker(report ProgressReporter, phase string, total int) *progressTracker {
+	step := total / progressUpdates
+	if step < 1 {
+		step = 1
+	}
+	return &progressTracker{report: report, phase: phase, total: total, step: step}
+}
+
+// update reports completed items when a step has passed since the last
+// report, and always at the start and the end
+func (p *progressTracker) update(completed int, message string) {
+	if p.report == nil {
+		return
+	}
+	if completed < p.next && completed != p.total {
+		return
+	}
+	p.next = completed + p.step
+	p.report(p.phase, completed, p.total, message)
+}
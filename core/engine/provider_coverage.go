@@ -0,0 +1,70 @@
+// Package engine - Frozen provider coverage
+// Every provider a resource references must have been frozen before
+// expansion. All gaps are reported together so users can fix their
+// provider blocks in one pass instead of one failure at a time.
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"terraform-cost/core/terraform"
+)
+
+// MissingProvidersError lists provider keys referenced by resources that
+// have no frozen provider
+type MissingProvidersError struct {
+	// Missing maps each provider key (e.g. aws.west) to the addresses of
+	// the resources that reference it
+	Missing map[string][]string
+}
+
+// Keys returns the missing provider keys in sorted order
+func (e *MissingProvidersError) Keys() []string {
+	keys := make([]string, 0, len(e.Missing))
+	for key := range e.Missing {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (e *MissingProvidersError) Error() string {
+	keys := e.Keys()
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s (referenced by %s)", key, strings.Join(e.Missing[key], ", ")))
+	}
+	return fmt.Sprintf("no frozen provider for %d provider(s): %s", len(keys), strings.Join(parts, "; "))
+}
+
+// ValidateProviderCoverage checks that every provider referenced by the
+// definitions has been frozen. It returns a *MissingProvidersError naming
+// all missing providers, or nil.
+func (o *AuthoritativeOrchestrator) ValidateProviderCoverage(definitions []*terraform.ResourceDefinition) error {
+	missing := make(map[string][]string)
+	for _, def := range definitions {
+		key := definitionProviderKey(def)
+		if _, ok := o.providerFinal.Get(key); !ok {
+			missing[key] = append(missing[key], def.Address)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	for _, addresses := range missing {
+		sort.Strings(addresses)
+	}
+	return &MissingProvidersError{Missing: missing}
+}
+
+// definitionProviderKey returns the frozen provider key a definition binds
+// to: its explicit provider, or the default provider of its resource type,
+// with the provider type normalized to its cloud name
+func definitionProviderKey(def *terraform.ResourceDefinition) string {
+	if def.Provider != "" {
+		return normalizeProviderKey(def.Provider)
+	}
+	return extractProviderType(def.Type)
+}
@@ -0,0 +1,124 @@
+// Package engine - Frozen provider coverage tests
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"terraform-cost/core/model"
+	"terraform-cost/core/terraform"
+)
+
+// frozenOrchestrator returns an orchestrator with the given providers frozen
+func frozenOrchestrator(t *testing.T, mode terraform.EvaluationMode, providers ...*terraform.ProviderContext) *AuthoritativeOrchestrator {
+	t.Helper()
+	o := NewAuthoritativeOrchestrator(mode)
+	o.phase = PhaseGraphBuilt
+	if err := o.FreezeProviders(context.Background(), providers); err != nil {
+		t.Fatalf("FreezeProviders: %v", err)
+	}
+	return o
+}
+
+func TestExpandAssetsReportsAllMissingProviders(t *testing.T) {
+	definitions := []*terraform.ResourceDefinition{
+		{Address: "aws_instance.default", Type: "aws_instance"},
+		{Address: "aws_instance.west", Type: "aws_instance", Provider: "aws.west"},
+		{Address: "aws_s3_bucket.west", Type: "aws_s3_bucket", Provider: "aws.west"},
+		{Address: "aws_instance.east", Type: "aws_instance", Provider: "aws.east"},
+	}
+	providers := []*terraform.ProviderContext{{ProviderType: "aws", Region: "us-east-1"}}
+
+	o := frozenOrchestrator(t, terraform.ModeStrict, providers...)
+	err := o.ExpandAssets(context.Background(), definitions)
+
+	var missing *MissingProvidersError
+	if !errors.As(err, &missing) {
+		t.Fatalf("expected MissingProvidersError, got %v", err)
+	}
+	if got := strings.Join(missing.Keys(), ","); got != "aws.east,aws.west" {
+		t.Errorf("missing keys = %s, want aws.east,aws.west", got)
+	}
+	if got := strings.Join(missing.Missing["aws.west"], ","); got != "aws_instance.west,aws_s3_bucket.west" {
+		t.Errorf("aws.west referenced by %s", got)
+	}
+	if o.GetPhase() != PhaseProvidersFrozen {
+		t.Errorf("phase advanced to %s", o.GetPhase())
+	}
+
+	// Permissive mode records the same error and expands anyway
+	o = frozenOrchestrator(t, terraform.ModePermissive, providers...)
+	if err := o.ExpandAssets(context.Background(), definitions); err != nil {
+		t.Fatalf("permissive ExpandAssets: %v", err)
+	}
+	errs := o.GetErrors()
+	if len(errs) != 1 || errs[0].Fatal || !errors.As(errs[0].Cause, &missing) {
+		t.Fatalf("expected one non-fatal missing provider error, got %+v", errs)
+	}
+	if len(o.GetAssetGraph().instances) != len(definitions) {
+		t.Errorf("expanded %d assets, want %d", len(o.GetAssetGraph().instances), len(definitions))
+	}
+}
+
+func TestValidateProviderCoverageAliased(t *testing.T) {
+	o := frozenOrchestrator(t, terraform.ModeStrict,
+		&terraform.ProviderContext{ProviderType: "aws", Region: "us-east-1"},
+		&terraform.ProviderContext{ProviderType: "aws", Alias: "west", Region: "us-west-2"},
+	)
+	definitions := []*terraform.ResourceDefinition{
+		{Address: "aws_instance.default", Type: "aws_instance"},
+		{Address: "aws_instance.west", Type: "aws_instance", Provider: "aws.west"},
+	}
+	if err := o.ValidateProviderCoverage(definitions); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestExtractProviderTypeNormalizesFamilies(t *testing.T) {
+	for resourceType, want := range map[string]string{
+		"aws_instance":                  "aws",
+		"azurerm_linux_virtual_machine": "azure",
+		"google_compute_instance":       "gcp",
+		"kubernetes_deployment":         "kubernetes",
+	} {
+		if got := extractProviderType(resourceType); got != want {
+			t.Errorf("extractProviderType(%s) = %s, want %s", resourceType, got, want)
+		}
+	}
+}
+
+func TestProviderFamiliesBindToFrozenProviders(t *testing.T) {
+	o := frozenOrchestrator(t, terraform.ModeStrict,
+		&terraform.ProviderContext{ProviderType: "aws", Region: "us-east-1"},
+		&terraform.ProviderContext{ProviderType: "azurerm", Region: "westeurope"},
+		&terraform.ProviderContext{ProviderType: "azurerm", Alias: "east", Region: "eastus"},
+		&terraform.ProviderContext{ProviderType: "google", Region: "us-central1"},
+	)
+	definitions := []*terraform.ResourceDefinition{
+		{Address: "aws_instance.web", Type: "aws_instance"},
+		{Address: "azurerm_linux_virtual_machine.app", Type: "azurerm_linux_virtual_machine"},
+		{Address: "azurerm_linux_virtual_machine.east", Type: "azurerm_linux_virtual_machine", Provider: "azurerm.east"},
+		{Address: "google_compute_instance.worker", Type: "google_compute_instance"},
+	}
+	if err := o.ExpandAssets(context.Background(), definitions); err != nil {
+		t.Fatalf("ExpandAssets: %v", err)
+	}
+
+	want := map[model.InstanceAddress]string{
+		"aws_instance.web":                   "aws/us-east-1",
+		"azurerm_linux_virtual_machine.app":  "azure/westeurope",
+		"azurerm_linux_virtual_machine.east": "azure/eastus",
+		"google_compute_instance.worker":     "gcp/us-central1",
+	}
+	for _, inst := range o.GetAssetGraph().instances {
+		if inst.Provider == nil {
+			t.Errorf("%s has no provider", inst.Address)
+			continue
+		}
+		if got := inst.Provider.Type + "/" + inst.Provider.Region; got != want[inst.Address] {
+			t.Errorf("%s bound to %s, want %s", inst.Address, got, want[inst.Address])
+		}
+	}
+}
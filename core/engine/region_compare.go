@@ -0,0 +1,130 @@
+// Package engine - Region comparison
+// Choosing a region means pricing the same graph in several. Each region is
+// estimated from its own snapshot with the region overriding the instances'
+// provider regions; a region without a snapshot is reported and skipped
+// rather than failing the comparison.
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"terraform-cost/core/cost"
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/model"
+)
+
+// RegionComparison is the same graph priced in several regions
+type RegionComparison struct {
+	// Regions holds one estimate per priced region, in request order
+	Regions []*RegionEstimate
+
+	// Warnings names the regions that could not be priced
+	Warnings []string
+}
+
+// RegionEstimate is the estimate for one region
+type RegionEstimate struct {
+	Region string
+	Result *EstimationResult
+
+	// ByService sums displayed monthly costs per service
+	// (cost.ServiceForResourceType)
+	ByService map[string]determinism.Money
+}
+
+// Services returns every service priced in any region, sorted
+func (c *RegionComparison) Services() []string {
+	byService := make([]map[string]determinism.Money, len(c.Regions))
+	for i, r := range c.Regions {
+		byService[i] = r.ByService
+	}
+	return sortedServices(byService)
+}
+
+// sortedServices returns every service in any of the per-service totals,
+// sorted
+func sortedServices(byService []map[string]determinism.Money) []string {
+	seen := make(map[string]bool)
+	for _, totals := range byService {
+		for service := range totals {
+			seen[service] = true
+		}
+	}
+	services := make([]string, 0, len(seen))
+	for service := range seen {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	return services
+}
+
+// CompareRegions estimates req once per region against that region's
+// snapshot. req.SnapshotRequest supplies the provider, alias and AsOf; its
+// region and snapshot ID are ignored. OnInstanceCost is not called, since
+// per-service totals need every instance cost.
+//
+// Regions that fail to estimate (usually for lack of a snapshot) become
+// warnings. An error is returned only when ctx is canceled or no region
+// could be priced.
+func (e *Engine) CompareRegions(ctx context.Context, req *EstimateRequest, regions []string) (*RegionComparison, error) {
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("no regions to compare")
+	}
+
+	comparison := &RegionComparison{}
+	seen := make(map[string]bool)
+	for _, region := range regions {
+		if region == "" || seen[region] {
+			continue
+		}
+		seen[region] = true
+
+		regionReq := *req
+		regionReq.OnInstanceCost = nil
+		regionReq.SnapshotRequest = SnapshotRequest{
+			Provider:       req.SnapshotRequest.Provider,
+			Region:         region,
+			Alias:          req.SnapshotRequest.Alias,
+			AsOf:           req.SnapshotRequest.AsOf,
+			OverrideRegion: true,
+		}
+
+		result, err := e.Estimate(ctx, &regionReq)
+		if err != nil {
+			if ctx.Err() != nil {
+				return comparison, fmt.Errorf("region comparison canceled: %w", ctx.Err())
+			}
+			comparison.Warnings = append(comparison.Warnings,
+				fmt.Sprintf("region %s skipped: %v", region, err))
+			continue
+		}
+		comparison.Regions = append(comparison.Regions, &RegionEstimate{
+			Region:    region,
+			Result:    result,
+			ByService: serviceTotals(result),
+		})
+	}
+
+	if len(comparison.Regions) == 0 {
+		return comparison, fmt.Errorf("no region could be priced: %s", strings.Join(comparison.Warnings, "; "))
+	}
+	return comparison, nil
+}
+
+// serviceTotals sums the displayed monthly cost of each service
+func serviceTotals(result *EstimationResult) map[string]determinism.Money {
+	totals := make(map[string]determinism.Money)
+	result.InstanceCosts.Range(func(_ model.InstanceID, c *InstanceCost) bool {
+		service := cost.ServiceForResourceType(c.ResourceType)
+		total, ok := totals[service]
+		if !ok {
+			total = determinism.Zero(result.TotalMonthlyCost.Currency())
+		}
+		totals[service] = total.Add(c.DisplayMonthlyCost())
+		return true
+	})
+	return totals
+}
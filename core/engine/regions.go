@@ -0,0 +1,192 @@
+// Package engine - Per-instance region snapshots
+// Instances carry the region of their (possibly aliased) provider. Each
+// region is priced from its own snapshot; a region without a snapshot is
+// reported instead of silently priced with the request's default region.
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"terraform-cost/core/model"
+	"terraform-cost/core/pricing"
+)
+
+// regionSnapshots resolves and memoizes snapshots per region for one estimate
+type regionSnapshots struct {
+	engine  *Engine
+	ctx     context.Context
+	req     SnapshotRequest
+	primary *pricing.PricingSnapshot
+
+	// byRegion holds resolved snapshots; a nil entry means unavailable
+	byRegion map[string]*pricing.PricingSnapshot
+
+	// reference is the fallback-region snapshot, loaded on first use
+	reference       *pricing.PricingSnapshot
+	referenceLoaded bool
+
+	// missing counts instances per region with no snapshot
+	missing map[string]int
+
+	// bundled lists regions priced from the bundled fallback rates
+	bundled []string
+
+	// overrides are layered on every loaded snapshot
+	overrides *pricing.RateOverrides
+}
+
+func newRegionSnapshots(ctx context.Context, e *Engine, req SnapshotRequest, primary *pricing.PricingSnapshot, overrides *pricing.RateOverrides) *regionSnapshots {
+	return &regionSnapshots{
+		engine:    e,
+		ctx:       ctx,
+		req:       req,
+		primary:   primary,
+		overrides: overrides,
+		byRegion:  map[string]*pricing.PricingSnapshot{primary.Region: primary},
+		missing:   make(map[string]int),
+	}
+}
+
+// forInstance returns the snapshot for the instance's region (the primary
+// snapshot when the instance has no region or the request overrides it)
+// and the fallback snapshot to use for rates missing from it. The snapshot
+// is nil when the instance's region has no snapshot.
+func (r *regionSnapshots) forInstance(inst *model.AssetInstance) (snapshot, fallback *pricing.PricingSnapshot) {
+	snapshot, fallback = r.lookup(inst)
+	if snapshot == nil {
+		r.missing[r.region(inst)]++
+	}
+	return snapshot, fallback
+}
+
+// lookup is forInstance without reporting a region that has no snapshot
+func (r *regionSnapshots) lookup(inst *model.AssetInstance) (snapshot, fallback *pricing.PricingSnapshot) {
+	snapshot = r.get(r.region(inst))
+	if snapshot == nil || snapshot.Region != r.engine.fallbackRegion() {
+		fallback = r.fallback()
+	}
+	return snapshot, fallback
+}
+
+// region returns the region the instance is priced in
+func (r *regionSnapshots) region(inst *model.AssetInstance) string {
+	if inst.Provider.Region == "" || r.req.OverrideRegion {
+		return r.primary.Region
+	}
+	return inst.Provider.Region
+}
+
+// preload resolves the snapshots every instance will be priced from,
+// including the fallback, without reporting missing regions. Destroyed
+// ignored instances are not priced, so they load nothing.
+func (r *regionSnapshots) preload(instances, ignored []*model.AssetInstance) {
+	for _, inst := range instances {
+		r.lookup(inst)
+	}
+	for _, inst := range ignored {
+		if !inst.Metadata.Destroyed {
+			r.lookup(inst)
+		}
+	}
+}
+
+// cacheParts names every resolved snapshot by region, ID and content
+// hash, in region order; an unavailable region is named as such
+func (r *regionSnapshots) cacheParts() []string {
+	regions := make([]string, 0, len(r.byRegion))
+	for region := range r.byRegion {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	parts := make([]string, 0, len(regions))
+	for _, region := range regions {
+		snapshot := r.byRegion[region]
+		if snapshot == nil {
+			parts = append(parts, region+"=none")
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s=%s:%s", region, snapshot.ID, snapshot.ContentHash.Hex()))
+	}
+	return parts
+}
+
+// get returns the memoized snapshot for a region, loading it on first use
+func (r *regionSnapshots) get(region string) *pricing.PricingSnapshot {
+	snapshot, ok := r.byRegion[region]
+	if !ok {
+		snapshot = r.load(region)
+		r.byRegion[region] = snapshot
+	}
+	return snapshot
+}
+
+// load fetches and verifies the snapshot for a region
+func (r *regionSnapshots) load(region string) *pricing.PricingSnapshot {
+	snap, err := r.engine.pricingResolver.GetSnapshot(r.ctx, SnapshotRequest{
+		Provider: r.primary.Provider,
+		Region:   region,
+		Alias:    r.req.Alias,
+		AsOf:     r.req.AsOf,
+	})
+	if err != nil {
+		if bundled := r.engine.bundledSnapshot(r.primary.Provider, region); bundled != nil {
+			r.bundled = append(r.bundled, region)
+			return r.overlay(bundled)
+		}
+	}
+	if err != nil || snap == nil || !snap.Verify() {
+		return nil
+	}
+	return r.overlay(snap)
+}
+
+// overlay layers the rate overrides on a region's snapshot. An override in
+// another currency than the region's rates leaves the region unpriced
+// rather than mixing currencies.
+func (r *regionSnapshots) overlay(snap *pricing.PricingSnapshot) *pricing.PricingSnapshot {
+	overlaid, err := r.overrides.Apply(snap)
+	if err != nil {
+		return nil
+	}
+	return overlaid
+}
+
+// fallback returns the reference-region snapshot when region fallback is
+// enabled, or nil
+func (r *regionSnapshots) fallback() *pricing.PricingSnapshot {
+	if !r.engine.config.AllowRegionFallback {
+		return nil
+	}
+	if !r.referenceLoaded {
+		r.referenceLoaded = true
+		r.reference = r.get(r.engine.fallbackRegion())
+	}
+	return r.reference
+}
+
+// warnings describes regions that had no snapshot, in region order
+func (r *regionSnapshots) warnings() []string {
+	regions := make([]string, 0, len(r.missing))
+	for region := range r.missing {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	out := make([]string, 0, len(regions))
+	for _, region := range regions {
+		handling := "left unpriced"
+		if r.fallback() != nil {
+			handling = "priced from " + r.engine.fallbackRegion() + " where possible"
+		}
+		out = append(out, fmt.Sprintf("no pricing snapshot for region %s: %d instance(s) %s",
+			region, r.missing[region], handling))
+	}
+	sort.Strings(r.bundled)
+	for _, region := range r.bundled {
+		out = append(out, fmt.Sprintf("no pricing snapshot for region %s: priced from the bundled fallback rates", region))
+	}
+	return out
+}
@@ -0,0 +1,138 @@
+// Package engine - Estimation result cache
+// Repeated CI runs on an unchanged plan would otherwise reprice every
+// instance. A result is reused only for the same input, the same pricing
+// snapshots (ID and content hash of every region's snapshot and the
+// fallback, so a new snapshot invalidates it) and the same usage settings.
+package engine
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"terraform-cost/core/model"
+	"terraform-cost/internal/logging"
+)
+
+// resultCacheVersion is part of every cache key; bump it when the
+// EstimationResult encoding or pricing logic changes incompatibly
+const resultCacheVersion = "6"
+
+// ResultCache stores estimation results by key
+type ResultCache interface {
+	// Get returns the result stored under key; ok is false on a miss
+	Get(ctx context.Context, key string) (result *EstimationResult, ok bool, err error)
+
+	// Put stores a result under key
+	Put(ctx context.Context, key string, result *EstimationResult) error
+}
+
+// SetResultCache enables reuse of results for requests with a CacheKey
+// (nil disables it)
+func (e *Engine) SetResultCache(cache ResultCache) {
+	e.resultCache = cache
+}
+
+// PlanCacheKey hashes plan JSON into a request CacheKey. The JSON is
+// canonicalized (keys sorted, whitespace dropped) and the top-level
+// timestamp removed, so re-running terraform plan on an unchanged
+// configuration yields the same key.
+func PlanCacheKey(planJSON []byte) (string, error) {
+	var plan interface{}
+	if err := json.Unmarshal(planJSON, &plan); err != nil {
+		return "", fmt.Errorf("invalid plan JSON: %w", err)
+	}
+	if m, ok := plan.(map[string]interface{}); ok {
+		delete(m, "timestamp")
+	}
+	canonical, err := json.Marshal(plan)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// resultCacheKey derives the cache key for a request priced from snapshot,
+// or "" when the result must not be cached. The snapshots of every region
+// the instances are priced in are resolved first, so they are part of it.
+func (e *Engine) resultCacheKey(req *EstimateRequest, snapshot *SnapshotReference, regions *regionSnapshots, instances, ignored []*model.AssetInstance) string {
+	if e.resultCache == nil || req.CacheKey == "" || req.OnInstanceCost != nil {
+		return ""
+	}
+	regions.preload(instances, ignored)
+
+	overrides, err := json.Marshal(req.UsageOverrides)
+	if err != nil {
+		return ""
+	}
+
+	h := sha256.New()
+	for _, part := range append([]string{
+		resultCacheVersion,
+		req.CacheKey,
+		string(snapshot.ID),
+		snapshot.ContentHash.Hex(),
+		snapshot.OverlayHash.Hex(),
+		snapshot.Alias,
+		strconv.FormatBool(snapshot.Stale),
+		strconv.FormatBool(req.SnapshotRequest.OverrideRegion),
+		req.UsageProfile,
+		req.Workspace,
+		string(overrides),
+		strings.Join(req.IgnorePatterns, ","),
+		strconv.FormatFloat(e.HoursPerMonth(), 'g', -1, 64),
+		strconv.FormatFloat(e.config.DropBelowConfidence, 'g', -1, 64),
+	}, regions.cacheParts()...) {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cachedResult returns the result cached under key, or nil. The snapshot
+// reference and request ID are refreshed and policies are re-evaluated,
+// since neither is part of the key.
+func (e *Engine) cachedResult(ctx context.Context, key string, snapshot *SnapshotReference, requestID string) *EstimationResult {
+	if key == "" {
+		return nil
+	}
+
+	result, ok, err := e.resultCache.Get(ctx, key)
+	if err != nil {
+		e.logger.Warn("result cache lookup failed", logging.String("key", key), logging.Err(err))
+		return nil
+	}
+	if !ok || result == nil {
+		return nil
+	}
+
+	result.Snapshot = snapshot
+	result.RequestID = requestID
+	result.Cached = true
+	result.PolicyResult = nil
+	if e.policyEvaluator != nil {
+		policyResult, err := e.policyEvaluator.Evaluate(ctx, result)
+		if err != nil {
+			result.Warnings = append(result.Warnings,
+				fmt.Sprintf("policy evaluation failed: %v", err))
+		} else {
+			result.PolicyResult = policyResult
+		}
+	}
+	return result
+}
+
+// storeResult caches a completed result; failures only cost a future miss
+func (e *Engine) storeResult(ctx context.Context, key string, result *EstimationResult) {
+	if key == "" {
+		return
+	}
+	if err := e.resultCache.Put(ctx, key, result); err != nil {
+		e.logger.Warn("result cache store failed", logging.String("key", key), logging.Err(err))
+	}
+}
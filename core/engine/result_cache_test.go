@@ -0,0 +1,210 @@
+// Package engine - Result cache tests
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/model"
+	"terraform-cost/core/pricing"
+	"terraform-cost/internal/logging"
+)
+
+// jsonCache stores results encoded, as a persistent cache would
+type jsonCache struct {
+	entries map[string][]byte
+	hits    int
+}
+
+func (c *jsonCache) Get(ctx context.Context, key string) (*EstimationResult, bool, error) {
+	data, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	var result EstimationResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, false, err
+	}
+	c.hits++
+	return &result, true, nil
+}
+
+func (c *jsonCache) Put(ctx context.Context, key string, result *EstimationResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	c.entries[key] = data
+	return nil
+}
+
+func TestResultCache(t *testing.T) {
+	mapped := 0
+	eng := newTestEngine(&computePlugin{onMap: func() { mapped++ }})
+	cache := &jsonCache{entries: map[string][]byte{}}
+	eng.SetResultCache(cache)
+
+	key, err := PlanCacheKey([]byte(`{"format_version":"1.2","timestamp":"2026-01-01T00:00:00Z","resource_changes":[]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &EstimateRequest{Graph: newTestGraph(3), CacheKey: key}
+
+	first, err := eng.Estimate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Cached || mapped != 3 {
+		t.Fatalf("first run: cached=%v, mapped %d instances", first.Cached, mapped)
+	}
+
+	// Same plan re-generated later: whitespace, key order and timestamp differ
+	again, err := PlanCacheKey([]byte(`{ "resource_changes": [], "timestamp": "2026-02-01T00:00:00Z", "format_version": "1.2" }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != key {
+		t.Fatalf("canonicalized plan keys differ: %s != %s", again, key)
+	}
+
+	second, err := eng.Estimate(context.Background(), &EstimateRequest{Graph: newTestGraph(3), CacheKey: again, RequestID: "req-2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second.Cached || cache.hits != 1 || mapped != 3 {
+		t.Fatalf("second run: cached=%v, hits %d, mapped %d instances", second.Cached, cache.hits, mapped)
+	}
+	if second.RequestID != "req-2" {
+		t.Errorf("request ID = %q, want req-2", second.RequestID)
+	}
+	if second.TotalMonthlyCost.Cmp(first.TotalMonthlyCost) != 0 || second.InstanceCosts.Len() != 3 {
+		t.Errorf("cached result differs: %s over %d instances, want %s over 3",
+			second.TotalMonthlyCost, second.InstanceCosts.Len(), first.TotalMonthlyCost)
+	}
+
+	// A new snapshot invalidates the entry
+	eng.pricingResolver.(*staticResolver).snapshot = pricing.NewSnapshotBuilder("aws", "us-east-1").
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(0.2), "hour", "USD").
+		Build()
+	third, err := eng.Estimate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.Cached || mapped != 6 {
+		t.Fatalf("after snapshot change: cached=%v, mapped %d instances", third.Cached, mapped)
+	}
+
+	// Requests without a cache key are never cached
+	if r, _ := eng.Estimate(context.Background(), &EstimateRequest{Graph: newTestGraph(3)}); r.Cached {
+		t.Error("request without CacheKey was served from cache")
+	}
+}
+
+// TestScenarioCacheKey proves a scenario's key does not depend on the
+// order its substitutions are given in, but does on their values, the
+// plan and the snapshot
+func TestScenarioCacheKey(t *testing.T) {
+	subs := []Substitution{
+		{Address: "aws_instance.web", Attribute: "instance_type", Value: "t3.large"},
+		{Address: "aws_db_instance.main", Attribute: "instance_class", Value: "db.r5.large"},
+		{Address: "aws_instance.web", Attribute: "ebs_optimized", Value: "true"},
+		{Address: "aws_instance.api", Attribute: "instance_type", Value: "m5.large"},
+	}
+	snapshot := &SnapshotReference{ID: "snap-1"}
+	want := ScenarioCacheKey("plan", Scenario{Name: "bigger", Substitutions: subs}, snapshot)
+
+	rng := rand.New(rand.NewSource(1431))
+	for i := 0; i < 50; i++ {
+		shuffled := append([]Substitution(nil), subs...)
+		rng.Shuffle(len(shuffled), func(a, b int) { shuffled[a], shuffled[b] = shuffled[b], shuffled[a] })
+		if i%2 == 1 {
+			// A repeated substitution is the same scenario
+			shuffled = append(shuffled, shuffled[0])
+		}
+		if got := ScenarioCacheKey("plan", Scenario{Name: "renamed", Substitutions: shuffled}, snapshot); got != want {
+			t.Fatalf("order %v: key %s, want %s", shuffled, got, want)
+		}
+	}
+
+	changed := append([]Substitution(nil), subs...)
+	changed[0].Value = "t3.xlarge"
+	for name, key := range map[string]string{
+		"value":    ScenarioCacheKey("plan", Scenario{Substitutions: changed}, snapshot),
+		"fewer":    ScenarioCacheKey("plan", Scenario{Substitutions: subs[1:]}, snapshot),
+		"plan":     ScenarioCacheKey("other-plan", Scenario{Substitutions: subs}, snapshot),
+		"snapshot": ScenarioCacheKey("plan", Scenario{Substitutions: subs}, &SnapshotReference{ID: "snap-2"}),
+	} {
+		if key == want {
+			t.Errorf("a different %s must change the key", name)
+		}
+	}
+}
+
+// TestResultCacheRegionSnapshots proves a new snapshot for any region an
+// instance is priced in, or for the fallback region, invalidates the entry
+func TestResultCacheRegionSnapshots(t *testing.T) {
+	snapshot := func(region string, price float64) *pricing.PricingSnapshot {
+		b := pricing.NewSnapshotBuilder("aws", region)
+		if price > 0 {
+			b = b.AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(price), "hour", "USD")
+		}
+		return b.Build()
+	}
+	resolver := &regionResolver{byRegion: map[string]*pricing.PricingSnapshot{
+		"us-east-1":  snapshot("us-east-1", 0.1),
+		"eu-west-1":  snapshot("eu-west-1", 0.2),
+		"eu-north-1": snapshot("eu-north-1", 0),
+	}}
+	eng := NewEngine(resolver, noUsage{}, nil, EngineConfig{AllowRegionFallback: true})
+	eng.SetLogger(logging.Nop())
+	eng.RegisterPlugin(&computePlugin{})
+	eng.SetResultCache(&jsonCache{entries: map[string][]byte{}})
+
+	graph := model.NewInstanceGraph()
+	for i, region := range []string{"eu-north-1", "eu-west-1"} {
+		graph.AddInstance(&model.AssetInstance{
+			ID:       model.InstanceID(fmt.Sprintf("inst-%03d", i)),
+			Address:  model.InstanceAddress(fmt.Sprintf("aws_instance.web[%d]", i)),
+			Provider: model.ResolvedProvider{Type: "aws", Region: region},
+		})
+	}
+	estimate := func() *EstimationResult {
+		t.Helper()
+		result, err := eng.Estimate(context.Background(), &EstimateRequest{
+			Graph:           graph,
+			CacheKey:        "plan",
+			SnapshotRequest: SnapshotRequest{Provider: "aws", Region: "eu-north-1"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	first := estimate()
+	if first.Cached {
+		t.Fatal("first run served from cache")
+	}
+	if !estimate().Cached {
+		t.Fatal("unchanged snapshots missed the cache")
+	}
+
+	// The primary snapshot is unchanged; the instance's region is not
+	resolver.byRegion["eu-west-1"] = snapshot("eu-west-1", 0.3)
+	second := estimate()
+	if second.Cached || second.TotalMonthlyCost.Cmp(first.TotalMonthlyCost) == 0 {
+		t.Fatalf("new eu-west-1 snapshot: cached=%v, total %s", second.Cached, second.TotalMonthlyCost)
+	}
+
+	// eu-north-1 has no rate, so it is priced from the fallback region
+	resolver.byRegion["us-east-1"] = snapshot("us-east-1", 0.4)
+	third := estimate()
+	if third.Cached || third.TotalMonthlyCost.Cmp(second.TotalMonthlyCost) == 0 {
+		t.Fatalf("new fallback snapshot: cached=%v, total %s", third.Cached, third.TotalMonthlyCost)
+	}
+}
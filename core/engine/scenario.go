@@ -0,0 +1,80 @@
+// Package engine - What-if scenario cache keys
+// A what-if scenario reprices a plan with some attributes substituted
+// (e.g. aws_instance.web's instance_type set to t3.large). Running the
+// same scenario again should not reprice anything, so scenarios are keyed
+// by content: the canonical plan hash (PlanCacheKey), the substitutions
+// in canonical order and the pricing snapshot. The order substitutions
+// were given in never changes the key.
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+)
+
+// scenarioCacheVersion is part of every scenario key; bump it when the
+// key's inputs or their encoding change
+const scenarioCacheVersion = "1"
+
+// Substitution sets one attribute of one resource in a what-if scenario
+type Substitution struct {
+	Address   string `json:"address"`
+	Attribute string `json:"attribute"`
+	Value     string `json:"value"`
+}
+
+// Scenario is a named set of substitutions applied to a plan
+type Scenario struct {
+	Name          string         `json:"name,omitempty"`
+	Substitutions []Substitution `json:"substitutions"`
+}
+
+// CanonicalSubstitutions returns the substitutions sorted by address,
+// attribute and value, without exact duplicates
+func CanonicalSubstitutions(subs []Substitution) []Substitution {
+	out := append([]Substitution(nil), subs...)
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Address != out[j].Address {
+			return out[i].Address < out[j].Address
+		}
+		if out[i].Attribute != out[j].Attribute {
+			return out[i].Attribute < out[j].Attribute
+		}
+		return out[i].Value < out[j].Value
+	})
+	deduped := out[:0]
+	for i, s := range out {
+		if i == 0 || s != out[i-1] {
+			deduped = append(deduped, s)
+		}
+	}
+	return deduped
+}
+
+// ScenarioCacheKey derives the content-addressed key of a scenario priced
+// from snapshot. planHash is PlanCacheKey of the plan; the scenario's
+// name is a label and not part of the key.
+func ScenarioCacheKey(planHash string, scenario Scenario, snapshot *SnapshotReference) string {
+	h := sha256.New()
+	write := func(part string) {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	write(scenarioCacheVersion)
+	write(planHash)
+	for _, s := range CanonicalSubstitutions(scenario.Substitutions) {
+		write(s.Address)
+		write(s.Attribute)
+		write(s.Value)
+	}
+	// Separates the substitutions from the snapshot fields
+	write("")
+	if snapshot != nil {
+		write(string(snapshot.ID))
+		write(snapshot.ContentHash.Hex())
+		write(snapshot.OverlayHash.Hex())
+		write(snapshot.Alias)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,54 @@
+// Package engine - Static resolver
+// StaticResolver prices from snapshots held in memory, with no pricing
+// store behind it: the bundled demo rates (see core/pricing/demo) and
+// tests use it.
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"terraform-cost/core/pricing"
+)
+
+// StaticResolver serves a fixed set of snapshots, matched like
+// StaticFallback
+type StaticResolver struct {
+	snapshots []*pricing.PricingSnapshot
+	match     FallbackSnapshotFunc
+}
+
+// NewStaticResolver creates a resolver over fixed snapshots
+func NewStaticResolver(snapshots ...*pricing.PricingSnapshot) *StaticResolver {
+	return &StaticResolver{snapshots: snapshots, match: StaticFallback(snapshots...)}
+}
+
+// GetSnapshot returns the snapshot for the request's provider/region, or
+// the one with the requested ID. Point-in-time requests are refused: the
+// snapshots have no history.
+func (r *StaticResolver) GetSnapshot(ctx context.Context, req SnapshotRequest) (*pricing.PricingSnapshot, error) {
+	if req.SnapshotID != "" {
+		for _, s := range r.snapshots {
+			if s.ID == req.SnapshotID {
+				return s, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: snapshot %s not found", ErrPricingUnavailable, req.SnapshotID)
+	}
+	if req.AsOf != nil {
+		return nil, fmt.Errorf("%w: static rates have no history to price as of %s", ErrPricingUnavailable, req.AsOf.Format("2006-01-02"))
+	}
+	if s := r.match(req.Provider, req.Region); s != nil {
+		return s, nil
+	}
+	return nil, fmt.Errorf("%w for %s/%s: no static rates", ErrPricingUnavailable, req.Provider, req.Region)
+}
+
+// LookupRate finds a rate within a snapshot
+func (r *StaticResolver) LookupRate(snapshot *pricing.PricingSnapshot, resourceType, component string, attrs map[string]string) (*pricing.RateEntry, error) {
+	rate, ok := snapshot.LookupRate(resourceType, component, attrs)
+	if !ok {
+		return nil, fmt.Errorf("no rate for %s/%s %v", resourceType, component, attrs)
+	}
+	return rate, nil
+}
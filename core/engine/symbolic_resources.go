@@ -0,0 +1,176 @@
+// Package engine - Symbolic resources
+// A resource whose count or for_each cannot be resolved has no concrete
+// instance count: it is priced at an assumed count, or not at all. The
+// expansion phase reports these as cardinality warnings; the engine
+// carries them onto the result so every output can say which resources
+// are symbolic and why. Outputs list a resource's placeholder instances
+// once, as a PlaceholderGroup, rather than one row per placeholder.
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/model"
+	"terraform-cost/core/terraform"
+)
+
+// SymbolicResource is a resource with an unknown instance count
+type SymbolicResource struct {
+	Address string
+
+	// Reason is what could not be resolved: "count", "for_each" or
+	// "provider"
+	Reason string
+
+	// Min and Max bound the instance count; Max is -1 when unbounded
+	Min int
+	Max int
+
+	// Expression is the unresolved expression, when known
+	Expression string
+
+	// Message is the expansion phase's explanation
+	Message string
+
+	// AssumedCount is the instance count priced in its place
+	AssumedCount int
+
+	// BlocksEstimation is true when strict mode refuses to estimate it
+	BlocksEstimation bool
+}
+
+// Range renders the instance count bounds, e.g. "0..∞" or "1..3"
+func (s SymbolicResource) Range() string {
+	if s.Max < 0 {
+		return fmt.Sprintf("%d..∞", s.Min)
+	}
+	return fmt.Sprintf("%d..%d", s.Min, s.Max)
+}
+
+// symbolicResources converts cardinality warnings, one per address in
+// address order
+func symbolicResources(warnings []terraform.CardinalityWarning) []SymbolicResource {
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(warnings))
+	out := make([]SymbolicResource, 0, len(warnings))
+	for _, w := range warnings {
+		if seen[w.Address] {
+			continue
+		}
+		seen[w.Address] = true
+
+		r := SymbolicResource{
+			Address:          w.Address,
+			Reason:           w.Type,
+			Max:              -1,
+			Expression:       w.Expression,
+			Message:          w.Message,
+			AssumedCount:     w.AssumedCount,
+			BlocksEstimation: w.BlocksEstimation,
+		}
+		if w.SymbolicRange != nil {
+			r.Min, r.Max = w.SymbolicRange.Min, w.SymbolicRange.Max
+			if r.Expression == "" {
+				r.Expression = w.SymbolicRange.Expression
+			}
+		}
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Address < out[j].Address })
+	return out
+}
+
+// PlaceholderGroup is the placeholder instances priced for one resource
+// whose count or for_each is unknown
+type PlaceholderGroup struct {
+	// Address is the resource address with a [*] key,
+	// e.g. aws_instance.worker[*]
+	Address      string
+	DefinitionID model.DefinitionID
+	ResourceType string
+
+	// Placeholders is how many instances were priced
+	Placeholders int
+
+	// MonthlyCost and HourlyCost sum the placeholders' costs as displayed
+	MonthlyCost determinism.Money
+	HourlyCost  determinism.Money
+
+	// Confidence is the lowest placeholder confidence
+	Confidence float64
+
+	// Symbolic is the resource's entry in SymbolicResources, when reported
+	Symbolic *SymbolicResource
+}
+
+// Label describes the group, e.g.
+// "aws_instance.worker[*] (unknown count, range 0..∞)"
+func (g PlaceholderGroup) Label() string {
+	if g.Symbolic == nil {
+		return g.Address + " (unknown count)"
+	}
+	return fmt.Sprintf("%s (unknown %s, range %s)", g.Address, g.Symbolic.Reason, g.Symbolic.Range())
+}
+
+// PlaceholderGroups groups the placeholder instance costs by resource,
+// in the order their first placeholder is listed. Results whose instance
+// costs were streamed have none.
+func (r *EstimationResult) PlaceholderGroups() []PlaceholderGroup {
+	if r.InstanceCosts == nil {
+		return nil
+	}
+	symbolic := make(map[string]*SymbolicResource, len(r.SymbolicResources))
+	for i := range r.SymbolicResources {
+		symbolic[r.SymbolicResources[i].Address] = &r.SymbolicResources[i]
+	}
+
+	var groups []PlaceholderGroup
+	index := make(map[string]int)
+	r.InstanceCosts.Range(func(_ model.InstanceID, c *InstanceCost) bool {
+		if !c.Symbolic {
+			return true
+		}
+		address := placeholderResourceAddress(c.Address)
+		i, ok := index[address]
+		if !ok {
+			i = len(groups)
+			index[address] = i
+			groups = append(groups, PlaceholderGroup{
+				Address:      address + "[*]",
+				DefinitionID: c.DefinitionID,
+				ResourceType: c.ResourceType,
+				MonthlyCost:  determinism.Zero(c.MonthlyCost.Currency()),
+				HourlyCost:   determinism.Zero(c.HourlyCost.Currency()),
+				Confidence:   c.Confidence.Score,
+				Symbolic:     symbolic[address],
+			})
+		}
+		g := &groups[i]
+		g.Placeholders++
+		g.MonthlyCost = g.MonthlyCost.Add(c.DisplayMonthlyCost())
+		g.HourlyCost = g.HourlyCost.Add(c.DisplayHourlyCost())
+		if c.Confidence.Score < g.Confidence {
+			g.Confidence = c.Confidence.Score
+		}
+		return true
+	})
+	return groups
+}
+
+// placeholderResourceAddress strips a placeholder's instance key:
+// aws_instance.worker[2] is a placeholder of aws_instance.worker
+func placeholderResourceAddress(address model.InstanceAddress) string {
+	addr := string(address)
+	if strings.HasSuffix(addr, "]") {
+		if i := strings.LastIndex(addr, "["); i > 0 {
+			return addr[:i]
+		}
+	}
+	return addr
+}
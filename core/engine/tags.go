@@ -0,0 +1,84 @@
+// Package engine - Cost-allocation tag breakdown
+// Teams charge back by tag (team, cost-center). Tags are read from each
+// instance's tags_all attribute, which includes provider default_tags,
+// falling back to tags.
+package engine
+
+import (
+	"fmt"
+
+	"terraform-cost/core/model"
+)
+
+// UntaggedValue is the bucket for instances without the grouping tag
+const UntaggedValue = "untagged"
+
+// TagGroup is the cost of all instances sharing one value of a tag
+type TagGroup = CostGroup
+
+// GroupByTag sums instance costs per value of the tag key. Instances
+// without the tag (or with an empty value) fall into UntaggedValue.
+// Groups are ordered by monthly cost, highest first, then by value.
+func (r *EstimationResult) GroupByTag(key string) []TagGroup {
+	return r.groupCosts(func(ic *InstanceCost) string {
+		if value := ic.Tags[key]; value != "" {
+			return value
+		}
+		return UntaggedValue
+	})
+}
+
+// instanceTags reads cost-allocation tags from an instance. Unknown
+// attributes are skipped; non-string tag values are formatted.
+func instanceTags(inst *model.AssetInstance) map[string]string {
+	known := func(name string) any {
+		if attr, ok := inst.Attributes[name]; ok && !attr.IsUnknown {
+			return attr.Value
+		}
+		return nil
+	}
+	return AllocationTags(known("tags_all"), known("tags"))
+}
+
+// AllocationTags returns the cost-allocation tags of a resource from its
+// decoded tags_all and tags attributes: tags_all, which includes provider
+// default_tags, falling back to tags. Pass nil for an absent or unknown
+// attribute.
+func AllocationTags(tagsAll, tags any) map[string]string {
+	if t := TagsFromValue(tagsAll); len(t) > 0 {
+		return t
+	}
+	return TagsFromValue(tags)
+}
+
+// TagsFromValue converts a decoded tags attribute to a string map
+func TagsFromValue(v any) map[string]string {
+	switch m := v.(type) {
+	case map[string]string:
+		if len(m) == 0 {
+			return nil
+		}
+		tags := make(map[string]string, len(m))
+		for k, val := range m {
+			tags[k] = val
+		}
+		return tags
+	case map[string]any:
+		if len(m) == 0 {
+			return nil
+		}
+		tags := make(map[string]string, len(m))
+		for k, val := range m {
+			switch tv := val.(type) {
+			case nil:
+				continue
+			case string:
+				tags[k] = tv
+			default:
+				tags[k] = fmt.Sprint(tv)
+			}
+		}
+		return tags
+	}
+	return nil
+}
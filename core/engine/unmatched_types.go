@@ -0,0 +1,362 @@
+// Package engine - Unmatched instance types
+// A component whose instance type has no rate is priced at zero with zero
+// confidence, which reads like a free resource. Retired families (m1, t1,
+// db.m3) and misspelled types are the usual cause. The engine collects
+// those types per region and explains each one: either the type is priced
+// in another region's snapshot, or it is unknown and the nearest priced
+// type is suggested.
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"terraform-cost/core/model"
+	"terraform-cost/core/pricing"
+)
+
+// sizingAttributes are the component attributes that name an instance type
+var sizingAttributes = []string{"instance_type", "instance_class", "node_type"}
+
+// UnmatchedType is an instance type the snapshot has no rate for
+type UnmatchedType struct {
+	ResourceType string
+	Attribute    string // e.g. instance_type
+	Value        string // e.g. m1.small
+	Region       string
+
+	// Addresses of the instances using the type
+	Addresses []string
+
+	// AvailableIn is another region whose snapshot prices the type, when
+	// the type exists but not in Region
+	AvailableIn string
+
+	// Suggestion is the nearest type priced in Region, when the type is
+	// unknown and a close match exists
+	Suggestion string
+}
+
+// unmatchedKey groups misses so each type is reported once per region
+type unmatchedKey struct {
+	resourceType string
+	attribute    string
+	value        string
+	region       string
+}
+
+// unmatchedMiss is one unmatched type with what is needed to diagnose it
+type unmatchedMiss struct {
+	UnmatchedType
+	component string
+	attrs     map[string]string
+	snapshot  *pricing.PricingSnapshot
+}
+
+// unmatchedTypes collects rate misses on sizing attributes for one estimate
+type unmatchedTypes struct {
+	misses map[unmatchedKey]*unmatchedMiss
+}
+
+func newUnmatchedTypes() *unmatchedTypes {
+	return &unmatchedTypes{misses: make(map[unmatchedKey]*unmatchedMiss)}
+}
+
+// record notes a component that found no rate in snapshot. Components
+// without a sizing attribute, and instances whose region has no snapshot
+// (reported separately), are ignored.
+func (u *unmatchedTypes) record(inst *model.AssetInstance, comp CostComponent, snapshot *pricing.PricingSnapshot) {
+	if snapshot == nil {
+		return
+	}
+	attribute, value := sizingAttribute(comp.Attributes)
+	if attribute == "" {
+		return
+	}
+
+	key := unmatchedKey{comp.ResourceType, attribute, value, snapshot.Region}
+	miss, ok := u.misses[key]
+	if !ok {
+		miss = &unmatchedMiss{
+			UnmatchedType: UnmatchedType{
+				ResourceType: comp.ResourceType,
+				Attribute:    attribute,
+				Value:        value,
+				Region:       snapshot.Region,
+			},
+			component: comp.Name,
+			attrs:     comp.Attributes,
+			snapshot:  snapshot,
+		}
+		u.misses[key] = miss
+	}
+	addr := string(inst.Address)
+	if n := len(miss.Addresses); n == 0 || miss.Addresses[n-1] != addr {
+		miss.Addresses = append(miss.Addresses, addr)
+	}
+}
+
+// resolve diagnoses every recorded type, returning them in a stable order
+// with one warning each
+func (u *unmatchedTypes) resolve(regions *regionSnapshots) ([]UnmatchedType, []string) {
+	if len(u.misses) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]unmatchedKey, 0, len(u.misses))
+	for k := range u.misses {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.region != b.region {
+			return a.region < b.region
+		}
+		if a.resourceType != b.resourceType {
+			return a.resourceType < b.resourceType
+		}
+		if a.attribute != b.attribute {
+			return a.attribute < b.attribute
+		}
+		return a.value < b.value
+	})
+
+	types := make([]UnmatchedType, 0, len(keys))
+	warnings := make([]string, 0, len(keys))
+	for _, k := range keys {
+		miss := u.misses[k]
+		miss.AvailableIn = availableIn(regions, miss)
+		if miss.AvailableIn == "" {
+			miss.Suggestion = nearestType(miss.snapshot, miss)
+		}
+		types = append(types, miss.UnmatchedType)
+		warnings = append(warnings, miss.warning())
+	}
+	return types, warnings
+}
+
+// warning describes the unmatched type and what to do about it
+func (m *unmatchedMiss) warning() string {
+	what := fmt.Sprintf("%s %s %q", m.ResourceType, m.Attribute, m.Value)
+	used := fmt.Sprintf("%d instance(s), e.g. %s", len(m.Addresses), m.Addresses[0])
+	switch {
+	case m.AvailableIn != "":
+		return fmt.Sprintf("%s has no rate in %s but is priced in %s; it may not be offered in this region (%s)",
+			what, m.Region, m.AvailableIn, used)
+	case m.Suggestion != "":
+		return fmt.Sprintf("%s is not in the %s pricing snapshot (retired or unknown type); nearest available: %s (%s)",
+			what, m.Region, m.Suggestion, used)
+	default:
+		return fmt.Sprintf("%s is not in the %s pricing snapshot (retired or unknown type) (%s)",
+			what, m.Region, used)
+	}
+}
+
+// sizingAttribute returns the first sizing attribute set on a component
+func sizingAttribute(attrs map[string]string) (string, string) {
+	for _, a := range sizingAttributes {
+		if v := attrs[a]; v != "" {
+			return a, v
+		}
+	}
+	return "", ""
+}
+
+// availableIn returns a region, other than the miss's, whose snapshot
+// prices the exact component. The reference region is loaded if needed
+// and checked first; other regions are only those this estimate loaded.
+func availableIn(regions *regionSnapshots, miss *unmatchedMiss) string {
+	ref := regions.engine.fallbackRegion()
+	candidates := []string{ref}
+	others := make([]string, 0, len(regions.byRegion))
+	for region := range regions.byRegion {
+		if region != ref {
+			others = append(others, region)
+		}
+	}
+	sort.Strings(others)
+	candidates = append(candidates, others...)
+
+	for _, region := range candidates {
+		if region == miss.Region {
+			continue
+		}
+		snap := regions.get(region)
+		if snap == nil {
+			continue
+		}
+		if _, ok := snap.LookupRate(miss.ResourceType, miss.component, miss.attrs); ok {
+			return region
+		}
+	}
+	return ""
+}
+
+// nearestType returns the priced type closest to the unmatched one among
+// rates for the same component that differ only in the sizing attribute.
+// Types like "m4.large" prefer the same class and size in the nearest
+// newer generation ("m5.large"); other types fall back to the longest
+// shared prefix, when it covers at least half the name.
+func nearestType(snapshot *pricing.PricingSnapshot, miss *unmatchedMiss) string {
+	want := attributesWithout(miss.attrs, miss.Attribute)
+
+	var candidates []string
+	seen := make(map[string]bool)
+	for _, rate := range snapshot.Rates() {
+		key := rate.Key
+		if key.ResourceType != miss.ResourceType || key.Component != miss.component || key.UsageType != "" {
+			continue
+		}
+		attrs := parseRateAttributes(key.Attributes)
+		value := attrs[miss.Attribute]
+		if value == "" || value == miss.Value || seen[value] {
+			continue
+		}
+		if attributesWithout(attrs, miss.Attribute) != want {
+			continue
+		}
+		seen[value] = true
+		candidates = append(candidates, value)
+	}
+	sort.Strings(candidates)
+
+	if s := nearestGeneration(miss.Value, candidates); s != "" {
+		return s
+	}
+	return longestSharedPrefix(miss.Value, candidates)
+}
+
+// instanceType is a parsed "<prefix.><class><generation><suffix>.<size>"
+// type, e.g. db.r6gd.xlarge = {"db.", "r", 6, "gd", "xlarge"}
+type instanceType struct {
+	prefix     string
+	class      string
+	generation int
+	suffix     string
+	size       string
+}
+
+// parseInstanceType parses a dotted instance type; ok is false for other
+// naming schemes
+func parseInstanceType(value string) (instanceType, bool) {
+	dot := strings.LastIndex(value, ".")
+	if dot <= 0 || dot == len(value)-1 {
+		return instanceType{}, false
+	}
+	t := instanceType{size: value[dot+1:]}
+	family := value[:dot]
+	if i := strings.LastIndex(family, "."); i >= 0 {
+		t.prefix, family = family[:i+1], family[i+1:]
+	}
+
+	i := 0
+	for i < len(family) && family[i] >= 'a' && family[i] <= 'z' {
+		i++
+	}
+	j := i
+	for j < len(family) && family[j] >= '0' && family[j] <= '9' {
+		j++
+	}
+	if i == 0 || j == i {
+		return instanceType{}, false
+	}
+	t.class = family[:i]
+	t.generation, _ = strconv.Atoi(family[i:j])
+	t.suffix = family[j:]
+	return t, true
+}
+
+// nearestGeneration picks the candidate with the same prefix, class and
+// size whose generation is closest, preferring newer generations and then
+// the same suffix
+func nearestGeneration(value string, candidates []string) string {
+	want, ok := parseInstanceType(value)
+	if !ok {
+		return ""
+	}
+
+	best := ""
+	var bestType instanceType
+	better := func(t instanceType) bool {
+		if best == "" {
+			return true
+		}
+		newer, bestNewer := t.generation > want.generation, bestType.generation > want.generation
+		if newer != bestNewer {
+			return newer
+		}
+		dist, bestDist := abs(t.generation-want.generation), abs(bestType.generation-want.generation)
+		if dist != bestDist {
+			return dist < bestDist
+		}
+		return t.suffix == want.suffix && bestType.suffix != want.suffix
+	}
+	for _, c := range candidates {
+		t, ok := parseInstanceType(c)
+		if !ok || t.prefix != want.prefix || t.class != want.class || t.size != want.size {
+			continue
+		}
+		if better(t) {
+			best, bestType = c, t
+		}
+	}
+	return best
+}
+
+// longestSharedPrefix picks the candidate sharing the longest prefix with
+// value, if that prefix is at least half of value
+func longestSharedPrefix(value string, candidates []string) string {
+	best, bestLen := "", 0
+	for _, c := range candidates {
+		n := 0
+		for n < len(c) && n < len(value) && c[n] == value[n] {
+			n++
+		}
+		if n > bestLen {
+			best, bestLen = c, n
+		}
+	}
+	if bestLen*2 < len(value) {
+		return ""
+	}
+	return best
+}
+
+// parseRateAttributes parses a RateKey's "k=v,k=v" attribute string
+func parseRateAttributes(s string) map[string]string {
+	attrs := make(map[string]string)
+	if s == "" {
+		return attrs
+	}
+	for _, pair := range strings.Split(s, ",") {
+		if k, v, ok := strings.Cut(pair, "="); ok {
+			attrs[k] = v
+		}
+	}
+	return attrs
+}
+
+// attributesWithout serializes attrs, minus one key, in sorted key order
+func attributesWithout(attrs map[string]string, skip string) string {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		if k != skip {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + attrs[k]
+	}
+	return strings.Join(parts, ",")
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
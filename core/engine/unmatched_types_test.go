@@ -0,0 +1,120 @@
+// Package engine - Unmatched instance type tests
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/catalog"
+	"terraform-cost/core/model"
+	"terraform-cost/core/pricing"
+)
+
+// typedPlugin maps each instance to a compute component with its type
+type typedPlugin struct {
+	types map[model.InstanceID]string
+}
+
+func (p *typedPlugin) Provider() string { return "aws" }
+
+func (p *typedPlugin) CatalogVersion() string { return catalog.Version }
+
+func (p *typedPlugin) SupportedTypes() []string { return []string{"aws_instance"} }
+
+func (p *typedPlugin) MapInstance(inst *model.AssetInstance) ([]CostComponent, error) {
+	return []CostComponent{{
+		Name:         "compute",
+		ResourceType: "aws_instance",
+		Unit:         "hours",
+		Attributes:   map[string]string{"instance_type": p.types[inst.ID], "os": "linux"},
+	}}, nil
+}
+
+func TestUnmatchedTypes(t *testing.T) {
+	snapshot := func(region string, types ...string) *pricing.PricingSnapshot {
+		b := pricing.NewSnapshotBuilder("aws", region)
+		for _, typ := range types {
+			b.AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute", Attributes: "instance_type=" + typ + ",os=linux"},
+				decimal.NewFromFloat(0.1), "hour", "USD")
+		}
+		// Same type for another OS must not be suggested for linux
+		b.AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute", Attributes: "instance_type=m4.large,os=windows"},
+			decimal.NewFromFloat(0.2), "hour", "USD")
+		return b.Build()
+	}
+	resolver := &regionResolver{byRegion: map[string]*pricing.PricingSnapshot{
+		"us-east-1": snapshot("us-east-1", "m5.large", "m6i.large", "m7g.large", "x2iedn.large"),
+		"eu-west-3": snapshot("eu-west-3", "m5.large", "m3.large"),
+	}}
+	plugin := &typedPlugin{types: map[model.InstanceID]string{
+		"ok":       "m5.large",
+		"old-1":    "m4.large",
+		"old-2":    "m4.large",
+		"regional": "x2iedn.large",
+		"typo":     "qq9.huge",
+	}}
+	eng := NewEngine(resolver, noUsage{}, nil, EngineConfig{})
+	eng.RegisterPlugin(plugin)
+
+	graph := model.NewInstanceGraph()
+	for id := range plugin.types {
+		graph.AddInstance(&model.AssetInstance{
+			ID:       id,
+			Address:  model.InstanceAddress("aws_instance." + id),
+			Provider: model.ResolvedProvider{Type: "aws", Region: "eu-west-3"},
+		})
+	}
+
+	result, err := eng.Estimate(context.Background(), &EstimateRequest{
+		Graph:           graph,
+		SnapshotRequest: SnapshotRequest{Provider: "aws", Region: "eu-west-3"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.UnmatchedTypes) != 3 {
+		t.Fatalf("expected 3 unmatched types, got %+v", result.UnmatchedTypes)
+	}
+	byValue := make(map[string]UnmatchedType)
+	for _, u := range result.UnmatchedTypes {
+		byValue[u.Value] = u
+	}
+
+	old := byValue["m4.large"]
+	if old.Suggestion != "m5.large" || old.AvailableIn != "" || len(old.Addresses) != 2 {
+		t.Errorf("m4.large: suggestion %q, available in %q, %d addresses", old.Suggestion, old.AvailableIn, len(old.Addresses))
+	}
+	if regional := byValue["x2iedn.large"]; regional.AvailableIn != "us-east-1" || regional.Suggestion != "" {
+		t.Errorf("x2iedn.large: available in %q, suggestion %q", regional.AvailableIn, regional.Suggestion)
+	}
+	if typo := byValue["qq9.huge"]; typo.Suggestion != "" || typo.AvailableIn != "" {
+		t.Errorf("qq9.huge: suggestion %q, available in %q", typo.Suggestion, typo.AvailableIn)
+	}
+
+	warnings := strings.Join(result.Warnings, "\n")
+	for _, want := range []string{"nearest available: m5.large", "priced in us-east-1", `"qq9.huge" is not in the eu-west-3 pricing snapshot`} {
+		if !strings.Contains(warnings, want) {
+			t.Errorf("warnings missing %q:\n%s", want, warnings)
+		}
+	}
+}
+
+func TestNearestGeneration(t *testing.T) {
+	candidates := []string{"db.m5.large", "db.m6g.large", "db.r5.large", "m3.large", "m5.xlarge"}
+	tests := map[string]string{
+		"db.m4.large": "db.m5.large",
+		"db.m7.large": "db.m6g.large",
+		"m1.large":    "m3.large",
+		"db.t2.micro": "",
+		"Standard_D2": "",
+	}
+	for value, want := range tests {
+		if got := nearestGeneration(value, candidates); got != want {
+			t.Errorf("nearestGeneration(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Package engine - Named usage profiles
+// A profile scales default usage so an estimate can describe how an
+// environment is run (24x7 production vs. a dev stack used 8 hours a day).
+// Explicit usage overrides always win over a profile.
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"terraform-cost/core/model"
+)
+
+// UsageProfile scales default usage metrics
+type UsageProfile struct {
+	Name        string
+	Description string
+
+	// UptimeFraction scales hour-based usage (1.0 = always on)
+	UptimeFraction float64
+
+	// TrafficMultiplier scales request, transfer, and other volume metrics
+	TrafficMultiplier float64
+}
+
+// Built-in profile names
+const (
+	ProfileProduction  = "production"
+	ProfileDevelopment = "development"
+	ProfileOffHours    = "off-hours"
+)
+
+var usageProfiles = map[string]UsageProfile{
+	ProfileProduction: {
+		Name:              ProfileProduction,
+		Description:       "always on, full traffic",
+		UptimeFraction:    1.0,
+		TrafficMultiplier: 1.0,
+	},
+	ProfileDevelopment: {
+		Name:              ProfileDevelopment,
+		Description:       "8 hours/day, low traffic",
+		UptimeFraction:    8.0 / 24.0,
+		TrafficMultiplier: 0.1,
+	},
+	ProfileOffHours: {
+		Name:              ProfileOffHours,
+		Description:       "stopped outside business hours (12h x 5 days), reduced traffic",
+		UptimeFraction:    60.0 / 168.0,
+		TrafficMultiplier: 0.5,
+	},
+}
+
+// usageProfileAliases maps short names to built-in profiles
+var usageProfileAliases = map[string]string{
+	"prod": ProfileProduction,
+	"dev":  ProfileDevelopment,
+	"test": ProfileDevelopment,
+}
+
+// LookupUsageProfile returns a built-in profile by name or alias
+func LookupUsageProfile(name string) (UsageProfile, bool) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := usageProfileAliases[key]; ok {
+		key = alias
+	}
+	p, ok := usageProfiles[key]
+	return p, ok
+}
+
+// UsageProfileNames returns the built-in profile names in sorted order
+func UsageProfileNames() []string {
+	names := make([]string, 0, len(usageProfiles))
+	for name := range usageProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProfileUsageEstimator wraps another estimator and scales its output
+type ProfileUsageEstimator struct {
+	inner   UsageEstimator
+	profile UsageProfile
+}
+
+// NewProfileUsageEstimator creates a profile-scaled estimator.
+// inner may be nil, in which case only the uptime fraction is reported.
+func NewProfileUsageEstimator(inner UsageEstimator, profile UsageProfile) *ProfileUsageEstimator {
+	return &ProfileUsageEstimator{inner: inner, profile: profile}
+}
+
+// Profile returns the applied profile
+func (p *ProfileUsageEstimator) Profile() UsageProfile {
+	return p.profile
+}
+
+// Estimate implements UsageEstimator
+func (p *ProfileUsageEstimator) Estimate(ctx context.Context, instance *model.AssetInstance) (*UsageResult, error) {
+	base := &UsageResult{Metrics: map[string]UsageMetric{}, Confidence: 1.0}
+	if p.inner != nil {
+		r, err := p.inner.Estimate(ctx, instance)
+		if err != nil {
+			return nil, fmt.Errorf("usage profile %s: %w", p.profile.Name, err)
+		}
+		if r != nil {
+			base = r
+		}
+	}
+
+	scaled := &UsageResult{
+		Metrics:        make(map[string]UsageMetric, len(base.Metrics)),
+		Source:         base.Source,
+		Confidence:     base.Confidence,
+		UptimeFraction: p.profile.UptimeFraction,
+		Profile:        p.profile.Name,
+	}
+	if base.UptimeFraction > 0 {
+		scaled.UptimeFraction = base.UptimeFraction * p.profile.UptimeFraction
+	}
+
+	for name, m := range base.Metrics {
+		if !m.IsUnknown {
+			if isHourUnit(m.Unit) {
+				m.Value *= p.profile.UptimeFraction
+			} else {
+				m.Value *= p.profile.TrafficMultiplier
+			}
+		}
+		scaled.Metrics[name] = m
+	}
+
+	return scaled, nil
+}
+
+func isHourUnit(unit string) bool {
+	switch strings.ToLower(unit) {
+	case "hour", "hours", "hr", "hrs", "h":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+// Package engine - Usage override validation
+// Checks a usage map against an instance graph before estimating: which
+// instances have usage, which usage-dependent components still have none,
+// and which keys point at nothing (a typo there silently prices defaults).
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"terraform-cost/core/model"
+)
+
+// UsageValidation is the result of validating usage overrides
+type UsageValidation struct {
+	// Covered lists instances with at least one applied override
+	Covered []CoveredUsage
+
+	// Missing lists components whose usage is unknown and not overridden;
+	// they will be priced as symbolic
+	Missing []MissingUsage
+
+	// UnknownKeys lists overrides that match no instance or component
+	UnknownKeys []UnknownUsageKey
+}
+
+// CoveredUsage is an instance with usage provided
+type CoveredUsage struct {
+	InstanceID model.InstanceID
+	Address    model.InstanceAddress
+	Components []string
+}
+
+// MissingUsage is a symbolic component that still lacks usage
+type MissingUsage struct {
+	InstanceID model.InstanceID
+	Address    model.InstanceAddress
+	Component  string
+}
+
+// UnknownUsageKey is an override that will never be applied
+type UnknownUsageKey struct {
+	InstanceID model.InstanceID
+	Component  string // empty when the instance itself is unknown
+	Reason     string
+}
+
+// Valid reports whether every usage-dependent component is covered and
+// every override is applied
+func (v *UsageValidation) Valid() bool {
+	return len(v.Missing) == 0 && len(v.UnknownKeys) == 0
+}
+
+// ValidateUsage checks overrides against the graph using the same component
+// mapping and usage estimation as Estimate. No pricing snapshot is needed.
+func (e *Engine) ValidateUsage(ctx context.Context, graph *model.InstanceGraph, overrides map[model.InstanceID]map[string]float64, usageProfile string) (*UsageValidation, error) {
+	if graph == nil {
+		return nil, fmt.Errorf("instance graph is required")
+	}
+
+	usageEstimator := e.usageEstimator
+	if usageProfile != "" {
+		profile, ok := LookupUsageProfile(usageProfile)
+		if !ok {
+			return nil, fmt.Errorf("unknown usage profile %q (available: %s)",
+				usageProfile, strings.Join(UsageProfileNames(), ", "))
+		}
+		usageEstimator = NewProfileUsageEstimator(usageEstimator, profile)
+	}
+
+	result := &UsageValidation{}
+	instances := make(map[model.InstanceID]*model.AssetInstance)
+	components := make(map[model.InstanceID][]string)
+	byAddress := make(map[string]model.InstanceID)
+
+	for _, inst := range graph.Instances() {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("usage validation canceled: %w", err)
+		}
+		instances[inst.ID] = inst
+		if inst.Metadata.Destroyed {
+			continue
+		}
+		byAddress[string(inst.Address)] = inst.ID
+
+		plugin, ok := e.cloudPlugins[inst.Provider.Type]
+		if !ok {
+			continue
+		}
+		mapped, err := plugin.MapInstance(inst)
+		if err != nil {
+			return nil, fmt.Errorf("failed to map %s: %w", inst.Address, err)
+		}
+
+		var usage *UsageResult
+		if usageEstimator != nil {
+			if usage, err = usageEstimator.Estimate(ctx, inst); err != nil {
+				return nil, fmt.Errorf("failed to estimate usage for %s: %w", inst.Address, err)
+			}
+		}
+
+		instOverrides := overrides[inst.ID]
+		var covered []string
+		for _, comp := range mapped {
+			components[inst.ID] = append(components[inst.ID], comp.Name)
+			if _, ok := instOverrides[comp.Name]; ok {
+				covered = append(covered, comp.Name)
+				continue
+			}
+			if usage != nil && usage.Metrics[comp.Name].IsUnknown {
+				result.Missing = append(result.Missing, MissingUsage{
+					InstanceID: inst.ID,
+					Address:    inst.Address,
+					Component:  comp.Name,
+				})
+			}
+		}
+		if len(covered) > 0 {
+			result.Covered = append(result.Covered, CoveredUsage{
+				InstanceID: inst.ID,
+				Address:    inst.Address,
+				Components: covered,
+			})
+		}
+	}
+
+	for id, comps := range overrides {
+		inst, ok := instances[id]
+		if !ok {
+			reason := "no such instance"
+			if actual, isAddr := byAddress[string(id)]; isAddr {
+				reason = fmt.Sprintf("usage is keyed by instance ID; use %q", actual)
+			}
+			result.UnknownKeys = append(result.UnknownKeys, UnknownUsageKey{InstanceID: id, Reason: reason})
+			continue
+		}
+
+		// An instance that is destroyed or maps to no components exists,
+		// but none of its usage keys will be applied
+		known := components[id]
+		for name := range comps {
+			if containsString(known, name) {
+				continue
+			}
+			reason := "no such component (available: " + strings.Join(known, ", ") + ")"
+			switch {
+			case inst.Metadata.Destroyed:
+				reason = "instance is destroyed by the plan"
+			case len(known) == 0:
+				reason = "instance has no priced components"
+			}
+			result.UnknownKeys = append(result.UnknownKeys, UnknownUsageKey{
+				InstanceID: id,
+				Component:  name,
+				Reason:     reason,
+			})
+		}
+	}
+	sort.Slice(result.UnknownKeys, func(i, j int) bool {
+		a, b := result.UnknownKeys[i], result.UnknownKeys[j]
+		if a.InstanceID != b.InstanceID {
+			return a.InstanceID < b.InstanceID
+		}
+		return a.Component < b.Component
+	})
+
+	return result, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
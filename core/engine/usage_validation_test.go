@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"terraform-cost/core/model"
+)
+
+// TestValidateUsageUnpricedInstances proves override keys for instances in
+// the graph that map to no components are reported against the component,
+// not as a missing instance
+func TestValidateUsageUnpricedInstances(t *testing.T) {
+	eng := newTestEngine(&computePlugin{})
+	graph := newTestGraph(1)
+	graph.AddInstance(&model.AssetInstance{
+		ID:       "gcp-1",
+		Address:  "google_compute_instance.web",
+		Provider: model.ResolvedProvider{Type: "google", Region: "us-central1"},
+	})
+	destroyed := &model.AssetInstance{
+		ID:       "old-1",
+		Address:  "aws_instance.old",
+		Provider: model.ResolvedProvider{Type: "aws", Region: "us-east-1"},
+	}
+	destroyed.Metadata.Destroyed = true
+	graph.AddInstance(destroyed)
+
+	validation, err := eng.ValidateUsage(context.Background(), graph, map[model.InstanceID]map[string]float64{
+		"inst-000": {"compute": 100},
+		"gcp-1":    {"compute": 100},
+		"old-1":    {"compute": 100},
+		"ghost":    {"compute": 100},
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(validation.Covered) != 1 || validation.Covered[0].InstanceID != "inst-000" {
+		t.Errorf("covered = %+v, want inst-000", validation.Covered)
+	}
+	want := map[model.InstanceID]UnknownUsageKey{
+		"gcp-1": {InstanceID: "gcp-1", Component: "compute", Reason: "instance has no priced components"},
+		"ghost": {InstanceID: "ghost", Reason: "no such instance"},
+		"old-1": {InstanceID: "old-1", Component: "compute", Reason: "instance is destroyed by the plan"},
+	}
+	if len(validation.UnknownKeys) != len(want) {
+		t.Fatalf("unknown keys = %+v, want %d", validation.UnknownKeys, len(want))
+	}
+	for _, k := range validation.UnknownKeys {
+		if k != want[k.InstanceID] {
+			t.Errorf("unknown key = %+v, want %+v", k, want[k.InstanceID])
+		}
+	}
+}
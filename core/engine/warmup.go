@@ -0,0 +1,250 @@
+// Package engine - Pricing warm-up
+// The first estimate after startup pays for loading every snapshot it
+// touches. Warm-up loads the configured snapshots ahead of traffic, and
+// CachingResolver keeps them in memory for later estimates, re-reading
+// the active snapshot once its TTL passes or the cache is invalidated.
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/pricing"
+)
+
+// CachingResolver memoizes snapshots of an inner resolver, keyed by
+// provider, region and alias. Point-in-time requests (AsOf set) are never
+// cached. With a TTL, an active snapshot is re-read once it is older than
+// the TTL: the same content hash keeps the cached snapshot, a different
+// one (a newer snapshot was activated) replaces it. Snapshots requested
+// by ID never change, so they never expire. A loaded snapshot whose
+// content hash is already cached is swapped for the cached one, so its
+// lookup index is built once per content hash.
+type CachingResolver struct {
+	inner PricingResolver
+	ttl   time.Duration
+	clock determinism.Clock
+
+	mu        sync.RWMutex
+	snapshots map[SnapshotRequest]cachedSnapshot
+	byHash    map[determinism.ContentHash]*pricing.PricingSnapshot
+}
+
+// cachedSnapshot is a cached snapshot and when it was loaded
+type cachedSnapshot struct {
+	snapshot *pricing.PricingSnapshot
+	loadedAt time.Time
+}
+
+// NewCachingResolver wraps a resolver with an in-memory snapshot cache.
+// Without a TTL (see SetTTL) snapshots are kept until InvalidateCache.
+func NewCachingResolver(inner PricingResolver) *CachingResolver {
+	return &CachingResolver{
+		inner:     inner,
+		snapshots: make(map[SnapshotRequest]cachedSnapshot),
+		byHash:    make(map[determinism.ContentHash]*pricing.PricingSnapshot),
+	}
+}
+
+// SetTTL sets how long an active snapshot is served before it is re-read
+// from the inner resolver (0 = until InvalidateCache)
+func (r *CachingResolver) SetTTL(ttl time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ttl = ttl
+}
+
+// TTL returns how long an active snapshot is served before it is re-read
+func (r *CachingResolver) TTL() time.Duration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.ttl
+}
+
+// SetClock sets the clock snapshot ages are measured with
+func (r *CachingResolver) SetClock(clock determinism.Clock) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clock = clock
+}
+
+// now returns the current time from the resolver's clock; callers hold mu
+func (r *CachingResolver) now() time.Time {
+	if r.clock == nil {
+		return determinism.DefaultClock.Now()
+	}
+	return r.clock.Now()
+}
+
+// cacheKey is the cache key of req: the default alias is spelled out so
+// "" and "default" share an entry
+func cacheKey(req SnapshotRequest) SnapshotRequest {
+	if req.Alias == "" {
+		req.Alias = DefaultProviderAlias
+	}
+	return req
+}
+
+// GetSnapshot returns a cached snapshot or loads it from the inner resolver
+func (r *CachingResolver) GetSnapshot(ctx context.Context, req SnapshotRequest) (*pricing.PricingSnapshot, error) {
+	key := cacheKey(req)
+	if req.AsOf == nil {
+		r.mu.RLock()
+		entry, ok := r.snapshots[key]
+		fresh := ok && (r.ttl <= 0 || key.SnapshotID != "" || r.now().Sub(entry.loadedAt) < r.ttl)
+		r.mu.RUnlock()
+		if fresh {
+			return entry.snapshot, nil
+		}
+	}
+
+	snapshot, err := r.inner.GetSnapshot(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if held, ok := r.byHash[snapshot.ContentHash]; ok {
+		snapshot = held
+	}
+	if req.AsOf == nil {
+		previous, replaced := r.snapshots[key]
+		r.byHash[snapshot.ContentHash] = snapshot
+		r.snapshots[key] = cachedSnapshot{snapshot: snapshot, loadedAt: r.now()}
+		if replaced && previous.snapshot.ContentHash != snapshot.ContentHash {
+			r.release(previous.snapshot.ContentHash)
+		}
+	}
+	return snapshot, nil
+}
+
+// release drops a content hash no cached entry uses any more; callers
+// hold mu
+func (r *CachingResolver) release(hash determinism.ContentHash) {
+	for _, entry := range r.snapshots {
+		if entry.snapshot.ContentHash == hash {
+			return
+		}
+	}
+	delete(r.byHash, hash)
+}
+
+// InvalidateCache drops every cached snapshot, so the next request for
+// each reads the active snapshot again. Call it after activating or
+// deactivating a snapshot for the change to take effect before the TTL.
+func (r *CachingResolver) InvalidateCache() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.snapshots = make(map[SnapshotRequest]cachedSnapshot)
+	r.byHash = make(map[determinism.ContentHash]*pricing.PricingSnapshot)
+}
+
+// LookupRate delegates to the inner resolver
+func (r *CachingResolver) LookupRate(snapshot *pricing.PricingSnapshot, resourceType, component string, attrs map[string]string) (*pricing.RateEntry, error) {
+	return r.inner.LookupRate(snapshot, resourceType, component, attrs)
+}
+
+// Size returns the number of cached snapshots
+func (r *CachingResolver) Size() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.snapshots)
+}
+
+// SnapshotCacheInvalidator is a pricing resolver whose snapshot cache can
+// be dropped
+type SnapshotCacheInvalidator interface {
+	InvalidateCache()
+}
+
+// InvalidatePricingCache drops the pricing resolver's cached snapshots;
+// it reports false when the resolver does not cache them
+func (e *Engine) InvalidatePricingCache() bool {
+	invalidator, ok := e.pricingResolver.(SnapshotCacheInvalidator)
+	if ok {
+		invalidator.InvalidateCache()
+	}
+	return ok
+}
+
+// WarmupReport is the outcome of loading snapshots ahead of traffic
+type WarmupReport struct {
+	Targets  []WarmupTarget
+	Duration time.Duration
+}
+
+// WarmupTarget is one provider/region/alias snapshot load
+type WarmupTarget struct {
+	Provider   string
+	Region     string
+	Alias      string
+	SnapshotID pricing.SnapshotID
+	Rates      int
+	Err        error
+}
+
+// Ready reports whether every target loaded
+func (r *WarmupReport) Ready() bool {
+	for _, t := range r.Targets {
+		if t.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Snapshots returns the number of snapshots loaded
+func (r *WarmupReport) Snapshots() int {
+	n := 0
+	for _, t := range r.Targets {
+		if t.Err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Rates returns the number of rates across loaded snapshots
+func (r *WarmupReport) Rates() int {
+	n := 0
+	for _, t := range r.Targets {
+		n += t.Rates
+	}
+	return n
+}
+
+// Warmup loads the active snapshot of each target through the pricing
+// resolver, so a CachingResolver serves them from memory afterwards.
+// A target that fails to load is reported, not returned as an error.
+func (e *Engine) Warmup(ctx context.Context, targets []SnapshotRequest) (*WarmupReport, error) {
+	start := time.Now()
+	report := &WarmupReport{Targets: make([]WarmupTarget, 0, len(targets))}
+
+	for _, req := range targets {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("warm-up canceled: %w", err)
+		}
+		if req.Alias == "" {
+			req.Alias = DefaultProviderAlias
+		}
+
+		target := WarmupTarget{Provider: req.Provider, Region: req.Region, Alias: req.Alias}
+		snapshot, err := e.pricingResolver.GetSnapshot(ctx, req)
+		switch {
+		case err != nil:
+			target.Err = err
+		case snapshot == nil:
+			target.Err = fmt.Errorf("no active snapshot for %s/%s", req.Provider, req.Region)
+		default:
+			target.SnapshotID = snapshot.ID
+			target.Rates = len(snapshot.Rates())
+		}
+		report.Targets = append(report.Targets, target)
+	}
+
+	report.Duration = time.Since(start)
+	return report, nil
+}
@@ -0,0 +1,146 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/pricing"
+)
+
+// swappingResolver serves whichever snapshot is currently active
+type swappingResolver struct {
+	staticResolver
+
+	mu     sync.Mutex
+	active *pricing.PricingSnapshot
+	loads  int
+}
+
+func (r *swappingResolver) GetSnapshot(ctx context.Context, req SnapshotRequest) (*pricing.PricingSnapshot, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loads++
+	return r.active, nil
+}
+
+func (r *swappingResolver) activate(s *pricing.PricingSnapshot) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.active = s
+}
+
+// steppingClock is a clock tests move forward by hand
+type steppingClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *steppingClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *steppingClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func computeSnapshot(price float64) *pricing.PricingSnapshot {
+	return pricing.NewSnapshotBuilder("aws", "us-east-1").
+		AddRate(pricing.RateKey{ResourceType: "aws_instance", Component: "compute"}, decimal.NewFromFloat(price), "hour", "USD").
+		Build()
+}
+
+// TestCachingResolverTTL proves concurrent estimates share one cached
+// snapshot per provider/region/alias, a newer active snapshot replaces it
+// once the TTL passes, an unchanged one is kept, and InvalidateCache
+// takes effect before the TTL
+func TestCachingResolverTTL(t *testing.T) {
+	old, newer := computeSnapshot(0.1), computeSnapshot(0.2)
+	if old.ContentHash == newer.ContentHash {
+		t.Fatal("snapshots with different rates share a content hash")
+	}
+	inner := &swappingResolver{active: old}
+	clock := &steppingClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	r := NewCachingResolver(inner)
+	r.SetTTL(time.Minute)
+	r.SetClock(clock)
+
+	// hammer reads the cache from many goroutines, returning the content
+	// hashes they saw
+	hammer := func(during func()) map[string]bool {
+		var mu sync.Mutex
+		seen := make(map[string]bool)
+		var wg sync.WaitGroup
+		for g := 0; g < 16; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				alias := ""
+				if g%2 == 0 {
+					alias = DefaultProviderAlias
+				}
+				for i := 0; i < 200; i++ {
+					s, err := r.GetSnapshot(context.Background(), SnapshotRequest{Provider: "aws", Region: "us-east-1", Alias: alias})
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					mu.Lock()
+					seen[s.ContentHash.Hex()] = true
+					mu.Unlock()
+				}
+			}(g)
+		}
+		if during != nil {
+			during()
+		}
+		wg.Wait()
+		return seen
+	}
+
+	if seen := hammer(nil); len(seen) != 1 || !seen[old.ContentHash.Hex()] {
+		t.Fatalf("first reads saw %v, want only the old snapshot", seen)
+	}
+	if r.Size() != 1 {
+		t.Errorf("cache holds %d snapshots; \"\" and the default alias should share one", r.Size())
+	}
+	if inner.loads > 16 {
+		t.Errorf("%d loads for 3200 reads; the cache is not shared", inner.loads)
+	}
+
+	// A newer snapshot is activated: the cached one is served until the
+	// TTL passes, and the flip is picked up while reads are in flight
+	inner.activate(newer)
+	if s, _ := r.GetSnapshot(context.Background(), SnapshotRequest{Provider: "aws", Region: "us-east-1"}); s != old {
+		t.Error("a snapshot within its TTL was re-read")
+	}
+	hammer(func() { clock.advance(2 * time.Minute) })
+	if s, _ := r.GetSnapshot(context.Background(), SnapshotRequest{Provider: "aws", Region: "us-east-1"}); s.ContentHash != newer.ContentHash {
+		t.Error("the newer active snapshot did not replace the expired one")
+	}
+	if _, ok := r.byHash[old.ContentHash]; ok {
+		t.Error("the replaced snapshot is still held")
+	}
+
+	// An unchanged snapshot re-read after the TTL keeps the cached one and
+	// its lookup index
+	inner.activate(computeSnapshot(0.2))
+	clock.advance(2 * time.Minute)
+	if s, _ := r.GetSnapshot(context.Background(), SnapshotRequest{Provider: "aws", Region: "us-east-1"}); s != newer {
+		t.Error("a re-read with the same content hash replaced the cached snapshot")
+	}
+
+	// InvalidateCache takes a flip before the TTL passes
+	inner.activate(old)
+	r.InvalidateCache()
+	if s, _ := r.GetSnapshot(context.Background(), SnapshotRequest{Provider: "aws", Region: "us-east-1"}); s != old {
+		t.Error("InvalidateCache did not drop the cached snapshot")
+	}
+}
@@ -0,0 +1,81 @@
+// Package engine - Workspace comparison
+// One configuration often serves several environments through
+// workspaces, sizing resources by terraform.workspace. Comparing them
+// means expanding the configuration once per workspace and pricing each
+// graph against the same snapshot, so the differences come from the
+// configuration alone.
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"terraform-cost/core/determinism"
+)
+
+// WorkspaceComparison is one configuration priced per workspace
+type WorkspaceComparison struct {
+	// Workspaces holds one estimate per priced workspace, in request order
+	Workspaces []*WorkspaceEstimate
+
+	// Warnings names the workspaces that could not be priced
+	Warnings []string
+}
+
+// WorkspaceEstimate is the estimate for one workspace
+type WorkspaceEstimate struct {
+	Workspace string
+	Result    *EstimationResult
+
+	// ByService sums displayed monthly costs per service
+	// (cost.ServiceForResourceType)
+	ByService map[string]determinism.Money
+}
+
+// Services returns every service priced in any workspace, sorted
+func (c *WorkspaceComparison) Services() []string {
+	byService := make([]map[string]determinism.Money, len(c.Workspaces))
+	for i, w := range c.Workspaces {
+		byService[i] = w.ByService
+	}
+	return sortedServices(byService)
+}
+
+// CompareWorkspaces estimates each request, one per workspace: its Graph
+// expanded for req.Workspace. OnInstanceCost is not called, since
+// per-service totals need every instance cost.
+//
+// Workspaces that fail to estimate become warnings. An error is returned
+// only when ctx is canceled or no workspace could be priced.
+func (e *Engine) CompareWorkspaces(ctx context.Context, reqs []*EstimateRequest) (*WorkspaceComparison, error) {
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("no workspaces to compare")
+	}
+
+	comparison := &WorkspaceComparison{}
+	for _, req := range reqs {
+		workspaceReq := *req
+		workspaceReq.OnInstanceCost = nil
+
+		result, err := e.Estimate(ctx, &workspaceReq)
+		if err != nil {
+			if ctx.Err() != nil {
+				return comparison, fmt.Errorf("workspace comparison canceled: %w", ctx.Err())
+			}
+			comparison.Warnings = append(comparison.Warnings,
+				fmt.Sprintf("workspace %s skipped: %v", req.Workspace, err))
+			continue
+		}
+		comparison.Workspaces = append(comparison.Workspaces, &WorkspaceEstimate{
+			Workspace: req.Workspace,
+			Result:    result,
+			ByService: serviceTotals(result),
+		})
+	}
+
+	if len(comparison.Workspaces) == 0 {
+		return comparison, fmt.Errorf("no workspace could be priced: %s", strings.Join(comparison.Warnings, "; "))
+	}
+	return comparison, nil
+}
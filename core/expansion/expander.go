@@ -5,6 +5,7 @@ package expansion
 import (
 	"fmt"
 	"sort"
+	"strings"
 
 	"terraform-cost/core/expression"
 	"terraform-cost/core/types"
@@ -112,16 +113,18 @@ func NewExpander() *Expander {
 	}
 }
 
-// Expand expands a single asset into instances
+// Expand expands a single asset into instances. A known count of 0 or an
+// empty for_each yields no instances (and so no cost); an unknown one
+// yields a placeholder instance with IsKnown false, to be costed as symbolic.
 func (e *Expander) Expand(asset *types.Asset, ctx *expression.Context) ([]*AssetInstance, error) {
 	// Check for count meta-argument
-	if countAttr := asset.Attributes.Get("count"); countAttr != nil {
-		return e.expandCount(asset, countAttr, ctx)
+	if countAttr, ok := asset.Attributes["count"]; ok {
+		return e.expandCount(asset, metaArgument(countAttr), ctx)
 	}
 
 	// Check for for_each meta-argument
-	if forEachAttr := asset.Attributes.Get("for_each"); forEachAttr != nil {
-		return e.expandForEach(asset, forEachAttr, ctx)
+	if forEachAttr, ok := asset.Attributes["for_each"]; ok {
+		return e.expandForEach(asset, metaArgument(forEachAttr), ctx)
 	}
 
 	// No expansion - return single instance
@@ -144,10 +147,17 @@ func (e *Expander) Expand(asset *types.Asset, ctx *expression.Context) ([]*Asset
 func (e *Expander) expandCount(asset *types.Asset, countVal interface{}, ctx *expression.Context) ([]*AssetInstance, error) {
 	count, isKnown := e.resolveCount(countVal, ctx)
 
-	if count == 0 {
-		// count = 0 means no instances
+	if isKnown && count == 0 {
+		// count = 0 means no instances, not an unknown count
 		return []*AssetInstance{}, nil
 	}
+	if isKnown && count < 0 {
+		return nil, fmt.Errorf("%s: count must be non-negative, got %d", asset.Address, count)
+	}
+	if !isKnown && count < 1 {
+		// An unknown count always leaves a placeholder to cost as symbolic
+		count = 1
+	}
 
 	instances := make([]*AssetInstance, count)
 	for i := 0; i < count; i++ {
@@ -162,7 +172,7 @@ func (e *Expander) expandCount(asset *types.Asset, countVal interface{}, ctx *ex
 			Base:       asset,
 			Key:        key,
 			Address:    addr,
-			Attributes: asset.Attributes,
+			Attributes: resolveInstanceAttributes(asset.Attributes, instanceCtx),
 			Metadata: InstanceMetadata{
 				ExpansionType:   ExpansionCount,
 				OriginalAddress: asset.Address,
@@ -171,7 +181,7 @@ func (e *Expander) expandCount(asset *types.Asset, countVal interface{}, ctx *ex
 		}
 
 		if !isKnown {
-			instances[i].Metadata.Warning = "count could not be determined; assuming 1"
+			instances[i].Metadata.Warning = fmt.Sprintf("count could not be determined; assuming %d", count)
 		}
 	}
 
@@ -200,15 +210,11 @@ func (e *Expander) resolveCount(countVal interface{}, ctx *expression.Context) (
 		}
 	}
 
-	// If it's a string reference, try to resolve
+	// If it's a string reference or conditional, try to resolve
 	if s, ok := countVal.(string); ok {
-		ref, err := expression.ParseReference(s)
-		if err == nil && ctx != nil {
-			resolved, err := ctx.Resolve(ref)
-			if err == nil && !resolved.IsUnknown() {
-				if n, err := resolved.AsInt(); err == nil {
-					return int(n), true
-				}
+		if resolved, ok := evaluateCountExpression(s, ctx); ok {
+			if n, err := resolved.AsInt(); err == nil {
+				return int(n), true
 			}
 		}
 	}
@@ -221,7 +227,26 @@ func (e *Expander) resolveCount(countVal interface{}, ctx *expression.Context) (
 func (e *Expander) expandForEach(asset *types.Asset, forEachVal interface{}, ctx *expression.Context) ([]*AssetInstance, error) {
 	keys, values, isKnown := e.resolveForEach(forEachVal, ctx)
 
+	if !isKnown {
+		// An unknown for_each leaves a placeholder to cost as symbolic
+		return []*AssetInstance{
+			{
+				Base:       asset,
+				Key:        InstanceKey{Type: KeyTypeNone},
+				Address:    asset.Address,
+				Attributes: asset.Attributes,
+				Metadata: InstanceMetadata{
+					ExpansionType:   ExpansionUnknown,
+					OriginalAddress: asset.Address,
+					IsKnown:         false,
+					Warning:         "for_each could not be determined",
+				},
+			},
+		}, nil
+	}
+
 	if len(keys) == 0 {
+		// An empty for_each means no instances, not an unknown one
 		return []*AssetInstance{}, nil
 	}
 
@@ -239,22 +264,50 @@ func (e *Expander) expandForEach(asset *types.Asset, forEachVal interface{}, ctx
 			Key:        instanceKey,
 			Address:    addr,
 			EachValue:  values[key],
-			Attributes: asset.Attributes,
+			Attributes: resolveInstanceAttributes(asset.Attributes, instanceCtx),
 			Metadata: InstanceMetadata{
 				ExpansionType:   ExpansionForEach,
 				OriginalAddress: asset.Address,
-				IsKnown:         isKnown,
+				IsKnown:         true,
 			},
 		}
-
-		if !isKnown {
-			instances[i].Metadata.Warning = "for_each could not be determined"
-		}
 	}
 
 	return instances, nil
 }
 
+// resolveInstanceAttributes evaluates deferred reference expressions
+// (count.index, each.key, each.value.<attr>) in the per-instance context.
+// Attributes that cannot be resolved are kept unchanged.
+func resolveInstanceAttributes(attrs types.Attributes, ctx *expression.Context) types.Attributes {
+	if ctx == nil {
+		return attrs
+	}
+
+	resolved := make(types.Attributes, len(attrs))
+	for name, attr := range attrs {
+		resolved[name] = attr
+		if attr.Value != nil || attr.Expression == "" || name == "count" || name == "for_each" {
+			continue
+		}
+
+		ref, err := expression.ParseReference(attr.Expression)
+		if err != nil || (ref.Kind != expression.RefEach && ref.Kind != expression.RefCount) {
+			continue
+		}
+		val, err := ctx.Resolve(ref)
+		if err != nil || !val.IsKnown() || val.IsNull() {
+			continue
+		}
+
+		attr.Value = val.ToGo()
+		attr.IsComputed = false
+		attr.IsUnknown = false
+		resolved[name] = attr
+	}
+	return resolved
+}
+
 // resolveForEach attempts to resolve a for_each value to keys and values
 func (e *Expander) resolveForEach(forEachVal interface{}, ctx *expression.Context) ([]string, map[string]expression.Value, bool) {
 	values := make(map[string]expression.Value)
@@ -283,6 +336,15 @@ func (e *Expander) resolveForEach(forEachVal interface{}, ctx *expression.Contex
 		return keys, values, true
 	}
 
+	// If it's a reference, try to resolve
+	if s, ok := forEachVal.(string); ok {
+		resolved, ok := evaluateCountExpression(s, ctx)
+		if !ok {
+			return nil, nil, false
+		}
+		forEachVal = resolved
+	}
+
 	// If it's an expression.Value
 	if v, ok := forEachVal.(expression.Value); ok {
 		if v.IsUnknown() {
@@ -318,6 +380,90 @@ func (e *Expander) resolveForEach(forEachVal interface{}, ctx *expression.Contex
 	return nil, nil, false
 }
 
+// metaArgument returns the value of a count/for_each attribute, falling back
+// to its source expression when the value was not evaluated
+func metaArgument(attr types.Attribute) interface{} {
+	switch {
+	case attr.IsUnknown:
+		return expression.Unknown()
+	case attr.Value != nil:
+		return attr.Value
+	case attr.Expression != "":
+		return attr.Expression
+	default:
+		return expression.Unknown()
+	}
+}
+
+// evaluateCountExpression resolves a reference such as var.replicas, or the
+// common toggle `cond ? a : b` whose condition and branches are literals or
+// references. It reports false when the result cannot be known pre-apply.
+func evaluateCountExpression(expr string, ctx *expression.Context) (expression.Value, bool) {
+	expr = strings.TrimSpace(expr)
+
+	if q := strings.Index(expr, "?"); q > 0 {
+		c := strings.Index(expr[q:], ":")
+		if c < 0 {
+			return expression.Unknown(), false
+		}
+		cond, ok := evaluateOperand(expr[:q], ctx)
+		if !ok {
+			return expression.Unknown(), false
+		}
+		b, err := cond.AsBool()
+		if err != nil {
+			return expression.Unknown(), false
+		}
+		if b {
+			return evaluateOperand(expr[q+1:q+c], ctx)
+		}
+		return evaluateOperand(expr[q+c+1:], ctx)
+	}
+
+	return evaluateOperand(expr, ctx)
+}
+
+// evaluateOperand resolves a literal, a reference, or a negated one
+func evaluateOperand(operand string, ctx *expression.Context) (expression.Value, bool) {
+	operand = strings.TrimSpace(operand)
+	if strings.HasPrefix(operand, "(") && strings.HasSuffix(operand, ")") {
+		operand = strings.TrimSpace(operand[1 : len(operand)-1])
+	}
+
+	if strings.HasPrefix(operand, "!") {
+		v, ok := evaluateOperand(operand[1:], ctx)
+		if !ok {
+			return expression.Unknown(), false
+		}
+		b, err := v.AsBool()
+		if err != nil {
+			return expression.Unknown(), false
+		}
+		return expression.Bool(!b), true
+	}
+
+	switch operand {
+	case "true":
+		return expression.Bool(true), true
+	case "false":
+		return expression.Bool(false), true
+	}
+	var n int64
+	if _, err := fmt.Sscan(operand, &n); err == nil && fmt.Sprint(n) == operand {
+		return expression.NumberFromInt(n), true
+	}
+
+	ref, err := expression.ParseReference(operand)
+	if err != nil || ctx == nil {
+		return expression.Unknown(), false
+	}
+	resolved, err := ctx.Resolve(ref)
+	if err != nil || resolved.IsUnknown() {
+		return expression.Unknown(), false
+	}
+	return resolved, true
+}
+
 // ExpandAll expands all assets in a graph
 func (e *Expander) ExpandAll(assets []*types.Asset, ctx *expression.Context) ([]*AssetInstance, error) {
 	var allInstances []*AssetInstance
@@ -170,6 +170,57 @@ func TestForEachExpansion(t *testing.T) {
 	}
 }
 
+// TestForEachMapOfObjects tests that each.value attributes resolve per instance
+func TestForEachMapOfObjects(t *testing.T) {
+	expander := NewExpander()
+	ctx := expression.NewContext()
+
+	asset := &types.Asset{
+		Address: "aws_instance.fleet",
+		Type:    "aws_instance",
+		Name:    "fleet",
+		Attributes: types.Attributes{
+			"for_each": {Value: map[string]interface{}{
+				"api":    map[string]interface{}{"size": "m5.large", "disk": 100},
+				"worker": map[string]interface{}{"size": "c5.xlarge", "disk": 50},
+			}},
+			"instance_type": {Expression: "each.value.size", IsComputed: true},
+			"name":          {Expression: "each.key", IsComputed: true},
+			"ami":           {Value: "ami-123"},
+		},
+	}
+
+	instances, err := expander.Expand(asset, ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(instances) != 2 {
+		t.Fatalf("expected 2 instances, got %d", len(instances))
+	}
+
+	want := map[string]string{"api": "m5.large", "worker": "c5.xlarge"}
+	for _, inst := range instances {
+		key := inst.Key.StrValue
+		if got := inst.Attributes.GetString("instance_type"); got != want[key] {
+			t.Errorf("%s: instance_type = %q, want %q", inst.Address, got, want[key])
+		}
+		if inst.Attributes["instance_type"].IsComputed {
+			t.Errorf("%s: resolved attribute should not be computed", inst.Address)
+		}
+		if got := inst.Attributes.GetString("name"); got != key {
+			t.Errorf("%s: name = %q, want %q", inst.Address, got, key)
+		}
+		if got := inst.Attributes.GetString("ami"); got != "ami-123" {
+			t.Errorf("%s: literal attribute lost, got %q", inst.Address, got)
+		}
+	}
+
+	// The base asset must not be mutated by per-instance resolution
+	if asset.Attributes["instance_type"].Value != nil {
+		t.Error("base asset attributes were modified")
+	}
+}
+
 // TestNoExpansion tests that assets without count/for_each produce single instance
 func TestNoExpansion(t *testing.T) {
 	expander := NewExpander()
@@ -309,3 +360,79 @@ func TestExpandedGraph(t *testing.T) {
 		t.Errorf("expected 2 instances for base address, got %d", len(baseInstances))
 	}
 }
+
+// TestZeroCountIsNoCost proves a legitimately-zero count or empty for_each
+// yields no instances, while an unknown one still yields a placeholder
+func TestZeroCountIsNoCost(t *testing.T) {
+	expander := NewExpander()
+	toggle := func(attr string, value types.Attribute) *types.Asset {
+		return &types.Asset{
+			Address:    "aws_nat_gateway.this",
+			Type:       "aws_nat_gateway",
+			Name:       "this",
+			Attributes: types.Attributes{attr: value},
+		}
+	}
+
+	disabled := expression.NewContext()
+	disabled.SetVariable("enabled", expression.Bool(false))
+	enabled := expression.NewContext()
+	enabled.SetVariable("enabled", expression.Bool(true))
+	unset := expression.NewContext()
+
+	tests := []struct {
+		name      string
+		asset     *types.Asset
+		ctx       *expression.Context
+		wantCount int
+		wantKnown bool
+	}{
+		{"count toggled off", toggle("count", types.Attribute{Expression: "var.enabled ? 1 : 0"}), disabled, 0, true},
+		{"count toggled on", toggle("count", types.Attribute{Expression: "var.enabled ? 1 : 0"}), enabled, 1, true},
+		{"negated toggle", toggle("count", types.Attribute{Expression: "!var.enabled ? 2 : 0"}), disabled, 2, true},
+		{"count unknown", toggle("count", types.Attribute{Expression: "var.enabled ? 1 : 0"}), unset, 1, false},
+		{"count computed", toggle("count", types.Attribute{IsUnknown: true}), enabled, 1, false},
+		{"empty for_each", toggle("for_each", types.Attribute{Value: map[string]interface{}{}}), enabled, 0, true},
+		{"for_each unknown", toggle("for_each", types.Attribute{Expression: "var.subnets"}), unset, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instances, err := expander.Expand(tt.asset, tt.ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(instances) != tt.wantCount {
+				t.Fatalf("expected %d instances, got %d", tt.wantCount, len(instances))
+			}
+			for _, inst := range instances {
+				if inst.Metadata.IsKnown != tt.wantKnown {
+					t.Errorf("IsKnown = %v, want %v", inst.Metadata.IsKnown, tt.wantKnown)
+				}
+				if !tt.wantKnown && inst.Metadata.Warning == "" {
+					t.Error("placeholder should carry a warning")
+				}
+			}
+		})
+	}
+
+	// Even with no default, an unknown count is never mistaken for zero
+	noDefault := &Expander{DefaultCountOnUnknown: 0}
+	instances, err := noDefault.Expand(toggle("count", types.Attribute{Value: expression.Unknown()}), unset)
+	if err != nil || len(instances) != 1 || instances[0].Metadata.IsKnown {
+		t.Errorf("unknown count should yield one placeholder, got %d (err %v)", len(instances), err)
+	}
+
+	// The sealed expander agrees: zero is empty, not symbolic
+	sealed := NewSealedExpander(false)
+	if result := sealed.TryExpandCount("aws_nat_gateway.this", 0, true); result.Outcome != OutcomeEmpty || result.IsSymbolic() {
+		t.Errorf("count = 0 should be empty, got outcome %v", result.Outcome)
+	}
+	if result := sealed.TryExpandCount("aws_nat_gateway.this", nil, false); !result.IsSymbolic() {
+		t.Errorf("unknown count should be symbolic, got outcome %v", result.Outcome)
+	}
+
+	if _, err := expander.Expand(toggle("count", types.Attribute{Value: -1}), enabled); err == nil {
+		t.Error("negative count should be an error")
+	}
+}
@@ -283,7 +283,16 @@ func (c *Context) resolveEach(ref *Reference) (Value, error) {
 		if c.eachKey == nil {
 			return Unknown(), fmt.Errorf("each.value not available in this context")
 		}
-		return c.eachValue, nil
+		// each.value.size - traverse into the element object
+		val := c.eachValue
+		for _, seg := range ref.Remaining {
+			attr, err := val.GetAttr(seg)
+			if err != nil {
+				return Unknown(), nil
+			}
+			val = attr
+		}
+		return val, nil
 	default:
 		return Unknown(), fmt.Errorf("each only has 'key' and 'value' attributes")
 	}
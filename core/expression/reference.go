@@ -158,6 +158,9 @@ func ParseReference(ref string) (*Reference, error) {
 		if len(parts) > 1 {
 			result.Attribute = parts[1] // key or value
 		}
+		if len(parts) > 2 {
+			result.Remaining = parts[2:] // each.value.<attr>...
+		}
 
 	case "path":
 		result.Kind = RefPath
@@ -188,7 +191,8 @@ func ParseReference(ref string) (*Reference, error) {
 	}
 
 	// Extract attribute from remaining if present
-	if len(result.Remaining) > 0 {
+	// (each already carries key/value as its attribute)
+	if len(result.Remaining) > 0 && result.Kind != RefEach {
 		result.Attribute = result.Remaining[0]
 		result.Remaining = result.Remaining[1:]
 	}
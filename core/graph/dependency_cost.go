@@ -4,6 +4,8 @@
 package graph
 
 import (
+	"sort"
+
 	"terraform-cost/core/cost"
 	"terraform-cost/core/model"
 )
@@ -216,6 +218,35 @@ type DependencyLink struct {
 	Relation string
 }
 
+// GetServiceAggregates returns per-service cost aggregates sorted by service.
+// The project root also holds every node directly, so summing services (not
+// reading the root) is the way to get an un-duplicated total.
+func (g *DependencyAwareCostGraph) GetServiceAggregates() []*cost.CostAggregate {
+	if g.costs == nil {
+		return nil
+	}
+	services := append([]*cost.CostAggregate(nil), g.costs.ByLevel[cost.LevelService]...)
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+	return services
+}
+
+// GetCostNodes returns every cost node sorted by instance ID
+func (g *DependencyAwareCostGraph) GetCostNodes() []*cost.CostNode {
+	if g.costs == nil {
+		return nil
+	}
+	nodes := make([]*cost.CostNode, 0, len(g.costs.NodesByID))
+	for _, node := range g.costs.NodesByID {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].InstanceID < nodes[j].InstanceID
+	})
+	return nodes
+}
+
 // CalculateChangeCost calculates cost change for a set of changed nodes
 func (g *DependencyAwareCostGraph) CalculateChangeCost(changedNodes []string) *ChangeCostAnalysis {
 	analysis := &ChangeCostAnalysis{
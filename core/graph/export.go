@@ -0,0 +1,182 @@
+// Package graph - Dependency graph export
+// The infrastructure graph decides which resources a cost depends on, so a
+// missing edge shows up as a wrong or symbolic cost. Exporting it as DOT
+// (for Graphviz) or JSON lets users see the edges the estimate used.
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"terraform-cost/core/determinism"
+)
+
+// GraphExport is the JSON form of an infrastructure graph
+type GraphExport struct {
+	Nodes []ExportNode `json:"nodes"`
+	Edges []ExportEdge `json:"edges"`
+}
+
+// ExportNode is one node of an exported graph
+type ExportNode struct {
+	Address string `json:"address"`
+	Kind    string `json:"kind"`
+
+	// ResourceType is set for resources and data sources (e.g. aws_instance)
+	ResourceType string `json:"resource_type,omitempty"`
+	ModulePath   string `json:"module_path,omitempty"`
+	SourceFile   string `json:"source_file,omitempty"`
+	SourceLine   int    `json:"source_line,omitempty"`
+
+	// MonthlyCost is set when the node was priced
+	MonthlyCost *determinism.Money `json:"monthly_cost,omitempty"`
+}
+
+// ExportEdge is a dependency: From depends on To. Kind is "depends_on"
+// for an explicit dependency and "reference" for an expression or module
+// output reference.
+type ExportEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+}
+
+// Export returns the graph's nodes and edges, sorted by address
+func (g *InfrastructureGraph) Export() *GraphExport {
+	export := &GraphExport{
+		Nodes: make([]ExportNode, 0, len(g.nodes)),
+		Edges: make([]ExportEdge, 0, g.EdgeCount()),
+	}
+	for _, addr := range g.sortedAddresses() {
+		node := g.nodes[addr]
+		export.Nodes = append(export.Nodes, ExportNode{
+			Address:      addr,
+			Kind:         node.Type.String(),
+			ResourceType: resourceType(node),
+			ModulePath:   node.ModulePath,
+			SourceFile:   node.SourceFile,
+			SourceLine:   node.SourceLine,
+			MonthlyCost:  node.MonthlyCost,
+		})
+
+		seen := make(map[string]bool, len(g.edges[addr]))
+		deps := append([]string(nil), g.edges[addr]...)
+		sort.Strings(deps)
+		for _, to := range deps {
+			if seen[to] {
+				continue
+			}
+			seen[to] = true
+			kind := EdgeReference
+			for _, explicit := range node.ExplicitDeps {
+				if explicit == to {
+					kind = EdgeDependsOn
+					break
+				}
+			}
+			export.Edges = append(export.Edges, ExportEdge{From: addr, To: to, Kind: kind.String()})
+		}
+	}
+	return export
+}
+
+// ExportJSON writes the graph as indented JSON (see GraphExport)
+func (g *InfrastructureGraph) ExportJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(g.Export())
+}
+
+// ExportDOT writes the graph in Graphviz DOT. Each node is labelled with
+// its address, resource type and monthly cost when priced; depends_on
+// edges are dashed.
+func (g *InfrastructureGraph) ExportDOT(w io.Writer) error {
+	export := g.Export()
+
+	var sb strings.Builder
+	sb.WriteString("digraph infrastructure {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=box, fontname=\"Helvetica\"];\n")
+	for _, node := range export.Nodes {
+		label := []string{node.Address}
+		if node.ResourceType != "" {
+			label = append(label, node.ResourceType)
+		} else {
+			label = append(label, node.Kind)
+		}
+		if node.MonthlyCost != nil {
+			label = append(label, node.MonthlyCost.Format(determinism.DisplayPlaces)+"/month")
+		}
+		attrs := fmt.Sprintf("label=%s", dotQuote(strings.Join(label, "\n")))
+		switch node.Kind {
+		case "data":
+			attrs += ", shape=ellipse"
+		case "module":
+			attrs += ", shape=folder"
+		}
+		fmt.Fprintf(&sb, "  %s [%s];\n", dotQuote(node.Address), attrs)
+	}
+	for _, edge := range export.Edges {
+		style := ""
+		if edge.Kind == EdgeDependsOn.String() {
+			style = " [style=dashed]"
+		}
+		fmt.Fprintf(&sb, "  %s -> %s%s;\n", dotQuote(edge.From), dotQuote(edge.To), style)
+	}
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// sortedAddresses returns every node address in order
+func (g *InfrastructureGraph) sortedAddresses() []string {
+	addrs := make([]string, 0, len(g.nodes))
+	for addr := range g.nodes {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+// resourceType extracts the type from a resource or data source address:
+// module.vpc.aws_subnet.main[0] → aws_subnet
+func resourceType(node *InfraNode) string {
+	if node.Type != NodeResource && node.Type != NodeDataSource {
+		return ""
+	}
+	addr := node.Address
+	for strings.HasPrefix(addr, "module.") {
+		// The module name may carry a for_each key with dots in it
+		rest := addr[len("module."):]
+		depth, next := 0, -1
+		for i, c := range rest {
+			if c == '[' {
+				depth++
+			} else if c == ']' {
+				depth--
+			} else if c == '.' && depth == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return ""
+		}
+		addr = rest[next+1:]
+	}
+	addr = strings.TrimPrefix(addr, "data.")
+	if i := strings.Index(addr, "."); i > 0 {
+		return addr[:i]
+	}
+	return ""
+}
+
+// dotQuote quotes s as a DOT string; newlines become line breaks in labels
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,99 @@
+// Package graph_test - Graph export tests
+package graph_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/graph"
+)
+
+func exportGraph(t *testing.T) *graph.InfrastructureGraph {
+	t.Helper()
+	g, err := graph.NewInfraGraphBuilder().Build(&graph.ParsedInfra{
+		Resources: []*graph.ParsedResource{
+			{Address: "aws_instance.web", ImplicitRefs: []string{"aws_subnet.main.id", "data.aws_ami.ubuntu.id"}, DependsOn: []string{"aws_iam_role.app"}},
+			{Address: "aws_subnet.main"},
+			{Address: "aws_iam_role.app"},
+			{Address: `module.app["a.b"].aws_eip.ip`, ModulePath: `module.app["a.b"]`},
+		},
+		DataSources: []*graph.ParsedDataSource{{Address: "data.aws_ami.ubuntu"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cost := determinism.NewMoneyFromFloat(60.736, "USD")
+	g.GetNode("aws_instance.web").MonthlyCost = &cost
+	return g
+}
+
+// dotStatement matches the node, edge and attribute statements ExportDOT
+// writes: a quoted ID with an optional attribute list, or an edge
+var dotStatement = regexp.MustCompile(`^  (` + dotID + `( -> ` + dotID + `)?( ` + dotAttrs + `)?|rankdir=LR|node ` + dotAttrs + `);$`)
+
+const (
+	dotID    = `"(?:[^"\\]|\\.)*"`
+	dotAttrs = `\[(?:"(?:[^"\\]|\\.)*"|[^\]"])*\]`
+)
+
+// TestExportDOT proves ExportDOT writes a valid digraph with every node,
+// labelled with its type and cost, and every edge
+func TestExportDOT(t *testing.T) {
+	var buf bytes.Buffer
+	if err := exportGraph(t).ExportDOT(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if lines[0] != "digraph infrastructure {" || lines[len(lines)-1] != "}" {
+		t.Fatalf("not a digraph:\n%s", buf.String())
+	}
+	for _, line := range lines[1 : len(lines)-1] {
+		if !dotStatement.MatchString(line) {
+			t.Errorf("invalid DOT statement %q", line)
+		}
+	}
+
+	dot := buf.String()
+	for _, want := range []string{
+		`"aws_instance.web" [label="aws_instance.web\naws_instance\n$60.74/month"];`,
+		`"data.aws_ami.ubuntu" [label="data.aws_ami.ubuntu\naws_ami", shape=ellipse];`,
+		`"module.app[\"a.b\"].aws_eip.ip" [label="module.app[\"a.b\"].aws_eip.ip\naws_eip"];`,
+		`"aws_instance.web" -> "aws_iam_role.app" [style=dashed];`,
+		`"aws_instance.web" -> "aws_subnet.main";`,
+		`"aws_instance.web" -> "data.aws_ami.ubuntu";`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT is missing %s:\n%s", want, dot)
+		}
+	}
+}
+
+// TestExportJSON proves the JSON export carries costs and edge kinds
+func TestExportJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := exportGraph(t).ExportJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var export graph.GraphExport
+	if err := json.Unmarshal(buf.Bytes(), &export); err != nil {
+		t.Fatal(err)
+	}
+	if len(export.Nodes) != 5 || len(export.Edges) != 3 {
+		t.Fatalf("got %d nodes and %d edges, want 5 and 3", len(export.Nodes), len(export.Edges))
+	}
+	for _, n := range export.Nodes {
+		if (n.MonthlyCost != nil) != (n.Address == "aws_instance.web") {
+			t.Errorf("%s monthly cost = %v", n.Address, n.MonthlyCost)
+		}
+	}
+	if e := export.Edges[0]; e.To != "aws_iam_role.app" || e.Kind != "depends_on" {
+		t.Errorf("first edge = %+v, want the depends_on", e)
+	}
+	if e := export.Edges[1]; e.Kind != "reference" {
+		t.Errorf("second edge = %+v, want a reference", e)
+	}
+}
@@ -5,9 +5,11 @@ package graph
 
 import (
 	"fmt"
+	"regexp"
 	"sort"
 	"strings"
 
+	"terraform-cost/core/determinism"
 	"terraform-cost/core/model"
 )
 
@@ -56,6 +58,9 @@ type InfraNode struct {
 
 	// Lineage
 	Lineage *NodeLineage
+
+	// MonthlyCost is set once the node is priced (nil = not priced)
+	MonthlyCost *determinism.Money
 }
 
 // NodeType indicates the type of infrastructure node
@@ -375,12 +380,12 @@ func (b *InfraGraphBuilder) Build(parsed *ParsedInfra) (*InfrastructureGraph, er
 				b.graph.AddEdge(addr, dep)
 			}
 		}
-		// Implicit references
+		// Implicit references (module.x.output follows the output expression)
 		for _, ref := range node.ImplicitDeps {
-			// Normalize reference to resource address
-			targetAddr := b.normalizeReference(ref)
-			if _, exists := b.graph.nodes[targetAddr]; exists {
-				b.graph.AddEdge(addr, targetAddr)
+			for _, targetAddr := range b.resolveReference(node.ModulePath, ref, 0) {
+				if targetAddr != addr {
+					b.graph.AddEdge(addr, targetAddr)
+				}
 			}
 		}
 	}
@@ -388,20 +393,109 @@ func (b *InfraGraphBuilder) Build(parsed *ParsedInfra) (*InfrastructureGraph, er
 	return b.graph, nil
 }
 
+// maxModuleOutputDepth bounds output-to-output chains (and output cycles)
+const maxModuleOutputDepth = 16
+
+// resolveReference maps a reference made from within modulePath to the
+// node addresses it depends on. module.x.output references are followed
+// through the module's output expression to the underlying resources;
+// other references name a node in modulePath itself. A reference that
+// resolves to no node yields none: it never falls back to the root module,
+// where a same-named resource is a different resource.
+func (b *InfraGraphBuilder) resolveReference(modulePath, ref string, depth int) []string {
+	if depth > maxModuleOutputDepth {
+		return nil
+	}
+
+	parts := strings.Split(ref, ".")
+	if len(parts) >= 3 && parts[0] == "module" {
+		childPath := joinModulePath(modulePath, "module."+stripIndex(parts[1]))
+		mod, ok := b.graph.modules[childPath]
+		if !ok {
+			return nil
+		}
+		expr, ok := mod.Outputs[stripIndex(parts[2])]
+		if !ok {
+			return nil
+		}
+
+		var targets []string
+		for _, inner := range expressionReferences(expr) {
+			targets = append(targets, b.resolveReference(childPath, inner, depth+1)...)
+		}
+		return targets
+	}
+
+	target := joinModulePath(modulePath, b.normalizeReference(ref))
+	if b.graph.nodes[target] != nil {
+		return []string{target}
+	}
+	return nil
+}
+
+// ResolveModuleOutput follows a module output to the resource addresses
+// its expression references. modulePath is the full module path
+// (e.g. "module.vpc").
+func (g *InfrastructureGraph) ResolveModuleOutput(modulePath, output string) []string {
+	b := &InfraGraphBuilder{graph: g}
+	parent, name := splitModulePath(modulePath)
+	return b.resolveReference(parent, name+"."+output, 0)
+}
+
 func (b *InfraGraphBuilder) normalizeReference(ref string) string {
 	// aws_instance.web.id → aws_instance.web
-	// module.vpc.aws_subnet.main[0] → module.vpc.aws_subnet.main
+	// data.aws_ami.ubuntu.id → data.aws_ami.ubuntu
 	parts := strings.Split(ref, ".")
+	if parts[0] == "data" && len(parts) >= 3 {
+		return "data." + parts[1] + "." + stripIndex(parts[2])
+	}
 	if len(parts) >= 2 {
-		// Check for index
-		if idx := strings.Index(parts[1], "["); idx != -1 {
-			parts[1] = parts[1][:idx]
-		}
-		return parts[0] + "." + parts[1]
+		return parts[0] + "." + stripIndex(parts[1])
 	}
 	return ref
 }
 
+// stripIndex removes an instance index: web[0] → web
+func stripIndex(s string) string {
+	if idx := strings.Index(s, "["); idx != -1 {
+		return s[:idx]
+	}
+	return s
+}
+
+// joinModulePath appends a module segment to a parent module path
+func joinModulePath(parent, child string) string {
+	if parent == "" {
+		return child
+	}
+	return parent + "." + child
+}
+
+// splitModulePath splits module.a.module.b into (module.a, module.b)
+func splitModulePath(path string) (string, string) {
+	idx := strings.LastIndex(path, "module.")
+	if idx <= 0 {
+		return "", path
+	}
+	return strings.TrimSuffix(path[:idx], "."), path[idx:]
+}
+
+// moduleOutputRefPattern matches references inside an output expression
+var moduleOutputRefPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_-]*(\.[A-Za-z0-9_*"-]+(\[[^\]]*\])?)+`)
+
+// expressionReferences extracts resource/module references from an expression
+func expressionReferences(expr string) []string {
+	var refs []string
+	for _, m := range moduleOutputRefPattern.FindAllString(expr, -1) {
+		switch strings.SplitN(m, ".", 2)[0] {
+		case "var", "local", "each", "count", "path", "terraform", "self":
+			continue
+		}
+		refs = append(refs, m)
+	}
+	return refs
+}
+
 func (b *InfraGraphBuilder) buildLineage(res *ParsedResource) *NodeLineage {
 	lineage := &NodeLineage{
 		ExpressionRefs: []ExpressionRef{},
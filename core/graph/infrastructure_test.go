@@ -0,0 +1,143 @@
+// Package graph_test - Infrastructure graph tests
+package graph_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"terraform-cost/core/graph"
+)
+
+// TestModuleOutputReferences tests that module.x.output references follow
+// the output expression to the underlying resource
+func TestModuleOutputReferences(t *testing.T) {
+	parsed := &graph.ParsedInfra{
+		Resources: []*graph.ParsedResource{
+			{Address: "module.vpc.aws_subnet.main", ModulePath: "module.vpc"},
+			{Address: "module.vpc.module.sizing.aws_ssm_parameter.size", ModulePath: "module.vpc.module.sizing"},
+			{
+				Address:      "aws_instance.web",
+				ImplicitRefs: []string{"module.vpc.subnet_id", "module.vpc.instance_type"},
+			},
+			{
+				Address:      "aws_instance.bastion",
+				ImplicitRefs: []string{"module.vpc.cidr"},
+			},
+		},
+		Modules: []*graph.ParsedModule{
+			{
+				Path: "module.vpc",
+				Outputs: map[string]string{
+					"subnet_id":     "aws_subnet.main[0].id",
+					"instance_type": "${module.sizing.value}",
+					"cidr":          "var.cidr",
+				},
+			},
+			{
+				Path:       "module.vpc.module.sizing",
+				ParentPath: "module.vpc",
+				Outputs:    map[string]string{"value": "aws_ssm_parameter.size.value"},
+			},
+		},
+	}
+
+	g, err := graph.NewInfraGraphBuilder().Build(parsed)
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+
+	deps := g.GetDependencies("aws_instance.web")
+	want := []string{"module.vpc.aws_subnet.main", "module.vpc.module.sizing.aws_ssm_parameter.size"}
+	if !sameSet(deps, want) {
+		t.Errorf("aws_instance.web deps = %v, want %v", deps, want)
+	}
+
+	if deps := g.GetDependencies("aws_instance.bastion"); len(deps) != 0 {
+		t.Errorf("output backed by a variable should add no edge, got %v", deps)
+	}
+
+	if got := g.ResolveModuleOutput("module.vpc.module.sizing", "value"); !reflect.DeepEqual(got, want[1:]) {
+		t.Errorf("ResolveModuleOutput = %v, want %v", got, want[1:])
+	}
+}
+
+// TestUnresolvedReferencesAddNoEdge tests that a reference resolving to no
+// node in its own module adds no edge, even when the root module has a
+// resource of the same name
+func TestUnresolvedReferencesAddNoEdge(t *testing.T) {
+	parsed := &graph.ParsedInfra{
+		Resources: []*graph.ParsedResource{
+			{Address: "aws_s3_bucket.logs"},
+			{Address: "aws_security_group.web"},
+			{Address: "module.app.aws_security_group.web", ModulePath: "module.app"},
+			{
+				Address:      "module.app.aws_instance.web",
+				ModulePath:   "module.app",
+				ImplicitRefs: []string{"aws_s3_bucket.logs.arn", "aws_security_group.web.id"},
+			},
+			{
+				Address:      "aws_instance.bastion",
+				ImplicitRefs: []string{"module.app.aws_security_group.web.id", "module.network.subnet_id", "module.app.missing"},
+			},
+		},
+		Modules: []*graph.ParsedModule{
+			{Path: "module.app", Outputs: map[string]string{"sg_id": "aws_security_group.web.id"}},
+		},
+	}
+
+	g, err := graph.NewInfraGraphBuilder().Build(parsed)
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+
+	want := []string{"module.app.aws_security_group.web"}
+	if deps := g.GetDependencies("module.app.aws_instance.web"); !sameSet(deps, want) {
+		t.Errorf("module.app.aws_instance.web deps = %v, want %v", deps, want)
+	}
+	if deps := g.GetDependencies("aws_instance.bastion"); len(deps) != 0 {
+		t.Errorf("references to no module output should add no edge, got %v", deps)
+	}
+	if got := g.ResolveModuleOutput("module.app", "sg_id"); !reflect.DeepEqual(got, want) {
+		t.Errorf("ResolveModuleOutput = %v, want %v", got, want)
+	}
+}
+
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]int)
+	for _, s := range a {
+		seen[s]++
+	}
+	for _, s := range b {
+		if seen[s] == 0 {
+			return false
+		}
+		seen[s]--
+	}
+	return true
+}
+
+// BenchmarkBuildDenseGraph builds a graph of 1000 resources, each
+// referencing up to 50 earlier ones; its result is guarded against
+// regressions by scripts/bench-guard.sh
+func BenchmarkBuildDenseGraph(b *testing.B) {
+	const resources, fanOut = 1000, 50
+	parsed := &graph.ParsedInfra{}
+	for i := 0; i < resources; i++ {
+		res := &graph.ParsedResource{Address: fmt.Sprintf("aws_instance.r%d", i)}
+		for j := max(0, i-fanOut); j < i; j++ {
+			res.ImplicitRefs = append(res.ImplicitRefs, fmt.Sprintf("aws_instance.r%d.id", j))
+		}
+		parsed.Resources = append(parsed.Resources, res)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := graph.NewInfraGraphBuilder().Build(parsed); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
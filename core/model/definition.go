@@ -5,6 +5,7 @@ package model
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"sort"
 	"time"
@@ -219,6 +220,11 @@ type InstanceMetadata struct {
 	Source        InstanceSource
 	IsPlaceholder bool   // True if created for unknown expansion
 	Warning       string // Any warning during expansion
+	Destroyed     bool   // Removed by the plan; priced only as savings
+
+	// Location is where the instance's definition is written; zero for
+	// instances read from plan JSON or state
+	Location SourceLocation
 }
 
 // InstanceSource tracks how the instance was created
@@ -289,12 +295,35 @@ func NewInstanceGraph() *InstanceGraph {
 	}
 }
 
-// AddInstance adds an instance to the graph
-func (g *InstanceGraph) AddInstance(inst *AssetInstance) {
+// ErrInstanceIDCollision is returned when two addresses share an instance ID
+var ErrInstanceIDCollision = errors.New("instance ID collision")
+
+// AddInstance adds an instance to the graph. Adding an address again
+// replaces its instance; an ID already held by another address is
+// rejected rather than silently overwriting that instance.
+func (g *InstanceGraph) AddInstance(inst *AssetInstance) error {
+	if existing, ok := g.instances[inst.ID]; ok {
+		if existing.Address != inst.Address {
+			return fmt.Errorf("%w: %s and %s both have ID %s", ErrInstanceIDCollision, existing.Address, inst.Address, inst.ID)
+		}
+		g.removeFromDefinition(existing)
+	}
 	g.instances[inst.ID] = inst
 	g.byAddress[inst.Address] = inst
 	g.byDefinition[inst.DefinitionID] = append(g.byDefinition[inst.DefinitionID], inst)
 	g.orderValid = false
+	return nil
+}
+
+// removeFromDefinition drops an instance from its definition's index
+func (g *InstanceGraph) removeFromDefinition(inst *AssetInstance) {
+	insts := g.byDefinition[inst.DefinitionID]
+	for i, other := range insts {
+		if other == inst {
+			g.byDefinition[inst.DefinitionID] = append(insts[:i:i], insts[i+1:]...)
+			return
+		}
+	}
 }
 
 // AddEdge adds a dependency edge
@@ -303,6 +332,22 @@ func (g *InstanceGraph) AddEdge(from, to InstanceID, edgeType EdgeType) {
 	g.orderValid = false
 }
 
+// Edges returns the dependency edges sorted by from, to and type
+func (g *InstanceGraph) Edges() []InstanceEdge {
+	edges := make([]InstanceEdge, len(g.edges))
+	copy(edges, g.edges)
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		if edges[i].To != edges[j].To {
+			return edges[i].To < edges[j].To
+		}
+		return edges[i].Type < edges[j].Type
+	})
+	return edges
+}
+
 // Instances returns all instances in stable, sorted order
 func (g *InstanceGraph) Instances() []*AssetInstance {
 	ids := make([]InstanceID, 0, len(g.instances))
@@ -390,3 +435,8 @@ func (g *InstanceGraph) TopologicalOrder() []InstanceID {
 func (g *InstanceGraph) Size() int {
 	return len(g.instances)
 }
+
+// EdgeCount returns the number of dependency edges
+func (g *InstanceGraph) EdgeCount() int {
+	return len(g.edges)
+}
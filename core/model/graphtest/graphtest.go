@@ -0,0 +1,164 @@
+// Package graphtest snapshots instance graphs for golden-file tests.
+// The expander, builder and plan reader decide which instances exist,
+// how they are bound to providers, which values are unknown and how the
+// graph orders them; these regress quietly. Snapshot renders all of it
+// as stable JSON keyed by address, so a fixture's graph can be checked
+// in and any change to it shows up as a reviewable diff.
+package graphtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"terraform-cost/core/model"
+)
+
+// Graph is the checked-in form of an instance graph. Instance IDs are
+// hashes, so everything refers to instances by address.
+type Graph struct {
+	Instances []Instance `json:"instances"`
+	Edges     []Edge     `json:"edges"`
+
+	// Order is the graph's topological order
+	Order []string `json:"order"`
+}
+
+// Instance is one instance of a snapshot
+type Instance struct {
+	Address  string `json:"address"`
+	Provider string `json:"provider"`
+	Alias    string `json:"alias,omitempty"`
+	Region   string `json:"region,omitempty"`
+	Source   string `json:"source"`
+
+	Placeholder bool   `json:"placeholder,omitempty"`
+	Destroyed   bool   `json:"destroyed,omitempty"`
+	Warning     string `json:"warning,omitempty"`
+
+	// Attributes holds the known values; Unknown names the rest
+	Attributes map[string]any `json:"attributes,omitempty"`
+	Unknown    []string       `json:"unknown,omitempty"`
+
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+// Edge is one dependency edge of a snapshot
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"`
+}
+
+// sourceNames names model.InstanceSource values
+var sourceNames = map[model.InstanceSource]string{
+	model.SourceHCL:         "hcl",
+	model.SourcePlanJSON:    "plan_json",
+	model.SourceState:       "state",
+	model.SourcePlaceholder: "placeholder",
+}
+
+// edgeTypeNames names model.EdgeType values
+var edgeTypeNames = map[model.EdgeType]string{
+	model.EdgeExplicit: "explicit",
+	model.EdgeImplicit: "implicit",
+	model.EdgeProvider: "provider",
+}
+
+// Snapshot converts a graph to its checked-in form
+func Snapshot(g *model.InstanceGraph) Graph {
+	addresses := make(map[model.InstanceID]string, g.Size())
+	for _, inst := range g.Instances() {
+		addresses[inst.ID] = string(inst.Address)
+	}
+	address := func(id model.InstanceID) string {
+		if a, ok := addresses[id]; ok {
+			return a
+		}
+		// An edge to an instance outside the graph is itself a finding
+		return "missing:" + string(id)
+	}
+
+	out := Graph{Instances: []Instance{}, Edges: []Edge{}, Order: []string{}}
+	for _, inst := range g.Instances() {
+		snap := Instance{
+			Address:     string(inst.Address),
+			Provider:    inst.Provider.Type,
+			Alias:       inst.Provider.Alias,
+			Region:      inst.Provider.Region,
+			Source:      sourceNames[inst.Metadata.Source],
+			Placeholder: inst.Metadata.IsPlaceholder,
+			Destroyed:   inst.Metadata.Destroyed,
+			Warning:     inst.Metadata.Warning,
+		}
+		for name, attr := range inst.Attributes {
+			if attr.IsUnknown {
+				snap.Unknown = append(snap.Unknown, name)
+				continue
+			}
+			if snap.Attributes == nil {
+				snap.Attributes = make(map[string]any)
+			}
+			snap.Attributes[name] = attr.Value
+		}
+		sort.Strings(snap.Unknown)
+		for _, dep := range inst.Dependencies {
+			snap.Dependencies = append(snap.Dependencies, address(dep))
+		}
+		sort.Strings(snap.Dependencies)
+		out.Instances = append(out.Instances, snap)
+	}
+	sort.Slice(out.Instances, func(i, j int) bool { return out.Instances[i].Address < out.Instances[j].Address })
+
+	for _, e := range g.Edges() {
+		out.Edges = append(out.Edges, Edge{From: address(e.From), To: address(e.To), Type: edgeTypeNames[e.Type]})
+	}
+	sort.SliceStable(out.Edges, func(i, j int) bool {
+		if out.Edges[i].From != out.Edges[j].From {
+			return out.Edges[i].From < out.Edges[j].From
+		}
+		return out.Edges[i].To < out.Edges[j].To
+	})
+
+	for _, id := range g.TopologicalOrder() {
+		out.Order = append(out.Order, address(id))
+	}
+	return out
+}
+
+// Marshal renders a graph's snapshot as indented JSON
+func Marshal(g *model.InstanceGraph) ([]byte, error) {
+	data, err := json.MarshalIndent(Snapshot(g), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Assert compares a graph's snapshot with the golden file, first
+// rewriting the file when update is set
+func Assert(t testing.TB, golden string, g *model.InstanceGraph, update bool) {
+	t.Helper()
+	got, err := Marshal(g)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if update {
+		if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("instance graph differs from %s (run with -update to accept):\n%s", golden, got)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -107,8 +108,8 @@ func ParseAddress(addr string) (CanonicalAddress, error) {
 	// Parse Terraform-style address
 	builder := NewAddressBuilder()
 
-	// Split into parts
-	parts := strings.Split(addr, ".")
+	// Split into parts; dots inside keys are not separators
+	parts := splitAddress(addr)
 
 	i := 0
 	// Collect module path
@@ -140,8 +141,12 @@ func ParseAddress(addr string) (CanonicalAddress, error) {
 			fmt.Sscanf(keyPart, "%d", &index)
 			builder.WithCount(index)
 		} else {
-			// Remove quotes if present
-			keyPart = strings.Trim(keyPart, "\"")
+			// Remove quotes if present, undoing escapes within them
+			if unquoted, err := strconv.Unquote(keyPart); err == nil {
+				keyPart = unquoted
+			} else {
+				keyPart = strings.Trim(keyPart, "\"")
+			}
 			builder.WithForEach(keyPart)
 		}
 	}
@@ -150,6 +155,33 @@ func ParseAddress(addr string) (CanonicalAddress, error) {
 	return builder.Build(), nil
 }
 
+// splitAddress splits a Terraform address on the dots between its
+// segments, keeping quoted and bracketed keys (["a.b"]) whole
+func splitAddress(addr string) []string {
+	var parts []string
+	depth, inQuote, escaped, start := 0, false, false, 0
+	for i := 0; i < len(addr); i++ {
+		c := addr[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inQuote && c == '\\':
+			escaped = true
+		case c == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == '.' && depth == 0:
+			parts = append(parts, addr[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, addr[start:])
+}
+
 // isCanonical checks if address is already in canonical form
 func isCanonical(addr string) bool {
 	// Canonical format uses [key_type=value] not [index] or ["key"]
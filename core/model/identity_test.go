@@ -0,0 +1,84 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStableIDsDistinct(t *testing.T) {
+	addresses := []string{
+		`aws_instance.web`,
+		`aws_instance.web[0]`,
+		`aws_instance.web["0"]`,
+		`aws_instance.web["a.b"]`,
+		`aws_instance.web["a.c"]`,
+		`aws_instance.web["a"]`,
+		`aws_instance.web["a]"]`,
+		`aws_instance.web["a\"b"]`,
+		`aws_instance.web["a b/c:d=e"]`,
+		`aws_instance.web["café"]`,
+		`module.app.aws_instance.web["a.b"]`,
+		`module.app["x.y"].aws_instance.web["a.b"]`,
+		`module.app["x.z"].aws_instance.web["a.b"]`,
+		`module.app.module.db.module.replica.aws_db_instance.main["us-east-1.primary"]`,
+		`module.app.module.db.module.replica.aws_db_instance.main["us-east-1.replica"]`,
+		`module.app.module.db.aws_db_instance.main["replica.us-east-1.primary"]`,
+		`module.app["db"].module.db.aws_db_instance.main[0]`,
+		`module.app["db"].module.db.aws_db_instance.main[1]`,
+	}
+
+	seen := make(map[InstanceID]string, len(addresses))
+	for _, addr := range addresses {
+		canonical, err := ParseAddress(addr)
+		if err != nil {
+			t.Fatalf("ParseAddress(%s): %v", addr, err)
+		}
+		id := canonical.StableID()
+		if other, ok := seen[id]; ok {
+			t.Errorf("%s and %s share ID %s (canonical %s)", other, addr, id, canonical)
+		}
+		seen[id] = addr
+	}
+}
+
+func TestParseAddressKeepsDottedKeys(t *testing.T) {
+	tests := map[string]CanonicalAddress{
+		`aws_instance.web["a.b"]`:                    `aws_instance.web[for_each=a.b]`,
+		`aws_instance.web["a\"b"]`:                   `aws_instance.web[for_each=a"b]`,
+		`module.app["x.y"].aws_instance.web[2]`:      `module.app["x.y"]:aws_instance.web[count=2]`,
+		`module.app.module.db.aws_instance.web["k"]`: `module.app:module.db:aws_instance.web[for_each=k]`,
+	}
+	for addr, want := range tests {
+		got, err := ParseAddress(addr)
+		if err != nil {
+			t.Fatalf("ParseAddress(%s): %v", addr, err)
+		}
+		if got != want {
+			t.Errorf("ParseAddress(%s) = %s, want %s", addr, got, want)
+		}
+	}
+}
+
+func TestAddInstanceRejectsIDCollision(t *testing.T) {
+	g := NewInstanceGraph()
+	if err := g.AddInstance(&AssetInstance{ID: "id-1", Address: "aws_instance.a", DefinitionID: "def"}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := g.AddInstance(&AssetInstance{ID: "id-1", Address: "aws_instance.b", DefinitionID: "def"})
+	if !errors.Is(err, ErrInstanceIDCollision) {
+		t.Fatalf("err = %v, want ErrInstanceIDCollision", err)
+	}
+	if inst, _ := g.ByAddress("aws_instance.a"); inst == nil || g.Instances()[0] != inst {
+		t.Error("colliding instance overwrote the first")
+	}
+
+	// Re-adding the same address replaces it
+	replacement := &AssetInstance{ID: "id-1", Address: "aws_instance.a", DefinitionID: "def"}
+	if err := g.AddInstance(replacement); err != nil {
+		t.Fatalf("re-adding an address: %v", err)
+	}
+	if insts := g.ByDefinition("def"); len(insts) != 1 || insts[0] != replacement {
+		t.Errorf("ByDefinition = %v, want only the replacement", insts)
+	}
+}
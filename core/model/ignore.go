@@ -0,0 +1,89 @@
+// Package model - Ignored instances
+// Ephemeral or sandbox resources can be left out of an estimate with glob
+// patterns over instance addresses, such as "aws_instance.test_*" or
+// "module.sandbox.*". The graph drops matching instances before pricing,
+// so every adapter ignores the same resources for the same patterns.
+package model
+
+import (
+	"regexp"
+	"strings"
+)
+
+// IgnoreMatcher matches instance addresses against ignore patterns
+type IgnoreMatcher struct {
+	patterns []*regexp.Regexp
+}
+
+// NewIgnoreMatcher compiles glob patterns over instance addresses. "*"
+// matches any run of characters, dots and brackets included, and "?" any
+// one character; everything else is literal, so "aws_instance.web[0]"
+// names one instance. A pattern naming a resource also matches each of
+// its instances. Comma-separated patterns are split, and no patterns give
+// a nil matcher, which matches nothing.
+func NewIgnoreMatcher(patterns []string) *IgnoreMatcher {
+	var m *IgnoreMatcher
+	for _, value := range patterns {
+		for _, pattern := range strings.Split(value, ",") {
+			pattern = strings.TrimSpace(pattern)
+			if pattern == "" {
+				continue
+			}
+			glob := regexp.QuoteMeta(pattern)
+			glob = strings.ReplaceAll(glob, `\*`, ".*")
+			glob = strings.ReplaceAll(glob, `\?`, ".")
+			if m == nil {
+				m = &IgnoreMatcher{}
+			}
+			m.patterns = append(m.patterns, regexp.MustCompile("^"+glob+"$"))
+		}
+	}
+	return m
+}
+
+// Matches reports whether an instance address, or the address of the
+// resource it is an instance of, matches any pattern
+func (m *IgnoreMatcher) Matches(address InstanceAddress) bool {
+	if m == nil {
+		return false
+	}
+	addr := string(address)
+	resource := addr
+	if strings.HasSuffix(addr, "]") {
+		if i := strings.LastIndex(addr, "["); i > 0 {
+			resource = addr[:i]
+		}
+	}
+	for _, re := range m.patterns {
+		if re.MatchString(addr) || re.MatchString(resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// Without returns a copy of the graph without the instances ignore
+// matches, and the instances it left out in stable order. Edges to or
+// from a left-out instance are dropped.
+func (g *InstanceGraph) Without(ignore func(*AssetInstance) bool) (*InstanceGraph, []*AssetInstance) {
+	kept := NewInstanceGraph()
+	var dropped []*AssetInstance
+	for _, inst := range g.Instances() {
+		if ignore(inst) {
+			dropped = append(dropped, inst)
+			continue
+		}
+		// IDs are unique in g, so adding to an empty graph cannot collide
+		_ = kept.AddInstance(inst)
+	}
+	for _, edge := range g.edges {
+		if _, ok := kept.instances[edge.From]; !ok {
+			continue
+		}
+		if _, ok := kept.instances[edge.To]; !ok {
+			continue
+		}
+		kept.AddEdge(edge.From, edge.To, edge.Type)
+	}
+	return kept, dropped
+}
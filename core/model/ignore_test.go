@@ -0,0 +1,51 @@
+package model
+
+import "testing"
+
+func TestIgnoreMatcher(t *testing.T) {
+	m := NewIgnoreMatcher([]string{"aws_instance.test_*,module.sandbox.*", "aws_s3_bucket.logs", `aws_instance.web["a.b"]`})
+	for addr, want := range map[InstanceAddress]bool{
+		"aws_instance.test_a":                      true,
+		`aws_instance.test_b["x"]`:                 true,
+		"module.sandbox.aws_instance.web[0]":       true,
+		"aws_s3_bucket.logs":                       true,
+		"aws_s3_bucket.logs[2]":                    true,
+		`aws_instance.web["a.b"]`:                  true,
+		`aws_instance.web["a.c"]`:                  false,
+		"aws_instance.testing":                     false,
+		"aws_s3_bucket.logs_archive":               false,
+		"module.sandbox_prod.aws_instance.web":     false,
+		"module.app.module.sandbox.aws_instance.x": false,
+	} {
+		if got := m.Matches(addr); got != want {
+			t.Errorf("Matches(%s) = %v, want %v", addr, got, want)
+		}
+	}
+
+	if m := NewIgnoreMatcher(nil); m != nil || m.Matches("aws_instance.web") {
+		t.Error("no patterns should match nothing")
+	}
+}
+
+func TestGraphWithout(t *testing.T) {
+	g := NewInstanceGraph()
+	for _, addr := range []InstanceAddress{"aws_instance.a", "aws_instance.test_b", "aws_instance.c"} {
+		if err := g.AddInstance(&AssetInstance{ID: InstanceID(addr), Address: addr}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g.AddEdge("aws_instance.a", "aws_instance.test_b", EdgeImplicit)
+	g.AddEdge("aws_instance.a", "aws_instance.c", EdgeImplicit)
+
+	m := NewIgnoreMatcher([]string{"aws_instance.test_*"})
+	kept, dropped := g.Without(func(inst *AssetInstance) bool { return m.Matches(inst.Address) })
+	if kept.Size() != 2 || kept.EdgeCount() != 1 {
+		t.Errorf("kept %d instances and %d edges, want 2 and 1", kept.Size(), kept.EdgeCount())
+	}
+	if len(dropped) != 1 || dropped[0].Address != "aws_instance.test_b" {
+		t.Errorf("dropped %+v, want aws_instance.test_b", dropped)
+	}
+	if g.Size() != 3 || g.EdgeCount() != 2 {
+		t.Error("Without modified the original graph")
+	}
+}
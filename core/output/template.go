@@ -0,0 +1,330 @@
+// Package output - Template reports
+// Teams want the estimate in their own layout: a Slack message, an email
+// body, a markdown table. A TemplateFormatter renders the result through
+// a Go text/template with helpers for money, grouping and sorting, so a
+// new layout is a template file rather than a code change. The data a
+// template sees is TemplateData, documented in docs/TEMPLATES.md.
+package output
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/determinism"
+)
+
+// FormatTemplate is a report rendered through a text/template
+const FormatTemplate Format = "template"
+
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// TemplateData is what a template renders ("." at the top level)
+type TemplateData struct {
+	// Currency is the currency of every amount
+	Currency string
+
+	// TotalMonthly and TotalHourly are the estimate's totals
+	TotalMonthly decimal.Decimal
+	TotalHourly  decimal.Decimal
+
+	// Confidence is the estimate's confidence, 0.0 to 1.0
+	Confidence float64
+
+	// Resources are the priced resources, highest monthly cost first
+	Resources []TemplateResource
+
+	// Metadata describes the run
+	Metadata EstimationMetadata
+
+	// Result is the full estimation result, for anything not above
+	Result *EstimationResult
+}
+
+// TemplateResource is one priced resource
+type TemplateResource struct {
+	ID       string
+	Address  string
+	Type     string
+	Name     string
+	Provider string
+	Category string
+	Region   string
+	Tags     map[string]string
+
+	Monthly decimal.Decimal
+	Hourly  decimal.Decimal
+
+	// Components are the resource's cost units, in pricing order
+	Components []TemplateComponent
+}
+
+// TemplateComponent is one cost unit of a resource
+type TemplateComponent struct {
+	Label      string
+	Measure    string
+	Quantity   decimal.Decimal
+	Rate       decimal.Decimal
+	Amount     decimal.Decimal
+	Confidence float64
+}
+
+// TemplateGroup is resources sharing a groupBy key
+type TemplateGroup struct {
+	Key       string
+	Monthly   decimal.Decimal
+	Resources []TemplateResource
+}
+
+// NewTemplateData flattens a result for templates
+func NewTemplateData(result *EstimationResult) *TemplateData {
+	data := &TemplateData{
+		Confidence: result.Confidence,
+		Metadata:   result.Metadata,
+		Result:     result,
+	}
+	costs := result.CostGraph
+	if costs == nil {
+		return data
+	}
+	data.Currency = string(costs.Currency)
+	data.TotalMonthly = costs.TotalMonthlyCost
+	data.TotalHourly = costs.TotalHourlyCost
+
+	for id, agg := range costs.ByAsset {
+		if len(agg.Units) == 0 {
+			continue
+		}
+		res := TemplateResource{ID: id, Address: id, Monthly: agg.MonthlyCost, Hourly: agg.HourlyCost}
+		if result.AssetGraph != nil {
+			if asset, ok := result.AssetGraph.ByID[id]; ok {
+				res.Address = string(asset.Address)
+				res.Type = asset.Type
+				res.Name = asset.Name
+				res.Provider = string(asset.Provider)
+				res.Category = string(asset.Category)
+				res.Region = string(asset.Region)
+				res.Tags = asset.Tags
+			}
+		}
+		for _, unit := range agg.Units {
+			res.Components = append(res.Components, TemplateComponent{
+				Label:      unit.Label,
+				Measure:    unit.Measure,
+				Quantity:   unit.Quantity,
+				Rate:       unit.Rate,
+				Amount:     unit.Amount,
+				Confidence: unit.Confidence,
+			})
+		}
+		data.Resources = append(data.Resources, res)
+	}
+	data.Resources, _ = sortResources("monthly", data.Resources)
+	return data
+}
+
+// TemplateFormatter renders results through a text/template
+type TemplateFormatter struct {
+	tmpl *template.Template
+}
+
+// NewTemplateFormatter parses a template; name labels its errors
+func NewTemplateFormatter(name, text string) (*TemplateFormatter, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs("")).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return &TemplateFormatter{tmpl: tmpl}, nil
+}
+
+// LoadTemplateFormatter reads the template at path, or the built-in
+// template of that name (see BuiltinTemplates) when no such file exists
+func LoadTemplateFormatter(path string) (*TemplateFormatter, error) {
+	text, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		if builtin, ok := builtinTemplate(path); ok {
+			return NewTemplateFormatter(path, builtin)
+		}
+		return nil, fmt.Errorf("template %s not found (built-in templates: %s)",
+			path, strings.Join(BuiltinTemplates(), ", "))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+	return NewTemplateFormatter(path, string(text))
+}
+
+// BuiltinTemplates lists the names of the built-in templates
+func BuiltinTemplates() []string {
+	entries, _ := builtinTemplates.ReadDir("templates")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".tmpl"))
+	}
+	return names
+}
+
+func builtinTemplate(name string) (string, bool) {
+	text, err := builtinTemplates.ReadFile(path.Join("templates", name+".tmpl"))
+	return string(text), err == nil
+}
+
+// Format returns FormatTemplate
+func (f *TemplateFormatter) Format() Format {
+	return FormatTemplate
+}
+
+// Render executes the template with the result's TemplateData
+func (f *TemplateFormatter) Render(w io.Writer, result *EstimationResult) error {
+	data := NewTemplateData(result)
+	tmpl, err := f.tmpl.Clone()
+	if err != nil {
+		return err
+	}
+	// money and friends format in the result's currency
+	if err := tmpl.Funcs(templateFuncs(data.Currency)).Execute(w, data); err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+	return nil
+}
+
+// templateFuncs are the helpers templates can call
+func templateFuncs(currency string) template.FuncMap {
+	format := func(places int) func(any) (string, error) {
+		return func(v any) (string, error) {
+			d, err := toDecimal(v)
+			if err != nil {
+				return "", err
+			}
+			return determinism.FormatAmount(d, currency, places), nil
+		}
+	}
+	return template.FuncMap{
+		"money":  format(determinism.DisplayPlaces),
+		"hourly": format(determinism.HourlyDisplayPlaces),
+		"signed": func(v any) (string, error) {
+			d, err := toDecimal(v)
+			if err != nil {
+				return "", err
+			}
+			return determinism.FormatSignedAmount(d, currency, determinism.DisplayPlaces), nil
+		},
+		"percent": func(f float64) string { return fmt.Sprintf("%.0f%%", f*100) },
+		"sortBy":  sortResources,
+		"groupBy": groupResources,
+		"top": func(n int, resources []TemplateResource) []TemplateResource {
+			return resources[:min(max(n, 0), len(resources))]
+		},
+		"sum": func(resources []TemplateResource) decimal.Decimal {
+			total := decimal.Zero
+			for _, r := range resources {
+				total = total.Add(r.Monthly)
+			}
+			return total
+		},
+		"upper":    strings.ToUpper,
+		"lower":    strings.ToLower,
+		"join":     func(sep string, elems []string) string { return strings.Join(elems, sep) },
+		"padRight": func(n int, s string) string { return fmt.Sprintf("%-*s", n, s) },
+		"padLeft":  func(n int, s string) string { return fmt.Sprintf("%*s", n, s) },
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}
+}
+
+// toDecimal accepts the amounts a template may hold
+func toDecimal(v any) (decimal.Decimal, error) {
+	switch v := v.(type) {
+	case decimal.Decimal:
+		return v, nil
+	case float64:
+		return decimal.NewFromFloat(v), nil
+	case int:
+		return decimal.NewFromInt(int64(v)), nil
+	case string:
+		return decimal.NewFromString(v)
+	}
+	return decimal.Zero, fmt.Errorf("not an amount: %v (%T)", v, v)
+}
+
+// sortResources returns the resources sorted by field: monthly (highest
+// first), address, type or name. Ties keep address order.
+func sortResources(field string, resources []TemplateResource) ([]TemplateResource, error) {
+	sorted := append([]TemplateResource(nil), resources...)
+	var less func(a, b *TemplateResource) int
+	switch strings.ToLower(field) {
+	case "monthly", "cost":
+		less = func(a, b *TemplateResource) int { return b.Monthly.Cmp(a.Monthly) }
+	case "address":
+		less = func(a, b *TemplateResource) int { return 0 }
+	case "type":
+		less = func(a, b *TemplateResource) int { return strings.Compare(a.Type, b.Type) }
+	case "name":
+		less = func(a, b *TemplateResource) int { return strings.Compare(a.Name, b.Name) }
+	default:
+		return nil, fmt.Errorf("cannot sort by %q (monthly, address, type, name)", field)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if c := less(&sorted[i], &sorted[j]); c != 0 {
+			return c < 0
+		}
+		return sorted[i].Address < sorted[j].Address
+	})
+	return sorted, nil
+}
+
+// groupResources groups resources by key: type, provider, category,
+// region, or tag:<name>. Groups are sorted by monthly cost, highest
+// first; resources without the key are grouped under "(none)".
+func groupResources(key string, resources []TemplateResource) ([]TemplateGroup, error) {
+	var keyOf func(r *TemplateResource) string
+	switch tag, isTag := strings.CutPrefix(key, "tag:"); {
+	case isTag:
+		keyOf = func(r *TemplateResource) string { return r.Tags[tag] }
+	case key == "type":
+		keyOf = func(r *TemplateResource) string { return r.Type }
+	case key == "provider":
+		keyOf = func(r *TemplateResource) string { return r.Provider }
+	case key == "category":
+		keyOf = func(r *TemplateResource) string { return r.Category }
+	case key == "region":
+		keyOf = func(r *TemplateResource) string { return r.Region }
+	default:
+		return nil, fmt.Errorf("cannot group by %q (type, provider, category, region, tag:<name>)", key)
+	}
+
+	index := make(map[string]int)
+	var groups []TemplateGroup
+	for i := range resources {
+		k := keyOf(&resources[i])
+		if k == "" {
+			k = "(none)"
+		}
+		n, ok := index[k]
+		if !ok {
+			n = len(groups)
+			index[k] = n
+			groups = append(groups, TemplateGroup{Key: k, Monthly: decimal.Zero})
+		}
+		groups[n].Resources = append(groups[n].Resources, resources[i])
+		groups[n].Monthly = groups[n].Monthly.Add(resources[i].Monthly)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if c := groups[i].Monthly.Cmp(groups[j].Monthly); c != 0 {
+			return c > 0
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups, nil
+}
@@ -0,0 +1,108 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/types"
+)
+
+// templateResult prices three instances of two types for two teams
+func templateResult() *EstimationResult {
+	assets := types.NewAssetGraph()
+	costs := types.NewCostGraph(types.CurrencyUSD)
+	for _, r := range []struct {
+		id, typ, team, amount string
+	}{
+		{"aws_instance.web", "aws_instance", "web", "60.74"},
+		{"aws_instance.api", "aws_instance", "api", "30.37"},
+		{"aws_db_instance.main", "aws_db_instance", "api", "124.10"},
+	} {
+		name := strings.SplitN(r.id, ".", 2)[1]
+		asset := &types.Asset{ID: r.id, Address: types.ResourceAddress(r.id), Provider: "aws",
+			Type: r.typ, Name: name, Tags: map[string]string{"team": r.team}}
+		assets.Add(asset)
+		costs.AddCostUnit(&types.CostUnit{ID: r.id + "-compute", Label: "Compute",
+			Amount: decimal.RequireFromString(r.amount), Currency: types.CurrencyUSD}, asset)
+	}
+	costs.Summarize()
+	return &EstimationResult{CostGraph: costs, AssetGraph: assets, Confidence: 0.9}
+}
+
+func render(t *testing.T, f *TemplateFormatter) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := f.Render(&buf, templateResult()); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestTemplateHelpers(t *testing.T) {
+	f, err := NewTemplateFormatter("report", `{{ money .TotalMonthly }} {{ percent .Confidence }}
+{{ range groupBy "tag:team" .Resources }}{{ .Key }}={{ money .Monthly }} {{ end }}
+{{ range sortBy "name" .Resources }}{{ .Name }} {{ end }}
+{{ range top 1 .Resources }}{{ .Address }}{{ end }} {{ money (sum (top 2 .Resources)) }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `$215.21 90%
+api=$154.47 web=$60.74 
+api main web 
+aws_db_instance.main $184.84`
+	if got := render(t, f); got != want {
+		t.Errorf("rendered:\n%s\nwant:\n%s", got, want)
+	}
+
+	f, err = NewTemplateFormatter("bad", `{{ groupBy "owner" .Resources }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := f.Render(&buf, templateResult()); err == nil || !strings.Contains(err.Error(), `cannot group by "owner"`) {
+		t.Errorf("err = %v, want an unknown group key", err)
+	}
+	if _, err := NewTemplateFormatter("bad", `{{ nosuchfunc }}`); err == nil {
+		t.Error("expected an undefined function to fail parsing")
+	}
+}
+
+func TestBuiltinTemplates(t *testing.T) {
+	if got := strings.Join(BuiltinTemplates(), ","); got != "plain,slack" {
+		t.Fatalf("built-in templates = %s", got)
+	}
+
+	plain, err := LoadTemplateFormatter("plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := render(t, plain)
+	for _, want := range []string{"Estimated monthly cost: $215.21", "aws_instance", "$91.11", "aws_db_instance.main"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("plain report lacks %q:\n%s", want, out)
+		}
+	}
+
+	slack, err := LoadTemplateFormatter("slack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload struct {
+		Text   string           `json:"text"`
+		Blocks []map[string]any `json:"blocks"`
+	}
+	if err := json.Unmarshal([]byte(render(t, slack)), &payload); err != nil {
+		t.Fatalf("slack template is not JSON: %v", err)
+	}
+	if payload.Text != "Estimated monthly cost: $215.21" || len(payload.Blocks) != 5 {
+		t.Errorf("slack payload = %+v", payload)
+	}
+
+	if _, err := LoadTemplateFormatter("teams"); err == nil || !strings.Contains(err.Error(), "plain, slack") {
+		t.Errorf("err = %v, want the built-in templates listed", err)
+	}
+}
@@ -35,6 +35,29 @@ type DeepPolicy interface {
 	Evaluate(ctx context.Context, input *PolicyInput) (*PolicyOutput, error)
 }
 
+// severityPolicy wraps a policy with the severity of its violations
+type severityPolicy struct {
+	DeepPolicy
+	severity Severity
+}
+
+// WithSeverity sets the severity reported when p fails. An empty severity
+// keeps the default, SeverityError.
+func WithSeverity(p DeepPolicy, severity Severity) DeepPolicy {
+	if severity == "" {
+		return p
+	}
+	return &severityPolicy{DeepPolicy: p, severity: severity}
+}
+
+// SeverityOf returns the severity of a policy's violations
+func SeverityOf(p DeepPolicy) Severity {
+	if sp, ok := p.(*severityPolicy); ok {
+		return sp.severity
+	}
+	return SeverityError
+}
+
 // PolicyInput provides DEEP context for policy evaluation
 type PolicyInput struct {
 	// Instance costs (per-instance, not aggregated)
@@ -59,6 +82,16 @@ type PolicyInput struct {
 
 	// Pricing snapshot used
 	Snapshot *pricing.PricingSnapshot
+
+	// Coverage is the share of resources priced symbolically or not at
+	// all; nil when the caller does not measure it
+	Coverage *CoverageInput
+}
+
+// CoverageInput is the estimate's coverage, in percent of resources
+type CoverageInput struct {
+	SymbolicPercent    float64
+	UnsupportedPercent float64
 }
 
 // InstanceCostDetail provides deep detail for a single instance
@@ -69,6 +102,9 @@ type InstanceCostDetail struct {
 	DefinitionID model.DefinitionID
 	InstanceKey  model.InstanceKey
 
+	// ResourceType is the Terraform type (e.g. aws_instance)
+	ResourceType string
+
 	// Provider and region
 	Provider string
 	Region   string
@@ -179,9 +215,10 @@ type DeepResult struct {
 
 // DeepPolicyResult is the result of a single policy
 type DeepPolicyResult struct {
-	Name    string
-	Passed  bool
-	Message string
+	Name     string
+	Passed   bool
+	Message  string
+	Severity Severity
 
 	AffectedInstances []model.InstanceID
 	AffectedCost      determinism.Money
@@ -205,6 +242,7 @@ func (e *DeepEvaluator) Evaluate(ctx context.Context, input *PolicyInput) (*Deep
 		pr := DeepPolicyResult{
 			Name:              p.Name(),
 			Passed:            output.Passed,
+			Severity:          SeverityOf(p),
 			Message:           output.Message,
 			AffectedInstances: output.AffectedInstances,
 			AffectedCost:      output.AffectedCost,
@@ -288,7 +326,10 @@ func (p *BudgetPolicy) findTopContributors(input *PolicyInput, n int) []model.In
 	}
 
 	sort.Slice(items, func(i, j int) bool {
-		return items[i].cost.Cmp(items[j].cost) > 0
+		if c := items[i].cost.Cmp(items[j].cost); c != 0 {
+			return c > 0
+		}
+		return items[i].id < items[j].id
 	})
 
 	result := make([]model.InstanceID, 0, n)
@@ -349,6 +390,62 @@ func (p *ConfidencePolicy) Evaluate(ctx context.Context, input *PolicyInput) (*P
 	return output, nil
 }
 
+// CoverageMetric is the coverage percentage a CoveragePolicy limits
+type CoverageMetric string
+
+const (
+	// CoverageSymbolic limits resources whose cost is symbolic
+	CoverageSymbolic CoverageMetric = "symbolic"
+	// CoverageUnsupported limits resources with no pricing support
+	CoverageUnsupported CoverageMetric = "unsupported"
+)
+
+// CoveragePolicy checks the percentage of resources that are symbolic or
+// unsupported stays within a limit
+type CoveragePolicy struct {
+	name       string
+	metric     CoverageMetric
+	maxPercent float64
+}
+
+// NewCoveragePolicy creates a coverage policy
+func NewCoveragePolicy(name string, metric CoverageMetric, maxPercent float64) *CoveragePolicy {
+	return &CoveragePolicy{
+		name:       name,
+		metric:     metric,
+		maxPercent: maxPercent,
+	}
+}
+
+func (p *CoveragePolicy) Name() string { return p.name }
+
+func (p *CoveragePolicy) Evaluate(ctx context.Context, input *PolicyInput) (*PolicyOutput, error) {
+	if input.Coverage == nil {
+		return &PolicyOutput{Passed: true, Message: "Coverage was not measured"}, nil
+	}
+
+	label, actual := "Symbolic", input.Coverage.SymbolicPercent
+	if p.metric == CoverageUnsupported {
+		label, actual = "Unsupported", input.Coverage.UnsupportedPercent
+	}
+	if actual > p.maxPercent {
+		output := &PolicyOutput{
+			Passed:  false,
+			Message: fmt.Sprintf("%s coverage %.1f%% exceeds limit %.1f%%", label, actual, p.maxPercent),
+		}
+		if p.metric == CoverageSymbolic {
+			output.Suggestions = []string{"Provide the variables or usage that leave counts and sizes unknown"}
+		} else {
+			output.Suggestions = []string{"Check the unsupported resource types are expected to be free"}
+		}
+		return output, nil
+	}
+	return &PolicyOutput{
+		Passed:  true,
+		Message: fmt.Sprintf("%s coverage %.1f%% is within limit %.1f%%", label, actual, p.maxPercent),
+	}, nil
+}
+
 // ResourceTypePolicy checks limits on specific resource types
 type ResourceTypePolicy struct {
 	name         string
@@ -380,14 +477,13 @@ func (p *ResourceTypePolicy) Evaluate(ctx context.Context, input *PolicyInput) (
 	affected := []model.InstanceID{}
 
 	for id, detail := range input.InstanceCosts {
-		// Check if this instance matches the resource type
-		addr := string(detail.Address)
-		if len(addr) >= len(p.resourceType) && addr[:len(p.resourceType)] == p.resourceType {
+		if matchesType(detail, p.resourceType) {
 			count++
 			totalCost = totalCost.Add(detail.MonthlyCost)
 			affected = append(affected, id)
 		}
 	}
+	sortInstanceIDs(affected)
 
 	// Check instance count
 	if p.maxInstances > 0 && count > p.maxInstances {
@@ -417,6 +513,75 @@ func (p *ResourceTypePolicy) Evaluate(ctx context.Context, input *PolicyInput) (
 	return output, nil
 }
 
+// matchesType reports whether an instance is of resourceType, falling back
+// to the address prefix when the input carries no type
+func matchesType(detail *InstanceCostDetail, resourceType string) bool {
+	if detail.ResourceType != "" {
+		return detail.ResourceType == resourceType
+	}
+	addr := string(detail.Address)
+	return len(addr) >= len(resourceType) && addr[:len(resourceType)] == resourceType
+}
+
+// sortInstanceIDs orders instances collected from the InstanceCosts map,
+// whose iteration order is random, so outputs are stable between runs
+func sortInstanceIDs(ids []model.InstanceID) {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+}
+
+// DeniedTypePolicy fails when any instance is of a denied resource type
+type DeniedTypePolicy struct {
+	name  string
+	types []string
+}
+
+// NewDeniedTypePolicy creates a denied resource type policy
+func NewDeniedTypePolicy(name string, types []string) *DeniedTypePolicy {
+	return &DeniedTypePolicy{
+		name:  name,
+		types: types,
+	}
+}
+
+func (p *DeniedTypePolicy) Name() string { return p.name }
+
+func (p *DeniedTypePolicy) Evaluate(ctx context.Context, input *PolicyInput) (*PolicyOutput, error) {
+	output := &PolicyOutput{
+		Passed: true,
+	}
+
+	found := make(map[string]bool)
+	cost := determinism.Zero("USD")
+	for id, detail := range input.InstanceCosts {
+		for _, t := range p.types {
+			if matchesType(detail, t) {
+				found[t] = true
+				cost = cost.Add(detail.MonthlyCost)
+				output.AffectedInstances = append(output.AffectedInstances, id)
+				break
+			}
+		}
+	}
+
+	if len(found) == 0 {
+		output.Message = "No denied resource types"
+		return output, nil
+	}
+
+	denied := make([]string, 0, len(found))
+	for t := range found {
+		denied = append(denied, t)
+	}
+	sort.Strings(denied)
+	sortInstanceIDs(output.AffectedInstances)
+
+	output.Passed = false
+	output.Message = fmt.Sprintf("%d instances of denied types %v", len(output.AffectedInstances), denied)
+	output.AffectedCost = cost
+	output.Suggestions = []string{"Remove or replace resources of denied types"}
+	return output, nil
+}
+
 // TagRequirementPolicy checks that instances have required tags
 type TagRequirementPolicy struct {
 	name         string
@@ -455,6 +620,7 @@ func (p *TagRequirementPolicy) Evaluate(ctx context.Context, input *PolicyInput)
 		for id := range missingTags {
 			output.AffectedInstances = append(output.AffectedInstances, id)
 		}
+		sortInstanceIDs(output.AffectedInstances)
 
 		output.Suggestions = []string{
 			"Add required tags: " + fmt.Sprintf("%v", p.requiredTags),
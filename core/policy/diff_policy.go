@@ -4,6 +4,8 @@
 package policy
 
 import (
+	"sort"
+
 	"terraform-cost/core/graph"
 	"terraform-cost/core/model"
 )
@@ -28,6 +30,17 @@ type DiffPolicyContext struct {
 	// Change analysis
 	Changes *graph.ChangeCostAnalysis
 
+	// Per-resource changes, sorted by instance ID. Unchanged resources
+	// are left out.
+	Deltas []ResourceDelta
+
+	// Resources only in After, and only in Before
+	Created   []model.InstanceID
+	Destroyed []model.InstanceID
+
+	// TotalDelta is the change in total monthly cost
+	TotalDelta float64
+
 	// Scope filter
 	Scope DiffScope
 
@@ -44,6 +57,39 @@ type CostSnapshot struct {
 	Timestamp     string
 }
 
+// ResourceDelta is one resource's monthly cost change
+type ResourceDelta struct {
+	InstanceID   model.InstanceID
+	ResourceType string
+	Change       ResourceChange
+	Before       float64
+	After        float64
+	Delta        float64
+}
+
+// ResourceChange classifies a resource's change between two estimates
+type ResourceChange int
+
+const (
+	ResourceCreated ResourceChange = iota
+	ResourceDestroyed
+	ResourceUpdated
+)
+
+// String returns the change name
+func (c ResourceChange) String() string {
+	switch c {
+	case ResourceCreated:
+		return "create"
+	case ResourceDestroyed:
+		return "destroy"
+	case ResourceUpdated:
+		return "update"
+	default:
+		return "unknown"
+	}
+}
+
 // DiffScope defines what to evaluate
 type DiffScope struct {
 	// Only evaluate new resources
@@ -282,8 +328,14 @@ func (p *DeltaBudgetPolicy) EvaluateDiff(ctx *DiffPolicyContext) *DiffPolicyResu
 		}
 	}
 
-	// Check per-service limits
-	for service, limit := range p.ServiceLimits {
+	// Check per-service limits, in service order so violations are stable
+	services := make([]string, 0, len(p.ServiceLimits))
+	for service := range p.ServiceLimits {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	for _, service := range services {
+		limit := p.ServiceLimits[service]
 		beforeCost := ctx.Before.ByService[service]
 		afterCost := ctx.After.ByService[service]
 		serviceDelta := afterCost - beforeCost
@@ -302,6 +354,48 @@ func (p *DeltaBudgetPolicy) EvaluateDiff(ctx *DiffPolicyContext) *DiffPolicyResu
 	return result
 }
 
+// ResourceDeltaPolicy limits how much any single resource's monthly cost
+// may increase
+type ResourceDeltaPolicy struct {
+	// Maximum monthly increase per resource
+	MaxResourceIncrease float64
+}
+
+// NewResourceDeltaPolicy creates a policy
+func NewResourceDeltaPolicy(maxIncrease float64) *ResourceDeltaPolicy {
+	return &ResourceDeltaPolicy{MaxResourceIncrease: maxIncrease}
+}
+
+// Name returns the policy name
+func (p *ResourceDeltaPolicy) Name() string {
+	return "resource-delta"
+}
+
+// EvaluateDiff checks each resource's increase against the limit
+func (p *ResourceDeltaPolicy) EvaluateDiff(ctx *DiffPolicyContext) *DiffPolicyResult {
+	result := &DiffPolicyResult{
+		PolicyName: p.Name(),
+		Passed:     true,
+		Violations: []DiffViolation{},
+		CostImpact: ctx.TotalDelta,
+	}
+
+	for _, d := range ctx.Deltas {
+		if d.Delta > p.MaxResourceIncrease {
+			result.Passed = false
+			result.Violations = append(result.Violations, DiffViolation{
+				Type:       ViolationBudgetExceeded,
+				Address:    string(d.InstanceID),
+				Reason:     d.ResourceType + " " + d.Change.String() + " increase exceeds per-resource limit",
+				CostImpact: d.Delta,
+				Blocking:   true,
+			})
+		}
+	}
+
+	return result
+}
+
 // NewResourcesOnlyPolicy evaluates only new resources
 type NewResourcesOnlyPolicy struct {
 	// Inner policy to apply
@@ -328,22 +422,37 @@ func (p *NewResourcesOnlyPolicy) EvaluateDiff(ctx *DiffPolicyContext) *DiffPolic
 			ByService:    make(map[string]float64),
 			Confidence:   1.0,
 		},
-		After:          ctx.After,
 		ConfidenceInfo: ctx.ConfidenceInfo,
 		Scope:          ctx.Scope,
+		Created:        ctx.Created,
 	}
 
-	// Remove resources that existed before
-	if ctx.Before != nil {
-		for id := range ctx.Before.Resources {
-			delete(filteredCtx.After.Resources, id)
+	// Copy After without the resources that existed before, so policies
+	// evaluated after this one still see the full context
+	after := CostSnapshot{}
+	if ctx.After != nil {
+		after = *ctx.After
+	}
+	after.Resources = make(map[model.InstanceID]float64)
+	after.TotalMonthly = 0
+	if ctx.After != nil {
+		for id, cost := range ctx.After.Resources {
+			if ctx.Before != nil {
+				if _, existed := ctx.Before.Resources[id]; existed {
+					continue
+				}
+			}
+			after.Resources[id] = cost
+			after.TotalMonthly += cost
 		}
 	}
+	filteredCtx.After = &after
 
-	// Recalculate total
-	filteredCtx.After.TotalMonthly = 0
-	for _, cost := range filteredCtx.After.Resources {
-		filteredCtx.After.TotalMonthly += cost
+	for _, d := range ctx.Deltas {
+		if d.Change == ResourceCreated {
+			filteredCtx.Deltas = append(filteredCtx.Deltas, d)
+			filteredCtx.TotalDelta += d.Delta
+		}
 	}
 
 	return p.inner.EvaluateDiff(filteredCtx)
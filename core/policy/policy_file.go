@@ -0,0 +1,338 @@
+// Package policy - Policy configuration file
+// One policy.yaml (or policy.json) declares every cost policy for a
+// project, so CI, the CLI and the HTTP adapter enforce the same rules
+// instead of each taking its own flags and config fields.
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"terraform-cost/core/determinism"
+)
+
+// PolicyFileVersion is the only supported policy file version
+const PolicyFileVersion = 1
+
+// DefaultBudgetWarnAt is the budget fraction that triggers a warning message
+// when warn_at is not set
+const DefaultBudgetWarnAt = 0.8
+
+// PolicyFile is the parsed policy configuration
+type PolicyFile struct {
+	// Version of the file format (PolicyFileVersion; 0 is treated as 1)
+	Version int `json:"version"`
+
+	// Budgets are monthly spend limits; each is evaluated separately
+	Budgets []BudgetRule `json:"budgets,omitempty"`
+
+	// Coverage holds estimate-wide quality thresholds
+	Coverage CoverageRule `json:"coverage,omitempty"`
+
+	// ResourceTypes limit instance count or cost per resource type
+	ResourceTypes []ResourceTypeRule `json:"resource_types,omitempty"`
+
+	// DeniedTypes are resource types that must not appear
+	DeniedTypes []string `json:"denied_types,omitempty"`
+
+	// RequiredTags must be present on every resource
+	RequiredTags []string `json:"required_tags,omitempty"`
+}
+
+// BudgetRule is one monthly budget
+type BudgetRule struct {
+	Name         string   `json:"name"`
+	MonthlyLimit float64  `json:"monthly_limit"`
+	WarnAt       float64  `json:"warn_at,omitempty"`
+	Severity     Severity `json:"severity,omitempty"`
+}
+
+// CoverageRule holds thresholds on how much of the estimate is trustworthy.
+// Unset fields leave the consumer's defaults in place.
+type CoverageRule struct {
+	MaxSymbolicPercent    *float64 `json:"max_symbolic_percent,omitempty"`
+	MaxUnsupportedPercent *float64 `json:"max_unsupported_percent,omitempty"`
+	MinConfidence         *float64 `json:"min_confidence,omitempty"`
+}
+
+// ResourceTypeRule limits one resource type
+type ResourceTypeRule struct {
+	Type           string   `json:"type"`
+	MaxInstances   int      `json:"max_instances,omitempty"`
+	MaxMonthlyCost *float64 `json:"max_monthly_cost,omitempty"`
+	Severity       Severity `json:"severity,omitempty"`
+}
+
+// PolicyFileError lists every problem found in a policy file
+type PolicyFileError struct {
+	Path     string
+	Problems []string
+}
+
+func (e *PolicyFileError) Error() string {
+	return fmt.Sprintf("invalid policy file %s: %s", e.Path, strings.Join(e.Problems, "; "))
+}
+
+// LoadPolicyFile reads and validates a policy file. The format follows the
+// extension: .yaml/.yml or .json.
+func LoadPolicyFile(path string) (*PolicyFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	var format string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		format = "yaml"
+	case ".json":
+		format = "json"
+	default:
+		return nil, fmt.Errorf("policy file %s: unsupported extension (use .yaml, .yml or .json)", path)
+	}
+
+	f, err := ParsePolicyFile(data, format)
+	if perr, ok := err.(*PolicyFileError); ok {
+		perr.Path = path
+	}
+	return f, err
+}
+
+// ParsePolicyFile parses and validates policy file content in "yaml" or
+// "json". Unknown keys are reported rather than ignored, so a misspelled
+// limit cannot silently disable a policy.
+func ParsePolicyFile(data []byte, format string) (*PolicyFile, error) {
+	var raw interface{}
+	switch format {
+	case "yaml":
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, &PolicyFileError{Problems: []string{err.Error()}}
+		}
+	case "json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&raw); err != nil {
+			return nil, &PolicyFileError{Problems: []string{err.Error()}}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported policy file format %q", format)
+	}
+	if raw == nil {
+		raw = map[string]interface{}{}
+	}
+
+	problems := unknownKeys(raw, reflect.TypeOf(PolicyFile{}), "")
+
+	// Decode through JSON so YAML and JSON share the field tags
+	normalized, err := json.Marshal(raw)
+	if err != nil {
+		return nil, &PolicyFileError{Problems: append(problems, err.Error())}
+	}
+	var f PolicyFile
+	if err := json.Unmarshal(normalized, &f); err != nil {
+		return nil, &PolicyFileError{Problems: append(problems, err.Error())}
+	}
+
+	problems = append(problems, f.validate()...)
+	if len(problems) > 0 {
+		return nil, &PolicyFileError{Problems: problems}
+	}
+	return &f, nil
+}
+
+// unknownKeys returns "unknown key" problems for map keys that have no
+// matching json tag in t, recursing into nested structs and slices
+func unknownKeys(raw interface{}, t reflect.Type, path string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var problems []string
+	switch v := raw.(type) {
+	case map[string]interface{}:
+		if t.Kind() != reflect.Struct {
+			return nil
+		}
+		fields := make(map[string]reflect.Type, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+			fields[name] = t.Field(i).Type
+		}
+
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			key := k
+			if path != "" {
+				key = path + "." + k
+			}
+			ft, ok := fields[k]
+			if !ok {
+				problems = append(problems, "unknown key "+key)
+				continue
+			}
+			problems = append(problems, unknownKeys(v[k], ft, key)...)
+		}
+	case []interface{}:
+		if t.Kind() != reflect.Slice {
+			return nil
+		}
+		for i, item := range v {
+			problems = append(problems, unknownKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return problems
+}
+
+// validate checks values the decoder cannot
+func (f *PolicyFile) validate() []string {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if f.Version != 0 && f.Version != PolicyFileVersion {
+		add("unsupported version %d (expected %d)", f.Version, PolicyFileVersion)
+	}
+
+	names := make(map[string]bool)
+	for i, b := range f.Budgets {
+		switch {
+		case b.Name == "":
+			add("budgets[%d].name is required", i)
+		case names[b.Name]:
+			add("budgets[%d].name %q is used by another budget", i, b.Name)
+		}
+		names[b.Name] = true
+		if b.MonthlyLimit <= 0 {
+			add("budgets[%d].monthly_limit must be positive", i)
+		}
+		if b.WarnAt < 0 || b.WarnAt > 1 {
+			add("budgets[%d].warn_at must be between 0 and 1", i)
+		}
+		if !validSeverity(b.Severity) {
+			add("budgets[%d].severity %q is not one of info, warning, error, block", i, b.Severity)
+		}
+	}
+
+	if p := f.Coverage.MaxSymbolicPercent; p != nil && (*p < 0 || *p > 100) {
+		add("coverage.max_symbolic_percent must be between 0 and 100")
+	}
+	if p := f.Coverage.MaxUnsupportedPercent; p != nil && (*p < 0 || *p > 100) {
+		add("coverage.max_unsupported_percent must be between 0 and 100")
+	}
+	if p := f.Coverage.MinConfidence; p != nil && (*p < 0 || *p > 1) {
+		add("coverage.min_confidence must be between 0 and 1")
+	}
+
+	for i, r := range f.ResourceTypes {
+		if r.Type == "" {
+			add("resource_types[%d].type is required", i)
+		}
+		if r.MaxInstances < 0 {
+			add("resource_types[%d].max_instances must not be negative", i)
+		}
+		if r.MaxMonthlyCost != nil && *r.MaxMonthlyCost < 0 {
+			add("resource_types[%d].max_monthly_cost must not be negative", i)
+		}
+		if r.MaxInstances == 0 && r.MaxMonthlyCost == nil {
+			add("resource_types[%d] sets neither max_instances nor max_monthly_cost", i)
+		}
+		if !validSeverity(r.Severity) {
+			add("resource_types[%d].severity %q is not one of info, warning, error, block", i, r.Severity)
+		}
+	}
+
+	for i, t := range f.DeniedTypes {
+		if t == "" {
+			add("denied_types[%d] is empty", i)
+		}
+	}
+	for i, t := range f.RequiredTags {
+		if t == "" {
+			add("required_tags[%d] is empty", i)
+		}
+	}
+	return problems
+}
+
+// validSeverity reports whether s is empty (default) or a known severity
+func validSeverity(s Severity) bool {
+	switch s {
+	case "", SeverityInfo, SeverityWarning, SeverityError, SeverityBlock:
+		return true
+	}
+	return false
+}
+
+// ResourcePolicies returns the policies evaluated over resources and
+// totals: budgets, resource type limits, denied types and required tags
+func (f *PolicyFile) ResourcePolicies() []DeepPolicy {
+	var policies []DeepPolicy
+	for _, b := range f.Budgets {
+		warnAt := b.WarnAt
+		if warnAt == 0 {
+			warnAt = DefaultBudgetWarnAt
+		}
+		limit := determinism.NewMoneyFromFloat(b.MonthlyLimit, "USD")
+		policies = append(policies, WithSeverity(NewBudgetPolicy("budget:"+b.Name, limit, warnAt), b.Severity))
+	}
+
+	for _, r := range f.ResourceTypes {
+		var maxCost *determinism.Money
+		if r.MaxMonthlyCost != nil {
+			m := determinism.NewMoneyFromFloat(*r.MaxMonthlyCost, "USD")
+			maxCost = &m
+		}
+		policies = append(policies, WithSeverity(
+			NewResourceTypePolicy("resource_type:"+r.Type, r.Type, r.MaxInstances, maxCost), r.Severity))
+	}
+
+	if len(f.DeniedTypes) > 0 {
+		policies = append(policies, NewDeniedTypePolicy("denied_types", f.DeniedTypes))
+	}
+	if len(f.RequiredTags) > 0 {
+		policies = append(policies, NewTagRequirementPolicy("required_tags", f.RequiredTags))
+	}
+	return policies
+}
+
+// CoveragePolicies returns the policies for the coverage thresholds that
+// are set. They fail as warnings, like the CI defaults they replace.
+func (f *PolicyFile) CoveragePolicies() []DeepPolicy {
+	var policies []DeepPolicy
+	if v := f.Coverage.MaxSymbolicPercent; v != nil {
+		policies = append(policies, WithSeverity(NewCoveragePolicy("max_symbolic_percent", CoverageSymbolic, *v), SeverityWarning))
+	}
+	if v := f.Coverage.MaxUnsupportedPercent; v != nil {
+		policies = append(policies, WithSeverity(NewCoveragePolicy("max_unsupported_percent", CoverageUnsupported, *v), SeverityWarning))
+	}
+	if v := f.Coverage.MinConfidence; v != nil {
+		policies = append(policies, WithSeverity(NewConfidencePolicy("min_confidence", *v), SeverityWarning))
+	}
+	return policies
+}
+
+// Evaluator returns an evaluator with every policy in the file. It is the
+// one evaluator CI, the CLI and the HTTP adapter enforce a file with.
+func (f *PolicyFile) Evaluator() *DeepEvaluator {
+	e := NewDeepEvaluator()
+	for _, p := range f.ResourcePolicies() {
+		e.Register(p)
+	}
+	for _, p := range f.CoveragePolicies() {
+		e.Register(p)
+	}
+	return e
+}
@@ -0,0 +1,138 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/model"
+)
+
+const testPolicyYAML = `
+version: 1
+budgets:
+  - name: total
+    monthly_limit: 500
+  - name: hard-cap
+    monthly_limit: 2000
+    severity: block
+coverage:
+  max_symbolic_percent: 15
+  min_confidence: 0.8
+resource_types:
+  - type: aws_instance
+    max_instances: 1
+    severity: warning
+denied_types: [aws_redshift_cluster]
+required_tags: [team]
+`
+
+func TestLoadPolicyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(testPolicyYAML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := LoadPolicyFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Budgets) != 2 || *f.Coverage.MaxSymbolicPercent != 15 || f.Coverage.MaxUnsupportedPercent != nil {
+		t.Fatalf("unexpected parse: %+v", f)
+	}
+
+	usd := func(v float64) determinism.Money { return determinism.NewMoneyFromFloat(v, "USD") }
+	input := &PolicyInput{
+		TotalMonthlyCost:  usd(900),
+		OverallConfidence: 0.9,
+		Coverage:          &CoverageInput{SymbolicPercent: 20},
+		InstanceCosts: map[model.InstanceID]*InstanceCostDetail{
+			"web-0": {Address: "aws_instance.web[0]", ResourceType: "aws_instance", MonthlyCost: usd(300), Tags: map[string]string{"team": "a"}},
+			"web-1": {Address: "aws_instance.web[1]", ResourceType: "aws_instance", MonthlyCost: usd(300), Tags: map[string]string{"team": "a"}},
+			"dw":    {Address: "module.dw.aws_redshift_cluster.main", ResourceType: "aws_redshift_cluster", MonthlyCost: usd(300)},
+		},
+	}
+	result, err := f.Evaluator().Evaluate(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]Severity)
+	for _, p := range result.Policies {
+		if !p.Passed {
+			got[p.Name] = p.Severity
+		}
+	}
+	want := map[string]Severity{
+		"budget:total":               SeverityError,
+		"resource_type:aws_instance": SeverityWarning,
+		"denied_types":               SeverityError,
+		"required_tags":              SeverityError,
+		"max_symbolic_percent":       SeverityWarning,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("failed policies = %v, want %v", got, want)
+	}
+	if len(result.Policies) != 7 {
+		t.Errorf("evaluated %d policies, want 7 (two budgets, type limit, denied, tags, symbolic, confidence)", len(result.Policies))
+	}
+}
+
+func TestParsePolicyFileReportsProblems(t *testing.T) {
+	data := []byte(`{
+		"budgets": [{"name": "total", "monthly_limt": 500, "severity": "fatal"}],
+		"coverage": {"min_confidence": 80},
+		"resource_types": [{"type": "aws_instance"}],
+		"required_tag": ["team"]
+	}`)
+
+	_, err := ParsePolicyFile(data, "json")
+	var perr *PolicyFileError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *PolicyFileError, got %v", err)
+	}
+	want := []string{
+		"unknown key budgets[0].monthly_limt",
+		"unknown key required_tag",
+		"budgets[0].monthly_limit must be positive",
+		`budgets[0].severity "fatal" is not one of info, warning, error, block`,
+		"coverage.min_confidence must be between 0 and 1",
+		"resource_types[0] sets neither max_instances nor max_monthly_cost",
+	}
+	if !reflect.DeepEqual(perr.Problems, want) {
+		t.Errorf("problems =\n%q\nwant\n%q", perr.Problems, want)
+	}
+}
+
+// TestCoveragePolicies proves each coverage threshold limits its own
+// percentage, and passes when the caller does not measure coverage
+func TestCoveragePolicies(t *testing.T) {
+	f, err := ParsePolicyFile([]byte(`{"coverage": {"max_symbolic_percent": 20, "max_unsupported_percent": 5}}`), "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	evaluate := func(coverage *CoverageInput) map[string]bool {
+		t.Helper()
+		result, err := f.Evaluator().Evaluate(context.Background(), &PolicyInput{Coverage: coverage})
+		if err != nil {
+			t.Fatal(err)
+		}
+		passed := make(map[string]bool)
+		for _, p := range result.Policies {
+			passed[p.Name] = p.Passed
+		}
+		return passed
+	}
+
+	got := evaluate(&CoverageInput{SymbolicPercent: 10, UnsupportedPercent: 8})
+	if want := map[string]bool{"max_symbolic_percent": true, "max_unsupported_percent": false}; !reflect.DeepEqual(got, want) {
+		t.Errorf("passed = %v, want %v", got, want)
+	}
+	got = evaluate(nil)
+	if want := map[string]bool{"max_symbolic_percent": true, "max_unsupported_percent": true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unmeasured coverage: passed = %v, want %v", got, want)
+	}
+}
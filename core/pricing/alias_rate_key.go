@@ -228,10 +228,7 @@ func (e *RateNotFoundError) Error() string {
 
 // LookupRateByKey looks up a rate by key string
 func (s *PricingSnapshot) LookupRateByKey(key string) (*RateEntry, bool) {
-	for i := range s.rates {
-		if s.rates[i].Key.String() == key {
-			return &s.rates[i], true
-		}
-	}
-	return nil, false
+	s.keyIndexOnce.Do(s.buildKeyIndex)
+	rate, ok := s.keyIndex[key]
+	return rate, ok
 }
@@ -0,0 +1,94 @@
+// Package demo embeds a small, approximate AWS rate set so the tool can
+// estimate out of the box, without ingesting a pricing catalog or running
+// Postgres. It covers common EC2, EBS, RDS and S3 rates in a few regions:
+// us-east-1 list prices, scaled by a per-region factor elsewhere.
+//
+// The rates are for demos and first runs only. Their snapshots carry
+// pricing.SourceDemo, which the engine reports as approximate and at low
+// confidence.
+package demo
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/pricing"
+)
+
+// Provider is the only provider the demo rates cover
+const Provider = "aws"
+
+// DefaultRegion is the region used when none is given
+const DefaultRegion = "us-east-1"
+
+//go:embed rates.json
+var ratesJSON []byte
+
+// rateFile is the layout of rates.json
+type rateFile struct {
+	EffectiveAt time.Time                  `json:"effective_at"`
+	Regions     map[string]decimal.Decimal `json:"regions"`
+	Rates       []struct {
+		ResourceType string            `json:"resource_type"`
+		Component    string            `json:"component"`
+		Attributes   map[string]string `json:"attributes"`
+		Price        decimal.Decimal   `json:"price"`
+		Unit         string            `json:"unit"`
+	} `json:"rates"`
+}
+
+var (
+	loadOnce  sync.Once
+	snapshots []*pricing.PricingSnapshot
+	loadErr   error
+)
+
+// Snapshots returns one sealed snapshot per demo region, in region order
+func Snapshots() ([]*pricing.PricingSnapshot, error) {
+	loadOnce.Do(func() { snapshots, loadErr = load(ratesJSON) })
+	return snapshots, loadErr
+}
+
+// Regions lists the regions the demo rates cover
+func Regions() []string {
+	all, _ := Snapshots()
+	regions := make([]string, len(all))
+	for i, s := range all {
+		regions[i] = s.Region
+	}
+	return regions
+}
+
+// load builds the snapshots of a rates file
+func load(data []byte) ([]*pricing.PricingSnapshot, error) {
+	var file rateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("demo rates: %w", err)
+	}
+
+	regions := make([]string, 0, len(file.Regions))
+	for region := range file.Regions {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	out := make([]*pricing.PricingSnapshot, 0, len(regions))
+	for _, region := range regions {
+		factor := file.Regions[region]
+		builder := pricing.NewSnapshotBuilder(Provider, region).
+			WithSource(pricing.SourceDemo).
+			WithEffectiveAt(file.EffectiveAt)
+		for _, r := range file.Rates {
+			builder.AddRate(pricing.LookupKey(r.ResourceType, r.Component, r.Attributes),
+				r.Price.Mul(factor).Round(6), r.Unit, "USD")
+		}
+		out = append(out, builder.Build())
+	}
+	return out, nil
+}
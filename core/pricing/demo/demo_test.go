@@ -0,0 +1,48 @@
+package demo
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/pricing"
+)
+
+func TestSnapshots(t *testing.T) {
+	snapshots, err := Snapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) == 0 {
+		t.Fatal("no demo snapshots")
+	}
+
+	prices := map[string]decimal.Decimal{}
+	for _, s := range snapshots {
+		if s.Provider != Provider || s.Source != pricing.SourceDemo || !s.Verify() {
+			t.Errorf("%s: provider %q source %s verified %v", s.Region, s.Provider, s.Source, s.Verify())
+		}
+		for _, lookup := range []struct {
+			resourceType, component string
+			attrs                   map[string]string
+		}{
+			{"aws_instance", "compute", map[string]string{"instance_type": "t3.micro"}},
+			{"aws_ebs_volume", "storage", map[string]string{"volume_type": "gp3"}},
+			{"aws_db_instance", "compute", map[string]string{"instance_class": "db.t3.micro"}},
+			{"aws_s3_bucket", "storage", map[string]string{"storage_class": "STANDARD"}},
+		} {
+			if _, ok := s.LookupRate(lookup.resourceType, lookup.component, lookup.attrs); !ok {
+				t.Errorf("%s: no %s %s rate", s.Region, lookup.resourceType, lookup.component)
+			}
+		}
+		rate, _ := s.LookupRate("aws_instance", "compute", map[string]string{"instance_type": "t3.micro"})
+		prices[s.Region] = rate.Price
+	}
+
+	if !prices[DefaultRegion].Equal(decimal.RequireFromString("0.0104")) {
+		t.Errorf("%s t3.micro = %s, want list price 0.0104", DefaultRegion, prices[DefaultRegion])
+	}
+	if !prices["eu-west-1"].Equal(decimal.RequireFromString("0.01144")) {
+		t.Errorf("eu-west-1 t3.micro = %s, want 0.0104 x 1.10", prices["eu-west-1"])
+	}
+}
@@ -39,9 +39,9 @@ type SnapshotStore interface {
 	// Get retrieves a specific snapshot by ID
 	Get(ctx context.Context, id SnapshotID) (*PricingSnapshot, error)
 
-	// GetLatest retrieves the latest snapshot for a provider/region
+	// GetLatest retrieves the latest snapshot for a provider/region/alias
 	// Returns error if no snapshot exists
-	GetLatest(ctx context.Context, provider, region string) (*PricingSnapshot, error)
+	GetLatest(ctx context.Context, provider, region, alias string) (*PricingSnapshot, error)
 
 	// Store saves a snapshot
 	Store(ctx context.Context, snapshot *PricingSnapshot) error
@@ -74,9 +74,10 @@ type SnapshotRequest struct {
 	// SnapshotID - if provided, use this specific snapshot
 	SnapshotID SnapshotID
 
-	// Otherwise, find latest for provider/region
+	// Otherwise, find latest for provider/region/alias
 	Provider string
 	Region   string
+	Alias    string // empty = default account
 
 	// AllowExpired allows using expired snapshots (with warning)
 	AllowExpired bool
@@ -108,9 +109,9 @@ func (r *EnforcedResolver) GetSnapshot(ctx context.Context, req SnapshotRequest)
 			return nil, fmt.Errorf("failed to get snapshot %s: %w", req.SnapshotID, err)
 		}
 	} else if req.Provider != "" && req.Region != "" {
-		snapshot, err = r.store.GetLatest(ctx, req.Provider, req.Region)
+		snapshot, err = r.store.GetLatest(ctx, req.Provider, req.Region, req.Alias)
 		if err != nil {
-			return nil, fmt.Errorf("no snapshot for %s/%s: %w", req.Provider, req.Region, ErrNoSnapshot)
+			return nil, fmt.Errorf("no snapshot for %s/%s (alias %s): %w", req.Provider, req.Region, aliasOrDefault(req.Alias), ErrNoSnapshot)
 		}
 	} else {
 		return nil, ErrNoSnapshot
@@ -237,7 +238,7 @@ func (r DegradationReason) String() string {
 type InMemorySnapshotStore struct {
 	mu        sync.RWMutex
 	snapshots map[SnapshotID]*PricingSnapshot
-	latest    map[string]*PricingSnapshot // key: provider:region
+	latest    map[string]*PricingSnapshot // key: latestKey(provider, region, alias)
 }
 
 // NewInMemorySnapshotStore creates an in-memory store
@@ -259,11 +260,11 @@ func (s *InMemorySnapshotStore) Get(ctx context.Context, id SnapshotID) (*Pricin
 	return snap, nil
 }
 
-// GetLatest retrieves the latest snapshot for provider/region
-func (s *InMemorySnapshotStore) GetLatest(ctx context.Context, provider, region string) (*PricingSnapshot, error) {
+// GetLatest retrieves the latest snapshot for provider/region/alias
+func (s *InMemorySnapshotStore) GetLatest(ctx context.Context, provider, region, alias string) (*PricingSnapshot, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	key := provider + ":" + region
+	key := latestKey(provider, region, alias)
 	snap, ok := s.latest[key]
 	if !ok {
 		return nil, ErrNoSnapshot
@@ -276,10 +277,29 @@ func (s *InMemorySnapshotStore) Store(ctx context.Context, snapshot *PricingSnap
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.snapshots[snapshot.ID] = snapshot
-	key := snapshot.Provider + ":" + snapshot.Region
+	key := latestKey(snapshot.Provider, snapshot.Region, snapshot.Alias)
 	// Update latest if this is newer
 	if existing, ok := s.latest[key]; !ok || snapshot.CreatedAt.After(existing.CreatedAt) {
 		s.latest[key] = snapshot
 	}
 	return nil
 }
+
+// DefaultAlias is the provider alias of the default account
+const DefaultAlias = "default"
+
+// latestKey keys the latest-snapshot index. The default alias keeps the
+// original provider:region form so existing indexes stay readable.
+func latestKey(provider, region, alias string) string {
+	if alias == "" || alias == DefaultAlias {
+		return provider + ":" + region
+	}
+	return provider + ":" + region + ":" + alias
+}
+
+func aliasOrDefault(alias string) string {
+	if alias == "" {
+		return DefaultAlias
+	}
+	return alias
+}
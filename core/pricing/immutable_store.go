@@ -43,6 +43,7 @@ type SnapshotMetadata struct {
 	EffectiveAt time.Time  `json:"effective_at"`
 	Provider    string     `json:"provider"`
 	Region      string     `json:"region"`
+	Alias       string     `json:"alias,omitempty"`
 	Version     int        `json:"version"`
 	Size        int64      `json:"size"`
 	FilePath    string     `json:"file_path"`
@@ -114,6 +115,7 @@ func (s *ImmutableSnapshotStore) Store(ctx context.Context, snapshot *PricingSna
 		EffectiveAt: snapshot.EffectiveAt,
 		Provider:    snapshot.Provider,
 		Region:      snapshot.Region,
+		Alias:       snapshot.Alias,
 		Version:     1, // First version
 		Size:        int64(len(data)),
 		FilePath:    filePath,
@@ -122,7 +124,7 @@ func (s *ImmutableSnapshotStore) Store(ctx context.Context, snapshot *PricingSna
 	s.index[snapshot.ID] = meta
 
 	// Update latest
-	key := snapshot.Provider + ":" + snapshot.Region
+	key := latestKey(snapshot.Provider, snapshot.Region, snapshot.Alias)
 	if current, ok := s.latest[key]; !ok {
 		s.latest[key] = snapshot.ID
 	} else {
@@ -163,10 +165,10 @@ func (s *ImmutableSnapshotStore) Get(ctx context.Context, id SnapshotID) (*Prici
 	return s.deserialize(data)
 }
 
-// GetLatest retrieves the latest snapshot for provider/region
-func (s *ImmutableSnapshotStore) GetLatest(ctx context.Context, provider, region string) (*PricingSnapshot, error) {
+// GetLatest retrieves the latest snapshot for provider/region/alias
+func (s *ImmutableSnapshotStore) GetLatest(ctx context.Context, provider, region, alias string) (*PricingSnapshot, error) {
 	s.mu.RLock()
-	key := provider + ":" + region
+	key := latestKey(provider, region, alias)
 	id, ok := s.latest[key]
 	s.mu.RUnlock()
 
@@ -224,6 +226,7 @@ func (s *ImmutableSnapshotStore) serialize(snapshot *PricingSnapshot) ([]byte, e
 		EffectiveAt time.Time          `json:"effective_at"`
 		Provider    string             `json:"provider"`
 		Region      string             `json:"region"`
+		Alias       string             `json:"alias,omitempty"`
 		Rates       []RateEntry        `json:"rates"`
 		Coverage    SnapshotCoverage   `json:"coverage"`
 	}
@@ -235,6 +238,7 @@ func (s *ImmutableSnapshotStore) serialize(snapshot *PricingSnapshot) ([]byte, e
 		EffectiveAt: snapshot.EffectiveAt,
 		Provider:    snapshot.Provider,
 		Region:      snapshot.Region,
+		Alias:       snapshot.Alias,
 		Rates:       snapshot.Rates(),
 		Coverage:    snapshot.Coverage,
 	}
@@ -250,6 +254,7 @@ func (s *ImmutableSnapshotStore) deserialize(data []byte) (*PricingSnapshot, err
 		EffectiveAt time.Time          `json:"effective_at"`
 		Provider    string             `json:"provider"`
 		Region      string             `json:"region"`
+		Alias       string             `json:"alias,omitempty"`
 		Rates       []RateEntry        `json:"rates"`
 		Coverage    SnapshotCoverage   `json:"coverage"`
 	}
@@ -264,10 +269,11 @@ func (s *ImmutableSnapshotStore) deserialize(data []byte) (*PricingSnapshot, err
 		WithEffectiveAt(ss.EffectiveAt)
 
 	for _, rate := range ss.Rates {
-		builder.AddRate(rate.Key, rate.Price, rate.Unit, rate.Currency)
+		builder.AddRateEntry(rate)
 	}
 
 	snapshot := builder.Build()
+	snapshot.Alias = ss.Alias
 	return snapshot, nil
 }
 
@@ -0,0 +1,241 @@
+// Package pricing - Rate overrides layered on a snapshot
+// Teams with a few negotiated prices override them from a small file
+// instead of re-ingesting a snapshot. An OverlaySnapshot answers lookups
+// from the overrides first and the base snapshot otherwise; overridden
+// rates carry the file name, so lineage shows where a price came from.
+//
+// An overrides file is JSON:
+//
+//	{"rates": [{"provider": "aws", "region": "us-east-1",
+//	  "resource_type": "aws_instance", "component": "compute",
+//	  "attributes": {"instanceType": "m5.large", "operatingSystem": "Linux", ...},
+//	  "price": "0.081", "unit": "hour"}]}
+//
+// provider and region are optional and restrict an override to matching
+// snapshots. The attributes must be the full set the rate is looked up by.
+// currency is optional and defaults to the base snapshot's; an override in
+// another currency is rejected, since costs are summed in one currency.
+package pricing
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/determinism"
+)
+
+// RateOverride is one negotiated price
+type RateOverride struct {
+	Provider     string            `json:"provider,omitempty"`
+	Region       string            `json:"region,omitempty"`
+	ResourceType string            `json:"resource_type"`
+	Component    string            `json:"component"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+	Price        decimal.Decimal   `json:"price"`
+	Unit         string            `json:"unit"`
+	Currency     string            `json:"currency,omitempty"`
+	Description  string            `json:"description,omitempty"`
+}
+
+// RateOverrides is a loaded overrides file
+type RateOverrides struct {
+	// Source names the file in lineage
+	Source string `json:"-"`
+
+	Rates []RateOverride `json:"rates"`
+}
+
+// LoadRateOverrides reads and validates an overrides file
+func LoadRateOverrides(path string) (*RateOverrides, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rate overrides: %w", err)
+	}
+	var overrides RateOverrides
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse rate overrides %s: %w", path, err)
+	}
+	overrides.Source = filepath.Base(path)
+	if err := overrides.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid rate overrides %s: %w", path, err)
+	}
+	return &overrides, nil
+}
+
+// Validate checks each override is complete and no two overlap
+func (o *RateOverrides) Validate() error {
+	seen := make(map[string]int, len(o.Rates))
+	for i, r := range o.Rates {
+		switch {
+		case r.ResourceType == "" || r.Component == "":
+			return fmt.Errorf("rate %d: resource_type and component are required", i)
+		case r.Unit == "":
+			return fmt.Errorf("rate %d (%s %s): unit is required", i, r.ResourceType, r.Component)
+		case r.Price.IsNegative():
+			return fmt.Errorf("rate %d (%s %s): price %s is negative", i, r.ResourceType, r.Component, r.Price)
+		}
+		scope := r.Provider + "/" + r.Region + "/" + LookupKey(r.ResourceType, r.Component, r.Attributes).String()
+		if j, ok := seen[scope]; ok {
+			return fmt.Errorf("rates %d and %d override the same rate", j, i)
+		}
+		seen[scope] = i
+	}
+	return nil
+}
+
+// Apply layers the overrides on base, returning base itself when none
+// apply to its provider and region. A nil RateOverrides applies none.
+func (o *RateOverrides) Apply(base *PricingSnapshot) (*PricingSnapshot, error) {
+	if o == nil || base == nil {
+		return base, nil
+	}
+	overlay, err := NewOverlaySnapshot(base, o)
+	if err != nil {
+		return nil, err
+	}
+	return overlay.Snapshot(), nil
+}
+
+// OverlaySnapshot is a base snapshot with some of its rates overridden
+type OverlaySnapshot struct {
+	Base *PricingSnapshot
+
+	// overrides holds the rates that apply to Base, by lookup key
+	overrides map[RateKey]*RateEntry
+
+	once     sync.Once
+	snapshot *PricingSnapshot
+}
+
+// NewOverlaySnapshot layers the overrides matching base's provider and
+// region on base. It fails when one of them is priced in another currency
+// than base's rates.
+func NewOverlaySnapshot(base *PricingSnapshot, overrides *RateOverrides) (*OverlaySnapshot, error) {
+	o := &OverlaySnapshot{Base: base, overrides: make(map[RateKey]*RateEntry)}
+	idGen := determinism.NewIDGenerator("rate_override")
+	specificity := make(map[RateKey]int)
+	baseCurrency := snapshotCurrency(base)
+	for i, r := range overrides.Rates {
+		if (r.Provider != "" && r.Provider != base.Provider) || (r.Region != "" && r.Region != base.Region) {
+			continue
+		}
+		currency := r.Currency
+		if currency == "" {
+			currency = baseCurrency
+		}
+		if currency != baseCurrency {
+			return nil, fmt.Errorf("rate %d (%s %s): currency %s differs from the %s/%s snapshot's %s",
+				i, r.ResourceType, r.Component, currency, base.Provider, base.Region, baseCurrency)
+		}
+		// The override naming the provider or region wins over a broader one
+		score := 0
+		if r.Provider != "" {
+			score++
+		}
+		if r.Region != "" {
+			score += 2
+		}
+		key := LookupKey(r.ResourceType, r.Component, r.Attributes)
+		if _, ok := o.overrides[key]; ok && specificity[key] >= score {
+			continue
+		}
+		specificity[key] = score
+		o.overrides[key] = &RateEntry{
+			ID:          RateID(idGen.Generate(overrides.Source, key.String())),
+			Key:         key,
+			Price:       r.Price,
+			Unit:        r.Unit,
+			Currency:    currency,
+			Description: r.Description,
+			Candidates:  1,
+			Overlay:     overrides.Source,
+		}
+	}
+	return o, nil
+}
+
+// snapshotCurrency is the currency a snapshot's rates are priced in; one
+// without rates prices in the default currency
+func snapshotCurrency(s *PricingSnapshot) string {
+	for _, r := range s.rates {
+		if r.Currency != "" {
+			return r.Currency
+		}
+	}
+	return determinism.DefaultCurrency
+}
+
+// LookupRate returns the override for the key when there is one and the
+// base snapshot's rate otherwise
+func (o *OverlaySnapshot) LookupRate(resourceType, component string, attrs map[string]string) (*RateEntry, bool) {
+	if rate, ok := o.overrides[LookupKey(resourceType, component, attrs)]; ok {
+		return rate, true
+	}
+	return o.Base.LookupRate(resourceType, component, attrs)
+}
+
+// Snapshot returns the overlay as a sealed snapshot for code that takes a
+// *PricingSnapshot. Its content hash covers the overrides, so caches keyed
+// by hash never mix it up with the base; it is the base itself when no
+// override applies.
+func (o *OverlaySnapshot) Snapshot() *PricingSnapshot {
+	o.once.Do(func() {
+		o.snapshot = o.build()
+	})
+	return o.snapshot
+}
+
+// build merges the overrides into a copy of the base's rates, replacing
+// every base rate that shares an overridden key
+func (o *OverlaySnapshot) build() *PricingSnapshot {
+	if len(o.overrides) == 0 {
+		return o.Base
+	}
+
+	rates := make([]RateEntry, 0, len(o.Base.rates)+len(o.overrides))
+	for _, r := range o.Base.rates {
+		if _, ok := o.overrides[r.Key]; !ok {
+			rates = append(rates, r)
+		}
+	}
+	for _, r := range o.overrides {
+		rates = append(rates, *r)
+	}
+	// Base rates are already in preference order within a key
+	sort.SliceStable(rates, func(i, j int) bool {
+		return rates[i].Key.String() < rates[j].Key.String()
+	})
+
+	index := make(map[RateKey]*RateEntry, len(rates))
+	for i := range rates {
+		if _, ok := index[rates[i].Key]; !ok {
+			index[rates[i].Key] = &rates[i]
+		}
+	}
+
+	coverage := o.Base.Coverage
+	coverage.TotalRates = len(rates)
+	snap := &PricingSnapshot{
+		CreatedAt:   o.Base.CreatedAt,
+		EffectiveAt: o.Base.EffectiveAt,
+		ExpiresAt:   o.Base.ExpiresAt,
+		Source:      o.Base.Source,
+		Region:      o.Base.Region,
+		Provider:    o.Base.Provider,
+		Alias:       o.Base.Alias,
+		rates:       rates,
+		rateIndex:   index,
+		Coverage:    coverage,
+	}
+	snap.ContentHash = snap.computeHash()
+	snap.ID = o.Base.ID + SnapshotID("+overlay-"+hex.EncodeToString(snap.ContentHash[:4]))
+	snap.sealed = true
+	return snap
+}
@@ -3,7 +3,11 @@
 package pricing
 
 import (
+	"fmt"
+	"strings"
 	"testing"
+
+	"github.com/shopspring/decimal"
 )
 
 // TestPricingBeforeFinalizationPanics proves pricing cannot occur before finalization
@@ -139,3 +143,205 @@ func TestScopedPricingSnapshot(t *testing.T) {
 
 	t.Log("Pricing snapshots are correctly alias-scoped")
 }
+
+// TestDuplicateRatesResolveDeterministically proves rates sharing a key
+// resolve to the same SKU whatever order ingestion added them in
+func TestDuplicateRatesResolveDeterministically(t *testing.T) {
+	key := RateKey{ResourceType: "aws_instance", Component: "compute", Attributes: "instance_type=m5.large"}
+	entry := func(sku, price string, attrs map[string]string) RateEntry {
+		return RateEntry{Key: key, SKU: sku, Price: decimal.RequireFromString(price), Unit: "hour", Currency: "USD", Attributes: attrs}
+	}
+	entries := []RateEntry{
+		entry("DEDICATED", "0.010", map[string]string{"tenancy": "Dedicated", "operatingSystem": "Linux"}),
+		entry("WINDOWS", "0.050", map[string]string{"tenancy": "Shared", "operatingSystem": "Windows"}),
+		entry("LINUX-B", "0.096", map[string]string{"tenancy": "Shared", "operatingSystem": "Linux"}),
+		entry("LINUX-A", "0.096", map[string]string{"tenancy": "Shared", "operatingSystem": "Linux"}),
+		entry("LINUX-DEAR", "0.200", map[string]string{"tenancy": "Shared", "operatingSystem": "Linux"}),
+	}
+
+	for shift := range entries {
+		builder := NewSnapshotBuilder("aws", "us-east-1")
+		for i := range entries {
+			builder.AddRateEntry(entries[(i+shift)%len(entries)])
+		}
+		rate, ok := builder.Build().GetRate(key)
+		if !ok {
+			t.Fatal("rate not found")
+		}
+		if rate.SKU != "LINUX-A" {
+			t.Errorf("order %d chose %s, want LINUX-A", shift, rate.SKU)
+		}
+		if rate.Candidates != len(entries) {
+			t.Errorf("candidates = %d, want %d", rate.Candidates, len(entries))
+		}
+	}
+}
+
+// TestLookupRateByKeyPrefersRate proves the key index returns the same
+// preferred rate as GetRate
+func TestLookupRateByKeyPrefersRate(t *testing.T) {
+	key := RateKey{ResourceType: "aws_instance", Component: "compute", Attributes: "instance_type=m5.large"}
+	snapshot := NewSnapshotBuilder("aws", "us-east-1").
+		AddRateEntry(RateEntry{Key: key, SKU: "DEAR", Price: decimal.RequireFromString("0.2"), Unit: "hour", Currency: "USD"}).
+		AddRateEntry(RateEntry{Key: key, SKU: "CHEAP", Price: decimal.RequireFromString("0.1"), Unit: "hour", Currency: "USD"}).
+		Build()
+
+	want, _ := snapshot.GetRate(key)
+	got, ok := snapshot.LookupRateByKey(key.String())
+	if !ok || got != want {
+		t.Errorf("LookupRateByKey = %v, want %v", got, want)
+	}
+	if _, ok := snapshot.LookupRateByKey("aws_instance/compute//instance_type=none"); ok {
+		t.Error("found a rate for an unknown key")
+	}
+}
+
+// BenchmarkLookupRateByKey compares the key index with a linear scan on a
+// 100k rate snapshot
+func BenchmarkLookupRateByKey(b *testing.B) {
+	const n = 100000
+	builder := NewSnapshotBuilder("aws", "us-east-1")
+	for i := 0; i < n; i++ {
+		key := RateKey{ResourceType: "aws_instance", Component: "compute", Attributes: fmt.Sprintf("instance_type=t%d", i)}
+		builder.AddRate(key, decimal.NewFromInt(1), "hour", "USD")
+	}
+	snapshot := builder.Build()
+	keys := make([]string, 1000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("aws_instance/compute//instance_type=t%d", i*(n/len(keys)))
+	}
+
+	b.Run("indexed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, ok := snapshot.LookupRateByKey(keys[i%len(keys)]); !ok {
+				b.Fatal("rate not found")
+			}
+		}
+	})
+	b.Run("linear", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			key := keys[i%len(keys)]
+			found := false
+			for j := range snapshot.rates {
+				if snapshot.rates[j].Key.String() == key {
+					found = true
+					break
+				}
+			}
+			if !found {
+				b.Fatal("rate not found")
+			}
+		}
+	})
+}
+
+// TestOverlaySnapshot proves overrides win over the base rate, apply only
+// to their provider and region, and change the content hash
+func TestOverlaySnapshot(t *testing.T) {
+	large := map[string]string{"instanceType": "m5.large"}
+	small := map[string]string{"instanceType": "t3.micro"}
+	base := NewSnapshotBuilder("aws", "us-east-1").
+		AddRate(LookupKey("aws_instance", "compute", large), decimal.RequireFromString("0.096"), "hour", "USD").
+		AddRate(LookupKey("aws_instance", "compute", small), decimal.RequireFromString("0.0104"), "hour", "USD").
+		Build()
+	overrides := &RateOverrides{Source: "overrides.json", Rates: []RateOverride{
+		{ResourceType: "aws_instance", Component: "compute", Attributes: large, Price: decimal.RequireFromString("0.081"), Unit: "hour"},
+		{Region: "eu-west-1", ResourceType: "aws_instance", Component: "compute", Attributes: small, Price: decimal.RequireFromString("0.001"), Unit: "hour"},
+	}}
+	if err := overrides.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	overlay, err := NewOverlaySnapshot(base, overrides)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, snap := range []interface {
+		LookupRate(string, string, map[string]string) (*RateEntry, bool)
+	}{overlay, overlay.Snapshot()} {
+		rate, ok := snap.LookupRate("aws_instance", "compute", large)
+		if !ok || rate.Price.String() != "0.081" || rate.Overlay != "overrides.json" {
+			t.Errorf("m5.large = %+v, want the 0.081 override", rate)
+		}
+		rate, ok = snap.LookupRate("aws_instance", "compute", small)
+		if !ok || rate.Price.String() != "0.0104" || rate.Overlay != "" {
+			t.Errorf("t3.micro = %+v, want the base rate (override is for eu-west-1)", rate)
+		}
+	}
+
+	snap := overlay.Snapshot()
+	if !snap.Verify() || snap.ContentHash == base.ContentHash {
+		t.Error("overlay snapshot must verify and hash differently from its base")
+	}
+	if got, err := (&RateOverrides{Rates: overrides.Rates[1:]}).Apply(base); err != nil || got != base {
+		t.Error("overrides for another region should leave the base unchanged")
+	}
+
+	dup := &RateOverrides{Rates: []RateOverride{overrides.Rates[0], overrides.Rates[0]}}
+	if err := dup.Validate(); err == nil {
+		t.Error("two overrides of the same rate should be rejected")
+	}
+}
+
+// TestOverlaySnapshotCurrency proves an override priced in another currency
+// than its base snapshot is rejected, and one without a currency takes the
+// base's
+func TestOverlaySnapshotCurrency(t *testing.T) {
+	large := map[string]string{"instanceType": "m5.large"}
+	base := NewSnapshotBuilder("aws", "us-east-1").
+		AddRate(LookupKey("aws_instance", "compute", large), decimal.RequireFromString("0.096"), "hour", "USD").
+		Build()
+	override := RateOverride{ResourceType: "aws_instance", Component: "compute", Attributes: large, Price: decimal.RequireFromString("0.081"), Unit: "hour"}
+
+	overlay, err := NewOverlaySnapshot(base, &RateOverrides{Rates: []RateOverride{override}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rate, _ := overlay.LookupRate("aws_instance", "compute", large); rate.Currency != "USD" {
+		t.Errorf("override currency = %q, want the base's USD", rate.Currency)
+	}
+
+	override.Currency = "EUR"
+	eur := &RateOverrides{Rates: []RateOverride{override}}
+	if _, err := NewOverlaySnapshot(base, eur); err == nil || !strings.Contains(err.Error(), "currency EUR differs from the aws/us-east-1 snapshot's USD") {
+		t.Errorf("EUR override on a USD snapshot: err = %v", err)
+	}
+	if _, err := eur.Apply(base); err == nil {
+		t.Error("Apply should reject the EUR override")
+	}
+
+	// An override for another region is not compared with this snapshot
+	override.Region = "eu-west-1"
+	if got, err := (&RateOverrides{Rates: []RateOverride{override}}).Apply(base); err != nil || got != base {
+		t.Errorf("Apply = %v, %v; want the base unchanged", got, err)
+	}
+}
+
+// TestImmutableStoreRoundTripKeepsAlias proves a stored snapshot keeps the
+// provider alias it is priced for
+func TestImmutableStoreRoundTripKeepsAlias(t *testing.T) {
+	snap := NewSnapshotBuilder("aws", "us-east-1").
+		AddRate(RateKey{ResourceType: "aws_instance", Component: "compute", Attributes: "instance_type=t3.micro"}, decimal.RequireFromString("0.0104"), "hour", "USD").
+		Build()
+	snap.Alias = "billing"
+
+	store := &ImmutableSnapshotStore{}
+	data, err := store.serialize(snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.deserialize(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Alias != "billing" {
+		t.Errorf("Alias = %q after a round trip, want billing", got.Alias)
+	}
+	if got.ID != snap.ID || got.Provider != "aws" || got.Region != "us-east-1" {
+		t.Errorf("round trip = %s %s/%s, want %s aws/us-east-1", got.ID, got.Provider, got.Region, snap.ID)
+	}
+	rate, ok := got.LookupRate("aws_instance", "compute", map[string]string{"instance_type": "t3.micro"})
+	if !ok || !rate.Price.Equal(decimal.RequireFromString("0.0104")) {
+		t.Errorf("rate after a round trip = %+v, %v", rate, ok)
+	}
+}
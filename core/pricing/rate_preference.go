@@ -0,0 +1,54 @@
+// Package pricing - Choosing between rates that share a key
+// Offer files can list several SKUs for one logical rate, e.g. the same
+// instance type under shared and dedicated tenancy or with different
+// operating systems. Lookups must pick the same SKU on every ingestion, so
+// candidates are ranked by a fixed preference:
+//
+//  1. shared tenancy, then tenancy not stated, then dedicated or host
+//  2. Linux, then operating system not stated, then any other
+//  3. lowest price
+//  4. SKU in lexical order
+//  5. unit and currency in lexical order
+package pricing
+
+import "strings"
+
+// tenancyAttributes and osAttributes are the attribute names providers use
+var (
+	tenancyAttributes = []string{"tenancy"}
+	osAttributes      = []string{"operatingSystem", "operating_system", "os"}
+)
+
+// preferRate reports whether a should be chosen over b
+func preferRate(a, b *RateEntry) bool {
+	if ra, rb := attributeRank(a, tenancyAttributes, "shared"), attributeRank(b, tenancyAttributes, "shared"); ra != rb {
+		return ra < rb
+	}
+	if ra, rb := attributeRank(a, osAttributes, "linux"), attributeRank(b, osAttributes, "linux"); ra != rb {
+		return ra < rb
+	}
+	if c := a.Price.Cmp(b.Price); c != 0 {
+		return c < 0
+	}
+	if a.SKU != b.SKU {
+		return a.SKU < b.SKU
+	}
+	if a.Unit != b.Unit {
+		return a.Unit < b.Unit
+	}
+	return a.Currency < b.Currency
+}
+
+// attributeRank is 0 when the rate's attribute (under any of names)
+// equals preferred, 1 when it is not set and 2 otherwise
+func attributeRank(r *RateEntry, names []string, preferred string) int {
+	for _, name := range names {
+		if v, ok := r.Attributes[name]; ok && v != "" {
+			if strings.EqualFold(v, preferred) {
+				return 0
+			}
+			return 2
+		}
+	}
+	return 1
+}
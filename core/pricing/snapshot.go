@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -33,11 +34,18 @@ type PricingSnapshot struct {
 	Source      PricingSource
 	Region      string
 	Provider    string // aws, azure, gcp
+	Alias       string // provider alias for per-account pricing ("" = default)
 
 	// The actual rates (sorted for determinism)
 	rates       []RateEntry
 	rateIndex   map[RateKey]*RateEntry
 
+	// keyIndex maps each rate key string to its preferred rate. It is
+	// built on the first LookupRateByKey; the snapshot is sealed, so it
+	// never goes stale for its content hash.
+	keyIndexOnce sync.Once
+	keyIndex     map[string]*RateEntry
+
 	// Coverage information
 	Coverage    SnapshotCoverage
 
@@ -54,6 +62,7 @@ const (
 	SourceDatabase                         // From pricing database
 	SourceManual                           // Manually specified
 	SourceDefault                          // Hardcoded defaults
+	SourceDemo                             // Approximate demo rates (core/pricing/demo)
 )
 
 // String returns the source name
@@ -69,6 +78,8 @@ func (s PricingSource) String() string {
 		return "manual"
 	case SourceDefault:
 		return "default"
+	case SourceDemo:
+		return "demo"
 	default:
 		return "unknown"
 	}
@@ -97,6 +108,21 @@ type RateEntry struct {
 	Description string
 	Tiers       []RateTier // For tiered pricing
 	Conditions  []RateCondition
+
+	// SKU is the provider's product code, when known
+	SKU string
+
+	// Attributes are product attributes not in Key (tenancy, operating
+	// system), used to choose between rates that share a key
+	Attributes map[string]string
+
+	// Candidates is how many rates in the snapshot share Key; the entry
+	// LookupRate returns was chosen by preferRate
+	Candidates int
+
+	// Overlay names the rate overrides file this rate came from; empty
+	// for rates of the snapshot itself (see OverlaySnapshot)
+	Overlay string
 }
 
 // RateTier represents a tier in tiered pricing
@@ -116,12 +142,19 @@ type RateCondition struct {
 // Bytes returns deterministic bytes for hashing
 func (r *RateEntry) Bytes() []byte {
 	// Use JSON for deterministic serialization
-	data, _ := json.Marshal(map[string]interface{}{
+	fields := map[string]interface{}{
 		"key":      r.Key.String(),
 		"price":    r.Price.String(),
 		"unit":     r.Unit,
 		"currency": r.Currency,
-	})
+	}
+	if r.SKU != "" {
+		fields["sku"] = r.SKU
+	}
+	if r.Overlay != "" {
+		fields["overlay"] = r.Overlay
+	}
+	data, _ := json.Marshal(fields)
 	return data
 }
 
@@ -220,6 +253,16 @@ func (b *SnapshotBuilder) AddRate(key RateKey, price decimal.Decimal, unit, curr
 	return b
 }
 
+// AddRateEntry adds a rate with its SKU and product attributes. Several
+// entries may share a key; Build picks one for lookups.
+func (b *SnapshotBuilder) AddRateEntry(entry RateEntry) *SnapshotBuilder {
+	entry.ID = ""
+	entry.Candidates = 0
+	b.rates = append(b.rates, entry)
+	b.services[entry.Key.ResourceType] = true
+	return b
+}
+
 // AddMissing documents a missing rate
 func (b *SnapshotBuilder) AddMissing(resourceType, component string, reason MissingReason, message string) *SnapshotBuilder {
 	b.missing = append(b.missing, MissingRate{
@@ -233,20 +276,34 @@ func (b *SnapshotBuilder) AddMissing(resourceType, component string, reason Miss
 
 // Build creates an immutable snapshot
 func (b *SnapshotBuilder) Build() *PricingSnapshot {
-	// Sort rates for deterministic ordering
-	sort.Slice(b.rates, func(i, j int) bool {
-		return b.rates[i].Key.String() < b.rates[j].Key.String()
+	// Sort rates for deterministic ordering; rates sharing a key are
+	// ordered by preference so the first is the one looked up
+	sort.SliceStable(b.rates, func(i, j int) bool {
+		ki, kj := b.rates[i].Key.String(), b.rates[j].Key.String()
+		if ki != kj {
+			return ki < kj
+		}
+		return preferRate(&b.rates[i], &b.rates[j])
 	})
 
 	// Generate IDs for each rate
 	idGen := determinism.NewIDGenerator("rate")
 	for i := range b.rates {
-		b.rates[i].ID = RateID(idGen.Generate(b.rates[i].Key.String()))
+		if sku := b.rates[i].SKU; sku != "" {
+			b.rates[i].ID = RateID(idGen.Generate(b.rates[i].Key.String(), sku))
+		} else {
+			b.rates[i].ID = RateID(idGen.Generate(b.rates[i].Key.String()))
+		}
 	}
 
-	// Build index
+	// Build index from the preferred rate of each key
 	index := make(map[RateKey]*RateEntry)
 	for i := range b.rates {
+		if first, ok := index[b.rates[i].Key]; ok {
+			first.Candidates++
+			continue
+		}
+		b.rates[i].Candidates = 1
 		index[b.rates[i].Key] = &b.rates[i]
 	}
 
@@ -308,6 +365,11 @@ func (s *PricingSnapshot) GetRate(key RateKey) (*RateEntry, bool) {
 
 // LookupRate finds a rate by resource type and component
 func (s *PricingSnapshot) LookupRate(resourceType, component string, attrs map[string]string) (*RateEntry, bool) {
+	return s.GetRate(LookupKey(resourceType, component, attrs))
+}
+
+// LookupKey returns the rate key LookupRate looks up
+func LookupKey(resourceType, component string, attrs map[string]string) RateKey {
 	// Serialize attributes deterministically
 	attrKeys := determinism.SortedKeys(attrs)
 	var attrStr string
@@ -318,12 +380,23 @@ func (s *PricingSnapshot) LookupRate(resourceType, component string, attrs map[s
 		attrStr += k + "=" + attrs[k]
 	}
 
-	key := RateKey{
+	return RateKey{
 		ResourceType: resourceType,
 		Component:    component,
 		Attributes:   attrStr,
 	}
-	return s.GetRate(key)
+}
+
+// buildKeyIndex indexes the first rate of each key string. Rates are
+// sorted with the preferred rate of a key first.
+func (s *PricingSnapshot) buildKeyIndex() {
+	s.keyIndex = make(map[string]*RateEntry, len(s.rateIndex))
+	for i := range s.rates {
+		key := s.rates[i].Key.String()
+		if _, ok := s.keyIndex[key]; !ok {
+			s.keyIndex[key] = &s.rates[i]
+		}
+	}
 }
 
 // Rates returns all rates in sorted order
@@ -366,12 +439,26 @@ type CostLineage struct {
 	RateID      RateID
 	RateKey     RateKey
 
+	// SKU is the chosen rate's product code and Candidates how many rates
+	// shared its key (see preferRate)
+	SKU         string
+	Candidates  int
+
+	// Overlay names the rate overrides file the rate came from, when it
+	// was overridden
+	Overlay     string
+
 	// Formula applied
 	Formula     FormulaApplication
 
 	// Usage input
 	Usage       UsageLineage
 
+	// Credit marks a deduction (free tier, savings plan coverage); its
+	// formula output is negative. CreditFor names the component it offsets.
+	Credit      bool
+	CreditFor   string
+
 	// Derived costs (for aggregated)
 	DerivedFrom []*CostLineage
 
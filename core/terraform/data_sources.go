@@ -0,0 +1,72 @@
+// Package terraform - Data source values
+// Data sources often drive expansion, e.g. for_each =
+// toset(data.aws_subnets.private.ids). From HCL alone their results are
+// unknown and the resource expands to placeholders at best; terraform plan
+// has read them, so when their values are supplied the references resolve
+// and the resource expands to concrete instances.
+package terraform
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+
+	"terraform-cost/core/model"
+)
+
+// DataSourceValues holds data source values keyed by address, e.g.
+// "data.aws_subnets.private". A value is the data source's attribute map,
+// a []any of instance values for a data source with count, or a
+// map[string]any of them keyed by each.key for one with for_each.
+type DataSourceValues map[string]any
+
+// WithData returns the resolver resolving references to the data sources
+// in values
+func (r *Resolver) WithData(values DataSourceValues) *Resolver {
+	r.data = values
+	return r
+}
+
+// resolveDataSources converts data source values for the resolved module
+func resolveDataSources(values DataSourceValues) map[string]ResolvedData {
+	resolved := make(map[string]ResolvedData, len(values))
+	for addr, value := range values {
+		data := ResolvedData{Address: addr, IsKnown: true, Value: value}
+		if attrs, ok := value.(map[string]any); ok {
+			data.Attributes = make(map[string]model.ResolvedAttribute, len(attrs))
+			for name, v := range attrs {
+				data.Attributes[name] = model.ResolvedAttribute{Value: v}
+			}
+		}
+		resolved[addr] = data
+	}
+	return resolved
+}
+
+// addDataValue adds the data source a data.TYPE.NAME traversal refers to;
+// false when it is not known
+func addDataValue(data map[string]map[string]cty.Value, traversal hcl.Traversal, resolved *ResolvedModule) bool {
+	if len(traversal) < 3 {
+		return false
+	}
+	typ, ok := traversal[1].(hcl.TraverseAttr)
+	if !ok {
+		return false
+	}
+	name, ok := traversal[2].(hcl.TraverseAttr)
+	if !ok {
+		return false
+	}
+	source, ok := resolved.ResolvedData["data."+typ.Name+"."+name.Name]
+	if !ok || !source.IsKnown {
+		return false
+	}
+	val, ok := goToCty(source.Value)
+	if !ok {
+		return false
+	}
+	if data[typ.Name] == nil {
+		data[typ.Name] = make(map[string]cty.Value)
+	}
+	data[typ.Name][name.Name] = val
+	return true
+}
@@ -4,6 +4,7 @@ package terraform
 import (
 	"fmt"
 	"sort"
+	"strings"
 
 	"terraform-cost/core/model"
 )
@@ -195,11 +196,39 @@ func (c *EvalContext) Evaluate(expr model.Expression) (any, error) {
 		if val, ok := c.GetLocal(ref); ok {
 			return val, nil
 		}
+		// each.value.size - resolve the longest known prefix, then
+		// walk the remaining attributes through nested objects
+		if val, ok := c.resolvePath(ref); ok {
+			return val, nil
+		}
 	}
 
 	return nil, fmt.Errorf("cannot evaluate expression: %s", expr.Raw)
 }
 
+// resolvePath resolves a dotted reference by finding the longest prefix
+// bound as a local and traversing the rest through map values
+func (c *EvalContext) resolvePath(ref string) (any, bool) {
+	parts := strings.Split(ref, ".")
+	for i := len(parts) - 1; i > 0; i-- {
+		val, ok := c.GetLocal(strings.Join(parts[:i], "."))
+		if !ok {
+			continue
+		}
+		for _, attr := range parts[i:] {
+			m, ok := val.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if val, ok = m[attr]; !ok {
+				return nil, false
+			}
+		}
+		return val, true
+	}
+	return nil, false
+}
+
 // NestedDynamicBlock handles nested dynamic blocks (dynamic within dynamic)
 type NestedDynamicBlock struct {
 	Parent  string
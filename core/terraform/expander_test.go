@@ -0,0 +1,66 @@
+// Package terraform - Expander tests
+package terraform
+
+import (
+	"testing"
+
+	"terraform-cost/core/determinism"
+	"terraform-cost/core/model"
+)
+
+// TestAssumedCount proves a per-type default wins over the global one and
+// negative per-type counts are ignored
+func TestAssumedCount(t *testing.T) {
+	e := NewExpander(1).WithTypeDefaults(map[string]int{
+		"aws_autoscaling_group": 3,
+		"aws_instance":          -2,
+		"aws_nat_gateway":       0,
+	})
+
+	for resourceType, want := range map[model.ResourceType]int{
+		"aws_autoscaling_group": 3,
+		"aws_instance":          1,
+		"aws_nat_gateway":       0,
+		"aws_lambda_function":   1,
+	} {
+		if got := e.assumedCount(resourceType); got != want {
+			t.Errorf("assumedCount(%s) = %d, want %d", resourceType, got, want)
+		}
+	}
+}
+
+// TestExpandUnknownCountByType proves an unknown count expands to the
+// resource type's assumed count and records it on the cardinality warning
+func TestExpandUnknownCountByType(t *testing.T) {
+	e := NewExpander(1).WithTypeDefaults(map[string]int{"aws_autoscaling_group": 3})
+	resolved := &ResolvedModule{ResolvedVariables: map[string]any{}}
+	count := &model.Expression{Raw: "var.size", References: []string{"var.size"}}
+
+	tests := []struct {
+		resourceType model.ResourceType
+		want         int
+	}{
+		{"aws_autoscaling_group", 3},
+		{"aws_instance", 1},
+	}
+	for _, tt := range tests {
+		def := &model.AssetDefinition{
+			ID:      model.DefinitionID(tt.resourceType),
+			Address: model.DefinitionAddress(string(tt.resourceType) + ".this"),
+			Type:    tt.resourceType,
+			Count:   count,
+		}
+		instances, _, cardinality := e.expandDefinition(def, resolved, determinism.NewIDGenerator("test"))
+		if len(instances) != tt.want {
+			t.Errorf("%s: expanded %d instances, want %d", tt.resourceType, len(instances), tt.want)
+		}
+		if cardinality == nil || cardinality.AssumedCount != tt.want {
+			t.Fatalf("%s: cardinality = %+v, want AssumedCount %d", tt.resourceType, cardinality, tt.want)
+		}
+		for _, inst := range instances {
+			if !inst.Metadata.IsPlaceholder {
+				t.Errorf("%s: %s not marked as a placeholder", tt.resourceType, inst.Address)
+			}
+		}
+	}
+}
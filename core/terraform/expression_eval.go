@@ -0,0 +1,203 @@
+// Package terraform - Expression evaluation
+// Sizing is often chosen per environment through a function call, e.g.
+// lookup(var.sizes, var.env, "t3.micro") or a ternary on var.env. When
+// every reference is a resolved input variable, computed local, data
+// source read by terraform plan or terraform.workspace, the expression is
+// evaluated with a safe subset of Terraform's functions. Any other reference or function leaves
+// it unknown, as before.
+package terraform
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/tryfunc"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+
+	"terraform-cost/core/model"
+)
+
+// evalFunctions are the functions an expression may call. All are pure:
+// no file, network or clock access.
+var evalFunctions = map[string]function.Function{
+	"coalesce": coalesceFunc,
+	"element":  stdlib.ElementFunc,
+	"format":   stdlib.FormatFunc,
+	"join":     stdlib.JoinFunc,
+	"length":   stdlib.LengthFunc,
+	"lookup":   stdlib.LookupFunc,
+	"toset":    stdlib.MakeToFunc(cty.Set(cty.DynamicPseudoType)),
+	"try":      tryfunc.TryFunc,
+}
+
+// coalesceFunc is Terraform's coalesce: unlike cty's, it also skips
+// empty strings
+var coalesceFunc = function.New(&function.Spec{
+	VarParam: &function.Parameter{
+		Name:             "vals",
+		Type:             cty.DynamicPseudoType,
+		AllowNull:        true,
+		AllowDynamicType: true,
+	},
+	Type: function.StaticReturnType(cty.DynamicPseudoType),
+	Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+		vals := make([]cty.Value, 0, len(args))
+		for _, arg := range args {
+			if arg.Type() == cty.String && !arg.IsNull() && arg.AsString() == "" {
+				continue
+			}
+			vals = append(vals, arg)
+		}
+		return stdlib.CoalesceFunc.Call(vals)
+	},
+})
+
+// evaluateExpression evaluates an expression whose references are all
+// resolved input variables, computed locals, known data sources or the
+// workspace
+func evaluateExpression(expr model.Expression, resolved *ResolvedModule) (any, bool) {
+	if resolved == nil || expr.Raw == "" {
+		return nil, false
+	}
+	syntax, diags := hclsyntax.ParseExpression([]byte(expr.Raw), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, false
+	}
+
+	// try() would hide an unresolved reference behind its fallback, so
+	// every reference must resolve before anything is evaluated
+	values := map[string]map[string]cty.Value{"var": {}, "local": {}, "terraform": {}}
+	data := map[string]map[string]cty.Value{}
+	for _, traversal := range syntax.Variables() {
+		if traversal.RootName() == "data" {
+			if !addDataValue(data, traversal, resolved) {
+				return nil, false
+			}
+			continue
+		}
+		root, ok := values[traversal.RootName()]
+		if !ok || len(traversal) < 2 {
+			return nil, false
+		}
+		attr, ok := traversal[1].(hcl.TraverseAttr)
+		if !ok {
+			return nil, false
+		}
+		var raw any
+		switch traversal.RootName() {
+		case "var":
+			raw, ok = resolved.ResolvedVariables[attr.Name]
+		case "local":
+			raw, ok = resolved.LocalValue(attr.Name)
+		default:
+			raw, ok = resolved.Workspace, attr.Name == "workspace" && resolved.Workspace != ""
+		}
+		if !ok {
+			return nil, false
+		}
+		val, ok := goToCty(raw)
+		if !ok {
+			return nil, false
+		}
+		root[attr.Name] = val
+	}
+
+	dataTypes := make(map[string]cty.Value, len(data))
+	for typ, names := range data {
+		dataTypes[typ] = cty.ObjectVal(names)
+	}
+	val, diags := syntax.Value(&hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var":       cty.ObjectVal(values["var"]),
+			"local":     cty.ObjectVal(values["local"]),
+			"terraform": cty.ObjectVal(values["terraform"]),
+			"data":      cty.ObjectVal(dataTypes),
+		},
+		Functions: evalFunctions,
+	})
+	if diags.HasErrors() || !val.IsWhollyKnown() || val.IsNull() {
+		return nil, false
+	}
+	return ctyToGo(val)
+}
+
+// goToCty converts a variable value as decoded from JSON or tfvars
+func goToCty(v any) (cty.Value, bool) {
+	switch v := v.(type) {
+	case nil:
+		return cty.NullVal(cty.DynamicPseudoType), true
+	case string:
+		return cty.StringVal(v), true
+	case bool:
+		return cty.BoolVal(v), true
+	case int:
+		return cty.NumberIntVal(int64(v)), true
+	case int64:
+		return cty.NumberIntVal(v), true
+	case float64:
+		return cty.NumberFloatVal(v), true
+	case []any:
+		elems := make([]cty.Value, len(v))
+		for i, item := range v {
+			val, ok := goToCty(item)
+			if !ok {
+				return cty.NilVal, false
+			}
+			elems[i] = val
+		}
+		return cty.TupleVal(elems), true
+	case map[string]any:
+		attrs := make(map[string]cty.Value, len(v))
+		for k, item := range v {
+			val, ok := goToCty(item)
+			if !ok {
+				return cty.NilVal, false
+			}
+			attrs[k] = val
+		}
+		return cty.ObjectVal(attrs), true
+	}
+	return cty.NilVal, false
+}
+
+// ctyToGo converts a known result back; numbers become float64, as for
+// literals
+func ctyToGo(val cty.Value) (any, bool) {
+	if val.IsNull() {
+		return nil, true
+	}
+	ty := val.Type()
+	switch {
+	case ty == cty.String:
+		return val.AsString(), true
+	case ty == cty.Bool:
+		return val.True(), true
+	case ty == cty.Number:
+		f, _ := val.AsBigFloat().Float64()
+		return f, true
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		list := make([]any, 0, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			item, ok := ctyToGo(elem)
+			if !ok {
+				return nil, false
+			}
+			list = append(list, item)
+		}
+		return list, true
+	case ty.IsMapType() || ty.IsObjectType():
+		m := make(map[string]any, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			k, elem := it.Element()
+			item, ok := ctyToGo(elem)
+			if !ok {
+				return nil, false
+			}
+			m[k.AsString()] = item
+		}
+		return m, true
+	}
+	return nil, false
+}
@@ -0,0 +1,58 @@
+// Package terraform - Expression evaluation tests
+package terraform
+
+import (
+	"reflect"
+	"testing"
+
+	"terraform-cost/core/model"
+)
+
+// TestEvaluateExpression proves each supported function evaluates over
+// resolved variables, and anything else stays unknown
+func TestEvaluateExpression(t *testing.T) {
+	resolved := &ResolvedModule{ResolvedVariables: map[string]any{
+		"env":   "prod",
+		"sizes": map[string]any{"prod": "m5.large", "dev": "t3.small"},
+		"types": []any{"t3.micro", "t3.small", "t3.medium"},
+		"empty": "",
+		"nodes": 3,
+	}}
+
+	for _, tc := range []struct {
+		name string
+		raw  string
+		refs []string
+		want any
+	}{
+		{"lookup", `lookup(var.sizes, var.env, "t3.micro")`, []string{"var.sizes", "var.env"}, "m5.large"},
+		{"lookup default", `lookup(var.sizes, "staging", "t3.micro")`, []string{"var.sizes"}, "t3.micro"},
+		{"coalesce", `coalesce(var.empty, "t3.micro")`, []string{"var.empty"}, "t3.micro"},
+		{"try", `try(var.sizes["staging"], "t3.micro")`, []string{"var.sizes"}, "t3.micro"},
+		{"format", `format("%s-%d", var.env, var.nodes)`, []string{"var.env", "var.nodes"}, "prod-3"},
+		{"join", `join(",", var.types)`, []string{"var.types"}, "t3.micro,t3.small,t3.medium"},
+		{"element", `element(var.types, 4)`, []string{"var.types"}, "t3.small"},
+		{"ternary", `var.env == "prod" ? 3 : 1`, []string{"var.env"}, float64(3)},
+		{"index", `var.sizes[var.env]`, []string{"var.sizes", "var.env"}, "m5.large"},
+		{"no references", `lookup({ a = "t3.micro" }, "a", "t3.small")`, nil, "t3.micro"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := expressionValue(model.Expression{Raw: tc.raw, References: tc.refs}, resolved)
+			if !ok || !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("%s = %#v, %v; want %#v", tc.raw, got, ok, tc.want)
+			}
+		})
+	}
+
+	for _, raw := range []string{
+		`try(aws_instance.web.instance_type, "t3.micro")`, // resource reference
+		`lookup(var.sizes, var.region, "t3.micro")`,       // unresolved variable
+		`coalesce(local.size, "t3.micro")`,                // local not computed
+		`file("size.txt")`,                                // not a safe function
+		`lookup(var.sizes, "staging")`,                    // missing key, no default
+	} {
+		if got, ok := expressionValue(model.Expression{Raw: raw, References: []string{"x"}}, resolved); ok {
+			t.Errorf("%s = %#v, want unknown", raw, got)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Package terraform - Locals
+// Locals may reference variables and each other, so they are evaluated in
+// dependency order: the evaluator computes those that need no variables,
+// and the resolver the rest once variables are known. A local that cannot
+// be evaluated (it references a resource, say) stays unknown, as does
+// every local that depends on it.
+package terraform
+
+import "strings"
+
+// LocalsCycleError reports locals that reference each other in a cycle
+type LocalsCycleError struct {
+	// Cycle names the locals in reference order; the first is repeated
+	// at the end
+	Cycle []string
+}
+
+func (e *LocalsCycleError) Error() string {
+	return "locals reference each other in a cycle: " + strings.Join(e.Cycle, " -> ")
+}
+
+// localDependencies returns the names of the locals an expression's
+// references name, in order of first reference
+func localDependencies(local *LocalBlock) []string {
+	var deps []string
+	seen := make(map[string]bool)
+	for _, ref := range local.Expression.References {
+		name, ok := strings.CutPrefix(ref, "local.")
+		if !ok {
+			continue
+		}
+		if i := strings.IndexAny(name, ".["); i >= 0 {
+			name = name[:i]
+		}
+		if !seen[name] {
+			seen[name] = true
+			deps = append(deps, name)
+		}
+	}
+	return deps
+}
+
+// orderLocals sorts locals so each follows the locals it references.
+// Locals in a cycle, and those depending on one, are left out and the
+// first cycle found is returned as a LocalsCycleError.
+func orderLocals(locals []*LocalBlock) ([]*LocalBlock, error) {
+	byName := make(map[string]*LocalBlock, len(locals))
+	for _, local := range locals {
+		byName[local.Name] = local
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+		cyclic
+	)
+	state := make(map[string]int, len(locals))
+	ordered := make([]*LocalBlock, 0, len(locals))
+	var cycleErr *LocalsCycleError
+	var path []string
+
+	// visit reports whether the local can be ordered
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		switch state[name] {
+		case done:
+			return true
+		case cyclic:
+			return false
+		case visiting:
+			if cycleErr == nil {
+				start := len(path) - 1
+				for path[start] != name {
+					start--
+				}
+				cycle := make([]string, 0, len(path)-start+1)
+				for _, n := range path[start:] {
+					cycle = append(cycle, "local."+n)
+				}
+				cycleErr = &LocalsCycleError{Cycle: append(cycle, "local."+name)}
+			}
+			return false
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+		ok := true
+		for _, dep := range localDependencies(byName[name]) {
+			// An undeclared local is unknown, not a cycle
+			if _, declared := byName[dep]; declared && !visit(dep) {
+				ok = false
+			}
+		}
+		path = path[:len(path)-1]
+
+		if !ok {
+			state[name] = cyclic
+			return false
+		}
+		state[name] = done
+		ordered = append(ordered, byName[name])
+		return true
+	}
+
+	for _, local := range locals {
+		visit(local.Name)
+	}
+	if cycleErr != nil {
+		return ordered, cycleErr
+	}
+	return ordered, nil
+}
+
+// evaluateLocals computes, in order, each local not yet computed whose
+// references are all known
+func evaluateLocals(ordered []*LocalBlock, resolved *ResolvedModule) {
+	for _, local := range ordered {
+		if _, ok := resolved.ComputedLocals[local.Name]; ok {
+			continue
+		}
+		if val, ok := expressionValue(local.Expression, resolved); ok {
+			resolved.ComputedLocals[local.Name] = val
+		}
+	}
+}
+
+// LocalValue returns a computed local
+func (m *ResolvedModule) LocalValue(name string) (any, bool) {
+	if m == nil || m.EvaluatedModule == nil {
+		return nil, false
+	}
+	val, ok := m.ComputedLocals[name]
+	return val, ok
+}
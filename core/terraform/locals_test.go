@@ -0,0 +1,74 @@
+// Package terraform - Locals tests
+package terraform
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"terraform-cost/core/model"
+)
+
+func local(name, raw string, refs ...string) *LocalBlock {
+	return &LocalBlock{Name: name, Expression: model.Expression{Raw: raw, References: refs}}
+}
+
+// TestResolveLocals proves the evaluator computes locals needing no
+// variables, and the resolver the rest in dependency order
+func TestResolveLocals(t *testing.T) {
+	parsed := &ParsedModule{
+		Variables: []*VariableBlock{{Name: "env"}},
+		Locals: []*LocalBlock{
+			local("name", `"${local.prefix}-${local.env}"`, "local.prefix", "local.env"),
+			local("env", "var.env", "var.env"),
+			local("prefix", `upper("app")`),
+			local("base", `"app"`),
+			local("instance", "aws_instance.web.id", "aws_instance.web.id"),
+			local("tag", `"${local.instance}-x"`, "local.instance"),
+		},
+	}
+	parsed.Locals[3].Expression.IsLiteral = true
+	parsed.Locals[3].Expression.LiteralVal = "app"
+
+	evaluated, err := NewEvaluator().Evaluate(context.Background(), parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(evaluated.ComputedLocals, map[string]any{"base": "app"}) {
+		t.Errorf("evaluator computed %v, want only base", evaluated.ComputedLocals)
+	}
+
+	resolved, err := NewResolver(map[string]any{"env": "prod"}).Resolve(context.Background(), evaluated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := resolved.LocalValue("env"); !ok || got != "prod" {
+		t.Errorf("local.env = %v, %v; want prod", got, ok)
+	}
+	for _, name := range []string{"name", "prefix", "instance", "tag"} {
+		if got, ok := resolved.LocalValue(name); ok {
+			t.Errorf("local.%s = %v, want unknown", name, got)
+		}
+	}
+}
+
+// TestOrderLocalsCycle proves a cycle is reported and only the locals
+// outside it are ordered
+func TestOrderLocalsCycle(t *testing.T) {
+	ordered, err := orderLocals([]*LocalBlock{
+		local("a", "local.b", "local.b"),
+		local("b", "local.a", "local.a"),
+		local("c", `"x"`),
+		local("d", "local.a", "local.a"),
+		local("self", "local.self", "local.self"),
+	})
+
+	var cycle *LocalsCycleError
+	if !errors.As(err, &cycle) || !reflect.DeepEqual(cycle.Cycle, []string{"local.a", "local.b", "local.a"}) {
+		t.Fatalf("err = %v, want the cycle local.a -> local.b -> local.a", err)
+	}
+	if len(ordered) != 1 || ordered[0].Name != "c" {
+		t.Errorf("ordered %+v, want only c", ordered)
+	}
+}
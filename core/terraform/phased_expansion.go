@@ -185,6 +185,7 @@ type ExpandedInstance struct {
 	DefinitionID  string
 	ResourceType  string
 	InstanceKey   interface{}
+	EachValue     interface{} // for_each element value (nil for count)
 	Provider      *ProviderContext
 	Attributes    map[string]interface{}
 	DependsOn     []string
@@ -407,9 +408,10 @@ func (e *PhasedExpander) executeExpandResources(ctx context.Context, modules []*
 					}
 				}
 
-				keys := e.extractKeys(res.ForEach.Value)
+				keys, values := e.extractKeys(res.ForEach.Value)
 				for _, key := range keys {
 					inst := e.createInstance(res, 0, key, providerCtx)
+					inst.EachValue = values[key]
 					instances = append(instances, inst)
 					e.stats.InstancesCreated++
 				}
@@ -489,24 +491,29 @@ func (e *PhasedExpander) toInt(v interface{}) int {
 	}
 }
 
-func (e *PhasedExpander) extractKeys(v interface{}) []string {
+// extractKeys returns the sorted for_each keys and the element value per key.
+// For maps of objects the full object is kept so each.value.<attr> resolves.
+func (e *PhasedExpander) extractKeys(v interface{}) ([]string, map[string]interface{}) {
+	values := make(map[string]interface{})
 	switch val := v.(type) {
 	case map[string]interface{}:
 		keys := make([]string, 0, len(val))
-		for k := range val {
+		for k, item := range val {
 			keys = append(keys, k)
+			values[k] = item
 		}
 		sort.Strings(keys)
-		return keys
+		return keys, values
 	case []interface{}:
 		keys := make([]string, 0, len(val))
 		for _, item := range val {
 			if s, ok := item.(string); ok {
 				keys = append(keys, s)
+				values[s] = s
 			}
 		}
-		return keys
+		return keys, values
 	default:
-		return []string{}
+		return []string{}, values
 	}
 }
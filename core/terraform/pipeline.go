@@ -4,8 +4,10 @@ package terraform
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
+	"strings"
 
 	"terraform-cost/core/determinism"
 	"terraform-cost/core/model"
@@ -54,7 +56,8 @@ type Pipeline struct {
 
 // PipelineOptions configures pipeline behavior
 type PipelineOptions struct {
-	// Workspace name (default: "default")
+	// Workspace name (default: "default"); terraform.workspace resolves
+	// to it unless ScanInput.Workspace names another
 	Workspace string
 
 	// Variables from CLI/environment
@@ -71,6 +74,42 @@ type PipelineOptions struct {
 
 	// Unknown handling
 	UnknownCountDefault int
+
+	// UnknownCountByType overrides UnknownCountDefault per resource type
+	// (e.g. aws_autoscaling_group: 3), where one instance would undercount.
+	// An unknown for_each expands to no instances unless
+	// EstimateUnknownForEach is set: there are no keys to address them by.
+	UnknownCountByType map[string]int
+
+	// EstimateUnknownForEach expands an unknown for_each to placeholder
+	// instances at the assumed count, as for an unknown count, so it
+	// contributes a best-effort estimate instead of nothing. Placeholder
+	// instances (Metadata.IsPlaceholder) are excluded from firm totals.
+	EstimateUnknownForEach bool
+
+	// SourceParser reads Terraform source in the parse phase. Nil uses the
+	// parser registered with RegisterSourceParser.
+	SourceParser SourceParser
+
+	// DataSources are the values of data sources read by terraform plan.
+	// References to them resolve, so a count or for_each over a data
+	// source expands to concrete instances instead of placeholders.
+	DataSources DataSourceValues
+}
+
+// SourceParser reads the Terraform source of one module into blocks
+type SourceParser interface {
+	ParseModule(ctx context.Context, input *ScanInput) (*ParsedModule, error)
+}
+
+// registeredParser is the default SourceParser, set by the HCL adapter
+var registeredParser SourceParser
+
+// RegisterSourceParser sets the SourceParser used by pipelines that do not
+// configure one. The HCL adapter registers itself on import, so pipelines
+// parse .tf files directly without running terraform init or plan.
+func RegisterSourceParser(p SourceParser) {
+	registeredParser = p
 }
 
 // NewPipeline creates a new evaluation pipeline
@@ -83,21 +122,93 @@ func NewPipeline(opts PipelineOptions) *Pipeline {
 	}
 
 	return &Pipeline{
-		parser:    NewParser(),
+		parser:    NewParser(opts.SourceParser),
 		evaluator: NewEvaluator(),
-		resolver:  NewResolver(opts.Variables),
-		expander:  NewExpander(opts.UnknownCountDefault),
+		resolver:  newPipelineResolver(opts),
+		expander:  newPipelineExpander(opts),
 		builder:   NewGraphBuilder(),
 		opts:      opts,
 	}
 }
 
+// WithVariables returns a copy of the pipeline whose variable inputs are
+// extended by vars, for values supplied per request
+func (p *Pipeline) WithVariables(vars map[string]any) *Pipeline {
+	merged := make(map[string]any, len(p.opts.Variables)+len(vars))
+	for k, v := range p.opts.Variables {
+		merged[k] = v
+	}
+	for k, v := range vars {
+		merged[k] = v
+	}
+	cp := *p
+	cp.opts.Variables = merged
+	cp.resolver = newPipelineResolver(cp.opts)
+	return &cp
+}
+
+// WithDataSources returns a copy of the pipeline that resolves references
+// to the data sources in values, e.g. those a plan read
+// (PipelineOptions.DataSources)
+func (p *Pipeline) WithDataSources(values DataSourceValues) *Pipeline {
+	cp := *p
+	cp.opts.DataSources = values
+	cp.resolver = newPipelineResolver(cp.opts)
+	return &cp
+}
+
+// WithWorkspace returns a copy of the pipeline that resolves
+// terraform.workspace to workspace (PipelineOptions.Workspace)
+func (p *Pipeline) WithWorkspace(workspace string) *Pipeline {
+	if workspace == "" {
+		workspace = "default"
+	}
+	cp := *p
+	cp.opts.Workspace = workspace
+	cp.resolver = newPipelineResolver(cp.opts)
+	return &cp
+}
+
+// newPipelineResolver creates the resolver for a pipeline's options
+func newPipelineResolver(opts PipelineOptions) *Resolver {
+	return NewResolver(opts.Variables).WithData(opts.DataSources).WithWorkspace(opts.Workspace)
+}
+
+// WithSymbolicEstimate returns a copy of the pipeline that expands an
+// unknown for_each to placeholder instances (EstimateUnknownForEach)
+func (p *Pipeline) WithSymbolicEstimate() *Pipeline {
+	cp := *p
+	cp.opts.EstimateUnknownForEach = true
+	cp.expander = newPipelineExpander(cp.opts)
+	return &cp
+}
+
 // PipelineResult is the output of the pipeline
 type PipelineResult struct {
 	Graph    *model.InstanceGraph
 	Warnings []Warning
 	Errors   []Error
 	Stats    PipelineStats
+
+	// CardinalityWarnings lists resources whose count or for_each could
+	// not be resolved, so their instances are assumed or missing
+	CardinalityWarnings []CardinalityWarning
+
+	// Workspace is the workspace terraform.workspace resolved to
+	Workspace string
+}
+
+// WarningMessages renders the warnings as "address: message" lines
+func (r *PipelineResult) WarningMessages() []string {
+	msgs := make([]string, 0, len(r.Warnings))
+	for _, w := range r.Warnings {
+		if w.Address == "" {
+			msgs = append(msgs, w.Message)
+			continue
+		}
+		msgs = append(msgs, w.Address+": "+w.Message)
+	}
+	return msgs
 }
 
 // PipelineStats tracks statistics from the pipeline run
@@ -145,7 +256,7 @@ func (p *Pipeline) Execute(ctx context.Context, input *ScanInput) (*PipelineResu
 	}
 
 	// Phase 3: Resolve variables, locals, data sources
-	resolved, err := p.runResolve(ctx, evaluated, result)
+	resolved, err := p.runResolve(ctx, input, evaluated, result)
 	if err != nil && !p.opts.ContinueOnError {
 		return result, fmt.Errorf("resolve phase failed: %w", err)
 	}
@@ -155,6 +266,7 @@ func (p *Pipeline) Execute(ctx context.Context, input *ScanInput) (*PipelineResu
 	if err != nil && !p.opts.ContinueOnError {
 		return result, fmt.Errorf("expand phase failed: %w", err)
 	}
+	p.resolveProviders(input, expanded)
 
 	// Phase 5: Build instance graph
 	graph, err := p.runBuild(ctx, expanded, result)
@@ -173,7 +285,12 @@ type ScanInput struct {
 	RootPath    string
 	Files       []string
 	ModulePaths []string
-	Workspace   string
+
+	// Workspace overrides PipelineOptions.Workspace for this run
+	Workspace string
+
+	// DefaultRegion prices resources whose provider block sets no region
+	DefaultRegion string
 }
 
 // ParsedModule represents parsed HCL content
@@ -247,9 +364,21 @@ type ValidationRule struct {
 	ErrorMessage string
 }
 
-// runParse executes the parse phase
+// runParse executes the parse phase. Child modules are not loaded, so
+// each module call is reported as unpriced.
 func (p *Pipeline) runParse(ctx context.Context, input *ScanInput, result *PipelineResult) (*ParsedModule, error) {
-	return p.parser.Parse(ctx, input)
+	parsed, err := p.parser.Parse(ctx, input)
+	if parsed == nil {
+		parsed = emptyModule(input.RootPath)
+	}
+	for _, m := range parsed.Modules {
+		result.Warnings = append(result.Warnings, Warning{
+			Phase:   PhaseParse,
+			Address: "module." + m.Name,
+			Message: fmt.Sprintf("module %s (%s) is not expanded; its resources are not priced", m.Name, m.Source),
+		})
+	}
+	return parsed, err
 }
 
 // EvaluatedModule has expressions partially evaluated
@@ -272,6 +401,8 @@ type ResolvedModule struct {
 	ResolvedVariables map[string]any
 	// Data sources evaluated (some may be unknown)
 	ResolvedData map[string]ResolvedData
+	// Workspace terraform.workspace resolves to; empty leaves it unknown
+	Workspace string
 }
 
 // ResolvedData is a resolved data source
@@ -279,10 +410,20 @@ type ResolvedData struct {
 	Address    string
 	Attributes map[string]model.ResolvedAttribute
 	IsKnown    bool
+
+	// Value is the data source's value as DataSourceValues holds it: its
+	// attributes, or its instances for a data source with count or for_each
+	Value any
 }
 
-func (p *Pipeline) runResolve(ctx context.Context, evaluated *EvaluatedModule, result *PipelineResult) (*ResolvedModule, error) {
-	return p.resolver.Resolve(ctx, evaluated)
+func (p *Pipeline) runResolve(ctx context.Context, input *ScanInput, evaluated *EvaluatedModule, result *PipelineResult) (*ResolvedModule, error) {
+	resolver := p.resolver
+	if input != nil && input.Workspace != "" && input.Workspace != resolver.workspace {
+		cp := *resolver
+		resolver = cp.WithWorkspace(input.Workspace)
+	}
+	result.Workspace = resolver.workspace
+	return resolver.Resolve(ctx, evaluated)
 }
 
 // ExpandedModule has all count/for_each expanded
@@ -301,19 +442,37 @@ func (p *Pipeline) runBuild(ctx context.Context, expanded *ExpandedModule, resul
 }
 
 // Parser handles Phase 1: Parse
-type Parser struct{}
+type Parser struct {
+	source SourceParser
+}
+
+// NewParser creates a parser reading source with the given SourceParser,
+// or the registered one when nil
+func NewParser(source SourceParser) *Parser { return &Parser{source: source} }
 
-func NewParser() *Parser { return &Parser{} }
+// ErrNoSourceParser is returned when no SourceParser is configured or
+// registered; import terraform-cost/adapters/terraform/hcl to register one
+var ErrNoSourceParser = errors.New("no Terraform source parser registered")
 
 func (p *Parser) Parse(ctx context.Context, input *ScanInput) (*ParsedModule, error) {
-	// Implementation would use HCL parser
-	// For now, return skeleton
+	source := p.source
+	if source == nil {
+		source = registeredParser
+	}
+	if source == nil {
+		return emptyModule(input.RootPath), ErrNoSourceParser
+	}
+	return source.ParseModule(ctx, input)
+}
+
+// emptyModule is a module with no blocks
+func emptyModule(path string) *ParsedModule {
 	return &ParsedModule{
-		Path:        input.RootPath,
+		Path:        path,
 		Definitions: []*model.AssetDefinition{},
 		Variables:   []*VariableBlock{},
 		Locals:      []*LocalBlock{},
-	}, nil
+	}
 }
 
 // Evaluator handles Phase 2: Evaluate
@@ -328,15 +487,39 @@ func (e *Evaluator) Evaluate(ctx context.Context, parsed *ParsedModule) (*Evalua
 		ResolvedProviders: make(map[string]ProviderConfig),
 	}
 
-	// Evaluate locals in dependency order
-	// Resolve provider configurations
+	// Evaluate locals in dependency order; those referencing variables
+	// are left to the resolver
+	ordered, err := orderLocals(parsed.Locals)
+	evaluateLocals(ordered, &ResolvedModule{EvaluatedModule: result, ResolvedVariables: map[string]any{}})
+
+	// Provider configurations with literal settings; settings that
+	// reference variables are filled in by the resolver
+	for _, block := range parsed.Providers {
+		cfg := ProviderConfig{Type: block.Type, Alias: block.Alias, Config: make(map[string]any)}
+		for name, expr := range block.Attributes {
+			if expr.IsLiteral {
+				cfg.Config[name] = expr.LiteralVal
+			}
+		}
+		cfg.Region, _ = cfg.Config["region"].(string)
+		result.ResolvedProviders[providerConfigKey(block.Type, block.Alias)] = cfg
+	}
 
-	return result, nil
+	return result, err
+}
+
+func providerConfigKey(providerType, alias string) string {
+	if alias == "" {
+		return providerType
+	}
+	return providerType + "." + alias
 }
 
 // Resolver handles Phase 3: Resolve
 type Resolver struct {
 	inputVars map[string]any
+	data      DataSourceValues
+	workspace string
 }
 
 func NewResolver(vars map[string]any) *Resolver {
@@ -346,11 +529,19 @@ func NewResolver(vars map[string]any) *Resolver {
 	return &Resolver{inputVars: vars}
 }
 
+// WithWorkspace returns the resolver resolving terraform.workspace to
+// workspace
+func (r *Resolver) WithWorkspace(workspace string) *Resolver {
+	r.workspace = workspace
+	return r
+}
+
 func (r *Resolver) Resolve(ctx context.Context, evaluated *EvaluatedModule) (*ResolvedModule, error) {
 	result := &ResolvedModule{
 		EvaluatedModule:   evaluated,
 		ResolvedVariables: make(map[string]any),
-		ResolvedData:      make(map[string]ResolvedData),
+		ResolvedData:      resolveDataSources(r.data),
+		Workspace:         r.workspace,
 	}
 
 	// Resolve variables from inputs, defaults, environment
@@ -362,18 +553,131 @@ func (r *Resolver) Resolve(ctx context.Context, evaluated *EvaluatedModule) (*Re
 		}
 	}
 
+	// Locals that reference variables; a cycle was reported by the
+	// evaluator
+	ordered, _ := orderLocals(evaluated.Locals)
+	evaluateLocals(ordered, result)
+
+	// Provider settings such as region = var.region
+	for _, block := range evaluated.Providers {
+		cfg := evaluated.ResolvedProviders[providerConfigKey(block.Type, block.Alias)]
+		for name, expr := range block.Attributes {
+			if expr.IsLiteral {
+				continue
+			}
+			if val, ok := expressionValue(expr, result); ok {
+				cfg.Config[name] = val
+			}
+		}
+		cfg.Region, _ = cfg.Config["region"].(string)
+		evaluated.ResolvedProviders[providerConfigKey(block.Type, block.Alias)] = cfg
+	}
+
 	return result, nil
 }
 
+// expressionValue returns the value of a literal, of a direct variable or
+// local reference ("var.instance_count", "local.size"), or of an
+// expression over resolved variables and locals (see evaluateExpression)
+func expressionValue(expr model.Expression, resolved *ResolvedModule) (any, bool) {
+	if expr.IsLiteral {
+		return expr.LiteralVal, true
+	}
+	if len(expr.References) != 1 || expr.Raw != expr.References[0] {
+		return evaluateExpression(expr, resolved)
+	}
+	if resolved == nil {
+		return nil, false
+	}
+	if strings.HasPrefix(expr.Raw, "data.") || expr.Raw == "terraform.workspace" {
+		return evaluateExpression(expr, resolved)
+	}
+	if name, ok := strings.CutPrefix(expr.Raw, "local."); ok {
+		if strings.Contains(name, ".") {
+			return evaluateExpression(expr, resolved)
+		}
+		return resolved.LocalValue(name)
+	}
+	name, ok := strings.CutPrefix(expr.Raw, "var.")
+	if !ok {
+		return nil, false
+	}
+	val, ok := resolved.ResolvedVariables[name]
+	return val, ok
+}
+
+// resolveProviders sets each instance's provider from the module's
+// provider blocks, falling back to DefaultProviders and then to the
+// input's default region
+func (p *Pipeline) resolveProviders(input *ScanInput, expanded *ExpandedModule) {
+	if expanded == nil {
+		return
+	}
+	resolver := NewProviderResolver()
+	for _, cfg := range p.opts.DefaultProviders {
+		resolver.AddProvider(cfg)
+	}
+	for _, cfg := range expanded.ResolvedProviders {
+		if cfg.Region == "" && input.DefaultRegion != "" {
+			cfg.Region = input.DefaultRegion
+		}
+		resolver.AddProvider(cfg)
+	}
+	if input.DefaultRegion != "" {
+		for providerType := range resolver.defaultRegions {
+			resolver.defaultRegions[providerType] = input.DefaultRegion
+		}
+	}
+
+	definitions := make(map[model.DefinitionID]*model.AssetDefinition, len(expanded.Definitions))
+	for _, def := range expanded.Definitions {
+		definitions[def.ID] = def
+	}
+	// Resolve never fails; unknown providers fall back to defaults
+	_ = resolver.ResolveAllProviders(expanded.Instances, definitions)
+}
+
 // Expander handles Phase 4: Expand
 type Expander struct {
 	defaultCount int
+	countByType  map[string]int
+
+	// forEachPlaceholders expands an unknown for_each at the assumed count
+	forEachPlaceholders bool
 }
 
 func NewExpander(defaultCount int) *Expander {
 	return &Expander{defaultCount: defaultCount}
 }
 
+// newPipelineExpander builds the expander for pipeline options
+func newPipelineExpander(opts PipelineOptions) *Expander {
+	e := NewExpander(opts.UnknownCountDefault).WithTypeDefaults(opts.UnknownCountByType)
+	e.forEachPlaceholders = opts.EstimateUnknownForEach
+	return e
+}
+
+// WithTypeDefaults sets per-resource-type counts assumed for an unknown
+// count; negative values are ignored
+func (e *Expander) WithTypeDefaults(countByType map[string]int) *Expander {
+	e.countByType = make(map[string]int, len(countByType))
+	for t, n := range countByType {
+		if n >= 0 {
+			e.countByType[t] = n
+		}
+	}
+	return e
+}
+
+// assumedCount is the instance count assumed when a resource's count is
+// unknown
+func (e *Expander) assumedCount(resourceType model.ResourceType) int {
+	if n, ok := e.countByType[string(resourceType)]; ok {
+		return n
+	}
+	return e.defaultCount
+}
+
 func (e *Expander) Expand(ctx context.Context, resolved *ResolvedModule, result *PipelineResult) (*ExpandedModule, error) {
 	expanded := &ExpandedModule{
 		ResolvedModule: resolved,
@@ -383,8 +687,14 @@ func (e *Expander) Expand(ctx context.Context, resolved *ResolvedModule, result
 	idGen := determinism.NewIDGenerator("inst")
 
 	for _, def := range resolved.Definitions {
-		instances, warnings := e.expandDefinition(def, resolved, idGen)
+		instances, warnings, cardinality := e.expandDefinition(def, resolved, idGen)
+		for _, inst := range instances {
+			inst.Metadata.Location = def.Location
+		}
 		expanded.Instances = append(expanded.Instances, instances...)
+		if cardinality != nil {
+			result.CardinalityWarnings = append(result.CardinalityWarnings, *cardinality)
+		}
 
 		for _, w := range warnings {
 			result.Warnings = append(result.Warnings, Warning{
@@ -403,15 +713,19 @@ func (e *Expander) Expand(ctx context.Context, resolved *ResolvedModule, result
 	return expanded, nil
 }
 
-func (e *Expander) expandDefinition(def *model.AssetDefinition, resolved *ResolvedModule, idGen *determinism.IDGenerator) ([]*model.AssetInstance, []string) {
+func (e *Expander) expandDefinition(def *model.AssetDefinition, resolved *ResolvedModule, idGen *determinism.IDGenerator) ([]*model.AssetInstance, []string, *CardinalityWarning) {
 	var warnings []string
+	var cardinality *CardinalityWarning
 
 	// Handle count
 	if def.Count != nil {
 		count, known := e.resolveCount(def.Count, resolved)
 		if !known {
-			warnings = append(warnings, fmt.Sprintf("count could not be determined, assuming %d", e.defaultCount))
-			count = e.defaultCount
+			count = e.assumedCount(def.Type)
+			msg := fmt.Sprintf("count could not be determined, assuming %d", count)
+			warnings = append(warnings, msg)
+			cardinality = unknownCardinality(def, "count", def.Count, msg)
+			cardinality.AssumedCount = count
 		}
 
 		instances := make([]*model.AssetInstance, count)
@@ -424,16 +738,42 @@ func (e *Expander) expandDefinition(def *model.AssetDefinition, resolved *Resolv
 				Key:          model.InstanceKey{Type: model.KeyTypeInt, IntValue: i},
 				Attributes:   e.resolveAttributes(def, i, "", resolved),
 			}
+			if !known {
+				instances[i].Metadata = placeholderMetadata(cardinality.Message)
+			}
 		}
-		return instances, warnings
+		return instances, warnings, cardinality
 	}
 
 	// Handle for_each
 	if def.ForEach != nil {
 		keys, known := e.resolveForEach(def.ForEach, resolved)
+		if !known && !e.forEachPlaceholders {
+			msg := "for_each could not be determined, no instances priced"
+			warnings = append(warnings, msg)
+			return []*model.AssetInstance{}, warnings, unknownCardinality(def, "for_each", def.ForEach, msg)
+		}
 		if !known {
-			warnings = append(warnings, "for_each could not be determined")
-			return []*model.AssetInstance{}, warnings
+			// No keys to address instances by, so placeholders stand in
+			count := e.assumedCount(def.Type)
+			msg := fmt.Sprintf("for_each could not be determined, estimating %d placeholder instances", count)
+			warnings = append(warnings, msg)
+			cardinality = unknownCardinality(def, "for_each", def.ForEach, msg)
+			cardinality.AssumedCount = count
+
+			instances := make([]*model.AssetInstance, count)
+			for i := 0; i < count; i++ {
+				key := fmt.Sprintf("<unknown-%d>", i)
+				instances[i] = &model.AssetInstance{
+					ID:           model.InstanceID(idGen.Generate(string(def.ID), key)),
+					DefinitionID: def.ID,
+					Address:      model.InstanceAddress(fmt.Sprintf("%s[%q]", def.Address, key)),
+					Key:          model.InstanceKey{Type: model.KeyTypeString, StrValue: key},
+					Attributes:   e.resolveAttributes(def, 0, key, resolved),
+					Metadata:     placeholderMetadata(msg),
+				}
+			}
+			return instances, warnings, cardinality
 		}
 
 		// Sort keys for determinism
@@ -450,7 +790,7 @@ func (e *Expander) expandDefinition(def *model.AssetDefinition, resolved *Resolv
 				Attributes:   e.resolveAttributes(def, 0, key, resolved),
 			}
 		}
-		return instances, warnings
+		return instances, warnings, nil
 	}
 
 	// No expansion - single instance
@@ -462,25 +802,49 @@ func (e *Expander) expandDefinition(def *model.AssetDefinition, resolved *Resolv
 			Key:          model.InstanceKey{Type: model.KeyTypeNone},
 			Attributes:   e.resolveAttributes(def, 0, "", resolved),
 		},
-	}, warnings
+	}, warnings, nil
+}
+
+// placeholderMetadata marks an instance priced at an assumed count
+func placeholderMetadata(msg string) model.InstanceMetadata {
+	return model.InstanceMetadata{IsPlaceholder: true, Warning: msg}
+}
+
+// unknownCardinality describes a count or for_each that could not be
+// resolved; the instance count could be anything from zero up
+func unknownCardinality(def *model.AssetDefinition, kind string, expr *model.Expression, msg string) *CardinalityWarning {
+	return &CardinalityWarning{
+		Address:    string(def.Address),
+		Type:       kind,
+		Expression: expr.Raw,
+		SymbolicRange: &SymbolicRange{
+			Min:               0,
+			Max:               -1,
+			Expression:        expr.Raw,
+			UnknownReferences: expr.References,
+		},
+		Message: msg,
+	}
 }
 
 func (e *Expander) resolveCount(expr *model.Expression, resolved *ResolvedModule) (int, bool) {
-	if expr.IsLiteral {
-		if n, ok := expr.LiteralVal.(int); ok {
-			return n, true
-		}
-		if f, ok := expr.LiteralVal.(float64); ok {
-			return int(f), true
-		}
+	val, ok := expressionValue(*expr, resolved)
+	if !ok {
+		// Would need full expression evaluation
+		return 0, false
+	}
+	switch n := val.(type) {
+	case int:
+		return n, true
+	case float64:
+		return int(n), true
 	}
-	// Would need full expression evaluation
 	return 0, false
 }
 
 func (e *Expander) resolveForEach(expr *model.Expression, resolved *ResolvedModule) ([]string, bool) {
-	if expr.IsLiteral {
-		switch v := expr.LiteralVal.(type) {
+	if val, ok := expressionValue(*expr, resolved); ok {
+		switch v := val.(type) {
 		case map[string]any:
 			keys := make([]string, 0, len(v))
 			for k := range v {
@@ -500,18 +864,30 @@ func (e *Expander) resolveForEach(expr *model.Expression, resolved *ResolvedModu
 	return nil, false
 }
 
+// metaArguments configure Terraform rather than the resource, so they are
+// never resolved into the attributes a mapper prices from
+var metaArguments = map[string]bool{
+	"count":       true,
+	"for_each":    true,
+	"depends_on":  true,
+	"lifecycle":   true,
+	"provider":    true,
+	"provisioner": true,
+	"connection":  true,
+}
+
 func (e *Expander) resolveAttributes(def *model.AssetDefinition, countIdx int, eachKey string, resolved *ResolvedModule) map[string]model.ResolvedAttribute {
 	result := make(map[string]model.ResolvedAttribute)
 
 	for name, expr := range def.Attributes {
 		// Skip meta-arguments
-		if name == "count" || name == "for_each" || name == "depends_on" || name == "lifecycle" || name == "provider" {
+		if metaArguments[name] {
 			continue
 		}
 
-		if expr.IsLiteral {
+		if val, ok := expressionValue(expr, resolved); ok {
 			result[name] = model.ResolvedAttribute{
-				Value:     expr.LiteralVal,
+				Value:     val,
 				IsUnknown: false,
 			}
 		} else {
@@ -536,7 +912,9 @@ func (b *GraphBuilder) Build(ctx context.Context, expanded *ExpandedModule) (*mo
 
 	// Add all instances
 	for _, inst := range expanded.Instances {
-		graph.AddInstance(inst)
+		if err := graph.AddInstance(inst); err != nil {
+			return nil, err
+		}
 	}
 
 	// Build dependency edges from depends_on and references
@@ -0,0 +1,61 @@
+// Package terraform - Resource definitions from scanned assets
+// The HCL scanner keeps expressions unevaluated on raw assets; the
+// orchestrator expands resource definitions. DefinitionFromRawAsset
+// bridges the two and keeps the provider configuration the resource
+// selects, so an aliased resource binds to its own region.
+package terraform
+
+import (
+	"github.com/zclconf/go-cty/cty"
+
+	"terraform-cost/core/types"
+)
+
+// DefinitionFromRawAsset converts a scanned resource into a definition.
+// count and for_each become the definition's expansion expressions,
+// depends_on its explicit dependencies; other meta-arguments are dropped.
+func DefinitionFromRawAsset(raw types.RawAsset) *ResourceDefinition {
+	def := &ResourceDefinition{
+		Address:    string(raw.Address),
+		ModulePath: raw.Module,
+		Type:       raw.Type,
+		Name:       raw.Name,
+		Provider:   raw.ProviderKey(),
+		Attributes: make(map[string]*ExpressionValue, len(raw.Attributes)),
+	}
+	for name, attr := range raw.Attributes {
+		switch {
+		case name == "count":
+			def.Count = expressionFromAttribute(attr)
+		case name == "for_each":
+			def.ForEach = expressionFromAttribute(attr)
+		case name == "depends_on":
+			def.DependsOn = append(def.DependsOn, attr.References...)
+		case !metaArguments[name]:
+			def.Attributes[name] = expressionFromAttribute(attr)
+		}
+	}
+	return def
+}
+
+// expressionFromAttribute converts a scanned attribute; the scanner keeps
+// literals as cty values, which become plain Go values
+func expressionFromAttribute(attr types.Attribute) *ExpressionValue {
+	expr := &ExpressionValue{
+		IsKnown:    !attr.IsComputed && !attr.IsUnknown,
+		Value:      attr.Value,
+		Expression: attr.Expression,
+		References: attr.References,
+	}
+	if v, ok := attr.Value.(cty.Value); ok {
+		expr.Value = nil
+		if !v.IsWhollyKnown() {
+			expr.IsKnown = false
+		} else if goValue, ok := ctyToGo(v); ok {
+			expr.Value = goValue
+		} else {
+			expr.IsKnown = false
+		}
+	}
+	return expr
+}
@@ -305,6 +305,10 @@ type CardinalityWarning struct {
 	SymbolicRange    *SymbolicRange
 	Message          string
 	BlocksEstimation bool
+
+	// AssumedCount is the instance count priced in place of the unknown
+	// value (0 when nothing was priced)
+	AssumedCount int
 }
 
 // CardinalityWarnings collects cardinality warnings
@@ -4,6 +4,7 @@ package terraform
 
 import (
 	"fmt"
+	"sort"
 
 	"terraform-cost/core/model"
 )
@@ -403,7 +404,13 @@ func (e *UnknownAwareExpander) expandForEach(
 
 	switch v := forEach.(type) {
 	case map[string]interface{}:
-		for key, value := range v {
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value := v[key]
 			instances = append(instances, &model.AssetInstance{
 				ID:           model.InstanceID(fmt.Sprintf("%s:%s", def.ID, key)),
 				DefinitionID: def.ID,
@@ -445,7 +452,7 @@ func (e *UnknownAwareExpander) resolveAttributes(
 	result := make(map[string]model.ResolvedAttribute)
 
 	for name, expr := range def.Attributes {
-		if name == "count" || name == "for_each" || name == "depends_on" || name == "lifecycle" || name == "provider" {
+		if metaArguments[name] {
 			continue
 		}
 
@@ -1,6 +1,8 @@
 // Package types - Asset domain types
 package types
 
+import "strings"
+
 // RawAsset represents a parsed infrastructure resource before graph construction.
 // This is the output of scanners - no pricing or cost information here.
 type RawAsset struct {
@@ -10,6 +12,11 @@ type RawAsset struct {
 	// Provider is the cloud provider (aws, azure, gcp)
 	Provider Provider `json:"provider"`
 
+	// ProviderAlias is the alias of the provider configuration selected
+	// with the provider meta-argument (provider = aws.us_west → us_west);
+	// empty for the default configuration
+	ProviderAlias string `json:"provider_alias,omitempty"`
+
 	// Type is the resource type (e.g., "aws_instance", "aws_s3_bucket")
 	Type string `json:"type"`
 
@@ -38,6 +45,17 @@ type RawAsset struct {
 	SourceLine int `json:"source_line,omitempty"`
 }
 
+// ProviderKey returns the provider configuration the resource binds to:
+// the provider named by its type prefix, with the alias it selects
+// (aws_instance with provider = aws.us_west → aws.us_west)
+func (r RawAsset) ProviderKey() string {
+	name, _, _ := strings.Cut(r.Type, "_")
+	if r.ProviderAlias == "" {
+		return name
+	}
+	return name + "." + r.ProviderAlias
+}
+
 // Asset represents a node in the Asset Graph.
 // This is the normalized, provider-agnostic representation of infrastructure.
 type Asset struct {
@@ -1,7 +1,11 @@
 // Package types - Cost graph types
 package types
 
-import "github.com/shopspring/decimal"
+import (
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/core/determinism"
+)
 
 // Currency represents a currency code
 type Currency string
@@ -72,6 +76,13 @@ type CostUnit struct {
 	// Lineage tracks why this cost exists
 	Lineage CostLineage `json:"lineage"`
 
+	// Confidence is how firm the amount is (0.0 to 1.0; 0 = not assessed)
+	Confidence float64 `json:"confidence,omitempty"`
+
+	// ConfidenceReason explains a reduced confidence, e.g. an assumed
+	// attribute or a default rate
+	ConfidenceReason string `json:"confidence_reason,omitempty"`
+
 	// IsSubcost indicates if this is a sub-component of a larger cost
 	IsSubcost bool `json:"is_subcost,omitempty"`
 }
@@ -162,6 +173,10 @@ type CostGraph struct {
 	// Currency is the primary currency
 	Currency Currency `json:"currency"`
 
+	// HoursPerMonth converts the monthly total to hourly
+	// (0 = determinism.DefaultHoursPerMonth)
+	HoursPerMonth float64 `json:"hours_per_month,omitempty"`
+
 	// Metadata contains graph-level information
 	Metadata CostGraphMetadata `json:"metadata"`
 }
@@ -254,6 +269,10 @@ func (g *CostGraph) AddCostUnit(unit *CostUnit, asset *Asset) {
 // Summarize recalculates all totals in the graph
 func (g *CostGraph) Summarize() {
 	g.TotalMonthlyCost = g.Root.Total()
-	// Convert to hourly (730 hours/month)
-	g.TotalHourlyCost = g.TotalMonthlyCost.Div(decimal.NewFromInt(730))
+
+	hours := g.HoursPerMonth
+	if hours <= 0 {
+		hours = determinism.DefaultHoursPerMonth
+	}
+	g.TotalHourlyCost = g.TotalMonthlyCost.Div(decimal.NewFromFloat(hours))
 }
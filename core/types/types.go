@@ -66,7 +66,31 @@ func (a Attributes) Get(key string) interface{} {
 	return nil
 }
 
-// GetString retrieves a string attribute value
+// Lookup retrieves an attribute value and whether it is set. An attribute
+// that is absent or null is not set: a plan's "iops": null for an unset
+// optional argument means "use the provider's default", not zero.
+func (a Attributes) Lookup(key string) (interface{}, bool) {
+	attr, ok := a[key]
+	if !ok || attr.Value == nil {
+		return nil, false
+	}
+	return attr.Value, true
+}
+
+// IsSet reports whether an attribute is present with a non-null value
+func (a Attributes) IsSet(key string) bool {
+	_, ok := a.Lookup(key)
+	return ok
+}
+
+// IsNull reports whether an attribute is present but null
+func (a Attributes) IsNull(key string) bool {
+	attr, ok := a[key]
+	return ok && attr.Value == nil
+}
+
+// GetString retrieves a string attribute value. A null or unset attribute
+// reads as ""; mappers applying a default use GetStringOr instead.
 func (a Attributes) GetString(key string) string {
 	if v := a.Get(key); v != nil {
 		if s, ok := v.(string); ok {
@@ -76,7 +100,9 @@ func (a Attributes) GetString(key string) string {
 	return ""
 }
 
-// GetInt retrieves an integer attribute value
+// GetInt retrieves an integer attribute value. A null or unset attribute
+// reads as 0, indistinguishable from an explicit 0; mappers applying a
+// default use GetIntOr, which keeps an explicit 0.
 func (a Attributes) GetInt(key string) int {
 	if v := a.Get(key); v != nil {
 		switch n := v.(type) {
@@ -91,7 +117,8 @@ func (a Attributes) GetInt(key string) int {
 	return 0
 }
 
-// GetBool retrieves a boolean attribute value
+// GetBool retrieves a boolean attribute value. A null or unset attribute
+// reads as false; mappers applying a default use GetBoolOr instead.
 func (a Attributes) GetBool(key string) bool {
 	if v := a.Get(key); v != nil {
 		if b, ok := v.(bool); ok {
@@ -101,7 +128,9 @@ func (a Attributes) GetBool(key string) bool {
 	return false
 }
 
-// GetFloat retrieves a float64 attribute value
+// GetFloat retrieves a float64 attribute value. A null or unset attribute
+// reads as 0; mappers applying a default use GetFloatOr, which keeps an
+// explicit 0.
 func (a Attributes) GetFloat(key string) float64 {
 	if v := a.Get(key); v != nil {
 		switch n := v.(type) {
@@ -116,6 +145,60 @@ func (a Attributes) GetFloat(key string) float64 {
 	return 0
 }
 
+// GetStringOr retrieves a string attribute value, or def when the
+// attribute is unset, null or not a string
+func (a Attributes) GetStringOr(key, def string) string {
+	if v, ok := a.Lookup(key); ok {
+		if s, ok := v.(string); ok {
+			return s
+		}
+	}
+	return def
+}
+
+// GetIntOr retrieves an integer attribute value, or def when the
+// attribute is unset, null or not a number. An explicit 0 is returned.
+func (a Attributes) GetIntOr(key string, def int) int {
+	if v, ok := a.Lookup(key); ok {
+		switch n := v.(type) {
+		case int:
+			return n
+		case int64:
+			return int(n)
+		case float64:
+			return int(n)
+		}
+	}
+	return def
+}
+
+// GetBoolOr retrieves a boolean attribute value, or def when the
+// attribute is unset, null or not a bool
+func (a Attributes) GetBoolOr(key string, def bool) bool {
+	if v, ok := a.Lookup(key); ok {
+		if b, ok := v.(bool); ok {
+			return b
+		}
+	}
+	return def
+}
+
+// GetFloatOr retrieves a float64 attribute value, or def when the
+// attribute is unset, null or not a number. An explicit 0 is returned.
+func (a Attributes) GetFloatOr(key string, def float64) float64 {
+	if v, ok := a.Lookup(key); ok {
+		switch n := v.(type) {
+		case float64:
+			return n
+		case int:
+			return float64(n)
+		case int64:
+			return float64(n)
+		}
+	}
+	return def
+}
+
 // Metadata contains common metadata fields
 type Metadata struct {
 	CreatedAt time.Time `json:"created_at"`
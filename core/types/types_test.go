@@ -0,0 +1,39 @@
+// Package types - Attribute accessor tests
+package types
+
+import "testing"
+
+func TestAttributesNullUnsetAndZero(t *testing.T) {
+	attrs := Attributes{
+		"null": {Value: nil},
+		"zero": {Value: float64(0)},
+		"set":  {Value: float64(4000)},
+	}
+
+	for _, key := range []string{"null", "unset"} {
+		if attrs.IsSet(key) {
+			t.Errorf("%s is set", key)
+		}
+		if got := attrs.GetIntOr(key, 3000); got != 3000 {
+			t.Errorf("GetIntOr(%s) = %d, want the default", key, got)
+		}
+		if got := attrs.GetInt(key); got != 0 {
+			t.Errorf("GetInt(%s) = %d, want 0", key, got)
+		}
+	}
+	if !attrs.IsNull("null") || attrs.IsNull("unset") || attrs.IsNull("zero") {
+		t.Error("IsNull does not tell null from unset and zero")
+	}
+	if got := attrs.GetIntOr("zero", 3000); got != 0 {
+		t.Errorf("GetIntOr(zero) = %d, want the explicit 0", got)
+	}
+	if got := attrs.GetFloatOr("set", 3000); got != 4000 {
+		t.Errorf("GetFloatOr(set) = %v, want 4000", got)
+	}
+	if got := attrs.GetStringOr("null", "gp3"); got != "gp3" {
+		t.Errorf("GetStringOr(null) = %q, want the default", got)
+	}
+	if got := attrs.GetBoolOr("null", true); !got {
+		t.Error("GetBoolOr(null) = false, want the default")
+	}
+}
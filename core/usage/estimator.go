@@ -6,6 +6,7 @@ package usage
 import (
 	"context"
 
+	"terraform-cost/core/determinism"
 	"terraform-cost/core/types"
 )
 
@@ -40,6 +41,19 @@ type Context struct {
 
 	// CustomDefaults are additional default values
 	CustomDefaults map[types.UsageMetric]float64
+
+	// HoursPerMonth is the billing month of the estimate
+	// (0 = determinism.DefaultHoursPerMonth)
+	HoursPerMonth float64
+}
+
+// BillingHours returns the hours in the estimate's billing month, which
+// always-on resources run for. A nil context has the default month.
+func (c *Context) BillingHours() float64 {
+	if c != nil && c.HoursPerMonth > 0 {
+		return c.HoursPerMonth
+	}
+	return determinism.DefaultHoursPerMonth
 }
 
 // DefaultContext creates a new usage context with defaults
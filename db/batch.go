@@ -0,0 +1,165 @@
+// Package db - Batched inserts
+// Committing a snapshot inserts one rate key and one rate per normalized
+// price. Row-by-row that is two round trips per price inside a single
+// transaction, which is slow and holds locks for minutes on 100k+ rate
+// catalogs. The bulk methods below send multi-row INSERTs instead, still
+// on the caller's transaction so a snapshot commits atomically.
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// DefaultCommitBatchSize is the number of rows per multi-row INSERT
+const DefaultCommitBatchSize = 1000
+
+// maxInsertParams is PostgreSQL's limit on bind parameters per statement
+const maxInsertParams = 65535
+
+const (
+	rateKeyColumns = 6
+	rateColumns    = 10
+)
+
+// BulkUpsertRateKeys inserts rate keys, or finds the existing ones, and
+// sets each key's ID. Keys that are equal share an ID.
+func (t *PostgresTx) BulkUpsertRateKeys(ctx context.Context, keys []*RateKey) error {
+	// ON CONFLICT cannot touch the same row twice in one statement, so
+	// each distinct key is sent once
+	byIdentity := make(map[string][]*RateKey, len(keys))
+	var unique []*RateKey
+	var attrs [][]byte
+	for _, key := range keys {
+		attrsJSON, err := json.Marshal(key.Attributes)
+		if err != nil {
+			return err
+		}
+		id := rateKeyIdentity(key.Cloud, key.Service, key.ProductFamily, key.Region, attrsJSON)
+		if _, seen := byIdentity[id]; !seen {
+			unique = append(unique, key)
+			attrs = append(attrs, attrsJSON)
+		}
+		byIdentity[id] = append(byIdentity[id], key)
+	}
+
+	for start := 0; start < len(unique); start += maxInsertParams / rateKeyColumns {
+		end := min(start+maxInsertParams/rateKeyColumns, len(unique))
+
+		args := make([]interface{}, 0, (end-start)*rateKeyColumns)
+		for i, key := range unique[start:end] {
+			if key.ID == uuid.Nil {
+				key.ID = uuid.New()
+			}
+			args = append(args, key.ID, key.Cloud, key.Service, key.ProductFamily, key.Region, attrs[start+i])
+		}
+
+		query := `
+			INSERT INTO pricing_rate_keys (id, cloud, service, product_family, region, attributes)
+			VALUES ` + valuesClause(end-start, rateKeyColumns) + `
+			ON CONFLICT (cloud, service, product_family, region, attributes)
+			DO UPDATE SET id = pricing_rate_keys.id
+			RETURNING id, cloud, service, product_family, region, attributes
+		`
+		if err := t.assignRateKeyIDs(ctx, query, args, byIdentity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assignRateKeyIDs runs a rate key upsert and sets the returned IDs on
+// the keys they belong to. RETURNING order is not the VALUES order, so
+// rows are matched back by identity.
+func (t *PostgresTx) assignRateKeyIDs(ctx context.Context, query string, args []interface{}, byIdentity map[string][]*RateKey) error {
+	rows, err := t.tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uuid.UUID
+		var cloud CloudProvider
+		var service, family, region string
+		var attrsJSON []byte
+		if err := rows.Scan(&id, &cloud, &service, &family, &region, &attrsJSON); err != nil {
+			return err
+		}
+		// Re-encode so the identity matches json.Marshal's key order
+		// rather than jsonb's text form
+		var attrs map[string]string
+		if err := json.Unmarshal(attrsJSON, &attrs); err != nil {
+			return err
+		}
+		canonical, err := json.Marshal(attrs)
+		if err != nil {
+			return err
+		}
+		identity := rateKeyIdentity(cloud, service, family, region, canonical)
+		keys, ok := byIdentity[identity]
+		if !ok {
+			return fmt.Errorf("upserted rate key %s does not match any key in the batch", id)
+		}
+		for _, key := range keys {
+			key.ID = id
+		}
+	}
+	return rows.Err()
+}
+
+// BulkCreateRates inserts rates in multi-row statements
+func (t *PostgresTx) BulkCreateRates(ctx context.Context, rates []*PricingRate) error {
+	for start := 0; start < len(rates); start += maxInsertParams / rateColumns {
+		end := min(start+maxInsertParams/rateColumns, len(rates))
+
+		args := make([]interface{}, 0, (end-start)*rateColumns)
+		for _, rate := range rates[start:end] {
+			args = append(args,
+				rate.ID, rate.SnapshotID, rate.RateKeyID, rate.Unit,
+				rate.Price, rate.Currency, rate.Confidence,
+				rate.TierMin, rate.TierMax, rate.EffectiveDate,
+			)
+		}
+
+		query := `
+			INSERT INTO pricing_rates
+			(id, snapshot_id, rate_key_id, unit, price, currency, confidence, tier_min, tier_max, effective_date)
+			VALUES ` + valuesClause(end-start, rateColumns)
+		if _, err := t.tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// valuesClause returns the placeholders for rows of cols parameters:
+// ($1, $2), ($3, $4), ...
+func valuesClause(rows, cols int) string {
+	var b strings.Builder
+	n := 1
+	for r := 0; r < rows; r++ {
+		if r > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('(')
+		for c := 0; c < cols; c++ {
+			if c > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "$%d", n)
+			n++
+		}
+		b.WriteByte(')')
+	}
+	return b.String()
+}
+
+// rateKeyIdentity is the unique_rate_key constraint as a string
+func rateKeyIdentity(cloud CloudProvider, service, family, region string, attrsJSON []byte) string {
+	return strings.Join([]string{string(cloud), service, family, region, string(attrsJSON)}, "\x00")
+}
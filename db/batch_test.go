@@ -0,0 +1,142 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func TestValuesClause(t *testing.T) {
+	if got, want := valuesClause(2, 3), "($1, $2, $3), ($4, $5, $6)"; got != want {
+		t.Errorf("valuesClause(2, 3) = %q, want %q", got, want)
+	}
+	if maxInsertParams/rateColumns*rateColumns > maxInsertParams {
+		t.Error("rate chunk exceeds the parameter limit")
+	}
+}
+
+// testStore connects to TEST_DATABASE_URL, a migrated database. Tests
+// using it are skipped without one.
+func testStore(tb testing.TB) *PostgresStore {
+	tb.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		tb.Skip("TEST_DATABASE_URL not set")
+	}
+	store, err := NewPostgresStoreFromURL(url)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { store.Close() })
+	return store
+}
+
+// commitSnapshot writes n rates over n/2 distinct keys with the bulk
+// methods in one transaction, rolled back unless commit is set
+func commitSnapshot(tb testing.TB, store *PostgresStore, n, batchSize int, commit bool) uuid.UUID {
+	tb.Helper()
+	ctx := context.Background()
+	tx, err := store.BeginTx(ctx)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	snapshot := &PricingSnapshot{
+		ID:            uuid.New(),
+		Cloud:         AWS,
+		Region:        "bulk-test-" + uuid.NewString()[:8],
+		ProviderAlias: "default",
+		Source:        "bulk_test",
+		FetchedAt:     time.Now(),
+		ValidFrom:     time.Now(),
+		Hash:          uuid.NewString(),
+		Version:       "1.0",
+	}
+	if err := tx.CreateSnapshot(ctx, snapshot); err != nil {
+		tb.Fatal(err)
+	}
+
+	for start := 0; start < n; start += batchSize {
+		end := min(start+batchSize, n)
+		keys := make([]*RateKey, end-start)
+		rates := make([]*PricingRate, end-start)
+		for i := range keys {
+			keys[i] = &RateKey{
+				Cloud:         AWS,
+				Service:       "AmazonEC2",
+				ProductFamily: "Compute Instance",
+				Region:        snapshot.Region,
+				Attributes:    map[string]string{"instanceType": "t3." + strconv.Itoa((start+i)/2)},
+			}
+		}
+		if err := tx.BulkUpsertRateKeys(ctx, keys); err != nil {
+			tb.Fatal(err)
+		}
+		for i, key := range keys {
+			rates[i] = &PricingRate{
+				ID:         uuid.New(),
+				SnapshotID: snapshot.ID,
+				RateKeyID:  key.ID,
+				Unit:       fmt.Sprintf("hours-%d", (start+i)%2),
+				Price:      decimal.NewFromFloat(0.01),
+				Currency:   "USD",
+				Confidence: 1,
+			}
+		}
+		if err := tx.BulkCreateRates(ctx, rates); err != nil {
+			tb.Fatal(err)
+		}
+	}
+
+	if commit {
+		if err := tx.Commit(); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	return snapshot.ID
+}
+
+// TestBulkCommitIntegration proves bulk writes store every rate and share
+// rate keys across batches and duplicates
+func TestBulkCommitIntegration(t *testing.T) {
+	store := testStore(t)
+	ctx := context.Background()
+
+	id := commitSnapshot(t, store, 2500, 1000, true)
+	t.Cleanup(func() { store.db.ExecContext(ctx, "DELETE FROM pricing_snapshots WHERE id = $1", id) })
+
+	count, err := store.CountRates(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2500 {
+		t.Fatalf("stored %d rates, want 2500", count)
+	}
+
+	rates, err := store.ListRates(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := make(map[uuid.UUID]bool)
+	for _, r := range rates {
+		keys[r.Rate.RateKeyID] = true
+	}
+	if len(keys) != 1250 {
+		t.Errorf("got %d distinct rate keys, want 1250", len(keys))
+	}
+}
+
+// BenchmarkBulkCommit measures committing a 100k rate snapshot
+func BenchmarkBulkCommit(b *testing.B) {
+	store := testStore(b)
+	for i := 0; i < b.N; i++ {
+		commitSnapshot(b, store, 100000, DefaultCommitBatchSize, false)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"terraform-cost/db"
+	"terraform-cost/db/units"
 
 	"github.com/shopspring/decimal"
 )
@@ -142,6 +143,26 @@ func (f *AWSFetcher) getStubPrices(region string) []RawPrice {
 			Unit: "Hrs", PricePerUnit: "0.252", Currency: "USD",
 			Attributes: map[string]string{"instanceType": "r5.xlarge", "operatingSystem": "Linux", "tenancy": "Shared"}},
 
+		// Graviton (arm64)
+		{SKU: "ec2-t4g-micro", ServiceCode: "AmazonEC2", ProductFamily: "Compute Instance", Region: region,
+			Unit: "Hrs", PricePerUnit: "0.0084", Currency: "USD",
+			Attributes: map[string]string{"instanceType": "t4g.micro", "operatingSystem": "Linux", "tenancy": "Shared", "physicalProcessor": "AWS Graviton2 Processor"}},
+		{SKU: "ec2-t4g-small", ServiceCode: "AmazonEC2", ProductFamily: "Compute Instance", Region: region,
+			Unit: "Hrs", PricePerUnit: "0.0168", Currency: "USD",
+			Attributes: map[string]string{"instanceType": "t4g.small", "operatingSystem": "Linux", "tenancy": "Shared", "physicalProcessor": "AWS Graviton2 Processor"}},
+		{SKU: "ec2-t4g-medium", ServiceCode: "AmazonEC2", ProductFamily: "Compute Instance", Region: region,
+			Unit: "Hrs", PricePerUnit: "0.0336", Currency: "USD",
+			Attributes: map[string]string{"instanceType": "t4g.medium", "operatingSystem": "Linux", "tenancy": "Shared", "physicalProcessor": "AWS Graviton2 Processor"}},
+		{SKU: "ec2-m6g-large", ServiceCode: "AmazonEC2", ProductFamily: "Compute Instance", Region: region,
+			Unit: "Hrs", PricePerUnit: "0.077", Currency: "USD",
+			Attributes: map[string]string{"instanceType": "m6g.large", "operatingSystem": "Linux", "tenancy": "Shared", "physicalProcessor": "AWS Graviton2 Processor"}},
+		{SKU: "ec2-c6g-large", ServiceCode: "AmazonEC2", ProductFamily: "Compute Instance", Region: region,
+			Unit: "Hrs", PricePerUnit: "0.068", Currency: "USD",
+			Attributes: map[string]string{"instanceType": "c6g.large", "operatingSystem": "Linux", "tenancy": "Shared", "physicalProcessor": "AWS Graviton2 Processor"}},
+		{SKU: "ec2-r6g-large", ServiceCode: "AmazonEC2", ProductFamily: "Compute Instance", Region: region,
+			Unit: "Hrs", PricePerUnit: "0.1008", Currency: "USD",
+			Attributes: map[string]string{"instanceType": "r6g.large", "operatingSystem": "Linux", "tenancy": "Shared", "physicalProcessor": "AWS Graviton2 Processor"}},
+
 		// Windows Instances
 		{SKU: "ec2-t3-micro-win", ServiceCode: "AmazonEC2", ProductFamily: "Compute Instance", Region: region,
 			Unit: "Hrs", PricePerUnit: "0.0208", Currency: "USD",
@@ -311,7 +332,7 @@ func (f *AWSFetcher) getStubPrices(region string) []RawPrice {
 			Attributes: map[string]string{"group": "AWS-Lambda-Provisioned-Concurrency"}},
 
 		// ============================================================
-		// LOAD BALANCERS - aws_lb, aws_alb, aws_nlb
+		// LOAD BALANCERS - aws_lb (application, network, gateway), aws_elb
 		// ============================================================
 		{SKU: "alb-hour", ServiceCode: "ElasticLoadBalancing", ProductFamily: "Load Balancer", Region: region,
 			Unit: "Hrs", PricePerUnit: "0.0225", Currency: "USD",
@@ -321,10 +342,16 @@ func (f *AWSFetcher) getStubPrices(region string) []RawPrice {
 			Attributes: map[string]string{"productFamily": "Load Balancer-Application", "usagetype": "LCUUsage"}},
 		{SKU: "nlb-hour", ServiceCode: "ElasticLoadBalancing", ProductFamily: "Load Balancer", Region: region,
 			Unit: "Hrs", PricePerUnit: "0.0225", Currency: "USD",
-			Attributes: map[string]string{"productFamily": "Load Balancer-Network"}},
+			Attributes: map[string]string{"productFamily": "Load Balancer-Network", "usagetype": "LoadBalancerUsage"}},
 		{SKU: "nlb-lcu", ServiceCode: "ElasticLoadBalancing", ProductFamily: "Load Balancer", Region: region,
 			Unit: "NLCU-Hrs", PricePerUnit: "0.006", Currency: "USD",
 			Attributes: map[string]string{"productFamily": "Load Balancer-Network", "usagetype": "NLCUUsage"}},
+		{SKU: "gwlb-hour", ServiceCode: "ElasticLoadBalancing", ProductFamily: "Load Balancer", Region: region,
+			Unit: "Hrs", PricePerUnit: "0.0125", Currency: "USD",
+			Attributes: map[string]string{"productFamily": "Load Balancer-Gateway", "usagetype": "LoadBalancerUsage"}},
+		{SKU: "gwlb-lcu", ServiceCode: "ElasticLoadBalancing", ProductFamily: "Load Balancer", Region: region,
+			Unit: "GLCU-Hrs", PricePerUnit: "0.004", Currency: "USD",
+			Attributes: map[string]string{"productFamily": "Load Balancer-Gateway", "usagetype": "GLCUUsage"}},
 		{SKU: "clb-hour", ServiceCode: "ElasticLoadBalancing", ProductFamily: "Load Balancer", Region: region,
 			Unit: "Hrs", PricePerUnit: "0.025", Currency: "USD",
 			Attributes: map[string]string{"productFamily": "Load Balancer-Classic"}},
@@ -522,50 +549,63 @@ func (n *AWSNormalizer) Cloud() db.CloudProvider {
 // Normalize converts raw AWS prices to normalized rates
 func (n *AWSNormalizer) Normalize(raw []RawPrice) ([]NormalizedRate, error) {
 	var rates []NormalizedRate
-	
 	for _, r := range raw {
-		price, err := ParsePrice(r.PricePerUnit)
-		if err != nil {
-			continue // Skip unparseable prices
-		}
-		
-		// Normalize attributes
-		attrs := n.normalizeAttributes(r.Attributes)
-		
-		// Create rate key
-		rateKey := db.RateKey{
-			Cloud:         db.AWS,
-			Service:       r.ServiceCode,
-			ProductFamily: r.ProductFamily,
-			Region:        r.Region,
-			Attributes:    attrs,
+		if nr, ok := n.normalizePrice(r); ok {
+			rates = append(rates, nr)
 		}
-		
-		// Create normalized rate
-		nr := NormalizedRate{
-			RateKey:    rateKey,
-			Unit:       n.normalizeUnit(r.Unit),
-			Price:      price,
-			Currency:   r.Currency,
-			Confidence: 1.0, // Direct from AWS API
-		}
-		
-		// Handle tiers
-		if r.TierStart != nil {
-			d := decimal.NewFromFloat(*r.TierStart)
-			nr.TierMin = &d
-		}
-		if r.TierEnd != nil {
-			d := decimal.NewFromFloat(*r.TierEnd)
-			nr.TierMax = &d
-		}
-		
-		rates = append(rates, nr)
 	}
-	
 	return rates, nil
 }
 
+// StreamNormalize normalizes prices one at a time as they arrive
+func (n *AWSNormalizer) StreamNormalize(in <-chan RawPrice) <-chan NormalizedRate {
+	return streamEach(in, n.normalizePrice)
+}
+
+// normalizePrice normalizes one price; ok is false for skipped prices
+func (n *AWSNormalizer) normalizePrice(r RawPrice) (NormalizedRate, bool) {
+	price, err := ParsePrice(r.PricePerUnit)
+	if err != nil {
+		return NormalizedRate{}, false // Skip unparseable prices
+	}
+	
+	// Normalize attributes
+	attrs := n.normalizeAttributes(r.Attributes)
+	if r.ProductFamily == "Compute Instance" && attrs["instance_type"] != "" {
+		attrs["architecture"] = instanceArchitecture(attrs)
+	}
+	
+	// Create rate key
+	rateKey := db.RateKey{
+		Cloud:         db.AWS,
+		Service:       r.ServiceCode,
+		ProductFamily: r.ProductFamily,
+		Region:        r.Region,
+		Attributes:    attrs,
+	}
+	
+	// Create normalized rate
+	nr := NormalizedRate{
+		RateKey:    rateKey,
+		Unit:       n.normalizeUnit(r.Unit),
+		Price:      price,
+		Currency:   r.Currency,
+		Confidence: 1.0, // Direct from AWS API
+	}
+	
+	// Handle tiers
+	if r.TierStart != nil {
+		d := decimal.NewFromFloat(*r.TierStart)
+		nr.TierMin = &d
+	}
+	if r.TierEnd != nil {
+		d := decimal.NewFromFloat(*r.TierEnd)
+		nr.TierMax = &d
+	}
+	
+	return nr, true
+}
+
 func (n *AWSNormalizer) normalizeAttributes(raw map[string]string) map[string]string {
 	result := make(map[string]string)
 	
@@ -580,6 +620,16 @@ func (n *AWSNormalizer) normalizeAttributes(raw map[string]string) map[string]st
 	return result
 }
 
+// instanceArchitecture is arm64 for Graviton instance prices and x86_64
+// otherwise, so rates can be keyed the way the EC2 mapper looks them up.
+// The price list names the processor rather than the architecture.
+func instanceArchitecture(attrs map[string]string) string {
+	if strings.Contains(attrs["physicalprocessor"], "graviton") {
+		return "arm64"
+	}
+	return "x86_64"
+}
+
 func (n *AWSNormalizer) normalizeKey(k string) string {
 	// Map AWS attribute names to canonical names
 	mapping := map[string]string{
@@ -600,19 +650,7 @@ func (n *AWSNormalizer) normalizeKey(k string) string {
 }
 
 func (n *AWSNormalizer) normalizeUnit(unit string) string {
-	// Normalize unit names
-	mapping := map[string]string{
-		"Hrs":        "hours",
-		"GB-Mo":      "GB-month",
-		"GB":         "GB",
-		"Requests":   "requests",
-		"GB-Second":  "GB-seconds",
-	}
-	
-	if normalized, ok := mapping[unit]; ok {
-		return normalized
-	}
-	return strings.ToLower(unit)
+	return units.Canonical(db.AWS, unit)
 }
 
 // fetchFromAWSAPI is the production implementation (placeholder)
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"terraform-cost/db"
+	"terraform-cost/db/units"
 
 	"github.com/shopspring/decimal"
 )
@@ -20,6 +21,7 @@ import (
 type AWSPricingAPIFetcher struct {
 	httpClient *http.Client
 	regions    []string
+	services   []string
 	baseURL    string
 }
 
@@ -28,6 +30,8 @@ func NewAWSPricingAPIFetcher() *AWSPricingAPIFetcher {
 	return &AWSPricingAPIFetcher{
 		httpClient: &http.Client{Timeout: 60 * time.Second},
 		baseURL:    "https://pricing.us-east-1.amazonaws.com",
+		// Core services to fetch - use correct AWS service codes
+		services: []string{"AmazonEC2", "AmazonRDS", "AWSLambda", "AmazonS3", "AWSELB"},
 		regions: []string{
 			"us-east-1", "us-east-2", "us-west-1", "us-west-2",
 			"eu-west-1", "eu-west-2", "eu-west-3", "eu-central-1", "eu-north-1",
@@ -68,6 +72,7 @@ type AWSPriceListIndex struct {
 
 // AWSRegionIndex represents regional price index
 type AWSRegionIndex struct {
+	PublicationDate string `json:"publicationDate"`
 	Regions map[string]struct {
 		CurrentVersionURL string `json:"currentVersionUrl"`
 	} `json:"regions"`
@@ -118,17 +123,8 @@ type AWSPriceDimension struct {
 func (f *AWSPricingAPIFetcher) FetchRegion(ctx context.Context, region string) ([]RawPrice, error) {
 	var allPrices []RawPrice
 	
-	// Core services to fetch - use correct AWS service codes
-	services := []string{
-		"AmazonEC2",
-		"AmazonRDS", 
-		"AWSLambda",
-		"AmazonS3",
-		"AWSELB",
-	}
-	
-	for _, service := range services {
-		prices, err := f.fetchServicePricing(ctx, service, region)
+	for _, service := range f.services {
+		prices, err := f.FetchService(ctx, service, region)
 		if err != nil {
 			// Log but continue with other services
 			fmt.Printf("Warning: failed to fetch %s pricing: %v\n", service, err)
@@ -141,9 +137,51 @@ func (f *AWSPricingAPIFetcher) FetchRegion(ctx context.Context, region string) (
 	return allPrices, nil
 }
 
-// fetchServicePricing fetches pricing for a specific service using region_index
-func (f *AWSPricingAPIFetcher) fetchServicePricing(ctx context.Context, service, region string) ([]RawPrice, error) {
-	// Get the index first
+// Services returns the services FetchRegion fetches
+func (f *AWSPricingAPIFetcher) Services() []string {
+	return f.services
+}
+
+// ServiceVersions returns the offer file version of each service for a
+// region: the region index publication date, or the region's current
+// version URL when the index has none. Only the small region_index.json of
+// each service is downloaded. A service whose index cannot be read maps
+// to "" so it is always refetched.
+func (f *AWSPricingAPIFetcher) ServiceVersions(ctx context.Context, region string) (map[string]string, error) {
+	versions := make(map[string]string, len(f.services))
+	for _, service := range f.services {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		index, err := f.fetchRegionIndex(ctx, service)
+		if err != nil {
+			versions[service] = ""
+			continue
+		}
+		version := index.PublicationDate
+		if version == "" {
+			version = index.Regions[region].CurrentVersionURL
+		}
+		versions[service] = version
+	}
+	return versions, nil
+}
+
+// FetchService fetches all prices of one service for a region
+func (f *AWSPricingAPIFetcher) FetchService(ctx context.Context, service, region string) ([]RawPrice, error) {
+	return collectPrices(func(emit func(RawPrice) error) error {
+		return f.StreamService(ctx, service, region, emit)
+	})
+}
+
+// StreamService fetches one service's prices for a region, decoding the
+// offer file as it downloads so it is never held whole
+func (f *AWSPricingAPIFetcher) StreamService(ctx context.Context, service, region string, emit func(RawPrice) error) error {
+	return f.streamServicePricing(ctx, service, region, emit)
+}
+
+// fetchRegionIndex fetches a service's region_index.json
+func (f *AWSPricingAPIFetcher) fetchRegionIndex(ctx context.Context, service string) (*AWSRegionIndex, error) {
 	indexURL := fmt.Sprintf("%s/offers/v1.0/aws/%s/current/region_index.json", f.baseURL, service)
 	
 	req, err := http.NewRequestWithContext(ctx, "GET", indexURL, nil)
@@ -170,52 +208,96 @@ func (f *AWSPricingAPIFetcher) fetchServicePricing(ctx context.Context, service,
 	if err := json.Unmarshal(body, &regionIndex); err != nil {
 		return nil, fmt.Errorf("failed to parse region index: %w", err)
 	}
+	return &regionIndex, nil
+}
+
+// streamServicePricing streams pricing for a specific service using region_index
+func (f *AWSPricingAPIFetcher) streamServicePricing(ctx context.Context, service, region string, emit func(RawPrice) error) error {
+	// Get the index first
+	regionIndex, err := f.fetchRegionIndex(ctx, service)
+	if err != nil {
+		return err
+	}
 
 	// Find the region-specific URL
 	regionData, ok := regionIndex.Regions[region]
 	if !ok {
-		return nil, fmt.Errorf("region %s not found in index", region)
+		return fmt.Errorf("region %s not found in index", region)
 	}
 
 	// Fetch region-specific pricing
 	regionURL := f.baseURL + regionData.CurrentVersionURL
-	req, err = http.NewRequestWithContext(ctx, "GET", regionURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", regionURL, nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	resp, err = f.httpClient.Do(req)
+	resp, err := f.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("region pricing request failed: %w", err)
+		return fmt.Errorf("region pricing request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("region pricing not found: %d", resp.StatusCode)
-	}
-
-	body, err = io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+		return fmt.Errorf("region pricing not found: %d", resp.StatusCode)
 	}
 
-	return f.parsePriceList(body, service, region)
+	return decodePriceList(resp.Body, service, region, emit)
 }
 
-// parsePriceList parses AWS price list JSON
-func (f *AWSPricingAPIFetcher) parsePriceList(data []byte, service, region string) ([]RawPrice, error) {
-	var priceList AWSPriceList
-	if err := json.Unmarshal(data, &priceList); err != nil {
-		return nil, fmt.Errorf("failed to parse price list: %w", err)
+// decodePriceList decodes an AWS price list (see AWSPriceList) token by
+// token, emitting the on-demand prices of the region's products as their
+// terms are read. Only the region's products are held; other terms, such
+// as Reserved, are skipped without being decoded. Offer files list
+// products before terms, which the decoder relies on.
+func decodePriceList(r io.Reader, service, region string, emit func(RawPrice) error) error {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return fmt.Errorf("failed to parse price list: %w", err)
 	}
 
-	var prices []RawPrice
+	var products map[string]AWSProduct
+	for dec.More() {
+		key, err := objectKey(dec)
+		if err != nil {
+			return fmt.Errorf("failed to parse price list: %w", err)
+		}
+		switch key {
+		case "products":
+			if products, err = decodeRegionProducts(dec, region); err != nil {
+				return fmt.Errorf("failed to parse price list products: %w", err)
+			}
+		case "terms":
+			if products == nil {
+				return fmt.Errorf("failed to parse price list: terms precede products")
+			}
+			if err := decodeOnDemandTerms(dec, products, service, region, emit); err != nil {
+				return err
+			}
+		default:
+			if err := skipValue(dec); err != nil {
+				return fmt.Errorf("failed to parse price list: %w", err)
+			}
+		}
+	}
+	return nil
+}
 
-	// Process on-demand terms
-	for sku, productTerms := range priceList.Terms.OnDemand {
-		product, ok := priceList.Products[sku]
-		if !ok {
-			continue
+// decodeRegionProducts decodes the products object, keeping the products
+// of region
+func decodeRegionProducts(dec *json.Decoder, region string) (map[string]AWSProduct, error) {
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	products := make(map[string]AWSProduct)
+	for dec.More() {
+		sku, err := objectKey(dec)
+		if err != nil {
+			return nil, err
+		}
+		var product AWSProduct
+		if err := dec.Decode(&product); err != nil {
+			return nil, err
 		}
 
 		// Filter by region
@@ -225,45 +307,143 @@ func (f *AWSPricingAPIFetcher) parsePriceList(data []byte, service, region strin
 		if prodLocation := product.Attributes["location"]; prodLocation != "" && !matchesRegion(prodLocation, region) {
 			continue
 		}
+		products[sku] = product
+	}
+	_, err := dec.Token()
+	return products, err
+}
 
-		for _, term := range productTerms {
-			for _, dim := range term.PriceDimensions {
-				price := RawPrice{
-					SKU:           sku,
-					ServiceCode:   service,
-					ProductFamily: product.ProductFamily,
-					Region:        region,
-					Unit:          dim.Unit,
-					PricePerUnit:  dim.PricePerUnit.USD,
-					Currency:      "USD",
-					Attributes:    product.Attributes,
-				}
+// decodeOnDemandTerms decodes the terms object, emitting a price for each
+// on-demand price dimension of a kept product
+func decodeOnDemandTerms(dec *json.Decoder, products map[string]AWSProduct, service, region string, emit func(RawPrice) error) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return fmt.Errorf("failed to parse price list terms: %w", err)
+	}
+	for dec.More() {
+		termType, err := objectKey(dec)
+		if err != nil {
+			return fmt.Errorf("failed to parse price list terms: %w", err)
+		}
+		if termType != "OnDemand" {
+			if err := skipValue(dec); err != nil {
+				return fmt.Errorf("failed to parse price list terms: %w", err)
+			}
+			continue
+		}
 
-				// Parse tiers
-				if dim.BeginRange != "0" && dim.BeginRange != "" {
-					if val, err := parseFloat(dim.BeginRange); err == nil {
-						price.TierStart = &val
-					}
+		if err := expectDelim(dec, '{'); err != nil {
+			return fmt.Errorf("failed to parse on-demand terms: %w", err)
+		}
+		for dec.More() {
+			sku, err := objectKey(dec)
+			if err != nil {
+				return fmt.Errorf("failed to parse on-demand terms: %w", err)
+			}
+			product, ok := products[sku]
+			if !ok {
+				if err := skipValue(dec); err != nil {
+					return fmt.Errorf("failed to parse on-demand terms: %w", err)
 				}
-				if dim.EndRange != "Inf" && dim.EndRange != "" {
-					if val, err := parseFloat(dim.EndRange); err == nil {
-						price.TierEnd = &val
+				continue
+			}
+			var productTerms map[string]AWSTerm
+			if err := dec.Decode(&productTerms); err != nil {
+				return fmt.Errorf("failed to parse on-demand terms of %s: %w", sku, err)
+			}
+			for _, term := range productTerms {
+				for _, dim := range term.PriceDimensions {
+					if err := emit(awsDimensionPrice(sku, service, region, product, term, dim)); err != nil {
+						return err
 					}
 				}
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return fmt.Errorf("failed to parse on-demand terms: %w", err)
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
 
-				// Parse effective date
-				if term.EffectiveDate != "" {
-					if t, err := time.Parse("2006-01-02T15:04:05Z", term.EffectiveDate); err == nil {
-						price.EffectiveDate = &t
-					}
-				}
+// awsDimensionPrice is the raw price of one price dimension of a term
+func awsDimensionPrice(sku, service, region string, product AWSProduct, term AWSTerm, dim AWSPriceDimension) RawPrice {
+	price := RawPrice{
+		SKU:           sku,
+		ServiceCode:   service,
+		ProductFamily: product.ProductFamily,
+		Region:        region,
+		Unit:          dim.Unit,
+		PricePerUnit:  dim.PricePerUnit.USD,
+		Currency:      "USD",
+		Attributes:    product.Attributes,
+	}
 
-				prices = append(prices, price)
-			}
+	// Parse tiers
+	if dim.BeginRange != "0" && dim.BeginRange != "" {
+		if val, err := parseFloat(dim.BeginRange); err == nil {
+			price.TierStart = &val
+		}
+	}
+	if dim.EndRange != "Inf" && dim.EndRange != "" {
+		if val, err := parseFloat(dim.EndRange); err == nil {
+			price.TierEnd = &val
+		}
+	}
+
+	// Parse effective date
+	if term.EffectiveDate != "" {
+		if t, err := time.Parse("2006-01-02T15:04:05Z", term.EffectiveDate); err == nil {
+			price.EffectiveDate = &t
 		}
 	}
+	return price
+}
+
+// expectDelim reads the next token and checks it is delim
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("expected %s, got %v", delim, tok)
+	}
+	return nil
+}
+
+// objectKey reads the next object key
+func objectKey(dec *json.Decoder) (string, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return "", err
+	}
+	key, ok := tok.(string)
+	if !ok {
+		return "", fmt.Errorf("expected an object key, got %v", tok)
+	}
+	return key, nil
+}
 
-	return prices, nil
+// skipValue reads past the next value one token at a time, so a large
+// value is never held
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
 }
 
 // mapRegionToAWSName maps region codes to AWS naming convention
@@ -361,58 +541,68 @@ func (n *AWSPricingAPINormalizer) Cloud() db.CloudProvider {
 // Normalize converts raw AWS Pricing API data to normalized rates
 func (n *AWSPricingAPINormalizer) Normalize(raw []RawPrice) ([]NormalizedRate, error) {
 	var rates []NormalizedRate
-
 	for _, r := range raw {
-		// Skip zero/empty prices
-		if r.PricePerUnit == "" || r.PricePerUnit == "0" || r.PricePerUnit == "0.0000000000" {
-			continue
+		if nr, ok := n.normalizePrice(r); ok {
+			rates = append(rates, nr)
 		}
+	}
+	return rates, nil
+}
 
-		price, err := decimal.NewFromString(r.PricePerUnit)
-		if err != nil {
-			continue
-		}
+// StreamNormalize normalizes prices one at a time as they arrive
+func (n *AWSPricingAPINormalizer) StreamNormalize(in <-chan RawPrice) <-chan NormalizedRate {
+	return streamEach(in, n.normalizePrice)
+}
 
-		// Skip zero prices
-		if price.IsZero() {
-			continue
-		}
+// normalizePrice normalizes one price; ok is false for skipped prices
+func (n *AWSPricingAPINormalizer) normalizePrice(r RawPrice) (NormalizedRate, bool) {
+	// Skip zero/empty prices
+	if r.PricePerUnit == "" || r.PricePerUnit == "0" || r.PricePerUnit == "0.0000000000" {
+		return NormalizedRate{}, false
+	}
 
-		// Normalize attributes
-		attrs := n.normalizeAttributes(r.Attributes)
+	price, err := decimal.NewFromString(r.PricePerUnit)
+	if err != nil {
+		return NormalizedRate{}, false
+	}
 
-		// Create rate key
-		rateKey := db.RateKey{
-			Cloud:         db.AWS,
-			Service:       r.ServiceCode,
-			ProductFamily: r.ProductFamily,
-			Region:        r.Region,
-			Attributes:    attrs,
-		}
+	// Skip zero prices
+	if price.IsZero() {
+		return NormalizedRate{}, false
+	}
 
-		// Create normalized rate
-		nr := NormalizedRate{
-			RateKey:    rateKey,
-			Unit:       n.normalizeUnit(r.Unit),
-			Price:      price,
-			Currency:   r.Currency,
-			Confidence: 1.0, // Direct from AWS API = full confidence
-		}
+	// Normalize attributes
+	attrs := n.normalizeAttributes(r.Attributes)
 
-		// Handle tiers
-		if r.TierStart != nil {
-			d := decimal.NewFromFloat(*r.TierStart)
-			nr.TierMin = &d
-		}
-		if r.TierEnd != nil {
-			d := decimal.NewFromFloat(*r.TierEnd)
-			nr.TierMax = &d
-		}
+	// Create rate key
+	rateKey := db.RateKey{
+		Cloud:         db.AWS,
+		Service:       r.ServiceCode,
+		ProductFamily: r.ProductFamily,
+		Region:        r.Region,
+		Attributes:    attrs,
+	}
 
-		rates = append(rates, nr)
+	// Create normalized rate
+	nr := NormalizedRate{
+		RateKey:    rateKey,
+		Unit:       n.normalizeUnit(r.Unit),
+		Price:      price,
+		Currency:   r.Currency,
+		Confidence: 1.0, // Direct from AWS API = full confidence
 	}
 
-	return rates, nil
+	// Handle tiers
+	if r.TierStart != nil {
+		d := decimal.NewFromFloat(*r.TierStart)
+		nr.TierMin = &d
+	}
+	if r.TierEnd != nil {
+		d := decimal.NewFromFloat(*r.TierEnd)
+		nr.TierMax = &d
+	}
+
+	return nr, true
 }
 
 func (n *AWSPricingAPINormalizer) normalizeAttributes(raw map[string]string) map[string]string {
@@ -442,24 +632,5 @@ func (n *AWSPricingAPINormalizer) normalizeAttributes(raw map[string]string) map
 }
 
 func (n *AWSPricingAPINormalizer) normalizeUnit(unit string) string {
-	mapping := map[string]string{
-		"Hrs":           "hours",
-		"hrs":           "hours",
-		"GB-Mo":         "GB-month",
-		"GB-month":      "GB-month",
-		"GB":            "GB",
-		"Requests":      "requests",
-		"requests":      "requests",
-		"GB-Second":     "GB-seconds",
-		"GB-Seconds":    "GB-seconds",
-		"Lambda-GB-Second": "GB-seconds",
-		"Quantity":      "units",
-		"LCU-Hrs":       "LCU-hours",
-		"NLCU-Hrs":      "NLCU-hours",
-	}
-
-	if normalized, ok := mapping[unit]; ok {
-		return normalized
-	}
-	return strings.ToLower(unit)
+	return units.Canonical(db.AWS, unit)
 }
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"terraform-cost/db"
+	"terraform-cost/db/units"
 )
 
 // AzurePricingAPIClient fetches pricing from Azure Retail Prices API
@@ -182,7 +183,15 @@ func (c *AzurePricingAPIClient) SupportedServices() []string {
 // FetchRegion fetches ALL pricing for a region from Azure Retail Prices API
 // This is mapper-agnostic - fetches complete catalogs
 func (c *AzurePricingAPIClient) FetchRegion(ctx context.Context, region string) ([]RawPrice, error) {
-	var allPrices []RawPrice
+	return collectPrices(func(emit func(RawPrice) error) error {
+		return c.StreamRegion(ctx, region, emit)
+	})
+}
+
+// StreamRegion fetches ALL pricing for a region, emitting each page's
+// prices as the page arrives
+func (c *AzurePricingAPIClient) StreamRegion(ctx context.Context, region string, emit func(RawPrice) error) error {
+	fetched := 0
 
 	// Azure Retail Prices API uses OData filter syntax
 	// We paginate through ALL prices for the region
@@ -193,29 +202,34 @@ func (c *AzurePricingAPIClient) FetchRegion(ctx context.Context, region string)
 	for nextLink != "" {
 		select {
 		case <-ctx.Done():
-			return nil, ctx.Err()
+			return ctx.Err()
 		default:
 		}
 
 		prices, next, err := c.fetchPage(ctx, nextLink)
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch Azure pricing page: %w", err)
+			return fmt.Errorf("failed to fetch Azure pricing page: %w", err)
 		}
 
-		allPrices = append(allPrices, prices...)
-		nextLink = next
+		for _, price := range prices {
+			if err := emit(price); err != nil {
+				return err
+			}
+			fetched++
 
-		// Log progress
-		if len(allPrices)%10000 == 0 {
-			fmt.Printf("  Fetched %d Azure prices for %s...\n", len(allPrices), region)
+			// Log progress
+			if fetched%10000 == 0 {
+				fmt.Printf("  Fetched %d Azure prices for %s...\n", fetched, region)
+			}
 		}
+		nextLink = next
 	}
 
-	if len(allPrices) == 0 {
-		return nil, fmt.Errorf("failed to fetch any pricing for Azure region %s", region)
+	if fetched == 0 {
+		return fmt.Errorf("failed to fetch any pricing for Azure region %s", region)
 	}
 
-	return allPrices, nil
+	return nil
 }
 
 // buildURL constructs the API URL with filter
@@ -373,37 +387,47 @@ func (n *AzurePricingNormalizer) Cloud() db.CloudProvider {
 // Normalize converts raw Azure prices to normalized rates
 func (n *AzurePricingNormalizer) Normalize(raw []RawPrice) ([]NormalizedRate, error) {
 	var rates []NormalizedRate
-
 	for _, r := range raw {
-		price, err := ParsePrice(r.PricePerUnit)
-		if err != nil {
-			continue
+		if nr, ok := n.normalizePrice(r); ok {
+			rates = append(rates, nr)
 		}
+	}
+	return rates, nil
+}
 
-		// Normalize attributes
-		attrs := n.normalizeAttributes(r.Attributes)
+// StreamNormalize normalizes prices one at a time as they arrive
+func (n *AzurePricingNormalizer) StreamNormalize(in <-chan RawPrice) <-chan NormalizedRate {
+	return streamEach(in, n.normalizePrice)
+}
 
-		// Create rate key
-		rateKey := db.RateKey{
-			Cloud:         db.Azure,
-			Service:       r.ServiceCode,
-			ProductFamily: r.ProductFamily,
-			Region:        r.Region,
-			Attributes:    attrs,
-		}
+// normalizePrice normalizes one price; ok is false for skipped prices
+func (n *AzurePricingNormalizer) normalizePrice(r RawPrice) (NormalizedRate, bool) {
+	price, err := ParsePrice(r.PricePerUnit)
+	if err != nil {
+		return NormalizedRate{}, false
+	}
 
-		nr := NormalizedRate{
-			RateKey:    rateKey,
-			Unit:       n.normalizeUnit(r.Unit),
-			Price:      price,
-			Currency:   r.Currency,
-			Confidence: 1.0,
-		}
+	// Normalize attributes
+	attrs := n.normalizeAttributes(r.Attributes)
 
-		rates = append(rates, nr)
+	// Create rate key
+	rateKey := db.RateKey{
+		Cloud:         db.Azure,
+		Service:       r.ServiceCode,
+		ProductFamily: r.ProductFamily,
+		Region:        r.Region,
+		Attributes:    attrs,
 	}
 
-	return rates, nil
+	nr := NormalizedRate{
+		RateKey:    rateKey,
+		Unit:       n.normalizeUnit(r.Unit),
+		Price:      price,
+		Currency:   r.Currency,
+		Confidence: 1.0,
+	}
+
+	return nr, true
 }
 
 // normalizeAttributes converts Azure attributes to canonical form
@@ -436,22 +460,5 @@ func (n *AzurePricingNormalizer) normalizeAttributes(raw map[string]string) map[
 
 // normalizeUnit converts Azure units to canonical form
 func (n *AzurePricingNormalizer) normalizeUnit(unit string) string {
-	mapping := map[string]string{
-		"1 Hour":             "hours",
-		"1/Hour":             "hours",
-		"1 GB/Hour":          "GB-hours",
-		"1 GB/Month":         "GB-month",
-		"1 GB":               "GB",
-		"10K":                "10K-requests",
-		"1M":                 "1M-requests",
-		"10,000 Transactions": "10K-transactions",
-		"100":                "100-units",
-		"1":                  "unit",
-	}
-
-	if normalized, ok := mapping[unit]; ok {
-		return normalized
-	}
-
-	return strings.ToLower(strings.ReplaceAll(unit, " ", "-"))
+	return units.Canonical(db.Azure, unit)
 }
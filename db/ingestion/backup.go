@@ -86,6 +86,21 @@ func (m *BackupManager) WriteBackup(baseDir string, backup *SnapshotBackup) (str
 
 // ReadBackup reads a snapshot backup from disk
 func (m *BackupManager) ReadBackup(path string) (*SnapshotBackup, error) {
+	backup, err := m.decodeBackup(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate
+	if err := m.ValidateBackup(backup); err != nil {
+		return nil, fmt.Errorf("backup validation failed: %w", err)
+	}
+
+	return backup, nil
+}
+
+// decodeBackup reads a backup file without validating it
+func (m *BackupManager) decodeBackup(path string) (*SnapshotBackup, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open backup file: %w", err)
@@ -109,11 +124,6 @@ func (m *BackupManager) ReadBackup(path string) (*SnapshotBackup, error) {
 		return nil, fmt.Errorf("failed to decode backup: %w", err)
 	}
 
-	// Validate
-	if err := m.ValidateBackup(&backup); err != nil {
-		return nil, fmt.Errorf("backup validation failed: %w", err)
-	}
-
 	return &backup, nil
 }
 
@@ -136,8 +146,7 @@ func (m *BackupManager) ValidateBackup(backup *SnapshotBackup) error {
 	}
 
 	// Verify hash
-	actualHash := calculateHash(backup.Rates)
-	if actualHash != backup.ContentHash {
+	if actualHash, ok := backupHashMatches(backup); !ok {
 		return fmt.Errorf("backup content hash mismatch: expected %s, got %s", backup.ContentHash, actualHash)
 	}
 
@@ -2,7 +2,10 @@
 package ingestion
 
 import (
+	"context"
 	"fmt"
+	"sort"
+	"strings"
 
 	"terraform-cost/db"
 
@@ -246,3 +249,67 @@ func (r *CoverageReport) String() string {
 		r.SnapshotID,
 	)
 }
+
+// DetectCoverageRegression returns the service families priced by the previous
+// snapshot that the new rates no longer cover, sorted by service then family
+func DetectCoverageRegression(previous []db.ServiceFamily, rates []NormalizedRate) []db.ServiceFamily {
+	covered := make(map[db.ServiceFamily]bool, len(previous))
+	for _, r := range rates {
+		covered[db.ServiceFamily{Service: r.RateKey.Service, ProductFamily: r.RateKey.ProductFamily}] = true
+	}
+
+	var lost []db.ServiceFamily
+	for _, sf := range previous {
+		if !covered[sf] {
+			lost = append(lost, sf)
+		}
+	}
+
+	sort.Slice(lost, func(i, j int) bool {
+		if lost[i].Service != lost[j].Service {
+			return lost[i].Service < lost[j].Service
+		}
+		return lost[i].ProductFamily < lost[j].ProductFamily
+	})
+	return lost
+}
+
+// checkCoverageRegression compares rates against the active snapshot for the
+// configured provider/region/alias. Lost families are always returned; an
+// error is returned only when coverage regressed and config.Force is unset.
+func checkCoverageRegression(ctx context.Context, store db.PricingStore, config *LifecycleConfig, rates []NormalizedRate) ([]db.ServiceFamily, error) {
+	active, err := store.GetActiveSnapshot(ctx, config.Provider, config.Region, config.Alias)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load active snapshot: %w", err)
+	}
+	if active == nil {
+		return nil, nil
+	}
+
+	previous, err := store.CoveredServiceFamilies(ctx, active.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load coverage of active snapshot %s: %w", active.ID, err)
+	}
+
+	lost := DetectCoverageRegression(previous, rates)
+	if len(lost) > 0 && !config.Force {
+		return lost, fmt.Errorf("coverage regression against active snapshot %s: %d service families no longer covered (%s); use --force to accept",
+			active.ID, len(lost), formatServiceFamilies(lost))
+	}
+	return lost, nil
+}
+
+func formatServiceFamilies(families []db.ServiceFamily) string {
+	return strings.Join(serviceFamilyNames(families), ", ")
+}
+
+func serviceFamilyNames(families []db.ServiceFamily) []string {
+	if len(families) == 0 {
+		return nil
+	}
+	names := make([]string, len(families))
+	for i, sf := range families {
+		names[i] = sf.String()
+	}
+	return names
+}
@@ -0,0 +1,99 @@
+// Package ingestion - Coverage regression tests
+package ingestion
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"terraform-cost/db"
+)
+
+// coveredStore serves an active snapshot and the service families it covers
+type coveredStore struct {
+	db.PricingStore
+	active  *db.PricingSnapshot
+	covered []db.ServiceFamily
+}
+
+func (s *coveredStore) GetActiveSnapshot(ctx context.Context, cloud db.CloudProvider, region, alias string) (*db.PricingSnapshot, error) {
+	return s.active, nil
+}
+
+func (s *coveredStore) CoveredServiceFamilies(ctx context.Context, id uuid.UUID) ([]db.ServiceFamily, error) {
+	return s.covered, nil
+}
+
+// coveringRates returns one rate per service family
+func coveringRates(families ...db.ServiceFamily) []NormalizedRate {
+	rates := make([]NormalizedRate, len(families))
+	for i, sf := range families {
+		rates[i] = NormalizedRate{RateKey: db.RateKey{Service: sf.Service, ProductFamily: sf.ProductFamily}}
+	}
+	return rates
+}
+
+var (
+	ec2Compute = db.ServiceFamily{Service: "AmazonEC2", ProductFamily: "Compute Instance"}
+	ec2Storage = db.ServiceFamily{Service: "AmazonEC2", ProductFamily: "Storage"}
+	rdsDB      = db.ServiceFamily{Service: "AmazonRDS", ProductFamily: "Database Instance"}
+	lambda     = db.ServiceFamily{Service: "AWSLambda", ProductFamily: "Serverless"}
+)
+
+func TestDetectCoverageRegression(t *testing.T) {
+	previous := []db.ServiceFamily{rdsDB, ec2Storage, ec2Compute}
+
+	for _, tc := range []struct {
+		name  string
+		rates []NormalizedRate
+		lost  []db.ServiceFamily
+	}{
+		{"same coverage", coveringRates(ec2Compute, ec2Storage, rdsDB), nil},
+		{"wider coverage", coveringRates(ec2Compute, ec2Storage, rdsDB, lambda), nil},
+		{"lost families", coveringRates(ec2Compute), []db.ServiceFamily{ec2Storage, rdsDB}},
+		{"no rates", nil, []db.ServiceFamily{ec2Compute, ec2Storage, rdsDB}},
+	} {
+		if lost := DetectCoverageRegression(previous, tc.rates); !reflect.DeepEqual(lost, tc.lost) {
+			t.Errorf("%s: lost %v, want %v", tc.name, lost, tc.lost)
+		}
+	}
+
+	if lost := DetectCoverageRegression(nil, coveringRates(ec2Compute)); lost != nil {
+		t.Errorf("no previous coverage: lost %v, want none", lost)
+	}
+}
+
+func TestCheckCoverageRegression(t *testing.T) {
+	active := &db.PricingSnapshot{ID: uuid.New()}
+	rates := coveringRates(ec2Compute)
+
+	for _, tc := range []struct {
+		name  string
+		store *coveredStore
+		force bool
+		lost  []db.ServiceFamily
+		err   string
+	}{
+		{"no active snapshot", &coveredStore{}, false, nil, ""},
+		{"kept coverage", &coveredStore{active: active, covered: []db.ServiceFamily{ec2Compute}}, false, nil, ""},
+		{"regressed", &coveredStore{active: active, covered: []db.ServiceFamily{ec2Compute, rdsDB}}, false,
+			[]db.ServiceFamily{rdsDB}, "1 service families no longer covered (AmazonRDS/Database Instance)"},
+		{"regressed with --force", &coveredStore{active: active, covered: []db.ServiceFamily{ec2Compute, rdsDB}}, true,
+			[]db.ServiceFamily{rdsDB}, ""},
+	} {
+		config := &LifecycleConfig{Provider: db.AWS, Region: "us-east-1", Force: tc.force}
+		lost, err := checkCoverageRegression(context.Background(), tc.store, config, rates)
+		if !reflect.DeepEqual(lost, tc.lost) {
+			t.Errorf("%s: lost %v, want %v", tc.name, lost, tc.lost)
+		}
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("%s: %v", tc.name, err)
+		case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+			t.Errorf("%s: error = %v, want %q", tc.name, err, tc.err)
+		}
+	}
+}
@@ -168,6 +168,28 @@ func (n *FilteredNormalizer) Normalize(raw []RawPrice) ([]NormalizedRate, error)
 	return n.deduplicate(rates), nil
 }
 
+// StreamNormalize normalizes and filters dimensions as prices arrive,
+// deduplicating across the whole stream. Only the seen rate keys are
+// retained, not the rates.
+func (n *FilteredNormalizer) StreamNormalize(in <-chan RawPrice) <-chan NormalizedRate {
+	rates := AsStreamNormalizer(n.inner, defaultStreamBatchSize).StreamNormalize(in)
+	out := make(chan NormalizedRate, streamBufferSize)
+	go func() {
+		defer close(out)
+		seen := make(map[string]bool)
+		for r := range rates {
+			r.RateKey.Attributes = n.allowlist.Filter(r.RateKey.Cloud, r.RateKey.Service, r.RateKey.Attributes)
+			key := rateKeyString(r.RateKey) + "|" + r.Unit
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			out <- r
+		}
+	}()
+	return out
+}
+
 // deduplicate removes duplicate rates (keeping first)
 func (n *FilteredNormalizer) deduplicate(rates []NormalizedRate) []NormalizedRate {
 	seen := make(map[string]bool)
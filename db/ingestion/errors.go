@@ -0,0 +1,91 @@
+// Package ingestion - Typed pipeline errors
+// Every lifecycle failure is returned as one of these types so callers can
+// tell a network failure from a governance failure with errors.As, and read
+// the phase that failed without parsing LifecycleResult.Error.
+package ingestion
+
+import (
+	"errors"
+	"fmt"
+)
+
+// FetchError is a failure downloading raw pricing from the cloud API
+type FetchError struct {
+	Phase IngestionPhase
+	Cause error
+}
+
+func (e *FetchError) Error() string { return fmt.Sprintf("%s: %v", e.Phase, e.Cause) }
+func (e *FetchError) Unwrap() error { return e.Cause }
+
+// NormalizeError is a failure transforming raw prices into canonical rates
+type NormalizeError struct {
+	Phase IngestionPhase
+	Cause error
+}
+
+func (e *NormalizeError) Error() string { return fmt.Sprintf("%s: %v", e.Phase, e.Cause) }
+func (e *NormalizeError) Unwrap() error { return e.Cause }
+
+// ValidationError is a governance failure: production guards, coverage
+// thresholds, or a coverage regression against the active snapshot
+type ValidationError struct {
+	Phase IngestionPhase
+	Cause error
+}
+
+func (e *ValidationError) Error() string { return fmt.Sprintf("%s: %v", e.Phase, e.Cause) }
+func (e *ValidationError) Unwrap() error { return e.Cause }
+
+// BackupError is a failure writing or verifying the mandatory backup
+type BackupError struct {
+	Phase IngestionPhase
+	Path  string
+	Cause error
+}
+
+func (e *BackupError) Error() string { return fmt.Sprintf("%s: %v", e.Phase, e.Cause) }
+func (e *BackupError) Unwrap() error { return e.Cause }
+
+// CommitError is a failure writing the snapshot to the database.
+// The transaction is rolled back, so no partial snapshot is left behind.
+type CommitError struct {
+	Phase IngestionPhase
+	Cause error
+}
+
+func (e *CommitError) Error() string { return fmt.Sprintf("%s: %v", e.Phase, e.Cause) }
+func (e *CommitError) Unwrap() error { return e.Cause }
+
+// FailedPhase returns the phase recorded on a typed pipeline error
+func FailedPhase(err error) (IngestionPhase, bool) {
+	var (
+		fe *FetchError
+		ne *NormalizeError
+		ve *ValidationError
+		be *BackupError
+		ce *CommitError
+	)
+	switch {
+	case errors.As(err, &fe):
+		return fe.Phase, true
+	case errors.As(err, &ne):
+		return ne.Phase, true
+	case errors.As(err, &ve):
+		return ve.Phase, true
+	case errors.As(err, &be):
+		return be.Phase, true
+	case errors.As(err, &ce):
+		return ce.Phase, true
+	}
+	return PhaseFailed, false
+}
+
+// commitFailure wraps err as a CommitError unless it is already typed
+// (a coverage regression detected at commit time is a ValidationError)
+func commitFailure(err error) error {
+	if _, ok := FailedPhase(err); ok {
+		return err
+	}
+	return &CommitError{Phase: PhaseCommitting, Cause: err}
+}
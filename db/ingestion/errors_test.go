@@ -0,0 +1,98 @@
+// Package ingestion - Typed pipeline error tests
+package ingestion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"terraform-cost/db"
+)
+
+// failingFetcher fails every region fetch
+type failingFetcher struct{ err error }
+
+func (f *failingFetcher) Cloud() db.CloudProvider { return db.AWS }
+func (f *failingFetcher) FetchRegion(ctx context.Context, region string) ([]RawPrice, error) {
+	return nil, f.err
+}
+func (f *failingFetcher) SupportedRegions() []string  { return []string{"us-east-1"} }
+func (f *failingFetcher) SupportedServices() []string { return nil }
+
+func TestFailedPhase(t *testing.T) {
+	cause := errors.New("boom")
+	for _, tc := range []struct {
+		err   error
+		phase IngestionPhase
+	}{
+		{&FetchError{Phase: PhaseFetching, Cause: cause}, PhaseFetching},
+		{&NormalizeError{Phase: PhaseNormalizing, Cause: cause}, PhaseNormalizing},
+		{&ValidationError{Phase: PhaseValidating, Cause: cause}, PhaseValidating},
+		{&BackupError{Phase: PhaseBackedUp, Path: "/backups", Cause: cause}, PhaseBackedUp},
+		{&CommitError{Phase: PhaseCommitting, Cause: cause}, PhaseCommitting},
+		{fmt.Errorf("lifecycle execution failed: %w", &FetchError{Phase: PhaseFetching, Cause: cause}), PhaseFetching},
+	} {
+		phase, ok := FailedPhase(tc.err)
+		if !ok || phase != tc.phase {
+			t.Errorf("FailedPhase(%v) = %s, %v; want %s", tc.err, phase, ok, tc.phase)
+		}
+		if !errors.Is(tc.err, cause) {
+			t.Errorf("%v does not unwrap to its cause", tc.err)
+		}
+	}
+
+	if phase, ok := FailedPhase(cause); ok || phase != PhaseFailed {
+		t.Errorf("FailedPhase(untyped) = %s, %v; want %s, false", phase, ok, PhaseFailed)
+	}
+}
+
+// TestCommitFailure proves a commit failure is a CommitError unless it was
+// already typed, e.g. a coverage regression found while committing
+func TestCommitFailure(t *testing.T) {
+	var commitErr *CommitError
+	if err := commitFailure(errors.New("connection reset")); !errors.As(err, &commitErr) || commitErr.Phase != PhaseCommitting {
+		t.Errorf("commitFailure(untyped) = %#v, want a CommitError", err)
+	}
+
+	regression := &ValidationError{Phase: PhaseCommitting, Cause: errors.New("coverage regression")}
+	if err := commitFailure(regression); err != regression {
+		t.Errorf("commitFailure(ValidationError) = %#v, want it unchanged", err)
+	}
+}
+
+// TestLifecycleTypedErrors proves Execute returns the typed error of the
+// phase that failed, alongside a failed result
+func TestLifecycleTypedErrors(t *testing.T) {
+	cause := errors.New("offer file unavailable")
+	for _, tc := range []struct {
+		name   string
+		config *LifecycleConfig
+		check  func(error) bool
+		phase  IngestionPhase
+	}{
+		{
+			"production guard",
+			&LifecycleConfig{Provider: db.AWS, Region: "us-east-1", Environment: "production", AllowMockPricing: true},
+			func(err error) bool { var e *ValidationError; return errors.As(err, &e) },
+			PhaseInit,
+		},
+		{
+			"fetch",
+			&LifecycleConfig{Provider: db.AWS, Region: "us-east-1", Environment: "development"},
+			func(err error) bool { var e *FetchError; return errors.As(err, &e) && errors.Is(err, cause) },
+			PhaseFetching,
+		},
+	} {
+		result, err := NewLifecycle(&failingFetcher{err: cause}, nil, nil).Execute(context.Background(), tc.config)
+		if !tc.check(err) {
+			t.Errorf("%s: err = %#v, wrong type", tc.name, err)
+		}
+		if phase, _ := FailedPhase(err); phase != tc.phase {
+			t.Errorf("%s: failed phase = %s, want %s", tc.name, phase, tc.phase)
+		}
+		if result == nil || result.Success || result.Phase != PhaseFailed || result.Error != err.Error() {
+			t.Errorf("%s: result = %+v", tc.name, result)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+// Package ingestion - File pricing source
+// Negotiated or private pricing never appears in the cloud pricing APIs.
+// FilePriceFetcher reads it from a user-supplied JSON or CSV file of
+// RawPrice records instead; the records then go through the provider's
+// normalizer and the same governance validation as API-sourced prices.
+package ingestion
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"terraform-cost/db"
+
+	"github.com/shopspring/decimal"
+)
+
+// FilePriceFetcher serves RawPrice records loaded from a local file
+type FilePriceFetcher struct {
+	cloud    db.CloudProvider
+	path     string
+	byRegion map[string][]RawPrice
+	services []string
+}
+
+// NewFilePriceFetcher loads a price file for a cloud. The format follows
+// the extension: .csv, or JSON (an array of RawPrice objects) otherwise.
+//
+// CSV files need a header row. Columns named after RawPrice's JSON fields
+// (sku, service_code, product_family, region, unit, price_per_unit,
+// currency, tier_start, tier_end, effective_date) fill those fields; any
+// other column becomes an attribute, and empty cells are skipped.
+//
+// Every record needs a service_code, region, unit and a decimal
+// price_per_unit; currency defaults to USD. Regions use the provider's own
+// codes (us-east-1, eastus, us-central1).
+func NewFilePriceFetcher(cloud db.CloudProvider, path string) (*FilePriceFetcher, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read price file: %w", err)
+	}
+
+	var prices []RawPrice
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		prices, err = parseCSVPrices(data)
+	} else {
+		prices, err = parseJSONPrices(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid price file %s: %w", path, err)
+	}
+	if len(prices) == 0 {
+		return nil, fmt.Errorf("invalid price file %s: no prices", path)
+	}
+
+	f := &FilePriceFetcher{
+		cloud:    cloud,
+		path:     path,
+		byRegion: make(map[string][]RawPrice),
+	}
+	services := make(map[string]bool)
+	for i, p := range prices {
+		if err := checkFilePrice(&p); err != nil {
+			return nil, fmt.Errorf("invalid price file %s: record %d: %w", path, i+1, err)
+		}
+		f.byRegion[p.Region] = append(f.byRegion[p.Region], p)
+		services[p.ServiceCode] = true
+	}
+	for service := range services {
+		f.services = append(f.services, service)
+	}
+	sort.Strings(f.services)
+	return f, nil
+}
+
+// Cloud returns the cloud provider the file prices
+func (f *FilePriceFetcher) Cloud() db.CloudProvider {
+	return f.cloud
+}
+
+// Path returns the price file's path
+func (f *FilePriceFetcher) Path() string {
+	return f.path
+}
+
+// FetchRegion returns the file's prices for a region
+func (f *FilePriceFetcher) FetchRegion(ctx context.Context, region string) ([]RawPrice, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	prices := f.byRegion[region]
+	if len(prices) == 0 {
+		return nil, fmt.Errorf("price file %s has no prices for region %s", f.path, region)
+	}
+	// Copy so normalization cannot alter the loaded records
+	out := make([]RawPrice, len(prices))
+	copy(out, prices)
+	return out, nil
+}
+
+// SupportedRegions returns the regions the file has prices for, sorted
+func (f *FilePriceFetcher) SupportedRegions() []string {
+	regions := make([]string, 0, len(f.byRegion))
+	for region := range f.byRegion {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// SupportedServices returns the services the file has prices for, sorted
+func (f *FilePriceFetcher) SupportedServices() []string {
+	return append([]string(nil), f.services...)
+}
+
+// checkFilePrice rejects records the normalizers would silently drop, and
+// fills the default currency
+func checkFilePrice(p *RawPrice) error {
+	p.ServiceCode = strings.TrimSpace(p.ServiceCode)
+	p.Region = strings.TrimSpace(p.Region)
+	switch {
+	case p.ServiceCode == "":
+		return errors.New("service_code is required")
+	case p.Region == "":
+		return errors.New("region is required")
+	case strings.TrimSpace(p.Unit) == "":
+		return errors.New("unit is required")
+	}
+	if _, err := decimal.NewFromString(strings.TrimSpace(p.PricePerUnit)); err != nil {
+		return fmt.Errorf("price_per_unit %q is not a decimal", p.PricePerUnit)
+	}
+	p.PricePerUnit = strings.TrimSpace(p.PricePerUnit)
+	if p.Currency == "" {
+		p.Currency = "USD"
+	}
+	return nil
+}
+
+// parseJSONPrices decodes an array of RawPrice objects. Unknown fields are
+// errors so a misspelled field is not silently ignored.
+func parseJSONPrices(data []byte) ([]RawPrice, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var prices []RawPrice
+	if err := dec.Decode(&prices); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("unexpected data after the price array")
+	}
+	return prices, nil
+}
+
+// parseCSVPrices decodes CSV rows, mapping columns by the header row
+func parseCSVPrices(data []byte) ([]RawPrice, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("missing header row")
+		}
+		return nil, err
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+
+	var prices []RawPrice
+	for line := 2; ; line++ {
+		row, err := r.Read()
+		if err == io.EOF {
+			return prices, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		p := RawPrice{Attributes: make(map[string]string)}
+		for i, value := range row {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+			if err := setCSVField(&p, header[i], value); err != nil {
+				return nil, fmt.Errorf("line %d: %s: %w", line, header[i], err)
+			}
+		}
+		prices = append(prices, p)
+	}
+}
+
+// setCSVField sets the RawPrice field for a column, or an attribute
+func setCSVField(p *RawPrice, column, value string) error {
+	switch column {
+	case "sku":
+		p.SKU = value
+	case "service_code":
+		p.ServiceCode = value
+	case "product_family":
+		p.ProductFamily = value
+	case "region":
+		p.Region = value
+	case "unit":
+		p.Unit = value
+	case "price_per_unit":
+		p.PricePerUnit = value
+	case "currency":
+		p.Currency = value
+	case "tier_start", "tier_end":
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		if column == "tier_start" {
+			p.TierStart = &v
+		} else {
+			p.TierEnd = &v
+		}
+	case "effective_date":
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			if t, err = time.Parse("2006-01-02", value); err != nil {
+				return fmt.Errorf("want RFC 3339 or YYYY-MM-DD, got %q", value)
+			}
+		}
+		p.EffectiveDate = &t
+	default:
+		p.Attributes[column] = value
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+// Package ingestion - File pricing source tests
+package ingestion
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"terraform-cost/db"
+)
+
+// writePriceFile writes content to a price file named name in a temp dir
+func writePriceFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFilePriceFetcherCSV(t *testing.T) {
+	path := writePriceFile(t, "prices.CSV", strings.Join([]string{
+		"sku, service_code, region, unit, price_per_unit, tier_start, tier_end, effective_date, instanceType, volumeType",
+		"A1, AmazonEC2, us-east-1, Hrs, 0.0832, , , 2024-03-01, t3.large,",
+		"B1, AmazonS3, us-east-1, GB-Mo, 0.023, 0, 51200, 2024-03-01T12:00:00Z, , Standard",
+		"C1, AmazonEC2, eu-west-1, Hrs, 0.0912, , , , t3.large,",
+	}, "\n"))
+
+	f, err := NewFilePriceFetcher(db.AWS, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(f.SupportedRegions(), ","); got != "eu-west-1,us-east-1" {
+		t.Errorf("regions = %s", got)
+	}
+	if got := strings.Join(f.SupportedServices(), ","); got != "AmazonEC2,AmazonS3" {
+		t.Errorf("services = %s", got)
+	}
+
+	prices, err := f.FetchRegion(context.Background(), "us-east-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prices) != 2 {
+		t.Fatalf("got %d us-east-1 prices, want 2", len(prices))
+	}
+
+	ec2, s3 := prices[0], prices[1]
+	if ec2.SKU != "A1" || ec2.ServiceCode != "AmazonEC2" || ec2.Unit != "Hrs" || ec2.PricePerUnit != "0.0832" || ec2.Currency != "USD" {
+		t.Errorf("EC2 price fields = %+v", ec2)
+	}
+	if len(ec2.Attributes) != 1 || ec2.Attributes["instanceType"] != "t3.large" {
+		t.Errorf("EC2 attributes = %v, want only instanceType (empty cells skipped)", ec2.Attributes)
+	}
+	if ec2.TierStart != nil || ec2.TierEnd != nil {
+		t.Errorf("EC2 tiers = %v/%v, want unset", ec2.TierStart, ec2.TierEnd)
+	}
+	if ec2.EffectiveDate == nil || !ec2.EffectiveDate.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("EC2 effective date = %v, want 2024-03-01", ec2.EffectiveDate)
+	}
+
+	if s3.TierStart == nil || *s3.TierStart != 0 || s3.TierEnd == nil || *s3.TierEnd != 51200 {
+		t.Errorf("S3 tiers = %v/%v, want 0/51200", s3.TierStart, s3.TierEnd)
+	}
+	if s3.EffectiveDate == nil || !s3.EffectiveDate.Equal(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("S3 effective date = %v, want 2024-03-01T12:00:00Z", s3.EffectiveDate)
+	}
+	if s3.Attributes["volumeType"] != "Standard" {
+		t.Errorf("S3 attributes = %v", s3.Attributes)
+	}
+
+	// Returned records are copies of the loaded ones
+	prices[0].PricePerUnit = "0"
+	again, _ := f.FetchRegion(context.Background(), "us-east-1")
+	if again[0].PricePerUnit != "0.0832" {
+		t.Error("FetchRegion returned the loaded records, not copies")
+	}
+	if _, err := f.FetchRegion(context.Background(), "ap-south-1"); err == nil {
+		t.Error("expected an error for a region the file does not price")
+	}
+}
+
+func TestFilePriceFetcherRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "json unknown field",
+			file:    "prices.json",
+			content: `[{"service_code": "AmazonEC2", "region": "us-east-1", "unit": "Hrs", "price": "0.1"}]`,
+			wantErr: `unknown field "price"`,
+		},
+		{
+			name:    "json trailing data",
+			file:    "prices.json",
+			content: `[] []`,
+			wantErr: "unexpected data after the price array",
+		},
+		{
+			name:    "json empty",
+			file:    "prices.json",
+			content: `[]`,
+			wantErr: "no prices",
+		},
+		{
+			name:    "csv missing header",
+			file:    "prices.csv",
+			content: "",
+			wantErr: "missing header row",
+		},
+		{
+			name:    "csv bad tier",
+			file:    "prices.csv",
+			content: "service_code,region,unit,price_per_unit,tier_start\nAmazonS3,us-east-1,GB-Mo,0.023,ten\n",
+			wantErr: "line 2: tier_start",
+		},
+		{
+			name:    "csv bad effective date",
+			file:    "prices.csv",
+			content: "service_code,region,unit,price_per_unit,effective_date\nAmazonS3,us-east-1,GB-Mo,0.023,03/01/2024\n",
+			wantErr: `want RFC 3339 or YYYY-MM-DD, got "03/01/2024"`,
+		},
+		{
+			name:    "record checked",
+			file:    "prices.csv",
+			content: "service_code,region,unit,price_per_unit\nAmazonS3,us-east-1,GB-Mo,0.023\nAmazonS3,,GB-Mo,0.023\n",
+			wantErr: "record 2: region is required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewFilePriceFetcher(db.AWS, writePriceFile(t, tt.file, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckFilePrice(t *testing.T) {
+	valid := func() RawPrice {
+		return RawPrice{ServiceCode: " AmazonEC2 ", Region: " us-east-1 ", Unit: "Hrs", PricePerUnit: " 0.0832 "}
+	}
+	tests := []struct {
+		name    string
+		edit    func(*RawPrice)
+		wantErr string
+	}{
+		{name: "valid", edit: func(*RawPrice) {}},
+		{name: "no service", edit: func(p *RawPrice) { p.ServiceCode = " " }, wantErr: "service_code is required"},
+		{name: "no region", edit: func(p *RawPrice) { p.Region = "" }, wantErr: "region is required"},
+		{name: "no unit", edit: func(p *RawPrice) { p.Unit = " " }, wantErr: "unit is required"},
+		{name: "bad price", edit: func(p *RawPrice) { p.PricePerUnit = "$0.08" }, wantErr: `price_per_unit "$0.08" is not a decimal`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid()
+			tt.edit(&p)
+			err := checkFilePrice(&p)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.ServiceCode != "AmazonEC2" || p.Region != "us-east-1" || p.PricePerUnit != "0.0832" || p.Currency != "USD" {
+				t.Errorf("checked price = %+v, want trimmed fields and USD", p)
+			}
+		})
+	}
+
+	p := valid()
+	p.Currency = "EUR"
+	if err := checkFilePrice(&p); err != nil || p.Currency != "EUR" {
+		t.Errorf("currency = %s (%v), want EUR kept", p.Currency, err)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"terraform-cost/db"
+	"terraform-cost/db/units"
 )
 
 // GCPPricingAPIClient fetches pricing from GCP Cloud Billing Catalog API
@@ -192,37 +193,52 @@ func (c *GCPPricingAPIClient) SupportedServices() []string {
 // FetchRegion fetches ALL pricing for a region from GCP Cloud Billing API
 // This is mapper-agnostic - fetches complete catalogs
 func (c *GCPPricingAPIClient) FetchRegion(ctx context.Context, region string) ([]RawPrice, error) {
-	var allPrices []RawPrice
+	return collectPrices(func(emit func(RawPrice) error) error {
+		return c.StreamRegion(ctx, region, emit)
+	})
+}
+
+// StreamRegion fetches ALL pricing for a region, emitting each page's
+// prices as the page arrives. A service that fails before emitting any
+// price is skipped; one that fails part way fails the region, whose
+// catalog would otherwise be missing part of the service.
+func (c *GCPPricingAPIClient) StreamRegion(ctx context.Context, region string, emit func(RawPrice) error) error {
+	fetched := 0
 
 	// First, get list of all services
 	services, err := c.listServices(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list GCP services: %w", err)
+		return fmt.Errorf("failed to list GCP services: %w", err)
 	}
 
 	// Fetch SKUs for each service
 	for _, service := range services {
 		select {
 		case <-ctx.Done():
-			return nil, ctx.Err()
+			return ctx.Err()
 		default:
 		}
 
-		skus, err := c.fetchServiceSKUs(ctx, service.ServiceID, region)
+		before := fetched
+		err := c.streamServiceSKUs(ctx, service.ServiceID, region, func(p RawPrice) error {
+			fetched++
+			return emit(p)
+		})
 		if err != nil {
+			if ctx.Err() != nil || fetched > before {
+				return fmt.Errorf("failed to fetch SKUs for %s: %w", service.DisplayName, err)
+			}
 			// Log but continue
 			fmt.Printf("Warning: failed to fetch SKUs for %s: %v\n", service.DisplayName, err)
 			continue
 		}
-
-		allPrices = append(allPrices, skus...)
 	}
 
-	if len(allPrices) == 0 {
-		return nil, fmt.Errorf("failed to fetch any pricing for GCP region %s", region)
+	if fetched == 0 {
+		return fmt.Errorf("failed to fetch any pricing for GCP region %s", region)
 	}
 
-	return allPrices, nil
+	return nil
 }
 
 // listServices fetches all billable GCP services
@@ -267,9 +283,9 @@ func (c *GCPPricingAPIClient) listServices(ctx context.Context) ([]GCPService, e
 	return allServices, nil
 }
 
-// fetchServiceSKUs fetches all SKUs for a service
-func (c *GCPPricingAPIClient) fetchServiceSKUs(ctx context.Context, serviceID, region string) ([]RawPrice, error) {
-	var allPrices []RawPrice
+// streamServiceSKUs fetches all SKUs for a service, emitting their prices
+// a page at a time
+func (c *GCPPricingAPIClient) streamServiceSKUs(ctx context.Context, serviceID, region string, emit func(RawPrice) error) error {
 	pageToken := ""
 
 	for {
@@ -280,22 +296,22 @@ func (c *GCPPricingAPIClient) fetchServiceSKUs(ctx context.Context, serviceID, r
 
 		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		resp, err := c.httpClient.Do(req)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		defer resp.Body.Close()
 
 		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("GCP SKUs API returned status %d", resp.StatusCode)
+			return fmt.Errorf("GCP SKUs API returned status %d", resp.StatusCode)
 		}
 
 		var response GCPSKUsResponse
 		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-			return nil, err
+			return err
 		}
 
 		// Convert SKUs to RawPrice
@@ -305,8 +321,11 @@ func (c *GCPPricingAPIClient) fetchServiceSKUs(ctx context.Context, serviceID, r
 				continue
 			}
 
-			prices := c.skuToPrices(sku, region)
-			allPrices = append(allPrices, prices...)
+			for _, price := range c.skuToPrices(sku, region) {
+				if err := emit(price); err != nil {
+					return err
+				}
+			}
 		}
 
 		if response.NextPageToken == "" {
@@ -315,7 +334,7 @@ func (c *GCPPricingAPIClient) fetchServiceSKUs(ctx context.Context, serviceID, r
 		pageToken = response.NextPageToken
 	}
 
-	return allPrices, nil
+	return nil
 }
 
 // skuMatchesRegion checks if a SKU applies to a region
@@ -479,35 +498,45 @@ func (n *GCPPricingNormalizer) Cloud() db.CloudProvider {
 // Normalize converts raw GCP prices to normalized rates
 func (n *GCPPricingNormalizer) Normalize(raw []RawPrice) ([]NormalizedRate, error) {
 	var rates []NormalizedRate
-
 	for _, r := range raw {
-		price, err := ParsePrice(r.PricePerUnit)
-		if err != nil {
-			continue
+		if nr, ok := n.normalizePrice(r); ok {
+			rates = append(rates, nr)
 		}
+	}
+	return rates, nil
+}
 
-		attrs := n.normalizeAttributes(r.Attributes)
+// StreamNormalize normalizes prices one at a time as they arrive
+func (n *GCPPricingNormalizer) StreamNormalize(in <-chan RawPrice) <-chan NormalizedRate {
+	return streamEach(in, n.normalizePrice)
+}
 
-		rateKey := db.RateKey{
-			Cloud:         db.GCP,
-			Service:       r.ServiceCode,
-			ProductFamily: r.ProductFamily,
-			Region:        r.Region,
-			Attributes:    attrs,
-		}
+// normalizePrice normalizes one price; ok is false for skipped prices
+func (n *GCPPricingNormalizer) normalizePrice(r RawPrice) (NormalizedRate, bool) {
+	price, err := ParsePrice(r.PricePerUnit)
+	if err != nil {
+		return NormalizedRate{}, false
+	}
 
-		nr := NormalizedRate{
-			RateKey:    rateKey,
-			Unit:       n.normalizeUnit(r.Unit),
-			Price:      price,
-			Currency:   r.Currency,
-			Confidence: 1.0,
-		}
+	attrs := n.normalizeAttributes(r.Attributes)
 
-		rates = append(rates, nr)
+	rateKey := db.RateKey{
+		Cloud:         db.GCP,
+		Service:       r.ServiceCode,
+		ProductFamily: r.ProductFamily,
+		Region:        r.Region,
+		Attributes:    attrs,
 	}
 
-	return rates, nil
+	nr := NormalizedRate{
+		RateKey:    rateKey,
+		Unit:       n.normalizeUnit(r.Unit),
+		Price:      price,
+		Currency:   r.Currency,
+		Confidence: 1.0,
+	}
+
+	return nr, true
 }
 
 // normalizeAttributes converts GCP attributes to canonical form
@@ -537,21 +566,5 @@ func (n *GCPPricingNormalizer) normalizeAttributes(raw map[string]string) map[st
 
 // normalizeUnit converts GCP units to canonical form
 func (n *GCPPricingNormalizer) normalizeUnit(unit string) string {
-	mapping := map[string]string{
-		"h":         "hours",
-		"mo":        "month",
-		"GiBy":      "GB",
-		"GiBy.h":    "GB-hours",
-		"GiBy.mo":   "GB-month",
-		"By":        "bytes",
-		"count":     "count",
-		"request":   "requests",
-		"s":         "seconds",
-	}
-
-	if normalized, ok := mapping[unit]; ok {
-		return normalized
-	}
-
-	return strings.ToLower(unit)
+	return units.Canonical(db.GCP, unit)
 }
@@ -69,21 +69,52 @@ const (
 type IngestionValidator struct {
 	contracts          map[string]IngestionContract
 	minCoveragePercent float64
+	rules              *RuleRegistry
 }
 
+// Default rule names, registered for every provider
+const (
+	RulePricesNonNegative    = "prices_non_negative"
+	RuleDimensionsComplete   = "dimensions_complete"
+	RuleCoverageNotDecreased = "coverage_not_decreased"
+)
+
 // NewIngestionValidator creates a new validator with default contracts
+// and the default rules
 func NewIngestionValidator() *IngestionValidator {
 	v := &IngestionValidator{
 		contracts:          make(map[string]IngestionContract),
 		minCoveragePercent: 95.0, // Very high coverage required
+		rules:              NewRuleRegistry(),
 	}
 	for _, c := range DefaultContracts() {
 		key := fmt.Sprintf("%s:%s", c.Cloud, c.Service)
 		v.contracts[key] = c
 	}
+
+	v.rules.Register(RulePricesNonNegative, func(in *RuleInput) error {
+		return v.ValidatePricesPositive(in.Rates)
+	})
+	v.rules.Register(RuleDimensionsComplete, func(in *RuleInput) error {
+		return v.ValidateDimensionsComplete(in.Rates)
+	})
+	// No duplicate check: AWS pricing naturally has tiered rates with the
+	// same rate key (different price tiers, effective dates, etc.)
+	v.rules.Register(RuleCoverageNotDecreased, func(in *RuleInput) error {
+		if in.PreviousCount == 0 {
+			return nil
+		}
+		return v.ValidateCoverageNotDecreased(len(in.Rates), in.PreviousCount)
+	})
 	return v
 }
 
+// Rules returns the validator's rule registry, to which operators add
+// their own governance rules
+func (v *IngestionValidator) Rules() *RuleRegistry {
+	return v.rules
+}
+
 // SetMinCoveragePercent sets the minimum coverage percentage
 func (v *IngestionValidator) SetMinCoveragePercent(pct float64) {
 	v.minCoveragePercent = pct
@@ -216,32 +247,21 @@ func CalculateChecksum(rates []NormalizedRate) string {
 	return hex.EncodeToString(hasher.Sum(nil))
 }
 
-// ValidateAll runs all pre-commit validations (abort on failure)
+// ValidateAll runs all pre-commit validation rules (abort on failure) for
+// the provider of the rates. Without an active snapshot to compare with,
+// rules such as PriceDeltaRule pass.
 func (v *IngestionValidator) ValidateAll(rates []NormalizedRate, prevRateCount int) error {
-	// 1. Validate no negative prices
-	if err := v.ValidatePricesPositive(rates); err != nil {
-		return err
-	}
-
-	// 2. Validate required dimensions exist
-	if err := v.ValidateDimensionsComplete(rates); err != nil {
-		return err
-	}
-
-	// 3. Duplicate check disabled - AWS pricing naturally has tiered rates
-	// with the same rate key (different price tiers, effective dates, etc.)
-	// if err := v.ValidateNoDuplicates(rates); err != nil {
-	// 	return err
-	// }
-
-	// 4. Validate coverage not decreased (if previous exists)
-	if prevRateCount > 0 {
-		if err := v.ValidateCoverageNotDecreased(len(rates), prevRateCount); err != nil {
-			return err
-		}
+	in := &RuleInput{Rates: rates, PreviousCount: prevRateCount}
+	if len(rates) > 0 {
+		in.Cloud = rates[0].RateKey.Cloud
 	}
+	return v.ValidateInput(in)
+}
 
-	return nil
+// ValidateInput runs every rule registered for in.Cloud and reports all
+// the failing rules
+func (v *IngestionValidator) ValidateInput(in *RuleInput) error {
+	return v.rules.Validate(in)
 }
 
 // ValidatePricesPositive ensures no negative prices
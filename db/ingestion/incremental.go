@@ -0,0 +1,157 @@
+// Package ingestion - Incremental ingestion
+// Offer files are republished per service, and most refreshes change only
+// a few of them. Incremental mode compares each service's offer file
+// version with the one recorded on the active snapshot, refetches the
+// services that changed and carries the rates of the others over from the
+// active snapshot.
+package ingestion
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"terraform-cost/db"
+	"terraform-cost/internal/logging"
+)
+
+// IncrementalFetcher is a ServiceFetcher that can report offer file versions
+type IncrementalFetcher interface {
+	ServiceFetcher
+
+	// ServiceVersions returns the current offer file version of every
+	// service FetchRegion covers. An empty version means unknown.
+	ServiceVersions(ctx context.Context, region string) (map[string]string, error)
+}
+
+// incrementalFetch is the outcome of an incremental fetch
+type incrementalFetch struct {
+	// Active is the snapshot rates were reused from (nil if none)
+	Active *db.PricingSnapshot
+
+	// Fetched are the raw prices of changed services
+	Fetched []RawPrice
+
+	// Reused are the rates carried over from the active snapshot
+	Reused []NormalizedRate
+
+	// Versions are the offer file versions the new snapshot is built from
+	Versions map[string]string
+
+	// Changed and Skipped are the refetched and reused services
+	Changed []string
+	Skipped []string
+}
+
+// Unchanged reports whether every service matches the active snapshot
+func (f *incrementalFetch) Unchanged() bool {
+	return f.Active != nil && len(f.Changed) == 0
+}
+
+// fetchIncremental fetches the services whose offer file version differs
+// from the active snapshot and reuses the active snapshot's rates for the
+// rest. A service that fails to fetch keeps its previous rates and
+// version, so the next run retries it.
+func fetchIncremental(ctx context.Context, fetcher IncrementalFetcher, store db.PricingStore, config *LifecycleConfig, tally *serviceTally, logger logging.LeveledLogger) (*incrementalFetch, error) {
+	versions, err := fetcher.ServiceVersions(ctx, config.Region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service versions: %w", err)
+	}
+
+	result := &incrementalFetch{Versions: make(map[string]string, len(versions))}
+	if store != nil {
+		active, err := store.GetActiveSnapshot(ctx, config.Provider, config.Region, config.Alias)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load active snapshot: %w", err)
+		}
+		result.Active = active
+	}
+
+	services := make([]string, 0, len(versions))
+	for service := range versions {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	for _, service := range services {
+		version := versions[service]
+		previous := ""
+		if result.Active != nil {
+			previous = result.Active.ServiceVersions[service]
+		}
+
+		if version != "" && version == previous {
+			reused, err := reuseServiceRates(ctx, store, result.Active, service)
+			if err == nil {
+				result.Reused = append(result.Reused, reused...)
+				result.Skipped = append(result.Skipped, service)
+				result.Versions[service] = version
+				tally.reused(service, len(reused))
+				continue
+			}
+			logger.Warn("could not reuse rates, refetching service",
+				logging.String("service", service), logging.Err(err))
+		}
+
+		prices, err := fetcher.FetchService(ctx, service, config.Region)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			logger.Warn("failed to fetch service", logging.String("service", service), logging.Err(err))
+			tally.failed(service, err)
+			if result.Active != nil {
+				if reused, rerr := reuseServiceRates(ctx, store, result.Active, service); rerr == nil && len(reused) > 0 {
+					result.Reused = append(result.Reused, reused...)
+					result.Skipped = append(result.Skipped, service)
+					result.Versions[service] = previous
+					tally.reused(service, len(reused))
+				}
+			}
+			continue
+		}
+
+		result.Fetched = append(result.Fetched, prices...)
+		result.Changed = append(result.Changed, service)
+		tally.get(service)
+		tally.fetched(prices)
+		result.Versions[service] = version
+		logger.Info("fetched changed service",
+			logging.String("service", service),
+			logging.String("version", version),
+			logging.String("previous_version", previous),
+			logging.Int("prices", len(prices)))
+	}
+
+	return result, nil
+}
+
+// reuseServiceRates loads a service's rates from a snapshot as normalized
+// rates, ready to be committed into a new snapshot
+func reuseServiceRates(ctx context.Context, store db.PricingStore, snapshot *db.PricingSnapshot, service string) ([]NormalizedRate, error) {
+	rates, err := store.ListServiceRates(ctx, snapshot.ID, service)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := make([]NormalizedRate, 0, len(rates))
+	for _, r := range rates {
+		normalized = append(normalized, normalizedFromSnapshot(r))
+	}
+	return normalized, nil
+}
+
+// recordServiceVersions returns the fetcher's service versions for a full
+// fetch, or nil when the fetcher cannot report them. Versions are read
+// before the fetch, so a service republished mid-run is refetched next time.
+func recordServiceVersions(ctx context.Context, fetcher PriceFetcher, region string) map[string]string {
+	inc, ok := fetcher.(IncrementalFetcher)
+	if !ok {
+		return nil
+	}
+	versions, err := inc.ServiceVersions(ctx, region)
+	if err != nil {
+		return nil
+	}
+	return versions
+}
@@ -0,0 +1,204 @@
+// Package ingestion - Incremental ingestion tests
+package ingestion
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db"
+	"terraform-cost/internal/logging"
+)
+
+// versionedFetcher serves one price per service and reports fixed versions
+type versionedFetcher struct {
+	versions map[string]string
+	failing  map[string]bool
+	fetched  []string
+}
+
+func (f *versionedFetcher) Cloud() db.CloudProvider { return db.AWS }
+
+func (f *versionedFetcher) FetchRegion(ctx context.Context, region string) ([]RawPrice, error) {
+	return nil, errors.New("FetchRegion called in incremental mode")
+}
+
+func (f *versionedFetcher) SupportedRegions() []string { return []string{"us-east-1"} }
+
+func (f *versionedFetcher) SupportedServices() []string { return f.Services() }
+
+func (f *versionedFetcher) Services() []string {
+	var services []string
+	for service := range f.versions {
+		services = append(services, service)
+	}
+	return services
+}
+
+func (f *versionedFetcher) FetchService(ctx context.Context, service, region string) ([]RawPrice, error) {
+	f.fetched = append(f.fetched, service)
+	if f.failing[service] {
+		return nil, errors.New("offer file unavailable")
+	}
+	return []RawPrice{{ServiceCode: service, Region: region, Unit: "Hrs", PricePerUnit: "0.2"}}, nil
+}
+
+func (f *versionedFetcher) ServiceVersions(ctx context.Context, region string) (map[string]string, error) {
+	return f.versions, nil
+}
+
+// activeStore serves an active snapshot and its rates by service
+type activeStore struct {
+	db.PricingStore
+	active    *db.PricingSnapshot
+	rates     map[string][]db.SnapshotRate
+	listFails bool
+}
+
+func (s *activeStore) GetActiveSnapshot(ctx context.Context, cloud db.CloudProvider, region, alias string) (*db.PricingSnapshot, error) {
+	return s.active, nil
+}
+
+func (s *activeStore) ListServiceRates(ctx context.Context, id uuid.UUID, service string) ([]db.SnapshotRate, error) {
+	if s.listFails {
+		return nil, errors.New("connection reset")
+	}
+	return s.rates[service], nil
+}
+
+// newActiveStore returns a store whose active snapshot was built from
+// versions, with one 0.1 rate per service
+func newActiveStore(versions map[string]string) *activeStore {
+	store := &activeStore{
+		active: &db.PricingSnapshot{ID: uuid.New(), Cloud: db.AWS, Region: "us-east-1", ServiceVersions: versions},
+		rates:  make(map[string][]db.SnapshotRate),
+	}
+	for service := range versions {
+		store.rates[service] = []db.SnapshotRate{{
+			Key:  db.RateKey{Cloud: db.AWS, Service: service, Region: "us-east-1"},
+			Rate: db.PricingRate{Unit: "Hrs", Price: decimal.RequireFromString("0.1"), Currency: "USD"},
+		}}
+	}
+	return store
+}
+
+func runIncremental(t *testing.T, fetcher *versionedFetcher, store db.PricingStore) (*incrementalFetch, *serviceTally) {
+	t.Helper()
+	tally := newServiceTally()
+	config := &LifecycleConfig{Provider: db.AWS, Region: "us-east-1", Alias: "default", Incremental: true}
+	result, err := fetchIncremental(context.Background(), fetcher, store, config, tally, logging.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result, tally
+}
+
+func reusedServices(rates []NormalizedRate) string {
+	var services []string
+	for _, r := range rates {
+		services = append(services, r.RateKey.Service)
+	}
+	return strings.Join(services, ",")
+}
+
+// TestFetchIncremental proves unchanged services reuse the active
+// snapshot's rates, changed ones are refetched, and a changed service that
+// fails keeps its previous rates and version
+func TestFetchIncremental(t *testing.T) {
+	store := newActiveStore(map[string]string{"AWSLambda": "v1", "AmazonEC2": "v1", "AmazonS3": "v1"})
+	fetcher := &versionedFetcher{
+		versions: map[string]string{"AWSLambda": "v2", "AmazonEC2": "v1", "AmazonRDS": "", "AmazonS3": "v2"},
+		failing:  map[string]bool{"AWSLambda": true},
+	}
+
+	result, tally := runIncremental(t, fetcher, store)
+
+	if got := strings.Join(fetcher.fetched, ","); got != "AWSLambda,AmazonRDS,AmazonS3" {
+		t.Errorf("fetched services = %s, want the changed and unversioned ones", got)
+	}
+	if got := strings.Join(result.Changed, ","); got != "AmazonRDS,AmazonS3" {
+		t.Errorf("changed = %s", got)
+	}
+	if got := strings.Join(result.Skipped, ","); got != "AWSLambda,AmazonEC2" {
+		t.Errorf("skipped = %s", got)
+	}
+	if got := reusedServices(result.Reused); got != "AWSLambda,AmazonEC2" {
+		t.Errorf("reused rates = %s", got)
+	}
+	for _, r := range result.Reused {
+		if !r.Price.Equal(decimal.RequireFromString("0.1")) {
+			t.Errorf("reused %s at %s, want the active snapshot's 0.1", r.RateKey.Service, r.Price)
+		}
+	}
+	if len(result.Fetched) != 2 {
+		t.Errorf("fetched %d prices, want 2", len(result.Fetched))
+	}
+	want := map[string]string{"AWSLambda": "v1", "AmazonEC2": "v1", "AmazonRDS": "", "AmazonS3": "v2"}
+	for service, version := range want {
+		if got, ok := result.Versions[service]; !ok || got != version {
+			t.Errorf("version of %s = %q, want %q", service, got, version)
+		}
+	}
+	if result.Unchanged() {
+		t.Error("Unchanged() with changed services")
+	}
+
+	results := make(map[string]ServiceResult)
+	for _, r := range tally.Results() {
+		results[r.Service] = r
+	}
+	if r := results["AWSLambda"]; !r.Failed() || r.Reused != 1 {
+		t.Errorf("AWSLambda result = %+v, want failed with 1 reused rate", r)
+	}
+	if r := results["AmazonEC2"]; r.Failed() || r.Reused != 1 || r.Fetched != 0 {
+		t.Errorf("AmazonEC2 result = %+v, want 1 reused rate", r)
+	}
+	if r := results["AmazonS3"]; r.Failed() || r.Fetched != 1 {
+		t.Errorf("AmazonS3 result = %+v, want 1 fetched price", r)
+	}
+}
+
+func TestFetchIncrementalUnchanged(t *testing.T) {
+	versions := map[string]string{"AmazonEC2": "v1", "AmazonS3": "v1"}
+	fetcher := &versionedFetcher{versions: versions}
+
+	result, _ := runIncremental(t, fetcher, newActiveStore(versions))
+	if !result.Unchanged() {
+		t.Errorf("Unchanged() = false, changed = %v", result.Changed)
+	}
+	if len(fetcher.fetched) != 0 {
+		t.Errorf("fetched %v, want nothing", fetcher.fetched)
+	}
+	if got := reusedServices(result.Reused); got != "AmazonEC2,AmazonS3" {
+		t.Errorf("reused rates = %s", got)
+	}
+}
+
+// TestFetchIncrementalRefetches proves every service is fetched when there
+// is no active snapshot, or its rates cannot be read
+func TestFetchIncrementalRefetches(t *testing.T) {
+	versions := map[string]string{"AmazonEC2": "v1", "AmazonS3": "v1"}
+
+	unreadable := newActiveStore(versions)
+	unreadable.listFails = true
+	stores := map[string]db.PricingStore{
+		"no active snapshot": &activeStore{},
+		"unreadable rates":   unreadable,
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			fetcher := &versionedFetcher{versions: versions}
+			result, _ := runIncremental(t, fetcher, store)
+			if got := strings.Join(result.Changed, ","); got != "AmazonEC2,AmazonS3" {
+				t.Errorf("changed = %s, want every service", got)
+			}
+			if len(result.Reused) != 0 || result.Unchanged() {
+				t.Errorf("reused %d rates, want none", len(result.Reused))
+			}
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"terraform-cost/db"
+	"terraform-cost/internal/logging"
 
 	"github.com/google/uuid"
 )
@@ -63,6 +64,10 @@ type LifecycleState struct {
 	// In-memory only - NEVER written to DB until commit
 	RawPrices     []RawPrice
 	Normalized    []NormalizedRate
+
+	// RawCount is the number of raw prices; RawPrices is released once
+	// normalized
+	RawCount      int
 	ContentHash   string
 
 	// Backup verification
@@ -73,6 +78,18 @@ type LifecycleState struct {
 	// Only assigned after successful commit
 	SnapshotID    *uuid.UUID
 
+	// Service families covered by the active snapshot but not by this one
+	LostCoverage  []db.ServiceFamily
+
+	// Incremental mode: offer file versions, rates carried over from the
+	// active snapshot, and the services they belong to
+	ServiceVersions map[string]string
+	ReusedRates     []NormalizedRate
+	SkippedServices []string
+
+	// Per-service fetched/normalized counts and errors
+	services *serviceTally
+
 	// Tracking
 	StartTime     time.Time
 	Errors        []string
@@ -89,6 +106,8 @@ type LifecycleConfig struct {
 	AllowMockPricing bool   // MUST BE FALSE IN PRODUCTION
 	MinCoverage      float64
 	Timeout          time.Duration
+	Force            bool   // Commit even if coverage regresses vs. the active snapshot
+	Incremental      bool   // Only fetch services whose offer file changed since the active snapshot
 }
 
 // DefaultLifecycleConfig returns safe production defaults
@@ -134,7 +153,14 @@ func NewLifecycle(
 	}
 }
 
-// Execute runs the complete strict ingestion lifecycle
+// Rules returns the validation rules run in the validating phase
+func (l *Lifecycle) Rules() *RuleRegistry {
+	return l.validator.Rules()
+}
+
+// Execute runs the complete strict ingestion lifecycle.
+// On failure the result is still returned, together with a typed error
+// (FetchError, NormalizeError, ValidationError, BackupError or CommitError).
 func (l *Lifecycle) Execute(ctx context.Context, config *LifecycleConfig) (*LifecycleResult, error) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
@@ -152,6 +178,7 @@ func (l *Lifecycle) Execute(ctx context.Context, config *LifecycleConfig) (*Life
 		Alias:       config.Alias,
 		Environment: config.Environment,
 		StartTime:   time.Now(),
+		services:    newServiceTally(),
 	}
 
 	// Apply timeout
@@ -165,42 +192,48 @@ func (l *Lifecycle) Execute(ctx context.Context, config *LifecycleConfig) (*Life
 	// PRODUCTION GUARD - No mocks allowed
 	// ==================================================
 	if err := l.enforceProductionGuards(); err != nil {
-		return l.fail(err)
+		return l.fail(&ValidationError{Phase: PhaseInit, Cause: err})
 	}
 
 	// ==================================================
 	// PHASE: FETCHING (NO DB ACCESS)
 	// ==================================================
-	if err := l.phaseFetching(ctx); err != nil {
-		return l.fail(err)
+	unchanged, err := l.phaseFetching(ctx)
+	if err != nil {
+		return l.fail(&FetchError{Phase: PhaseFetching, Cause: err})
+	}
+	if unchanged != nil {
+		l.state.SnapshotID = &unchanged.ID
+		l.state.Phase = PhaseActive
+		return l.success(fmt.Sprintf("no service changed since snapshot %s", unchanged.ID))
 	}
 
 	// ==================================================
 	// PHASE: NORMALIZING (NO DB ACCESS)
 	// ==================================================
 	if err := l.phaseNormalizing(ctx); err != nil {
-		return l.fail(err)
+		return l.fail(&NormalizeError{Phase: PhaseNormalizing, Cause: err})
 	}
 
 	// ==================================================
 	// PHASE: VALIDATING (NO DB ACCESS)
 	// ==================================================
 	if err := l.phaseValidating(ctx); err != nil {
-		return l.fail(err)
+		return l.fail(&ValidationError{Phase: PhaseValidating, Cause: err})
 	}
 
 	// ==================================================
 	// PHASE: STAGING (NO DB ACCESS)
 	// ==================================================
 	if err := l.phaseStaging(ctx); err != nil {
-		return l.fail(err)
+		return l.fail(&ValidationError{Phase: PhaseStaging, Cause: err})
 	}
 
 	// ==================================================
 	// PHASE: BACKUP (MANDATORY)
 	// ==================================================
 	if err := l.phaseBackup(ctx); err != nil {
-		return l.fail(err)
+		return l.fail(&BackupError{Phase: PhaseBackedUp, Path: config.BackupDir, Cause: err})
 	}
 
 	// ==================================================
@@ -215,7 +248,7 @@ func (l *Lifecycle) Execute(ctx context.Context, config *LifecycleConfig) (*Life
 	// PHASE: COMMITTING (SINGLE DB TRANSACTION)
 	// ==================================================
 	if err := l.phaseCommitting(ctx); err != nil {
-		return l.fail(err)
+		return l.fail(commitFailure(err))
 	}
 
 	return l.success("ingestion complete")
@@ -238,32 +271,73 @@ func (l *Lifecycle) enforceProductionGuards() error {
 	return nil
 }
 
-// phaseFetching downloads raw pricing (NO DB ACCESS)
-func (l *Lifecycle) phaseFetching(ctx context.Context) error {
+// phaseFetching downloads raw pricing (NO DB WRITES). In incremental mode
+// it reads the active snapshot to reuse unchanged services, and returns
+// that snapshot when no service changed.
+func (l *Lifecycle) phaseFetching(ctx context.Context) (*db.PricingSnapshot, error) {
 	l.state.Phase = PhaseFetching
 
-	rawPrices, err := l.fetcher.FetchRegion(ctx, l.config.Region)
+	if inc, ok := l.fetcher.(IncrementalFetcher); ok && l.config.Incremental {
+		fetched, err := fetchIncremental(ctx, inc, l.store, l.config, l.state.services, logging.Default())
+		if err != nil {
+			return nil, fmt.Errorf("fetch failed: %w", err)
+		}
+		if fetched.Unchanged() {
+			l.state.SkippedServices = fetched.Skipped
+			return fetched.Active, nil
+		}
+		if len(fetched.Fetched) == 0 && len(fetched.Reused) == 0 {
+			return nil, fmt.Errorf("fetch returned 0 prices")
+		}
+
+		// Store in memory only - NO DB WRITES
+		l.state.RawPrices = fetched.Fetched
+		l.state.ReusedRates = fetched.Reused
+		l.state.ServiceVersions = fetched.Versions
+		l.state.SkippedServices = fetched.Skipped
+		return nil, nil
+	}
+
+	versions := recordServiceVersions(ctx, l.fetcher, l.config.Region)
+	rawPrices, err := fetchPrices(ctx, l.fetcher, l.config.Region, l.state.services)
 	if err != nil {
-		return fmt.Errorf("fetch failed: %w", err)
+		return nil, fmt.Errorf("fetch failed: %w", err)
 	}
 
 	if len(rawPrices) == 0 {
-		return fmt.Errorf("fetch returned 0 prices")
+		return nil, fmt.Errorf("fetch returned 0 prices")
 	}
 
 	// Store in memory only - NO DB WRITES
 	l.state.RawPrices = rawPrices
-	return nil
+	l.state.ServiceVersions = versions
+	return nil, nil
 }
 
 // phaseNormalizing transforms to canonical form (NO DB ACCESS)
 func (l *Lifecycle) phaseNormalizing(ctx context.Context) error {
 	l.state.Phase = PhaseNormalizing
 
-	normalized, err := l.normalizer.Normalize(l.state.RawPrices)
-	if err != nil {
-		return fmt.Errorf("normalization failed: %w", err)
+	// Stream raw prices through the normalizer, releasing each once sent,
+	// so the raw and normalized catalogs are not both fully held
+	var normalized []NormalizedRate
+	if len(l.state.RawPrices) > 0 {
+		l.state.RawCount = len(l.state.RawPrices)
+		rates, failures := normalizeStream(l.normalizer, sendPrices(l.state.RawPrices, nil), defaultStreamBatchSize)
+		for r := range rates {
+			normalized = append(normalized, r)
+		}
+		l.state.RawPrices = nil
+
+		if failed := failures(); len(failed) > 0 {
+			for _, f := range failed {
+				l.state.services.normalizeFailed(f.Prices, f.Err)
+			}
+			return fmt.Errorf("normalization failed: %w", failed[0])
+		}
+		l.state.services.normalized(normalized)
 	}
+	normalized = append(normalized, l.state.ReusedRates...)
 
 	if len(normalized) == 0 {
 		return fmt.Errorf("normalization produced 0 rates")
@@ -281,14 +355,8 @@ func (l *Lifecycle) phaseValidating(ctx context.Context) error {
 
 	l.validator.SetMinCoveragePercent(l.config.MinCoverage)
 
-	// Get previous snapshot for coverage comparison
-	prevSnapshot, _ := l.store.GetActiveSnapshot(ctx, l.config.Provider, l.config.Region, l.config.Alias)
-	var prevRateCount int
-	if prevSnapshot != nil {
-		prevRateCount, _ = l.store.CountRates(ctx, prevSnapshot.ID)
-	}
-
-	return l.validator.ValidateAll(l.state.Normalized, prevRateCount)
+	// Compare against the active snapshot
+	return l.validator.ValidateInput(activeSnapshotInput(ctx, l.store, l.config.Provider, l.config.Region, l.config.Alias, l.state.Normalized))
 }
 
 // phaseStaging prepares for commit (NO DB ACCESS)
@@ -375,6 +443,13 @@ func (l *Lifecycle) phaseCommitting(ctx context.Context) error {
 		return nil
 	}
 
+	// Refuse to replace the active snapshot with one covering less
+	lost, err := checkCoverageRegression(ctx, l.store, l.config, l.state.Normalized)
+	l.state.LostCoverage = lost
+	if err != nil {
+		return &ValidationError{Phase: PhaseCommitting, Cause: err}
+	}
+
 	// Create snapshot
 	snapshotID := uuid.New()
 	snapshot := &db.PricingSnapshot{
@@ -388,6 +463,7 @@ func (l *Lifecycle) phaseCommitting(ctx context.Context) error {
 		Hash:          l.state.ContentHash,
 		Version:       "1.0",
 		IsActive:      false, // Not active until transaction commits
+		ServiceVersions: l.state.ServiceVersions,
 	}
 
 	// Begin transaction
@@ -449,7 +525,7 @@ func (l *Lifecycle) phaseCommitting(ctx context.Context) error {
 	return nil
 }
 
-// fail marks the lifecycle as failed
+// fail marks the lifecycle as failed, returning err alongside the result
 func (l *Lifecycle) fail(err error) (*LifecycleResult, error) {
 	l.state.Phase = PhaseFailed
 	l.state.Errors = append(l.state.Errors, err.Error())
@@ -460,9 +536,22 @@ func (l *Lifecycle) fail(err error) (*LifecycleResult, error) {
 		Error:        err.Error(),
 		Duration:     time.Since(l.state.StartTime),
 		BackupPath:   l.state.BackupPath,
-		RawCount:     len(l.state.RawPrices),
+		RawCount:     l.rawCount(),
 		NormalizedCount: len(l.state.Normalized),
-	}, nil
+		LostCoverage: serviceFamilyNames(l.state.LostCoverage),
+		ReusedCount:  len(l.state.ReusedRates),
+		SkippedServices: l.state.SkippedServices,
+		ServiceResults: l.state.services.Results(),
+	}, err
+}
+
+// rawCount returns the number of raw prices fetched, before or after
+// normalization released them
+func (l *Lifecycle) rawCount() int {
+	if l.state.RawCount > 0 {
+		return l.state.RawCount
+	}
+	return len(l.state.RawPrices)
 }
 
 // success marks the lifecycle as successful
@@ -475,8 +564,12 @@ func (l *Lifecycle) success(msg string) (*LifecycleResult, error) {
 		SnapshotID:      l.state.SnapshotID,
 		BackupPath:      l.state.BackupPath,
 		ContentHash:     l.state.ContentHash,
-		RawCount:        len(l.state.RawPrices),
+		RawCount:        l.rawCount(),
 		NormalizedCount: len(l.state.Normalized),
+		LostCoverage:    serviceFamilyNames(l.state.LostCoverage),
+		ReusedCount:     len(l.state.ReusedRates),
+		SkippedServices: l.state.SkippedServices,
+		ServiceResults:  l.state.services.Results(),
 	}, nil
 }
 
@@ -492,6 +585,27 @@ type LifecycleResult struct {
 	ContentHash     string         `json:"content_hash,omitempty"`
 	RawCount        int            `json:"raw_count"`
 	NormalizedCount int            `json:"normalized_count"`
+	LostCoverage    []string       `json:"lost_coverage,omitempty"`
+
+	// Incremental mode: rates carried over from the active snapshot and
+	// the services they belong to
+	ReusedCount     int            `json:"reused_count,omitempty"`
+	SkippedServices []string       `json:"skipped_services,omitempty"`
+
+	// ServiceResults reports each service's counts and error, so a run
+	// where some services failed shows which ones
+	ServiceResults  []ServiceResult `json:"service_results,omitempty"`
+}
+
+// FailedServices returns the services that could not be ingested
+func (r *LifecycleResult) FailedServices() []string {
+	var failed []string
+	for _, s := range r.ServiceResults {
+		if s.Failed() {
+			failed = append(failed, s.Service)
+		}
+	}
+	return failed
 }
 
 // RealAPIFetcher is an interface for fetchers that can verify they use real APIs
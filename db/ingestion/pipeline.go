@@ -141,7 +141,9 @@ type PriceFetcher interface {
 	SupportedServices() []string
 }
 
-// PriceNormalizer converts raw prices to normalized rates
+// PriceNormalizer converts raw prices to normalized rates.
+// Normalize needs the whole input and output in memory; normalizers that
+// also implement StreamNormalizer are streamed by both lifecycles instead.
 type PriceNormalizer interface {
 	// Cloud returns the cloud provider
 	Cloud() db.CloudProvider
@@ -174,6 +176,11 @@ func NewPipeline(
 	}
 }
 
+// Rules returns the validation rules run before commit
+func (p *Pipeline) Rules() *RuleRegistry {
+	return p.validator.Rules()
+}
+
 // Execute runs the full 5-phase ingestion pipeline
 func (p *Pipeline) Execute(ctx context.Context, config *PipelineConfig) (*PipelineResult, error) {
 	if config == nil {
@@ -305,15 +312,8 @@ func (p *Pipeline) phaseValidate(ctx context.Context, config *PipelineConfig, ra
 	// Configure validator
 	p.validator.SetMinCoveragePercent(config.MinCoveragePercent)
 
-	// Get previous snapshot for coverage comparison
-	prevSnapshot, _ := p.store.GetActiveSnapshot(ctx, config.Provider, config.Region, config.Alias)
-	var prevRateCount int
-	if prevSnapshot != nil {
-		prevRateCount, _ = p.store.CountRates(ctx, prevSnapshot.ID)
-	}
-
-	// Run all validations
-	return p.validator.ValidateAll(rates, prevRateCount)
+	// Run all validations against the active snapshot
+	return p.validator.ValidateInput(activeSnapshotInput(ctx, p.store, config.Provider, config.Region, config.Alias, rates))
 }
 
 // phaseBackup writes snapshot dump to local file
@@ -410,7 +410,9 @@ func (p *Pipeline) phaseCommit(ctx context.Context, config *PipelineConfig, rate
 	return snapshot.ID, nil
 }
 
-// calculateHash computes a deterministic hash of rates
+// calculateHash computes a deterministic hash of rates. Rates sharing a
+// rate key (price tiers) are ordered by unit, price and tier, so the hash
+// does not depend on the order rates arrive in.
 func calculateHash(rates []NormalizedRate) string {
 	// Sort for determinism
 	sorted := make([]NormalizedRate, len(rates))
@@ -418,9 +420,16 @@ func calculateHash(rates []NormalizedRate) string {
 	sort.Slice(sorted, func(i, j int) bool {
 		ki := rateKeyString(sorted[i].RateKey)
 		kj := rateKeyString(sorted[j].RateKey)
-		return ki < kj
+		if ki != kj {
+			return ki < kj
+		}
+		return rateLess(sorted[i], sorted[j])
 	})
+	return hashSortedRates(sorted)
+}
 
+// hashSortedRates hashes rates in the order given
+func hashSortedRates(sorted []NormalizedRate) string {
 	hasher := sha256.New()
 	for _, r := range sorted {
 		hasher.Write([]byte(rateKeyString(r.RateKey)))
@@ -431,6 +440,33 @@ func calculateHash(rates []NormalizedRate) string {
 	return hex.EncodeToString(hasher.Sum(nil))
 }
 
+// rateLess orders rates that share a rate key
+func rateLess(a, b NormalizedRate) bool {
+	if a.Unit != b.Unit {
+		return a.Unit < b.Unit
+	}
+	if c := a.Price.Cmp(b.Price); c != 0 {
+		return c < 0
+	}
+	if c := compareTier(a.TierMin, b.TierMin); c != 0 {
+		return c < 0
+	}
+	return compareTier(a.TierMax, b.TierMax) < 0
+}
+
+// compareTier compares optional tier bounds, unset first
+func compareTier(a, b *decimal.Decimal) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	return a.Cmp(*b)
+}
+
 func rateKeyString(k db.RateKey) string {
 	attrs := make([]string, 0, len(k.Attributes))
 	for k, v := range k.Attributes {
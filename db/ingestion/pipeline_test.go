@@ -2,6 +2,7 @@
 package ingestion
 
 import (
+	"fmt"
 	"testing"
 
 	"terraform-cost/db"
@@ -154,3 +155,39 @@ func TestPipelineResult(t *testing.T) {
 		t.Errorf("expected 5 phases, got %d", len(result.PhasesCompleted))
 	}
 }
+
+// BenchmarkNormalizeRateBatch normalizes 50000 AWS prices through the
+// production normalizer; its result is guarded against regressions by
+// scripts/bench-guard.sh
+func BenchmarkNormalizeRateBatch(b *testing.B) {
+	normalizer, err := GetProductionNormalizer(db.AWS)
+	if err != nil {
+		b.Fatal(err)
+	}
+	raw := make([]RawPrice, 50000)
+	for i := range raw {
+		raw[i] = RawPrice{
+			SKU:           fmt.Sprintf("SKU%06d", i),
+			ServiceCode:   "AmazonEC2",
+			ProductFamily: "Compute Instance",
+			Region:        "us-east-1",
+			Unit:          "Hrs",
+			PricePerUnit:  fmt.Sprintf("0.%06d", i+1),
+			Currency:      "USD",
+			Attributes: map[string]string{
+				"instanceType":    fmt.Sprintf("m%d.%dxlarge", i%8, i/8),
+				"operatingSystem": "Linux",
+				"tenancy":         "Shared",
+				"capacitystatus":  "Used",
+				"preInstalledSw":  "NA",
+			},
+		}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := normalizer.Normalize(raw); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// Package ingestion - Ingestion progress
+// A streaming ingestion runs for minutes. A ProgressReporter set with
+// StreamingLifecycle.SetProgressReporter receives structured updates so
+// the CLI can render a progress bar instead of parsing log lines.
+package ingestion
+
+// Item-level progress phases reported by StreamingLifecycle
+const (
+	ProgressPhaseNormalizing = "NORMALIZING"
+	ProgressPhaseWriting     = "WRITING"
+)
+
+// ProgressReporter receives progress: the phase, how many of total items
+// in it are complete, and a message.
+//
+// Phase boundaries are reported under the phase name (e.g. "BACKUP") with
+// completed and total counting the lifecycle's phases. Within a phase,
+// ProgressPhaseNormalizing counts raw prices normalized and
+// ProgressPhaseWriting counts rates committed. A total of 0 is unknown:
+// a streamed fetch is normalized before its size is known, and reports
+// the final count once it ends.
+//
+// A lifecycle calls its reporter only from the goroutine running Execute.
+// Lifecycles running concurrently (e.g. several regions) call their
+// reporters concurrently, so a reporter shared between them must be safe
+// for concurrent use.
+type ProgressReporter func(phase string, completed, total int, message string)
+
+// SetProgressReporter sets the reporter for structured progress; nil
+// disables it
+func (s *StreamingLifecycle) SetProgressReporter(report ProgressReporter) {
+	s.progress = report
+}
+
+// reportProgress calls the progress reporter, if any
+func (s *StreamingLifecycle) reportProgress(phase string, completed, total int, message string) {
+	if s.progress != nil {
+		s.progress(phase, completed, total, message)
+	}
+}
@@ -0,0 +1,273 @@
+// Package ingestion - Validation rule registry
+// Governance differs between providers and operators: one refuses $0
+// rates, another requires USD, another refuses a snapshot whose prices
+// moved too far from the active one. Each check is a ValidationRule
+// registered for every provider or for one, and validation runs all of
+// them, reporting every failing rule rather than the first.
+package ingestion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"terraform-cost/db"
+
+	"github.com/shopspring/decimal"
+)
+
+// RuleInput is what validation rules check: the rates about to be
+// committed and the active snapshot they would replace
+type RuleInput struct {
+	Cloud db.CloudProvider
+	Rates []NormalizedRate
+
+	// PreviousCount is the rate count of the active snapshot, 0 when
+	// there is none
+	PreviousCount int
+
+	// loadPrevious reads the active snapshot's rates; nil when there is
+	// no active snapshot
+	loadPrevious func() ([]NormalizedRate, error)
+	previous     []NormalizedRate
+	previousErr  error
+	loaded       bool
+}
+
+// PreviousRates returns the rates of the active snapshot, read on first
+// use so only rules comparing against it pay for the read. It returns nil
+// when there is no active snapshot.
+func (in *RuleInput) PreviousRates() ([]NormalizedRate, error) {
+	if !in.loaded {
+		in.loaded = true
+		if in.loadPrevious != nil {
+			in.previous, in.previousErr = in.loadPrevious()
+		}
+	}
+	return in.previous, in.previousErr
+}
+
+// activeSnapshotInput builds the input for rates replacing the active
+// snapshot of a provider/region/alias
+func activeSnapshotInput(ctx context.Context, store db.PricingStore, cloud db.CloudProvider, region, alias string, rates []NormalizedRate) *RuleInput {
+	in := &RuleInput{Cloud: cloud, Rates: rates}
+	active, _ := store.GetActiveSnapshot(ctx, cloud, region, alias)
+	if active == nil {
+		return in
+	}
+	in.PreviousCount, _ = store.CountRates(ctx, active.ID)
+	in.loadPrevious = func() ([]NormalizedRate, error) {
+		rates, err := store.ListRates(ctx, active.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read active snapshot %s: %w", active.ID, err)
+		}
+		previous := make([]NormalizedRate, 0, len(rates))
+		for _, r := range rates {
+			previous = append(previous, normalizedFromSnapshot(r))
+		}
+		return previous, nil
+	}
+	return in
+}
+
+// ValidationRule checks rates before they are committed; an error fails
+// the ingestion
+type ValidationRule func(in *RuleInput) error
+
+// namedRule is a registered rule
+type namedRule struct {
+	name string
+	rule ValidationRule
+}
+
+// RuleRegistry holds the validation rules run for each provider
+type RuleRegistry struct {
+	mu      sync.RWMutex
+	common  []namedRule
+	byCloud map[db.CloudProvider][]namedRule
+}
+
+// NewRuleRegistry creates an empty registry
+func NewRuleRegistry() *RuleRegistry {
+	return &RuleRegistry{byCloud: make(map[db.CloudProvider][]namedRule)}
+}
+
+// Register adds a rule run for every provider. A rule registered again
+// under the same name replaces the earlier one.
+func (r *RuleRegistry) Register(name string, rule ValidationRule) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.common = withRule(r.common, name, rule)
+}
+
+// RegisterFor adds a rule run only for cloud
+func (r *RuleRegistry) RegisterFor(cloud db.CloudProvider, name string, rule ValidationRule) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byCloud[cloud] = withRule(r.byCloud[cloud], name, rule)
+}
+
+// Unregister removes the rule named name for every provider
+func (r *RuleRegistry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.common = withoutRule(r.common, name)
+	for cloud, rules := range r.byCloud {
+		r.byCloud[cloud] = withoutRule(rules, name)
+	}
+}
+
+// Names lists the rules run for cloud in the order they run: rules for
+// every provider first, then the provider's own
+func (r *RuleRegistry) Names(cloud db.CloudProvider) []string {
+	rules := r.rulesFor(cloud)
+	names := make([]string, len(rules))
+	for i, rule := range rules {
+		names[i] = rule.name
+	}
+	return names
+}
+
+// Validate runs every rule for in.Cloud and returns the failures joined,
+// each prefixed with the rule's name; nil when all pass
+func (r *RuleRegistry) Validate(in *RuleInput) error {
+	var errs []error
+	for _, rule := range r.rulesFor(in.Cloud) {
+		if err := rule.rule(in); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", rule.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *RuleRegistry) rulesFor(cloud db.CloudProvider) []namedRule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rules := make([]namedRule, 0, len(r.common)+len(r.byCloud[cloud]))
+	rules = append(rules, r.common...)
+	return append(rules, r.byCloud[cloud]...)
+}
+
+func withRule(rules []namedRule, name string, rule ValidationRule) []namedRule {
+	for i := range rules {
+		if rules[i].name == name {
+			rules[i].rule = rule
+			return rules
+		}
+	}
+	return append(rules, namedRule{name: name, rule: rule})
+}
+
+func withoutRule(rules []namedRule, name string) []namedRule {
+	kept := rules[:0]
+	for _, rule := range rules {
+		if rule.name != name {
+			kept = append(kept, rule)
+		}
+	}
+	return kept
+}
+
+// maxListedRates caps the rates a rule names in its error
+const maxListedRates = 5
+
+// rateFailures formats the rates failing a rule, naming the first few
+func rateFailures(what string, failed []string) error {
+	listed := failed
+	if len(listed) > maxListedRates {
+		listed = listed[:maxListedRates]
+	}
+	msg := fmt.Sprintf("%d rates %s: %s", len(failed), what, strings.Join(listed, ", "))
+	if len(failed) > len(listed) {
+		msg += fmt.Sprintf(" and %d more", len(failed)-len(listed))
+	}
+	return errors.New(msg)
+}
+
+// rateLabel names a rate in rule errors
+func rateLabel(r NormalizedRate) string {
+	return fmt.Sprintf("%s/%s/%s", r.RateKey.Service, r.RateKey.ProductFamily, r.RateKey.Region)
+}
+
+// NoZeroRatesRule refuses rates priced at zero, which usually mean a
+// price the normalizer failed to parse. Tiered free allowances are zero
+// too, so register it only for catalogs without them.
+func NoZeroRatesRule() ValidationRule {
+	return func(in *RuleInput) error {
+		var failed []string
+		for _, r := range in.Rates {
+			if r.Price.IsZero() {
+				failed = append(failed, rateLabel(r))
+			}
+		}
+		if len(failed) == 0 {
+			return nil
+		}
+		return rateFailures("are priced at zero", failed)
+	}
+}
+
+// CurrencyRule requires every rate to be quoted in currency
+func CurrencyRule(currency string) ValidationRule {
+	return func(in *RuleInput) error {
+		var failed []string
+		for _, r := range in.Rates {
+			if !strings.EqualFold(r.Currency, currency) {
+				failed = append(failed, fmt.Sprintf("%s in %q", rateLabel(r), r.Currency))
+			}
+		}
+		if len(failed) == 0 {
+			return nil
+		}
+		return rateFailures("are not in "+currency, failed)
+	}
+}
+
+// PriceDeltaRule refuses rates whose price moved more than maxPercent
+// from the same rate in the active snapshot. Rates new to the snapshot,
+// and rates that were free, have nothing to compare against and pass, as
+// does the first ingestion of a provider/region.
+func PriceDeltaRule(maxPercent float64) ValidationRule {
+	limit := decimal.NewFromFloat(maxPercent)
+	return func(in *RuleInput) error {
+		previous, err := in.PreviousRates()
+		if err != nil {
+			return err
+		}
+		if len(previous) == 0 {
+			return nil
+		}
+		before := make(map[string]decimal.Decimal, len(previous))
+		for _, r := range previous {
+			before[rateIdentity(r)] = r.Price
+		}
+
+		var failed []string
+		for _, r := range in.Rates {
+			old, ok := before[rateIdentity(r)]
+			if !ok || old.IsZero() {
+				continue
+			}
+			change := r.Price.Sub(old).Div(old).Mul(decimal.NewFromInt(100))
+			if change.Abs().GreaterThan(limit) {
+				failed = append(failed, fmt.Sprintf("%s %s → %s (%s%%)",
+					rateLabel(r), old.String(), r.Price.String(), change.StringFixed(1)))
+			}
+		}
+		if len(failed) == 0 {
+			return nil
+		}
+		return rateFailures(fmt.Sprintf("changed by more than %g%% vs the active snapshot", maxPercent), failed)
+	}
+}
+
+// rateIdentity keys a rate across snapshots: its rate key, unit and tier
+func rateIdentity(r NormalizedRate) string {
+	tier := ""
+	if r.TierMin != nil {
+		tier = r.TierMin.String()
+	}
+	return rateKeyString(r.RateKey) + "|" + r.Unit + "|" + tier
+}
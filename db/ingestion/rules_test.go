@@ -0,0 +1,115 @@
+// Package ingestion - Validation rule tests
+package ingestion
+
+import (
+	"strings"
+	"testing"
+
+	"terraform-cost/db"
+
+	"github.com/shopspring/decimal"
+)
+
+func ruleRate(instanceType, price, currency string) NormalizedRate {
+	return NormalizedRate{
+		RateKey: db.RateKey{Cloud: db.AWS, Service: "AmazonEC2", ProductFamily: "Compute Instance",
+			Region: "us-east-1", Attributes: map[string]string{"instanceType": instanceType}},
+		Unit:     "Hrs",
+		Price:    decimal.RequireFromString(price),
+		Currency: currency,
+	}
+}
+
+func TestRuleRegistryReportsEveryFailure(t *testing.T) {
+	v := NewIngestionValidator()
+	v.Rules().RegisterFor(db.AWS, "no_zero_rates", NoZeroRatesRule())
+	v.Rules().RegisterFor(db.AWS, "usd_only", CurrencyRule("USD"))
+	v.Rules().RegisterFor(db.AWS, "price_delta", PriceDeltaRule(50))
+	v.Rules().RegisterFor(db.Azure, "azure_only", func(*RuleInput) error {
+		t.Error("an Azure rule ran for AWS rates")
+		return nil
+	})
+
+	want := []string{RulePricesNonNegative, RuleDimensionsComplete, RuleCoverageNotDecreased,
+		"no_zero_rates", "usd_only", "price_delta"}
+	if got := v.Rules().Names(db.AWS); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("rules = %v, want %v", got, want)
+	}
+
+	previous := []NormalizedRate{
+		ruleRate("t3.micro", "0.0104", "USD"),
+		ruleRate("t3.small", "0.0208", "USD"),
+		ruleRate("t3.large", "0.0832", "USD"),
+	}
+	loads := 0
+	in := &RuleInput{
+		Cloud: db.AWS,
+		Rates: []NormalizedRate{
+			ruleRate("t3.micro", "0", "USD"),      // zero, and a 100% drop
+			ruleRate("t3.small", "0.0210", "EUR"), // wrong currency
+			ruleRate("t3.large", "0.2000", "USD"), // +140%
+			ruleRate("t3.nano", "0.0052", "USD"),  // new: nothing to compare
+		},
+		PreviousCount: len(previous),
+		loadPrevious: func() ([]NormalizedRate, error) {
+			loads++
+			return previous, nil
+		},
+	}
+
+	err := v.ValidateInput(in)
+	if err == nil {
+		t.Fatal("expected validation to fail")
+	}
+	msg := err.Error()
+	for _, failing := range []string{"no_zero_rates: 1 rates", "usd_only: 1 rates", "price_delta: 2 rates"} {
+		if !strings.Contains(msg, failing) {
+			t.Errorf("error does not report %q:\n%s", failing, msg)
+		}
+	}
+	for _, passing := range []string{RulePricesNonNegative, RuleDimensionsComplete, RuleCoverageNotDecreased} {
+		if strings.Contains(msg, passing) {
+			t.Errorf("passing rule %s reported:\n%s", passing, msg)
+		}
+	}
+	if !strings.Contains(msg, "0.0832 → 0.2 (140.4%)") {
+		t.Errorf("price delta does not name the change:\n%s", msg)
+	}
+	if loads != 1 {
+		t.Errorf("active snapshot read %d times, want once", loads)
+	}
+
+	// Replacing and removing rules by name
+	v.Rules().RegisterFor(db.AWS, "usd_only", CurrencyRule("EUR"))
+	v.Rules().Unregister("no_zero_rates")
+	v.Rules().Unregister("price_delta")
+	msg = v.ValidateInput(&RuleInput{Cloud: db.AWS, Rates: in.Rates}).Error()
+	if strings.Contains(msg, "no_zero_rates") || !strings.Contains(msg, "usd_only: 3 rates are not in EUR") {
+		t.Errorf("after replace and unregister:\n%s", msg)
+	}
+}
+
+func TestPriceDeltaRuleWithoutActiveSnapshot(t *testing.T) {
+	rates := []NormalizedRate{ruleRate("t3.micro", "5", "USD")}
+	if err := PriceDeltaRule(10)(&RuleInput{Cloud: db.AWS, Rates: rates}); err != nil {
+		t.Errorf("first ingestion failed the delta rule: %v", err)
+	}
+
+	// Tiers of one rate key are compared tier by tier
+	tier := func(min, price string) NormalizedRate {
+		r := ruleRate("t3.micro", price, "USD")
+		m := decimal.RequireFromString(min)
+		r.TierMin = &m
+		return r
+	}
+	in := &RuleInput{
+		Cloud: db.AWS,
+		Rates: []NormalizedRate{tier("0", "0.10"), tier("100", "0.05")},
+		loadPrevious: func() ([]NormalizedRate, error) {
+			return []NormalizedRate{tier("0", "0.10"), tier("100", "0.05")}, nil
+		},
+	}
+	if err := PriceDeltaRule(10)(in); err != nil {
+		t.Errorf("unchanged tiers failed: %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+// Package ingestion - Per-service ingestion results
+// A region is fetched service by service, and one service failing does not
+// stop the others. Aggregate counts hide which service failed, so each run
+// also reports fetched and normalized counts and the error per service.
+package ingestion
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// ServiceFetcher is a PriceFetcher that can fetch one service at a time, so
+// a failing service is reported instead of silently missing
+type ServiceFetcher interface {
+	PriceFetcher
+
+	// Services returns the services FetchRegion covers
+	Services() []string
+
+	// FetchService fetches all prices of one service (NO DB WRITES)
+	FetchService(ctx context.Context, service, region string) ([]RawPrice, error)
+}
+
+// ServiceResult is the outcome of ingesting one service
+type ServiceResult struct {
+	Service    string `json:"service"`
+	Fetched    int    `json:"fetched"`
+	Normalized int    `json:"normalized"`
+
+	// Reused counts rates carried over from the active snapshot
+	// (incremental mode, or a failed service that kept its old rates)
+	Reused int `json:"reused,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// Failed reports whether the service could not be fetched or normalized
+func (r ServiceResult) Failed() bool {
+	return r.Error != ""
+}
+
+// serviceTally accumulates ServiceResults during a run. It is safe for
+// concurrent use, as a streamed fetch counts prices while they are
+// normalized.
+type serviceTally struct {
+	mu      sync.Mutex
+	results map[string]*ServiceResult
+}
+
+func newServiceTally() *serviceTally {
+	return &serviceTally{results: make(map[string]*ServiceResult)}
+}
+
+// get returns a service's result, adding it if new
+func (t *serviceTally) get(service string) *ServiceResult {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.entry(service)
+}
+
+func (t *serviceTally) entry(service string) *ServiceResult {
+	r, ok := t.results[service]
+	if !ok {
+		r = &ServiceResult{Service: service}
+		t.results[service] = r
+	}
+	return r
+}
+
+// fetched counts raw prices by service code
+func (t *serviceTally) fetched(prices []RawPrice) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, p := range prices {
+		t.entry(p.ServiceCode).Fetched++
+	}
+}
+
+// normalized counts normalized rates by rate key service
+func (t *serviceTally) normalized(rates []NormalizedRate) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, r := range rates {
+		t.entry(r.RateKey.Service).Normalized++
+	}
+}
+
+// reused records rates carried over from the active snapshot
+func (t *serviceTally) reused(service string, n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.entry(service).Reused += n
+}
+
+// failed records the first error of a service
+func (t *serviceTally) failed(service string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if r := t.entry(service); r.Error == "" {
+		r.Error = err.Error()
+	}
+}
+
+// normalizeFailed records a normalization error for every service in a
+// batch that could not be normalized
+func (t *serviceTally) normalizeFailed(prices []RawPrice, err error) {
+	seen := make(map[string]bool)
+	for _, p := range prices {
+		if !seen[p.ServiceCode] {
+			seen[p.ServiceCode] = true
+			t.failed(p.ServiceCode, err)
+		}
+	}
+}
+
+// Results returns the results sorted by service, or nil if none
+func (t *serviceTally) Results() []ServiceResult {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.results) == 0 {
+		return nil
+	}
+	out := make([]ServiceResult, 0, len(t.results))
+	for _, r := range t.results {
+		out = append(out, *r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Service < out[j].Service })
+	return out
+}
+
+// fetchPrices fetches a region service by service when the fetcher
+// supports it, recording each failure and continuing with the other
+// services. Other fetchers fetch the region in one call.
+func fetchPrices(ctx context.Context, fetcher PriceFetcher, region string, tally *serviceTally) ([]RawPrice, error) {
+	sf, ok := fetcher.(ServiceFetcher)
+	if !ok {
+		prices, err := fetcher.FetchRegion(ctx, region)
+		if err == nil {
+			tally.fetched(prices)
+		}
+		return prices, err
+	}
+
+	var all []RawPrice
+	for _, service := range sf.Services() {
+		prices, err := sf.FetchService(ctx, service, region)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			tally.failed(service, err)
+			continue
+		}
+		tally.get(service)
+		tally.fetched(prices)
+		all = append(all, prices...)
+	}
+	return all, nil
+}
@@ -0,0 +1,69 @@
+// Package ingestion - Per-service ingestion result tests
+package ingestion
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestServiceResultsMixed proves a run where some services fail reports
+// each service's counts, and only the failed ones as failed
+func TestServiceResultsMixed(t *testing.T) {
+	fetcher := &versionedFetcher{
+		versions: map[string]string{"AWSLambda": "", "AmazonEC2": "", "AmazonRDS": "", "AmazonS3": ""},
+		failing:  map[string]bool{"AWSLambda": true},
+	}
+	tally := newServiceTally()
+
+	prices, err := fetchPrices(context.Background(), fetcher, "us-east-1", tally)
+	if err != nil {
+		t.Fatalf("a failing service should not fail the fetch: %v", err)
+	}
+	if len(prices) != 3 {
+		t.Fatalf("fetched %d prices, want one per succeeding service", len(prices))
+	}
+
+	// AmazonRDS fails to normalize; the others normalize to one rate each
+	var normalized []RawPrice
+	for _, p := range prices {
+		if p.ServiceCode == "AmazonRDS" {
+			tally.normalizeFailed([]RawPrice{p, p}, errors.New("unknown unit"))
+			continue
+		}
+		normalized = append(normalized, p)
+	}
+	for _, p := range normalized {
+		tally.normalized([]NormalizedRate{skuRate(p)})
+	}
+
+	results := tally.Results()
+	var services []string
+	for _, r := range results {
+		services = append(services, r.Service)
+	}
+	if got := strings.Join(services, ","); got != "AWSLambda,AmazonEC2,AmazonRDS,AmazonS3" {
+		t.Fatalf("services = %s, want every service sorted", got)
+	}
+
+	want := []ServiceResult{
+		{Service: "AWSLambda", Error: "offer file unavailable"},
+		{Service: "AmazonEC2", Fetched: 1, Normalized: 1},
+		{Service: "AmazonRDS", Fetched: 1, Error: "unknown unit"},
+		{Service: "AmazonS3", Fetched: 1, Normalized: 1},
+	}
+	for i, w := range want {
+		if results[i] != w {
+			t.Errorf("result %d = %+v, want %+v", i, results[i], w)
+		}
+	}
+
+	run := &LifecycleResult{ServiceResults: results}
+	if got := strings.Join(run.FailedServices(), ","); got != "AWSLambda,AmazonRDS" {
+		t.Errorf("failed services = %s", got)
+	}
+	if newServiceTally().Results() != nil {
+		t.Error("an empty tally should have no results")
+	}
+}
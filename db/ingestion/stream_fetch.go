@@ -0,0 +1,147 @@
+// Package ingestion - Streaming fetch
+// A fetcher that returns a region as one []RawPrice holds the whole raw
+// catalog before the first price is normalized. StreamFetcher and
+// ServiceStreamFetcher emit prices as they are read instead, and
+// streamPrices feeds them straight into the normalizer's channel, so the
+// streaming lifecycle holds the prices in flight rather than the catalog.
+package ingestion
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// StreamFetcher is a PriceFetcher that emits a region's prices as it reads
+// them
+type StreamFetcher interface {
+	PriceFetcher
+
+	// StreamRegion fetches all prices for a region, passing each to emit
+	// as it is read (NO DB WRITES). It returns the first error emit
+	// returns.
+	StreamRegion(ctx context.Context, region string, emit func(RawPrice) error) error
+}
+
+// ServiceStreamFetcher is a ServiceFetcher that emits one service's prices
+// as it reads them
+type ServiceStreamFetcher interface {
+	ServiceFetcher
+
+	// StreamService fetches all prices of one service, passing each to
+	// emit as it is read (NO DB WRITES). It returns the first error emit
+	// returns.
+	StreamService(ctx context.Context, service, region string, emit func(RawPrice) error) error
+}
+
+// collectPrices gathers a stream into a slice, for the slice-returning
+// PriceFetcher methods of streaming fetchers
+func collectPrices(stream func(emit func(RawPrice) error) error) ([]RawPrice, error) {
+	var prices []RawPrice
+	err := stream(func(p RawPrice) error {
+		prices = append(prices, p)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return prices, nil
+}
+
+// streamPrices fetches a region into a channel as the fetcher reads it,
+// recording per-service results like fetchPrices. Streaming fetchers hold
+// only the prices in flight; others are fetched a service (or the region)
+// at a time, and each price is released once sent. sent, if not nil,
+// counts the prices sent. wait returns the fetch error and is only valid
+// once the channel has been drained.
+//
+// A service that fails before emitting anything is recorded and skipped,
+// as in fetchPrices. One that fails part way fails the fetch: the prices
+// it already emitted cannot be withdrawn, and a snapshot must not price a
+// service from part of its catalog.
+func streamPrices(ctx context.Context, fetcher PriceFetcher, region string, tally *serviceTally, sent *atomic.Int64) (prices <-chan RawPrice, wait func() error) {
+	out := make(chan RawPrice, streamBufferSize)
+	done := make(chan error, 1)
+
+	var emitted int
+	emit := func(p RawPrice) error {
+		select {
+		case out <- p:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		tally.fetched([]RawPrice{p})
+		emitted++
+		if sent != nil {
+			sent.Add(1)
+		}
+		return nil
+	}
+	emitAll := func(prices []RawPrice) error {
+		for i := range prices {
+			if err := emit(prices[i]); err != nil {
+				return err
+			}
+			prices[i] = RawPrice{}
+		}
+		return nil
+	}
+	fetchService := func(service string, fetch func() error) error {
+		tally.get(service)
+		before := emitted
+		err := fetch()
+		switch {
+		case err == nil:
+			return nil
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case emitted > before:
+			tally.failed(service, err)
+			return fmt.Errorf("service %s failed after %d prices: %w", service, emitted-before, err)
+		}
+		tally.failed(service, err)
+		return nil
+	}
+
+	go func() {
+		defer close(out)
+		switch f := fetcher.(type) {
+		case ServiceStreamFetcher:
+			for _, service := range f.Services() {
+				err := fetchService(service, func() error {
+					return f.StreamService(ctx, service, region, emit)
+				})
+				if err != nil {
+					done <- err
+					return
+				}
+			}
+			done <- nil
+		case ServiceFetcher:
+			for _, service := range f.Services() {
+				err := fetchService(service, func() error {
+					prices, err := f.FetchService(ctx, service, region)
+					if err != nil {
+						return err
+					}
+					return emitAll(prices)
+				})
+				if err != nil {
+					done <- err
+					return
+				}
+			}
+			done <- nil
+		case StreamFetcher:
+			done <- f.StreamRegion(ctx, region, emit)
+		default:
+			prices, err := fetcher.FetchRegion(ctx, region)
+			if err != nil {
+				done <- err
+				return
+			}
+			done <- emitAll(prices)
+		}
+	}()
+	return out, func() error { return <-done }
+}
@@ -0,0 +1,263 @@
+// Package ingestion - Streaming fetch tests
+package ingestion
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"terraform-cost/db"
+	"terraform-cost/internal/logging"
+)
+
+// scriptedStreamFetcher streams a fixed script of prices per service; a
+// service listed in failAfter fails once it has emitted that many prices.
+// Its slice-returning methods fail, so a test notices if they are used.
+type scriptedStreamFetcher struct {
+	services  []string
+	prices    map[string][]string
+	failAfter map[string]int
+}
+
+func (f *scriptedStreamFetcher) Cloud() db.CloudProvider { return db.AWS }
+
+func (f *scriptedStreamFetcher) FetchRegion(ctx context.Context, region string) ([]RawPrice, error) {
+	return nil, errors.New("FetchRegion called on a streaming fetcher")
+}
+
+func (f *scriptedStreamFetcher) SupportedRegions() []string { return []string{"us-east-1"} }
+
+func (f *scriptedStreamFetcher) SupportedServices() []string { return f.services }
+
+func (f *scriptedStreamFetcher) Services() []string { return f.services }
+
+func (f *scriptedStreamFetcher) FetchService(ctx context.Context, service, region string) ([]RawPrice, error) {
+	return nil, errors.New("FetchService called on a streaming fetcher")
+}
+
+func (f *scriptedStreamFetcher) StreamService(ctx context.Context, service, region string, emit func(RawPrice) error) error {
+	for i, sku := range f.prices[service] {
+		if n, ok := f.failAfter[service]; ok && i == n {
+			return errors.New("connection reset")
+		}
+		if err := emit(RawPrice{SKU: sku, ServiceCode: service, Region: region, Unit: "Hrs", PricePerUnit: "0.1"}); err != nil {
+			return err
+		}
+	}
+	if n, ok := f.failAfter[service]; ok && n >= len(f.prices[service]) {
+		return errors.New("connection reset")
+	}
+	return nil
+}
+
+// regionFetcher returns a region as one slice
+type regionFetcher struct {
+	prices []RawPrice
+}
+
+func (f *regionFetcher) Cloud() db.CloudProvider { return db.AWS }
+
+func (f *regionFetcher) FetchRegion(ctx context.Context, region string) ([]RawPrice, error) {
+	return f.prices, nil
+}
+
+func (f *regionFetcher) SupportedRegions() []string { return []string{"us-east-1"} }
+
+func (f *regionFetcher) SupportedServices() []string { return nil }
+
+func drainPrices(prices <-chan RawPrice) string {
+	var skus []string
+	for p := range prices {
+		skus = append(skus, p.SKU)
+	}
+	return strings.Join(skus, ",")
+}
+
+// TestStreamPrices proves a streaming fetcher's prices are passed on as
+// emitted, a service failing before its first price is recorded and
+// skipped, and one failing part way fails the fetch
+func TestStreamPrices(t *testing.T) {
+	fetcher := &scriptedStreamFetcher{
+		services:  []string{"AmazonEC2", "AmazonRDS", "AmazonS3"},
+		prices:    map[string][]string{"AmazonEC2": {"e1", "e2"}, "AmazonRDS": {"r1"}, "AmazonS3": {"s1"}},
+		failAfter: map[string]int{"AmazonRDS": 0},
+	}
+	tally := newServiceTally()
+	var sent atomic.Int64
+
+	prices, wait := streamPrices(context.Background(), fetcher, "us-east-1", tally, &sent)
+	if got := drainPrices(prices); got != "e1,e2,s1" {
+		t.Errorf("prices = %s", got)
+	}
+	if err := wait(); err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	if sent.Load() != 3 {
+		t.Errorf("sent = %d, want 3", sent.Load())
+	}
+	results := make(map[string]ServiceResult)
+	for _, r := range tally.Results() {
+		results[r.Service] = r
+	}
+	if r := results["AmazonEC2"]; r.Fetched != 2 || r.Failed() {
+		t.Errorf("AmazonEC2 = %+v, want 2 fetched", r)
+	}
+	if r := results["AmazonRDS"]; !r.Failed() || r.Fetched != 0 {
+		t.Errorf("AmazonRDS = %+v, want failed with nothing fetched", r)
+	}
+
+	fetcher.failAfter = map[string]int{"AmazonEC2": 1}
+	prices, wait = streamPrices(context.Background(), fetcher, "us-east-1", newServiceTally(), nil)
+	if got := drainPrices(prices); got != "e1" {
+		t.Errorf("prices = %s, want the fetch stopped at the failing service", got)
+	}
+	if err := wait(); err == nil || !strings.Contains(err.Error(), "service AmazonEC2 failed after 1 prices") {
+		t.Errorf("error = %v, want a part-way service failure", err)
+	}
+}
+
+// TestStreamPricesFromSlice proves a slice-returning fetcher's prices are
+// released as they are sent
+func TestStreamPricesFromSlice(t *testing.T) {
+	fetcher := &regionFetcher{prices: skuPrices("a", "b")}
+	prices, wait := streamPrices(context.Background(), fetcher, "us-east-1", newServiceTally(), nil)
+	if got := drainPrices(prices); got != "a,b" {
+		t.Errorf("prices = %s", got)
+	}
+	if err := wait(); err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range fetcher.prices {
+		if p.SKU != "" {
+			t.Errorf("price %d not released: %+v", i, p)
+		}
+	}
+}
+
+// offerFile is an AWS price list with a product in another region, a
+// Reserved term and a key the decoder does not know
+const offerFile = `{
+  "formatVersion": "v1.0",
+  "publicationDate": "2024-06-01T00:00:00Z",
+  "products": {
+    "SKU1": {"sku": "SKU1", "productFamily": "Compute Instance", "attributes": {"regionCode": "us-east-1", "instanceType": "t3.micro"}},
+    "SKU2": {"sku": "SKU2", "productFamily": "Storage", "attributes": {"regionCode": "us-east-1", "volumeType": "gp3"}},
+    "SKU3": {"sku": "SKU3", "productFamily": "Compute Instance", "attributes": {"regionCode": "eu-west-1", "instanceType": "t3.micro"}}
+  },
+  "terms": {
+    "Reserved": {"SKU1": {"SKU1.R": {"sku": "SKU1", "priceDimensions": {"SKU1.R.1": {"unit": "Hrs", "pricePerUnit": {"USD": "0.006"}}}}}},
+    "OnDemand": {
+      "SKU1": {"SKU1.T": {"sku": "SKU1", "effectiveDate": "2024-06-01T00:00:00Z", "priceDimensions": {
+        "SKU1.T.1": {"unit": "Hrs", "beginRange": "0", "endRange": "Inf", "pricePerUnit": {"USD": "0.0104"}}}}},
+      "SKU2": {"SKU2.T": {"sku": "SKU2", "priceDimensions": {
+        "SKU2.T.1": {"unit": "GB-Mo", "beginRange": "0", "endRange": "51200", "pricePerUnit": {"USD": "0.08"}}}}},
+      "SKU3": {"SKU3.T": {"sku": "SKU3", "priceDimensions": {
+        "SKU3.T.1": {"unit": "Hrs", "pricePerUnit": {"USD": "0.0114"}}}}}
+    }
+  },
+  "attributesList": {}
+}`
+
+func TestDecodePriceList(t *testing.T) {
+	var prices []RawPrice
+	err := decodePriceList(strings.NewReader(offerFile), "AmazonEC2", "us-east-1", func(p RawPrice) error {
+		prices = append(prices, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prices) != 2 {
+		t.Fatalf("got %d prices, want the 2 on-demand us-east-1 prices: %+v", len(prices), prices)
+	}
+
+	compute, storage := prices[0], prices[1]
+	if compute.SKU != "SKU1" || compute.PricePerUnit != "0.0104" || compute.Unit != "Hrs" || compute.ServiceCode != "AmazonEC2" ||
+		compute.Attributes["instanceType"] != "t3.micro" || compute.TierStart != nil || compute.TierEnd != nil || compute.EffectiveDate == nil {
+		t.Errorf("compute price = %+v", compute)
+	}
+	if storage.SKU != "SKU2" || storage.ProductFamily != "Storage" || storage.TierEnd == nil || *storage.TierEnd != 51200 {
+		t.Errorf("storage price = %+v", storage)
+	}
+
+	termsFirst := `{"terms": {"OnDemand": {}}, "products": {}}`
+	if err := decodePriceList(strings.NewReader(termsFirst), "AmazonEC2", "us-east-1", func(RawPrice) error { return nil }); err == nil {
+		t.Error("expected an error for terms before products")
+	}
+
+	stop := errors.New("stop")
+	if err := decodePriceList(strings.NewReader(offerFile), "AmazonEC2", "us-east-1", func(RawPrice) error { return stop }); !errors.Is(err, stop) {
+		t.Errorf("error = %v, want emit's error", err)
+	}
+}
+
+// TestAWSStreamService proves the offer file is decoded from the response
+// as it is read, and FetchService collects the same prices
+func TestAWSStreamService(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/offers/v1.0/aws/AmazonEC2/current/region_index.json":
+			w.Write([]byte(`{"publicationDate": "2024-06-01", "regions": {"us-east-1": {"currentVersionUrl": "/offers/ec2/us-east-1.json"}}}`))
+		case "/offers/ec2/us-east-1.json":
+			w.Write([]byte(offerFile))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	f := NewAWSPricingAPIFetcher()
+	f.baseURL = server.URL
+	f.services = []string{"AmazonEC2"}
+
+	var streamed []string
+	if err := f.StreamService(context.Background(), "AmazonEC2", "us-east-1", func(p RawPrice) error {
+		streamed = append(streamed, p.SKU)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(streamed, ",") != "SKU1,SKU2" {
+		t.Errorf("streamed %v", streamed)
+	}
+
+	prices, err := f.FetchService(context.Background(), "AmazonEC2", "us-east-1")
+	if err != nil || len(prices) != 2 {
+		t.Errorf("FetchService = %d prices, %v; want 2", len(prices), err)
+	}
+	if err := f.StreamService(context.Background(), "AmazonEC2", "ap-south-1", func(RawPrice) error { return nil }); err == nil {
+		t.Error("expected an error for a region missing from the index")
+	}
+}
+
+// TestStreamingLifecycleStreamsFetch proves the streaming lifecycle
+// normalizes a streaming fetcher's prices without its slice methods
+func TestStreamingLifecycleStreamsFetch(t *testing.T) {
+	fetcher := &scriptedStreamFetcher{
+		services: []string{"TestService"},
+		prices:   map[string][]string{"TestService": {"a", "b", "c"}},
+	}
+	config := DefaultStreamingConfig()
+	config.WorkDir = t.TempDir()
+	config.EnableCheckpointing = false
+	lifecycle := NewStreamingLifecycle(fetcher, &streamingNormalizer{}, nil, config)
+	lifecycle.SetLogger(logging.Nop())
+
+	result, err := lifecycle.Execute(context.Background(), &LifecycleConfig{
+		Provider:  db.AWS,
+		Region:    "us-east-1",
+		Alias:     "default",
+		BackupDir: t.TempDir(),
+		DryRun:    true,
+	})
+	if err != nil {
+		t.Fatalf("ingestion failed: %v", err)
+	}
+	if result.RawCount != 3 || result.NormalizedCount != 3 {
+		t.Errorf("raw = %d, normalized = %d; want 3 and 3", result.RawCount, result.NormalizedCount)
+	}
+}
@@ -0,0 +1,149 @@
+// Package ingestion - Streaming normalization
+// PriceNormalizer.Normalize takes the whole catalog as a slice and returns
+// another slice, so the raw and normalized catalogs are both in memory
+// at the peak. A StreamNormalizer consumes prices from a channel and emits
+// rates as it goes: the normalizer itself holds one price (or one batch,
+// for wrapped batch normalizers) plus the channel buffers, and the caller
+// decides what to retain. The lifecycles release each raw price once it
+// has been sent, so the raw catalog shrinks as normalized rates accumulate.
+package ingestion
+
+import "sync/atomic"
+
+// StreamNormalizer normalizes prices as they arrive
+type StreamNormalizer interface {
+	// StreamNormalize reads prices until in is closed and emits normalized
+	// rates, closing the returned channel when done. The caller must drain
+	// the returned channel, or the normalizer blocks.
+	StreamNormalize(in <-chan RawPrice) <-chan NormalizedRate
+}
+
+// streamBufferSize is the buffer of channels between the lifecycle and
+// the normalizer
+const streamBufferSize = 256
+
+// defaultStreamBatchSize is the batch size used to wrap batch-only
+// normalizers
+const defaultStreamBatchSize = 1000
+
+// BatchNormalizeError is a batch a wrapped batch normalizer failed on
+type BatchNormalizeError struct {
+	Prices []RawPrice
+	Err    error
+}
+
+func (e *BatchNormalizeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *BatchNormalizeError) Unwrap() error {
+	return e.Err
+}
+
+// BatchStreamNormalizer adapts a batch PriceNormalizer to StreamNormalizer
+// by normalizing batchSize prices at a time, so peak memory is one batch
+// rather than the catalog. A failed batch is skipped and reported by
+// Failures.
+type BatchStreamNormalizer struct {
+	normalizer PriceNormalizer
+	batchSize  int
+	failures   []*BatchNormalizeError
+}
+
+// NewBatchStreamNormalizer wraps a batch normalizer
+// (batchSize <= 0 = defaultStreamBatchSize)
+func NewBatchStreamNormalizer(normalizer PriceNormalizer, batchSize int) *BatchStreamNormalizer {
+	if batchSize <= 0 {
+		batchSize = defaultStreamBatchSize
+	}
+	return &BatchStreamNormalizer{normalizer: normalizer, batchSize: batchSize}
+}
+
+// StreamNormalize implements StreamNormalizer
+func (b *BatchStreamNormalizer) StreamNormalize(in <-chan RawPrice) <-chan NormalizedRate {
+	out := make(chan NormalizedRate, streamBufferSize)
+	go func() {
+		defer close(out)
+		batch := make([]RawPrice, 0, b.batchSize)
+		flush := func() {
+			rates, err := b.normalizer.Normalize(batch)
+			if err != nil {
+				b.failures = append(b.failures, &BatchNormalizeError{Prices: batch, Err: err})
+				batch = make([]RawPrice, 0, b.batchSize)
+				return
+			}
+			for _, r := range rates {
+				out <- r
+			}
+			batch = batch[:0]
+		}
+		for p := range in {
+			batch = append(batch, p)
+			if len(batch) == b.batchSize {
+				flush()
+			}
+		}
+		if len(batch) > 0 {
+			flush()
+		}
+	}()
+	return out
+}
+
+// Failures returns the batches that failed to normalize. It is only valid
+// once the output channel has been drained.
+func (b *BatchStreamNormalizer) Failures() []*BatchNormalizeError {
+	return b.failures
+}
+
+// AsStreamNormalizer returns n itself when it streams, or wraps its batch
+// Normalize in a BatchStreamNormalizer
+func AsStreamNormalizer(n PriceNormalizer, batchSize int) StreamNormalizer {
+	if s, ok := n.(StreamNormalizer); ok {
+		return s
+	}
+	return NewBatchStreamNormalizer(n, batchSize)
+}
+
+// normalizeStream streams prices through n. failures reports batches a
+// wrapped batch normalizer failed on, once the output has been drained.
+func normalizeStream(n PriceNormalizer, in <-chan RawPrice, batchSize int) (rates <-chan NormalizedRate, failures func() []*BatchNormalizeError) {
+	s := AsStreamNormalizer(n, batchSize)
+	if b, ok := s.(*BatchStreamNormalizer); ok {
+		return b.StreamNormalize(in), b.Failures
+	}
+	return s.StreamNormalize(in), func() []*BatchNormalizeError { return nil }
+}
+
+// sendPrices feeds prices into a channel, zeroing each slice entry once
+// sent so the memory it references can be reclaimed while the rest of the
+// catalog is normalized. sent, if not nil, counts the prices sent.
+func sendPrices(prices []RawPrice, sent *atomic.Int64) <-chan RawPrice {
+	in := make(chan RawPrice, streamBufferSize)
+	go func() {
+		defer close(in)
+		for i := range prices {
+			in <- prices[i]
+			prices[i] = RawPrice{}
+			if sent != nil {
+				sent.Add(1)
+			}
+		}
+	}()
+	return in
+}
+
+// streamEach applies a per-price normalization to a stream, dropping
+// prices for which ok is false
+func streamEach(in <-chan RawPrice, normalize func(RawPrice) (NormalizedRate, bool)) <-chan NormalizedRate {
+	out := make(chan NormalizedRate, streamBufferSize)
+	go func() {
+		defer close(out)
+		for p := range in {
+			if r, ok := normalize(p); ok {
+				out <- r
+			}
+		}
+	}()
+	return out
+}
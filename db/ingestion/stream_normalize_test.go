@@ -0,0 +1,144 @@
+// Package ingestion - Streaming normalization tests
+package ingestion
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db"
+)
+
+// skuNormalizer normalizes each price to a rate keyed by its SKU, failing
+// any batch with a price whose SKU is "bad"
+type skuNormalizer struct {
+	batches []int
+}
+
+func (n *skuNormalizer) Cloud() db.CloudProvider { return db.AWS }
+
+func (n *skuNormalizer) Normalize(raw []RawPrice) ([]NormalizedRate, error) {
+	n.batches = append(n.batches, len(raw))
+	rates := make([]NormalizedRate, 0, len(raw))
+	for _, p := range raw {
+		if p.SKU == "bad" {
+			return nil, errors.New("unparseable price")
+		}
+		rates = append(rates, skuRate(p))
+	}
+	return rates, nil
+}
+
+func skuRate(p RawPrice) NormalizedRate {
+	return NormalizedRate{
+		RateKey:  db.RateKey{Cloud: db.AWS, Service: p.ServiceCode, Region: "us-east-1", Attributes: map[string]string{"sku": p.SKU}},
+		Unit:     "Hrs",
+		Price:    decimal.RequireFromString("0.1"),
+		Currency: "USD",
+	}
+}
+
+// streamingNormalizer is a normalizer that streams itself
+type streamingNormalizer struct{ skuNormalizer }
+
+func (n *streamingNormalizer) StreamNormalize(in <-chan RawPrice) <-chan NormalizedRate {
+	return streamEach(in, func(p RawPrice) (NormalizedRate, bool) { return skuRate(p), true })
+}
+
+func skuPrices(skus ...string) []RawPrice {
+	prices := make([]RawPrice, len(skus))
+	for i, sku := range skus {
+		prices[i] = RawPrice{SKU: sku, ServiceCode: "AmazonEC2", Region: "us-east-1", PricePerUnit: "0.1"}
+	}
+	return prices
+}
+
+func drainSKUs(rates <-chan NormalizedRate) string {
+	var skus []string
+	for r := range rates {
+		skus = append(skus, r.RateKey.Attributes["sku"])
+	}
+	return strings.Join(skus, ",")
+}
+
+// TestBatchStreamNormalizer proves a batch normalizer is fed batchSize
+// prices at a time, in order, and a failed batch is skipped and reported
+func TestBatchStreamNormalizer(t *testing.T) {
+	inner := &skuNormalizer{}
+	b := NewBatchStreamNormalizer(inner, 2)
+
+	got := drainSKUs(b.StreamNormalize(sendPrices(skuPrices("a", "b", "bad", "c", "d"), nil)))
+	if got != "a,b,d" {
+		t.Errorf("rates = %s, want the batches without the failed one", got)
+	}
+	if sizes := inner.batches; len(sizes) != 3 || sizes[0] != 2 || sizes[1] != 2 || sizes[2] != 1 {
+		t.Errorf("batch sizes = %v, want [2 2 1]", sizes)
+	}
+
+	failures := b.Failures()
+	if len(failures) != 1 {
+		t.Fatalf("failures = %d, want 1", len(failures))
+	}
+	if f := failures[0]; len(f.Prices) != 2 || f.Prices[0].SKU != "bad" || f.Prices[1].SKU != "c" {
+		t.Errorf("failed batch = %+v, want [bad c]", f.Prices)
+	}
+	if !strings.Contains(failures[0].Error(), "unparseable price") {
+		t.Errorf("failure = %v", failures[0])
+	}
+
+	if d := NewBatchStreamNormalizer(inner, 0); d.batchSize != defaultStreamBatchSize {
+		t.Errorf("default batch size = %d, want %d", d.batchSize, defaultStreamBatchSize)
+	}
+}
+
+func TestAsStreamNormalizer(t *testing.T) {
+	streaming := &streamingNormalizer{}
+	if s := AsStreamNormalizer(streaming, 10); s != StreamNormalizer(streaming) {
+		t.Errorf("a streaming normalizer was wrapped: %T", s)
+	}
+	if _, ok := AsStreamNormalizer(&skuNormalizer{}, 10).(*BatchStreamNormalizer); !ok {
+		t.Error("a batch normalizer was not wrapped")
+	}
+
+	rates, failures := normalizeStream(streaming, sendPrices(skuPrices("a", "b"), nil), 10)
+	if got := drainSKUs(rates); got != "a,b" {
+		t.Errorf("rates = %s", got)
+	}
+	if f := failures(); f != nil {
+		t.Errorf("failures = %v, want none from a streaming normalizer", f)
+	}
+}
+
+// TestSendPrices proves each price is sent in order and released once sent
+func TestSendPrices(t *testing.T) {
+	prices := skuPrices("a", "b", "c")
+	var sent atomic.Int64
+
+	var got []string
+	for p := range sendPrices(prices, &sent) {
+		got = append(got, p.SKU)
+	}
+	if strings.Join(got, ",") != "a,b,c" {
+		t.Errorf("sent %v", got)
+	}
+	if sent.Load() != 3 {
+		t.Errorf("sent count = %d, want 3", sent.Load())
+	}
+	for i, p := range prices {
+		if p.SKU != "" || p.ServiceCode != "" {
+			t.Errorf("price %d not released: %+v", i, p)
+		}
+	}
+}
+
+func TestStreamEach(t *testing.T) {
+	rates := streamEach(sendPrices(skuPrices("a", "skip", "b"), nil), func(p RawPrice) (NormalizedRate, bool) {
+		return skuRate(p), p.SKU != "skip"
+	})
+	if got := drainSKUs(rates); got != "a,b" {
+		t.Errorf("rates = %s, want the dropped price left out", got)
+	}
+}
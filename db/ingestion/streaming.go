@@ -12,9 +12,11 @@ import (
 	"path/filepath"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"terraform-cost/db"
+	"terraform-cost/internal/logging"
 
 	"github.com/google/uuid"
 )
@@ -45,6 +47,11 @@ type StreamingConfig struct {
 	// GCInterval is how often to force garbage collection (in batches)
 	// Default: 5 (every 5 batches)
 	GCInterval int
+
+	// CommitBatchSize is the number of rates per multi-row INSERT when
+	// committing the snapshot. All batches share one transaction.
+	// Default: db.DefaultCommitBatchSize
+	CommitBatchSize int
 }
 
 // DefaultStreamingConfig returns configuration safe for 4GB RAM servers
@@ -56,6 +63,7 @@ func DefaultStreamingConfig() *StreamingConfig {
 		ConcurrentFetches:   2,
 		EnableCheckpointing: true,
 		GCInterval:          5,
+		CommitBatchSize:     db.DefaultCommitBatchSize,
 	}
 }
 
@@ -68,6 +76,7 @@ func LowMemoryConfig() *StreamingConfig {
 		ConcurrentFetches:   1,
 		EnableCheckpointing: true,
 		GCInterval:          3,
+		CommitBatchSize:     db.DefaultCommitBatchSize / 2,
 	}
 }
 
@@ -80,6 +89,7 @@ func HighMemoryConfig() *StreamingConfig {
 		ConcurrentFetches:   4,
 		EnableCheckpointing: true,
 		GCInterval:          10,
+		CommitBatchSize:     db.DefaultCommitBatchSize * 5,
 	}
 }
 
@@ -91,12 +101,22 @@ type StreamingLifecycle struct {
 	fetcher     PriceFetcher
 	normalizer  PriceNormalizer
 	store       db.PricingStore
+	logger      logging.LeveledLogger
+	progress    ProgressReporter
 	
 	// Progress tracking
 	totalFetched    int
 	totalNormalized int
 	totalWritten    int
 	batchCount      int
+	lostCoverage    []db.ServiceFamily
+
+	// Incremental mode
+	serviceVersions map[string]string
+	reusedCount     int
+	skippedServices []string
+	unchanged       *db.PricingSnapshot
+	services        *serviceTally
 	
 	// Temporary storage
 	tempFiles   []string
@@ -129,10 +149,20 @@ func NewStreamingLifecycle(
 		fetcher:    fetcher,
 		normalizer: normalizer,
 		store:      store,
+		logger:     logging.Default(),
+	}
+}
+
+// SetLogger sets the logger used for progress reporting
+func (s *StreamingLifecycle) SetLogger(logger logging.LeveledLogger) {
+	if logger == nil {
+		logger = logging.Nop()
 	}
+	s.logger = logger
 }
 
-// Execute runs the streaming ingestion pipeline
+// Execute runs the streaming ingestion pipeline.
+// Like Lifecycle.Execute, failures return both the result and a typed error.
 func (s *StreamingLifecycle) Execute(ctx context.Context, config *LifecycleConfig) (*LifecycleResult, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -141,6 +171,8 @@ func (s *StreamingLifecycle) Execute(ctx context.Context, config *LifecycleConfi
 		config = DefaultLifecycleConfig()
 	}
 	s.lcConfig = config
+	s.serviceVersions, s.reusedCount, s.skippedServices, s.unchanged = nil, 0, nil, nil
+	s.services = newServiceTally()
 
 	startTime := time.Now()
 	s.logProgress("STARTING", "Initializing streaming ingestion lifecycle...")
@@ -168,6 +200,20 @@ func (s *StreamingLifecycle) Execute(ctx context.Context, config *LifecycleConfi
 		s.cleanup()
 		return s.fail(err, startTime)
 	}
+	if s.unchanged != nil {
+		s.cleanup()
+		s.deleteCheckpoint()
+		s.logProgress("UNCHANGED", fmt.Sprintf("No service changed since snapshot %s", s.unchanged.ID))
+		return &LifecycleResult{
+			Success:         true,
+			Phase:           PhaseActive,
+			Message:         fmt.Sprintf("no service changed since snapshot %s", s.unchanged.ID),
+			Duration:        time.Since(startTime),
+			SnapshotID:      &s.unchanged.ID,
+			SkippedServices: s.skippedServices,
+			ServiceResults:  s.services.Results(),
+		}, nil
+	}
 	s.logPhaseComplete(1, 4, "FETCH & NORMALIZE", fmt.Sprintf("Fetched %d raw prices", s.totalFetched))
 
 	// Phase 2: Merge and validate
@@ -175,7 +221,7 @@ func (s *StreamingLifecycle) Execute(ctx context.Context, config *LifecycleConfi
 	allRates, err := s.mergeAndValidate(ctx)
 	if err != nil {
 		s.cleanup()
-		return s.fail(err, startTime)
+		return s.fail(&ValidationError{Phase: PhaseValidating, Cause: err}, startTime)
 	}
 	s.logPhaseComplete(2, 4, "MERGE & VALIDATE", fmt.Sprintf("Validated %d normalized rates", len(allRates)))
 
@@ -184,7 +230,7 @@ func (s *StreamingLifecycle) Execute(ctx context.Context, config *LifecycleConfi
 	backupPath, err := s.writeBackup(allRates)
 	if err != nil {
 		s.cleanup()
-		return s.fail(fmt.Errorf("backup failed: %w", err), startTime)
+		return s.fail(&BackupError{Phase: PhaseBackedUp, Path: s.lcConfig.BackupDir, Cause: fmt.Errorf("backup failed: %w", err)}, startTime)
 	}
 	s.logPhaseComplete(3, 4, "BACKUP", fmt.Sprintf("Backup saved to %s", backupPath))
 
@@ -195,7 +241,7 @@ func (s *StreamingLifecycle) Execute(ctx context.Context, config *LifecycleConfi
 		sid, err := s.streamCommit(ctx, allRates)
 		if err != nil {
 			s.cleanup()
-			return s.fail(fmt.Errorf("commit failed: %w", err), startTime)
+			return s.fail(commitFailure(fmt.Errorf("commit failed: %w", err)), startTime)
 		}
 		snapshotID = &sid
 		s.logPhaseComplete(4, 4, "COMMIT", fmt.Sprintf("Snapshot %s activated", sid))
@@ -219,101 +265,134 @@ func (s *StreamingLifecycle) Execute(ctx context.Context, config *LifecycleConfi
 		ContentHash:     calculateHash(allRates),
 		RawCount:        s.totalFetched,
 		NormalizedCount: len(allRates),
+		LostCoverage:    serviceFamilyNames(s.lostCoverage),
+		ReusedCount:     s.reusedCount,
+		SkippedServices: s.skippedServices,
+		ServiceResults:  s.services.Results(),
 	}, nil
 }
 
-// streamFetchAndNormalize fetches pricing in batches and writes to temp files
+// streamFetchAndNormalize streams fetched prices through the normalizer and
+// writes the rates to a temp file. A full fetch is normalized as the
+// fetcher reads it; an incremental one fetches only the changed services
+// first.
 func (s *StreamingLifecycle) streamFetchAndNormalize(ctx context.Context) error {
 
-	s.logProgress("FETCHING", "Fetching all pricing data from cloud API...")
-	
-	// Fetch ALL prices once (not per-service to avoid duplication)
-	rawPrices, err := s.fetcher.FetchRegion(ctx, s.lcConfig.Region)
-	if err != nil {
-		return fmt.Errorf("failed to fetch pricing: %w", err)
+	var sent atomic.Int64
+	var rawPrices <-chan RawPrice
+	fetchErr := func() error { return nil }
+	totalPrices := 0 // unknown until a streamed fetch ends
+	var reused []NormalizedRate
+	if inc, ok := s.fetcher.(IncrementalFetcher); ok && s.lcConfig.Incremental {
+		s.logProgress("FETCHING", "Comparing service versions with the active snapshot...")
+		fetched, err := fetchIncremental(ctx, inc, s.store, s.lcConfig, s.services, s.logger)
+		if err != nil {
+			return &FetchError{Phase: PhaseFetching, Cause: fmt.Errorf("failed to fetch pricing: %w", err)}
+		}
+		s.skippedServices = fetched.Skipped
+		if fetched.Unchanged() {
+			s.unchanged = fetched.Active
+			return nil
+		}
+		totalPrices, reused = len(fetched.Fetched), fetched.Reused
+		rawPrices = sendPrices(fetched.Fetched, &sent)
+		s.serviceVersions = fetched.Versions
+		s.logProgress("INCREMENTAL", fmt.Sprintf("%d services changed, %d unchanged (%d rates reused)",
+			len(fetched.Changed), len(fetched.Skipped), len(reused)))
+	} else {
+		s.logProgress("FETCHING", "Streaming all pricing data from cloud API...")
+		s.serviceVersions = recordServiceVersions(ctx, s.fetcher, s.lcConfig.Region)
+
+		// Fetch ALL prices once (not per-service to avoid duplication),
+		// normalizing them as they are read
+		rawPrices, fetchErr = streamPrices(ctx, s.fetcher, s.lcConfig.Region, s.services, &sent)
 	}
 	
-	totalPrices := len(rawPrices)
-	s.logProgress("FETCHED", fmt.Sprintf("Retrieved %d raw prices", totalPrices))
-	
 	// Create temp file for normalized rates
 	tempFile := filepath.Join(s.config.WorkDir, fmt.Sprintf("pricing_%s_%s_%d.jsonl.gz",
 		s.lcConfig.Provider, s.lcConfig.Region, time.Now().UnixNano()))
 
 	f, err := os.Create(tempFile)
 	if err != nil {
-		return fmt.Errorf("failed to create temp file: %w", err)
+		return &NormalizeError{Phase: PhaseNormalizing, Cause: fmt.Errorf("failed to create temp file: %w", err)}
 	}
 	defer f.Close()
+	s.tempFiles = append(s.tempFiles, tempFile)
 
 	gzw := gzip.NewWriter(f)
 	defer gzw.Close()
 
 	writer := bufio.NewWriter(gzw)
 	
-	s.logProgress("NORMALIZING", fmt.Sprintf("Processing %d prices in batches of %d...", totalPrices, s.config.BatchSize))
-
-	// Process in batches to control memory
-	batchNum := 0
-	for i := 0; i < len(rawPrices); i += s.config.BatchSize {
-		end := i + s.config.BatchSize
-		if end > len(rawPrices) {
-			end = len(rawPrices)
-		}
+	s.logProgress("NORMALIZING", fmt.Sprintf("Processing prices in batches of %d...", s.config.BatchSize))
 
-		batch := rawPrices[i:end]
+	// Stream prices through the normalizer and write each rate as it
+	// arrives; no raw price is kept once sent
+	rates, failures := normalizeStream(s.normalizer, rawPrices, s.config.BatchSize)
+	written := 0
+	s.reportProgress(ProgressPhaseNormalizing, 0, totalPrices, "normalizing prices")
+	for rate := range rates {
+		s.services.normalized([]NormalizedRate{rate})
 
-		// Normalize batch
-		normalized, err := s.normalizer.Normalize(batch)
+		// Write to temp file (JSON Lines format)
+		data, err := json.Marshal(rate)
 		if err != nil {
-			s.logProgress("WARNING", fmt.Sprintf("Batch %d normalization error: %v", batchNum, err))
 			continue
 		}
+		writer.Write(data)
+		writer.WriteString("\n")
+		s.totalNormalized++
+		written++
+
+		if written%s.config.BatchSize == 0 {
+			done := int(sent.Load())
+			if totalPrices > 0 {
+				progress := float64(done) / float64(totalPrices) * 100
+				s.logProgress("PROCESSING", fmt.Sprintf("%s %d/%d prices (%.1f%%)", s.progressBar(progress), done, totalPrices, progress))
+			} else {
+				s.logProgress("PROCESSING", fmt.Sprintf("%d prices fetched", done))
+			}
+			s.reportProgress(ProgressPhaseNormalizing, done, totalPrices, fmt.Sprintf("%d rates normalized", written))
 
-		// Write to temp file (JSON Lines format)
-		for _, rate := range normalized {
-			data, err := json.Marshal(rate)
-			if err != nil {
-				continue
+			// Memory management - flush and GC
+			if (written/s.config.BatchSize)%s.config.GCInterval == 0 {
+				writer.Flush()
+				s.checkMemoryAndGC()
 			}
-			writer.Write(data)
-			writer.WriteString("\n")
-			s.totalNormalized++
 		}
+	}
+	if err := fetchErr(); err != nil {
+		return &FetchError{Phase: PhaseFetching, Cause: fmt.Errorf("failed to fetch pricing: %w", err)}
+	}
+	totalPrices = int(sent.Load())
+	s.totalFetched = totalPrices
+	s.logProgress("FETCHED", fmt.Sprintf("Retrieved %d raw prices", totalPrices))
+	s.reportProgress(ProgressPhaseNormalizing, totalPrices, totalPrices, fmt.Sprintf("%d rates normalized", written))
+	for _, f := range failures() {
+		s.logProgress("WARNING", fmt.Sprintf("Skipped %d prices: normalization error: %v", len(f.Prices), f.Err))
+		s.services.normalizeFailed(f.Prices, f.Err)
+	}
 
-		s.totalFetched += len(batch)
-		batchNum++
-
-		// Progress update
-		progress := float64(i+len(batch)) / float64(totalPrices) * 100
-		s.logProgress("PROCESSING", fmt.Sprintf("%s %d/%d prices (%.1f%%)", s.progressBar(progress), i+len(batch), totalPrices, progress))
-
-		// Memory management - flush and GC
-		if batchNum%s.config.GCInterval == 0 {
-			writer.Flush()
-			s.checkMemoryAndGC()
+	// Rates carried over from the active snapshot are already normalized
+	for _, rate := range reused {
+		data, err := json.Marshal(rate)
+		if err != nil {
+			continue
 		}
+		writer.Write(data)
+		writer.WriteString("\n")
+		s.totalNormalized++
 	}
+	s.reusedCount = len(reused)
 
 	writer.Flush()
-	
-	// Clear raw prices from memory
-	rawPrices = nil
 	runtime.GC()
 	
-	s.tempFiles = append(s.tempFiles, tempFile)
 	s.logProgress("NORMALIZED", fmt.Sprintf("Written %d normalized rates to temp file", s.totalNormalized))
 
 	return nil
 }
 
-// fetchServicePricing fetches pricing for a service (uses streaming internally)
-func (s *StreamingLifecycle) fetchServicePricing(ctx context.Context, service string) ([]RawPrice, error) {
-	// For now, delegate to fetcher
-	// In future, implement streaming JSON parsing for very large responses
-	return s.fetcher.FetchRegion(ctx, s.lcConfig.Region)
-}
-
 // mergeAndValidate reads temp files and validates
 func (s *StreamingLifecycle) mergeAndValidate(ctx context.Context) ([]NormalizedRate, error) {
 	var allRates []NormalizedRate
@@ -377,8 +456,6 @@ func (s *StreamingLifecycle) readTempFile(path string) ([]NormalizedRate, error)
 
 // writeBackup writes the final backup
 func (s *StreamingLifecycle) writeBackup(rates []NormalizedRate) (string, error) {
-	fmt.Println("\nPhase 3: Write backup...")
-
 	backup := &SnapshotBackup{
 		Provider:      s.lcConfig.Provider,
 		Region:        s.lcConfig.Region,
@@ -396,7 +473,7 @@ func (s *StreamingLifecycle) writeBackup(rates []NormalizedRate) (string, error)
 		return "", err
 	}
 
-	fmt.Printf("  ✓ Backup written: %s\n", path)
+	s.logger.Info("backup written", logging.String("path", path), logging.Int("rates", len(rates)))
 	return path, nil
 }
 
@@ -405,6 +482,17 @@ func (s *StreamingLifecycle) streamCommit(ctx context.Context, rates []Normalize
 	totalRates := len(rates)
 	s.logProgress("COMMIT", fmt.Sprintf("Starting database commit of %d rates...", totalRates))
 
+	lost, err := checkCoverageRegression(ctx, s.store, s.lcConfig, rates)
+	s.lostCoverage = lost
+	if err != nil {
+		return uuid.Nil, &ValidationError{Phase: PhaseCommitting, Cause: err}
+	}
+	if len(lost) > 0 {
+		s.logger.Warn("coverage regression accepted with force",
+			logging.Int("lost_families", len(lost)),
+			logging.Strings("lost", serviceFamilyNames(lost)))
+	}
+
 	snapshotID := uuid.New()
 	snapshot := &db.PricingSnapshot{
 		ID:            snapshotID,
@@ -417,6 +505,7 @@ func (s *StreamingLifecycle) streamCommit(ctx context.Context, rates []Normalize
 		Hash:          calculateHash(rates),
 		Version:       "1.0",
 		IsActive:      false,
+		ServiceVersions: s.serviceVersions,
 	}
 
 	tx, err := s.store.BeginTx(ctx)
@@ -435,25 +524,34 @@ func (s *StreamingLifecycle) streamCommit(ctx context.Context, rates []Normalize
 		return uuid.Nil, err
 	}
 
-	// Commit in batches
-	batchSize := s.config.BatchSize
+	// Commit in multi-row batches
+	batchSize := s.config.CommitBatchSize
+	if batchSize <= 0 {
+		batchSize = db.DefaultCommitBatchSize
+	}
+	s.reportProgress(ProgressPhaseWriting, 0, len(rates), "writing rates")
 	for i := 0; i < len(rates); i += batchSize {
 		end := i + batchSize
 		if end > len(rates) {
 			end = len(rates)
 		}
 
-		for _, nr := range rates[i:end] {
-			nr.RateKey.ID = uuid.New()
-			key, err := tx.UpsertRateKey(ctx, &nr.RateKey)
-			if err != nil {
-				return uuid.Nil, err
-			}
+		keys := make([]*db.RateKey, end-i)
+		for j := range rates[i:end] {
+			key := rates[i+j].RateKey
+			key.ID = uuid.New()
+			keys[j] = &key
+		}
+		if err = tx.BulkUpsertRateKeys(ctx, keys); err != nil {
+			return uuid.Nil, err
+		}
 
-			rate := &db.PricingRate{
+		batch := make([]*db.PricingRate, end-i)
+		for j, nr := range rates[i:end] {
+			batch[j] = &db.PricingRate{
 				ID:         uuid.New(),
 				SnapshotID: snapshotID,
-				RateKeyID:  key.ID,
+				RateKeyID:  keys[j].ID,
 				Unit:       nr.Unit,
 				Price:      nr.Price,
 				Currency:   nr.Currency,
@@ -461,14 +559,15 @@ func (s *StreamingLifecycle) streamCommit(ctx context.Context, rates []Normalize
 				TierMin:    nr.TierMin,
 				TierMax:    nr.TierMax,
 			}
-			if err = tx.CreateRate(ctx, rate); err != nil {
-				return uuid.Nil, err
-			}
+		}
+		if err = tx.BulkCreateRates(ctx, batch); err != nil {
+			return uuid.Nil, err
 		}
 
 		s.totalWritten += (end - i)
 		progress := float64(s.totalWritten) / float64(len(rates)) * 100
 		s.logProgress("WRITING", fmt.Sprintf("%s %d/%d rates (%.1f%%)", s.progressBar(progress), s.totalWritten, len(rates), progress))
+		s.reportProgress(ProgressPhaseWriting, end, len(rates), "writing rates")
 
 		// GC between batches
 		if (i/batchSize)%s.config.GCInterval == 0 {
@@ -485,7 +584,7 @@ func (s *StreamingLifecycle) streamCommit(ctx context.Context, rates []Normalize
 	}
 	committed = true
 
-	fmt.Printf("  ✓ Snapshot activated: %s\n", snapshotID)
+	s.logger.Info("snapshot activated", logging.String("snapshot_id", snapshotID.String()))
 	return snapshotID, nil
 }
 
@@ -501,25 +600,43 @@ func (s *StreamingLifecycle) checkMemoryAndGC() {
 	}
 }
 
-// logProgress prints a timestamped progress message
+// logProgress emits a progress message tagged with its stage
 func (s *StreamingLifecycle) logProgress(stage, message string) {
-	timestamp := time.Now().Format("15:04:05")
-	fmt.Printf("[%s] %-12s │ %s\n", timestamp, stage, message)
+	fields := []logging.Field{logging.String("stage", stage)}
+	if s.lcConfig != nil {
+		fields = append(fields,
+			logging.String("provider", string(s.lcConfig.Provider)),
+			logging.String("region", s.lcConfig.Region),
+		)
+	}
+
+	if stage == "WARNING" {
+		s.logger.Warn(message, fields...)
+		return
+	}
+	s.logger.Info(message, fields...)
 }
 
-// logPhaseStart prints a phase start banner
+// logPhaseStart emits a phase start event
 func (s *StreamingLifecycle) logPhaseStart(current, total int, name, description string) {
-	timestamp := time.Now().Format("15:04:05")
-	fmt.Printf("\n[%s] ══════════════════════════════════════════════════════════\n", timestamp)
-	fmt.Printf("[%s] PHASE %d/%d: %s\n", timestamp, current, total, name)
-	fmt.Printf("[%s] %s\n", timestamp, description)
-	fmt.Printf("[%s] ══════════════════════════════════════════════════════════\n", timestamp)
+	s.reportProgress(name, current-1, total, description)
+	s.logger.Info(description,
+		logging.String("event", "phase_start"),
+		logging.String("phase", name),
+		logging.Int("phase_index", current),
+		logging.Int("phase_total", total),
+	)
 }
 
-// logPhaseComplete prints a phase completion message
+// logPhaseComplete emits a phase completion event
 func (s *StreamingLifecycle) logPhaseComplete(current, total int, name, result string) {
-	timestamp := time.Now().Format("15:04:05")
-	fmt.Printf("[%s] ✓ PHASE %d/%d COMPLETE: %s\n", timestamp, current, total, result)
+	s.reportProgress(name, current, total, result)
+	s.logger.Info(result,
+		logging.String("event", "phase_complete"),
+		logging.String("phase", name),
+		logging.Int("phase_index", current),
+		logging.Int("phase_total", total),
+	)
 }
 
 // progressBar generates a progress bar string
@@ -614,5 +731,7 @@ func (s *StreamingLifecycle) fail(err error, startTime time.Time) (*LifecycleRes
 		Duration:        time.Since(startTime),
 		RawCount:        s.totalFetched,
 		NormalizedCount: s.totalNormalized,
-	}, nil
+		LostCoverage:    serviceFamilyNames(s.lostCoverage),
+		ServiceResults:  s.services.Results(),
+	}, err
 }
@@ -0,0 +1,95 @@
+// Package ingestion - Integrity verification tests
+package ingestion
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"terraform-cost/db"
+
+	"github.com/shopspring/decimal"
+)
+
+// tieredRates are two tiers of one rate key plus a second key
+func tieredRates() []NormalizedRate {
+	key := db.RateKey{Cloud: db.AWS, Service: "AmazonS3", ProductFamily: "Storage", Region: "us-east-1", Attributes: map[string]string{"storageClass": "STANDARD"}}
+	other := db.RateKey{Cloud: db.AWS, Service: "AmazonS3", ProductFamily: "Storage", Region: "us-east-1", Attributes: map[string]string{"storageClass": "GLACIER"}}
+	tier := decimal.NewFromInt(51200)
+	return []NormalizedRate{
+		{RateKey: key, Unit: "GB-Mo", Price: decimal.NewFromFloat(0.023), Currency: "USD"},
+		{RateKey: key, Unit: "GB-Mo", Price: decimal.NewFromFloat(0.022), Currency: "USD", TierMin: &tier},
+		{RateKey: other, Unit: "GB-Mo", Price: decimal.NewFromFloat(0.004), Currency: "USD"},
+	}
+}
+
+// TestCalculateHashOrderIndependent proves tiers of one rate key hash the
+// same in any order, as they come back from the database
+func TestCalculateHashOrderIndependent(t *testing.T) {
+	rates := tieredRates()
+	reordered := []NormalizedRate{rates[2], rates[1], rates[0]}
+	if calculateHash(rates) != calculateHash(reordered) {
+		t.Error("hash depends on the order of tiered rates")
+	}
+}
+
+func writeTestBackup(t *testing.T, rates []NormalizedRate, hash string) string {
+	t.Helper()
+	path, err := NewBackupManager().WriteBackup(t.TempDir(), &SnapshotBackup{
+		Provider:      db.AWS,
+		Region:        "us-east-1",
+		Timestamp:     time.Now(),
+		ContentHash:   hash,
+		RateCount:     len(rates),
+		SchemaVersion: "1.0",
+		Rates:         rates,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestVerifyBackup(t *testing.T) {
+	rates := tieredRates()
+	report, err := NewBackupManager().VerifyBackup(writeTestBackup(t, rates, calculateHash(rates)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() {
+		t.Fatalf("expected an intact backup to verify, got %v", report.Problems())
+	}
+
+	// A backup hashed before tiers were ordered still verifies
+	legacy := writeTestBackup(t, rates, legacyContentHash(rates))
+	if report, err := NewBackupManager().VerifyBackup(legacy); err != nil || !report.OK() {
+		t.Errorf("legacy backup: %v, %v", err, report)
+	}
+}
+
+func TestVerifyBackupDetectsCorruption(t *testing.T) {
+	rates := tieredRates()
+	hash := calculateHash(rates)
+
+	corrupted := tieredRates()
+	corrupted[2].Price = decimal.NewFromFloat(-0.004)
+	report, err := NewBackupManager().VerifyBackup(writeTestBackup(t, corrupted, hash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OK() || report.HashMatches() || report.GovernanceError == "" {
+		t.Errorf("expected hash and governance failures, got %+v", report)
+	}
+
+	truncated := writeTestBackup(t, rates[:2], hash)
+	report, err = NewBackupManager().VerifyBackup(truncated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.HashMatches() {
+		t.Error("expected a hash mismatch for a truncated backup")
+	}
+	if problems := strings.Join(report.Problems(), "; "); !strings.Contains(problems, "content hash mismatch") {
+		t.Errorf("problems = %q", problems)
+	}
+}
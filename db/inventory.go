@@ -0,0 +1,60 @@
+// Package db - Active snapshot inventory
+// An operator needs to know which clouds and regions have pricing and how
+// old it is before estimates start failing or quietly pricing from stale
+// rates. SnapshotInventory lists every active snapshot with its rate count
+// and age, flagging the ones past a freshness threshold.
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InventoryEntry describes one active snapshot
+type InventoryEntry struct {
+	SnapshotID uuid.UUID
+	Cloud      CloudProvider
+	Region     string
+	Alias      string
+	Rates      int
+	FetchedAt  time.Time
+
+	// Age is measured from FetchedAt
+	Age time.Duration
+
+	// Stale is true when Age exceeds the inventory's maxAge
+	Stale bool
+}
+
+// SnapshotInventory lists the active snapshot of every cloud, region and
+// alias with its rate count and age at now. Snapshots older than maxAge
+// are marked stale; a zero maxAge marks none.
+func SnapshotInventory(ctx context.Context, store PricingStore, now time.Time, maxAge time.Duration) ([]InventoryEntry, error) {
+	snapshots, err := store.ListActiveSnapshots(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active snapshots: %w", err)
+	}
+
+	entries := make([]InventoryEntry, 0, len(snapshots))
+	for _, s := range snapshots {
+		rates, err := store.CountRates(ctx, s.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count rates of snapshot %s: %w", s.ID, err)
+		}
+		age := now.Sub(s.FetchedAt)
+		entries = append(entries, InventoryEntry{
+			SnapshotID: s.ID,
+			Cloud:      s.Cloud,
+			Region:     s.Region,
+			Alias:      s.ProviderAlias,
+			Rates:      rates,
+			FetchedAt:  s.FetchedAt,
+			Age:        age,
+			Stale:      maxAge > 0 && age > maxAge,
+		})
+	}
+	return entries, nil
+}
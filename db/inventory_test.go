@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// inventoryStore serves active snapshots and rate counts from memory
+type inventoryStore struct {
+	PricingStore
+	snapshots []*PricingSnapshot
+	rates     map[uuid.UUID]int
+}
+
+func (s *inventoryStore) ListActiveSnapshots(ctx context.Context) ([]*PricingSnapshot, error) {
+	return s.snapshots, nil
+}
+
+func (s *inventoryStore) CountRates(ctx context.Context, id uuid.UUID) (int, error) {
+	return s.rates[id], nil
+}
+
+func TestSnapshotInventory(t *testing.T) {
+	now := time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+	fresh := &PricingSnapshot{ID: uuid.New(), Cloud: AWS, Region: "us-east-1", ProviderAlias: "default", FetchedAt: now.Add(-24 * time.Hour)}
+	old := &PricingSnapshot{ID: uuid.New(), Cloud: Azure, Region: "eastus", ProviderAlias: "default", FetchedAt: now.Add(-30 * 24 * time.Hour)}
+	store := &inventoryStore{
+		snapshots: []*PricingSnapshot{fresh, old},
+		rates:     map[uuid.UUID]int{fresh.ID: 1200, old.ID: 800},
+	}
+
+	entries, err := SnapshotInventory(context.Background(), store, now, 7*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if e := entries[0]; e.Cloud != AWS || e.Rates != 1200 || e.Age != 24*time.Hour || e.Stale {
+		t.Errorf("fresh snapshot: %+v", e)
+	}
+	if e := entries[1]; e.Cloud != Azure || e.Rates != 800 || !e.Stale {
+		t.Errorf("old snapshot should be stale: %+v", e)
+	}
+
+	entries, err = SnapshotInventory(context.Background(), store, now, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Stale {
+			t.Errorf("no max age, but %s/%s is stale", e.Cloud, e.Region)
+		}
+	}
+}
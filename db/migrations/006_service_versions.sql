@@ -0,0 +1,9 @@
+-- Migration: Record per-service offer file versions on snapshots
+-- Incremental ingestion compares these with the current publication dates
+-- and only refetches services that changed.
+
+ALTER TABLE pricing_snapshots
+ADD COLUMN IF NOT EXISTS service_versions JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+COMMENT ON COLUMN pricing_snapshots.service_versions IS
+'Offer file version (publication date) of each service, keyed by service code';
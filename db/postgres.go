@@ -86,15 +86,20 @@ func (s *PostgresStore) Close() error {
 
 // CreateSnapshot inserts a new pricing snapshot
 func (s *PostgresStore) CreateSnapshot(ctx context.Context, snapshot *PricingSnapshot) error {
+	versions, err := serviceVersionsJSON(snapshot.ServiceVersions)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO pricing_snapshots 
-		(id, cloud, region, provider_alias, source, fetched_at, valid_from, valid_to, hash, version, is_active)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		(id, cloud, region, provider_alias, source, fetched_at, valid_from, valid_to, hash, version, is_active, service_versions)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 	`
-	_, err := s.db.ExecContext(ctx, query,
+	_, err = s.db.ExecContext(ctx, query,
 		snapshot.ID, snapshot.Cloud, snapshot.Region, snapshot.ProviderAlias,
 		snapshot.Source, snapshot.FetchedAt, snapshot.ValidFrom, snapshot.ValidTo,
-		snapshot.Hash, snapshot.Version, snapshot.IsActive,
+		snapshot.Hash, snapshot.Version, snapshot.IsActive, versions,
 	)
 	return err
 }
@@ -102,38 +107,46 @@ func (s *PostgresStore) CreateSnapshot(ctx context.Context, snapshot *PricingSna
 // GetSnapshot retrieves a snapshot by ID
 func (s *PostgresStore) GetSnapshot(ctx context.Context, id uuid.UUID) (*PricingSnapshot, error) {
 	query := `
-		SELECT id, cloud, region, provider_alias, source, fetched_at, valid_from, valid_to, hash, version, is_active, created_at
+		SELECT id, cloud, region, provider_alias, source, fetched_at, valid_from, valid_to, hash, version, is_active, created_at, service_versions
 		FROM pricing_snapshots WHERE id = $1
 	`
 	snapshot := &PricingSnapshot{}
+	var versions []byte
 	err := s.db.QueryRowContext(ctx, query, id).Scan(
 		&snapshot.ID, &snapshot.Cloud, &snapshot.Region, &snapshot.ProviderAlias,
 		&snapshot.Source, &snapshot.FetchedAt, &snapshot.ValidFrom, &snapshot.ValidTo,
-		&snapshot.Hash, &snapshot.Version, &snapshot.IsActive, &snapshot.CreatedAt,
+		&snapshot.Hash, &snapshot.Version, &snapshot.IsActive, &snapshot.CreatedAt, &versions,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
-	return snapshot, err
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, json.Unmarshal(versions, &snapshot.ServiceVersions)
 }
 
 // GetActiveSnapshot retrieves the active snapshot for a cloud/region/alias
 func (s *PostgresStore) GetActiveSnapshot(ctx context.Context, cloud CloudProvider, region, alias string) (*PricingSnapshot, error) {
 	query := `
-		SELECT id, cloud, region, provider_alias, source, fetched_at, valid_from, valid_to, hash, version, is_active, created_at
+		SELECT id, cloud, region, provider_alias, source, fetched_at, valid_from, valid_to, hash, version, is_active, created_at, service_versions
 		FROM pricing_snapshots 
 		WHERE cloud = $1 AND region = $2 AND provider_alias = $3 AND is_active = TRUE
 	`
 	snapshot := &PricingSnapshot{}
+	var versions []byte
 	err := s.db.QueryRowContext(ctx, query, cloud, region, alias).Scan(
 		&snapshot.ID, &snapshot.Cloud, &snapshot.Region, &snapshot.ProviderAlias,
 		&snapshot.Source, &snapshot.FetchedAt, &snapshot.ValidFrom, &snapshot.ValidTo,
-		&snapshot.Hash, &snapshot.Version, &snapshot.IsActive, &snapshot.CreatedAt,
+		&snapshot.Hash, &snapshot.Version, &snapshot.IsActive, &snapshot.CreatedAt, &versions,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
-	return snapshot, err
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, json.Unmarshal(versions, &snapshot.ServiceVersions)
 }
 
 // ActivateSnapshot activates a snapshot (deactivates others)
@@ -145,7 +158,7 @@ func (s *PostgresStore) ActivateSnapshot(ctx context.Context, id uuid.UUID) erro
 // ListSnapshots lists snapshots for a cloud/region
 func (s *PostgresStore) ListSnapshots(ctx context.Context, cloud CloudProvider, region string) ([]*PricingSnapshot, error) {
 	query := `
-		SELECT id, cloud, region, provider_alias, source, fetched_at, valid_from, valid_to, hash, version, is_active, created_at
+		SELECT id, cloud, region, provider_alias, source, fetched_at, valid_from, valid_to, hash, version, is_active, created_at, service_versions
 		FROM pricing_snapshots 
 		WHERE cloud = $1 AND region = $2
 		ORDER BY created_at DESC
@@ -155,21 +168,46 @@ func (s *PostgresStore) ListSnapshots(ctx context.Context, cloud CloudProvider,
 		return nil, err
 	}
 	defer rows.Close()
+	return scanSnapshots(rows)
+}
 
+// ListActiveSnapshots lists the active snapshot of every cloud, region and
+// provider alias
+func (s *PostgresStore) ListActiveSnapshots(ctx context.Context) ([]*PricingSnapshot, error) {
+	query := `
+		SELECT id, cloud, region, provider_alias, source, fetched_at, valid_from, valid_to, hash, version, is_active, created_at, service_versions
+		FROM pricing_snapshots 
+		WHERE is_active = true
+		ORDER BY cloud, region, provider_alias
+	`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanSnapshots(rows)
+}
+
+// scanSnapshots reads snapshot rows selected in ListSnapshots column order
+func scanSnapshots(rows *sql.Rows) ([]*PricingSnapshot, error) {
 	var snapshots []*PricingSnapshot
 	for rows.Next() {
 		s := &PricingSnapshot{}
+		var versions []byte
 		err := rows.Scan(
 			&s.ID, &s.Cloud, &s.Region, &s.ProviderAlias,
 			&s.Source, &s.FetchedAt, &s.ValidFrom, &s.ValidTo,
-			&s.Hash, &s.Version, &s.IsActive, &s.CreatedAt,
+			&s.Hash, &s.Version, &s.IsActive, &s.CreatedAt, &versions,
 		)
 		if err != nil {
 			return nil, err
 		}
+		if err := json.Unmarshal(versions, &s.ServiceVersions); err != nil {
+			return nil, err
+		}
 		snapshots = append(snapshots, s)
 	}
-	return snapshots, nil
+	return snapshots, rows.Err()
 }
 
 // UpsertRateKey inserts or returns existing rate key
@@ -362,15 +400,20 @@ func (s *PostgresStore) BeginTx(ctx context.Context) (Tx, error) {
 
 // CreateSnapshot creates a snapshot within a transaction
 func (t *PostgresTx) CreateSnapshot(ctx context.Context, snapshot *PricingSnapshot) error {
+	versions, err := serviceVersionsJSON(snapshot.ServiceVersions)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO pricing_snapshots 
-		(id, cloud, region, provider_alias, source, fetched_at, valid_from, valid_to, hash, version, is_active)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		(id, cloud, region, provider_alias, source, fetched_at, valid_from, valid_to, hash, version, is_active, service_versions)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 	`
-	_, err := t.tx.ExecContext(ctx, query,
+	_, err = t.tx.ExecContext(ctx, query,
 		snapshot.ID, snapshot.Cloud, snapshot.Region, snapshot.ProviderAlias,
 		snapshot.Source, snapshot.FetchedAt, snapshot.ValidFrom, snapshot.ValidTo,
-		snapshot.Hash, snapshot.Version, snapshot.IsActive,
+		snapshot.Hash, snapshot.Version, snapshot.IsActive, versions,
 	)
 	return err
 }
@@ -434,6 +477,33 @@ func (t *PostgresTx) ActivateSnapshot(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+// LockSnapshots locks every snapshot of a cloud/region/alias until the
+// transaction ends and returns them as they are once locked, so a
+// concurrent activation waits and the active one read here stays current
+func (t *PostgresTx) LockSnapshots(ctx context.Context, cloud CloudProvider, region, alias string) ([]*PricingSnapshot, error) {
+	rows, err := t.tx.QueryContext(ctx, `
+		SELECT id, cloud, region, provider_alias, source, fetched_at, valid_from, valid_to, hash, version, is_active, created_at, service_versions
+		FROM pricing_snapshots
+		WHERE cloud = $1 AND region = $2 AND provider_alias = $3
+		ORDER BY id
+		FOR UPDATE
+	`, cloud, region, alias)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanSnapshots(rows)
+}
+
+// DeactivateSnapshot deactivates a snapshot within a transaction.
+// The cloud/region/alias is left with no active snapshot.
+func (t *PostgresTx) DeactivateSnapshot(ctx context.Context, id uuid.UUID) error {
+	_, err := t.tx.ExecContext(ctx, `
+		UPDATE pricing_snapshots SET is_active = FALSE WHERE id = $1
+	`, id)
+	return err
+}
+
 // Commit commits the transaction
 func (t *PostgresTx) Commit() error {
 	return t.tx.Commit()
@@ -447,22 +517,26 @@ func (t *PostgresTx) Rollback() error {
 // FindSnapshotByHash finds a snapshot with matching content hash
 func (s *PostgresStore) FindSnapshotByHash(ctx context.Context, cloud CloudProvider, region, alias, hash string) (*PricingSnapshot, error) {
 	query := `
-		SELECT id, cloud, region, provider_alias, source, fetched_at, valid_from, valid_to, hash, version, is_active, created_at
+		SELECT id, cloud, region, provider_alias, source, fetched_at, valid_from, valid_to, hash, version, is_active, created_at, service_versions
 		FROM pricing_snapshots 
 		WHERE cloud = $1 AND region = $2 AND provider_alias = $3 AND hash = $4
 		ORDER BY created_at DESC
 		LIMIT 1
 	`
 	snapshot := &PricingSnapshot{}
+	var versions []byte
 	err := s.db.QueryRowContext(ctx, query, cloud, region, alias, hash).Scan(
 		&snapshot.ID, &snapshot.Cloud, &snapshot.Region, &snapshot.ProviderAlias,
 		&snapshot.Source, &snapshot.FetchedAt, &snapshot.ValidFrom, &snapshot.ValidTo,
-		&snapshot.Hash, &snapshot.Version, &snapshot.IsActive, &snapshot.CreatedAt,
+		&snapshot.Hash, &snapshot.Version, &snapshot.IsActive, &snapshot.CreatedAt, &versions,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
-	return snapshot, err
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, json.Unmarshal(versions, &snapshot.ServiceVersions)
 }
 
 // CountRates returns the count of rates in a snapshot
@@ -474,3 +548,85 @@ func (s *PostgresStore) CountRates(ctx context.Context, snapshotID uuid.UUID) (i
 	).Scan(&count)
 	return count, err
 }
+
+// CoveredServiceFamilies lists the distinct service/product families priced by a snapshot
+func (s *PostgresStore) CoveredServiceFamilies(ctx context.Context, snapshotID uuid.UUID) ([]ServiceFamily, error) {
+	query := `
+		SELECT DISTINCT rk.service, rk.product_family
+		FROM pricing_rates pr
+		JOIN pricing_rate_keys rk ON rk.id = pr.rate_key_id
+		WHERE pr.snapshot_id = $1
+		ORDER BY rk.service, rk.product_family
+	`
+	rows, err := s.db.QueryContext(ctx, query, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var families []ServiceFamily
+	for rows.Next() {
+		var sf ServiceFamily
+		if err := rows.Scan(&sf.Service, &sf.ProductFamily); err != nil {
+			return nil, err
+		}
+		families = append(families, sf)
+	}
+	return families, rows.Err()
+}
+
+// ListRates returns every rate in a snapshot with its rate key
+func (s *PostgresStore) ListRates(ctx context.Context, snapshotID uuid.UUID) ([]SnapshotRate, error) {
+	return s.listSnapshotRates(ctx, snapshotID, "")
+}
+
+// ListServiceRates returns every rate a snapshot holds for one service
+func (s *PostgresStore) ListServiceRates(ctx context.Context, snapshotID uuid.UUID, service string) ([]SnapshotRate, error) {
+	return s.listSnapshotRates(ctx, snapshotID, service)
+}
+
+// listSnapshotRates loads a snapshot's rates, restricted to one service
+// when service is non-empty
+func (s *PostgresStore) listSnapshotRates(ctx context.Context, snapshotID uuid.UUID, service string) ([]SnapshotRate, error) {
+	query := `
+		SELECT rk.id, rk.cloud, rk.service, rk.product_family, rk.region, rk.attributes, rk.created_at,
+		       pr.id, pr.unit, pr.price, pr.currency, pr.confidence, pr.tier_min, pr.tier_max, pr.effective_date, pr.created_at
+		FROM pricing_rates pr
+		JOIN pricing_rate_keys rk ON rk.id = pr.rate_key_id
+		WHERE pr.snapshot_id = $1 AND ($2 = '' OR rk.service = $2)
+	`
+	rows, err := s.db.QueryContext(ctx, query, snapshotID, service)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []SnapshotRate
+	for rows.Next() {
+		var r SnapshotRate
+		var attrs []byte
+		if err := rows.Scan(
+			&r.Key.ID, &r.Key.Cloud, &r.Key.Service, &r.Key.ProductFamily, &r.Key.Region, &attrs, &r.Key.CreatedAt,
+			&r.Rate.ID, &r.Rate.Unit, &r.Rate.Price, &r.Rate.Currency, &r.Rate.Confidence,
+			&r.Rate.TierMin, &r.Rate.TierMax, &r.Rate.EffectiveDate, &r.Rate.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(attrs, &r.Key.Attributes); err != nil {
+			return nil, err
+		}
+		r.Rate.SnapshotID = snapshotID
+		r.Rate.RateKeyID = r.Key.ID
+		rates = append(rates, r)
+	}
+	return rates, rows.Err()
+}
+
+// serviceVersionsJSON encodes service versions for the JSONB column,
+// storing an empty object rather than NULL
+func serviceVersionsJSON(versions map[string]string) ([]byte, error) {
+	if versions == nil {
+		versions = map[string]string{}
+	}
+	return json.Marshal(versions)
+}
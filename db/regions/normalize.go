@@ -0,0 +1,138 @@
+// Package regions - Region code normalization
+// Each provider spells regions differently: AWS "us-east-1", Azure's
+// armRegionName "eastus" (display name "East US"), GCP "us-east1".
+// Normalization turns any of these spellings, or a zone within the region,
+// into the code the provider's pricing API expects.
+package regions
+
+import (
+	"strings"
+
+	"terraform-cost/db"
+)
+
+// equivalentRegion is one physical location across providers.
+// An empty code means the provider has no region there.
+type equivalentRegion struct {
+	aws, azure, gcp string
+}
+
+// equivalentRegions pairs regions in the same metro or country, so an
+// estimate written against one provider's region can be priced in another's
+var equivalentRegions = []equivalentRegion{
+	// North America
+	{"us-east-1", "eastus", "us-east4"},                       // Virginia
+	{"us-east-2", "", "us-east5"},                             // Ohio
+	{"us-west-1", "westus", "us-west2"},                       // California
+	{"us-west-2", "westus2", "us-west1"},                      // Oregon / Washington
+	{"ca-central-1", "canadaeast", "northamerica-northeast1"}, // Québec
+
+	// Europe
+	{"eu-west-1", "northeurope", ""},                       // Ireland
+	{"eu-west-2", "uksouth", "europe-west2"},               // London
+	{"eu-west-3", "francecentral", "europe-west9"},         // Paris
+	{"eu-central-1", "germanywestcentral", "europe-west3"}, // Frankfurt
+	{"eu-central-2", "switzerlandnorth", "europe-west6"},   // Zürich
+	{"eu-north-1", "swedencentral", "europe-north1"},       // Nordics
+	{"eu-south-1", "italynorth", "europe-west8"},           // Milan
+	{"eu-south-2", "", "europe-southwest1"},                // Spain
+
+	// Asia Pacific
+	{"ap-northeast-1", "japaneast", "asia-northeast1"},               // Tokyo
+	{"ap-northeast-2", "koreacentral", "asia-northeast3"},            // Seoul
+	{"ap-northeast-3", "japanwest", "asia-northeast2"},               // Osaka
+	{"ap-southeast-1", "southeastasia", "asia-southeast1"},           // Singapore
+	{"ap-southeast-2", "australiaeast", "australia-southeast1"},      // Sydney
+	{"ap-southeast-3", "", "asia-southeast2"},                        // Jakarta
+	{"ap-southeast-4", "australiasoutheast", "australia-southeast2"}, // Melbourne
+	{"ap-south-1", "westindia", "asia-south1"},                       // Mumbai
+	{"ap-east-1", "eastasia", "asia-east2"},                          // Hong Kong
+
+	// South America, Middle East, Africa
+	{"sa-east-1", "brazilsouth", "southamerica-east1"}, // São Paulo
+	{"me-central-1", "uaenorth", ""},                   // UAE
+	{"il-central-1", "israelcentral", "me-west1"},      // Israel
+	{"af-south-1", "southafricawest", ""},              // Cape Town
+	{"", "southafricanorth", "africa-south1"},          // Johannesburg
+}
+
+// code returns the region code of a provider in an equivalence row
+func (e equivalentRegion) code(provider db.CloudProvider) string {
+	switch provider {
+	case db.AWS:
+		return e.aws
+	case db.Azure:
+		return e.azure
+	case db.GCP:
+		return e.gcp
+	}
+	return ""
+}
+
+// Normalize returns the provider's region code for region, which may be the
+// provider's own code in any case, an Azure display name, a zone in the
+// region, or the equivalent region of another provider. It reports false
+// when the region is not known to the registry.
+func (r *Registry) Normalize(provider db.CloudProvider, region string) (string, bool) {
+	region = strings.ToLower(strings.TrimSpace(region))
+	if region == "" {
+		return "", false
+	}
+
+	if code, ok := r.nativeCode(provider, region); ok {
+		return code, true
+	}
+
+	for _, other := range []db.CloudProvider{db.AWS, db.Azure, db.GCP} {
+		if other == provider {
+			continue
+		}
+		code, ok := r.nativeCode(other, region)
+		if !ok {
+			continue
+		}
+		if equivalent, ok := Equivalent(other, code, provider); ok {
+			return equivalent, true
+		}
+	}
+	return "", false
+}
+
+// nativeCode matches region against the provider's own spellings
+func (r *Registry) nativeCode(provider db.CloudProvider, region string) (string, bool) {
+	candidates := []string{region}
+	switch provider {
+	case db.AWS:
+		// us-east-1a -> us-east-1
+		candidates = append(candidates, strings.TrimRight(region, "abcdefghijklmnopqrstuvwxyz"))
+	case db.Azure:
+		// "East US 2", "east-us-2", "east_us_2" -> eastus2
+		candidates = append(candidates, strings.NewReplacer(" ", "", "-", "", "_", "").Replace(region))
+	case db.GCP:
+		// us-east1-b -> us-east1
+		if i := strings.LastIndex(region, "-"); i > 0 && len(region)-i == 2 {
+			candidates = append(candidates, region[:i])
+		}
+	}
+
+	for _, candidate := range candidates {
+		if reg := r.GetRegion(provider, candidate); reg != nil {
+			return reg.Region, true
+		}
+	}
+	return "", false
+}
+
+// Equivalent returns the region of provider to that is in the same location
+// as region of provider from
+func Equivalent(from db.CloudProvider, region string, to db.CloudProvider) (string, bool) {
+	if from == to {
+		return region, true
+	}
+	for _, e := range equivalentRegions {
+		if e.code(from) == region && e.code(to) != "" {
+			return e.code(to), true
+		}
+	}
+	return "", false
+}
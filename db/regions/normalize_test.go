@@ -0,0 +1,75 @@
+package regions
+
+import (
+	"strings"
+	"testing"
+
+	"terraform-cost/db"
+)
+
+func TestNormalize(t *testing.T) {
+	r := NewRegistry()
+	tests := []struct {
+		provider db.CloudProvider
+		region   string
+		want     string
+	}{
+		{db.AWS, "us-east-1", "us-east-1"},
+		{db.AWS, "US-EAST-1", "us-east-1"},
+		{db.AWS, "eu-west-2b", "eu-west-2"},
+		{db.AWS, "eastus", "us-east-1"},
+		{db.AWS, "europe-west3", "eu-central-1"},
+
+		{db.Azure, "eastus", "eastus"},
+		{db.Azure, "East US 2", "eastus2"},
+		{db.Azure, "west-europe", "westeurope"},
+		{db.Azure, "us-east-1", "eastus"},
+		{db.Azure, "ap-northeast-1", "japaneast"},
+		{db.Azure, "asia-southeast1", "southeastasia"},
+
+		{db.GCP, "us-east1", "us-east1"},
+		{db.GCP, "us-central1-a", "us-central1"},
+		{db.GCP, "us-east-1", "us-east4"},
+		{db.GCP, "uksouth", "europe-west2"},
+	}
+	for _, tt := range tests {
+		got, ok := r.Normalize(tt.provider, tt.region)
+		if !ok || got != tt.want {
+			t.Errorf("Normalize(%s, %q) = %q, %v; want %q", tt.provider, tt.region, got, ok, tt.want)
+		}
+	}
+
+	for _, region := range []string{"", "mars-north-1", "eu-west-1x"} {
+		if got, ok := r.Normalize(db.GCP, region); ok {
+			t.Errorf("Normalize(gcp, %q) = %q, want unknown", region, got)
+		}
+	}
+	// Ireland has no GCP region
+	if got, ok := r.Normalize(db.GCP, "eu-west-1"); ok {
+		t.Errorf("eu-west-1 has no GCP equivalent, got %q", got)
+	}
+}
+
+// TestBillableRegionCodes proves ingestion gets codes the provider's pricing
+// API understands, e.g. Azure armRegionName
+func TestBillableRegionCodes(t *testing.T) {
+	r := NewRegistry()
+	for _, provider := range []db.CloudProvider{db.AWS, db.Azure, db.GCP} {
+		for _, reg := range r.GetBillableRegions(provider) {
+			if got, ok := r.Normalize(provider, reg.Region); !ok || got != reg.Region {
+				t.Errorf("%s %s does not normalize to itself: %q", provider, reg.Region, got)
+			}
+			if provider == db.Azure && strings.ContainsAny(reg.Region, "- ") {
+				t.Errorf("azure region %q is not an armRegionName", reg.Region)
+			}
+		}
+	}
+
+	for _, e := range equivalentRegions {
+		for _, provider := range []db.CloudProvider{db.AWS, db.Azure, db.GCP} {
+			if code := e.code(provider); code != "" && r.GetRegion(provider, code) == nil {
+				t.Errorf("equivalence table has unknown %s region %q", provider, code)
+			}
+		}
+	}
+}
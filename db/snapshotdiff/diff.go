@@ -0,0 +1,225 @@
+// Package snapshotdiff - Rate-level comparison of two pricing snapshots
+// Pricing audits need to know what an ingestion actually changed: SKUs
+// that appeared or disappeared and prices that moved. Rates are matched on
+// their rate key (service, product family, region, attributes) plus unit
+// and tier, so a tier boundary or unit change shows up as a removal and an
+// addition rather than a price change.
+package snapshotdiff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db"
+)
+
+// Order selects how price changes are ranked
+type Order string
+
+const (
+	// ByPercent ranks changes by relative movement; changes from a zero
+	// price rank first since their movement is unbounded
+	ByPercent Order = "percent"
+
+	// ByAbsolute ranks changes by the size of the price delta
+	ByAbsolute Order = "absolute"
+)
+
+// Options controls which price changes are reported
+type Options struct {
+	// ThresholdPercent hides price changes whose relative movement is
+	// below it; zero reports every change
+	ThresholdPercent float64
+
+	// Order ranks the reported changes, largest movement first
+	Order Order
+}
+
+// Rate is one rate as it appears in a diff
+type Rate struct {
+	Key      string          `json:"key"`
+	Service  string          `json:"service"`
+	Family   string          `json:"product_family"`
+	Region   string          `json:"region"`
+	Unit     string          `json:"unit"`
+	Price    decimal.Decimal `json:"price"`
+	Currency string          `json:"currency"`
+}
+
+// Change is a rate present in both snapshots at different prices
+type Change struct {
+	Rate
+	OldPrice decimal.Decimal `json:"old_price"`
+	Delta    decimal.Decimal `json:"delta"`
+
+	// Percent is the relative movement; nil when the old price was zero
+	Percent *float64 `json:"percent,omitempty"`
+}
+
+// Result is the comparison of snapshot A (before) with snapshot B (after)
+type Result struct {
+	Added   []Rate   `json:"added"`
+	Removed []Rate   `json:"removed"`
+	Changed []Change `json:"changed"`
+
+	// Unchanged counts rates with the same price in both snapshots
+	Unchanged int `json:"unchanged"`
+
+	// BelowThreshold counts price changes hidden by ThresholdPercent
+	BelowThreshold int `json:"below_threshold"`
+}
+
+// Increases returns how many reported changes raised the price
+func (r *Result) Increases() int {
+	n := 0
+	for _, c := range r.Changed {
+		if c.Delta.IsPositive() {
+			n++
+		}
+	}
+	return n
+}
+
+// Diff compares the rates of snapshot a with those of snapshot b
+func Diff(a, b []db.SnapshotRate, opts Options) *Result {
+	before := index(a)
+	after := index(b)
+	result := &Result{}
+
+	for key, old := range before {
+		cur, ok := after[key]
+		if !ok {
+			result.Removed = append(result.Removed, newRate(key, old))
+			continue
+		}
+		if old.Rate.Price.Equal(cur.Rate.Price) {
+			result.Unchanged++
+			continue
+		}
+
+		change := Change{
+			Rate:     newRate(key, cur),
+			OldPrice: old.Rate.Price,
+			Delta:    cur.Rate.Price.Sub(old.Rate.Price),
+		}
+		if !old.Rate.Price.IsZero() {
+			pct, _ := change.Delta.Div(old.Rate.Price).Mul(decimal.NewFromInt(100)).Float64()
+			change.Percent = &pct
+		}
+		if change.Percent != nil && abs(*change.Percent) < opts.ThresholdPercent {
+			result.BelowThreshold++
+			continue
+		}
+		result.Changed = append(result.Changed, change)
+	}
+	for key, cur := range after {
+		if _, ok := before[key]; !ok {
+			result.Added = append(result.Added, newRate(key, cur))
+		}
+	}
+
+	sortRates(result.Added)
+	sortRates(result.Removed)
+	sortChanges(result.Changed, opts.Order)
+	return result
+}
+
+// index keys a snapshot's rates by identity
+func index(rates []db.SnapshotRate) map[string]db.SnapshotRate {
+	byKey := make(map[string]db.SnapshotRate, len(rates))
+	for _, r := range rates {
+		byKey[identity(r)] = r
+	}
+	return byKey
+}
+
+// identity renders the parts of a rate that make it the same SKU across
+// snapshots: everything but the price and the database IDs
+func identity(r db.SnapshotRate) string {
+	attrs := make([]string, 0, len(r.Key.Attributes))
+	for k, v := range r.Key.Attributes {
+		attrs = append(attrs, k+"="+v)
+	}
+	sort.Strings(attrs)
+
+	key := fmt.Sprintf("%s/%s/%s/%s{%s} %s",
+		r.Key.Cloud, r.Key.Service, r.Key.ProductFamily, r.Key.Region, strings.Join(attrs, ","), r.Rate.Unit)
+	if r.Rate.TierMin != nil || r.Rate.TierMax != nil {
+		key += " tier " + tierBound(r.Rate.TierMin) + ".." + tierBound(r.Rate.TierMax)
+	}
+	return key
+}
+
+func tierBound(d *decimal.Decimal) string {
+	if d == nil {
+		return ""
+	}
+	return d.String()
+}
+
+func newRate(key string, r db.SnapshotRate) Rate {
+	return Rate{
+		Key:      key,
+		Service:  r.Key.Service,
+		Family:   r.Key.ProductFamily,
+		Region:   r.Key.Region,
+		Unit:     r.Rate.Unit,
+		Price:    r.Rate.Price,
+		Currency: r.Rate.Currency,
+	}
+}
+
+func sortRates(rates []Rate) {
+	sort.Slice(rates, func(i, j int) bool { return rates[i].Key < rates[j].Key })
+}
+
+// sortChanges orders changes by largest movement first, breaking ties by
+// the other measure and then by key so output is stable
+func sortChanges(changes []Change, order Order) {
+	byPercent := func(a, b Change) int {
+		switch {
+		case a.Percent == nil && b.Percent == nil:
+			return 0
+		case a.Percent == nil:
+			return 1
+		case b.Percent == nil:
+			return -1
+		}
+		pa, pb := abs(*a.Percent), abs(*b.Percent)
+		switch {
+		case pa > pb:
+			return 1
+		case pa < pb:
+			return -1
+		}
+		return 0
+	}
+	byAbsolute := func(a, b Change) int {
+		return a.Delta.Abs().Cmp(b.Delta.Abs())
+	}
+
+	primary, secondary := byPercent, byAbsolute
+	if order == ByAbsolute {
+		primary, secondary = byAbsolute, byPercent
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if c := primary(a, b); c != 0 {
+			return c > 0
+		}
+		if c := secondary(a, b); c != 0 {
+			return c > 0
+		}
+		return a.Key < b.Key
+	})
+}
+
+func abs(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
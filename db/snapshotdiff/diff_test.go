@@ -0,0 +1,88 @@
+package snapshotdiff
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db"
+)
+
+func rate(instanceType, price string) db.SnapshotRate {
+	return db.SnapshotRate{
+		Key: db.RateKey{
+			Cloud:         db.AWS,
+			Service:       "AmazonEC2",
+			ProductFamily: "Compute Instance",
+			Region:        "us-east-1",
+			Attributes:    map[string]string{"instanceType": instanceType},
+		},
+		Rate: db.PricingRate{Unit: "hours", Price: decimal.RequireFromString(price), Currency: "USD"},
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := []db.SnapshotRate{
+		rate("m5.large", "0.096"),
+		rate("m5.xlarge", "0.192"),
+		rate("t3.micro", "0.0104"),
+		rate("c5.large", "0.085"),
+		rate("m1.small", "0.044"),
+		rate("free.tier", "0"),
+	}
+	b := []db.SnapshotRate{
+		rate("m5.large", "0.096"),   // unchanged
+		rate("m5.xlarge", "0.300"),  // +0.108, +56%
+		rate("t3.micro", "0.0208"),  // +0.0104, +100%
+		rate("c5.large", "0.0855"),  // +0.6%, below threshold
+		rate("free.tier", "0.01"),   // from zero
+		rate("m7i.large", "0.1008"), // added
+	}
+
+	result := Diff(a, b, Options{ThresholdPercent: 1, Order: ByPercent})
+
+	if len(result.Added) != 1 || result.Added[0].Price.String() != "0.1008" {
+		t.Errorf("added = %+v", result.Added)
+	}
+	if len(result.Removed) != 1 || result.Removed[0].Price.String() != "0.044" {
+		t.Errorf("removed = %+v", result.Removed)
+	}
+	if result.Unchanged != 1 || result.BelowThreshold != 1 {
+		t.Errorf("unchanged = %d, below threshold = %d", result.Unchanged, result.BelowThreshold)
+	}
+	if len(result.Changed) != 3 {
+		t.Fatalf("changed = %+v", result.Changed)
+	}
+
+	// Changes from zero have unbounded movement and rank first
+	if result.Changed[0].Percent != nil || result.Changed[1].Price.String() != "0.0208" {
+		t.Errorf("percent order wrong: %+v", result.Changed)
+	}
+	if p := *result.Changed[1].Percent; p != 100 {
+		t.Errorf("t3.micro percent = %v, want 100", p)
+	}
+
+	result = Diff(a, b, Options{Order: ByAbsolute})
+	if result.BelowThreshold != 0 || len(result.Changed) != 4 {
+		t.Fatalf("zero threshold should report every change, got %+v", result.Changed)
+	}
+	if !result.Changed[0].Delta.Equal(decimal.RequireFromString("0.108")) {
+		t.Errorf("absolute order should rank m5.xlarge first, got %+v", result.Changed[0])
+	}
+}
+
+func TestDiffMatchesOnTier(t *testing.T) {
+	tiered := func(min, price string) db.SnapshotRate {
+		r := rate("s3", price)
+		m := decimal.RequireFromString(min)
+		r.Rate.TierMin = &m
+		return r
+	}
+	a := []db.SnapshotRate{tiered("0", "0.023"), tiered("51200", "0.022")}
+	b := []db.SnapshotRate{tiered("0", "0.023"), tiered("102400", "0.022")}
+
+	result := Diff(a, b, Options{})
+	if len(result.Added) != 1 || len(result.Removed) != 1 || len(result.Changed) != 0 {
+		t.Errorf("a moved tier boundary is a removal and an addition, got %+v", result)
+	}
+}
@@ -33,6 +33,10 @@ type PricingSnapshot struct {
 	Version       string        `db:"version" json:"version"`
 	IsActive      bool          `db:"is_active" json:"is_active"`
 	CreatedAt     time.Time     `db:"created_at" json:"created_at"`
+
+	// ServiceVersions records the offer file version (publication date)
+	// of each service the snapshot was built from
+	ServiceVersions map[string]string `db:"service_versions" json:"service_versions,omitempty"`
 }
 
 // RateKey represents a unique pricing lookup key
@@ -88,6 +92,7 @@ type PricingStore interface {
 	GetActiveSnapshot(ctx context.Context, cloud CloudProvider, region, alias string) (*PricingSnapshot, error)
 	ActivateSnapshot(ctx context.Context, id uuid.UUID) error
 	ListSnapshots(ctx context.Context, cloud CloudProvider, region string) ([]*PricingSnapshot, error)
+	ListActiveSnapshots(ctx context.Context) ([]*PricingSnapshot, error)
 	FindSnapshotByHash(ctx context.Context, cloud CloudProvider, region, alias, hash string) (*PricingSnapshot, error)
 
 	// Rate Keys
@@ -98,6 +103,9 @@ type PricingStore interface {
 	CreateRate(ctx context.Context, rate *PricingRate) error
 	BulkCreateRates(ctx context.Context, rates []*PricingRate) error
 	CountRates(ctx context.Context, snapshotID uuid.UUID) (int, error)
+	CoveredServiceFamilies(ctx context.Context, snapshotID uuid.UUID) ([]ServiceFamily, error)
+	ListRates(ctx context.Context, snapshotID uuid.UUID) ([]SnapshotRate, error)
+	ListServiceRates(ctx context.Context, snapshotID uuid.UUID, service string) ([]SnapshotRate, error)
 	
 	// Resolution
 	ResolveRate(ctx context.Context, cloud CloudProvider, service, productFamily, region string, attrs map[string]string, unit, alias string) (*ResolvedRate, error)
@@ -111,12 +119,33 @@ type PricingStore interface {
 	Close() error
 }
 
+// ServiceFamily identifies a service/product family pair priced by a snapshot
+type ServiceFamily struct {
+	Service       string
+	ProductFamily string
+}
+
+// String returns "service/product_family"
+func (sf ServiceFamily) String() string {
+	return sf.Service + "/" + sf.ProductFamily
+}
+
+// SnapshotRate is a rate together with its key, as stored in a snapshot
+type SnapshotRate struct {
+	Key  RateKey
+	Rate PricingRate
+}
+
 // Tx is a transaction interface for atomic operations
 type Tx interface {
 	CreateSnapshot(ctx context.Context, snapshot *PricingSnapshot) error
 	UpsertRateKey(ctx context.Context, key *RateKey) (*RateKey, error)
 	CreateRate(ctx context.Context, rate *PricingRate) error
+	BulkUpsertRateKeys(ctx context.Context, keys []*RateKey) error
+	BulkCreateRates(ctx context.Context, rates []*PricingRate) error
+	LockSnapshots(ctx context.Context, cloud CloudProvider, region, alias string) ([]*PricingSnapshot, error)
 	ActivateSnapshot(ctx context.Context, id uuid.UUID) error
+	DeactivateSnapshot(ctx context.Context, id uuid.UUID) error
 	Commit() error
 	Rollback() error
 }
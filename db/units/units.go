@@ -0,0 +1,124 @@
+// Package units - Canonical pricing units
+// Cloud pricing APIs spell the same unit many ways: AWS "Hrs" and
+// "GB-Mo", Azure "1 Hour" and "1 GB/Month", GCP "h" and "GiBy.mo".
+// Normalizers map every spelling to one canonical unit, so rates for the
+// same dimension match regardless of which API or API version they came
+// from.
+package units
+
+import (
+	"strings"
+
+	"terraform-cost/db"
+)
+
+// Canonical units stored on normalized rates
+const (
+	Hours     = "hours"
+	Seconds   = "seconds"
+	Month     = "month"
+	GB        = "GB"
+	GBMonth   = "GB-month"
+	GBHours   = "GB-hours"
+	GBSeconds = "GB-seconds"
+	Requests  = "requests"
+)
+
+// common maps spellings shared by every provider, keyed by lookupKey
+var common = map[string]string{
+	"h":      Hours,
+	"hr":     Hours,
+	"hrs":    Hours,
+	"hour":   Hours,
+	"hours":  Hours,
+	"1 hour": Hours,
+	"1/hour": Hours,
+
+	"s":       Seconds,
+	"sec":     Seconds,
+	"second":  Seconds,
+	"seconds": Seconds,
+
+	"mo":      Month,
+	"month":   Month,
+	"months":  Month,
+	"1/month": Month,
+
+	"gb":        GB,
+	"1 gb":      GB,
+	"gib":       GB,
+	"giby":      GB,
+	"gigabyte":  GB,
+	"gigabytes": GB,
+
+	"gb-mo":      GBMonth,
+	"gb-month":   GBMonth,
+	"gb-months":  GBMonth,
+	"gb/month":   GBMonth,
+	"1 gb/month": GBMonth,
+	"gib-mo":     GBMonth,
+	"gib-month":  GBMonth,
+	"giby.mo":    GBMonth,
+
+	"gb-hr":     GBHours,
+	"gb-hrs":    GBHours,
+	"gb-hour":   GBHours,
+	"gb-hours":  GBHours,
+	"gb/hour":   GBHours,
+	"1 gb/hour": GBHours,
+	"giby.h":    GBHours,
+
+	"gb-second":        GBSeconds,
+	"gb-seconds":       GBSeconds,
+	"lambda-gb-second": GBSeconds,
+	"giby.s":           GBSeconds,
+
+	"request":  Requests,
+	"requests": Requests,
+	"req":      Requests,
+}
+
+// providerUnits maps spellings only one provider uses, such as Azure's
+// bare multipliers, keyed by lookupKey
+var providerUnits = map[db.CloudProvider]map[string]string{
+	db.AWS: {
+		"quantity": "units",
+		"lcu-hrs":  "LCU-hours",
+		"nlcu-hrs": "NLCU-hours",
+		"glcu-hrs": "GLCU-hours",
+	},
+	db.Azure: {
+		"10k":                 "10K-requests",
+		"1m":                  "1M-requests",
+		"10,000 transactions": "10K-transactions",
+		"100":                 "100-units",
+		"1":                   "unit",
+	},
+	db.GCP: {
+		"by":    "bytes",
+		"count": "count",
+	},
+}
+
+// Canonical returns the canonical unit for a provider's unit spelling.
+// Matching ignores case and surrounding or repeated whitespace. Unknown
+// units are lowercased (Azure also replaces spaces with dashes) so they
+// still compare consistently.
+func Canonical(provider db.CloudProvider, unit string) string {
+	key := lookupKey(unit)
+	if u, ok := providerUnits[provider][key]; ok {
+		return u
+	}
+	if u, ok := common[key]; ok {
+		return u
+	}
+	if provider == db.Azure {
+		return strings.ReplaceAll(key, " ", "-")
+	}
+	return key
+}
+
+// lookupKey lowercases a unit and collapses whitespace
+func lookupKey(unit string) string {
+	return strings.ToLower(strings.Join(strings.Fields(unit), " "))
+}
@@ -0,0 +1,68 @@
+package units
+
+import (
+	"testing"
+
+	"terraform-cost/db"
+)
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		provider db.CloudProvider
+		unit     string
+		want     string
+	}{
+		// AWS
+		{db.AWS, "Hrs", Hours},
+		{db.AWS, "Hours", Hours},
+		{db.AWS, "hrs", Hours},
+		{db.AWS, "GB-Mo", GBMonth},
+		{db.AWS, "GB-Month", GBMonth},
+		{db.AWS, "GB-month", GBMonth},
+		{db.AWS, "GB", GB},
+		{db.AWS, "Requests", Requests},
+		{db.AWS, "Lambda-GB-Second", GBSeconds},
+		{db.AWS, "GB-Seconds", GBSeconds},
+		{db.AWS, "Quantity", "units"},
+		{db.AWS, "LCU-Hrs", "LCU-hours"},
+		{db.AWS, "GLCU-Hrs", "GLCU-hours"},
+
+		// Azure
+		{db.Azure, "1 Hour", Hours},
+		{db.Azure, "1/Hour", Hours},
+		{db.Azure, "1 GB/Month", GBMonth},
+		{db.Azure, "1  GB/Month ", GBMonth},
+		{db.Azure, "1 GB/Hour", GBHours},
+		{db.Azure, "1 GB", GB},
+		{db.Azure, "10K", "10K-requests"},
+		{db.Azure, "1", "unit"},
+		{db.Azure, "1 Million Transactions", "1-million-transactions"},
+
+		// GCP
+		{db.GCP, "h", Hours},
+		{db.GCP, "GiBy.mo", GBMonth},
+		{db.GCP, "GiBy.h", GBHours},
+		{db.GCP, "GiBy", GB},
+		{db.GCP, "request", Requests},
+		{db.GCP, "s", Seconds},
+		{db.GCP, "mo", Month},
+		{db.GCP, "By", "bytes"},
+		{db.GCP, "Widget", "widget"},
+	}
+	for _, tt := range tests {
+		if got := Canonical(tt.provider, tt.unit); got != tt.want {
+			t.Errorf("Canonical(%s, %q) = %q, want %q", tt.provider, tt.unit, got, tt.want)
+		}
+	}
+}
+
+func TestCanonicalIsStable(t *testing.T) {
+	// Canonical units map to themselves, so normalizing twice is harmless
+	for _, u := range []string{Hours, Seconds, Month, GB, GBMonth, GBHours, GBSeconds, Requests} {
+		for _, p := range []db.CloudProvider{db.AWS, db.Azure, db.GCP} {
+			if got := Canonical(p, u); got != u {
+				t.Errorf("Canonical(%s, %q) = %q", p, u, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Package atomicfile - Atomic file replacement
+// Output is written to a temp file in the destination directory and renamed
+// into place on Commit, so a consumer never sees a partially written file.
+// The directory is synced after the rename so the new entry survives a
+// crash. Concurrent writers each get their own temp file; the last rename
+// wins and the file is always one writer's complete content.
+package atomicfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// File is a pending replacement for the file at Path
+type File struct {
+	tmp  *os.File
+	path string
+	done bool
+}
+
+// Create starts writing a replacement for path. Nothing is visible at path
+// until Commit succeeds.
+func Create(path string) (*File, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return nil, fmt.Errorf("create temp file for %s: %w", path, err)
+	}
+	return &File{tmp: tmp, path: path}, nil
+}
+
+// Path returns the destination path
+func (f *File) Path() string {
+	return f.path
+}
+
+// Write implements io.Writer
+func (f *File) Write(p []byte) (int, error) {
+	return f.tmp.Write(p)
+}
+
+// Commit flushes the temp file and renames it over the destination
+func (f *File) Commit() error {
+	if f.done {
+		return fmt.Errorf("%s: already committed or aborted", f.path)
+	}
+	f.done = true
+
+	if err := f.tmp.Sync(); err != nil {
+		f.discard()
+		return fmt.Errorf("sync %s: %w", f.path, err)
+	}
+	if err := f.tmp.Close(); err != nil {
+		os.Remove(f.tmp.Name())
+		return fmt.Errorf("close %s: %w", f.path, err)
+	}
+	// CreateTemp uses 0600; artifacts should be readable like a normal file
+	if err := os.Chmod(f.tmp.Name(), 0644); err != nil {
+		os.Remove(f.tmp.Name())
+		return fmt.Errorf("chmod %s: %w", f.path, err)
+	}
+	if err := os.Rename(f.tmp.Name(), f.path); err != nil {
+		os.Remove(f.tmp.Name())
+		return fmt.Errorf("rename to %s: %w", f.path, err)
+	}
+	if err := syncDir(filepath.Dir(f.path)); err != nil {
+		return fmt.Errorf("sync directory of %s: %w", f.path, err)
+	}
+	return nil
+}
+
+// WriteFile atomically replaces path with data
+func WriteFile(path string, data []byte) error {
+	f, err := Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Abort()
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return f.Commit()
+}
+
+// syncDir flushes a directory so a rename into it is durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// Abort discards the temp file, leaving any existing destination untouched.
+// It is a no-op after Commit.
+func (f *File) Abort() {
+	if f.done {
+		return
+	}
+	f.done = true
+	f.discard()
+}
+
+func (f *File) discard() {
+	f.tmp.Close()
+	os.Remove(f.tmp.Name())
+}
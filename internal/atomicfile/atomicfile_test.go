@@ -0,0 +1,67 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommitReplacesFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "estimate.json")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("new"))
+
+	// Not visible until committed
+	if data, _ := os.ReadFile(path); string(data) != "old" {
+		t.Fatalf("destination changed before commit: %q", data)
+	}
+
+	if err := f.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Fatalf("expected committed content, got %q", data)
+	}
+	assertOnlyFile(t, dir, "estimate.json")
+}
+
+func TestAbortLeavesDestination(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "estimate.json")
+
+	f, err := Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("partial"))
+	f.Abort()
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected no destination file after abort, got err=%v", err)
+	}
+	assertOnlyFile(t, dir)
+}
+
+func assertOnlyFile(t *testing.T, dir string, want ...string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %v in %s, found %d entries", want, dir, len(entries))
+	}
+	for i, e := range entries {
+		if e.Name() != want[i] {
+			t.Errorf("unexpected entry %s", e.Name())
+		}
+	}
+}
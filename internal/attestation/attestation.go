@@ -0,0 +1,163 @@
+// Package attestation signs estimate JSON so an artifact can be shown
+// untampered. The Ed25519 signature covers the SHA-256 digest of the
+// canonical document: the JSON object without its signature and
+// public_key fields, re-encoded with sorted keys and no whitespace.
+// Indentation and key order therefore do not matter; any changed value
+// does. The estimate itself must marshal deterministically for a
+// re-run to reproduce the same digest.
+package attestation
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// SignatureField holds the base64 Ed25519 signature
+	SignatureField = "signature"
+
+	// PublicKeyField holds the base64 public key that verifies it
+	PublicKeyField = "public_key"
+)
+
+var (
+	// ErrUnsigned is returned for a document without a signature
+	ErrUnsigned = errors.New("document is not signed")
+
+	// ErrInvalidSignature is returned when the signature does not match
+	ErrInvalidSignature = errors.New("signature does not match document")
+
+	// ErrUntrustedKey is returned when the document verifies but was
+	// signed by a key outside the trusted set
+	ErrUntrustedKey = errors.New("document is signed by an untrusted key")
+)
+
+// Signature is embedded in a signed document
+type Signature struct {
+	Signature string `json:"signature"`
+	PublicKey string `json:"public_key"`
+}
+
+// Digest returns the SHA-256 digest of a JSON object's canonical form
+func Digest(doc []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	var obj map[string]interface{}
+	if err := dec.Decode(&obj); err != nil {
+		return nil, fmt.Errorf("document is not a JSON object: %w", err)
+	}
+	delete(obj, SignatureField)
+	delete(obj, PublicKeyField)
+
+	canonical, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(canonical)
+	return sum[:], nil
+}
+
+// Sign marshals v and signs its digest; the caller embeds the result
+func Sign(v interface{}, key ed25519.PrivateKey) (*Signature, error) {
+	doc, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := Digest(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &Signature{
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, digest)),
+		PublicKey: base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+	}, nil
+}
+
+// Verify checks a signed document against its embedded public key. With
+// trusted keys, that key must also be one of them; without, the
+// signature only proves the document is unchanged since signing.
+func Verify(doc []byte, trusted ...ed25519.PublicKey) (ed25519.PublicKey, error) {
+	var sig Signature
+	if err := json.Unmarshal(doc, &sig); err != nil {
+		return nil, fmt.Errorf("document is not a JSON object: %w", err)
+	}
+	if sig.Signature == "" || sig.PublicKey == "" {
+		return nil, ErrUnsigned
+	}
+	key, err := ParsePublicKey(sig.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.StdEncoding.DecodeString(sig.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	digest, err := Digest(doc)
+	if err != nil {
+		return nil, err
+	}
+	if !ed25519.Verify(key, digest, signature) {
+		return key, ErrInvalidSignature
+	}
+	if len(trusted) == 0 {
+		return key, nil
+	}
+	for _, t := range trusted {
+		if key.Equal(t) {
+			return key, nil
+		}
+	}
+	return key, ErrUntrustedKey
+}
+
+// LoadPrivateKey reads a PEM-encoded PKCS#8 Ed25519 private key, as
+// written by `openssl genpkey -algorithm ed25519`
+func LoadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %s is not PEM encoded", path)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key %s: %w", path, err)
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is %T, want Ed25519", path, parsed)
+	}
+	return key, nil
+}
+
+// ParsePublicKey parses a public key as embedded in a signed document
+// (base64), or a PEM-encoded PKIX Ed25519 public key
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	if block, _ := pem.Decode([]byte(s)); block != nil {
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %w", err)
+		}
+		key, ok := parsed.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key is %T, want Ed25519", parsed)
+		}
+		return key, nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key %q", s)
+	}
+	return ed25519.PublicKey(raw), nil
+}
@@ -0,0 +1,95 @@
+package attestation
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type estimate struct {
+	TotalCost float64  `json:"total_cost"`
+	Resources []string `json:"resources"`
+	Signature string   `json:"signature,omitempty"`
+	PublicKey string   `json:"public_key,omitempty"`
+}
+
+// signed returns an estimate signed by key, indented as CI writes it
+func signed(t *testing.T, key ed25519.PrivateKey) []byte {
+	t.Helper()
+	result := estimate{TotalCost: 12.5, Resources: []string{"aws_instance.web"}}
+	sig, err := Sign(result, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result.Signature, result.PublicKey = sig.Signature, sig.PublicKey
+	doc, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestSignVerify(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(nil)
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	doc := signed(t, key)
+
+	got, err := Verify(doc)
+	if err != nil || !got.Equal(pub) {
+		t.Fatalf("Verify = %v, %v", got, err)
+	}
+	if _, err := Verify(doc, otherPub, pub); err != nil {
+		t.Errorf("Verify with trusted key: %v", err)
+	}
+	if _, err := Verify(doc, otherPub); !errors.Is(err, ErrUntrustedKey) {
+		t.Errorf("Verify with untrusted key = %v", err)
+	}
+
+	// Formatting does not matter; a changed value does
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, doc); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(compact.Bytes()); err != nil {
+		t.Errorf("Verify compacted: %v", err)
+	}
+	tampered := bytes.Replace(doc, []byte("12.5"), []byte("1.5"), 1)
+	if _, err := Verify(tampered); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify tampered = %v", err)
+	}
+
+	if _, err := Verify([]byte(`{"total_cost": 1}`)); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("Verify unsigned = %v", err)
+	}
+}
+
+func TestLoadKeys(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(nil)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "signing.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadPrivateKey(path)
+	if err != nil || !loaded.Equal(key) {
+		t.Fatalf("LoadPrivateKey = %v", err)
+	}
+
+	der, err = x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParsePublicKey(string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})))
+	if err != nil || !parsed.Equal(pub) {
+		t.Fatalf("ParsePublicKey = %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+// Package benchguard - Benchmark regression guard
+// The benchmarks of the hot paths (pricing a large graph, building a
+// dense dependency graph, normalizing a rate batch) are compared against
+// a checked-in baseline. A benchmark slower or allocating more than the
+// baseline by more than a threshold fails the guard, so a regression is
+// caught in CI rather than noticed in production.
+package benchguard
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Result is one benchmark's cost per operation
+type Result struct {
+	NsPerOp     float64 `json:"ns_per_op"`
+	AllocsPerOp float64 `json:"allocs_per_op"`
+}
+
+// Results maps a benchmark, qualified by its package
+// ("terraform-cost/core/graph.BenchmarkBuildDenseGraph"), to its result
+type Results map[string]Result
+
+// benchLine matches a result line of go test -bench; the -N GOMAXPROCS
+// suffix is dropped so results compare across machines
+var benchLine = regexp.MustCompile(`^(Benchmark\S+?)(?:-\d+)?\s+\d+\s+(.*)$`)
+
+// Parse reads go test -bench output. A benchmark run several times
+// (-count) keeps its fastest run and fewest allocations, the results least
+// disturbed by noise.
+func Parse(r io.Reader) (Results, error) {
+	results := make(Results)
+	pkg := ""
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if p, ok := strings.CutPrefix(line, "pkg: "); ok {
+			pkg = p
+			continue
+		}
+		m := benchLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		result, ok := parseMetrics(m[2])
+		if !ok {
+			continue
+		}
+		name := m[1]
+		if pkg != "" {
+			name = pkg + "." + name
+		}
+		if prev, seen := results[name]; seen {
+			result.NsPerOp = min(result.NsPerOp, prev.NsPerOp)
+			result.AllocsPerOp = min(result.AllocsPerOp, prev.AllocsPerOp)
+		}
+		results[name] = result
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read benchmark output: %w", err)
+	}
+	return results, nil
+}
+
+// parseMetrics reads the "value unit" pairs of a result line; ok is false
+// without ns/op
+func parseMetrics(s string) (Result, bool) {
+	var result Result
+	hasTime := false
+	fields := strings.Fields(s)
+	for i := 0; i+1 < len(fields); i += 2 {
+		v, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return Result{}, false
+		}
+		switch fields[i+1] {
+		case "ns/op":
+			result.NsPerOp = v
+			hasTime = true
+		case "allocs/op":
+			result.AllocsPerOp = v
+		}
+	}
+	return result, hasTime
+}
+
+// Thresholds are the increases over the baseline tolerated, as fractions:
+// 0.3 fails a benchmark more than 30% slower
+type Thresholds struct {
+	Time   float64
+	Allocs float64
+}
+
+// DefaultThresholds tolerate machine noise in time; allocations barely
+// vary between runs, so a smaller increase is a regression
+var DefaultThresholds = Thresholds{Time: 0.30, Allocs: 0.10}
+
+// Regression is a benchmark worse than its baseline
+type Regression struct {
+	Name     string
+	Metric   string // ns/op, allocs/op, or missing
+	Baseline float64
+	Current  float64
+}
+
+func (r Regression) String() string {
+	if r.Metric == "missing" {
+		return fmt.Sprintf("%s: in the baseline but not run", r.Name)
+	}
+	return fmt.Sprintf("%s: %s %.0f → %.0f (%+.1f%%)",
+		r.Name, r.Metric, r.Baseline, r.Current, (r.Current-r.Baseline)/r.Baseline*100)
+}
+
+// Compare lists the benchmarks of baseline that regressed in current,
+// sorted by name. A baseline benchmark missing from current is a
+// regression too: the guard would otherwise pass by not running it.
+// Benchmarks new in current are not compared.
+func Compare(baseline, current Results, limits Thresholds) []Regression {
+	var regressions []Regression
+	for name, base := range baseline {
+		cur, ok := current[name]
+		if !ok {
+			regressions = append(regressions, Regression{Name: name, Metric: "missing"})
+			continue
+		}
+		if exceeds(base.NsPerOp, cur.NsPerOp, limits.Time) {
+			regressions = append(regressions, Regression{name, "ns/op", base.NsPerOp, cur.NsPerOp})
+		}
+		if exceeds(base.AllocsPerOp, cur.AllocsPerOp, limits.Allocs) {
+			regressions = append(regressions, Regression{name, "allocs/op", base.AllocsPerOp, cur.AllocsPerOp})
+		}
+	}
+	sort.Slice(regressions, func(i, j int) bool {
+		if regressions[i].Name != regressions[j].Name {
+			return regressions[i].Name < regressions[j].Name
+		}
+		return regressions[i].Metric < regressions[j].Metric
+	})
+	return regressions
+}
+
+func exceeds(base, cur, threshold float64) bool {
+	return base > 0 && cur > base*(1+threshold)
+}
+
+// ReadBaseline reads a baseline written by WriteBaseline
+func ReadBaseline(path string) (Results, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var results Results
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("parse baseline %s: %w", path, err)
+	}
+	return results, nil
+}
+
+// WriteBaseline writes results as the baseline at path
+func WriteBaseline(path string, results Results) error {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
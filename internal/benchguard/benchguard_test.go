@@ -0,0 +1,81 @@
+package benchguard
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const benchOutput = `goos: linux
+goarch: amd64
+pkg: terraform-cost/core/graph
+cpu: Intel(R) Xeon(R) Processor
+BenchmarkBuildDenseGraph-8   	      42	  36864783 ns/op	11632656 B/op	  210820 allocs/op
+BenchmarkBuildDenseGraph-8   	      40	  35000000 ns/op	11632656 B/op	  210821 allocs/op
+PASS
+ok  	terraform-cost/core/graph	2.537s
+pkg: terraform-cost/db/ingestion
+BenchmarkNormalizeRateBatch 	       8	 130192569 ns/op
+BenchmarkNormalizeRateBatch/sub-case-16 	       8	 1000 ns/op	 3 allocs/op
+--- FAIL: BenchmarkBroken
+PASS
+`
+
+func TestParseKeepsBestRun(t *testing.T) {
+	got, err := Parse(strings.NewReader(benchOutput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Results{
+		"terraform-cost/core/graph.BenchmarkBuildDenseGraph":               {NsPerOp: 35000000, AllocsPerOp: 210820},
+		"terraform-cost/db/ingestion.BenchmarkNormalizeRateBatch":          {NsPerOp: 130192569},
+		"terraform-cost/db/ingestion.BenchmarkNormalizeRateBatch/sub-case": {NsPerOp: 1000, AllocsPerOp: 3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseline := Results{
+		"pkg.BenchmarkFast":    {NsPerOp: 1000, AllocsPerOp: 100},
+		"pkg.BenchmarkSlower":  {NsPerOp: 1000, AllocsPerOp: 100},
+		"pkg.BenchmarkAllocs":  {NsPerOp: 1000, AllocsPerOp: 100},
+		"pkg.BenchmarkRemoved": {NsPerOp: 1000},
+	}
+	current := Results{
+		"pkg.BenchmarkFast":   {NsPerOp: 1290, AllocsPerOp: 109}, // within both thresholds
+		"pkg.BenchmarkSlower": {NsPerOp: 1400, AllocsPerOp: 100},
+		"pkg.BenchmarkAllocs": {NsPerOp: 500, AllocsPerOp: 120},
+		"pkg.BenchmarkNew":    {NsPerOp: 99999},
+	}
+
+	var got []string
+	for _, r := range Compare(baseline, current, DefaultThresholds) {
+		got = append(got, r.String())
+	}
+	want := []string{
+		"pkg.BenchmarkAllocs: allocs/op 100 → 120 (+20.0%)",
+		"pkg.BenchmarkRemoved: in the baseline but not run",
+		"pkg.BenchmarkSlower: ns/op 1000 → 1400 (+40.0%)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("regressions = %q\nwant %q", got, want)
+	}
+}
+
+func TestBaselineRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	results := Results{"pkg.BenchmarkX": {NsPerOp: 12.5, AllocsPerOp: 3}}
+	if err := WriteBaseline(path, results); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, results) {
+		t.Errorf("ReadBaseline = %+v, want %+v", got, results)
+	}
+}
@@ -3,9 +3,11 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 
+	"terraform-cost/core/terraform"
 	"terraform-cost/core/types"
 	"terraform-cost/internal/logging"
 )
@@ -35,6 +37,31 @@ type Config struct {
 
 	// GCP contains GCP-specific configuration
 	GCP GCPConfig `json:"gcp,omitempty"`
+
+	// Terraform contains plan expansion settings
+	Terraform TerraformConfig `json:"terraform"`
+}
+
+// TerraformConfig contains plan expansion settings
+type TerraformConfig struct {
+	// UnknownCountDefault is the instance count assumed when a resource's
+	// count cannot be determined
+	UnknownCountDefault int `json:"unknown_count_default"`
+
+	// UnknownCountByType overrides UnknownCountDefault per resource type
+	UnknownCountByType map[string]int `json:"unknown_count_by_type,omitempty"`
+}
+
+// PipelineOptions applies the expansion settings to pipeline options
+func (c TerraformConfig) PipelineOptions(opts terraform.PipelineOptions) terraform.PipelineOptions {
+	opts.UnknownCountDefault = c.UnknownCountDefault
+	opts.UnknownCountByType = c.UnknownCountByType
+	return opts
+}
+
+// NewPipeline creates an evaluation pipeline with the expansion settings
+func (c TerraformConfig) NewPipeline() *terraform.Pipeline {
+	return terraform.NewPipeline(c.PipelineOptions(terraform.PipelineOptions{}))
 }
 
 // PricingConfig contains pricing-related settings
@@ -148,6 +175,13 @@ func Default() *Config {
 		GCP: GCPConfig{
 			DefaultRegion: "us-central1",
 		},
+		Terraform: TerraformConfig{
+			UnknownCountDefault: 1,
+			UnknownCountByType: map[string]int{
+				// Groups run several instances; one would undercount
+				"aws_autoscaling_group": 3,
+			},
+		},
 	}
 }
 
@@ -165,10 +199,26 @@ func Load(path string) (*Config, error) {
 	if err := json.Unmarshal(data, config); err != nil {
 		return nil, err
 	}
+	if err := config.Terraform.validate(); err != nil {
+		return nil, err
+	}
 
 	return config, nil
 }
 
+// validate rejects negative assumed counts
+func (c TerraformConfig) validate() error {
+	if c.UnknownCountDefault < 0 {
+		return fmt.Errorf("terraform.unknown_count_default must not be negative, got %d", c.UnknownCountDefault)
+	}
+	for t, n := range c.UnknownCountByType {
+		if n < 0 {
+			return fmt.Errorf("terraform.unknown_count_by_type.%s must not be negative, got %d", t, n)
+		}
+	}
+	return nil
+}
+
 // Save saves configuration to a file
 func (c *Config) Save(path string) error {
 	// Ensure directory exists
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"terraform-cost/core/terraform"
+)
+
+// TestTerraformConfigValidate proves negative assumed counts are rejected
+func TestTerraformConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  TerraformConfig
+		wantErr string
+	}{
+		{"defaults", Default().Terraform, ""},
+		{"zero", TerraformConfig{UnknownCountByType: map[string]int{"aws_instance": 0}}, ""},
+		{"negative default", TerraformConfig{UnknownCountDefault: -1}, "unknown_count_default"},
+		{"negative type", TerraformConfig{UnknownCountDefault: 1, UnknownCountByType: map[string]int{"aws_instance": -3}},
+			"unknown_count_by_type.aws_instance"},
+	}
+	for _, tt := range tests {
+		err := tt.config.validate()
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: error = %v, want one naming %s", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// TestLoadRejectsNegativeCount proves Load validates the file it reads
+func TestLoadRejectsNegativeCount(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"terraform": {"unknown_count_by_type": {"aws_instance": -1}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Fatal("Load accepted a negative assumed count")
+	}
+}
+
+// TestPipelineOptions proves the expansion settings reach the pipeline
+// options and other options are kept
+func TestPipelineOptions(t *testing.T) {
+	opts := Default().Terraform.PipelineOptions(terraform.PipelineOptions{ContinueOnError: true})
+	if !opts.ContinueOnError {
+		t.Error("ContinueOnError was dropped")
+	}
+	if opts.UnknownCountDefault != 1 {
+		t.Errorf("UnknownCountDefault = %d, want 1", opts.UnknownCountDefault)
+	}
+	if got := opts.UnknownCountByType["aws_autoscaling_group"]; got != 3 {
+		t.Errorf("aws_autoscaling_group assumed count = %d, want 3", got)
+	}
+}
@@ -0,0 +1,81 @@
+// Package logging - Leveled, structured logger interface
+// Adapters depend on LeveledLogger rather than on zap directly so callers can
+// inject their own logger (or a no-op one in tests).
+package logging
+
+import (
+	"go.uber.org/zap"
+)
+
+// Field is a structured log field
+type Field = zap.Field
+
+// Field constructors
+var (
+	String   = zap.String
+	Strings  = zap.Strings
+	Int      = zap.Int
+	Int64    = zap.Int64
+	Float64  = zap.Float64
+	Bool     = zap.Bool
+	Duration = zap.Duration
+	Time     = zap.Time
+	Err      = zap.Error
+	Any      = zap.Any
+)
+
+// LeveledLogger is a leveled logger with structured fields
+type LeveledLogger interface {
+	Debug(msg string, fields ...Field)
+	Info(msg string, fields ...Field)
+	Warn(msg string, fields ...Field)
+	Error(msg string, fields ...Field)
+
+	// With returns a child logger that always includes the given fields
+	With(fields ...Field) LeveledLogger
+}
+
+// zapLeveled adapts a *zap.Logger to LeveledLogger
+type zapLeveled struct {
+	l *zap.Logger
+}
+
+// NewLeveled wraps a zap logger
+func NewLeveled(l *zap.Logger) LeveledLogger {
+	if l == nil {
+		l = zap.NewNop()
+	}
+	return &zapLeveled{l: l}
+}
+
+func (z *zapLeveled) Debug(msg string, fields ...Field) { z.l.Debug(msg, fields...) }
+func (z *zapLeveled) Info(msg string, fields ...Field)  { z.l.Info(msg, fields...) }
+func (z *zapLeveled) Warn(msg string, fields ...Field)  { z.l.Warn(msg, fields...) }
+func (z *zapLeveled) Error(msg string, fields ...Field) { z.l.Error(msg, fields...) }
+
+func (z *zapLeveled) With(fields ...Field) LeveledLogger {
+	return &zapLeveled{l: z.l.With(fields...)}
+}
+
+// globalLeveled delegates to the global Logger at call time, so it picks up
+// any re-initialization (e.g. after CLI flags are parsed)
+type globalLeveled struct{}
+
+func (globalLeveled) Debug(msg string, fields ...Field) { Logger.Debug(msg, fields...) }
+func (globalLeveled) Info(msg string, fields ...Field)  { Logger.Info(msg, fields...) }
+func (globalLeveled) Warn(msg string, fields ...Field)  { Logger.Warn(msg, fields...) }
+func (globalLeveled) Error(msg string, fields ...Field) { Logger.Error(msg, fields...) }
+
+func (globalLeveled) With(fields ...Field) LeveledLogger {
+	return &zapLeveled{l: Logger.With(fields...)}
+}
+
+// Default returns a LeveledLogger backed by the global logger
+func Default() LeveledLogger {
+	return globalLeveled{}
+}
+
+// Nop returns a LeveledLogger that discards everything
+func Nop() LeveledLogger {
+	return &zapLeveled{l: zap.NewNop()}
+}
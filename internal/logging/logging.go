@@ -21,7 +21,7 @@ type Config struct {
 	// Level is the minimum log level
 	Level string `json:"level"`
 
-	// Format is the output format (json, console)
+	// Format is the output format (json, console/text)
 	Format string `json:"format"`
 
 	// Output is the output destination (stdout, stderr, file path)
@@ -53,7 +53,7 @@ func Initialize(cfg Config) error {
 	encoderConfig.TimeKey = "timestamp"
 	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 
-	if cfg.Format == "console" {
+	if cfg.Format == "console" || cfg.Format == "text" {
 		encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
 		encoder = zapcore.NewConsoleEncoder(encoderConfig)
 	} else {
@@ -0,0 +1,212 @@
+// Package watch - File change watching
+// Watch reports changes to the files of a directory tree as fsnotify-style
+// events (create, write, remove). Changes are batched: a burst of saves,
+// such as an editor writing a temp file and renaming it or a formatter
+// rewriting every file, is reported once, after the tree has been quiet
+// for the debounce interval. The tree is polled rather than subscribed
+// to, which needs no platform support; a Terraform project is small
+// enough that stat'ing its files a few times a second is cheap.
+package watch
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Op is the kind of change to a file
+type Op int
+
+const (
+	// Create is a file that appeared
+	Create Op = iota + 1
+	// Write is a file whose content changed
+	Write
+	// Remove is a file that disappeared
+	Remove
+)
+
+// String returns the operation's name
+func (op Op) String() string {
+	switch op {
+	case Create:
+		return "create"
+	case Write:
+		return "write"
+	case Remove:
+		return "remove"
+	}
+	return "unknown"
+}
+
+// Event is a change to one file
+type Event struct {
+	Path string
+	Op   Op
+}
+
+// Options configure a watch
+type Options struct {
+	// Interval is how often the tree is polled
+	Interval time.Duration
+
+	// Debounce is how long the tree must be quiet before a batch of
+	// changes is reported
+	Debounce time.Duration
+
+	// Match selects the files watched; all files when nil
+	Match func(path string) bool
+}
+
+// DefaultOptions poll every 250ms and report changes after 300ms of quiet
+func DefaultOptions() Options {
+	return Options{
+		Interval: 250 * time.Millisecond,
+		Debounce: 300 * time.Millisecond,
+	}
+}
+
+// HasSuffix matches files ending in any of suffixes, e.g. ".tf"
+func HasSuffix(suffixes ...string) func(path string) bool {
+	return func(path string) bool {
+		for _, suffix := range suffixes {
+			if strings.HasSuffix(path, suffix) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// fileState is what a poll records of a file to detect writes
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watch polls the tree under root until ctx is done, calling onChange with
+// each debounced batch of changes, sorted by path. Hidden directories,
+// such as .terraform and .git, are skipped. It returns nil when ctx is
+// done, or the error of the first poll when root cannot be read.
+func Watch(ctx context.Context, root string, opts Options, onChange func([]Event)) error {
+	defaults := DefaultOptions()
+	if opts.Interval <= 0 {
+		opts.Interval = defaults.Interval
+	}
+	if opts.Debounce <= 0 {
+		opts.Debounce = defaults.Debounce
+	}
+
+	files, err := scan(root, opts.Match)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	pending := make(map[string]Op)
+	var lastChange time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			current, err := scan(root, opts.Match)
+			if err != nil {
+				// The tree is mid-rewrite; the next poll sees it settled
+				continue
+			}
+			if changes := diff(files, current); len(changes) > 0 {
+				for _, e := range changes {
+					pending[e.Path] = merge(pending[e.Path], e.Op)
+					if pending[e.Path] == 0 {
+						delete(pending, e.Path)
+					}
+				}
+				lastChange = now
+			}
+			files = current
+
+			if len(pending) > 0 && now.Sub(lastChange) >= opts.Debounce {
+				onChange(batch(pending))
+				pending = make(map[string]Op)
+			}
+		}
+	}
+}
+
+// scan records the state of every matching file under root
+func scan(root string, match func(string) bool) (map[string]fileState, error) {
+	files := make(map[string]fileState)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if match != nil && !match(path) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	return files, err
+}
+
+// diff lists the changes between two polls
+func diff(before, after map[string]fileState) []Event {
+	var events []Event
+	for path, state := range after {
+		old, ok := before[path]
+		switch {
+		case !ok:
+			events = append(events, Event{Path: path, Op: Create})
+		case !old.modTime.Equal(state.modTime) || old.size != state.size:
+			events = append(events, Event{Path: path, Op: Write})
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			events = append(events, Event{Path: path, Op: Remove})
+		}
+	}
+	return events
+}
+
+// merge folds a change into the pending change of the same file, so a
+// batch reports each file's net change; 0 means no net change
+func merge(pending, op Op) Op {
+	switch {
+	case pending == 0:
+		return op
+	case pending == Create && op == Remove:
+		return 0
+	case pending == Create:
+		return Create
+	case pending == Remove && op == Create:
+		return Write
+	}
+	return op
+}
+
+// batch lists pending changes sorted by path
+func batch(pending map[string]Op) []Event {
+	events := make([]Event, 0, len(pending))
+	for path, op := range pending {
+		events = append(events, Event{Path: path, Op: op})
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Path < events[j].Path })
+	return events
+}
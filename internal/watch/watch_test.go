@@ -0,0 +1,87 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatchBatchesChanges(t *testing.T) {
+	dir := t.TempDir()
+	mainTF := filepath.Join(dir, "main.tf")
+	if err := os.WriteFile(mainTF, []byte("# v1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, ".terraform"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	batches := make(chan []Event, 10)
+	done := make(chan error, 1)
+	opts := Options{Interval: 10 * time.Millisecond, Debounce: 60 * time.Millisecond, Match: HasSuffix(".tf")}
+	go func() { done <- Watch(ctx, dir, opts, func(events []Event) { batches <- events }) }()
+	time.Sleep(30 * time.Millisecond)
+
+	next := func() []Event {
+		t.Helper()
+		select {
+		case events := <-batches:
+			return events
+		case <-time.After(2 * time.Second):
+			t.Fatal("no changes reported")
+			return nil
+		}
+	}
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A burst of saves is one batch; files not matched and hidden
+	// directories are ignored, and a file created then removed in the
+	// burst is no change at all
+	varsTF := filepath.Join(dir, "variables.tf")
+	scratchTF := filepath.Join(dir, "scratch.tf")
+	write(mainTF, "# v2, longer\n")
+	write(varsTF, "# vars\n")
+	write(scratchTF, "# scratch\n")
+	write(filepath.Join(dir, "notes.txt"), "ignored\n")
+	write(filepath.Join(dir, ".terraform", "cached.tf"), "ignored\n")
+	time.Sleep(20 * time.Millisecond)
+	write(mainTF, "# v3, longer still\n")
+	if err := os.Remove(scratchTF); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Event{{Path: mainTF, Op: Write}, {Path: varsTF, Op: Create}}
+	if got := next(); !reflect.DeepEqual(got, want) {
+		t.Errorf("batch = %v, want %v", got, want)
+	}
+
+	if err := os.Remove(varsTF); err != nil {
+		t.Fatal(err)
+	}
+	want = []Event{{Path: varsTF, Op: Remove}}
+	if got := next(); !reflect.DeepEqual(got, want) {
+		t.Errorf("batch = %v, want %v", got, want)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Watch = %v, want nil once cancelled", err)
+	}
+}
+
+func TestWatchMissingRoot(t *testing.T) {
+	err := Watch(context.Background(), filepath.Join(t.TempDir(), "missing"), DefaultOptions(), func([]Event) {})
+	if err == nil {
+		t.Error("watching a missing directory succeeded")
+	}
+}
@@ -0,0 +1,25 @@
+#!/bin/bash
+# ============================================================
+# Benchmark Regression Guard
+# Runs the hot-path benchmarks and fails if any is slower or
+# allocates more than testdata/benchmarks/baseline.json allows.
+#
+#   bash scripts/bench-guard.sh           compare against the baseline
+#   bash scripts/bench-guard.sh -update   record a new baseline
+#
+# BENCH_COUNT sets the runs per benchmark (default 5); the best
+# run is compared. Other flags are passed to cmd/benchguard,
+# e.g. -time-threshold 0.5 on noisy CI runners.
+# ============================================================
+
+set -euo pipefail
+
+cd "$(dirname "$0")/.."
+
+COUNT="${BENCH_COUNT:-5}"
+BENCHMARKS='^(BenchmarkEstimateLargeGraph|BenchmarkBuildDenseGraph|BenchmarkNormalizeRateBatch)$'
+PACKAGES=(./core/engine ./core/graph ./db/ingestion)
+
+go test -run '^$' -bench "$BENCHMARKS" -benchmem -count "$COUNT" "${PACKAGES[@]}" \
+    | tee /dev/stderr \
+    | go run ./cmd/benchguard "$@"